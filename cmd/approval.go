@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+
+	"agent-quant-system/internal/api"
+	"agent-quant-system/internal/api/controlpb"
+	"agent-quant-system/internal/config"
+	"agent-quant-system/internal/trading"
+
+	"github.com/shopspring/decimal"
+	"github.com/spf13/cobra"
+)
+
+var (
+	approver      string
+	approvalAll   bool
+	approvalNotes string
+)
+
+// approvalCmd 大额订单人工确认命令
+var approvalCmd = &cobra.Command{
+	Use:   "approval",
+	Short: "大额订单人工确认",
+	Long: `查看、确认或拒绝等待人工确认的大额订单（需要运行中的引擎启用 trading.approval）。
+优先通过控制API交给运行中的引擎处理，引擎未启用控制API时读写 trading.approval.file，由引擎轮询该文件`,
+}
+
+// approvalListCmd 查看待确认订单
+var approvalListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "查看待确认订单",
+	RunE:  listApprovals,
+}
+
+// approveCmd 确认订单
+var approveCmd = &cobra.Command{
+	Use:   "approve <id>",
+	Short: "确认订单，确认后引擎立即提交",
+	Args:  cobra.ExactArgs(1),
+	RunE:  approveOrder,
+}
+
+// rejectCmd 拒绝订单
+var rejectCmd = &cobra.Command{
+	Use:   "reject <id>",
+	Short: "拒绝订单",
+	Args:  cobra.ExactArgs(1),
+	RunE:  rejectOrder,
+}
+
+func init() {
+	approvalCmd.PersistentFlags().StringVar(&approver, "by", os.Getenv("USER"), "确认人")
+	approvalListCmd.Flags().BoolVar(&approvalAll, "all", false, "包含已处理的订单")
+	rejectCmd.Flags().StringVar(&approvalNotes, "reason", "", "拒绝原因")
+
+	approvalCmd.AddCommand(approvalListCmd, approveCmd, rejectCmd)
+	rootCmd.AddCommand(approvalCmd)
+}
+
+// newApprovalManager 根据配置创建人工确认管理器
+func newApprovalManager() (*trading.ApprovalManager, error) {
+	cfg, err := config.LoadConfig(configFile)
+	if err != nil {
+		return nil, fmt.Errorf("加载配置失败: %w", err)
+	}
+	if !cfg.Trading.Approval.Enabled {
+		return nil, fmt.Errorf("未启用大额订单人工确认（trading.approval.enabled）")
+	}
+	return trading.NewApprovalManager(cfg.Trading.Approval), nil
+}
+
+// listApprovals 列出确认请求，优先通过控制API读取运行中引擎的请求，引擎不可用时直接读取确认请求文件
+func listApprovals(cmd *cobra.Command, args []string) error {
+	var requests []trading.ApprovalRequest
+	err := callControl(func(ctx context.Context, client *api.Client) error {
+		resp, err := client.ListApprovals(ctx, &controlpb.ListApprovalsRequest{All: approvalAll})
+		if err != nil {
+			return err
+		}
+		for _, approval := range resp.Approvals {
+			requests = append(requests, approvalFromProto(approval))
+		}
+		return nil
+	})
+	if errors.Is(err, api.ErrEngineUnavailable) {
+		requests, err = listApprovalsFromFile()
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(requests) == 0 {
+		fmt.Printf("没有待确认的订单\n")
+		return nil
+	}
+
+	fmt.Printf("\n=== 确认请求 ===\n")
+	for _, request := range requests {
+		fmt.Printf("%s [%s] 账户=%s 策略=%s %s %s %s @ %s, 名义金额=%s, 截止=%s\n",
+			request.ID, request.Status, request.AccountName, request.Strategy,
+			request.Side, request.Symbol, request.Quantity, request.Price,
+			request.Notional.StringFixed(2), request.ExpireTime.Local().Format("2006-01-02 15:04:05"))
+		if request.DecidedBy != "" {
+			fmt.Printf("  处理人: %s, 时间: %s %s\n", request.DecidedBy,
+				request.DecideTime.Local().Format("2006-01-02 15:04:05"), request.Reason)
+		}
+	}
+	return nil
+}
+
+// listApprovalsFromFile 直接读取确认请求文件
+func listApprovalsFromFile() ([]trading.ApprovalRequest, error) {
+	approvals, err := newApprovalManager()
+	if err != nil {
+		return nil, err
+	}
+	status := trading.ApprovalPending
+	if approvalAll {
+		status = ""
+	}
+	return approvals.List(status)
+}
+
+// approvalFromProto 转换控制API返回的确认请求
+func approvalFromProto(approval *controlpb.Approval) trading.ApprovalRequest {
+	return trading.ApprovalRequest{
+		ID:            approval.Id,
+		AccountName:   approval.AccountName,
+		Strategy:      approval.Strategy,
+		Symbol:        approval.Symbol,
+		Side:          trading.OrderSide(approval.Side),
+		Type:          trading.OrderType(approval.Type),
+		Quantity:      decimal.NewFromFloat(approval.Quantity),
+		Price:         decimal.NewFromFloat(approval.Price),
+		Notional:      decimal.NewFromFloat(approval.Notional),
+		Status:        trading.ApprovalStatus(approval.Status),
+		CreateTime:    approval.CreateTime.AsTime(),
+		ExpireTime:    approval.ExpireTime.AsTime(),
+		DecidedBy:     approval.DecidedBy,
+		DecideTime:    approval.DecideTime.AsTime(),
+		Reason:        approval.Reason,
+		ClientOrderID: approval.ClientOrderId,
+	}
+}
+
+// decideApproval 确认或拒绝订单：优先通过控制API交给运行中的引擎，确认后引擎立即提交；
+// 引擎不可用时写入确认请求文件，由轮询该文件的引擎处理
+func decideApproval(id string, approve bool) error {
+	request := &controlpb.DecideApprovalRequest{Id: id, By: approver, Reason: approvalNotes}
+	err := callControl(func(ctx context.Context, client *api.Client) (err error) {
+		if approve {
+			_, err = client.ApproveOrder(ctx, request)
+		} else {
+			_, err = client.RejectOrder(ctx, request)
+		}
+		return err
+	})
+	if !errors.Is(err, api.ErrEngineUnavailable) {
+		return err
+	}
+	log.Printf("%v；改为写入确认请求文件", err)
+
+	approvals, err := newApprovalManager()
+	if err != nil {
+		return err
+	}
+	if approve {
+		_, err = approvals.Approve(id, approver)
+	} else {
+		_, err = approvals.Reject(id, approver, approvalNotes)
+	}
+	return err
+}
+
+// approveOrder 确认订单
+func approveOrder(cmd *cobra.Command, args []string) error {
+	if err := decideApproval(args[0], true); err != nil {
+		return err
+	}
+	fmt.Printf("已确认订单 %s\n", args[0])
+	return nil
+}
+
+// rejectOrder 拒绝订单
+func rejectOrder(cmd *cobra.Command, args []string) error {
+	if err := decideApproval(args[0], false); err != nil {
+		return err
+	}
+	fmt.Printf("已拒绝订单 %s\n", args[0])
+	return nil
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"agent-quant-system/internal/audit"
+	"agent-quant-system/internal/config"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	auditFrom        string
+	auditTo          string
+	auditKinds       []string
+	auditCorrelation string
+	auditOrderID     string
+	auditAccount     string
+	auditStrategy    string
+	auditSymbol      string
+	auditLimit       int
+	auditVerify      bool
+	auditJSON        bool
+)
+
+// auditCmd 审计日志查询命令
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "查询订单审计日志",
+	Long: `查询交易引擎的审计日志（trading.audit_file）：信号、风控决定、下单、经纪商响应、成交和撤单；
+同一信号产生的事件使用同一个关联ID（客户端订单号），可用 --correlation 追踪一笔订单的完整过程；
+--verify 校验哈希链，发现被修改、删除或插入的记录时返回错误`,
+	RunE: showAudit,
+}
+
+func init() {
+	auditCmd.Flags().StringVar(&auditFrom, "from", "", "开始时间")
+	auditCmd.Flags().StringVar(&auditTo, "to", "", "结束时间")
+	auditCmd.Flags().StringSliceVar(&auditKinds, "kind", nil, "事件类型: signal / risk / submit / broker_response / fill / cancel（逗号分隔）")
+	auditCmd.Flags().StringVar(&auditCorrelation, "correlation", "", "关联ID（客户端订单号）")
+	auditCmd.Flags().StringVar(&auditOrderID, "order", "", "经纪商订单ID")
+	auditCmd.Flags().StringVarP(&auditAccount, "account", "a", "", "账户名称")
+	auditCmd.Flags().StringVar(&auditStrategy, "strategy", "", "策略名称")
+	auditCmd.Flags().StringVarP(&auditSymbol, "symbol", "s", "", "标的")
+	auditCmd.Flags().IntVarP(&auditLimit, "limit", "n", 50, "最多显示最近多少条事件，0 表示全部")
+	auditCmd.Flags().BoolVar(&auditVerify, "verify", false, "校验哈希链")
+	auditCmd.Flags().BoolVar(&auditJSON, "json", false, "按 JSON Lines 输出原始事件")
+	rootCmd.AddCommand(auditCmd)
+}
+
+// showAudit 查询或校验审计日志
+func showAudit(cmd *cobra.Command, args []string) error {
+	cfg, err := config.LoadConfig(configFile)
+	if err != nil {
+		return fmt.Errorf("加载配置失败: %w", err)
+	}
+	if cfg.Trading.AuditFile == "" {
+		return fmt.Errorf("未启用审计日志（trading.audit_file 为空）")
+	}
+
+	if auditVerify {
+		count, err := audit.Verify(cfg.Trading.AuditFile)
+		if err != nil {
+			return fmt.Errorf("审计日志校验失败（已通过 %d 条）: %w", count, err)
+		}
+		fmt.Printf("审计日志校验通过: %d 条记录\n", count)
+		return nil
+	}
+
+	filter := audit.Filter{
+		CorrelationID: auditCorrelation,
+		OrderID:       auditOrderID,
+		Account:       auditAccount,
+		Strategy:      auditStrategy,
+		Symbol:        auditSymbol,
+		Limit:         auditLimit,
+	}
+	if filter.From, err = parseHistoryTime(auditFrom); err != nil {
+		return err
+	}
+	if filter.To, err = parseHistoryTime(auditTo); err != nil {
+		return err
+	}
+	for _, kind := range auditKinds {
+		filter.Kinds = append(filter.Kinds, audit.Kind(strings.ToLower(strings.TrimSpace(kind))))
+	}
+
+	events, err := audit.Query(cfg.Trading.AuditFile, filter)
+	if err != nil {
+		return err
+	}
+	if len(events) == 0 {
+		fmt.Printf("没有符合条件的审计事件\n")
+		return nil
+	}
+
+	for _, event := range events {
+		if auditJSON {
+			line, err := json.Marshal(event)
+			if err != nil {
+				return fmt.Errorf("序列化审计事件失败: %w", err)
+			}
+			fmt.Println(string(line))
+			continue
+		}
+		printAuditEvent(event)
+	}
+	return nil
+}
+
+// printAuditEvent 打印一条审计事件
+func printAuditEvent(event audit.Event) {
+	fmt.Printf("#%d %s %-15s 关联=%s", event.Seq, event.Time.Format("2006-01-02 15:04:05.000"), event.Kind, event.CorrelationID)
+	if event.Account != "" {
+		fmt.Printf(" 账户=%s", event.Account)
+	}
+	if event.Strategy != "" {
+		fmt.Printf(" 策略=%s", event.Strategy)
+	}
+	if event.OrderID != "" {
+		fmt.Printf(" 订单=%s", event.OrderID)
+	}
+	fmt.Printf(" %s %s %s @ %s", event.Side, event.Symbol, event.Quantity, event.Price)
+	if event.Status != "" {
+		fmt.Printf(" [%s]", event.Status)
+	}
+	if event.Check != "" {
+		fmt.Printf(" 检查=%s 决定=%s", event.Check, event.Decision)
+	}
+	fmt.Println()
+	if event.Message != "" {
+		fmt.Printf("  %s\n", event.Message)
+	}
+	if len(event.Details) > 0 {
+		keys := make([]string, 0, len(event.Details))
+		for key := range event.Details {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		parts := make([]string, 0, len(keys))
+		for _, key := range keys {
+			parts = append(parts, fmt.Sprintf("%s=%v", key, event.Details[key]))
+		}
+		fmt.Printf("  %s\n", strings.Join(parts, " "))
+	}
+}
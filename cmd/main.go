@@ -1,15 +1,27 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"os"
 	"os/signal"
+	"sort"
+	"strings"
 	"syscall"
 	"time"
 
+	"agent-quant-system/internal/agent"
+	"agent-quant-system/internal/api"
+	"agent-quant-system/internal/api/controlpb"
 	"agent-quant-system/internal/config"
 	"agent-quant-system/internal/core"
+	"agent-quant-system/internal/data"
+	"agent-quant-system/internal/money"
+	"agent-quant-system/internal/secrets"
+	"agent-quant-system/internal/symbols"
+	"agent-quant-system/internal/trading"
 
 	"github.com/spf13/cobra"
 )
@@ -20,6 +32,59 @@ var (
 	startDate  string
 	endDate    string
 	interval   time.Duration
+	paper      bool
+	dryRun     bool
+
+	backtestResume   bool
+	backtestSymbols  []string
+	backtestWorkers  int
+	backtestStrategy string
+
+	accountName string
+	amount      float64
+	closePct    float64
+
+	calibrateDays    int
+	calibrateSamples int
+	calibrateOutput  string
+
+	apiAddress string
+
+	haltReason string
+
+	historyFrom   string
+	historyTo     string
+	historyAt     string
+	historySymbol string
+	historyLimit  int
+	// 输出决策说明而不是原始记录
+	historyExplain bool
+
+	leaderboardBy     string
+	leaderboardWindow time.Duration
+
+	// agent-report 参数
+	agentReportDays    int
+	agentReportHorizon time.Duration
+	agentReportSymbol  string
+
+	syncSymbols []string
+	syncDays    int
+
+	importSymbol     string
+	importFormat     string
+	importTimezone   string
+	importTimeFormat string
+	importColumns    []string
+	importBook       bool
+
+	tickDays int
+
+	optionsExpiry string
+
+	qualitySymbols []string
+	qualityDays    int
+	qualityAll     bool
 )
 
 // rootCmd 根命令
@@ -70,6 +135,221 @@ var healthCmd = &cobra.Command{
 	RunE:  checkHealth,
 }
 
+// accountCmd 模拟账户资金管理命令
+var accountCmd = &cobra.Command{
+	Use:   "account",
+	Short: "模拟账户资金管理",
+	Long: `调整模拟/纸面交易账户的资金：入金、出金、设置余额和重置；
+通过控制API（api.grpc_address）由正在运行的引擎执行，需先以 serve 或 run（api.enabled = true）启动引擎`,
+}
+
+// depositCmd 入金命令
+var depositCmd = &cobra.Command{
+	Use:   "deposit",
+	Short: "向模拟账户入金",
+	RunE:  depositFunds,
+}
+
+// withdrawCmd 出金命令
+var withdrawCmd = &cobra.Command{
+	Use:   "withdraw",
+	Short: "从模拟账户出金",
+	RunE:  withdrawFunds,
+}
+
+// setBalanceCmd 设置余额命令
+var setBalanceCmd = &cobra.Command{
+	Use:   "set-balance",
+	Short: "设置模拟账户余额",
+	RunE:  setAccountBalance,
+}
+
+// resetAccountCmd 重置账户命令
+var resetAccountCmd = &cobra.Command{
+	Use:   "reset",
+	Short: "将模拟账户重置为初始资金并清空持仓",
+	RunE:  resetAccount,
+}
+
+// closeCmd 平仓命令
+var closeCmd = &cobra.Command{
+	Use:   "close",
+	Short: "按比例平掉账户在标的上的持仓",
+	Long: `根据经纪商当前持仓提交反向市价单，多头卖出、空头买入，--pct 小于1时部分平仓；
+通过控制API由正在运行的引擎执行，需先以 serve 或 run（api.enabled = true）启动引擎`,
+	RunE: closePosition,
+}
+
+// haltCmd 紧急停止命令
+var haltCmd = &cobra.Command{
+	Use:   "halt",
+	Short: "紧急停止交易",
+	Long: `撤销所有账户的未成交订单，之后的信号不再执行（平仓和止损止盈除外），直到执行 resume；
+通过控制API由正在运行的引擎停止并撤单，连接不上控制API时由本进程撤单，停止状态写入 risk.kill_switch.state_file，
+引擎启动后保持停止`,
+	RunE: haltTrading,
+}
+
+// resumeCmd 恢复交易命令
+var resumeCmd = &cobra.Command{
+	Use:   "resume",
+	Short: "解除紧急停止，恢复交易",
+	RunE:  resumeTrading,
+}
+
+// strategyCmd 策略管理命令
+var strategyCmd = &cobra.Command{
+	Use:   "strategy",
+	Short: "策略管理",
+}
+
+// enableStrategyCmd 恢复被停用策略命令
+var enableStrategyCmd = &cobra.Command{
+	Use:   "enable <strategy>",
+	Short: "恢复被策略监控停用的策略",
+	Long: `恢复因回撤或连续亏损被 risk.strategy_supervisor 停用的策略，回撤和连续亏损从恢复时重新计算；
+停用状态写入 risk.strategy_supervisor.state_file，正在运行的引擎会在下一次下单或循环时生效`,
+	Args: cobra.ExactArgs(1),
+	RunE: enableStrategy,
+}
+
+// historyCmd 交易循环记录查询命令
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "查询交易循环记录",
+	Long: `按时间查询每轮交易循环的行情、Agent指导、策略信号、订单和错误（engine.history_file）；
+--at 显示该时刻正在执行或最近一次完成的循环，时间格式为 YYYY-MM-DD 或 "YYYY-MM-DD HH:MM"（本地时间）；
+--explain 输出每轮循环的决策说明：策略指标值、触发的条件，以及未生成信号或信号未执行的原因`,
+	RunE: showHistory,
+}
+
+// snapshotCmd 持仓快照导出命令
+var snapshotCmd = &cobra.Command{
+	Use:   "snapshot",
+	Short: "导出持仓和余额快照",
+	Long: `按 trading.snapshot 的格式、目录和推送地址导出一次全部账户的持仓和余额快照；
+通过控制API由正在运行的引擎导出，需先以 serve 或 run（api.enabled = true）启动引擎`,
+	RunE: exportSnapshot,
+}
+
+// bootstrapCmd 首次部署初始化命令
+var bootstrapCmd = &cobra.Command{
+	Use:   "bootstrap",
+	Short: "首次部署初始化并检查是否就绪",
+	Long: `按配置创建本地存储目录、预热观察列表的行情、以只读请求（余额、持仓）检查经纪商凭证并检查Agent服务，
+输出每一步的结果；有失败的步骤时以非零状态退出`,
+	RunE: bootstrapSystem,
+}
+
+// portfolioCmd 组合调仓计划命令
+var portfolioCmd = &cobra.Command{
+	Use:   "portfolio",
+	Short: "查看组合目标权重和调仓计划",
+	Long: `按 portfolio 配置对观察列表计算目标权重（均值方差或风险平价），
+并按调仓账户的权益和持仓列出需要的调仓交易，不会下单`,
+	RunE: showPortfolio,
+}
+
+// leaderboardCmd 策略排行榜命令
+var leaderboardCmd = &cobra.Command{
+	Use:   "leaderboard",
+	Short: "按滚动窗口的实盘表现查看策略排名",
+	Long: `按 trading.leaderboard 配置的各滚动窗口列出策略的盈亏、夏普比率和最大回撤，
+按排名窗口排序；近期窗口亏损而长期窗口盈利的策略标记为衰退`,
+	RunE: showLeaderboard,
+}
+
+// agentReportCmd Agent指导效果报告命令
+var agentReportCmd = &cobra.Command{
+	Use:   "agent-report",
+	Short: "评估Agent情绪调整是否改善了信号结果",
+	Long: `按 engine.guidance_file 中的指导记录，比较同一批决策在情绪调整后与中性情绪基线下的信号，
+持有 --horizon 后的命中率和盈亏，并按情绪与信号同向/反向分组；增量模式的策略没有基线，不参与对比`,
+	RunE: showAgentReport,
+}
+
+// dataCmd 行情数据命令
+var dataCmd = &cobra.Command{
+	Use:   "data",
+	Short: "行情数据管理",
+}
+
+// dataSyncCmd K线缓存预热命令
+var dataSyncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "预热K线本地缓存",
+	Long: `按各标的当前的数据源获取日期区间内缓存缺失的K线并写入 data.cache，
+之后相同区间的回测直接从缓存读取；已缓存的区间不会重复请求`,
+	RunE: syncData,
+}
+
+// dataImportCmd 导入OHLCV文件命令
+var dataImportCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "导入用户提供的OHLCV文件",
+	Long: `按 data.import 的列名映射和时区解析CSV或Parquet文件，K线保存到 data.import.dir，
+通过名为 imported 的数据源读取（如 [data.providers] 中设置 stock = "imported"）；
+重复导入时替换相同时间区间的K线`,
+	Args: cobra.ExactArgs(1),
+	RunE: importData,
+}
+
+// dataImportTicksCmd 导入逐笔数据文件命令
+var dataImportTicksCmd = &cobra.Command{
+	Use:   "import-ticks <file>",
+	Short: "导入逐笔成交或订单簿快照文件",
+	Long: `按 data.import 的时区和时间格式解析CSV文件，按日期保存到 data.ticks.dir 下名为 imported 的数据源；
+逐笔成交的列为 timestamp, price, size[, side]，订单簿快照（--book）的列为 timestamp, side(bid/ask), price, size，
+同一时间的多行组成一个快照；重复导入时替换相同日期的数据`,
+	Args: cobra.ExactArgs(1),
+	RunE: importTicks,
+}
+
+// dataTicksCmd 逐笔数据概览命令
+var dataTicksCmd = &cobra.Command{
+	Use:   "ticks <symbol>",
+	Short: "查看标的的逐笔成交和订单簿快照",
+	Long:  `按标的当前的数据源获取日期区间内的逐笔成交和订单簿快照，输出数量、时间范围、成交量和平均买卖价差`,
+	Args:  cobra.ExactArgs(1),
+	RunE:  showTicks,
+}
+
+// dataOptionsCmd 期权链查询命令
+var dataOptionsCmd = &cobra.Command{
+	Use:   "options <symbol>",
+	Short: "查看标的的期权链",
+	Long: `按标的当前的数据源获取期权链，列出各行权价看涨和看跌期权的买卖价、Delta 和持仓量；
+未指定 --expiry 时列出可交易的到期日并显示最近到期日的期权链`,
+	Args: cobra.ExactArgs(1),
+	RunE: showOptionChain,
+}
+
+// dataQualityCmd 数据质量报告命令
+var dataQualityCmd = &cobra.Command{
+	Use:   "quality",
+	Short: "检查行情数据质量",
+	Long: `按各标的当前的数据源获取日期区间内的K线，按 data.quality 的阈值检查缺口、重复或乱序的时间、
+零或负价格、异常跳变和过期数据，输出每个标的的质量报告；有标的存在问题时返回错误`,
+	RunE: checkDataQuality,
+}
+
+// serveCmd 控制API命令
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "启动控制API",
+	Long:  `启动控制API（接口定义见 proto/control.proto），通过 StartEngine/StopEngine 控制引擎运行`,
+	RunE:  serveAPI,
+}
+
+// calibrateSlippageCmd 滑点模型校准命令
+var calibrateSlippageCmd = &cobra.Command{
+	Use:   "calibrate-slippage",
+	Short: "用实盘成交流水校准回测滑点模型",
+	Long: `分析成交流水中成交价相对参考价格的不利偏离，按标的、订单规模和时段拟合滑点模型，
+写入 backtest.slippage_model_file 后回测按该模型计算滑点`,
+	RunE: calibrateSlippage,
+}
+
 func init() {
 	// 添加全局标志
 	rootCmd.PersistentFlags().StringVarP(&configFile, "config", "c", "config.toml", "配置文件路径")
@@ -77,17 +357,111 @@ func init() {
 	// 添加 run 命令标志
 	runCmd.Flags().StringVarP(&symbol, "symbol", "s", "AAPL", "交易标的")
 	runCmd.Flags().DurationVarP(&interval, "interval", "i", 5*time.Minute, "交易循环间隔")
+	runCmd.Flags().BoolVar(&paper, "paper", false, "纸面交易模式：订单按实时报价模拟成交")
+	runCmd.Flags().BoolVar(&dryRun, "dry-run", false, "演练模式：完整运行行情、Agent、策略和风控，订单只记录到日志和审计日志，不发送到经纪商")
 
 	// 添加 backtest 命令标志
 	backtestCmd.Flags().StringVarP(&symbol, "symbol", "s", "AAPL", "回测标的")
 	backtestCmd.Flags().StringVar(&startDate, "start", "", "开始日期 (YYYY-MM-DD)")
 	backtestCmd.Flags().StringVar(&endDate, "end", "", "结束日期 (YYYY-MM-DD)")
+	backtestCmd.Flags().StringSliceVar(&backtestSymbols, "symbols", nil, "多个回测标的（逗号分隔），并发回测并输出对比表和合并报告，指定时忽略 --symbol")
+	backtestCmd.Flags().IntVar(&backtestWorkers, "workers", 0, "多标的回测的并发数，默认使用 backtest.workers 配置")
+	backtestCmd.Flags().StringVar(&backtestStrategy, "strategy", "", "回测的策略，默认使用 backtest.strategy 配置（如 ma_cross、rsi、donchian）")
+	backtestCmd.Flags().BoolVar(&backtestResume, "resume", false, "从上次中断保存的检查点继续，需要与中断前相同的标的、区间和策略参数")
+
+	// 添加 account 命令标志
+	accountCmd.PersistentFlags().StringVarP(&accountName, "account", "a", "", "账户名称")
+	_ = accountCmd.MarkPersistentFlagRequired("account")
+	for _, cmd := range []*cobra.Command{depositCmd, withdrawCmd, setBalanceCmd} {
+		cmd.Flags().Float64Var(&amount, "amount", 0, "金额")
+		_ = cmd.MarkFlagRequired("amount")
+	}
+	accountCmd.AddCommand(depositCmd, withdrawCmd, setBalanceCmd, resetAccountCmd)
+
+	// 添加 close 命令标志
+	closeCmd.Flags().StringVarP(&accountName, "account", "a", "", "账户名称")
+	closeCmd.Flags().StringVarP(&symbol, "symbol", "s", "", "平仓标的")
+	closeCmd.Flags().Float64Var(&closePct, "pct", 1, "平仓比例 (0, 1]，1 表示全部平仓")
+	_ = closeCmd.MarkFlagRequired("account")
+	_ = closeCmd.MarkFlagRequired("symbol")
+
+	// 添加 halt 命令标志
+	haltCmd.Flags().StringVar(&haltReason, "reason", "手动停止", "停止原因")
+
+	// 添加 history 命令标志
+	historyCmd.Flags().StringVar(&historyFrom, "from", "", "开始时间")
+	historyCmd.Flags().StringVar(&historyTo, "to", "", "结束时间")
+	historyCmd.Flags().StringVar(&historyAt, "at", "", "显示该时刻的循环")
+	historyCmd.Flags().StringVarP(&historySymbol, "symbol", "s", "", "只显示该标的")
+	historyCmd.Flags().IntVarP(&historyLimit, "limit", "n", 20, "最多显示最近多少轮循环")
+	historyCmd.Flags().BoolVar(&historyExplain, "explain", false, "输出决策说明")
 
 	// 添加子命令
 	rootCmd.AddCommand(runCmd)
 	rootCmd.AddCommand(backtestCmd)
 	rootCmd.AddCommand(statusCmd)
 	rootCmd.AddCommand(healthCmd)
+	rootCmd.AddCommand(accountCmd)
+	rootCmd.AddCommand(closeCmd)
+	rootCmd.AddCommand(haltCmd)
+	rootCmd.AddCommand(resumeCmd)
+	strategyCmd.AddCommand(enableStrategyCmd)
+	rootCmd.AddCommand(strategyCmd)
+	rootCmd.AddCommand(historyCmd)
+	rootCmd.AddCommand(snapshotCmd)
+	rootCmd.AddCommand(bootstrapCmd)
+	rootCmd.AddCommand(portfolioCmd)
+
+	leaderboardCmd.Flags().StringVar(&leaderboardBy, "by", "", "排名指标: sharpe / pnl / drawdown，默认使用配置")
+	leaderboardCmd.Flags().DurationVarP(&leaderboardWindow, "window", "w", 0, "排名使用的窗口，需在 windows 配置中，默认使用配置")
+	rootCmd.AddCommand(leaderboardCmd)
+
+	agentReportCmd.Flags().IntVar(&agentReportDays, "days", 30, "评估最近多少天的指导")
+	agentReportCmd.Flags().DurationVar(&agentReportHorizon, "horizon", 24*time.Hour, "持有期")
+	agentReportCmd.Flags().StringVarP(&agentReportSymbol, "symbol", "s", "", "只评估该标的")
+	rootCmd.AddCommand(agentReportCmd)
+
+	dataSyncCmd.Flags().StringSliceVarP(&syncSymbols, "symbols", "s", nil, "预热的标的，默认使用 scanner.watchlist")
+	dataSyncCmd.Flags().StringVar(&startDate, "start", "", "开始日期 (YYYY-MM-DD)，默认为 --days 天前")
+	dataSyncCmd.Flags().StringVar(&endDate, "end", "", "结束日期 (YYYY-MM-DD)，默认为今天")
+	dataSyncCmd.Flags().IntVar(&syncDays, "days", 365, "未指定 --start 时预热最近多少天")
+	dataCmd.AddCommand(dataSyncCmd)
+	dataImportCmd.Flags().StringVarP(&importSymbol, "symbol", "s", "", "标的；文件有标的列时只导入该标的，未指定则导入全部")
+	dataImportCmd.Flags().StringVar(&importFormat, "format", "", "文件格式 (csv / parquet)，默认按扩展名识别")
+	dataImportCmd.Flags().StringVar(&importTimezone, "timezone", "", "覆盖 data.import.timezone")
+	dataImportCmd.Flags().StringVar(&importTimeFormat, "time-format", "", "覆盖 data.import.time_format")
+	dataImportCmd.Flags().StringSliceVar(&importColumns, "column", nil, "覆盖列名映射，如 --column timestamp=Date --column close=\"Adj Close\"")
+	dataCmd.AddCommand(dataImportCmd)
+	dataImportTicksCmd.Flags().StringVarP(&importSymbol, "symbol", "s", "", "标的")
+	dataImportTicksCmd.Flags().BoolVar(&importBook, "book", false, "文件为订单簿快照，默认为逐笔成交")
+	dataImportTicksCmd.Flags().StringVar(&importTimezone, "timezone", "", "覆盖 data.import.timezone")
+	dataImportTicksCmd.Flags().StringVar(&importTimeFormat, "time-format", "", "覆盖 data.import.time_format")
+	dataImportTicksCmd.MarkFlagRequired("symbol")
+	dataCmd.AddCommand(dataImportTicksCmd)
+	dataTicksCmd.Flags().StringVar(&startDate, "start", "", "开始日期 (YYYY-MM-DD)，默认为 --days 天前")
+	dataTicksCmd.Flags().StringVar(&endDate, "end", "", "结束日期 (YYYY-MM-DD)，默认为今天")
+	dataTicksCmd.Flags().IntVar(&tickDays, "days", 1, "未指定 --start 时查看最近多少天")
+	dataCmd.AddCommand(dataTicksCmd)
+	dataOptionsCmd.Flags().StringVar(&optionsExpiry, "expiry", "", "到期日 (YYYY-MM-DD)，默认为最近的到期日")
+	dataCmd.AddCommand(dataOptionsCmd)
+	dataQualityCmd.Flags().StringSliceVarP(&qualitySymbols, "symbols", "s", nil, "检查的标的，默认使用 scanner.watchlist")
+	dataQualityCmd.Flags().StringVar(&startDate, "start", "", "开始日期 (YYYY-MM-DD)，默认为 --days 天前")
+	dataQualityCmd.Flags().StringVar(&endDate, "end", "", "结束日期 (YYYY-MM-DD)，默认为今天")
+	dataQualityCmd.Flags().IntVar(&qualityDays, "days", 30, "未指定 --start 时检查最近多少天")
+	dataQualityCmd.Flags().BoolVar(&qualityAll, "all", false, "列出全部问题，默认每个标的最多列出10个")
+	dataCmd.AddCommand(dataQualityCmd)
+	rootCmd.AddCommand(dataCmd)
+
+	calibrateSlippageCmd.Flags().IntVar(&calibrateDays, "days", 90, "使用最近多少天的成交")
+	calibrateSlippageCmd.Flags().IntVar(&calibrateSamples, "min-samples", 5, "单独拟合标的或时段所需的最少样本数")
+	calibrateSlippageCmd.Flags().StringVarP(&calibrateOutput, "output", "o", "", "模型输出文件，默认使用 backtest.slippage_model_file")
+	rootCmd.AddCommand(calibrateSlippageCmd)
+
+	serveCmd.Flags().StringVar(&apiAddress, "address", "", "监听地址，默认使用 api.address")
+	serveCmd.Flags().DurationVarP(&interval, "interval", "i", 5*time.Minute, "StartEngine 未指定间隔时的交易循环间隔")
+	serveCmd.Flags().BoolVar(&paper, "paper", false, "纸面交易模式：订单按实时报价模拟成交")
+	serveCmd.Flags().BoolVar(&dryRun, "dry-run", false, "演练模式：完整运行行情、Agent、策略和风控，订单只记录到日志和审计日志，不发送到经纪商")
+	rootCmd.AddCommand(serveCmd)
 }
 
 func main() {
@@ -110,12 +484,19 @@ func runSystem(cmd *cobra.Command, args []string) error {
 	if err := cfg.Validate(); err != nil {
 		return fmt.Errorf("配置验证失败: %w", err)
 	}
+	if paper {
+		cfg.Trading.Paper = true
+	}
+	if dryRun {
+		cfg.Trading.DryRun = true
+	}
 
 	// 创建量化引擎
 	engine, err := core.NewQuantEngine(cfg)
 	if err != nil {
 		return fmt.Errorf("创建量化引擎失败: %w", err)
 	}
+	defer engine.FlushNotifications()
 
 	// 启动引擎
 	if err := engine.Start(); err != nil {
@@ -129,6 +510,24 @@ func runSystem(cmd *cobra.Command, args []string) error {
 
 	log.Printf("量化引擎已启动，交易标的: %s, 循环间隔: %v", symbol, interval)
 
+	// 监视配置文件修改
+	if cfg.Engine.Reload.Enabled {
+		stopWatch, err := engine.WatchConfig(configFile)
+		if err != nil {
+			return fmt.Errorf("启用配置热加载失败: %w", err)
+		}
+		defer stopWatch()
+	}
+
+	// 启动控制API
+	if cfg.API.Enabled {
+		server := api.NewServer(engine, cfg.API, interval)
+		if err := server.Start(); err != nil {
+			return fmt.Errorf("启动控制API失败: %w", err)
+		}
+		defer server.Stop()
+	}
+
 	// 设置信号处理
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
@@ -147,6 +546,61 @@ func runSystem(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// serveAPI 启动控制API，引擎由 StartEngine/StopEngine 控制
+func serveAPI(cmd *cobra.Command, args []string) error {
+	cfg, err := config.LoadConfig(configFile)
+	if err != nil {
+		return fmt.Errorf("加载配置失败: %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("配置验证失败: %w", err)
+	}
+	if paper {
+		cfg.Trading.Paper = true
+	}
+	if dryRun {
+		cfg.Trading.DryRun = true
+	}
+	cfg.API.Enabled = true
+	if apiAddress != "" {
+		cfg.API.Address = apiAddress
+	}
+
+	engine, err := core.NewQuantEngine(cfg)
+	if err != nil {
+		return fmt.Errorf("创建量化引擎失败: %w", err)
+	}
+	defer engine.FlushNotifications()
+
+	if cfg.Engine.Reload.Enabled {
+		stopWatch, err := engine.WatchConfig(configFile)
+		if err != nil {
+			return fmt.Errorf("启用配置热加载失败: %w", err)
+		}
+		defer stopWatch()
+	}
+
+	server := api.NewServer(engine, cfg.API, interval)
+	if err := server.Start(); err != nil {
+		return fmt.Errorf("启动控制API失败: %w", err)
+	}
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	<-sigChan
+	log.Printf("收到停止信号，正在关闭控制API...")
+
+	if err := server.Stop(); err != nil {
+		log.Printf("停止控制API失败: %v", err)
+	}
+	if engine.IsRunning() {
+		if err := engine.Stop(); err != nil {
+			log.Printf("停止量化引擎失败: %v", err)
+		}
+	}
+	return nil
+}
+
 // runBacktest 运行回测
 func runBacktest(cmd *cobra.Command, args []string) error {
 	log.Printf("开始运行回测")
@@ -164,6 +618,9 @@ func runBacktest(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("加载配置失败: %w", err)
 	}
+	if backtestStrategy != "" {
+		cfg.Backtest.Strategy = backtestStrategy
+	}
 
 	// 创建量化引擎
 	engine, err := core.NewQuantEngine(cfg)
@@ -171,10 +628,16 @@ func runBacktest(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("创建量化引擎失败: %w", err)
 	}
 
+	defer engine.FlushNotifications()
+
+	if len(backtestSymbols) > 0 {
+		return runBatchBacktest(engine)
+	}
+
 	log.Printf("回测参数: 标的=%s, 开始日期=%s, 结束日期=%s", symbol, startDate, endDate)
 
 	// 运行回测
-	if err := engine.RunBacktest(symbol, startDate, endDate); err != nil {
+	if err := engine.RunBacktest(symbol, startDate, endDate, backtestResume); err != nil {
 		return fmt.Errorf("回测执行失败: %w", err)
 	}
 
@@ -182,147 +645,999 @@ func runBacktest(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-// showStatus 显示状态
-func showStatus(cmd *cobra.Command, args []string) error {
-	log.Printf("查看系统状态")
-
-	// 加载配置
-	cfg, err := config.LoadConfig(configFile)
-	if err != nil {
-		return fmt.Errorf("加载配置失败: %w", err)
+// runBatchBacktest 并发回测多个标的并打印对比表
+func runBatchBacktest(engine *core.QuantEngine) error {
+	symbols := make([]string, 0, len(backtestSymbols))
+	for _, s := range backtestSymbols {
+		if s = strings.ToUpper(strings.TrimSpace(s)); s != "" {
+			symbols = append(symbols, s)
+		}
 	}
 
-	// 创建量化引擎
-	engine, err := core.NewQuantEngine(cfg)
+	report, path, err := engine.RunBatchBacktest(symbols, startDate, endDate, backtestWorkers, backtestResume)
 	if err != nil {
-		return fmt.Errorf("创建量化引擎失败: %w", err)
+		return fmt.Errorf("多标的回测失败: %w", err)
 	}
 
-	// 获取状态
-	status := engine.GetStatus()
-
-	// 打印状态信息
-	fmt.Printf("\n=== 系统状态 ===\n")
-	fmt.Printf("运行状态: %v\n", status.IsRunning)
-	fmt.Printf("启动时间: %s\n", status.StartTime.Format("2006-01-02 15:04:05"))
-	fmt.Printf("最后更新: %s\n", status.LastUpdateTime.Format("2006-01-02 15:04:05"))
-	fmt.Printf("总循环数: %d\n", status.TotalCycles)
-	fmt.Printf("成功循环: %d\n", status.SuccessfulCycles)
-	fmt.Printf("失败循环: %d\n", status.FailedCycles)
-	fmt.Printf("总信号数: %d\n", status.TotalSignals)
-	fmt.Printf("已执行交易: %d\n", status.ExecutedTrades)
-	fmt.Printf("总盈亏: %.2f\n", status.TotalPnL)
-
-	// 打印账户状态
-	fmt.Printf("\n=== 账户状态 ===\n")
-	for name, account := range status.Accounts {
-		fmt.Printf("账户: %s\n", name)
-		fmt.Printf("  类型: %s\n", account.BrokerType)
-		fmt.Printf("  状态: %v\n", account.IsActive)
-		fmt.Printf("  余额: %.2f\n", account.Balance)
-		fmt.Printf("  可用余额: %.2f\n", account.AvailableBalance)
-		fmt.Printf("  持仓数量: %d\n", account.PositionCount)
-		fmt.Printf("  最后更新: %s\n", account.LastUpdate.Format("2006-01-02 15:04:05"))
+	fmt.Printf("=== 多标的回测: %s, %s ~ %s, 并发 %d, 耗时 %v ===\n", report.StrategyName, report.StartDate, report.EndDate,
+		report.Workers, report.Duration.Round(time.Millisecond))
+	fmt.Printf("%-12s %10s %10s %10s %8s %8s %8s %10s %s\n", "标的", "总收益", "年化", "最大回撤", "夏普", "交易数", "胜率", "超额收益", "备注")
+	for _, item := range report.Results {
+		result := item.Result
+		if result == nil {
+			fmt.Printf("%-12s 失败: %s\n", item.Symbol, item.Error)
+			continue
+		}
+		excess, note := "-", ""
+		if result.Benchmark != nil {
+			excess = fmt.Sprintf("%.2f%%", result.Benchmark.ExcessReturn*100)
+		}
+		if result.Aborted {
+			note = "部分结果: " + result.AbortReason
+		}
+		fmt.Printf("%-12s %9.2f%% %9.2f%% %9.2f%% %8.2f %8d %7.2f%% %10s %s\n", item.Symbol,
+			result.TotalReturn*100, result.AnnualReturn*100, result.MaxDrawdown*100, result.SharpeRatio,
+			result.TotalTrades, result.WinRate*100, excess, note)
 	}
 
-	// 打印策略状态
-	fmt.Printf("\n=== 策略状态 ===\n")
-	for name, strategy := range status.Strategies {
-		fmt.Printf("策略: %s\n", name)
-		fmt.Printf("  名称: %s\n", strategy.Name)
-		fmt.Printf("  状态: %v\n", strategy.IsActive)
-		fmt.Printf("  描述: %s\n", strategy.Description)
+	summary := report.Summary
+	fmt.Printf("成功 %d 个, 失败 %d 个, 中止 %d 个\n", summary.Succeeded, summary.Failed, summary.Aborted)
+	if summary.Succeeded > 0 {
+		fmt.Printf("平均收益 %.2f%%, 中位数 %.2f%%, 平均夏普 %.2f, 平均回撤 %.2f%%, 最大回撤 %.2f%%, 总交易 %d\n",
+			summary.AvgReturn*100, summary.MedianReturn*100, summary.AvgSharpe, summary.AvgDrawdown*100,
+			summary.WorstDrawdown*100, summary.TotalTrades)
+		fmt.Printf("盈利 %d 个, 跑赢买入持有 %d 个, 最好 %s, 最差 %s\n", summary.Profitable, summary.BeatBenchmark, summary.Best, summary.Worst)
 	}
-
-	// 打印交易引擎状态
-	fmt.Printf("\n=== 交易引擎状态 ===\n")
-	fmt.Printf("运行状态: %v\n", status.TradingStatus.IsRunning)
-	fmt.Printf("经纪商数量: %d\n", len(status.TradingStatus.Brokers))
-	for name, broker := range status.TradingStatus.Brokers {
-		fmt.Printf("  经纪商: %s (%s)\n", name, broker.Status)
+	if path != "" {
+		fmt.Printf("合并报告: %s\n", path)
+	}
+	if summary.Succeeded == 0 {
+		return fmt.Errorf("所有标的回测均失败")
 	}
-
 	return nil
 }
 
-// checkHealth 健康检查
-func checkHealth(cmd *cobra.Command, args []string) error {
-	log.Printf("执行系统健康检查")
-
-	// 加载配置
+// calibrateSlippage 校准滑点模型
+func calibrateSlippage(cmd *cobra.Command, args []string) error {
 	cfg, err := config.LoadConfig(configFile)
 	if err != nil {
 		return fmt.Errorf("加载配置失败: %w", err)
 	}
 
-	// 创建量化引擎
+	output := calibrateOutput
+	if output == "" {
+		output = cfg.Backtest.SlippageModelFile
+	}
+	if output == "" {
+		return fmt.Errorf("未指定模型输出文件")
+	}
+
 	engine, err := core.NewQuantEngine(cfg)
 	if err != nil {
 		return fmt.Errorf("创建量化引擎失败: %w", err)
 	}
 
-	// 执行健康检查
-	health := engine.HealthCheck()
+	model, err := engine.CalibrateSlippage(time.Now().AddDate(0, 0, -calibrateDays), calibrateSamples)
+	if err != nil {
+		return fmt.Errorf("校准滑点模型失败: %w", err)
+	}
+	if err := model.Save(output); err != nil {
+		return err
+	}
 
-	// 打印健康状态
-	fmt.Printf("\n=== 系统健康检查 ===\n")
-	fmt.Printf("检查时间: %s\n", health.Timestamp.Format("2006-01-02 15:04:05"))
-	fmt.Printf("总体状态: %s\n", health.Overall)
+	fmt.Printf("=== 滑点模型 ===\n")
+	fmt.Printf("样本数: %d\n", model.Samples)
+	fmt.Printf("基础滑点率: %.4f%%\n", model.BaseRate*100)
+	fmt.Printf("规模系数: %.4f%% / sqrt(名义金额/10000)\n", model.SizeCoefficient*100)
+	symbols := make([]string, 0, len(model.SymbolRates))
+	for symbol := range model.SymbolRates {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+	for _, symbol := range symbols {
+		fmt.Printf("  %s: %.4f%%\n", symbol, model.SymbolRates[symbol]*100)
+	}
+	for hour := 0; hour < 24; hour++ {
+		if multiplier, exists := model.HourlyMultipliers[hour]; exists {
+			fmt.Printf("  %02d:00 时段系数: %.2f\n", hour, multiplier)
+		}
+	}
+	fmt.Printf("已写入: %s\n", output)
+	return nil
+}
 
-	fmt.Printf("\n=== 服务状态 ===\n")
-	for _, service := range health.Services {
-		statusIcon := "✓"
-		if service.Status != "healthy" {
-			statusIcon = "✗"
+// sortedTotals 按名称排序的盈亏合计
+func sortedTotals(totals map[string]*trading.PnLTotals) []string {
+	names := make([]string, 0, len(totals))
+	for name := range totals {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// printPnL 打印按账户、策略和持仓的盈亏（账户计价币种）
+func printPnL(report *trading.PnLReport) {
+	fmt.Printf("\n=== 盈亏 (已实现 / 未实现 / 合计) ===\n")
+	for _, name := range sortedTotals(report.ByAccount) {
+		totals := report.ByAccount[name]
+		fmt.Printf("账户 %s: %s / %s / %s\n", name, totals.RealizedPnL.StringFixed(2),
+			totals.UnrealizedPnL.StringFixed(2), totals.TotalPnL.StringFixed(2))
+	}
+	for _, name := range sortedTotals(report.ByStrategy) {
+		totals := report.ByStrategy[name]
+		if name == "" {
+			name = "(手动)"
 		}
-		fmt.Printf("%s %s: %s\n", statusIcon, service.Name, service.Status)
-		if service.Error != "" {
-			fmt.Printf("   错误: %s\n", service.Error)
+		fmt.Printf("策略 %s: %s / %s / %s\n", name, totals.RealizedPnL.StringFixed(2),
+			totals.UnrealizedPnL.StringFixed(2), totals.TotalPnL.StringFixed(2))
+	}
+	for _, position := range report.Positions {
+		fmt.Printf("  %s/%s/%s: 持仓 %s @ %s, 现价 %s, 已实现 %s, 未实现 %s", position.Account, position.Strategy,
+			position.Symbol, position.Quantity, position.AvgCost.StringFixed(4), position.MarketPrice.StringFixed(4),
+			position.RealizedPnL.StringFixed(2), position.UnrealizedPnL.StringFixed(2))
+		if !position.Funding.IsZero() {
+			fmt.Printf(", 资金费 %s", position.Funding.StringFixed(2))
 		}
+		if position.PriceError != "" {
+			fmt.Printf(" (价格不可用: %s)", position.PriceError)
+		}
+		fmt.Println()
 	}
+}
 
-	if health.Overall == "healthy" {
-		fmt.Printf("\n系统状态良好，所有服务正常运行\n")
-		return nil
-	} else {
-		fmt.Printf("\n系统存在健康问题，请检查上述错误\n")
-		return fmt.Errorf("系统健康检查失败")
+// printLeaderboard 打印策略排行榜，每个窗口显示 盈亏 / 夏普比率 / 最大回撤
+func printLeaderboard(board *trading.LeaderboardReport) {
+	fmt.Printf("\n=== 策略排行榜 (按 %s 窗口的 %s 排名) ===\n", formatWindow(board.RankWindow), board.RankBy)
+	for _, entry := range board.Entries {
+		name := entry.Strategy
+		if name == "" {
+			name = "(手动)"
+		}
+		flags := ""
+		if !entry.Active {
+			flags += " [未启用]"
+		}
+		if entry.Decaying {
+			flags += " [衰退]"
+		}
+		fmt.Printf("%d. %s: 累计 %.2f%s\n", entry.Rank, name, entry.TotalPnL, flags)
+		for _, window := range entry.Windows {
+			partial := ""
+			if !window.Complete {
+				partial = " (采样不足一个窗口)"
+			}
+			fmt.Printf("   %-4s %12.2f / %6.2f / %10.2f  样本 %d%s\n", formatWindow(window.Window),
+				window.PnL, window.SharpeRatio, window.MaxDrawdown, window.Samples, partial)
+		}
 	}
 }
 
-// runSingleLoop 运行单次循环（用于测试）
-func runSingleLoop(cmd *cobra.Command, args []string) error {
-	log.Printf("执行单次交易循环")
+// printAgentReport 打印Agent指导效果报告，每组显示 信号数 / 命中率 / 盈亏
+func printAgentReport(report *core.GuidanceReport) {
+	fmt.Printf("\n=== Agent指导效果 (最近 %d 天, 持有期 %s) ===\n", agentReportDays, formatWindow(report.Horizon))
+	sentiments := make([]string, 0, len(report.Sentiments))
+	for sentiment, count := range report.Sentiments {
+		sentiments = append(sentiments, fmt.Sprintf("%s=%d", sentiment, count))
+	}
+	sort.Strings(sentiments)
+	fmt.Printf("指导: %d 条 (%s), 持有期未结束: %d, 无基线: %d\n", report.Entries, strings.Join(sentiments, ", "), report.Pending, report.NoBaseline)
 
-	// 加载配置
+	labels := map[string]string{
+		"total":               "合计",
+		core.GuidanceAgree:    "情绪同向",
+		core.GuidanceDisagree: "情绪反向",
+		core.GuidanceNeutral:  "中性情绪",
+	}
+	printComparison := func(c core.GuidanceComparison) {
+		name := c.Name
+		if label, ok := labels[name]; ok {
+			name = label
+		}
+		fmt.Printf("  %-16s 决策 %4d  调整后 %4d / %5.1f%% / %12.2f  基线 %4d / %5.1f%% / %12.2f\n", name, c.Decisions,
+			c.Adjusted.Signals, c.Adjusted.HitRate*100, c.Adjusted.PnL, c.Baseline.Signals, c.Baseline.HitRate*100, c.Baseline.PnL)
+	}
+	printComparison(report.Total)
+	if len(report.Alignment) > 0 {
+		fmt.Println("按情绪与信号方向:")
+		for _, c := range report.Alignment {
+			printComparison(c)
+		}
+	}
+	if len(report.Strategies) > 0 {
+		fmt.Println("按策略:")
+		for _, c := range report.Strategies {
+			printComparison(c)
+		}
+	}
+	fmt.Printf("建议: %s\n", report.Recommendation)
+}
+
+// formatWindow 整天数的窗口显示为天数
+func formatWindow(window time.Duration) string {
+	if window > 0 && window%(24*time.Hour) == 0 {
+		return fmt.Sprintf("%dd", window/(24*time.Hour))
+	}
+	return window.String()
+}
+
+// syncData 预热K线缓存
+func syncData(cmd *cobra.Command, args []string) error {
 	cfg, err := config.LoadConfig(configFile)
 	if err != nil {
 		return fmt.Errorf("加载配置失败: %w", err)
 	}
-
-	// 创建量化引擎
-	engine, err := core.NewQuantEngine(cfg)
+	if !cfg.Data.Cache.Enabled {
+		return fmt.Errorf("未启用K线缓存，请先设置 data.cache.enabled = true")
+	}
+	dataManager, err := newDataManager(cfg)
 	if err != nil {
-		return fmt.Errorf("创建量化引擎失败: %w", err)
+		return fmt.Errorf("创建数据管理器失败: %w", err)
 	}
 
-	// 启动引擎
-	if err := engine.Start(); err != nil {
-		return fmt.Errorf("启动量化引擎失败: %w", err)
+	symbols := syncSymbols
+	if len(symbols) == 0 {
+		symbols = cfg.Scanner.Watchlist
 	}
-	defer func() {
-		if err := engine.Stop(); err != nil {
-			log.Printf("停止量化引擎失败: %v", err)
-		}
-	}()
-
-	// 执行单次循环
-	if err := engine.RunSingleLoop(); err != nil {
-		return fmt.Errorf("执行单次循环失败: %w", err)
+	if len(symbols) == 0 {
+		return fmt.Errorf("没有需要预热的标的，请使用 --symbols 指定")
 	}
 
-	log.Printf("单次交易循环执行完成")
-	return nil
+	end := time.Now()
+	if endDate != "" {
+		if end, err = time.Parse("2006-01-02", endDate); err != nil {
+			return fmt.Errorf("解析结束日期失败: %w", err)
+		}
+	}
+	end, _ = time.Parse("2006-01-02", end.Format("2006-01-02"))
+	start := end.AddDate(0, 0, -syncDays)
+	if startDate != "" {
+		if start, err = time.Parse("2006-01-02", startDate); err != nil {
+			return fmt.Errorf("解析开始日期失败: %w", err)
+		}
+	}
+	if !start.Before(end) {
+		return fmt.Errorf("开始日期必须早于结束日期")
+	}
+
+	results, err := dataManager.SyncCache(symbols, start, end)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("\n=== K线缓存预热 (%s ~ %s, 目录 %s) ===\n", start.Format("2006-01-02"), end.Format("2006-01-02"), cfg.Data.Cache.Dir)
+	failed := 0
+	for _, result := range results {
+		if result.Error != "" {
+			failed++
+			fmt.Printf("✗ %s (%s): %s\n", result.Symbol, result.Provider, result.Error)
+			continue
+		}
+		fmt.Printf("✓ %s (%s): 共 %d 根K线，本次获取 %d 根\n", result.Symbol, result.Provider, result.Bars, result.FetchedBars)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d 个标的预热失败", failed)
+	}
+	return nil
+}
+
+// checkDataQuality 输出各标的的数据质量报告
+func checkDataQuality(cmd *cobra.Command, args []string) error {
+	cfg, err := config.LoadConfig(configFile)
+	if err != nil {
+		return fmt.Errorf("加载配置失败: %w", err)
+	}
+	dataManager, err := newDataManager(cfg)
+	if err != nil {
+		return fmt.Errorf("创建数据管理器失败: %w", err)
+	}
+
+	symbols := qualitySymbols
+	if len(symbols) == 0 {
+		symbols = cfg.Scanner.Watchlist
+	}
+	if len(symbols) == 0 {
+		return fmt.Errorf("没有需要检查的标的，请使用 --symbols 指定")
+	}
+
+	end := time.Now()
+	if endDate != "" {
+		if end, err = time.Parse("2006-01-02", endDate); err != nil {
+			return fmt.Errorf("解析结束日期失败: %w", err)
+		}
+	}
+	start := end.AddDate(0, 0, -qualityDays)
+	if startDate != "" {
+		if start, err = time.Parse("2006-01-02", startDate); err != nil {
+			return fmt.Errorf("解析开始日期失败: %w", err)
+		}
+	}
+	if start.After(end) {
+		return fmt.Errorf("开始日期不能晚于结束日期")
+	}
+	from, to := start.Format("2006-01-02"), end.Format("2006-01-02")
+
+	fmt.Printf("\n=== 行情数据质量 (%s ~ %s，按各标的交易所当地日期) ===\n", from, to)
+	failed := 0
+	for _, symbol := range symbols {
+		report, err := dataManager.CheckSymbolQuality(symbol, from, to)
+		if err != nil {
+			failed++
+			fmt.Printf("✗ %s: %v\n", symbol, err)
+			continue
+		}
+		if report.Passed() {
+			fmt.Printf("✓ %s: %d 根K线，周期 %v，无质量问题\n", symbol, report.Bars, report.Interval)
+			continue
+		}
+		failed++
+		fmt.Printf("✗ %s: %d 根K线，周期 %v，%s\n", symbol, report.Bars, report.Interval, report.Summary())
+		for i, issue := range report.Issues {
+			if !qualityAll && i == 10 {
+				fmt.Printf("  ... 另有 %d 个问题，使用 --all 查看全部\n", len(report.Issues)-i)
+				break
+			}
+			fmt.Printf("  %s %-13s %s\n", issue.Time.Format("2006-01-02 15:04"), issue.Kind, issue.Message)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d 个标的存在数据质量问题", failed)
+	}
+	return nil
+}
+
+// importData 导入OHLCV文件
+func importData(cmd *cobra.Command, args []string) error {
+	cfg, err := config.LoadConfig(configFile)
+	if err != nil {
+		return fmt.Errorf("加载配置失败: %w", err)
+	}
+
+	settings := cfg.Data.Import
+	if importTimezone != "" {
+		settings.Timezone = importTimezone
+	}
+	if importTimeFormat != "" {
+		settings.TimeFormat = importTimeFormat
+	}
+	for _, mapping := range importColumns {
+		name, header, ok := strings.Cut(mapping, "=")
+		if !ok {
+			return fmt.Errorf("列名映射格式应为 名称=列名: %s", mapping)
+		}
+		columns := &settings.Columns
+		target := map[string]*string{
+			"timestamp": &columns.Timestamp, "time": &columns.Time, "open": &columns.Open, "high": &columns.High,
+			"low": &columns.Low, "close": &columns.Close, "volume": &columns.Volume, "symbol": &columns.Symbol,
+		}[strings.ToLower(strings.TrimSpace(name))]
+		if target == nil {
+			return fmt.Errorf("未知的列: %s，可选: timestamp, time, open, high, low, close, volume, symbol", name)
+		}
+		*target = strings.TrimSpace(header)
+	}
+	if err := settings.Validate(); err != nil {
+		return fmt.Errorf("导入配置无效: %w", err)
+	}
+
+	opts, err := data.ImportOptionsFromConfig(settings)
+	if err != nil {
+		return err
+	}
+	opts.Format = importFormat
+
+	cfg.Data.Import = settings
+	dataManager, err := newDataManager(cfg)
+	if err != nil {
+		return fmt.Errorf("创建数据管理器失败: %w", err)
+	}
+	results, err := dataManager.ImportFile(args[0], importSymbol, opts)
+	for _, result := range results {
+		fmt.Printf("✓ %s: 导入 %d 根K线 (%s ~ %s)，共 %d 根\n", result.Symbol, result.Bars,
+			result.Start.Format(time.RFC3339), result.End.Format(time.RFC3339), result.Total)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Printf("已保存到 %s，数据源名称: %s\n", settings.Dir, data.ImportedProviderName)
+	return nil
+}
+
+// importTicks 导入逐笔成交或订单簿快照文件
+func importTicks(cmd *cobra.Command, args []string) error {
+	cfg, err := config.LoadConfig(configFile)
+	if err != nil {
+		return fmt.Errorf("加载配置失败: %w", err)
+	}
+
+	settings := cfg.Data.Import
+	if importTimezone != "" {
+		settings.Timezone = importTimezone
+	}
+	if importTimeFormat != "" {
+		settings.TimeFormat = importTimeFormat
+	}
+	opts, err := data.ImportOptionsFromConfig(settings)
+	if err != nil {
+		return err
+	}
+
+	dataManager, err := newDataManager(cfg)
+	if err != nil {
+		return fmt.Errorf("创建数据管理器失败: %w", err)
+	}
+	result, err := dataManager.ImportTickFile(args[0], importSymbol, importBook, opts)
+	if err != nil {
+		return err
+	}
+	kind := "逐笔成交"
+	if importBook {
+		kind = "订单簿快照"
+	}
+	fmt.Printf("✓ %s: 导入 %d 条%s (%s ~ %s)，共 %d 天\n", result.Symbol, result.Records, kind,
+		result.Start.Format(time.RFC3339), result.End.Format(time.RFC3339), result.Days)
+	fmt.Printf("已保存到 %s，数据源名称: %s\n", cfg.Data.Ticks.Dir, data.ImportedProviderName)
+	return nil
+}
+
+// showTicks 显示标的的逐笔数据概览
+func showTicks(cmd *cobra.Command, args []string) error {
+	cfg, err := config.LoadConfig(configFile)
+	if err != nil {
+		return fmt.Errorf("加载配置失败: %w", err)
+	}
+	dataManager, err := newDataManager(cfg)
+	if err != nil {
+		return fmt.Errorf("创建数据管理器失败: %w", err)
+	}
+
+	symbol := strings.ToUpper(args[0])
+	start := startDate
+	if start == "" {
+		start = time.Now().AddDate(0, 0, -tickDays).Format("2006-01-02")
+	}
+	from, to, err := dataManager.DateRange(symbol, start, endDate)
+	if err != nil {
+		return err
+	}
+	ticks, err := dataManager.GetTicks(symbol, from, to)
+	if err != nil {
+		return err
+	}
+	books, err := dataManager.GetBookSnapshots(symbol, from, to)
+	if err != nil {
+		return err
+	}
+
+	volume := 0.0
+	for _, tick := range ticks {
+		volume += tick.Size
+	}
+	fmt.Printf("%s 逐笔成交: %d 笔 (%s ~ %s)，成交量 %.4f\n", symbol, len(ticks),
+		ticks[0].Timestamp.Format(time.RFC3339), ticks[len(ticks)-1].Timestamp.Format(time.RFC3339), volume)
+	if len(books) == 0 {
+		fmt.Printf("没有订单簿快照\n")
+		return nil
+	}
+	spread, quoted := 0.0, 0
+	for i := range books {
+		if mid := books[i].Mid(); mid > 0 {
+			spread += books[i].Spread() / mid * 10000
+			quoted++
+		}
+	}
+	if quoted > 0 {
+		spread /= float64(quoted)
+	}
+	fmt.Printf("订单簿快照: %d 个 (%s ~ %s)，平均买卖价差 %.2f 基点\n", len(books),
+		books[0].Timestamp.Format(time.RFC3339), books[len(books)-1].Timestamp.Format(time.RFC3339), spread)
+	return nil
+}
+
+// showOptionChain 显示标的的期权链
+func showOptionChain(cmd *cobra.Command, args []string) error {
+	cfg, err := config.LoadConfig(configFile)
+	if err != nil {
+		return fmt.Errorf("加载配置失败: %w", err)
+	}
+	dataManager, err := newDataManager(cfg)
+	if err != nil {
+		return fmt.Errorf("创建数据管理器失败: %w", err)
+	}
+
+	symbol := strings.ToUpper(args[0])
+	expiries, err := dataManager.GetOptionExpiries(symbol)
+	if err != nil {
+		return err
+	}
+	if len(expiries) == 0 {
+		return fmt.Errorf("%s 没有可交易的期权", symbol)
+	}
+	expiry := expiries[0]
+	if optionsExpiry != "" {
+		if expiry, err = time.Parse("2006-01-02", optionsExpiry); err != nil {
+			return fmt.Errorf("到期日格式错误: %w", err)
+		}
+	}
+	chain, err := dataManager.GetOptionChain(symbol, expiry)
+	if err != nil {
+		return err
+	}
+
+	dates := make([]string, len(expiries))
+	for i, date := range expiries {
+		dates[i] = date.Format("2006-01-02")
+	}
+	fmt.Printf("%s 期权链: 到期日=%s, 标的价格=%.2f\n", symbol, expiry.Format("2006-01-02"), chain.UnderlyingPrice)
+	fmt.Printf("可交易的到期日: %s\n\n", strings.Join(dates, ", "))
+	fmt.Printf("%-10s %-10s %-8s %-10s | %-10s | %-10s %-10s %-8s %-10s\n",
+		"买价", "卖价", "Delta", "持仓量", "行权价", "买价", "卖价", "Delta", "持仓量")
+	for i, call := range chain.Calls {
+		line := fmt.Sprintf("%-10.2f %-10.2f %-8.2f %-10d | %-10.2f |", call.Bid, call.Ask, call.Delta, call.OpenInterest, call.Strike)
+		if i < len(chain.Puts) && chain.Puts[i].Strike == call.Strike {
+			put := chain.Puts[i]
+			line += fmt.Sprintf(" %-10.2f %-10.2f %-8.2f %-10d", put.Bid, put.Ask, put.Delta, put.OpenInterest)
+		}
+		fmt.Println(line)
+	}
+	return nil
+}
+
+// showLeaderboard 显示策略排行榜
+func showLeaderboard(cmd *cobra.Command, args []string) error {
+	engine, err := newEngineForAccount()
+	if err != nil {
+		return err
+	}
+	defer engine.FlushNotifications()
+
+	board, err := engine.GetLeaderboard(leaderboardBy, leaderboardWindow)
+	if err != nil {
+		return err
+	}
+	if len(board.Entries) == 0 {
+		fmt.Println("暂无策略盈亏记录")
+		return nil
+	}
+	printLeaderboard(board)
+	return nil
+}
+
+// showAgentReport 显示Agent指导效果报告
+func showAgentReport(cmd *cobra.Command, args []string) error {
+	engine, err := newEngineForAccount()
+	if err != nil {
+		return err
+	}
+	defer engine.FlushNotifications()
+
+	report, err := engine.GuidanceReport(core.GuidanceQuery{
+		From:    time.Now().AddDate(0, 0, -agentReportDays),
+		Symbol:  strings.ToUpper(agentReportSymbol),
+		Horizon: agentReportHorizon,
+	})
+	if err != nil {
+		return err
+	}
+	if report.Entries == 0 {
+		fmt.Println("暂无Agent指导记录")
+		return nil
+	}
+	printAgentReport(report)
+	return nil
+}
+
+// showStatus 显示状态
+func showStatus(cmd *cobra.Command, args []string) error {
+	log.Printf("查看系统状态")
+
+	// 加载配置
+	cfg, err := config.LoadConfig(configFile)
+	if err != nil {
+		return fmt.Errorf("加载配置失败: %w", err)
+	}
+
+	// 创建量化引擎
+	engine, err := core.NewQuantEngine(cfg)
+	if err != nil {
+		return fmt.Errorf("创建量化引擎失败: %w", err)
+	}
+
+	// 获取状态
+	status := engine.GetStatus()
+
+	// 打印状态信息
+	fmt.Printf("\n=== 系统状态 ===\n")
+	fmt.Printf("运行状态: %v\n", status.IsRunning)
+	fmt.Printf("启动时间: %s\n", status.StartTime.Format("2006-01-02 15:04:05"))
+	fmt.Printf("最后更新: %s\n", status.LastUpdateTime.Format("2006-01-02 15:04:05"))
+	fmt.Printf("总循环数: %d\n", status.TotalCycles)
+	fmt.Printf("成功循环: %d\n", status.SuccessfulCycles)
+	fmt.Printf("失败循环: %d\n", status.FailedCycles)
+	fmt.Printf("总信号数: %d\n", status.TotalSignals)
+	fmt.Printf("已执行交易: %d\n", status.ExecutedTrades)
+	fmt.Printf("总盈亏: %.2f %s\n", status.TotalPnL, status.ReportingCurrency)
+	if status.PnL != nil {
+		fmt.Printf("  已实现: %.2f, 未实现: %.2f\n", status.PnL.RealizedPnL, status.PnL.UnrealizedPnL)
+	}
+	fmt.Printf("最近循环耗时: %v\n", status.LastCycleDuration)
+	fmt.Printf("最长循环耗时: %v\n", status.MaxCycleDuration)
+	fmt.Printf("超时循环: %d (跳过触发: %d)\n", status.OverrunCycles, status.SkippedTicks)
+	fmt.Printf("观察列表: %s\n", strings.Join(status.Watchlist, ", "))
+	if len(status.ScannerCandidates) > 0 {
+		fmt.Printf("观察期候选标的: %s\n", strings.Join(status.ScannerCandidates, ", "))
+	}
+	for _, mention := range status.DiscoveredSymbols {
+		fmt.Printf("  新闻发现: %s, 提及 %d 篇, 得分 %.0f\n", mention.Symbol, mention.Articles, mention.Score)
+	}
+
+	// 打印依赖状态
+	fmt.Printf("\n=== 依赖状态 ===\n")
+	for _, dependency := range []core.Dependency{core.DependencyData, core.DependencyAgent, core.DependencyNews, core.DependencyBroker} {
+		health := status.Dependencies[dependency]
+		if health.Healthy {
+			fmt.Printf("%s: 正常\n", dependency)
+			continue
+		}
+		fmt.Printf("%s: 异常 (降级=%s, 自 %s, 失败 %d 次): %s\n", dependency, health.Mode,
+			health.Since.Format("2006-01-02 15:04:05"), health.Failures, health.LastError)
+	}
+
+	// 打印账户状态
+	fmt.Printf("\n=== 账户状态 ===\n")
+	for name, account := range status.Accounts {
+		fmt.Printf("账户: %s\n", name)
+		fmt.Printf("  类型: %s\n", account.BrokerType)
+		fmt.Printf("  状态: %v\n", account.IsActive)
+		fmt.Printf("  余额: %.2f %s (折合 %.2f %s)\n", account.Balance, account.Currency,
+			account.ReportingBalance, status.ReportingCurrency)
+		fmt.Printf("  可用余额: %.2f\n", account.AvailableBalance)
+		fmt.Printf("  持仓数量: %d\n", account.PositionCount)
+		if account.Leverage > 1 {
+			fmt.Printf("  保证金: 杠杆 %.2f, 借入 %.2f, 购买力 %.2f, 使用率 %.2f%%, 强制平仓 %d 次\n",
+				account.Leverage, account.Borrowed, account.BuyingPower, account.MarginUtilization*100, account.MarginCalls)
+		}
+		fmt.Printf("  最后更新: %s\n", account.LastUpdate.Format("2006-01-02 15:04:05"))
+	}
+
+	fmt.Printf("账户总余额: %.2f %s\n", status.TotalBalance, status.ReportingCurrency)
+
+	// 打印策略状态
+	fmt.Printf("\n=== 策略状态 ===\n")
+	for name, strategy := range status.Strategies {
+		fmt.Printf("策略: %s\n", name)
+		fmt.Printf("  名称: %s\n", strategy.Name)
+		fmt.Printf("  状态: %v\n", strategy.IsActive)
+		if strategy.DisabledReason != "" {
+			fmt.Printf("  已停用: %s（执行 strategy enable %s 恢复）\n", strategy.DisabledReason, name)
+		}
+		fmt.Printf("  描述: %s\n", strategy.Description)
+		if p := strategy.Performance; p != nil {
+			fmt.Printf("  实盘表现: 成交 %d 笔, 平仓 %d 次, 胜率 %.1f%%, 盈亏 %.2f (已实现 %.2f / 未实现 %.2f), 回撤 %.2f, 最大回撤 %.2f\n",
+				p.Orders, p.Trades, p.HitRate*100, p.TotalPnL, p.RealizedPnL, p.UnrealizedPnL, p.Drawdown, p.MaxDrawdown)
+		}
+	}
+
+	// 打印交易引擎状态
+	fmt.Printf("\n=== 交易引擎状态 ===\n")
+	fmt.Printf("运行状态: %v\n", status.TradingStatus.IsRunning)
+	if status.TradingStatus.Paper {
+		fmt.Printf("交易模式: 纸面交易\n")
+	}
+	if status.TradingStatus.DryRun {
+		fmt.Printf("演练模式: 订单不发送到经纪商\n")
+	}
+	if halt := status.TradingStatus.Halt; halt.Halted {
+		fmt.Printf("紧急停止: 是 (%s, 触发=%s, 原因=%s)\n", halt.Since.Format("2006-01-02 15:04:05"), halt.Trigger, halt.Reason)
+	}
+	fmt.Printf("经纪商数量: %d\n", len(status.TradingStatus.Brokers))
+	for name, broker := range status.TradingStatus.Brokers {
+		fmt.Printf("  经纪商: %s (%s), 待处理订单: %d\n", name, broker.Status, broker.PendingOrders)
+		if broker.LastError != "" {
+			fmt.Printf("    最近错误: %s\n", broker.LastError)
+		}
+		if broker.NextRetry != nil {
+			fmt.Printf("    下次重连: %s\n", broker.NextRetry.Format("2006-01-02 15:04:05"))
+		}
+	}
+
+	// 打印盈亏明细
+	if status.PnL != nil && len(status.PnL.Report.Positions) > 0 {
+		printPnL(status.PnL.Report)
+	}
+
+	// 打印策略排行榜
+	if status.Leaderboard != nil {
+		printLeaderboard(status.Leaderboard)
+	}
+
+	// 打印交易成本
+	if costs := status.TradingStatus.Costs; costs != nil {
+		fmt.Printf("\n=== 交易成本 (当日 / 当月) ===\n")
+		printPeriodCosts("合计", &costs.Total)
+		for name, accountCosts := range costs.ByAccount {
+			printPeriodCosts("账户 "+name, accountCosts)
+		}
+		for name, strategyCosts := range costs.ByStrategy {
+			printPeriodCosts("策略 "+name, strategyCosts)
+		}
+	}
+
+	// 打印策略资金分配
+	if len(status.TradingStatus.Allocations) > 0 {
+		fmt.Printf("\n=== 策略资金分配 ===\n")
+		for _, allocation := range status.TradingStatus.Allocations {
+			limit := "不限"
+			if allocation.Fraction > 0 {
+				limit = fmt.Sprintf("%.0f%%", allocation.Fraction*100)
+			}
+			fmt.Printf("  策略: %s -> 账户: %s, 比例: %s, 已占用: %s, 预占: %s\n",
+				allocation.Strategy, allocation.Account, limit, allocation.Used.StringFixed(2), allocation.Reserved.StringFixed(2))
+		}
+	}
+
+	// 打印交易名单
+	lists := status.TradingStatus.SymbolLists
+	if len(lists.Global.Blacklist) > 0 || len(lists.Global.Whitelist) > 0 || len(lists.Accounts) > 0 {
+		fmt.Printf("\n=== 交易名单 ===\n")
+		printSymbolList("全局", lists.Global)
+		for name, list := range lists.Accounts {
+			printSymbolList("账户 "+name, list)
+		}
+	}
+
+	// 打印策略晋级状态
+	if promotion := status.TradingStatus.Promotion; len(promotion) > 0 {
+		fmt.Printf("\n=== 策略晋级 ===\n")
+		for _, p := range promotion {
+			mode := "纸面"
+			if p.Live {
+				mode = "实盘"
+			}
+			fmt.Printf("%s: %s, 纸面天数=%d, 成交=%d, 夏普=%.2f, 最大回撤=%.2f%%\n",
+				p.Strategy, mode, p.PaperDays, p.Trades, p.SharpeRatio, p.MaxDrawdown*100)
+			if !p.Live && p.Requested {
+				for _, reason := range p.Reasons {
+					fmt.Printf("  未满足: %s\n", reason)
+				}
+			}
+		}
+	}
+
+	// 打印网格挂单
+	if grids := status.TradingStatus.Grids; len(grids) > 0 {
+		fmt.Printf("\n=== 网格挂单 ===\n")
+		for _, grid := range grids {
+			fmt.Printf("%s %s (%s): 挂单 %d 个, 累计成交 %d, 改单 %d, 库存 %.8g", grid.Strategy, grid.Symbol, grid.Account,
+				len(grid.Orders), grid.Fills, grid.Replaced, grid.Inventory)
+			if grid.MaxInventory > 0 {
+				fmt.Printf(" / %.8g", grid.MaxInventory)
+			}
+			fmt.Println()
+			for _, order := range grid.Orders {
+				fmt.Printf("  档位 %d: %s %s @ %s\n", order.Level, order.Side, order.Quantity, order.Price)
+			}
+			if grid.Error != "" {
+				fmt.Printf("  最近同步错误: %s\n", grid.Error)
+			}
+		}
+	}
+
+	// 打印限流统计
+	if throttle := status.TradingStatus.Throttle; throttle != nil {
+		fmt.Printf("\n=== 下单频率限制 ===\n")
+		fmt.Printf("放行: %d, 限流: %d\n", throttle.Allowed, throttle.Throttled)
+	}
+
+	// 打印经纪商接口频率限制统计
+	if len(status.TradingStatus.RateLimits) > 0 {
+		fmt.Printf("\n=== 经纪商接口频率限制 ===\n")
+		names := make([]string, 0, len(status.TradingStatus.RateLimits))
+		for name := range status.TradingStatus.RateLimits {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			stats := status.TradingStatus.RateLimits[name]
+			fmt.Printf("%s: 下单 %d 次（排队 %d, 拒绝 %d）, 查询 %d 次（排队 %d, 拒绝 %d）, 累计等待 %v, 最长等待 %v\n", name,
+				stats.Requests[trading.RequestOrder], stats.Delayed[trading.RequestOrder], stats.Throttled[trading.RequestOrder],
+				stats.Requests[trading.RequestQuery], stats.Delayed[trading.RequestQuery], stats.Throttled[trading.RequestQuery],
+				stats.TotalWait.Round(time.Millisecond), stats.MaxWait.Round(time.Millisecond))
+		}
+	}
+
+	// 打印待确认订单
+	if len(status.TradingStatus.Approvals) > 0 {
+		fmt.Printf("\n=== 待确认订单 ===\n")
+		for _, request := range status.TradingStatus.Approvals {
+			fmt.Printf("%s: 账户=%s %s %s %s, 名义金额=%s, 截止=%s\n", request.ID, request.AccountName,
+				request.Side, request.Symbol, request.Quantity, request.Notional.StringFixed(2),
+				request.ExpireTime.Format("15:04:05"))
+		}
+	}
+
+	// 打印风控调整情况
+	if risk := status.TradingStatus.Risk; risk != nil {
+		fmt.Printf("\n=== 风控统计 ===\n")
+		fmt.Printf("检查订单: %d, 通过: %d, 缩减: %d, 拒绝: %d\n",
+			risk.TotalChecks, risk.Passed, risk.Resized, risk.Rejected)
+		for name, strategyStats := range risk.ByStrategy {
+			fmt.Printf("  策略: %s, 检查: %d, 缩减: %d, 拒绝: %d, 风控生效比例: %.1f%%\n",
+				name, strategyStats.Checks, strategyStats.Resized, strategyStats.Rejected, strategyStats.BindingRate()*100)
+		}
+		for _, adjustment := range risk.Recent {
+			fmt.Printf("  [%s] %s %s %s %s: %s -> %s (%s)\n",
+				adjustment.Time.Format("2006-01-02 15:04:05"), adjustment.Account, adjustment.Strategy,
+				adjustment.Symbol, adjustment.Action, adjustment.OriginalQuantity, adjustment.AdjustedQuantity, adjustment.Reason)
+		}
+	}
+
+	// 打印组合敞口
+	if status.Exposure != nil {
+		printExposure(status.Exposure)
+	}
+
+	return nil
+}
+
+// printExposure 打印全部账户合并后的组合敞口和限制
+func printExposure(report *trading.ExposureReport) {
+	limit := func(ratio float64) string {
+		if ratio <= 0 {
+			return "不限"
+		}
+		return fmt.Sprintf("%.0f%%", ratio*100)
+	}
+
+	fmt.Printf("\n=== 组合敞口 ===\n")
+	fmt.Printf("权益合计: %s, 总敞口: %s (%.2f%%, 上限 %s), 净敞口: %s (%.2f%%)\n",
+		report.Equity.StringFixed(2), report.Gross.StringFixed(2), report.GrossRatio*100, limit(report.Limits.MaxGrossExposure),
+		report.Net.StringFixed(2), report.NetRatio*100)
+	for _, bucket := range report.AssetClasses {
+		fmt.Printf("  资产类别 %s: 总敞口 %.2f%% (上限 %s), 净敞口 %.2f%% (上限 %s)\n",
+			bucket.Name, bucket.GrossRatio*100, limit(bucket.Limit), bucket.NetRatio*100, limit(report.Limits.MaxNetExposure))
+	}
+	for _, bucket := range report.Sectors {
+		fmt.Printf("  行业 %s: 总敞口 %.2f%% (上限 %s), 净敞口 %.2f%%\n",
+			bucket.Name, bucket.GrossRatio*100, limit(bucket.Limit), bucket.NetRatio*100)
+	}
+	for _, symbol := range report.Symbols {
+		sector := ""
+		if symbol.Sector != "" {
+			sector = ", 行业 " + symbol.Sector
+		}
+		fmt.Printf("  %s (%s%s): 净市值 %s, 权重 %.2f%% (上限 %s)\n", symbol.Symbol, symbol.AssetClass, sector,
+			symbol.Value.StringFixed(2), symbol.Weight*100, limit(report.Limits.MaxSymbolWeight))
+	}
+	for _, breach := range report.Breaches {
+		fmt.Printf("  超出限制: %s\n", breach)
+	}
+	for _, failure := range report.Errors {
+		fmt.Printf("  获取持仓失败: %s\n", failure)
+	}
+}
+
+// printPeriodCosts 打印当日和当月的成本明细
+func printPeriodCosts(label string, costs *trading.PeriodCosts) {
+	fmt.Printf("%s: 成交 %d / %d, 手续费 %s / %s, 滑点 %s / %s, 资金费用 %s / %s, 合计 %s / %s\n",
+		label,
+		costs.Day.Trades, costs.Month.Trades,
+		costs.Day.Commission.StringFixed(2), costs.Month.Commission.StringFixed(2),
+		costs.Day.Slippage.StringFixed(2), costs.Month.Slippage.StringFixed(2),
+		costs.Day.Funding.StringFixed(2), costs.Month.Funding.StringFixed(2),
+		costs.Day.Total.StringFixed(2), costs.Month.Total.StringFixed(2))
+}
+
+// printSymbolList 打印一组交易名单
+func printSymbolList(scope string, list trading.SymbolList) {
+	if len(list.Blacklist) > 0 {
+		fmt.Printf("  %s 黑名单: %s\n", scope, strings.Join(list.Blacklist, ", "))
+	}
+	if len(list.Whitelist) > 0 {
+		fmt.Printf("  %s 白名单: %s\n", scope, strings.Join(list.Whitelist, ", "))
+	}
+}
+
+// checkHealth 健康检查
+func checkHealth(cmd *cobra.Command, args []string) error {
+	log.Printf("执行系统健康检查")
+
+	// 加载配置
+	cfg, err := config.LoadConfig(configFile)
+	if err != nil {
+		return fmt.Errorf("加载配置失败: %w", err)
+	}
+
+	// 创建量化引擎
+	engine, err := core.NewQuantEngine(cfg)
+	if err != nil {
+		return fmt.Errorf("创建量化引擎失败: %w", err)
+	}
+
+	// 执行健康检查
+	health := engine.HealthCheck()
+
+	// 打印健康状态
+	fmt.Printf("\n=== 系统健康检查 ===\n")
+	fmt.Printf("检查时间: %s\n", health.Timestamp.Format("2006-01-02 15:04:05"))
+	fmt.Printf("总体状态: %s\n", health.Overall)
+
+	fmt.Printf("\n=== 服务状态 ===\n")
+	for _, service := range health.Services {
+		statusIcon := "✓"
+		if service.Status != "healthy" {
+			statusIcon = "✗"
+		}
+		fmt.Printf("%s %s: %s\n", statusIcon, service.Name, service.Status)
+		if service.Error != "" {
+			fmt.Printf("   错误: %s\n", service.Error)
+		}
+	}
+
+	if breaker := health.AgentBreaker; breaker != nil && breaker.State != agent.BreakerClosed {
+		fmt.Printf("\n=== Agent熔断器 ===\n")
+		fmt.Printf("状态: %s, 连续失败: %d, 已拒绝请求: %d\n", breaker.State, breaker.ConsecutiveFails, breaker.Rejected)
+		if !breaker.RetryAt.IsZero() {
+			fmt.Printf("下次试探: %s\n", breaker.RetryAt.Format("2006-01-02 15:04:05"))
+		}
+		if breaker.LastError != "" {
+			fmt.Printf("最近错误: %s\n", breaker.LastError)
+		}
+	}
+
+	if report := health.Reconciliation; report != nil && report.Summary() != "" {
+		fmt.Printf("\n=== 对账差异 (%s) ===\n", report.Time.Format("2006-01-02 15:04:05"))
+		fmt.Println(report.Summary())
+	}
+
+	if health.Overall == "healthy" {
+		fmt.Printf("\n系统状态良好，所有服务正常运行\n")
+		return nil
+	} else {
+		fmt.Printf("\n系统存在健康问题，请检查上述错误\n")
+		return fmt.Errorf("系统健康检查失败")
+	}
+}
+
+// runSingleLoop 运行单次循环（用于测试）
+func runSingleLoop(cmd *cobra.Command, args []string) error {
+	log.Printf("执行单次交易循环")
+
+	// 加载配置
+	cfg, err := config.LoadConfig(configFile)
+	if err != nil {
+		return fmt.Errorf("加载配置失败: %w", err)
+	}
+	if paper {
+		cfg.Trading.Paper = true
+	}
+	if dryRun {
+		cfg.Trading.DryRun = true
+	}
+
+	// 创建量化引擎
+	engine, err := core.NewQuantEngine(cfg)
+	if err != nil {
+		return fmt.Errorf("创建量化引擎失败: %w", err)
+	}
+	defer engine.FlushNotifications()
+
+	// 启动引擎
+	if err := engine.Start(); err != nil {
+		return fmt.Errorf("启动量化引擎失败: %w", err)
+	}
+	defer func() {
+		if err := engine.Stop(); err != nil {
+			log.Printf("停止量化引擎失败: %v", err)
+		}
+	}()
+
+	// 执行单次循环
+	if err := engine.RunSingleLoop(); err != nil {
+		return fmt.Errorf("执行单次循环失败: %w", err)
+	}
+
+	log.Printf("单次交易循环执行完成")
+	return nil
 }
 
 // init 初始化函数
@@ -338,5 +1653,441 @@ func init() {
 		RunE:  runSingleLoop,
 	}
 	singleLoopCmd.Flags().StringVarP(&symbol, "symbol", "s", "AAPL", "交易标的")
+	singleLoopCmd.Flags().BoolVar(&paper, "paper", false, "纸面交易模式：订单按实时报价模拟成交")
+	singleLoopCmd.Flags().BoolVar(&dryRun, "dry-run", false, "演练模式：完整运行行情、Agent、策略和风控，订单只记录到日志和审计日志，不发送到经纪商")
 	rootCmd.AddCommand(singleLoopCmd)
 }
+
+// newEngineForAccount 加载配置并创建量化引擎，用于账户管理命令
+func newEngineForAccount() (*core.QuantEngine, error) {
+	cfg, err := config.LoadConfig(configFile)
+	if err != nil {
+		return nil, fmt.Errorf("加载配置失败: %w", err)
+	}
+
+	engine, err := core.NewQuantEngine(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("创建量化引擎失败: %w", err)
+	}
+	return engine, nil
+}
+
+// controlCallTimeout 命令行调用控制API的超时时间，平仓等操作需要等待订单执行
+const controlCallTimeout = 2 * time.Minute
+
+// newControlClient 加载配置并连接正在运行的引擎的控制API。模拟账户的余额和持仓只存在于引擎进程内，
+// 资金调整等命令必须由运行中的引擎执行，另建引擎实例的修改不会生效
+func newControlClient() (*api.Client, error) {
+	cfg, err := config.LoadConfig(configFile)
+	if err != nil {
+		return nil, fmt.Errorf("加载配置失败: %w", err)
+	}
+	if cfg.API.Auth.Token != "" {
+		secret, err := secrets.NewResolver(cfg.Secrets).Resolve(cfg.API.Auth.Token)
+		if err != nil {
+			return nil, fmt.Errorf("解析 api.auth.token 失败: %w", err)
+		}
+		cfg.API.Auth.Token = secret.Reveal()
+	}
+	return api.NewClient(cfg.API, controlCallTimeout)
+}
+
+// callControl 连接控制API并执行一次调用
+func callControl(call func(ctx context.Context, client *api.Client) error) error {
+	client, err := newControlClient()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	ctx, cancel := client.Context()
+	defer cancel()
+	return api.ClientError(call(ctx, client))
+}
+
+// fundsRequest 按命令行参数创建资金调整请求
+func fundsRequest() *controlpb.AccountFundsRequest {
+	return &controlpb.AccountFundsRequest{Account: accountName, Amount: money.FromFloat(amount).String()}
+}
+
+// depositFunds 入金
+func depositFunds(cmd *cobra.Command, args []string) error {
+	var resp *controlpb.AccountFundsResponse
+	err := callControl(func(ctx context.Context, client *api.Client) (err error) {
+		resp, err = client.Deposit(ctx, fundsRequest())
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("账户 %s 入金 %.2f 成功，当前余额: %s\n", accountName, amount, resp.Balance)
+	return nil
+}
+
+// withdrawFunds 出金
+func withdrawFunds(cmd *cobra.Command, args []string) error {
+	var resp *controlpb.AccountFundsResponse
+	err := callControl(func(ctx context.Context, client *api.Client) (err error) {
+		resp, err = client.Withdraw(ctx, fundsRequest())
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("账户 %s 出金 %.2f 成功，当前余额: %s\n", accountName, amount, resp.Balance)
+	return nil
+}
+
+// setAccountBalance 设置余额
+func setAccountBalance(cmd *cobra.Command, args []string) error {
+	err := callControl(func(ctx context.Context, client *api.Client) error {
+		_, err := client.SetBalance(ctx, fundsRequest())
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("账户 %s 余额已设置为: %.2f\n", accountName, amount)
+	return nil
+}
+
+// resetAccount 重置账户
+func resetAccount(cmd *cobra.Command, args []string) error {
+	var resp *controlpb.AccountFundsResponse
+	err := callControl(func(ctx context.Context, client *api.Client) (err error) {
+		resp, err = client.ResetAccount(ctx, &controlpb.ResetAccountRequest{Account: accountName})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("账户 %s 已重置，当前余额: %s\n", accountName, resp.Balance)
+	return nil
+}
+
+// showHistory 查询交易循环记录
+func showHistory(cmd *cobra.Command, args []string) error {
+	cfg, err := config.LoadConfig(configFile)
+	if err != nil {
+		return fmt.Errorf("加载配置失败: %w", err)
+	}
+	if cfg.Engine.HistoryFile == "" {
+		return fmt.Errorf("未启用循环记录（engine.history_file 为空）")
+	}
+
+	query := core.CycleQuery{Symbol: strings.ToUpper(historySymbol), Limit: historyLimit}
+	if query.From, err = parseHistoryTime(historyFrom); err != nil {
+		return err
+	}
+	if query.To, err = parseHistoryTime(historyTo); err != nil {
+		return err
+	}
+	if historyAt != "" {
+		if query.To, err = parseHistoryTime(historyAt); err != nil {
+			return err
+		}
+		query.Limit = 1
+	}
+
+	history, err := core.NewCycleHistory(cfg.Engine.HistoryFile)
+	if err != nil {
+		return err
+	}
+	records, err := history.Query(query)
+	if err != nil {
+		return err
+	}
+	if len(records) == 0 {
+		fmt.Printf("没有符合条件的循环记录\n")
+		return nil
+	}
+
+	for _, record := range records {
+		if historyExplain {
+			printCycleExplanation(core.ExplainCycle(record))
+			continue
+		}
+		printCycleRecord(record)
+	}
+	return nil
+}
+
+// parseHistoryTime 解析本地时间，为空时返回零值
+func parseHistoryTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	for _, layout := range []string{"2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("无法解析时间 '%s'，格式应为 YYYY-MM-DD 或 \"YYYY-MM-DD HH:MM\"", value)
+}
+
+// printCycleRecord 打印一轮循环记录
+func printCycleRecord(record core.CycleRecord) {
+	fmt.Printf("\n=== 循环 #%d  %s  %s  耗时 %v ===\n", record.ID,
+		record.Start.Local().Format("2006-01-02 15:04:05"), record.Status, record.Duration.Round(time.Millisecond))
+	if !record.Replay.IsZero() {
+		fmt.Printf("回放行情时间: %s\n", record.Replay.Local().Format("2006-01-02 15:04"))
+	}
+	for _, e := range record.Errors {
+		fmt.Printf("错误: %s\n", e)
+	}
+	for _, order := range record.Deferred {
+		fmt.Printf("排队信号: %s\n", formatOrderRecord(order))
+	}
+
+	for _, s := range record.Symbols {
+		fmt.Printf("%s (耗时 %v): 策略=%s, 新闻=%d, 行情=%d, 收盘=%.2f\n", s.Symbol, s.Duration.Round(time.Millisecond),
+			strings.Join(s.Strategies, ","), s.News, s.Bars, s.LastClose)
+		if s.Guidance != nil {
+			fmt.Printf("  指导: %s (置信度 %.2f) %s\n", s.Guidance.Sentiment, s.Guidance.Confidence, s.Guidance.Reason)
+		}
+		for _, signal := range s.Signals {
+			fmt.Printf("  信号: %s %s %.4f @ %.2f (置信度 %.2f) %s\n", signal.Strategy, signal.Signal,
+				signal.Quantity, signal.Price, signal.Confidence, signal.Reason)
+		}
+		for _, order := range s.Orders {
+			fmt.Printf("  订单: %s\n", formatOrderRecord(order))
+		}
+		if s.Error != "" {
+			fmt.Printf("  错误: %s\n", s.Error)
+		}
+	}
+}
+
+// printCycleExplanation 打印一轮循环的决策说明
+func printCycleExplanation(explanation core.CycleExplanation) {
+	fmt.Printf("\n=== 循环 #%d  %s ===\n%s\n", explanation.CycleID,
+		explanation.Start.Local().Format("2006-01-02 15:04:05"), explanation.Summary)
+	for _, line := range explanation.Details {
+		fmt.Printf("  %s\n", line)
+	}
+	for _, s := range explanation.Symbols {
+		fmt.Printf("%s: %s\n", s.Symbol, s.Summary)
+		for _, line := range s.Details {
+			fmt.Printf("  %s\n", line)
+		}
+	}
+}
+
+// formatOrderRecord 格式化订单执行记录
+func formatOrderRecord(order core.OrderRecord) string {
+	if order.Error != "" {
+		return fmt.Sprintf("%s %s 失败: %s", order.Strategy, order.Symbol, order.Error)
+	}
+	text := fmt.Sprintf("%s %s %s %s @ %s, 账户=%s, 订单ID=%s, 状态=%s", order.Strategy, order.Side, order.Symbol,
+		order.Quantity, order.Price, order.Account, order.OrderID, order.Status)
+	if order.Paper {
+		text += " (纸面)"
+	}
+	if order.DryRun {
+		text += " (演练)"
+	}
+	return text
+}
+
+// haltTrading 紧急停止交易：由正在运行的引擎执行，撤销它跟踪的未成交订单；
+// 没有运行中的引擎时由本进程撤销经纪商的未成交订单并写入停止状态文件，引擎启动后保持停止
+func haltTrading(cmd *cobra.Command, args []string) error {
+	var resp *controlpb.HaltState
+	err := callControl(func(ctx context.Context, client *api.Client) (err error) {
+		resp, err = client.HaltTrading(ctx, &controlpb.HaltTradingRequest{Reason: haltReason})
+		return err
+	})
+	if err == nil {
+		fmt.Printf("运行中的引擎已紧急停止: %s (触发=%s, 原因=%s)\n",
+			resp.Since.AsTime().Local().Format("2006-01-02 15:04:05"), resp.Trigger, resp.Reason)
+		fmt.Printf("执行 resume 恢复交易\n")
+		return nil
+	}
+	if !errors.Is(err, api.ErrEngineUnavailable) {
+		return err
+	}
+	log.Printf("%v；改为在本进程撤单并写入停止状态文件", err)
+
+	engine, err := newEngineForAccount()
+	if err != nil {
+		return err
+	}
+	defer engine.FlushNotifications()
+
+	state := engine.Halt(haltReason)
+	fmt.Printf("交易已紧急停止: %s (触发=%s, 原因=%s)\n", state.Since.Format("2006-01-02 15:04:05"), state.Trigger, state.Reason)
+	fmt.Printf("执行 resume 恢复交易\n")
+	return nil
+}
+
+// resumeTrading 恢复交易，与 halt 相同优先由正在运行的引擎执行
+func resumeTrading(cmd *cobra.Command, args []string) error {
+	err := callControl(func(ctx context.Context, client *api.Client) error {
+		_, err := client.ResumeTrading(ctx, &controlpb.ResumeTradingRequest{})
+		return err
+	})
+	if err == nil {
+		fmt.Printf("运行中的引擎已恢复交易\n")
+		return nil
+	}
+	if !errors.Is(err, api.ErrEngineUnavailable) {
+		return err
+	}
+	log.Printf("%v；改为更新停止状态文件", err)
+
+	engine, err := newEngineForAccount()
+	if err != nil {
+		return err
+	}
+	defer engine.FlushNotifications()
+
+	engine.Resume()
+	fmt.Printf("交易已恢复\n")
+	return nil
+}
+
+// enableStrategy 恢复被停用的策略
+func enableStrategy(cmd *cobra.Command, args []string) error {
+	engine, err := newEngineForAccount()
+	if err != nil {
+		return err
+	}
+	defer engine.FlushNotifications()
+
+	if err := engine.EnableStrategy(args[0]); err != nil {
+		return err
+	}
+	fmt.Printf("策略 %s 已恢复\n", args[0])
+	return nil
+}
+
+// exportSnapshot 导出持仓快照：由正在运行的引擎导出它的持仓和余额（模拟账户的状态只存在于引擎进程内）
+func exportSnapshot(cmd *cobra.Command, args []string) error {
+	var snapshot *controlpb.PositionSnapshot
+	err := callControl(func(ctx context.Context, client *api.Client) (err error) {
+		snapshot, err = client.ExportSnapshot(ctx, &controlpb.ExportSnapshotRequest{})
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("导出持仓快照失败: %w", err)
+	}
+	positions := 0
+	for _, account := range snapshot.Accounts {
+		positions += len(account.Positions)
+	}
+	fmt.Printf("已导出持仓快照: %s, 账户 %d 个, 持仓 %d 个\n",
+		snapshot.Time.AsTime().Local().Format("2006-01-02 15:04:05"), len(snapshot.Accounts), positions)
+	for name, reason := range snapshot.Errors {
+		fmt.Printf("  账户 %s 无法导出: %s\n", name, reason)
+	}
+	return nil
+}
+
+// bootstrapSystem 首次部署初始化
+func bootstrapSystem(cmd *cobra.Command, args []string) error {
+	engine, err := newEngineForAccount()
+	if err != nil {
+		return err
+	}
+	defer engine.FlushNotifications()
+
+	report := engine.Bootstrap()
+
+	fmt.Printf("\n=== 初始化 (%s) ===\n", configFile)
+	for _, step := range report.Steps {
+		icon := "✓"
+		switch step.Status {
+		case core.BootstrapWarning:
+			icon = "!"
+		case core.BootstrapFailed:
+			icon = "✗"
+		case core.BootstrapSkipped:
+			icon = "-"
+		}
+		fmt.Printf("%s %s: %s (%v)\n", icon, step.Name, step.Status, step.Duration.Round(time.Millisecond))
+		if step.Detail != "" {
+			fmt.Printf("   %s\n", step.Detail)
+		}
+	}
+
+	if !report.Ready {
+		return fmt.Errorf("系统未就绪，请处理失败的步骤后重新运行 bootstrap")
+	}
+	fmt.Printf("\n系统已就绪，可以运行 run 或 serve\n")
+	return nil
+}
+
+// showPortfolio 显示组合目标权重和调仓计划
+func showPortfolio(cmd *cobra.Command, args []string) error {
+	engine, err := newEngineForAccount()
+	if err != nil {
+		return err
+	}
+	defer engine.FlushNotifications()
+
+	plan, err := engine.PlanRebalance()
+	if err != nil {
+		return err
+	}
+
+	allocation := plan.Allocation
+	fmt.Printf("\n=== 组合目标权重 (%s, %d 个收益率样本) ===\n", allocation.Method, allocation.Observations)
+	fmt.Printf("预期收益率: %.4f%%, 波动率: %.4f%%（按K线周期）\n", allocation.ExpectedReturn*100, allocation.Volatility*100)
+	fmt.Printf("\n账户: %s, 权益: %.2f\n", plan.Account, plan.Equity)
+	fmt.Printf("%-10s %10s %10s %14s %12s\n", "标的", "当前权重", "目标权重", "调仓数量", "价格")
+	for _, trade := range plan.Trades {
+		note := ""
+		if trade.Skipped != "" {
+			note = "  (" + trade.Skipped + ")"
+		}
+		fmt.Printf("%-10s %9.2f%% %9.2f%% %14.4f %12.2f%s\n",
+			trade.Symbol, trade.CurrentWeight*100, trade.TargetWeight*100, trade.Quantity, trade.Price, note)
+	}
+	if len(plan.Signals) == 0 {
+		fmt.Printf("\n权重偏离未超过阈值，无需调仓\n")
+	} else {
+		fmt.Printf("\n需要调仓 %d 笔；启用 portfolio.enabled 后交易循环按 rebalance_interval 执行\n", len(plan.Signals))
+	}
+	return nil
+}
+
+// closePosition 通过控制API由正在运行的引擎平仓，平仓订单经过引擎的订单队列并同步移除止损止盈规则
+func closePosition(cmd *cobra.Command, args []string) error {
+	var resp *controlpb.PlaceManualOrderResponse
+	err := callControl(func(ctx context.Context, client *api.Client) (err error) {
+		resp, err = client.ClosePosition(ctx, &controlpb.ClosePositionRequest{Account: accountName, Symbol: symbol, Percent: closePct})
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("平仓失败: %w", err)
+	}
+	order := resp.Order
+
+	fmt.Printf("已提交平仓订单: 订单ID=%s, %s %s %s, 状态=%s\n",
+		order.Id, order.Side, order.Symbol, order.Quantity, order.Status)
+	return nil
+}
+
+// newDataManager 按配置创建命令行使用的数据管理器，可用数据源和标的代码转换与交易服务一致
+func newDataManager(cfg *config.Config) (*data.DataManager, error) {
+	keys := cfg.APIKeys
+	if keys.FinnhubKey != "" {
+		secret, err := secrets.NewResolver(cfg.Secrets).Resolve(keys.FinnhubKey)
+		if err != nil {
+			log.Printf("解析 api_keys.finnhub_key 失败，不使用 finnhub 数据源: %v", err)
+			keys.FinnhubKey = ""
+		} else {
+			keys.FinnhubKey = secret.Reveal()
+		}
+	}
+	dataManager, err := data.NewDataManagerFromConfig(cfg.Data, data.VendorProviders(keys)...)
+	if err != nil {
+		return nil, err
+	}
+	dataManager.SetSymbols(symbols.NewMapper(cfg.Symbols))
+	return dataManager, nil
+}
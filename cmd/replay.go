@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"agent-quant-system/internal/config"
+	"agent-quant-system/internal/core"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	replaySymbols []string
+	replayStep    time.Duration
+	replayDelay   time.Duration
+	replayAgent   string
+)
+
+// replayCmd 回放命令
+var replayCmd = &cobra.Command{
+	Use:   "replay",
+	Short: "用历史行情驱动实盘引擎",
+	Long: `按回放时钟把历史K线逐轮提供给实盘引擎，走与 run 相同的扫描、Agent、策略、风控和下单流程，
+所有账户改用纸面经纪商按回放价格成交，用于验证实盘引擎（而不只是回测器）在历史行情下的行为；
+循环记录、成交流水、审计日志和各状态文件写入 engine.replay.output_dir，可用 history 等命令配合 --config 查看`,
+	RunE: runReplay,
+}
+
+func init() {
+	replayCmd.Flags().StringVar(&startDate, "start", "", "开始日期 (YYYY-MM-DD)，默认为30天前")
+	replayCmd.Flags().StringVar(&endDate, "end", "", "结束日期 (YYYY-MM-DD)，包含当天，默认为今天")
+	replayCmd.Flags().StringSliceVarP(&replaySymbols, "symbols", "s", nil, "回放的标的（逗号分隔），默认使用 scanner.watchlist")
+	replayCmd.Flags().DurationVar(&replayStep, "step", -1, "每轮循环推进的行情时间，0 表示逐根K线，默认使用 engine.replay.step")
+	replayCmd.Flags().DurationVar(&replayDelay, "delay", -1, "每轮循环之间的等待时间，默认使用 engine.replay.delay")
+	replayCmd.Flags().StringVar(&replayAgent, "agent", "", "mock 或 live，默认使用 engine.replay.agent")
+	rootCmd.AddCommand(replayCmd)
+}
+
+// runReplay 运行回放并打印结果
+func runReplay(cmd *cobra.Command, args []string) error {
+	cfg, err := config.LoadConfig(configFile)
+	if err != nil {
+		return fmt.Errorf("加载配置失败: %w", err)
+	}
+	if replayStep >= 0 {
+		cfg.Engine.Replay.Step = replayStep
+	}
+	if replayDelay >= 0 {
+		cfg.Engine.Replay.Delay = replayDelay
+	}
+	if replayAgent != "" {
+		cfg.Engine.Replay.Agent = replayAgent
+	}
+	if err := cfg.Engine.Replay.Validate(); err != nil {
+		return fmt.Errorf("engine.replay 配置无效: %w", err)
+	}
+
+	start := time.Now().AddDate(0, 0, -30)
+	if startDate != "" {
+		if start, err = time.ParseInLocation("2006-01-02", startDate, time.Local); err != nil {
+			return fmt.Errorf("解析开始日期失败: %w", err)
+		}
+	}
+	end := time.Now()
+	if endDate != "" {
+		if end, err = time.ParseInLocation("2006-01-02", endDate, time.Local); err != nil {
+			return fmt.Errorf("解析结束日期失败: %w", err)
+		}
+		end = end.AddDate(0, 0, 1)
+	}
+
+	if err := core.PrepareReplayConfig(cfg); err != nil {
+		return err
+	}
+	engine, err := core.NewQuantEngine(cfg)
+	if err != nil {
+		return fmt.Errorf("创建量化引擎失败: %w", err)
+	}
+	defer engine.FlushNotifications()
+
+	report, err := engine.RunReplay(replaySymbols, start, end)
+	if err != nil {
+		return fmt.Errorf("回放失败: %w", err)
+	}
+	log.Printf("回放结束")
+
+	fmt.Printf("\n=== 回放结果 ===\n")
+	fmt.Printf("标的: %v\n", report.Symbols)
+	fmt.Printf("区间: %s ~ %s, K线时间数: %d\n", report.Start.Format("2006-01-02"), report.End.Add(-time.Nanosecond).Format("2006-01-02"), report.Bars)
+	fmt.Printf("循环: %d (失败 %d), 信号: %d, 成交: %d\n", report.Cycles, report.FailedCycles, report.Signals, report.Trades)
+	fmt.Printf("盈亏: 已实现 %.2f, 未实现 %.2f, 合计 %.2f\n", report.RealizedPnL, report.UnrealizedPnL, report.TotalPnL)
+	fmt.Printf("耗时: %v\n", report.Elapsed.Round(time.Millisecond))
+	fmt.Printf("回放记录目录: %s\n", report.OutputDir)
+	return nil
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"agent-quant-system/internal/config"
+	"agent-quant-system/internal/instrument"
+	"agent-quant-system/internal/trading"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	taxAccount string
+	taxFrom    string
+	taxTo      string
+	taxYear    int
+	taxCSV     string
+)
+
+// taxCmd 税务批次命令
+var taxCmd = &cobra.Command{
+	Use:   "tax",
+	Short: "税务批次和已实现损益",
+	Long: `按成交流水（trading.journal_file）逐笔建立税务批次，平仓时按 trading.tax_lots.method
+（fifo / lifo / highest_cost，可按账户覆盖）选择批次，计算含手续费的已实现损益和长短期`,
+}
+
+// taxLotsCmd 未平仓批次命令
+var taxLotsCmd = &cobra.Command{
+	Use:   "lots",
+	Short: "查看未平仓的税务批次",
+	RunE:  showTaxLots,
+}
+
+// taxGainsCmd 已实现损益命令
+var taxGainsCmd = &cobra.Command{
+	Use:   "gains",
+	Short: "查看或导出已实现损益",
+	Long:  `列出时间区间内平仓的每个批次的卖出所得、成本和损益，按短期和长期汇总；--csv 导出为报税用的CSV文件`,
+	RunE:  showRealizedGains,
+}
+
+func init() {
+	taxCmd.PersistentFlags().StringVarP(&taxAccount, "account", "a", "", "账户名称，默认全部账户")
+	taxGainsCmd.Flags().StringVar(&taxFrom, "from", "", "平仓开始时间")
+	taxGainsCmd.Flags().StringVar(&taxTo, "to", "", "平仓结束时间（不含）")
+	taxGainsCmd.Flags().IntVar(&taxYear, "year", 0, "纳税年度，指定时忽略 --from / --to")
+	taxGainsCmd.Flags().StringVar(&taxCSV, "csv", "", "导出CSV文件路径")
+	taxCmd.AddCommand(taxLotsCmd, taxGainsCmd)
+	rootCmd.AddCommand(taxCmd)
+}
+
+// loadTaxLots 按配置的成交流水重建税务批次账本
+func loadTaxLots() (*trading.TaxLotBook, error) {
+	cfg, err := config.LoadConfig(configFile)
+	if err != nil {
+		return nil, fmt.Errorf("加载配置失败: %w", err)
+	}
+	if cfg.Trading.JournalFile == "" {
+		return nil, fmt.Errorf("未启用成交流水（trading.journal_file 为空），无法建立税务批次")
+	}
+	instruments, err := instrument.NewRegistry(cfg.Instruments)
+	if err != nil {
+		return nil, fmt.Errorf("创建合约规格表失败: %w", err)
+	}
+	return trading.BuildTaxLots(cfg.Trading.JournalFile, cfg.Trading.TaxLots, instruments)
+}
+
+// showTaxLots 打印未平仓的税务批次
+func showTaxLots(cmd *cobra.Command, args []string) error {
+	book, err := loadTaxLots()
+	if err != nil {
+		return err
+	}
+	lots := book.OpenLots(taxAccount)
+	if len(lots) == 0 {
+		fmt.Printf("没有未平仓的税务批次\n")
+		return nil
+	}
+
+	fmt.Printf("\n=== 未平仓税务批次 ===\n")
+	account := ""
+	for _, lot := range lots {
+		if lot.Account != account {
+			account = lot.Account
+			fmt.Printf("\n账户 %s（%s）\n", account, book.Method(account))
+		}
+		days := int(time.Since(lot.Opened).Hours() / 24)
+		fmt.Printf("  %s %-10s 数量 %s, 开仓 %s（%d 天）, 单位成本 %s, 总成本 %s\n",
+			lot.ID, lot.Symbol, lot.Quantity, lot.Opened.Format("2006-01-02 15:04"), days,
+			lot.CostBasis.StringFixed(4), lot.CostBasis.Mul(lot.Quantity.Abs()).StringFixed(2))
+	}
+	return nil
+}
+
+// showRealizedGains 打印或导出已实现损益
+func showRealizedGains(cmd *cobra.Command, args []string) error {
+	book, err := loadTaxLots()
+	if err != nil {
+		return err
+	}
+
+	var from, to time.Time
+	if taxYear > 0 {
+		from = time.Date(taxYear, 1, 1, 0, 0, 0, 0, time.Local)
+		to = from.AddDate(1, 0, 0)
+	} else {
+		if from, err = parseHistoryTime(taxFrom); err != nil {
+			return err
+		}
+		if to, err = parseHistoryTime(taxTo); err != nil {
+			return err
+		}
+	}
+	gains := book.Gains(from, to, taxAccount)
+
+	if taxCSV != "" {
+		file, err := os.Create(taxCSV)
+		if err != nil {
+			return fmt.Errorf("创建CSV文件失败: %w", err)
+		}
+		defer file.Close()
+		if err := trading.WriteGainsCSV(file, gains); err != nil {
+			return err
+		}
+		fmt.Printf("已导出 %d 条已实现损益: %s\n", len(gains), taxCSV)
+	} else if len(gains) == 0 {
+		fmt.Printf("没有符合条件的已实现损益\n")
+		return nil
+	} else {
+		fmt.Printf("\n=== 已实现损益 ===\n")
+		for _, gain := range gains {
+			fmt.Printf("%s %-15s %-10s 数量 %s, 开仓 %s, 所得 %s, 成本 %s, 损益 %s (%s) [%s]\n",
+				gain.Closed.Format("2006-01-02 15:04"), gain.Account, gain.Symbol, gain.Quantity,
+				gain.Opened.Format("2006-01-02"), gain.Proceeds.StringFixed(2), gain.CostBasis.StringFixed(2),
+				gain.Gain.StringFixed(2), gain.Term, gain.LotID)
+		}
+	}
+
+	summary := trading.SummarizeGains(gains)
+	fmt.Printf("\n合计: 所得 %s, 成本 %s, 短期 %s, 长期 %s, 总计 %s\n",
+		summary.Proceeds.StringFixed(2), summary.CostBasis.StringFixed(2),
+		summary.ShortTerm.StringFixed(2), summary.LongTerm.StringFixed(2), summary.Total.StringFixed(2))
+	return nil
+}
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"agent-quant-system/internal/api"
+	"agent-quant-system/internal/api/controlpb"
+	"agent-quant-system/internal/core"
+	"agent-quant-system/internal/trading"
+
+	"github.com/shopspring/decimal"
+	"github.com/spf13/cobra"
+)
+
+var (
+	tradeSide      string
+	tradeQuantity  string
+	tradeType      string
+	tradePrice     string
+	tradeStopPrice string
+	tradeTIF       string
+	tradeStrategy  string
+	tradeReason    string
+	tradeOperator  string
+	tradeYes       bool
+)
+
+// tradeCmd 手动下单命令
+var tradeCmd = &cobra.Command{
+	Use:   "trade",
+	Short: "手动下单",
+	Long: `提交手动订单，用于主观调仓和紧急退出。订单与策略信号经过同样的紧急停止、交易名单、
+资金分配、风控和下单频率检查，提交结果不经抽样记入信号日志。提交前显示订单和当前持仓并要求确认，--yes 跳过确认。
+引擎正在运行时订单通过控制API交给它提交，与 halt / resume 相同，连接不上控制API时才在本进程创建引擎下单。
+紧急停止期间只能用 close 命令平仓`,
+	Example: `  quant-system trade --account my_stock_broker --symbol AAPL --side buy --qty 10 --type limit --price 180
+  quant-system trade -a my_crypto_exchange -s BTCUSDT --side sell --qty 0.5 --reason "减仓" --yes`,
+	RunE: placeTrade,
+}
+
+func init() {
+	tradeCmd.Flags().StringVarP(&accountName, "account", "a", "", "账户名称")
+	tradeCmd.Flags().StringVarP(&symbol, "symbol", "s", "", "标的")
+	tradeCmd.Flags().StringVar(&tradeSide, "side", "", "方向: buy / sell")
+	tradeCmd.Flags().StringVar(&tradeQuantity, "qty", "", "数量")
+	tradeCmd.Flags().StringVar(&tradeType, "type", "market", "订单类型: market / limit / stop")
+	tradeCmd.Flags().StringVar(&tradePrice, "price", "", "限价单价格")
+	tradeCmd.Flags().StringVar(&tradeStopPrice, "stop-price", "", "止损单触发价")
+	tradeCmd.Flags().StringVar(&tradeTIF, "tif", "", "订单有效期: gtc / day / ioc / fok，默认使用经纪商默认")
+	tradeCmd.Flags().StringVar(&tradeStrategy, "strategy", "", "归属策略（用于资金分配和盈亏归属），默认 manual")
+	tradeCmd.Flags().StringVar(&tradeReason, "reason", "", "下单原因，记入信号日志")
+	tradeCmd.Flags().StringVar(&tradeOperator, "by", os.Getenv("USER"), "操作人")
+	tradeCmd.Flags().BoolVarP(&tradeYes, "yes", "y", false, "跳过确认直接提交")
+	for _, flag := range []string{"account", "symbol", "side", "qty"} {
+		_ = tradeCmd.MarkFlagRequired(flag)
+	}
+	rootCmd.AddCommand(tradeCmd)
+}
+
+// placeTrade 手动下单
+func placeTrade(cmd *cobra.Command, args []string) error {
+	order, err := tradeOrder()
+	if err != nil {
+		return err
+	}
+	if err := core.ValidateManualOrder(order); err != nil {
+		return fmt.Errorf("订单无效: %w", err)
+	}
+
+	// 预览持仓时即确定由运行中的引擎还是本进程下单，连接不上控制API时 engine 为本进程的引擎
+	var engine *core.QuantEngine
+	var snapshot *controlpb.PositionSnapshot
+	err = callControl(func(ctx context.Context, client *api.Client) (err error) {
+		snapshot, err = client.ExportSnapshot(ctx, &controlpb.ExportSnapshotRequest{})
+		return err
+	})
+	if err != nil {
+		if !errors.Is(err, api.ErrEngineUnavailable) {
+			return err
+		}
+		log.Printf("%v；改为在本进程创建引擎下单", err)
+		if engine, err = newEngineForAccount(); err != nil {
+			return err
+		}
+		defer engine.FlushNotifications()
+	}
+
+	printTradePreview(order)
+	if engine != nil {
+		printLocalPosition(engine, order.Symbol)
+	} else {
+		printSnapshotPosition(snapshot, order.Symbol)
+	}
+	if !tradeYes && !confirm("确认提交该订单?") {
+		fmt.Println("已取消")
+		return nil
+	}
+
+	if engine == nil {
+		var resp *controlpb.PlaceManualOrderResponse
+		err := callControl(func(ctx context.Context, client *api.Client) (err error) {
+			resp, err = client.PlaceManualOrder(ctx, manualOrderRequest(order))
+			return err
+		})
+		if err == nil {
+			filled := resp.Order
+			fmt.Printf("运行中的引擎已提交订单: 订单ID=%s, %s %s %s, 状态=%s\n",
+				filled.Id, filled.Side, filled.Symbol, filled.Quantity, filled.Status)
+			if filledQty, _ := decimal.NewFromString(filled.FilledQuantity); filledQty.IsPositive() {
+				fmt.Printf("已成交: %s @ %s, 手续费 %s\n", filled.FilledQuantity, filled.AveragePrice, filled.Commission)
+			}
+			return nil
+		}
+		if !errors.Is(err, api.ErrEngineUnavailable) {
+			return fmt.Errorf("下单失败: %w", err)
+		}
+		log.Printf("%v；改为在本进程创建引擎下单", err)
+		if engine, err = newEngineForAccount(); err != nil {
+			return err
+		}
+		defer engine.FlushNotifications()
+	}
+
+	filled, err := engine.PlaceOrder(accountName, order, core.OrderOrigin{Source: "cli", Operator: tradeOperator, Reason: tradeReason})
+	if err != nil {
+		return fmt.Errorf("下单失败: %w", err)
+	}
+
+	fmt.Printf("已提交订单: 订单ID=%s, %s %s %s, 状态=%s\n", filled.ID, filled.Side, filled.Symbol, filled.Quantity, filled.Status)
+	if filled.FilledQty.IsPositive() {
+		fmt.Printf("已成交: %s @ %s, 手续费 %s\n", filled.FilledQty, filled.AvgPrice, filled.Commission)
+	}
+	return nil
+}
+
+// manualOrderRequest 转换为控制API的手动下单请求
+func manualOrderRequest(order trading.Order) *controlpb.PlaceManualOrderRequest {
+	request := &controlpb.PlaceManualOrderRequest{
+		Account:     accountName,
+		Symbol:      order.Symbol,
+		Side:        string(order.Side),
+		Type:        string(order.Type),
+		Quantity:    order.Quantity.String(),
+		TimeInForce: string(order.TimeInForce),
+		Strategy:    order.Strategy,
+		Operator:    tradeOperator,
+		Reason:      tradeReason,
+	}
+	if !order.Price.IsZero() {
+		request.Price = order.Price.String()
+	}
+	if !order.StopPrice.IsZero() {
+		request.StopPrice = order.StopPrice.String()
+	}
+	return request
+}
+
+// tradeOrder 按命令行参数创建订单
+func tradeOrder() (trading.Order, error) {
+	order := trading.Order{
+		Symbol:      strings.ToUpper(symbol),
+		Side:        trading.OrderSide(strings.ToLower(tradeSide)),
+		Type:        trading.OrderType(strings.ToLower(tradeType)),
+		TimeInForce: trading.TimeInForce(strings.ToLower(tradeTIF)),
+		Strategy:    tradeStrategy,
+	}
+
+	var err error
+	for _, field := range []struct {
+		name   string
+		value  string
+		target *decimal.Decimal
+	}{
+		{"--qty", tradeQuantity, &order.Quantity},
+		{"--price", tradePrice, &order.Price},
+		{"--stop-price", tradeStopPrice, &order.StopPrice},
+	} {
+		if field.value == "" {
+			continue
+		}
+		if *field.target, err = decimal.NewFromString(field.value); err != nil {
+			return trading.Order{}, fmt.Errorf("%s 格式无效: %w", field.name, err)
+		}
+	}
+	return order, nil
+}
+
+// printTradePreview 显示待提交的订单
+func printTradePreview(order trading.Order) {
+	fmt.Printf("=== 手动订单 ===\n")
+	fmt.Printf("账户: %s\n", accountName)
+	fmt.Printf("订单: %s %s %s (%s)\n", order.Side, order.Quantity, order.Symbol, order.Type)
+	switch order.Type {
+	case trading.LimitOrder:
+		fmt.Printf("限价: %s, 名义金额: %s\n", order.Price, order.Price.Mul(order.Quantity).StringFixed(2))
+	case trading.StopOrder:
+		fmt.Printf("触发价: %s, 名义金额: %s\n", order.StopPrice, order.StopPrice.Mul(order.Quantity).StringFixed(2))
+	default:
+		fmt.Printf("价格: 市价（按最新价格估算风控和资金）\n")
+	}
+	if order.TimeInForce != "" {
+		fmt.Printf("有效期: %s\n", order.TimeInForce)
+	}
+	if tradeReason != "" {
+		fmt.Printf("原因: %s\n", tradeReason)
+	}
+}
+
+// printLocalPosition 显示本进程引擎中账户的余额和在该标的上的当前持仓
+func printLocalPosition(engine *core.QuantEngine, symbol string) {
+	if balance, err := engine.GetAccountBalance(accountName); err == nil {
+		fmt.Printf("账户余额: %s\n", balance.StringFixed(2))
+	}
+	positions, err := engine.GetAccountPositions(accountName)
+	if err != nil {
+		fmt.Printf("当前持仓: 获取失败 (%v)\n", err)
+		return
+	}
+	if position, exists := positions[symbol]; exists && !position.Quantity.IsZero() {
+		fmt.Printf("当前持仓: %s @ %s, 市值 %s\n", position.Quantity, position.AvgPrice, position.MarketValue.StringFixed(2))
+	} else {
+		fmt.Printf("当前持仓: 无\n")
+	}
+}
+
+// printSnapshotPosition 按运行中引擎导出的持仓快照显示账户的余额和在该标的上的当前持仓
+func printSnapshotPosition(snapshot *controlpb.PositionSnapshot, symbol string) {
+	if reason, failed := snapshot.GetErrors()[accountName]; failed {
+		fmt.Printf("当前持仓: 获取失败 (%s)\n", reason)
+		return
+	}
+	for _, account := range snapshot.GetAccounts() {
+		if account.Account != accountName {
+			continue
+		}
+		fmt.Printf("账户余额: %.2f\n", account.Balance)
+		for _, position := range account.Positions {
+			if position.Symbol == symbol && position.Quantity != 0 {
+				fmt.Printf("当前持仓: %g @ %g, 市值 %.2f\n", position.Quantity, position.AveragePrice, position.MarketValue)
+				return
+			}
+		}
+	}
+	fmt.Printf("当前持仓: 无\n")
+}
+
+// confirm 在终端询问确认，输入 y 或 yes 视为确认
+func confirm(prompt string) bool {
+	fmt.Printf("%s [y/N]: ", prompt)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && answer == "" {
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
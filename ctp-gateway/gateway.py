@@ -0,0 +1,626 @@
+"""
+CTP 网关：把 CTP 交易接口封装为本地 HTTP JSON 服务，供交易引擎的 ctp 经纪商（internal/trading/ctp.go）使用。
+
+通过 openctp-ctp 提供的官方 thosttraderapi 绑定连接期货公司或券商的 CTP 交易前置（使用 openctp 模拟环境时
+安装 openctp-tts，接口相同）。请求和应答的字段沿用 CTP 结构体的字段名：
+
+    POST /login          ReqAuthenticate（配置 AppID 时）+ ReqUserLogin + ReqSettlementInfoConfirm，
+                         返回 TradingDay、FrontID、SessionID、MaxOrderRef
+    GET  /status         前置连接和登录状态
+    POST /orders         InputOrder -> ReqOrderInsert
+    POST /orders/cancel  InputOrderAction -> ReqOrderAction
+    GET  /orders         当日报单（私有流回报，网关重启后从交易日开始重传）
+    GET  /trades         当日成交
+    GET  /account        ReqQryTradingAccount
+    GET  /positions      ReqQryInvestorPosition，同一合约同一方向合并，附带合约乘数 VolumeMultiple
+    GET  /quotes/{id}    ReqQryDepthMarketData
+
+错误以 {"ErrorID": ..., "ErrorMsg": ...} 返回：前置未连接时状态码 503，未登录时 401，CTP 拒绝请求时 400。
+查询请求按 CTP 流控要求串行发送，间隔至少 1 秒；前置断线重连后用上次的登录信息自动重新登录。
+"""
+
+import os
+import logging
+import threading
+import time
+from datetime import datetime
+from typing import Callable, Dict, List, Optional, Tuple
+
+import uvicorn
+from fastapi import FastAPI, Request
+from fastapi.responses import JSONResponse
+from dotenv import load_dotenv
+
+try:
+    from openctp_ctp import tdapi
+except ImportError:  # openctp 模拟环境（TTS）的绑定
+    from openctp_tts import tdapi
+
+load_dotenv()
+
+logging.basicConfig(level=logging.INFO, format='%(asctime)s - %(name)s - %(levelname)s - %(message)s')
+logger = logging.getLogger("ctp_gateway")
+
+# 网关设置
+TD_FRONT = os.getenv("CTP_TD_FRONT", "")                    # 交易前置地址，如 tcp://180.168.146.187:10201
+HOST = os.getenv("CTP_GATEWAY_HOST", "127.0.0.1")
+PORT = int(os.getenv("CTP_GATEWAY_PORT", "7001"))
+FLOW_DIR = os.getenv("CTP_FLOW_DIR", "flow/")               # CTP 流文件目录
+REQUEST_TIMEOUT = float(os.getenv("CTP_REQUEST_TIMEOUT", "10"))  # 等待 CTP 应答的时间（秒）
+ACK_TIMEOUT = float(os.getenv("CTP_ACK_TIMEOUT", "1"))      # 报单、撤单等待拒绝应答的时间（秒），超时视为已受理
+
+# CTP 查询流控：同时只能有一个未应答的查询，每秒最多一次
+QUERY_INTERVAL = 1.0
+QUERY_RETRIES = 10
+
+# 应答中的字段
+ORDER_FIELDS = [
+    "InstrumentID", "ExchangeID", "OrderRef", "FrontID", "SessionID", "OrderSysID", "Direction",
+    "CombOffsetFlag", "OrderPriceType", "LimitPrice", "VolumeTotalOriginal", "VolumeTraded",
+    "OrderStatus", "OrderSubmitStatus", "StatusMsg", "InsertDate", "InsertTime",
+]
+TRADE_FIELDS = [
+    "TradeID", "OrderRef", "OrderSysID", "InstrumentID", "ExchangeID", "Direction", "OffsetFlag",
+    "Price", "Volume", "TradeDate", "TradeTime",
+]
+ACCOUNT_FIELDS = ["Balance", "Available", "CloseProfit", "PositionProfit"]
+POSITION_FIELDS = [
+    "InstrumentID", "ExchangeID", "PosiDirection", "Position", "TodayPosition", "OpenCost",
+    "PositionProfit", "CloseProfit",
+]
+QUOTE_FIELDS = ["LastPrice", "PreClosePrice", "UpperLimitPrice", "LowerLimitPrice"]
+LOGIN_FIELDS = ["TradingDay", "FrontID", "SessionID", "MaxOrderRef"]
+
+# 报单录入请求中由调用方填写的字段及类型
+INPUT_ORDER_FIELDS = {
+    "InstrumentID": str, "ExchangeID": str, "OrderRef": str, "Direction": str, "CombOffsetFlag": str,
+    "CombHedgeFlag": str, "OrderPriceType": str, "LimitPrice": float, "VolumeTotalOriginal": int,
+    "TimeCondition": str, "VolumeCondition": str, "MinVolume": int, "ContingentCondition": str,
+    "ForceCloseReason": str,
+}
+
+
+class CTPError(Exception):
+    """CTP 应答错误或网关状态错误"""
+
+    def __init__(self, error_id: int, error_msg: str, status: int = 400):
+        super().__init__(f"{error_id}: {error_msg}")
+        self.error_id = error_id
+        self.error_msg = error_msg
+        self.status = status
+
+
+def to_dict(field, names: List[str]) -> Dict:
+    """复制 CTP 结构体的字段；CTP 以 DBL_MAX 表示无效价格，转为 0"""
+    result = {}
+    for name in names:
+        value = getattr(field, name)
+        if isinstance(value, float) and abs(value) > 1e300:
+            value = 0.0
+        result[name] = value
+    return result
+
+
+class _Pending:
+    """等待中的请求：收集应答数据直到 bIsLast"""
+
+    def __init__(self, convert: Optional[Callable]):
+        self.convert = convert
+        self.items: List = []
+        self.error: Tuple[int, str] = (0, "")
+        self.event = threading.Event()
+
+
+class TraderSpi(tdapi.CThostFtdcTraderSpi):
+    """交易接口回调，转交网关处理；回调在 CTP 线程中执行，不能在其中等待应答"""
+
+    def __init__(self, gateway: "Gateway"):
+        super().__init__()
+        self.gateway = gateway
+
+    def OnFrontConnected(self):
+        self.gateway.on_front_connected()
+
+    def OnFrontDisconnected(self, nReason):
+        self.gateway.on_front_disconnected(nReason)
+
+    def OnRspAuthenticate(self, pRspAuthenticateField, pRspInfo, nRequestID, bIsLast):
+        self.gateway.on_response(pRspAuthenticateField, pRspInfo, nRequestID, bIsLast)
+
+    def OnRspUserLogin(self, pRspUserLogin, pRspInfo, nRequestID, bIsLast):
+        self.gateway.on_response(pRspUserLogin, pRspInfo, nRequestID, bIsLast)
+
+    def OnRspSettlementInfoConfirm(self, pSettlementInfoConfirm, pRspInfo, nRequestID, bIsLast):
+        self.gateway.on_response(pSettlementInfoConfirm, pRspInfo, nRequestID, bIsLast)
+
+    def OnRspQryInstrument(self, pInstrument, pRspInfo, nRequestID, bIsLast):
+        self.gateway.on_response(pInstrument, pRspInfo, nRequestID, bIsLast)
+
+    def OnRspQryTradingAccount(self, pTradingAccount, pRspInfo, nRequestID, bIsLast):
+        self.gateway.on_response(pTradingAccount, pRspInfo, nRequestID, bIsLast)
+
+    def OnRspQryInvestorPosition(self, pInvestorPosition, pRspInfo, nRequestID, bIsLast):
+        self.gateway.on_response(pInvestorPosition, pRspInfo, nRequestID, bIsLast)
+
+    def OnRspQryDepthMarketData(self, pDepthMarketData, pRspInfo, nRequestID, bIsLast):
+        self.gateway.on_response(pDepthMarketData, pRspInfo, nRequestID, bIsLast)
+
+    def OnRspOrderInsert(self, pInputOrder, pRspInfo, nRequestID, bIsLast):
+        self.gateway.on_order_insert_error(pInputOrder, pRspInfo)
+
+    def OnErrRtnOrderInsert(self, pInputOrder, pRspInfo):
+        self.gateway.on_order_insert_error(pInputOrder, pRspInfo)
+
+    def OnRspOrderAction(self, pInputOrderAction, pRspInfo, nRequestID, bIsLast):
+        self.gateway.on_response(pInputOrderAction, pRspInfo, nRequestID, bIsLast)
+
+    def OnErrRtnOrderAction(self, pOrderAction, pRspInfo):
+        if pRspInfo is not None and pRspInfo.ErrorID != 0:
+            logger.warning(f"撤单被拒绝: OrderRef={pOrderAction.OrderRef}, 错误 {pRspInfo.ErrorID}: {pRspInfo.ErrorMsg}")
+
+    def OnRtnOrder(self, pOrder):
+        self.gateway.on_order(to_dict(pOrder, ORDER_FIELDS))
+
+    def OnRtnTrade(self, pTrade):
+        self.gateway.on_trade(to_dict(pTrade, TRADE_FIELDS))
+
+    def OnRspError(self, pRspInfo, nRequestID, bIsLast):
+        self.gateway.on_response(None, pRspInfo, nRequestID, bIsLast)
+
+
+class Gateway:
+    """一个 CTP 交易会话：登录状态、当日报单和成交回报、合约乘数"""
+
+    def __init__(self, front: str, flow_dir: str):
+        self.front = front
+        self.flow_dir = flow_dir
+        self.api = None
+        self.spi = None
+
+        self.connected = False
+        self.logged_in = False
+        self.message = "前置未连接"
+        self.credentials: Optional[Dict] = None
+        self.login_info: Dict = {}
+        self.relogging = False
+
+        self.orders: Dict[Tuple, Dict] = {}          # (FrontID, SessionID, OrderRef) -> 报单
+        self.trades: Dict[Tuple, Dict] = {}          # (ExchangeID, TradeID, Direction) -> 成交
+        self.multipliers: Dict[str, int] = {}        # ExchangeID:InstrumentID -> 合约乘数
+        self.order_errors: Dict[str, Tuple] = {}     # 本会话 OrderRef -> 报单错误
+        self.order_events: Dict[str, threading.Event] = {}
+
+        self.pending: Dict[int, _Pending] = {}
+        self.request_id = 0
+        self.lock = threading.Lock()
+        self.query_lock = threading.Lock()
+        self.login_lock = threading.Lock()
+        self.last_query = 0.0
+        self.connected_event = threading.Event()
+
+    def start(self):
+        """创建交易接口并连接前置；私有流从交易日开始重传，网关重启后仍有当日全部报单和成交"""
+        os.makedirs(self.flow_dir, exist_ok=True)
+        self.api = tdapi.CThostFtdcTraderApi.CreateFtdcTraderApi(self.flow_dir)
+        self.spi = TraderSpi(self)
+        self.api.RegisterSpi(self.spi)
+        self.api.RegisterFront(self.front)
+        self.api.SubscribePrivateTopic(tdapi.THOST_TERT_RESTART)
+        self.api.SubscribePublicTopic(tdapi.THOST_TERT_QUICK)
+        self.api.Init()
+        logger.info(f"连接 CTP 交易前置: {self.front}, 接口版本 {self.api.GetApiVersion()}")
+
+    # ---- 回调 ----
+
+    def on_front_connected(self):
+        logger.info("CTP 交易前置已连接")
+        with self.lock:
+            self.connected = True
+            self.message = "已连接，未登录"
+            relogin = self.credentials is not None and not self.relogging
+            if relogin:
+                self.relogging = True
+        self.connected_event.set()
+        if relogin:
+            # 回调线程中不能等待应答，在新线程中重新登录
+            threading.Thread(target=self._relogin, daemon=True).start()
+
+    def on_front_disconnected(self, reason: int):
+        logger.warning(f"CTP 交易前置断开: 原因 0x{reason:x}")
+        self.connected_event.clear()
+        with self.lock:
+            self.connected = False
+            self.logged_in = False
+            self.message = f"前置断开（原因 0x{reason:x}），等待自动重连"
+            pending = list(self.pending.values())
+            self.pending.clear()
+        for item in pending:
+            item.error = (-1, "前置断开")
+            item.event.set()
+
+    def on_response(self, data, info, request_id: int, is_last: bool):
+        with self.lock:
+            pending = self.pending.get(request_id)
+        if pending is None:
+            return
+        if info is not None and info.ErrorID != 0:
+            pending.error = (info.ErrorID, info.ErrorMsg)
+        if data is not None and pending.convert is not None:
+            # 结构体只在回调期间有效，立即复制
+            pending.items.append(pending.convert(data))
+        if is_last:
+            pending.event.set()
+
+    def on_order(self, order: Dict):
+        key = (order["FrontID"], order["SessionID"], order["OrderRef"].strip())
+        with self.lock:
+            self.orders[key] = order
+            event = self._own_order_event(order)
+        if event is not None:
+            event.set()
+
+    def on_trade(self, trade: Dict):
+        with self.lock:
+            self.trades[(trade["ExchangeID"], trade["TradeID"].strip(), trade["Direction"])] = trade
+
+    def on_order_insert_error(self, input_order, info):
+        """报单被期货公司或交易所拒绝：没有报单回报时按拒单记录，便于轮询报单时看到拒绝原因"""
+        if info is None or info.ErrorID == 0:
+            return
+        order_ref = input_order.OrderRef.strip()
+        logger.warning(f"报单被拒绝: OrderRef={order_ref}, 错误 {info.ErrorID}: {info.ErrorMsg}")
+        with self.lock:
+            front_id, session_id = self.login_info.get("FrontID", 0), self.login_info.get("SessionID", 0)
+            key = (front_id, session_id, order_ref)
+            order = self.orders.get(key)
+            if order is None:
+                order = {
+                    "InstrumentID": input_order.InstrumentID,
+                    "ExchangeID": input_order.ExchangeID,
+                    "OrderRef": order_ref,
+                    "FrontID": front_id,
+                    "SessionID": session_id,
+                    "OrderSysID": "",
+                    "Direction": input_order.Direction,
+                    "CombOffsetFlag": input_order.CombOffsetFlag,
+                    "OrderPriceType": input_order.OrderPriceType,
+                    "LimitPrice": input_order.LimitPrice,
+                    "VolumeTotalOriginal": input_order.VolumeTotalOriginal,
+                    "VolumeTraded": 0,
+                    "OrderStatus": tdapi.THOST_FTDC_OST_Canceled,
+                    "InsertDate": self.login_info.get("TradingDay", ""),
+                    "InsertTime": datetime.now().strftime("%H:%M:%S"),
+                }
+                self.orders[key] = order
+            order["OrderSubmitStatus"] = tdapi.THOST_FTDC_OSS_InsertRejected
+            order["StatusMsg"] = info.ErrorMsg
+            self.order_errors[order_ref] = (info.ErrorID, info.ErrorMsg)
+            event = self.order_events.get(order_ref)
+        if event is not None:
+            event.set()
+
+    def _own_order_event(self, order: Dict) -> Optional[threading.Event]:
+        """本会话报单的等待事件（调用时持有 self.lock）"""
+        if order["FrontID"] != self.login_info.get("FrontID") or order["SessionID"] != self.login_info.get("SessionID"):
+            return None
+        return self.order_events.get(order["OrderRef"].strip())
+
+    # ---- 请求 ----
+
+    def _next_request_id(self) -> int:
+        with self.lock:
+            self.request_id += 1
+            return self.request_id
+
+    def _request(self, send: Callable, field, convert: Optional[Callable] = None) -> List:
+        """发送请求并等待全部应答，CTP 返回错误时抛出 CTPError"""
+        request_id = self._next_request_id()
+        pending = _Pending(convert)
+        with self.lock:
+            self.pending[request_id] = pending
+        try:
+            code = send(field, request_id)
+            if code != 0:
+                raise CTPError(code, _send_error(code), 503 if code == -1 else 400)
+            if not pending.event.wait(REQUEST_TIMEOUT):
+                raise CTPError(-1, f"等待 CTP 应答超时（{REQUEST_TIMEOUT:.0f}秒）", 504)
+        finally:
+            with self.lock:
+                self.pending.pop(request_id, None)
+        if pending.error[0] != 0:
+            raise CTPError(pending.error[0], pending.error[1], 503 if pending.error[0] == -1 else 400)
+        return pending.items
+
+    def _query(self, send: Callable, field, convert: Callable) -> List:
+        """按流控发送查询：同一时间只有一个查询，间隔至少 1 秒，超出流控时等待后重试"""
+        with self.query_lock:
+            for attempt in range(QUERY_RETRIES):
+                wait = self.last_query + QUERY_INTERVAL - time.time()
+                if wait > 0:
+                    time.sleep(wait)
+                self.last_query = time.time()
+                try:
+                    return self._request(send, field, convert)
+                except CTPError as e:
+                    # -2 未处理请求超过许可数，-3 每秒发送请求数超过许可数
+                    if e.error_id not in (-2, -3) or attempt == QUERY_RETRIES - 1:
+                        raise
+            return []
+
+    def require_login(self):
+        with self.lock:
+            if not self.connected:
+                raise CTPError(-1, self.message, 503)
+            if not self.logged_in:
+                raise CTPError(-1, "未登录", 401)
+
+    def login(self, credentials: Dict) -> Dict:
+        """登录（含穿透式认证）；已用同一账户登录时返回当前会话"""
+        with self.lock:
+            if self.logged_in and self.credentials is not None:
+                same = all(self.credentials.get(k) == credentials.get(k) for k in ("BrokerID", "UserID"))
+                if not same:
+                    raise CTPError(-1, f"网关已登录账户 {self.credentials['UserID']}", 409)
+                return dict(self.login_info, ErrorID=0, ErrorMsg="")
+        if not self.connected_event.wait(REQUEST_TIMEOUT):
+            raise CTPError(-1, self.message, 503)
+        info = self._login(credentials)
+        return dict(info, ErrorID=0, ErrorMsg="")
+
+    def _login(self, credentials: Dict) -> Dict:
+        with self.login_lock:
+            broker_id, user_id = credentials.get("BrokerID", ""), credentials.get("UserID", "")
+            if credentials.get("AppID"):
+                field = tdapi.CThostFtdcReqAuthenticateField()
+                field.BrokerID = broker_id
+                field.UserID = user_id
+                field.AppID = credentials["AppID"]
+                field.AuthCode = credentials.get("AuthCode", "")
+                self._request(self.api.ReqAuthenticate, field)
+
+            field = tdapi.CThostFtdcReqUserLoginField()
+            field.BrokerID = broker_id
+            field.UserID = user_id
+            field.Password = credentials.get("Password", "")
+            items = self._request(self.api.ReqUserLogin, field, lambda data: to_dict(data, LOGIN_FIELDS))
+            if not items:
+                raise CTPError(-1, "登录应答为空")
+            info = items[0]
+
+            field = tdapi.CThostFtdcSettlementInfoConfirmField()
+            field.BrokerID = broker_id
+            field.InvestorID = user_id
+            self._request(self.api.ReqSettlementInfoConfirm, field)
+
+            with self.lock:
+                self.credentials = dict(credentials)
+                self.login_info = info
+                self.logged_in = True
+                self.message = "已登录"
+            logger.info(f"CTP 已登录: 投资者={user_id}, 交易日={info['TradingDay']}, "
+                        f"FrontID={info['FrontID']}, SessionID={info['SessionID']}")
+
+            if not self.multipliers:
+                self._load_instruments()
+            return info
+
+    def _relogin(self):
+        try:
+            with self.lock:
+                credentials = dict(self.credentials)
+            self._login(credentials)
+        except CTPError as e:
+            logger.error(f"CTP 自动重新登录失败: {e}")
+            with self.lock:
+                self.message = f"自动重新登录失败: {e.error_msg}"
+        finally:
+            with self.lock:
+                self.relogging = False
+
+    def _load_instruments(self):
+        """查询全部合约的乘数，持仓市值按乘数折算"""
+        try:
+            items = self._query(self.api.ReqQryInstrument, tdapi.CThostFtdcQryInstrumentField(),
+                                lambda data: (data.ExchangeID, data.InstrumentID, data.VolumeMultiple))
+        except CTPError as e:
+            logger.warning(f"查询合约失败，持仓的合约乘数按 1 计算: {e}")
+            return
+        with self.lock:
+            for exchange_id, instrument_id, multiple in items:
+                self.multipliers[f"{exchange_id}:{instrument_id}"] = multiple or 1
+        logger.info(f"已加载 {len(items)} 个合约")
+
+    def insert_order(self, body: Dict) -> Dict:
+        """报单录入；等待片刻以便把期货公司的拒单原因直接返回"""
+        self.require_login()
+        field = tdapi.CThostFtdcInputOrderField()
+        with self.lock:
+            field.BrokerID = self.credentials["BrokerID"]
+            field.InvestorID = self.credentials["UserID"]
+            field.UserID = self.credentials["UserID"]
+        for name, kind in INPUT_ORDER_FIELDS.items():
+            if name in body:
+                setattr(field, name, kind(body[name]))
+        field.IsAutoSuspend = 0
+        field.UserForceClose = 0
+
+        order_ref = str(body.get("OrderRef", "")).strip()
+        event = threading.Event()
+        with self.lock:
+            self.order_events[order_ref] = event
+        try:
+            code = self.api.ReqOrderInsert(field, self._next_request_id())
+            if code != 0:
+                raise CTPError(code, _send_error(code), 503 if code == -1 else 400)
+            event.wait(ACK_TIMEOUT)
+            with self.lock:
+                error = self.order_errors.pop(order_ref, None)
+        finally:
+            with self.lock:
+                self.order_events.pop(order_ref, None)
+        if error is not None:
+            raise CTPError(error[0], error[1])
+        return {"ErrorID": 0, "ErrorMsg": ""}
+
+    def cancel_order(self, body: Dict) -> Dict:
+        """撤单；撤单被拒绝时在等待时间内返回原因"""
+        self.require_login()
+        field = tdapi.CThostFtdcInputOrderActionField()
+        with self.lock:
+            field.BrokerID = self.credentials["BrokerID"]
+            field.InvestorID = self.credentials["UserID"]
+            field.UserID = self.credentials["UserID"]
+        field.OrderRef = str(body.get("OrderRef", ""))
+        field.FrontID = int(body.get("FrontID", 0))
+        field.SessionID = int(body.get("SessionID", 0))
+        field.InstrumentID = str(body.get("InstrumentID", ""))
+        field.ExchangeID = str(body.get("ExchangeID", ""))
+        field.ActionFlag = str(body.get("ActionFlag", tdapi.THOST_FTDC_AF_Delete))
+
+        request_id = self._next_request_id()
+        pending = _Pending(None)
+        with self.lock:
+            self.pending[request_id] = pending
+        try:
+            code = self.api.ReqOrderAction(field, request_id)
+            if code != 0:
+                raise CTPError(code, _send_error(code), 503 if code == -1 else 400)
+            pending.event.wait(ACK_TIMEOUT)
+        finally:
+            with self.lock:
+                self.pending.pop(request_id, None)
+        if pending.error[0] != 0:
+            raise CTPError(pending.error[0], pending.error[1])
+        return {"ErrorID": 0, "ErrorMsg": ""}
+
+    def list_orders(self) -> List[Dict]:
+        self.require_login()
+        with self.lock:
+            return list(self.orders.values())
+
+    def list_trades(self) -> List[Dict]:
+        self.require_login()
+        with self.lock:
+            return list(self.trades.values())
+
+    def account(self) -> Dict:
+        self.require_login()
+        field = tdapi.CThostFtdcQryTradingAccountField()
+        with self.lock:
+            field.BrokerID = self.credentials["BrokerID"]
+            field.InvestorID = self.credentials["UserID"]
+        items = self._query(self.api.ReqQryTradingAccount, field, lambda data: to_dict(data, ACCOUNT_FIELDS))
+        if not items:
+            raise CTPError(-1, "资金账户查询结果为空")
+        return items[0]
+
+    def positions(self) -> List[Dict]:
+        """查询持仓；上期所和能源中心的今仓、昨仓分两条记录返回，按合约和方向合并"""
+        self.require_login()
+        field = tdapi.CThostFtdcQryInvestorPositionField()
+        with self.lock:
+            field.BrokerID = self.credentials["BrokerID"]
+            field.InvestorID = self.credentials["UserID"]
+        items = self._query(self.api.ReqQryInvestorPosition, field, lambda data: to_dict(data, POSITION_FIELDS))
+
+        merged: Dict[Tuple, Dict] = {}
+        for item in items:
+            key = (item["ExchangeID"], item["InstrumentID"], item["PosiDirection"])
+            if key not in merged:
+                merged[key] = dict(item)
+                continue
+            for name in ("Position", "TodayPosition", "OpenCost", "PositionProfit", "CloseProfit"):
+                merged[key][name] += item[name]
+        with self.lock:
+            for (exchange_id, instrument_id, _), item in merged.items():
+                item["VolumeMultiple"] = self.multipliers.get(f"{exchange_id}:{instrument_id}", 1)
+        return [item for item in merged.values() if item["Position"] or item["CloseProfit"]]
+
+    def quote(self, instrument_id: str) -> Dict:
+        self.require_login()
+        field = tdapi.CThostFtdcQryDepthMarketDataField()
+        field.InstrumentID = instrument_id
+        items = self._query(self.api.ReqQryDepthMarketData, field, lambda data: to_dict(data, QUOTE_FIELDS))
+        if not items:
+            raise CTPError(-1, f"没有合约 {instrument_id} 的行情", 404)
+        return items[0]
+
+    def status(self) -> Dict:
+        with self.lock:
+            return {"Connected": self.connected, "LoggedIn": self.logged_in, "Message": self.message}
+
+
+def _send_error(code: int) -> str:
+    """请求函数返回值的含义"""
+    return {
+        -1: "网络连接失败",
+        -2: "未处理请求超过许可数",
+        -3: "每秒发送请求数超过许可数",
+    }.get(code, f"发送请求失败（返回值 {code}）")
+
+
+gateway = Gateway(TD_FRONT, FLOW_DIR)
+app = FastAPI(title="CTP Gateway", description="把 CTP 交易接口封装为 HTTP JSON 接口", version="1.0.0")
+
+
+@app.on_event("startup")
+async def startup():
+    if not TD_FRONT:
+        raise RuntimeError("未设置 CTP_TD_FRONT（交易前置地址）")
+    gateway.start()
+
+
+@app.exception_handler(CTPError)
+async def ctp_error_handler(request: Request, e: CTPError):
+    return JSONResponse(status_code=e.status, content={"ErrorID": e.error_id, "ErrorMsg": e.error_msg})
+
+
+# 同步处理函数由 FastAPI 在线程池中执行，不阻塞事件循环
+@app.post("/login")
+def login(body: Dict):
+    return gateway.login(body)
+
+
+@app.get("/status")
+def status():
+    return gateway.status()
+
+
+@app.post("/orders")
+def insert_order(body: Dict):
+    return gateway.insert_order(body)
+
+
+@app.post("/orders/cancel")
+def cancel_order(body: Dict):
+    return gateway.cancel_order(body)
+
+
+@app.get("/orders")
+def list_orders():
+    return gateway.list_orders()
+
+
+@app.get("/trades")
+def list_trades():
+    return gateway.list_trades()
+
+
+@app.get("/account")
+def account():
+    return gateway.account()
+
+
+@app.get("/positions")
+def positions():
+    return gateway.positions()
+
+
+@app.get("/quotes/{instrument_id}")
+def quote(instrument_id: str):
+    return gateway.quote(instrument_id)
+
+
+if __name__ == "__main__":
+    # 网关接收登录密码，只监听本机
+    uvicorn.run(app, host=HOST, port=PORT)
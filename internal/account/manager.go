@@ -5,10 +5,15 @@ import (
 	"encoding/hex"
 	"fmt"
 	"log"
+	"strings"
 	"sync"
 	"time"
 
 	"agent-quant-system/internal/config"
+	"agent-quant-system/internal/money"
+	"agent-quant-system/internal/secrets"
+
+	"github.com/shopspring/decimal"
 )
 
 // AccountManager 账户管理器
@@ -16,57 +21,79 @@ type AccountManager struct {
 	config   *config.Config
 	accounts map[string]*Account
 	mutex    sync.RWMutex
+
+	resolver *secrets.Resolver // 解析配置中的凭证引用
 }
 
-// Account 账户信息
+// Account 账户信息，凭证序列化时只输出占位符
 type Account struct {
 	Name        string              `json:"name"`
 	BrokerType  string              `json:"broker_type"`
-	APIKey      string              `json:"api_key"`
-	APISecret   string              `json:"api_secret"`
+	Currency    string              `json:"currency"`
+	APIKey      secrets.Secret      `json:"api_key"`
+	APISecret   secrets.Secret      `json:"api_secret"`
 	Credentials AccountCredentials  `json:"credentials"`
-	Balance     float64             `json:"balance"`
+	Balance     decimal.Decimal     `json:"balance"`
 	Positions   map[string]Position `json:"positions"`
 	IsActive    bool                `json:"is_active"`
 	LastUpdate  time.Time           `json:"last_update"`
+
+	CredentialError string `json:"credential_error,omitempty"` // 最近一次解析凭证失败的原因
+
+	// 保证金账户的最新状态，未使用杠杆的账户为空
+	Margin *MarginInfo `json:"margin,omitempty"`
+}
+
+// MarginInfo 保证金账户状态
+type MarginInfo struct {
+	Leverage    float64         `json:"leverage"`
+	Borrowed    decimal.Decimal `json:"borrowed"`     // 借入资金
+	BuyingPower decimal.Decimal `json:"buying_power"` // 剩余购买力
+	Utilization float64         `json:"utilization"`  // 保证金使用率：持仓市值 / (权益 * 杠杆)
+	Interest    decimal.Decimal `json:"interest"`     // 累计融资利息
+	MarginCalls int             `json:"margin_calls"` // 强制平仓次数
 }
 
 // AccountCredentials 账户凭证
 type AccountCredentials struct {
-	APIKey     string `json:"api_key"`
-	APISecret  string `json:"api_secret"`
-	BrokerType string `json:"broker_type"`
+	APIKey     secrets.Secret `json:"api_key"`
+	APISecret  secrets.Secret `json:"api_secret"`
+	BrokerType string         `json:"broker_type"`
 	// 其他特定经纪商的凭证
-	Passphrase string `json:"passphrase,omitempty"` // 用于某些交易所
-	Sandbox    bool   `json:"sandbox,omitempty"`    // 是否使用沙盒环境
+	Passphrase secrets.Secret `json:"passphrase,omitempty"` // 用于某些交易所
+	Sandbox    bool           `json:"sandbox,omitempty"`    // 是否使用沙盒环境
 }
 
 // Position 持仓信息
 type Position struct {
-	Symbol       string    `json:"symbol"`
-	Quantity     float64   `json:"quantity"`
-	AvgPrice     float64   `json:"avg_price"`
-	MarketValue  float64   `json:"market_value"`
-	UnrealizedPL float64   `json:"unrealized_pl"`
-	RealizedPL   float64   `json:"realized_pl"`
-	OpenTime     time.Time `json:"open_time"`
-	LastUpdate   time.Time `json:"last_update"`
+	Symbol       string          `json:"symbol"`
+	Quantity     decimal.Decimal `json:"quantity"`
+	AvgPrice     decimal.Decimal `json:"avg_price"`
+	MarketValue  decimal.Decimal `json:"market_value"`
+	UnrealizedPL decimal.Decimal `json:"unrealized_pl"`
+	RealizedPL   decimal.Decimal `json:"realized_pl"`
+	OpenTime     time.Time       `json:"open_time"`
+	LastUpdate   time.Time       `json:"last_update"`
 }
 
 // BalanceInfo 余额信息
 type BalanceInfo struct {
-	TotalBalance     float64   `json:"total_balance"`
-	AvailableBalance float64   `json:"available_balance"`
-	FrozenBalance    float64   `json:"frozen_balance"`
-	Currency         string    `json:"currency"`
-	LastUpdate       time.Time `json:"last_update"`
+	TotalBalance     decimal.Decimal `json:"total_balance"`
+	AvailableBalance decimal.Decimal `json:"available_balance"`
+	FrozenBalance    decimal.Decimal `json:"frozen_balance"`
+	Currency         string          `json:"currency"`
+	LastUpdate       time.Time       `json:"last_update"`
 }
 
-// NewAccountManager 创建账户管理器
-func NewAccountManager(cfg *config.Config) *AccountManager {
+// NewAccountManager 创建账户管理器，凭证引用通过 resolver 解析，为 nil 时按 secrets 配置创建
+func NewAccountManager(cfg *config.Config, resolver *secrets.Resolver) *AccountManager {
+	if resolver == nil {
+		resolver = secrets.NewResolver(cfg.Secrets)
+	}
 	manager := &AccountManager{
 		config:   cfg,
 		accounts: make(map[string]*Account),
+		resolver: resolver,
 	}
 
 	// 初始化账户
@@ -80,21 +107,26 @@ func (am *AccountManager) initializeAccounts() {
 	log.Printf("初始化账户管理器")
 
 	for name, accountConfig := range am.config.Accounts {
+		currency := accountConfig.Currency
+		if currency == "" {
+			currency = "USD"
+		}
+
 		account := &Account{
 			Name:       name,
 			BrokerType: accountConfig.BrokerType,
-			APIKey:     accountConfig.APIKey,
-			APISecret:  accountConfig.APISecret,
-			Credentials: AccountCredentials{
-				APIKey:     accountConfig.APIKey,
-				APISecret:  accountConfig.APISecret,
-				BrokerType: accountConfig.BrokerType,
-			},
-			Balance:    100000.0, // 模拟初始余额
+			Currency:   currency,
+			Balance:    money.FromFloat(accountConfig.StartingBalance()),
 			Positions:  make(map[string]Position),
 			IsActive:   true,
 			LastUpdate: time.Now(),
 		}
+		am.setCredentials(account, accountConfig)
+		if account.CredentialError != "" {
+			log.Printf("解析账户 '%s' 的凭证失败: %s", name, account.CredentialError)
+		} else if accountConfig.BrokerType != "ibkr" && accountConfig.APISecret != "" && !am.resolver.IsReference(accountConfig.APISecret) {
+			log.Printf("账户 '%s' 的 api_secret 以明文写在配置文件中，建议改为 env:、keyring:、vault: 或 aws: 引用", name)
+		}
 
 		am.accounts[name] = account
 		log.Printf("已初始化账户: %s (%s)", name, accountConfig.BrokerType)
@@ -114,14 +146,53 @@ func (am *AccountManager) GetAccount(name string) (*Account, error) {
 	return account, nil
 }
 
-// GetAccountCredentials 获取账户凭证
+// setCredentials 解析配置中的凭证写入账户，调用方需持有写锁或在初始化阶段调用
+func (am *AccountManager) setCredentials(account *Account, accountConfig config.AccountConfig) {
+	credentials := AccountCredentials{BrokerType: accountConfig.BrokerType}
+	var failures []string
+	for _, item := range []struct {
+		field  string
+		value  string
+		target *secrets.Secret
+	}{
+		{"api_key", accountConfig.APIKey, &credentials.APIKey},
+		{"api_secret", accountConfig.APISecret, &credentials.APISecret},
+	} {
+		secret, err := am.resolver.Resolve(item.value)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", item.field, err))
+			continue
+		}
+		*item.target = secret
+	}
+
+	account.Credentials = credentials
+	account.APIKey = credentials.APIKey
+	account.APISecret = credentials.APISecret
+	account.CredentialError = strings.Join(failures, "; ")
+}
+
+// GetAccountCredentials 获取账户凭证；每次按配置重新解析（在 secrets.cache_ttl 内使用缓存），密钥轮换后无需重启
 func (am *AccountManager) GetAccountCredentials(name string) (*AccountCredentials, error) {
-	account, err := am.GetAccount(name)
+	accountConfig, err := am.config.GetAccountConfig(name)
 	if err != nil {
 		return nil, err
 	}
 
-	return &account.Credentials, nil
+	am.mutex.Lock()
+	defer am.mutex.Unlock()
+
+	account, exists := am.accounts[name]
+	if !exists {
+		return nil, fmt.Errorf("账户 '%s' 不存在", name)
+	}
+	am.setCredentials(account, *accountConfig)
+	if account.CredentialError != "" {
+		return nil, fmt.Errorf("解析账户 '%s' 的凭证失败: %s", name, account.CredentialError)
+	}
+
+	credentials := account.Credentials
+	return &credentials, nil
 }
 
 // GetAllAccounts 获取所有账户
@@ -139,7 +210,7 @@ func (am *AccountManager) GetAllAccounts() map[string]*Account {
 }
 
 // UpdateAccountBalance 更新账户余额
-func (am *AccountManager) UpdateAccountBalance(name string, balance float64) error {
+func (am *AccountManager) UpdateAccountBalance(name string, balance decimal.Decimal) error {
 	am.mutex.Lock()
 	defer am.mutex.Unlock()
 
@@ -151,12 +222,46 @@ func (am *AccountManager) UpdateAccountBalance(name string, balance float64) err
 	account.Balance = balance
 	account.LastUpdate = time.Now()
 
-	log.Printf("已更新账户 '%s' 余额: %.2f", name, balance)
+	log.Printf("已更新账户 '%s' 余额: %s", name, balance)
+	return nil
+}
+
+// UpdateAccountMargin 更新账户的保证金状态
+func (am *AccountManager) UpdateAccountMargin(name string, margin MarginInfo) error {
+	am.mutex.Lock()
+	defer am.mutex.Unlock()
+
+	account, exists := am.accounts[name]
+	if !exists {
+		return fmt.Errorf("账户 '%s' 不存在", name)
+	}
+
+	account.Margin = &margin
+	account.LastUpdate = time.Now()
+	return nil
+}
+
+// ResetAccount 重置账户余额并清空持仓
+func (am *AccountManager) ResetAccount(name string, balance decimal.Decimal) error {
+	am.mutex.Lock()
+	defer am.mutex.Unlock()
+
+	account, exists := am.accounts[name]
+	if !exists {
+		return fmt.Errorf("账户 '%s' 不存在", name)
+	}
+
+	account.Balance = balance
+	account.Positions = make(map[string]Position)
+	account.Margin = nil
+	account.LastUpdate = time.Now()
+
+	log.Printf("已重置账户 '%s': 余额=%s", name, balance)
 	return nil
 }
 
 // AddPosition 添加持仓
-func (am *AccountManager) AddPosition(accountName, symbol string, quantity, avgPrice float64) error {
+func (am *AccountManager) AddPosition(accountName, symbol string, quantity, avgPrice decimal.Decimal) error {
 	am.mutex.Lock()
 	defer am.mutex.Unlock()
 
@@ -169,9 +274,9 @@ func (am *AccountManager) AddPosition(accountName, symbol string, quantity, avgP
 		Symbol:       symbol,
 		Quantity:     quantity,
 		AvgPrice:     avgPrice,
-		MarketValue:  quantity * avgPrice,
-		UnrealizedPL: 0.0,
-		RealizedPL:   0.0,
+		MarketValue:  quantity.Mul(avgPrice),
+		UnrealizedPL: decimal.Zero,
+		RealizedPL:   decimal.Zero,
 		OpenTime:     time.Now(),
 		LastUpdate:   time.Now(),
 	}
@@ -179,14 +284,14 @@ func (am *AccountManager) AddPosition(accountName, symbol string, quantity, avgP
 	account.Positions[symbol] = position
 	account.LastUpdate = time.Now()
 
-	log.Printf("已添加持仓: 账户=%s, 标的=%s, 数量=%.2f, 均价=%.2f",
+	log.Printf("已添加持仓: 账户=%s, 标的=%s, 数量=%s, 均价=%s",
 		accountName, symbol, quantity, avgPrice)
 
 	return nil
 }
 
 // UpdatePosition 更新持仓
-func (am *AccountManager) UpdatePosition(accountName, symbol string, quantity, avgPrice float64) error {
+func (am *AccountManager) UpdatePosition(accountName, symbol string, quantity, avgPrice decimal.Decimal) error {
 	am.mutex.Lock()
 	defer am.mutex.Unlock()
 
@@ -202,13 +307,13 @@ func (am *AccountManager) UpdatePosition(accountName, symbol string, quantity, a
 
 	position.Quantity = quantity
 	position.AvgPrice = avgPrice
-	position.MarketValue = quantity * avgPrice
+	position.MarketValue = quantity.Mul(avgPrice)
 	position.LastUpdate = time.Now()
 
 	account.Positions[symbol] = position
 	account.LastUpdate = time.Now()
 
-	log.Printf("已更新持仓: 账户=%s, 标的=%s, 数量=%.2f, 均价=%.2f",
+	log.Printf("已更新持仓: 账户=%s, 标的=%s, 数量=%s, 均价=%s",
 		accountName, symbol, quantity, avgPrice)
 
 	return nil
@@ -280,16 +385,16 @@ func (am *AccountManager) GetBalanceInfo(accountName string) (*BalanceInfo, erro
 	}
 
 	// 计算持仓市值
-	totalPositionValue := 0.0
+	totalPositionValue := decimal.Zero
 	for _, position := range account.Positions {
-		totalPositionValue += position.MarketValue
+		totalPositionValue = totalPositionValue.Add(position.MarketValue)
 	}
 
 	balanceInfo := &BalanceInfo{
 		TotalBalance:     account.Balance,
-		AvailableBalance: account.Balance - totalPositionValue,
-		FrozenBalance:    0.0, // 模拟冻结余额
-		Currency:         "USD",
+		AvailableBalance: account.Balance.Sub(totalPositionValue),
+		FrozenBalance:    decimal.Zero, // 模拟冻结余额
+		Currency:         account.Currency,
 		LastUpdate:       time.Now(),
 	}
 
@@ -303,14 +408,21 @@ func (am *AccountManager) ValidateAccountCredentials(accountName string) error {
 		return err
 	}
 
-	if account.APIKey == "" || account.APISecret == "" {
-		return fmt.Errorf("账户 '%s' 的API凭证不完整", accountName)
-	}
-
 	if account.BrokerType == "" {
 		return fmt.Errorf("账户 '%s' 的经纪商类型未设置", accountName)
 	}
 
+	// 盈透证券通过网关会话认证，不使用API密钥；FIX 会话的用户名和密码可选
+	if account.BrokerType != "ibkr" && account.BrokerType != "fix" {
+		credentials, err := am.GetAccountCredentials(accountName)
+		if err != nil {
+			return err
+		}
+		if credentials.APIKey.IsEmpty() || credentials.APISecret.IsEmpty() {
+			return fmt.Errorf("账户 '%s' 的API凭证不完整", accountName)
+		}
+	}
+
 	log.Printf("账户 '%s' 凭证验证通过", accountName)
 	return nil
 }
@@ -323,7 +435,7 @@ func (am *AccountManager) GetAccountHash(accountName string) (string, error) {
 	}
 
 	// 使用API Key生成哈希
-	hash := sha256.Sum256([]byte(account.APIKey))
+	hash := sha256.Sum256([]byte(account.APIKey.Reveal()))
 	return hex.EncodeToString(hash[:]), nil
 }
 
@@ -359,12 +471,20 @@ func (am *AccountManager) GetAccountStatus(accountName string) (*AccountStatus,
 	status := &AccountStatus{
 		Name:             account.Name,
 		BrokerType:       account.BrokerType,
+		Currency:         account.Currency,
 		IsActive:         account.IsActive,
-		Balance:          account.Balance,
-		AvailableBalance: balanceInfo.AvailableBalance,
+		Balance:          money.Float(account.Balance),
+		AvailableBalance: money.Float(balanceInfo.AvailableBalance),
 		PositionCount:    len(account.Positions),
 		LastUpdate:       account.LastUpdate,
 	}
+	if margin := account.Margin; margin != nil {
+		status.Leverage = margin.Leverage
+		status.Borrowed = money.Float(margin.Borrowed)
+		status.BuyingPower = money.Float(margin.BuyingPower)
+		status.MarginUtilization = margin.Utilization
+		status.MarginCalls = margin.MarginCalls
+	}
 
 	return status, nil
 }
@@ -373,11 +493,20 @@ func (am *AccountManager) GetAccountStatus(accountName string) (*AccountStatus,
 type AccountStatus struct {
 	Name             string    `json:"name"`
 	BrokerType       string    `json:"broker_type"`
+	Currency         string    `json:"currency"`
 	IsActive         bool      `json:"is_active"`
 	Balance          float64   `json:"balance"`
+	ReportingBalance float64   `json:"reporting_balance"` // 折算为报告币种的余额
 	AvailableBalance float64   `json:"available_balance"`
 	PositionCount    int       `json:"position_count"`
 	LastUpdate       time.Time `json:"last_update"`
+
+	// 保证金账户的杠杆、借入资金、剩余购买力、保证金使用率和强制平仓次数，未使用杠杆时为0
+	Leverage          float64 `json:"leverage,omitempty"`
+	Borrowed          float64 `json:"borrowed,omitempty"`
+	BuyingPower       float64 `json:"buying_power,omitempty"`
+	MarginUtilization float64 `json:"margin_utilization,omitempty"`
+	MarginCalls       int     `json:"margin_calls,omitempty"`
 }
 
 // GetAllAccountStatuses 获取所有账户状态
@@ -409,16 +538,16 @@ func (am *AccountManager) RefreshAccountData(accountName string) error {
 	log.Printf("正在刷新账户 '%s' 的数据", accountName)
 
 	// 更新余额（模拟）
-	account.Balance += float64(time.Now().Unix() % 100) // 模拟余额变化
+	account.Balance = account.Balance.Add(decimal.NewFromInt(time.Now().Unix() % 100)) // 模拟余额变化
 	account.LastUpdate = time.Now()
 
 	// 更新持仓市值（模拟）
 	for symbol, position := range account.Positions {
 		// 模拟价格变化
-		priceChange := (float64(time.Now().Unix()%100) - 50) / 1000.0
-		newPrice := position.AvgPrice * (1 + priceChange)
-		position.MarketValue = position.Quantity * newPrice
-		position.UnrealizedPL = position.MarketValue - (position.Quantity * position.AvgPrice)
+		priceChange := decimal.New(time.Now().Unix()%100-50, -3)
+		newPrice := position.AvgPrice.Mul(decimal.NewFromInt(1).Add(priceChange))
+		position.MarketValue = position.Quantity.Mul(newPrice)
+		position.UnrealizedPL = position.MarketValue.Sub(position.Quantity.Mul(position.AvgPrice))
 		position.LastUpdate = time.Now()
 		account.Positions[symbol] = position
 	}
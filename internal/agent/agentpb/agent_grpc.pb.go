@@ -0,0 +1,220 @@
+// Go 交易引擎与 Python Agent 服务之间的 gRPC 协议。
+// 修改后重新生成 Go 代码（internal/agent/agentpb）；Python 服务运行时直接加载本文件，无需生成代码。
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             v4.24.4
+// source: agent.proto
+
+package agentpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	AgentService_AnalyzeNews_FullMethodName       = "/agent.v1.AgentService/AnalyzeNews"
+	AgentService_AnalyzeNewsStream_FullMethodName = "/agent.v1.AgentService/AnalyzeNewsStream"
+	AgentService_Health_FullMethodName            = "/agent.v1.AgentService/Health"
+)
+
+// AgentServiceClient is the client API for AgentService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type AgentServiceClient interface {
+	// AnalyzeNews 分析新闻情绪
+	AnalyzeNews(ctx context.Context, in *NewsAnalysisRequest, opts ...grpc.CallOption) (*NewsAnalysisResponse, error)
+	// AnalyzeNewsStream 耗时较长的分析：服务端先推送进度事件保持连接活跃，最后一个事件携带分析结果
+	AnalyzeNewsStream(ctx context.Context, in *NewsAnalysisRequest, opts ...grpc.CallOption) (AgentService_AnalyzeNewsStreamClient, error)
+	// Health 健康检查
+	Health(ctx context.Context, in *HealthRequest, opts ...grpc.CallOption) (*HealthResponse, error)
+}
+
+type agentServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAgentServiceClient(cc grpc.ClientConnInterface) AgentServiceClient {
+	return &agentServiceClient{cc}
+}
+
+func (c *agentServiceClient) AnalyzeNews(ctx context.Context, in *NewsAnalysisRequest, opts ...grpc.CallOption) (*NewsAnalysisResponse, error) {
+	out := new(NewsAnalysisResponse)
+	err := c.cc.Invoke(ctx, AgentService_AnalyzeNews_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *agentServiceClient) AnalyzeNewsStream(ctx context.Context, in *NewsAnalysisRequest, opts ...grpc.CallOption) (AgentService_AnalyzeNewsStreamClient, error) {
+	stream, err := c.cc.NewStream(ctx, &AgentService_ServiceDesc.Streams[0], AgentService_AnalyzeNewsStream_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &agentServiceAnalyzeNewsStreamClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type AgentService_AnalyzeNewsStreamClient interface {
+	Recv() (*AnalysisEvent, error)
+	grpc.ClientStream
+}
+
+type agentServiceAnalyzeNewsStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *agentServiceAnalyzeNewsStreamClient) Recv() (*AnalysisEvent, error) {
+	m := new(AnalysisEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *agentServiceClient) Health(ctx context.Context, in *HealthRequest, opts ...grpc.CallOption) (*HealthResponse, error) {
+	out := new(HealthResponse)
+	err := c.cc.Invoke(ctx, AgentService_Health_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AgentServiceServer is the server API for AgentService service.
+// All implementations must embed UnimplementedAgentServiceServer
+// for forward compatibility
+type AgentServiceServer interface {
+	// AnalyzeNews 分析新闻情绪
+	AnalyzeNews(context.Context, *NewsAnalysisRequest) (*NewsAnalysisResponse, error)
+	// AnalyzeNewsStream 耗时较长的分析：服务端先推送进度事件保持连接活跃，最后一个事件携带分析结果
+	AnalyzeNewsStream(*NewsAnalysisRequest, AgentService_AnalyzeNewsStreamServer) error
+	// Health 健康检查
+	Health(context.Context, *HealthRequest) (*HealthResponse, error)
+	mustEmbedUnimplementedAgentServiceServer()
+}
+
+// UnimplementedAgentServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedAgentServiceServer struct {
+}
+
+func (UnimplementedAgentServiceServer) AnalyzeNews(context.Context, *NewsAnalysisRequest) (*NewsAnalysisResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AnalyzeNews not implemented")
+}
+func (UnimplementedAgentServiceServer) AnalyzeNewsStream(*NewsAnalysisRequest, AgentService_AnalyzeNewsStreamServer) error {
+	return status.Errorf(codes.Unimplemented, "method AnalyzeNewsStream not implemented")
+}
+func (UnimplementedAgentServiceServer) Health(context.Context, *HealthRequest) (*HealthResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Health not implemented")
+}
+func (UnimplementedAgentServiceServer) mustEmbedUnimplementedAgentServiceServer() {}
+
+// UnsafeAgentServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to AgentServiceServer will
+// result in compilation errors.
+type UnsafeAgentServiceServer interface {
+	mustEmbedUnimplementedAgentServiceServer()
+}
+
+func RegisterAgentServiceServer(s grpc.ServiceRegistrar, srv AgentServiceServer) {
+	s.RegisterService(&AgentService_ServiceDesc, srv)
+}
+
+func _AgentService_AnalyzeNews_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(NewsAnalysisRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AgentServiceServer).AnalyzeNews(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AgentService_AnalyzeNews_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AgentServiceServer).AnalyzeNews(ctx, req.(*NewsAnalysisRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AgentService_AnalyzeNewsStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(NewsAnalysisRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AgentServiceServer).AnalyzeNewsStream(m, &agentServiceAnalyzeNewsStreamServer{stream})
+}
+
+type AgentService_AnalyzeNewsStreamServer interface {
+	Send(*AnalysisEvent) error
+	grpc.ServerStream
+}
+
+type agentServiceAnalyzeNewsStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *agentServiceAnalyzeNewsStreamServer) Send(m *AnalysisEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _AgentService_Health_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HealthRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AgentServiceServer).Health(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AgentService_Health_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AgentServiceServer).Health(ctx, req.(*HealthRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// AgentService_ServiceDesc is the grpc.ServiceDesc for AgentService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var AgentService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "agent.v1.AgentService",
+	HandlerType: (*AgentServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "AnalyzeNews",
+			Handler:    _AgentService_AnalyzeNews_Handler,
+		},
+		{
+			MethodName: "Health",
+			Handler:    _AgentService_Health_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "AnalyzeNewsStream",
+			Handler:       _AgentService_AnalyzeNewsStream_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "agent.proto",
+}
@@ -13,19 +13,29 @@ type Client struct {
 	httpClient *resty.Client
 	baseURL    string
 	timeout    time.Duration
+	options    Options
+	breaker    *circuitBreaker
 }
 
 // NewClient 创建Agent客户端
 func NewClient(baseURL string) *Client {
+	return NewClientWithOptions(baseURL, DefaultOptions())
+}
+
+// NewClientWithOptions 按超时、重试、熔断和安全设置创建Agent客户端
+func NewClientWithOptions(baseURL string, options Options) *Client {
 	client := resty.New()
-	client.SetTimeout(30 * time.Second)
+	client.SetTimeout(options.Timeout)
 	client.SetHeader("Content-Type", "application/json")
 	client.SetHeader("Accept", "application/json")
+	options.Security.apply(client)
 
 	return &Client{
 		httpClient: client,
 		baseURL:    baseURL,
-		timeout:    30 * time.Second,
+		timeout:    options.Timeout,
+		options:    options,
+		breaker:    newCircuitBreaker(options.BreakerThreshold, options.BreakerCooldown),
 	}
 }
 
@@ -70,24 +80,42 @@ func (c *Client) AnalyzeNews(symbol string, newsItems []string) (*AnalysisRespon
 		NewsItems: newsItems,
 	}
 
-	// 发送请求
-	resp, err := c.httpClient.R().
-		SetBody(request).
-		SetResult(&NewsAnalysisResponse{}).
-		Post(c.baseURL + "/analyze")
-
-	if err != nil {
-		return nil, fmt.Errorf("发送请求失败: %w", err)
+	// 熔断时不发送请求
+	if err := c.breaker.allow(); err != nil {
+		return nil, err
 	}
 
-	if resp.StatusCode() != 200 {
-		return nil, fmt.Errorf("请求失败，状态码: %d, 响应: %s", resp.StatusCode(), resp.String())
-	}
+	// 发送请求，网络错误、超时和服务端错误按退避策略重试
+	var response *NewsAnalysisResponse
+	err := c.withRetry("新闻分析请求", func() error {
+		resp, err := c.httpClient.R().
+			SetBody(request).
+			SetResult(&NewsAnalysisResponse{}).
+			Post(c.baseURL + "/analyze")
 
-	// 转换响应
-	response, ok := resp.Result().(*NewsAnalysisResponse)
-	if !ok {
-		return nil, fmt.Errorf("响应解析失败")
+		if err != nil {
+			return &retryError{fmt.Errorf("发送请求失败: %w", err)}
+		}
+
+		if resp.StatusCode() != 200 {
+			err := fmt.Errorf("请求失败，状态码: %d, 响应: %s", resp.StatusCode(), resp.String())
+			if retryableStatus(resp.StatusCode()) {
+				return &retryError{err}
+			}
+			return err
+		}
+
+		// 转换响应
+		var ok bool
+		response, ok = resp.Result().(*NewsAnalysisResponse)
+		if !ok {
+			return fmt.Errorf("响应解析失败")
+		}
+		return nil
+	})
+	c.breaker.record(err)
+	if err != nil {
+		return nil, err
 	}
 
 	// 转换为内部格式
@@ -206,6 +234,7 @@ func (c *Client) HealthCheck() error {
 // SetTimeout 设置超时时间
 func (c *Client) SetTimeout(timeout time.Duration) {
 	c.timeout = timeout
+	c.options.Timeout = timeout
 	c.httpClient.SetTimeout(timeout)
 }
 
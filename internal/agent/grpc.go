@@ -0,0 +1,385 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"agent-quant-system/internal/agent/agentpb"
+)
+
+// GRPCOptions 通过 gRPC 调用Python Agent服务的设置
+type GRPCOptions struct {
+	Address string // 服务地址，如 localhost:50051
+
+	// Stream 使用流式接口：服务端分析期间持续推送进度，客户端按事件间隔而不是整体耗时判断超时，
+	// 适合耗时较长的分析
+	Stream bool
+}
+
+// GRPCClient 通过 gRPC 调用Python Agent服务，协议定义见 proto/agent.proto
+type GRPCClient struct {
+	grpc    GRPCOptions
+	options Options
+	breaker *circuitBreaker
+
+	conn   *grpc.ClientConn
+	client agentpb.AgentServiceClient
+
+	history map[string][]*AnalysisResponse // 最近的分析结果，按时间升序
+	mutex   sync.Mutex
+}
+
+// NewGRPCClient 创建 gRPC 客户端，连接在首次请求时建立，options 为超时、重试和熔断设置
+func NewGRPCClient(grpcOptions GRPCOptions, options Options) (*GRPCClient, error) {
+	if grpcOptions.Address == "" {
+		return nil, fmt.Errorf("未配置 gRPC 服务地址")
+	}
+	client := &GRPCClient{
+		grpc:    grpcOptions,
+		options: options,
+		breaker: newCircuitBreaker(options.BreakerThreshold, options.BreakerCooldown),
+		history: make(map[string][]*AnalysisResponse),
+	}
+	if err := client.dial(grpcOptions.Address); err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+// dial 连接服务地址并替换原有连接，配置了TLS时使用加密连接
+func (gc *GRPCClient) dial(address string) error {
+	transport := insecure.NewCredentials()
+	if gc.options.Security.TLS != nil {
+		transport = credentials.NewTLS(gc.options.Security.TLS)
+	}
+	conn, err := grpc.Dial(address, grpc.WithTransportCredentials(transport),
+		grpc.WithDefaultCallOptions(grpc.ForceCodec(signedCodec{})))
+	if err != nil {
+		return fmt.Errorf("连接 gRPC 服务失败: %w", err)
+	}
+
+	gc.mutex.Lock()
+	old := gc.conn
+	gc.conn = conn
+	gc.client = agentpb.NewAgentServiceClient(conn)
+	gc.grpc.Address = address
+	gc.mutex.Unlock()
+
+	if old != nil {
+		old.Close()
+	}
+	return nil
+}
+
+// stub 当前连接的服务客户端和超时设置
+func (gc *GRPCClient) stub() (agentpb.AgentServiceClient, time.Duration) {
+	gc.mutex.Lock()
+	defer gc.mutex.Unlock()
+	return gc.client, gc.options.Timeout
+}
+
+// signedCodec 按确定性序列化发送请求消息：签名时对同一消息序列化得到的字节与实际发送的字节相同，
+// 服务端按收到的原始字节校验签名
+type signedCodec struct{}
+
+// Marshal 确定性序列化
+func (signedCodec) Marshal(v any) ([]byte, error) {
+	message, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("不支持的消息类型 %T", v)
+	}
+	return proto.MarshalOptions{Deterministic: true}.Marshal(message)
+}
+
+// Unmarshal 反序列化响应消息
+func (signedCodec) Unmarshal(data []byte, v any) error {
+	message, ok := v.(proto.Message)
+	if !ok {
+		return fmt.Errorf("不支持的消息类型 %T", v)
+	}
+	return proto.Unmarshal(data, message)
+}
+
+// Name 与默认编码相同，服务端按 application/grpc+proto 处理
+func (signedCodec) Name() string {
+	return "proto"
+}
+
+// authorize 为调用附加认证和签名 metadata，签名覆盖实际发送的请求消息字节
+func (gc *GRPCClient) authorize(ctx context.Context, method string, request proto.Message) (context.Context, error) {
+	if gc.options.Security.SigningSecret == "" {
+		return gc.options.Security.outgoing(ctx, method, nil), nil
+	}
+	body, err := signedCodec{}.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("序列化请求失败: %w", err)
+	}
+	return gc.options.Security.outgoing(ctx, method, body), nil
+}
+
+// AnalyzeNews 通过 gRPC 分析新闻情绪
+func (gc *GRPCClient) AnalyzeNews(symbol string, newsItems []string) (*AnalysisResponse, error) {
+	log.Printf("开始分析新闻(gRPC): 标的=%s, 新闻数量=%d", symbol, len(newsItems))
+
+	// 熔断时不发送请求
+	if err := gc.breaker.allow(); err != nil {
+		return nil, err
+	}
+
+	request := &agentpb.NewsAnalysisRequest{Symbol: symbol, NewsItems: newsItems}
+	var result *agentpb.NewsAnalysisResponse
+	err := retry(gc.options, "gRPC分析请求", func() error {
+		var err error
+		if gc.grpc.Stream {
+			result, err = gc.analyzeStream(request)
+		} else {
+			result, err = gc.analyze(request)
+		}
+		return err
+	})
+	gc.breaker.record(err)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := fromProto(symbol, result)
+	if err != nil {
+		return nil, fmt.Errorf("解析分析结果失败: %w", err)
+	}
+	gc.remember(response)
+
+	log.Printf("新闻分析完成: 标的=%s, 情绪=%s, 置信度=%.2f",
+		symbol, response.Sentiment, response.ConfidenceScore)
+	return response, nil
+}
+
+// analyze 发送一次分析请求
+func (gc *GRPCClient) analyze(request *agentpb.NewsAnalysisRequest) (*agentpb.NewsAnalysisResponse, error) {
+	client, timeout := gc.stub()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	ctx, err := gc.authorize(ctx, agentpb.AgentService_AnalyzeNews_FullMethodName, request)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := client.AnalyzeNews(ctx, request)
+	if err != nil {
+		return nil, grpcError("分析请求失败", err)
+	}
+	return result, nil
+}
+
+// analyzeStream 发送一次流式分析请求：两个事件之间超过单次请求超时即失败，整体耗时不超过总耗时上限
+func (gc *GRPCClient) analyzeStream(request *agentpb.NewsAnalysisRequest) (*agentpb.NewsAnalysisResponse, error) {
+	client, timeout := gc.stub()
+	ctx, abort := context.WithCancelCause(context.Background())
+	defer abort(nil)
+	if gc.options.TotalTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, gc.options.TotalTimeout)
+		defer cancel()
+	}
+	ctx, err := gc.authorize(ctx, agentpb.AgentService_AnalyzeNewsStream_FullMethodName, request)
+	if err != nil {
+		return nil, err
+	}
+
+	idle := &retryError{fmt.Errorf("流式分析超过 %v 没有新的事件", timeout)}
+	timer := time.AfterFunc(timeout, func() { abort(idle) })
+	defer timer.Stop()
+
+	stream, err := client.AnalyzeNewsStream(ctx, request)
+	if err != nil {
+		return nil, grpcError("流式分析请求失败", err)
+	}
+	for {
+		event, err := stream.Recv()
+		if err == io.EOF {
+			return nil, fmt.Errorf("流式分析结束但没有返回结果")
+		}
+		if err != nil {
+			if context.Cause(ctx) == error(idle) {
+				return nil, idle
+			}
+			return nil, grpcError("流式分析失败", err)
+		}
+		timer.Reset(timeout)
+
+		switch e := event.Event.(type) {
+		case *agentpb.AnalysisEvent_Progress:
+			log.Printf("%s 分析进度: %s %.0f%% %s", request.Symbol, e.Progress.Stage, e.Progress.Fraction*100, e.Progress.Message)
+		case *agentpb.AnalysisEvent_Result:
+			return e.Result, nil
+		}
+	}
+}
+
+// grpcError 包装调用错误，连接不可用、超时、限流和中止按可重试处理
+func grpcError(message string, err error) error {
+	wrapped := fmt.Errorf("%s: %w", message, err)
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Aborted:
+		return &retryError{wrapped}
+	}
+	return wrapped
+}
+
+// fromProto 转换分析结果，服务端未返回分析ID或时间时由客户端生成
+func fromProto(symbol string, result *agentpb.NewsAnalysisResponse) (*AnalysisResponse, error) {
+	if result == nil {
+		return nil, errors.New("结果为空")
+	}
+	var sentiment string
+	switch result.Sentiment {
+	case agentpb.Sentiment_SENTIMENT_POSITIVE:
+		sentiment = "Positive"
+	case agentpb.Sentiment_SENTIMENT_NEGATIVE:
+		sentiment = "Negative"
+	case agentpb.Sentiment_SENTIMENT_NEUTRAL:
+		sentiment = "Neutral"
+	default:
+		return nil, fmt.Errorf("无效的情绪: %v", result.Sentiment)
+	}
+
+	response := &AnalysisResponse{
+		Symbol:          result.Symbol,
+		Sentiment:       sentiment,
+		Reason:          result.Reason,
+		ConfidenceScore: result.ConfidenceScore,
+		Timestamp:       time.Now(),
+		AnalysisID:      result.AnalysisId,
+	}
+	if response.Symbol == "" {
+		response.Symbol = symbol
+	}
+	if result.Timestamp != nil {
+		response.Timestamp = result.Timestamp.AsTime()
+	}
+	if response.AnalysisID == "" {
+		response.AnalysisID = fmt.Sprintf("GRPC_%d", time.Now().UnixNano())
+	}
+	return response, nil
+}
+
+// remember 保存分析结果，每个标的最多保留 llmHistoryLimit 条
+func (gc *GRPCClient) remember(response *AnalysisResponse) {
+	gc.mutex.Lock()
+	defer gc.mutex.Unlock()
+
+	history := append(gc.history[response.Symbol], response)
+	if len(history) > llmHistoryLimit {
+		history = history[len(history)-llmHistoryLimit:]
+	}
+	gc.history[response.Symbol] = history
+}
+
+// AnalyzeMarketSentiment 分析市场情绪
+func (gc *GRPCClient) AnalyzeMarketSentiment(symbol string, marketData map[string]interface{}) (*AnalysisResponse, error) {
+	return gc.AnalyzeNews(symbol, []string{
+		fmt.Sprintf("标的 %s 当前价格: %v, 成交量: %v", symbol, marketData["price"], marketData["volume"]),
+	})
+}
+
+// AnalyzeTechnicalIndicators 分析技术指标
+func (gc *GRPCClient) AnalyzeTechnicalIndicators(symbol string, indicators map[string]float64) (*AnalysisResponse, error) {
+	items := make([]string, 0, len(indicators))
+	for name, value := range indicators {
+		items = append(items, fmt.Sprintf("技术指标 %s = %.4f", name, value))
+	}
+	return gc.AnalyzeNews(symbol, items)
+}
+
+// BatchAnalyze 批量分析
+func (gc *GRPCClient) BatchAnalyze(symbols []string, newsItems []string) (map[string]*AnalysisResponse, error) {
+	results := make(map[string]*AnalysisResponse)
+	for _, symbol := range symbols {
+		response, err := gc.AnalyzeNews(symbol, newsItems)
+		if err != nil {
+			log.Printf("分析标的 %s 失败: %v", symbol, err)
+			continue
+		}
+		results[symbol] = response
+	}
+	return results, nil
+}
+
+// GetAnalysisHistory 获取本进程内最近的分析结果（从新到旧）
+func (gc *GRPCClient) GetAnalysisHistory(symbol string, limit int) ([]*AnalysisResponse, error) {
+	gc.mutex.Lock()
+	defer gc.mutex.Unlock()
+
+	history := gc.history[symbol]
+	result := make([]*AnalysisResponse, 0, len(history))
+	for i := len(history) - 1; i >= 0 && (limit <= 0 || len(result) < limit); i-- {
+		result = append(result, history[i])
+	}
+	return result, nil
+}
+
+// HealthCheck 检查Agent服务是否可用
+func (gc *GRPCClient) HealthCheck() error {
+	client, timeout := gc.stub()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	request := &agentpb.HealthRequest{}
+	ctx, err := gc.authorize(ctx, agentpb.AgentService_Health_FullMethodName, request)
+	if err != nil {
+		return err
+	}
+
+	health, err := client.Health(ctx, request)
+	if err != nil {
+		return fmt.Errorf("健康检查失败: %w", err)
+	}
+	if health.Status != "healthy" {
+		return fmt.Errorf("Agent服务状态异常: %s", health.Status)
+	}
+	return nil
+}
+
+// SetTimeout 设置超时时间
+func (gc *GRPCClient) SetTimeout(timeout time.Duration) {
+	gc.mutex.Lock()
+	defer gc.mutex.Unlock()
+	gc.options.Timeout = timeout
+}
+
+// SetBaseURL 设置服务地址并重新连接
+func (gc *GRPCClient) SetBaseURL(baseURL string) {
+	if err := gc.dial(baseURL); err != nil {
+		log.Printf("切换 gRPC 服务地址失败: %v", err)
+	}
+}
+
+// GetBaseURL 获取服务地址
+func (gc *GRPCClient) GetBaseURL() string {
+	gc.mutex.Lock()
+	defer gc.mutex.Unlock()
+	return gc.grpc.Address
+}
+
+// BreakerStatus 熔断器状态
+func (gc *GRPCClient) BreakerStatus() BreakerStatus {
+	return gc.breaker.status()
+}
+
+// Close 关闭连接
+func (gc *GRPCClient) Close() error {
+	gc.mutex.Lock()
+	defer gc.mutex.Unlock()
+	return gc.conn.Close()
+}
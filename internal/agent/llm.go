@@ -0,0 +1,404 @@
+package agent
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// LLM 服务商
+const (
+	LLMOpenAI    = "openai"    // OpenAI 及兼容 /chat/completions 接口的服务
+	LLMAnthropic = "anthropic" // Anthropic Messages 接口
+)
+
+// llmHistoryLimit 每个标的保留的分析记录数
+const llmHistoryLimit = 50
+
+// anthropicVersion Anthropic 接口版本
+const anthropicVersion = "2023-06-01"
+
+// DefaultPromptTemplate 默认的新闻情绪分析提示词，可使用 .Symbol 和 .News
+const DefaultPromptTemplate = `你是一名专业的证券分析师。请根据以下关于 {{.Symbol}} 的新闻判断其对短期股价的影响。
+
+新闻:
+{{range $i, $item := .News}}{{add $i 1}}. {{$item}}
+{{end}}
+只输出一个 JSON 对象，不要输出其他内容，格式如下:
+{"sentiment": "Positive|Negative|Neutral", "confidence_score": 0到1之间的小数, "reason": "简要理由"}`
+
+// llmSystemPrompt 系统提示词
+const llmSystemPrompt = "你是量化交易系统的新闻情绪分析模块，只输出要求的 JSON。"
+
+// LLMOptions 直接调用大模型接口的设置
+type LLMOptions struct {
+	Provider       string  // openai / anthropic
+	BaseURL        string  // 为空时使用服务商的默认地址
+	APIKey         string  // 服务商密钥
+	Model          string  // 模型名称
+	MaxTokens      int     // 最大输出长度
+	Temperature    float64 // 采样温度
+	PromptTemplate string  // 提示词模板（text/template），为空时使用 DefaultPromptTemplate
+
+	// SetupPromptTemplate 交易方案提示词模板，为空时使用 DefaultSetupPromptTemplate
+	SetupPromptTemplate string
+}
+
+// promptData 提示词模板的数据
+type promptData struct {
+	Symbol string
+	News   []string
+}
+
+// LLMClient 直接调用 OpenAI/Anthropic 兼容接口进行新闻情绪分析，无需 Python Agent 服务
+type LLMClient struct {
+	httpClient *resty.Client
+	llm        LLMOptions
+	prompt     *template.Template
+	options    Options
+	breaker    *circuitBreaker
+
+	setupPrompt *template.Template
+
+	history map[string][]*AnalysisResponse // 最近的分析结果，按时间升序
+	mutex   sync.Mutex
+}
+
+// NewLLMClient 创建大模型客户端，options 为超时、重试和熔断设置
+func NewLLMClient(llm LLMOptions, options Options) (*LLMClient, error) {
+	switch llm.Provider {
+	case LLMOpenAI:
+		if llm.BaseURL == "" {
+			llm.BaseURL = "https://api.openai.com/v1"
+		}
+	case LLMAnthropic:
+		if llm.BaseURL == "" {
+			llm.BaseURL = "https://api.anthropic.com"
+		}
+	default:
+		return nil, fmt.Errorf("不支持的大模型服务商: %s", llm.Provider)
+	}
+	if llm.APIKey == "" {
+		return nil, fmt.Errorf("未配置 %s 的API密钥", llm.Provider)
+	}
+	if llm.Model == "" {
+		return nil, fmt.Errorf("未配置模型名称")
+	}
+	if llm.MaxTokens <= 0 {
+		llm.MaxTokens = 512
+	}
+	llm.BaseURL = strings.TrimRight(llm.BaseURL, "/")
+
+	text := llm.PromptTemplate
+	if text == "" {
+		text = DefaultPromptTemplate
+	}
+	prompt, err := template.New("prompt").Funcs(template.FuncMap{
+		"add":  func(a, b int) int { return a + b },
+		"join": strings.Join,
+	}).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("解析提示词模板失败: %w", err)
+	}
+	setupPrompt, err := parseSetupPrompt(llm.SetupPromptTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("解析交易方案提示词模板失败: %w", err)
+	}
+
+	client := resty.New()
+	client.SetTimeout(options.Timeout)
+	client.SetHeader("Content-Type", "application/json")
+	client.SetHeader("Accept", "application/json")
+	if llm.Provider == LLMAnthropic {
+		client.SetHeader("x-api-key", llm.APIKey)
+		client.SetHeader("anthropic-version", anthropicVersion)
+	} else {
+		client.SetAuthToken(llm.APIKey)
+	}
+
+	return &LLMClient{
+		httpClient: client,
+		llm:        llm,
+		prompt:     prompt,
+		options:    options,
+		breaker:    newCircuitBreaker(options.BreakerThreshold, options.BreakerCooldown),
+		history:    make(map[string][]*AnalysisResponse),
+
+		setupPrompt: setupPrompt,
+	}, nil
+}
+
+// chatMessage 对话消息
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// openAIRequest /chat/completions 请求
+type openAIRequest struct {
+	Model       string        `json:"model"`
+	Messages    []chatMessage `json:"messages"`
+	MaxTokens   int           `json:"max_tokens"`
+	Temperature float64       `json:"temperature"`
+}
+
+// openAIResponse /chat/completions 响应
+type openAIResponse struct {
+	Choices []struct {
+		Message chatMessage `json:"message"`
+	} `json:"choices"`
+}
+
+// anthropicRequest /v1/messages 请求
+type anthropicRequest struct {
+	Model       string        `json:"model"`
+	System      string        `json:"system"`
+	Messages    []chatMessage `json:"messages"`
+	MaxTokens   int           `json:"max_tokens"`
+	Temperature float64       `json:"temperature"`
+}
+
+// anthropicResponse /v1/messages 响应
+type anthropicResponse struct {
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+}
+
+// AnalyzeNews 用大模型分析新闻情绪
+func (lc *LLMClient) AnalyzeNews(symbol string, newsItems []string) (*AnalysisResponse, error) {
+	log.Printf("开始分析新闻(%s): 标的=%s, 新闻数量=%d", lc.llm.Model, symbol, len(newsItems))
+
+	var prompt bytes.Buffer
+	if err := lc.prompt.Execute(&prompt, promptData{Symbol: symbol, News: newsItems}); err != nil {
+		return nil, fmt.Errorf("生成提示词失败: %w", err)
+	}
+
+	// 熔断时不发送请求
+	if err := lc.breaker.allow(); err != nil {
+		return nil, err
+	}
+
+	var content string
+	err := retry(lc.options, "大模型分析请求", func() error {
+		var err error
+		content, err = lc.complete(prompt.String())
+		return err
+	})
+	lc.breaker.record(err)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := parseSentiment(content)
+	if err != nil {
+		return nil, fmt.Errorf("解析模型输出失败: %w（输出: %s）", err, content)
+	}
+
+	response := &AnalysisResponse{
+		Symbol:          symbol,
+		Sentiment:       result.Sentiment,
+		Reason:          result.Reason,
+		ConfidenceScore: result.ConfidenceScore,
+		Timestamp:       time.Now(),
+		AnalysisID:      fmt.Sprintf("LLM_%d", time.Now().UnixNano()),
+	}
+	lc.remember(response)
+
+	log.Printf("新闻分析完成: 标的=%s, 情绪=%s, 置信度=%.2f",
+		symbol, response.Sentiment, response.ConfidenceScore)
+	return response, nil
+}
+
+// complete 发送一次对话请求，返回模型输出的文本
+func (lc *LLMClient) complete(prompt string) (string, error) {
+	messages := []chatMessage{{Role: "user", Content: prompt}}
+
+	request := lc.httpClient.R()
+	var url string
+	if lc.llm.Provider == LLMAnthropic {
+		url = lc.llm.BaseURL + "/v1/messages"
+		request.SetBody(anthropicRequest{
+			Model:       lc.llm.Model,
+			System:      llmSystemPrompt,
+			Messages:    messages,
+			MaxTokens:   lc.llm.MaxTokens,
+			Temperature: lc.llm.Temperature,
+		})
+	} else {
+		url = lc.llm.BaseURL + "/chat/completions"
+		request.SetBody(openAIRequest{
+			Model:       lc.llm.Model,
+			Messages:    append([]chatMessage{{Role: "system", Content: llmSystemPrompt}}, messages...),
+			MaxTokens:   lc.llm.MaxTokens,
+			Temperature: lc.llm.Temperature,
+		})
+	}
+
+	resp, err := request.Post(url)
+	if err != nil {
+		return "", &retryError{fmt.Errorf("发送请求失败: %w", err)}
+	}
+	if resp.StatusCode() != 200 {
+		err := fmt.Errorf("请求失败，状态码: %d, 响应: %s", resp.StatusCode(), resp.String())
+		if retryableStatus(resp.StatusCode()) {
+			return "", &retryError{err}
+		}
+		return "", err
+	}
+
+	// 兼容服务的 Content-Type 不一定是 JSON，直接解析响应体
+	if lc.llm.Provider == LLMAnthropic {
+		var result anthropicResponse
+		if err := json.Unmarshal(resp.Body(), &result); err != nil {
+			return "", fmt.Errorf("响应解析失败: %w", err)
+		}
+		for _, block := range result.Content {
+			if block.Type == "text" {
+				return block.Text, nil
+			}
+		}
+	} else {
+		var result openAIResponse
+		if err := json.Unmarshal(resp.Body(), &result); err != nil {
+			return "", fmt.Errorf("响应解析失败: %w", err)
+		}
+		if len(result.Choices) > 0 {
+			return result.Choices[0].Message.Content, nil
+		}
+	}
+	return "", fmt.Errorf("响应中没有模型输出")
+}
+
+// parseSentiment 从模型输出中提取情绪分析结果，兼容包裹在代码块或说明文字中的 JSON
+func parseSentiment(content string) (*NewsAnalysisResponse, error) {
+	start := strings.Index(content, "{")
+	end := strings.LastIndex(content, "}")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("没有找到 JSON 对象")
+	}
+
+	var result NewsAnalysisResponse
+	if err := json.Unmarshal([]byte(content[start:end+1]), &result); err != nil {
+		return nil, err
+	}
+
+	switch strings.ToLower(strings.TrimSpace(result.Sentiment)) {
+	case "positive", "bullish":
+		result.Sentiment = "Positive"
+	case "negative", "bearish":
+		result.Sentiment = "Negative"
+	case "neutral":
+		result.Sentiment = "Neutral"
+	default:
+		return nil, fmt.Errorf("无效的情绪: %q", result.Sentiment)
+	}
+	if result.ConfidenceScore < 0 {
+		result.ConfidenceScore = 0
+	}
+	if result.ConfidenceScore > 1 {
+		result.ConfidenceScore = 1
+	}
+	return &result, nil
+}
+
+// remember 保存分析结果，每个标的最多保留 llmHistoryLimit 条
+func (lc *LLMClient) remember(response *AnalysisResponse) {
+	lc.mutex.Lock()
+	defer lc.mutex.Unlock()
+
+	history := append(lc.history[response.Symbol], response)
+	if len(history) > llmHistoryLimit {
+		history = history[len(history)-llmHistoryLimit:]
+	}
+	lc.history[response.Symbol] = history
+}
+
+// AnalyzeMarketSentiment 分析市场情绪
+func (lc *LLMClient) AnalyzeMarketSentiment(symbol string, marketData map[string]interface{}) (*AnalysisResponse, error) {
+	return lc.AnalyzeNews(symbol, []string{
+		fmt.Sprintf("标的 %s 当前价格: %v, 成交量: %v", symbol, marketData["price"], marketData["volume"]),
+	})
+}
+
+// AnalyzeTechnicalIndicators 分析技术指标
+func (lc *LLMClient) AnalyzeTechnicalIndicators(symbol string, indicators map[string]float64) (*AnalysisResponse, error) {
+	items := make([]string, 0, len(indicators))
+	for name, value := range indicators {
+		items = append(items, fmt.Sprintf("技术指标 %s = %.4f", name, value))
+	}
+	return lc.AnalyzeNews(symbol, items)
+}
+
+// BatchAnalyze 批量分析
+func (lc *LLMClient) BatchAnalyze(symbols []string, newsItems []string) (map[string]*AnalysisResponse, error) {
+	results := make(map[string]*AnalysisResponse)
+	for _, symbol := range symbols {
+		response, err := lc.AnalyzeNews(symbol, newsItems)
+		if err != nil {
+			log.Printf("分析标的 %s 失败: %v", symbol, err)
+			continue
+		}
+		results[symbol] = response
+	}
+	return results, nil
+}
+
+// GetAnalysisHistory 获取本进程内最近的分析结果（从新到旧）
+func (lc *LLMClient) GetAnalysisHistory(symbol string, limit int) ([]*AnalysisResponse, error) {
+	lc.mutex.Lock()
+	defer lc.mutex.Unlock()
+
+	history := lc.history[symbol]
+	result := make([]*AnalysisResponse, 0, len(history))
+	for i := len(history) - 1; i >= 0 && (limit <= 0 || len(result) < limit); i-- {
+		result = append(result, history[i])
+	}
+	return result, nil
+}
+
+// HealthCheck 检查大模型接口是否可用（列出模型，不消耗额度）
+func (lc *LLMClient) HealthCheck() error {
+	url := lc.llm.BaseURL + "/models"
+	if lc.llm.Provider == LLMAnthropic {
+		url = lc.llm.BaseURL + "/v1/models"
+	}
+
+	resp, err := lc.httpClient.R().Get(url)
+	if err != nil {
+		return fmt.Errorf("健康检查失败: %w", err)
+	}
+	if resp.StatusCode() != 200 {
+		return fmt.Errorf("大模型接口不可用，状态码: %d", resp.StatusCode())
+	}
+	return nil
+}
+
+// SetTimeout 设置超时时间
+func (lc *LLMClient) SetTimeout(timeout time.Duration) {
+	lc.options.Timeout = timeout
+	lc.httpClient.SetTimeout(timeout)
+}
+
+// SetBaseURL 设置接口地址
+func (lc *LLMClient) SetBaseURL(baseURL string) {
+	lc.llm.BaseURL = strings.TrimRight(baseURL, "/")
+}
+
+// GetBaseURL 获取接口地址
+func (lc *LLMClient) GetBaseURL() string {
+	return lc.llm.BaseURL
+}
+
+// BreakerStatus 获取熔断器状态
+func (lc *LLMClient) BreakerStatus() BreakerStatus {
+	return lc.breaker.status()
+}
@@ -0,0 +1,210 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen 熔断器打开，请求未发送
+var ErrCircuitOpen = errors.New("Agent服务熔断中")
+
+// Options Agent客户端的超时、重试、熔断和安全设置
+type Options struct {
+	Timeout          time.Duration // 单次请求超时
+	TotalTimeout     time.Duration // 一次分析（含重试）的总耗时上限，0表示不限制
+	MaxRetries       int           // 失败后的最大重试次数
+	RetryBackoff     time.Duration // 首次重试等待时间，之后每次翻倍
+	MaxRetryBackoff  time.Duration // 重试等待时间上限
+	BreakerThreshold int           // 连续失败多少次后熔断，0表示不启用熔断
+	BreakerCooldown  time.Duration // 熔断后多久允许一次试探请求
+
+	// Security 认证、请求签名和TLS，只用于Python Agent服务（REST 和 gRPC）
+	Security Security
+}
+
+// DefaultOptions 默认设置
+func DefaultOptions() Options {
+	return Options{
+		Timeout:          30 * time.Second,
+		MaxRetries:       0,
+		RetryBackoff:     500 * time.Millisecond,
+		MaxRetryBackoff:  5 * time.Second,
+		BreakerThreshold: 0,
+		BreakerCooldown:  time.Minute,
+	}
+}
+
+// BreakerState 熔断器状态
+type BreakerState string
+
+const (
+	BreakerClosed   BreakerState = "closed"    // 正常放行
+	BreakerOpen     BreakerState = "open"      // 熔断，请求直接失败
+	BreakerHalfOpen BreakerState = "half_open" // 冷却结束，放行一次试探请求
+)
+
+// BreakerStatus 熔断器状态快照
+type BreakerStatus struct {
+	State            BreakerState `json:"state"`
+	ConsecutiveFails int          `json:"consecutive_failures"`
+	OpenedAt         time.Time    `json:"opened_at,omitempty"`
+	RetryAt          time.Time    `json:"retry_at,omitempty"` // 熔断时下一次允许试探的时间
+	LastError        string       `json:"last_error,omitempty"`
+	Rejected         int          `json:"rejected"` // 熔断期间直接拒绝的请求数
+}
+
+// BreakerReporter 提供熔断器状态的客户端
+type BreakerReporter interface {
+	BreakerStatus() BreakerStatus
+}
+
+// circuitBreaker 连续失败达到阈值后熔断，冷却后放行一次试探请求，成功则恢复
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	state     BreakerState
+	fails     int
+	openedAt  time.Time
+	probing   bool // 半开状态下已有试探请求
+	lastError string
+	rejected  int
+	mutex     sync.Mutex
+}
+
+// newCircuitBreaker 创建熔断器，threshold 为0时始终放行
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{threshold: threshold, cooldown: cooldown, state: BreakerClosed}
+}
+
+// allow 是否放行请求
+func (b *circuitBreaker) allow() error {
+	if b.threshold <= 0 {
+		return nil
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if time.Since(b.openedAt) < b.cooldown {
+			b.rejected++
+			return fmt.Errorf("%w，将于 %s 后重试: %s", ErrCircuitOpen,
+				time.Until(b.openedAt.Add(b.cooldown)).Round(time.Second), b.lastError)
+		}
+		b.state = BreakerHalfOpen
+		b.probing = true
+		log.Printf("Agent熔断冷却结束，发送试探请求")
+		return nil
+	case BreakerHalfOpen:
+		if b.probing {
+			b.rejected++
+			return fmt.Errorf("%w，等待试探请求结果", ErrCircuitOpen)
+		}
+		b.probing = true
+	}
+	return nil
+}
+
+// record 记录请求结果
+func (b *circuitBreaker) record(err error) {
+	if b.threshold <= 0 {
+		return
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.probing = false
+	if err == nil {
+		if b.state != BreakerClosed {
+			log.Printf("Agent服务恢复，熔断器关闭")
+		}
+		b.state = BreakerClosed
+		b.fails = 0
+		b.lastError = ""
+		return
+	}
+
+	b.fails++
+	b.lastError = err.Error()
+	if b.state == BreakerHalfOpen || b.fails >= b.threshold {
+		if b.state != BreakerOpen {
+			log.Printf("Agent服务连续失败 %d 次，熔断 %v", b.fails, b.cooldown)
+		}
+		b.state = BreakerOpen
+		b.openedAt = time.Now()
+	}
+}
+
+// status 熔断器状态快照
+func (b *circuitBreaker) status() BreakerStatus {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	status := BreakerStatus{
+		State:            b.state,
+		ConsecutiveFails: b.fails,
+		LastError:        b.lastError,
+		Rejected:         b.rejected,
+	}
+	if b.state == BreakerOpen {
+		status.OpenedAt = b.openedAt
+		status.RetryAt = b.openedAt.Add(b.cooldown)
+	}
+	return status
+}
+
+// retryableStatus 可以重试的HTTP状态码
+func retryableStatus(code int) bool {
+	return code == http.StatusTooManyRequests || code >= http.StatusInternalServerError
+}
+
+// retryError 标记可以重试的错误
+type retryError struct {
+	err error
+}
+
+func (e *retryError) Error() string { return e.err.Error() }
+func (e *retryError) Unwrap() error { return e.err }
+
+// withRetry 按退避策略重试可重试的错误，等待时间不超过总耗时上限
+func (c *Client) withRetry(operation string, call func() error) error {
+	return retry(c.options, operation, call)
+}
+
+// retry 按 options 的退避策略重试标记为 retryError 的错误
+func retry(options Options, operation string, call func() error) error {
+	start := time.Now()
+	backoff := options.RetryBackoff
+
+	var err error
+	for attempt := 0; ; attempt++ {
+		err = call()
+		var retryable *retryError
+		if err == nil || !errors.As(err, &retryable) || attempt >= options.MaxRetries {
+			return err
+		}
+
+		if options.TotalTimeout > 0 && time.Since(start)+backoff+options.Timeout > options.TotalTimeout {
+			return fmt.Errorf("%w（重试 %d 次后超出总耗时上限 %v）", err, attempt, options.TotalTimeout)
+		}
+
+		log.Printf("%s失败，%v 后第 %d 次重试: %v", operation, backoff, attempt+1, err)
+		time.Sleep(backoff)
+		backoff *= 2
+		if options.MaxRetryBackoff > 0 && backoff > options.MaxRetryBackoff {
+			backoff = options.MaxRetryBackoff
+		}
+	}
+}
+
+// BreakerStatus 获取熔断器状态
+func (c *Client) BreakerStatus() BreakerStatus {
+	return c.breaker.status()
+}
@@ -0,0 +1,156 @@
+package agent
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"google.golang.org/grpc/metadata"
+)
+
+// 认证和签名使用的请求头，gRPC 中以小写的 metadata 发送
+const (
+	HeaderAPIKey    = "X-API-Key"
+	HeaderTimestamp = "X-Agent-Timestamp" // 签名时间（Unix 秒），服务端据此拒绝过期的请求
+	HeaderNonce     = "X-Agent-Nonce"     // 每个请求随机生成，服务端在签名有效期内拒绝重复的值，防止重放
+	HeaderSignature = "X-Agent-Signature" // 请求签名，见 Sign
+)
+
+// Security 调用Python Agent服务时的认证、请求签名和TLS设置，零值表示不认证、不加密
+type Security struct {
+	APIKey        string      // 以 X-API-Key 头发送
+	BearerToken   string      // 以 Authorization: Bearer 头发送
+	SigningSecret string      // 非空时对每个请求做 HMAC-SHA256 签名
+	TLS           *tls.Config // nil 时使用明文连接
+}
+
+// TLSOptions TLS 设置，配置客户端证书时使用双向TLS
+type TLSOptions struct {
+	CAFile             string // 校验服务端证书的CA，为空时使用系统CA
+	CertFile           string // 客户端证书
+	KeyFile            string // 客户端私钥
+	ServerName         string // 校验的服务端证书名称，为空时使用连接地址
+	InsecureSkipVerify bool   // 不校验服务端证书，仅用于测试
+}
+
+// NewTLSConfig 按设置加载证书并创建TLS配置
+func NewTLSConfig(options TLSOptions) (*tls.Config, error) {
+	config := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         options.ServerName,
+		InsecureSkipVerify: options.InsecureSkipVerify,
+	}
+	if options.CAFile != "" {
+		pem, err := os.ReadFile(options.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("读取CA证书失败: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("CA证书 %s 中没有有效的证书", options.CAFile)
+		}
+		config.RootCAs = pool
+	}
+	if options.CertFile != "" || options.KeyFile != "" {
+		certificate, err := tls.LoadX509KeyPair(options.CertFile, options.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("加载客户端证书失败: %w", err)
+		}
+		config.Certificates = []tls.Certificate{certificate}
+	}
+	return config, nil
+}
+
+// Sign 计算请求签名：HMAC-SHA256(secret, timestamp + "\n" + nonce + "\n" + method + "\n" + path + "\n" + hex(sha256(body)))，
+// 结果为十六进制。REST 请求的 path 为URL路径，body 为实际发送的请求体；gRPC 请求的 method 为 POST，path 为完整方法名，
+// body 为实际发送的请求消息字节（见 signedCodec），服务端按收到的原始字节校验，不依赖各语言的序列化结果一致
+func Sign(secret, timestamp, nonce, method, path string, body []byte) string {
+	digest := sha256.Sum256(body)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strings.Join([]string{timestamp, nonce, method, path, hex.EncodeToString(digest[:])}, "\n")))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// newNonce 生成请求的随机值
+func newNonce() string {
+	nonce := make([]byte, 16)
+	_, _ = rand.Read(nonce)
+	return hex.EncodeToString(nonce)
+}
+
+// headers 请求需要附加的认证和签名头
+func (s Security) headers(method, path string, body []byte) map[string]string {
+	headers := make(map[string]string)
+	if s.APIKey != "" {
+		headers[HeaderAPIKey] = s.APIKey
+	}
+	if s.BearerToken != "" {
+		headers["Authorization"] = "Bearer " + s.BearerToken
+	}
+	if s.SigningSecret != "" {
+		timestamp, nonce := strconv.FormatInt(time.Now().Unix(), 10), newNonce()
+		headers[HeaderTimestamp] = timestamp
+		headers[HeaderNonce] = nonce
+		headers[HeaderSignature] = Sign(s.SigningSecret, timestamp, nonce, method, path, body)
+	}
+	return headers
+}
+
+// apply 为 REST 客户端配置认证头、请求签名和TLS
+func (s Security) apply(client *resty.Client) {
+	if s.TLS != nil {
+		client.SetTLSClientConfig(s.TLS)
+	}
+	if s.APIKey == "" && s.BearerToken == "" && s.SigningSecret == "" {
+		return
+	}
+	// 在请求体序列化之后设置，签名覆盖实际发送的内容
+	client.SetPreRequestHook(func(_ *resty.Client, request *http.Request) error {
+		body, err := requestBody(request)
+		if err != nil {
+			return fmt.Errorf("读取请求体失败: %w", err)
+		}
+		for name, value := range s.headers(request.Method, request.URL.Path, body) {
+			request.Header.Set(name, value)
+		}
+		return nil
+	})
+}
+
+// requestBody 读取请求体的副本，没有请求体时返回 nil（resty 此时的 GetBody 返回 nil）
+func requestBody(request *http.Request) ([]byte, error) {
+	if request.GetBody == nil {
+		return nil, nil
+	}
+	reader, err := request.GetBody()
+	if err != nil || reader == nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
+
+// outgoing 为 gRPC 调用附加认证和签名 metadata
+func (s Security) outgoing(ctx context.Context, method string, body []byte) context.Context {
+	headers := s.headers(http.MethodPost, method, body)
+	if len(headers) == 0 {
+		return ctx
+	}
+	pairs := make([]string, 0, len(headers)*2)
+	for name, value := range headers {
+		pairs = append(pairs, strings.ToLower(name), value)
+	}
+	return metadata.AppendToOutgoingContext(ctx, pairs...)
+}
@@ -0,0 +1,255 @@
+package agent
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// 交易方案的建议操作
+const (
+	ActionBuy  = "buy"
+	ActionSell = "sell"
+	ActionHold = "hold"
+)
+
+// DefaultSetupPromptTemplate 默认的交易方案提示词，可使用 .Symbol、.Price、.Position、.Equity 和 .News
+const DefaultSetupPromptTemplate = `你是一名专业的交易员。请根据以下信息为 {{.Symbol}} 给出交易方案。
+
+当前价格: {{.Price}}
+当前持仓数量: {{.Position}}（负数为空头）
+账户权益: {{.Equity}}
+
+新闻:
+{{range $i, $item := .News}}{{add $i 1}}. {{$item}}
+{{end}}
+只输出一个 JSON 对象，不要输出其他内容，格式如下:
+{"action": "buy|sell|hold", "target_allocation": 目标仓位占账户权益的比例(0到1，买入为多头、卖出为空头，0表示清仓), "stop_loss": 止损价格(不设置时为0), "take_profit": 止盈价格(不设置时为0), "confidence_score": 0到1之间的小数, "rationale": "简要理由"}`
+
+// TradeSetupRequest 交易方案请求
+type TradeSetupRequest struct {
+	Symbol    string   `json:"symbol"`
+	Price     float64  `json:"price"`    // 最新价格
+	Position  float64  `json:"position"` // 当前净持仓数量，空头为负数
+	Equity    float64  `json:"equity"`   // 账户权益
+	NewsItems []string `json:"news_items"`
+}
+
+// TradeSetupResponse Agent建议的交易方案
+type TradeSetupResponse struct {
+	Symbol           string    `json:"symbol"`
+	Action           string    `json:"action"`            // buy / sell / hold
+	TargetAllocation float64   `json:"target_allocation"` // 目标仓位占账户权益的比例 [0, 1]，买入为多头、卖出为空头
+	StopLoss         float64   `json:"stop_loss"`         // 止损价格，0 表示未给出
+	TakeProfit       float64   `json:"take_profit"`       // 止盈价格，0 表示未给出
+	ConfidenceScore  float64   `json:"confidence_score"`
+	Rationale        string    `json:"rationale"`
+	Timestamp        time.Time `json:"timestamp"`
+	AnalysisID       string    `json:"analysis_id"`
+}
+
+// TradeSetupAnalyzer 能给出结构化交易方案的Agent客户端（可选接口）
+type TradeSetupAnalyzer interface {
+	AnalyzeTradeSetup(request TradeSetupRequest) (*TradeSetupResponse, error)
+}
+
+// normalizeTradeSetup 统一操作名称并把比例、置信度和价格限制在有效范围内
+func normalizeTradeSetup(setup *TradeSetupResponse) error {
+	switch strings.ToLower(strings.TrimSpace(setup.Action)) {
+	case "buy", "long":
+		setup.Action = ActionBuy
+	case "sell", "short":
+		setup.Action = ActionSell
+	case "hold", "":
+		setup.Action = ActionHold
+	default:
+		return fmt.Errorf("无效的操作: %q", setup.Action)
+	}
+	setup.TargetAllocation = clamp(setup.TargetAllocation, 0, 1)
+	setup.ConfidenceScore = clamp(setup.ConfidenceScore, 0, 1)
+	if setup.StopLoss < 0 {
+		setup.StopLoss = 0
+	}
+	if setup.TakeProfit < 0 {
+		setup.TakeProfit = 0
+	}
+	return nil
+}
+
+// clamp 把 value 限制在 [min, max]
+func clamp(value, min, max float64) float64 {
+	if value < min {
+		return min
+	}
+	if value > max {
+		return max
+	}
+	return value
+}
+
+// AnalyzeTradeSetup 请求Agent服务给出交易方案
+func (c *Client) AnalyzeTradeSetup(request TradeSetupRequest) (*TradeSetupResponse, error) {
+	log.Printf("开始分析交易方案: 标的=%s, 价格=%.2f, 持仓=%.2f", request.Symbol, request.Price, request.Position)
+
+	// 熔断时不发送请求
+	if err := c.breaker.allow(); err != nil {
+		return nil, err
+	}
+
+	var setup *TradeSetupResponse
+	err := c.withRetry("交易方案请求", func() error {
+		resp, err := c.httpClient.R().
+			SetBody(request).
+			SetResult(&TradeSetupResponse{}).
+			Post(c.baseURL + "/analyze_setup")
+
+		if err != nil {
+			return &retryError{fmt.Errorf("发送请求失败: %w", err)}
+		}
+
+		if resp.StatusCode() != 200 {
+			err := fmt.Errorf("请求失败，状态码: %d, 响应: %s", resp.StatusCode(), resp.String())
+			if retryableStatus(resp.StatusCode()) {
+				return &retryError{err}
+			}
+			return err
+		}
+
+		var ok bool
+		setup, ok = resp.Result().(*TradeSetupResponse)
+		if !ok {
+			return fmt.Errorf("响应解析失败")
+		}
+		return nil
+	})
+	c.breaker.record(err)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := normalizeTradeSetup(setup); err != nil {
+		return nil, fmt.Errorf("交易方案无效: %w", err)
+	}
+	setup.Symbol = request.Symbol
+	setup.Timestamp = time.Now()
+	if setup.AnalysisID == "" {
+		setup.AnalysisID = fmt.Sprintf("SETUP_%d", time.Now().UnixNano())
+	}
+
+	log.Printf("交易方案分析完成: 标的=%s, 操作=%s, 目标仓位=%.2f%%, 置信度=%.2f",
+		request.Symbol, setup.Action, setup.TargetAllocation*100, setup.ConfidenceScore)
+	return setup, nil
+}
+
+// AnalyzeTradeSetup 模拟交易方案：看多时建仓 10%，看空时清仓
+func (mc *MockClient) AnalyzeTradeSetup(request TradeSetupRequest) (*TradeSetupResponse, error) {
+	analysis, err := mc.AnalyzeNews(request.Symbol, request.NewsItems)
+	if err != nil {
+		return nil, err
+	}
+
+	setup := &TradeSetupResponse{
+		Symbol:          request.Symbol,
+		Action:          ActionHold,
+		ConfidenceScore: analysis.ConfidenceScore,
+		Rationale:       analysis.Reason,
+		Timestamp:       time.Now(),
+		AnalysisID:      fmt.Sprintf("MOCK_SETUP_%d", time.Now().UnixNano()),
+	}
+	switch analysis.Sentiment {
+	case "Positive":
+		setup.Action = ActionBuy
+		setup.TargetAllocation = 0.1
+		setup.StopLoss = request.Price * 0.95
+		setup.TakeProfit = request.Price * 1.1
+	case "Negative":
+		setup.Action = ActionSell
+	}
+	return setup, nil
+}
+
+// setupPromptData 交易方案提示词模板的数据
+type setupPromptData struct {
+	Symbol   string
+	Price    float64
+	Position float64
+	Equity   float64
+	News     []string
+}
+
+// parseSetupPrompt 解析交易方案提示词模板
+func parseSetupPrompt(text string) (*template.Template, error) {
+	if text == "" {
+		text = DefaultSetupPromptTemplate
+	}
+	return template.New("setup").Funcs(template.FuncMap{
+		"add":  func(a, b int) int { return a + b },
+		"join": strings.Join,
+	}).Parse(text)
+}
+
+// AnalyzeTradeSetup 用大模型给出交易方案
+func (lc *LLMClient) AnalyzeTradeSetup(request TradeSetupRequest) (*TradeSetupResponse, error) {
+	log.Printf("开始分析交易方案(%s): 标的=%s, 价格=%.2f", lc.llm.Model, request.Symbol, request.Price)
+
+	var prompt bytes.Buffer
+	if err := lc.setupPrompt.Execute(&prompt, setupPromptData{
+		Symbol:   request.Symbol,
+		Price:    request.Price,
+		Position: request.Position,
+		Equity:   request.Equity,
+		News:     request.NewsItems,
+	}); err != nil {
+		return nil, fmt.Errorf("生成提示词失败: %w", err)
+	}
+
+	// 熔断时不发送请求
+	if err := lc.breaker.allow(); err != nil {
+		return nil, err
+	}
+
+	var content string
+	err := retry(lc.options, "大模型交易方案请求", func() error {
+		var err error
+		content, err = lc.complete(prompt.String())
+		return err
+	})
+	lc.breaker.record(err)
+	if err != nil {
+		return nil, err
+	}
+
+	setup, err := parseTradeSetup(content)
+	if err != nil {
+		return nil, fmt.Errorf("解析模型输出失败: %w（输出: %s）", err, content)
+	}
+	setup.Symbol = request.Symbol
+	setup.Timestamp = time.Now()
+	setup.AnalysisID = fmt.Sprintf("LLM_SETUP_%d", time.Now().UnixNano())
+
+	log.Printf("交易方案分析完成: 标的=%s, 操作=%s, 目标仓位=%.2f%%, 置信度=%.2f",
+		request.Symbol, setup.Action, setup.TargetAllocation*100, setup.ConfidenceScore)
+	return setup, nil
+}
+
+// parseTradeSetup 从模型输出中提取交易方案，兼容包裹在代码块或说明文字中的 JSON
+func parseTradeSetup(content string) (*TradeSetupResponse, error) {
+	start := strings.Index(content, "{")
+	end := strings.LastIndex(content, "}")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("没有找到 JSON 对象")
+	}
+
+	var setup TradeSetupResponse
+	if err := json.Unmarshal([]byte(content[start:end+1]), &setup); err != nil {
+		return nil, err
+	}
+	if err := normalizeTradeSetup(&setup); err != nil {
+		return nil, err
+	}
+	return &setup, nil
+}
@@ -0,0 +1,116 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+
+	"agent-quant-system/internal/agent"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// tokenQueryParam 监控面板页面和 SSE 连接携带令牌的查询参数（浏览器的 EventSource 不能设置请求头）
+const tokenQueryParam = "token"
+
+// authenticator 控制API的令牌认证，与调用Agent服务时的认证头相同：Authorization: Bearer <token> 或 X-API-Key；
+// token 为空时不认证
+type authenticator struct {
+	token string
+}
+
+// enabled 是否需要认证
+func (a authenticator) enabled() bool {
+	return a.token != ""
+}
+
+// check 按取请求头的函数校验令牌
+func (a authenticator) check(header func(name string) string) error {
+	if !a.enabled() {
+		return nil
+	}
+	provided := header(agent.HeaderAPIKey)
+	if bearer, found := strings.CutPrefix(header("Authorization"), "Bearer "); found {
+		provided = bearer
+	}
+	if provided == "" {
+		return errorf(CodeUnauthenticated, "缺少访问令牌")
+	}
+	if subtle.ConstantTimeCompare([]byte(provided), []byte(a.token)) != 1 {
+		return errorf(CodeUnauthenticated, "访问令牌无效")
+	}
+	return nil
+}
+
+// middleware 校验HTTP请求的令牌，也接受 ?token= 查询参数
+func (a authenticator) middleware(next http.Handler) http.Handler {
+	if !a.enabled() {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err := a.check(func(name string) string {
+			if value := r.Header.Get(name); value != "" {
+				return value
+			}
+			if name == agent.HeaderAPIKey {
+				return r.URL.Query().Get(tokenQueryParam)
+			}
+			return ""
+		})
+		if err != nil {
+			log.Printf("拒绝未通过认证的控制API请求: %s %s, 来源=%s, %v", r.Method, r.URL.Path, r.RemoteAddr, err)
+			writeError(w, err)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// checkContext 校验 gRPC 请求 metadata 中的令牌
+func (a authenticator) checkContext(ctx context.Context, method string) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+	err := a.check(func(name string) string {
+		if values := md.Get(strings.ToLower(name)); len(values) > 0 {
+			return values[0]
+		}
+		return ""
+	})
+	if err != nil {
+		log.Printf("拒绝未通过认证的gRPC请求: %s, %v", method, err)
+		return grpcError(err)
+	}
+	return nil
+}
+
+// unaryInterceptor 校验一元调用的令牌
+func (a authenticator) unaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if err := a.checkContext(ctx, info.FullMethod); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// streamInterceptor 校验流式调用的令牌
+func (a authenticator) streamInterceptor(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := a.checkContext(stream.Context(), info.FullMethod); err != nil {
+		return err
+	}
+	return handler(srv, stream)
+}
+
+// loopback 监听地址是否只接受本机连接
+func loopback(address string) bool {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
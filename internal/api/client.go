@@ -0,0 +1,80 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"agent-quant-system/internal/api/controlpb"
+	"agent-quant-system/internal/config"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+// ErrEngineUnavailable 无法连接正在运行的引擎的控制API
+var ErrEngineUnavailable = errors.New("无法连接控制API，请先以 serve 或 run（api.enabled = true）启动引擎")
+
+// Client 控制API的 gRPC 客户端，命令行通过它让正在运行的引擎执行操作，而不是另建一个引擎实例
+type Client struct {
+	controlpb.ControlServiceClient
+	conn    *grpc.ClientConn
+	timeout time.Duration
+}
+
+// tokenCredentials 每次调用附加访问令牌
+type tokenCredentials string
+
+// GetRequestMetadata 实现 credentials.PerRPCCredentials
+func (t tokenCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + string(t)}, nil
+}
+
+// RequireTransportSecurity 控制API默认只监听本机，允许明文连接
+func (t tokenCredentials) RequireTransportSecurity() bool {
+	return false
+}
+
+// NewClient 按控制API配置连接 gRPC 地址，配置 auth.token 时每次调用携带令牌（令牌需已解析密钥引用）
+func NewClient(cfg config.APIConfig, timeout time.Duration) (*Client, error) {
+	if cfg.GRPCAddress == "" {
+		return nil, fmt.Errorf("%w: 未配置 api.grpc_address", ErrEngineUnavailable)
+	}
+	options := []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
+	if cfg.Auth.Token != "" {
+		options = append(options, grpc.WithPerRPCCredentials(tokenCredentials(cfg.Auth.Token)))
+	}
+	conn, err := grpc.Dial(cfg.GRPCAddress, options...)
+	if err != nil {
+		return nil, fmt.Errorf("连接控制API失败: %w", err)
+	}
+	return &Client{ControlServiceClient: controlpb.NewControlServiceClient(conn), conn: conn, timeout: timeout}, nil
+}
+
+// Context 单次调用的上下文
+func (c *Client) Context() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), c.timeout)
+}
+
+// Close 关闭连接
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// ClientError 转换调用错误：连接不上时返回 ErrEngineUnavailable，其余返回服务端的错误信息
+func ClientError(err error) error {
+	if err == nil {
+		return nil
+	}
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+	if st.Code() == codes.Unavailable {
+		return fmt.Errorf("%w: %s", ErrEngineUnavailable, st.Message())
+	}
+	return fmt.Errorf("%s: %s", st.Code(), st.Message())
+}
@@ -0,0 +1,1161 @@
+// 量化引擎控制API
+//
+// 服务端实现见 internal/api，同时以两种方式提供：
+//   - gRPC（api.grpc_address），修改后重新生成 Go 代码（internal/api/controlpb）
+//   - HTTP/JSON（api.address）：每个 RPC 以 POST /quant.v1.ControlService/<方法名> 提供，请求和响应为本文件消息的
+//     JSON 编码（proto3 JSON 字段名），StreamEvents 以逐行 JSON 推送事件
+// 配置 api.auth.token 时，两种方式的请求都需要携带令牌（Authorization: Bearer <token> 或 X-API-Key）。
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             v4.24.4
+// source: control.proto
+
+package controlpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	ControlService_StartEngine_FullMethodName          = "/quant.v1.ControlService/StartEngine"
+	ControlService_StopEngine_FullMethodName           = "/quant.v1.ControlService/StopEngine"
+	ControlService_GetStatus_FullMethodName            = "/quant.v1.ControlService/GetStatus"
+	ControlService_ListStrategies_FullMethodName       = "/quant.v1.ControlService/ListStrategies"
+	ControlService_DiscoverStrategies_FullMethodName   = "/quant.v1.ControlService/DiscoverStrategies"
+	ControlService_UpdateStrategyParams_FullMethodName = "/quant.v1.ControlService/UpdateStrategyParams"
+	ControlService_PlaceManualOrder_FullMethodName     = "/quant.v1.ControlService/PlaceManualOrder"
+	ControlService_GetSymbolLists_FullMethodName       = "/quant.v1.ControlService/GetSymbolLists"
+	ControlService_UpdateSymbolList_FullMethodName     = "/quant.v1.ControlService/UpdateSymbolList"
+	ControlService_HaltTrading_FullMethodName          = "/quant.v1.ControlService/HaltTrading"
+	ControlService_ResumeTrading_FullMethodName        = "/quant.v1.ControlService/ResumeTrading"
+	ControlService_EnableStrategy_FullMethodName       = "/quant.v1.ControlService/EnableStrategy"
+	ControlService_GetCycleHistory_FullMethodName      = "/quant.v1.ControlService/GetCycleHistory"
+	ControlService_ExplainCycles_FullMethodName        = "/quant.v1.ControlService/ExplainCycles"
+	ControlService_GetDataProviders_FullMethodName     = "/quant.v1.ControlService/GetDataProviders"
+	ControlService_SwitchDataProvider_FullMethodName   = "/quant.v1.ControlService/SwitchDataProvider"
+	ControlService_GetLeaderboard_FullMethodName       = "/quant.v1.ControlService/GetLeaderboard"
+	ControlService_StreamEvents_FullMethodName         = "/quant.v1.ControlService/StreamEvents"
+	ControlService_Deposit_FullMethodName              = "/quant.v1.ControlService/Deposit"
+	ControlService_Withdraw_FullMethodName             = "/quant.v1.ControlService/Withdraw"
+	ControlService_SetBalance_FullMethodName           = "/quant.v1.ControlService/SetBalance"
+	ControlService_ResetAccount_FullMethodName         = "/quant.v1.ControlService/ResetAccount"
+	ControlService_ClosePosition_FullMethodName        = "/quant.v1.ControlService/ClosePosition"
+	ControlService_ExportSnapshot_FullMethodName       = "/quant.v1.ControlService/ExportSnapshot"
+	ControlService_ListApprovals_FullMethodName        = "/quant.v1.ControlService/ListApprovals"
+	ControlService_ApproveOrder_FullMethodName         = "/quant.v1.ControlService/ApproveOrder"
+	ControlService_RejectOrder_FullMethodName          = "/quant.v1.ControlService/RejectOrder"
+)
+
+// ControlServiceClient is the client API for ControlService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type ControlServiceClient interface {
+	// StartEngine 启动引擎并按间隔运行交易循环
+	StartEngine(ctx context.Context, in *StartEngineRequest, opts ...grpc.CallOption) (*StartEngineResponse, error)
+	// StopEngine 停止引擎，等待队列中的订单执行完毕
+	StopEngine(ctx context.Context, in *StopEngineRequest, opts ...grpc.CallOption) (*StopEngineResponse, error)
+	// GetStatus 获取引擎状态
+	GetStatus(ctx context.Context, in *GetStatusRequest, opts ...grpc.CallOption) (*GetStatusResponse, error)
+	// ListStrategies 列出可用策略及其参数
+	ListStrategies(ctx context.Context, in *ListStrategiesRequest, opts ...grpc.CallOption) (*ListStrategiesResponse, error)
+	// DiscoverStrategies 按资产类别、K线周期、标签或关键字筛选策略，供前端展示
+	DiscoverStrategies(ctx context.Context, in *DiscoverStrategiesRequest, opts ...grpc.CallOption) (*ListStrategiesResponse, error)
+	// UpdateStrategyParams 更新策略参数
+	UpdateStrategyParams(ctx context.Context, in *UpdateStrategyParamsRequest, opts ...grpc.CallOption) (*UpdateStrategyParamsResponse, error)
+	// PlaceManualOrder 提交手动订单，经过与策略订单相同的风控和执行流程
+	PlaceManualOrder(ctx context.Context, in *PlaceManualOrderRequest, opts ...grpc.CallOption) (*PlaceManualOrderResponse, error)
+	// GetSymbolLists 获取全局和各账户的交易名单
+	GetSymbolLists(ctx context.Context, in *GetSymbolListsRequest, opts ...grpc.CallOption) (*GetSymbolListsResponse, error)
+	// UpdateSymbolList 运行时修改交易名单（黑名单或白名单），account 为空时修改全局名单
+	UpdateSymbolList(ctx context.Context, in *UpdateSymbolListRequest, opts ...grpc.CallOption) (*GetSymbolListsResponse, error)
+	// HaltTrading 紧急停止：撤销全部未成交订单，之后的信号不再执行，直到 ResumeTrading
+	HaltTrading(ctx context.Context, in *HaltTradingRequest, opts ...grpc.CallOption) (*HaltState, error)
+	// ResumeTrading 解除紧急停止
+	ResumeTrading(ctx context.Context, in *ResumeTradingRequest, opts ...grpc.CallOption) (*HaltState, error)
+	// EnableStrategy 恢复因回撤或连续亏损被策略监控停用的策略
+	EnableStrategy(ctx context.Context, in *EnableStrategyRequest, opts ...grpc.CallOption) (*EnableStrategyResponse, error)
+	// GetCycleHistory 按时间查询交易循环记录（行情摘要、Agent指导、信号、订单、错误、耗时）
+	GetCycleHistory(ctx context.Context, in *GetCycleHistoryRequest, opts ...grpc.CallOption) (*GetCycleHistoryResponse, error)
+	// ExplainCycles 按时间查询交易循环的决策说明（指标值、Agent指导、未生成信号或信号未执行的原因）
+	ExplainCycles(ctx context.Context, in *GetCycleHistoryRequest, opts ...grpc.CallOption) (*ExplainCyclesResponse, error)
+	// GetDataProviders 获取各资产类别当前的行情数据源
+	GetDataProviders(ctx context.Context, in *GetDataProvidersRequest, opts ...grpc.CallOption) (*GetDataProvidersResponse, error)
+	// SwitchDataProvider 运行时切换资产类别的行情数据源，等待旧数据源进行中的请求完成后返回
+	SwitchDataProvider(ctx context.Context, in *SwitchDataProviderRequest, opts ...grpc.CallOption) (*ProviderSwap, error)
+	// GetLeaderboard 按滚动窗口的实盘表现（盈亏、夏普比率、最大回撤）对策略排名，用于发现表现衰退的策略
+	GetLeaderboard(ctx context.Context, in *GetLeaderboardRequest, opts ...grpc.CallOption) (*Leaderboard, error)
+	// StreamEvents 推送引擎事件（成交、风控、循环失败、经纪商异常等）
+	StreamEvents(ctx context.Context, in *StreamEventsRequest, opts ...grpc.CallOption) (ControlService_StreamEventsClient, error)
+	// Deposit 向运行中引擎的模拟账户入金
+	Deposit(ctx context.Context, in *AccountFundsRequest, opts ...grpc.CallOption) (*AccountFundsResponse, error)
+	// Withdraw 从运行中引擎的模拟账户出金
+	Withdraw(ctx context.Context, in *AccountFundsRequest, opts ...grpc.CallOption) (*AccountFundsResponse, error)
+	// SetBalance 设置运行中引擎的模拟账户余额
+	SetBalance(ctx context.Context, in *AccountFundsRequest, opts ...grpc.CallOption) (*AccountFundsResponse, error)
+	// ResetAccount 将运行中引擎的模拟账户重置为初始资金并清空持仓
+	ResetAccount(ctx context.Context, in *ResetAccountRequest, opts ...grpc.CallOption) (*AccountFundsResponse, error)
+	// ClosePosition 按比例平掉账户在标的上的持仓，返回平仓订单的执行结果
+	ClosePosition(ctx context.Context, in *ClosePositionRequest, opts ...grpc.CallOption) (*PlaceManualOrderResponse, error)
+	// ExportSnapshot 按 trading.snapshot 配置导出运行中引擎的持仓和余额快照（写文件和推送），并返回快照
+	ExportSnapshot(ctx context.Context, in *ExportSnapshotRequest, opts ...grpc.CallOption) (*PositionSnapshot, error)
+	// ListApprovals 列出大额订单的确认请求
+	ListApprovals(ctx context.Context, in *ListApprovalsRequest, opts ...grpc.CallOption) (*ListApprovalsResponse, error)
+	// ApproveOrder 确认等待人工确认的订单，运行中的引擎随即提交
+	ApproveOrder(ctx context.Context, in *DecideApprovalRequest, opts ...grpc.CallOption) (*Approval, error)
+	// RejectOrder 拒绝等待人工确认的订单
+	RejectOrder(ctx context.Context, in *DecideApprovalRequest, opts ...grpc.CallOption) (*Approval, error)
+}
+
+type controlServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewControlServiceClient(cc grpc.ClientConnInterface) ControlServiceClient {
+	return &controlServiceClient{cc}
+}
+
+func (c *controlServiceClient) StartEngine(ctx context.Context, in *StartEngineRequest, opts ...grpc.CallOption) (*StartEngineResponse, error) {
+	out := new(StartEngineResponse)
+	err := c.cc.Invoke(ctx, ControlService_StartEngine_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlServiceClient) StopEngine(ctx context.Context, in *StopEngineRequest, opts ...grpc.CallOption) (*StopEngineResponse, error) {
+	out := new(StopEngineResponse)
+	err := c.cc.Invoke(ctx, ControlService_StopEngine_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlServiceClient) GetStatus(ctx context.Context, in *GetStatusRequest, opts ...grpc.CallOption) (*GetStatusResponse, error) {
+	out := new(GetStatusResponse)
+	err := c.cc.Invoke(ctx, ControlService_GetStatus_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlServiceClient) ListStrategies(ctx context.Context, in *ListStrategiesRequest, opts ...grpc.CallOption) (*ListStrategiesResponse, error) {
+	out := new(ListStrategiesResponse)
+	err := c.cc.Invoke(ctx, ControlService_ListStrategies_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlServiceClient) DiscoverStrategies(ctx context.Context, in *DiscoverStrategiesRequest, opts ...grpc.CallOption) (*ListStrategiesResponse, error) {
+	out := new(ListStrategiesResponse)
+	err := c.cc.Invoke(ctx, ControlService_DiscoverStrategies_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlServiceClient) UpdateStrategyParams(ctx context.Context, in *UpdateStrategyParamsRequest, opts ...grpc.CallOption) (*UpdateStrategyParamsResponse, error) {
+	out := new(UpdateStrategyParamsResponse)
+	err := c.cc.Invoke(ctx, ControlService_UpdateStrategyParams_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlServiceClient) PlaceManualOrder(ctx context.Context, in *PlaceManualOrderRequest, opts ...grpc.CallOption) (*PlaceManualOrderResponse, error) {
+	out := new(PlaceManualOrderResponse)
+	err := c.cc.Invoke(ctx, ControlService_PlaceManualOrder_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlServiceClient) GetSymbolLists(ctx context.Context, in *GetSymbolListsRequest, opts ...grpc.CallOption) (*GetSymbolListsResponse, error) {
+	out := new(GetSymbolListsResponse)
+	err := c.cc.Invoke(ctx, ControlService_GetSymbolLists_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlServiceClient) UpdateSymbolList(ctx context.Context, in *UpdateSymbolListRequest, opts ...grpc.CallOption) (*GetSymbolListsResponse, error) {
+	out := new(GetSymbolListsResponse)
+	err := c.cc.Invoke(ctx, ControlService_UpdateSymbolList_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlServiceClient) HaltTrading(ctx context.Context, in *HaltTradingRequest, opts ...grpc.CallOption) (*HaltState, error) {
+	out := new(HaltState)
+	err := c.cc.Invoke(ctx, ControlService_HaltTrading_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlServiceClient) ResumeTrading(ctx context.Context, in *ResumeTradingRequest, opts ...grpc.CallOption) (*HaltState, error) {
+	out := new(HaltState)
+	err := c.cc.Invoke(ctx, ControlService_ResumeTrading_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlServiceClient) EnableStrategy(ctx context.Context, in *EnableStrategyRequest, opts ...grpc.CallOption) (*EnableStrategyResponse, error) {
+	out := new(EnableStrategyResponse)
+	err := c.cc.Invoke(ctx, ControlService_EnableStrategy_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlServiceClient) GetCycleHistory(ctx context.Context, in *GetCycleHistoryRequest, opts ...grpc.CallOption) (*GetCycleHistoryResponse, error) {
+	out := new(GetCycleHistoryResponse)
+	err := c.cc.Invoke(ctx, ControlService_GetCycleHistory_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlServiceClient) ExplainCycles(ctx context.Context, in *GetCycleHistoryRequest, opts ...grpc.CallOption) (*ExplainCyclesResponse, error) {
+	out := new(ExplainCyclesResponse)
+	err := c.cc.Invoke(ctx, ControlService_ExplainCycles_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlServiceClient) GetDataProviders(ctx context.Context, in *GetDataProvidersRequest, opts ...grpc.CallOption) (*GetDataProvidersResponse, error) {
+	out := new(GetDataProvidersResponse)
+	err := c.cc.Invoke(ctx, ControlService_GetDataProviders_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlServiceClient) SwitchDataProvider(ctx context.Context, in *SwitchDataProviderRequest, opts ...grpc.CallOption) (*ProviderSwap, error) {
+	out := new(ProviderSwap)
+	err := c.cc.Invoke(ctx, ControlService_SwitchDataProvider_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlServiceClient) GetLeaderboard(ctx context.Context, in *GetLeaderboardRequest, opts ...grpc.CallOption) (*Leaderboard, error) {
+	out := new(Leaderboard)
+	err := c.cc.Invoke(ctx, ControlService_GetLeaderboard_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlServiceClient) StreamEvents(ctx context.Context, in *StreamEventsRequest, opts ...grpc.CallOption) (ControlService_StreamEventsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &ControlService_ServiceDesc.Streams[0], ControlService_StreamEvents_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &controlServiceStreamEventsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type ControlService_StreamEventsClient interface {
+	Recv() (*Event, error)
+	grpc.ClientStream
+}
+
+type controlServiceStreamEventsClient struct {
+	grpc.ClientStream
+}
+
+func (x *controlServiceStreamEventsClient) Recv() (*Event, error) {
+	m := new(Event)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *controlServiceClient) Deposit(ctx context.Context, in *AccountFundsRequest, opts ...grpc.CallOption) (*AccountFundsResponse, error) {
+	out := new(AccountFundsResponse)
+	err := c.cc.Invoke(ctx, ControlService_Deposit_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlServiceClient) Withdraw(ctx context.Context, in *AccountFundsRequest, opts ...grpc.CallOption) (*AccountFundsResponse, error) {
+	out := new(AccountFundsResponse)
+	err := c.cc.Invoke(ctx, ControlService_Withdraw_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlServiceClient) SetBalance(ctx context.Context, in *AccountFundsRequest, opts ...grpc.CallOption) (*AccountFundsResponse, error) {
+	out := new(AccountFundsResponse)
+	err := c.cc.Invoke(ctx, ControlService_SetBalance_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlServiceClient) ResetAccount(ctx context.Context, in *ResetAccountRequest, opts ...grpc.CallOption) (*AccountFundsResponse, error) {
+	out := new(AccountFundsResponse)
+	err := c.cc.Invoke(ctx, ControlService_ResetAccount_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlServiceClient) ClosePosition(ctx context.Context, in *ClosePositionRequest, opts ...grpc.CallOption) (*PlaceManualOrderResponse, error) {
+	out := new(PlaceManualOrderResponse)
+	err := c.cc.Invoke(ctx, ControlService_ClosePosition_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlServiceClient) ExportSnapshot(ctx context.Context, in *ExportSnapshotRequest, opts ...grpc.CallOption) (*PositionSnapshot, error) {
+	out := new(PositionSnapshot)
+	err := c.cc.Invoke(ctx, ControlService_ExportSnapshot_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlServiceClient) ListApprovals(ctx context.Context, in *ListApprovalsRequest, opts ...grpc.CallOption) (*ListApprovalsResponse, error) {
+	out := new(ListApprovalsResponse)
+	err := c.cc.Invoke(ctx, ControlService_ListApprovals_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlServiceClient) ApproveOrder(ctx context.Context, in *DecideApprovalRequest, opts ...grpc.CallOption) (*Approval, error) {
+	out := new(Approval)
+	err := c.cc.Invoke(ctx, ControlService_ApproveOrder_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlServiceClient) RejectOrder(ctx context.Context, in *DecideApprovalRequest, opts ...grpc.CallOption) (*Approval, error) {
+	out := new(Approval)
+	err := c.cc.Invoke(ctx, ControlService_RejectOrder_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ControlServiceServer is the server API for ControlService service.
+// All implementations must embed UnimplementedControlServiceServer
+// for forward compatibility
+type ControlServiceServer interface {
+	// StartEngine 启动引擎并按间隔运行交易循环
+	StartEngine(context.Context, *StartEngineRequest) (*StartEngineResponse, error)
+	// StopEngine 停止引擎，等待队列中的订单执行完毕
+	StopEngine(context.Context, *StopEngineRequest) (*StopEngineResponse, error)
+	// GetStatus 获取引擎状态
+	GetStatus(context.Context, *GetStatusRequest) (*GetStatusResponse, error)
+	// ListStrategies 列出可用策略及其参数
+	ListStrategies(context.Context, *ListStrategiesRequest) (*ListStrategiesResponse, error)
+	// DiscoverStrategies 按资产类别、K线周期、标签或关键字筛选策略，供前端展示
+	DiscoverStrategies(context.Context, *DiscoverStrategiesRequest) (*ListStrategiesResponse, error)
+	// UpdateStrategyParams 更新策略参数
+	UpdateStrategyParams(context.Context, *UpdateStrategyParamsRequest) (*UpdateStrategyParamsResponse, error)
+	// PlaceManualOrder 提交手动订单，经过与策略订单相同的风控和执行流程
+	PlaceManualOrder(context.Context, *PlaceManualOrderRequest) (*PlaceManualOrderResponse, error)
+	// GetSymbolLists 获取全局和各账户的交易名单
+	GetSymbolLists(context.Context, *GetSymbolListsRequest) (*GetSymbolListsResponse, error)
+	// UpdateSymbolList 运行时修改交易名单（黑名单或白名单），account 为空时修改全局名单
+	UpdateSymbolList(context.Context, *UpdateSymbolListRequest) (*GetSymbolListsResponse, error)
+	// HaltTrading 紧急停止：撤销全部未成交订单，之后的信号不再执行，直到 ResumeTrading
+	HaltTrading(context.Context, *HaltTradingRequest) (*HaltState, error)
+	// ResumeTrading 解除紧急停止
+	ResumeTrading(context.Context, *ResumeTradingRequest) (*HaltState, error)
+	// EnableStrategy 恢复因回撤或连续亏损被策略监控停用的策略
+	EnableStrategy(context.Context, *EnableStrategyRequest) (*EnableStrategyResponse, error)
+	// GetCycleHistory 按时间查询交易循环记录（行情摘要、Agent指导、信号、订单、错误、耗时）
+	GetCycleHistory(context.Context, *GetCycleHistoryRequest) (*GetCycleHistoryResponse, error)
+	// ExplainCycles 按时间查询交易循环的决策说明（指标值、Agent指导、未生成信号或信号未执行的原因）
+	ExplainCycles(context.Context, *GetCycleHistoryRequest) (*ExplainCyclesResponse, error)
+	// GetDataProviders 获取各资产类别当前的行情数据源
+	GetDataProviders(context.Context, *GetDataProvidersRequest) (*GetDataProvidersResponse, error)
+	// SwitchDataProvider 运行时切换资产类别的行情数据源，等待旧数据源进行中的请求完成后返回
+	SwitchDataProvider(context.Context, *SwitchDataProviderRequest) (*ProviderSwap, error)
+	// GetLeaderboard 按滚动窗口的实盘表现（盈亏、夏普比率、最大回撤）对策略排名，用于发现表现衰退的策略
+	GetLeaderboard(context.Context, *GetLeaderboardRequest) (*Leaderboard, error)
+	// StreamEvents 推送引擎事件（成交、风控、循环失败、经纪商异常等）
+	StreamEvents(*StreamEventsRequest, ControlService_StreamEventsServer) error
+	// Deposit 向运行中引擎的模拟账户入金
+	Deposit(context.Context, *AccountFundsRequest) (*AccountFundsResponse, error)
+	// Withdraw 从运行中引擎的模拟账户出金
+	Withdraw(context.Context, *AccountFundsRequest) (*AccountFundsResponse, error)
+	// SetBalance 设置运行中引擎的模拟账户余额
+	SetBalance(context.Context, *AccountFundsRequest) (*AccountFundsResponse, error)
+	// ResetAccount 将运行中引擎的模拟账户重置为初始资金并清空持仓
+	ResetAccount(context.Context, *ResetAccountRequest) (*AccountFundsResponse, error)
+	// ClosePosition 按比例平掉账户在标的上的持仓，返回平仓订单的执行结果
+	ClosePosition(context.Context, *ClosePositionRequest) (*PlaceManualOrderResponse, error)
+	// ExportSnapshot 按 trading.snapshot 配置导出运行中引擎的持仓和余额快照（写文件和推送），并返回快照
+	ExportSnapshot(context.Context, *ExportSnapshotRequest) (*PositionSnapshot, error)
+	// ListApprovals 列出大额订单的确认请求
+	ListApprovals(context.Context, *ListApprovalsRequest) (*ListApprovalsResponse, error)
+	// ApproveOrder 确认等待人工确认的订单，运行中的引擎随即提交
+	ApproveOrder(context.Context, *DecideApprovalRequest) (*Approval, error)
+	// RejectOrder 拒绝等待人工确认的订单
+	RejectOrder(context.Context, *DecideApprovalRequest) (*Approval, error)
+	mustEmbedUnimplementedControlServiceServer()
+}
+
+// UnimplementedControlServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedControlServiceServer struct {
+}
+
+func (UnimplementedControlServiceServer) StartEngine(context.Context, *StartEngineRequest) (*StartEngineResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method StartEngine not implemented")
+}
+func (UnimplementedControlServiceServer) StopEngine(context.Context, *StopEngineRequest) (*StopEngineResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method StopEngine not implemented")
+}
+func (UnimplementedControlServiceServer) GetStatus(context.Context, *GetStatusRequest) (*GetStatusResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetStatus not implemented")
+}
+func (UnimplementedControlServiceServer) ListStrategies(context.Context, *ListStrategiesRequest) (*ListStrategiesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListStrategies not implemented")
+}
+func (UnimplementedControlServiceServer) DiscoverStrategies(context.Context, *DiscoverStrategiesRequest) (*ListStrategiesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DiscoverStrategies not implemented")
+}
+func (UnimplementedControlServiceServer) UpdateStrategyParams(context.Context, *UpdateStrategyParamsRequest) (*UpdateStrategyParamsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateStrategyParams not implemented")
+}
+func (UnimplementedControlServiceServer) PlaceManualOrder(context.Context, *PlaceManualOrderRequest) (*PlaceManualOrderResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PlaceManualOrder not implemented")
+}
+func (UnimplementedControlServiceServer) GetSymbolLists(context.Context, *GetSymbolListsRequest) (*GetSymbolListsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSymbolLists not implemented")
+}
+func (UnimplementedControlServiceServer) UpdateSymbolList(context.Context, *UpdateSymbolListRequest) (*GetSymbolListsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateSymbolList not implemented")
+}
+func (UnimplementedControlServiceServer) HaltTrading(context.Context, *HaltTradingRequest) (*HaltState, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method HaltTrading not implemented")
+}
+func (UnimplementedControlServiceServer) ResumeTrading(context.Context, *ResumeTradingRequest) (*HaltState, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ResumeTrading not implemented")
+}
+func (UnimplementedControlServiceServer) EnableStrategy(context.Context, *EnableStrategyRequest) (*EnableStrategyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method EnableStrategy not implemented")
+}
+func (UnimplementedControlServiceServer) GetCycleHistory(context.Context, *GetCycleHistoryRequest) (*GetCycleHistoryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCycleHistory not implemented")
+}
+func (UnimplementedControlServiceServer) ExplainCycles(context.Context, *GetCycleHistoryRequest) (*ExplainCyclesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ExplainCycles not implemented")
+}
+func (UnimplementedControlServiceServer) GetDataProviders(context.Context, *GetDataProvidersRequest) (*GetDataProvidersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetDataProviders not implemented")
+}
+func (UnimplementedControlServiceServer) SwitchDataProvider(context.Context, *SwitchDataProviderRequest) (*ProviderSwap, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SwitchDataProvider not implemented")
+}
+func (UnimplementedControlServiceServer) GetLeaderboard(context.Context, *GetLeaderboardRequest) (*Leaderboard, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetLeaderboard not implemented")
+}
+func (UnimplementedControlServiceServer) StreamEvents(*StreamEventsRequest, ControlService_StreamEventsServer) error {
+	return status.Errorf(codes.Unimplemented, "method StreamEvents not implemented")
+}
+func (UnimplementedControlServiceServer) Deposit(context.Context, *AccountFundsRequest) (*AccountFundsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Deposit not implemented")
+}
+func (UnimplementedControlServiceServer) Withdraw(context.Context, *AccountFundsRequest) (*AccountFundsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Withdraw not implemented")
+}
+func (UnimplementedControlServiceServer) SetBalance(context.Context, *AccountFundsRequest) (*AccountFundsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetBalance not implemented")
+}
+func (UnimplementedControlServiceServer) ResetAccount(context.Context, *ResetAccountRequest) (*AccountFundsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ResetAccount not implemented")
+}
+func (UnimplementedControlServiceServer) ClosePosition(context.Context, *ClosePositionRequest) (*PlaceManualOrderResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ClosePosition not implemented")
+}
+func (UnimplementedControlServiceServer) ExportSnapshot(context.Context, *ExportSnapshotRequest) (*PositionSnapshot, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ExportSnapshot not implemented")
+}
+func (UnimplementedControlServiceServer) ListApprovals(context.Context, *ListApprovalsRequest) (*ListApprovalsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListApprovals not implemented")
+}
+func (UnimplementedControlServiceServer) ApproveOrder(context.Context, *DecideApprovalRequest) (*Approval, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ApproveOrder not implemented")
+}
+func (UnimplementedControlServiceServer) RejectOrder(context.Context, *DecideApprovalRequest) (*Approval, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RejectOrder not implemented")
+}
+func (UnimplementedControlServiceServer) mustEmbedUnimplementedControlServiceServer() {}
+
+// UnsafeControlServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ControlServiceServer will
+// result in compilation errors.
+type UnsafeControlServiceServer interface {
+	mustEmbedUnimplementedControlServiceServer()
+}
+
+func RegisterControlServiceServer(s grpc.ServiceRegistrar, srv ControlServiceServer) {
+	s.RegisterService(&ControlService_ServiceDesc, srv)
+}
+
+func _ControlService_StartEngine_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StartEngineRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlServiceServer).StartEngine(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ControlService_StartEngine_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlServiceServer).StartEngine(ctx, req.(*StartEngineRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ControlService_StopEngine_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StopEngineRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlServiceServer).StopEngine(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ControlService_StopEngine_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlServiceServer).StopEngine(ctx, req.(*StopEngineRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ControlService_GetStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlServiceServer).GetStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ControlService_GetStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlServiceServer).GetStatus(ctx, req.(*GetStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ControlService_ListStrategies_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListStrategiesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlServiceServer).ListStrategies(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ControlService_ListStrategies_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlServiceServer).ListStrategies(ctx, req.(*ListStrategiesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ControlService_DiscoverStrategies_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DiscoverStrategiesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlServiceServer).DiscoverStrategies(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ControlService_DiscoverStrategies_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlServiceServer).DiscoverStrategies(ctx, req.(*DiscoverStrategiesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ControlService_UpdateStrategyParams_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateStrategyParamsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlServiceServer).UpdateStrategyParams(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ControlService_UpdateStrategyParams_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlServiceServer).UpdateStrategyParams(ctx, req.(*UpdateStrategyParamsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ControlService_PlaceManualOrder_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PlaceManualOrderRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlServiceServer).PlaceManualOrder(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ControlService_PlaceManualOrder_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlServiceServer).PlaceManualOrder(ctx, req.(*PlaceManualOrderRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ControlService_GetSymbolLists_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetSymbolListsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlServiceServer).GetSymbolLists(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ControlService_GetSymbolLists_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlServiceServer).GetSymbolLists(ctx, req.(*GetSymbolListsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ControlService_UpdateSymbolList_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateSymbolListRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlServiceServer).UpdateSymbolList(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ControlService_UpdateSymbolList_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlServiceServer).UpdateSymbolList(ctx, req.(*UpdateSymbolListRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ControlService_HaltTrading_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HaltTradingRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlServiceServer).HaltTrading(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ControlService_HaltTrading_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlServiceServer).HaltTrading(ctx, req.(*HaltTradingRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ControlService_ResumeTrading_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ResumeTradingRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlServiceServer).ResumeTrading(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ControlService_ResumeTrading_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlServiceServer).ResumeTrading(ctx, req.(*ResumeTradingRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ControlService_EnableStrategy_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EnableStrategyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlServiceServer).EnableStrategy(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ControlService_EnableStrategy_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlServiceServer).EnableStrategy(ctx, req.(*EnableStrategyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ControlService_GetCycleHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetCycleHistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlServiceServer).GetCycleHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ControlService_GetCycleHistory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlServiceServer).GetCycleHistory(ctx, req.(*GetCycleHistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ControlService_ExplainCycles_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetCycleHistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlServiceServer).ExplainCycles(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ControlService_ExplainCycles_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlServiceServer).ExplainCycles(ctx, req.(*GetCycleHistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ControlService_GetDataProviders_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetDataProvidersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlServiceServer).GetDataProviders(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ControlService_GetDataProviders_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlServiceServer).GetDataProviders(ctx, req.(*GetDataProvidersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ControlService_SwitchDataProvider_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SwitchDataProviderRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlServiceServer).SwitchDataProvider(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ControlService_SwitchDataProvider_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlServiceServer).SwitchDataProvider(ctx, req.(*SwitchDataProviderRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ControlService_GetLeaderboard_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetLeaderboardRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlServiceServer).GetLeaderboard(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ControlService_GetLeaderboard_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlServiceServer).GetLeaderboard(ctx, req.(*GetLeaderboardRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ControlService_StreamEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamEventsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ControlServiceServer).StreamEvents(m, &controlServiceStreamEventsServer{stream})
+}
+
+type ControlService_StreamEventsServer interface {
+	Send(*Event) error
+	grpc.ServerStream
+}
+
+type controlServiceStreamEventsServer struct {
+	grpc.ServerStream
+}
+
+func (x *controlServiceStreamEventsServer) Send(m *Event) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _ControlService_Deposit_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AccountFundsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlServiceServer).Deposit(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ControlService_Deposit_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlServiceServer).Deposit(ctx, req.(*AccountFundsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ControlService_Withdraw_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AccountFundsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlServiceServer).Withdraw(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ControlService_Withdraw_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlServiceServer).Withdraw(ctx, req.(*AccountFundsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ControlService_SetBalance_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AccountFundsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlServiceServer).SetBalance(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ControlService_SetBalance_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlServiceServer).SetBalance(ctx, req.(*AccountFundsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ControlService_ResetAccount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ResetAccountRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlServiceServer).ResetAccount(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ControlService_ResetAccount_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlServiceServer).ResetAccount(ctx, req.(*ResetAccountRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ControlService_ClosePosition_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ClosePositionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlServiceServer).ClosePosition(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ControlService_ClosePosition_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlServiceServer).ClosePosition(ctx, req.(*ClosePositionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ControlService_ExportSnapshot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExportSnapshotRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlServiceServer).ExportSnapshot(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ControlService_ExportSnapshot_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlServiceServer).ExportSnapshot(ctx, req.(*ExportSnapshotRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ControlService_ListApprovals_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListApprovalsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlServiceServer).ListApprovals(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ControlService_ListApprovals_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlServiceServer).ListApprovals(ctx, req.(*ListApprovalsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ControlService_ApproveOrder_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DecideApprovalRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlServiceServer).ApproveOrder(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ControlService_ApproveOrder_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlServiceServer).ApproveOrder(ctx, req.(*DecideApprovalRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ControlService_RejectOrder_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DecideApprovalRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlServiceServer).RejectOrder(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ControlService_RejectOrder_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlServiceServer).RejectOrder(ctx, req.(*DecideApprovalRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// ControlService_ServiceDesc is the grpc.ServiceDesc for ControlService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ControlService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "quant.v1.ControlService",
+	HandlerType: (*ControlServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "StartEngine",
+			Handler:    _ControlService_StartEngine_Handler,
+		},
+		{
+			MethodName: "StopEngine",
+			Handler:    _ControlService_StopEngine_Handler,
+		},
+		{
+			MethodName: "GetStatus",
+			Handler:    _ControlService_GetStatus_Handler,
+		},
+		{
+			MethodName: "ListStrategies",
+			Handler:    _ControlService_ListStrategies_Handler,
+		},
+		{
+			MethodName: "DiscoverStrategies",
+			Handler:    _ControlService_DiscoverStrategies_Handler,
+		},
+		{
+			MethodName: "UpdateStrategyParams",
+			Handler:    _ControlService_UpdateStrategyParams_Handler,
+		},
+		{
+			MethodName: "PlaceManualOrder",
+			Handler:    _ControlService_PlaceManualOrder_Handler,
+		},
+		{
+			MethodName: "GetSymbolLists",
+			Handler:    _ControlService_GetSymbolLists_Handler,
+		},
+		{
+			MethodName: "UpdateSymbolList",
+			Handler:    _ControlService_UpdateSymbolList_Handler,
+		},
+		{
+			MethodName: "HaltTrading",
+			Handler:    _ControlService_HaltTrading_Handler,
+		},
+		{
+			MethodName: "ResumeTrading",
+			Handler:    _ControlService_ResumeTrading_Handler,
+		},
+		{
+			MethodName: "EnableStrategy",
+			Handler:    _ControlService_EnableStrategy_Handler,
+		},
+		{
+			MethodName: "GetCycleHistory",
+			Handler:    _ControlService_GetCycleHistory_Handler,
+		},
+		{
+			MethodName: "ExplainCycles",
+			Handler:    _ControlService_ExplainCycles_Handler,
+		},
+		{
+			MethodName: "GetDataProviders",
+			Handler:    _ControlService_GetDataProviders_Handler,
+		},
+		{
+			MethodName: "SwitchDataProvider",
+			Handler:    _ControlService_SwitchDataProvider_Handler,
+		},
+		{
+			MethodName: "GetLeaderboard",
+			Handler:    _ControlService_GetLeaderboard_Handler,
+		},
+		{
+			MethodName: "Deposit",
+			Handler:    _ControlService_Deposit_Handler,
+		},
+		{
+			MethodName: "Withdraw",
+			Handler:    _ControlService_Withdraw_Handler,
+		},
+		{
+			MethodName: "SetBalance",
+			Handler:    _ControlService_SetBalance_Handler,
+		},
+		{
+			MethodName: "ResetAccount",
+			Handler:    _ControlService_ResetAccount_Handler,
+		},
+		{
+			MethodName: "ClosePosition",
+			Handler:    _ControlService_ClosePosition_Handler,
+		},
+		{
+			MethodName: "ExportSnapshot",
+			Handler:    _ControlService_ExportSnapshot_Handler,
+		},
+		{
+			MethodName: "ListApprovals",
+			Handler:    _ControlService_ListApprovals_Handler,
+		},
+		{
+			MethodName: "ApproveOrder",
+			Handler:    _ControlService_ApproveOrder_Handler,
+		},
+		{
+			MethodName: "RejectOrder",
+			Handler:    _ControlService_RejectOrder_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamEvents",
+			Handler:       _ControlService_StreamEvents_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "control.proto",
+}
@@ -0,0 +1,429 @@
+package api
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"agent-quant-system/internal/config"
+	"agent-quant-system/internal/core"
+	"agent-quant-system/internal/trading"
+)
+
+//go:embed dashboard/index.html
+var dashboardPage []byte
+
+// EquityPoint 权益曲线的一个采样点（报告币种）
+type EquityPoint struct {
+	Time   time.Time `json:"time"`
+	Equity float64   `json:"equity"`
+}
+
+// DashboardHealth 引擎健康状态
+type DashboardHealth struct {
+	Running           bool                                      `json:"running"`
+	Paper             bool                                      `json:"paper"`
+	DryRun            bool                                      `json:"dry_run"`
+	Halt              trading.HaltState                         `json:"halt"`
+	StartTime         time.Time                                 `json:"start_time"`
+	LastUpdateTime    time.Time                                 `json:"last_update_time"`
+	TotalCycles       int                                       `json:"total_cycles"`
+	FailedCycles      int                                       `json:"failed_cycles"`
+	LastCycleDuration time.Duration                             `json:"last_cycle_duration"`
+	ReportingCurrency string                                    `json:"reporting_currency"`
+	TotalBalance      float64                                   `json:"total_balance"`
+	RealizedPnL       float64                                   `json:"realized_pnl"`
+	UnrealizedPnL     float64                                   `json:"unrealized_pnl"`
+	Dependencies      map[core.Dependency]core.DependencyHealth `json:"dependencies"`
+	Brokers           map[string]trading.BrokerStatus           `json:"brokers"`
+}
+
+// DashboardPosition 账户持仓
+type DashboardPosition struct {
+	Account string `json:"account"`
+	trading.Position
+}
+
+// DashboardSignal 交易循环中策略生成的信号
+type DashboardSignal struct {
+	Time   time.Time `json:"time"`
+	Cycle  int       `json:"cycle"`
+	Symbol string    `json:"symbol"`
+	core.SignalRecord
+}
+
+// DashboardSentiment Agent对标的的情绪判断
+type DashboardSentiment struct {
+	Time   time.Time `json:"time"`
+	Symbol string    `json:"symbol"`
+	core.GuidanceRecord
+}
+
+// DashboardSnapshot 监控面板的状态快照，Errors 为获取失败的部分
+type DashboardSnapshot struct {
+	Time      time.Time            `json:"time"`
+	Health    DashboardHealth      `json:"health"`
+	Equity    []EquityPoint        `json:"equity"`
+	Positions []DashboardPosition  `json:"positions"`
+	Orders    []Order              `json:"orders"`
+	Trades    []trading.Trade      `json:"trades"`
+	Signals   []DashboardSignal    `json:"signals"`
+	Sentiment []DashboardSentiment `json:"sentiment"`
+	Errors    []string             `json:"errors,omitempty"`
+}
+
+// dashboard 网页监控面板：定期采样权益曲线；有事件流连接时每个刷新间隔只生成一次状态快照，推送给全部连接
+type dashboard struct {
+	engine      *core.QuantEngine
+	config      config.DashboardConfig
+	eventBuffer int
+
+	equity      []EquityPoint
+	equityError string
+	stopChan    chan struct{}
+	mutex       sync.Mutex
+
+	// 事件流连接，每个连接只保留最新一份未读快照
+	subscribers map[chan []byte]struct{}
+	latest      []byte    // 最近一次推送的快照（JSON）
+	latestAt    time.Time // latest 的生成时间
+	subMutex    sync.Mutex
+}
+
+// newDashboard 创建监控面板
+func newDashboard(engine *core.QuantEngine, cfg config.APIConfig) *dashboard {
+	return &dashboard{
+		engine:      engine,
+		config:      cfg.Dashboard,
+		eventBuffer: cfg.EventBuffer,
+		subscribers: make(map[chan []byte]struct{}),
+	}
+}
+
+// start 开始采样权益曲线
+func (d *dashboard) start() {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if d.stopChan != nil {
+		return
+	}
+	d.stopChan = make(chan struct{})
+	go d.runSampling(d.stopChan)
+}
+
+// stop 停止采样权益曲线
+func (d *dashboard) stop() {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if d.stopChan != nil {
+		close(d.stopChan)
+		d.stopChan = nil
+	}
+}
+
+// runSampling 按刷新间隔采样权益并向事件流推送快照，直到 stop 关闭
+func (d *dashboard) runSampling(stop <-chan struct{}) {
+	d.sampleEquity()
+	ticker := time.NewTicker(d.config.RefreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			d.sampleEquity()
+			d.broadcast()
+		}
+	}
+}
+
+// subscribe 注册事件流连接，返回接收快照的通道和取消函数
+func (d *dashboard) subscribe() (<-chan []byte, func()) {
+	ch := make(chan []byte, 1)
+	d.subMutex.Lock()
+	d.subscribers[ch] = struct{}{}
+	d.subMutex.Unlock()
+
+	return ch, func() {
+		d.subMutex.Lock()
+		delete(d.subscribers, ch)
+		d.subMutex.Unlock()
+	}
+}
+
+// broadcast 生成一次快照并推送给全部事件流连接，没有连接时不生成；
+// 连接还没读取上一份快照时替换为新的，慢连接不会阻塞其他连接
+func (d *dashboard) broadcast() {
+	d.subMutex.Lock()
+	count := len(d.subscribers)
+	d.subMutex.Unlock()
+	if count == 0 {
+		return
+	}
+
+	payload, err := json.Marshal(d.snapshot())
+	if err != nil {
+		log.Printf("序列化监控面板快照失败: %v", err)
+		return
+	}
+
+	d.subMutex.Lock()
+	defer d.subMutex.Unlock()
+	d.latest, d.latestAt = payload, time.Now()
+	for ch := range d.subscribers {
+		select {
+		case ch <- payload:
+		default:
+			// 只有 broadcast 向通道发送，取出旧快照后一定有空位
+			select {
+			case <-ch:
+			default:
+			}
+			ch <- payload
+		}
+	}
+}
+
+// currentSnapshot 新连接的第一份快照：最近一次推送的快照未超过刷新间隔时直接使用，否则重新生成
+func (d *dashboard) currentSnapshot() ([]byte, error) {
+	d.subMutex.Lock()
+	latest, latestAt := d.latest, d.latestAt
+	d.subMutex.Unlock()
+	if latest != nil && time.Since(latestAt) < d.config.RefreshInterval {
+		return latest, nil
+	}
+	return json.Marshal(d.snapshot())
+}
+
+// sampleEquity 记录一个权益采样点，超出保留点数时丢弃最早的
+func (d *dashboard) sampleEquity() {
+	equity, err := d.engine.TotalEquity()
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if err != nil {
+		d.equityError = err.Error()
+		return
+	}
+	d.equityError = ""
+	d.equity = append(d.equity, EquityPoint{Time: time.Now(), Equity: equity})
+	if excess := len(d.equity) - d.config.EquityPoints; excess > 0 {
+		d.equity = append([]EquityPoint(nil), d.equity[excess:]...)
+	}
+}
+
+// snapshot 生成状态快照
+func (d *dashboard) snapshot() *DashboardSnapshot {
+	status := d.engine.GetStatus()
+	snapshot := &DashboardSnapshot{
+		Time: time.Now(),
+		Health: DashboardHealth{
+			Running:           status.IsRunning,
+			StartTime:         status.StartTime,
+			LastUpdateTime:    status.LastUpdateTime,
+			TotalCycles:       status.TotalCycles,
+			FailedCycles:      status.FailedCycles,
+			LastCycleDuration: status.LastCycleDuration,
+			ReportingCurrency: status.ReportingCurrency,
+			TotalBalance:      status.TotalBalance,
+			Dependencies:      status.Dependencies,
+		},
+		Positions: []DashboardPosition{},
+		Orders:    []Order{},
+		Trades:    []trading.Trade{},
+		Signals:   []DashboardSignal{},
+		Sentiment: []DashboardSentiment{},
+	}
+	if status.PnL != nil {
+		snapshot.Health.RealizedPnL = status.PnL.RealizedPnL
+		snapshot.Health.UnrealizedPnL = status.PnL.UnrealizedPnL
+	}
+	if status.TradingStatus != nil {
+		snapshot.Health.Paper = status.TradingStatus.Paper
+		snapshot.Health.DryRun = status.TradingStatus.DryRun
+		snapshot.Health.Halt = status.TradingStatus.Halt
+		snapshot.Health.Brokers = status.TradingStatus.Brokers
+	}
+
+	d.mutex.Lock()
+	snapshot.Equity = append([]EquityPoint{}, d.equity...)
+	if d.equityError != "" {
+		snapshot.Errors = append(snapshot.Errors, "权益采样: "+d.equityError)
+	}
+	d.mutex.Unlock()
+
+	accounts := make([]string, 0, len(status.Accounts))
+	for name := range status.Accounts {
+		accounts = append(accounts, name)
+	}
+	sort.Strings(accounts)
+	for _, name := range accounts {
+		d.addAccount(snapshot, name)
+	}
+	sort.Slice(snapshot.Orders, func(i, j int) bool { return snapshot.Orders[i].CreateTime.After(snapshot.Orders[j].CreateTime) })
+	sort.Slice(snapshot.Trades, func(i, j int) bool { return snapshot.Trades[i].Timestamp.After(snapshot.Trades[j].Timestamp) })
+	if len(snapshot.Orders) > d.config.RecentLimit {
+		snapshot.Orders = snapshot.Orders[:d.config.RecentLimit]
+	}
+	if len(snapshot.Trades) > d.config.RecentLimit {
+		snapshot.Trades = snapshot.Trades[:d.config.RecentLimit]
+	}
+
+	d.addCycles(snapshot)
+	return snapshot
+}
+
+// addAccount 加入账户的持仓、订单和成交
+func (d *dashboard) addAccount(snapshot *DashboardSnapshot, accountName string) {
+	positions, err := d.engine.GetAccountPositions(accountName)
+	if err != nil {
+		snapshot.Errors = append(snapshot.Errors, fmt.Sprintf("%s 持仓: %v", accountName, err))
+	}
+	symbols := make([]string, 0, len(positions))
+	for symbol := range positions {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+	for _, symbol := range symbols {
+		snapshot.Positions = append(snapshot.Positions, DashboardPosition{Account: accountName, Position: positions[symbol]})
+	}
+
+	orders, err := d.engine.GetAccountOrders(accountName, "", "")
+	if err != nil {
+		snapshot.Errors = append(snapshot.Errors, fmt.Sprintf("%s 订单: %v", accountName, err))
+	}
+	for i := range orders {
+		if orders[i].AccountName == "" {
+			orders[i].AccountName = accountName
+		}
+		snapshot.Orders = append(snapshot.Orders, orderMessage(&orders[i]))
+	}
+
+	trades, err := d.engine.GetAccountTrades(accountName, "", d.config.RecentLimit)
+	if err != nil {
+		snapshot.Errors = append(snapshot.Errors, fmt.Sprintf("%s 成交: %v", accountName, err))
+	}
+	for _, trade := range trades {
+		if trade.AccountName == "" {
+			trade.AccountName = accountName
+		}
+		snapshot.Trades = append(snapshot.Trades, trade)
+	}
+}
+
+// addCycles 从最近的交易循环记录中取出策略信号和Agent情绪（最新的在前）
+func (d *dashboard) addCycles(snapshot *DashboardSnapshot) {
+	records, err := d.engine.CycleHistory(core.CycleQuery{Limit: d.config.RecentLimit})
+	if err != nil {
+		snapshot.Errors = append(snapshot.Errors, fmt.Sprintf("循环记录: %v", err))
+		return
+	}
+	for i := len(records) - 1; i >= 0; i-- {
+		record := records[i]
+		for _, symbol := range record.Symbols {
+			if symbol.Guidance != nil && len(snapshot.Sentiment) < d.config.RecentLimit {
+				snapshot.Sentiment = append(snapshot.Sentiment, DashboardSentiment{Time: record.Start, Symbol: symbol.Symbol, GuidanceRecord: *symbol.Guidance})
+			}
+			for _, signal := range symbol.Signals {
+				if len(snapshot.Signals) < d.config.RecentLimit {
+					snapshot.Signals = append(snapshot.Signals, DashboardSignal{Time: record.Start, Cycle: record.ID, Symbol: symbol.Symbol, SignalRecord: signal})
+				}
+			}
+		}
+	}
+}
+
+// register 注册监控面板的页面和接口
+func (d *dashboard) register(mux *http.ServeMux) {
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		target := "/dashboard/"
+		if r.URL.RawQuery != "" {
+			target += "?" + r.URL.RawQuery // 保留访问令牌
+		}
+		http.Redirect(w, r, target, http.StatusFound)
+	})
+	mux.HandleFunc("/dashboard/", d.handlePage)
+	mux.HandleFunc("/dashboard/snapshot", d.handleSnapshot)
+	mux.HandleFunc("/dashboard/stream", d.handleStream)
+}
+
+// handlePage 监控面板页面
+func (d *dashboard) handlePage(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/dashboard/" {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(dashboardPage)
+}
+
+// handleSnapshot 以JSON返回状态快照
+func (d *dashboard) handleSnapshot(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		writeError(w, errorf(CodeInvalidArgument, "只支持 GET 请求"))
+		return
+	}
+	writeJSON(w, http.StatusOK, d.snapshot())
+}
+
+// handleStream SSE 事件流：连接后立即推送一次快照，之后推送按刷新间隔统一生成的 snapshot 事件，引擎事件按 engine 事件推送
+func (d *dashboard) handleStream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, errorf(CodeInternal, "连接不支持流式响应"))
+		return
+	}
+
+	snapshots, unsubscribeSnapshots := d.subscribe()
+	defer unsubscribeSnapshots()
+	events, unsubscribe := d.engine.SubscribeEvents(d.eventBuffer)
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	write := func(name string, payload []byte) error {
+		if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", name, payload); err != nil {
+			return err
+		}
+		flusher.Flush()
+		return nil
+	}
+
+	initial, err := d.currentSnapshot()
+	if err != nil || write("snapshot", initial) != nil {
+		return
+	}
+	for {
+		var err error
+		select {
+		case <-r.Context().Done():
+			return
+		case payload := <-snapshots:
+			err = write("snapshot", payload)
+		case message, ok := <-events:
+			if !ok {
+				return
+			}
+			var payload []byte
+			if payload, err = json.Marshal(eventMessage(message)); err == nil {
+				err = write("engine", payload)
+			}
+		}
+		if err != nil {
+			log.Printf("监控面板事件流中断: %v", err)
+			return
+		}
+	}
+}
@@ -0,0 +1,304 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"agent-quant-system/internal/api/controlpb"
+	"agent-quant-system/internal/strategy"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// grpcService 以 gRPC 提供控制服务：请求转换为 messages.go 中的消息后调用 Server 的同名方法，
+// 响应按 proto3 JSON 编码转换为生成的消息（两者字段名一一对应）
+type grpcService struct {
+	controlpb.UnimplementedControlServiceServer
+	server *Server
+}
+
+// grpcCodes API错误码对应的 gRPC 状态码
+var grpcCodes = map[Code]codes.Code{
+	CodeInvalidArgument:    codes.InvalidArgument,
+	CodeNotFound:           codes.NotFound,
+	CodeFailedPrecondition: codes.FailedPrecondition,
+	CodeUnauthenticated:    codes.Unauthenticated,
+	CodeInternal:           codes.Internal,
+}
+
+// grpcError 转换为 gRPC 状态错误，非API错误按内部错误处理
+func grpcError(err error) error {
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		return status.Error(codes.Internal, err.Error())
+	}
+	code, exists := grpcCodes[apiErr.Code]
+	if !exists {
+		code = codes.Internal
+	}
+	return status.Error(code, apiErr.Message)
+}
+
+// responseOptions 响应转换时忽略 proto 中没有的字段，新增字段未同步到 control.proto 时不影响调用
+var responseOptions = protojson.UnmarshalOptions{DiscardUnknown: true}
+
+// toProto 将响应转换为生成的消息
+func toProto[Resp any, Msg proto.Message](resp *Resp, err error, message Msg) (Msg, error) {
+	var zero Msg
+	if err != nil {
+		return zero, grpcError(err)
+	}
+	encoded, err := json.Marshal(resp)
+	if err != nil {
+		return zero, status.Errorf(codes.Internal, "编码响应失败: %v", err)
+	}
+	if err := responseOptions.Unmarshal(encoded, message); err != nil {
+		return zero, status.Errorf(codes.Internal, "转换响应失败: %v", err)
+	}
+	return message, nil
+}
+
+// StartEngine 启动引擎
+func (g *grpcService) StartEngine(ctx context.Context, req *controlpb.StartEngineRequest) (*controlpb.StartEngineResponse, error) {
+	resp, err := g.server.StartEngine(ctx, &StartEngineRequest{IntervalSeconds: req.GetIntervalSeconds()})
+	return toProto(resp, err, &controlpb.StartEngineResponse{})
+}
+
+// StopEngine 停止引擎
+func (g *grpcService) StopEngine(ctx context.Context, req *controlpb.StopEngineRequest) (*controlpb.StopEngineResponse, error) {
+	resp, err := g.server.StopEngine(ctx, &StopEngineRequest{})
+	return toProto(resp, err, &controlpb.StopEngineResponse{})
+}
+
+// GetStatus 获取引擎状态
+func (g *grpcService) GetStatus(ctx context.Context, req *controlpb.GetStatusRequest) (*controlpb.GetStatusResponse, error) {
+	resp, err := g.server.GetStatus(ctx, &GetStatusRequest{})
+	return toProto(resp, err, &controlpb.GetStatusResponse{})
+}
+
+// ListStrategies 列出可用策略
+func (g *grpcService) ListStrategies(ctx context.Context, req *controlpb.ListStrategiesRequest) (*controlpb.ListStrategiesResponse, error) {
+	resp, err := g.server.ListStrategies(ctx, &ListStrategiesRequest{})
+	return toProto(resp, err, &controlpb.ListStrategiesResponse{})
+}
+
+// DiscoverStrategies 筛选策略
+func (g *grpcService) DiscoverStrategies(ctx context.Context, req *controlpb.DiscoverStrategiesRequest) (*controlpb.ListStrategiesResponse, error) {
+	resp, err := g.server.DiscoverStrategies(ctx, &DiscoverStrategiesRequest{
+		AssetClass: req.GetAssetClass(),
+		Timeframe:  req.GetTimeframe(),
+		Tag:        req.GetTag(),
+		Query:      req.GetQuery(),
+	})
+	return toProto(resp, err, &controlpb.ListStrategiesResponse{})
+}
+
+// UpdateStrategyParams 更新策略参数
+func (g *grpcService) UpdateStrategyParams(ctx context.Context, req *controlpb.UpdateStrategyParamsRequest) (*controlpb.UpdateStrategyParamsResponse, error) {
+	resp, err := g.server.UpdateStrategyParams(ctx, &UpdateStrategyParamsRequest{
+		Strategy:   req.GetStrategy(),
+		Parameters: strategy.StrategyParams(req.GetParameters().AsMap()),
+	})
+	return toProto(resp, err, &controlpb.UpdateStrategyParamsResponse{})
+}
+
+// PlaceManualOrder 提交手动订单
+func (g *grpcService) PlaceManualOrder(ctx context.Context, req *controlpb.PlaceManualOrderRequest) (*controlpb.PlaceManualOrderResponse, error) {
+	resp, err := g.server.PlaceManualOrder(ctx, &PlaceManualOrderRequest{
+		Account:     req.GetAccount(),
+		Symbol:      req.GetSymbol(),
+		Side:        req.GetSide(),
+		Type:        req.GetType(),
+		Quantity:    req.GetQuantity(),
+		Price:       req.GetPrice(),
+		StopPrice:   req.GetStopPrice(),
+		TimeInForce: req.GetTimeInForce(),
+		Strategy:    req.GetStrategy(),
+		Operator:    req.GetOperator(),
+		Reason:      req.GetReason(),
+	})
+	return toProto(resp, err, &controlpb.PlaceManualOrderResponse{})
+}
+
+// GetSymbolLists 获取交易名单
+func (g *grpcService) GetSymbolLists(ctx context.Context, req *controlpb.GetSymbolListsRequest) (*controlpb.GetSymbolListsResponse, error) {
+	resp, err := g.server.GetSymbolLists(ctx, &GetSymbolListsRequest{})
+	return toProto(resp, err, &controlpb.GetSymbolListsResponse{})
+}
+
+// UpdateSymbolList 修改交易名单
+func (g *grpcService) UpdateSymbolList(ctx context.Context, req *controlpb.UpdateSymbolListRequest) (*controlpb.GetSymbolListsResponse, error) {
+	resp, err := g.server.UpdateSymbolList(ctx, &UpdateSymbolListRequest{
+		List:    req.GetList(),
+		Account: req.GetAccount(),
+		Add:     req.GetAdd(),
+		Remove:  req.GetRemove(),
+	})
+	return toProto(resp, err, &controlpb.GetSymbolListsResponse{})
+}
+
+// HaltTrading 紧急停止交易
+func (g *grpcService) HaltTrading(ctx context.Context, req *controlpb.HaltTradingRequest) (*controlpb.HaltState, error) {
+	resp, err := g.server.HaltTrading(ctx, &HaltTradingRequest{Reason: req.GetReason()})
+	return toProto(resp, err, &controlpb.HaltState{})
+}
+
+// ResumeTrading 解除紧急停止
+func (g *grpcService) ResumeTrading(ctx context.Context, req *controlpb.ResumeTradingRequest) (*controlpb.HaltState, error) {
+	resp, err := g.server.ResumeTrading(ctx, &ResumeTradingRequest{})
+	return toProto(resp, err, &controlpb.HaltState{})
+}
+
+// EnableStrategy 恢复被策略监控停用的策略
+func (g *grpcService) EnableStrategy(ctx context.Context, req *controlpb.EnableStrategyRequest) (*controlpb.EnableStrategyResponse, error) {
+	resp, err := g.server.EnableStrategy(ctx, &EnableStrategyRequest{Strategy: req.GetStrategy()})
+	return toProto(resp, err, &controlpb.EnableStrategyResponse{})
+}
+
+// GetCycleHistory 查询交易循环记录
+func (g *grpcService) GetCycleHistory(ctx context.Context, req *controlpb.GetCycleHistoryRequest) (*controlpb.GetCycleHistoryResponse, error) {
+	resp, err := g.server.GetCycleHistory(ctx, cycleHistoryRequest(req))
+	return toProto(resp, err, &controlpb.GetCycleHistoryResponse{})
+}
+
+// ExplainCycles 查询交易循环的决策说明
+func (g *grpcService) ExplainCycles(ctx context.Context, req *controlpb.GetCycleHistoryRequest) (*controlpb.ExplainCyclesResponse, error) {
+	resp, err := g.server.ExplainCycles(ctx, cycleHistoryRequest(req))
+	return toProto(resp, err, &controlpb.ExplainCyclesResponse{})
+}
+
+// cycleHistoryRequest 转换交易循环查询，未设置的时间不限制
+func cycleHistoryRequest(req *controlpb.GetCycleHistoryRequest) *GetCycleHistoryRequest {
+	query := &GetCycleHistoryRequest{Symbol: req.GetSymbol(), Limit: int(req.GetLimit())}
+	if req.GetFrom() != nil {
+		query.From = req.GetFrom().AsTime()
+	}
+	if req.GetTo() != nil {
+		query.To = req.GetTo().AsTime()
+	}
+	return query
+}
+
+// GetDataProviders 获取各资产类别当前的数据源
+func (g *grpcService) GetDataProviders(ctx context.Context, req *controlpb.GetDataProvidersRequest) (*controlpb.GetDataProvidersResponse, error) {
+	resp, err := g.server.GetDataProviders(ctx, &GetDataProvidersRequest{})
+	return toProto(resp, err, &controlpb.GetDataProvidersResponse{})
+}
+
+// SwitchDataProvider 切换资产类别的数据源
+func (g *grpcService) SwitchDataProvider(ctx context.Context, req *controlpb.SwitchDataProviderRequest) (*controlpb.ProviderSwap, error) {
+	resp, err := g.server.SwitchDataProvider(ctx, &SwitchDataProviderRequest{AssetClass: req.GetAssetClass(), Provider: req.GetProvider()})
+	return toProto(resp, err, &controlpb.ProviderSwap{})
+}
+
+// GetLeaderboard 获取策略排行榜
+func (g *grpcService) GetLeaderboard(ctx context.Context, req *controlpb.GetLeaderboardRequest) (*controlpb.Leaderboard, error) {
+	resp, err := g.server.GetLeaderboard(ctx, &GetLeaderboardRequest{RankBy: req.GetRankBy(), RankWindow: time.Duration(req.GetRankWindow())})
+	return toProto(resp, err, &controlpb.Leaderboard{})
+}
+
+// StreamEvents 推送引擎事件
+func (g *grpcService) StreamEvents(req *controlpb.StreamEventsRequest, stream controlpb.ControlService_StreamEventsServer) error {
+	if err := g.server.StreamEvents(&StreamEventsRequest{Kinds: req.GetKinds()}, grpcEventStream{stream}); err != nil {
+		return grpcError(err)
+	}
+	return nil
+}
+
+// Deposit 向模拟账户入金
+func (g *grpcService) Deposit(ctx context.Context, req *controlpb.AccountFundsRequest) (*controlpb.AccountFundsResponse, error) {
+	resp, err := g.server.Deposit(ctx, &AccountFundsRequest{Account: req.GetAccount(), Amount: req.GetAmount()})
+	return toProto(resp, err, &controlpb.AccountFundsResponse{})
+}
+
+// Withdraw 从模拟账户出金
+func (g *grpcService) Withdraw(ctx context.Context, req *controlpb.AccountFundsRequest) (*controlpb.AccountFundsResponse, error) {
+	resp, err := g.server.Withdraw(ctx, &AccountFundsRequest{Account: req.GetAccount(), Amount: req.GetAmount()})
+	return toProto(resp, err, &controlpb.AccountFundsResponse{})
+}
+
+// SetBalance 设置模拟账户余额
+func (g *grpcService) SetBalance(ctx context.Context, req *controlpb.AccountFundsRequest) (*controlpb.AccountFundsResponse, error) {
+	resp, err := g.server.SetBalance(ctx, &AccountFundsRequest{Account: req.GetAccount(), Amount: req.GetAmount()})
+	return toProto(resp, err, &controlpb.AccountFundsResponse{})
+}
+
+// ResetAccount 重置模拟账户
+func (g *grpcService) ResetAccount(ctx context.Context, req *controlpb.ResetAccountRequest) (*controlpb.AccountFundsResponse, error) {
+	resp, err := g.server.ResetAccount(ctx, &ResetAccountRequest{Account: req.GetAccount()})
+	return toProto(resp, err, &controlpb.AccountFundsResponse{})
+}
+
+// ClosePosition 按比例平仓
+func (g *grpcService) ClosePosition(ctx context.Context, req *controlpb.ClosePositionRequest) (*controlpb.PlaceManualOrderResponse, error) {
+	resp, err := g.server.ClosePosition(ctx, &ClosePositionRequest{Account: req.GetAccount(), Symbol: req.GetSymbol(), Percent: req.GetPercent()})
+	return toProto(resp, err, &controlpb.PlaceManualOrderResponse{})
+}
+
+// ExportSnapshot 导出持仓快照
+func (g *grpcService) ExportSnapshot(ctx context.Context, req *controlpb.ExportSnapshotRequest) (*controlpb.PositionSnapshot, error) {
+	resp, err := g.server.ExportSnapshot(ctx, &ExportSnapshotRequest{})
+	return toProto(resp, err, &controlpb.PositionSnapshot{})
+}
+
+// ListApprovals 列出确认请求
+func (g *grpcService) ListApprovals(ctx context.Context, req *controlpb.ListApprovalsRequest) (*controlpb.ListApprovalsResponse, error) {
+	resp, err := g.server.ListApprovals(ctx, &ListApprovalsRequest{All: req.GetAll()})
+	return toProto(resp, err, &controlpb.ListApprovalsResponse{})
+}
+
+// ApproveOrder 确认订单
+func (g *grpcService) ApproveOrder(ctx context.Context, req *controlpb.DecideApprovalRequest) (*controlpb.Approval, error) {
+	resp, err := g.server.ApproveOrder(ctx, decideApprovalRequest(req))
+	return toProto(resp, err, &controlpb.Approval{})
+}
+
+// RejectOrder 拒绝订单
+func (g *grpcService) RejectOrder(ctx context.Context, req *controlpb.DecideApprovalRequest) (*controlpb.Approval, error) {
+	resp, err := g.server.RejectOrder(ctx, decideApprovalRequest(req))
+	return toProto(resp, err, &controlpb.Approval{})
+}
+
+// decideApprovalRequest 转换确认请求
+func decideApprovalRequest(req *controlpb.DecideApprovalRequest) *DecideApprovalRequest {
+	return &DecideApprovalRequest{ID: req.GetId(), By: req.GetBy(), Reason: req.GetReason()}
+}
+
+// grpcEventStream 基于 gRPC 服务端流的事件流
+type grpcEventStream struct {
+	stream controlpb.ControlService_StreamEventsServer
+}
+
+// Context 事件流的上下文，客户端断开时取消
+func (st grpcEventStream) Context() context.Context {
+	return st.stream.Context()
+}
+
+// Send 发送一个事件
+func (st grpcEventStream) Send(event *Event) error {
+	return st.stream.Send(&controlpb.Event{
+		Kind:  event.Kind,
+		Title: event.Title,
+		Body:  event.Body,
+		Time:  timestamppb.New(event.Time),
+	})
+}
+
+// newGRPCServer 创建注册了控制服务和令牌认证的 gRPC 服务
+func (s *Server) newGRPCServer() *grpc.Server {
+	server := grpc.NewServer(
+		grpc.UnaryInterceptor(s.auth.unaryInterceptor),
+		grpc.StreamInterceptor(s.auth.streamInterceptor),
+	)
+	controlpb.RegisterControlServiceServer(server, &grpcService{server: s})
+	return server
+}
+
+var _ controlpb.ControlServiceServer = (*grpcService)(nil)
@@ -0,0 +1,301 @@
+package api
+
+import (
+	"time"
+
+	"agent-quant-system/internal/core"
+	"agent-quant-system/internal/data"
+	"agent-quant-system/internal/notify"
+	"agent-quant-system/internal/strategy"
+	"agent-quant-system/internal/trading"
+)
+
+// 以下消息与 control.proto 中的定义一一对应，JSON 字段名使用 proto3 JSON 编码
+
+// StartEngineRequest 启动引擎请求
+type StartEngineRequest struct {
+	IntervalSeconds int64 `json:"interval_seconds"` // 0 表示使用服务端默认间隔
+}
+
+// StartEngineResponse 启动引擎响应
+type StartEngineResponse struct {
+	Running         bool  `json:"running"`
+	IntervalSeconds int64 `json:"interval_seconds"`
+}
+
+// StopEngineRequest 停止引擎请求
+type StopEngineRequest struct{}
+
+// StopEngineResponse 停止引擎响应
+type StopEngineResponse struct {
+	Running bool `json:"running"`
+}
+
+// GetStatusRequest 获取状态请求
+type GetStatusRequest struct{}
+
+// AccountBalance 账户余额
+type AccountBalance struct {
+	Currency string  `json:"currency"`
+	Balance  float64 `json:"balance"`
+}
+
+// GetStatusResponse 引擎状态
+type GetStatusResponse struct {
+	Running           bool                      `json:"running"`
+	StartTime         time.Time                 `json:"start_time"`
+	LastUpdateTime    time.Time                 `json:"last_update_time"`
+	TotalCycles       int64                     `json:"total_cycles"`
+	SuccessfulCycles  int64                     `json:"successful_cycles"`
+	FailedCycles      int64                     `json:"failed_cycles"`
+	TotalSignals      int64                     `json:"total_signals"`
+	ExecutedTrades    int64                     `json:"executed_trades"`
+	TotalPnL          float64                   `json:"total_pnl"`
+	ReportingCurrency string                    `json:"reporting_currency"`
+	TotalBalance      float64                   `json:"total_balance"`
+	Watchlist         []string                  `json:"watchlist"`
+	Accounts          map[string]AccountBalance `json:"accounts"`
+	Paper             bool                      `json:"paper"`
+	DryRun            bool                      `json:"dry_run"`
+	Halt              trading.HaltState         `json:"halt"`
+
+	RealizedPnL   float64 `json:"realized_pnl"`   // 报告币种，已扣除手续费
+	UnrealizedPnL float64 `json:"unrealized_pnl"` // 报告币种，按最新价格计算
+
+	Leaderboard *trading.LeaderboardReport `json:"leaderboard,omitempty"` // 未启用策略排行榜时为空
+
+	DisabledStrategies []trading.DisabledStrategy `json:"disabled_strategies,omitempty"` // 被策略监控停用的策略
+}
+
+// ListStrategiesRequest 列出策略请求
+type ListStrategiesRequest struct{}
+
+// StrategyInfo 策略信息
+type StrategyInfo struct {
+	Name        string                  `json:"name"`
+	Description string                  `json:"description"`
+	Parameters  strategy.StrategyParams `json:"parameters"`
+
+	Metadata strategy.StrategyMetadata `json:"metadata"`
+}
+
+// DiscoverStrategiesRequest 策略发现请求，为空的条件不参与筛选
+type DiscoverStrategiesRequest struct {
+	AssetClass string `json:"asset_class"`
+	Timeframe  string `json:"timeframe"`
+	Tag        string `json:"tag"`
+	Query      string `json:"query"`
+}
+
+// ListStrategiesResponse 策略列表（按名称排序）
+type ListStrategiesResponse struct {
+	Strategies []StrategyInfo `json:"strategies"`
+}
+
+// UpdateStrategyParamsRequest 更新策略参数请求，只需包含要修改的参数
+type UpdateStrategyParamsRequest struct {
+	Strategy   string                  `json:"strategy"`
+	Parameters strategy.StrategyParams `json:"parameters"`
+}
+
+// UpdateStrategyParamsResponse 更新后的策略信息
+type UpdateStrategyParamsResponse struct {
+	Strategy StrategyInfo `json:"strategy"`
+}
+
+// PlaceManualOrderRequest 手动下单请求，数量和价格为十进制字符串
+type PlaceManualOrderRequest struct {
+	Account     string `json:"account"`
+	Symbol      string `json:"symbol"`
+	Side        string `json:"side"`
+	Type        string `json:"type"`
+	Quantity    string `json:"quantity"`
+	Price       string `json:"price"`
+	StopPrice   string `json:"stop_price"`
+	TimeInForce string `json:"time_in_force"`
+	Strategy    string `json:"strategy"`
+	Operator    string `json:"operator"` // 操作人，与原因一起记入信号日志
+	Reason      string `json:"reason"`
+}
+
+// Order 订单
+type Order struct {
+	ID             string    `json:"id"`
+	Account        string    `json:"account"`
+	Strategy       string    `json:"strategy"`
+	Symbol         string    `json:"symbol"`
+	Side           string    `json:"side"`
+	Type           string    `json:"type"`
+	Status         string    `json:"status"`
+	Quantity       string    `json:"quantity"`
+	Price          string    `json:"price"`
+	FilledQuantity string    `json:"filled_quantity"`
+	AveragePrice   string    `json:"average_price"`
+	Commission     string    `json:"commission"`
+	CreateTime     time.Time `json:"create_time"`
+}
+
+// PlaceManualOrderResponse 手动下单响应
+type PlaceManualOrderResponse struct {
+	Order Order `json:"order"`
+}
+
+// GetSymbolListsRequest 获取交易名单请求
+type GetSymbolListsRequest struct{}
+
+// GetSymbolListsResponse 全局和各账户的交易名单
+type GetSymbolListsResponse struct {
+	Global   trading.SymbolList            `json:"global"`
+	Accounts map[string]trading.SymbolList `json:"accounts"`
+}
+
+// UpdateSymbolListRequest 修改交易名单请求
+type UpdateSymbolListRequest struct {
+	List    string   `json:"list"`
+	Account string   `json:"account"`
+	Add     []string `json:"add"`
+	Remove  []string `json:"remove"`
+}
+
+// HaltTradingRequest 紧急停止请求
+type HaltTradingRequest struct {
+	Reason string `json:"reason"`
+}
+
+// ResumeTradingRequest 解除紧急停止请求
+type ResumeTradingRequest struct{}
+
+// EnableStrategyRequest 恢复被策略监控停用的策略请求
+type EnableStrategyRequest struct {
+	Strategy string `json:"strategy"`
+}
+
+// EnableStrategyResponse 恢复后仍处于停用状态的策略
+type EnableStrategyResponse struct {
+	Disabled []trading.DisabledStrategy `json:"disabled"`
+}
+
+// GetCycleHistoryRequest 查询交易循环记录请求
+type GetCycleHistoryRequest struct {
+	From   time.Time `json:"from"`
+	To     time.Time `json:"to"`
+	Symbol string    `json:"symbol"`
+	Limit  int       `json:"limit"`
+}
+
+// GetCycleHistoryResponse 交易循环记录（按时间升序）
+type GetCycleHistoryResponse struct {
+	Cycles []core.CycleRecord `json:"cycles"`
+}
+
+// ExplainCyclesResponse 交易循环的决策说明（按时间升序），请求与 GetCycleHistoryRequest 相同
+type ExplainCyclesResponse struct {
+	Explanations []core.CycleExplanation `json:"explanations"`
+}
+
+// GetDataProvidersRequest 获取数据源请求
+type GetDataProvidersRequest struct{}
+
+// GetDataProvidersResponse 各资产类别当前的数据源和可切换的数据源
+type GetDataProvidersResponse struct {
+	Active    []data.ProviderStatus `json:"active"`
+	Available []string              `json:"available"`
+}
+
+// SwitchDataProviderRequest 切换数据源请求
+type SwitchDataProviderRequest struct {
+	AssetClass string `json:"asset_class"`
+	Provider   string `json:"provider"`
+}
+
+// GetLeaderboardRequest 策略排行榜请求，字段为空时使用 trading.leaderboard 配置
+type GetLeaderboardRequest struct {
+	RankBy     string        `json:"rank_by"`     // sharpe / pnl / drawdown
+	RankWindow time.Duration `json:"rank_window"` // 排名使用的窗口，纳秒，需在 windows 配置中
+}
+
+// AccountFundsRequest 模拟账户入金、出金或设置余额请求，金额为十进制字符串
+type AccountFundsRequest struct {
+	Account string `json:"account"`
+	Amount  string `json:"amount"`
+}
+
+// ResetAccountRequest 重置模拟账户请求
+type ResetAccountRequest struct {
+	Account string `json:"account"`
+}
+
+// AccountFundsResponse 调整后的账户余额
+type AccountFundsResponse struct {
+	Account string `json:"account"`
+	Balance string `json:"balance"`
+}
+
+// ClosePositionRequest 平仓请求
+type ClosePositionRequest struct {
+	Account string  `json:"account"`
+	Symbol  string  `json:"symbol"`
+	Percent float64 `json:"percent"` // (0, 1]，0 表示全部平仓
+}
+
+// ExportSnapshotRequest 导出持仓快照请求
+type ExportSnapshotRequest struct{}
+
+// ListApprovalsRequest 列出确认请求
+type ListApprovalsRequest struct {
+	All bool `json:"all"` // 包含已处理的请求
+}
+
+// ListApprovalsResponse 确认请求列表
+type ListApprovalsResponse struct {
+	Approvals []trading.ApprovalRequest `json:"approvals"`
+}
+
+// DecideApprovalRequest 确认或拒绝订单
+type DecideApprovalRequest struct {
+	ID     string `json:"id"`
+	By     string `json:"by"`
+	Reason string `json:"reason"`
+}
+
+// StreamEventsRequest 事件流请求
+type StreamEventsRequest struct {
+	Kinds []string `json:"kinds"` // 为空时推送全部事件
+}
+
+// Event 引擎事件
+type Event struct {
+	Kind  string    `json:"kind"`
+	Title string    `json:"title"`
+	Body  string    `json:"body"`
+	Time  time.Time `json:"time"`
+}
+
+// orderMessage 转换为API订单消息
+func orderMessage(order *trading.Order) Order {
+	return Order{
+		ID:             order.ID,
+		Account:        order.AccountName,
+		Strategy:       order.Strategy,
+		Symbol:         order.Symbol,
+		Side:           string(order.Side),
+		Type:           string(order.Type),
+		Status:         string(order.Status),
+		Quantity:       order.Quantity.String(),
+		Price:          order.Price.String(),
+		FilledQuantity: order.FilledQty.String(),
+		AveragePrice:   order.AvgPrice.String(),
+		Commission:     order.Commission.String(),
+		CreateTime:     order.CreateTime,
+	}
+}
+
+// eventMessage 转换为API事件消息
+func eventMessage(message notify.Message) *Event {
+	return &Event{
+		Kind:  string(message.Kind),
+		Title: message.Title,
+		Body:  message.Body,
+		Time:  message.Time,
+	}
+}
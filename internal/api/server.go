@@ -0,0 +1,859 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"agent-quant-system/internal/config"
+	"agent-quant-system/internal/core"
+	"agent-quant-system/internal/data"
+	"agent-quant-system/internal/strategy"
+	"agent-quant-system/internal/trading"
+
+	"github.com/shopspring/decimal"
+	"google.golang.org/grpc"
+)
+
+// ServiceName 控制服务的全名，各方法以 /<ServiceName>/<方法名> 的路径提供
+const ServiceName = "quant.v1.ControlService"
+
+// Code 错误码，取值与 gRPC 状态码名称相同
+type Code string
+
+const (
+	CodeInvalidArgument    Code = "INVALID_ARGUMENT"
+	CodeNotFound           Code = "NOT_FOUND"
+	CodeFailedPrecondition Code = "FAILED_PRECONDITION"
+	CodeUnauthenticated    Code = "UNAUTHENTICATED"
+	CodeInternal           Code = "INTERNAL"
+)
+
+// Error 带错误码的API错误
+type Error struct {
+	Code    Code   `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error 实现 error 接口
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+// errorf 创建API错误
+func errorf(code Code, format string, args ...interface{}) error {
+	return &Error{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// httpStatus 错误码对应的HTTP状态码
+func (c Code) httpStatus() int {
+	switch c {
+	case CodeInvalidArgument:
+		return http.StatusBadRequest
+	case CodeNotFound:
+		return http.StatusNotFound
+	case CodeFailedPrecondition:
+		return http.StatusConflict
+	case CodeUnauthenticated:
+		return http.StatusUnauthorized
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// EventStream 服务端事件流
+type EventStream interface {
+	Context() context.Context
+	Send(event *Event) error
+}
+
+// ControlServiceServer 控制服务接口，与 control.proto 中的 ControlService 对应
+type ControlServiceServer interface {
+	StartEngine(ctx context.Context, req *StartEngineRequest) (*StartEngineResponse, error)
+	StopEngine(ctx context.Context, req *StopEngineRequest) (*StopEngineResponse, error)
+	GetStatus(ctx context.Context, req *GetStatusRequest) (*GetStatusResponse, error)
+	ListStrategies(ctx context.Context, req *ListStrategiesRequest) (*ListStrategiesResponse, error)
+	DiscoverStrategies(ctx context.Context, req *DiscoverStrategiesRequest) (*ListStrategiesResponse, error)
+	UpdateStrategyParams(ctx context.Context, req *UpdateStrategyParamsRequest) (*UpdateStrategyParamsResponse, error)
+	PlaceManualOrder(ctx context.Context, req *PlaceManualOrderRequest) (*PlaceManualOrderResponse, error)
+	GetSymbolLists(ctx context.Context, req *GetSymbolListsRequest) (*GetSymbolListsResponse, error)
+	UpdateSymbolList(ctx context.Context, req *UpdateSymbolListRequest) (*GetSymbolListsResponse, error)
+	HaltTrading(ctx context.Context, req *HaltTradingRequest) (*trading.HaltState, error)
+	ResumeTrading(ctx context.Context, req *ResumeTradingRequest) (*trading.HaltState, error)
+	EnableStrategy(ctx context.Context, req *EnableStrategyRequest) (*EnableStrategyResponse, error)
+	GetCycleHistory(ctx context.Context, req *GetCycleHistoryRequest) (*GetCycleHistoryResponse, error)
+	ExplainCycles(ctx context.Context, req *GetCycleHistoryRequest) (*ExplainCyclesResponse, error)
+	GetDataProviders(ctx context.Context, req *GetDataProvidersRequest) (*GetDataProvidersResponse, error)
+	SwitchDataProvider(ctx context.Context, req *SwitchDataProviderRequest) (*data.ProviderSwap, error)
+	GetLeaderboard(ctx context.Context, req *GetLeaderboardRequest) (*trading.LeaderboardReport, error)
+	StreamEvents(req *StreamEventsRequest, stream EventStream) error
+	Deposit(ctx context.Context, req *AccountFundsRequest) (*AccountFundsResponse, error)
+	Withdraw(ctx context.Context, req *AccountFundsRequest) (*AccountFundsResponse, error)
+	SetBalance(ctx context.Context, req *AccountFundsRequest) (*AccountFundsResponse, error)
+	ResetAccount(ctx context.Context, req *ResetAccountRequest) (*AccountFundsResponse, error)
+	ClosePosition(ctx context.Context, req *ClosePositionRequest) (*PlaceManualOrderResponse, error)
+	ExportSnapshot(ctx context.Context, req *ExportSnapshotRequest) (*trading.PositionSnapshot, error)
+	ListApprovals(ctx context.Context, req *ListApprovalsRequest) (*ListApprovalsResponse, error)
+	ApproveOrder(ctx context.Context, req *DecideApprovalRequest) (*trading.ApprovalRequest, error)
+	RejectOrder(ctx context.Context, req *DecideApprovalRequest) (*trading.ApprovalRequest, error)
+}
+
+// Server 量化引擎控制服务
+type Server struct {
+	engine          *core.QuantEngine
+	config          config.APIConfig
+	defaultInterval time.Duration
+	dashboard       *dashboard // 未启用监控面板时为nil
+	auth            authenticator
+
+	httpServer *http.Server
+	grpcServer *grpc.Server // 未配置 grpc_address 时为nil
+	mutex      sync.Mutex
+}
+
+// NewServer 创建控制服务，defaultInterval 为 StartEngine 未指定间隔时的交易循环间隔
+func NewServer(engine *core.QuantEngine, cfg config.APIConfig, defaultInterval time.Duration) *Server {
+	server := &Server{
+		engine:          engine,
+		config:          cfg,
+		defaultInterval: defaultInterval,
+		auth:            authenticator{token: cfg.Auth.Token},
+	}
+	if cfg.Dashboard.Enabled {
+		server.dashboard = newDashboard(engine, cfg)
+	}
+	return server
+}
+
+// Start 开始监听控制API（HTTP/JSON 和 gRPC）；未配置访问令牌时只允许监听本机地址
+func (s *Server) Start() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.httpServer != nil {
+		return fmt.Errorf("控制API已在运行")
+	}
+	if !s.auth.enabled() {
+		for _, address := range []string{s.config.Address, s.config.GRPCAddress} {
+			if address != "" && !loopback(address) {
+				return fmt.Errorf("控制API监听非本机地址 %s 时必须配置 api.auth.token", address)
+			}
+		}
+		log.Printf("警告: 未配置 api.auth.token，控制API不校验访问令牌")
+	}
+
+	listener, err := net.Listen("tcp", s.config.Address)
+	if err != nil {
+		return fmt.Errorf("监听控制API地址失败: %w", err)
+	}
+
+	if s.config.GRPCAddress != "" {
+		grpcListener, err := net.Listen("tcp", s.config.GRPCAddress)
+		if err != nil {
+			listener.Close()
+			return fmt.Errorf("监听控制API gRPC地址失败: %w", err)
+		}
+		s.grpcServer = s.newGRPCServer()
+		go func(server *grpc.Server) {
+			if err := server.Serve(grpcListener); err != nil {
+				log.Printf("控制API gRPC服务退出: %v", err)
+			}
+		}(s.grpcServer)
+		log.Printf("控制API gRPC服务已启动: %s", grpcListener.Addr())
+	}
+
+	s.httpServer = &http.Server{Handler: s.Handler()}
+	go func(server *http.Server) {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("控制API退出: %v", err)
+		}
+	}(s.httpServer)
+
+	log.Printf("控制API已启动: %s", listener.Addr())
+	if s.dashboard != nil {
+		s.dashboard.start()
+		log.Printf("监控面板: http://%s/dashboard/", listener.Addr())
+	}
+	return nil
+}
+
+// Stop 停止控制API，关闭进行中的事件流
+func (s *Server) Stop() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.httpServer == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if s.dashboard != nil {
+		s.dashboard.stop()
+	}
+	if s.grpcServer != nil {
+		// 事件流在客户端断开前不会结束，超时后强制关闭
+		stopped := make(chan struct{})
+		go func(server *grpc.Server) {
+			server.GracefulStop()
+			close(stopped)
+		}(s.grpcServer)
+		select {
+		case <-stopped:
+		case <-ctx.Done():
+			s.grpcServer.Stop()
+		}
+		s.grpcServer = nil
+	}
+	err := s.httpServer.Shutdown(ctx)
+	if errors.Is(err, context.DeadlineExceeded) {
+		err = s.httpServer.Close()
+	}
+	s.httpServer = nil
+	log.Printf("控制API已停止")
+	return err
+}
+
+// Handler 控制API的HTTP处理器，配置访问令牌时所有方法和监控面板都需要认证
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle(methodPath("StartEngine"), unary(s.StartEngine))
+	mux.Handle(methodPath("StopEngine"), unary(s.StopEngine))
+	mux.Handle(methodPath("GetStatus"), unary(s.GetStatus))
+	mux.Handle(methodPath("ListStrategies"), unary(s.ListStrategies))
+	mux.Handle(methodPath("DiscoverStrategies"), unary(s.DiscoverStrategies))
+	mux.Handle(methodPath("UpdateStrategyParams"), unary(s.UpdateStrategyParams))
+	mux.Handle(methodPath("PlaceManualOrder"), unary(s.PlaceManualOrder))
+	mux.Handle(methodPath("GetSymbolLists"), unary(s.GetSymbolLists))
+	mux.Handle(methodPath("UpdateSymbolList"), unary(s.UpdateSymbolList))
+	mux.Handle(methodPath("HaltTrading"), unary(s.HaltTrading))
+	mux.Handle(methodPath("ResumeTrading"), unary(s.ResumeTrading))
+	mux.Handle(methodPath("EnableStrategy"), unary(s.EnableStrategy))
+	mux.Handle(methodPath("GetCycleHistory"), unary(s.GetCycleHistory))
+	mux.Handle(methodPath("ExplainCycles"), unary(s.ExplainCycles))
+	mux.Handle(methodPath("GetDataProviders"), unary(s.GetDataProviders))
+	mux.Handle(methodPath("GetLeaderboard"), unary(s.GetLeaderboard))
+	mux.Handle(methodPath("SwitchDataProvider"), unary(s.SwitchDataProvider))
+	mux.HandleFunc(methodPath("StreamEvents"), s.handleStreamEvents)
+	mux.Handle(methodPath("Deposit"), unary(s.Deposit))
+	mux.Handle(methodPath("Withdraw"), unary(s.Withdraw))
+	mux.Handle(methodPath("SetBalance"), unary(s.SetBalance))
+	mux.Handle(methodPath("ResetAccount"), unary(s.ResetAccount))
+	mux.Handle(methodPath("ClosePosition"), unary(s.ClosePosition))
+	mux.Handle(methodPath("ExportSnapshot"), unary(s.ExportSnapshot))
+	mux.Handle(methodPath("ListApprovals"), unary(s.ListApprovals))
+	mux.Handle(methodPath("ApproveOrder"), unary(s.ApproveOrder))
+	mux.Handle(methodPath("RejectOrder"), unary(s.RejectOrder))
+	if s.dashboard != nil {
+		s.dashboard.register(mux)
+	}
+	return s.auth.middleware(mux)
+}
+
+// StartEngine 启动引擎并在后台按间隔运行交易循环
+func (s *Server) StartEngine(ctx context.Context, req *StartEngineRequest) (*StartEngineResponse, error) {
+	if req.IntervalSeconds < 0 {
+		return nil, errorf(CodeInvalidArgument, "interval_seconds 不能为负数")
+	}
+	interval := s.defaultInterval
+	if req.IntervalSeconds > 0 {
+		interval = time.Duration(req.IntervalSeconds) * time.Second
+	}
+
+	if s.engine.IsRunning() {
+		return nil, errorf(CodeFailedPrecondition, "量化引擎已在运行")
+	}
+	if err := s.engine.Start(); err != nil {
+		return nil, errorf(CodeFailedPrecondition, "%v", err)
+	}
+
+	go func() {
+		if err := s.engine.RunContinuous(interval); err != nil {
+			log.Printf("连续运行失败: %v", err)
+		}
+	}()
+
+	log.Printf("已通过控制API启动引擎，循环间隔: %v", interval)
+	return &StartEngineResponse{Running: true, IntervalSeconds: int64(interval / time.Second)}, nil
+}
+
+// StopEngine 停止引擎
+func (s *Server) StopEngine(ctx context.Context, req *StopEngineRequest) (*StopEngineResponse, error) {
+	if !s.engine.IsRunning() {
+		return nil, errorf(CodeFailedPrecondition, "量化引擎未运行")
+	}
+	if err := s.engine.Stop(); err != nil {
+		return nil, errorf(CodeFailedPrecondition, "%v", err)
+	}
+
+	log.Printf("已通过控制API停止引擎")
+	return &StopEngineResponse{Running: false}, nil
+}
+
+// GetStatus 获取引擎状态
+func (s *Server) GetStatus(ctx context.Context, req *GetStatusRequest) (*GetStatusResponse, error) {
+	status := s.engine.GetStatus()
+
+	resp := &GetStatusResponse{
+		Running:           status.IsRunning,
+		StartTime:         status.StartTime,
+		LastUpdateTime:    status.LastUpdateTime,
+		TotalCycles:       int64(status.TotalCycles),
+		SuccessfulCycles:  int64(status.SuccessfulCycles),
+		FailedCycles:      int64(status.FailedCycles),
+		TotalSignals:      int64(status.TotalSignals),
+		ExecutedTrades:    int64(status.ExecutedTrades),
+		TotalPnL:          status.TotalPnL,
+		ReportingCurrency: status.ReportingCurrency,
+		TotalBalance:      status.TotalBalance,
+		Watchlist:         status.Watchlist,
+		Accounts:          make(map[string]AccountBalance, len(status.Accounts)),
+	}
+	for name, account := range status.Accounts {
+		resp.Accounts[name] = AccountBalance{Currency: account.Currency, Balance: account.Balance}
+	}
+	if status.PnL != nil {
+		resp.RealizedPnL = status.PnL.RealizedPnL
+		resp.UnrealizedPnL = status.PnL.UnrealizedPnL
+	}
+	if status.TradingStatus != nil {
+		resp.Paper = status.TradingStatus.Paper
+		resp.DryRun = status.TradingStatus.DryRun
+		resp.Halt = status.TradingStatus.Halt
+	}
+	resp.Leaderboard = status.Leaderboard
+	resp.DisabledStrategies = s.engine.DisabledStrategies()
+	return resp, nil
+}
+
+// ListStrategies 列出可用策略
+func (s *Server) ListStrategies(ctx context.Context, req *ListStrategiesRequest) (*ListStrategiesResponse, error) {
+	return strategyList(s.engine.GetAvailableStrategies()), nil
+}
+
+// DiscoverStrategies 按资产类别、周期、标签或关键字筛选策略
+func (s *Server) DiscoverStrategies(ctx context.Context, req *DiscoverStrategiesRequest) (*ListStrategiesResponse, error) {
+	return strategyList(s.engine.DiscoverStrategies(strategy.StrategyFilter{
+		AssetClass: req.AssetClass,
+		Timeframe:  req.Timeframe,
+		Tag:        req.Tag,
+		Query:      req.Query,
+	})), nil
+}
+
+// strategyList 按名称排序的策略列表
+func strategyList(available map[string]strategy.StrategyInfo) *ListStrategiesResponse {
+	resp := &ListStrategiesResponse{Strategies: make([]StrategyInfo, 0, len(available))}
+	for name, info := range available {
+		resp.Strategies = append(resp.Strategies, strategyMessage(name, info))
+	}
+	sort.Slice(resp.Strategies, func(i, j int) bool { return resp.Strategies[i].Name < resp.Strategies[j].Name })
+	return resp
+}
+
+// UpdateStrategyParams 更新策略参数，未包含的参数保持原值
+func (s *Server) UpdateStrategyParams(ctx context.Context, req *UpdateStrategyParamsRequest) (*UpdateStrategyParamsResponse, error) {
+	if req.Strategy == "" {
+		return nil, errorf(CodeInvalidArgument, "strategy 不能为空")
+	}
+	if len(req.Parameters) == 0 {
+		return nil, errorf(CodeInvalidArgument, "parameters 不能为空")
+	}
+
+	if _, exists := s.engine.GetAvailableStrategies()[req.Strategy]; !exists {
+		return nil, errorf(CodeNotFound, "策略 '%s' 不存在", req.Strategy)
+	}
+
+	// 只修改请求中的参数，与当前参数的合并和验证由策略管理器完成
+	if err := s.engine.UpdateStrategyParameters(req.Strategy, req.Parameters); err != nil {
+		return nil, errorf(CodeInvalidArgument, "%v", err)
+	}
+
+	info := s.engine.GetAvailableStrategies()[req.Strategy]
+	return &UpdateStrategyParamsResponse{Strategy: strategyMessage(req.Strategy, info)}, nil
+}
+
+// PlaceManualOrder 提交手动订单并等待执行结果
+func (s *Server) PlaceManualOrder(ctx context.Context, req *PlaceManualOrderRequest) (*PlaceManualOrderResponse, error) {
+	order, err := manualOrder(req)
+	if err != nil {
+		return nil, err
+	}
+	if !s.engine.IsRunning() {
+		return nil, errorf(CodeFailedPrecondition, "量化引擎未运行")
+	}
+
+	filled, err := s.engine.PlaceOrder(req.Account, order, core.OrderOrigin{Source: "api", Operator: req.Operator, Reason: req.Reason})
+	if err != nil {
+		return nil, errorf(CodeFailedPrecondition, "%v", err)
+	}
+	return &PlaceManualOrderResponse{Order: orderMessage(filled)}, nil
+}
+
+// GetSymbolLists 获取交易名单
+func (s *Server) GetSymbolLists(ctx context.Context, req *GetSymbolListsRequest) (*GetSymbolListsResponse, error) {
+	return symbolListsMessage(s.engine.GetSymbolLists()), nil
+}
+
+// UpdateSymbolList 修改交易名单
+func (s *Server) UpdateSymbolList(ctx context.Context, req *UpdateSymbolListRequest) (*GetSymbolListsResponse, error) {
+	kind := trading.SymbolListKind(strings.ToLower(req.List))
+	if kind != trading.Blacklist && kind != trading.Whitelist {
+		return nil, errorf(CodeInvalidArgument, "list 只能是 blacklist 或 whitelist")
+	}
+	if len(req.Add) == 0 && len(req.Remove) == 0 {
+		return nil, errorf(CodeInvalidArgument, "add 和 remove 不能同时为空")
+	}
+
+	if err := s.engine.UpdateSymbolList(kind, req.Account, req.Add, req.Remove); err != nil {
+		return nil, errorf(CodeNotFound, "%v", err)
+	}
+	return symbolListsMessage(s.engine.GetSymbolLists()), nil
+}
+
+// HaltTrading 紧急停止交易
+func (s *Server) HaltTrading(ctx context.Context, req *HaltTradingRequest) (*trading.HaltState, error) {
+	reason := req.Reason
+	if reason == "" {
+		reason = "控制API手动停止"
+	}
+	state := s.engine.Halt(reason)
+	return &state, nil
+}
+
+// ResumeTrading 解除紧急停止
+func (s *Server) ResumeTrading(ctx context.Context, req *ResumeTradingRequest) (*trading.HaltState, error) {
+	state := s.engine.Resume()
+	return &state, nil
+}
+
+// EnableStrategy 恢复被策略监控停用的策略
+func (s *Server) EnableStrategy(ctx context.Context, req *EnableStrategyRequest) (*EnableStrategyResponse, error) {
+	if req.Strategy == "" {
+		return nil, errorf(CodeInvalidArgument, "strategy 不能为空")
+	}
+	if err := s.engine.EnableStrategy(req.Strategy); err != nil {
+		return nil, errorf(CodeFailedPrecondition, "%v", err)
+	}
+	return &EnableStrategyResponse{Disabled: s.engine.DisabledStrategies()}, nil
+}
+
+// GetCycleHistory 查询交易循环记录
+func (s *Server) GetCycleHistory(ctx context.Context, req *GetCycleHistoryRequest) (*GetCycleHistoryResponse, error) {
+	if req.Limit < 0 {
+		return nil, errorf(CodeInvalidArgument, "limit 不能为负数")
+	}
+	cycles, err := s.engine.CycleHistory(core.CycleQuery{
+		From:   req.From,
+		To:     req.To,
+		Symbol: strings.ToUpper(req.Symbol),
+		Limit:  req.Limit,
+	})
+	if err != nil {
+		return nil, errorf(CodeFailedPrecondition, "%v", err)
+	}
+	if cycles == nil {
+		cycles = []core.CycleRecord{}
+	}
+	return &GetCycleHistoryResponse{Cycles: cycles}, nil
+}
+
+// ExplainCycles 查询交易循环的决策说明
+func (s *Server) ExplainCycles(ctx context.Context, req *GetCycleHistoryRequest) (*ExplainCyclesResponse, error) {
+	if req.Limit < 0 {
+		return nil, errorf(CodeInvalidArgument, "limit 不能为负数")
+	}
+	explanations, err := s.engine.ExplainCycles(core.CycleQuery{
+		From:   req.From,
+		To:     req.To,
+		Symbol: strings.ToUpper(req.Symbol),
+		Limit:  req.Limit,
+	})
+	if err != nil {
+		return nil, errorf(CodeFailedPrecondition, "%v", err)
+	}
+	if explanations == nil {
+		explanations = []core.CycleExplanation{}
+	}
+	return &ExplainCyclesResponse{Explanations: explanations}, nil
+}
+
+// GetDataProviders 获取各资产类别当前的数据源
+func (s *Server) GetDataProviders(ctx context.Context, req *GetDataProvidersRequest) (*GetDataProvidersResponse, error) {
+	active, available := s.engine.GetDataProviders()
+	return &GetDataProvidersResponse{Active: active, Available: available}, nil
+}
+
+// SwitchDataProvider 切换资产类别的数据源，等待旧数据源进行中的请求完成后返回
+func (s *Server) SwitchDataProvider(ctx context.Context, req *SwitchDataProviderRequest) (*data.ProviderSwap, error) {
+	if req.AssetClass == "" || req.Provider == "" {
+		return nil, errorf(CodeInvalidArgument, "asset_class 和 provider 不能为空")
+	}
+	swap, err := s.engine.SwitchDataProvider(data.AssetClass(strings.ToLower(req.AssetClass)), req.Provider)
+	if err != nil {
+		return nil, errorf(CodeInvalidArgument, "%v", err)
+	}
+	return swap, nil
+}
+
+// GetLeaderboard 获取策略排行榜
+func (s *Server) GetLeaderboard(ctx context.Context, req *GetLeaderboardRequest) (*trading.LeaderboardReport, error) {
+	switch req.RankBy {
+	case "", "sharpe", "pnl", "drawdown":
+	default:
+		return nil, errorf(CodeInvalidArgument, "rank_by 只能是 sharpe、pnl 或 drawdown")
+	}
+	board, err := s.engine.GetLeaderboard(req.RankBy, req.RankWindow)
+	if err != nil {
+		return nil, errorf(CodeFailedPrecondition, "%v", err)
+	}
+	return board, nil
+}
+
+// StreamEvents 推送引擎事件，直到客户端断开或服务停止
+func (s *Server) StreamEvents(req *StreamEventsRequest, stream EventStream) error {
+	kinds := make(map[string]bool, len(req.Kinds))
+	for _, kind := range req.Kinds {
+		kinds[strings.ToLower(strings.TrimSpace(kind))] = true
+	}
+
+	events, unsubscribe := s.engine.SubscribeEvents(s.config.EventBuffer)
+	defer unsubscribe()
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case message, ok := <-events:
+			if !ok {
+				return nil
+			}
+			if len(kinds) > 0 && !kinds[string(message.Kind)] {
+				continue
+			}
+			if err := stream.Send(eventMessage(message)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// Deposit 向模拟账户入金
+func (s *Server) Deposit(ctx context.Context, req *AccountFundsRequest) (*AccountFundsResponse, error) {
+	amount, err := fundsAmount(req)
+	if err != nil {
+		return nil, err
+	}
+	balance, err := s.engine.Deposit(req.Account, amount)
+	if err != nil {
+		return nil, errorf(CodeFailedPrecondition, "%v", err)
+	}
+	log.Printf("已通过控制API入金: 账户=%s, 金额=%s, 余额=%s", req.Account, amount, balance)
+	return &AccountFundsResponse{Account: req.Account, Balance: balance.String()}, nil
+}
+
+// Withdraw 从模拟账户出金
+func (s *Server) Withdraw(ctx context.Context, req *AccountFundsRequest) (*AccountFundsResponse, error) {
+	amount, err := fundsAmount(req)
+	if err != nil {
+		return nil, err
+	}
+	balance, err := s.engine.Withdraw(req.Account, amount)
+	if err != nil {
+		return nil, errorf(CodeFailedPrecondition, "%v", err)
+	}
+	log.Printf("已通过控制API出金: 账户=%s, 金额=%s, 余额=%s", req.Account, amount, balance)
+	return &AccountFundsResponse{Account: req.Account, Balance: balance.String()}, nil
+}
+
+// SetBalance 设置模拟账户余额
+func (s *Server) SetBalance(ctx context.Context, req *AccountFundsRequest) (*AccountFundsResponse, error) {
+	amount, err := fundsAmount(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.engine.SetAccountBalance(req.Account, amount); err != nil {
+		return nil, errorf(CodeFailedPrecondition, "%v", err)
+	}
+	log.Printf("已通过控制API设置余额: 账户=%s, 余额=%s", req.Account, amount)
+	return &AccountFundsResponse{Account: req.Account, Balance: amount.String()}, nil
+}
+
+// ResetAccount 将模拟账户重置为初始资金并清空持仓
+func (s *Server) ResetAccount(ctx context.Context, req *ResetAccountRequest) (*AccountFundsResponse, error) {
+	if req.Account == "" {
+		return nil, errorf(CodeInvalidArgument, "account 不能为空")
+	}
+	balance, err := s.engine.ResetAccount(req.Account)
+	if err != nil {
+		return nil, errorf(CodeFailedPrecondition, "%v", err)
+	}
+	log.Printf("已通过控制API重置账户: 账户=%s, 余额=%s", req.Account, balance)
+	return &AccountFundsResponse{Account: req.Account, Balance: balance.String()}, nil
+}
+
+// ClosePosition 按比例平仓并等待平仓订单的执行结果
+func (s *Server) ClosePosition(ctx context.Context, req *ClosePositionRequest) (*PlaceManualOrderResponse, error) {
+	if req.Account == "" || req.Symbol == "" {
+		return nil, errorf(CodeInvalidArgument, "account 和 symbol 不能为空")
+	}
+	percent := req.Percent
+	if percent == 0 {
+		percent = 1
+	}
+	if percent < 0 || percent > 1 {
+		return nil, errorf(CodeInvalidArgument, "percent 必须在 (0, 1] 范围内")
+	}
+	if !s.engine.IsRunning() {
+		return nil, errorf(CodeFailedPrecondition, "量化引擎未运行")
+	}
+
+	order, err := s.engine.ClosePosition(req.Account, req.Symbol, percent)
+	if err != nil {
+		return nil, errorf(CodeFailedPrecondition, "%v", err)
+	}
+	log.Printf("已通过控制API平仓: 账户=%s, 标的=%s, 比例=%.2f, 订单ID=%s", req.Account, order.Symbol, percent, order.ID)
+	return &PlaceManualOrderResponse{Order: orderMessage(order)}, nil
+}
+
+// ExportSnapshot 导出持仓快照
+func (s *Server) ExportSnapshot(ctx context.Context, req *ExportSnapshotRequest) (*trading.PositionSnapshot, error) {
+	snapshot, err := s.engine.ExportSnapshot()
+	if err != nil {
+		return nil, errorf(CodeFailedPrecondition, "%v", err)
+	}
+	return snapshot, nil
+}
+
+// ListApprovals 列出确认请求
+func (s *Server) ListApprovals(ctx context.Context, req *ListApprovalsRequest) (*ListApprovalsResponse, error) {
+	status := trading.ApprovalPending
+	if req.All {
+		status = ""
+	}
+	approvals, err := s.engine.ListApprovals(status)
+	if err != nil {
+		return nil, errorf(CodeFailedPrecondition, "%v", err)
+	}
+	if approvals == nil {
+		approvals = []trading.ApprovalRequest{}
+	}
+	return &ListApprovalsResponse{Approvals: approvals}, nil
+}
+
+// ApproveOrder 确认订单
+func (s *Server) ApproveOrder(ctx context.Context, req *DecideApprovalRequest) (*trading.ApprovalRequest, error) {
+	return s.decideApproval(req, true)
+}
+
+// RejectOrder 拒绝订单
+func (s *Server) RejectOrder(ctx context.Context, req *DecideApprovalRequest) (*trading.ApprovalRequest, error) {
+	return s.decideApproval(req, false)
+}
+
+// decideApproval 记录确认结果
+func (s *Server) decideApproval(req *DecideApprovalRequest, approve bool) (*trading.ApprovalRequest, error) {
+	if req.ID == "" {
+		return nil, errorf(CodeInvalidArgument, "id 不能为空")
+	}
+	by := req.By
+	if by == "" {
+		by = "api"
+	}
+	request, err := s.engine.DecideApproval(req.ID, by, req.Reason, approve)
+	if err != nil {
+		return nil, errorf(CodeFailedPrecondition, "%v", err)
+	}
+	log.Printf("已通过控制API处理确认请求: ID=%s, 状态=%s, 处理人=%s", request.ID, request.Status, by)
+	return &request, nil
+}
+
+// fundsAmount 校验资金调整请求的账户和金额
+func fundsAmount(req *AccountFundsRequest) (decimal.Decimal, error) {
+	if req.Account == "" || req.Amount == "" {
+		return decimal.Zero, errorf(CodeInvalidArgument, "account 和 amount 不能为空")
+	}
+	amount, err := parseDecimal("amount", req.Amount)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	if amount.IsNegative() {
+		return decimal.Zero, errorf(CodeInvalidArgument, "amount 不能为负数")
+	}
+	return amount, nil
+}
+
+// manualOrder 校验手动下单请求并转换为订单
+func manualOrder(req *PlaceManualOrderRequest) (trading.Order, error) {
+	if req.Account == "" || req.Symbol == "" {
+		return trading.Order{}, errorf(CodeInvalidArgument, "account 和 symbol 不能为空")
+	}
+
+	order := trading.Order{
+		Symbol:      strings.ToUpper(req.Symbol),
+		Side:        trading.OrderSide(strings.ToLower(req.Side)),
+		Type:        trading.OrderType(strings.ToLower(req.Type)),
+		TimeInForce: trading.TimeInForce(strings.ToLower(req.TimeInForce)),
+		Strategy:    req.Strategy,
+	}
+	if order.Type == "" {
+		order.Type = trading.MarketOrder
+	}
+
+	var err error
+	if order.Quantity, err = parseDecimal("quantity", req.Quantity); err != nil {
+		return trading.Order{}, err
+	}
+	if order.Price, err = parseDecimal("price", req.Price); err != nil {
+		return trading.Order{}, err
+	}
+	if order.StopPrice, err = parseDecimal("stop_price", req.StopPrice); err != nil {
+		return trading.Order{}, err
+	}
+
+	if err := core.ValidateManualOrder(order); err != nil {
+		return trading.Order{}, errorf(CodeInvalidArgument, "%v", err)
+	}
+	return order, nil
+}
+
+// parseDecimal 解析十进制字符串，空字符串为0
+func parseDecimal(field, value string) (decimal.Decimal, error) {
+	if value == "" {
+		return decimal.Zero, nil
+	}
+	parsed, err := decimal.NewFromString(value)
+	if err != nil {
+		return decimal.Zero, errorf(CodeInvalidArgument, "%s 格式无效: %v", field, err)
+	}
+	return parsed, nil
+}
+
+// strategyMessage 转换为API策略信息，名称使用策略注册名（与配置中的 strategy.active 相同）
+func strategyMessage(name string, info strategy.StrategyInfo) StrategyInfo {
+	return StrategyInfo{Name: name, Description: info.Description, Parameters: info.Parameters, Metadata: info.Metadata}
+}
+
+// symbolListsMessage 转换为API交易名单消息
+func symbolListsMessage(status trading.SymbolListsStatus) *GetSymbolListsResponse {
+	return &GetSymbolListsResponse{Global: status.Global, Accounts: status.Accounts}
+}
+
+// methodPath 方法的请求路径
+func methodPath(method string) string {
+	return "/" + ServiceName + "/" + method
+}
+
+// unary 包装一元方法：POST 请求体为 JSON 编码的请求消息（可为空），响应为 JSON 编码的响应消息或错误
+func unary[Req, Resp any](method func(context.Context, *Req) (*Resp, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			writeError(w, errorf(CodeInvalidArgument, "只支持 POST 请求"))
+			return
+		}
+
+		req := new(Req)
+		if err := decodeRequest(r, req); err != nil {
+			writeError(w, err)
+			return
+		}
+
+		resp, err := method(r.Context(), req)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
+
+// handleStreamEvents 事件流：响应为逐行 JSON 编码的事件
+func (s *Server) handleStreamEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeError(w, errorf(CodeInvalidArgument, "只支持 POST 请求"))
+		return
+	}
+
+	req := &StreamEventsRequest{}
+	if err := decodeRequest(r, req); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, errorf(CodeInternal, "连接不支持流式响应"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	stream := &httpEventStream{ctx: r.Context(), encoder: json.NewEncoder(w), flusher: flusher}
+	if err := s.StreamEvents(req, stream); err != nil {
+		log.Printf("事件流中断: %v", err)
+	}
+}
+
+// httpEventStream 基于HTTP分块响应的事件流
+type httpEventStream struct {
+	ctx     context.Context
+	encoder *json.Encoder
+	flusher http.Flusher
+}
+
+// Context 事件流的上下文，客户端断开时取消
+func (st *httpEventStream) Context() context.Context {
+	return st.ctx
+}
+
+// Send 发送一个事件
+func (st *httpEventStream) Send(event *Event) error {
+	if err := st.encoder.Encode(event); err != nil {
+		return err
+	}
+	st.flusher.Flush()
+	return nil
+}
+
+// decodeRequest 解析请求体，空请求体视为空消息
+func decodeRequest(r *http.Request, req interface{}) error {
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(req); err != nil && !errors.Is(err, io.EOF) {
+		return errorf(CodeInvalidArgument, "请求格式无效: %v", err)
+	}
+	return nil
+}
+
+// writeError 写入错误响应，非API错误按内部错误处理
+func writeError(w http.ResponseWriter, err error) {
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		apiErr = &Error{Code: CodeInternal, Message: err.Error()}
+	}
+	writeJSON(w, apiErr.Code.httpStatus(), apiErr)
+}
+
+// writeJSON 写入JSON响应
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		log.Printf("写入控制API响应失败: %v", err)
+	}
+}
+
+var _ ControlServiceServer = (*Server)(nil)
@@ -0,0 +1,274 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Kind 审计事件类型
+type Kind string
+
+const (
+	KindSignal         Kind = "signal"          // 交易引擎收到的交易信号
+	KindRisk           Kind = "risk"            // 风控、交易名单、资金分配、敞口、限流等检查的决定
+	KindSubmit         Kind = "submit"          // 订单提交到经纪商
+	KindBrokerResponse Kind = "broker_response" // 经纪商对下单请求的响应
+	KindFill           Kind = "fill"            // 成交（部分成交时为本次新增的成交）
+	KindCancel         Kind = "cancel"          // 撤单
+	KindReplace        Kind = "replace"         // 改单（修改挂单的价格和数量）
+)
+
+// 风控检查的决定
+const (
+	DecisionApproved = "approved"
+	DecisionResized  = "resized"
+	DecisionRejected = "rejected"
+)
+
+// Event 一条审计事件。CorrelationID 为客户端订单号，同一信号产生的检查、下单、成交和撤单使用同一个值
+type Event struct {
+	Seq           int64                  `json:"seq"`
+	Time          time.Time              `json:"time"`
+	Kind          Kind                   `json:"kind"`
+	CorrelationID string                 `json:"correlation_id,omitempty"`
+	Account       string                 `json:"account,omitempty"`
+	Strategy      string                 `json:"strategy,omitempty"`
+	Symbol        string                 `json:"symbol,omitempty"`
+	OrderID       string                 `json:"order_id,omitempty"`
+	Side          string                 `json:"side,omitempty"`
+	Quantity      decimal.Decimal        `json:"quantity"`
+	Price         decimal.Decimal        `json:"price"`
+	Status        string                 `json:"status,omitempty"`   // 订单状态
+	Check         string                 `json:"check,omitempty"`    // 风控事件的检查项
+	Decision      string                 `json:"decision,omitempty"` // 风控事件的决定
+	Message       string                 `json:"message,omitempty"`  // 原因或错误
+	Details       map[string]interface{} `json:"details,omitempty"`
+	PrevHash      string                 `json:"prev_hash"`
+	Hash          string                 `json:"hash,omitempty"` // sha256(prev_hash + 不含 hash 字段的记录)
+}
+
+// hashSuffix 记录行末尾的哈希字段，长度固定
+const hashSuffix = `,"hash":"` // 后接64位十六进制哈希和 "}
+
+// Journal 只追加的审计日志：每条记录按行写入JSON，并以前一条记录的哈希串成链，修改或删除任何一条都能被 Verify 发现
+type Journal struct {
+	path     string
+	seq      int64
+	lastHash string
+	mutex    sync.Mutex
+}
+
+// Open 打开审计日志，从最后一条记录继续序号和哈希链
+func Open(path string) (*Journal, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("创建审计日志目录失败: %w", err)
+	}
+	journal := &Journal{path: path}
+	events, err := Read(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(events) > 0 {
+		last := events[len(events)-1]
+		journal.seq, journal.lastHash = last.Seq, last.Hash
+	}
+	return journal, nil
+}
+
+// Path 审计日志文件
+func (j *Journal) Path() string {
+	return j.path
+}
+
+// Record 追加一条事件，分配序号并计算哈希
+func (j *Journal) Record(event Event) error {
+	if j == nil {
+		return nil
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	event.Seq = j.seq + 1
+	event.PrevHash = j.lastHash
+	event.Hash = ""
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("序列化审计事件失败: %w", err)
+	}
+	hash := chainHash(event.PrevHash, body)
+	line := append(body[:len(body)-1], []byte(hashSuffix+hash+`"}`+"\n")...)
+
+	file, err := os.OpenFile(j.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("打开审计日志失败: %w", err)
+	}
+	defer file.Close()
+	if _, err := file.Write(line); err != nil {
+		return fmt.Errorf("写入审计日志失败: %w", err)
+	}
+
+	j.seq, j.lastHash = event.Seq, hash
+	return nil
+}
+
+// chainHash 记录的哈希：前一条记录的哈希与本条不含 hash 字段的JSON
+func chainHash(prevHash string, body []byte) string {
+	sum := sha256.Sum256(append([]byte(prevHash), body...))
+	return hex.EncodeToString(sum[:])
+}
+
+// Read 读取全部事件，文件不存在时返回空
+func Read(path string) ([]Event, error) {
+	var events []Event
+	err := scan(path, func(line int, raw []byte) error {
+		var event Event
+		if err := json.Unmarshal(raw, &event); err != nil {
+			return fmt.Errorf("第 %d 行无法解析: %w", line, err)
+		}
+		events = append(events, event)
+		return nil
+	})
+	return events, err
+}
+
+// scan 逐行读取审计日志
+func scan(path string, fn func(line int, raw []byte) error) error {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("打开审计日志失败: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		if err := fn(line, scanner.Bytes()); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("读取审计日志失败: %w", err)
+	}
+	return nil
+}
+
+// Verify 校验哈希链和序号，返回校验通过的记录数；发现被修改、删除或插入的记录时返回错误
+func Verify(path string) (int, error) {
+	count := 0
+	prevHash := ""
+	err := scan(path, func(line int, raw []byte) error {
+		index := bytes.LastIndex(raw, []byte(hashSuffix))
+		if index < 0 || len(raw)-index != len(hashSuffix)+sha256.Size*2+2 {
+			return fmt.Errorf("第 %d 行缺少哈希", line)
+		}
+		body := append(append([]byte{}, raw[:index]...), '}')
+		hash := string(raw[index+len(hashSuffix) : len(raw)-2])
+
+		var event Event
+		if err := json.Unmarshal(raw, &event); err != nil {
+			return fmt.Errorf("第 %d 行无法解析: %w", line, err)
+		}
+		if event.Seq != int64(count+1) {
+			return fmt.Errorf("第 %d 行序号为 %d，应为 %d（记录被删除或插入）", line, event.Seq, count+1)
+		}
+		if event.PrevHash != prevHash {
+			return fmt.Errorf("第 %d 行（序号 %d）与前一条记录的哈希不一致", line, event.Seq)
+		}
+		if chainHash(prevHash, body) != hash {
+			return fmt.Errorf("第 %d 行（序号 %d）的内容与哈希不一致（记录被修改）", line, event.Seq)
+		}
+		prevHash = hash
+		count++
+		return nil
+	})
+	return count, err
+}
+
+// Filter 查询条件，为空的条件不参与筛选
+type Filter struct {
+	From          time.Time
+	To            time.Time
+	Kinds         []Kind
+	CorrelationID string
+	OrderID       string
+	Account       string
+	Strategy      string
+	Symbol        string
+	Limit         int // 大于0时只返回最近的 Limit 条
+}
+
+// match 事件是否满足条件
+func (f Filter) match(event Event) bool {
+	if !f.From.IsZero() && event.Time.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && event.Time.After(f.To) {
+		return false
+	}
+	if len(f.Kinds) > 0 {
+		found := false
+		for _, kind := range f.Kinds {
+			found = found || kind == event.Kind
+		}
+		if !found {
+			return false
+		}
+	}
+	if f.CorrelationID != "" && event.CorrelationID != f.CorrelationID {
+		return false
+	}
+	if f.OrderID != "" && event.OrderID != f.OrderID {
+		return false
+	}
+	if f.Account != "" && event.Account != f.Account {
+		return false
+	}
+	if f.Strategy != "" && event.Strategy != f.Strategy {
+		return false
+	}
+	if f.Symbol != "" && !strings.EqualFold(event.Symbol, f.Symbol) {
+		return false
+	}
+	return true
+}
+
+// Query 按条件查询事件，按序号升序
+func Query(path string, filter Filter) ([]Event, error) {
+	events, err := Read(path)
+	if err != nil {
+		return nil, err
+	}
+	matched := events[:0]
+	for _, event := range events {
+		if filter.match(event) {
+			matched = append(matched, event)
+		}
+	}
+	if filter.Limit > 0 && len(matched) > filter.Limit {
+		matched = matched[len(matched)-filter.Limit:]
+	}
+	return matched, nil
+}
@@ -1,13 +1,22 @@
 package backtest
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"math"
+	"strings"
 	"time"
 
+	"agent-quant-system/internal/commission"
 	"agent-quant-system/internal/data"
+	"agent-quant-system/internal/instrument"
+	"agent-quant-system/internal/money"
+	"agent-quant-system/internal/sizing"
+	"agent-quant-system/internal/slippage"
 	"agent-quant-system/internal/strategy"
+
+	"github.com/shopspring/decimal"
 )
 
 // Backtester 回测器
@@ -17,6 +26,18 @@ type Backtester struct {
 	initialCapital float64
 	commissionRate float64
 	slippageRate   float64
+	slippageModel  *SlippageModel
+	impactModel    slippage.Model
+	commission     commission.Model
+	precision      *money.PrecisionTable
+	maxEntries     int
+	limits         Limits
+	checkpoint     CheckpointOptions
+	margin         MarginOptions
+	instrument     instrument.Instrument
+	sizing         sizing.Policy
+	ticks          *TickOptions
+	incremental    bool
 }
 
 // NewBacktester 创建回测器
@@ -27,9 +48,71 @@ func NewBacktester(strategy strategy.Strategy, dataManager *data.DataManager, in
 		initialCapital: initialCapital,
 		commissionRate: commissionRate,
 		slippageRate:   slippageRate,
+		maxEntries:     1,
+		incremental:    true,
 	}
 }
 
+// SetPrecision 设置成交价格、数量和金额的精度表
+func (bt *Backtester) SetPrecision(precision *money.PrecisionTable) {
+	bt.precision = precision
+}
+
+// SetSlippageModel 设置由实盘成交校准的滑点模型，为空时使用固定滑点率
+func (bt *Backtester) SetSlippageModel(model *SlippageModel) {
+	bt.slippageModel = model
+}
+
+// SetImpactModel 设置配置的滑点模型，按每笔成交的数量、K线振幅和成交量估算滑点，替代固定滑点率；校准滑点模型优先
+func (bt *Backtester) SetImpactModel(model slippage.Model) {
+	bt.impactModel = model
+}
+
+// SetCommissionModel 设置佣金模型，为空时按佣金率比例收取
+func (bt *Backtester) SetCommissionModel(model commission.Model) {
+	bt.commission = model
+}
+
+// commissionModel 回测使用的佣金模型
+func (bt *Backtester) commissionModel() commission.Model {
+	if bt.commission != nil {
+		return bt.commission
+	}
+	return commission.Percentage{Rate: money.FromFloat(bt.commissionRate)}
+}
+
+// affordable 以 price 买入时资金可负担的最大数量：先按佣金率估算，再按佣金模型（每股、分档、最低佣金等）逐步缩减
+func (bt *Backtester) affordable(capital, price decimal.Decimal, symbol string, precision money.Precision) decimal.Decimal {
+	if !price.IsPositive() || !capital.IsPositive() {
+		return decimal.Zero
+	}
+	model := bt.commissionModel()
+	multiplier := bt.multiplier()
+	quantity := precision.RoundQuantity(capital.Div(price.Mul(multiplier).Mul(decimal.NewFromInt(1).Add(money.FromFloat(bt.commissionRate)))))
+	for i := 0; i < 10 && quantity.IsPositive(); i++ {
+		fill := commission.Fill{Symbol: symbol, Buy: true, Quantity: quantity, Price: price, Multiplier: multiplier}
+		cost := quantity.Mul(price).Mul(multiplier).Add(model.Commission(fill))
+		if cost.LessThanOrEqual(capital) {
+			return quantity
+		}
+		quantity = precision.RoundQuantity(quantity.Mul(capital).Div(cost))
+	}
+	return decimal.Zero
+}
+
+// SetMaxEntries 设置每个标的最多同时持有的开仓笔数，大于1时允许加仓
+func (bt *Backtester) SetMaxEntries(maxEntries int) {
+	if maxEntries < 1 {
+		maxEntries = 1
+	}
+	bt.maxEntries = maxEntries
+}
+
+// SetLimits 设置运行时间、内存和K线数预算
+func (bt *Backtester) SetLimits(limits Limits) {
+	bt.limits = limits
+}
+
 // BacktestResult 回测结果
 type BacktestResult struct {
 	StrategyName         string        `json:"strategy_name"`
@@ -56,6 +139,41 @@ type BacktestResult struct {
 	Slippage             float64       `json:"slippage"`
 	EquityCurve          []EquityPoint `json:"equity_curve"`
 	TradeHistory         []TradeRecord `json:"trade_history"`
+
+	// 同一标的同一区间的买入持有基准及相对指标
+	Benchmark *BenchmarkResult `json:"benchmark,omitempty"`
+
+	// 保证金交易：强制平仓次数、借入资金的利息和最高保证金使用率，未使用杠杆时为0
+	MarginCalls          int     `json:"margin_calls,omitempty"`
+	Interest             float64 `json:"interest,omitempty"`
+	MaxMarginUtilization float64 `json:"max_margin_utilization,omitempty"`
+
+	// 期货连续合约的换月次数，换月佣金计入 Commission
+	Rolls int `json:"rolls,omitempty"`
+
+	// 逐笔回测的撮合统计，K线回测时为空
+	Execution *ExecutionStats `json:"execution,omitempty"`
+
+	// 超出资源预算时提前中止，指标只覆盖已处理的K线，EndDate 为最后处理的K线日期
+	Aborted     bool   `json:"aborted,omitempty"`
+	AbortReason string `json:"abort_reason,omitempty"`
+}
+
+// BenchmarkResult 买入持有基准：第一根可交易K线全仓买入并持有到结束
+type BenchmarkResult struct {
+	FinalCapital float64       `json:"final_capital"`
+	TotalReturn  float64       `json:"total_return"`
+	AnnualReturn float64       `json:"annual_return"`
+	MaxDrawdown  float64       `json:"max_drawdown"`
+	SharpeRatio  float64       `json:"sharpe_ratio"`
+	SortinoRatio float64       `json:"sortino_ratio"`
+	EquityCurve  []EquityPoint `json:"equity_curve"`
+
+	// 策略相对基准的指标
+	ExcessReturn     float64 `json:"excess_return"`     // 策略总收益率减基准总收益率
+	Beta             float64 `json:"beta"`              // 策略收益对基准收益的贝塔
+	Correlation      float64 `json:"correlation"`       // 策略与基准收益的相关系数
+	InformationRatio float64 `json:"information_ratio"` // 超额收益均值 / 跟踪误差
 }
 
 // EquityPoint 净值曲线点
@@ -80,13 +198,20 @@ type TradeRecord struct {
 
 // Run 运行回测
 func (bt *Backtester) Run(symbol, startDate, endDate string) (*BacktestResult, error) {
+	if bt.ticks != nil {
+		return bt.runTicks(symbol, startDate, endDate)
+	}
 	log.Printf("开始回测: 标的=%s, 开始日期=%s, 结束日期=%s", symbol, startDate, endDate)
+	budget := newBudget(bt.limits)
 
 	// 获取历史数据
 	df, err := bt.dataManager.GetMarketData(symbol, startDate, endDate)
 	if err != nil {
 		return nil, fmt.Errorf("获取历史数据失败: %w", err)
 	}
+	if err := budget.loaded("获取历史数据"); err != nil {
+		return nil, err
+	}
 
 	// 验证数据
 	if err := bt.dataManager.ValidateData(df); err != nil {
@@ -95,21 +220,32 @@ func (bt *Backtester) Run(symbol, startDate, endDate string) (*BacktestResult, e
 
 	// 初始化回测状态
 	state := &BacktestState{
-		Capital:      bt.initialCapital,
-		Position:     0,
-		EntryPrice:   0,
-		EntryTime:    time.Time{},
+		Capital:      money.FromFloat(bt.initialCapital),
 		EquityCurve:  make([]EquityPoint, 0),
 		TradeHistory: make([]TradeRecord, 0),
+		multiplier:   bt.multiplier(),
 	}
 
-	// 执行回测
-	if err := bt.executeBacktest(df, state); err != nil {
+	// 执行回测，超出资源预算时用已处理的部分生成报告
+	var exceeded *budgetExceeded
+	key := runKey{symbol: symbol, startDate: startDate, endDate: endDate}
+	err = bt.executeBacktest(df, bt.precision.For(symbol), key, budget, state)
+	if err != nil && !errors.As(err, &exceeded) {
 		return nil, fmt.Errorf("执行回测失败: %w", err)
 	}
+	if exceeded != nil {
+		log.Printf("回测提前中止，生成部分结果: %v", exceeded)
+		if n := len(state.EquityCurve); n > 0 {
+			endDate = bt.dataManager.Session(symbol).FormatDate(state.EquityCurve[n-1].Date)
+		}
+	}
 
 	// 生成报告
 	result := bt.generateReport(symbol, startDate, endDate, state)
+	if exceeded != nil {
+		result.Aborted = true
+		result.AbortReason = exceeded.Error()
+	}
 
 	log.Printf("回测完成: 总收益=%.2f%%, 最大回撤=%.2f%%, 夏普比率=%.2f",
 		result.TotalReturn*100, result.MaxDrawdown*100, result.SharpeRatio)
@@ -117,182 +253,405 @@ func (bt *Backtester) Run(symbol, startDate, endDate string) (*BacktestResult, e
 	return result, nil
 }
 
-// BacktestState 回测状态
+// BacktestState 回测状态，资金和持仓使用十进制计算，统计指标使用浮点数
 type BacktestState struct {
-	Capital      float64
-	Position     float64
-	EntryPrice   float64
-	EntryTime    time.Time
+	Capital      decimal.Decimal
+	Entries      []*Entry // 未平仓的持仓，按开仓顺序
+	LastPrice    decimal.Decimal
+	Commission   decimal.Decimal
+	Slippage     decimal.Decimal
 	EquityCurve  []EquityPoint
 	TradeHistory []TradeRecord
+
+	BenchmarkCurve []EquityPoint // 买入持有基准的净值曲线，与 EquityCurve 对齐
+
+	// 保证金交易的累计利息、强制平仓次数和最高保证金使用率
+	Interest             decimal.Decimal
+	MarginCalls          int
+	MaxMarginUtilization float64
+
+	// 期货连续合约的换月次数
+	Rolls int
+
+	multiplier decimal.Decimal // 合约乘数，期货的持仓价值和盈亏按乘数放大
+
+	barState strategy.BarState // 增量模式下策略的增量状态
 }
 
-// executeBacktest 执行回测逻辑
-func (bt *Backtester) executeBacktest(df data.DataFrame, state *BacktestState) error {
-	closeData := df["close"]
-	volumeData := df["volume"]
-	timestampData := df["timestamp"]
+// executeBacktest 执行回测逻辑：按K线推送事件，依次处理 K线 -> 信号 -> 订单 -> 成交；
+// 设置了检查点时定期保存进度，超出资源预算中止时也保存，恢复时从检查点的下一根K线继续
+func (bt *Backtester) executeBacktest(df data.DataFrame, precision money.Precision, key runKey, budget *budget, state *BacktestState) error {
+	bars, err := barsFromDataFrame(df)
+	if err != nil {
+		return fmt.Errorf("解析K线失败: %w", err)
+	}
+	if err := budget.loaded("解析K线"); err != nil {
+		return err
+	}
 
-	dataLength := len(closeData)
+	warmup := bt.warmupBars()
+	if len(bars) < warmup {
+		return fmt.Errorf("数据长度不足: 需要 %d 根K线, 实际 %d", warmup, len(bars))
+	}
 
-	for i := int(bt.strategy.GetParameters()["long_period"].(float64)); i < dataLength; i++ {
-		// 创建当前时间窗口的数据
-		windowData := bt.createDataWindow(df, i)
+	book := NewOrderBook(bt.commissionRate, bt.slippageRate, precision)
+	book.SetSlippageModel(bt.slippageModel)
+	book.SetImpactModel(bt.impactModel)
+	book.SetCommissionModel(bt.commissionModel())
+	book.SetInstrument(bt.instrument)
+	queue := &EventQueue{}
+	var benchmark *buyAndHold
 
-		// 生成交易信号
-		signals, err := bt.strategy.GenerateSignals(windowData, nil)
-		if err != nil {
-			log.Printf("生成信号失败: %v", err)
-			continue
+	start := 0
+	checkpoint, err := bt.resumePoint(key, bars)
+	if err != nil {
+		return err
+	}
+	if checkpoint != nil {
+		start, benchmark = checkpoint.restore(book, state)
+		log.Printf("从检查点恢复回测: 进度=%d/%d, 最后K线=%s, 保存于 %s",
+			start, len(bars), checkpoint.LastBarTime.Format("2006-01-02 15:04"), checkpoint.SavedAt.Format("2006-01-02 15:04:05"))
+	}
+	// 增量状态不保存在检查点中，恢复时用已处理的K线重建
+	if state.barState = bt.newBarState(key.symbol); state.barState != nil {
+		for i := 0; i < start; i++ {
+			if _, err := state.barState.OnBar(bars[i].strategyBar(), nil); err != nil {
+				return fmt.Errorf("重建增量状态失败: %w", err)
+			}
 		}
+	}
+
+	for i := start; i < len(bars); i++ {
+		bar := &bars[i]
+		queue.Push(Event{Type: BarEventType, Time: bar.Timestamp, Bar: bar})
 
-		// 处理交易信号
-		currentPrice := closeData[i].(float64)
-		currentVolume := volumeData[i].(int64)
-		currentTime := timestampData[i].(time.Time)
+		if i > 0 {
+			bt.accrueInterest(bar.Timestamp.Sub(bars[i-1].Timestamp), precision, state)
+		}
+		for queue.Len() > 0 {
+			event, _ := queue.Pop()
+			bt.handleEvent(event, bar, df, warmup, book, queue, state)
+		}
+		bt.checkMaintenance(key.symbol, bar, book, state)
+		if utilization := bt.marginUtilization(state); bt.margin.enabled() && utilization > state.MaxMarginUtilization {
+			state.MaxMarginUtilization = utilization
+		}
 
-		for _, signal := range signals {
-			if err := bt.processSignal(signal, currentPrice, currentVolume, currentTime, state); err != nil {
-				log.Printf("处理信号失败: %v", err)
+		if i >= warmup-1 {
+			bt.updateEquityCurve(bar.Timestamp, state)
+
+			if benchmark == nil {
+				benchmark = bt.newBuyAndHold(bar.Close, precision)
 			}
+			state.BenchmarkCurve = append(state.BenchmarkCurve, EquityPoint{
+				Date:  bar.Timestamp,
+				Value: money.Float(benchmark.value(bar.Close)),
+			})
 		}
 
-		// 更新净值曲线
-		bt.updateEquityCurve(currentTime, state)
+		// 预算按本次运行处理的K线数计算，恢复后重新计数
+		if err := budget.check(i + 1 - start); err != nil {
+			bt.saveCheckpoint(key, bars, i+1, book, benchmark, state)
+			return err
+		}
+		if interval := bt.checkpoint.Interval; interval > 0 && (i+1)%interval == 0 && i+1 < len(bars) {
+			bt.saveCheckpoint(key, bars, i+1, book, benchmark, state)
+		}
 	}
 
+	bt.removeCheckpoint()
 	return nil
 }
 
-// createDataWindow 创建数据窗口
-func (bt *Backtester) createDataWindow(df data.DataFrame, currentIndex int) data.DataFrame {
-	windowSize := int(bt.strategy.GetParameters()["long_period"].(float64))
-	startIndex := currentIndex - windowSize + 1
+// handleEvent 处理单个回测事件
+func (bt *Backtester) handleEvent(event Event, bar *Bar, df data.DataFrame, warmup int, book *OrderBook, queue *EventQueue, state *BacktestState) {
+	switch event.Type {
+	case BarEventType:
+		state.LastPrice = money.FromFloat(bar.Close)
+
+		// 先用新K线撮合之前的挂单
+		for _, fill := range book.Match(*bar) {
+			fill := fill
+			queue.Push(Event{Type: FillEventType, Time: fill.Time, Fill: &fill})
+		}
+		bt.rollContract(bar, df, book, state)
 
-	windowData := data.DataFrame{
-		"timestamp": make([]interface{}, windowSize),
-		"open":      make([]interface{}, windowSize),
-		"high":      make([]interface{}, windowSize),
-		"low":       make([]interface{}, windowSize),
-		"close":     make([]interface{}, windowSize),
-		"volume":    make([]interface{}, windowSize),
+		// 生成交易信号
+		signals, err := bt.barSignals(bar, df, warmup, state)
+		if err != nil {
+			log.Printf("生成信号失败: %v", err)
+			return
+		}
+		for i := range signals {
+			queue.Push(Event{Type: SignalEventType, Time: bar.Timestamp, Signal: &signals[i]})
+		}
+
+	case SignalEventType:
+		order := bt.orderFromSignal(*event.Signal, bar, book.precision, state)
+		if order != nil {
+			queue.Push(Event{Type: OrderEventType, Time: bar.Timestamp, Order: order})
+		}
+
+	case OrderEventType:
+		fill, err := book.Submit(event.Order, *bar)
+		if err != nil {
+			log.Printf("处理信号失败: %v", err)
+			return
+		}
+		if fill != nil {
+			queue.Push(Event{Type: FillEventType, Time: fill.Time, Fill: fill})
+		} else if entry := state.entry(event.Order.EntryID); entry != nil {
+			entry.exitOrders = append(entry.exitOrders, event.Order.ID)
+		}
+
+	case FillEventType:
+		bt.applyFill(*event.Fill, book, queue, state)
 	}
+}
 
-	for i := 0; i < windowSize; i++ {
-		idx := startIndex + i
-		windowData["timestamp"][i] = df["timestamp"][idx]
-		windowData["open"][i] = df["open"][idx]
-		windowData["high"][i] = df["high"][idx]
-		windowData["low"][i] = df["low"][idx]
-		windowData["close"][i] = df["close"][idx]
-		windowData["volume"][i] = df["volume"][idx]
+// warmupBars 策略所需的预热K线数：增量模式下为策略声明的预热K线数，否则取参数中最大的周期加一
+func (bt *Backtester) warmupBars() int {
+	warmup := 2
+	if incremental := bt.incrementalStrategy(); incremental != nil {
+		if bars := incremental.WarmupBars(); bars > warmup {
+			warmup = bars
+		}
+		return warmup
 	}
+	for name, value := range bt.strategy.GetParameters() {
+		if !strings.HasSuffix(name, "_period") {
+			continue
+		}
 
-	return windowData
+		var period int
+		switch v := value.(type) {
+		case float64:
+			period = int(v)
+		case int:
+			period = v
+		}
+		if period+1 > warmup {
+			warmup = period + 1
+		}
+	}
+	return warmup
 }
 
-// processSignal 处理交易信号
-func (bt *Backtester) processSignal(signal strategy.TradingSignal, price float64, volume int64, timestamp time.Time, state *BacktestState) error {
+// orderFromSignal 将信号转换为模拟订单，不满足条件时返回nil
+func (bt *Backtester) orderFromSignal(signal strategy.TradingSignal, bar *Bar, precision money.Precision, state *BacktestState) *SimOrder {
 	switch signal.Signal {
 	case strategy.Buy:
-		return bt.processBuySignal(signal, price, volume, timestamp, state)
+		if len(state.Entries) >= bt.maxEntries {
+			// 已达到加仓上限，跳过
+			return nil
+		}
+
+		// 计算可买入数量（考虑佣金和滑点），使用杠杆时按购买力计算
+		price := money.FromFloat(bar.Close * (1 + bt.slippageRate))
+		quantity := decimal.Min(bt.signalQuantity(signal, price, precision, state), bt.affordable(bt.buyingPower(state), price, signal.Symbol, precision))
+		if !quantity.IsPositive() {
+			log.Printf("处理信号失败: 资金不足，无法买入")
+			return nil
+		}
+
+		order := &SimOrder{
+			Symbol:     signal.Symbol,
+			Side:       SimBuy,
+			Type:       SimMarketOrder,
+			Quantity:   quantity,
+			CreateTime: bar.Timestamp,
+			Reason:     signal.Reason,
+		}
+		if signal.StopLoss > 0 {
+			order.StopLoss = money.FromFloat(signal.StopLoss)
+		}
+		if signal.TakeProfit > 0 {
+			order.TakeProfit = money.FromFloat(signal.TakeProfit)
+		}
+		return order
+
 	case strategy.Sell:
-		return bt.processSellSignal(signal, price, volume, timestamp, state)
-	default:
-		return nil
+		position := state.Position()
+		if !position.IsPositive() {
+			// 无持仓，跳过
+			return nil
+		}
+
+		return &SimOrder{
+			Symbol:     signal.Symbol,
+			Side:       SimSell,
+			Type:       SimMarketOrder,
+			Quantity:   position,
+			CreateTime: bar.Timestamp,
+			Reason:     signal.Reason,
+		}
 	}
+
+	return nil
 }
 
-// processBuySignal 处理买入信号
-func (bt *Backtester) processBuySignal(signal strategy.TradingSignal, price float64, volume int64, timestamp time.Time, state *BacktestState) error {
-	if state.Position > 0 {
-		// 已有持仓，跳过
-		return nil
+// applyFill 根据成交更新资金、持仓和交易记录
+func (bt *Backtester) applyFill(fill Fill, book orderCanceler, queue *EventQueue, state *BacktestState) {
+	if fill.Side == SimBuy {
+		bt.openEntry(fill, queue, state)
+		return
 	}
+	bt.closeEntries(fill, book, state)
+}
 
-	// 计算可买入数量
-	maxQuantity := state.Capital / price
-	quantity := math.Min(signal.Quantity, maxQuantity)
+// openEntry 买入成交后新增一笔持仓，并为其挂出独立的止损止盈单
+func (bt *Backtester) openEntry(fill Fill, queue *EventQueue, state *BacktestState) {
+	state.Commission = state.Commission.Add(fill.Commission)
+	state.Slippage = state.Slippage.Add(fill.Slippage)
 
-	if quantity <= 0 {
-		return fmt.Errorf("资金不足，无法买入")
+	totalCost := state.contractValue(fill.Quantity, fill.Price).Add(fill.Commission)
+	state.Capital = state.Capital.Sub(totalCost)
+
+	entry := &Entry{
+		ID:         fill.OrderID,
+		Quantity:   fill.Quantity,
+		Price:      fill.Price,
+		Time:       fill.Time,
+		Commission: fill.Commission,
+		StopLoss:   fill.StopLoss,
+		TakeProfit: fill.TakeProfit,
 	}
+	state.Entries = append(state.Entries, entry)
 
-	// 计算佣金和滑点
-	commission := quantity * price * bt.commissionRate
-	slippage := quantity * price * bt.slippageRate
-	totalCost := quantity*price + commission + slippage
+	// 止损单先于止盈单挂出，同一根K线同时触及时按止损处理
+	if entry.StopLoss.IsPositive() {
+		queue.Push(Event{Type: OrderEventType, Time: fill.Time, Order: &SimOrder{
+			Symbol: fill.Symbol, Side: SimSell, Type: SimStopOrder, Quantity: entry.Quantity,
+			Price: entry.StopLoss, CreateTime: fill.Time, Reason: "止损", EntryID: entry.ID,
+		}})
+	}
+	if entry.TakeProfit.IsPositive() {
+		queue.Push(Event{Type: OrderEventType, Time: fill.Time, Order: &SimOrder{
+			Symbol: fill.Symbol, Side: SimSell, Type: SimLimitOrder, Quantity: entry.Quantity,
+			Price: entry.TakeProfit, CreateTime: fill.Time, Reason: "止盈", EntryID: entry.ID,
+		}})
+	}
+
+	log.Printf("买入: 价格=%s, 数量=%s, 成本=%s, 持仓笔数=%d", fill.Price, fill.Quantity, totalCost, len(state.Entries))
+}
 
-	if totalCost > state.Capital {
-		return fmt.Errorf("资金不足，考虑佣金和滑点后无法买入")
+// closeEntries 卖出成交后平仓：指定 EntryID 时只平该笔持仓，否则按开仓顺序依次平仓，每笔生成一条交易记录
+func (bt *Backtester) closeEntries(fill Fill, book orderCanceler, state *BacktestState) {
+	targets := state.Entries
+	if fill.EntryID != "" {
+		entry := state.entry(fill.EntryID)
+		if entry == nil {
+			log.Printf("持仓 %s 已平仓，忽略成交 %s", fill.EntryID, fill.OrderID)
+			return
+		}
+		targets = []*Entry{entry}
 	}
 
-	// 执行买入
-	state.Position = quantity
-	state.EntryPrice = price
-	state.EntryTime = timestamp
-	state.Capital -= totalCost
+	state.Commission = state.Commission.Add(fill.Commission)
+	state.Slippage = state.Slippage.Add(fill.Slippage)
 
-	log.Printf("买入: 价格=%.2f, 数量=%.2f, 成本=%.2f", price, quantity, totalCost)
+	remaining := fill.Quantity
+	for _, entry := range targets {
+		if !remaining.IsPositive() {
+			break
+		}
 
-	return nil
-}
+		quantity := decimal.Min(remaining, entry.Quantity)
+		exitCommission := fill.Commission.Mul(quantity).Div(fill.Quantity)
+		entryCommission := entry.Commission.Mul(quantity).Div(entry.Quantity)
+
+		proceeds := state.contractValue(quantity, fill.Price).Sub(exitCommission)
+		cost := state.contractValue(quantity, entry.Price)
+		pnl := proceeds.Sub(cost)
+
+		trade := TradeRecord{
+			EntryDate:  entry.Time,
+			ExitDate:   fill.Time,
+			Symbol:     fill.Symbol,
+			Side:       "long",
+			EntryPrice: money.Float(entry.Price),
+			ExitPrice:  money.Float(fill.Price),
+			Quantity:   money.Float(quantity),
+			PnL:        money.Float(pnl),
+			Commission: money.Float(entryCommission.Add(exitCommission)),
+		}
+		if cost.IsPositive() {
+			trade.Return = money.Float(pnl.Div(cost))
+		}
+		state.TradeHistory = append(state.TradeHistory, trade)
 
-// processSellSignal 处理卖出信号
-func (bt *Backtester) processSellSignal(signal strategy.TradingSignal, price float64, volume int64, timestamp time.Time, state *BacktestState) error {
-	if state.Position <= 0 {
-		// 无持仓，跳过
-		return nil
+		state.Capital = state.Capital.Add(proceeds)
+		entry.Quantity = entry.Quantity.Sub(quantity)
+		entry.Commission = entry.Commission.Sub(entryCommission)
+		remaining = remaining.Sub(quantity)
+
+		log.Printf("卖出: 持仓=%s, 价格=%s, 数量=%s, 盈亏=%s", entry.ID, fill.Price, quantity, pnl)
 	}
 
-	quantity := state.Position
+	state.removeClosed(book)
+}
 
-	// 计算佣金和滑点
-	commission := quantity * price * bt.commissionRate
-	slippage := quantity * price * bt.slippageRate
-	totalCost := commission + slippage
-	proceeds := quantity*price - totalCost
+// Entry 一笔开仓记录，加仓时同一标的有多笔，止损止盈按笔独立触发
+type Entry struct {
+	ID         string
+	Quantity   decimal.Decimal
+	Price      decimal.Decimal
+	Time       time.Time
+	Commission decimal.Decimal // 剩余数量分摊的开仓佣金
+	StopLoss   decimal.Decimal
+	TakeProfit decimal.Decimal
+	exitOrders []string // 订单簿上该笔持仓的止损止盈单
+}
 
-	// 计算盈亏
-	pnl := proceeds - (quantity * state.EntryPrice)
+// Position 当前总持仓数量
+func (state *BacktestState) Position() decimal.Decimal {
+	total := decimal.Zero
+	for _, entry := range state.Entries {
+		total = total.Add(entry.Quantity)
+	}
+	return total
+}
 
-	// 记录交易
-	trade := TradeRecord{
-		EntryDate:  state.EntryTime,
-		ExitDate:   timestamp,
-		Symbol:     signal.Symbol,
-		Side:       "long",
-		EntryPrice: state.EntryPrice,
-		ExitPrice:  price,
-		Quantity:   quantity,
-		PnL:        pnl,
-		Commission: commission,
-		Return:     pnl / (quantity * state.EntryPrice),
+// entry 按ID查找未平仓的持仓
+func (state *BacktestState) entry(id string) *Entry {
+	for _, entry := range state.Entries {
+		if entry.ID == id {
+			return entry
+		}
 	}
-	state.TradeHistory = append(state.TradeHistory, trade)
+	return nil
+}
 
-	// 更新资金
-	state.Capital += proceeds
-	state.Position = 0
-	state.EntryPrice = 0
-	state.EntryTime = time.Time{}
+// orderCanceler 可撤销挂单的撮合器：按K线撮合的 OrderBook 或逐笔撮合的 TickBook
+type orderCanceler interface {
+	Cancel(orderID string) bool
+}
 
-	log.Printf("卖出: 价格=%.2f, 数量=%.2f, 盈亏=%.2f", price, quantity, pnl)
+// removeClosed 移除已全部平仓的持仓并撤销其剩余的止损止盈单
+func (state *BacktestState) removeClosed(book orderCanceler) {
+	open := state.Entries[:0]
+	for _, entry := range state.Entries {
+		if entry.Quantity.IsPositive() {
+			open = append(open, entry)
+			continue
+		}
+		for _, orderID := range entry.exitOrders {
+			book.Cancel(orderID)
+		}
+	}
+	state.Entries = open
+}
 
-	return nil
+// equity 当前权益（持仓按最新价格计算）
+func (state *BacktestState) equity() decimal.Decimal {
+	return state.Capital.Add(state.contractValue(state.Position(), state.LastPrice))
 }
 
 // updateEquityCurve 更新净值曲线
 func (bt *Backtester) updateEquityCurve(timestamp time.Time, state *BacktestState) {
-	equity := state.Capital
-	if state.Position > 0 {
-		// 计算持仓市值（简化处理，使用入场价格）
-		equity += state.Position * state.EntryPrice
-	}
-
 	state.EquityCurve = append(state.EquityCurve, EquityPoint{
 		Date:  timestamp,
-		Value: equity,
+		Value: money.Float(state.equity()),
 	})
 }
 
@@ -302,16 +661,24 @@ func (bt *Backtester) generateReport(symbol, startDate, endDate string, state *B
 		StrategyName:   bt.strategy.GetName(),
 		Symbol:         symbol,
 		InitialCapital: bt.initialCapital,
-		FinalCapital:   state.Capital,
+		FinalCapital:   money.Float(state.equity()),
+		Commission:     money.Float(state.Commission),
+		Slippage:       money.Float(state.Slippage),
 		EquityCurve:    state.EquityCurve,
 		TradeHistory:   state.TradeHistory,
+
+		MarginCalls:          state.MarginCalls,
+		Interest:             money.Float(state.Interest),
+		MaxMarginUtilization: state.MaxMarginUtilization,
+		Rolls:                state.Rolls,
 	}
 
-	// 解析日期
-	if start, err := time.Parse("2006-01-02", startDate); err == nil {
+	// 解析日期，按标的所在交易所的当地日期
+	session := bt.dataManager.Session(symbol)
+	if start, err := session.ParseDate(startDate); err == nil {
 		result.StartDate = start
 	}
-	if end, err := time.Parse("2006-01-02", endDate); err == nil {
+	if end, err := session.ParseDate(endDate); err == nil {
 		result.EndDate = end
 	}
 
@@ -332,9 +699,121 @@ func (bt *Backtester) generateReport(symbol, startDate, endDate string, state *B
 	// 计算风险指标
 	bt.calculateRiskMetrics(result)
 
+	// 买入持有基准
+	result.Benchmark = bt.benchmarkReport(result, state.BenchmarkCurve)
+
 	return result
 }
 
+// buyAndHold 买入持有基准的持仓
+type buyAndHold struct {
+	cash       decimal.Decimal
+	quantity   decimal.Decimal
+	multiplier decimal.Decimal
+}
+
+// newBuyAndHold 按与策略相同的佣金、滑点和精度全仓买入
+func (bt *Backtester) newBuyAndHold(price float64, precision money.Precision) *buyAndHold {
+	capital := money.FromFloat(bt.initialCapital)
+	fillPrice := precision.RoundPrice(money.FromFloat(price * (1 + bt.slippageRate)))
+	if !fillPrice.IsPositive() {
+		return &buyAndHold{cash: capital, multiplier: bt.multiplier()}
+	}
+
+	multiplier := bt.multiplier()
+	quantity := bt.affordable(capital, fillPrice, "", precision)
+	cost := quantity.Mul(fillPrice).Mul(multiplier)
+	fee := precision.RoundAmount(bt.commissionModel().Commission(commission.Fill{Buy: true, Quantity: quantity, Price: fillPrice, Multiplier: multiplier}))
+
+	return &buyAndHold{
+		cash:       capital.Sub(cost).Sub(fee),
+		quantity:   quantity,
+		multiplier: multiplier,
+	}
+}
+
+// value 按价格计算基准权益
+func (b *buyAndHold) value(price float64) decimal.Decimal {
+	value := b.quantity.Mul(money.FromFloat(price))
+	if b.multiplier.IsPositive() {
+		value = value.Mul(b.multiplier)
+	}
+	return b.cash.Add(value)
+}
+
+// benchmarkReport 计算基准的收益风险指标及策略相对基准的指标
+func (bt *Backtester) benchmarkReport(result *BacktestResult, curve []EquityPoint) *BenchmarkResult {
+	if len(curve) == 0 {
+		return nil
+	}
+
+	// 复用策略的风险指标计算
+	metrics := &BacktestResult{EquityCurve: curve}
+	bt.calculateRiskMetrics(metrics)
+
+	benchmark := &BenchmarkResult{
+		FinalCapital: curve[len(curve)-1].Value,
+		MaxDrawdown:  metrics.MaxDrawdown,
+		SharpeRatio:  metrics.SharpeRatio,
+		SortinoRatio: metrics.SortinoRatio,
+		EquityCurve:  curve,
+	}
+	benchmark.TotalReturn = (benchmark.FinalCapital - bt.initialCapital) / bt.initialCapital
+	if !result.StartDate.IsZero() && !result.EndDate.IsZero() {
+		years := result.EndDate.Sub(result.StartDate).Hours() / (24 * 365)
+		if years > 0 && benchmark.TotalReturn > -1 {
+			benchmark.AnnualReturn = math.Pow(1+benchmark.TotalReturn, 1/years) - 1
+		}
+	}
+	benchmark.ExcessReturn = result.TotalReturn - benchmark.TotalReturn
+
+	// 逐K线收益率的相对指标
+	strategyReturns := curveReturns(result.EquityCurve)
+	benchmarkReturns := curveReturns(curve)
+	if len(strategyReturns) != len(benchmarkReturns) || len(strategyReturns) < 2 {
+		return benchmark
+	}
+
+	meanStrategy := bt.calculateMean(strategyReturns)
+	meanBenchmark := bt.calculateMean(benchmarkReturns)
+	var covariance, strategyVariance, benchmarkVariance float64
+	excess := make([]float64, len(strategyReturns))
+	for i := range strategyReturns {
+		ds := strategyReturns[i] - meanStrategy
+		db := benchmarkReturns[i] - meanBenchmark
+		covariance += ds * db
+		strategyVariance += ds * ds
+		benchmarkVariance += db * db
+		excess[i] = strategyReturns[i] - benchmarkReturns[i]
+	}
+
+	if benchmarkVariance > 0 {
+		benchmark.Beta = covariance / benchmarkVariance
+	}
+	if strategyVariance > 0 && benchmarkVariance > 0 {
+		benchmark.Correlation = covariance / math.Sqrt(strategyVariance*benchmarkVariance)
+	}
+	if trackingError := bt.calculateStd(excess); trackingError > 0 {
+		benchmark.InformationRatio = bt.calculateMean(excess) / trackingError
+	}
+
+	return benchmark
+}
+
+// curveReturns 净值曲线的逐点收益率
+func curveReturns(curve []EquityPoint) []float64 {
+	if len(curve) < 2 {
+		return nil
+	}
+	returns := make([]float64, len(curve)-1)
+	for i := 1; i < len(curve); i++ {
+		if curve[i-1].Value != 0 {
+			returns[i-1] = (curve[i].Value - curve[i-1].Value) / curve[i-1].Value
+		}
+	}
+	return returns
+}
+
 // calculateTradeStatistics 计算交易统计
 func (bt *Backtester) calculateTradeStatistics(result *BacktestResult) {
 	trades := result.TradeHistory
@@ -386,12 +865,6 @@ func (bt *Backtester) calculateTradeStatistics(result *BacktestResult) {
 	if totalLoss > 0 {
 		result.ProfitFactor = totalWin / totalLoss
 	}
-
-	// 计算佣金和滑点
-	for _, trade := range trades {
-		result.Commission += trade.Commission
-		result.Slippage += trade.Quantity * trade.ExitPrice * bt.slippageRate
-	}
 }
 
 // calculateRiskMetrics 计算风险指标
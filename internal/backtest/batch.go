@@ -0,0 +1,181 @@
+package backtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// BatchResult 多标的回测中单个标的的结果，失败时 Error 为失败原因
+type BatchResult struct {
+	Symbol   string          `json:"symbol"`
+	Result   *BacktestResult `json:"result,omitempty"`
+	Error    string          `json:"error,omitempty"`
+	Duration time.Duration   `json:"duration"`
+}
+
+// BatchSummary 多标的回测的汇总指标，只统计成功的标的
+type BatchSummary struct {
+	Succeeded     int     `json:"succeeded"`
+	Failed        int     `json:"failed"`
+	Aborted       int     `json:"aborted"` // 超出资源预算提前中止的标的数
+	AvgReturn     float64 `json:"avg_return"`
+	MedianReturn  float64 `json:"median_return"`
+	AvgSharpe     float64 `json:"avg_sharpe"`
+	AvgDrawdown   float64 `json:"avg_drawdown"`
+	WorstDrawdown float64 `json:"worst_drawdown"`
+	TotalTrades   int     `json:"total_trades"`
+	Profitable    int     `json:"profitable"`     // 总收益为正的标的数
+	BeatBenchmark int     `json:"beat_benchmark"` // 跑赢买入持有基准的标的数
+	Best          string  `json:"best,omitempty"`
+	Worst         string  `json:"worst,omitempty"`
+}
+
+// BatchReport 同一策略在多个标的上的回测报告，Results 按输入顺序排列
+type BatchReport struct {
+	StrategyName string        `json:"strategy_name"`
+	StartDate    string        `json:"start_date"`
+	EndDate      string        `json:"end_date"`
+	Workers      int           `json:"workers"`
+	StartedAt    time.Time     `json:"started_at"`
+	Duration     time.Duration `json:"duration"`
+	Results      []BatchResult `json:"results"`
+	Summary      BatchSummary  `json:"summary"`
+}
+
+// BacktesterFactory 为单个标的创建回测器，每个标的使用独立的回测器
+type BacktesterFactory func(symbol string) (*Backtester, error)
+
+// RunBatch 用最多 workers 个并发任务对每个标的运行回测；单个标的失败不影响其他标的
+func RunBatch(symbols []string, startDate, endDate string, workers int, factory BacktesterFactory) *BatchReport {
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > len(symbols) {
+		workers = len(symbols)
+	}
+	report := &BatchReport{
+		StartDate: startDate,
+		EndDate:   endDate,
+		Workers:   workers,
+		StartedAt: time.Now(),
+		Results:   make([]BatchResult, len(symbols)),
+	}
+	log.Printf("开始多标的回测: 标的数=%d, 并发数=%d, 区间=%s ~ %s", len(symbols), workers, startDate, endDate)
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				report.Results[i] = runOne(symbols[i], startDate, endDate, factory)
+			}
+		}()
+	}
+	for i := range symbols {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	report.Duration = time.Since(report.StartedAt)
+	for _, result := range report.Results {
+		if result.Result != nil {
+			report.StrategyName = result.Result.StrategyName
+			break
+		}
+	}
+	report.Summary = summarizeBatch(report.Results)
+	log.Printf("多标的回测完成: 成功=%d, 失败=%d, 耗时=%v",
+		report.Summary.Succeeded, report.Summary.Failed, report.Duration.Round(time.Millisecond))
+	return report
+}
+
+// runOne 运行单个标的的回测
+func runOne(symbol, startDate, endDate string, factory BacktesterFactory) BatchResult {
+	start := time.Now()
+	result := BatchResult{Symbol: symbol}
+	backtester, err := factory(symbol)
+	if err == nil {
+		result.Result, err = backtester.Run(symbol, startDate, endDate)
+	}
+	if err != nil {
+		log.Printf("标的 %s 回测失败: %v", symbol, err)
+		result.Error = err.Error()
+	}
+	result.Duration = time.Since(start)
+	return result
+}
+
+// summarizeBatch 汇总成功标的的收益、风险和交易指标
+func summarizeBatch(results []BatchResult) BatchSummary {
+	var summary BatchSummary
+	var returns []float64
+	best, worst := 0.0, 0.0
+	for _, item := range results {
+		result := item.Result
+		if result == nil {
+			summary.Failed++
+			continue
+		}
+		summary.Succeeded++
+		if result.Aborted {
+			summary.Aborted++
+		}
+		returns = append(returns, result.TotalReturn)
+		summary.AvgReturn += result.TotalReturn
+		summary.AvgSharpe += result.SharpeRatio
+		summary.AvgDrawdown += result.MaxDrawdown
+		if result.MaxDrawdown > summary.WorstDrawdown {
+			summary.WorstDrawdown = result.MaxDrawdown
+		}
+		summary.TotalTrades += result.TotalTrades
+		if result.TotalReturn > 0 {
+			summary.Profitable++
+		}
+		if result.Benchmark != nil && result.Benchmark.ExcessReturn > 0 {
+			summary.BeatBenchmark++
+		}
+		if summary.Best == "" || result.TotalReturn > best {
+			summary.Best, best = item.Symbol, result.TotalReturn
+		}
+		if summary.Worst == "" || result.TotalReturn < worst {
+			summary.Worst, worst = item.Symbol, result.TotalReturn
+		}
+	}
+	if n := float64(summary.Succeeded); n > 0 {
+		summary.AvgReturn /= n
+		summary.AvgSharpe /= n
+		summary.AvgDrawdown /= n
+
+		sort.Float64s(returns)
+		mid := len(returns) / 2
+		summary.MedianReturn = returns[mid]
+		if len(returns)%2 == 0 {
+			summary.MedianReturn = (returns[mid-1] + returns[mid]) / 2
+		}
+	}
+	return summary
+}
+
+// Save 以 JSON 写入合并报告
+func (r *BatchReport) Save(path string) error {
+	content, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化回测报告失败: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("创建报告目录失败: %w", err)
+	}
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		return fmt.Errorf("写入回测报告失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,288 @@
+package backtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"agent-quant-system/internal/money"
+	"agent-quant-system/internal/strategy"
+
+	"github.com/shopspring/decimal"
+)
+
+// checkpointVersion 检查点文件格式版本，格式变化后旧检查点不再恢复
+const checkpointVersion = 1
+
+// CheckpointOptions 回测检查点设置
+type CheckpointOptions struct {
+	Path     string // 检查点文件
+	Interval int    // 每处理多少根K线保存一次，0表示只在超出资源预算中止时保存
+	Resume   bool   // 存在匹配的检查点时从中断处继续
+}
+
+// Checkpoint 回测中断时的完整状态：处理到的K线位置、资金持仓、挂单、基准持仓和已生成的曲线与交易记录。
+// 策略按K线窗口重新计算信号，不保存策略内部状态
+type Checkpoint struct {
+	Version    int                     `json:"version"`
+	SavedAt    time.Time               `json:"saved_at"`
+	Strategy   string                  `json:"strategy"`
+	Parameters strategy.StrategyParams `json:"parameters"`
+	Symbol     string                  `json:"symbol"`
+	StartDate  string                  `json:"start_date"`
+	EndDate    string                  `json:"end_date"`
+
+	// 用于确认恢复时的行情与中断前一致
+	InitialCapital float64   `json:"initial_capital"`
+	TotalBars      int       `json:"total_bars"`
+	NextBar        int       `json:"next_bar"`      // 下一根待处理K线的序号
+	LastBarTime    time.Time `json:"last_bar_time"` // 最后处理的K线时间
+
+	Capital        decimal.Decimal   `json:"capital"`
+	Entries        []checkpointEntry `json:"entries"`
+	LastPrice      decimal.Decimal   `json:"last_price"`
+	Commission     decimal.Decimal   `json:"commission"`
+	Slippage       decimal.Decimal   `json:"slippage"`
+	EquityCurve    []EquityPoint     `json:"equity_curve"`
+	TradeHistory   []TradeRecord     `json:"trade_history"`
+	BenchmarkCurve []EquityPoint     `json:"benchmark_curve"`
+
+	Interest             decimal.Decimal `json:"interest,omitempty"`
+	MarginCalls          int             `json:"margin_calls,omitempty"`
+	MaxMarginUtilization float64         `json:"max_margin_utilization,omitempty"`
+	Rolls                int             `json:"rolls,omitempty"`
+
+	PendingOrders []*SimOrder       `json:"pending_orders"`
+	NextOrderID   int               `json:"next_order_id"`
+	Benchmark     *checkpointHolder `json:"benchmark,omitempty"`
+}
+
+// checkpointEntry 检查点中的一笔持仓
+type checkpointEntry struct {
+	ID         string          `json:"id"`
+	Quantity   decimal.Decimal `json:"quantity"`
+	Price      decimal.Decimal `json:"price"`
+	Time       time.Time       `json:"time"`
+	Commission decimal.Decimal `json:"commission"`
+	StopLoss   decimal.Decimal `json:"stop_loss"`
+	TakeProfit decimal.Decimal `json:"take_profit"`
+	ExitOrders []string        `json:"exit_orders,omitempty"`
+}
+
+// checkpointHolder 检查点中买入持有基准的持仓
+type checkpointHolder struct {
+	Cash     decimal.Decimal `json:"cash"`
+	Quantity decimal.Decimal `json:"quantity"`
+}
+
+// SetCheckpoint 设置检查点，Path 为空时不保存检查点
+func (bt *Backtester) SetCheckpoint(options CheckpointOptions) {
+	bt.checkpoint = options
+}
+
+// LoadCheckpoint 读取检查点文件
+func LoadCheckpoint(path string) (*Checkpoint, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var checkpoint Checkpoint
+	if err := json.Unmarshal(content, &checkpoint); err != nil {
+		return nil, fmt.Errorf("解析检查点失败: %w", err)
+	}
+	if checkpoint.Version != checkpointVersion {
+		return nil, fmt.Errorf("检查点格式版本 %d 与当前版本 %d 不兼容", checkpoint.Version, checkpointVersion)
+	}
+	return &checkpoint, nil
+}
+
+// save 原子地写入检查点文件，先写临时文件再重命名，写入中途中断不会损坏上一个检查点
+func (c *Checkpoint) save(path string) error {
+	content, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("序列化检查点失败: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("创建检查点目录失败: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, content, 0644); err != nil {
+		return fmt.Errorf("写入检查点失败: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("写入检查点失败: %w", err)
+	}
+	return nil
+}
+
+// runKey 本次回测的标识，用于校验检查点属于同一策略、参数、标的和区间
+type runKey struct {
+	symbol    string
+	startDate string
+	endDate   string
+}
+
+// newCheckpoint 按当前回测状态创建检查点，next 为下一根待处理K线的序号
+func (bt *Backtester) newCheckpoint(r runKey, bars []Bar, next int, book *OrderBook, benchmark *buyAndHold, state *BacktestState) *Checkpoint {
+	checkpoint := &Checkpoint{
+		Version:        checkpointVersion,
+		SavedAt:        time.Now(),
+		Strategy:       bt.strategy.GetName(),
+		Parameters:     bt.strategy.GetParameters(),
+		Symbol:         r.symbol,
+		StartDate:      r.startDate,
+		EndDate:        r.endDate,
+		InitialCapital: bt.initialCapital,
+		TotalBars:      len(bars),
+		NextBar:        next,
+		Capital:        state.Capital,
+		LastPrice:      state.LastPrice,
+		Commission:     state.Commission,
+		Slippage:       state.Slippage,
+		EquityCurve:    state.EquityCurve,
+		TradeHistory:   state.TradeHistory,
+		BenchmarkCurve: state.BenchmarkCurve,
+		PendingOrders:  book.pending,
+		NextOrderID:    book.nextID,
+
+		Interest:             state.Interest,
+		MarginCalls:          state.MarginCalls,
+		MaxMarginUtilization: state.MaxMarginUtilization,
+		Rolls:                state.Rolls,
+	}
+	if next > 0 {
+		checkpoint.LastBarTime = bars[next-1].Timestamp
+	}
+	for _, entry := range state.Entries {
+		checkpoint.Entries = append(checkpoint.Entries, checkpointEntry{
+			ID:         entry.ID,
+			Quantity:   entry.Quantity,
+			Price:      entry.Price,
+			Time:       entry.Time,
+			Commission: entry.Commission,
+			StopLoss:   entry.StopLoss,
+			TakeProfit: entry.TakeProfit,
+			ExitOrders: entry.exitOrders,
+		})
+	}
+	if benchmark != nil {
+		checkpoint.Benchmark = &checkpointHolder{Cash: benchmark.cash, Quantity: benchmark.quantity}
+	}
+	return checkpoint
+}
+
+// matches 检查检查点是否属于本次回测，不匹配时返回原因
+func (bt *Backtester) matches(c *Checkpoint, r runKey, bars []Bar) error {
+	switch {
+	case c.Symbol != r.symbol || c.StartDate != r.startDate || c.EndDate != r.endDate:
+		return fmt.Errorf("检查点属于 %s %s ~ %s", c.Symbol, c.StartDate, c.EndDate)
+	case c.Strategy != bt.strategy.GetName():
+		return fmt.Errorf("检查点属于策略 %s", c.Strategy)
+	case c.InitialCapital != bt.initialCapital:
+		return fmt.Errorf("检查点的初始资金为 %.2f", c.InitialCapital)
+	case c.TotalBars != len(bars):
+		return fmt.Errorf("检查点的行情有 %d 根K线，当前为 %d 根", c.TotalBars, len(bars))
+	case c.NextBar <= 0 || c.NextBar > len(bars):
+		return fmt.Errorf("检查点的K线位置 %d 无效", c.NextBar)
+	case !bars[c.NextBar-1].Timestamp.Equal(c.LastBarTime):
+		return fmt.Errorf("第 %d 根K线的时间与检查点不一致，行情已变化", c.NextBar)
+	}
+
+	// 参数经过 JSON 往返后整数变为浮点数，按序列化结果比较
+	saved, err := json.Marshal(c.Parameters)
+	if err != nil {
+		return err
+	}
+	current, err := json.Marshal(bt.strategy.GetParameters())
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(saved, current) {
+		return fmt.Errorf("策略参数已变化: 检查点 %s, 当前 %s", saved, current)
+	}
+	return nil
+}
+
+// restore 用检查点恢复回测状态、订单簿和基准持仓，返回下一根待处理K线的序号
+func (c *Checkpoint) restore(book *OrderBook, state *BacktestState) (int, *buyAndHold) {
+	state.Capital = c.Capital
+	state.LastPrice = c.LastPrice
+	state.Commission = c.Commission
+	state.Slippage = c.Slippage
+	state.EquityCurve = append(state.EquityCurve[:0], c.EquityCurve...)
+	state.TradeHistory = append(state.TradeHistory[:0], c.TradeHistory...)
+	state.BenchmarkCurve = c.BenchmarkCurve
+	state.Interest = c.Interest
+	state.MarginCalls = c.MarginCalls
+	state.MaxMarginUtilization = c.MaxMarginUtilization
+	state.Rolls = c.Rolls
+	state.Entries = nil
+	for _, entry := range c.Entries {
+		state.Entries = append(state.Entries, &Entry{
+			ID:         entry.ID,
+			Quantity:   entry.Quantity,
+			Price:      entry.Price,
+			Time:       entry.Time,
+			Commission: entry.Commission,
+			StopLoss:   entry.StopLoss,
+			TakeProfit: entry.TakeProfit,
+			exitOrders: entry.ExitOrders,
+		})
+	}
+
+	book.pending = c.PendingOrders
+	book.nextID = c.NextOrderID
+
+	var benchmark *buyAndHold
+	if c.Benchmark != nil {
+		benchmark = &buyAndHold{cash: c.Benchmark.Cash, quantity: c.Benchmark.Quantity, multiplier: state.multiplier}
+	}
+	return c.NextBar, benchmark
+}
+
+// resumePoint 按设置加载检查点；没有检查点时从头开始，检查点与本次回测不匹配时返回错误
+func (bt *Backtester) resumePoint(r runKey, bars []Bar) (*Checkpoint, error) {
+	if !bt.checkpoint.Resume || bt.checkpoint.Path == "" {
+		return nil, nil
+	}
+	checkpoint, err := LoadCheckpoint(bt.checkpoint.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		log.Printf("未找到回测检查点 %s，从头开始回测", bt.checkpoint.Path)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取回测检查点失败: %w", err)
+	}
+	if err := bt.matches(checkpoint, r, bars); err != nil {
+		return nil, fmt.Errorf("回测检查点 %s 与本次回测不匹配（删除该文件后重新运行）: %w", bt.checkpoint.Path, err)
+	}
+	return checkpoint, nil
+}
+
+// saveCheckpoint 保存检查点，失败时只记录日志不中断回测
+func (bt *Backtester) saveCheckpoint(r runKey, bars []Bar, next int, book *OrderBook, benchmark *buyAndHold, state *BacktestState) {
+	if bt.checkpoint.Path == "" {
+		return
+	}
+	if err := bt.newCheckpoint(r, bars, next, book, benchmark, state).save(bt.checkpoint.Path); err != nil {
+		log.Printf("保存回测检查点失败: %v", err)
+		return
+	}
+	log.Printf("已保存回测检查点: 文件=%s, 进度=%d/%d, 权益=%.2f",
+		bt.checkpoint.Path, next, len(bars), money.Float(state.equity()))
+}
+
+// removeCheckpoint 回测正常完成后删除检查点
+func (bt *Backtester) removeCheckpoint() {
+	if bt.checkpoint.Path == "" {
+		return
+	}
+	if err := os.Remove(bt.checkpoint.Path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Printf("删除回测检查点失败: %v", err)
+	}
+}
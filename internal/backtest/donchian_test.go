@@ -0,0 +1,84 @@
+package backtest
+
+import (
+	"testing"
+	"time"
+
+	"agent-quant-system/internal/config"
+	"agent-quant-system/internal/data"
+	"agent-quant-system/internal/sizing"
+	"agent-quant-system/internal/strategy"
+)
+
+// newFixtureDataManager 创建只使用导入数据源的数据管理器，并导入 testdata 中的K线
+func newFixtureDataManager(t *testing.T, path, symbol string) *data.DataManager {
+	t.Helper()
+
+	cfg := config.DataConfig{
+		Providers:     map[string]string{"stock": data.ImportedProviderName, "crypto": data.ImportedProviderName},
+		SymbolClasses: map[string]string{symbol: "stock"},
+	}
+	cfg.Import.Dir = t.TempDir()
+	dm, err := data.NewDataManagerFromConfig(cfg)
+	if err != nil {
+		t.Fatalf("创建数据管理器失败: %v", err)
+	}
+	opts := data.ImportOptions{
+		Columns: config.ImportColumns{
+			Timestamp: "timestamp", Open: "open", High: "high", Low: "low", Close: "close", Volume: "volume",
+		},
+		Location: time.UTC,
+	}
+	if _, err := dm.ImportFile(path, symbol, opts); err != nil {
+		t.Fatalf("导入K线失败: %v", err)
+	}
+	return dm
+}
+
+// TestDonchianBreakoutBacktest 固定K线上的唐奇安突破回测：第10根K线收盘价105突破之前5根K线的最高价101买入，
+// 第20根K线收盘价107跌破之前3根K线的最低价109卖出；无佣金和滑点时盈亏为 (107-105)*100
+func TestDonchianBreakoutBacktest(t *testing.T) {
+	for _, incremental := range []bool{true, false} {
+		ds := strategy.NewDonchianBreakoutStrategy()
+		params := ds.GetParameters()
+		params["entry_period"] = 5.0
+		params["exit_period"] = 3.0
+		params["atr_period"] = 3.0
+		params["stop_atr"] = 100.0 // 止损远离价格，只由通道下轨离场
+		params["take_profit_atr"] = 0.0
+		if err := ds.Initialize(); err != nil {
+			t.Fatalf("初始化策略失败: %v", err)
+		}
+
+		dm := newFixtureDataManager(t, "testdata/donchian_breakout.csv", "DONCH")
+		bt := NewBacktester(ds, dm, 100000, 0, 0)
+		bt.SetSizing(sizing.FixedNotional{Notional: 10500})
+		bt.SetIncremental(incremental)
+
+		result, err := bt.Run("DONCH", "2024-01-02", "2024-01-03")
+		if err != nil {
+			t.Fatalf("回测失败(增量=%v): %v", incremental, err)
+		}
+		if len(result.TradeHistory) != 1 {
+			t.Fatalf("成交笔数(增量=%v) = %d, 期望 1: %+v", incremental, len(result.TradeHistory), result.TradeHistory)
+		}
+
+		first := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+		trade := result.TradeHistory[0]
+		if want := first.Add(10 * time.Hour); !trade.EntryDate.Equal(want) {
+			t.Errorf("开仓时间(增量=%v) = %s, 期望 %s", incremental, trade.EntryDate, want)
+		}
+		if want := first.Add(20 * time.Hour); !trade.ExitDate.Equal(want) {
+			t.Errorf("平仓时间(增量=%v) = %s, 期望 %s", incremental, trade.ExitDate, want)
+		}
+		if trade.EntryPrice != 105 || trade.ExitPrice != 107 || trade.Quantity != 100 {
+			t.Errorf("成交(增量=%v) = %v@%v -> %v, 期望 100@105 -> 107", incremental, trade.Quantity, trade.EntryPrice, trade.ExitPrice)
+		}
+		if trade.PnL != 200 {
+			t.Errorf("盈亏(增量=%v) = %v, 期望 200", incremental, trade.PnL)
+		}
+		if result.FinalCapital != 100200 {
+			t.Errorf("期末资金(增量=%v) = %v, 期望 100200", incremental, result.FinalCapital)
+		}
+	}
+}
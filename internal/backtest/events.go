@@ -0,0 +1,145 @@
+package backtest
+
+import (
+	"fmt"
+	"time"
+
+	"agent-quant-system/internal/data"
+	"agent-quant-system/internal/indicators"
+	"agent-quant-system/internal/strategy"
+)
+
+// EventType 回测事件类型
+type EventType int
+
+const (
+	BarEventType    EventType = iota // 新K线
+	SignalEventType                  // 策略信号
+	OrderEventType                   // 下单
+	FillEventType                    // 成交
+)
+
+// String 事件类型名称
+func (t EventType) String() string {
+	switch t {
+	case BarEventType:
+		return "BAR"
+	case SignalEventType:
+		return "SIGNAL"
+	case OrderEventType:
+		return "ORDER"
+	case FillEventType:
+		return "FILL"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// Bar K线
+type Bar struct {
+	Index     int       `json:"index"`
+	Timestamp time.Time `json:"timestamp"`
+	Open      float64   `json:"open"`
+	High      float64   `json:"high"`
+	Low       float64   `json:"low"`
+	Close     float64   `json:"close"`
+	Volume    int64     `json:"volume"`
+}
+
+// Event 回测事件，根据 Type 使用对应字段
+type Event struct {
+	Type   EventType
+	Time   time.Time
+	Bar    *Bar
+	Signal *strategy.TradingSignal
+	Order  *SimOrder
+	Fill   *Fill
+}
+
+// EventQueue 先进先出的事件队列
+type EventQueue struct {
+	events []Event
+	head   int
+}
+
+// Push 追加事件
+func (q *EventQueue) Push(event Event) {
+	q.events = append(q.events, event)
+}
+
+// Pop 取出最早的事件
+func (q *EventQueue) Pop() (Event, bool) {
+	if q.head >= len(q.events) {
+		// 队列已空时复用底层数组
+		q.events = q.events[:0]
+		q.head = 0
+		return Event{}, false
+	}
+
+	event := q.events[q.head]
+	q.events[q.head] = Event{}
+	q.head++
+	return event, true
+}
+
+// Len 待处理事件数
+func (q *EventQueue) Len() int {
+	return len(q.events) - q.head
+}
+
+// barsFromDataFrame 将DataFrame一次性转换为K线序列
+func barsFromDataFrame(df data.DataFrame) ([]Bar, error) {
+	opens, err := indicators.Float64Column(df, "open")
+	if err != nil {
+		return nil, err
+	}
+	highs, err := indicators.Float64Column(df, "high")
+	if err != nil {
+		return nil, err
+	}
+	lows, err := indicators.Float64Column(df, "low")
+	if err != nil {
+		return nil, err
+	}
+	closes, err := indicators.Float64Column(df, "close")
+	if err != nil {
+		return nil, err
+	}
+	volumes, err := indicators.Float64Column(df, "volume")
+	if err != nil {
+		return nil, err
+	}
+
+	timestamps := df["timestamp"]
+	if len(timestamps) != len(closes) {
+		return nil, fmt.Errorf("时间戳列长度不一致")
+	}
+
+	bars := make([]Bar, len(closes))
+	for i := range closes {
+		timestamp, ok := timestamps[i].(time.Time)
+		if !ok {
+			return nil, fmt.Errorf("第 %d 行时间戳类型错误: %T", i, timestamps[i])
+		}
+		bars[i] = Bar{
+			Index:     i,
+			Timestamp: timestamp,
+			Open:      opens[i],
+			High:      highs[i],
+			Low:       lows[i],
+			Close:     closes[i],
+			Volume:    int64(volumes[i]),
+		}
+	}
+
+	return bars, nil
+}
+
+// windowView 返回 [start, end) 区间的DataFrame视图，共享底层数据而不复制
+func windowView(df data.DataFrame, start, end int) data.DataFrame {
+	view := make(data.DataFrame, len(df))
+	for column, values := range df {
+		view[column] = values[start:end:end]
+	}
+	return view
+}
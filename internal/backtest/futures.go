@@ -0,0 +1,78 @@
+package backtest
+
+import (
+	"log"
+
+	"agent-quant-system/internal/commission"
+	"agent-quant-system/internal/data"
+	"agent-quant-system/internal/instrument"
+	"agent-quant-system/internal/money"
+
+	"github.com/shopspring/decimal"
+)
+
+// SetInstrument 设置标的的交易规格：期货按手数交易，盈亏、保证金和佣金按合约乘数放大，成交价取整到最小价格变动
+func (bt *Backtester) SetInstrument(inst instrument.Instrument) {
+	bt.instrument = inst
+}
+
+// multiplier 合约乘数，未设置规格时为1
+func (bt *Backtester) multiplier() decimal.Decimal {
+	if bt.instrument.Multiplier.IsPositive() {
+		return bt.instrument.Multiplier
+	}
+	return decimal.NewFromInt(1)
+}
+
+// SetInstrument 设置合约乘数和最小价格变动，成交价取整到最小价格变动，佣金和滑点金额按乘数放大
+func (ob *OrderBook) SetInstrument(inst instrument.Instrument) {
+	ob.instrument = inst
+}
+
+// contractValue 数量按价格计算的合约价值：数量 * 价格 * 合约乘数
+func (state *BacktestState) contractValue(quantity, price decimal.Decimal) decimal.Decimal {
+	value := quantity.Mul(price)
+	if state.multiplier.IsPositive() {
+		value = value.Mul(state.multiplier)
+	}
+	return value
+}
+
+// rollContract 连续合约换月：K线所属合约变化且有持仓时，按收盘价计入平旧仓和开新仓两次佣金；
+// 价格已由数据层按换月价差复权，持仓数量和开仓价不变
+func (bt *Backtester) rollContract(bar *Bar, df data.DataFrame, book *OrderBook, state *BacktestState) {
+	if bar.Index == 0 || !state.Position().IsPositive() {
+		return
+	}
+	column, ok := df[data.ContractColumn]
+	if !ok || bar.Index >= len(column) {
+		return
+	}
+	previous, current := column[bar.Index-1], column[bar.Index]
+	if previous == current {
+		return
+	}
+
+	position := state.Position()
+	price := book.precision.RoundPrice(money.FromFloat(bar.Close))
+	model := bt.commissionModel()
+	fill := commission.Fill{Symbol: bt.instrument.Symbol, Quantity: position, Price: price, Multiplier: state.multiplier}
+	cost := model.Commission(fill)
+	fill.Buy = true
+	cost = book.precision.RoundAmount(cost.Add(model.Commission(fill)))
+
+	state.Capital = state.Capital.Sub(cost)
+	state.Commission = state.Commission.Add(cost)
+	state.Rolls++
+	log.Printf("移仓: 时间=%s, %v -> %v, 数量=%s, 佣金=%s",
+		bar.Timestamp.Format("2006-01-02 15:04"), previous, current, position, cost)
+}
+
+// value 数量按价格计算的合约价值，未设置规格时乘数为1
+func (ob *OrderBook) value(quantity, price decimal.Decimal) decimal.Decimal {
+	value := quantity.Mul(price)
+	if ob.instrument.Multiplier.IsPositive() {
+		value = value.Mul(ob.instrument.Multiplier)
+	}
+	return value
+}
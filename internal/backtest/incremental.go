@@ -0,0 +1,59 @@
+package backtest
+
+import (
+	"agent-quant-system/internal/data"
+	"agent-quant-system/internal/strategy"
+)
+
+// SetIncremental 设置增量模式（默认启用）：策略实现 strategy.IncrementalStrategy 时，每根K线调用一次 OnBar
+// 按滚动指标更新状态，不再对每根K线的窗口重新计算指标；策略不支持或关闭时按窗口生成信号，可用于对比两种模式的结果
+func (bt *Backtester) SetIncremental(enabled bool) {
+	bt.incremental = enabled
+}
+
+// incrementalStrategy 以增量模式回测时返回策略，否则返回nil
+func (bt *Backtester) incrementalStrategy() strategy.IncrementalStrategy {
+	if !bt.incremental {
+		return nil
+	}
+	incremental, _ := bt.strategy.(strategy.IncrementalStrategy)
+	return incremental
+}
+
+// newBarState 增量模式下为本次回测创建策略的增量状态，否则返回nil
+func (bt *Backtester) newBarState(symbol string) strategy.BarState {
+	if incremental := bt.incrementalStrategy(); incremental != nil {
+		return incremental.NewBarState(symbol)
+	}
+	return nil
+}
+
+// barSignals 生成K线结束时的信号：增量模式下每根K线都更新状态，预热期内的信号丢弃；
+// 否则预热完成后对截至该K线的窗口调用 GenerateSignals
+func (bt *Backtester) barSignals(bar *Bar, df data.DataFrame, warmup int, state *BacktestState) ([]strategy.TradingSignal, error) {
+	if state.barState != nil {
+		signals, err := state.barState.OnBar(bar.strategyBar(), nil)
+		if err != nil || bar.Index < warmup-1 {
+			return nil, err
+		}
+		return signals, nil
+	}
+	if bar.Index < warmup-1 {
+		return nil, nil
+	}
+	// 窗口为共享底层数据的视图
+	window := windowView(df, bar.Index-warmup+1, bar.Index+1)
+	return bt.strategy.GenerateSignals(window, nil)
+}
+
+// strategyBar 转换为策略的K线
+func (b *Bar) strategyBar() strategy.Bar {
+	return strategy.Bar{
+		Timestamp: b.Timestamp,
+		Open:      b.Open,
+		High:      b.High,
+		Low:       b.Low,
+		Close:     b.Close,
+		Volume:    float64(b.Volume),
+	}
+}
@@ -0,0 +1,84 @@
+package backtest
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"time"
+)
+
+// limitCheckInterval 每处理多少根K线检查一次资源预算，读取内存统计有开销，不宜每根检查
+const limitCheckInterval = 500
+
+// Limits 单次回测的资源预算，零值表示不限制；超出时中止回测并返回已处理部分的结果。
+// MaxMemoryMB 检查的是整个进程的堆内存（runtime.MemStats.HeapAlloc），包含引擎本身和同时运行的其他回测，
+// 用于防止进程被耗尽，而不是单次回测的分配量
+type Limits struct {
+	MaxDuration time.Duration // 最长运行时间，包含加载数据的时间
+	MaxMemoryMB int           // 进程堆内存上限（MB）
+	MaxBars     int           // 最多处理的K线数
+}
+
+// budgetExceeded 回测超出资源预算
+type budgetExceeded struct {
+	reason string
+	bars   int // 中止前已处理的K线数
+}
+
+func (e *budgetExceeded) Error() string {
+	return fmt.Sprintf("回测超出资源预算（已处理 %d 根K线）: %s", e.bars, e.reason)
+}
+
+// budget 跟踪一次回测的资源使用
+type budget struct {
+	limits Limits
+	start  time.Time
+}
+
+// newBudget 开始计时，在加载数据之前创建
+func newBudget(limits Limits) *budget {
+	return &budget{limits: limits, start: time.Now()}
+}
+
+// check 处理完 processed 根K线后检查预算，超出时返回 budgetExceeded
+func (b *budget) check(processed int) error {
+	if b.limits.MaxBars > 0 && processed >= b.limits.MaxBars {
+		return &budgetExceeded{reason: fmt.Sprintf("K线数达到上限 %d", b.limits.MaxBars), bars: processed}
+	}
+	if processed%limitCheckInterval != 0 {
+		return nil
+	}
+	return b.checkResources(processed)
+}
+
+// loaded 加载一批数据后检查运行时间和进程堆内存，超出时在处理K线之前中止回测（没有可报告的部分结果）
+func (b *budget) loaded(stage string) error {
+	var exceeded *budgetExceeded
+	if err := b.checkResources(0); errors.As(err, &exceeded) {
+		return fmt.Errorf("%s后超出资源预算: %s", stage, exceeded.reason)
+	}
+	return nil
+}
+
+// checkResources 检查运行时间和进程堆内存
+func (b *budget) checkResources(processed int) error {
+	if b.limits.MaxDuration > 0 {
+		if elapsed := time.Since(b.start); elapsed > b.limits.MaxDuration {
+			return &budgetExceeded{
+				reason: fmt.Sprintf("运行时间 %v 超过上限 %v", elapsed.Round(time.Millisecond), b.limits.MaxDuration),
+				bars:   processed,
+			}
+		}
+	}
+	if b.limits.MaxMemoryMB > 0 {
+		var stats runtime.MemStats
+		runtime.ReadMemStats(&stats)
+		if heapMB := stats.HeapAlloc / (1 << 20); heapMB > uint64(b.limits.MaxMemoryMB) {
+			return &budgetExceeded{
+				reason: fmt.Sprintf("堆内存 %dMB 超过上限 %dMB", heapMB, b.limits.MaxMemoryMB),
+				bars:   processed,
+			}
+		}
+	}
+	return nil
+}
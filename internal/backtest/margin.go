@@ -0,0 +1,113 @@
+package backtest
+
+import (
+	"log"
+	"time"
+
+	"agent-quant-system/internal/money"
+
+	"github.com/shopspring/decimal"
+)
+
+// MarginOptions 回测的保证金设置，Leverage 不大于1时不使用杠杆
+type MarginOptions struct {
+	Leverage          float64 // 最大杠杆倍数
+	MaintenanceMargin float64 // 维持保证金率，权益低于持仓市值的该比例时强制平仓，0表示不检查
+	InterestRate      float64 // 借入资金的年化利率
+}
+
+// enabled 是否使用杠杆
+func (m MarginOptions) enabled() bool {
+	return m.Leverage > 1
+}
+
+// leverage 生效的杠杆倍数
+func (m MarginOptions) leverage() decimal.Decimal {
+	if m.enabled() {
+		return money.FromFloat(m.Leverage)
+	}
+	return decimal.NewFromInt(1)
+}
+
+// SetMargin 设置保证金交易：买入按权益乘以杠杆的购买力计算，资金为负的部分为借入资金并计息
+func (bt *Backtester) SetMargin(options MarginOptions) {
+	bt.margin = options
+}
+
+// buyingPower 可用于买入的资金：不使用杠杆时为现金，使用杠杆时为权益乘以杠杆减去持仓市值
+func (bt *Backtester) buyingPower(state *BacktestState) decimal.Decimal {
+	if !bt.margin.enabled() {
+		return state.Capital
+	}
+	power := state.equity().Mul(bt.margin.leverage()).Sub(state.positionValue())
+	return decimal.Max(power, decimal.Zero)
+}
+
+// positionValue 持仓按最新价格计算的市值
+func (state *BacktestState) positionValue() decimal.Decimal {
+	return state.contractValue(state.Position(), state.LastPrice)
+}
+
+// borrowed 当前借入的资金
+func (state *BacktestState) borrowed() decimal.Decimal {
+	if state.Capital.IsNegative() {
+		return state.Capital.Neg()
+	}
+	return decimal.Zero
+}
+
+// marginUtilization 保证金使用率：持仓市值 / (权益 * 杠杆)，权益不为正时为1
+func (bt *Backtester) marginUtilization(state *BacktestState) float64 {
+	value := state.positionValue()
+	if !value.IsPositive() {
+		return 0
+	}
+	equity := state.equity()
+	if !equity.IsPositive() {
+		return 1
+	}
+	return money.Float(value.Div(equity.Mul(bt.margin.leverage())))
+}
+
+// accrueInterest 按两根K线之间的时间对借入资金计息，利息从资金中扣除
+func (bt *Backtester) accrueInterest(elapsed time.Duration, precision money.Precision, state *BacktestState) {
+	borrowed := state.borrowed()
+	if !bt.margin.enabled() || bt.margin.InterestRate <= 0 || !borrowed.IsPositive() || elapsed <= 0 {
+		return
+	}
+	years := elapsed.Hours() / (24 * 365)
+	interest := precision.RoundAmount(borrowed.Mul(money.FromFloat(bt.margin.InterestRate * years)))
+	state.Capital = state.Capital.Sub(interest)
+	state.Interest = state.Interest.Add(interest)
+}
+
+// checkMaintenance 检查维持保证金，权益低于持仓市值的维持保证金率时按当前K线收盘价强制平掉全部持仓
+func (bt *Backtester) checkMaintenance(symbol string, bar *Bar, book *OrderBook, state *BacktestState) {
+	if !bt.margin.enabled() || bt.margin.MaintenanceMargin <= 0 {
+		return
+	}
+	position := state.Position()
+	value := state.positionValue()
+	if !position.IsPositive() || !value.IsPositive() {
+		return
+	}
+	equity := state.equity()
+	required := value.Mul(money.FromFloat(bt.margin.MaintenanceMargin))
+	if equity.GreaterThanOrEqual(required) {
+		return
+	}
+
+	state.MarginCalls++
+	log.Printf("追加保证金: 时间=%s, 权益=%s, 维持保证金=%s，强制平仓 %s",
+		bar.Timestamp.Format("2006-01-02 15:04"), equity.StringFixed(2), required.StringFixed(2), position)
+
+	fill, err := book.Submit(&SimOrder{
+		Symbol: symbol, Side: SimSell, Type: SimMarketOrder, Quantity: position,
+		CreateTime: bar.Timestamp, Reason: "强制平仓",
+	}, *bar)
+	if err != nil {
+		log.Printf("强制平仓失败: %v", err)
+		return
+	}
+	bt.closeEntries(*fill, book, state)
+}
@@ -0,0 +1,246 @@
+package backtest
+
+import (
+	"fmt"
+	"time"
+
+	"agent-quant-system/internal/commission"
+	"agent-quant-system/internal/instrument"
+	"agent-quant-system/internal/money"
+	"agent-quant-system/internal/slippage"
+
+	"github.com/shopspring/decimal"
+)
+
+// SimOrderType 模拟订单类型
+type SimOrderType string
+
+const (
+	SimMarketOrder SimOrderType = "market" // 市价单，按当前K线收盘价成交
+	SimLimitOrder  SimOrderType = "limit"  // 限价单，价格触及后按限价成交
+	SimStopOrder   SimOrderType = "stop"   // 止损单，价格触及后按触发价成交
+)
+
+// SimOrderSide 模拟订单方向
+type SimOrderSide string
+
+const (
+	SimBuy  SimOrderSide = "buy"
+	SimSell SimOrderSide = "sell"
+)
+
+// SimOrder 回测中的模拟订单
+type SimOrder struct {
+	ID         string          `json:"id"`
+	Symbol     string          `json:"symbol"`
+	Side       SimOrderSide    `json:"side"`
+	Type       SimOrderType    `json:"type"`
+	Quantity   decimal.Decimal `json:"quantity"`
+	Price      decimal.Decimal `json:"price"` // 限价单为限价，止损单为触发价
+	CreateTime time.Time       `json:"create_time"`
+	Reason     string          `json:"reason"`
+
+	// 开仓单附带的止损止盈价，成交后作用于该笔持仓；平仓单的 EntryID 指定只平掉哪一笔持仓
+	StopLoss   decimal.Decimal `json:"stop_loss,omitempty"`
+	TakeProfit decimal.Decimal `json:"take_profit,omitempty"`
+	EntryID    string          `json:"entry_id,omitempty"`
+}
+
+// Fill 成交回报
+type Fill struct {
+	OrderID    string          `json:"order_id"`
+	Symbol     string          `json:"symbol"`
+	Side       SimOrderSide    `json:"side"`
+	Quantity   decimal.Decimal `json:"quantity"`
+	Price      decimal.Decimal `json:"price"` // 含滑点的成交价
+	Commission decimal.Decimal `json:"commission"`
+	Slippage   decimal.Decimal `json:"slippage"` // 滑点成本（金额）
+	Time       time.Time       `json:"time"`
+	StopLoss   decimal.Decimal `json:"stop_loss,omitempty"`
+	TakeProfit decimal.Decimal `json:"take_profit,omitempty"`
+	EntryID    string          `json:"entry_id,omitempty"`
+}
+
+// OrderBook 订单簿模拟器，保存挂单并在每根K线上撮合
+type OrderBook struct {
+	commission    commission.Model
+	slippageRate  decimal.Decimal
+	slippageModel *SlippageModel
+	impactModel   slippage.Model
+	precision     money.Precision
+	instrument    instrument.Instrument
+	pending       []*SimOrder
+	nextID        int
+}
+
+// NewOrderBook 创建订单簿模拟器，成交价格、数量和金额按精度取整
+func NewOrderBook(commissionRate, slippageRate float64, precision money.Precision) *OrderBook {
+	return &OrderBook{
+		commission:   commission.Percentage{Rate: money.FromFloat(commissionRate)},
+		slippageRate: money.FromFloat(slippageRate),
+		precision:    precision,
+	}
+}
+
+// SetSlippageModel 设置校准后的滑点模型，设置后按标的、订单规模和时段估算滑点率，替代固定滑点率
+func (ob *OrderBook) SetSlippageModel(model *SlippageModel) {
+	ob.slippageModel = model
+}
+
+// SetCommissionModel 设置佣金模型，替代佣金率；挂单成交（限价单）按 maker 计费
+func (ob *OrderBook) SetCommissionModel(model commission.Model) {
+	ob.commission = model
+}
+
+// SetImpactModel 设置配置的滑点模型，按订单数量、K线振幅和成交量估算滑点率；校准模型优先
+func (ob *OrderBook) SetImpactModel(model slippage.Model) {
+	ob.impactModel = model
+}
+
+// Submit 提交订单：市价单按当前K线立即成交并返回成交回报，其他订单挂单等待后续K线撮合
+func (ob *OrderBook) Submit(order *SimOrder, bar Bar) (*Fill, error) {
+	order.Quantity = ob.precision.RoundQuantity(order.Quantity)
+	if !order.Quantity.IsPositive() {
+		return nil, fmt.Errorf("订单数量必须大于0")
+	}
+	if order.Type != SimMarketOrder && !order.Price.IsPositive() {
+		return nil, fmt.Errorf("%s 订单必须指定价格", order.Type)
+	}
+
+	ob.nextID++
+	order.ID = fmt.Sprintf("BT_%d", ob.nextID)
+
+	if order.Type == SimMarketOrder {
+		fill := ob.fill(order, money.FromFloat(bar.Close), bar)
+		return &fill, nil
+	}
+
+	ob.pending = append(ob.pending, order)
+	return nil, nil
+}
+
+// Cancel 撤销挂单
+func (ob *OrderBook) Cancel(orderID string) bool {
+	for i, order := range ob.pending {
+		if order.ID == orderID {
+			ob.pending = append(ob.pending[:i], ob.pending[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Pending 当前挂单数量
+func (ob *OrderBook) Pending() int {
+	return len(ob.pending)
+}
+
+// Match 用新K线撮合之前的挂单，返回本根K线产生的成交
+func (ob *OrderBook) Match(bar Bar) []Fill {
+	var fills []Fill
+	remaining := ob.pending[:0]
+
+	for _, order := range ob.pending {
+		price, ok := ob.triggerPrice(order, bar)
+		if !ok {
+			remaining = append(remaining, order)
+			continue
+		}
+		fills = append(fills, ob.fill(order, price, bar))
+	}
+
+	ob.pending = remaining
+	return fills
+}
+
+// triggerPrice 判断订单在K线内是否成交及成交基准价
+func (ob *OrderBook) triggerPrice(order *SimOrder, bar Bar) (decimal.Decimal, bool) {
+	open := money.FromFloat(bar.Open)
+	high := money.FromFloat(bar.High)
+	low := money.FromFloat(bar.Low)
+
+	switch order.Type {
+	case SimMarketOrder:
+		return open, true
+	case SimLimitOrder:
+		if order.Side == SimBuy && low.LessThanOrEqual(order.Price) {
+			// 开盘即低于限价时按开盘价成交
+			if open.LessThan(order.Price) {
+				return open, true
+			}
+			return order.Price, true
+		}
+		if order.Side == SimSell && high.GreaterThanOrEqual(order.Price) {
+			if open.GreaterThan(order.Price) {
+				return open, true
+			}
+			return order.Price, true
+		}
+	case SimStopOrder:
+		if order.Side == SimSell && low.LessThanOrEqual(order.Price) {
+			// 跳空低开时按开盘价成交
+			if open.LessThan(order.Price) {
+				return open, true
+			}
+			return order.Price, true
+		}
+		if order.Side == SimBuy && high.GreaterThanOrEqual(order.Price) {
+			if open.GreaterThan(order.Price) {
+				return open, true
+			}
+			return order.Price, true
+		}
+	}
+	return decimal.Zero, false
+}
+
+// fill 按基准价生成成交，计入滑点和佣金
+func (ob *OrderBook) fill(order *SimOrder, price decimal.Decimal, bar Bar) Fill {
+	timestamp := bar.Timestamp
+	fillPrice := price
+	if order.Type != SimLimitOrder {
+		// 限价单按挂单价成交，不计滑点
+		one := decimal.NewFromInt(1)
+		slippageRate := ob.slippageRate
+		switch {
+		case ob.slippageModel != nil:
+			notional := money.Float(ob.value(order.Quantity, price))
+			slippageRate = money.FromFloat(ob.slippageModel.Rate(order.Symbol, notional, timestamp))
+		case ob.impactModel != nil:
+			slippageRate = money.FromFloat(ob.impactModel.Rate(slippage.Order{
+				Symbol:   order.Symbol,
+				Buy:      order.Side == SimBuy,
+				Quantity: money.Float(order.Quantity),
+				Price:    money.Float(price),
+				Time:     timestamp,
+				Volume:   float64(bar.Volume),
+				High:     bar.High,
+				Low:      bar.Low,
+			}))
+		}
+		if order.Side == SimBuy {
+			fillPrice = price.Mul(one.Add(slippageRate))
+		} else {
+			fillPrice = price.Mul(one.Sub(slippageRate))
+		}
+	}
+	fillPrice = ob.precision.RoundPrice(ob.instrument.RoundPrice(fillPrice))
+	fee := ob.commission.Commission(commission.Fill{
+		Symbol: order.Symbol, Buy: order.Side == SimBuy, Quantity: order.Quantity, Price: fillPrice, Maker: order.Type == SimLimitOrder,
+		Multiplier: ob.instrument.Multiplier,
+	})
+
+	return Fill{
+		OrderID:    order.ID,
+		Symbol:     order.Symbol,
+		Side:       order.Side,
+		Quantity:   order.Quantity,
+		Price:      fillPrice,
+		Commission: ob.precision.RoundAmount(fee),
+		Slippage:   ob.precision.RoundAmount(ob.value(order.Quantity, fillPrice.Sub(price).Abs())),
+		Time:       timestamp,
+		StopLoss:   order.StopLoss,
+		TakeProfit: order.TakeProfit,
+		EntryID:    order.EntryID,
+	}
+}
@@ -0,0 +1,43 @@
+package backtest
+
+import (
+	"log"
+
+	"agent-quant-system/internal/money"
+	"agent-quant-system/internal/sizing"
+	"agent-quant-system/internal/strategy"
+
+	"github.com/shopspring/decimal"
+)
+
+// SetSizing 设置开仓数量的计算方式，与实盘使用同一套仓位规则；未设置时使用策略给出的数量
+func (bt *Backtester) SetSizing(policy sizing.Policy) {
+	bt.sizing = policy
+}
+
+// signalQuantity 买入信号的开仓数量：按仓位计算方式以当前权益和已平仓交易的统计计算，未设置时为信号数量
+func (bt *Backtester) signalQuantity(signal strategy.TradingSignal, price decimal.Decimal, precision money.Precision, state *BacktestState) decimal.Decimal {
+	if bt.sizing == nil {
+		return money.FromFloat(signal.Quantity)
+	}
+	request := sizing.Request{
+		Equity:     money.Float(state.equity()),
+		Price:      money.Float(price),
+		StopLoss:   signal.StopLoss,
+		Quantity:   signal.Quantity,
+		Multiplier: money.Float(bt.multiplier()),
+		Stats:      state.tradeStats(),
+	}
+	quantity := precision.RoundQuantity(money.FromFloat(bt.sizing.Quantity(request)))
+	log.Printf("仓位计算(%s): 权益=%.2f, 信号数量=%.4f, 开仓数量=%s", bt.sizing.Name(), request.Equity, signal.Quantity, quantity)
+	return quantity
+}
+
+// tradeStats 已平仓交易的盈亏统计
+func (state *BacktestState) tradeStats() sizing.Stats {
+	var stats sizing.Stats
+	for _, trade := range state.TradeHistory {
+		stats.Add(trade.PnL)
+	}
+	return stats
+}
@@ -0,0 +1,186 @@
+package backtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// slippageSizeUnit 规模项的名义金额单位：滑点率 = 基础滑点率 + 规模系数 * sqrt(名义金额 / 单位)
+const slippageSizeUnit = 10000.0
+
+// SlippageSample 一笔实盘成交的滑点样本
+type SlippageSample struct {
+	Symbol   string    `json:"symbol"`
+	Time     time.Time `json:"time"`
+	Notional float64   `json:"notional"` // 按参考价格计算的名义金额
+	Slippage float64   `json:"slippage"` // 不利成交成本（金额）
+}
+
+// SlippageModel 按标的、订单规模和时段拟合的滑点模型：
+// 滑点率 = (标的基础滑点率 + 规模系数 * sqrt(名义金额/10000)) * 时段系数
+type SlippageModel struct {
+	BaseRate          float64            `json:"base_rate"`          // 样本不足的标的使用的基础滑点率
+	SizeCoefficient   float64            `json:"size_coefficient"`   // 规模系数
+	SymbolRates       map[string]float64 `json:"symbol_rates"`       // 标的基础滑点率
+	HourlyMultipliers map[int]float64    `json:"hourly_multipliers"` // 按小时（Timezone 时区）的系数，缺失时为1
+	Timezone          string             `json:"timezone"`
+	Samples           int                `json:"samples"`
+	FittedAt          time.Time          `json:"fitted_at"`
+
+	location *time.Location
+}
+
+// FitSlippageModel 用实盘成交样本拟合滑点模型，样本数少于 minSamples 的标的和时段不单独拟合
+func FitSlippageModel(samples []SlippageSample, timezone string, minSamples int) (*SlippageModel, error) {
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, fmt.Errorf("加载时区失败: %w", err)
+	}
+	if minSamples < 1 {
+		minSamples = 1
+	}
+
+	var xs, rates []float64
+	var valid []SlippageSample
+	for _, sample := range samples {
+		if sample.Notional <= 0 {
+			continue
+		}
+		valid = append(valid, sample)
+		xs = append(xs, math.Sqrt(sample.Notional/slippageSizeUnit))
+		rates = append(rates, sample.Slippage/sample.Notional)
+	}
+	if len(valid) < minSamples {
+		return nil, fmt.Errorf("有效成交样本不足: 需要 %d, 实际 %d", minSamples, len(valid))
+	}
+
+	model := &SlippageModel{
+		SymbolRates:       make(map[string]float64),
+		HourlyMultipliers: make(map[int]float64),
+		Timezone:          timezone,
+		Samples:           len(valid),
+		FittedAt:          time.Now(),
+		location:          location,
+	}
+
+	// 全部样本的最小二乘：滑点率 = 基础滑点率 + 规模系数 * x
+	meanX, meanRate := mean(xs), mean(rates)
+	var covariance, variance float64
+	for i := range xs {
+		covariance += (xs[i] - meanX) * (rates[i] - meanRate)
+		variance += (xs[i] - meanX) * (xs[i] - meanX)
+	}
+	if variance > 0 {
+		model.SizeCoefficient = math.Max(covariance/variance, 0)
+	}
+	model.BaseRate = math.Max(meanRate-model.SizeCoefficient*meanX, 0)
+
+	// 标的基础滑点率：基础滑点率加该标的的平均残差
+	residuals := make(map[string][]float64)
+	for i, sample := range valid {
+		residuals[sample.Symbol] = append(residuals[sample.Symbol], rates[i]-model.BaseRate-model.SizeCoefficient*xs[i])
+	}
+	for symbol, values := range residuals {
+		if len(values) >= minSamples {
+			model.SymbolRates[symbol] = math.Max(model.BaseRate+mean(values), 0)
+		}
+	}
+
+	// 时段系数：该小时实际滑点与模型预测滑点之比
+	actual := make(map[int]float64)
+	predicted := make(map[int]float64)
+	counts := make(map[int]int)
+	for i, sample := range valid {
+		hour := sample.Time.In(location).Hour()
+		actual[hour] += rates[i]
+		predicted[hour] += model.symbolRate(sample.Symbol) + model.SizeCoefficient*xs[i]
+		counts[hour]++
+	}
+	for hour, count := range counts {
+		if count >= minSamples && predicted[hour] > 0 {
+			model.HourlyMultipliers[hour] = actual[hour] / predicted[hour]
+		}
+	}
+
+	return model, nil
+}
+
+// symbolRate 标的基础滑点率
+func (m *SlippageModel) symbolRate(symbol string) float64 {
+	if rate, exists := m.SymbolRates[symbol]; exists {
+		return rate
+	}
+	return m.BaseRate
+}
+
+// Rate 估算订单的滑点率
+func (m *SlippageModel) Rate(symbol string, notional float64, t time.Time) float64 {
+	rate := m.symbolRate(symbol)
+	if notional > 0 {
+		rate += m.SizeCoefficient * math.Sqrt(notional/slippageSizeUnit)
+	}
+
+	location := m.location
+	if location == nil {
+		location = time.Local
+	}
+	if multiplier, exists := m.HourlyMultipliers[t.In(location).Hour()]; exists {
+		rate *= multiplier
+	}
+	return rate
+}
+
+// LoadSlippageModel 从JSON文件加载滑点模型
+func LoadSlippageModel(path string) (*SlippageModel, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取滑点模型失败: %w", err)
+	}
+
+	var model SlippageModel
+	if err := json.Unmarshal(content, &model); err != nil {
+		return nil, fmt.Errorf("解析滑点模型失败: %w", err)
+	}
+
+	model.location = time.Local
+	if model.Timezone != "" {
+		location, err := time.LoadLocation(model.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("滑点模型时区无效: %w", err)
+		}
+		model.location = location
+	}
+	return &model, nil
+}
+
+// Save 将滑点模型写入JSON文件
+func (m *SlippageModel) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("创建滑点模型目录失败: %w", err)
+	}
+
+	content, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化滑点模型失败: %w", err)
+	}
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		return fmt.Errorf("写入滑点模型失败: %w", err)
+	}
+	return nil
+}
+
+// mean 均值
+func mean(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
@@ -0,0 +1,306 @@
+package backtest
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"agent-quant-system/internal/data"
+	"agent-quant-system/internal/money"
+	"agent-quant-system/internal/strategy"
+
+	"github.com/shopspring/decimal"
+)
+
+// TickOptions 逐笔回测的设置：逐笔成交按 BarInterval 聚合为K线供策略生成信号，
+// 订单在K线结束时发出，经过 Latency 后按逐笔成交和订单簿快照撮合
+type TickOptions struct {
+	BarInterval time.Duration
+	Latency     time.Duration
+}
+
+// SetTickMode 启用逐笔回测，Run 改为回放区间内的逐笔成交和订单簿快照；
+// 逐笔回测不支持检查点、保证金计息和期货连续合约换月
+func (bt *Backtester) SetTickMode(opts TickOptions) {
+	bt.ticks = &opts
+}
+
+// runTicks 逐笔回测：按时间顺序回放订单簿快照和逐笔成交撮合订单，每根聚合K线结束时运行策略；
+// 实现了 RestingOrderStrategy 的策略（网格、做市）在每根K线结束时按返回的挂单撤补常驻限价单
+func (bt *Backtester) runTicks(symbol, startDate, endDate string) (*BacktestResult, error) {
+	opts := *bt.ticks
+	if opts.BarInterval <= 0 {
+		return nil, fmt.Errorf("逐笔回测的K线周期必须大于0")
+	}
+	if bt.checkpoint.Path != "" || bt.margin.enabled() {
+		log.Printf("逐笔回测不支持检查点和保证金交易，将忽略这些设置")
+	}
+
+	start, end, err := bt.dataManager.DateRange(symbol, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	budget := newBudget(bt.limits)
+	ticks, err := bt.dataManager.GetTicks(symbol, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("获取逐笔成交失败: %w", err)
+	}
+	if err := budget.loaded("获取逐笔成交"); err != nil {
+		return nil, err
+	}
+	books, err := bt.dataManager.GetBookSnapshots(symbol, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("获取订单簿快照失败: %w", err)
+	}
+	if err := budget.loaded("获取订单簿快照"); err != nil {
+		return nil, err
+	}
+	if len(books) == 0 {
+		log.Printf("%s 没有订单簿快照，市价单按逐笔成交价加滑点成交", symbol)
+	}
+
+	df := bt.dataManager.TickFrame(symbol, ticks, opts.BarInterval)
+	if err := bt.dataManager.ValidateData(df); err != nil {
+		return nil, fmt.Errorf("数据验证失败: %w", err)
+	}
+
+	state := &BacktestState{
+		Capital:      money.FromFloat(bt.initialCapital),
+		EquityCurve:  make([]EquityPoint, 0),
+		TradeHistory: make([]TradeRecord, 0),
+		multiplier:   bt.multiplier(),
+		barState:     bt.newBarState(symbol),
+	}
+	log.Printf("开始逐笔回测: 标的=%s, 逐笔成交=%d, 订单簿快照=%d, K线周期=%v, 延迟=%v",
+		symbol, len(ticks), len(books), opts.BarInterval, opts.Latency)
+
+	var exceeded *budgetExceeded
+	tb, err := bt.executeTicks(symbol, df, ticks, books, opts, budget, state)
+	if err != nil && !errors.As(err, &exceeded) {
+		return nil, fmt.Errorf("执行逐笔回测失败: %w", err)
+	}
+	if exceeded != nil {
+		log.Printf("逐笔回测提前中止，生成部分结果: %v", exceeded)
+		if n := len(state.EquityCurve); n > 0 {
+			endDate = bt.dataManager.Session(symbol).FormatDate(state.EquityCurve[n-1].Date)
+		}
+	}
+
+	result := bt.generateReport(symbol, startDate, endDate, state)
+	stats := tb.Stats()
+	result.Execution = &stats
+	if exceeded != nil {
+		result.Aborted = true
+		result.AbortReason = exceeded.Error()
+	}
+	log.Printf("逐笔回测完成: 总收益=%.2f%%, 主动成交=%d, 挂单成交=%d, 部分成交=%d, 平均价差=%.2f 基点",
+		result.TotalReturn*100, stats.TakerFills, stats.MakerFills, stats.PartialFills, stats.AvgSpreadBps)
+	return result, nil
+}
+
+// tickRun 一次逐笔回测的运行状态
+type tickRun struct {
+	symbol     string
+	tb         *TickBook
+	queue      *EventQueue
+	state      *BacktestState
+	lastFilled int // 最近一次成交的常驻单档位，没有成交时为 -1
+}
+
+// executeTicks 逐笔推进：每根K线结束前回放其间的快照和成交（同一时刻快照先于成交），K线结束时生成信号
+func (bt *Backtester) executeTicks(symbol string, df data.DataFrame, ticks []data.Tick, books []data.BookSnapshot, opts TickOptions, budget *budget, state *BacktestState) (*TickBook, error) {
+	bars, err := barsFromDataFrame(df)
+	if err != nil {
+		return nil, fmt.Errorf("解析K线失败: %w", err)
+	}
+	warmup := bt.warmupBars()
+	if len(bars) < warmup {
+		return nil, fmt.Errorf("数据长度不足: 需要 %d 根K线, 实际 %d", warmup, len(bars))
+	}
+
+	precision := bt.precision.For(symbol)
+	book := NewOrderBook(bt.commissionRate, bt.slippageRate, precision)
+	book.SetSlippageModel(bt.slippageModel)
+	book.SetImpactModel(bt.impactModel)
+	book.SetCommissionModel(bt.commissionModel())
+	book.SetInstrument(bt.instrument)
+	run := &tickRun{symbol: symbol, tb: NewTickBook(book, opts.Latency), queue: &EventQueue{}, state: state, lastFilled: -1}
+	resting, _ := bt.strategy.(strategy.RestingOrderStrategy)
+	if resting != nil && !resting.AppliesTo(symbol) {
+		resting = nil
+	}
+
+	var benchmark *buyAndHold
+	ti, bi := 0, 0
+	for i := range bars {
+		bar := &bars[i]
+		barEnd := bar.Timestamp.Add(opts.BarInterval)
+		for {
+			nextBook := bi < len(books) && books[bi].Timestamp.Before(barEnd)
+			nextTick := ti < len(ticks) && ticks[ti].Timestamp.Before(barEnd)
+			if !nextBook && !nextTick {
+				break
+			}
+			if nextBook && (!nextTick || !ticks[ti].Timestamp.Before(books[bi].Timestamp)) {
+				bt.applyTickFills(run, run.tb.OnBook(&books[bi]))
+				bi++
+				continue
+			}
+			bt.applyTickFills(run, run.tb.OnTrade(ticks[ti]))
+			state.LastPrice = money.FromFloat(ticks[ti].Price)
+			ti++
+		}
+
+		// K线结束：按收盘价运行策略，订单以K线结束时间发出
+		state.LastPrice = money.FromFloat(bar.Close)
+		signals, err := bt.barSignals(bar, df, warmup, state)
+		if err != nil {
+			log.Printf("生成信号失败: %v", err)
+		}
+		if bar.Index >= warmup-1 {
+			for j := range signals {
+				if order := bt.orderFromSignal(signals[j], bar, precision, state); order != nil {
+					bt.submitTick(run, order, barEnd, -1)
+				}
+			}
+			if resting != nil {
+				bt.requote(run, resting, bar, barEnd)
+			}
+			bt.applyTickFills(run, run.tb.Activate(barEnd))
+
+			bt.updateEquityCurve(bar.Timestamp, state)
+			if benchmark == nil {
+				benchmark = bt.newBuyAndHold(bar.Close, precision)
+			}
+			state.BenchmarkCurve = append(state.BenchmarkCurve, EquityPoint{
+				Date:  bar.Timestamp,
+				Value: money.Float(benchmark.value(bar.Close)),
+			})
+		}
+
+		if err := budget.check(i + 1); err != nil {
+			return run.tb, err
+		}
+	}
+	return run.tb, nil
+}
+
+// submitTick 提交订单到逐笔撮合器，平仓单登记到对应持仓以便平仓后撤销
+func (bt *Backtester) submitTick(run *tickRun, order *SimOrder, now time.Time, level int) {
+	if err := run.tb.Submit(order, now, level); err != nil {
+		log.Printf("处理信号失败: %v", err)
+		return
+	}
+	if entry := run.state.entry(order.EntryID); entry != nil {
+		entry.exitOrders = append(entry.exitOrders, order.ID)
+	}
+}
+
+// applyTickFills 按成交更新资金和持仓，成交挂出的止损止盈单经过延迟后进入撮合；
+// 没有持仓的卖出成交（如常驻卖单）按现有持仓截断，没有持仓时撤销该订单
+func (bt *Backtester) applyTickFills(run *tickRun, fills []Fill) {
+	for _, fill := range fills {
+		if level := run.tb.FillLevel(fill); level >= 0 {
+			run.lastFilled = level
+		}
+		if fill.Side == SimSell && fill.EntryID == "" {
+			position := run.state.Position()
+			if !position.IsPositive() {
+				run.tb.Cancel(baseOrderID(fill.OrderID))
+				continue
+			}
+			if fill.Quantity.GreaterThan(position) {
+				fill.Commission = fill.Commission.Mul(position).Div(fill.Quantity)
+				fill.Slippage = fill.Slippage.Mul(position).Div(fill.Quantity)
+				fill.Quantity = position
+			}
+		}
+		bt.applyFill(fill, run.tb, run.queue, run.state)
+	}
+	for run.queue.Len() > 0 {
+		event, _ := run.queue.Pop()
+		if event.Type == OrderEventType {
+			bt.submitTick(run, event.Order, event.Time, -1)
+		}
+	}
+}
+
+// baseOrderID 部分成交的成交ID对应的订单ID
+func baseOrderID(fillID string) string {
+	for i := len(fillID) - 1; i >= 0; i-- {
+		if fillID[i] == '.' {
+			return fillID[:i]
+		}
+	}
+	return fillID
+}
+
+// requote 按策略返回的常驻单撤补挂单：档位、方向、价格和数量都相同的挂单保留，其余撤销后重新挂出；
+// 买单按可用资金依次挂出，资金不足的档位不挂
+func (bt *Backtester) requote(run *tickRun, resting strategy.RestingOrderStrategy, bar *Bar, now time.Time) {
+	price := bar.Close
+	if book := run.tb.book; book != nil && book.Mid() > 0 {
+		price = book.Mid()
+	}
+	desired, err := resting.RestingOrders(run.symbol, price, money.Float(run.state.Position()), run.lastFilled)
+	if err != nil {
+		log.Printf("计算常驻挂单失败: %v", err)
+		return
+	}
+
+	type key struct {
+		level int
+		side  SimOrderSide
+		price string
+		size  string
+	}
+	precision := run.tb.ob.precision
+	wanted := make(map[key]strategy.RestingOrder, len(desired))
+	for _, order := range desired {
+		side := SimBuy
+		if order.Side == strategy.Sell {
+			side = SimSell
+		}
+		k := key{order.Level, side, precision.RoundPrice(money.FromFloat(order.Price)).String(), precision.RoundQuantity(money.FromFloat(order.Quantity)).String()}
+		wanted[k] = order
+	}
+
+	reserved := decimal.Zero
+	for _, order := range run.tb.Resting() {
+		k := key{run.tb.levelOf(order.ID), order.Side, order.Price.String(), order.Quantity.String()}
+		if _, keep := wanted[k]; keep {
+			delete(wanted, k)
+			if order.Side == SimBuy {
+				reserved = reserved.Add(run.state.contractValue(order.Quantity, order.Price))
+			}
+			continue
+		}
+		run.tb.Cancel(order.ID)
+	}
+
+	for _, order := range desired {
+		side := SimBuy
+		if order.Side == strategy.Sell {
+			side = SimSell
+		}
+		limit := precision.RoundPrice(money.FromFloat(order.Price))
+		quantity := precision.RoundQuantity(money.FromFloat(order.Quantity))
+		k := key{order.Level, side, limit.String(), quantity.String()}
+		if _, missing := wanted[k]; !missing {
+			continue
+		}
+		delete(wanted, k)
+		if side == SimBuy {
+			cost := run.state.contractValue(quantity, limit)
+			if reserved.Add(cost).GreaterThan(bt.buyingPower(run.state)) {
+				continue
+			}
+			reserved = reserved.Add(cost)
+		}
+		bt.submitTick(run, &SimOrder{
+			Symbol: run.symbol, Side: side, Type: SimLimitOrder, Quantity: quantity, Price: limit,
+			CreateTime: now, Reason: fmt.Sprintf("常驻挂单 第%d档", order.Level),
+		}, now, order.Level)
+	}
+}
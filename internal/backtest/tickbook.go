@@ -0,0 +1,332 @@
+package backtest
+
+import (
+	"fmt"
+	"time"
+
+	"agent-quant-system/internal/commission"
+	"agent-quant-system/internal/data"
+	"agent-quant-system/internal/money"
+
+	"github.com/shopspring/decimal"
+)
+
+// ExecutionStats 逐笔回测的撮合统计
+type ExecutionStats struct {
+	Ticks         int           `json:"ticks"`          // 回放的逐笔成交数
+	BookSnapshots int           `json:"book_snapshots"` // 回放的订单簿快照数
+	TakerFills    int           `json:"taker_fills"`    // 主动成交（市价单、可立即成交的限价单、触发的止损单）
+	MakerFills    int           `json:"maker_fills"`    // 挂单被动成交
+	PartialFills  int           `json:"partial_fills"`  // 未全部成交的部分成交
+	Cancelled     int           `json:"cancelled"`      // 撤销的挂单
+	AvgSpreadBps  float64       `json:"avg_spread_bps"` // 成交时买卖价差的平均值（基点）
+	AvgFillDelay  time.Duration `json:"avg_fill_delay"` // 从发出订单到成交的平均时长
+
+	spreadSum   float64
+	spreadCount int
+	delaySum    time.Duration
+	fillCount   int
+}
+
+// tickOrder 逐笔撮合中的订单
+type tickOrder struct {
+	order      *SimOrder
+	remaining  decimal.Decimal
+	active     time.Time // 进入撮合的时间：发出时间加延迟
+	resting    bool      // 已进入撮合并挂在订单簿上
+	queueAhead float64   // 挂单价位上排在前面的数量
+	fills      int
+	level      int // 策略常驻单的档位，-1 表示普通订单
+}
+
+// TickBook 逐笔撮合器：订单经过延迟后进入撮合，市价单按订单簿快照逐档吃单，
+// 限价单按挂单时该价位的挂单量排队，逐笔成交消耗完前面的数量后才成交，价格穿过限价时剩余数量全部成交；
+// 佣金、精度和没有订单簿时的滑点沿用 OrderBook 的设置
+type TickBook struct {
+	ob      *OrderBook
+	latency time.Duration
+	book    *data.BookSnapshot // 最近一次的订单簿快照
+	last    decimal.Decimal    // 最近一笔成交价
+	orders  []*tickOrder
+	stats   ExecutionStats
+	levels  map[string]int // 常驻单成交的成交ID -> 档位
+}
+
+// NewTickBook 创建逐笔撮合器
+func NewTickBook(ob *OrderBook, latency time.Duration) *TickBook {
+	return &TickBook{ob: ob, latency: latency, levels: make(map[string]int)}
+}
+
+// Submit 提交订单，订单在 now 加延迟后进入撮合；level 为策略常驻单的档位，普通订单为 -1
+func (tb *TickBook) Submit(order *SimOrder, now time.Time, level int) error {
+	order.Quantity = tb.ob.precision.RoundQuantity(order.Quantity)
+	if !order.Quantity.IsPositive() {
+		return fmt.Errorf("订单数量必须大于0")
+	}
+	if order.Type != SimMarketOrder && !order.Price.IsPositive() {
+		return fmt.Errorf("%s 订单必须指定价格", order.Type)
+	}
+
+	tb.ob.nextID++
+	order.ID = fmt.Sprintf("BT_%d", tb.ob.nextID)
+	if order.CreateTime.IsZero() {
+		order.CreateTime = now
+	}
+	tb.orders = append(tb.orders, &tickOrder{order: order, remaining: order.Quantity, active: now.Add(tb.latency), level: level})
+	return nil
+}
+
+// Cancel 撤销订单
+func (tb *TickBook) Cancel(orderID string) bool {
+	for i, order := range tb.orders {
+		if order.order.ID == orderID {
+			tb.orders = append(tb.orders[:i], tb.orders[i+1:]...)
+			tb.stats.Cancelled++
+			return true
+		}
+	}
+	return false
+}
+
+// Pending 未成交的订单数量
+func (tb *TickBook) Pending() int {
+	return len(tb.orders)
+}
+
+// Resting 策略常驻单，按档位
+func (tb *TickBook) Resting() []*SimOrder {
+	var orders []*SimOrder
+	for _, order := range tb.orders {
+		if order.level >= 0 {
+			orders = append(orders, order.order)
+		}
+	}
+	return orders
+}
+
+// levelOf 订单的常驻单档位，普通订单为 -1
+func (tb *TickBook) levelOf(orderID string) int {
+	for _, order := range tb.orders {
+		if order.order.ID == orderID {
+			return order.level
+		}
+	}
+	return -1
+}
+
+// FillLevel 成交对应的常驻单档位，普通订单的成交为 -1
+func (tb *TickBook) FillLevel(fill Fill) int {
+	if level, ok := tb.levels[fill.OrderID]; ok {
+		return level
+	}
+	return -1
+}
+
+// Stats 撮合统计
+func (tb *TickBook) Stats() ExecutionStats {
+	stats := tb.stats
+	if stats.spreadCount > 0 {
+		stats.AvgSpreadBps = stats.spreadSum / float64(stats.spreadCount)
+	}
+	if stats.fillCount > 0 {
+		stats.AvgFillDelay = stats.delaySum / time.Duration(stats.fillCount)
+	}
+	return stats
+}
+
+// Activate 让到达 now 的订单进入撮合，返回立即产生的成交
+func (tb *TickBook) Activate(now time.Time) []Fill {
+	var fills []Fill
+	for _, order := range tb.snapshot() {
+		if order.resting || order.active.After(now) {
+			continue
+		}
+		order.resting = true
+		switch order.order.Type {
+		case SimMarketOrder:
+			fills = append(fills, tb.take(order, order.active, decimal.Zero)...)
+		case SimLimitOrder:
+			fills = append(fills, tb.take(order, order.active, order.order.Price)...)
+			if order.remaining.IsPositive() && tb.book != nil {
+				order.queueAhead = tb.book.SizeAt(order.order.Side == SimBuy, money.Float(order.order.Price))
+			}
+		}
+	}
+	tb.prune()
+	return fills
+}
+
+// OnBook 更新订单簿快照，先让快照之前到达的订单按上一快照撮合
+func (tb *TickBook) OnBook(snapshot *data.BookSnapshot) []Fill {
+	tb.stats.BookSnapshots++
+	fills := tb.Activate(snapshot.Timestamp)
+	tb.book = snapshot
+	return fills
+}
+
+// OnTrade 用一笔逐笔成交撮合挂单和止损单
+func (tb *TickBook) OnTrade(tick data.Tick) []Fill {
+	tb.stats.Ticks++
+	fills := tb.Activate(tick.Timestamp)
+	tb.last = money.FromFloat(tick.Price)
+	price := tb.last
+
+	for _, order := range tb.snapshot() {
+		if !order.resting || !order.remaining.IsPositive() {
+			continue
+		}
+		buy := order.order.Side == SimBuy
+		switch order.order.Type {
+		case SimStopOrder:
+			if buy && price.GreaterThanOrEqual(order.order.Price) || !buy && price.LessThanOrEqual(order.order.Price) {
+				fills = append(fills, tb.take(order, tick.Timestamp, decimal.Zero)...)
+			}
+		case SimLimitOrder:
+			limit := order.order.Price
+			through := buy && price.LessThan(limit) || !buy && price.GreaterThan(limit)
+			if through {
+				// 成交价穿过限价，该价位的挂单已全部成交
+				fills = append(fills, tb.fill(order, order.remaining, limit, limit, true, tick.Timestamp))
+				continue
+			}
+			// 成交价等于限价：主动方向与挂单相对（或未知）时先消耗排在前面的数量
+			aggressor := tick.Side == "" || buy && tick.Side == data.TickSell || !buy && tick.Side == data.TickBuy
+			if !price.Equal(limit) || !aggressor {
+				continue
+			}
+			available := tick.Size - order.queueAhead
+			order.queueAhead -= tick.Size
+			if order.queueAhead < 0 {
+				order.queueAhead = 0
+			}
+			if available <= 0 {
+				continue
+			}
+			quantity := tb.ob.precision.RoundQuantity(decimal.Min(order.remaining, money.FromFloat(available)))
+			if quantity.IsPositive() {
+				fills = append(fills, tb.fill(order, quantity, limit, limit, true, tick.Timestamp))
+			}
+		}
+	}
+	tb.prune()
+	return fills
+}
+
+// take 按订单簿主动成交：买单从最优卖价逐档向上、卖单从最优买价逐档向下，limit 为正时不超过限价，
+// 吃掉的各档合并为一笔按成交量加权均价的成交；市价单和触发的止损单超出快照深度的部分按最后一档价格成交。
+// 没有订单簿时市价单按最新成交价加滑点成交
+func (tb *TickBook) take(order *tickOrder, now time.Time, limit decimal.Decimal) []Fill {
+	buy := order.order.Side == SimBuy
+	if tb.book == nil || len(tb.opposite(buy)) == 0 {
+		if limit.IsPositive() || !tb.last.IsPositive() {
+			// 没有订单簿时限价单按逐笔成交价撮合，市价单没有成交价时等待第一笔成交
+			order.resting = limit.IsPositive()
+			return nil
+		}
+		remaining := *order.order
+		remaining.Quantity = order.remaining
+		fill := tb.ob.fill(&remaining, tb.last, Bar{Timestamp: now})
+		order.remaining = decimal.Zero
+		return []Fill{tb.record(order, fill, false, now)}
+	}
+
+	filled, notional := decimal.Zero, decimal.Zero
+	levels := tb.opposite(buy)
+	for i, level := range levels {
+		left := order.remaining.Sub(filled)
+		if !left.IsPositive() {
+			break
+		}
+		price := money.FromFloat(level.Price)
+		if limit.IsPositive() && (buy && price.GreaterThan(limit) || !buy && price.LessThan(limit)) {
+			break
+		}
+		quantity := decimal.Min(left, money.FromFloat(level.Size))
+		if i == len(levels)-1 && !limit.IsPositive() {
+			quantity = left
+		}
+		filled = filled.Add(quantity)
+		notional = notional.Add(quantity.Mul(price))
+	}
+	quantity := tb.ob.precision.RoundQuantity(filled)
+	if !quantity.IsPositive() {
+		return nil
+	}
+	return []Fill{tb.fill(order, quantity, notional.Div(filled), money.FromFloat(tb.book.Mid()), false, now)}
+}
+
+// opposite 订单主动成交时对手方的档位
+func (tb *TickBook) opposite(buy bool) []data.BookLevel {
+	if buy {
+		return tb.book.Asks
+	}
+	return tb.book.Bids
+}
+
+// fill 按成交价生成一笔成交，reference 为计算滑点的基准价（主动成交为中间价，挂单成交为限价）
+func (tb *TickBook) fill(order *tickOrder, quantity, price, reference decimal.Decimal, maker bool, now time.Time) Fill {
+	price = tb.ob.precision.RoundPrice(tb.ob.instrument.RoundPrice(price))
+	fee := tb.ob.commission.Commission(commission.Fill{
+		Symbol: order.order.Symbol, Buy: order.order.Side == SimBuy, Quantity: quantity, Price: price, Maker: maker,
+		Multiplier: tb.ob.instrument.Multiplier,
+	})
+	order.remaining = order.remaining.Sub(quantity)
+	fill := Fill{
+		OrderID:    order.order.ID,
+		Symbol:     order.order.Symbol,
+		Side:       order.order.Side,
+		Quantity:   quantity,
+		Price:      price,
+		Commission: tb.ob.precision.RoundAmount(fee),
+		Slippage:   tb.ob.precision.RoundAmount(tb.ob.value(quantity, price.Sub(reference).Abs())),
+		Time:       now,
+		StopLoss:   order.order.StopLoss,
+		TakeProfit: order.order.TakeProfit,
+		EntryID:    order.order.EntryID,
+	}
+	return tb.record(order, fill, maker, now)
+}
+
+// record 记录成交统计；同一订单的后续部分成交使用带序号的成交ID，使每次买入成交成为独立的一笔持仓
+func (tb *TickBook) record(order *tickOrder, fill Fill, maker bool, now time.Time) Fill {
+	order.fills++
+	if order.fills > 1 {
+		fill.OrderID = fmt.Sprintf("%s.%d", order.order.ID, order.fills)
+	}
+	if order.level >= 0 {
+		tb.levels[fill.OrderID] = order.level
+	}
+	if maker {
+		tb.stats.MakerFills++
+	} else {
+		tb.stats.TakerFills++
+	}
+	if order.remaining.IsPositive() {
+		tb.stats.PartialFills++
+	}
+	if tb.book != nil {
+		if mid := tb.book.Mid(); mid > 0 {
+			tb.stats.spreadSum += tb.book.Spread() / mid * 10000
+			tb.stats.spreadCount++
+		}
+	}
+	tb.stats.delaySum += now.Sub(order.order.CreateTime)
+	tb.stats.fillCount++
+	return fill
+}
+
+// snapshot 当前订单的副本，撮合过程中可以安全地撤单
+func (tb *TickBook) snapshot() []*tickOrder {
+	return append([]*tickOrder(nil), tb.orders...)
+}
+
+// prune 移除已全部成交的订单
+func (tb *TickBook) prune() {
+	open := tb.orders[:0]
+	for _, order := range tb.orders {
+		if order.remaining.IsPositive() {
+			open = append(open, order)
+		}
+	}
+	tb.orders = open
+}
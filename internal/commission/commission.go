@@ -0,0 +1,176 @@
+package commission
+
+import (
+	"fmt"
+
+	"agent-quant-system/internal/config"
+	"agent-quant-system/internal/money"
+
+	"github.com/shopspring/decimal"
+)
+
+// Fill 计算佣金所需的成交信息
+type Fill struct {
+	Symbol   string
+	Buy      bool
+	Quantity decimal.Decimal
+	Price    decimal.Decimal
+	Maker    bool // 成交前在订单簿上挂单（提供流动性），立即成交的订单为 taker
+
+	Multiplier decimal.Decimal // 合约乘数，期货每手对应的标的数量，0表示1
+}
+
+// notional 成交金额，期货为手数乘以价格再乘以合约乘数
+func (f Fill) notional() decimal.Decimal {
+	notional := f.Quantity.Mul(f.Price)
+	if f.Multiplier.IsPositive() {
+		notional = notional.Mul(f.Multiplier)
+	}
+	return notional
+}
+
+// Model 佣金模型，返回单笔成交的佣金金额（未按精度取整）
+type Model interface {
+	// Name 模型名称
+	Name() string
+
+	// Commission 计算成交的佣金
+	Commission(fill Fill) decimal.Decimal
+}
+
+// Percentage 按成交金额比例收取
+type Percentage struct {
+	Rate decimal.Decimal
+}
+
+// Name 模型名称
+func (m Percentage) Name() string { return "percentage" }
+
+// Commission 成交金额乘以费率
+func (m Percentage) Commission(fill Fill) decimal.Decimal {
+	return fill.notional().Mul(m.Rate)
+}
+
+// PerShare 按成交股数收取，MaxPercent 大于0时不超过成交金额的该比例
+type PerShare struct {
+	PerShare   decimal.Decimal
+	MaxPercent decimal.Decimal
+}
+
+// Name 模型名称
+func (m PerShare) Name() string { return "per_share" }
+
+// Commission 股数乘以每股佣金
+func (m PerShare) Commission(fill Fill) decimal.Decimal {
+	fee := fill.Quantity.Abs().Mul(m.PerShare)
+	if m.MaxPercent.IsPositive() {
+		fee = decimal.Min(fee, fill.notional().Abs().Mul(m.MaxPercent))
+	}
+	return fee
+}
+
+// Tier 分档费率：成交金额中不超过 UpTo 的部分按 Rate 收取，最后一档的 UpTo 为0表示不设上限
+type Tier struct {
+	UpTo decimal.Decimal
+	Rate decimal.Decimal
+}
+
+// Tiered 按成交金额分档累进收取，每一档只对落在该档的金额按该档费率计费
+type Tiered struct {
+	Tiers []Tier
+}
+
+// Name 模型名称
+func (m Tiered) Name() string { return "tiered" }
+
+// Commission 各档金额乘以该档费率之和
+func (m Tiered) Commission(fill Fill) decimal.Decimal {
+	remaining := fill.notional().Abs()
+	fee := decimal.Zero
+	lower := decimal.Zero
+	for _, tier := range m.Tiers {
+		if !remaining.IsPositive() {
+			break
+		}
+		portion := remaining
+		if tier.UpTo.IsPositive() {
+			portion = decimal.Min(remaining, tier.UpTo.Sub(lower))
+			lower = tier.UpTo
+		}
+		fee = fee.Add(portion.Mul(tier.Rate))
+		remaining = remaining.Sub(portion)
+	}
+	return fee
+}
+
+// MakerTaker 挂单成交和立即成交使用不同费率，maker 费率为负表示返佣
+type MakerTaker struct {
+	MakerRate decimal.Decimal
+	TakerRate decimal.Decimal
+}
+
+// Name 模型名称
+func (m MakerTaker) Name() string { return "maker_taker" }
+
+// Commission 按成交是否提供流动性选择费率
+func (m MakerTaker) Commission(fill Fill) decimal.Decimal {
+	if fill.Maker {
+		return fill.notional().Mul(m.MakerRate)
+	}
+	return fill.notional().Mul(m.TakerRate)
+}
+
+// bounded 给模型加上单笔最低和最高佣金
+type bounded struct {
+	Model
+	minimum decimal.Decimal
+	maximum decimal.Decimal
+}
+
+// Commission 不低于最低佣金、不高于最高佣金；返佣不受最低佣金限制
+func (m bounded) Commission(fill Fill) decimal.Decimal {
+	fee := m.Model.Commission(fill)
+	if m.minimum.IsPositive() && !fee.IsNegative() && fee.LessThan(m.minimum) {
+		fee = m.minimum
+	}
+	if m.maximum.IsPositive() && fee.GreaterThan(m.maximum) {
+		fee = m.maximum
+	}
+	return fee
+}
+
+// New 按配置创建佣金模型，未配置模型时返回 nil
+func New(cfg config.CommissionConfig) (Model, error) {
+	var model Model
+	switch cfg.Model {
+	case "":
+		return nil, nil
+	case "percentage":
+		model = Percentage{Rate: money.FromFloat(cfg.Rate)}
+	case "per_share":
+		model = PerShare{PerShare: money.FromFloat(cfg.PerShare), MaxPercent: money.FromFloat(cfg.MaxPercent)}
+	case "tiered":
+		tiered := Tiered{}
+		for _, tier := range cfg.Tiers {
+			tiered.Tiers = append(tiered.Tiers, Tier{UpTo: money.FromFloat(tier.UpTo), Rate: money.FromFloat(tier.Rate)})
+		}
+		model = tiered
+	case "maker_taker":
+		model = MakerTaker{MakerRate: money.FromFloat(cfg.MakerRate), TakerRate: money.FromFloat(cfg.TakerRate)}
+	default:
+		return nil, fmt.Errorf("未知的佣金模型: %s", cfg.Model)
+	}
+	if cfg.Minimum > 0 || cfg.Maximum > 0 {
+		model = bounded{Model: model, minimum: money.FromFloat(cfg.Minimum), maximum: money.FromFloat(cfg.Maximum)}
+	}
+	return model, nil
+}
+
+// NewOrDefault 按配置创建佣金模型，未配置模型时按 defaultRate 比例收取
+func NewOrDefault(cfg config.CommissionConfig, defaultRate float64) (Model, error) {
+	model, err := New(cfg)
+	if err != nil || model != nil {
+		return model, err
+	}
+	return Percentage{Rate: money.FromFloat(defaultRate)}, nil
+}
@@ -3,58 +3,2185 @@ package config
 import (
 	"fmt"
 	"os"
+	"regexp"
+	"slices"
 	"strings"
+	"time"
+
+	"agent-quant-system/internal/money"
 
 	"github.com/spf13/viper"
 )
 
-// Config 系统配置结构体
-type Config struct {
-	AgentService AgentServiceConfig       `mapstructure:"agent_service"`
-	APIKeys      APIKeysConfig            `mapstructure:"api_keys"`
-	Accounts     map[string]AccountConfig `mapstructure:"accounts"`
-	Database     DatabaseConfig           `mapstructure:"database"`
-	Logging      LoggingConfig            `mapstructure:"logging"`
-	Backtest     BacktestConfig           `mapstructure:"backtest"`
+// Config 系统配置结构体
+type Config struct {
+	AgentService AgentServiceConfig       `mapstructure:"agent_service"`
+	APIKeys      APIKeysConfig            `mapstructure:"api_keys"`
+	Accounts     map[string]AccountConfig `mapstructure:"accounts"`
+	Database     DatabaseConfig           `mapstructure:"database"`
+	Logging      LoggingConfig            `mapstructure:"logging"`
+	Backtest     BacktestConfig           `mapstructure:"backtest"`
+	Data         DataConfig               `mapstructure:"data"`
+	Trading      TradingConfig            `mapstructure:"trading"`
+	Risk         RiskConfig               `mapstructure:"risk"`
+	Engine       EngineConfig             `mapstructure:"engine"`
+	Degradation  DegradationConfig        `mapstructure:"degradation"`
+	FX           FXConfig                 `mapstructure:"fx"`
+	News         NewsConfig               `mapstructure:"news"`
+	Strategy     StrategyConfig           `mapstructure:"strategy"`
+	Scanner      ScannerConfig            `mapstructure:"scanner"`
+	API          APIConfig                `mapstructure:"api"`
+
+	Notifications NotificationsConfig `mapstructure:"notifications"`
+	Portfolio     PortfolioConfig     `mapstructure:"portfolio"`
+	Secrets       SecretsConfig       `mapstructure:"secrets"`
+	Instruments   InstrumentsConfig   `mapstructure:"instruments"`
+	Symbols       SymbolsConfig       `mapstructure:"symbols"`
+}
+
+// AgentServiceConfig Agent服务配置
+type AgentServiceConfig struct {
+	Mode string     `mapstructure:"mode"` // service: 通过REST调用Python Agent服务; grpc: 通过gRPC调用Python Agent服务; llm: 直接调用大模型接口，无需Python服务
+	URL  string     `mapstructure:"url"`
+	GRPC GRPCConfig `mapstructure:"grpc"`
+	LLM  LLMConfig  `mapstructure:"llm"`
+
+	// 请求超时和重试：网络错误、超时和 5xx/429 响应按指数退避重试
+	Timeout         time.Duration `mapstructure:"timeout"`           // 单次请求超时
+	TotalTimeout    time.Duration `mapstructure:"total_timeout"`     // 一次分析（含重试）的总耗时上限，0表示不限制
+	MaxRetries      int           `mapstructure:"max_retries"`       // 最大重试次数
+	RetryBackoff    time.Duration `mapstructure:"retry_backoff"`     // 首次重试等待时间，之后每次翻倍
+	MaxRetryBackoff time.Duration `mapstructure:"max_retry_backoff"` // 重试等待时间上限
+
+	// 熔断：连续失败 breaker_threshold 次后不再请求，breaker_cooldown 后放行一次试探请求；
+	// 熔断期间按 degradation.agent 处理
+	BreakerThreshold int           `mapstructure:"breaker_threshold"` // 0表示不启用熔断
+	BreakerCooldown  time.Duration `mapstructure:"breaker_cooldown"`
+
+	// Async 异步分析：交易循环不再逐个标的等待Agent响应
+	Async AsyncAnalysisConfig `mapstructure:"async"`
+
+	// Auth 和 TLS 用于 service 和 grpc 模式，Python 服务暴露到本机以外时应启用
+	Auth AgentAuthConfig `mapstructure:"auth"`
+	TLS  AgentTLSConfig  `mapstructure:"tls"`
+}
+
+// AgentAuthConfig 调用Python Agent服务的认证，值可以写成密钥引用（见 secrets），需与服务端的环境变量一致
+type AgentAuthConfig struct {
+	APIKey      string `mapstructure:"api_key"`      // 以 X-API-Key 头发送
+	BearerToken string `mapstructure:"bearer_token"` // 以 Authorization: Bearer 头发送
+
+	// SigningSecret 非空时对每个请求做 HMAC-SHA256 签名（X-Agent-Timestamp / X-Agent-Nonce / X-Agent-Signature），
+	// 服务端据此拒绝篡改和重放的请求
+	SigningSecret string `mapstructure:"signing_secret"`
+}
+
+// AgentTLSConfig 调用Python Agent服务的TLS，配置 cert_file 和 key_file 时使用双向TLS
+type AgentTLSConfig struct {
+	Enabled            bool   `mapstructure:"enabled"`
+	CAFile             string `mapstructure:"ca_file"`              // 校验服务端证书的CA，为空时使用系统CA
+	CertFile           string `mapstructure:"cert_file"`            // 客户端证书
+	KeyFile            string `mapstructure:"key_file"`             // 客户端私钥
+	ServerName         string `mapstructure:"server_name"`          // 校验的服务端证书名称，为空时使用连接地址
+	InsecureSkipVerify bool   `mapstructure:"insecure_skip_verify"` // 不校验服务端证书，仅用于测试
+}
+
+// Validate 验证TLS配置
+func (t AgentTLSConfig) Validate() error {
+	if !t.Enabled {
+		return nil
+	}
+	if (t.CertFile == "") != (t.KeyFile == "") {
+		return fmt.Errorf("cert_file 和 key_file 需要同时配置")
+	}
+	return nil
+}
+
+// AsyncAnalysisConfig 异步Agent分析：分析请求提交到有界队列由 workers 个工作协程处理，每个标的最多一个排队中的请求；
+// 交易循环提交后最多等待 max_wait，之后使用该标的最近完成的分析，提交时间超过 max_age 的分析不再使用（按中性处理）
+type AsyncAnalysisConfig struct {
+	Enabled   bool          `mapstructure:"enabled"`
+	Workers   int           `mapstructure:"workers"`    // 工作协程数
+	QueueSize int           `mapstructure:"queue_size"` // 排队请求上限，队列已满时本轮不提交
+	MaxWait   time.Duration `mapstructure:"max_wait"`   // 提交后最多等待本次分析的时间，0 表示不等待
+	MaxAge    time.Duration `mapstructure:"max_age"`    // 可使用的分析距提交的最长时间
+}
+
+// Validate 验证异步分析配置
+func (a AsyncAnalysisConfig) Validate() error {
+	if !a.Enabled {
+		return nil
+	}
+	if a.Workers < 1 || a.QueueSize < 1 {
+		return fmt.Errorf("workers 和 queue_size 必须大于0")
+	}
+	if a.MaxWait < 0 {
+		return fmt.Errorf("max_wait 不能为负数")
+	}
+	if a.MaxAge <= a.MaxWait {
+		return fmt.Errorf("max_age 必须大于 max_wait")
+	}
+	return nil
+}
+
+// GRPCConfig 通过 gRPC 调用Python Agent服务的配置，协议定义见 proto/agent.proto
+type GRPCConfig struct {
+	Address string `mapstructure:"address"` // 服务地址，如 localhost:50051
+
+	// Stream 使用流式接口：服务端分析期间推送进度，timeout 为两个事件之间的最长间隔，
+	// 整体耗时由 total_timeout 限制，适合耗时较长的分析
+	Stream bool `mapstructure:"stream"`
+}
+
+// LLMConfig 直接调用大模型接口的配置，密钥使用 api_keys 中对应服务商的密钥
+type LLMConfig struct {
+	Provider    string  `mapstructure:"provider"`    // openai（含兼容接口）/ anthropic
+	BaseURL     string  `mapstructure:"base_url"`    // 为空时使用服务商的默认地址
+	Model       string  `mapstructure:"model"`       // 模型名称
+	MaxTokens   int     `mapstructure:"max_tokens"`  // 最大输出长度
+	Temperature float64 `mapstructure:"temperature"` // 采样温度
+	PromptFile  string  `mapstructure:"prompt_file"` // 提示词模板文件（text/template，可用 .Symbol 和 .News），为空时使用内置模板
+
+	// SetupPromptFile 交易方案提示词模板文件（可用 .Symbol、.Price、.Position、.Equity 和 .News），为空时使用内置模板
+	SetupPromptFile string `mapstructure:"setup_prompt_file"`
+}
+
+// Validate 验证Agent服务配置
+func (a AgentServiceConfig) Validate() error {
+	switch a.Mode {
+	case "service":
+		if a.URL == "" {
+			return fmt.Errorf("url 不能为空")
+		}
+	case "grpc":
+		if a.GRPC.Address == "" {
+			return fmt.Errorf("grpc.address 不能为空")
+		}
+	case "llm":
+		if a.LLM.Provider != "openai" && a.LLM.Provider != "anthropic" {
+			return fmt.Errorf("llm.provider 只能是 openai 或 anthropic")
+		}
+		if a.LLM.Model == "" {
+			return fmt.Errorf("llm.model 不能为空")
+		}
+		if a.LLM.MaxTokens < 0 || a.LLM.Temperature < 0 {
+			return fmt.Errorf("llm.max_tokens 和 llm.temperature 不能为负数")
+		}
+	default:
+		return fmt.Errorf("mode 只能是 service、grpc 或 llm")
+	}
+	if a.Timeout <= 0 {
+		return fmt.Errorf("timeout 必须大于0")
+	}
+	if a.MaxRetries < 0 || a.BreakerThreshold < 0 {
+		return fmt.Errorf("max_retries 和 breaker_threshold 不能为负数")
+	}
+	if a.BreakerThreshold > 0 && a.BreakerCooldown <= 0 {
+		return fmt.Errorf("启用熔断时 breaker_cooldown 必须大于0")
+	}
+	if err := a.Async.Validate(); err != nil {
+		return fmt.Errorf("async: %w", err)
+	}
+	if err := a.TLS.Validate(); err != nil {
+		return fmt.Errorf("tls: %w", err)
+	}
+	if a.TLS.Enabled && a.Mode == "service" && !strings.HasPrefix(a.URL, "https://") {
+		return fmt.Errorf("启用 tls 时 url 必须以 https:// 开头")
+	}
+	return nil
+}
+
+// APIKeysConfig API密钥配置
+type APIKeysConfig struct {
+	OpenAIKey    string `mapstructure:"openai_key"`
+	AnthropicKey string `mapstructure:"anthropic_key"`
+	NewsAPIKey   string `mapstructure:"newsapi_key"`
+	FinnhubKey   string `mapstructure:"finnhub_key"`
+}
+
+// SecretsConfig 密钥管理配置。账户的 api_key / api_secret 和 api_keys 中的密钥可以写成引用，运行时从密钥源解析：
+// "env:变量名"、"keyring:服务/用户"、"vault:路径#字段"（KV v2）或 "aws:密钥ID#字段"（Secrets Manager）；
+// 不带这些前缀的值按明文密钥处理
+type SecretsConfig struct {
+	CacheTTL time.Duration      `mapstructure:"cache_ttl"` // 解析结果的缓存时间，过期后重新获取，0 表示每次都获取
+	Vault    VaultSecretsConfig `mapstructure:"vault"`
+	AWS      AWSSecretsConfig   `mapstructure:"aws"`
+}
+
+// VaultSecretsConfig HashiCorp Vault 配置，令牌从 VAULT_TOKEN 环境变量读取
+type VaultSecretsConfig struct {
+	Address   string        `mapstructure:"address"`   // 为空时使用 VAULT_ADDR 环境变量
+	Mount     string        `mapstructure:"mount"`     // KV v2 引擎的挂载路径
+	Namespace string        `mapstructure:"namespace"` // Vault 企业版命名空间
+	Timeout   time.Duration `mapstructure:"timeout"`
+}
+
+// AWSSecretsConfig AWS Secrets Manager 配置，访问凭证从 AWS_ACCESS_KEY_ID / AWS_SECRET_ACCESS_KEY 环境变量读取
+type AWSSecretsConfig struct {
+	Region   string        `mapstructure:"region"`   // 为空时使用 AWS_REGION 环境变量
+	Endpoint string        `mapstructure:"endpoint"` // 自定义接口地址（如 VPC 终端节点），为空时使用区域默认地址
+	Timeout  time.Duration `mapstructure:"timeout"`
+}
+
+// Validate 验证密钥管理配置
+func (s SecretsConfig) Validate() error {
+	if s.CacheTTL < 0 {
+		return fmt.Errorf("cache_ttl 不能为负数")
+	}
+	if s.Vault.Timeout < 0 || s.AWS.Timeout < 0 {
+		return fmt.Errorf("timeout 不能为负数")
+	}
+	return nil
+}
+
+// AccountConfig 账户配置
+type AccountConfig struct {
+	APIKey     string `mapstructure:"api_key"`
+	APISecret  string `mapstructure:"api_secret"`
+	BrokerType string `mapstructure:"broker_type"`
+	Currency   string `mapstructure:"currency"` // 账户计价币种，默认 USD
+
+	// InitialBalance 模拟经纪商的初始资金，未配置时使用 DefaultInitialBalance
+	InitialBalance float64 `mapstructure:"initial_balance"`
+
+	// 价格/数量/金额精度，未设置的项使用经纪商类型的默认精度；可按标的覆盖
+	Precision       PrecisionConfig            `mapstructure:"precision"`
+	SymbolPrecision map[string]PrecisionConfig `mapstructure:"symbol_precision"`
+
+	// IBKR 盈透证券连接配置，仅 broker_type = "ibkr" 时使用
+	IBKR IBKRConfig `mapstructure:"ibkr"`
+
+	// Coinbase Coinbase Advanced Trade 连接配置，仅 broker_type = "coinbase" 时使用
+	Coinbase CoinbaseConfig `mapstructure:"coinbase"`
+
+	// Bybit Bybit 永续合约连接配置，仅 broker_type = "bybit" 时使用
+	Bybit BybitConfig `mapstructure:"bybit"`
+
+	// FIX 通过 FIX 4.4 会话连接机构经纪商的配置，仅 broker_type = "fix" 时使用
+	FIX FIXConfig `mapstructure:"fix"`
+
+	// CTP 通过 CTP 网关交易 A 股和国内期货的配置，仅 broker_type = "ctp" 时使用
+	CTP CTPConfig `mapstructure:"ctp"`
+
+	// 经纪商接口请求频率限制，未配置时不限制
+	RateLimit RateLimitConfig `mapstructure:"rate_limit"`
+
+	// 佣金模型，未配置时使用 trading.commissions 中该经纪商类型的模型
+	Commission CommissionConfig `mapstructure:"commission"`
+
+	// 保证金交易，未配置时不使用杠杆；纸面交易按该配置模拟融资、计息和强制平仓，风控按杠杆计算保证金使用率
+	Margin MarginConfig `mapstructure:"margin"`
+}
+
+// MarginConfig 保证金配置：可用购买力 = 权益 * leverage - 持仓市值，现金为负的部分为借入资金，
+// 按年化 interest_rate 逐日计息；权益低于持仓市值的 maintenance_margin 时触发追加保证金并强制平仓
+type MarginConfig struct {
+	Leverage          float64 `mapstructure:"leverage"`           // 最大杠杆倍数，0或1表示不使用杠杆
+	MaintenanceMargin float64 `mapstructure:"maintenance_margin"` // 维持保证金率，0表示不检查
+	InterestRate      float64 `mapstructure:"interest_rate"`      // 借入资金的年化利率
+}
+
+// Enabled 是否使用杠杆
+func (m MarginConfig) Enabled() bool {
+	return m.Leverage > 1
+}
+
+// EffectiveLeverage 生效的杠杆倍数，未使用杠杆时为1
+func (m MarginConfig) EffectiveLeverage() float64 {
+	if m.Enabled() {
+		return m.Leverage
+	}
+	return 1
+}
+
+// Validate 验证保证金配置
+func (m MarginConfig) Validate() error {
+	if m.Leverage < 0 || m.MaintenanceMargin < 0 || m.InterestRate < 0 {
+		return fmt.Errorf("leverage、maintenance_margin 和 interest_rate 不能为负数")
+	}
+	// 满仓时权益占持仓市值的比例为 1/leverage，维持保证金率不低于该比例时开仓即触发强制平仓
+	if m.MaintenanceMargin >= 1/m.EffectiveLeverage() {
+		return fmt.Errorf("maintenance_margin 必须小于 1/leverage (%.4f)", 1/m.EffectiveLeverage())
+	}
+	return nil
+}
+
+// CommissionConfig 佣金模型配置：percentage 按成交金额比例；per_share 按股数，可按成交金额比例封顶；
+// tiered 按成交金额分档累进；maker_taker 挂单与立即成交分别计费。minimum / maximum 为单笔最低和最高佣金
+type CommissionConfig struct {
+	Model      string           `mapstructure:"model"`       // percentage / per_share / tiered / maker_taker，为空表示未配置
+	Rate       float64          `mapstructure:"rate"`        // percentage: 费率
+	PerShare   float64          `mapstructure:"per_share"`   // per_share: 每股佣金
+	MaxPercent float64          `mapstructure:"max_percent"` // per_share: 不超过成交金额的比例，0表示不封顶
+	Tiers      []CommissionTier `mapstructure:"tiers"`       // tiered: 按 up_to 升序排列的分档
+	MakerRate  float64          `mapstructure:"maker_rate"`  // maker_taker: 挂单成交费率，负数表示返佣
+	TakerRate  float64          `mapstructure:"taker_rate"`  // maker_taker: 立即成交费率
+	Minimum    float64          `mapstructure:"minimum"`     // 单笔最低佣金，0表示不限制
+	Maximum    float64          `mapstructure:"maximum"`     // 单笔最高佣金，0表示不限制
+}
+
+// CommissionTier 佣金分档：成交金额中不超过 up_to 的部分按 rate 收取，最后一档 up_to 为0表示不设上限
+type CommissionTier struct {
+	UpTo float64 `mapstructure:"up_to"`
+	Rate float64 `mapstructure:"rate"`
+}
+
+// Validate 验证佣金模型配置
+func (c CommissionConfig) Validate() error {
+	switch c.Model {
+	case "", "percentage", "per_share", "maker_taker":
+	case "tiered":
+		if len(c.Tiers) == 0 {
+			return fmt.Errorf("tiered 模型至少需要一个分档")
+		}
+		for i, tier := range c.Tiers {
+			if tier.Rate < 0 || tier.UpTo < 0 {
+				return fmt.Errorf("第 %d 档的 up_to 和 rate 不能为负数", i+1)
+			}
+			if tier.UpTo == 0 && i != len(c.Tiers)-1 {
+				return fmt.Errorf("只有最后一档可以不设 up_to")
+			}
+			if i > 0 && tier.UpTo != 0 && tier.UpTo <= c.Tiers[i-1].UpTo {
+				return fmt.Errorf("分档的 up_to 必须递增")
+			}
+		}
+	default:
+		return fmt.Errorf("未知的佣金模型: %s（可选 percentage、per_share、tiered、maker_taker）", c.Model)
+	}
+	if c.Rate < 0 || c.PerShare < 0 || c.MaxPercent < 0 || c.TakerRate < 0 || c.Minimum < 0 || c.Maximum < 0 {
+		return fmt.Errorf("rate、per_share、max_percent、taker_rate、minimum 和 maximum 不能为负数")
+	}
+	if c.Maximum > 0 && c.Minimum > c.Maximum {
+		return fmt.Errorf("minimum 不能大于 maximum")
+	}
+	return nil
+}
+
+// RateLimitConfig 经纪商接口频率限制（令牌桶）：下单和撤单按每秒次数限制，查询按每分钟次数限制；
+// 超出频率的请求排队等待，预计等待超过 max_wait 时直接拒绝。为0的限制不启用
+type RateLimitConfig struct {
+	OrdersPerSecond  float64       `mapstructure:"orders_per_second"`
+	OrderBurst       int           `mapstructure:"order_burst"` // 允许连续突发的下单次数，默认 1
+	QueriesPerMinute float64       `mapstructure:"queries_per_minute"`
+	QueryBurst       int           `mapstructure:"query_burst"` // 允许连续突发的查询次数，默认 1
+	MaxWait          time.Duration `mapstructure:"max_wait"`    // 单个请求最长等待时间，默认 10s
+}
+
+// Enabled 是否配置了任一频率限制
+func (r RateLimitConfig) Enabled() bool {
+	return r.OrdersPerSecond > 0 || r.QueriesPerMinute > 0
+}
+
+// Validate 验证频率限制配置
+func (r RateLimitConfig) Validate() error {
+	if r.OrdersPerSecond < 0 || r.QueriesPerMinute < 0 {
+		return fmt.Errorf("orders_per_second 和 queries_per_minute 不能为负数")
+	}
+	if r.OrderBurst < 0 || r.QueryBurst < 0 {
+		return fmt.Errorf("order_burst 和 query_burst 不能为负数")
+	}
+	if r.MaxWait < 0 {
+		return fmt.Errorf("max_wait 不能为负数")
+	}
+	return nil
+}
+
+// IBKRConfig 通过 Client Portal Gateway 连接盈透证券的配置
+type IBKRConfig struct {
+	GatewayURL         string        `mapstructure:"gateway_url"`          // 网关地址，默认 https://localhost:5000/v1/api
+	AccountID          string        `mapstructure:"account_id"`           // 交易账户号，如 U1234567
+	InsecureSkipVerify bool          `mapstructure:"insecure_skip_verify"` // 网关默认使用自签名证书
+	PollInterval       time.Duration `mapstructure:"poll_interval"`        // 订单状态轮询间隔，默认 2s
+}
+
+// CoinbaseConfig 通过 Advanced Trade REST API 连接 Coinbase 的配置，API 密钥使用账户的 api_key / api_secret：
+// api_secret 为 EC 私钥（PEM）时按 CDP 密钥签发 JWT，否则按旧版密钥做 HMAC 签名
+type CoinbaseConfig struct {
+	BaseURL       string        `mapstructure:"base_url"`       // 接口地址，默认 https://api.coinbase.com/api/v3/brokerage
+	PortfolioID   string        `mapstructure:"portfolio_id"`   // 下单和查询使用的投资组合，默认为密钥所属的组合
+	QuoteCurrency string        `mapstructure:"quote_currency"` // 不带分隔符的标的（如 BTC）使用的计价币种，默认为账户币种
+	PollInterval  time.Duration `mapstructure:"poll_interval"`  // 订单状态轮询间隔，默认 2s
+	Timeout       time.Duration `mapstructure:"timeout"`        // 请求超时，默认 15s
+}
+
+// Validate 验证 Coinbase 连接配置
+func (c CoinbaseConfig) Validate() error {
+	if c.PollInterval < 0 || c.Timeout < 0 {
+		return fmt.Errorf("poll_interval 和 timeout 不能为负数")
+	}
+	return nil
+}
+
+// BybitConfig 通过 V5 API 交易 Bybit 永续合约的配置，使用单向持仓模式，API 密钥使用账户的 api_key / api_secret
+type BybitConfig struct {
+	BaseURL        string             `mapstructure:"base_url"`        // 接口地址，默认 https://api.bybit.com，测试网为 https://api-testnet.bybit.com
+	Category       string             `mapstructure:"category"`        // 合约类型：linear（U本位，默认）或 inverse（币本位）
+	SettleCoin     string             `mapstructure:"settle_coin"`     // 结算币种，默认 USDT；余额为该币种的钱包余额
+	AccountType    string             `mapstructure:"account_type"`    // 钱包类型，默认 UNIFIED（统一交易账户）
+	Leverage       float64            `mapstructure:"leverage"`        // 首次交易标的时设置的杠杆倍数，0 表示沿用交易所的设置
+	SymbolLeverage map[string]float64 `mapstructure:"symbol_leverage"` // 按标的覆盖杠杆倍数
+	RecvWindow     time.Duration      `mapstructure:"recv_window"`     // 请求签名的有效时间，默认 5s
+	PollInterval   time.Duration      `mapstructure:"poll_interval"`   // 订单状态和资金费轮询间隔，默认 2s
+	Timeout        time.Duration      `mapstructure:"timeout"`         // 请求超时，默认 15s
+}
+
+// LeverageFor 标的的杠杆倍数，未配置时为0
+func (b BybitConfig) LeverageFor(symbol string) float64 {
+	if leverage, ok := b.SymbolLeverage[symbol]; ok {
+		return leverage
+	}
+	return b.Leverage
+}
+
+// Validate 验证 Bybit 连接配置
+func (b BybitConfig) Validate() error {
+	switch b.Category {
+	case "", "linear", "inverse":
+	default:
+		return fmt.Errorf("category 只能是 linear 或 inverse: %s", b.Category)
+	}
+	if b.Leverage < 0 {
+		return fmt.Errorf("leverage 不能为负数")
+	}
+	for symbol, leverage := range b.SymbolLeverage {
+		if leverage <= 0 {
+			return fmt.Errorf("symbol_leverage.%s 必须大于0", symbol)
+		}
+	}
+	if b.RecvWindow < 0 || b.PollInterval < 0 || b.Timeout < 0 {
+		return fmt.Errorf("recv_window、poll_interval 和 timeout 不能为负数")
+	}
+	return nil
+}
+
+// FIXConfig FIX 4.4 会话配置；登录的 Username / Password 使用账户的 api_key / api_secret，均为空时不发送。
+// 经纪商不回报持仓和资金时，余额和持仓按本会话收到的成交回报从 initial_balance 起推算
+type FIXConfig struct {
+	Address            string        `mapstructure:"address"`              // 会话地址 host:port
+	SenderCompID       string        `mapstructure:"sender_comp_id"`       // 本方标识（49）
+	TargetCompID       string        `mapstructure:"target_comp_id"`       // 经纪商标识（56）
+	Account            string        `mapstructure:"account"`              // 订单上的账户（1），为空时不发送
+	HeartbeatInterval  time.Duration `mapstructure:"heartbeat_interval"`   // 心跳间隔（108），默认 30s
+	KeepSequence       bool          `mapstructure:"keep_sequence"`        // 重连时延续消息序号，默认每次登录重置（141=Y）
+	TLS                bool          `mapstructure:"tls"`                  // 使用 TLS 连接
+	InsecureSkipVerify bool          `mapstructure:"insecure_skip_verify"` // 不校验服务器证书
+	LogonTimeout       time.Duration `mapstructure:"logon_timeout"`        // 等待登录应答的时间，默认 10s
+	AckTimeout         time.Duration `mapstructure:"ack_timeout"`          // 等待订单首个执行报告的时间，默认 5s
+}
+
+// Validate 验证 FIX 会话配置
+func (f FIXConfig) Validate() error {
+	if f.Address == "" {
+		return fmt.Errorf("address 不能为空")
+	}
+	if f.SenderCompID == "" || f.TargetCompID == "" {
+		return fmt.Errorf("sender_comp_id 和 target_comp_id 不能为空")
+	}
+	if f.HeartbeatInterval < 0 || f.LogonTimeout < 0 || f.AckTimeout < 0 {
+		return fmt.Errorf("heartbeat_interval、logon_timeout 和 ack_timeout 不能为负数")
+	}
+	return nil
+}
+
+// CTPConfig 通过 CTP 网关（把 CTP 交易接口封装为 HTTP 接口的本地进程）交易 A 股和国内期货的配置；
+// 登录密码使用账户的 api_secret，穿透式认证的授权码使用 api_key
+type CTPConfig struct {
+	GatewayURL   string        `mapstructure:"gateway_url"`   // 网关地址，默认 http://localhost:7001
+	BrokerID     string        `mapstructure:"broker_id"`     // 期货公司或券商代码
+	UserID       string        `mapstructure:"user_id"`       // 投资者账号
+	AppID        string        `mapstructure:"app_id"`        // 穿透式认证的 AppID
+	STSymbols    []string      `mapstructure:"st_symbols"`    // 风险警示（ST）股票，网关未提供涨跌停价时按 5% 计算
+	PollInterval time.Duration `mapstructure:"poll_interval"` // 订单状态轮询间隔，默认 2s
+	Timeout      time.Duration `mapstructure:"timeout"`       // 请求超时，默认 15s
+}
+
+// Validate 验证 CTP 连接配置
+func (c CTPConfig) Validate() error {
+	if c.BrokerID == "" || c.UserID == "" {
+		return fmt.Errorf("broker_id 和 user_id 不能为空")
+	}
+	if c.PollInterval < 0 || c.Timeout < 0 {
+		return fmt.Errorf("poll_interval 和 timeout 不能为负数")
+	}
+	return nil
+}
+
+// PrecisionConfig 精度配置（小数位数），为空表示使用默认值
+type PrecisionConfig struct {
+	Price    *int32 `mapstructure:"price"`
+	Quantity *int32 `mapstructure:"quantity"`
+	Amount   *int32 `mapstructure:"amount"`
+}
+
+// Apply 在默认精度上应用已配置的项
+func (p PrecisionConfig) Apply(base money.Precision) money.Precision {
+	if p.Price != nil {
+		base.Price = *p.Price
+	}
+	if p.Quantity != nil {
+		base.Quantity = *p.Quantity
+	}
+	if p.Amount != nil {
+		base.Amount = *p.Amount
+	}
+	return base
+}
+
+// PrecisionTable 构建账户的精度表
+func (a AccountConfig) PrecisionTable() *money.PrecisionTable {
+	return buildPrecisionTable(money.DefaultPrecisionFor(a.BrokerType), a.Precision, a.SymbolPrecision)
+}
+
+// buildPrecisionTable 在基础精度上应用默认配置和按标的覆盖
+func buildPrecisionTable(base money.Precision, precision PrecisionConfig, symbolPrecision map[string]PrecisionConfig) *money.PrecisionTable {
+	defaults := precision.Apply(base)
+
+	symbols := make(map[string]money.Precision, len(symbolPrecision))
+	for symbol, override := range symbolPrecision {
+		symbols[symbol] = override.Apply(defaults)
+	}
+
+	return money.NewPrecisionTable(defaults, symbols)
+}
+
+// DefaultInitialBalance 模拟账户默认初始资金
+const DefaultInitialBalance = 100000.0
+
+// AssetClass 账户交易的资产类别：crypto 交易所、Coinbase 和 Bybit 为 crypto，其余为 stock
+func (a AccountConfig) AssetClass() string {
+	if a.BrokerType == "crypto" || a.BrokerType == "coinbase" || a.BrokerType == "bybit" {
+		return "crypto"
+	}
+	return "stock"
+}
+
+// StartingBalance 获取账户初始资金
+func (a AccountConfig) StartingBalance() float64 {
+	if a.InitialBalance > 0 {
+		return a.InitialBalance
+	}
+	return DefaultInitialBalance
+}
+
+// DatabaseConfig 数据库配置
+type DatabaseConfig struct {
+	Host         string `mapstructure:"host"`
+	Port         int    `mapstructure:"port"`
+	Username     string `mapstructure:"username"`
+	Password     string `mapstructure:"password"`
+	DatabaseName string `mapstructure:"database_name"`
+}
+
+// LoggingConfig 日志配置
+type LoggingConfig struct {
+	Level string `mapstructure:"level"`
+	File  string `mapstructure:"file"`
+
+	// Signals 交易信号和Agent响应的结构化日志
+	Signals SignalLogConfig `mapstructure:"signals"`
+}
+
+// SignalLogConfig 交易信号和Agent响应的结构化日志（JSON Lines），按标的和循环抽样并脱敏
+type SignalLogConfig struct {
+	Enabled bool   `mapstructure:"enabled"`
+	File    string `mapstructure:"file"` // 为空时写入标准日志
+
+	// 抽样：每个循环中每个标的按 sample_rate 的概率记录（同一标的的Agent响应和信号一起保留或丢弃），
+	// 被丢弃的条数在循环结束时汇总记录一条
+	SampleRate     float64 `mapstructure:"sample_rate"`     // 0~1，1 表示全部记录
+	KeepActionable bool    `mapstructure:"keep_actionable"` // 买入、卖出信号不参与抽样，始终记录
+
+	// 脱敏：redact_fields 中的字段整体替换，redact_patterns 匹配到的内容在所有文本字段中替换
+	RedactFields   []string `mapstructure:"redact_fields"`
+	RedactPatterns []string `mapstructure:"redact_patterns"`
+	MaxFieldLength int      `mapstructure:"max_field_length"` // 文本字段的最大长度，超出部分截断，0 表示不截断
+}
+
+// Validate 验证信号日志配置
+func (s SignalLogConfig) Validate() error {
+	if s.SampleRate < 0 || s.SampleRate > 1 {
+		return fmt.Errorf("sample_rate 必须在 0 到 1 之间")
+	}
+	if s.MaxFieldLength < 0 {
+		return fmt.Errorf("max_field_length 不能为负数")
+	}
+	for _, pattern := range s.RedactPatterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("redact_patterns 中的正则表达式无效 %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// DataConfig 行情数据源配置
+type DataConfig struct {
+	// 资产类别（stock / crypto）使用的数据源，未配置的类别使用模拟数据源；运行时可通过控制API切换
+	Providers map[string]string `mapstructure:"providers"`
+	// 按标的指定资产类别，未指定时按交易对后缀（USDT、-USD 等）识别加密货币，其余视为股票
+	SymbolClasses map[string]string `mapstructure:"symbol_classes"`
+	DrainTimeout  time.Duration     `mapstructure:"drain_timeout"` // 切换数据源时等待进行中请求完成的最长时间
+
+	// 按资产类别（stock / crypto）配置交易所的时区和交易时段，用于按交易所日期解析日期区间、对齐K线；
+	// symbol_sessions 按标的覆盖，如港股、A股标的
+	Sessions       map[string]SessionConfig `mapstructure:"sessions"`
+	SymbolSessions map[string]SessionConfig `mapstructure:"symbol_sessions"`
+
+	// K线本地缓存
+	Cache DataCacheConfig `mapstructure:"cache"`
+	// 用户导入的OHLCV文件
+	Import DataImportConfig `mapstructure:"import"`
+	// 逐笔成交和订单簿快照的本地存储
+	Ticks DataTicksConfig `mapstructure:"ticks"`
+	// 行情数据质量检查
+	Quality DataQualityConfig `mapstructure:"quality"`
+}
+
+// DataQualityConfig 行情数据质量检查：检测缺口、重复或乱序的时间、零或负价格、异常跳变和过期数据，
+// 按 policy 拒绝、记录或插值修复有问题的数据
+type DataQualityConfig struct {
+	Policy         string        `mapstructure:"policy"`          // fail（拒绝）/ warn（只记录）/ repair（插值修复）/ off（不检查）
+	GapFactor      float64       `mapstructure:"gap_factor"`      // 相邻K线间隔超过原始周期的该倍数视为缺口，0 表示不检查
+	SpikeThreshold float64       `mapstructure:"spike_threshold"` // 收盘价相对前后两根K线同向偏离超过该比例视为异常跳变，0 表示不检查
+	StaleAfter     time.Duration `mapstructure:"stale_after"`     // 最后一根K线早于请求的结束时间超过该时长视为过期，0 表示不检查
+}
+
+// Validate 验证数据质量检查配置
+func (c DataQualityConfig) Validate() error {
+	switch c.Policy {
+	case "fail", "warn", "repair", "off":
+	default:
+		return fmt.Errorf("policy 无效: '%s'，可选: fail, warn, repair, off", c.Policy)
+	}
+	if c.GapFactor < 0 || (c.GapFactor > 0 && c.GapFactor <= 1) {
+		return fmt.Errorf("gap_factor 必须大于1，0 表示不检查")
+	}
+	if c.SpikeThreshold < 0 {
+		return fmt.Errorf("spike_threshold 不能为负数")
+	}
+	if c.StaleAfter < 0 {
+		return fmt.Errorf("stale_after 不能为负数")
+	}
+	return nil
+}
+
+// SessionConfig 交易所的时区和常规交易时段
+type SessionConfig struct {
+	Timezone     string `mapstructure:"timezone"`      // 交易所时区，如 America/New_York，为空时为UTC
+	Open         string `mapstructure:"open"`          // 开盘时间 HH:MM（交易所时区），与 close 都为空时表示全天交易
+	Close        string `mapstructure:"close"`         // 收盘时间 HH:MM
+	Weekends     bool   `mapstructure:"weekends"`      // 周六周日是否交易
+	RegularHours bool   `mapstructure:"regular_hours"` // 只保留常规交易时段内的K线，默认保留全部（含盘前盘后）
+}
+
+// Validate 验证交易所时段配置
+func (c SessionConfig) Validate() error {
+	if _, err := time.LoadLocation(c.Timezone); err != nil {
+		return fmt.Errorf("无效的时区 %s: %w", c.Timezone, err)
+	}
+	if (c.Open == "") != (c.Close == "") {
+		return fmt.Errorf("open 和 close 需要同时配置，都为空表示全天交易")
+	}
+	if c.Open == "" {
+		return nil
+	}
+	open, err := time.Parse("15:04", c.Open)
+	if err != nil {
+		return fmt.Errorf("open 格式无效，应为 HH:MM: %w", err)
+	}
+	close, err := time.Parse("15:04", c.Close)
+	if err != nil {
+		return fmt.Errorf("close 格式无效，应为 HH:MM: %w", err)
+	}
+	if !close.After(open) {
+		return fmt.Errorf("close 必须晚于 open")
+	}
+	return nil
+}
+
+// DataTicksConfig 逐笔数据存储：按数据源、标的和日期保存逐笔成交和订单簿快照，
+// 已存储的日期不再向数据源获取；导入的逐笔数据也保存在该目录，通过 imported 数据源读取
+type DataTicksConfig struct {
+	Dir string `mapstructure:"dir"` // 为空时不存储，每次向数据源获取
+}
+
+// DataImportConfig 导入OHLCV文件的配置：导入的K线保存在 dir 中，通过名为 imported 的数据源读取
+type DataImportConfig struct {
+	Dir        string        `mapstructure:"dir"`
+	Timezone   string        `mapstructure:"timezone"`    // 文件中不带时区的时间所属时区，导入后统一保存为UTC
+	TimeFormat string        `mapstructure:"time_format"` // Go 时间格式，为空时自动识别常见格式和 Unix 秒/毫秒
+	Delimiter  string        `mapstructure:"delimiter"`
+	Columns    ImportColumns `mapstructure:"columns"`
+}
+
+// ImportColumns 导入文件的列名映射，列名不区分大小写
+type ImportColumns struct {
+	Timestamp string `mapstructure:"timestamp"` // 时间或日期列
+	Time      string `mapstructure:"time"`      // 可选，日期和时间分列时的时间列
+	Open      string `mapstructure:"open"`
+	High      string `mapstructure:"high"`
+	Low       string `mapstructure:"low"`
+	Close     string `mapstructure:"close"`
+	Volume    string `mapstructure:"volume"` // 可选
+	Symbol    string `mapstructure:"symbol"` // 可选，一个文件包含多个标的时的标的列
+}
+
+// Validate 验证导入配置
+func (c DataImportConfig) Validate() error {
+	if c.Dir == "" {
+		return fmt.Errorf("dir 不能为空")
+	}
+	if _, err := time.LoadLocation(c.Timezone); err != nil {
+		return fmt.Errorf("无效的时区 %s: %w", c.Timezone, err)
+	}
+	if len([]rune(c.Delimiter)) > 1 {
+		return fmt.Errorf("delimiter 只能是单个字符")
+	}
+	columns := c.Columns
+	for name, column := range map[string]string{"timestamp": columns.Timestamp, "open": columns.Open, "high": columns.High, "low": columns.Low, "close": columns.Close} {
+		if column == "" {
+			return fmt.Errorf("columns.%s 不能为空", name)
+		}
+	}
+	return nil
+}
+
+// DataCacheConfig K线本地缓存配置：按日期区间请求的行情（回测、交易循环、扫描）先查本地缓存，只向数据源获取缺失的区间
+type DataCacheConfig struct {
+	Enabled       bool          `mapstructure:"enabled"`
+	Backend       string        `mapstructure:"backend"`        // 存储后端，目前支持 sqlite（<dir>/ohlcv.db，只改写重新获取的区间）
+	Dir           string        `mapstructure:"dir"`            // 缓存目录
+	RefreshRecent time.Duration `mapstructure:"refresh_recent"` // 最近这段时间内的K线可能尚未走完，每次请求时重新获取
+}
+
+// Validate 验证K线缓存配置
+func (c DataCacheConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.Backend == "file" {
+		return fmt.Errorf("file 缓存后端已移除，请改为 sqlite（缓存会重新获取）")
+	}
+	if c.Backend != "sqlite" {
+		return fmt.Errorf("不支持的缓存后端: %s，可选: sqlite", c.Backend)
+	}
+	if c.Dir == "" {
+		return fmt.Errorf("dir 不能为空")
+	}
+	if c.RefreshRecent < 0 {
+		return fmt.Errorf("refresh_recent 不能为负数")
+	}
+	return nil
+}
+
+// Validate 验证数据源配置，数据源名称在创建数据管理器时检查
+func (d DataConfig) Validate() error {
+	classes := map[string]bool{"stock": true, "crypto": true}
+	for class := range d.Providers {
+		if !classes[class] {
+			return fmt.Errorf("providers 中的资产类别 '%s' 无效，可选: stock, crypto", class)
+		}
+	}
+	for symbol, class := range d.SymbolClasses {
+		if !classes[class] {
+			return fmt.Errorf("symbol_classes 中标的 '%s' 的资产类别 '%s' 无效，可选: stock, crypto", symbol, class)
+		}
+	}
+	if d.DrainTimeout <= 0 {
+		return fmt.Errorf("drain_timeout 必须大于0")
+	}
+	for class, session := range d.Sessions {
+		if !classes[class] {
+			return fmt.Errorf("sessions 中的资产类别 '%s' 无效，可选: stock, crypto", class)
+		}
+		if err := session.Validate(); err != nil {
+			return fmt.Errorf("sessions.%s: %w", class, err)
+		}
+	}
+	for symbol, session := range d.SymbolSessions {
+		if err := session.Validate(); err != nil {
+			return fmt.Errorf("symbol_sessions.%s: %w", symbol, err)
+		}
+	}
+	if err := d.Cache.Validate(); err != nil {
+		return fmt.Errorf("cache: %w", err)
+	}
+	if err := d.Import.Validate(); err != nil {
+		return fmt.Errorf("import: %w", err)
+	}
+	if err := d.Quality.Validate(); err != nil {
+		return fmt.Errorf("quality: %w", err)
+	}
+	return nil
+}
+
+// BacktestConfig 回测配置
+type BacktestConfig struct {
+	Strategy       string  `mapstructure:"strategy"` // 回测的策略名称
+	InitialCapital float64 `mapstructure:"initial_capital"`
+	CommissionRate float64 `mapstructure:"commission_rate"`
+	SlippageRate   float64 `mapstructure:"slippage_rate"`
+	Currency       string  `mapstructure:"currency"`    // 回测资金计价币种，默认与报告币种相同
+	MaxEntries     int     `mapstructure:"max_entries"` // 每个标的最多同时持有的开仓笔数，大于1时允许加仓，默认1
+
+	// 由 calibrate-slippage 从成交流水拟合的滑点模型文件，文件存在时替代固定滑点率
+	SlippageModelFile string `mapstructure:"slippage_model_file"`
+
+	// 单次回测的资源预算，超出时中止并返回已处理部分的结果，0表示不限制
+	MaxDuration time.Duration `mapstructure:"max_duration"`  // 最长运行时间，包含加载数据的时间
+	MaxMemoryMB int           `mapstructure:"max_memory_mb"` // 进程堆内存上限（MB），检查整个进程而不是单次回测
+	MaxBars     int           `mapstructure:"max_bars"`      // 最多处理的K线数
+
+	// 回测成交的价格/数量/金额精度，默认使用股票精度（整股）；加密货币等可拆分的标的需按标的覆盖数量精度
+	Precision       PrecisionConfig            `mapstructure:"precision"`
+	SymbolPrecision map[string]PrecisionConfig `mapstructure:"symbol_precision"`
+
+	// 长时间回测的检查点，中断后可用 backtest --resume 从最后保存的K线继续
+	Checkpoint BacktestCheckpointConfig `mapstructure:"checkpoint"`
+
+	// 回测的佣金模型，未配置时按 commission_rate 比例收取
+	Commission CommissionConfig `mapstructure:"commission"`
+
+	// 按订单估算滑点的模型，回测和纸面交易的每笔成交都按该模型计算滑点；为空时回测使用 slippage_rate
+	Slippage SlippageConfig `mapstructure:"slippage"`
+
+	// 回测的保证金交易，未配置杠杆时资金不足即无法买入
+	Margin MarginConfig `mapstructure:"margin"`
+
+	// 逐笔回测：用 data.ticks 存储的逐笔成交和订单簿快照撮合订单，适合对成交敏感的剥头皮、做市策略
+	Ticks BacktestTicksConfig `mapstructure:"ticks"`
+
+	// 多标的回测（backtest --symbols）的并发数和合并报告目录，报告目录为空时不写文件
+	Workers   int    `mapstructure:"workers"`
+	ReportDir string `mapstructure:"report_dir"`
+}
+
+// SlippageConfig 滑点模型配置，滑点以基点（万分之一）表示：
+// fixed 固定滑点；spread 按买卖价差的一半，K线高低价已知时按K线振幅估算价差；
+// volume_impact 按成交量参与率的冲击成本 = base_bps + impact_coefficient * 参与率^impact_exponent * 10000
+type SlippageConfig struct {
+	Model             string  `mapstructure:"model"`              // fixed / spread / volume_impact，为空表示不使用
+	FixedBPS          float64 `mapstructure:"fixed_bps"`          // fixed 模型的滑点
+	SpreadBPS         float64 `mapstructure:"spread_bps"`         // spread 模型在K线振幅未知时使用的买卖价差
+	RangeFraction     float64 `mapstructure:"range_fraction"`     // spread 模型按K线振幅估算价差的比例
+	BaseBPS           float64 `mapstructure:"base_bps"`           // volume_impact 模型的基础滑点（半个价差）
+	ImpactCoefficient float64 `mapstructure:"impact_coefficient"` // volume_impact 模型的冲击系数，平方根冲击下约为标的的日波动率
+	ImpactExponent    float64 `mapstructure:"impact_exponent"`    // volume_impact 模型的参与率指数，0.5 为平方根冲击
+	MaxBPS            float64 `mapstructure:"max_bps"`            // 单笔滑点上限，0表示不限制
+}
+
+// Validate 验证滑点模型配置
+func (s SlippageConfig) Validate() error {
+	switch s.Model {
+	case "", "fixed", "spread", "volume_impact":
+	default:
+		return fmt.Errorf("未知的滑点模型: %s（可选 fixed、spread、volume_impact）", s.Model)
+	}
+	for name, value := range map[string]float64{
+		"fixed_bps": s.FixedBPS, "spread_bps": s.SpreadBPS, "range_fraction": s.RangeFraction, "base_bps": s.BaseBPS,
+		"impact_coefficient": s.ImpactCoefficient, "impact_exponent": s.ImpactExponent, "max_bps": s.MaxBPS,
+	} {
+		if value < 0 {
+			return fmt.Errorf("%s 不能为负数", name)
+		}
+	}
+	if s.Model == "volume_impact" && s.ImpactExponent == 0 {
+		return fmt.Errorf("volume_impact 模型的 impact_exponent 必须大于0")
+	}
+	return nil
+}
+
+// BacktestCheckpointConfig 回测检查点配置
+type BacktestCheckpointConfig struct {
+	Dir      string `mapstructure:"dir"`      // 检查点目录，为空时不保存检查点
+	Interval int    `mapstructure:"interval"` // 每处理多少根K线保存一次，0表示只在超出资源预算中止时保存
+}
+
+// BacktestTicksConfig 逐笔回测配置
+type BacktestTicksConfig struct {
+	Enabled     bool          `mapstructure:"enabled"`
+	BarInterval time.Duration `mapstructure:"bar_interval"` // 逐笔成交聚合为策略K线的周期
+	Latency     time.Duration `mapstructure:"latency"`      // 订单从发出到进入撮合的延迟
+}
+
+// Validate 验证逐笔回测配置
+func (t BacktestTicksConfig) Validate() error {
+	if t.Enabled && t.BarInterval <= 0 {
+		return fmt.Errorf("bar_interval 必须大于0")
+	}
+	if t.Latency < 0 {
+		return fmt.Errorf("latency 不能为负数")
+	}
+	return nil
+}
+
+// Validate 验证回测配置
+func (b BacktestConfig) Validate() error {
+	if b.MaxDuration < 0 || b.MaxMemoryMB < 0 || b.MaxBars < 0 {
+		return fmt.Errorf("max_duration、max_memory_mb 和 max_bars 不能为负数")
+	}
+	if b.Checkpoint.Interval < 0 {
+		return fmt.Errorf("checkpoint.interval 不能为负数")
+	}
+	if b.Workers < 1 {
+		return fmt.Errorf("workers 必须大于0")
+	}
+	if b.Strategy == "" {
+		return fmt.Errorf("strategy 不能为空")
+	}
+	if err := b.Slippage.Validate(); err != nil {
+		return fmt.Errorf("slippage: %w", err)
+	}
+	if err := b.Commission.Validate(); err != nil {
+		return fmt.Errorf("commission: %w", err)
+	}
+	if err := b.Margin.Validate(); err != nil {
+		return fmt.Errorf("margin: %w", err)
+	}
+	if err := b.Ticks.Validate(); err != nil {
+		return fmt.Errorf("ticks: %w", err)
+	}
+	return nil
+}
+
+// PrecisionTable 构建回测的精度表
+func (b BacktestConfig) PrecisionTable() *money.PrecisionTable {
+	return buildPrecisionTable(money.StockPrecision, b.Precision, b.SymbolPrecision)
+}
+
+// TradingConfig 交易执行配置
+type TradingConfig struct {
+	OrderConcurrency int `mapstructure:"order_concurrency"` // 每个经纪商的最大并发下单数
+	OrderQueueSize   int `mapstructure:"order_queue_size"`  // 每个标的的订单队列长度
+
+	// 按经纪商类型（stock / crypto / ibkr / coinbase / bybit / fix）的佣金模型，账户可用 accounts.<name>.commission 覆盖；
+	// 模拟和纸面交易按模型计算成交佣金，真实经纪商未回报佣金时按模型估算后记入成交流水和盈亏
+	Commissions map[string]CommissionConfig `mapstructure:"commissions"`
+
+	MonitorInterval     time.Duration `mapstructure:"monitor_interval"`      // 持仓监控检查间隔
+	TrailingStopPercent float64       `mapstructure:"trailing_stop_percent"` // 默认跟踪止损回撤比例，0表示不启用
+
+	JournalFile string `mapstructure:"journal_file"` // 成交流水文件（JSON Lines），为空时不记录
+
+	// 税务批次：按成交流水逐笔建立批次，平仓时按方法选择批次，生成已实现损益报表
+	TaxLots TaxLotConfig `mapstructure:"tax_lots"`
+
+	// 审计日志（JSON Lines，只追加并以哈希串成链）：信号、风控决定、下单、经纪商响应、成交和撤单，为空时不记录
+	AuditFile string `mapstructure:"audit_file"`
+
+	// 纸面交易模式：行情和Agent分析照常，所有账户的订单改由内部纸面经纪商按实时报价撮合
+	Paper bool `mapstructure:"paper"`
+
+	// 演练模式：行情、Agent、策略和风控照常运行，通过全部检查的订单只写入日志和审计日志，不发送到经纪商，也不撤销经纪商中的订单
+	DryRun bool `mapstructure:"dry_run"`
+
+	// DAY 订单在收盘时自动撤销
+	MarketClose    string `mapstructure:"market_close"`    // 收盘时间 HH:MM，默认 16:00
+	MarketTimezone string `mapstructure:"market_timezone"` // 收盘时间所在时区，默认 America/New_York
+
+	// 信号执行方式（全局默认），可按策略覆盖
+	Execution         ExecutionConfig            `mapstructure:"execution"`
+	StrategyExecution map[string]ExecutionConfig `mapstructure:"strategy_execution"`
+
+	// 大额订单人工确认
+	Approval ApprovalConfig `mapstructure:"approval"`
+
+	// 下单频率限制
+	Throttle ThrottleConfig `mapstructure:"throttle"`
+
+	// 纸面交易到实盘的晋级
+	Promotion PromotionConfig `mapstructure:"promotion"`
+
+	// 本地账户状态与经纪商对账
+	Reconciliation ReconciliationConfig `mapstructure:"reconciliation"`
+
+	// 定期导出持仓和余额快照
+	Snapshot SnapshotConfig `mapstructure:"snapshot"`
+
+	// 按实盘表现排名的策略排行榜
+	Leaderboard LeaderboardConfig `mapstructure:"leaderboard"`
+
+	// 常驻限价单策略（如网格策略）的挂单维护
+	Grid GridConfig `mapstructure:"grid"`
+
+	// 模拟经纪商（broker_type = stock / crypto）的撮合方式
+	Simulation SimulationConfig `mapstructure:"simulation"`
+
+	// 经纪商不可用导致下单结果不确定时的确认和重试
+	OrderRetry OrderRetryConfig `mapstructure:"order_retry"`
+
+	// 经纪商连接监控：健康检查、断线重连和断线期间的订单处理
+	Connection ConnectionConfig `mapstructure:"connection"`
+}
+
+// ConnectionConfig 经纪商连接监控配置：交易引擎运行时定期检查每个经纪商的连接，
+// 断线后按指数退避重连，断线期间的订单按 outage_policy 等待恢复或直接拒绝
+type ConnectionConfig struct {
+	HealthInterval time.Duration `mapstructure:"health_interval"` // 健康检查间隔
+	InitialBackoff time.Duration `mapstructure:"initial_backoff"` // 首次重连失败后的等待时间，之后每次翻倍
+	MaxBackoff     time.Duration `mapstructure:"max_backoff"`     // 重连等待时间上限
+	OutagePolicy   string        `mapstructure:"outage_policy"`   // 断线期间的订单: reject 立即拒绝 / queue 等待恢复连接
+	QueueTimeout   time.Duration `mapstructure:"queue_timeout"`   // queue 模式下订单最长等待时间，超时后拒绝
+}
+
+// Validate 验证经纪商连接监控配置
+func (c ConnectionConfig) Validate() error {
+	if c.HealthInterval <= 0 {
+		return fmt.Errorf("health_interval 必须大于0")
+	}
+	if c.InitialBackoff <= 0 || c.MaxBackoff < c.InitialBackoff {
+		return fmt.Errorf("initial_backoff 必须大于0且不大于 max_backoff")
+	}
+	switch c.OutagePolicy {
+	case "reject":
+	case "queue":
+		if c.QueueTimeout <= 0 {
+			return fmt.Errorf("queue 模式下 queue_timeout 必须大于0")
+		}
+	default:
+		return fmt.Errorf("未知的 outage_policy: %s（可选 reject、queue）", c.OutagePolicy)
+	}
+	return nil
+}
+
+// GridConfig 常驻限价单（网格、做市）维护配置：引擎按该间隔检查挂单成交并撤补订单，每个交易循环也会同步一次
+type GridConfig struct {
+	SyncInterval time.Duration `mapstructure:"sync_interval"`
+	CancelOnStop bool          `mapstructure:"cancel_on_stop"` // 停止交易引擎时撤销所有网格挂单
+	StateFile    string        `mapstructure:"state_file"`     // 挂单、档位和网格持仓，重启后恢复并与经纪商核对，为空时不保存
+
+	// 按标的的库存上限（净持仓数量的绝对值），全部成交后净持仓会超出上限的挂单不挂出；未配置的标的不限制
+	MaxInventory map[string]float64 `mapstructure:"max_inventory"`
+}
+
+// Validate 验证常驻限价单维护配置
+func (g GridConfig) Validate() error {
+	if g.SyncInterval <= 0 {
+		return fmt.Errorf("sync_interval 必须大于0")
+	}
+	for symbol, limit := range g.MaxInventory {
+		if limit <= 0 {
+			return fmt.Errorf("标的 %s 的 max_inventory 必须大于0", symbol)
+		}
+	}
+	return nil
+}
+
+// InventoryLimit 标的的库存上限，不区分大小写；未配置时返回0表示不限制
+func (g GridConfig) InventoryLimit(symbol string) float64 {
+	for name, limit := range g.MaxInventory {
+		if strings.EqualFold(name, symbol) {
+			return limit
+		}
+	}
+	return 0
+}
+
+// OrderRetryConfig 下单重试配置：重试前先按客户端订单号向经纪商确认首次请求是否已生效，
+// 经纪商不支持按客户端订单号查询时不重试
+type OrderRetryConfig struct {
+	Attempts int           `mapstructure:"attempts"` // 最多提交次数（含首次），1 表示不重试
+	Backoff  time.Duration `mapstructure:"backoff"`  // 重试前的等待时间
+}
+
+// SimulationConfig 模拟经纪商撮合配置
+type SimulationConfig struct {
+	// 市价单每次撮合成交剩余数量的比例，1 表示一次全部成交；小于1时模拟部分成交，
+	// 剩余数量在之后每次查询订单时继续成交，IOC 订单的剩余数量撤销，FOK 订单整单撤销
+	FillRatio float64 `mapstructure:"fill_ratio"`
+}
+
+// LeaderboardConfig 策略排行榜配置：定期采样各策略的累计盈亏，按多个滚动窗口计算盈亏、夏普比率和最大回撤并排名
+type LeaderboardConfig struct {
+	Enabled        bool            `mapstructure:"enabled"`
+	SampleInterval time.Duration   `mapstructure:"sample_interval"` // 盈亏采样间隔
+	Windows        []time.Duration `mapstructure:"windows"`         // 滚动窗口，从短到长
+	RankWindow     time.Duration   `mapstructure:"rank_window"`     // 排名使用的窗口，需在 windows 中
+	RankBy         string          `mapstructure:"rank_by"`         // 排名指标: sharpe / pnl / drawdown
+	StateFile      string          `mapstructure:"state_file"`      // 盈亏采样文件，重启后延续
+}
+
+// Validate 验证策略排行榜配置
+func (l LeaderboardConfig) Validate() error {
+	if !l.Enabled {
+		return nil
+	}
+	if l.SampleInterval <= 0 {
+		return fmt.Errorf("sample_interval 必须大于0")
+	}
+	if len(l.Windows) == 0 {
+		return fmt.Errorf("windows 不能为空")
+	}
+	found := false
+	for _, window := range l.Windows {
+		if window < l.SampleInterval {
+			return fmt.Errorf("窗口 %v 不能短于 sample_interval", window)
+		}
+		if window == l.RankWindow {
+			found = true
+		}
+	}
+	if !found {
+		return fmt.Errorf("rank_window %v 不在 windows 中", l.RankWindow)
+	}
+	switch l.RankBy {
+	case "sharpe", "pnl", "drawdown":
+	default:
+		return fmt.Errorf("不支持的 rank_by: %s", l.RankBy)
+	}
+	if l.StateFile == "" {
+		return fmt.Errorf("state_file 不能为空")
+	}
+	return nil
+}
+
+// SnapshotConfig 持仓快照导出配置：定期将全部账户的持仓和余额写入文件或推送到外部风控、合规系统
+type SnapshotConfig struct {
+	Enabled  bool          `mapstructure:"enabled"`
+	Interval time.Duration `mapstructure:"interval"` // 导出间隔
+	Format   string        `mapstructure:"format"`   // 文件格式: json / csv
+	Dir      string        `mapstructure:"dir"`      // 输出目录，为空时不写文件
+	URL      string        `mapstructure:"url"`      // 以 JSON POST 快照的地址，为空时不推送
+	Timeout  time.Duration `mapstructure:"timeout"`  // 推送超时
+}
+
+// Validate 验证持仓快照配置
+func (s SnapshotConfig) Validate() error {
+	if s.Format != "json" && s.Format != "csv" {
+		return fmt.Errorf("format 只能是 json 或 csv")
+	}
+	if !s.Enabled {
+		return nil
+	}
+	if s.Interval <= 0 {
+		return fmt.Errorf("interval 必须大于0")
+	}
+	if s.Dir == "" && s.URL == "" {
+		return fmt.Errorf("dir 和 url 不能同时为空")
+	}
+	if s.URL != "" && s.Timeout <= 0 {
+		return fmt.Errorf("timeout 必须大于0")
+	}
+	return nil
+}
+
+// ReconciliationConfig 对账配置：定期比较账户管理器中的余额、持仓与经纪商返回的状态
+type ReconciliationConfig struct {
+	Enabled           bool          `mapstructure:"enabled"`
+	Interval          time.Duration `mapstructure:"interval"`           // 对账间隔
+	AutoCorrect       bool          `mapstructure:"auto_correct"`       // 发现差异时按经纪商状态修正本地状态
+	BalanceTolerance  float64       `mapstructure:"balance_tolerance"`  // 余额允许的误差
+	QuantityTolerance float64       `mapstructure:"quantity_tolerance"` // 持仓数量允许的误差
+}
+
+// Validate 验证对账配置
+func (r ReconciliationConfig) Validate() error {
+	if !r.Enabled {
+		return nil
+	}
+	if r.Interval <= 0 {
+		return fmt.Errorf("interval 必须大于0")
+	}
+	if r.BalanceTolerance < 0 || r.QuantityTolerance < 0 {
+		return fmt.Errorf("balance_tolerance 和 quantity_tolerance 不能为负数")
+	}
+	return nil
+}
+
+// PromotionConfig 策略晋级配置：启用后策略信号默认在纸面账户中模拟成交，
+// 列入 live 的策略在最近 days 天的纸面表现满足全部条件后才发送到实盘经纪商
+type PromotionConfig struct {
+	Enabled     bool     `mapstructure:"enabled"`
+	Live        []string `mapstructure:"live"`         // 申请实盘的策略，移出后回到纸面交易
+	Days        int      `mapstructure:"days"`         // 评估天数，纸面交易时间不足时不晋级
+	MinTrades   int      `mapstructure:"min_trades"`   // 评估期内最少成交笔数
+	MinSharpe   float64  `mapstructure:"min_sharpe"`   // 评估期内最低年化夏普比率
+	MaxDrawdown float64  `mapstructure:"max_drawdown"` // 评估期内最大回撤上限
+	StateFile   string   `mapstructure:"state_file"`   // 纸面表现和晋级状态文件
+}
+
+// Validate 验证晋级配置
+func (p PromotionConfig) Validate() error {
+	if !p.Enabled {
+		return nil
+	}
+	if p.Days <= 0 {
+		return fmt.Errorf("days 必须大于0")
+	}
+	if p.MinTrades < 0 {
+		return fmt.Errorf("min_trades 不能为负数")
+	}
+	if p.MaxDrawdown <= 0 || p.MaxDrawdown > 1 {
+		return fmt.Errorf("max_drawdown 必须在 (0, 1] 范围内")
+	}
+	if p.StateFile == "" {
+		return fmt.Errorf("state_file 不能为空")
+	}
+	return nil
+}
+
+// ThrottleConfig 下单频率限制，用于遏制反复发出信号的失控策略；为0的限制不启用
+type ThrottleConfig struct {
+	Enabled              bool          `mapstructure:"enabled"`
+	MaxOrdersPerSymbol   int           `mapstructure:"max_orders_per_symbol"`   // 每个账户每个标的在 symbol_window 内的最大订单数
+	SymbolWindow         time.Duration `mapstructure:"symbol_window"`           // 默认 1m
+	MaxOrdersPerAccount  int           `mapstructure:"max_orders_per_account"`  // 每个账户在 account_window 内的最大订单数
+	AccountWindow        time.Duration `mapstructure:"account_window"`          // 默认 1m
+	MaxNotionalPerWindow float64       `mapstructure:"max_notional_per_window"` // 每个账户在 notional_window 内的最大下单名义金额（账户计价币种）
+	NotionalWindow       time.Duration `mapstructure:"notional_window"`         // 默认 1h
+}
+
+// Validate 验证下单频率限制配置
+func (t ThrottleConfig) Validate() error {
+	if !t.Enabled {
+		return nil
+	}
+	if t.MaxOrdersPerSymbol < 0 || t.MaxOrdersPerAccount < 0 || t.MaxNotionalPerWindow < 0 {
+		return fmt.Errorf("限制不能为负数")
+	}
+	if t.SymbolWindow <= 0 || t.AccountWindow <= 0 || t.NotionalWindow <= 0 {
+		return fmt.Errorf("时间窗口必须大于0")
+	}
+	return nil
+}
+
+// TaxLotConfig 税务批次配置：每笔开仓成交形成一个批次，平仓时按 method 选择平掉的批次，已实现损益含手续费
+type TaxLotConfig struct {
+	Method         string            `mapstructure:"method"`          // fifo（先进先出）/ lifo（后进先出）/ highest_cost（成本最高优先）
+	AccountMethods map[string]string `mapstructure:"account_methods"` // 按账户覆盖 method
+	LongTermDays   int               `mapstructure:"long_term_days"`  // 持有超过该天数的多头批次为长期损益，默认365
+}
+
+// Validate 验证税务批次配置
+func (c TaxLotConfig) Validate() error {
+	methods := map[string]bool{"fifo": true, "lifo": true, "highest_cost": true}
+	if !methods[c.Method] {
+		return fmt.Errorf("method 无效: '%s'，可选: fifo, lifo, highest_cost", c.Method)
+	}
+	for account, method := range c.AccountMethods {
+		if !methods[method] {
+			return fmt.Errorf("account_methods.%s 无效: '%s'，可选: fifo, lifo, highest_cost", account, method)
+		}
+	}
+	if c.LongTermDays <= 0 {
+		return fmt.Errorf("long_term_days 必须大于0")
+	}
+	return nil
+}
+
+// ApprovalConfig 大额订单人工确认配置：名义金额达到阈值的订单需在超时前确认后才会提交
+type ApprovalConfig struct {
+	Enabled      bool          `mapstructure:"enabled"`
+	MinNotional  float64       `mapstructure:"min_notional"`  // 需要确认的最小名义金额（账户计价币种）
+	Timeout      time.Duration `mapstructure:"timeout"`       // 等待确认的时间，超时视为拒绝
+	File         string        `mapstructure:"file"`          // 待确认订单文件，命令行通过该文件确认或拒绝
+	PollInterval time.Duration `mapstructure:"poll_interval"` // 检查确认结果的间隔
+}
+
+// Validate 验证人工确认配置
+func (a ApprovalConfig) Validate() error {
+	if !a.Enabled {
+		return nil
+	}
+	if a.MinNotional <= 0 {
+		return fmt.Errorf("min_notional 必须大于0")
+	}
+	if a.Timeout <= 0 || a.PollInterval <= 0 {
+		return fmt.Errorf("timeout 和 poll_interval 必须大于0")
+	}
+	if a.File == "" {
+		return fmt.Errorf("file 不能为空")
+	}
+	return nil
+}
+
+// ExecutionConfig 信号下单方式配置
+type ExecutionConfig struct {
+	OrderType    string        `mapstructure:"order_type"`    // market 或 limit
+	LimitOffset  float64       `mapstructure:"limit_offset"`  // 限价相对信号价格的偏移比例，买入向下、卖出向上
+	LimitTimeout time.Duration `mapstructure:"limit_timeout"` // 限价单未成交时转为市价单的等待时间，0表示不转换
+	TimeInForce  string        `mapstructure:"time_in_force"` // gtc、day、ioc 或 fok，为空时使用经纪商默认
+}
+
+// RiskConfig 风险控制配置（比例均相对于账户权益）
+type RiskConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+
+	// Preset 内置风控预设（conservative / normal / aggressive），按账户的资产类别取值，
+	// 替代下面的仓位和亏损限制并为没有止损止盈的信号补充默认值；为空时使用下面的数值
+	Preset string `mapstructure:"preset"`
+
+	MaxPositionSize  float64 `mapstructure:"max_position_size"`  // 单笔订单最大占比
+	MaxTotalExposure float64 `mapstructure:"max_total_exposure"` // 总持仓最大占比
+	MaxDailyLoss     float64 `mapstructure:"max_daily_loss"`     // 单日最大亏损比例
+	MaxDrawdown      float64 `mapstructure:"max_drawdown"`       // 最大回撤比例
+	ResizeOrders     bool    `mapstructure:"resize_orders"`      // 超限时缩减订单而不是拒绝
+
+	// 保证金账户（accounts.<name>.margin 配置了杠杆）的最大保证金使用率（持仓市值 / (权益 * 杠杆)），0表示不限制
+	MaxMarginUtilization float64 `mapstructure:"max_margin_utilization"`
+
+	// 交易名单（不受 enabled 影响）：黑名单中的标的禁止交易，白名单非空时只允许交易名单内的标的；
+	// 受限标的仍允许减少现有持仓。account_symbols 按账户在全局名单之外追加限制
+	Blacklist      []string                    `mapstructure:"blacklist"`
+	Whitelist      []string                    `mapstructure:"whitelist"`
+	AccountSymbols map[string]SymbolListConfig `mapstructure:"account_symbols"`
+
+	// 紧急停止（不受 enabled 影响）
+	KillSwitch KillSwitchConfig `mapstructure:"kill_switch"`
+
+	// 组合层面的敞口限制（不受 enabled 影响），按全部账户的持仓合并计算
+	Exposure ExposureConfig `mapstructure:"exposure"`
+
+	// 开仓信号的默认仓位计算方式（实盘和回测共用），可由 strategy.sizing.<策略名> 按策略覆盖；为空时使用策略给出的数量
+	Sizing SizingConfig `mapstructure:"sizing"`
+
+	// 策略监控（不受 enabled 影响）：单个策略的实盘回撤或连续亏损超限时停用该策略
+	StrategySupervisor StrategySupervisorConfig `mapstructure:"strategy_supervisor"`
+}
+
+// StrategySupervisorConfig 策略监控：每轮交易循环结束时按盈亏账本检查各策略，超限的策略停止开仓，
+// 需 strategy enable 命令或控制API显式恢复；金额为各账户计价币种的合计，0表示不检查
+type StrategySupervisorConfig struct {
+	Enabled              bool    `mapstructure:"enabled"`
+	MaxDrawdown          float64 `mapstructure:"max_drawdown"`           // 累计盈亏（含未实现盈亏）距最高值的回撤金额上限
+	MaxConsecutiveLosses int     `mapstructure:"max_consecutive_losses"` // 连续亏损的平仓次数上限
+	StateFile            string  `mapstructure:"state_file"`             // 停用状态文件，供其他进程恢复运行中引擎的策略
+}
+
+// Validate 验证策略监控配置
+func (s StrategySupervisorConfig) Validate() error {
+	if s.MaxDrawdown < 0 {
+		return fmt.Errorf("max_drawdown 不能为负数")
+	}
+	if s.MaxConsecutiveLosses < 0 {
+		return fmt.Errorf("max_consecutive_losses 不能为负数")
+	}
+	return nil
+}
+
+// ExposureConfig 组合敞口限制：同一标的在各账户的持仓先轧差合并，比例均相对全部账户的权益合计（未做汇率折算），
+// 0表示不限制。资产类别按合约规格（future / option）或持有账户的类别（stock / crypto）确定
+type ExposureConfig struct {
+	MaxGrossExposure float64            `mapstructure:"max_gross_exposure"` // 各标的持仓市值绝对值之和的上限
+	MaxNetExposure   float64            `mapstructure:"max_net_exposure"`   // 每个资产类别多空轧差后净市值绝对值的上限
+	MaxSymbolWeight  float64            `mapstructure:"max_symbol_weight"`  // 单个标的持仓市值绝对值的上限
+	AssetClasses     map[string]float64 `mapstructure:"asset_classes"`      // 按资产类别的总敞口上限
+
+	// 按行业的总敞口上限，未列入任何行业的标的不受行业限制
+	Sectors map[string]SectorConfig `mapstructure:"sectors"`
+}
+
+// SectorConfig 行业的成分标的和总敞口上限
+type SectorConfig struct {
+	MaxExposure float64  `mapstructure:"max_exposure"`
+	Symbols     []string `mapstructure:"symbols"`
+}
+
+// Enabled 是否配置了任何敞口限制
+func (e ExposureConfig) Enabled() bool {
+	return e.MaxGrossExposure > 0 || e.MaxNetExposure > 0 || e.MaxSymbolWeight > 0 || len(e.AssetClasses) > 0 || len(e.Sectors) > 0
+}
+
+// Validate 验证敞口限制配置
+func (e ExposureConfig) Validate() error {
+	if e.MaxGrossExposure < 0 || e.MaxNetExposure < 0 || e.MaxSymbolWeight < 0 {
+		return fmt.Errorf("max_gross_exposure、max_net_exposure 和 max_symbol_weight 不能为负数")
+	}
+	for class, limit := range e.AssetClasses {
+		if limit <= 0 {
+			return fmt.Errorf("资产类别 %s 的敞口上限必须大于0", class)
+		}
+	}
+	members := make(map[string]string)
+	for sector, sectorConfig := range e.Sectors {
+		if sectorConfig.MaxExposure <= 0 {
+			return fmt.Errorf("行业 %s 的 max_exposure 必须大于0", sector)
+		}
+		for _, symbol := range sectorConfig.Symbols {
+			symbol = strings.ToUpper(symbol)
+			if other, exists := members[symbol]; exists && other != sector {
+				return fmt.Errorf("标的 %s 同时属于行业 %s 和 %s", symbol, other, sector)
+			}
+			members[symbol] = sector
+		}
+	}
+	return nil
+}
+
+// SizingConfig 仓位计算方式：
+// fixed_fractional 每笔承担权益的 fraction 比例的风险（有止损时按止损距离计算数量，否则按 fraction 比例的市值）；
+// fixed_notional 每笔买入固定金额 notional；
+// kelly 按已平仓交易的胜率 W 和盈亏比 R 计算 Kelly 比例 W - (1-W)/R，乘以 kelly_fraction 后不超过 max_fraction，
+// 已平仓交易少于 min_trades 时使用 win_rate 和 payoff_ratio 的先验值
+type SizingConfig struct {
+	Method        string  `mapstructure:"method"`         // fixed_fractional / fixed_notional / kelly，为空表示使用策略给出的数量
+	Fraction      float64 `mapstructure:"fraction"`       // fixed_fractional 每笔风险占权益的比例
+	Notional      float64 `mapstructure:"notional"`       // fixed_notional 每笔买入金额（账户计价币种）
+	KellyFraction float64 `mapstructure:"kelly_fraction"` // kelly 使用的 Kelly 比例系数，如 0.5 为半 Kelly
+	MaxFraction   float64 `mapstructure:"max_fraction"`   // kelly 仓位市值占权益的上限，0表示不限制
+	WinRate       float64 `mapstructure:"win_rate"`       // kelly 的先验胜率
+	PayoffRatio   float64 `mapstructure:"payoff_ratio"`   // kelly 的先验盈亏比（平均盈利 / 平均亏损）
+	MinTrades     int     `mapstructure:"min_trades"`     // kelly 改用实际交易统计所需的最少已平仓交易数
+}
+
+// Validate 验证仓位计算配置
+func (s SizingConfig) Validate() error {
+	switch s.Method {
+	case "":
+	case "fixed_fractional":
+		if s.Fraction <= 0 || s.Fraction > 1 {
+			return fmt.Errorf("fixed_fractional 的 fraction 必须在 (0, 1] 之间")
+		}
+	case "fixed_notional":
+		if s.Notional <= 0 {
+			return fmt.Errorf("fixed_notional 的 notional 必须大于0")
+		}
+	case "kelly":
+		if s.KellyFraction <= 0 || s.KellyFraction > 1 {
+			return fmt.Errorf("kelly 的 kelly_fraction 必须在 (0, 1] 之间")
+		}
+		if s.WinRate < 0 || s.WinRate > 1 {
+			return fmt.Errorf("kelly 的 win_rate 必须在 0 到 1 之间")
+		}
+		if s.PayoffRatio < 0 || s.MaxFraction < 0 || s.MinTrades < 0 {
+			return fmt.Errorf("kelly 的 payoff_ratio、max_fraction 和 min_trades 不能为负数")
+		}
+	default:
+		return fmt.Errorf("未知的仓位计算方式: %s（可选 fixed_fractional、fixed_notional、kelly）", s.Method)
+	}
+	return nil
+}
+
+// RiskLimits 单个账户的仓位、亏损限制和默认止损止盈
+type RiskLimits struct {
+	MaxPositionSize   float64 `json:"max_position_size"`   // 单笔订单最大占比
+	MaxTotalExposure  float64 `json:"max_total_exposure"`  // 总持仓最大占比
+	MaxDailyLoss      float64 `json:"max_daily_loss"`      // 单日最大亏损比例
+	MaxDrawdown       float64 `json:"max_drawdown"`        // 最大回撤比例
+	StopLossPercent   float64 `json:"stop_loss_percent"`   // 信号没有止损时的默认止损百分比，0表示不补充
+	TakeProfitPercent float64 `json:"take_profit_percent"` // 信号没有止盈时的默认止盈百分比，0表示不补充
+}
+
+// 风控预设名称
+const (
+	RiskConservative = "conservative"
+	RiskNormal       = "normal"
+	RiskAggressive   = "aggressive"
+)
+
+// RiskPresets 内置风控预设，按预设名称和资产类别（stock / crypto）取值；
+// 加密货币波动更大，同一档位的仓位上限更低、止损更宽
+var RiskPresets = map[string]map[string]RiskLimits{
+	RiskConservative: {
+		"stock":  {MaxPositionSize: 0.05, MaxTotalExposure: 0.6, MaxDailyLoss: 0.02, MaxDrawdown: 0.1, StopLossPercent: 3, TakeProfitPercent: 6},
+		"crypto": {MaxPositionSize: 0.03, MaxTotalExposure: 0.4, MaxDailyLoss: 0.03, MaxDrawdown: 0.15, StopLossPercent: 5, TakeProfitPercent: 10},
+	},
+	RiskNormal: {
+		"stock":  {MaxPositionSize: 0.1, MaxTotalExposure: 1.0, MaxDailyLoss: 0.05, MaxDrawdown: 0.2, StopLossPercent: 5, TakeProfitPercent: 10},
+		"crypto": {MaxPositionSize: 0.05, MaxTotalExposure: 0.7, MaxDailyLoss: 0.06, MaxDrawdown: 0.25, StopLossPercent: 8, TakeProfitPercent: 16},
+	},
+	RiskAggressive: {
+		"stock":  {MaxPositionSize: 0.2, MaxTotalExposure: 1.0, MaxDailyLoss: 0.08, MaxDrawdown: 0.3, StopLossPercent: 8, TakeProfitPercent: 16},
+		"crypto": {MaxPositionSize: 0.1, MaxTotalExposure: 1.0, MaxDailyLoss: 0.1, MaxDrawdown: 0.4, StopLossPercent: 12, TakeProfitPercent: 24},
+	},
+}
+
+// Limits 资产类别适用的风控限制：配置了预设时使用预设，否则使用 risk 中的数值（不补充默认止损止盈）
+func (r RiskConfig) Limits(assetClass string) RiskLimits {
+	if presets, ok := RiskPresets[r.Preset]; ok {
+		if limits, ok := presets[assetClass]; ok {
+			return limits
+		}
+		return presets["stock"]
+	}
+	return RiskLimits{
+		MaxPositionSize:  r.MaxPositionSize,
+		MaxTotalExposure: r.MaxTotalExposure,
+		MaxDailyLoss:     r.MaxDailyLoss,
+		MaxDrawdown:      r.MaxDrawdown,
+	}
+}
+
+// KillSwitchConfig 紧急停止配置：enabled 控制自动触发，命令行和控制API始终可以手动停止；
+// 停止后撤销全部未成交订单，不再执行信号，直到显式恢复
+type KillSwitchConfig struct {
+	Enabled         bool    `mapstructure:"enabled"`
+	MaxDailyLoss    float64 `mapstructure:"max_daily_loss"`    // 任一账户单日亏损比例达到该值时停止，0表示不启用
+	MaxDrawdown     float64 `mapstructure:"max_drawdown"`      // 任一账户回撤比例达到该值时停止，0表示不启用
+	MaxFailedCycles int     `mapstructure:"max_failed_cycles"` // 连续失败的交易循环数达到该值时停止，0表示不启用
+	StateFile       string  `mapstructure:"state_file"`        // 停止状态文件，供其他进程停止或恢复运行中的引擎
+}
+
+// Validate 验证紧急停止配置
+func (k KillSwitchConfig) Validate() error {
+	if k.MaxDailyLoss < 0 || k.MaxDailyLoss > 1 || k.MaxDrawdown < 0 || k.MaxDrawdown > 1 {
+		return fmt.Errorf("max_daily_loss 和 max_drawdown 必须在 [0, 1] 范围内")
+	}
+	if k.MaxFailedCycles < 0 {
+		return fmt.Errorf("max_failed_cycles 不能为负数")
+	}
+	return nil
+}
+
+// SymbolListConfig 账户的交易名单
+type SymbolListConfig struct {
+	Blacklist []string `mapstructure:"blacklist"`
+	Whitelist []string `mapstructure:"whitelist"`
+}
+
+// EngineConfig 引擎运行配置
+type EngineConfig struct {
+	// OverrunPolicy 循环耗时超过间隔时的处理方式:
+	// "skip" 丢弃积压的触发，等待下一个整点间隔；"coalesce" 将积压的触发合并为一次立即执行
+	OverrunPolicy string `mapstructure:"overrun_policy"`
+
+	// HistoryFile 交易循环记录文件（JSON Lines），为空时不记录
+	HistoryFile string `mapstructure:"history_file"`
+
+	// GuidanceFile Agent指导记录文件（JSON Lines）：每条指导及其影响的信号、中性情绪下的基线信号和提交的订单，
+	// agent-report 命令据此评估情绪调整是否改善了结果；为空时不记录
+	GuidanceFile string `mapstructure:"guidance_file"`
+
+	// Reload 运行中监视配置文件，修改后热加载可在线调整的配置
+	Reload ReloadConfig `mapstructure:"reload"`
+
+	// Replay 回放模式：用历史K线按实盘引擎的完整流程运行
+	Replay ReplayConfig `mapstructure:"replay"`
+}
+
+// ReplayConfig 回放模式：历史K线按回放时钟逐轮提供给实盘引擎，订单由纸面经纪商按回放价格成交，
+// 循环记录、成交流水和各状态文件写入 output_dir，不影响实盘的状态
+type ReplayConfig struct {
+	OutputDir string        `mapstructure:"output_dir"` // 回放的状态文件目录
+	Agent     string        `mapstructure:"agent"`      // mock: 模拟Agent和模拟新闻; live: 使用配置的Agent服务和新闻源
+	Step      time.Duration `mapstructure:"step"`       // 每轮循环推进的行情时间，0 表示逐根K线推进
+	Delay     time.Duration `mapstructure:"delay"`      // 每轮循环之间的等待时间，0 表示尽快运行
+	Warmup    time.Duration `mapstructure:"warmup"`     // 开始时间之前额外加载的K线，供策略计算指标
+}
+
+// Validate 验证回放配置
+func (r ReplayConfig) Validate() error {
+	if r.OutputDir == "" {
+		return fmt.Errorf("output_dir 不能为空")
+	}
+	switch r.Agent {
+	case "mock", "live":
+	default:
+		return fmt.Errorf("agent 只能是 mock 或 live")
+	}
+	if r.Step < 0 || r.Delay < 0 || r.Warmup < 0 {
+		return fmt.Errorf("step、delay 和 warmup 不能为负数")
+	}
+	return nil
+}
+
+// ReloadConfig 配置热加载：策略参数和启用的策略、风控限制和交易名单、观察列表和扫描器、通知设置
+// 修改后在两轮循环之间生效，其他配置的修改需要重启
+type ReloadConfig struct {
+	Enabled  bool          `mapstructure:"enabled"`
+	Interval time.Duration `mapstructure:"interval"` // 检查配置文件修改的间隔
+}
+
+// DegradationConfig 外部依赖故障时的降级方式
+type DegradationConfig struct {
+	Data        string        `mapstructure:"data"`          // cache: 复用最近一次成功获取的行情; fail: 本轮跳过该标的
+	DataMaxAge  time.Duration `mapstructure:"data_max_age"`  // 缓存行情的最长复用时间
+	Agent       string        `mapstructure:"agent"`         // neutral: 按中性指导继续; cache: 复用最近一次成功的分析，没有时按中性; mock: 改用模拟客户端; fail: 本轮跳过该标的
+	AgentMaxAge time.Duration `mapstructure:"agent_max_age"` // cache 方式复用分析结果的最长时间
+	News        string        `mapstructure:"news"`          // mock: 使用模拟新闻; empty: 视为没有新闻; fail: 本轮跳过该标的
+	Broker      string        `mapstructure:"broker"`        // queue: 信号排队，下一轮重新提交; halt: 本轮停止下单并丢弃信号
+	QueueMaxAge time.Duration `mapstructure:"queue_max_age"` // 排队信号的有效期，过期后丢弃
+}
+
+// Validate 验证降级配置
+func (d DegradationConfig) Validate() error {
+	checks := []struct {
+		name    string
+		value   string
+		allowed []string
+	}{
+		{"data", d.Data, []string{"cache", "fail"}},
+		{"agent", d.Agent, []string{"neutral", "cache", "mock", "fail"}},
+		{"news", d.News, []string{"mock", "empty", "fail"}},
+		{"broker", d.Broker, []string{"queue", "halt"}},
+	}
+
+	for _, check := range checks {
+		valid := false
+		for _, allowed := range check.allowed {
+			if check.value == allowed {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return fmt.Errorf("%s 只能是 %s", check.name, strings.Join(check.allowed, "、"))
+		}
+	}
+	return nil
+}
+
+// FXConfig 汇率配置
+type FXConfig struct {
+	ReportingCurrency string             `mapstructure:"reporting_currency"` // 报告币种
+	Source            string             `mapstructure:"source"`             // 汇率来源: static 或 http
+	URL               string             `mapstructure:"url"`                // http 数据源地址
+	CacheTTL          time.Duration      `mapstructure:"cache_ttl"`          // 汇率缓存时长
+	Rates             map[string]float64 `mapstructure:"rates"`              // 静态汇率，键格式为 "usd_cny"
+}
+
+// NewsConfig 新闻采集配置
+type NewsConfig struct {
+	Enabled        bool          `mapstructure:"enabled"`
+	RSSFeeds       []string      `mapstructure:"rss_feeds"`       // RSS 地址，{symbol} 会被替换为标的代码
+	MaxAge         time.Duration `mapstructure:"max_age"`         // 只保留该时长内发布的新闻
+	MaxItems       int           `mapstructure:"max_items"`       // 每次发送给Agent的最大新闻数
+	RequestTimeout time.Duration `mapstructure:"request_timeout"` // 单个数据源请求超时
+
+	Discovery NewsDiscoveryConfig `mapstructure:"discovery"`
+	Relevance NewsRelevanceConfig `mapstructure:"relevance"`
+}
+
+// NewsRelevanceConfig 新闻相关性过滤配置：发送给Agent前去掉与标的无关、语言不符或标题相似的新闻
+type NewsRelevanceConfig struct {
+	Enabled             bool                `mapstructure:"enabled"`
+	Aliases             map[string][]string `mapstructure:"aliases"`              // 标的的公司名、产品名等别名，不区分大小写
+	MinScore            float64             `mapstructure:"min_score"`            // 标题提及标的或别名计2分，摘要提及计1分，低于该分数的新闻丢弃，0 表示不检查
+	Languages           []string            `mapstructure:"languages"`            // 保留的语言（en/de/fr/es/zh/ja/ko/ru），为空时不检查，无法识别语言的新闻保留
+	SimilarityThreshold float64             `mapstructure:"similarity_threshold"` // 标题词集合的 Jaccard 相似度达到该值视为重复，0 表示不检查
+}
+
+// Validate 验证新闻相关性过滤配置
+func (c NewsRelevanceConfig) Validate() error {
+	if c.MinScore < 0 {
+		return fmt.Errorf("min_score 不能为负数")
+	}
+	if c.SimilarityThreshold < 0 || c.SimilarityThreshold > 1 {
+		return fmt.Errorf("similarity_threshold 必须在 0 到 1 之间")
+	}
+	return nil
+}
+
+// NewsDiscoveryConfig 新闻标的发现配置：从新闻中找出频繁提及但不在观察列表中的标的
+type NewsDiscoveryConfig struct {
+	Enabled      bool          `mapstructure:"enabled"`
+	Queries      []string      `mapstructure:"queries"`        // 额外拉取的综合新闻查询（如 "stock market"），用于发现突发新闻
+	MinMentions  int           `mapstructure:"min_mentions"`   // 至少被多少篇新闻提及
+	MaxSymbols   int           `mapstructure:"max_symbols"`    // 每轮最多发现的标的数
+	AddToScanner bool          `mapstructure:"add_to_scanner"` // 是否加入扫描器标的池（需启用扫描器）
+	Probation    time.Duration `mapstructure:"probation"`      // 加入标的池的观察期，到期未再被提及则移出
+}
+
+// NotificationsConfig 告警通知配置
+type NotificationsConfig struct {
+	Enabled   bool     `mapstructure:"enabled"`
+	Events    []string `mapstructure:"events"`     // 需要通知的事件：trade, risk, cycle_failed, broker, backtest, approval, dependency, promotion, reconcile, halt, config
+	QueueSize int      `mapstructure:"queue_size"` // 待发送通知的队列长度，队列满时丢弃新通知
+
+	Telegram TelegramConfig `mapstructure:"telegram"`
+	Slack    SlackConfig    `mapstructure:"slack"`
+	Email    EmailConfig    `mapstructure:"email"`
+}
+
+// TelegramConfig Telegram机器人通知
+type TelegramConfig struct {
+	Enabled  bool   `mapstructure:"enabled"`
+	BotToken string `mapstructure:"bot_token"`
+	ChatID   string `mapstructure:"chat_id"`
+}
+
+// SlackConfig Slack Incoming Webhook 通知
+type SlackConfig struct {
+	Enabled    bool   `mapstructure:"enabled"`
+	WebhookURL string `mapstructure:"webhook_url"`
+}
+
+// EmailConfig SMTP邮件通知
+type EmailConfig struct {
+	Enabled  bool     `mapstructure:"enabled"`
+	Host     string   `mapstructure:"host"`
+	Port     int      `mapstructure:"port"`
+	Username string   `mapstructure:"username"`
+	Password string   `mapstructure:"password"`
+	From     string   `mapstructure:"from"`
+	To       []string `mapstructure:"to"`
+}
+
+// Validate 验证告警通知配置
+func (n NotificationsConfig) Validate() error {
+	if !n.Enabled {
+		return nil
+	}
+	if n.Telegram.Enabled && (n.Telegram.BotToken == "" || n.Telegram.ChatID == "") {
+		return fmt.Errorf("telegram 需要配置 bot_token 和 chat_id")
+	}
+	if n.Slack.Enabled && n.Slack.WebhookURL == "" {
+		return fmt.Errorf("slack 需要配置 webhook_url")
+	}
+	if n.Email.Enabled && (n.Email.Host == "" || n.Email.From == "" || len(n.Email.To) == 0) {
+		return fmt.Errorf("email 需要配置 host、from 和 to")
+	}
+	return nil
+}
+
+// StrategyConfig 策略配置
+type StrategyConfig struct {
+	PluginDir string   `mapstructure:"plugin_dir"` // 外部策略插件（.so）目录，为空时不加载
+	Active    []string `mapstructure:"active"`     // 每个循环运行的策略
+
+	// Incremental 实盘循环中以增量模式运行的策略（需支持增量模式，如 ma_cross、rsi、donchian）：指标状态按新收盘的K线逐根更新，
+	// 不再每轮对整个窗口重新计算；同一根K线只生成一次信号，未收盘的K线留到收盘后处理。回测始终对支持的策略使用增量模式
+	Incremental []string `mapstructure:"incremental"`
+
+	// 交易时间表，分别按策略名和标的配置，两者都满足时策略才会对该标的运行
+	Schedules       map[string]ScheduleConfig `mapstructure:"schedules"`
+	SymbolSchedules map[string]ScheduleConfig `mapstructure:"symbol_schedules"`
+
+	// 策略的账户路由和资金分配，未配置的策略使用 default_account（为空时为按名称排序的第一个账户）且不限制资金
+	Allocations    map[string]AllocationConfig `mapstructure:"allocations"`
+	DefaultAccount string                      `mapstructure:"default_account"`
+
+	// Parameters 按策略名覆盖策略参数，未配置的参数使用策略的默认值
+	Parameters map[string]map[string]interface{} `mapstructure:"parameters"`
+
+	// Sizing 按策略名覆盖 risk.sizing 的仓位计算方式
+	Sizing map[string]SizingConfig `mapstructure:"sizing"`
+
+	// Timeframes 按策略名指定K线周期（如 4h、1d），策略收到重采样后的K线；未配置时使用数据源原始周期
+	Timeframes map[string]string `mapstructure:"timeframes"`
+
+	// Ensemble 将多个策略对同一标的的信号合并为一个净交易决策
+	Ensemble EnsembleConfig `mapstructure:"ensemble"`
+
+	// Guidance Agent情绪对策略信号的影响方式，由策略管理器统一应用到所有策略
+	Guidance GuidanceConfig `mapstructure:"guidance"`
+
+	// Composites 按策略名声明组合策略，由过滤条件、入场规则、离场规则和仓位计算组件拼装而成；
+	// 声明的策略启动时注册，与内置策略一样通过 active 启用、通过 parameters 覆盖参数
+	Composites map[string]CompositeConfig `mapstructure:"composites"`
+}
+
+// CompositeConfig 组合策略的组件：入场信号依次经过 filters 检查，exit 设置止损止盈或发出离场信号
+type CompositeConfig struct {
+	Description string            `mapstructure:"description"`
+	Filters     []ComponentConfig `mapstructure:"filters"` // 入场过滤条件: trend（均线趋势）/ volatility（ATR百分比）
+	Entry       ComponentConfig   `mapstructure:"entry"`   // 入场规则: ma_cross / rsi / breakout
+	Exit        ComponentConfig   `mapstructure:"exit"`    // 离场规则: atr / percent（止损止盈）/ channel（跌破通道下轨卖出），为空时不设
+	Sizer       ComponentConfig   `mapstructure:"sizer"`   // 仓位计算: fixed / vol_target，为空时为 fixed
+}
+
+// ComponentConfig 组件的类型和参数，未配置的参数使用组件的默认值
+type ComponentConfig struct {
+	Type   string                 `mapstructure:"type"`
+	Params map[string]interface{} `mapstructure:"params"`
+}
+
+// Validate 验证组合策略配置，组件类型和参数在创建策略时检查
+func (c CompositeConfig) Validate() error {
+	if c.Entry.Type == "" {
+		return fmt.Errorf("entry.type 不能为空")
+	}
+	for i, filter := range c.Filters {
+		if filter.Type == "" {
+			return fmt.Errorf("filters[%d].type 不能为空", i)
+		}
+	}
+	switch c.Sizer.Type {
+	case "", "fixed", "vol_target":
+	default:
+		return fmt.Errorf("sizer.type 只能是 fixed 或 vol_target")
+	}
+	return nil
 }
 
-// AgentServiceConfig Agent服务配置
-type AgentServiceConfig struct {
-	URL string `mapstructure:"url"`
+// GuidanceConfig Agent情绪对信号置信度的调整：情绪与信号同向时增加 weight、反向时减少 weight（限制在0~1），
+// 仓位按调整后的置信度计算；Agent置信度低于 min_confidence 的情绪不起作用，反向情绪的置信度达到 veto_confidence 时不交易
+type GuidanceConfig struct {
+	Enabled           bool               `mapstructure:"enabled"`             // false 时忽略情绪，信号不做调整
+	Weight            float64            `mapstructure:"weight"`              // 置信度调整幅度
+	Weights           map[string]float64 `mapstructure:"weights"`             // 按策略名覆盖 weight，0 表示该策略不受情绪影响
+	ScaleByConfidence bool               `mapstructure:"scale_by_confidence"` // 调整幅度乘以Agent的置信度
+	MinConfidence     float64            `mapstructure:"min_confidence"`      // Agent置信度低于该值时忽略情绪
+	VetoConfidence    float64            `mapstructure:"veto_confidence"`     // 反向情绪否决信号的置信度阈值，0 表示不否决
 }
 
-// APIKeysConfig API密钥配置
-type APIKeysConfig struct {
-	OpenAIKey string `mapstructure:"openai_key"`
+// Validate 验证情绪调整配置
+func (g GuidanceConfig) Validate() error {
+	if g.Weight < 0 || g.Weight > 1 {
+		return fmt.Errorf("weight 必须在 0 到 1 之间")
+	}
+	for name, weight := range g.Weights {
+		if weight < 0 || weight > 1 {
+			return fmt.Errorf("策略 %s 的 weight 必须在 0 到 1 之间", name)
+		}
+	}
+	if g.MinConfidence < 0 || g.MinConfidence > 1 {
+		return fmt.Errorf("min_confidence 必须在 0 到 1 之间")
+	}
+	if g.VetoConfidence < 0 || g.VetoConfidence > 1 {
+		return fmt.Errorf("veto_confidence 必须在 0 到 1 之间")
+	}
+	return nil
 }
 
-// AccountConfig 账户配置
-type AccountConfig struct {
-	APIKey     string `mapstructure:"api_key"`
-	APISecret  string `mapstructure:"api_secret"`
-	BrokerType string `mapstructure:"broker_type"`
+// EnsembleConfig 策略组合：成员策略对同一标的的买卖信号按 method 合并为一个信号，以 name 作为策略名下单；
+// 平仓信号和非成员策略的信号不合并
+type EnsembleConfig struct {
+	Enabled    bool               `mapstructure:"enabled"`
+	Name       string             `mapstructure:"name"`       // 合并后信号的策略名，用于账户路由和盈亏记账
+	Strategies []string           `mapstructure:"strategies"` // 参与合并的成员策略
+	Method     string             `mapstructure:"method"`     // weighted（按权重和置信度加权投票）/ majority（多数）/ unanimous（一致）
+	Weights    map[string]float64 `mapstructure:"weights"`    // 成员策略的权重，未配置的为1
+	Threshold  float64            `mapstructure:"threshold"`  // weighted 的净得分（-1~1）绝对值达到该值才交易
+	Veto       []string           `mapstructure:"veto"`       // 有否决权的策略：给出相反方向的信号时不交易
 }
 
-// DatabaseConfig 数据库配置
-type DatabaseConfig struct {
-	Host         string `mapstructure:"host"`
-	Port         int    `mapstructure:"port"`
-	Username     string `mapstructure:"username"`
-	Password     string `mapstructure:"password"`
-	DatabaseName string `mapstructure:"database_name"`
+// Validate 验证策略组合配置
+func (e EnsembleConfig) Validate() error {
+	if !e.Enabled {
+		return nil
+	}
+	if e.Name == "" {
+		return fmt.Errorf("name 不能为空")
+	}
+	if len(e.Strategies) < 2 {
+		return fmt.Errorf("strategies 至少需要两个成员策略")
+	}
+	members := make(map[string]bool, len(e.Strategies))
+	for _, name := range e.Strategies {
+		if name == e.Name {
+			return fmt.Errorf("成员策略不能与组合同名: %s", name)
+		}
+		members[name] = true
+	}
+	switch e.Method {
+	case "weighted", "majority", "unanimous":
+	default:
+		return fmt.Errorf("method 只能是 weighted、majority 或 unanimous")
+	}
+	if e.Threshold < 0 || e.Threshold > 1 {
+		return fmt.Errorf("threshold 必须在 0 到 1 之间")
+	}
+	for name, weight := range e.Weights {
+		if weight < 0 {
+			return fmt.Errorf("策略 %s 的权重不能为负数", name)
+		}
+	}
+	for _, name := range e.Veto {
+		if !members[name] {
+			return fmt.Errorf("否决策略 %s 不在 strategies 中", name)
+		}
+	}
+	return nil
 }
 
-// LoggingConfig 日志配置
-type LoggingConfig struct {
-	Level string `mapstructure:"level"`
-	File  string `mapstructure:"file"`
+// AllocationConfig 策略的账户和资金分配
+type AllocationConfig struct {
+	Account  string  `mapstructure:"account"`  // 策略信号路由到的账户
+	Fraction float64 `mapstructure:"fraction"` // 策略可占用的账户权益比例 (0, 1]，0表示不限制
 }
 
-// BacktestConfig 回测配置
-type BacktestConfig struct {
-	InitialCapital float64 `mapstructure:"initial_capital"`
-	CommissionRate float64 `mapstructure:"commission_rate"`
-	SlippageRate   float64 `mapstructure:"slippage_rate"`
+// validateAllocations 检查分配的账户存在、比例有效，且同一账户的比例合计不超过1
+func (s StrategyConfig) validateAllocations(accounts map[string]AccountConfig) error {
+	if s.DefaultAccount != "" {
+		if _, exists := accounts[s.DefaultAccount]; !exists {
+			return fmt.Errorf("default_account '%s' 不存在", s.DefaultAccount)
+		}
+	}
+
+	totals := make(map[string]float64)
+	for name, allocation := range s.Allocations {
+		if allocation.Account != "" {
+			if _, exists := accounts[allocation.Account]; !exists {
+				return fmt.Errorf("策略 '%s' 的账户 '%s' 不存在", name, allocation.Account)
+			}
+		}
+		if allocation.Fraction < 0 || allocation.Fraction > 1 {
+			return fmt.Errorf("策略 '%s' 的 fraction 必须在 0 到 1 之间", name)
+		}
+
+		account := allocation.Account
+		if account == "" {
+			account = s.DefaultAccount
+		}
+		totals[account] += allocation.Fraction
+	}
+
+	for account, total := range totals {
+		if total > 1+1e-9 {
+			return fmt.Errorf("账户 '%s' 分配给各策略的比例合计 %.2f 超过1", account, total)
+		}
+	}
+	return nil
+}
+
+// ScheduleConfig 交易时间表配置，未设置的项表示不限制
+type ScheduleConfig struct {
+	Timezone string   `mapstructure:"timezone"` // 时间表使用的时区，默认本地时区
+	Days     []string `mapstructure:"days"`     // 允许的星期，如 ["mon", "tue"]
+	Windows  []string `mapstructure:"windows"`  // 允许的时间窗口，如 ["09:30-11:30", "13:00-15:00"]
+	Dates    []string `mapstructure:"dates"`    // 允许的日期区间，如 "2025-01-15:2025-02-15"，"01-15:02-15" 表示每年重复
+	Blackout []string `mapstructure:"blackout"` // 禁止交易的日期或区间，格式同 dates
+}
+
+// ScannerConfig 标的扫描配置
+type ScannerConfig struct {
+	Watchlist     []string `mapstructure:"watchlist"`      // 固定交易标的，始终保留在观察列表中
+	Enabled       bool     `mapstructure:"enabled"`        // 是否每个循环扫描标的池
+	Universe      []string `mapstructure:"universe"`       // 待扫描的标的池
+	LookbackDays  int      `mapstructure:"lookback_days"`  // 计算指标使用的历史天数
+	MinVolume     float64  `mapstructure:"min_volume"`     // 最小平均成交量
+	MinVolatility float64  `mapstructure:"min_volatility"` // 最小收益率波动率（标准差）
+	MaxVolatility float64  `mapstructure:"max_volatility"` // 最大收益率波动率，0 表示不限制
+	MinGap        float64  `mapstructure:"min_gap"`        // 最小跳空幅度（|开盘-前收|/前收），0 表示不要求
+	MaxPromoted   int      `mapstructure:"max_promoted"`   // 每次最多加入观察列表的标的数
+}
+
+// PortfolioConfig 组合优化：按观察列表的历史收益率计算目标权重，定期生成调仓信号（策略名 portfolio）
+type PortfolioConfig struct {
+	Enabled            bool          `mapstructure:"enabled"`
+	Method             string        `mapstructure:"method"`              // mean_variance 或 risk_parity
+	LookbackDays       int           `mapstructure:"lookback_days"`       // 计算收益率和协方差的历史天数
+	RiskAversion       float64       `mapstructure:"risk_aversion"`       // 均值-方差的风险厌恶系数
+	MaxWeight          float64       `mapstructure:"max_weight"`          // 单个标的权重上限，0表示不限制
+	Budget             float64       `mapstructure:"budget"`              // 投入组合的权益比例 (0, 1]
+	RebalanceInterval  time.Duration `mapstructure:"rebalance_interval"`  // 两次调仓的最短间隔
+	RebalanceThreshold float64       `mapstructure:"rebalance_threshold"` // 权重偏离超过该值才调仓
+	MinTradeValue      float64       `mapstructure:"min_trade_value"`     // 调仓金额低于该值时不交易
+
+	// 开仓信号的相关性过滤，不受 enabled 影响
+	Correlation CorrelationFilterConfig `mapstructure:"correlation"`
+}
+
+// CorrelationFilterConfig 相关性过滤：按持仓标的与新信号标的最近 window 个共同时间点的收益率相关系数，
+// 拒绝或缩减会使组合过于相关或集中的买入信号；0表示不检查对应的限制
+type CorrelationFilterConfig struct {
+	Enabled                 bool    `mapstructure:"enabled"`
+	LookbackDays            int     `mapstructure:"lookback_days"`             // 获取行情的历史天数
+	Window                  int     `mapstructure:"window"`                    // 计算相关系数使用的最近收益率个数
+	MaxPairCorrelation      float64 `mapstructure:"max_pair_correlation"`      // 与任一持仓标的的相关系数上限
+	MaxPortfolioCorrelation float64 `mapstructure:"max_portfolio_correlation"` // 按持仓市值加权的组合平均相关系数上限
+	MaxConcentration        float64 `mapstructure:"max_concentration"`         // 持仓市值的赫芬达尔集中度上限（1表示全部集中在一个标的）
+	Action                  string  `mapstructure:"action"`                    // reject（拒绝）或 downsize（缩减数量）
+	MinScale                float64 `mapstructure:"min_scale"`                 // downsize 缩减后低于原数量的该比例时拒绝
+}
+
+// Validate 验证相关性过滤配置
+func (c CorrelationFilterConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.LookbackDays <= 0 || c.Window < 2 {
+		return fmt.Errorf("lookback_days 必须大于0，window 至少为2")
+	}
+	for name, value := range map[string]float64{
+		"max_pair_correlation": c.MaxPairCorrelation, "max_portfolio_correlation": c.MaxPortfolioCorrelation,
+		"max_concentration": c.MaxConcentration, "min_scale": c.MinScale,
+	} {
+		if value < 0 || value > 1 {
+			return fmt.Errorf("%s 必须在 0 到 1 之间", name)
+		}
+	}
+	if c.Action != "reject" && c.Action != "downsize" {
+		return fmt.Errorf("action 只能是 reject 或 downsize")
+	}
+	return nil
+}
+
+// Validate 验证组合优化配置
+func (p PortfolioConfig) Validate() error {
+	if err := p.Correlation.Validate(); err != nil {
+		return fmt.Errorf("correlation: %w", err)
+	}
+	if !p.Enabled {
+		return nil
+	}
+	if p.Method != "mean_variance" && p.Method != "risk_parity" {
+		return fmt.Errorf("method 只能是 mean_variance 或 risk_parity")
+	}
+	if p.LookbackDays <= 0 {
+		return fmt.Errorf("lookback_days 必须大于0")
+	}
+	if p.Budget <= 0 || p.Budget > 1 {
+		return fmt.Errorf("budget 必须在 (0, 1] 之间")
+	}
+	if p.MaxWeight < 0 || p.MaxWeight > 1 {
+		return fmt.Errorf("max_weight 必须在 0 到 1 之间")
+	}
+	if p.RiskAversion < 0 || p.RebalanceThreshold < 0 || p.MinTradeValue < 0 {
+		return fmt.Errorf("risk_aversion、rebalance_threshold 和 min_trade_value 不能为负数")
+	}
+	return nil
+}
+
+// InstrumentsConfig 合约规格配置
+type InstrumentsConfig struct {
+	// 期货品种，按品种代码（如 ES、CL）配置。具体合约代码为 品种 + 月份代码 + 两位年份（如 ESZ26），
+	// 连续合约代码为 品种 + "=F"（如 ES=F）：行情按移仓日拼接各月合约，下单时映射为当前主力合约
+	Futures map[string]FuturesSpecConfig `mapstructure:"futures"`
+
+	// 交易引擎检查期货持仓是否到达移仓日的间隔，到达后平掉旧合约并在下一合约开同样的仓位；0表示不自动移仓
+	RollCheckInterval time.Duration `mapstructure:"roll_check_interval"`
+
+	// 期权合约代码使用 OCC 格式：标的 + 到期日 YYMMDD + C/P + 行权价*1000（8位），如 AAPL261218C00150000
+	Options OptionsConfig `mapstructure:"options"`
+}
+
+// SymbolsConfig 标的代码转换：系统内统一使用规范写法（美股 AAPL、其他市场 0700.HK、稳定币交易对 BTCUSDT、
+// 法币交易对 BTC-USD），向数据源和经纪商请求时按其写法转换，返回的行情、订单和持仓转换回规范写法
+type SymbolsConfig struct {
+	DefaultMarket string `mapstructure:"default_market"` // 规范写法中省略市场代码的股票市场，默认 US
+
+	// 按数据源名称、账户名称或经纪商类型配置的写法，账户名称优先于经纪商类型；未配置的数据源和经纪商不转换
+	Venues map[string]SymbolVenueConfig `mapstructure:"venues"`
+}
+
+// SymbolVenueConfig 数据源或经纪商的标的写法
+type SymbolVenueConfig struct {
+	StockFormat  string            `mapstructure:"stock_format"`  // plain（AAPL）/ suffix（AAPL.US）/ prefix（US.AAPL），默认 plain
+	CryptoFormat string            `mapstructure:"crypto_format"` // concat（BTCUSDT）/ dash（BTC-USDT）/ slash（BTC/USDT）/ underscore（BTC_USDT），默认 concat
+	Lowercase    bool              `mapstructure:"lowercase"`     // 使用小写代码
+	Quotes       map[string]string `mapstructure:"quotes"`        // 计价币种映射，如 { USDT = "USD" }
+	Aliases      map[string]string `mapstructure:"aliases"`       // 个别标的的固定映射：规范写法 -> 该处的写法，优先于格式规则
+}
+
+// Validate 验证标的代码转换配置
+func (s SymbolsConfig) Validate() error {
+	for name, venue := range s.Venues {
+		switch venue.StockFormat {
+		case "", "plain", "suffix", "prefix":
+		default:
+			return fmt.Errorf("venues.%s.stock_format 只能是 plain、suffix 或 prefix: %s", name, venue.StockFormat)
+		}
+		switch venue.CryptoFormat {
+		case "", "concat", "dash", "slash", "underscore":
+		default:
+			return fmt.Errorf("venues.%s.crypto_format 只能是 concat、dash、slash 或 underscore: %s", name, venue.CryptoFormat)
+		}
+		for canonical, alias := range venue.Aliases {
+			if strings.TrimSpace(canonical) == "" || strings.TrimSpace(alias) == "" {
+				return fmt.Errorf("venues.%s.aliases 的标的不能为空", name)
+			}
+		}
+	}
+	return nil
+}
+
+// OptionsConfig 期权规格
+type OptionsConfig struct {
+	Multiplier float64 `mapstructure:"multiplier"` // 每张合约对应的标的数量，美股期权为100
+	TickSize   float64 `mapstructure:"tick_size"`  // 权利金最小价格变动，0表示不限制
+}
+
+// Validate 验证期权规格
+func (o OptionsConfig) Validate() error {
+	if o.Multiplier <= 0 {
+		return fmt.Errorf("multiplier 必须大于0")
+	}
+	if o.TickSize < 0 {
+		return fmt.Errorf("tick_size 不能为负数")
+	}
+	return nil
+}
+
+// FuturesSpecConfig 期货品种规格
+type FuturesSpecConfig struct {
+	Multiplier float64 `mapstructure:"multiplier"`  // 合约乘数，每点价值
+	TickSize   float64 `mapstructure:"tick_size"`   // 最小价格变动，0表示不限制
+	Months     string  `mapstructure:"months"`      // 上市月份代码（F G H J K M N Q U V X Z），如季月合约为 "HMUZ"，为空表示每月
+	ExpiryRule string  `mapstructure:"expiry_rule"` // 到期日规则: third_friday / last_business_day / fixed_day，默认 third_friday
+	ExpiryDay  int     `mapstructure:"expiry_day"`  // fixed_day 规则的到期日（遇周末提前到前一个工作日）
+	RollDays   int     `mapstructure:"roll_days"`   // 到期前多少个自然日移仓到下一合约
+	Adjust     string  `mapstructure:"adjust"`      // 连续合约换月的价格调整: difference（价差后复权）/ ratio（比例后复权）/ none，默认 difference
+}
+
+// futuresMonthCodes 期货月份代码，依次对应 1-12 月
+const futuresMonthCodes = "FGHJKMNQUVXZ"
+
+// Validate 验证期货品种规格
+func (f FuturesSpecConfig) Validate() error {
+	if f.Multiplier <= 0 {
+		return fmt.Errorf("multiplier 必须大于0")
+	}
+	if f.TickSize < 0 || f.RollDays < 0 {
+		return fmt.Errorf("tick_size 和 roll_days 不能为负数")
+	}
+	for _, code := range strings.ToUpper(f.Months) {
+		if !strings.ContainsRune(futuresMonthCodes, code) {
+			return fmt.Errorf("未知的月份代码: %c（可选 %s）", code, futuresMonthCodes)
+		}
+	}
+	switch f.ExpiryRule {
+	case "", "third_friday", "last_business_day":
+	case "fixed_day":
+		if f.ExpiryDay < 1 || f.ExpiryDay > 28 {
+			return fmt.Errorf("fixed_day 规则的 expiry_day 必须在 1 到 28 之间")
+		}
+	default:
+		return fmt.Errorf("未知的到期日规则: %s（可选 third_friday、last_business_day、fixed_day）", f.ExpiryRule)
+	}
+	switch f.Adjust {
+	case "", "difference", "ratio", "none":
+	default:
+		return fmt.Errorf("未知的价格调整方式: %s（可选 difference、ratio、none）", f.Adjust)
+	}
+	return nil
+}
+
+// Validate 验证合约规格配置
+func (i InstrumentsConfig) Validate() error {
+	if i.RollCheckInterval < 0 {
+		return fmt.Errorf("roll_check_interval 不能为负数")
+	}
+	for root, spec := range i.Futures {
+		if err := spec.Validate(); err != nil {
+			return fmt.Errorf("futures.%s: %w", root, err)
+		}
+	}
+	if err := i.Options.Validate(); err != nil {
+		return fmt.Errorf("options: %w", err)
+	}
+	return nil
+}
+
+// APIConfig 控制API配置
+type APIConfig struct {
+	Enabled     bool   `mapstructure:"enabled"`      // run 命令是否同时启动控制API
+	Address     string `mapstructure:"address"`      // 监听地址
+	EventBuffer int    `mapstructure:"event_buffer"` // 每个事件流订阅者的缓冲事件数，处理过慢时丢弃
+	GRPCAddress string `mapstructure:"grpc_address"` // gRPC 监听地址，为空时只提供 HTTP/JSON
+
+	Auth APIAuthConfig `mapstructure:"auth"`
+
+	// 网页监控面板，与控制API使用同一地址
+	Dashboard DashboardConfig `mapstructure:"dashboard"`
+}
+
+// APIAuthConfig 控制API的认证：请求以 Authorization: Bearer <token> 或 X-API-Key 头携带令牌，
+// 监控面板在页面地址后附加 ?token=<token>；令牌可以写成密钥引用（见 secrets），为空时不认证且只允许监听本机地址
+type APIAuthConfig struct {
+	Token string `mapstructure:"token"`
+}
+
+// DashboardConfig 网页监控面板配置：在控制API地址的 /dashboard/ 下提供页面，通过 SSE 推送状态快照和引擎事件
+type DashboardConfig struct {
+	Enabled         bool          `mapstructure:"enabled"`
+	RefreshInterval time.Duration `mapstructure:"refresh_interval"` // 推送状态快照和采样权益的间隔
+	EquityPoints    int           `mapstructure:"equity_points"`    // 权益曲线保留的采样点数
+	RecentLimit     int           `mapstructure:"recent_limit"`     // 最近订单、成交、信号和情绪记录的条数
+}
+
+// Validate 验证监控面板配置
+func (d DashboardConfig) Validate() error {
+	if !d.Enabled {
+		return nil
+	}
+	if d.RefreshInterval < time.Second {
+		return fmt.Errorf("refresh_interval 至少为1秒")
+	}
+	if d.EquityPoints <= 0 || d.RecentLimit <= 0 {
+		return fmt.Errorf("equity_points 和 recent_limit 必须大于0")
+	}
+	return nil
 }
 
 // LoadConfig 加载配置文件
@@ -88,12 +2215,262 @@ func LoadConfig(path string) (*Config, error) {
 
 // setDefaults 设置默认配置值
 func setDefaults() {
+	viper.SetDefault("agent_service.mode", "service")
 	viper.SetDefault("agent_service.url", "http://localhost:8000")
+	viper.SetDefault("agent_service.grpc.address", "localhost:50051")
+	viper.SetDefault("agent_service.grpc.stream", false)
+	viper.SetDefault("agent_service.llm.provider", "openai")
+	viper.SetDefault("agent_service.llm.model", "gpt-4o-mini")
+	viper.SetDefault("agent_service.llm.max_tokens", 512)
+	viper.SetDefault("agent_service.llm.temperature", 0.0)
+	viper.SetDefault("agent_service.timeout", "10s")
+	viper.SetDefault("agent_service.total_timeout", "30s")
+	viper.SetDefault("agent_service.max_retries", 2)
+	viper.SetDefault("agent_service.retry_backoff", "500ms")
+	viper.SetDefault("agent_service.max_retry_backoff", "5s")
+	viper.SetDefault("agent_service.breaker_threshold", 5)
+	viper.SetDefault("agent_service.breaker_cooldown", "1m")
+	viper.SetDefault("agent_service.async.enabled", false)
+	viper.SetDefault("agent_service.async.workers", 4)
+	viper.SetDefault("agent_service.async.queue_size", 64)
+	viper.SetDefault("agent_service.async.max_wait", "2s")
+	viper.SetDefault("agent_service.async.max_age", "15m")
+	viper.SetDefault("agent_service.auth.api_key", "")
+	viper.SetDefault("agent_service.auth.bearer_token", "")
+	viper.SetDefault("agent_service.auth.signing_secret", "")
+	viper.SetDefault("agent_service.tls.enabled", false)
+	viper.SetDefault("agent_service.tls.ca_file", "")
+	viper.SetDefault("agent_service.tls.cert_file", "")
+	viper.SetDefault("agent_service.tls.key_file", "")
+	viper.SetDefault("agent_service.tls.server_name", "")
+	viper.SetDefault("agent_service.tls.insecure_skip_verify", false)
 	viper.SetDefault("logging.level", "info")
 	viper.SetDefault("logging.file", "logs/quant_system.log")
+	viper.SetDefault("logging.signals.enabled", true)
+	viper.SetDefault("logging.signals.file", "logs/signals.jsonl")
+	viper.SetDefault("logging.signals.sample_rate", 1.0)
+	viper.SetDefault("logging.signals.keep_actionable", true)
+	viper.SetDefault("logging.signals.redact_fields", []string{})
+	viper.SetDefault("logging.signals.redact_patterns", []string{
+		`(?i)(api[_-]?key|token|secret|password)\s*[:=]\s*\S+`,
+		`sk-[A-Za-z0-9_-]{16,}`,
+	})
+	viper.SetDefault("logging.signals.max_field_length", 1000)
+	viper.SetDefault("backtest.strategy", "ma_cross")
 	viper.SetDefault("backtest.initial_capital", 100000.0)
 	viper.SetDefault("backtest.commission_rate", 0.001)
 	viper.SetDefault("backtest.slippage_rate", 0.0005)
+	viper.SetDefault("backtest.max_entries", 1)
+	viper.SetDefault("backtest.slippage_model_file", "data/slippage_model.json")
+	viper.SetDefault("backtest.max_duration", "10m")
+	viper.SetDefault("data.providers", map[string]string{"stock": "mock", "crypto": "mock"})
+	viper.SetDefault("data.drain_timeout", "30s")
+	viper.SetDefault("data.sessions.stock.timezone", "America/New_York")
+	viper.SetDefault("data.sessions.stock.open", "09:30")
+	viper.SetDefault("data.sessions.stock.close", "16:00")
+	viper.SetDefault("data.sessions.crypto.timezone", "UTC")
+	viper.SetDefault("data.sessions.crypto.weekends", true)
+	viper.SetDefault("data.cache.enabled", false)
+	viper.SetDefault("data.cache.backend", "sqlite")
+	viper.SetDefault("data.cache.dir", "data/ohlcv")
+	viper.SetDefault("data.cache.refresh_recent", "24h")
+	viper.SetDefault("data.import.dir", "data/imported")
+	viper.SetDefault("data.import.timezone", "UTC")
+	viper.SetDefault("data.import.delimiter", ",")
+	viper.SetDefault("data.import.columns.timestamp", "timestamp")
+	viper.SetDefault("data.import.columns.open", "open")
+	viper.SetDefault("data.import.columns.high", "high")
+	viper.SetDefault("data.import.columns.low", "low")
+	viper.SetDefault("data.import.columns.close", "close")
+	viper.SetDefault("data.import.columns.volume", "volume")
+	viper.SetDefault("data.quality.policy", "warn")
+	viper.SetDefault("data.quality.gap_factor", 5.0)
+	viper.SetDefault("data.quality.spike_threshold", 0.25)
+	viper.SetDefault("data.quality.stale_after", "0s")
+	viper.SetDefault("backtest.max_memory_mb", 2048)
+	viper.SetDefault("backtest.max_bars", 0)
+	viper.SetDefault("backtest.checkpoint.dir", "data/checkpoints")
+	viper.SetDefault("backtest.checkpoint.interval", 10000)
+	viper.SetDefault("backtest.workers", 4)
+	viper.SetDefault("backtest.slippage.model", "")
+	viper.SetDefault("backtest.slippage.fixed_bps", 5.0)
+	viper.SetDefault("backtest.slippage.spread_bps", 10.0)
+	viper.SetDefault("backtest.slippage.range_fraction", 0.1)
+	viper.SetDefault("backtest.slippage.base_bps", 2.0)
+	viper.SetDefault("backtest.slippage.impact_coefficient", 0.02)
+	viper.SetDefault("backtest.slippage.impact_exponent", 0.5)
+	viper.SetDefault("backtest.slippage.max_bps", 100.0)
+	viper.SetDefault("backtest.report_dir", "reports")
+	viper.SetDefault("backtest.margin.leverage", 1.0)
+	viper.SetDefault("backtest.margin.maintenance_margin", 0.25)
+	viper.SetDefault("backtest.margin.interest_rate", 0.06)
+	viper.SetDefault("trading.order_concurrency", 4)
+	viper.SetDefault("trading.order_queue_size", 100)
+	viper.SetDefault("trading.monitor_interval", "30s")
+	viper.SetDefault("trading.trailing_stop_percent", 0.0)
+	viper.SetDefault("trading.journal_file", "data/trade_journal.jsonl")
+	viper.SetDefault("trading.tax_lots.method", "fifo")
+	viper.SetDefault("trading.tax_lots.long_term_days", 365)
+	viper.SetDefault("trading.audit_file", "data/audit.jsonl")
+	viper.SetDefault("trading.paper", false)
+	viper.SetDefault("trading.dry_run", false)
+	viper.SetDefault("trading.market_close", "16:00")
+	viper.SetDefault("trading.market_timezone", "America/New_York")
+	viper.SetDefault("trading.execution.order_type", "market")
+	viper.SetDefault("trading.execution.limit_offset", 0.0)
+	viper.SetDefault("trading.execution.limit_timeout", "30s")
+	viper.SetDefault("trading.approval.enabled", false)
+	viper.SetDefault("trading.approval.min_notional", 10000.0)
+	viper.SetDefault("trading.approval.timeout", "5m")
+	viper.SetDefault("trading.approval.file", "data/approvals.json")
+	viper.SetDefault("trading.approval.poll_interval", "1s")
+	viper.SetDefault("trading.promotion.enabled", false)
+	viper.SetDefault("trading.promotion.days", 30)
+	viper.SetDefault("trading.promotion.min_trades", 20)
+	viper.SetDefault("trading.promotion.min_sharpe", 1.0)
+	viper.SetDefault("trading.promotion.max_drawdown", 0.1)
+	viper.SetDefault("trading.promotion.state_file", "data/promotion.json")
+	viper.SetDefault("trading.leaderboard.enabled", false)
+	viper.SetDefault("trading.leaderboard.sample_interval", "15m")
+	viper.SetDefault("trading.leaderboard.windows", []string{"24h", "168h", "720h"})
+	viper.SetDefault("trading.leaderboard.rank_window", "168h")
+	viper.SetDefault("trading.leaderboard.rank_by", "sharpe")
+	viper.SetDefault("trading.leaderboard.state_file", "data/leaderboard.json")
+	viper.SetDefault("trading.grid.sync_interval", "30s")
+	viper.SetDefault("trading.grid.cancel_on_stop", true)
+	viper.SetDefault("trading.grid.state_file", "data/grids.json")
+	viper.SetDefault("trading.simulation.fill_ratio", 1.0)
+	viper.SetDefault("trading.order_retry.attempts", 2)
+	viper.SetDefault("trading.order_retry.backoff", "2s")
+	viper.SetDefault("trading.connection.health_interval", "30s")
+	viper.SetDefault("trading.connection.initial_backoff", "1s")
+	viper.SetDefault("trading.connection.max_backoff", "60s")
+	viper.SetDefault("trading.connection.outage_policy", "reject")
+	viper.SetDefault("trading.connection.queue_timeout", "60s")
+	viper.SetDefault("trading.reconciliation.enabled", true)
+	viper.SetDefault("trading.reconciliation.interval", "5m")
+	viper.SetDefault("trading.reconciliation.auto_correct", false)
+	viper.SetDefault("trading.reconciliation.balance_tolerance", 0.01)
+	viper.SetDefault("trading.reconciliation.quantity_tolerance", 0.0)
+	viper.SetDefault("trading.snapshot.enabled", false)
+	viper.SetDefault("trading.snapshot.interval", "15m")
+	viper.SetDefault("trading.snapshot.format", "json")
+	viper.SetDefault("trading.snapshot.dir", "data/snapshots")
+	viper.SetDefault("trading.snapshot.timeout", "10s")
+	viper.SetDefault("trading.throttle.enabled", false)
+	viper.SetDefault("trading.throttle.max_orders_per_symbol", 3)
+	viper.SetDefault("trading.throttle.symbol_window", "1m")
+	viper.SetDefault("trading.throttle.max_orders_per_account", 20)
+	viper.SetDefault("trading.throttle.account_window", "1m")
+	viper.SetDefault("trading.throttle.max_notional_per_window", 0.0)
+	viper.SetDefault("trading.throttle.notional_window", "1h")
+	viper.SetDefault("engine.overrun_policy", "skip")
+	viper.SetDefault("engine.history_file", "data/cycles.jsonl")
+	viper.SetDefault("engine.guidance_file", "data/guidance.jsonl")
+	viper.SetDefault("engine.reload.enabled", false)
+	viper.SetDefault("engine.reload.interval", "5s")
+	viper.SetDefault("engine.replay.output_dir", "data/replay")
+	viper.SetDefault("engine.replay.agent", "mock")
+	viper.SetDefault("engine.replay.step", "0s")
+	viper.SetDefault("engine.replay.delay", "0s")
+	viper.SetDefault("engine.replay.warmup", "1440h")
+	viper.SetDefault("degradation.data", "cache")
+	viper.SetDefault("degradation.data_max_age", "1h")
+	viper.SetDefault("degradation.agent", "neutral")
+	viper.SetDefault("degradation.agent_max_age", "2h")
+	viper.SetDefault("degradation.news", "mock")
+	viper.SetDefault("degradation.broker", "halt")
+	viper.SetDefault("degradation.queue_max_age", "5m")
+	viper.SetDefault("notifications.enabled", false)
+	viper.SetDefault("notifications.events", []string{"trade", "risk", "cycle_failed", "broker", "backtest", "approval", "dependency", "promotion", "reconcile", "halt", "config", "strategy"})
+	viper.SetDefault("notifications.queue_size", 100)
+	viper.SetDefault("notifications.email.port", 587)
+	viper.SetDefault("fx.reporting_currency", "USD")
+	viper.SetDefault("fx.source", "static")
+	viper.SetDefault("fx.cache_ttl", "1h")
+	viper.SetDefault("news.enabled", false)
+	viper.SetDefault("news.max_age", "24h")
+	viper.SetDefault("news.max_items", 20)
+	viper.SetDefault("news.request_timeout", "10s")
+	viper.SetDefault("news.discovery.enabled", false)
+	viper.SetDefault("news.discovery.min_mentions", 2)
+	viper.SetDefault("news.discovery.max_symbols", 5)
+	viper.SetDefault("news.discovery.add_to_scanner", false)
+	viper.SetDefault("news.discovery.probation", "24h")
+	viper.SetDefault("news.relevance.enabled", false)
+	viper.SetDefault("news.relevance.min_score", 1.0)
+	viper.SetDefault("news.relevance.similarity_threshold", 0.8)
+	viper.SetDefault("strategy.plugin_dir", "")
+	viper.SetDefault("strategy.active", []string{"ma_cross"})
+	viper.SetDefault("strategy.incremental", []string{})
+	viper.SetDefault("strategy.ensemble.enabled", false)
+	viper.SetDefault("strategy.ensemble.name", "ensemble")
+	viper.SetDefault("strategy.ensemble.method", "weighted")
+	viper.SetDefault("strategy.ensemble.threshold", 0.3)
+	viper.SetDefault("strategy.guidance.enabled", true)
+	viper.SetDefault("strategy.guidance.weight", 0.1)
+	viper.SetDefault("strategy.guidance.scale_by_confidence", false)
+	viper.SetDefault("strategy.guidance.min_confidence", 0.0)
+	viper.SetDefault("strategy.guidance.veto_confidence", 0.0)
+	viper.SetDefault("api.enabled", false)
+	viper.SetDefault("api.address", "127.0.0.1:9090")
+	viper.SetDefault("api.event_buffer", 100)
+	viper.SetDefault("api.grpc_address", "127.0.0.1:9091")
+	viper.SetDefault("api.auth.token", "")
+	viper.SetDefault("api.dashboard.enabled", true)
+	viper.SetDefault("api.dashboard.refresh_interval", "5s")
+	viper.SetDefault("api.dashboard.equity_points", 720)
+	viper.SetDefault("api.dashboard.recent_limit", 50)
+	viper.SetDefault("scanner.watchlist", []string{"AAPL"})
+	viper.SetDefault("scanner.enabled", false)
+	viper.SetDefault("scanner.lookback_days", 5)
+	viper.SetDefault("scanner.max_promoted", 5)
+	viper.SetDefault("instruments.roll_check_interval", "1h")
+	viper.SetDefault("instruments.options.multiplier", 100.0)
+	viper.SetDefault("instruments.options.tick_size", 0.01)
+	viper.SetDefault("portfolio.enabled", false)
+	viper.SetDefault("portfolio.method", "risk_parity")
+	viper.SetDefault("portfolio.lookback_days", 60)
+	viper.SetDefault("portfolio.risk_aversion", 3.0)
+	viper.SetDefault("portfolio.max_weight", 0.4)
+	viper.SetDefault("portfolio.budget", 0.9)
+	viper.SetDefault("portfolio.rebalance_interval", "24h")
+	viper.SetDefault("portfolio.rebalance_threshold", 0.02)
+	viper.SetDefault("portfolio.min_trade_value", 100.0)
+	viper.SetDefault("portfolio.correlation.enabled", false)
+	viper.SetDefault("portfolio.correlation.lookback_days", 90)
+	viper.SetDefault("portfolio.correlation.window", 30)
+	viper.SetDefault("portfolio.correlation.max_pair_correlation", 0.8)
+	viper.SetDefault("portfolio.correlation.max_portfolio_correlation", 0.6)
+	viper.SetDefault("portfolio.correlation.max_concentration", 0.5)
+	viper.SetDefault("portfolio.correlation.action", "downsize")
+	viper.SetDefault("portfolio.correlation.min_scale", 0.25)
+	viper.SetDefault("secrets.cache_ttl", "5m")
+	viper.SetDefault("secrets.vault.mount", "secret")
+	viper.SetDefault("secrets.vault.timeout", "10s")
+	viper.SetDefault("secrets.aws.timeout", "10s")
+	viper.SetDefault("risk.enabled", true)
+	viper.SetDefault("risk.preset", "")
+	viper.SetDefault("risk.max_position_size", 0.1)
+	viper.SetDefault("risk.max_total_exposure", 1.0)
+	viper.SetDefault("risk.max_daily_loss", 0.05)
+	viper.SetDefault("risk.max_drawdown", 0.2)
+	viper.SetDefault("risk.resize_orders", true)
+	viper.SetDefault("risk.max_margin_utilization", 0.9)
+	viper.SetDefault("risk.sizing.method", "")
+	viper.SetDefault("risk.sizing.kelly_fraction", 0.5)
+	viper.SetDefault("risk.sizing.max_fraction", 0.25)
+	viper.SetDefault("risk.sizing.win_rate", 0.5)
+	viper.SetDefault("risk.sizing.payoff_ratio", 1.0)
+	viper.SetDefault("risk.sizing.min_trades", 20)
+	viper.SetDefault("risk.kill_switch.enabled", true)
+	viper.SetDefault("risk.kill_switch.max_daily_loss", 0.1)
+	viper.SetDefault("risk.kill_switch.max_drawdown", 0.3)
+	viper.SetDefault("risk.kill_switch.max_failed_cycles", 5)
+	viper.SetDefault("risk.kill_switch.state_file", "data/kill_switch.json")
+	viper.SetDefault("risk.strategy_supervisor.enabled", false)
+	viper.SetDefault("risk.strategy_supervisor.max_consecutive_losses", 5)
+	viper.SetDefault("risk.strategy_supervisor.state_file", "data/strategy_supervisor.json")
 }
 
 // overrideFromEnv 从环境变量覆盖敏感配置
@@ -101,10 +2478,35 @@ func overrideFromEnv(config *Config) {
 	if openaiKey := os.Getenv("OPENAI_API_KEY"); openaiKey != "" {
 		config.APIKeys.OpenAIKey = openaiKey
 	}
+	if anthropicKey := os.Getenv("ANTHROPIC_API_KEY"); anthropicKey != "" {
+		config.APIKeys.AnthropicKey = anthropicKey
+	}
+	if newsAPIKey := os.Getenv("NEWSAPI_KEY"); newsAPIKey != "" {
+		config.APIKeys.NewsAPIKey = newsAPIKey
+	}
+	if finnhubKey := os.Getenv("FINNHUB_API_KEY"); finnhubKey != "" {
+		config.APIKeys.FinnhubKey = finnhubKey
+	}
 
 	// 可以添加更多环境变量覆盖逻辑
 }
 
+// LLMKey 当前大模型服务商的API密钥
+func (c *Config) LLMKey() string {
+	if c.AgentService.LLM.Provider == "anthropic" {
+		return c.APIKeys.AnthropicKey
+	}
+	return c.APIKeys.OpenAIKey
+}
+
+// LLMKeyName 当前大模型服务商的密钥在 api_keys 中的配置项
+func (c *Config) LLMKeyName() string {
+	if c.AgentService.LLM.Provider == "anthropic" {
+		return "anthropic_key"
+	}
+	return "openai_key"
+}
+
 // GetAccountConfig 获取指定账户的配置
 func (c *Config) GetAccountConfig(accountName string) (*AccountConfig, error) {
 	account, exists := c.Accounts[accountName]
@@ -114,28 +2516,277 @@ func (c *Config) GetAccountConfig(accountName string) (*AccountConfig, error) {
 	return &account, nil
 }
 
+// CommissionFor 账户使用的佣金模型配置：账户配置优先，其次是该经纪商类型的配置，都未配置时 Model 为空
+func (c *Config) CommissionFor(accountName string) CommissionConfig {
+	account, exists := c.Accounts[accountName]
+	if !exists {
+		return CommissionConfig{}
+	}
+	if account.Commission.Model != "" {
+		return account.Commission
+	}
+	return c.Trading.Commissions[account.BrokerType]
+}
+
 // Validate 验证配置的有效性
 func (c *Config) Validate() error {
-	if c.AgentService.URL == "" {
-		return fmt.Errorf("agent_service.url 不能为空")
+	if err := c.AgentService.Validate(); err != nil {
+		return fmt.Errorf("agent_service 配置无效: %w", err)
 	}
-
-	if c.APIKeys.OpenAIKey == "" {
-		return fmt.Errorf("openai_key 不能为空")
+	// 只有在Go中直接调用大模型时需要密钥，且只需要所选服务商的密钥
+	if c.AgentService.Mode == "llm" && c.LLMKey() == "" {
+		return fmt.Errorf("agent_service.llm.provider 为 %s 时 api_keys.%s 不能为空",
+			c.AgentService.LLM.Provider, c.LLMKeyName())
 	}
 
 	if len(c.Accounts) == 0 {
 		return fmt.Errorf("至少需要配置一个账户")
 	}
 
-	for name, account := range c.Accounts {
-		if account.APIKey == "" || account.APISecret == "" {
-			return fmt.Errorf("账户 '%s' 的 API 密钥不能为空", name)
+	if err := c.Logging.Signals.Validate(); err != nil {
+		return fmt.Errorf("logging.signals 配置无效: %w", err)
+	}
+	if err := c.Data.Validate(); err != nil {
+		return fmt.Errorf("data 配置无效: %w", err)
+	}
+	if err := c.Backtest.Validate(); err != nil {
+		return fmt.Errorf("backtest 配置无效: %w", err)
+	}
+	if err := c.Trading.Execution.Validate(); err != nil {
+		return fmt.Errorf("trading.execution 配置无效: %w", err)
+	}
+	if err := c.Trading.TaxLots.Validate(); err != nil {
+		return fmt.Errorf("trading.tax_lots 配置无效: %w", err)
+	}
+	for brokerType, commission := range c.Trading.Commissions {
+		if err := commission.Validate(); err != nil {
+			return fmt.Errorf("trading.commissions.%s 配置无效: %w", brokerType, err)
+		}
+	}
+	for name, execution := range c.Trading.StrategyExecution {
+		if err := execution.Validate(); err != nil {
+			return fmt.Errorf("trading.strategy_execution.%s 配置无效: %w", name, err)
+		}
+	}
+	if _, err := time.Parse("15:04", c.Trading.MarketClose); err != nil {
+		return fmt.Errorf("trading.market_close 格式无效，应为 HH:MM: %w", err)
+	}
+	if _, err := time.LoadLocation(c.Trading.MarketTimezone); err != nil {
+		return fmt.Errorf("trading.market_timezone 无效: %w", err)
+	}
+	if err := c.Trading.Approval.Validate(); err != nil {
+		return fmt.Errorf("trading.approval 配置无效: %w", err)
+	}
+	if err := c.Trading.Throttle.Validate(); err != nil {
+		return fmt.Errorf("trading.throttle 配置无效: %w", err)
+	}
+	if err := c.Trading.Promotion.Validate(); err != nil {
+		return fmt.Errorf("trading.promotion 配置无效: %w", err)
+	}
+	if err := c.Trading.Reconciliation.Validate(); err != nil {
+		return fmt.Errorf("trading.reconciliation 配置无效: %w", err)
+	}
+	if err := c.Trading.Snapshot.Validate(); err != nil {
+		return fmt.Errorf("trading.snapshot 配置无效: %w", err)
+	}
+	if err := c.Trading.Leaderboard.Validate(); err != nil {
+		return fmt.Errorf("trading.leaderboard 配置无效: %w", err)
+	}
+	if err := c.Trading.Grid.Validate(); err != nil {
+		return fmt.Errorf("trading.grid 配置无效: %w", err)
+	}
+	if ratio := c.Trading.Simulation.FillRatio; ratio <= 0 || ratio > 1 {
+		return fmt.Errorf("trading.simulation.fill_ratio 必须在 (0, 1] 之间")
+	}
+	if c.Trading.OrderRetry.Attempts < 1 {
+		return fmt.Errorf("trading.order_retry.attempts 至少为1")
+	}
+	if c.Trading.OrderRetry.Backoff < 0 {
+		return fmt.Errorf("trading.order_retry.backoff 不能为负数")
+	}
+	if err := c.Trading.Connection.Validate(); err != nil {
+		return fmt.Errorf("trading.connection 配置无效: %w", err)
+	}
+	if err := c.Risk.KillSwitch.Validate(); err != nil {
+		return fmt.Errorf("risk.kill_switch 配置无效: %w", err)
+	}
+	if err := c.Risk.StrategySupervisor.Validate(); err != nil {
+		return fmt.Errorf("risk.strategy_supervisor 配置无效: %w", err)
+	}
+	if err := c.Notifications.Validate(); err != nil {
+		return fmt.Errorf("notifications 配置无效: %w", err)
+	}
+	if err := c.Portfolio.Validate(); err != nil {
+		return fmt.Errorf("portfolio 配置无效: %w", err)
+	}
+
+	if err := c.Instruments.Validate(); err != nil {
+		return fmt.Errorf("instruments 配置无效: %w", err)
+	}
+	if err := c.Symbols.Validate(); err != nil {
+		return fmt.Errorf("symbols 配置无效: %w", err)
+	}
+	if err := c.Secrets.Validate(); err != nil {
+		return fmt.Errorf("secrets 配置无效: %w", err)
+	}
+
+	if len(c.Strategy.Active) == 0 {
+		return fmt.Errorf("strategy.active 至少需要一个策略")
+	}
+	if err := c.Strategy.validateAllocations(c.Accounts); err != nil {
+		return fmt.Errorf("strategy.allocations 配置无效: %w", err)
+	}
+	if err := c.Strategy.Ensemble.Validate(); err != nil {
+		return fmt.Errorf("strategy.ensemble 配置无效: %w", err)
+	}
+	if err := c.Strategy.Guidance.Validate(); err != nil {
+		return fmt.Errorf("strategy.guidance 配置无效: %w", err)
+	}
+	for name, composite := range c.Strategy.Composites {
+		if err := composite.Validate(); err != nil {
+			return fmt.Errorf("strategy.composites.%s 配置无效: %w", name, err)
+		}
+		if c.Strategy.Ensemble.Enabled && name == c.Strategy.Ensemble.Name {
+			return fmt.Errorf("组合策略 %s 与 strategy.ensemble 同名", name)
+		}
+	}
+	if c.Strategy.Ensemble.Enabled {
+		for _, name := range c.Strategy.Ensemble.Strategies {
+			if !slices.Contains(c.Strategy.Active, name) {
+				return fmt.Errorf("strategy.ensemble 的成员策略 %s 不在 strategy.active 中", name)
+			}
+		}
+	}
+
+	if c.API.Enabled && c.API.Address == "" {
+		return fmt.Errorf("api.address 不能为空")
+	}
+	if c.API.GRPCAddress != "" && c.API.GRPCAddress == c.API.Address {
+		return fmt.Errorf("api.grpc_address 不能与 api.address 相同")
+	}
+	if err := c.API.Dashboard.Validate(); err != nil {
+		return fmt.Errorf("api.dashboard 配置无效: %w", err)
+	}
+
+	if c.News.Discovery.Enabled && c.News.Discovery.MinMentions <= 0 {
+		return fmt.Errorf("news.discovery.min_mentions 必须大于0")
+	}
+	if err := c.News.Relevance.Validate(); err != nil {
+		return fmt.Errorf("news.relevance 配置无效: %w", err)
+	}
+
+	switch c.Engine.OverrunPolicy {
+	case "", "skip", "coalesce":
+	default:
+		return fmt.Errorf("engine.overrun_policy 只能是 skip 或 coalesce")
+	}
+	if c.Engine.Reload.Enabled && c.Engine.Reload.Interval <= 0 {
+		return fmt.Errorf("engine.reload.interval 必须大于0")
+	}
+	if err := c.Engine.Replay.Validate(); err != nil {
+		return fmt.Errorf("engine.replay 配置无效: %w", err)
+	}
+
+	if err := c.Degradation.Validate(); err != nil {
+		return fmt.Errorf("degradation 配置无效: %w", err)
+	}
+
+	for name := range c.Risk.AccountSymbols {
+		if _, exists := c.Accounts[name]; !exists {
+			return fmt.Errorf("risk.account_symbols 中的账户 '%s' 不存在", name)
+		}
+	}
+
+	if _, ok := RiskPresets[c.Risk.Preset]; c.Risk.Preset != "" && !ok {
+		return fmt.Errorf("risk.preset 只能是 conservative、normal 或 aggressive")
+	}
+	if c.Risk.Enabled && c.Risk.Preset == "" {
+		if c.Risk.MaxPositionSize <= 0 || c.Risk.MaxTotalExposure <= 0 {
+			return fmt.Errorf("risk.max_position_size 和 risk.max_total_exposure 必须大于0")
+		}
+		if c.Risk.MaxDailyLoss < 0 || c.Risk.MaxDrawdown < 0 {
+			return fmt.Errorf("risk.max_daily_loss 和 risk.max_drawdown 不能为负数")
+		}
+	}
+	if c.Risk.MaxMarginUtilization < 0 || c.Risk.MaxMarginUtilization > 1 {
+		return fmt.Errorf("risk.max_margin_utilization 必须在 0 到 1 之间")
+	}
+	if err := c.Risk.Exposure.Validate(); err != nil {
+		return fmt.Errorf("risk.exposure 配置无效: %w", err)
+	}
+	if err := c.Risk.Sizing.Validate(); err != nil {
+		return fmt.Errorf("risk.sizing 配置无效: %w", err)
+	}
+	for name, sizing := range c.Strategy.Sizing {
+		if err := sizing.Validate(); err != nil {
+			return fmt.Errorf("strategy.sizing.%s 配置无效: %w", name, err)
 		}
+	}
+
+	for name, account := range c.Accounts {
 		if account.BrokerType == "" {
 			return fmt.Errorf("账户 '%s' 的经纪商类型不能为空", name)
 		}
+		if err := account.RateLimit.Validate(); err != nil {
+			return fmt.Errorf("账户 '%s' 的 rate_limit 配置无效: %w", name, err)
+		}
+		if err := account.Commission.Validate(); err != nil {
+			return fmt.Errorf("账户 '%s' 的 commission 配置无效: %w", name, err)
+		}
+		if err := account.Margin.Validate(); err != nil {
+			return fmt.Errorf("账户 '%s' 的 margin 配置无效: %w", name, err)
+		}
+		// 盈透证券通过网关会话认证，不使用 API 密钥
+		if account.BrokerType == "ibkr" {
+			if account.IBKR.AccountID == "" {
+				return fmt.Errorf("账户 '%s' 的 ibkr.account_id 不能为空", name)
+			}
+			continue
+		}
+		if account.BrokerType == "coinbase" {
+			if err := account.Coinbase.Validate(); err != nil {
+				return fmt.Errorf("账户 '%s' 的 coinbase 配置无效: %w", name, err)
+			}
+		}
+		if account.BrokerType == "bybit" {
+			if err := account.Bybit.Validate(); err != nil {
+				return fmt.Errorf("账户 '%s' 的 bybit 配置无效: %w", name, err)
+			}
+		}
+		if account.BrokerType == "ctp" {
+			if err := account.CTP.Validate(); err != nil {
+				return fmt.Errorf("账户 '%s' 的 ctp 配置无效: %w", name, err)
+			}
+		}
+		// FIX 会话按 CompID 认证，用户名和密码可选
+		if account.BrokerType == "fix" {
+			if err := account.FIX.Validate(); err != nil {
+				return fmt.Errorf("账户 '%s' 的 fix 配置无效: %w", name, err)
+			}
+			continue
+		}
+		if account.APIKey == "" || account.APISecret == "" {
+			return fmt.Errorf("账户 '%s' 的 API 密钥不能为空", name)
+		}
 	}
 
 	return nil
 }
+
+// Validate 验证下单方式配置
+func (e ExecutionConfig) Validate() error {
+	switch e.OrderType {
+	case "", "market", "limit":
+	default:
+		return fmt.Errorf("不支持的下单类型: %s", e.OrderType)
+	}
+	if e.LimitOffset < 0 || e.LimitOffset >= 1 {
+		return fmt.Errorf("limit_offset 必须在 [0, 1) 范围内")
+	}
+	switch e.TimeInForce {
+	case "", "gtc", "day", "ioc", "fok":
+	default:
+		return fmt.Errorf("不支持的订单有效期: %s", e.TimeInForce)
+	}
+	return nil
+}
@@ -0,0 +1,188 @@
+package core
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"agent-quant-system/internal/agent"
+	"agent-quant-system/internal/config"
+)
+
+// analysisJob 提交到分析队列的一次Agent分析
+type analysisJob struct {
+	client    agent.ClientInterface
+	symbol    string
+	news      []string
+	submitted time.Time
+	done      chan struct{} // 分析完成（成功或失败）后关闭
+}
+
+// completedAnalysis 标的最近一次成功的分析，按提交时间判断新鲜度
+type completedAnalysis struct {
+	analysis  *agent.AnalysisResponse
+	submitted time.Time
+}
+
+// failedAnalysis 标的最近一次失败的分析
+type failedAnalysis struct {
+	err       error
+	submitted time.Time
+}
+
+// analysisPool 异步Agent分析：请求提交到有界队列，由固定数量的工作协程调用Agent，
+// 交易循环只等待 max_wait，之后使用各标的最近完成且未超过 max_age 的分析
+type analysisPool struct {
+	config   config.AsyncAnalysisConfig
+	queue    chan *analysisJob
+	stopChan chan struct{}
+
+	pending map[string]*analysisJob // 每个标的最多一个排队或执行中的分析
+	latest  map[string]completedAnalysis
+	failed  map[string]failedAnalysis
+	mutex   sync.Mutex
+
+	// onResult 分析完成后的回调，用于更新依赖状态
+	onResult func(symbol string, analysis *agent.AnalysisResponse, err error)
+}
+
+// newAnalysisPool 创建分析队列，工作协程在 start 后运行
+func newAnalysisPool(cfg config.AsyncAnalysisConfig, onResult func(string, *agent.AnalysisResponse, error)) *analysisPool {
+	return &analysisPool{
+		config:   cfg,
+		queue:    make(chan *analysisJob, cfg.QueueSize),
+		pending:  make(map[string]*analysisJob),
+		latest:   make(map[string]completedAnalysis),
+		failed:   make(map[string]failedAnalysis),
+		onResult: onResult,
+	}
+}
+
+// start 启动工作协程
+func (p *analysisPool) start() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.stopChan != nil {
+		return
+	}
+	p.stopChan = make(chan struct{})
+	for i := 0; i < p.config.Workers; i++ {
+		go p.work(p.stopChan)
+	}
+	log.Printf("异步Agent分析已启动: 工作协程=%d, 队列=%d", p.config.Workers, p.config.QueueSize)
+}
+
+// stop 停止工作协程，执行中的分析完成后退出，排队的请求保留到下次启动
+func (p *analysisPool) stop() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.stopChan != nil {
+		close(p.stopChan)
+		p.stopChan = nil
+	}
+}
+
+// work 依次处理队列中的分析请求
+func (p *analysisPool) work(stopChan chan struct{}) {
+	for {
+		select {
+		case <-stopChan:
+			return
+		case job := <-p.queue:
+			analysis, err := job.client.AnalyzeNews(job.symbol, job.news)
+			p.complete(job, analysis, err)
+		}
+	}
+}
+
+// complete 保存分析结果并唤醒等待的交易循环
+func (p *analysisPool) complete(job *analysisJob, analysis *agent.AnalysisResponse, err error) {
+	p.mutex.Lock()
+	if p.pending[job.symbol] == job {
+		delete(p.pending, job.symbol)
+	}
+	if err != nil {
+		p.failed[job.symbol] = failedAnalysis{err: err, submitted: job.submitted}
+	} else if job.submitted.After(p.latest[job.symbol].submitted) {
+		p.latest[job.symbol] = completedAnalysis{analysis: analysis, submitted: job.submitted}
+		delete(p.failed, job.symbol)
+	}
+	p.mutex.Unlock()
+
+	close(job.done)
+	if p.onResult != nil {
+		p.onResult(job.symbol, analysis, err)
+	}
+}
+
+// submit 提交标的的分析请求并返回对应的分析；该标的已有排队或执行中的分析时不重复提交，
+// 队列已满时不提交并返回 nil
+func (p *analysisPool) submit(client agent.ClientInterface, symbol string, news []string) *analysisJob {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if job, exists := p.pending[symbol]; exists {
+		return job
+	}
+	job := &analysisJob{client: client, symbol: symbol, news: news, submitted: time.Now(), done: make(chan struct{})}
+	select {
+	case p.queue <- job:
+		p.pending[symbol] = job
+		return job
+	default:
+		log.Printf("Agent分析队列已满(%d)，本轮不提交 %s 的分析", p.config.QueueSize, symbol)
+		return nil
+	}
+}
+
+// wait 等待分析完成，最多等待 timeout
+func (job *analysisJob) wait(timeout time.Duration) bool {
+	if job == nil || timeout <= 0 {
+		return false
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-job.done:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+// result 返回标的最近完成且提交时间未超过 max_age 的分析；没有可用的分析但最近一次分析失败时返回该错误
+func (p *analysisPool) result(symbol string) (*completedAnalysis, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	cutoff := time.Now().Add(-p.config.MaxAge)
+	if latest, ok := p.latest[symbol]; ok && latest.submitted.After(cutoff) {
+		return &latest, nil
+	}
+	if failed, ok := p.failed[symbol]; ok && failed.submitted.After(cutoff) {
+		return nil, failed.err
+	}
+	return nil, nil
+}
+
+// analyzeAsync 提交分析后最多等待 max_wait，使用最近完成的分析；最近一次分析失败时按降级配置处理，
+// 还没有完成的分析时按中性处理，交易循环不因Agent延迟而阻塞
+func (qe *QuantEngine) analyzeAsync(symbol string, newsItems []string) (*agent.AnalysisResponse, error) {
+	job := qe.analysisPool.submit(qe.agentClient, symbol, newsItems)
+	if job != nil && !job.wait(qe.analysisPool.config.MaxWait) {
+		log.Printf("%s 的Agent分析在 %v 内未完成，使用最近完成的分析", symbol, qe.analysisPool.config.MaxWait)
+	}
+
+	completed, err := qe.analysisPool.result(symbol)
+	switch {
+	case completed != nil:
+		if age := time.Since(completed.submitted); age > time.Second {
+			log.Printf("使用 %v 前提交的Agent分析: %s", age.Round(time.Second), symbol)
+		}
+		return completed.analysis, nil
+	case err != nil:
+		return qe.degradedAnalysis(symbol, newsItems, err)
+	default:
+		return neutralAnalysis(symbol, "Agent分析尚未完成，按中性处理"), nil
+	}
+}
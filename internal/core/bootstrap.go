@@ -0,0 +1,174 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// BootstrapStatus 初始化步骤的结果
+type BootstrapStatus string
+
+const (
+	BootstrapOK      BootstrapStatus = "ok"
+	BootstrapWarning BootstrapStatus = "warning" // 可以运行，但部分功能会降级
+	BootstrapFailed  BootstrapStatus = "failed"  // 需要处理后才能运行
+	BootstrapSkipped BootstrapStatus = "skipped"
+)
+
+// BootstrapStep 单个初始化步骤
+type BootstrapStep struct {
+	Name     string          `json:"name"`
+	Status   BootstrapStatus `json:"status"`
+	Detail   string          `json:"detail"`
+	Duration time.Duration   `json:"duration"`
+}
+
+// BootstrapReport 首次部署的初始化结果，没有失败的步骤时视为就绪
+type BootstrapReport struct {
+	Time  time.Time       `json:"time"`
+	Steps []BootstrapStep `json:"steps"`
+	Ready bool            `json:"ready"`
+}
+
+// Bootstrap 首次部署初始化：创建本地存储目录、预热观察列表的行情、以只读请求检查经纪商凭证并检查Agent服务
+func (qe *QuantEngine) Bootstrap() *BootstrapReport {
+	report := &BootstrapReport{Time: time.Now(), Ready: true}
+	run := func(name string, step func() (BootstrapStatus, string)) {
+		start := time.Now()
+		status, detail := step()
+		report.Steps = append(report.Steps, BootstrapStep{Name: name, Status: status, Detail: detail, Duration: time.Since(start)})
+		if status == BootstrapFailed {
+			report.Ready = false
+		}
+	}
+
+	run("数据库", func() (BootstrapStatus, string) {
+		return BootstrapSkipped, "当前版本不使用 database 配置，运行状态保存在下面的本地文件中"
+	})
+	run("本地存储", qe.bootstrapStorage)
+	run("行情预热", qe.bootstrapMarketData)
+	run("经纪商凭证", qe.bootstrapBrokers)
+	run("Agent服务", func() (BootstrapStatus, string) {
+		if err := qe.agentClient.HealthCheck(); err != nil {
+			return BootstrapWarning, fmt.Sprintf("%v（运行时按 degradation.agent=%s 降级）", err, qe.currentConfig().Degradation.Agent)
+		}
+		return BootstrapOK, "连接正常"
+	})
+	return report
+}
+
+// bootstrapStorage 创建配置中各状态文件的目录并检查可写
+func (qe *QuantEngine) bootstrapStorage() (BootstrapStatus, string) {
+	cfg := qe.currentConfig()
+	dirs := make(map[string]bool)
+	for _, file := range []string{
+		cfg.Engine.HistoryFile,
+		cfg.Engine.GuidanceFile,
+		cfg.Trading.JournalFile,
+		cfg.Trading.AuditFile,
+		cfg.Trading.Approval.File,
+		cfg.Trading.Promotion.StateFile,
+		cfg.Trading.Leaderboard.StateFile,
+		cfg.Risk.KillSwitch.StateFile,
+		cfg.Risk.StrategySupervisor.StateFile,
+		cfg.Backtest.SlippageModelFile,
+		cfg.Logging.File,
+		cfg.Logging.Signals.File,
+	} {
+		if file != "" {
+			dirs[filepath.Dir(file)] = true
+		}
+	}
+	if cfg.Trading.Snapshot.Dir != "" {
+		dirs[cfg.Trading.Snapshot.Dir] = true
+	}
+	if cfg.Data.Cache.Enabled && cfg.Data.Cache.Dir != "" {
+		dirs[cfg.Data.Cache.Dir] = true
+	}
+	if cfg.Data.Import.Dir != "" {
+		dirs[cfg.Data.Import.Dir] = true
+	}
+	if cfg.Backtest.Checkpoint.Dir != "" {
+		dirs[cfg.Backtest.Checkpoint.Dir] = true
+	}
+	if cfg.Backtest.ReportDir != "" {
+		dirs[cfg.Backtest.ReportDir] = true
+	}
+
+	sorted := make([]string, 0, len(dirs))
+	for dir := range dirs {
+		sorted = append(sorted, dir)
+	}
+	sort.Strings(sorted)
+
+	for _, dir := range sorted {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return BootstrapFailed, fmt.Sprintf("创建目录 %s 失败: %v", dir, err)
+		}
+		probe, err := os.CreateTemp(dir, ".bootstrap-*")
+		if err != nil {
+			return BootstrapFailed, fmt.Sprintf("目录 %s 不可写: %v", dir, err)
+		}
+		probe.Close()
+		os.Remove(probe.Name())
+	}
+	return BootstrapOK, "已创建并检查目录: " + strings.Join(sorted, ", ")
+}
+
+// bootstrapMarketData 获取观察列表中每个标的的近期行情
+func (qe *QuantEngine) bootstrapMarketData() (BootstrapStatus, string) {
+	qe.refreshWatchlist()
+	symbols := qe.watchlist.Symbols()
+	if len(symbols) == 0 {
+		return BootstrapFailed, "观察列表为空"
+	}
+
+	var failed []string
+	bars := 0
+	for _, symbol := range symbols {
+		df, err := qe.getMarketData(symbol)
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", symbol, err))
+			continue
+		}
+		if len(df["close"]) == 0 {
+			failed = append(failed, fmt.Sprintf("%s: 没有行情数据", symbol))
+			continue
+		}
+		bars += len(df["close"])
+	}
+
+	detail := fmt.Sprintf("%d/%d 个标的获取成功，共 %d 根K线", len(symbols)-len(failed), len(symbols), bars)
+	switch {
+	case len(failed) == len(symbols):
+		return BootstrapFailed, detail + "; " + strings.Join(failed, "; ")
+	case len(failed) > 0:
+		return BootstrapWarning, detail + "; " + strings.Join(failed, "; ")
+	default:
+		return BootstrapOK, detail
+	}
+}
+
+// bootstrapBrokers 以只读请求检查每个账户的经纪商连接和凭证
+func (qe *QuantEngine) bootstrapBrokers() (BootstrapStatus, string) {
+	checks := qe.tradingEngine.VerifyBrokers()
+	if len(checks) == 0 {
+		return BootstrapFailed, "没有配置账户"
+	}
+
+	var lines []string
+	status := BootstrapOK
+	for _, check := range checks {
+		if check.Error != "" {
+			status = BootstrapFailed
+			lines = append(lines, fmt.Sprintf("%s (%s): %s", check.Account, check.BrokerType, check.Error))
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("%s (%s): 余额 %s, 持仓 %d 个", check.Account, check.BrokerType, check.Balance.StringFixed(2), check.Positions))
+	}
+	return status, strings.Join(lines, "; ")
+}
@@ -0,0 +1,54 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+
+	"agent-quant-system/internal/config"
+	"agent-quant-system/internal/strategy"
+)
+
+// registerComposites 按名称顺序注册 strategy.composites 中声明的组合策略
+func registerComposites(manager *strategy.StrategyManager, composites map[string]config.CompositeConfig) error {
+	names := make([]string, 0, len(composites))
+	for name := range composites {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if err := manager.RegisterComposite(name, compositeSpec(composites[name])); err != nil {
+			return fmt.Errorf("注册组合策略 %s 失败: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// guidancePolicy 将情绪调整配置转换为策略管理器的调整方式
+func guidancePolicy(c config.GuidanceConfig) strategy.GuidancePolicy {
+	return strategy.GuidancePolicy{
+		Enabled:           c.Enabled,
+		Weight:            c.Weight,
+		Weights:           c.Weights,
+		ScaleByConfidence: c.ScaleByConfidence,
+		MinConfidence:     c.MinConfidence,
+		VetoConfidence:    c.VetoConfidence,
+	}
+}
+
+// compositeSpec 将组合策略配置转换为策略声明
+func compositeSpec(c config.CompositeConfig) strategy.CompositeSpec {
+	component := func(cc config.ComponentConfig) strategy.ComponentSpec {
+		return strategy.ComponentSpec{Type: cc.Type, Params: cc.Params}
+	}
+	spec := strategy.CompositeSpec{
+		Description: c.Description,
+		Entry:       component(c.Entry),
+		Exit:        component(c.Exit),
+		Sizer:       component(c.Sizer),
+	}
+	for _, filter := range c.Filters {
+		spec.Filters = append(spec.Filters, component(filter))
+	}
+	return spec
+}
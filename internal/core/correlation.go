@@ -0,0 +1,156 @@
+package core
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"agent-quant-system/internal/data"
+	"agent-quant-system/internal/money"
+	"agent-quant-system/internal/portfolio"
+	"agent-quant-system/internal/strategy"
+)
+
+// filterCorrelated 组合构建：按全部账户合并后的持仓与信号标的最近的收益率相关系数，
+// 拒绝或缩减会使组合过于相关或集中的买入信号；卖出、平仓信号和无法获取行情时原样通过
+func (qe *QuantEngine) filterCorrelated(symbol string, signals []strategy.TradingSignal) []strategy.TradingSignal {
+	cfg := qe.currentConfig().Portfolio.Correlation
+	if !cfg.Enabled || !hasOpeningSignal(signals) {
+		return signals
+	}
+
+	holdings := make(map[string]float64)
+	for _, exposure := range qe.tradingEngine.GetExposure().Symbols {
+		if !exposure.Value.IsZero() {
+			holdings[exposure.Symbol] = money.Float(exposure.Value)
+		}
+	}
+
+	matrices := make(map[string]*data.Matrix)
+	filtered := make([]strategy.TradingSignal, 0, len(signals))
+	for _, signal := range signals {
+		if signal.Signal != strategy.Buy || signal.ClosePercent > 0 || signal.Quantity <= 0 || signal.Price <= 0 {
+			filtered = append(filtered, signal)
+			continue
+		}
+		value := signal.Quantity * signal.Price * money.Float(qe.instruments.Lookup(signal.Symbol).Multiplier)
+
+		correlation, exists := matrices[signal.Symbol]
+		if !exists {
+			matrix, err := qe.correlationMatrix(signal.Symbol, holdings)
+			if err != nil {
+				log.Printf("相关性过滤跳过 %s 的信号: %v", signal.Symbol, err)
+				filtered = append(filtered, signal)
+				continue
+			}
+			correlation = matrix
+			matrices[signal.Symbol] = matrix
+		}
+
+		decision := portfolio.FilterByCorrelation(correlation, holdings, signal.Symbol, value, portfolio.CorrelationLimits{
+			MaxPairCorrelation:      cfg.MaxPairCorrelation,
+			MaxPortfolioCorrelation: cfg.MaxPortfolioCorrelation,
+			MaxConcentration:        cfg.MaxConcentration,
+			Downsize:                cfg.Action == "downsize",
+			MinScale:                cfg.MinScale,
+		})
+		if decision.Reason != "" {
+			qe.signalLog.record(signalLogCorrelation, qe.stats.TotalCycles, symbol, true, map[string]interface{}{
+				"strategy":              signal.Strategy,
+				"quantity":              signal.Quantity,
+				"scale":                 decision.Scale,
+				"pair_symbol":           decision.PairSymbol,
+				"pair_correlation":      decision.PairCorrelation,
+				"portfolio_correlation": decision.PortfolioCorrelation,
+				"concentration":         decision.Concentration,
+				"reason":                decision.Reason,
+			})
+		}
+		switch {
+		case decision.Scale <= 0:
+			log.Printf("相关性过滤拒绝信号: 策略=%s, 标的=%s, 原因=%s", signal.Strategy, signal.Symbol, decision.Reason)
+			continue
+		case decision.Scale < 1:
+			log.Printf("相关性过滤缩减信号: 策略=%s, 标的=%s, 数量 %.4f -> %.4f, 原因=%s",
+				signal.Strategy, signal.Symbol, signal.Quantity, signal.Quantity*decision.Scale, decision.Reason)
+			signal.Quantity *= decision.Scale
+			signal.Reason = fmt.Sprintf("%s（相关性过滤缩减至 %.0f%%: %s）", signal.Reason, decision.Scale*100, decision.Reason)
+		}
+		holdings[signal.Symbol] += value * decision.Scale
+		filtered = append(filtered, signal)
+	}
+	return filtered
+}
+
+// hasOpeningSignal 是否有买入开仓的信号
+func hasOpeningSignal(signals []strategy.TradingSignal) bool {
+	for _, signal := range signals {
+		if signal.Signal == strategy.Buy && signal.ClosePercent <= 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// correlationMatrix 信号标的与持仓标的最近 window 个共同时间点的收益率相关系数矩阵，
+// 获取不到行情的持仓标的（如期权）不参与比较
+func (qe *QuantEngine) correlationMatrix(symbol string, holdings map[string]float64) (*data.Matrix, error) {
+	cfg := qe.currentConfig().Portfolio.Correlation
+	end := time.Now()
+	start := end.AddDate(0, 0, -cfg.LookbackDays)
+
+	returns := func(target string) (*data.ReturnSeries, error) {
+		session := qe.dataManager.Session(target)
+		df, err := qe.dataManager.GetMarketData(target, session.FormatDate(start), session.FormatDate(end))
+		if err != nil {
+			return nil, err
+		}
+		series, err := data.ReturnsFromDataFrame(df)
+		if err != nil {
+			return nil, err
+		}
+		series.Symbol = target
+		return series, nil
+	}
+
+	own, err := returns(symbol)
+	if err != nil {
+		return nil, fmt.Errorf("获取收益率失败: %w", err)
+	}
+	series := []*data.ReturnSeries{own}
+	for _, held := range sortedHoldings(holdings) {
+		if held == symbol {
+			continue
+		}
+		heldSeries, err := returns(held)
+		if err != nil {
+			log.Printf("获取持仓 %s 的收益率失败，相关性过滤不比较该标的: %v", held, err)
+			continue
+		}
+		series = append(series, heldSeries)
+	}
+	if len(series) < 2 {
+		return &data.Matrix{Symbols: []string{symbol}, Values: [][]float64{{1}}}, nil
+	}
+
+	aligned, err := data.AlignReturns(series...)
+	if err != nil {
+		return nil, err
+	}
+	window := cfg.Window
+	if len(aligned.Times) < window {
+		window = len(aligned.Times)
+	}
+	return aligned.Correlation(len(aligned.Times)-window, len(aligned.Times))
+}
+
+// sortedHoldings 按名称排序的持仓标的
+func sortedHoldings(holdings map[string]float64) []string {
+	symbols := make([]string, 0, len(holdings))
+	for symbol := range holdings {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+	return symbols
+}
@@ -0,0 +1,353 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"agent-quant-system/internal/agent"
+	"agent-quant-system/internal/data"
+	"agent-quant-system/internal/notify"
+	"agent-quant-system/internal/strategy"
+	"agent-quant-system/internal/trading"
+)
+
+// Dependency 外部依赖
+type Dependency string
+
+const (
+	DependencyData   Dependency = "data"   // 行情数据
+	DependencyAgent  Dependency = "agent"  // Agent分析服务
+	DependencyNews   Dependency = "news"   // 新闻数据源
+	DependencyBroker Dependency = "broker" // 经纪商
+)
+
+// DependencyHealth 依赖的运行状态
+type DependencyHealth struct {
+	Healthy   bool      `json:"healthy"`
+	Mode      string    `json:"mode,omitempty"` // 异常时采用的降级方式
+	LastError string    `json:"last_error,omitempty"`
+	Since     time.Time `json:"since,omitempty"` // 开始异常的时间
+	Failures  int       `json:"failures"`        // 累计失败次数
+}
+
+// cachedMarketData 最近一次成功获取的行情
+type cachedMarketData struct {
+	data      data.DataFrame
+	fetchedAt time.Time
+}
+
+// cachedAnalysis 最近一次成功的Agent分析
+type cachedAnalysis struct {
+	analysis   *agent.AnalysisResponse
+	analyzedAt time.Time
+}
+
+// deferredSignal 经纪商不可用时排队等待重新提交的信号
+type deferredSignal struct {
+	signal   strategy.TradingSignal
+	queuedAt time.Time
+}
+
+// degradation 依赖故障的降级处理：按配置决定每个依赖失败时的行为，并记录依赖状态
+type degradation struct {
+	health   map[Dependency]*DependencyHealth
+	cache    map[string]cachedMarketData
+	analyses map[string]cachedAnalysis // agent 降级为 cache 时复用
+	deferred []deferredSignal
+	mock     agent.ClientInterface // agent 降级为 mock 时按需创建
+	notifier *notify.Dispatcher
+	mutex    sync.Mutex
+}
+
+// newDegradation 创建降级处理器
+func newDegradation(notifier *notify.Dispatcher) *degradation {
+	health := make(map[Dependency]*DependencyHealth)
+	for _, dependency := range []Dependency{DependencyData, DependencyAgent, DependencyNews, DependencyBroker} {
+		health[dependency] = &DependencyHealth{Healthy: true}
+	}
+
+	return &degradation{
+		health:   health,
+		cache:    make(map[string]cachedMarketData),
+		analyses: make(map[string]cachedAnalysis),
+		notifier: notifier,
+	}
+}
+
+// markHealthy 依赖调用成功
+func (d *degradation) markHealthy(dependency Dependency) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	health := d.health[dependency]
+	if !health.Healthy {
+		log.Printf("依赖已恢复: %s, 异常持续 %v", dependency, time.Since(health.Since).Round(time.Second))
+		d.notifier.Notifyf(dependencyEvent(dependency), fmt.Sprintf("%s 已恢复", dependency),
+			"异常持续 %v", time.Since(health.Since).Round(time.Second))
+	}
+	health.Healthy = true
+	health.Mode = ""
+	health.LastError = ""
+	health.Since = time.Time{}
+}
+
+// markDegraded 依赖调用失败，记录采用的降级方式
+func (d *degradation) markDegraded(dependency Dependency, mode string, err error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	health := d.health[dependency]
+	if health.Healthy {
+		health.Since = time.Now()
+		// 仅在由正常转为异常时通知，避免每次失败都发送
+		d.notifier.Notifyf(dependencyEvent(dependency), fmt.Sprintf("%s 不可用", dependency),
+			"降级方式: %s\n错误: %v", mode, err)
+	}
+	health.Healthy = false
+	health.Mode = mode
+	health.LastError = err.Error()
+	health.Failures++
+	log.Printf("依赖异常: %s, 降级方式=%s, 错误=%v", dependency, mode, err)
+}
+
+// dependencyEvent 依赖对应的通知事件
+func dependencyEvent(dependency Dependency) notify.EventKind {
+	if dependency == DependencyBroker {
+		return notify.EventBroker
+	}
+	return notify.EventDependency
+}
+
+// snapshot 获取依赖状态副本
+func (d *degradation) snapshot() map[Dependency]DependencyHealth {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	result := make(map[Dependency]DependencyHealth, len(d.health))
+	for dependency, health := range d.health {
+		result[dependency] = *health
+	}
+	return result
+}
+
+// fetchNews 获取标的相关新闻，未配置新闻源时使用模拟新闻，拉取失败时按降级配置处理
+func (qe *QuantEngine) fetchNews(symbol string) ([]string, error) {
+	if qe.newsFetcher == nil {
+		return qe.getMockNews(), nil
+	}
+
+	articles, err := qe.newsFetcher.FetchRelevant(symbol)
+	if err != nil {
+		mode := qe.currentConfig().Degradation.News
+		qe.degradation.markDegraded(DependencyNews, mode, err)
+		switch mode {
+		case "empty":
+			return nil, nil
+		case "fail":
+			return nil, fmt.Errorf("获取新闻失败: %w", err)
+		default:
+			return qe.getMockNews(), nil
+		}
+	}
+	qe.degradation.markHealthy(DependencyNews)
+	qe.cycleArticles = append(qe.cycleArticles, articles...)
+
+	headlines := make([]string, 0, len(articles))
+	for _, article := range articles {
+		headlines = append(headlines, article.Headline())
+	}
+	return headlines, nil
+}
+
+// analyzeNews 调用Agent分析新闻（没有近期新闻时视为中性），失败时按降级配置处理
+func (qe *QuantEngine) analyzeNews(symbol string, newsItems []string) (*agent.AnalysisResponse, error) {
+	if len(newsItems) == 0 {
+		return neutralAnalysis(symbol, "没有近期新闻"), nil
+	}
+	if qe.analysisPool != nil {
+		return qe.analyzeAsync(symbol, newsItems)
+	}
+
+	analysis, err := qe.agentClient.AnalyzeNews(symbol, newsItems)
+	qe.recordAnalysis(symbol, analysis, err)
+	if err == nil {
+		return analysis, nil
+	}
+	return qe.degradedAnalysis(symbol, newsItems, err)
+}
+
+// recordAnalysis 按分析结果更新Agent的依赖状态，成功的分析保存供降级时复用
+func (qe *QuantEngine) recordAnalysis(symbol string, analysis *agent.AnalysisResponse, err error) {
+	if err != nil {
+		qe.degradation.markDegraded(DependencyAgent, qe.currentConfig().Degradation.Agent, err)
+		return
+	}
+	qe.degradation.markHealthy(DependencyAgent)
+	qe.degradation.storeAnalysis(symbol, analysis)
+}
+
+// degradedAnalysis Agent分析失败时按 degradation.agent 处理
+func (qe *QuantEngine) degradedAnalysis(symbol string, newsItems []string, err error) (*agent.AnalysisResponse, error) {
+	switch qe.currentConfig().Degradation.Agent {
+	case "cache":
+		if cached, ok := qe.degradation.cachedAnalysis(symbol, qe.currentConfig().Degradation.AgentMaxAge); ok {
+			log.Printf("Agent服务不可用，复用 %s 的分析结果: %s", cached.analyzedAt.Format("15:04:05"), symbol)
+			return cached.analysis, nil
+		}
+		return neutralAnalysis(symbol, "Agent服务不可用且没有可复用的分析，按中性处理"), nil
+	case "mock":
+		return qe.mockAgent().AnalyzeNews(symbol, newsItems)
+	case "fail":
+		return nil, fmt.Errorf("Agent分析失败: %w", err)
+	default:
+		return neutralAnalysis(symbol, "Agent服务不可用，按中性处理"), nil
+	}
+}
+
+// storeAnalysis 保存最近一次成功的分析
+func (d *degradation) storeAnalysis(symbol string, analysis *agent.AnalysisResponse) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.analyses[symbol] = cachedAnalysis{analysis: analysis, analyzedAt: time.Now()}
+}
+
+// cachedAnalysis 获取未过期的最近一次分析
+func (d *degradation) cachedAnalysis(symbol string, maxAge time.Duration) (cachedAnalysis, bool) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	cached, ok := d.analyses[symbol]
+	if !ok || (maxAge > 0 && time.Since(cached.analyzedAt) > maxAge) {
+		return cachedAnalysis{}, false
+	}
+	return cached, true
+}
+
+// neutralAnalysis 中性分析结果
+func neutralAnalysis(symbol, reason string) *agent.AnalysisResponse {
+	return &agent.AnalysisResponse{
+		Symbol:          symbol,
+		Sentiment:       "Neutral",
+		Reason:          reason,
+		ConfidenceScore: 0,
+		Timestamp:       time.Now(),
+	}
+}
+
+// mockAgent 获取模拟Agent客户端（按需创建）
+func (qe *QuantEngine) mockAgent() agent.ClientInterface {
+	qe.degradation.mutex.Lock()
+	defer qe.degradation.mutex.Unlock()
+
+	if qe.degradation.mock == nil {
+		qe.degradation.mock = agent.CreateClient(qe.currentConfig().AgentService.URL, true)
+	}
+	return qe.degradation.mock
+}
+
+// getMarketData 获取近30天行情，失败时按降级配置复用缓存
+func (qe *QuantEngine) getMarketData(symbol string) (data.DataFrame, error) {
+	now := qe.now()
+	session := qe.dataManager.Session(symbol)
+	df, err := qe.dataManager.GetMarketData(symbol,
+		session.FormatDate(now.Add(-qe.marketDataLookback())),
+		session.FormatDate(now))
+	if err == nil {
+		qe.degradation.markHealthy(DependencyData)
+		qe.degradation.mutex.Lock()
+		qe.degradation.cache[symbol] = cachedMarketData{data: df, fetchedAt: now}
+		qe.degradation.mutex.Unlock()
+		return df, nil
+	}
+
+	mode := qe.currentConfig().Degradation.Data
+	qe.degradation.markDegraded(DependencyData, mode, err)
+	if mode == "cache" {
+		qe.degradation.mutex.Lock()
+		cached, exists := qe.degradation.cache[symbol]
+		qe.degradation.mutex.Unlock()
+
+		maxAge := qe.currentConfig().Degradation.DataMaxAge
+		if exists && (maxAge <= 0 || now.Sub(cached.fetchedAt) <= maxAge) {
+			log.Printf("使用缓存行情: 标的=%s, 获取时间=%s", symbol, cached.fetchedAt.Format("2006-01-02 15:04:05"))
+			return cached.data, nil
+		}
+	}
+	return nil, fmt.Errorf("获取市场数据失败: %w", err)
+}
+
+// handleBrokerFailure 经纪商不可用时按降级配置排队或丢弃信号，返回是否停止提交本轮剩余信号
+func (qe *QuantEngine) handleBrokerFailure(signal strategy.TradingSignal, err error) bool {
+	mode := qe.currentConfig().Degradation.Broker
+	qe.degradation.markDegraded(DependencyBroker, mode, err)
+
+	if mode == "queue" {
+		// 沿用首次提交的客户端订单号，若首次请求实际已成功，重新提交时不会重复下单
+		if clientOrderID := trading.ClientOrderIDOf(err); clientOrderID != "" {
+			signal.ClientOrderID = clientOrderID
+		}
+		qe.degradation.supersede([]strategy.TradingSignal{signal})
+		qe.degradation.mutex.Lock()
+		qe.degradation.deferred = append(qe.degradation.deferred, deferredSignal{signal: signal, queuedAt: time.Now()})
+		qe.degradation.mutex.Unlock()
+		log.Printf("经纪商不可用，信号排队等待重新提交: %s %s", signal.Symbol, signal.Signal.String())
+		return false
+	}
+
+	log.Printf("经纪商不可用，本轮停止下单: %s %s", signal.Symbol, signal.Signal.String())
+	return true
+}
+
+// supersede 丢弃与新信号同一标的、同一策略的排队信号：策略已按最新行情重新给出判断（包括持有），
+// 旧信号不再重新提交
+func (d *degradation) supersede(signals []strategy.TradingSignal) {
+	if len(signals) == 0 {
+		return
+	}
+	fresh := make(map[string]bool, len(signals))
+	for _, signal := range signals {
+		fresh[signal.Symbol+"/"+signal.Strategy] = true
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	kept := d.deferred[:0]
+	for _, item := range d.deferred {
+		if fresh[item.signal.Symbol+"/"+item.signal.Strategy] {
+			log.Printf("排队信号已被新信号取代，丢弃: %s %s, 策略=%s, 排队于 %s",
+				item.signal.Symbol, item.signal.Signal.String(), item.signal.Strategy, item.queuedAt.Format("15:04:05"))
+			continue
+		}
+		kept = append(kept, item)
+	}
+	d.deferred = kept
+}
+
+// takeDeferredSignals 取出仍在有效期内的排队信号，本轮已给出新信号的标的和策略已在 supersede 中丢弃
+func (qe *QuantEngine) takeDeferredSignals() []strategy.TradingSignal {
+	qe.degradation.mutex.Lock()
+	deferred := qe.degradation.deferred
+	qe.degradation.deferred = nil
+	qe.degradation.mutex.Unlock()
+
+	maxAge := qe.currentConfig().Degradation.QueueMaxAge
+	signals := make([]strategy.TradingSignal, 0, len(deferred))
+	for _, item := range deferred {
+		if maxAge > 0 && time.Since(item.queuedAt) > maxAge {
+			log.Printf("排队信号已过期，丢弃: %s %s, 排队于 %s",
+				item.signal.Symbol, item.signal.Signal.String(), item.queuedAt.Format("15:04:05"))
+			continue
+		}
+		signals = append(signals, item.signal)
+	}
+	return signals
+}
+
+// isBrokerUnavailable 是否为经纪商不可用导致的错误
+func isBrokerUnavailable(err error) bool {
+	return errors.Is(err, trading.ErrBrokerUnavailable)
+}
@@ -0,0 +1,41 @@
+package core
+
+import (
+	"log"
+
+	"agent-quant-system/internal/strategy"
+)
+
+// aggregateEnsemble 按 strategy.ensemble 将成员策略对该标的的买卖信号合并为一个净信号，
+// 投票结果记录到循环历史和信号日志，用于事后评估各成员策略的贡献
+func (qe *QuantEngine) aggregateEnsemble(symbol string, strategies []string, signals []strategy.TradingSignal, record *SymbolCycle) []strategy.TradingSignal {
+	cfg := qe.currentConfig().Strategy.Ensemble
+	if !cfg.Enabled {
+		return signals
+	}
+	ensemble := &strategy.Ensemble{
+		Name:      cfg.Name,
+		Method:    cfg.Method,
+		Members:   cfg.Strategies,
+		Weights:   cfg.Weights,
+		Threshold: cfg.Threshold,
+		Veto:      cfg.Veto,
+	}
+	signals, decision := ensemble.Aggregate(symbol, strategies, signals)
+	if decision == nil {
+		return signals
+	}
+
+	log.Printf("%s: %s", decision.Reason, decision.Signal)
+	record.Ensemble = decision
+	qe.signalLog.record(signalLogEnsemble, qe.stats.TotalCycles, symbol, decision.Signal != strategy.Hold.String(), map[string]interface{}{
+		"strategy": cfg.Name,
+		"method":   decision.Method,
+		"signal":   decision.Signal,
+		"score":    decision.Score,
+		"vetoed":   decision.Vetoed,
+		"votes":    decision.Votes,
+		"reason":   decision.Reason,
+	})
+	return signals
+}
@@ -0,0 +1,207 @@
+package core
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"agent-quant-system/internal/data"
+	"agent-quant-system/internal/strategy"
+)
+
+// CycleExplanation 一轮循环的决策说明
+type CycleExplanation struct {
+	CycleID int                 `json:"cycle_id"`
+	Start   time.Time           `json:"start"`
+	Status  CycleStatus         `json:"status"`
+	Summary string              `json:"summary"`
+	Details []string            `json:"details,omitempty"` // 循环级别的错误、排队信号和组合调仓
+	Symbols []SymbolExplanation `json:"symbols,omitempty"`
+}
+
+// SymbolExplanation 单个标的的决策说明：为什么生成或没有生成信号，以及信号的执行结果
+type SymbolExplanation struct {
+	Symbol  string   `json:"symbol"`
+	Summary string   `json:"summary"`
+	Details []string `json:"details"`
+}
+
+// decisionRecord 记录策略的决策结果，策略实现 Explainer 时附上指标值和条件说明
+func (qe *QuantEngine) decisionRecord(name string, df data.DataFrame, guidance *strategy.AgentGuidance, signals []strategy.TradingSignal, err error) DecisionRecord {
+	decision := DecisionRecord{Strategy: name, Outcome: DecisionNoSignal, Signals: len(signals)}
+	switch {
+	case err != nil:
+		decision.Outcome = DecisionFailed
+		decision.Error = err.Error()
+	case len(signals) > 0:
+		decision.Outcome = DecisionSignals
+	}
+	if explanation := qe.strategyManager.ExplainStrategy(name, df, guidance); explanation != nil {
+		decision.Indicators = explanation.Indicators
+		decision.Notes = explanation.Notes
+	}
+	return decision
+}
+
+// ExplainCycle 由循环记录生成简要的决策说明
+func ExplainCycle(record CycleRecord) CycleExplanation {
+	explanation := CycleExplanation{CycleID: record.ID, Start: record.Start, Status: record.Status}
+
+	if record.Status == CycleHalted {
+		explanation.Summary = "紧急停止期间跳过本轮，不分析也不下单"
+		explanation.Details = append(explanation.Details, record.Errors...)
+		return explanation
+	}
+
+	signals, submitted, failed := 0, 0, 0
+	for _, s := range record.Symbols {
+		signals += len(s.Signals)
+		for _, order := range s.Orders {
+			if order.Error != "" {
+				failed++
+			} else {
+				submitted++
+			}
+		}
+		explanation.Symbols = append(explanation.Symbols, explainSymbol(s))
+	}
+
+	status := "完成"
+	if record.Status == CycleFailed {
+		status = "失败"
+	}
+	explanation.Summary = fmt.Sprintf("循环%s: 处理 %d 个标的，生成 %d 个信号，提交 %d 个订单", status, len(record.Symbols), signals, submitted)
+	if failed > 0 {
+		explanation.Summary += fmt.Sprintf("，%d 个信号未能执行", failed)
+	}
+
+	for _, e := range record.Errors {
+		explanation.Details = append(explanation.Details, "错误: "+e)
+	}
+	for _, order := range record.Deferred {
+		explanation.Details = append(explanation.Details, "重新提交排队信号: "+explainOrder(order))
+	}
+	for _, order := range record.Rebalance {
+		explanation.Details = append(explanation.Details, "组合调仓: "+explainOrder(order))
+	}
+	return explanation
+}
+
+// explainSymbol 单个标的的决策说明
+func explainSymbol(s SymbolCycle) SymbolExplanation {
+	explanation := SymbolExplanation{Symbol: s.Symbol}
+	add := func(format string, args ...interface{}) {
+		explanation.Details = append(explanation.Details, fmt.Sprintf(format, args...))
+	}
+
+	if len(s.Strategies) == 0 {
+		explanation.Summary = "没有在运行时间内的策略，跳过"
+	}
+
+	if s.Guidance != nil {
+		add("新闻 %d 条，Agent指导: %s（置信度 %.2f）%s", s.News, s.Guidance.Sentiment, s.Guidance.Confidence, s.Guidance.Reason)
+	} else if len(s.Strategies) > 0 {
+		add("新闻 %d 条，没有Agent指导", s.News)
+	}
+	if s.Bars > 0 {
+		add("行情 %d 根K线，最新收盘价 %.4f", s.Bars, s.LastClose)
+	}
+
+	for _, decision := range s.Decisions {
+		line := fmt.Sprintf("[%s] ", decision.Strategy)
+		switch decision.Outcome {
+		case DecisionSignals:
+			line += fmt.Sprintf("生成 %d 个信号", decision.Signals)
+		case DecisionNoSignal:
+			line += "未生成信号"
+		case DecisionFailed:
+			line += "执行失败: " + decision.Error
+		case DecisionSkipped:
+			line += "不在运行时间内"
+		}
+		if indicators := formatIndicators(decision.Indicators); indicators != "" {
+			line += "；指标: " + indicators
+		}
+		if len(decision.Notes) > 0 {
+			line += "；" + strings.Join(decision.Notes, "；")
+		}
+		explanation.Details = append(explanation.Details, line)
+	}
+
+	for _, signal := range s.Signals {
+		add("[%s] 信号: %s %.4f @ %.4f（置信度 %.2f）%s", signal.Strategy, signal.Signal, signal.Quantity, signal.Price, signal.Confidence, signal.Reason)
+	}
+	rejected := 0
+	for _, order := range s.Orders {
+		if order.Error != "" {
+			rejected++
+			add("[%s] 信号未执行: %s", order.Strategy, order.Error)
+			continue
+		}
+		add("[%s] 订单: %s", order.Strategy, explainOrder(order))
+	}
+	if s.Error != "" {
+		add("处理失败: %s", s.Error)
+	}
+
+	if explanation.Summary == "" {
+		switch {
+		case s.Error != "":
+			explanation.Summary = "处理失败: " + s.Error
+		case len(s.Signals) == 0:
+			explanation.Summary = "未生成信号"
+		case rejected > 0:
+			explanation.Summary = fmt.Sprintf("生成 %d 个信号，%d 个未能执行", len(s.Signals), rejected)
+		default:
+			explanation.Summary = fmt.Sprintf("生成 %d 个信号，均已提交", len(s.Signals))
+		}
+	}
+	return explanation
+}
+
+// explainOrder 订单执行结果
+func explainOrder(order OrderRecord) string {
+	if order.Error != "" {
+		return fmt.Sprintf("%s %s 未执行: %s", order.Strategy, order.Symbol, order.Error)
+	}
+	text := fmt.Sprintf("%s %s %s @ %s, 账户=%s, 状态=%s", order.Side, order.Symbol, order.Quantity, order.Price, order.Account, order.Status)
+	if order.Paper {
+		text += "（纸面副本）"
+	}
+	if order.DryRun {
+		text += "（演练，未发送到经纪商）"
+	}
+	return text
+}
+
+// formatIndicators 按名称排序输出指标值
+func formatIndicators(indicators map[string]float64) string {
+	names := make([]string, 0, len(indicators))
+	for name := range indicators {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		value := strconv.FormatFloat(math.Round(indicators[name]*1e4)/1e4, 'f', -1, 64)
+		parts = append(parts, name+"="+value)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// ExplainCycles 查询循环记录并生成决策说明
+func (qe *QuantEngine) ExplainCycles(query CycleQuery) ([]CycleExplanation, error) {
+	records, err := qe.CycleHistory(query)
+	if err != nil {
+		return nil, err
+	}
+	explanations := make([]CycleExplanation, 0, len(records))
+	for _, record := range records {
+		explanations = append(explanations, ExplainCycle(record))
+	}
+	return explanations, nil
+}
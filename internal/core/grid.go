@@ -0,0 +1,35 @@
+package core
+
+import (
+	"log"
+
+	"agent-quant-system/internal/strategy"
+	"agent-quant-system/internal/trading"
+)
+
+// syncGrids 为本轮运行的常驻限价单策略同步标的上的挂单
+func (qe *QuantEngine) syncGrids(symbol string, strategies []string) {
+	for _, name := range strategies {
+		s, err := qe.strategyManager.GetStrategy(name)
+		if err != nil {
+			continue
+		}
+		planner, ok := s.(strategy.RestingOrderStrategy)
+		if !ok || !planner.AppliesTo(symbol) {
+			continue
+		}
+
+		status, err := qe.tradingEngine.SyncGrid(name, "", symbol, planner)
+		if err != nil {
+			log.Printf("同步网格挂单失败: 策略=%s, 标的=%s, 错误=%v", name, symbol, err)
+		}
+		if status != nil {
+			log.Printf("网格挂单已同步: 策略=%s, 标的=%s, 挂单=%d, 累计成交=%d", name, symbol, len(status.Orders), status.Fills)
+		}
+	}
+}
+
+// GetGrids 获取交易引擎维护的网格挂单
+func (qe *QuantEngine) GetGrids() []trading.GridStatus {
+	return qe.tradingEngine.GetGrids()
+}
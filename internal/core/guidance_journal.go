@@ -0,0 +1,179 @@
+package core
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"agent-quant-system/internal/agent"
+	"agent-quant-system/internal/data"
+	"agent-quant-system/internal/strategy"
+)
+
+// GuidanceEntry 一条Agent指导及其影响的信号和订单
+type GuidanceEntry struct {
+	Time       time.Time        `json:"time"`  // 循环时间，回放模式下为行情时间
+	Cycle      int              `json:"cycle"` // 本次运行内的循环序号
+	Symbol     string           `json:"symbol"`
+	AnalysisID string           `json:"analysis_id,omitempty"`
+	Sentiment  string           `json:"sentiment"`
+	Confidence float64          `json:"confidence"`
+	Reason     string           `json:"reason,omitempty"`
+	Price      float64          `json:"price,omitempty"` // 指导时的最新收盘价
+	Decisions  []GuidedDecision `json:"decisions,omitempty"`
+}
+
+// GuidedDecision 单个策略在指导下的决策，以及同一行情下中性情绪时的基线信号
+type GuidedDecision struct {
+	Strategy string         `json:"strategy"`
+	Signals  []SignalRecord `json:"signals,omitempty"`  // 结合指导生成的信号
+	Baseline []SignalRecord `json:"baseline,omitempty"` // 中性情绪下生成的信号
+
+	// NoBaseline 没有计算基线（增量模式的策略或基线运行失败），不参与有无调整的对比
+	NoBaseline bool `json:"no_baseline,omitempty"`
+
+	// Orders 本轮该策略信号提交的订单ID；策略组合的订单记在组合名下，成员策略没有订单
+	Orders []string `json:"orders,omitempty"`
+}
+
+// GuidanceJournal 持久化的Agent指导记录，按行追加JSON
+type GuidanceJournal struct {
+	path  string
+	mutex sync.Mutex
+}
+
+// NewGuidanceJournal 打开指导记录文件
+func NewGuidanceJournal(path string) (*GuidanceJournal, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("创建指导记录目录失败: %w", err)
+	}
+	return &GuidanceJournal{path: path}, nil
+}
+
+// Record 追加一条指导记录
+func (j *GuidanceJournal) Record(entry *GuidanceEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("序列化指导记录失败: %w", err)
+	}
+
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	file, err := os.OpenFile(j.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("打开指导记录失败: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("写入指导记录失败: %w", err)
+	}
+	return nil
+}
+
+// Query 按时间和标的查询指导记录（按时间升序），零值表示不限制
+func (j *GuidanceJournal) Query(from, to time.Time, symbol string) ([]GuidanceEntry, error) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	file, err := os.Open(j.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("打开指导记录失败: %w", err)
+	}
+	defer file.Close()
+
+	var entries []GuidanceEntry
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		var entry GuidanceEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			log.Printf("跳过无法解析的指导记录: 行=%d, 错误=%v", line, err)
+			continue
+		}
+		if !from.IsZero() && entry.Time.Before(from) {
+			continue
+		}
+		if !to.IsZero() && entry.Time.After(to) {
+			continue
+		}
+		if symbol != "" && entry.Symbol != symbol {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取指导记录失败: %w", err)
+	}
+	return entries, nil
+}
+
+// signalRecords 转换为信号记录
+func signalRecords(signals []strategy.TradingSignal) []SignalRecord {
+	var records []SignalRecord
+	for _, signal := range signals {
+		records = append(records, SignalRecord{
+			Strategy:   signal.Strategy,
+			Signal:     signal.Signal.String(),
+			Quantity:   signal.Quantity,
+			Price:      signal.Price,
+			Confidence: signal.Confidence,
+			Reason:     signal.Reason,
+		})
+	}
+	return records
+}
+
+// guidedDecision 记录策略在指导下生成的信号，并以中性情绪重新运行得到基线，未启用指导记录时返回 nil
+func (qe *QuantEngine) guidedDecision(name string, df data.DataFrame, guidance *strategy.AgentGuidance, signals []strategy.TradingSignal) *GuidedDecision {
+	if qe.guidance == nil {
+		return nil
+	}
+	decision := &GuidedDecision{Strategy: name, Signals: signalRecords(signals)}
+	baseline, ok, err := qe.strategyManager.BaselineSignals(name, df, guidance)
+	if err != nil {
+		log.Printf("策略 %s 计算中性情绪基线失败: %v", name, err)
+	}
+	decision.Baseline = signalRecords(baseline)
+	decision.NoBaseline = !ok
+	return decision
+}
+
+// recordGuidance 保存本轮标的的指导及其影响的信号和订单，未启用时忽略
+func (qe *QuantEngine) recordGuidance(now time.Time, analysis *agent.AnalysisResponse, record *SymbolCycle, decisions []*GuidedDecision) {
+	if qe.guidance == nil {
+		return
+	}
+	entry := &GuidanceEntry{
+		Time:       now,
+		Cycle:      qe.stats.TotalCycles,
+		Symbol:     record.Symbol,
+		AnalysisID: analysis.AnalysisID,
+		Sentiment:  analysis.Sentiment,
+		Confidence: analysis.ConfidenceScore,
+		Reason:     analysis.Reason,
+		Price:      record.LastClose,
+	}
+	for _, decision := range decisions {
+		for _, order := range record.Orders {
+			if order.Strategy == decision.Strategy && order.OrderID != "" {
+				decision.Orders = append(decision.Orders, order.OrderID)
+			}
+		}
+		entry.Decisions = append(entry.Decisions, *decision)
+	}
+	if err := qe.guidance.Record(entry); err != nil {
+		log.Printf("保存指导记录失败: %v", err)
+	}
+}
@@ -0,0 +1,301 @@
+package core
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"agent-quant-system/internal/indicators"
+	"agent-quant-system/internal/strategy"
+)
+
+// guidanceMinDecisions 给出建议所需的最少已评估决策数
+const guidanceMinDecisions = 20
+
+// 信号方向与Agent情绪的关系
+const (
+	GuidanceAgree    = "agree"    // 情绪与信号同向
+	GuidanceDisagree = "disagree" // 情绪与信号反向
+	GuidanceNeutral  = "neutral"  // 中性情绪，不调整
+)
+
+// GuidanceQuery 指导效果报告的条件，时间零值表示不限制
+type GuidanceQuery struct {
+	From    time.Time
+	To      time.Time
+	Symbol  string
+	Horizon time.Duration // 持有期：信号价格与持有期结束后第一根K线的收盘价比较
+}
+
+// GuidanceOutcome 一组信号持有到期后的结果
+type GuidanceOutcome struct {
+	Signals   int     `json:"signals"`
+	Hits      int     `json:"hits"` // 按方向收益为正的信号数
+	HitRate   float64 `json:"hit_rate"`
+	PnL       float64 `json:"pnl"`        // 按信号数量计算的盈亏
+	AvgReturn float64 `json:"avg_return"` // 按方向的平均收益率
+}
+
+// GuidanceComparison 同一组决策在情绪调整后与中性情绪基线下的结果
+type GuidanceComparison struct {
+	Name      string          `json:"name"`      // 策略名、情绪关系或 total
+	Decisions int             `json:"decisions"` // 已评估的决策数
+	Adjusted  GuidanceOutcome `json:"adjusted"`
+	Baseline  GuidanceOutcome `json:"baseline"`
+}
+
+// GuidanceReport Agent情绪调整的效果报告：只比较有基线且持有期已结束的决策
+type GuidanceReport struct {
+	From       time.Time      `json:"from"`
+	To         time.Time      `json:"to"`
+	Horizon    time.Duration  `json:"horizon"`
+	Entries    int            `json:"entries"`     // 指导条数
+	Sentiments map[string]int `json:"sentiments"`  // 各情绪的指导条数
+	Pending    int            `json:"pending"`     // 持有期尚未结束或取不到行情的决策数
+	NoBaseline int            `json:"no_baseline"` // 没有基线的决策数
+
+	Total      GuidanceComparison   `json:"total"`
+	Strategies []GuidanceComparison `json:"strategies"` // 按策略名排序
+	Alignment  []GuidanceComparison `json:"alignment"`  // 按情绪与基线信号方向的关系分组
+
+	Recommendation string `json:"recommendation"`
+}
+
+// GuidanceReport 按指导记录和之后的行情评估情绪调整是否改善了命中率和盈亏
+func (qe *QuantEngine) GuidanceReport(query GuidanceQuery) (*GuidanceReport, error) {
+	if qe.guidance == nil {
+		return nil, fmt.Errorf("未启用指导记录（engine.guidance_file 为空）")
+	}
+	if query.Horizon <= 0 {
+		return nil, fmt.Errorf("持有期必须大于0")
+	}
+	entries, err := qe.guidance.Query(query.From, query.To, query.Symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &GuidanceReport{
+		From:       query.From,
+		To:         query.To,
+		Horizon:    query.Horizon,
+		Entries:    len(entries),
+		Sentiments: make(map[string]int),
+		Total:      GuidanceComparison{Name: "total"},
+	}
+	strategies := make(map[string]*GuidanceComparison)
+	alignment := make(map[string]*GuidanceComparison)
+	prices := make(map[string]*exitPrices)
+
+	for _, entry := range entries {
+		report.Sentiments[entry.Sentiment]++
+		exits, ok := prices[entry.Symbol]
+		if !ok {
+			exits = qe.loadExitPrices(entry.Symbol, entries, query.Horizon)
+			prices[entry.Symbol] = exits
+		}
+		exit, ok := exits.after(entry.Time.Add(query.Horizon))
+
+		for _, decision := range entry.Decisions {
+			switch {
+			case decision.NoBaseline:
+				report.NoBaseline++
+				continue
+			case len(decision.Signals) == 0 && len(decision.Baseline) == 0:
+				continue
+			case !ok:
+				report.Pending++
+				continue
+			}
+
+			adjusted := evaluateSignals(decision.Signals, entry.Price, exit)
+			baseline := evaluateSignals(decision.Baseline, entry.Price, exit)
+			group := guidanceAlignment(entry.Sentiment, decision)
+			for _, comparison := range []*GuidanceComparison{
+				&report.Total,
+				comparisonFor(strategies, decision.Strategy),
+				comparisonFor(alignment, group),
+			} {
+				comparison.Decisions++
+				comparison.Adjusted.add(adjusted)
+				comparison.Baseline.add(baseline)
+			}
+		}
+	}
+
+	report.Total.finish()
+	report.Strategies = sortedComparisons(strategies)
+	for _, name := range []string{GuidanceAgree, GuidanceDisagree, GuidanceNeutral} {
+		if comparison, ok := alignment[name]; ok {
+			comparison.finish()
+			report.Alignment = append(report.Alignment, *comparison)
+		}
+	}
+	report.Recommendation = guidanceRecommendation(report)
+	return report, nil
+}
+
+// exitPrices 标的按时间排序的收盘价
+type exitPrices struct {
+	times  []time.Time
+	closes []float64
+}
+
+// after 返回开始时间不早于 t 的第一根K线的收盘价
+func (p *exitPrices) after(t time.Time) (float64, bool) {
+	if p == nil {
+		return 0, false
+	}
+	i := sort.Search(len(p.times), func(i int) bool { return !p.times[i].Before(t) })
+	if i == len(p.times) {
+		return 0, false
+	}
+	return p.closes[i], true
+}
+
+// loadExitPrices 获取覆盖标的全部指导记录及其持有期的行情，获取失败时该标的的决策都按未评估处理
+func (qe *QuantEngine) loadExitPrices(symbol string, entries []GuidanceEntry, horizon time.Duration) *exitPrices {
+	var start, end time.Time
+	for _, entry := range entries {
+		if entry.Symbol != symbol {
+			continue
+		}
+		if start.IsZero() || entry.Time.Before(start) {
+			start = entry.Time
+		}
+		if entry.Time.After(end) {
+			end = entry.Time
+		}
+	}
+	end = end.Add(horizon)
+
+	session := qe.dataManager.Session(symbol)
+	df, err := qe.dataManager.GetMarketData(symbol, session.FormatDate(start), session.FormatDate(end))
+	if err != nil {
+		log.Printf("获取 %s 的行情失败，不评估该标的的指导: %v", symbol, err)
+		return nil
+	}
+	closes, err := indicators.Float64Column(df, "close")
+	if err != nil {
+		log.Printf("解析 %s 的收盘价失败，不评估该标的的指导: %v", symbol, err)
+		return nil
+	}
+
+	prices := &exitPrices{}
+	for i, value := range df["timestamp"] {
+		if timestamp, ok := value.(time.Time); ok && i < len(closes) {
+			prices.times = append(prices.times, timestamp)
+			prices.closes = append(prices.closes, closes[i])
+		}
+	}
+	return prices
+}
+
+// evaluateSignals 按方向计算信号持有到 exit 价格的结果，信号没有价格时使用指导时的收盘价
+func evaluateSignals(signals []SignalRecord, price, exit float64) GuidanceOutcome {
+	var outcome GuidanceOutcome
+	for _, signal := range signals {
+		direction := 0.0
+		switch signal.Signal {
+		case strategy.Buy.String():
+			direction = 1
+		case strategy.Sell.String():
+			direction = -1
+		}
+		entry := signal.Price
+		if entry <= 0 {
+			entry = price
+		}
+		if direction == 0 || entry <= 0 {
+			continue
+		}
+
+		ret := direction * (exit - entry) / entry
+		outcome.Signals++
+		if ret > 0 {
+			outcome.Hits++
+		}
+		outcome.AvgReturn += ret
+		outcome.PnL += direction * (exit - entry) * signal.Quantity
+	}
+	return outcome
+}
+
+// add 累加另一组结果，AvgReturn 在 finish 前为收益率之和
+func (o *GuidanceOutcome) add(other GuidanceOutcome) {
+	o.Signals += other.Signals
+	o.Hits += other.Hits
+	o.PnL += other.PnL
+	o.AvgReturn += other.AvgReturn
+}
+
+// finish 计算命中率和平均收益率
+func (o *GuidanceOutcome) finish() {
+	if o.Signals == 0 {
+		return
+	}
+	o.HitRate = float64(o.Hits) / float64(o.Signals)
+	o.AvgReturn /= float64(o.Signals)
+}
+
+// finish 计算两组结果的命中率和平均收益率
+func (c *GuidanceComparison) finish() {
+	c.Adjusted.finish()
+	c.Baseline.finish()
+}
+
+// guidanceAlignment 按基线信号（基线没有信号时按调整后的信号）的方向判断与情绪的关系
+func guidanceAlignment(sentiment string, decision GuidedDecision) string {
+	signals := decision.Baseline
+	if len(signals) == 0 {
+		signals = decision.Signals
+	}
+	side := signals[0].Signal
+	switch {
+	case sentiment == "Positive" && side == strategy.Buy.String(), sentiment == "Negative" && side == strategy.Sell.String():
+		return GuidanceAgree
+	case sentiment == "Positive" && side == strategy.Sell.String(), sentiment == "Negative" && side == strategy.Buy.String():
+		return GuidanceDisagree
+	default:
+		return GuidanceNeutral
+	}
+}
+
+// comparisonFor 返回名称对应的对比，不存在时创建
+func comparisonFor(comparisons map[string]*GuidanceComparison, name string) *GuidanceComparison {
+	comparison, ok := comparisons[name]
+	if !ok {
+		comparison = &GuidanceComparison{Name: name}
+		comparisons[name] = comparison
+	}
+	return comparison
+}
+
+// sortedComparisons 计算各组结果并按名称排序
+func sortedComparisons(comparisons map[string]*GuidanceComparison) []GuidanceComparison {
+	sorted := make([]GuidanceComparison, 0, len(comparisons))
+	for _, comparison := range comparisons {
+		comparison.finish()
+		sorted = append(sorted, *comparison)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+	return sorted
+}
+
+// guidanceRecommendation 按总体盈亏和命中率的差异给出保留、调整或停用情绪调整的建议
+func guidanceRecommendation(report *GuidanceReport) string {
+	total := report.Total
+	if total.Decisions < guidanceMinDecisions {
+		return fmt.Sprintf("已评估的决策只有 %d 个（至少需要 %d 个），样本不足，暂不建议调整", total.Decisions, guidanceMinDecisions)
+	}
+	pnl := total.Adjusted.PnL - total.Baseline.PnL
+	hitRate := total.Adjusted.HitRate - total.Baseline.HitRate
+	switch {
+	case pnl > 0 && hitRate >= 0:
+		return fmt.Sprintf("情绪调整改善了结果（盈亏 %+.2f，命中率 %+.1f%%），建议保留", pnl, hitRate*100)
+	case pnl < 0 && hitRate <= 0:
+		return fmt.Sprintf("情绪调整降低了结果（盈亏 %+.2f，命中率 %+.1f%%），建议停用情绪对置信度的调整（strategy.guidance.enabled = false）", pnl, hitRate*100)
+	default:
+		return fmt.Sprintf("情绪调整的效果不一致（盈亏 %+.2f，命中率 %+.1f%%），建议按同向/反向分组的结果调小 strategy.guidance.weight 或设置 min_confidence", pnl, hitRate*100)
+	}
+}
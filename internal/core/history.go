@@ -0,0 +1,256 @@
+package core
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"agent-quant-system/internal/strategy"
+	"agent-quant-system/internal/trading"
+)
+
+// CycleStatus 交易循环结果
+type CycleStatus string
+
+const (
+	CycleSucceeded CycleStatus = "succeeded"
+	CycleFailed    CycleStatus = "failed"
+	CycleHalted    CycleStatus = "halted" // 紧急停止期间跳过
+)
+
+// CycleRecord 一次交易循环的记录
+type CycleRecord struct {
+	ID        int           `json:"id"` // 本次运行内的循环序号
+	Start     time.Time     `json:"start"`
+	Replay    time.Time     `json:"replay,omitempty"` // 回放模式下本轮循环的行情时间
+	Duration  time.Duration `json:"duration"`
+	Status    CycleStatus   `json:"status"`
+	Watchlist []string      `json:"watchlist,omitempty"`
+	Deferred  []OrderRecord `json:"deferred,omitempty"` // 重新提交的排队信号
+	Rebalance []OrderRecord `json:"rebalance,omitempty"`
+	Symbols   []SymbolCycle `json:"symbols,omitempty"`
+	Errors    []string      `json:"errors,omitempty"`
+}
+
+// SymbolCycle 循环中单个标的的处理过程
+type SymbolCycle struct {
+	Symbol     string          `json:"symbol"`
+	Strategies []string        `json:"strategies,omitempty"` // 按时间表本轮运行的策略
+	News       int             `json:"news"`                 // 新闻条数
+	Bars       int             `json:"bars"`                 // 行情条数
+	LastClose  float64         `json:"last_close,omitempty"`
+	Guidance   *GuidanceRecord `json:"guidance,omitempty"`
+	Signals    []SignalRecord  `json:"signals,omitempty"`
+	Orders     []OrderRecord   `json:"orders,omitempty"`
+	Error      string          `json:"error,omitempty"`
+	Duration   time.Duration   `json:"duration"`
+
+	// 各策略的决策过程，包括按时间表跳过的策略
+	Decisions []DecisionRecord `json:"decisions,omitempty"`
+
+	// Ensemble 策略组合的投票结果，Signals 中成员策略的买卖信号已替换为合并后的信号
+	Ensemble *strategy.EnsembleDecision `json:"ensemble,omitempty"`
+}
+
+// DecisionOutcome 策略在本轮的决策结果
+type DecisionOutcome string
+
+const (
+	DecisionSignals  DecisionOutcome = "signals"   // 生成了信号
+	DecisionNoSignal DecisionOutcome = "no_signal" // 运行了但没有生成信号
+	DecisionFailed   DecisionOutcome = "failed"
+	DecisionSkipped  DecisionOutcome = "skipped" // 不在运行时间内
+)
+
+// DecisionRecord 单个策略的决策过程
+type DecisionRecord struct {
+	Strategy   string             `json:"strategy"`
+	Outcome    DecisionOutcome    `json:"outcome"`
+	Signals    int                `json:"signals,omitempty"`
+	Indicators map[string]float64 `json:"indicators,omitempty"`
+	Notes      []string           `json:"notes,omitempty"`
+	Error      string             `json:"error,omitempty"`
+}
+
+// GuidanceRecord Agent 给出的指导
+type GuidanceRecord struct {
+	Sentiment  string  `json:"sentiment"`
+	Confidence float64 `json:"confidence"`
+	Reason     string  `json:"reason"`
+}
+
+// SignalRecord 策略生成的信号
+type SignalRecord struct {
+	Strategy   string  `json:"strategy"`
+	Signal     string  `json:"signal"`
+	Quantity   float64 `json:"quantity"`
+	Price      float64 `json:"price"`
+	Confidence float64 `json:"confidence"`
+	Reason     string  `json:"reason,omitempty"`
+}
+
+// OrderRecord 信号的执行结果
+type OrderRecord struct {
+	Strategy string `json:"strategy"`
+	Symbol   string `json:"symbol"`
+	Account  string `json:"account,omitempty"`
+	OrderID  string `json:"order_id,omitempty"`
+	Side     string `json:"side,omitempty"`
+	Quantity string `json:"quantity,omitempty"`
+	Price    string `json:"price,omitempty"`
+	Status   string `json:"status,omitempty"`
+	Paper    bool   `json:"paper,omitempty"`
+	DryRun   bool   `json:"dry_run,omitempty"`
+	Error    string `json:"error,omitempty"`
+
+	ClientOrderID string `json:"client_order_id,omitempty"`
+}
+
+// CycleHistory 持久化的交易循环记录，按行追加JSON
+type CycleHistory struct {
+	path  string
+	mutex sync.Mutex
+}
+
+// NewCycleHistory 打开循环记录文件
+func NewCycleHistory(path string) (*CycleHistory, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("创建循环记录目录失败: %w", err)
+	}
+	return &CycleHistory{path: path}, nil
+}
+
+// Record 追加一条循环记录
+func (h *CycleHistory) Record(record *CycleRecord) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("序列化循环记录失败: %w", err)
+	}
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	file, err := os.OpenFile(h.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("打开循环记录失败: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("写入循环记录失败: %w", err)
+	}
+	return nil
+}
+
+// CycleQuery 循环记录查询条件，零值表示不限制
+type CycleQuery struct {
+	From   time.Time
+	To     time.Time
+	Symbol string // 只返回处理过该标的的循环，且只保留该标的的记录
+	Limit  int    // 只返回最近的若干条
+}
+
+// Query 按开始时间查询循环记录（按时间升序）
+func (h *CycleHistory) Query(query CycleQuery) ([]CycleRecord, error) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	file, err := os.Open(h.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("打开循环记录失败: %w", err)
+	}
+	defer file.Close()
+
+	var records []CycleRecord
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		var record CycleRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			log.Printf("跳过无法解析的循环记录: 行=%d, 错误=%v", line, err)
+			continue
+		}
+		if !query.From.IsZero() && record.Start.Before(query.From) {
+			continue
+		}
+		if !query.To.IsZero() && record.Start.After(query.To) {
+			continue
+		}
+		if query.Symbol != "" && !record.keepSymbol(query.Symbol) {
+			continue
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取循环记录失败: %w", err)
+	}
+
+	if query.Limit > 0 && len(records) > query.Limit {
+		records = records[len(records)-query.Limit:]
+	}
+	return records, nil
+}
+
+// keepSymbol 只保留指定标的的处理记录，未处理该标的时返回 false
+func (r *CycleRecord) keepSymbol(symbol string) bool {
+	var kept []SymbolCycle
+	for _, s := range r.Symbols {
+		if s.Symbol == symbol {
+			kept = append(kept, s)
+		}
+	}
+	r.Symbols = kept
+	return len(kept) > 0
+}
+
+// symbol 添加标的处理记录
+func (r *CycleRecord) symbol(symbol string) *SymbolCycle {
+	r.Symbols = append(r.Symbols, SymbolCycle{Symbol: symbol})
+	return &r.Symbols[len(r.Symbols)-1]
+}
+
+// orderRecord 已执行订单的记录
+func orderRecord(order *trading.Order) OrderRecord {
+	return OrderRecord{
+		Strategy: order.Strategy,
+		Symbol:   order.Symbol,
+		Account:  order.AccountName,
+		OrderID:  order.ID,
+		Side:     string(order.Side),
+		Quantity: order.Quantity.String(),
+		Price:    order.Price.String(),
+		Status:   string(order.Status),
+		Paper:    order.Paper,
+		DryRun:   order.DryRun,
+
+		ClientOrderID: order.ClientOrderID,
+	}
+}
+
+// recordCycle 保存循环记录，未启用时忽略
+func (qe *QuantEngine) recordCycle(record *CycleRecord) {
+	if qe.history == nil {
+		return
+	}
+	if err := qe.history.Record(record); err != nil {
+		log.Printf("保存循环记录失败: %v", err)
+	}
+}
+
+// CycleHistory 查询交易循环记录
+func (qe *QuantEngine) CycleHistory(query CycleQuery) ([]CycleRecord, error) {
+	if qe.history == nil {
+		return nil, fmt.Errorf("未启用循环记录（engine.history_file 为空）")
+	}
+	return qe.history.Query(query)
+}
@@ -0,0 +1,87 @@
+package core
+
+import (
+	"log"
+	"time"
+
+	"agent-quant-system/internal/data"
+	"agent-quant-system/internal/instrument"
+	"agent-quant-system/internal/money"
+	"agent-quant-system/internal/strategy"
+)
+
+// optionSignals 为本轮运行的期权策略准备标的的持仓和期权链，返回其生成的标的与期权信号
+func (qe *QuantEngine) optionSignals(symbol string, strategies []string, price float64) []strategy.TradingSignal {
+	var signals []strategy.TradingSignal
+	for _, name := range strategies {
+		s, err := qe.strategyManager.GetStrategy(name)
+		if err != nil {
+			continue
+		}
+		planner, ok := s.(strategy.OptionStrategy)
+		if !ok || !planner.AppliesTo(symbol) {
+			continue
+		}
+
+		ctx, err := qe.optionContext(name, symbol, price)
+		if err != nil {
+			log.Printf("准备期权策略数据失败: 策略=%s, 标的=%s, 错误=%v", name, symbol, err)
+			continue
+		}
+		strategySignals, err := planner.OptionSignals(ctx)
+		if err != nil {
+			log.Printf("期权策略执行失败: 策略=%s, 标的=%s, 错误=%v", name, symbol, err)
+		}
+		for i := range strategySignals {
+			strategySignals[i].Strategy = name
+		}
+		log.Printf("期权策略 %s 在 %s 上生成 %d 个信号", name, symbol, len(strategySignals))
+		signals = append(signals, strategySignals...)
+	}
+	return signals
+}
+
+// optionContext 期权策略路由账户中标的及其期权的持仓、可交易的到期日和期权链
+func (qe *QuantEngine) optionContext(strategyName, symbol string, price float64) (strategy.OptionContext, error) {
+	ctx := strategy.OptionContext{
+		Underlying: symbol,
+		Price:      price,
+		Multiplier: qe.currentConfig().Instruments.Options.Multiplier,
+		Now:        time.Now(),
+		Chain: func(expiry time.Time) (*data.OptionChain, error) {
+			return qe.dataManager.GetOptionChain(symbol, expiry)
+		},
+	}
+
+	if accountName := qe.tradingEngine.RouteAccount(strategyName); accountName != "" {
+		positions, err := qe.tradingEngine.GetAccountPositions(accountName)
+		if err != nil {
+			return ctx, err
+		}
+		for held, position := range positions {
+			if held == symbol {
+				ctx.Position = money.Float(position.Quantity)
+				continue
+			}
+			option, ok := instrument.ParseOption(held)
+			if !ok || option.Underlying != symbol {
+				continue
+			}
+			ctx.Options = append(ctx.Options, strategy.OptionPosition{
+				Symbol:   held,
+				Strike:   money.Float(option.Strike),
+				Expiry:   option.Expiry,
+				Right:    option.Right,
+				Quantity: money.Float(position.Quantity),
+				AvgPrice: money.Float(position.AvgPrice),
+			})
+		}
+	}
+
+	expiries, err := qe.dataManager.GetOptionExpiries(symbol)
+	if err != nil {
+		return ctx, err
+	}
+	ctx.Expiries = expiries
+	return ctx, nil
+}
@@ -0,0 +1,71 @@
+package core
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"agent-quant-system/internal/money"
+	"agent-quant-system/internal/trading"
+)
+
+// PnLSummary 盈亏汇总，合计金额按各账户计价币种折算为报告币种
+type PnLSummary struct {
+	RealizedPnL   float64            `json:"realized_pnl"`
+	UnrealizedPnL float64            `json:"unrealized_pnl"`
+	TotalPnL      float64            `json:"total_pnl"`
+	Report        *trading.PnLReport `json:"report"` // 按持仓、账户、策略的明细（账户计价币种）
+}
+
+// pnlSummary 计算盈亏并折算为报告币种，折算失败的账户不计入合计
+func (qe *QuantEngine) pnlSummary() *PnLSummary {
+	report := qe.tradingEngine.GetPnL()
+	summary := &PnLSummary{Report: report}
+
+	accounts := qe.accountManager.GetAllAccountStatuses()
+	for name, totals := range report.ByAccount {
+		currency := ""
+		if accountStatus, exists := accounts[name]; exists {
+			currency = accountStatus.Currency
+		}
+		realized, err := qe.fxService.ToReporting(money.Float(totals.RealizedPnL), currency)
+		if err != nil {
+			log.Printf("账户 '%s' 盈亏折算失败: %v", name, err)
+			continue
+		}
+		unrealized, err := qe.fxService.ToReporting(money.Float(totals.UnrealizedPnL), currency)
+		if err != nil {
+			log.Printf("账户 '%s' 盈亏折算失败: %v", name, err)
+			continue
+		}
+		summary.RealizedPnL += realized
+		summary.UnrealizedPnL += unrealized
+	}
+	summary.TotalPnL = summary.RealizedPnL + summary.UnrealizedPnL
+	return summary
+}
+
+// TotalEquity 所有账户的权益（余额加持仓市值）折算为报告币种的合计；获取或折算失败的账户不计入合计并返回错误
+func (qe *QuantEngine) TotalEquity() (float64, error) {
+	var total float64
+	var failed []string
+	for name, accountStatus := range qe.accountManager.GetAllAccountStatuses() {
+		equity, err := qe.tradingEngine.GetAccountEquity(name)
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", name, err))
+			continue
+		}
+		reporting, err := qe.fxService.ToReporting(money.Float(equity), accountStatus.Currency)
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", name, err))
+			continue
+		}
+		total += reporting
+	}
+	if len(failed) > 0 {
+		sort.Strings(failed)
+		return total, fmt.Errorf("部分账户权益未计入: %s", strings.Join(failed, "; "))
+	}
+	return total, nil
+}
@@ -0,0 +1,105 @@
+package core
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"agent-quant-system/internal/money"
+	"agent-quant-system/internal/portfolio"
+	"agent-quant-system/internal/strategy"
+)
+
+// RebalancePlan 组合调仓计划
+type RebalancePlan struct {
+	Time       time.Time                `json:"time"`
+	Account    string                   `json:"account"`
+	Equity     float64                  `json:"equity"`
+	Allocation *portfolio.Allocation    `json:"allocation"`
+	Trades     []portfolio.Trade        `json:"trades"`
+	Signals    []strategy.TradingSignal `json:"signals"` // 需要执行的调仓信号
+}
+
+// PlanRebalance 按观察列表的历史收益率计算目标权重，并按调仓账户的权益和持仓生成调仓计划（不下单）
+func (qe *QuantEngine) PlanRebalance() (*RebalancePlan, error) {
+	cfg := qe.currentConfig().Portfolio
+	symbols := qe.watchlist.Symbols()
+	if len(symbols) == 0 {
+		return nil, fmt.Errorf("观察列表为空")
+	}
+
+	end := time.Now()
+	start := end.AddDate(0, 0, -cfg.LookbackDays)
+	returns, err := qe.dataManager.GetAlignedReturns(symbols, start.Format("2006-01-02"), end.Format("2006-01-02"))
+	if err != nil {
+		return nil, fmt.Errorf("获取收益率失败: %w", err)
+	}
+	allocation, err := portfolio.Optimize(returns, portfolio.Options{
+		Method:       cfg.Method,
+		RiskAversion: cfg.RiskAversion,
+		MaxWeight:    cfg.MaxWeight,
+		Budget:       cfg.Budget,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("计算目标权重失败: %w", err)
+	}
+
+	accountName := qe.tradingEngine.RouteAccount(portfolio.StrategyName)
+	equity, err := qe.tradingEngine.GetAccountEquity(accountName)
+	if err != nil {
+		return nil, fmt.Errorf("获取账户权益失败: %w", err)
+	}
+	positions, err := qe.tradingEngine.GetAccountPositions(accountName)
+	if err != nil {
+		return nil, fmt.Errorf("获取账户持仓失败: %w", err)
+	}
+
+	holdings := make(map[string]portfolio.Holding, len(symbols))
+	for _, symbol := range symbols {
+		price, err := qe.dataManager.GetLatestPrice(symbol)
+		if err != nil {
+			log.Printf("获取 %s 最新价格失败，本次不调整: %v", symbol, err)
+		}
+		holdings[symbol] = portfolio.Holding{Quantity: money.Float(positions[symbol].Quantity), Price: price}
+	}
+
+	plan := &RebalancePlan{
+		Time:       time.Now(),
+		Account:    accountName,
+		Equity:     money.Float(equity),
+		Allocation: allocation,
+	}
+	plan.Trades, plan.Signals, err = portfolio.Rebalance(allocation, plan.Equity, holdings, portfolio.RebalanceOptions{
+		Threshold:     cfg.RebalanceThreshold,
+		MinTradeValue: cfg.MinTradeValue,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// rebalancePortfolio 启用组合优化且距上次调仓超过间隔时执行调仓
+func (qe *QuantEngine) rebalancePortfolio(record *CycleRecord) {
+	cfg := qe.currentConfig().Portfolio
+	if !cfg.Enabled || time.Since(qe.lastRebalance) < cfg.RebalanceInterval {
+		return
+	}
+
+	plan, err := qe.PlanRebalance()
+	if err != nil {
+		log.Printf("组合调仓失败: %v", err)
+		record.Errors = append(record.Errors, fmt.Sprintf("组合调仓: %v", err))
+		return
+	}
+	qe.lastRebalance = plan.Time
+
+	if len(plan.Signals) == 0 {
+		log.Printf("组合权重偏离未超过阈值，无需调仓")
+		return
+	}
+	log.Printf("组合调仓: 账户=%s, 方法=%s, 信号 %d 个", plan.Account, plan.Allocation.Method, len(plan.Signals))
+	executed, orders := qe.executeTrades(plan.Signals)
+	qe.stats.ExecutedTrades += executed
+	record.Rebalance = orders
+}
@@ -1,33 +1,75 @@
 package core
 
 import (
+	"errors"
 	"fmt"
 	"log"
+	"os"
+	"path/filepath"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"agent-quant-system/internal/account"
 	"agent-quant-system/internal/agent"
 	"agent-quant-system/internal/backtest"
+	"agent-quant-system/internal/commission"
 	"agent-quant-system/internal/config"
 	"agent-quant-system/internal/data"
+	"agent-quant-system/internal/fx"
+	"agent-quant-system/internal/instrument"
+	"agent-quant-system/internal/money"
+	"agent-quant-system/internal/news"
+	"agent-quant-system/internal/notify"
+	"agent-quant-system/internal/scanner"
+	"agent-quant-system/internal/schedule"
+	"agent-quant-system/internal/secrets"
+	"agent-quant-system/internal/sizing"
+	"agent-quant-system/internal/slippage"
 	"agent-quant-system/internal/strategy"
 	"agent-quant-system/internal/trading"
+
+	"github.com/shopspring/decimal"
 )
 
 // QuantEngine 量化引擎
 type QuantEngine struct {
-	config          *config.Config
+	config          atomic.Pointer[config.Config] // 当前配置，热加载时整体替换，通过 currentConfig 读取
 	dataManager     *data.DataManager
+	instruments     *instrument.Registry
 	strategyManager *strategy.StrategyManager
 	agentClient     agent.ClientInterface
 	tradingEngine   *trading.TradingEngine
 	accountManager  *account.AccountManager
+	positionMonitor *trading.PositionMonitor
+	fxService       *fx.Service
+	newsFetcher     *news.Fetcher
+	scanner         *scanner.Scanner
+	watchlist       *scanner.Watchlist
+	scheduler       *schedule.Scheduler
+	degradation     *degradation
+	notifier        *notify.Dispatcher
+	history         *CycleHistory        // 未配置 engine.history_file 时为nil
+	guidance        *GuidanceJournal     // 未配置 engine.guidance_file 时为nil
+	analysisPool    *analysisPool        // 未启用 agent_service.async 时为nil
+	replay          *data.ReplayProvider // 回放模式下的回放数据源，实盘运行时为nil
+	signalLog       *signalLog           // 未启用 logging.signals 时为nil
+
+	// 本轮循环拉取的新闻及最近一次新闻发现的标的
+	cycleArticles []news.Article
+	discovered    []news.Mention
 
 	isRunning bool
 	mutex     sync.RWMutex
 	stopChan  chan struct{}
 
+	// 配置热加载：一轮循环执行期间持有 cycleMutex，热加载在两轮循环之间替换配置
+	cycleMutex sync.Mutex
+	reloadBase *config.Config // 最近一次从文件加载的配置，用于判断修改了哪些配置项
+
+	lastRebalance time.Time // 最近一次组合调仓的时间
+
 	// 统计信息
 	stats *EngineStats
 }
@@ -42,51 +84,178 @@ type EngineStats struct {
 	TotalSignals     int       `json:"total_signals"`
 	ExecutedTrades   int       `json:"executed_trades"`
 	TotalPnL         float64   `json:"total_pnl"`
+
+	// 循环耗时统计
+	LastCycleDuration time.Duration `json:"last_cycle_duration"`
+	MaxCycleDuration  time.Duration `json:"max_cycle_duration"`
+	OverrunCycles     int           `json:"overrun_cycles"`
+	SkippedTicks      int           `json:"skipped_ticks"`
 }
 
 // NewQuantEngine 创建量化引擎
 func NewQuantEngine(cfg *config.Config) (*QuantEngine, error) {
 	log.Printf("初始化量化引擎")
 
-	// 创建数据管理器
-	dataManager := data.NewDataManager()
+	// 解析配置中的密钥引用
+	resolver := secrets.NewResolver(cfg.Secrets)
+	resolveAPIKeys(&cfg.APIKeys, resolver)
+	if err := resolveAgentAuth(&cfg.AgentService.Auth, resolver); err != nil {
+		return nil, err
+	}
+	if err := resolveAPIToken(&cfg.API.Auth, resolver); err != nil {
+		return nil, err
+	}
+
+	// 创建数据管理器，注册真实行情数据源供 data.providers 选用和运行时切换
+	dataManager, err := data.NewDataManagerFromConfig(cfg.Data, data.VendorProviders(cfg.APIKeys)...)
+	if err != nil {
+		return nil, fmt.Errorf("创建数据管理器失败: %w", err)
+	}
+
+	// 期货品种规格：连续合约行情拼接、合约乘数和自动移仓
+	instruments, err := instrument.NewRegistry(cfg.Instruments)
+	if err != nil {
+		return nil, fmt.Errorf("创建合约规格表失败: %w", err)
+	}
+	dataManager.SetInstruments(instruments)
 
 	// 创建策略管理器
 	strategyManager := strategy.NewStrategyManager()
+	if cfg.Strategy.PluginDir != "" {
+		loaded, err := strategyManager.LoadPlugins(cfg.Strategy.PluginDir)
+		if err != nil {
+			log.Printf("加载策略插件出错: %v", err)
+		}
+		log.Printf("已从 %s 加载 %d 个策略插件", cfg.Strategy.PluginDir, loaded)
+	}
+	if err := registerComposites(strategyManager, cfg.Strategy.Composites); err != nil {
+		return nil, err
+	}
+	if err := strategyManager.SetIncremental(cfg.Strategy.Incremental); err != nil {
+		return nil, fmt.Errorf("strategy.incremental 配置无效: %w", err)
+	}
+	strategyManager.SetGuidancePolicy(guidancePolicy(cfg.Strategy.Guidance))
 
 	// 创建账户管理器
-	accountManager := account.NewAccountManager(cfg)
+	accountManager := account.NewAccountManager(cfg, resolver)
 
 	// 创建交易引擎
 	tradingEngine := trading.NewTradingEngine(cfg, accountManager)
+	tradingEngine.SetPriceSource(dataManager) // 平仓时使用实时价格
+	tradingEngine.SetInstruments(instruments)
+	dataManager.SetSymbols(tradingEngine.Symbols()) // 请求数据源时按数据源的写法转换标的
+
+	// 创建持仓监控
+	positionMonitor := trading.NewPositionMonitor(tradingEngine, dataManager,
+		cfg.Trading.MonitorInterval, cfg.Trading.TrailingStopPercent)
+
+	// 创建汇率服务
+	fxService := fx.NewServiceFromConfig(cfg.FX)
+
+	// 创建观察列表和标的扫描器
+	watchlist := scanner.NewWatchlist(cfg.Scanner.Watchlist)
+	var symbolScanner *scanner.Scanner
+	if cfg.Scanner.Enabled {
+		symbolScanner = scanner.NewScanner(cfg.Scanner, dataManager)
+	}
+	if cfg.News.Discovery.Enabled && cfg.News.Discovery.AddToScanner && symbolScanner == nil {
+		log.Printf("未启用标的扫描器，新闻发现的标的只做展示，不加入标的池")
+	}
+
+	// 创建策略/标的交易时间表
+	scheduler, err := schedule.NewScheduler(cfg.Strategy.Schedules, cfg.Strategy.SymbolSchedules)
+	if err != nil {
+		return nil, fmt.Errorf("交易时间表配置无效: %w", err)
+	}
+	if _, err := strategyTimeframes(cfg.Strategy.Timeframes); err != nil {
+		return nil, err
+	}
+
+	// 创建告警通知
+	notifier := notify.NewDispatcherFromConfig(cfg.Notifications)
+	if notifier == nil && (cfg.API.Enabled || cfg.Engine.Reload.Enabled) {
+		// 控制API的事件流和热加载通知渠道需要分发器，未配置通知渠道时只分发给订阅者
+		notifier = notify.NewDispatcher(nil, nil, cfg.Notifications.QueueSize)
+	}
+	tradingEngine.SetNotifier(notifier)
 
 	// 创建Agent客户端
-	agentClient := agent.CreateClient(cfg.AgentService.URL, false) // 使用真实客户端
+	agentClient, err := newAgentClient(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("创建Agent客户端失败: %w", err)
+	}
 
 	engine := &QuantEngine{
-		config:          cfg,
 		dataManager:     dataManager,
+		instruments:     instruments,
 		strategyManager: strategyManager,
 		agentClient:     agentClient,
 		tradingEngine:   tradingEngine,
 		accountManager:  accountManager,
+		positionMonitor: positionMonitor,
+		fxService:       fxService,
+		newsFetcher:     news.NewFetcherFromConfig(cfg.News, cfg.APIKeys),
+		scanner:         symbolScanner,
+		watchlist:       watchlist,
+		scheduler:       scheduler,
+		degradation:     newDegradation(notifier),
+		notifier:        notifier,
 		isRunning:       false,
 		stopChan:        make(chan struct{}),
 		stats: &EngineStats{
 			StartTime: time.Now(),
 		},
 	}
+	engine.config.Store(cfg)
+
+	if cfg.Engine.HistoryFile != "" {
+		history, err := NewCycleHistory(cfg.Engine.HistoryFile)
+		if err != nil {
+			log.Printf("打开循环记录失败，将不记录交易循环: %v", err)
+		} else {
+			engine.history = history
+		}
+	}
+	if cfg.AgentService.Async.Enabled {
+		engine.analysisPool = newAnalysisPool(cfg.AgentService.Async, engine.recordAnalysis)
+	}
+	if cfg.Engine.GuidanceFile != "" {
+		journal, err := NewGuidanceJournal(cfg.Engine.GuidanceFile)
+		if err != nil {
+			log.Printf("打开指导记录失败，将不记录Agent指导: %v", err)
+		} else {
+			engine.guidance = journal
+		}
+	}
+
+	if err := engine.applyStrategyParameters(cfg.Strategy.Parameters); err != nil {
+		return nil, fmt.Errorf("策略参数配置无效: %w", err)
+	}
 
-	// 验证Agent服务连接
+	signalLog, err := newSignalLog(cfg.Logging.Signals)
+	if err != nil {
+		log.Printf("创建信号日志失败，将不记录信号日志: %v", err)
+	}
+	engine.signalLog = signalLog
+
+	// 验证Agent服务连接，失败时按降级配置处理；除 mock 外仍保留真实客户端，服务恢复后自动生效
 	if err := engine.agentClient.HealthCheck(); err != nil {
-		log.Printf("Agent服务连接失败，将使用模拟客户端: %v", err)
-		engine.agentClient = agent.CreateClient(cfg.AgentService.URL, true)
+		engine.degradation.markDegraded(DependencyAgent, cfg.Degradation.Agent, err)
+		if cfg.Degradation.Agent == "mock" {
+			log.Printf("Agent服务连接失败，将使用模拟客户端")
+			engine.agentClient = agent.CreateClient(cfg.AgentService.URL, true)
+		}
 	}
 
 	log.Printf("量化引擎初始化完成")
 	return engine, nil
 }
 
+// currentConfig 当前配置。热加载时整体替换为新的配置（见 ApplyConfig），返回的配置不会再被修改
+func (qe *QuantEngine) currentConfig() *config.Config {
+	return qe.config.Load()
+}
+
 // Start 启动量化引擎
 func (qe *QuantEngine) Start() error {
 	qe.mutex.Lock()
@@ -103,6 +272,14 @@ func (qe *QuantEngine) Start() error {
 		return fmt.Errorf("启动交易引擎失败: %w", err)
 	}
 
+	// 启动持仓监控
+	qe.positionMonitor.Start()
+
+	if qe.analysisPool != nil {
+		qe.analysisPool.start()
+	}
+
+	qe.stopChan = make(chan struct{})
 	qe.isRunning = true
 	qe.stats.StartTime = time.Now()
 
@@ -124,6 +301,13 @@ func (qe *QuantEngine) Stop() error {
 	// 发送停止信号
 	close(qe.stopChan)
 
+	// 停止持仓监控（需在交易引擎关闭下单队列之前）
+	qe.positionMonitor.Stop()
+
+	if qe.analysisPool != nil {
+		qe.analysisPool.stop()
+	}
+
 	// 停止交易引擎
 	if err := qe.tradingEngine.Stop(); err != nil {
 		log.Printf("停止交易引擎失败: %v", err)
@@ -135,43 +319,226 @@ func (qe *QuantEngine) Stop() error {
 	return nil
 }
 
-// RunSingleLoop 运行单次循环
+// RunSingleLoop 运行单次循环，依次处理观察列表中的每个标的
 func (qe *QuantEngine) RunSingleLoop() error {
 	log.Printf("开始执行单次交易循环")
 
+	qe.cycleMutex.Lock()
+	defer qe.cycleMutex.Unlock()
+
+	record := &CycleRecord{ID: qe.stats.TotalCycles + 1, Start: time.Now()}
+	if qe.replay != nil {
+		record.Replay = qe.replay.Now()
+	}
+
+	// 紧急停止期间不分析也不下单，恢复后继续
+	if halt := qe.tradingEngine.GetHaltState(); halt.Halted {
+		log.Printf("交易已紧急停止，跳过本轮循环: %s", halt.Reason)
+		record.Status = CycleHalted
+		record.Errors = []string{halt.Reason}
+		qe.recordCycle(record)
+		return nil
+	}
+
 	qe.stats.TotalCycles++
 	qe.stats.LastUpdateTime = time.Now()
 
+	// 循环结束后（包括panic）保存循环记录并检查紧急停止条件
+	defer func(failedBefore int) {
+		success := qe.stats.FailedCycles == failedBefore
+		record.Duration = time.Since(record.Start)
+		record.Status = CycleSucceeded
+		if !success {
+			record.Status = CycleFailed
+		}
+		qe.recordCycle(record)
+		qe.signalLog.endCycle(record.ID)
+		summary := qe.pnlSummary()
+		qe.stats.TotalPnL = summary.TotalPnL
+		qe.tradingEngine.SuperviseStrategies(summary.Report)
+		qe.tradingEngine.RecordCycle(success)
+	}(qe.stats.FailedCycles)
+
 	defer func() {
 		if r := recover(); r != nil {
 			qe.stats.FailedCycles++
+			record.Errors = append(record.Errors, fmt.Sprintf("panic: %v", r))
 			log.Printf("交易循环发生panic: %v", r)
+			qe.notifier.Notifyf(notify.EventCycleFailed, "交易循环发生panic", "%v", r)
 		}
 	}()
 
-	// 1. 模拟获取新闻数据
-	newsItems := qe.getMockNews()
+	// 扫描标的池，更新观察列表
+	qe.refreshWatchlist()
+
+	symbols := qe.watchlist.Symbols()
+	record.Watchlist = symbols
+	if len(symbols) == 0 {
+		qe.stats.FailedCycles++
+		record.Errors = append(record.Errors, "观察列表为空")
+		return fmt.Errorf("观察列表为空")
+	}
+
+	qe.cycleArticles = nil
+	var errs []error
+	for _, symbol := range symbols {
+		symbolStart := time.Now()
+		symbolRecord := record.symbol(symbol)
+		if err := qe.runSymbol(symbol, symbolRecord); err != nil {
+			log.Printf("标的 %s 交易循环失败: %v", symbol, err)
+			errs = append(errs, fmt.Errorf("%s: %w", symbol, err))
+			symbolRecord.Error = err.Error()
+		}
+		symbolRecord.Duration = time.Since(symbolStart)
+	}
+
+	// 重新提交经纪商不可用时排队的信号，本轮策略已重新给出信号的标的不再提交旧信号
+	if deferred := qe.takeDeferredSignals(); len(deferred) > 0 {
+		log.Printf("重新提交 %d 个排队信号", len(deferred))
+		executed, orders := qe.executeTrades(deferred)
+		qe.stats.ExecutedTrades += executed
+		record.Deferred = orders
+	}
+
+	// 从本轮新闻中发现新标的，下一轮扫描时生效
+	qe.discoverSymbols(symbols)
+
+	// 按组合目标权重调仓
+	qe.rebalancePortfolio(record)
+
+	// 只有全部标的失败才视为循环失败
+	if len(errs) == len(symbols) {
+		qe.stats.FailedCycles++
+		err := errors.Join(errs...)
+		qe.notifier.Notifyf(notify.EventCycleFailed, "交易循环失败", "全部 %d 个标的失败:\n%v", len(symbols), err)
+		return err
+	}
+
+	qe.stats.SuccessfulCycles++
+	log.Printf("交易循环执行完成: 标的数=%d, 失败=%d", len(symbols), len(errs))
+	return nil
+}
+
+// refreshWatchlist 运行标的扫描并更新观察列表
+func (qe *QuantEngine) refreshWatchlist() {
+	if qe.scanner == nil {
+		return
+	}
+
+	candidates, err := qe.scanner.Scan()
+	if err != nil {
+		log.Printf("标的扫描失败，保留上次观察列表: %v", err)
+		return
+	}
+
+	symbols := make([]string, 0, len(candidates))
+	for _, candidate := range candidates {
+		symbols = append(symbols, candidate.Symbol)
+	}
+	qe.watchlist.SetPromoted(symbols)
+}
+
+// discoverSymbols 从新闻中发现观察列表之外被频繁提及的标的，按配置加入扫描器标的池
+func (qe *QuantEngine) discoverSymbols(known []string) {
+	discovery := qe.currentConfig().News.Discovery
+	if !discovery.Enabled || qe.newsFetcher == nil {
+		return
+	}
+
+	articles := qe.cycleArticles
+	for _, query := range discovery.Queries {
+		queryArticles, err := qe.newsFetcher.FetchArticles(query)
+		if err != nil {
+			log.Printf("获取综合新闻失败: 查询=%s, 错误=%v", query, err)
+			continue
+		}
+		articles = append(articles, queryArticles...)
+	}
+
+	mentions := news.DiscoverSymbols(articles, known, discovery.MinMentions, discovery.MaxSymbols)
+
+	qe.mutex.Lock()
+	qe.discovered = mentions
+	qe.mutex.Unlock()
+
+	if len(mentions) == 0 {
+		return
+	}
+
+	symbols := make([]string, 0, len(mentions))
+	for _, mention := range mentions {
+		symbols = append(symbols, mention.Symbol)
+	}
+	log.Printf("新闻发现观察列表之外的标的: %s", strings.Join(symbols, ", "))
+
+	if discovery.AddToScanner && qe.scanner != nil {
+		qe.scanner.AddCandidates(symbols, discovery.Probation)
+	}
+}
+
+// runSymbol 对单个标的执行 新闻 -> Agent分析 -> 行情 -> 策略 -> 交易 流程，过程写入 record
+func (qe *QuantEngine) runSymbol(symbol string, record *SymbolCycle) error {
+	// 按时间表筛选本轮运行的策略，没有可运行的策略时跳过该标的
+	now := qe.now()
+	var strategies []string
+	for _, name := range qe.currentConfig().Strategy.Active {
+		if ok, reason := qe.scheduler.Allowed(name, symbol, now); !ok {
+			log.Printf("策略 %s 对标的 %s 不在运行时间内: %s", name, symbol, reason)
+			record.Decisions = append(record.Decisions, DecisionRecord{Strategy: name, Outcome: DecisionSkipped, Notes: []string{reason}})
+			continue
+		}
+		if disabled, ok := qe.tradingEngine.StrategyDisabled(name); ok {
+			note := "策略已停用: " + disabled.Reason
+			record.Decisions = append(record.Decisions, DecisionRecord{Strategy: name, Outcome: DecisionSkipped, Notes: []string{note}})
+			continue
+		}
+		strategies = append(strategies, name)
+	}
+	record.Strategies = strategies
+	if len(strategies) == 0 {
+		return nil
+	}
+
+	// 1. 获取新闻数据
+	newsItems, err := qe.fetchNews(symbol)
+	if err != nil {
+		return err
+	}
 	log.Printf("获取到 %d 条新闻", len(newsItems))
+	record.News = len(newsItems)
 
 	// 2. 调用Agent分析新闻
-	symbol := "AAPL" // 默认标的
-	analysis, err := qe.agentClient.AnalyzeNews(symbol, newsItems)
+	analysis, err := qe.analyzeNews(symbol, newsItems)
 	if err != nil {
-		qe.stats.FailedCycles++
-		return fmt.Errorf("Agent分析失败: %w", err)
+		return err
 	}
 	log.Printf("Agent分析完成: 情绪=%s, 置信度=%.2f, 原因=%s",
 		analysis.Sentiment, analysis.ConfidenceScore, analysis.Reason)
+	record.Guidance = &GuidanceRecord{
+		Sentiment:  analysis.Sentiment,
+		Confidence: analysis.ConfidenceScore,
+		Reason:     analysis.Reason,
+	}
+	var guided []*GuidedDecision
+	defer func() { qe.recordGuidance(now, analysis, record, guided) }()
+	qe.signalLog.record(signalLogAgent, qe.stats.TotalCycles, symbol, false, map[string]interface{}{
+		"analysis_id": analysis.AnalysisID,
+		"sentiment":   analysis.Sentiment,
+		"confidence":  analysis.ConfidenceScore,
+		"reason":      analysis.Reason,
+		"news":        newsItems,
+	})
 
 	// 3. 获取市场数据
-	df, err := qe.dataManager.GetMarketData(symbol,
-		time.Now().AddDate(0, 0, -30).Format("2006-01-02"),
-		time.Now().Format("2006-01-02"))
+	df, err := qe.getMarketData(symbol)
 	if err != nil {
-		qe.stats.FailedCycles++
-		return fmt.Errorf("获取市场数据失败: %w", err)
+		return err
 	}
 	log.Printf("获取到 %d 条市场数据", len(df["close"]))
+	record.Bars = len(df["close"])
+	if closes := df["close"]; len(closes) > 0 {
+		record.LastClose, _ = closes[len(closes)-1].(float64)
+	}
 
 	// 4. 转换Agent指导为策略指导
 	guidance := &strategy.AgentGuidance{
@@ -181,28 +548,74 @@ func (qe *QuantEngine) RunSingleLoop() error {
 		Timestamp:  analysis.Timestamp,
 		Symbol:     symbol,
 	}
+	guidance.Setup = qe.tradeSetup(symbol, strategies, newsItems, record.LastClose)
 
 	// 5. 生成交易信号
-	signals, err := qe.strategyManager.ExecuteStrategy("ma_cross", df, guidance)
-	if err != nil {
-		qe.stats.FailedCycles++
-		return fmt.Errorf("策略执行失败: %w", err)
+	var signals []strategy.TradingSignal
+	var errs []error
+	frames := make(map[time.Duration]data.DataFrame)
+	for _, name := range strategies {
+		frame, err := qe.strategyFrame(name, df, frames)
+		if err != nil {
+			record.Decisions = append(record.Decisions, DecisionRecord{Strategy: name, Outcome: DecisionFailed, Error: err.Error()})
+			errs = append(errs, fmt.Errorf("策略 %s 重采样K线失败: %w", name, err))
+			continue
+		}
+		strategySignals, err := qe.strategyManager.ExecuteStrategy(name, frame, guidance)
+		record.Decisions = append(record.Decisions, qe.decisionRecord(name, frame, guidance, strategySignals, err))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("策略 %s 执行失败: %w", name, err))
+			continue
+		}
+		if decision := qe.guidedDecision(name, frame, guidance, strategySignals); decision != nil {
+			guided = append(guided, decision)
+		}
+		signals = append(signals, strategySignals...)
+	}
+	if len(errs) == len(strategies) {
+		return errors.Join(errs...)
+	}
+	for _, err := range errs {
+		log.Printf("%v", err)
 	}
+	signals = qe.aggregateEnsemble(symbol, strategies, signals, record)
+	signals = append(signals, qe.optionSignals(symbol, strategies, record.LastClose)...)
 	log.Printf("策略生成 %d 个交易信号", len(signals))
+	qe.syncGrids(symbol, strategies)
 
 	qe.stats.TotalSignals += len(signals)
-
-	// 6. 执行交易
 	for _, signal := range signals {
-		if err := qe.executeTrade(signal); err != nil {
-			log.Printf("执行交易失败: %v", err)
-			continue
-		}
-		qe.stats.ExecutedTrades++
+		record.Signals = append(record.Signals, SignalRecord{
+			Strategy:   signal.Strategy,
+			Signal:     signal.Signal.String(),
+			Quantity:   signal.Quantity,
+			Price:      signal.Price,
+			Confidence: signal.Confidence,
+			Reason:     signal.Reason,
+		})
+		qe.signalLog.record(signalLogSignal, qe.stats.TotalCycles, symbol, signal.Signal != strategy.Hold, map[string]interface{}{
+			"strategy":    signal.Strategy,
+			"signal":      signal.Signal.String(),
+			"quantity":    signal.Quantity,
+			"price":       signal.Price,
+			"confidence":  signal.Confidence,
+			"stop_loss":   signal.StopLoss,
+			"take_profit": signal.TakeProfit,
+			"reason":      signal.Reason,
+		})
 	}
 
-	qe.stats.SuccessfulCycles++
-	log.Printf("交易循环执行完成")
+	// 排队中的同一标的、同一策略的旧信号被本轮信号取代
+	qe.degradation.supersede(signals)
+
+	// 6. 组合构建：按与持仓的相关性拒绝或缩减买入信号
+	signals = qe.filterCorrelated(symbol, signals)
+
+	// 7. 执行交易（并发提交，同一标的保持顺序）
+	executed, orders := qe.executeTrades(signals)
+	qe.stats.ExecutedTrades += executed
+	record.Orders = orders
+
 	return nil
 }
 
@@ -210,47 +623,130 @@ func (qe *QuantEngine) RunSingleLoop() error {
 func (qe *QuantEngine) RunContinuous(interval time.Duration) error {
 	log.Printf("开始连续运行，间隔: %v", interval)
 
+	qe.mutex.RLock()
+	stopChan := qe.stopChan
+	qe.mutex.RUnlock()
+
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
 	for {
 		select {
-		case <-qe.stopChan:
+		case <-stopChan:
 			log.Printf("收到停止信号，退出连续运行")
 			return nil
 		case <-ticker.C:
+			cycleStart := time.Now()
 			if err := qe.RunSingleLoop(); err != nil {
 				log.Printf("交易循环执行失败: %v", err)
 			}
+			qe.handleCycleDuration(time.Since(cycleStart), interval, ticker)
 		}
 	}
 }
 
-// executeTrade 执行交易
-func (qe *QuantEngine) executeTrade(signal strategy.TradingSignal) error {
-	log.Printf("执行交易信号: %s %s %.2f @ %.2f",
-		signal.Symbol, signal.Signal.String(), signal.Quantity, signal.Price)
+// handleCycleDuration 记录循环耗时，并在超时时按配置处理积压的触发
+func (qe *QuantEngine) handleCycleDuration(duration, interval time.Duration, ticker *time.Ticker) {
+	qe.mutex.Lock()
+	defer qe.mutex.Unlock()
+
+	qe.stats.LastCycleDuration = duration
+	if duration > qe.stats.MaxCycleDuration {
+		qe.stats.MaxCycleDuration = duration
+	}
+
+	if duration <= interval {
+		return
+	}
+
+	qe.stats.OverrunCycles++
+	missedTicks := int(duration / interval)
+
+	switch qe.currentConfig().Engine.OverrunPolicy {
+	case "coalesce":
+		// 积压的触发只保留一个，下一次循环立即开始
+		if missedTicks > 1 {
+			qe.stats.SkippedTicks += missedTicks - 1
+		}
+		log.Printf("交易循环超时: 耗时=%v, 间隔=%v, 合并 %d 次触发后立即执行", duration, interval, missedTicks)
+	default:
+		// 丢弃积压的触发，等待下一个间隔
+		select {
+		case <-ticker.C:
+		default:
+		}
+		qe.stats.SkippedTicks += missedTicks
+		log.Printf("交易循环超时: 耗时=%v, 间隔=%v, 跳过 %d 次触发", duration, interval, missedTicks)
+	}
+}
 
-	// 选择账户（简化处理，使用第一个账户）
-	accounts := qe.accountManager.GetAllAccounts()
-	if len(accounts) == 0 {
-		return fmt.Errorf("没有可用的交易账户")
+// executeTrades 批量提交交易信号并等待结果，返回成功执行的数量和每个信号的执行记录
+func (qe *QuantEngine) executeTrades(signals []strategy.TradingSignal) (int, []OrderRecord) {
+	type pendingTrade struct {
+		signal     strategy.TradingSignal
+		resultChan <-chan trading.OrderResult
 	}
 
-	var accountName string
-	for name := range accounts {
-		accountName = name
-		break
+	var records []OrderRecord
+	pending := make([]pendingTrade, 0, len(signals))
+	for i, signal := range signals {
+		signal = qe.tradingEngine.ApplyDefaultStops(signal)
+		resultChan, err := qe.submitTrade(signal)
+		if err != nil {
+			log.Printf("执行交易失败: %v", err)
+			records = append(records, OrderRecord{Strategy: signal.Strategy, Symbol: signal.Symbol, Error: err.Error()})
+			if isBrokerUnavailable(err) && qe.handleBrokerFailure(signal, err) {
+				log.Printf("放弃本轮剩余 %d 个信号", len(signals)-i-1)
+				break
+			}
+			continue
+		}
+		pending = append(pending, pendingTrade{signal: signal, resultChan: resultChan})
 	}
 
-	// 执行交易
-	order, err := qe.tradingEngine.ExecuteSignal(signal, accountName)
+	executed := 0
+	for _, trade := range pending {
+		result := <-trade.resultChan
+		if result.Err != nil {
+			log.Printf("执行交易失败: 交易执行失败: %v", result.Err)
+			records = append(records, OrderRecord{Strategy: trade.signal.Strategy, Symbol: trade.signal.Symbol, Error: result.Err.Error()})
+			if isBrokerUnavailable(result.Err) {
+				qe.handleBrokerFailure(trade.signal, result.Err)
+			}
+			continue
+		}
+		qe.degradation.markHealthy(DependencyBroker)
+		log.Printf("交易执行成功: 订单ID=%s, 状态=%s", result.Order.ID, result.Order.Status)
+		records = append(records, orderRecord(result.Order))
+
+		// 登记止损止盈监控（纸面副本中的持仓不在实盘账户里，演练模式的订单没有持仓，不监控）
+		if !result.Order.Paper && !result.Order.DryRun {
+			qe.positionMonitor.TrackSignal(trade.signal, result.Order)
+		}
+		executed++
+	}
+
+	return executed, records
+}
+
+// submitTrade 提交交易信号到下单队列
+func (qe *QuantEngine) submitTrade(signal strategy.TradingSignal) (<-chan trading.OrderResult, error) {
+	log.Printf("执行交易信号: %s %s %.2f @ %.2f",
+		signal.Symbol, signal.Signal.String(), signal.Quantity, signal.Price)
+
+	// 按策略资金分配选择账户
+	accountName := qe.tradingEngine.RouteAccount(signal.Strategy)
+	if accountName == "" {
+		return nil, fmt.Errorf("没有可用的交易账户")
+	}
+
+	// 提交交易
+	resultChan, err := qe.tradingEngine.SubmitSignal(signal, accountName)
 	if err != nil {
-		return fmt.Errorf("交易执行失败: %w", err)
+		return nil, fmt.Errorf("提交交易失败: %w", err)
 	}
 
-	log.Printf("交易执行成功: 订单ID=%s, 状态=%s", order.ID, order.Status)
-	return nil
+	return resultChan, nil
 }
 
 // getMockNews 获取模拟新闻
@@ -291,52 +787,112 @@ func (qe *QuantEngine) GetStatus() *EngineStatus {
 		TotalSignals:     qe.stats.TotalSignals,
 		ExecutedTrades:   qe.stats.ExecutedTrades,
 		TotalPnL:         qe.stats.TotalPnL,
+
+		LastCycleDuration: qe.stats.LastCycleDuration,
+		MaxCycleDuration:  qe.stats.MaxCycleDuration,
+		OverrunCycles:     qe.stats.OverrunCycles,
+		SkippedTicks:      qe.stats.SkippedTicks,
 	}
 
-	// 获取账户状态
+	status.Watchlist = qe.watchlist.Symbols()
+	status.DiscoveredSymbols = qe.discovered
+	if qe.scanner != nil {
+		status.ScannerCandidates = qe.scanner.Candidates()
+	}
+	status.Dependencies = qe.degradation.snapshot()
+
+	// 获取账户状态，并折算为报告币种
 	status.Accounts = qe.accountManager.GetAllAccountStatuses()
+	status.ReportingCurrency = qe.fxService.ReportingCurrency()
+	for name, accountStatus := range status.Accounts {
+		reportingBalance, err := qe.fxService.ToReporting(accountStatus.Balance, accountStatus.Currency)
+		if err != nil {
+			log.Printf("账户 '%s' 余额折算失败: %v", name, err)
+			continue
+		}
+		accountStatus.ReportingBalance = reportingBalance
+		status.TotalBalance += reportingBalance
+	}
 
 	// 获取交易引擎状态
 	status.TradingStatus = qe.tradingEngine.GetTradingStatus()
+	status.Exposure = qe.tradingEngine.GetExposure()
 
-	// 获取策略状态
+	// 盈亏按最新价格实时计算
+	status.PnL = qe.pnlSummary()
+	status.TotalPnL = status.PnL.TotalPnL
+	if board, err := qe.tradingEngine.GetLeaderboard("", 0); err == nil {
+		status.Leaderboard = board
+	}
+
+	// 获取策略状态，附上按盈亏账本统计的实盘表现
 	status.Strategies = qe.strategyManager.GetAllStrategyStatuses()
+	if ensemble := qe.currentConfig().Strategy.Ensemble; ensemble.Enabled {
+		status.Strategies[ensemble.Name] = &strategy.StrategyStatus{
+			Name:        ensemble.Name,
+			IsActive:    true,
+			Description: fmt.Sprintf("策略组合（%s）: %s", ensemble.Method, strings.Join(ensemble.Strategies, ", ")),
+		}
+	}
+	for name, performance := range qe.tradingEngine.StrategyPerformance(status.PnL.Report) {
+		if strategyStatus, exists := status.Strategies[name]; exists {
+			strategyStatus.Performance = performance
+		}
+	}
+	for _, disabled := range qe.tradingEngine.DisabledStrategies() {
+		if strategyStatus, exists := status.Strategies[disabled.Strategy]; exists {
+			strategyStatus.IsActive = false
+			strategyStatus.DisabledReason = disabled.Reason
+		}
+	}
 
 	return status
 }
 
 // EngineStatus 引擎状态
 type EngineStatus struct {
-	IsRunning        bool                                `json:"is_running"`
-	StartTime        time.Time                           `json:"start_time"`
-	LastUpdateTime   time.Time                           `json:"last_update_time"`
-	TotalCycles      int                                 `json:"total_cycles"`
-	SuccessfulCycles int                                 `json:"successful_cycles"`
-	FailedCycles     int                                 `json:"failed_cycles"`
-	TotalSignals     int                                 `json:"total_signals"`
-	ExecutedTrades   int                                 `json:"executed_trades"`
-	TotalPnL         float64                             `json:"total_pnl"`
-	Accounts         map[string]*account.AccountStatus   `json:"accounts"`
-	TradingStatus    *trading.TradingStatus              `json:"trading_status"`
-	Strategies       map[string]*strategy.StrategyStatus `json:"strategies"`
+	IsRunning         bool                                `json:"is_running"`
+	StartTime         time.Time                           `json:"start_time"`
+	LastUpdateTime    time.Time                           `json:"last_update_time"`
+	TotalCycles       int                                 `json:"total_cycles"`
+	SuccessfulCycles  int                                 `json:"successful_cycles"`
+	FailedCycles      int                                 `json:"failed_cycles"`
+	TotalSignals      int                                 `json:"total_signals"`
+	ExecutedTrades    int                                 `json:"executed_trades"`
+	TotalPnL          float64                             `json:"total_pnl"`
+	LastCycleDuration time.Duration                       `json:"last_cycle_duration"`
+	MaxCycleDuration  time.Duration                       `json:"max_cycle_duration"`
+	OverrunCycles     int                                 `json:"overrun_cycles"`
+	SkippedTicks      int                                 `json:"skipped_ticks"`
+	ReportingCurrency string                              `json:"reporting_currency"`
+	TotalBalance      float64                             `json:"total_balance"` // 所有账户余额折算为报告币种的合计
+	Watchlist         []string                            `json:"watchlist"`
+	DiscoveredSymbols []news.Mention                      `json:"discovered_symbols,omitempty"` // 最近一次从新闻中发现的标的
+	ScannerCandidates []string                            `json:"scanner_candidates,omitempty"` // 观察期内的候选标的
+	Dependencies      map[Dependency]DependencyHealth     `json:"dependencies"`                 // 外部依赖状态及降级方式
+	Accounts          map[string]*account.AccountStatus   `json:"accounts"`
+	TradingStatus     *trading.TradingStatus              `json:"trading_status"`
+	Strategies        map[string]*strategy.StrategyStatus `json:"strategies"`
+	Exposure          *trading.ExposureReport             `json:"exposure"` // 全部账户合并后的组合敞口
+
+	PnL         *PnLSummary                `json:"pnl"`
+	Leaderboard *trading.LeaderboardReport `json:"leaderboard,omitempty"` // 未启用策略排行榜时为nil
+}
+
+// FlushNotifications 发送完待发送的通知，之后的通知将被丢弃（进程退出前调用）
+func (qe *QuantEngine) FlushNotifications() {
+	qe.notifier.Close()
 }
 
 // RunBacktest 运行回测
-func (qe *QuantEngine) RunBacktest(symbol, startDate, endDate string) error {
+func (qe *QuantEngine) RunBacktest(symbol, startDate, endDate string, resume bool) error {
 	log.Printf("开始运行回测: 标的=%s, 开始=%s, 结束=%s", symbol, startDate, endDate)
 
-	// 获取策略
-	strategy, err := qe.strategyManager.GetStrategy("ma_cross")
+	backtester, err := qe.newBacktester(symbol, startDate, endDate, resume)
 	if err != nil {
-		return fmt.Errorf("获取策略失败: %w", err)
+		return err
 	}
 
-	// 创建回测器
-	backtester := backtest.NewBacktester(strategy, qe.dataManager,
-		qe.config.Backtest.InitialCapital,
-		qe.config.Backtest.CommissionRate,
-		qe.config.Backtest.SlippageRate)
-
 	// 运行回测
 	result, err := backtester.Run(symbol, startDate, endDate)
 	if err != nil {
@@ -345,15 +901,151 @@ func (qe *QuantEngine) RunBacktest(symbol, startDate, endDate string) error {
 
 	// 打印回测结果
 	qe.printBacktestResult(result)
+	body := fmt.Sprintf("区间: %s ~ %s\n总收益率: %.2f%%, 年化: %.2f%%, 最大回撤: %.2f%%, 夏普: %.2f, 交易次数: %d, 胜率: %.2f%%",
+		startDate, endDate, result.TotalReturn*100, result.AnnualReturn*100, result.MaxDrawdown*100,
+		result.SharpeRatio, result.TotalTrades, result.WinRate*100)
+	if benchmark := result.Benchmark; benchmark != nil {
+		body += fmt.Sprintf("\n买入持有: %.2f%%, 超额收益率: %.2f%%, 信息比率: %.2f",
+			benchmark.TotalReturn*100, benchmark.ExcessReturn*100, benchmark.InformationRatio)
+	}
+	title := fmt.Sprintf("回测完成 %s %s", result.StrategyName, symbol)
+	if result.Aborted {
+		title = fmt.Sprintf("回测中止 %s %s（部分结果）", result.StrategyName, symbol)
+		body += "\n" + result.AbortReason
+	}
+	qe.notifier.Notify(notify.EventBacktest, title, body)
 
 	return nil
 }
 
+// newBacktester 按回测配置为单个标的创建回测器
+func (qe *QuantEngine) newBacktester(symbol, startDate, endDate string, resume bool) (*backtest.Backtester, error) {
+	cfg := qe.currentConfig()
+	backtestStrategy := cfg.Backtest.Strategy
+	strategy, err := qe.strategyManager.GetStrategy(backtestStrategy)
+	if err != nil {
+		return nil, fmt.Errorf("获取策略失败: %w", err)
+	}
+
+	backtester := backtest.NewBacktester(strategy, qe.dataManager,
+		cfg.Backtest.InitialCapital,
+		cfg.Backtest.CommissionRate,
+		cfg.Backtest.SlippageRate)
+	backtester.SetPrecision(cfg.Backtest.PrecisionTable())
+	backtester.SetMaxEntries(cfg.Backtest.MaxEntries)
+	backtester.SetLimits(backtest.Limits{
+		MaxDuration: cfg.Backtest.MaxDuration,
+		MaxMemoryMB: cfg.Backtest.MaxMemoryMB,
+		MaxBars:     cfg.Backtest.MaxBars,
+	})
+	if dir := cfg.Backtest.Checkpoint.Dir; dir != "" {
+		backtester.SetCheckpoint(backtest.CheckpointOptions{
+			Path:     backtestCheckpointPath(dir, backtestStrategy, symbol, startDate, endDate),
+			Interval: cfg.Backtest.Checkpoint.Interval,
+			Resume:   resume,
+		})
+	} else if resume {
+		return nil, fmt.Errorf("未配置 backtest.checkpoint.dir，无法从检查点恢复")
+	}
+	fees, err := commission.New(cfg.Backtest.Commission)
+	if err != nil {
+		return nil, fmt.Errorf("创建佣金模型失败: %w", err)
+	}
+	if fees != nil {
+		backtester.SetCommissionModel(fees)
+		log.Printf("使用佣金模型: %s", fees.Name())
+	}
+	if margin := cfg.Backtest.Margin; margin.Enabled() {
+		backtester.SetMargin(backtest.MarginOptions{
+			Leverage:          margin.Leverage,
+			MaintenanceMargin: margin.MaintenanceMargin,
+			InterestRate:      margin.InterestRate,
+		})
+		log.Printf("使用保证金交易: 杠杆=%.2f, 维持保证金率=%.2f%%, 年化利率=%.2f%%",
+			margin.Leverage, margin.MaintenanceMargin*100, margin.InterestRate*100)
+	}
+	sizingTable, err := sizing.NewTable(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("创建仓位计算方式失败: %w", err)
+	}
+	if policy := sizingTable.For(backtestStrategy); policy != nil {
+		backtester.SetSizing(policy)
+		log.Printf("使用仓位计算方式: %s", policy.Name())
+	}
+	if inst := qe.instruments.Lookup(symbol); inst.Type == instrument.Future {
+		backtester.SetInstrument(inst)
+		log.Printf("使用期货合约规格: %s, 合约乘数=%s, 最小价格变动=%s", inst.Symbol, inst.Multiplier, inst.TickSize)
+	}
+	model, err := slippage.New(cfg.Backtest.Slippage)
+	if err != nil {
+		return nil, fmt.Errorf("创建滑点模型失败: %w", err)
+	}
+	if model != nil {
+		backtester.SetImpactModel(model)
+		log.Printf("使用滑点模型: %s", model.Name())
+	}
+	if path := cfg.Backtest.SlippageModelFile; path != "" {
+		if _, err := os.Stat(path); err == nil {
+			model, err := backtest.LoadSlippageModel(path)
+			if err != nil {
+				return nil, fmt.Errorf("加载滑点模型失败: %w", err)
+			}
+			backtester.SetSlippageModel(model)
+			log.Printf("使用校准滑点模型: 文件=%s, 样本数=%d, 拟合时间=%s",
+				path, model.Samples, model.FittedAt.Format("2006-01-02 15:04"))
+		}
+	}
+	if ticks := cfg.Backtest.Ticks; ticks.Enabled {
+		backtester.SetTickMode(backtest.TickOptions{BarInterval: ticks.BarInterval, Latency: ticks.Latency})
+		log.Printf("使用逐笔回测: K线周期=%v, 延迟=%v", ticks.BarInterval, ticks.Latency)
+	}
+	return backtester, nil
+}
+
+// RunBatchBacktest 用同一策略并发回测多个标的，写入合并报告并返回；workers 为0时使用配置的并发数
+func (qe *QuantEngine) RunBatchBacktest(symbols []string, startDate, endDate string, workers int, resume bool) (*backtest.BatchReport, string, error) {
+	if len(symbols) == 0 {
+		return nil, "", fmt.Errorf("没有回测标的")
+	}
+	if workers <= 0 {
+		workers = qe.currentConfig().Backtest.Workers
+	}
+
+	report := backtest.RunBatch(symbols, startDate, endDate, workers, func(symbol string) (*backtest.Backtester, error) {
+		return qe.newBacktester(symbol, startDate, endDate, resume)
+	})
+
+	var path string
+	if dir := qe.currentConfig().Backtest.ReportDir; dir != "" {
+		path = filepath.Join(dir, fmt.Sprintf("backtest_%s_%s.json", qe.currentConfig().Backtest.Strategy, report.StartedAt.Format("20060102_150405")))
+		if err := report.Save(path); err != nil {
+			return report, "", err
+		}
+		log.Printf("多标的回测报告已写入 %s", path)
+	}
+
+	summary := report.Summary
+	body := fmt.Sprintf("区间: %s ~ %s, 标的 %d 个（失败 %d）\n平均收益率: %.2f%%, 中位数: %.2f%%, 平均夏普: %.2f, 盈利标的 %d 个, 跑赢基准 %d 个\n最好: %s, 最差: %s",
+		startDate, endDate, len(symbols), summary.Failed, summary.AvgReturn*100, summary.MedianReturn*100,
+		summary.AvgSharpe, summary.Profitable, summary.BeatBenchmark, summary.Best, summary.Worst)
+	qe.notifier.Notify(notify.EventBacktest, fmt.Sprintf("多标的回测完成 %s", report.StrategyName), body)
+	return report, path, nil
+}
+
+// backtestCheckpointPath 回测检查点文件路径，按策略、标的和区间区分，标的中的路径分隔符替换为下划线
+func backtestCheckpointPath(dir, strategyName, symbol, startDate, endDate string) string {
+	name := strings.NewReplacer("/", "_", "\\", "_", ":", "_").Replace(strings.ToUpper(symbol))
+	return filepath.Join(dir, fmt.Sprintf("%s_%s_%s_%s.json", strategyName, name, startDate, endDate))
+}
+
 // printBacktestResult 打印回测结果
 func (qe *QuantEngine) printBacktestResult(result *backtest.BacktestResult) {
 	log.Printf("=== 回测结果 ===")
 	log.Printf("策略名称: %s", result.StrategyName)
 	log.Printf("标的符号: %s", result.Symbol)
+	if result.Aborted {
+		log.Printf("回测提前中止，以下为截至 %s 的部分结果: %s", result.EndDate.Format("2006-01-02"), result.AbortReason)
+	}
 	log.Printf("初始资金: %.2f", result.InitialCapital)
 	log.Printf("最终资金: %.2f", result.FinalCapital)
 	log.Printf("总收益率: %.2f%%", result.TotalReturn*100)
@@ -370,11 +1062,81 @@ func (qe *QuantEngine) printBacktestResult(result *backtest.BacktestResult) {
 	log.Printf("最大连续亏损: %d", result.MaxConsecutiveLosses)
 	log.Printf("总佣金: %.2f", result.Commission)
 	log.Printf("总滑点: %.2f", result.Slippage)
+	if qe.currentConfig().Backtest.Margin.Enabled() {
+		log.Printf("融资利息: %.2f, 强制平仓次数: %d, 最高保证金使用率: %.2f%%",
+			result.Interest, result.MarginCalls, result.MaxMarginUtilization*100)
+	}
+	if result.Rolls > 0 {
+		log.Printf("期货换月次数: %d", result.Rolls)
+	}
+	if execution := result.Execution; execution != nil {
+		log.Printf("--- 逐笔撮合 ---")
+		log.Printf("逐笔成交: %d, 订单簿快照: %d", execution.Ticks, execution.BookSnapshots)
+		log.Printf("主动成交: %d, 挂单成交: %d, 部分成交: %d, 撤单: %d",
+			execution.TakerFills, execution.MakerFills, execution.PartialFills, execution.Cancelled)
+		log.Printf("成交时平均价差: %.2f 基点, 平均成交等待: %v", execution.AvgSpreadBps, execution.AvgFillDelay)
+	}
+	if benchmark := result.Benchmark; benchmark != nil {
+		log.Printf("--- 买入持有基准 ---")
+		log.Printf("基准最终资金: %.2f", benchmark.FinalCapital)
+		log.Printf("基准总收益率: %.2f%%", benchmark.TotalReturn*100)
+		log.Printf("基准年化收益率: %.2f%%", benchmark.AnnualReturn*100)
+		log.Printf("基准最大回撤: %.2f%%", benchmark.MaxDrawdown*100)
+		log.Printf("基准夏普比率: %.2f", benchmark.SharpeRatio)
+		log.Printf("超额收益率: %.2f%%", benchmark.ExcessReturn*100)
+		log.Printf("贝塔: %.2f, 相关系数: %.2f, 信息比率: %.2f",
+			benchmark.Beta, benchmark.Correlation, benchmark.InformationRatio)
+	}
+	if reportingCapital, err := qe.fxService.ToReporting(result.FinalCapital, qe.backtestCurrency()); err == nil {
+		log.Printf("最终资金(%s): %.2f", qe.fxService.ReportingCurrency(), reportingCapital)
+	}
 	log.Printf("==================")
 }
 
+// CalibrateSlippage 用成交流水中 since 之后的实盘成交拟合滑点模型
+func (qe *QuantEngine) CalibrateSlippage(since time.Time, minSamples int) (*backtest.SlippageModel, error) {
+	cfg := qe.currentConfig()
+	if cfg.Trading.JournalFile == "" {
+		return nil, fmt.Errorf("未配置成交流水文件")
+	}
+
+	entries, err := trading.ReadJournal(cfg.Trading.JournalFile, since)
+	if err != nil {
+		return nil, err
+	}
+
+	var samples []backtest.SlippageSample
+	for _, entry := range entries {
+		if entry.Kind != trading.JournalTrade || !entry.ReferencePrice.IsPositive() {
+			continue
+		}
+		samples = append(samples, backtest.SlippageSample{
+			Symbol:   entry.Symbol,
+			Time:     entry.Time,
+			Notional: money.Float(entry.Quantity.Mul(entry.ReferencePrice)),
+			Slippage: money.Float(entry.Slippage),
+		})
+	}
+
+	return backtest.FitSlippageModel(samples, cfg.Trading.MarketTimezone, minSamples)
+}
+
+// GetStopRules 获取持仓监控中的止损止盈规则
+func (qe *QuantEngine) GetStopRules() []trading.StopRule {
+	return qe.positionMonitor.GetRules()
+}
+
+// backtestCurrency 回测资金的计价币种
+func (qe *QuantEngine) backtestCurrency() string {
+	cfg := qe.currentConfig()
+	if cfg.Backtest.Currency != "" {
+		return cfg.Backtest.Currency
+	}
+	return qe.fxService.ReportingCurrency()
+}
+
 // GetAccountBalance 获取账户余额
-func (qe *QuantEngine) GetAccountBalance(accountName string) (float64, error) {
+func (qe *QuantEngine) GetAccountBalance(accountName string) (decimal.Decimal, error) {
 	return qe.tradingEngine.GetAccountBalance(accountName)
 }
 
@@ -393,6 +1155,178 @@ func (qe *QuantEngine) GetAccountTrades(accountName string, symbol string, limit
 	return qe.tradingEngine.GetAccountTrades(accountName, symbol, limit)
 }
 
+// OrderOrigin 手动订单的来源，记入信号日志留痕
+type OrderOrigin struct {
+	Source   string // cli / api
+	Operator string // 操作人
+	Reason   string
+}
+
+// ValidateManualOrder 校验手动订单的方向、数量、类型、价格和有效期
+func ValidateManualOrder(order trading.Order) error {
+	if order.Symbol == "" {
+		return fmt.Errorf("标的不能为空")
+	}
+	if order.Side != trading.BuySide && order.Side != trading.SellSide {
+		return fmt.Errorf("方向只能是 buy 或 sell")
+	}
+	if !order.Quantity.IsPositive() {
+		return fmt.Errorf("数量必须大于0")
+	}
+	if order.Price.IsNegative() || order.StopPrice.IsNegative() {
+		return fmt.Errorf("价格不能为负数")
+	}
+
+	switch order.Type {
+	case trading.MarketOrder:
+	case trading.LimitOrder:
+		if !order.Price.IsPositive() {
+			return fmt.Errorf("限价单需要指定价格")
+		}
+	case trading.StopOrder:
+		if !order.StopPrice.IsPositive() {
+			return fmt.Errorf("止损单需要指定触发价")
+		}
+	default:
+		return fmt.Errorf("订单类型只能是 market、limit 或 stop")
+	}
+
+	switch order.TimeInForce {
+	case "", trading.GTC, trading.DAY, trading.IOC, trading.FOK:
+	default:
+		return fmt.Errorf("有效期只能是 gtc、day、ioc 或 fok")
+	}
+	return nil
+}
+
+// PlaceOrder 向账户提交手动订单并等待执行结果，未指定策略时记为 manual；
+// 订单与策略信号经过同样的下单队列、紧急停止、交易名单、资金分配和风控检查，
+// 未指定价格的市价单按最新价格估算，用于风控和资金检查；提交结果不经抽样记入信号日志
+func (qe *QuantEngine) PlaceOrder(accountName string, order trading.Order, origin OrderOrigin) (*trading.Order, error) {
+	order.Symbol = strings.ToUpper(order.Symbol)
+	if order.Strategy == "" {
+		order.Strategy = "manual"
+	}
+	if err := ValidateManualOrder(order); err != nil {
+		return nil, err
+	}
+	if order.Type == trading.MarketOrder && !order.Price.IsPositive() {
+		price, err := qe.dataManager.GetLatestPrice(order.Symbol)
+		if err != nil {
+			return nil, fmt.Errorf("获取 %s 最新价格失败: %w", order.Symbol, err)
+		}
+		order.Price = money.FromFloat(price)
+	}
+
+	log.Printf("手动订单: 来源=%s, 操作人=%s, 账户=%s, %s %s %s @ %s, 原因=%s",
+		origin.Source, origin.Operator, accountName, order.Side, order.Quantity, order.Symbol, order.Price, origin.Reason)
+	filled, err := qe.submitManualOrder(accountName, order)
+
+	fields := map[string]interface{}{
+		"source":        origin.Source,
+		"operator":      origin.Operator,
+		"reason":        origin.Reason,
+		"account":       accountName,
+		"strategy":      order.Strategy,
+		"side":          string(order.Side),
+		"type":          string(order.Type),
+		"time_in_force": string(order.TimeInForce),
+		"quantity":      order.Quantity.String(),
+		"price":         order.Price.String(),
+		"stop_price":    order.StopPrice.String(),
+	}
+	if err != nil {
+		fields["error"] = err.Error()
+	} else {
+		fields["order_id"] = filled.ID
+		fields["status"] = string(filled.Status)
+		fields["filled_quantity"] = filled.FilledQty.String()
+		fields["average_price"] = filled.AvgPrice.String()
+	}
+	qe.signalLog.audit(signalLogManual, order.Symbol, fields)
+	return filled, err
+}
+
+// submitManualOrder 提交订单到账户的下单队列并等待结果
+func (qe *QuantEngine) submitManualOrder(accountName string, order trading.Order) (*trading.Order, error) {
+	resultChan, err := qe.tradingEngine.SubmitOrder(order, accountName)
+	if err != nil {
+		return nil, err
+	}
+
+	result := <-resultChan
+	if result.Err != nil {
+		return nil, result.Err
+	}
+	return result.Order, nil
+}
+
+// UpdateSymbolList 运行时修改交易名单，accountName 为空时修改全局名单
+func (qe *QuantEngine) UpdateSymbolList(kind trading.SymbolListKind, accountName string, add, remove []string) error {
+	return qe.tradingEngine.UpdateSymbolList(kind, accountName, add, remove)
+}
+
+// GetSymbolLists 获取当前交易名单
+func (qe *QuantEngine) GetSymbolLists() trading.SymbolListsStatus {
+	return qe.tradingEngine.GetSymbolLists()
+}
+
+// SubscribeEvents 订阅引擎事件（成交、风控、循环失败等），返回事件通道和取消订阅函数
+func (qe *QuantEngine) SubscribeEvents(bufferSize int) (<-chan notify.Message, func()) {
+	return qe.notifier.Subscribe(bufferSize)
+}
+
+// ClosePosition 按比例平掉账户在标的上的持仓并等待下单结果
+func (qe *QuantEngine) ClosePosition(accountName, symbol string, pct float64) (*trading.Order, error) {
+	resultChan, err := qe.tradingEngine.ClosePosition(accountName, strings.ToUpper(symbol), pct)
+	if err != nil {
+		return nil, err
+	}
+
+	result := <-resultChan
+	if result.Err != nil {
+		return nil, result.Err
+	}
+	if pct >= 1 {
+		qe.positionMonitor.RemoveRule(accountName, strings.ToUpper(symbol))
+	}
+	return result.Order, nil
+}
+
+// Deposit 向模拟账户入金
+func (qe *QuantEngine) Deposit(accountName string, amount decimal.Decimal) (decimal.Decimal, error) {
+	return qe.tradingEngine.Deposit(accountName, amount)
+}
+
+// Withdraw 从模拟账户出金
+func (qe *QuantEngine) Withdraw(accountName string, amount decimal.Decimal) (decimal.Decimal, error) {
+	return qe.tradingEngine.Withdraw(accountName, amount)
+}
+
+// SetAccountBalance 设置模拟账户余额
+func (qe *QuantEngine) SetAccountBalance(accountName string, balance decimal.Decimal) error {
+	return qe.tradingEngine.SetBalance(accountName, balance)
+}
+
+// ResetAccount 重置模拟账户
+func (qe *QuantEngine) ResetAccount(accountName string) (decimal.Decimal, error) {
+	return qe.tradingEngine.ResetAccount(accountName)
+}
+
+// ListApprovals 获取确认请求，status 为空时返回全部
+func (qe *QuantEngine) ListApprovals(status trading.ApprovalStatus) ([]trading.ApprovalRequest, error) {
+	approvals := qe.tradingEngine.GetApprovalManager()
+	if approvals == nil {
+		return nil, fmt.Errorf("未启用大额订单人工确认（trading.approval.enabled）")
+	}
+	return approvals.List(status)
+}
+
+// DecideApproval 确认或拒绝等待人工确认的订单，确认的订单立即提交
+func (qe *QuantEngine) DecideApproval(id, by, reason string, approve bool) (trading.ApprovalRequest, error) {
+	return qe.tradingEngine.DecideApproval(id, by, reason, approve)
+}
+
 // RefreshAccountData 刷新账户数据
 func (qe *QuantEngine) RefreshAccountData(accountName string) error {
 	return qe.accountManager.RefreshAccountData(accountName)
@@ -410,11 +1344,69 @@ func (qe *QuantEngine) UpdateStrategyParameters(strategyName string, params stra
 	return qe.strategyManager.UpdateStrategyParameters(strategyName, params)
 }
 
+// Halt 紧急停止交易，撤销全部未成交订单
+func (qe *QuantEngine) Halt(reason string) trading.HaltState {
+	return qe.tradingEngine.Halt(trading.HaltManual, reason)
+}
+
+// Resume 恢复紧急停止的交易
+func (qe *QuantEngine) Resume() trading.HaltState {
+	return qe.tradingEngine.Resume()
+}
+
+// GetHaltState 获取紧急停止状态
+func (qe *QuantEngine) GetHaltState() trading.HaltState {
+	return qe.tradingEngine.GetHaltState()
+}
+
+// EnableStrategy 恢复被策略监控停用的策略
+func (qe *QuantEngine) EnableStrategy(name string) error {
+	return qe.tradingEngine.EnableStrategy(name)
+}
+
+// DisabledStrategies 被策略监控停用的策略
+func (qe *QuantEngine) DisabledStrategies() []trading.DisabledStrategy {
+	return qe.tradingEngine.DisabledStrategies()
+}
+
+// ExportSnapshot 导出全部账户的持仓和余额快照
+func (qe *QuantEngine) ExportSnapshot() (*trading.PositionSnapshot, error) {
+	return qe.tradingEngine.ExportSnapshot()
+}
+
+// GetLeaderboard 获取按滚动窗口表现排名的策略排行榜；rankBy 和 rankWindow 为空时使用配置
+func (qe *QuantEngine) GetLeaderboard(rankBy string, rankWindow time.Duration) (*trading.LeaderboardReport, error) {
+	return qe.tradingEngine.GetLeaderboard(rankBy, rankWindow)
+}
+
+// GetDataProviders 获取各资产类别当前的数据源和已注册的数据源
+func (qe *QuantEngine) GetDataProviders() ([]data.ProviderStatus, []string) {
+	return qe.dataManager.ProviderStatus(), qe.dataManager.Providers()
+}
+
+// SwitchDataProvider 运行时切换资产类别的数据源（例如某个行情供应商故障时）
+func (qe *QuantEngine) SwitchDataProvider(class data.AssetClass, name string) (*data.ProviderSwap, error) {
+	swap, err := qe.dataManager.SwitchProvider(class, name)
+	if err != nil {
+		return nil, err
+	}
+	if swap.From != swap.To {
+		qe.notifier.Notifyf(notify.EventDependency, fmt.Sprintf("%s 数据源已切换", class),
+			"%s -> %s, 等待 %d 个进行中的请求 %v", swap.From, swap.To, swap.Drained, swap.Waited.Round(time.Millisecond))
+	}
+	return swap, nil
+}
+
 // GetAvailableStrategies 获取可用策略
 func (qe *QuantEngine) GetAvailableStrategies() map[string]strategy.StrategyInfo {
 	return qe.strategyManager.GetAvailableStrategies()
 }
 
+// DiscoverStrategies 按资产类别、周期、标签或关键字筛选可用策略
+func (qe *QuantEngine) DiscoverStrategies(filter strategy.StrategyFilter) map[string]strategy.StrategyInfo {
+	return qe.strategyManager.DiscoverStrategies(filter)
+}
+
 // HealthCheck 健康检查
 func (qe *QuantEngine) HealthCheck() *HealthStatus {
 	status := &HealthStatus{
@@ -422,7 +1414,7 @@ func (qe *QuantEngine) HealthCheck() *HealthStatus {
 		Services:  make(map[string]ServiceStatus),
 	}
 
-	// 检查Agent服务
+	// 检查Agent服务，熔断期间即使服务已恢复也视为异常，直到试探请求成功
 	if err := qe.agentClient.HealthCheck(); err != nil {
 		status.Services["agent"] = ServiceStatus{
 			Name:   "Agent服务",
@@ -435,6 +1427,16 @@ func (qe *QuantEngine) HealthCheck() *HealthStatus {
 			Status: "healthy",
 		}
 	}
+	if reporter, ok := qe.agentClient.(agent.BreakerReporter); ok {
+		breaker := reporter.BreakerStatus()
+		status.AgentBreaker = &breaker
+		if breaker.State != agent.BreakerClosed {
+			service := status.Services["agent"]
+			service.Status = "unhealthy"
+			service.Error = fmt.Sprintf("熔断器 %s, 连续失败 %d 次: %s", breaker.State, breaker.ConsecutiveFails, breaker.LastError)
+			status.Services["agent"] = service
+		}
+	}
 
 	// 检查交易引擎
 	if qe.tradingEngine.IsRunning() {
@@ -468,6 +1470,22 @@ func (qe *QuantEngine) HealthCheck() *HealthStatus {
 		Status: "healthy",
 	}
 
+	// 检查本地账户状态与经纪商是否一致，尚未定期对账时立即对账一次（不修正）
+	if qe.currentConfig().Trading.Reconciliation.Enabled {
+		report := qe.tradingEngine.GetReconciliation()
+		if report == nil {
+			report = qe.tradingEngine.Reconcile(false)
+		}
+		status.Reconciliation = report
+
+		service := ServiceStatus{Name: "账户对账", Status: "healthy"}
+		if !report.Healthy() {
+			service.Status = "unhealthy"
+			service.Error = fmt.Sprintf("%d 处差异, %d 个账户无法对账", len(report.Discrepancies), len(report.Errors))
+		}
+		status.Services["reconciliation"] = service
+	}
+
 	// 计算总体健康状态
 	allHealthy := true
 	for _, service := range status.Services {
@@ -491,6 +1509,98 @@ type HealthStatus struct {
 	Timestamp time.Time                `json:"timestamp"`
 	Overall   string                   `json:"overall"`
 	Services  map[string]ServiceStatus `json:"services"`
+
+	Reconciliation *trading.ReconciliationReport `json:"reconciliation,omitempty"` // 未启用对账时为nil
+	AgentBreaker   *agent.BreakerStatus          `json:"agent_breaker,omitempty"`  // 模拟客户端时为nil
+}
+
+// newAgentClient 按 agent_service.mode 创建通过REST或gRPC调用Python Agent服务、或直接调用大模型接口的客户端
+func newAgentClient(cfg *config.Config) (agent.ClientInterface, error) {
+	if cfg.AgentService.Mode == "llm" {
+		return newLLMClient(cfg)
+	}
+
+	options := agentOptions(cfg.AgentService)
+	security, err := agentSecurity(cfg.AgentService)
+	if err != nil {
+		return nil, err
+	}
+	options.Security = security
+
+	if cfg.AgentService.Mode == "grpc" {
+		log.Printf("Agent使用gRPC服务: 地址=%s, 流式=%v", cfg.AgentService.GRPC.Address, cfg.AgentService.GRPC.Stream)
+		return agent.NewGRPCClient(agent.GRPCOptions{
+			Address: cfg.AgentService.GRPC.Address,
+			Stream:  cfg.AgentService.GRPC.Stream,
+		}, options)
+	}
+	return agent.NewClientWithOptions(cfg.AgentService.URL, options), nil
+}
+
+// agentSecurity 调用Python Agent服务的认证、请求签名和TLS设置
+func agentSecurity(cfg config.AgentServiceConfig) (agent.Security, error) {
+	security := agent.Security{
+		APIKey:        cfg.Auth.APIKey,
+		BearerToken:   cfg.Auth.BearerToken,
+		SigningSecret: cfg.Auth.SigningSecret,
+	}
+	if cfg.TLS.Enabled {
+		tlsConfig, err := agent.NewTLSConfig(agent.TLSOptions{
+			CAFile:             cfg.TLS.CAFile,
+			CertFile:           cfg.TLS.CertFile,
+			KeyFile:            cfg.TLS.KeyFile,
+			ServerName:         cfg.TLS.ServerName,
+			InsecureSkipVerify: cfg.TLS.InsecureSkipVerify,
+		})
+		if err != nil {
+			return security, fmt.Errorf("agent_service.tls 配置无效: %w", err)
+		}
+		security.TLS = tlsConfig
+	}
+	return security, nil
+}
+
+// newLLMClient 创建直接调用大模型接口的客户端
+func newLLMClient(cfg *config.Config) (agent.ClientInterface, error) {
+
+	llm := cfg.AgentService.LLM
+	options := agent.LLMOptions{
+		Provider:    llm.Provider,
+		BaseURL:     llm.BaseURL,
+		APIKey:      cfg.LLMKey(),
+		Model:       llm.Model,
+		MaxTokens:   llm.MaxTokens,
+		Temperature: llm.Temperature,
+	}
+	if llm.PromptFile != "" {
+		content, err := os.ReadFile(llm.PromptFile)
+		if err != nil {
+			return nil, fmt.Errorf("读取提示词模板失败: %w", err)
+		}
+		options.PromptTemplate = string(content)
+	}
+	if llm.SetupPromptFile != "" {
+		content, err := os.ReadFile(llm.SetupPromptFile)
+		if err != nil {
+			return nil, fmt.Errorf("读取交易方案提示词模板失败: %w", err)
+		}
+		options.SetupPromptTemplate = string(content)
+	}
+	log.Printf("Agent使用大模型接口: 服务商=%s, 模型=%s", llm.Provider, llm.Model)
+	return agent.NewLLMClient(options, agentOptions(cfg.AgentService))
+}
+
+// agentOptions Agent客户端的超时、重试和熔断设置
+func agentOptions(cfg config.AgentServiceConfig) agent.Options {
+	return agent.Options{
+		Timeout:          cfg.Timeout,
+		TotalTimeout:     cfg.TotalTimeout,
+		MaxRetries:       cfg.MaxRetries,
+		RetryBackoff:     cfg.RetryBackoff,
+		MaxRetryBackoff:  cfg.MaxRetryBackoff,
+		BreakerThreshold: cfg.BreakerThreshold,
+		BreakerCooldown:  cfg.BreakerCooldown,
+	}
 }
 
 // ServiceStatus 服务状态
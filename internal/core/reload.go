@@ -0,0 +1,424 @@
+package core
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"agent-quant-system/internal/config"
+	"agent-quant-system/internal/notify"
+	"agent-quant-system/internal/scanner"
+	"agent-quant-system/internal/schedule"
+	"agent-quant-system/internal/strategy"
+)
+
+// ReloadResult 一次配置热加载的结果，配置项按配置文件中的名称
+type ReloadResult struct {
+	Time            time.Time `json:"time"`
+	Applied         []string  `json:"applied"`          // 已生效的配置
+	RestartRequired []string  `json:"restart_required"` // 已修改但需要重启才能生效的配置
+}
+
+// WatchConfig 监视配置文件，修改时间变化后热加载；返回停止监视的函数。
+// 与引擎是否运行无关，停止期间加载的配置在下次启动时生效
+func (qe *QuantEngine) WatchConfig(path string) (func(), error) {
+	base, err := config.LoadConfig(path)
+	if err != nil {
+		return nil, fmt.Errorf("加载配置失败: %w", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取配置文件失败: %w", err)
+	}
+
+	qe.mutex.Lock()
+	qe.reloadBase = base
+	interval := qe.currentConfig().Engine.Reload.Interval
+	qe.mutex.Unlock()
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+
+	stop := make(chan struct{})
+	go qe.watchConfig(path, info.ModTime(), interval, stop)
+	log.Printf("已启用配置热加载: 文件=%s, 检查间隔=%v", path, interval)
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(stop) })
+	}, nil
+}
+
+// watchConfig 定期检查配置文件的修改时间
+func (qe *QuantEngine) watchConfig(path string, modTime time.Time, interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			log.Printf("检查配置文件失败: %v", err)
+			continue
+		}
+		if info.ModTime().Equal(modTime) {
+			continue
+		}
+		modTime = info.ModTime()
+
+		result, err := qe.ReloadConfig(path)
+		if err != nil {
+			log.Printf("配置热加载失败，继续使用当前配置: %v", err)
+			qe.notifier.Notifyf(notify.EventConfig, "配置热加载失败", "%s: %v", path, err)
+			continue
+		}
+		if len(result.Applied) == 0 && len(result.RestartRequired) == 0 {
+			continue
+		}
+		qe.notifier.Notifyf(notify.EventConfig, "配置已热加载", "生效: %s\n需要重启: %s",
+			strings.Join(result.Applied, ", "), strings.Join(result.RestartRequired, ", "))
+	}
+}
+
+// ReloadConfig 重新读取并验证配置文件，通过后应用可热加载的部分
+func (qe *QuantEngine) ReloadConfig(path string) (*ReloadResult, error) {
+	next, err := config.LoadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := next.Validate(); err != nil {
+		return nil, fmt.Errorf("配置验证失败: %w", err)
+	}
+	return qe.ApplyConfig(next)
+}
+
+// ApplyConfig 应用新配置中可热加载的部分：先准备全部新的设置，任一无效时不做任何修改；
+// 再等待当前循环结束，在两轮循环之间一次性替换。当前配置不会被原地修改：可热加载的配置项
+// 写入当前配置的副本，完成后整体发布给量化引擎和交易引擎，读取方不会看到修改了一半的配置
+func (qe *QuantEngine) ApplyConfig(next *config.Config) (*ReloadResult, error) {
+	qe.mutex.RLock()
+	base := qe.reloadBase
+	qe.mutex.RUnlock()
+	current := qe.currentConfig()
+	if base == nil {
+		base = current
+	}
+
+	result := &ReloadResult{Time: time.Now()}
+	changed := changedSections(base, next)
+
+	// 准备策略设置
+	var (
+		parameters map[string]strategy.StrategyParams
+		scheduler  *schedule.Scheduler
+		err        error
+	)
+	if changed["strategy"] {
+		for _, name := range next.Strategy.Active {
+			if _, err := qe.strategyManager.GetStrategy(name); err != nil {
+				return nil, fmt.Errorf("strategy.active: %w", err)
+			}
+		}
+		for _, name := range next.Strategy.Incremental {
+			s, err := qe.strategyManager.GetStrategy(name)
+			if err != nil {
+				return nil, fmt.Errorf("strategy.incremental: %w", err)
+			}
+			if _, ok := s.(strategy.IncrementalStrategy); !ok {
+				return nil, fmt.Errorf("strategy.incremental: 策略 %s 不支持增量模式", name)
+			}
+		}
+		if !reflect.DeepEqual(base.Strategy.Parameters, next.Strategy.Parameters) {
+			if parameters, err = qe.strategyParameters(next.Strategy.Parameters); err != nil {
+				return nil, fmt.Errorf("strategy.parameters: %w", err)
+			}
+		}
+		if _, err := strategyTimeframes(next.Strategy.Timeframes); err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(base.Strategy.Schedules, next.Strategy.Schedules) ||
+			!reflect.DeepEqual(base.Strategy.SymbolSchedules, next.Strategy.SymbolSchedules) {
+			if scheduler, err = schedule.NewScheduler(next.Strategy.Schedules, next.Strategy.SymbolSchedules); err != nil {
+				return nil, fmt.Errorf("交易时间表配置无效: %w", err)
+			}
+		}
+	}
+
+	qe.cycleMutex.Lock()
+	defer qe.cycleMutex.Unlock()
+
+	updated := *current
+	for _, section := range sortedSections(changed) {
+		switch section {
+		case "strategy":
+			applied, restart := qe.applyStrategyConfig(&updated, base.Strategy, next.Strategy, parameters, scheduler)
+			result.Applied = append(result.Applied, applied...)
+			result.RestartRequired = append(result.RestartRequired, restart...)
+		case "risk":
+			applied, restart := qe.applyRiskConfig(&updated, base.Risk, next.Risk)
+			result.Applied = append(result.Applied, applied...)
+			result.RestartRequired = append(result.RestartRequired, restart...)
+		case "scanner":
+			qe.applyScannerConfig(&updated, next.Scanner)
+			result.Applied = append(result.Applied, "scanner")
+		case "notifications":
+			applied, restart := qe.applyNotificationsConfig(&updated, base.Notifications, next.Notifications)
+			result.Applied = append(result.Applied, applied...)
+			result.RestartRequired = append(result.RestartRequired, restart...)
+		default:
+			result.RestartRequired = append(result.RestartRequired, section)
+		}
+	}
+
+	if len(result.Applied) > 0 {
+		qe.config.Store(&updated)
+		qe.tradingEngine.SetConfig(&updated)
+	}
+
+	qe.mutex.Lock()
+	qe.reloadBase = next
+	qe.mutex.Unlock()
+
+	if len(result.Applied) > 0 || len(result.RestartRequired) > 0 {
+		log.Printf("配置已热加载: 生效=%v, 需要重启=%v", result.Applied, result.RestartRequired)
+	}
+	return result, nil
+}
+
+// applyStrategyConfig 更新启用的策略、策略参数、K线周期、策略组合和交易时间表，生效的配置项写入 updated
+func (qe *QuantEngine) applyStrategyConfig(updated *config.Config, base, next config.StrategyConfig, parameters map[string]strategy.StrategyParams, scheduler *schedule.Scheduler) (applied, restart []string) {
+	if !reflect.DeepEqual(base.Active, next.Active) {
+		updated.Strategy.Active = next.Active
+		applied = append(applied, "strategy.active")
+	}
+	if !reflect.DeepEqual(base.Incremental, next.Incremental) {
+		if err := qe.strategyManager.SetIncremental(next.Incremental); err != nil {
+			log.Printf("更新增量模式的策略失败: %v", err)
+		} else {
+			updated.Strategy.Incremental = next.Incremental
+			applied = append(applied, "strategy.incremental")
+		}
+	}
+	if parameters != nil {
+		for _, name := range sortedParamNames(parameters) {
+			if err := qe.strategyManager.UpdateStrategyParameters(name, parameters[name]); err != nil {
+				log.Printf("更新策略 '%s' 的参数失败: %v", name, err)
+			}
+		}
+		updated.Strategy.Parameters = next.Parameters
+		applied = append(applied, "strategy.parameters")
+	}
+	if !reflect.DeepEqual(base.Sizing, next.Sizing) {
+		updated.Strategy.Sizing = next.Sizing // 发布配置时重新创建仓位计算方式
+		applied = append(applied, "strategy.sizing")
+	}
+	if !reflect.DeepEqual(base.Ensemble, next.Ensemble) {
+		updated.Strategy.Ensemble = next.Ensemble
+		applied = append(applied, "strategy.ensemble")
+	}
+	if !reflect.DeepEqual(base.Guidance, next.Guidance) {
+		qe.strategyManager.SetGuidancePolicy(guidancePolicy(next.Guidance))
+		updated.Strategy.Guidance = next.Guidance
+		applied = append(applied, "strategy.guidance")
+	}
+	if !reflect.DeepEqual(base.Timeframes, next.Timeframes) {
+		updated.Strategy.Timeframes = next.Timeframes
+		applied = append(applied, "strategy.timeframes")
+	}
+	if scheduler != nil {
+		qe.scheduler = scheduler
+		updated.Strategy.Schedules = next.Schedules
+		updated.Strategy.SymbolSchedules = next.SymbolSchedules
+		applied = append(applied, "strategy.schedules")
+	}
+
+	if base.PluginDir != next.PluginDir {
+		restart = append(restart, "strategy.plugin_dir")
+	}
+	if !reflect.DeepEqual(base.Composites, next.Composites) {
+		restart = append(restart, "strategy.composites")
+	}
+	if !reflect.DeepEqual(base.Allocations, next.Allocations) || base.DefaultAccount != next.DefaultAccount {
+		restart = append(restart, "strategy.allocations")
+	}
+	return applied, restart
+}
+
+// applyRiskConfig 更新仓位和亏损限制、风控预设和交易名单，生效的配置写入 updated；是否启用风控和紧急停止的配置需要重启
+func (qe *QuantEngine) applyRiskConfig(updated *config.Config, base, next config.RiskConfig) (applied, restart []string) {
+	if base.Enabled != next.Enabled {
+		restart = append(restart, "risk.enabled")
+	}
+	if !reflect.DeepEqual(base.KillSwitch, next.KillSwitch) {
+		restart = append(restart, "risk.kill_switch")
+	}
+	if base.StrategySupervisor.StateFile != next.StrategySupervisor.StateFile {
+		restart = append(restart, "risk.strategy_supervisor.state_file")
+	}
+
+	risk := next
+	risk.Enabled = updated.Risk.Enabled
+	risk.KillSwitch = updated.Risk.KillSwitch
+	risk.StrategySupervisor.StateFile = updated.Risk.StrategySupervisor.StateFile
+	base.Enabled, base.KillSwitch = risk.Enabled, risk.KillSwitch
+	base.StrategySupervisor.StateFile = risk.StrategySupervisor.StateFile
+	if reflect.DeepEqual(base, risk) {
+		return nil, restart
+	}
+
+	updated.Risk = risk
+	qe.tradingEngine.ApplyRiskConfig(risk)
+	return []string{"risk"}, restart
+}
+
+// applyScannerConfig 更新固定标的、标的池和扫描条件，配置写入 updated；停用扫描器时移除扫描加入的标的
+func (qe *QuantEngine) applyScannerConfig(updated *config.Config, next config.ScannerConfig) {
+	updated.Scanner = next
+	qe.watchlist.SetStatic(next.Watchlist)
+
+	qe.mutex.Lock()
+	defer qe.mutex.Unlock()
+	switch {
+	case !next.Enabled:
+		if qe.scanner != nil {
+			qe.scanner = nil
+			qe.watchlist.SetPromoted(nil)
+		}
+	case qe.scanner == nil:
+		qe.scanner = scanner.NewScanner(next, qe.dataManager)
+	default:
+		qe.scanner.UpdateConfig(next)
+	}
+}
+
+// applyNotificationsConfig 更新通知渠道和事件，生效的配置写入 updated；队列长度需要重启
+func (qe *QuantEngine) applyNotificationsConfig(updated *config.Config, base, next config.NotificationsConfig) (applied, restart []string) {
+	if base.QueueSize != next.QueueSize {
+		restart = append(restart, "notifications.queue_size")
+	}
+	base.QueueSize = next.QueueSize
+	if reflect.DeepEqual(base, next) {
+		return nil, restart
+	}
+
+	// 启动时没有创建分发器（未启用通知、控制API和热加载）时无法热加载
+	if qe.notifier == nil {
+		return nil, append(restart, "notifications")
+	}
+	qe.notifier.Reconfigure(next)
+	queueSize := updated.Notifications.QueueSize
+	updated.Notifications = next
+	updated.Notifications.QueueSize = queueSize
+	return []string{"notifications"}, restart
+}
+
+// applyStrategyParameters 启动时应用配置文件中的策略参数
+func (qe *QuantEngine) applyStrategyParameters(overrides map[string]map[string]interface{}) error {
+	parameters, err := qe.strategyParameters(overrides)
+	if err != nil {
+		return err
+	}
+	for _, name := range sortedParamNames(parameters) {
+		if err := qe.strategyManager.UpdateStrategyParameters(name, parameters[name]); err != nil {
+			return fmt.Errorf("策略 '%s': %w", name, err)
+		}
+	}
+	return nil
+}
+
+// strategyParameters 将配置的参数合并到策略当前的参数上并验证，不修改策略
+func (qe *QuantEngine) strategyParameters(overrides map[string]map[string]interface{}) (map[string]strategy.StrategyParams, error) {
+	parameters := make(map[string]strategy.StrategyParams, len(overrides))
+	for name, values := range overrides {
+		s, err := qe.strategyManager.GetStrategy(name)
+		if err != nil {
+			return nil, err
+		}
+
+		current := s.GetParameters()
+		params := make(strategy.StrategyParams, len(current))
+		for key, value := range current {
+			params[key] = value
+		}
+		for key, value := range values {
+			existing, exists := current[key]
+			if !exists {
+				return nil, fmt.Errorf("策略 '%s' 没有参数 '%s'", name, key)
+			}
+			params[key] = coerceParam(existing, value)
+		}
+		if err := s.ValidateParameters(params); err != nil {
+			return nil, fmt.Errorf("策略 '%s' 参数验证失败: %w", name, err)
+		}
+		parameters[name] = params
+	}
+	return parameters, nil
+}
+
+// coerceParam 将配置文件中的参数值转换为策略当前参数的类型（TOML 整数解析为 int64，而策略参数多为 float64）
+func coerceParam(current, value interface{}) interface{} {
+	switch current.(type) {
+	case float64:
+		switch v := value.(type) {
+		case int64:
+			return float64(v)
+		case int:
+			return float64(v)
+		}
+	case int:
+		switch v := value.(type) {
+		case int64:
+			return int(v)
+		case float64:
+			if v == math.Trunc(v) {
+				return int(v)
+			}
+		}
+	}
+	return value
+}
+
+// changedSections 新旧配置中有修改的顶层配置项
+func changedSections(base, next *config.Config) map[string]bool {
+	baseValue, nextValue := reflect.ValueOf(*base), reflect.ValueOf(*next)
+	configType := baseValue.Type()
+
+	changed := make(map[string]bool)
+	for i := 0; i < configType.NumField(); i++ {
+		if !reflect.DeepEqual(baseValue.Field(i).Interface(), nextValue.Field(i).Interface()) {
+			changed[configType.Field(i).Tag.Get("mapstructure")] = true
+		}
+	}
+	return changed
+}
+
+// sortedSections 按名称排序的配置项
+func sortedSections(sections map[string]bool) []string {
+	names := make([]string, 0, len(sections))
+	for name := range sections {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// sortedParamNames 按名称排序的策略
+func sortedParamNames(parameters map[string]strategy.StrategyParams) []string {
+	names := make([]string, 0, len(parameters))
+	for name := range parameters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
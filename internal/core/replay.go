@@ -0,0 +1,161 @@
+package core
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"agent-quant-system/internal/agent"
+	"agent-quant-system/internal/config"
+	"agent-quant-system/internal/scanner"
+)
+
+// ReplayReport 回放结果
+type ReplayReport struct {
+	Symbols       []string      `json:"symbols"`
+	Start         time.Time     `json:"start"`
+	End           time.Time     `json:"end"`
+	Bars          int           `json:"bars"`   // 回放区间内的K线时间数
+	Cycles        int           `json:"cycles"` // 运行的交易循环数
+	FailedCycles  int           `json:"failed_cycles"`
+	Signals       int           `json:"signals"`
+	Trades        int           `json:"trades"`
+	RealizedPnL   float64       `json:"realized_pnl"`
+	UnrealizedPnL float64       `json:"unrealized_pnl"`
+	TotalPnL      float64       `json:"total_pnl"`
+	Elapsed       time.Duration `json:"elapsed"`
+	OutputDir     string        `json:"output_dir"`
+}
+
+// replayFiles 回放时改写到 engine.replay.output_dir 的状态文件
+func replayFiles(cfg *config.Config) []*string {
+	return []*string{
+		&cfg.Engine.HistoryFile,
+		&cfg.Engine.GuidanceFile,
+		&cfg.Trading.JournalFile,
+		&cfg.Trading.AuditFile,
+		&cfg.Trading.Approval.File,
+		&cfg.Trading.Promotion.StateFile,
+		&cfg.Trading.Leaderboard.StateFile,
+		&cfg.Risk.KillSwitch.StateFile,
+		&cfg.Risk.StrategySupervisor.StateFile,
+		&cfg.Logging.Signals.File,
+	}
+}
+
+// PrepareReplayConfig 把配置改为回放模式，需在创建量化引擎前调用：所有账户使用纸面经纪商，
+// 状态文件改写到 engine.replay.output_dir 并清空上次回放的内容；关闭控制API、热加载、通知、人工确认和经纪商接口频率限制
+func PrepareReplayConfig(cfg *config.Config) error {
+	dir := cfg.Engine.Replay.OutputDir
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("创建回放目录失败: %w", err)
+	}
+	for _, file := range replayFiles(cfg) {
+		if *file == "" {
+			continue
+		}
+		*file = filepath.Join(dir, filepath.Base(*file))
+		if err := os.Remove(*file); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("清理上次回放的文件失败: %w", err)
+		}
+	}
+
+	cfg.Trading.Paper = true
+	cfg.Trading.DryRun = false
+	cfg.Trading.Approval.Enabled = false
+	cfg.API.Enabled = false
+	cfg.Engine.Reload.Enabled = false
+	// 回放按回放时钟推进，Agent分析需与每轮循环同步完成
+	cfg.AgentService.Async.Enabled = false
+	cfg.Notifications.Enabled = false
+	// 纸面经纪商按回放速度运行，不限制经纪商接口频率
+	for name, account := range cfg.Accounts {
+		account.RateLimit = config.RateLimitConfig{}
+		cfg.Accounts[name] = account
+	}
+	return nil
+}
+
+// now 引擎当前时间，回放模式下为回放时钟
+func (qe *QuantEngine) now() time.Time {
+	if qe.replay != nil {
+		return qe.replay.Now()
+	}
+	return time.Now()
+}
+
+// RunReplay 用 [start, end) 的历史K线驱动实盘引擎：每推进一次回放时钟，先按回放价格检查止损止盈，
+// 再运行一轮与实盘相同的交易循环。symbols 为空时使用观察列表；配置需先经过 PrepareReplayConfig
+func (qe *QuantEngine) RunReplay(symbols []string, start, end time.Time) (*ReplayReport, error) {
+	cfg := qe.currentConfig().Engine.Replay
+	if !qe.currentConfig().Trading.Paper {
+		return nil, fmt.Errorf("回放模式需要纸面交易，请先调用 PrepareReplayConfig")
+	}
+	if len(symbols) == 0 {
+		symbols = qe.watchlist.Symbols()
+	}
+	if len(symbols) == 0 {
+		return nil, fmt.Errorf("没有需要回放的标的")
+	}
+
+	replay, err := qe.dataManager.StartReplay(symbols, start, end, cfg.Warmup)
+	if err != nil {
+		return nil, err
+	}
+	qe.replay = replay
+
+	// 回放只交易加载了历史数据的标的
+	qe.scanner = nil
+	qe.watchlist = scanner.NewWatchlist(symbols)
+	if cfg.Agent == "mock" {
+		qe.agentClient = agent.CreateClient(qe.currentConfig().AgentService.URL, true)
+		qe.newsFetcher = nil
+		qe.degradation.markHealthy(DependencyAgent)
+	}
+
+	if err := qe.Start(); err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := qe.Stop(); err != nil {
+			log.Printf("停止量化引擎失败: %v", err)
+		}
+	}()
+
+	_, bars := replay.Progress()
+	log.Printf("开始回放: 标的=%v, 区间=%s ~ %s, K线时间数=%d, 推进步长=%v, Agent=%s",
+		symbols, start.Format("2006-01-02"), end.Format("2006-01-02"), bars, cfg.Step, cfg.Agent)
+	began := time.Now()
+	for replay.Advance(cfg.Step) {
+		qe.positionMonitor.CheckAll()
+		if err := qe.RunSingleLoop(); err != nil {
+			log.Printf("回放循环失败: 行情时间=%s, 错误=%v", replay.Now().Format("2006-01-02 15:04"), err)
+		}
+		if cfg.Delay > 0 {
+			time.Sleep(cfg.Delay)
+		}
+	}
+
+	stats := qe.GetStats()
+	summary := qe.pnlSummary()
+	report := &ReplayReport{
+		Symbols:       symbols,
+		Start:         start,
+		End:           end,
+		Bars:          bars,
+		Cycles:        stats.TotalCycles,
+		FailedCycles:  stats.FailedCycles,
+		Signals:       stats.TotalSignals,
+		Trades:        stats.ExecutedTrades,
+		RealizedPnL:   summary.RealizedPnL,
+		UnrealizedPnL: summary.UnrealizedPnL,
+		TotalPnL:      summary.TotalPnL,
+		Elapsed:       time.Since(began),
+		OutputDir:     cfg.OutputDir,
+	}
+	log.Printf("回放完成: 循环=%d, 失败=%d, 信号=%d, 成交=%d, 总盈亏=%.2f, 耗时=%v",
+		report.Cycles, report.FailedCycles, report.Signals, report.Trades, report.TotalPnL, report.Elapsed.Round(time.Millisecond))
+	return report, nil
+}
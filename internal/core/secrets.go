@@ -0,0 +1,68 @@
+package core
+
+import (
+	"fmt"
+	"log"
+
+	"agent-quant-system/internal/config"
+	"agent-quant-system/internal/secrets"
+)
+
+// resolveAPIKeys 将 api_keys 中的密钥引用替换为解析后的密钥；解析失败的密钥置空，依赖它的功能按未配置处理
+func resolveAPIKeys(keys *config.APIKeysConfig, resolver *secrets.Resolver) {
+	for _, item := range []struct {
+		name  string
+		value *string
+	}{
+		{"openai_key", &keys.OpenAIKey},
+		{"anthropic_key", &keys.AnthropicKey},
+		{"newsapi_key", &keys.NewsAPIKey},
+		{"finnhub_key", &keys.FinnhubKey},
+	} {
+		if *item.value == "" {
+			continue
+		}
+		secret, err := resolver.Resolve(*item.value)
+		if err != nil {
+			log.Printf("解析 api_keys.%s 失败: %v", item.name, err)
+			*item.value = ""
+			continue
+		}
+		*item.value = secret.Reveal()
+	}
+}
+
+// resolveAPIToken 将 api.auth.token 中的令牌引用替换为解析后的值；解析失败时返回错误，避免控制API以不认证的方式启动
+func resolveAPIToken(auth *config.APIAuthConfig, resolver *secrets.Resolver) error {
+	if auth.Token == "" {
+		return nil
+	}
+	secret, err := resolver.Resolve(auth.Token)
+	if err != nil {
+		return fmt.Errorf("解析 api.auth.token 失败: %w", err)
+	}
+	auth.Token = secret.Reveal()
+	return nil
+}
+
+// resolveAgentAuth 将 agent_service.auth 中的凭证引用替换为解析后的值；解析失败时返回错误，避免以未认证的请求访问Agent服务
+func resolveAgentAuth(auth *config.AgentAuthConfig, resolver *secrets.Resolver) error {
+	for _, item := range []struct {
+		name  string
+		value *string
+	}{
+		{"api_key", &auth.APIKey},
+		{"bearer_token", &auth.BearerToken},
+		{"signing_secret", &auth.SigningSecret},
+	} {
+		if *item.value == "" {
+			continue
+		}
+		secret, err := resolver.Resolve(*item.value)
+		if err != nil {
+			return fmt.Errorf("解析 agent_service.auth.%s 失败: %w", item.name, err)
+		}
+		*item.value = secret.Reveal()
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package core
+
+import (
+	"log"
+	"strings"
+
+	"agent-quant-system/internal/agent"
+	"agent-quant-system/internal/money"
+	"agent-quant-system/internal/strategy"
+)
+
+// tradeSetup 有策略需要时请求Agent给出交易方案，并附上下单账户的权益和持仓。
+// 方案不可用时返回 nil，这类策略本轮不交易（不会沿用过期方案或按中性方案下单）
+func (qe *QuantEngine) tradeSetup(symbol string, strategies []string, newsItems []string, price float64) *strategy.TradeSetup {
+	consumer := ""
+	for _, name := range strategies {
+		s, err := qe.strategyManager.GetStrategy(name)
+		if err != nil {
+			continue
+		}
+		if c, ok := s.(strategy.TradeSetupConsumer); ok && c.UsesTradeSetup() {
+			consumer = name
+			break
+		}
+	}
+	if consumer == "" || len(newsItems) == 0 || price <= 0 {
+		return nil
+	}
+
+	analyzer, ok := qe.agentClient.(agent.TradeSetupAnalyzer)
+	if !ok {
+		log.Printf("Agent客户端不支持交易方案分析，跳过: %s", symbol)
+		return nil
+	}
+
+	accountName := qe.tradingEngine.RouteAccount(consumer)
+	equity, err := qe.tradingEngine.GetAccountEquity(accountName)
+	if err != nil {
+		log.Printf("获取账户权益失败，跳过交易方案: 账户=%s, %v", accountName, err)
+		return nil
+	}
+	positions, err := qe.tradingEngine.GetAccountPositions(accountName)
+	if err != nil {
+		log.Printf("获取账户持仓失败，跳过交易方案: 账户=%s, %v", accountName, err)
+		return nil
+	}
+
+	request := agent.TradeSetupRequest{
+		Symbol:    symbol,
+		Price:     price,
+		Position:  money.Float(positions[symbol].Quantity),
+		Equity:    money.Float(equity),
+		NewsItems: newsItems,
+	}
+	setup, err := analyzer.AnalyzeTradeSetup(request)
+	if err != nil && qe.currentConfig().Degradation.Agent == "mock" {
+		if mock, ok := qe.mockAgent().(agent.TradeSetupAnalyzer); ok {
+			setup, err = mock.AnalyzeTradeSetup(request)
+		}
+	}
+	if err != nil {
+		log.Printf("获取交易方案失败，本轮不按方案交易: %s, %v", symbol, err)
+		return nil
+	}
+	qe.signalLog.record(signalLogSetup, qe.stats.TotalCycles, symbol, false, map[string]interface{}{
+		"analysis_id":       setup.AnalysisID,
+		"action":            setup.Action,
+		"target_allocation": setup.TargetAllocation,
+		"stop_loss":         setup.StopLoss,
+		"take_profit":       setup.TakeProfit,
+		"confidence":        setup.ConfidenceScore,
+		"rationale":         setup.Rationale,
+		"account":           accountName,
+	})
+
+	return &strategy.TradeSetup{
+		Action:           setupAction(setup.Action),
+		TargetAllocation: setup.TargetAllocation,
+		StopLoss:         setup.StopLoss,
+		TakeProfit:       setup.TakeProfit,
+		Confidence:       setup.ConfidenceScore,
+		Rationale:        setup.Rationale,
+		Equity:           request.Equity,
+		Position:         request.Position,
+	}
+}
+
+// setupAction Agent操作对应的信号类型
+func setupAction(action string) strategy.Signal {
+	switch strings.ToLower(action) {
+	case agent.ActionBuy:
+		return strategy.Buy
+	case agent.ActionSell:
+		return strategy.Sell
+	default:
+		return strategy.Hold
+	}
+}
@@ -0,0 +1,198 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"agent-quant-system/internal/config"
+)
+
+// 信号日志的条目类型
+const (
+	signalLogAgent    = "agent_response" // Agent新闻分析结果
+	signalLogSetup    = "trade_setup"    // Agent交易方案
+	signalLogSignal   = "signal"         // 策略生成的交易信号
+	signalLogSampling = "sampling"       // 循环结束时被抽样丢弃的条数汇总
+	signalLogManual   = "manual_order"   // 命令行或控制API提交的手动订单
+
+	signalLogCorrelation = "correlation_filter" // 相关性过滤拒绝或缩减的买入信号
+	signalLogEnsemble    = "ensemble"           // 策略组合的投票和合并结果
+)
+
+// redactedValue 脱敏后的字段值
+const redactedValue = "[REDACTED]"
+
+// signalLog 交易信号和Agent响应的结构化日志，按标的和循环抽样并脱敏
+type signalLog struct {
+	cfg      config.SignalLogConfig
+	fields   map[string]bool
+	patterns []*regexp.Regexp
+
+	dropped map[string]int // 本轮循环被抽样丢弃的条数，按条目类型
+	mutex   sync.Mutex
+}
+
+// newSignalLog 按配置创建信号日志，未启用时返回nil
+func newSignalLog(cfg config.SignalLogConfig) (*signalLog, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	if cfg.File != "" {
+		if err := os.MkdirAll(filepath.Dir(cfg.File), 0755); err != nil {
+			return nil, fmt.Errorf("创建信号日志目录失败: %w", err)
+		}
+	}
+
+	l := &signalLog{cfg: cfg, fields: make(map[string]bool), dropped: make(map[string]int)}
+	for _, field := range cfg.RedactFields {
+		l.fields[strings.ToLower(field)] = true
+	}
+	for _, pattern := range cfg.RedactPatterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("脱敏正则表达式无效 %q: %w", pattern, err)
+		}
+		l.patterns = append(l.patterns, re)
+	}
+	return l, nil
+}
+
+// sampled 本轮循环是否记录该标的；按循环序号和标的取哈希，同一标的的条目一起保留或丢弃
+func (l *signalLog) sampled(cycle int, symbol string) bool {
+	if l.cfg.SampleRate >= 1 {
+		return true
+	}
+	if l.cfg.SampleRate <= 0 {
+		return false
+	}
+	h := fnv.New32a()
+	fmt.Fprintf(h, "%d:%s", cycle, symbol)
+	return float64(h.Sum32())/float64(1<<32) < l.cfg.SampleRate
+}
+
+// record 记录一条日志；actionable 表示买入、卖出信号，按 keep_actionable 不参与抽样
+func (l *signalLog) record(kind string, cycle int, symbol string, actionable bool, fields map[string]interface{}) {
+	if l == nil {
+		return
+	}
+	if !(actionable && l.cfg.KeepActionable) && !l.sampled(cycle, symbol) {
+		l.mutex.Lock()
+		l.dropped[kind]++
+		l.mutex.Unlock()
+		return
+	}
+
+	entry := make(map[string]interface{}, len(fields)+4)
+	for key, value := range fields {
+		entry[key] = l.redact(key, value)
+	}
+	entry["time"] = time.Now()
+	entry["kind"] = kind
+	entry["cycle"] = cycle
+	entry["symbol"] = symbol
+	l.write(entry)
+}
+
+// audit 记录一条不参与抽样的日志，用于手动订单等需要完整留痕的操作
+func (l *signalLog) audit(kind string, symbol string, fields map[string]interface{}) {
+	if l == nil {
+		return
+	}
+	entry := make(map[string]interface{}, len(fields)+3)
+	for key, value := range fields {
+		entry[key] = l.redact(key, value)
+	}
+	entry["time"] = time.Now()
+	entry["kind"] = kind
+	entry["symbol"] = symbol
+	l.write(entry)
+}
+
+// endCycle 循环结束时汇总本轮被抽样丢弃的条数
+func (l *signalLog) endCycle(cycle int) {
+	if l == nil {
+		return
+	}
+	l.mutex.Lock()
+	dropped := l.dropped
+	l.dropped = make(map[string]int)
+	l.mutex.Unlock()
+
+	if len(dropped) == 0 {
+		return
+	}
+	l.write(map[string]interface{}{
+		"time":        time.Now(),
+		"kind":        signalLogSampling,
+		"cycle":       cycle,
+		"sample_rate": l.cfg.SampleRate,
+		"dropped":     dropped,
+	})
+}
+
+// redact 对字段值脱敏：字段在 redact_fields 中时整体替换，文本按 redact_patterns 替换并截断
+func (l *signalLog) redact(key string, value interface{}) interface{} {
+	if l.fields[strings.ToLower(key)] {
+		return redactedValue
+	}
+	switch v := value.(type) {
+	case string:
+		return l.redactText(v)
+	case []string:
+		result := make([]string, len(v))
+		for i, item := range v {
+			result[i] = l.redactText(item)
+		}
+		return result
+	default:
+		return value
+	}
+}
+
+// redactText 替换敏感内容并截断过长的文本
+func (l *signalLog) redactText(text string) string {
+	for _, re := range l.patterns {
+		text = re.ReplaceAllString(text, redactedValue)
+	}
+	if l.cfg.MaxFieldLength > 0 {
+		if runes := []rune(text); len(runes) > l.cfg.MaxFieldLength {
+			text = string(runes[:l.cfg.MaxFieldLength]) + "...(截断)"
+		}
+	}
+	return text
+}
+
+// write 写入一条日志，写入失败只打印不影响交易
+func (l *signalLog) write(entry map[string]interface{}) {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		log.Printf("序列化信号日志失败: %v", err)
+		return
+	}
+	if l.cfg.File == "" {
+		log.Printf("信号日志: %s", line)
+		return
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	file, err := os.OpenFile(l.cfg.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		log.Printf("打开信号日志失败: %v", err)
+		return
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		log.Printf("写入信号日志失败: %v", err)
+	}
+}
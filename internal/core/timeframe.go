@@ -0,0 +1,62 @@
+package core
+
+import (
+	"fmt"
+	"time"
+
+	"agent-quant-system/internal/data"
+)
+
+// strategyTimeframes 解析 strategy.timeframes 中各策略的K线周期
+func strategyTimeframes(timeframes map[string]string) (map[string]time.Duration, error) {
+	intervals := make(map[string]time.Duration, len(timeframes))
+	for name, timeframe := range timeframes {
+		interval, err := data.ParseInterval(timeframe)
+		if err != nil {
+			return nil, fmt.Errorf("strategy.timeframes.%s: %w", name, err)
+		}
+		intervals[name] = interval
+	}
+	return intervals, nil
+}
+
+// timeframeBars 配置了较长K线周期时，行情回看区间至少覆盖该周期的K线根数
+const timeframeBars = 60
+
+// marketDataLookback 每轮获取行情的回看区间：默认30天，配置了 strategy.timeframes 时延长到能提供
+// timeframeBars 根最长周期的K线
+func (qe *QuantEngine) marketDataLookback() time.Duration {
+	lookback := 30 * 24 * time.Hour
+	intervals, err := strategyTimeframes(qe.currentConfig().Strategy.Timeframes)
+	if err != nil {
+		return lookback
+	}
+	for _, interval := range intervals {
+		if required := interval * timeframeBars; required > lookback {
+			lookback = required
+		}
+	}
+	return lookback
+}
+
+// strategyFrame 策略使用的行情数据：配置了 strategy.timeframes 的策略收到重采样后的K线，
+// 同一标的同一周期只重采样一次，结果缓存在 frames 中
+func (qe *QuantEngine) strategyFrame(name string, df data.DataFrame, frames map[time.Duration]data.DataFrame) (data.DataFrame, error) {
+	timeframe, ok := qe.currentConfig().Strategy.Timeframes[name]
+	if !ok || timeframe == "" {
+		return df, nil
+	}
+	interval, err := data.ParseInterval(timeframe)
+	if err != nil {
+		return nil, err
+	}
+	if frame, ok := frames[interval]; ok {
+		return frame, nil
+	}
+	frame, err := data.ResampleFrame(df, interval, qe.dataManager.Session(df.Symbol()))
+	if err != nil {
+		return nil, err
+	}
+	frames[interval] = frame
+	return frame, nil
+}
@@ -0,0 +1,475 @@
+package data
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"agent-quant-system/internal/config"
+
+	_ "modernc.org/sqlite"
+)
+
+// TimeRange 左闭右开的时间区间
+type TimeRange struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// CacheStore K线缓存的存储后端
+type CacheStore interface {
+	// Ranges 已完整获取过的区间（已合并、按时间排序），区间内没有K线表示数据源在该时段没有数据（如休市），不需要重新获取
+	Ranges(provider, symbol string) ([]TimeRange, error)
+
+	// Bars 读取 [start, end) 内的K线，按时间排序
+	Bars(provider, symbol string, start, end time.Time) ([]DataPoint, error)
+
+	// Replace 用 bars 替换 span 内的K线并写入合并后的已获取区间，只改写 span 内的数据
+	Replace(provider, symbol string, span TimeRange, bars []DataPoint, ranges []TimeRange) error
+}
+
+// SQLiteCacheStore 基于 SQLite（纯 Go 实现，不依赖 cgo）的K线存储：K线按数据源、标的和时间为主键，
+// 更新时只删除并写入重新获取的区间，不重写整个标的的数据；多个进程可同时读写同一个数据库文件
+type SQLiteCacheStore struct {
+	path string
+
+	db      *sql.DB
+	initErr error
+	once    sync.Once
+}
+
+// sqliteCacheSchema K线和已获取区间的表结构，时间为 UTC Unix 纳秒
+const sqliteCacheSchema = `
+CREATE TABLE IF NOT EXISTS bars (
+	provider TEXT NOT NULL,
+	symbol   TEXT NOT NULL,
+	ts       INTEGER NOT NULL,
+	open     REAL NOT NULL,
+	high     REAL NOT NULL,
+	low      REAL NOT NULL,
+	close    REAL NOT NULL,
+	volume   INTEGER NOT NULL,
+	PRIMARY KEY (provider, symbol, ts)
+) WITHOUT ROWID;
+CREATE TABLE IF NOT EXISTS ranges (
+	provider TEXT NOT NULL,
+	symbol   TEXT NOT NULL,
+	start    INTEGER NOT NULL,
+	end      INTEGER NOT NULL,
+	PRIMARY KEY (provider, symbol, start)
+) WITHOUT ROWID;`
+
+// NewSQLiteCacheStore 创建 SQLite K线存储，数据库文件在首次读写时创建
+func NewSQLiteCacheStore(path string) *SQLiteCacheStore {
+	return &SQLiteCacheStore{path: path}
+}
+
+// open 打开数据库并建表，只执行一次
+func (s *SQLiteCacheStore) open() (*sql.DB, error) {
+	s.once.Do(func() {
+		if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+			s.initErr = fmt.Errorf("创建缓存目录失败: %w", err)
+			return
+		}
+		// WAL 模式下读不阻塞写；其他进程写入时最多等待 busy_timeout
+		dsn := "file:" + s.path + "?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_pragma=synchronous(NORMAL)"
+		db, err := sql.Open("sqlite", dsn)
+		if err != nil {
+			s.initErr = fmt.Errorf("打开缓存数据库失败: %w", err)
+			return
+		}
+		// 同一进程内的写入串行进行，避免连接之间互相等待写锁
+		db.SetMaxOpenConns(1)
+		if _, err := db.Exec(sqliteCacheSchema); err != nil {
+			db.Close()
+			s.initErr = fmt.Errorf("初始化缓存数据库失败: %w", err)
+			return
+		}
+		s.db = db
+	})
+	return s.db, s.initErr
+}
+
+// Close 关闭数据库
+func (s *SQLiteCacheStore) Close() error {
+	if s.db == nil {
+		return nil
+	}
+	return s.db.Close()
+}
+
+// cacheSymbol 标的统一为大写
+func cacheSymbol(symbol string) string {
+	return strings.ToUpper(symbol)
+}
+
+// Ranges 读取已获取的区间
+func (s *SQLiteCacheStore) Ranges(provider, symbol string) ([]TimeRange, error) {
+	db, err := s.open()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := db.Query(`SELECT start, end FROM ranges WHERE provider = ? AND symbol = ? ORDER BY start`, provider, cacheSymbol(symbol))
+	if err != nil {
+		return nil, fmt.Errorf("读取缓存区间失败: %w", err)
+	}
+	defer rows.Close()
+
+	var ranges []TimeRange
+	for rows.Next() {
+		var start, end int64
+		if err := rows.Scan(&start, &end); err != nil {
+			return nil, fmt.Errorf("读取缓存区间失败: %w", err)
+		}
+		ranges = append(ranges, TimeRange{Start: time.Unix(0, start).UTC(), End: time.Unix(0, end).UTC()})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("读取缓存区间失败: %w", err)
+	}
+	return ranges, nil
+}
+
+// Bars 读取 [start, end) 内的K线
+func (s *SQLiteCacheStore) Bars(provider, symbol string, start, end time.Time) ([]DataPoint, error) {
+	return s.queryBars(`SELECT ts, open, high, low, close, volume FROM bars
+		WHERE provider = ? AND symbol = ? AND ts >= ? AND ts < ? ORDER BY ts`,
+		provider, cacheSymbol(symbol), start.UnixNano(), end.UnixNano())
+}
+
+// LastBar 最后一根K线，没有K线时返回nil
+func (s *SQLiteCacheStore) LastBar(provider, symbol string) (*DataPoint, error) {
+	bars, err := s.queryBars(`SELECT ts, open, high, low, close, volume FROM bars
+		WHERE provider = ? AND symbol = ? ORDER BY ts DESC LIMIT 1`, provider, cacheSymbol(symbol))
+	if err != nil || len(bars) == 0 {
+		return nil, err
+	}
+	return &bars[0], nil
+}
+
+// Count 标的的K线总数
+func (s *SQLiteCacheStore) Count(provider, symbol string) (int, error) {
+	db, err := s.open()
+	if err != nil {
+		return 0, err
+	}
+	var count int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM bars WHERE provider = ? AND symbol = ?`, provider, cacheSymbol(symbol)).Scan(&count); err != nil {
+		return 0, fmt.Errorf("统计缓存K线失败: %w", err)
+	}
+	return count, nil
+}
+
+// queryBars 执行K线查询
+func (s *SQLiteCacheStore) queryBars(query string, args ...interface{}) ([]DataPoint, error) {
+	db, err := s.open()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("读取缓存K线失败: %w", err)
+	}
+	defer rows.Close()
+
+	var bars []DataPoint
+	for rows.Next() {
+		var ts int64
+		var bar DataPoint
+		if err := rows.Scan(&ts, &bar.Open, &bar.High, &bar.Low, &bar.Close, &bar.Volume); err != nil {
+			return nil, fmt.Errorf("读取缓存K线失败: %w", err)
+		}
+		bar.Timestamp = time.Unix(0, ts).UTC()
+		bars = append(bars, bar)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("读取缓存K线失败: %w", err)
+	}
+	return bars, nil
+}
+
+// Replace 在一个事务中替换 span 内的K线和标的的已获取区间
+func (s *SQLiteCacheStore) Replace(provider, symbol string, span TimeRange, bars []DataPoint, ranges []TimeRange) error {
+	db, err := s.open()
+	if err != nil {
+		return err
+	}
+	symbol = cacheSymbol(symbol)
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("写入缓存失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM bars WHERE provider = ? AND symbol = ? AND ts >= ? AND ts < ?`,
+		provider, symbol, span.Start.UnixNano(), span.End.UnixNano()); err != nil {
+		return fmt.Errorf("写入缓存失败: %w", err)
+	}
+	insert, err := tx.Prepare(`INSERT OR REPLACE INTO bars (provider, symbol, ts, open, high, low, close, volume) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return fmt.Errorf("写入缓存失败: %w", err)
+	}
+	defer insert.Close()
+	for _, bar := range bars {
+		if _, err := insert.Exec(provider, symbol, bar.Timestamp.UnixNano(), bar.Open, bar.High, bar.Low, bar.Close, bar.Volume); err != nil {
+			return fmt.Errorf("写入缓存K线失败: %w", err)
+		}
+	}
+
+	if _, err := tx.Exec(`DELETE FROM ranges WHERE provider = ? AND symbol = ?`, provider, symbol); err != nil {
+		return fmt.Errorf("写入缓存区间失败: %w", err)
+	}
+	for _, r := range ranges {
+		if _, err := tx.Exec(`INSERT INTO ranges (provider, symbol, start, end) VALUES (?, ?, ?, ?)`,
+			provider, symbol, r.Start.UnixNano(), r.End.UnixNano()); err != nil {
+			return fmt.Errorf("写入缓存区间失败: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("写入缓存失败: %w", err)
+	}
+	return nil
+}
+
+// CacheStats 缓存命中统计
+type CacheStats struct {
+	Requests    int `json:"requests"`     // 经过缓存的请求数
+	Hits        int `json:"hits"`         // 完全由缓存提供的请求数
+	Fetches     int `json:"fetches"`      // 向数据源请求缺失区间的次数
+	FetchedBars int `json:"fetched_bars"` // 从数据源获取的K线数
+}
+
+// OHLCVCache K线本地缓存：请求时只向数据源获取缓存中缺失的区间，并只把这些区间写回存储后端
+type OHLCVCache struct {
+	store         CacheStore
+	refreshRecent time.Duration
+	now           func() time.Time
+
+	locks map[string]*sync.Mutex // 按数据源和标的串行化读写
+	stats CacheStats
+	mutex sync.Mutex
+}
+
+// NewOHLCVCache 创建K线缓存；最近 refreshRecent 内的K线可能尚未走完，不标记为已获取，每次请求时重新获取
+func NewOHLCVCache(store CacheStore, refreshRecent time.Duration) *OHLCVCache {
+	return &OHLCVCache{
+		store:         store,
+		refreshRecent: refreshRecent,
+		now:           time.Now,
+		locks:         make(map[string]*sync.Mutex),
+	}
+}
+
+// NewOHLCVCacheFromConfig 按配置创建K线缓存
+func NewOHLCVCacheFromConfig(cfg config.DataCacheConfig) (*OHLCVCache, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("data.cache 配置无效: %w", err)
+	}
+	switch cfg.Backend {
+	case "sqlite":
+		return NewOHLCVCache(NewSQLiteCacheStore(filepath.Join(cfg.Dir, "ohlcv.db")), cfg.RefreshRecent), nil
+	default:
+		return nil, fmt.Errorf("不支持的缓存后端: %s", cfg.Backend)
+	}
+}
+
+// lock 获取数据源和标的对应的锁
+func (c *OHLCVCache) lock(key string) *sync.Mutex {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	lock, exists := c.locks[key]
+	if !exists {
+		lock = &sync.Mutex{}
+		c.locks[key] = lock
+	}
+	return lock
+}
+
+// Stats 获取缓存命中统计
+func (c *OHLCVCache) Stats() CacheStats {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.stats
+}
+
+// Bars 获取 [start, end) 的K线：缓存中已有的区间直接返回，缺失的区间从数据源获取后写入缓存。
+// 读取或写入缓存失败时退回直接请求数据源
+func (c *OHLCVCache) Bars(provider Provider, symbol string, start, end time.Time) ([]DataPoint, error) {
+	bars, _, err := c.bars(provider, symbol, start, end)
+	return bars, err
+}
+
+// bars 获取K线，同时返回本次从数据源获取的K线数
+func (c *OHLCVCache) bars(provider Provider, symbol string, start, end time.Time) ([]DataPoint, int, error) {
+	key := provider.Name() + "/" + strings.ToUpper(symbol)
+	lock := c.lock(key)
+	lock.Lock()
+	defer lock.Unlock()
+
+	ranges, err := c.store.Ranges(provider.Name(), symbol)
+	var cached []DataPoint
+	if err == nil {
+		cached, err = c.store.Bars(provider.Name(), symbol, start, end)
+	}
+	if err != nil {
+		log.Printf("读取K线缓存失败，直接请求数据源: 标的=%s, %v", symbol, err)
+		bars, err := provider.Bars(symbol, start, end)
+		return bars, len(bars), err
+	}
+
+	missing := missingRanges(ranges, start, end)
+	fetchedBars := 0
+	// 尚未走完的K线不标记为已获取；已获取区间截止到 settled 处所在K线的开始时间，使重新获取的区间与K线边界对齐
+	settled := c.now().Add(-c.refreshRecent)
+	for _, gap := range missing {
+		bars, err := provider.Bars(symbol, gap.Start, gap.End)
+		if err != nil {
+			return nil, fetchedBars, err
+		}
+		fetchedBars += len(bars)
+		cached = mergeBars(cached, bars, gap)
+		covered := minTime(gap.End, settled)
+		if covered.Equal(settled) {
+			for _, bar := range bars {
+				if bar.Timestamp.After(settled) {
+					break
+				}
+				covered = bar.Timestamp
+			}
+		}
+		if covered.After(gap.Start) {
+			ranges = addRange(ranges, TimeRange{Start: gap.Start, End: covered})
+		}
+		// 只写入本次获取的区间，最近尚未走完的K线每次请求只改写这一小段
+		if err := c.store.Replace(provider.Name(), symbol, gap, bars, ranges); err != nil {
+			log.Printf("写入K线缓存失败: 标的=%s, %v", symbol, err)
+		}
+	}
+
+	c.mutex.Lock()
+	c.stats.Requests++
+	if len(missing) == 0 {
+		c.stats.Hits++
+	}
+	c.stats.Fetches += len(missing)
+	c.stats.FetchedBars += fetchedBars
+	c.mutex.Unlock()
+
+	return sliceBars(cached, start, end), fetchedBars, nil
+}
+
+// missingRanges [start, end) 中不在 covered 里的区间
+func missingRanges(covered []TimeRange, start, end time.Time) []TimeRange {
+	var missing []TimeRange
+	cursor := start
+	for _, r := range covered {
+		if !r.End.After(cursor) {
+			continue
+		}
+		if !r.Start.Before(end) {
+			break
+		}
+		if r.Start.After(cursor) {
+			missing = append(missing, TimeRange{Start: cursor, End: r.Start})
+		}
+		cursor = r.End
+		if !cursor.Before(end) {
+			return missing
+		}
+	}
+	if cursor.Before(end) {
+		missing = append(missing, TimeRange{Start: cursor, End: end})
+	}
+	return missing
+}
+
+// addRange 加入区间并合并重叠或相接的区间
+func addRange(ranges []TimeRange, added TimeRange) []TimeRange {
+	ranges = append(ranges, added)
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i].Start.Before(ranges[j].Start) })
+
+	merged := ranges[:1]
+	for _, r := range ranges[1:] {
+		last := &merged[len(merged)-1]
+		if !r.Start.After(last.End) {
+			if r.End.After(last.End) {
+				last.End = r.End
+			}
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
+
+// mergeBars 用新获取的K线替换 gap 区间内的旧K线，结果按时间排序
+func mergeBars(existing, fetched []DataPoint, gap TimeRange) []DataPoint {
+	merged := make([]DataPoint, 0, len(existing)+len(fetched))
+	for _, bar := range existing {
+		if bar.Timestamp.Before(gap.Start) || !bar.Timestamp.Before(gap.End) {
+			merged = append(merged, bar)
+		}
+	}
+	merged = append(merged, fetched...)
+	sort.SliceStable(merged, func(i, j int) bool { return merged[i].Timestamp.Before(merged[j].Timestamp) })
+	return merged
+}
+
+// sliceBars [start, end) 内的K线，bars 需按时间排序
+func sliceBars(bars []DataPoint, start, end time.Time) []DataPoint {
+	from := sort.Search(len(bars), func(i int) bool { return !bars[i].Timestamp.Before(start) })
+	to := sort.Search(len(bars), func(i int) bool { return !bars[i].Timestamp.Before(end) })
+	return append([]DataPoint(nil), bars[from:to]...)
+}
+
+// minTime 较早的时间
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+// SyncResult 一个标的的缓存预热结果
+type SyncResult struct {
+	Symbol      string `json:"symbol"`
+	Provider    string `json:"provider"`
+	Bars        int    `json:"bars"`         // 区间内的K线数
+	FetchedBars int    `json:"fetched_bars"` // 本次从数据源获取的K线数
+	Error       string `json:"error,omitempty"`
+}
+
+// SyncCache 预热K线缓存：按标的当前的数据源获取 [start, end) 内缓存缺失的区间
+func (dm *DataManager) SyncCache(symbols []string, start, end time.Time) ([]SyncResult, error) {
+	if dm.cache == nil {
+		return nil, fmt.Errorf("未启用K线缓存 (data.cache.enabled)")
+	}
+
+	results := make([]SyncResult, 0, len(symbols))
+	for _, symbol := range symbols {
+		slot := dm.acquire(symbol)
+		bars, fetched, err := dm.cache.bars(slot.provider, dm.providerSymbol(slot, symbol), start, end)
+		slot.release()
+		result := SyncResult{Symbol: symbol, Provider: slot.provider.Name(), Bars: len(bars), FetchedBars: fetched}
+		if err != nil {
+			result.Error = err.Error()
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// CacheStats 获取K线缓存的命中统计，未启用时返回nil
+func (dm *DataManager) CacheStats() *CacheStats {
+	if dm.cache == nil {
+		return nil
+	}
+	stats := dm.cache.Stats()
+	return &stats
+}
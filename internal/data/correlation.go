@@ -0,0 +1,252 @@
+package data
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// ReturnSeries 单个标的按时间排列的收益率序列
+type ReturnSeries struct {
+	Symbol  string      `json:"symbol"`
+	Times   []time.Time `json:"times"` // 每个收益率对应K线的时间
+	Returns []float64   `json:"returns"`
+}
+
+// ReturnsFromDataFrame 按收盘价计算简单收益率，前一根K线价格为0时跳过该点
+func ReturnsFromDataFrame(df DataFrame) (*ReturnSeries, error) {
+	closes := df["close"]
+	timestamps := df["timestamp"]
+	if len(closes) != len(timestamps) {
+		return nil, fmt.Errorf("close 和 timestamp 列的长度不一致")
+	}
+
+	series := &ReturnSeries{Symbol: df.Symbol()}
+	previous := math.NaN()
+	for i, value := range closes {
+		price, ok := toFloat64(value)
+		if !ok {
+			return nil, fmt.Errorf("第 %d 行的收盘价无效: %v", i, value)
+		}
+		timestamp, ok := timestamps[i].(time.Time)
+		if !ok {
+			return nil, fmt.Errorf("第 %d 行的时间戳无效: %v", i, timestamps[i])
+		}
+
+		if !math.IsNaN(previous) && previous != 0 {
+			series.Times = append(series.Times, timestamp)
+			series.Returns = append(series.Returns, price/previous-1)
+		}
+		previous = price
+	}
+	return series, nil
+}
+
+// toFloat64 将数值类型的列值转换为 float64
+func toFloat64(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
+
+// AlignedReturns 多个标的在共同时间点上的收益率，Returns[i] 为 Symbols[i] 的序列
+type AlignedReturns struct {
+	Symbols []string    `json:"symbols"`
+	Times   []time.Time `json:"times"`
+	Returns [][]float64 `json:"returns"`
+}
+
+// AlignReturns 按时间对齐多个标的的收益率，只保留所有标的都有数据的时间点
+func AlignReturns(series ...*ReturnSeries) (*AlignedReturns, error) {
+	if len(series) == 0 {
+		return nil, fmt.Errorf("没有收益率序列")
+	}
+
+	// 统计每个时间点出现在多少个序列中
+	counts := make(map[int64]int)
+	for _, s := range series {
+		seen := make(map[int64]bool, len(s.Times))
+		for _, t := range s.Times {
+			if key := t.UnixNano(); !seen[key] {
+				seen[key] = true
+				counts[key]++
+			}
+		}
+	}
+
+	aligned := &AlignedReturns{Returns: make([][]float64, len(series))}
+	added := make(map[int64]bool)
+	for _, t := range series[0].Times {
+		if key := t.UnixNano(); counts[key] == len(series) && !added[key] {
+			added[key] = true
+			aligned.Times = append(aligned.Times, t)
+		}
+	}
+	for i, s := range series {
+		// 同一时间点重复时取第一个
+		byTime := make(map[int64]float64, len(s.Times))
+		for j, t := range s.Times {
+			if _, exists := byTime[t.UnixNano()]; !exists {
+				byTime[t.UnixNano()] = s.Returns[j]
+			}
+		}
+
+		returns := make([]float64, len(aligned.Times))
+		for j, t := range aligned.Times {
+			returns[j] = byTime[t.UnixNano()]
+		}
+		aligned.Symbols = append(aligned.Symbols, s.Symbol)
+		aligned.Returns[i] = returns
+	}
+	return aligned, nil
+}
+
+// Matrix 按标的索引的协方差或相关系数矩阵
+type Matrix struct {
+	Symbols []string    `json:"symbols"`
+	Values  [][]float64 `json:"values"`
+}
+
+// Get 获取两个标的之间的值
+func (m *Matrix) Get(a, b string) (float64, bool) {
+	i, j := m.index(a), m.index(b)
+	if i < 0 || j < 0 {
+		return 0, false
+	}
+	return m.Values[i][j], true
+}
+
+// index 标的在矩阵中的位置，不存在时返回-1
+func (m *Matrix) index(symbol string) int {
+	for i, s := range m.Symbols {
+		if s == symbol {
+			return i
+		}
+	}
+	return -1
+}
+
+// newMatrix 创建 n×n 的零矩阵
+func newMatrix(symbols []string) *Matrix {
+	values := make([][]float64, len(symbols))
+	for i := range values {
+		values[i] = make([]float64, len(symbols))
+	}
+	return &Matrix{Symbols: append([]string(nil), symbols...), Values: values}
+}
+
+// Covariance 计算 [start, end) 时间点上的样本协方差矩阵
+func (a *AlignedReturns) Covariance(start, end int) (*Matrix, error) {
+	if start < 0 || end > len(a.Times) || end-start < 2 {
+		return nil, fmt.Errorf("计算协方差至少需要2个时间点: [%d, %d)", start, end)
+	}
+
+	n := float64(end - start)
+	means := make([]float64, len(a.Symbols))
+	for i, returns := range a.Returns {
+		for _, r := range returns[start:end] {
+			means[i] += r
+		}
+		means[i] /= n
+	}
+
+	matrix := newMatrix(a.Symbols)
+	for i := range a.Symbols {
+		for j := i; j < len(a.Symbols); j++ {
+			sum := 0.0
+			for k := start; k < end; k++ {
+				sum += (a.Returns[i][k] - means[i]) * (a.Returns[j][k] - means[j])
+			}
+			matrix.Values[i][j] = sum / (n - 1)
+			matrix.Values[j][i] = matrix.Values[i][j]
+		}
+	}
+	return matrix, nil
+}
+
+// Correlation 计算 [start, end) 时间点上的相关系数矩阵；收益率没有波动的标的与其他标的的相关系数为0
+func (a *AlignedReturns) Correlation(start, end int) (*Matrix, error) {
+	covariance, err := a.Covariance(start, end)
+	if err != nil {
+		return nil, err
+	}
+	return correlationFromCovariance(covariance), nil
+}
+
+// correlationFromCovariance 由协方差矩阵换算相关系数矩阵
+func correlationFromCovariance(covariance *Matrix) *Matrix {
+	matrix := newMatrix(covariance.Symbols)
+	for i := range covariance.Symbols {
+		for j := range covariance.Symbols {
+			if i == j {
+				matrix.Values[i][j] = 1
+				continue
+			}
+			denominator := math.Sqrt(covariance.Values[i][i] * covariance.Values[j][j])
+			if denominator > 0 {
+				matrix.Values[i][j] = covariance.Values[i][j] / denominator
+			}
+		}
+	}
+	return matrix
+}
+
+// RollingMatrix 一个滚动窗口的协方差和相关系数矩阵
+type RollingMatrix struct {
+	Time        time.Time `json:"time"` // 窗口最后一个时间点
+	Covariance  *Matrix   `json:"covariance"`
+	Correlation *Matrix   `json:"correlation"`
+}
+
+// Rolling 按长度为 window 的滑动窗口计算协方差和相关系数矩阵，每个时间点一个结果
+func (a *AlignedReturns) Rolling(window int) ([]RollingMatrix, error) {
+	if window < 2 {
+		return nil, fmt.Errorf("窗口长度至少为2")
+	}
+	if len(a.Times) < window {
+		return nil, fmt.Errorf("共同时间点 %d 个，少于窗口长度 %d", len(a.Times), window)
+	}
+
+	results := make([]RollingMatrix, 0, len(a.Times)-window+1)
+	for end := window; end <= len(a.Times); end++ {
+		covariance, err := a.Covariance(end-window, end)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, RollingMatrix{
+			Time:        a.Times[end-1],
+			Covariance:  covariance,
+			Correlation: correlationFromCovariance(covariance),
+		})
+	}
+	return results, nil
+}
+
+// GetAlignedReturns 获取多个标的的行情并按时间对齐收益率
+func (dm *DataManager) GetAlignedReturns(symbols []string, startDate, endDate string) (*AlignedReturns, error) {
+	series := make([]*ReturnSeries, 0, len(symbols))
+	for _, symbol := range symbols {
+		df, err := dm.GetMarketData(symbol, startDate, endDate)
+		if err != nil {
+			return nil, fmt.Errorf("获取 %s 行情失败: %w", symbol, err)
+		}
+		returns, err := ReturnsFromDataFrame(df)
+		if err != nil {
+			return nil, fmt.Errorf("计算 %s 收益率失败: %w", symbol, err)
+		}
+		if returns.Symbol == "" {
+			returns.Symbol = symbol
+		}
+		series = append(series, returns)
+	}
+	return AlignReturns(series...)
+}
@@ -0,0 +1,103 @@
+package data
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"agent-quant-system/internal/instrument"
+)
+
+// ContractColumn 连续合约行情的合约列，每行为该K线取自的月份合约，换月处合约代码变化
+const ContractColumn = "contract"
+
+// SetInstruments 设置期货品种规格，设置后连续合约代码（如 ES=F）的行情按移仓日拼接各月合约
+func (dm *DataManager) SetInstruments(registry *instrument.Registry) {
+	dm.mutex.Lock()
+	defer dm.mutex.Unlock()
+
+	dm.instruments = registry
+}
+
+// instrumentRegistry 当前的期货品种规格，未设置时为nil
+func (dm *DataManager) instrumentRegistry() *instrument.Registry {
+	dm.mutex.RLock()
+	defer dm.mutex.RUnlock()
+
+	return dm.instruments
+}
+
+// continuousBars 拼接 [start, end) 区间的连续合约行情：每个合约取上一合约移仓日到本合约移仓日之间的K线，
+// 换月时按品种的调整方式对之前的K线做后复权，使最近合约的价格保持不变；返回K线和每根K线所属的合约
+func (dm *DataManager) continuousBars(spec *instrument.Spec, start, end time.Time) ([]DataPoint, []interface{}, error) {
+	var data []DataPoint
+	var contracts []interface{}
+
+	segmentStart := start
+	for _, contract := range spec.Contracts(start, end) {
+		segmentEnd := contract.RollDate()
+		if segmentEnd.After(end) {
+			segmentEnd = end
+		}
+		if !segmentStart.Before(segmentEnd) {
+			continue
+		}
+
+		// 从上一合约最后一根K线的时间开始取，用同一时间的两个合约价格计算换月价差
+		fetchStart := segmentStart
+		if len(data) > 0 {
+			fetchStart = data[len(data)-1].Timestamp
+		}
+		bars, err := dm.bars(contract.Symbol(), fetchStart, segmentEnd)
+		if err != nil {
+			return nil, nil, fmt.Errorf("获取合约 %s 行情失败: %w", contract.Symbol(), err)
+		}
+
+		if len(data) > 0 && len(bars) > 0 {
+			last := data[len(data)-1]
+			reference := bars[0].Open
+			if bars[0].Timestamp.Equal(last.Timestamp) {
+				reference = bars[0].Close
+				bars = bars[1:]
+			}
+			adjustBars(data, spec.Adjust, last.Close, reference)
+			log.Printf("连续合约 %s 换月: %s -> %s, 时间=%s, 旧合约=%.4f, 新合约=%.4f",
+				spec.Continuous(), contracts[len(contracts)-1], contract.Symbol(),
+				last.Timestamp.Format("2006-01-02 15:04"), last.Close, reference)
+		}
+
+		for _, bar := range bars {
+			if bar.Timestamp.Before(segmentEnd) {
+				data = append(data, bar)
+				contracts = append(contracts, contract.Symbol())
+			}
+		}
+		segmentStart = segmentEnd
+	}
+	return data, contracts, nil
+}
+
+// adjustBars 换月后复权：difference 将之前的价格加上新旧合约价差，ratio 乘以新旧合约价格比，none 不调整
+func adjustBars(bars []DataPoint, method string, oldPrice, newPrice float64) {
+	switch method {
+	case "difference":
+		gap := newPrice - oldPrice
+		for i := range bars {
+			bars[i].Open += gap
+			bars[i].High += gap
+			bars[i].Low += gap
+			bars[i].Close += gap
+		}
+	case "ratio":
+		if oldPrice <= 0 {
+			return
+		}
+		ratio := newPrice / oldPrice
+		for i := range bars {
+			bars[i].Open *= ratio
+			bars[i].High *= ratio
+			bars[i].Low *= ratio
+			bars[i].Close *= ratio
+		}
+	}
+}
@@ -0,0 +1,360 @@
+package data
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"agent-quant-system/internal/config"
+)
+
+// ImportedProviderName 导入数据的数据源名称，可在 data.providers 中使用或运行时切换
+const ImportedProviderName = "imported"
+
+// ImportOptions 导入OHLCV文件的选项
+type ImportOptions struct {
+	Format     string               // csv / parquet，为空时按扩展名识别
+	Columns    config.ImportColumns // 列名映射
+	Location   *time.Location       // 时间不带时区时使用的时区
+	TimeFormat string               // 时间格式（Go 时间格式），为空时自动识别
+	Delimiter  rune
+}
+
+// ImportOptionsFromConfig 按 data.import 配置生成导入选项
+func ImportOptionsFromConfig(cfg config.DataImportConfig) (ImportOptions, error) {
+	location, err := time.LoadLocation(cfg.Timezone)
+	if err != nil {
+		return ImportOptions{}, fmt.Errorf("无效的时区 %s: %w", cfg.Timezone, err)
+	}
+	delimiter := ','
+	if cfg.Delimiter != "" {
+		delimiter = []rune(cfg.Delimiter)[0]
+	}
+	return ImportOptions{
+		Columns:    cfg.Columns,
+		Location:   location,
+		TimeFormat: cfg.TimeFormat,
+		Delimiter:  delimiter,
+	}, nil
+}
+
+// timeLayouts 未指定时间格式时依次尝试的格式
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04",
+	"2006/01/02 15:04:05",
+	"2006-01-02",
+	"2006/01/02",
+	"20060102",
+}
+
+// ReadOHLCVFile 读取OHLCV文件，按标的返回按时间排序的K线；没有标的列时全部K线归入 symbol
+func ReadOHLCVFile(path, symbol string, opts ImportOptions) (map[string][]DataPoint, error) {
+	format := strings.ToLower(opts.Format)
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	}
+	switch format {
+	case "csv", "txt", "parquet":
+	default:
+		return nil, fmt.Errorf("不支持的文件格式: %s，可选: csv, parquet", format)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("打开文件失败: %w", err)
+	}
+	defer file.Close()
+	if format == "parquet" {
+		return readOHLCVParquet(file, symbol, opts)
+	}
+	return readOHLCVCSV(file, symbol, opts)
+}
+
+// readOHLCVCSV 按列名映射解析CSV，列名不区分大小写
+func readOHLCVCSV(r io.Reader, symbol string, opts ImportOptions) (map[string][]DataPoint, error) {
+	reader := csv.NewReader(r)
+	if opts.Delimiter != 0 {
+		reader.Comma = opts.Delimiter
+	}
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("读取表头失败: %w", err)
+	}
+	return parseOHLCVRecords(header, reader.Read, 1, symbol, opts)
+}
+
+// parseOHLCVRecords 按列名映射（不区分大小写）解析表格形式的记录，CSV 和 Parquet 共用；
+// next 依次返回每行各列的文本，读完时返回 io.EOF，firstLine 为第一行记录之前的行号（用于错误信息）
+func parseOHLCVRecords(header []string, next func() ([]string, error), firstLine int, symbol string, opts ImportOptions) (map[string][]DataPoint, error) {
+	var err error
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		index[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	column := func(name string, required bool) (int, error) {
+		if name == "" {
+			if required {
+				return -1, fmt.Errorf("未配置必需列的映射")
+			}
+			return -1, nil
+		}
+		i, exists := index[strings.ToLower(name)]
+		if !exists {
+			if required {
+				return -1, fmt.Errorf("文件中没有列 %s（表头: %s）", name, strings.Join(header, ", "))
+			}
+			return -1, nil
+		}
+		return i, nil
+	}
+
+	columns := opts.Columns
+	var cols [8]int
+	for i, spec := range []struct {
+		name     string
+		required bool
+	}{
+		{columns.Timestamp, true}, {columns.Open, true}, {columns.High, true}, {columns.Low, true},
+		{columns.Close, true}, {columns.Volume, false}, {columns.Time, columns.Time != ""}, {columns.Symbol, columns.Symbol != ""},
+	} {
+		if cols[i], err = column(spec.name, spec.required); err != nil {
+			return nil, err
+		}
+	}
+	timestampCol, openCol, highCol, lowCol, closeCol, volumeCol, timeCol, symbolCol := cols[0], cols[1], cols[2], cols[3], cols[4], cols[5], cols[6], cols[7]
+	if symbolCol < 0 && symbol == "" {
+		return nil, fmt.Errorf("文件没有标的列，需要指定标的")
+	}
+
+	location := opts.Location
+	if location == nil {
+		location = time.UTC
+	}
+
+	result := make(map[string][]DataPoint)
+	line := firstLine
+	for {
+		record, err := next()
+		if err == io.EOF {
+			break
+		}
+		line++
+		if err != nil {
+			return nil, fmt.Errorf("第 %d 行解析失败: %w", line, err)
+		}
+		field := func(i int) string {
+			if i < 0 || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+
+		rowSymbol := symbol
+		if symbolCol >= 0 {
+			rowSymbol = strings.ToUpper(field(symbolCol))
+			if symbol != "" && rowSymbol != strings.ToUpper(symbol) {
+				continue
+			}
+		}
+
+		stamp := field(timestampCol)
+		if timeCol >= 0 {
+			stamp += " " + field(timeCol)
+		}
+		timestamp, err := parseTimestamp(stamp, opts.TimeFormat, location)
+		if err != nil {
+			return nil, fmt.Errorf("第 %d 行: %w", line, err)
+		}
+
+		var prices [4]float64
+		for i, col := range []int{openCol, highCol, lowCol, closeCol} {
+			if prices[i], err = strconv.ParseFloat(field(col), 64); err != nil {
+				return nil, fmt.Errorf("第 %d 行的 %s 无效: %q", line, header[col], field(col))
+			}
+		}
+		var volume int64
+		if text := field(volumeCol); volumeCol >= 0 && text != "" {
+			value, err := strconv.ParseFloat(text, 64)
+			if err != nil {
+				return nil, fmt.Errorf("第 %d 行的 %s 无效: %q", line, header[volumeCol], text)
+			}
+			volume = int64(math.Round(value))
+		}
+
+		result[rowSymbol] = append(result[rowSymbol], DataPoint{
+			Timestamp: timestamp,
+			Open:      prices[0],
+			High:      prices[1],
+			Low:       prices[2],
+			Close:     prices[3],
+			Volume:    volume,
+		})
+	}
+
+	for name, bars := range result {
+		sort.SliceStable(bars, func(i, j int) bool { return bars[i].Timestamp.Before(bars[j].Timestamp) })
+		result[name] = dedupeBars(bars)
+	}
+	return result, nil
+}
+
+// dedupeBars 去掉时间相同的重复K线，保留最后一条
+func dedupeBars(bars []DataPoint) []DataPoint {
+	deduped := bars[:0]
+	for _, bar := range bars {
+		if n := len(deduped); n > 0 && deduped[n-1].Timestamp.Equal(bar.Timestamp) {
+			deduped[n-1] = bar
+			continue
+		}
+		deduped = append(deduped, bar)
+	}
+	return deduped
+}
+
+// parseTimestamp 解析时间：带时区的时间按其时区，不带时区的按 location；纯数字按 Unix 秒或毫秒。结果统一为UTC
+func parseTimestamp(value, layout string, location *time.Location) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, fmt.Errorf("时间为空")
+	}
+	if layout != "" {
+		t, err := time.ParseInLocation(layout, value, location)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("时间 %q 不符合格式 %s", value, layout)
+		}
+		return t.UTC(), nil
+	}
+
+	if number, err := strconv.ParseInt(value, 10, 64); err == nil && len(value) != 8 {
+		if number > 1e11 {
+			return time.UnixMilli(number).UTC(), nil
+		}
+		return time.Unix(number, 0).UTC(), nil
+	}
+	for _, candidate := range timeLayouts {
+		if t, err := time.ParseInLocation(candidate, value, location); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("无法识别的时间: %q", value)
+}
+
+// ImportedProvider 从导入目录读取用户提供的OHLCV数据的数据源
+type ImportedProvider struct {
+	store *SQLiteCacheStore
+}
+
+// NewImportedProvider 创建导入数据源，导入的K线保存在 <dir>/imported.db
+func NewImportedProvider(dir string) *ImportedProvider {
+	return &ImportedProvider{store: NewSQLiteCacheStore(filepath.Join(dir, "imported.db"))}
+}
+
+// Name 数据源名称
+func (p *ImportedProvider) Name() string {
+	return ImportedProviderName
+}
+
+// Bars 返回 [start, end) 内导入的K线
+func (p *ImportedProvider) Bars(symbol string, start, end time.Time) ([]DataPoint, error) {
+	ranges, err := p.store.Ranges(ImportedProviderName, symbol)
+	if err != nil {
+		return nil, err
+	}
+	if len(ranges) == 0 {
+		return nil, fmt.Errorf("没有导入 %s 的数据", symbol)
+	}
+	return p.store.Bars(ImportedProviderName, symbol, start, end)
+}
+
+// LatestPrice 导入数据中最后一根K线的收盘价
+func (p *ImportedProvider) LatestPrice(symbol string) (float64, error) {
+	last, err := p.store.LastBar(ImportedProviderName, symbol)
+	if err != nil {
+		return 0, err
+	}
+	if last == nil {
+		return 0, fmt.Errorf("没有导入 %s 的数据", symbol)
+	}
+	return last.Close, nil
+}
+
+// ImportResult 一个标的的导入结果
+type ImportResult struct {
+	Symbol string    `json:"symbol"`
+	Bars   int       `json:"bars"`  // 本次导入的K线数
+	Total  int       `json:"total"` // 导入后该标的的K线总数
+	Start  time.Time `json:"start"`
+	End    time.Time `json:"end"`
+}
+
+// Import 写入K线，替换已导入数据中相同时间区间的K线
+func (p *ImportedProvider) Import(symbol string, bars []DataPoint) (*ImportResult, error) {
+	if len(bars) == 0 {
+		return nil, fmt.Errorf("%s 没有K线", symbol)
+	}
+	ranges, err := p.store.Ranges(ImportedProviderName, symbol)
+	if err != nil {
+		return nil, err
+	}
+	span := TimeRange{Start: bars[0].Timestamp, End: bars[len(bars)-1].Timestamp.Add(time.Nanosecond)}
+	if err := p.store.Replace(ImportedProviderName, symbol, span, bars, addRange(ranges, span)); err != nil {
+		return nil, err
+	}
+	total, err := p.store.Count(ImportedProviderName, symbol)
+	if err != nil {
+		return nil, err
+	}
+	return &ImportResult{
+		Symbol: symbol,
+		Bars:   len(bars),
+		Total:  total,
+		Start:  span.Start,
+		End:    bars[len(bars)-1].Timestamp,
+	}, nil
+}
+
+// ImportFile 导入OHLCV文件到导入数据源；symbol 为空时按文件中的标的列分别导入
+func (dm *DataManager) ImportFile(path, symbol string, opts ImportOptions) ([]ImportResult, error) {
+	dm.mutex.RLock()
+	provider, _ := dm.providers[ImportedProviderName].(*ImportedProvider)
+	dm.mutex.RUnlock()
+	if provider == nil {
+		return nil, fmt.Errorf("未注册导入数据源")
+	}
+
+	bySymbol, err := ReadOHLCVFile(path, strings.ToUpper(symbol), opts)
+	if err != nil {
+		return nil, err
+	}
+	if len(bySymbol) == 0 {
+		return nil, fmt.Errorf("文件中没有可导入的K线")
+	}
+
+	symbols := make([]string, 0, len(bySymbol))
+	for name := range bySymbol {
+		symbols = append(symbols, name)
+	}
+	sort.Strings(symbols)
+
+	results := make([]ImportResult, 0, len(symbols))
+	for _, name := range symbols {
+		result, err := provider.Import(name, bySymbol[name])
+		if err != nil {
+			return results, fmt.Errorf("导入 %s 失败: %w", name, err)
+		}
+		results = append(results, *result)
+	}
+	return results, nil
+}
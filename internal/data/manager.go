@@ -3,12 +3,31 @@ package data
 import (
 	"fmt"
 	"log"
+	"math"
+	"sync"
 	"time"
+
+	"agent-quant-system/internal/config"
+	"agent-quant-system/internal/instrument"
+	"agent-quant-system/internal/symbols"
 )
 
 // DataFrame 数据框架构体，用于存储市场数据
 type DataFrame map[string][]interface{}
 
+// SymbolColumn 标的列，每行为该K线所属的标的，策略据此为信号填写标的
+const SymbolColumn = "symbol"
+
+// Symbol 数据所属的标的（取最后一行），没有标的列时返回空字符串
+func (df DataFrame) Symbol() string {
+	values := df[SymbolColumn]
+	if len(values) == 0 {
+		return ""
+	}
+	symbol, _ := values[len(values)-1].(string)
+	return symbol
+}
+
 // DataPoint 数据点结构体
 type DataPoint struct {
 	Timestamp time.Time
@@ -27,77 +46,176 @@ type MarketData struct {
 	Data      []DataPoint
 }
 
-// DataManager 数据管理器
+// DataManager 数据管理器，按资产类别从对应的数据源获取行情，数据源可在运行时切换
 type DataManager struct {
-	// 可以添加数据库连接、API客户端等
-	// db *sql.DB
-	// apiClient *http.Client
+	providers     map[string]Provider
+	slots         map[AssetClass]*providerSlot
+	symbolClasses map[string]AssetClass
+	drainTimeout  time.Duration
+	mutex         sync.RWMutex
+
+	cache *OHLCVCache // 未启用K线缓存时为nil
+
+	quality config.DataQualityConfig // 行情数据质量检查，policy 为空时不检查
+
+	sessions       map[AssetClass]*Session // 各资产类别的交易所时段
+	symbolSessions map[string]*Session     // 按标的覆盖的交易所时段
+
+	instruments *instrument.Registry // 期货品种规格，用于拼接连续合约
+
+	symbols *symbols.Mapper // 标的代码转换，请求数据源时转换为其写法
+
+	ticks *TickStore // 逐笔数据存储，未配置 data.ticks.dir 时为nil
 }
 
-// NewDataManager 创建新的数据管理器
+// NewDataManager 创建新的数据管理器，所有资产类别使用模拟数据源
 func NewDataManager() *DataManager {
-	return &DataManager{}
+	mock := MockProvider{}
+	dm := &DataManager{
+		providers:     map[string]Provider{mock.Name(): mock},
+		slots:         make(map[AssetClass]*providerSlot, len(AssetClasses)),
+		symbolClasses: make(map[string]AssetClass),
+		drainTimeout:  30 * time.Second,
+	}
+	for _, class := range AssetClasses {
+		dm.slots[class] = &providerSlot{provider: mock, since: time.Now()}
+	}
+	return dm
 }
 
-// GetMarketData 获取市场数据
+// GetMarketData 获取市场数据：日期按标的所在交易所的当地日期解析，包含结束日期当天（不晚于当前时间）；
+// 交易时段启用 regular_hours 时只返回常规交易时段内的K线
 func (dm *DataManager) GetMarketData(symbol, startDate, endDate string) (DataFrame, error) {
 	log.Printf("获取市场数据: 符号=%s, 开始日期=%s, 结束日期=%s", symbol, startDate, endDate)
+	symbol = dm.normalizeSymbol(symbol)
 
 	// 解析日期
-	start, err := time.Parse("2006-01-02", startDate)
+	session := dm.Session(symbol)
+	start, end, err := dm.DateRange(symbol, startDate, endDate)
 	if err != nil {
-		return nil, fmt.Errorf("解析开始日期失败: %w", err)
+		return nil, err
 	}
 
-	end, err := time.Parse("2006-01-02", endDate)
-	if err != nil {
-		return nil, fmt.Errorf("解析结束日期失败: %w", err)
+	// 连续合约按移仓日拼接各月合约的行情
+	if spec, ok := dm.instrumentRegistry().ContinuousSpec(symbol); ok {
+		data, contracts, err := dm.continuousBars(spec, start, end)
+		if err != nil {
+			return nil, err
+		}
+		if session != nil && session.RegularHours {
+			data, contracts = filterContinuous(session, data, contracts)
+		}
+		dataFrame := dm.convertToDataFrame(symbol, data)
+		if len(data) > 0 {
+			dataFrame[ContractColumn] = contracts
+		}
+		log.Printf("成功获取 %d 条连续合约行情记录", len(data))
+		return dataFrame, nil
 	}
 
-	// 模拟数据生成（实际应用中应该从数据库或API获取）
-	data := dm.generateMockData(symbol, start, end)
+	data, err := dm.bars(symbol, start, end)
+	if err != nil {
+		return nil, err
+	}
+	data = session.Filter(data)
+	if data, err = dm.applyQuality(symbol, data, end); err != nil {
+		return nil, err
+	}
 
 	// 转换为DataFrame格式
-	dataFrame := dm.convertToDataFrame(data)
+	dataFrame := dm.convertToDataFrame(symbol, data)
 
 	log.Printf("成功获取 %d 条市场数据记录", len(data))
 	return dataFrame, nil
 }
 
-// GetLatestPrice 获取最新价格
+// DateRange 按标的所在交易所的当地日期解析日期区间，返回 [start, end)，包含结束日期当天且不晚于当前时间
+func (dm *DataManager) DateRange(symbol, startDate, endDate string) (time.Time, time.Time, error) {
+	session := dm.Session(dm.normalizeSymbol(symbol))
+	start, err := session.ParseDate(startDate)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("解析开始日期失败: %w", err)
+	}
+
+	end, err := session.ParseDate(endDate)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("解析结束日期失败: %w", err)
+	}
+	end = end.AddDate(0, 0, 1)
+	if now := time.Now(); end.After(now) {
+		end = now
+	}
+	return start, end, nil
+}
+
+// bars 从标的所属资产类别的数据源获取 [start, end) 区间的K线，启用缓存时经过缓存
+func (dm *DataManager) bars(symbol string, start, end time.Time) ([]DataPoint, error) {
+	slot := dm.acquire(symbol)
+	defer slot.release()
+	symbol = dm.providerSymbol(slot, symbol)
+	var data []DataPoint
+	var err error
+	// 导入的数据和回放数据本身就在本地，不经过缓存
+	_, imported := slot.provider.(*ImportedProvider)
+	_, replay := slot.provider.(*ReplayProvider)
+	if dm.cache != nil && !imported && !replay {
+		data, err = dm.cache.Bars(slot.provider, symbol, start, end)
+	} else {
+		data, err = slot.provider.Bars(symbol, start, end)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("数据源 %s 获取行情失败: %w", slot.provider.Name(), err)
+	}
+	return data, nil
+}
+
+// GetLatestPrice 获取最新价格，连续合约返回当前主力合约的价格，期权返回期权链中的权利金中间价
 func (dm *DataManager) GetLatestPrice(symbol string) (float64, error) {
 	log.Printf("获取最新价格: 符号=%s", symbol)
+	symbol = dm.instrumentRegistry().Resolve(dm.normalizeSymbol(symbol), time.Now())
+	if option, ok := instrument.ParseOption(symbol); ok {
+		return dm.optionPrice(option)
+	}
 
-	// 模拟获取最新价格
-	mockPrice := 150.25 + float64(time.Now().Unix()%100)/100.0
+	slot := dm.acquire(symbol)
+	defer slot.release()
+	price, err := slot.provider.LatestPrice(dm.providerSymbol(slot, symbol))
+	if err != nil {
+		return 0, fmt.Errorf("数据源 %s 获取最新价格失败: %w", slot.provider.Name(), err)
+	}
 
-	log.Printf("最新价格: %.2f", mockPrice)
-	return mockPrice, nil
+	log.Printf("最新价格: %.2f", price)
+	return price, nil
 }
 
-// GetHistoricalData 获取历史数据（支持不同时间周期）
+// GetHistoricalData 获取最近 limit 个周期的历史数据，数据源的K线按 interval（如 1m、5m、1h、1d）重采样并按交易所时段对齐，
+// 最后一个周期可能尚未走完；连续合约返回当前主力合约的数据
 func (dm *DataManager) GetHistoricalData(symbol string, interval string, limit int) (*MarketData, error) {
 	log.Printf("获取历史数据: 符号=%s, 周期=%s, 限制=%d", symbol, interval, limit)
+	symbol = dm.instrumentRegistry().Resolve(dm.normalizeSymbol(symbol), time.Now())
+
+	duration, err := ParseInterval(interval)
+	if err != nil {
+		return nil, fmt.Errorf("不支持的时间周期: %w", err)
+	}
 
-	// 计算时间范围
+	// 计算时间范围，从周期起点开始以免第一个周期不完整
+	session := dm.Session(symbol)
 	endTime := time.Now()
-	var startTime time.Time
+	startTime := session.Align(endTime, duration).Add(-time.Duration(limit-1) * duration)
 
-	switch interval {
-	case "1m":
-		startTime = endTime.Add(-time.Duration(limit) * time.Minute)
-	case "5m":
-		startTime = endTime.Add(-time.Duration(limit) * 5 * time.Minute)
-	case "1h":
-		startTime = endTime.Add(-time.Duration(limit) * time.Hour)
-	case "1d":
-		startTime = endTime.Add(-time.Duration(limit) * 24 * time.Hour)
-	default:
-		return nil, fmt.Errorf("不支持的时间周期: %s", interval)
+	slot := dm.acquire(symbol)
+	defer slot.release()
+	data, err := slot.provider.Bars(dm.providerSymbol(slot, symbol), startTime, endTime)
+	if err != nil {
+		return nil, fmt.Errorf("数据源 %s 获取历史数据失败: %w", slot.provider.Name(), err)
+	}
+	if data, err = Resample(session.Filter(data), duration, session); err != nil {
+		return nil, fmt.Errorf("重采样为 %s 失败: %w", interval, err)
+	}
+	if limit > 0 && len(data) > limit {
+		data = data[len(data)-limit:]
 	}
-
-	// 生成模拟数据
-	data := dm.generateMockData(symbol, startTime, endTime)
 
 	return &MarketData{
 		Symbol:    symbol,
@@ -107,8 +225,25 @@ func (dm *DataManager) GetHistoricalData(symbol string, interval string, limit i
 	}, nil
 }
 
+// GetAverageVolume 获取最近 days 天的日均成交量
+func (dm *DataManager) GetAverageVolume(symbol string, days int) (float64, error) {
+	history, err := dm.GetHistoricalData(symbol, "1d", days)
+	if err != nil {
+		return 0, err
+	}
+	if len(history.Data) == 0 {
+		return 0, fmt.Errorf("没有 %s 的历史成交量数据", symbol)
+	}
+
+	var total int64
+	for _, point := range history.Data {
+		total += point.Volume
+	}
+	return float64(total) / float64(len(history.Data)), nil
+}
+
 // generateMockData 生成模拟市场数据
-func (dm *DataManager) generateMockData(symbol string, start, end time.Time) []DataPoint {
+func generateMockData(symbol string, start, end time.Time) []DataPoint {
 	var data []DataPoint
 	current := start
 	basePrice := 100.0
@@ -120,6 +255,8 @@ func (dm *DataManager) generateMockData(symbol string, start, end time.Time) []D
 		high := open + float64(current.Unix()%10)/100.0
 		low := open - float64(current.Unix()%10)/100.0
 		close := open + (float64(current.Unix()%20)-10)/100.0
+		high = math.Max(high, math.Max(open, close))
+		low = math.Min(low, math.Min(open, close))
 		volume := int64(1000000 + current.Unix()%500000)
 
 		data = append(data, DataPoint{
@@ -139,7 +276,7 @@ func (dm *DataManager) generateMockData(symbol string, start, end time.Time) []D
 }
 
 // convertToDataFrame 将市场数据转换为DataFrame格式
-func (dm *DataManager) convertToDataFrame(data []DataPoint) DataFrame {
+func (dm *DataManager) convertToDataFrame(symbol string, data []DataPoint) DataFrame {
 	if len(data) == 0 {
 		return DataFrame{}
 	}
@@ -151,9 +288,12 @@ func (dm *DataManager) convertToDataFrame(data []DataPoint) DataFrame {
 		"low":       make([]interface{}, len(data)),
 		"close":     make([]interface{}, len(data)),
 		"volume":    make([]interface{}, len(data)),
+
+		SymbolColumn: make([]interface{}, len(data)),
 	}
 
 	for i, point := range data {
+		df[SymbolColumn][i] = symbol
 		df["timestamp"][i] = point.Timestamp
 		df["open"][i] = point.Open
 		df["high"][i] = point.High
@@ -165,7 +305,8 @@ func (dm *DataManager) convertToDataFrame(data []DataPoint) DataFrame {
 	return df
 }
 
-// ValidateData 验证数据完整性
+// ValidateData 验证数据完整性：必需的列齐全且长度一致、时间有效；data.quality.policy 为 fail 时
+// 还要求通过数据质量检查（不检查过期）
 func (dm *DataManager) ValidateData(df DataFrame) error {
 	if len(df) == 0 {
 		return fmt.Errorf("数据为空")
@@ -186,9 +327,45 @@ func (dm *DataManager) ValidateData(df DataFrame) error {
 		}
 	}
 
+	points, err := frameToPoints(df)
+	if err != nil {
+		return err
+	}
+	if dm.quality.Policy == QualityFail {
+		if report := CheckQuality(df.Symbol(), points, time.Time{}, dm.quality); !report.Passed() {
+			first := report.Issues[0]
+			return fmt.Errorf("数据质量检查未通过: %s（%s %s）", report.Summary(), first.Time.Format("2006-01-02 15:04"), first.Message)
+		}
+	}
 	return nil
 }
 
+// frameToPoints 把行情数据的 OHLCV 列转换为K线
+func frameToPoints(df DataFrame) ([]DataPoint, error) {
+	points := make([]DataPoint, len(df["close"]))
+	for i := range points {
+		t, ok := df["timestamp"][i].(time.Time)
+		if !ok {
+			return nil, fmt.Errorf("第 %d 行的时间无效: %v", i+1, df["timestamp"][i])
+		}
+		point := DataPoint{Timestamp: t}
+		for column, target := range map[string]*float64{"open": &point.Open, "high": &point.High, "low": &point.Low, "close": &point.Close} {
+			value, ok := toFloat64(df[column][i])
+			if !ok {
+				return nil, fmt.Errorf("第 %d 行的 %s 无效: %v", i+1, column, df[column][i])
+			}
+			*target = value
+		}
+		volume, ok := toFloat64(df["volume"][i])
+		if !ok {
+			return nil, fmt.Errorf("第 %d 行的 volume 无效: %v", i+1, df["volume"][i])
+		}
+		point.Volume = int64(volume)
+		points[i] = point
+	}
+	return points, nil
+}
+
 // GetDataStats 获取数据统计信息
 func (dm *DataManager) GetDataStats(df DataFrame) map[string]interface{} {
 	closeData := df["close"]
@@ -0,0 +1,189 @@
+package data
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"agent-quant-system/internal/instrument"
+	"agent-quant-system/internal/money"
+)
+
+// OptionQuote 期权链中一个合约的报价，价格均为每单位标的的权利金
+type OptionQuote struct {
+	Symbol       string           `json:"symbol"` // OCC 期权代码
+	Strike       float64          `json:"strike"`
+	Right        instrument.Right `json:"right"`
+	Bid          float64          `json:"bid"`
+	Ask          float64          `json:"ask"`
+	Last         float64          `json:"last"`
+	Volume       int64            `json:"volume"`
+	OpenInterest int64            `json:"open_interest"`
+	ImpliedVol   float64          `json:"implied_vol"`
+	Delta        float64          `json:"delta"`
+}
+
+// Mid 买卖价中间价，没有买卖报价时为最新价
+func (q OptionQuote) Mid() float64 {
+	if q.Bid > 0 && q.Ask > 0 {
+		return (q.Bid + q.Ask) / 2
+	}
+	return q.Last
+}
+
+// OptionChain 标的在一个到期日的期权链，看涨和看跌期权各按行权价升序
+type OptionChain struct {
+	Underlying      string        `json:"underlying"`
+	Expiry          time.Time     `json:"expiry"`
+	UnderlyingPrice float64       `json:"underlying_price"`
+	Calls           []OptionQuote `json:"calls"`
+	Puts            []OptionQuote `json:"puts"`
+	Timestamp       time.Time     `json:"timestamp"`
+}
+
+// Quote 按期权代码查找报价
+func (c *OptionChain) Quote(symbol string) (OptionQuote, bool) {
+	for _, quotes := range [][]OptionQuote{c.Calls, c.Puts} {
+		for _, quote := range quotes {
+			if quote.Symbol == symbol {
+				return quote, true
+			}
+		}
+	}
+	return OptionQuote{}, false
+}
+
+// OptionChainProvider 提供期权链的数据源（可选接口）
+type OptionChainProvider interface {
+	// OptionExpiries 标的可交易的期权到期日，升序
+	OptionExpiries(underlying string) ([]time.Time, error)
+
+	// OptionChain 标的在到期日的期权链
+	OptionChain(underlying string, expiry time.Time) (*OptionChain, error)
+}
+
+// 模拟期权链的参数：月度到期日个数、平值上下的行权价档数、行权价间隔占标的价格的比例、波动率和无风险利率
+const (
+	mockOptionExpiries   = 4
+	mockOptionStrikes    = 10
+	mockOptionStrikeStep = 0.025
+	mockOptionVolatility = 0.3
+	mockOptionRate       = 0.04
+)
+
+// OptionExpiries 模拟数据源的月度到期日（每月第三个星期五）
+func (MockProvider) OptionExpiries(underlying string) ([]time.Time, error) {
+	return instrument.MonthlyExpiries(time.Now().UTC(), mockOptionExpiries), nil
+}
+
+// OptionChain 围绕标的最新价格生成期权链，权利金按 Black-Scholes 模型以固定波动率计算，买卖价差为理论价的2%
+func (p MockProvider) OptionChain(underlying string, expiry time.Time) (*OptionChain, error) {
+	spot, err := p.LatestPrice(underlying)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	years := expiry.AddDate(0, 0, 1).Sub(now).Hours() / (24 * 365)
+	step := mockStrikeStep(spot)
+	atm := math.Round(spot/step) * step
+
+	chain := &OptionChain{Underlying: underlying, Expiry: expiry, UnderlyingPrice: spot, Timestamp: now}
+	for i := -mockOptionStrikes; i <= mockOptionStrikes; i++ {
+		strike := atm + float64(i)*step
+		if strike <= 0 {
+			continue
+		}
+		for _, right := range []instrument.Right{instrument.Call, instrument.Put} {
+			price, delta := instrument.BlackScholes(right, spot, strike, years, mockOptionRate, mockOptionVolatility)
+			price = math.Max(math.Round(price*100)/100, 0.01)
+			quote := OptionQuote{
+				Symbol: instrument.OptionContract{Underlying: underlying, Expiry: expiry,
+					Strike: money.FromFloat(strike), Right: right}.Symbol(),
+				Strike:       strike,
+				Right:        right,
+				Bid:          math.Round(price*0.99*100) / 100,
+				Ask:          math.Round(price*1.01*100) / 100,
+				Last:         price,
+				Volume:       int64(1000 / (1 + math.Abs(float64(i)))),
+				OpenInterest: int64(5000 / (1 + math.Abs(float64(i)))),
+				ImpliedVol:   mockOptionVolatility,
+				Delta:        delta,
+			}
+			if right == instrument.Call {
+				chain.Calls = append(chain.Calls, quote)
+			} else {
+				chain.Puts = append(chain.Puts, quote)
+			}
+		}
+	}
+	return chain, nil
+}
+
+// mockStrikeStep 模拟期权链的行权价间隔，按标的价格比例取整到 0.5、1、2.5、5 等常见间隔
+func mockStrikeStep(spot float64) float64 {
+	raw := spot * mockOptionStrikeStep
+	magnitude := math.Pow(10, math.Floor(math.Log10(raw)))
+	for _, factor := range []float64{1, 2.5, 5, 10} {
+		if raw <= factor*magnitude {
+			return factor * magnitude
+		}
+	}
+	return 10 * magnitude
+}
+
+// optionChainProvider 标的所属资产类别的数据源，不支持期权链时返回错误
+func (dm *DataManager) optionChainProvider(underlying string) (OptionChainProvider, *providerSlot, error) {
+	slot := dm.acquire(underlying)
+	provider, ok := slot.provider.(OptionChainProvider)
+	if !ok {
+		slot.release()
+		return nil, nil, fmt.Errorf("数据源 %s 不支持期权链", slot.provider.Name())
+	}
+	return provider, slot, nil
+}
+
+// GetOptionExpiries 获取标的可交易的期权到期日
+func (dm *DataManager) GetOptionExpiries(underlying string) ([]time.Time, error) {
+	provider, slot, err := dm.optionChainProvider(underlying)
+	if err != nil {
+		return nil, err
+	}
+	defer slot.release()
+
+	expiries, err := provider.OptionExpiries(dm.providerSymbol(slot, underlying))
+	if err != nil {
+		return nil, fmt.Errorf("数据源 %s 获取期权到期日失败: %w", slot.provider.Name(), err)
+	}
+	return expiries, nil
+}
+
+// GetOptionChain 获取标的在到期日的期权链
+func (dm *DataManager) GetOptionChain(underlying string, expiry time.Time) (*OptionChain, error) {
+	log.Printf("获取期权链: 标的=%s, 到期日=%s", underlying, expiry.Format("2006-01-02"))
+	provider, slot, err := dm.optionChainProvider(underlying)
+	if err != nil {
+		return nil, err
+	}
+	defer slot.release()
+
+	chain, err := provider.OptionChain(dm.providerSymbol(slot, underlying), expiry)
+	if err != nil {
+		return nil, fmt.Errorf("数据源 %s 获取期权链失败: %w", slot.provider.Name(), err)
+	}
+	return chain, nil
+}
+
+// optionPrice 期权合约的最新权利金：取期权链中该合约的中间价
+func (dm *DataManager) optionPrice(option instrument.OptionContract) (float64, error) {
+	chain, err := dm.GetOptionChain(option.Underlying, option.Expiry)
+	if err != nil {
+		return 0, err
+	}
+	symbol := option.Symbol()
+	quote, ok := chain.Quote(symbol)
+	if !ok {
+		return 0, fmt.Errorf("期权链中没有合约 %s", symbol)
+	}
+	return quote.Mid(), nil
+}
@@ -0,0 +1,179 @@
+package data
+
+import (
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/deprecated"
+	"github.com/shopspring/decimal"
+)
+
+// parquetBatchSize 每次从 Parquet 文件读取的行数
+const parquetBatchSize = 1024
+
+// julianUnixEpoch Unix 纪元对应的儒略日，用于转换 INT96 时间
+const julianUnixEpoch = 2440588
+
+// readOHLCVParquet 读取 Parquet 文件：顶层的列按与 CSV 相同的列名映射解析。
+// 时间列为 TIMESTAMP / DATE / INT96 类型时直接转换，带 UTC 标记的按UTC，不带的按 opts.Location，忽略 opts.TimeFormat；
+// 数值或字符串类型的时间列与 CSV 相同处理
+func readOHLCVParquet(file *os.File, symbol string, opts ImportOptions) (map[string][]DataPoint, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("读取文件信息失败: %w", err)
+	}
+	pf, err := parquet.OpenFile(file, info.Size())
+	if err != nil {
+		return nil, fmt.Errorf("打开 Parquet 文件失败: %w", err)
+	}
+
+	schema := pf.Schema()
+	var header []string
+	var columns []int // 顶层列对应的叶子列序号
+	for _, field := range schema.Fields() {
+		if !field.Leaf() {
+			continue
+		}
+		leaf, ok := schema.Lookup(field.Name())
+		if !ok {
+			continue
+		}
+		header = append(header, field.Name())
+		columns = append(columns, leaf.ColumnIndex)
+	}
+	if len(header) == 0 {
+		return nil, fmt.Errorf("Parquet 文件没有可读取的列")
+	}
+
+	location := opts.Location
+	if location == nil {
+		location = time.UTC
+	}
+	formatters := make([]func(parquet.Value) string, len(columns))
+	for i, name := range header {
+		leaf, _ := schema.Lookup(name)
+		formatters[i] = parquetFormatter(leaf.Node.Type(), location)
+		if strings.EqualFold(name, opts.Columns.Timestamp) && parquetTemporal(leaf.Node.Type()) {
+			opts.TimeFormat = ""
+		}
+	}
+
+	reader := parquet.NewReader(pf)
+	defer reader.Close()
+	rows := make([]parquet.Row, parquetBatchSize)
+	var pending []parquet.Row
+	next := func() ([]string, error) {
+		for len(pending) == 0 {
+			n, err := reader.ReadRows(rows)
+			pending = rows[:n]
+			if n == 0 {
+				if err == nil {
+					err = io.EOF
+				}
+				return nil, err
+			}
+		}
+		row := pending[0]
+		pending = pending[1:]
+
+		values := make(map[int]parquet.Value, len(row))
+		for _, value := range row {
+			values[value.Column()] = value
+		}
+		record := make([]string, len(columns))
+		for i, column := range columns {
+			if value, exists := values[column]; exists && !value.IsNull() {
+				record[i] = formatters[i](value)
+			}
+		}
+		return record, nil
+	}
+	return parseOHLCVRecords(header, next, 0, symbol, opts)
+}
+
+// parquetTemporal 列是否为时间类型
+func parquetTemporal(t parquet.Type) bool {
+	if logical := t.LogicalType(); logical != nil && (logical.Timestamp != nil || logical.Date != nil) {
+		return true
+	}
+	return t.Kind() == parquet.Int96
+}
+
+// parquetFormatter 按列类型把值转换为文本：时间类型转换为 RFC3339（带UTC标记）或按 location 的本地时间，
+// DECIMAL 按小数位换算，其余按数值或字符串
+func parquetFormatter(t parquet.Type, location *time.Location) func(parquet.Value) string {
+	toText := func(ts time.Time) string { return ts.Format(time.RFC3339Nano) }
+
+	logical := t.LogicalType()
+	switch {
+	case logical != nil && logical.Timestamp != nil:
+		unit := logical.Timestamp.Unit
+		adjusted := logical.Timestamp.IsAdjustedToUTC
+		return func(v parquet.Value) string {
+			var ts time.Time
+			switch {
+			case unit.Millis != nil:
+				ts = time.UnixMilli(v.Int64())
+			case unit.Micros != nil:
+				ts = time.UnixMicro(v.Int64())
+			default:
+				ts = time.Unix(0, v.Int64())
+			}
+			if !adjusted {
+				// 不带时区的时间按墙上时间保存，换算到 location
+				ts = ts.UTC()
+				ts = time.Date(ts.Year(), ts.Month(), ts.Day(), ts.Hour(), ts.Minute(), ts.Second(), ts.Nanosecond(), location)
+			}
+			return toText(ts)
+		}
+	case logical != nil && logical.Date != nil:
+		return func(v parquet.Value) string {
+			day := time.Unix(int64(v.Int32())*86400, 0).UTC()
+			return toText(time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, location))
+		}
+	case logical != nil && logical.Decimal != nil:
+		scale := logical.Decimal.Scale
+		return func(v parquet.Value) string {
+			var unscaled big.Int
+			switch v.Kind() {
+			case parquet.Int32, parquet.Int64:
+				unscaled.SetInt64(v.Int64())
+			default:
+				// 定长或变长字节数组为大端补码
+				raw := v.ByteArray()
+				unscaled.SetBytes(raw)
+				if len(raw) > 0 && raw[0]&0x80 != 0 {
+					unscaled.Sub(&unscaled, new(big.Int).Lsh(big.NewInt(1), uint(len(raw))*8))
+				}
+			}
+			return decimal.NewFromBigInt(&unscaled, -scale).String()
+		}
+	case t.Kind() == parquet.Int96:
+		// 旧版 Spark / Hive 的时间：前8字节为当天的纳秒，后4字节为儒略日，按UTC
+		return func(v parquet.Value) string {
+			return toText(int96Time(v.Int96()))
+		}
+	}
+
+	switch t.Kind() {
+	case parquet.Float:
+		return func(v parquet.Value) string { return strconv.FormatFloat(float64(v.Float()), 'g', -1, 32) }
+	case parquet.Double:
+		return func(v parquet.Value) string { return strconv.FormatFloat(v.Double(), 'g', -1, 64) }
+	default:
+		return func(v parquet.Value) string { return v.String() }
+	}
+}
+
+// int96Time 转换 INT96 时间
+func int96Time(value deprecated.Int96) time.Time {
+	nanos := int64(uint64(value[1])<<32 | uint64(value[0]))
+	days := int64(value[2]) - julianUnixEpoch
+	return time.Unix(days*86400, nanos).UTC()
+}
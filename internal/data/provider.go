@@ -0,0 +1,249 @@
+package data
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"agent-quant-system/internal/config"
+)
+
+// AssetClass 资产类别，每个类别使用一个行情数据源
+type AssetClass string
+
+const (
+	AssetStock  AssetClass = "stock"
+	AssetCrypto AssetClass = "crypto"
+)
+
+// AssetClasses 支持的资产类别
+var AssetClasses = []AssetClass{AssetStock, AssetCrypto}
+
+// cryptoQuotes 加密货币交易对常见的计价币种后缀
+var cryptoQuotes = []string{"USDT", "USDC", "BUSD", "-USD", "/USD"}
+
+// Provider 行情数据源
+type Provider interface {
+	Name() string
+	Bars(symbol string, start, end time.Time) ([]DataPoint, error)
+	LatestPrice(symbol string) (float64, error)
+}
+
+// MockProvider 模拟数据源，按时间生成确定的行情
+type MockProvider struct{}
+
+// Name 数据源名称
+func (MockProvider) Name() string { return "mock" }
+
+// Bars 生成 [start, end) 区间的小时K线
+func (MockProvider) Bars(symbol string, start, end time.Time) ([]DataPoint, error) {
+	return generateMockData(symbol, start, end), nil
+}
+
+// LatestPrice 模拟最新价格
+func (MockProvider) LatestPrice(symbol string) (float64, error) {
+	return 150.25 + float64(time.Now().Unix()%100)/100.0, nil
+}
+
+// providerSlot 资产类别当前使用的数据源及其进行中的请求
+type providerSlot struct {
+	provider Provider
+	since    time.Time
+	inflight sync.WaitGroup
+	active   int
+	mutex    sync.Mutex
+}
+
+// release 请求完成
+func (s *providerSlot) release() {
+	s.mutex.Lock()
+	s.active--
+	s.mutex.Unlock()
+	s.inflight.Done()
+}
+
+// ProviderStatus 资产类别当前的数据源
+type ProviderStatus struct {
+	AssetClass AssetClass `json:"asset_class"`
+	Provider   string     `json:"provider"`
+	Since      time.Time  `json:"since"`     // 开始使用该数据源的时间
+	InFlight   int        `json:"in_flight"` // 进行中的请求数
+}
+
+// ProviderSwap 一次数据源切换的结果
+type ProviderSwap struct {
+	AssetClass AssetClass    `json:"asset_class"`
+	From       string        `json:"from"`
+	To         string        `json:"to"`
+	Drained    int           `json:"drained"`   // 切换时仍在进行中的旧数据源请求数
+	Waited     time.Duration `json:"waited"`    // 等待旧请求完成的时间
+	TimedOut   bool          `json:"timed_out"` // 等待超时，旧请求仍在后台完成
+}
+
+// RegisterProvider 注册数据源，已存在同名数据源时替换
+func (dm *DataManager) RegisterProvider(provider Provider) {
+	dm.mutex.Lock()
+	defer dm.mutex.Unlock()
+	dm.providers[provider.Name()] = provider
+}
+
+// Providers 已注册的数据源名称
+func (dm *DataManager) Providers() []string {
+	dm.mutex.RLock()
+	defer dm.mutex.RUnlock()
+
+	names := make([]string, 0, len(dm.providers))
+	for name := range dm.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ClassifySymbol 标的所属的资产类别：优先使用配置，其次按交易对后缀识别加密货币，其余视为股票
+func (dm *DataManager) ClassifySymbol(symbol string) AssetClass {
+	symbol = strings.ToUpper(symbol)
+	if class, ok := dm.symbolClasses[symbol]; ok {
+		return class
+	}
+	for _, quote := range cryptoQuotes {
+		if strings.HasSuffix(symbol, quote) && len(symbol) > len(quote) {
+			return AssetCrypto
+		}
+	}
+	return AssetStock
+}
+
+// acquire 获取标的对应的数据源并登记一个进行中的请求，请求完成后需调用 release
+func (dm *DataManager) acquire(symbol string) *providerSlot {
+	class := dm.ClassifySymbol(symbol)
+
+	dm.mutex.RLock()
+	defer dm.mutex.RUnlock()
+
+	slot := dm.slots[class]
+	slot.inflight.Add(1)
+	slot.mutex.Lock()
+	slot.active++
+	slot.mutex.Unlock()
+	return slot
+}
+
+// SwitchProvider 运行时切换资产类别的数据源：新请求立即使用新数据源，
+// 等待旧数据源进行中的请求完成（最多 drain_timeout）后返回
+func (dm *DataManager) SwitchProvider(class AssetClass, name string) (*ProviderSwap, error) {
+	dm.mutex.Lock()
+	provider, ok := dm.providers[name]
+	if !ok {
+		dm.mutex.Unlock()
+		return nil, fmt.Errorf("未注册的数据源: %s", name)
+	}
+	old, ok := dm.slots[class]
+	if !ok {
+		dm.mutex.Unlock()
+		return nil, fmt.Errorf("未知的资产类别: %s", class)
+	}
+	swap := &ProviderSwap{AssetClass: class, From: old.provider.Name(), To: name}
+	if old.provider.Name() == name {
+		dm.mutex.Unlock()
+		return swap, nil
+	}
+	dm.slots[class] = &providerSlot{provider: provider, since: time.Now()}
+	dm.mutex.Unlock()
+
+	old.mutex.Lock()
+	swap.Drained = old.active
+	old.mutex.Unlock()
+	log.Printf("切换数据源: 资产类别=%s, %s -> %s, 等待 %d 个进行中的请求完成", class, swap.From, swap.To, swap.Drained)
+
+	start := time.Now()
+	drained := make(chan struct{})
+	go func() {
+		old.inflight.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+	case <-time.After(dm.drainTimeout):
+		swap.TimedOut = true
+	}
+	swap.Waited = time.Since(start)
+
+	if swap.TimedOut {
+		log.Printf("数据源切换完成，等待旧请求超时 (%v)，剩余请求将在后台完成: 资产类别=%s, 数据源=%s", dm.drainTimeout, class, name)
+	} else {
+		log.Printf("数据源切换完成: 资产类别=%s, 数据源=%s, 等待 %v", class, name, swap.Waited.Round(time.Millisecond))
+	}
+	return swap, nil
+}
+
+// ProviderStatus 各资产类别当前的数据源
+func (dm *DataManager) ProviderStatus() []ProviderStatus {
+	dm.mutex.RLock()
+	defer dm.mutex.RUnlock()
+
+	statuses := make([]ProviderStatus, 0, len(AssetClasses))
+	for _, class := range AssetClasses {
+		slot := dm.slots[class]
+		slot.mutex.Lock()
+		statuses = append(statuses, ProviderStatus{
+			AssetClass: class,
+			Provider:   slot.provider.Name(),
+			Since:      slot.since,
+			InFlight:   slot.active,
+		})
+		slot.mutex.Unlock()
+	}
+	return statuses
+}
+
+// NewDataManagerFromConfig 按配置创建数据管理器，providers 为除模拟数据源外额外可用的数据源
+func NewDataManagerFromConfig(cfg config.DataConfig, providers ...Provider) (*DataManager, error) {
+	dm := NewDataManager()
+	if cfg.Import.Dir != "" {
+		dm.RegisterProvider(NewImportedProvider(cfg.Import.Dir))
+	}
+	for _, provider := range providers {
+		dm.RegisterProvider(provider)
+	}
+	if cfg.DrainTimeout > 0 {
+		dm.drainTimeout = cfg.DrainTimeout
+	}
+	dm.quality = cfg.Quality
+	sessions, symbolSessions, err := newSessions(cfg)
+	if err != nil {
+		return nil, err
+	}
+	dm.sessions, dm.symbolSessions = sessions, symbolSessions
+	for symbol, class := range cfg.SymbolClasses {
+		dm.symbolClasses[strings.ToUpper(symbol)] = AssetClass(class)
+	}
+
+	for class, name := range cfg.Providers {
+		provider, ok := dm.providers[name]
+		if !ok {
+			return nil, fmt.Errorf("资产类别 '%s' 使用了未注册的数据源: %s", class, name)
+		}
+		slot, ok := dm.slots[AssetClass(class)]
+		if !ok {
+			return nil, fmt.Errorf("未知的资产类别: %s", class)
+		}
+		slot.provider = provider
+	}
+
+	if cfg.Ticks.Dir != "" {
+		dm.ticks = NewTickStore(cfg.Ticks.Dir)
+	}
+
+	if cfg.Cache.Enabled {
+		cache, err := NewOHLCVCacheFromConfig(cfg.Cache)
+		if err != nil {
+			return nil, err
+		}
+		dm.cache = cache
+	}
+	return dm, nil
+}
@@ -0,0 +1,364 @@
+package data
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"agent-quant-system/internal/config"
+)
+
+// IssueKind 数据质量问题类型
+type IssueKind string
+
+const (
+	IssueGap          IssueKind = "gap"           // 缺少K线
+	IssueDuplicate    IssueKind = "duplicate"     // 时间重复
+	IssueOutOfOrder   IssueKind = "out_of_order"  // 时间乱序
+	IssueInvalidPrice IssueKind = "invalid_price" // 零或负价格、最高最低价与开收盘价矛盾、负成交量
+	IssueSpike        IssueKind = "spike"         // 异常跳变（坏点）
+	IssueStale        IssueKind = "stale"         // 数据过期
+)
+
+// issueNames 问题类型的中文名称，按报告中的显示顺序排列
+var issueNames = []struct {
+	kind IssueKind
+	name string
+}{
+	{IssueGap, "缺口"},
+	{IssueDuplicate, "重复时间"},
+	{IssueOutOfOrder, "时间乱序"},
+	{IssueInvalidPrice, "无效价格"},
+	{IssueSpike, "异常跳变"},
+	{IssueStale, "数据过期"},
+}
+
+// 数据质量检查策略
+const (
+	QualityFail   = "fail"
+	QualityWarn   = "warn"
+	QualityRepair = "repair"
+	QualityOff    = "off"
+)
+
+// QualityIssue 一个数据质量问题，Time 为有问题的K线（缺口为缺口开始前的最后一根）的时间
+type QualityIssue struct {
+	Kind    IssueKind `json:"kind"`
+	Time    time.Time `json:"time"`
+	Message string    `json:"message"`
+}
+
+// QualityReport 单个标的的行情数据质量报告
+type QualityReport struct {
+	Symbol   string         `json:"symbol"`
+	Bars     int            `json:"bars"`
+	First    time.Time      `json:"first,omitempty"`
+	Last     time.Time      `json:"last,omitempty"`
+	Interval time.Duration  `json:"interval"` // 原始周期，相邻K线间隔的中位数
+	Issues   []QualityIssue `json:"issues,omitempty"`
+
+	// 修复结果，只在 repair 策略下填写
+	Replaced int `json:"replaced,omitempty"` // 按前后K线插值替换的无效或跳变K线数
+	Filled   int `json:"filled,omitempty"`   // 在缺口中插值补齐的K线数
+	Removed  int `json:"removed,omitempty"`  // 删除的重复K线数
+}
+
+// Passed 是否没有发现质量问题
+func (r QualityReport) Passed() bool {
+	return len(r.Issues) == 0
+}
+
+// Counts 各类问题的数量
+func (r QualityReport) Counts() map[IssueKind]int {
+	counts := make(map[IssueKind]int)
+	for _, issue := range r.Issues {
+		counts[issue.Kind]++
+	}
+	return counts
+}
+
+// Summary 问题数量的简要说明，如 "缺口 2 处, 异常跳变 1 处"
+func (r QualityReport) Summary() string {
+	if r.Passed() {
+		return "无质量问题"
+	}
+	counts := r.Counts()
+	var parts []string
+	for _, entry := range issueNames {
+		if count := counts[entry.kind]; count > 0 {
+			parts = append(parts, fmt.Sprintf("%s %d 处", entry.name, count))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// CheckQuality 检查按时间升序排列的K线：缺口、重复或乱序的时间、无效价格、异常跳变，
+// 以及最后一根K线相对 end（晚于当前时间时取当前时间）是否过期；end 为零值时不检查过期
+func CheckQuality(symbol string, points []DataPoint, end time.Time, cfg config.DataQualityConfig) QualityReport {
+	report := QualityReport{Symbol: symbol, Bars: len(points)}
+	if len(points) == 0 {
+		return report
+	}
+	report.Interval = medianInterval(points)
+
+	last := points[0].Timestamp
+	report.First = last
+	for i, point := range points {
+		if point.Timestamp.After(last) {
+			last = point.Timestamp
+		}
+		if point.Timestamp.Before(report.First) {
+			report.First = point.Timestamp
+		}
+		if i > 0 {
+			prev := points[i-1].Timestamp
+			gap := point.Timestamp.Sub(prev)
+			switch {
+			case gap == 0:
+				report.addIssue(IssueDuplicate, point.Timestamp, "时间重复")
+			case gap < 0:
+				report.addIssue(IssueOutOfOrder, point.Timestamp, fmt.Sprintf("早于前一根K线 %s", prev.Format("2006-01-02 15:04")))
+			case isGap(gap, report.Interval, cfg.GapFactor):
+				report.addIssue(IssueGap, prev, fmt.Sprintf("距下一根K线 %s 间隔 %v，超过周期 %v 的 %.4g 倍",
+					point.Timestamp.Format("2006-01-02 15:04"), gap, report.Interval, cfg.GapFactor))
+			}
+		}
+		if reason := invalidPrice(point); reason != "" {
+			report.addIssue(IssueInvalidPrice, point.Timestamp, reason)
+		}
+	}
+	report.Last = last
+
+	for _, i := range spikes(points, cfg.SpikeThreshold) {
+		point := points[i]
+		report.addIssue(IssueSpike, point.Timestamp, fmt.Sprintf("收盘价 %.4f 相对前后K线 %.4f / %.4f 偏离超过 %.0f%%",
+			point.Close, points[i-1].Close, points[i+1].Close, cfg.SpikeThreshold*100))
+	}
+
+	if cfg.StaleAfter > 0 && !end.IsZero() {
+		if now := time.Now(); end.After(now) {
+			end = now
+		}
+		if age := end.Sub(last); age > cfg.StaleAfter {
+			report.addIssue(IssueStale, last, fmt.Sprintf("最后一根K线距 %s 已有 %v，超过 %v",
+				end.Format("2006-01-02 15:04"), age.Round(time.Minute), cfg.StaleAfter))
+		}
+	}
+
+	sort.SliceStable(report.Issues, func(i, j int) bool { return report.Issues[i].Time.Before(report.Issues[j].Time) })
+	return report
+}
+
+// addIssue 记录一个质量问题
+func (r *QualityReport) addIssue(kind IssueKind, t time.Time, message string) {
+	r.Issues = append(r.Issues, QualityIssue{Kind: kind, Time: t, Message: message})
+}
+
+// RepairBars 修复K线并返回修复后的副本：按时间排序并去掉重复的K线（保留最后一条），
+// 无效价格和异常跳变的K线按前后有效K线的价格线性插值替换，缺口按原始周期插值补齐（成交量为0）；
+// 过期数据无法修复。report 为修复前的检查结果，并填写修复数量
+func RepairBars(symbol string, points []DataPoint, end time.Time, cfg config.DataQualityConfig) ([]DataPoint, QualityReport) {
+	report := CheckQuality(symbol, points, end, cfg)
+	if report.Passed() {
+		return points, report
+	}
+
+	bars := append([]DataPoint(nil), points...)
+	sort.SliceStable(bars, func(i, j int) bool { return bars[i].Timestamp.Before(bars[j].Timestamp) })
+	bars = dedupeBars(bars)
+	report.Removed = len(points) - len(bars)
+
+	// 替换无效和跳变的K线
+	bad := make([]bool, len(bars))
+	for i, bar := range bars {
+		bad[i] = invalidPrice(bar) != ""
+	}
+	for _, i := range spikes(bars, cfg.SpikeThreshold) {
+		bad[i] = true
+	}
+	var valid []DataPoint
+	for i, bar := range bars {
+		if !bad[i] {
+			valid = append(valid, bar)
+		}
+	}
+	if len(valid) == 0 {
+		report.Removed = len(points)
+		return nil, report
+	}
+	next := 0 // 第一根时间不早于当前K线的有效K线
+	for i := range bars {
+		for next < len(valid) && valid[next].Timestamp.Before(bars[i].Timestamp) {
+			next++
+		}
+		if !bad[i] {
+			continue
+		}
+		var before, after *DataPoint
+		if next > 0 {
+			before = &valid[next-1]
+		}
+		if next < len(valid) {
+			after = &valid[next]
+		}
+		bars[i] = interpolateBar(bars[i].Timestamp, before, after, bars[i].Volume)
+		report.Replaced++
+	}
+
+	// 补齐缺口
+	interval := report.Interval
+	if cfg.GapFactor <= 0 || interval <= 0 {
+		return bars, report
+	}
+	repaired := make([]DataPoint, 0, len(bars))
+	for i, bar := range bars {
+		if i > 0 && isGap(bar.Timestamp.Sub(bars[i-1].Timestamp), interval, cfg.GapFactor) {
+			before := bars[i-1]
+			for t := before.Timestamp.Add(interval); t.Before(bar.Timestamp); t = t.Add(interval) {
+				repaired = append(repaired, interpolateBar(t, &before, &bar, 0))
+				report.Filled++
+			}
+		}
+		repaired = append(repaired, bar)
+	}
+	return repaired, report
+}
+
+// interpolateBar 按 before 的收盘价和 after 的开盘价在 t 处线性插值出一根K线，只有一侧时沿用该侧的价格
+func interpolateBar(t time.Time, before, after *DataPoint, volume int64) DataPoint {
+	if volume < 0 {
+		volume = 0
+	}
+	var price float64
+	switch {
+	case before != nil && after != nil:
+		span := after.Timestamp.Sub(before.Timestamp)
+		weight := 0.0
+		if span > 0 {
+			weight = float64(t.Sub(before.Timestamp)) / float64(span)
+		}
+		price = before.Close + (after.Open-before.Close)*weight
+	case before != nil:
+		price = before.Close
+	default:
+		price = after.Open
+	}
+	return DataPoint{Timestamp: t, Open: price, High: price, Low: price, Close: price, Volume: volume}
+}
+
+// medianInterval 相邻K线正间隔的中位数，作为原始周期；不足两根时为0
+func medianInterval(points []DataPoint) time.Duration {
+	var gaps []time.Duration
+	for i := 1; i < len(points); i++ {
+		if gap := points[i].Timestamp.Sub(points[i-1].Timestamp); gap > 0 {
+			gaps = append(gaps, gap)
+		}
+	}
+	if len(gaps) == 0 {
+		return 0
+	}
+	sort.Slice(gaps, func(i, j int) bool { return gaps[i] < gaps[j] })
+	return gaps[len(gaps)/2]
+}
+
+// isGap 间隔是否超过原始周期的 factor 倍，factor 为0时不检查
+func isGap(gap, interval time.Duration, factor float64) bool {
+	return factor > 0 && interval > 0 && float64(gap) > factor*float64(interval)
+}
+
+// invalidPrice K线价格或成交量无效的原因，有效时返回空字符串
+func invalidPrice(point DataPoint) string {
+	for _, price := range []float64{point.Open, point.High, point.Low, point.Close} {
+		if price <= 0 || math.IsNaN(price) || math.IsInf(price, 0) {
+			return fmt.Sprintf("价格为零、负数或非数值: O=%.4f H=%.4f L=%.4f C=%.4f", point.Open, point.High, point.Low, point.Close)
+		}
+	}
+	if point.High < point.Low || point.Open > point.High || point.Open < point.Low || point.Close > point.High || point.Close < point.Low {
+		return fmt.Sprintf("最高价和最低价与开盘价、收盘价矛盾: O=%.4f H=%.4f L=%.4f C=%.4f", point.Open, point.High, point.Low, point.Close)
+	}
+	if point.Volume < 0 {
+		return fmt.Sprintf("成交量为负数: %d", point.Volume)
+	}
+	return ""
+}
+
+// spikes 异常跳变的K线下标：收盘价相对前后两根K线同向偏离都超过 threshold，且前后K线价格有效、时间递增；
+// 第一根和最后一根无法判断，threshold 为0时不检查
+func spikes(points []DataPoint, threshold float64) []int {
+	if threshold <= 0 {
+		return nil
+	}
+	var result []int
+	for i := 1; i+1 < len(points); i++ {
+		prev, point, next := points[i-1], points[i], points[i+1]
+		if !point.Timestamp.After(prev.Timestamp) || !next.Timestamp.After(point.Timestamp) {
+			continue
+		}
+		if invalidPrice(prev) != "" || invalidPrice(next) != "" || point.Close <= 0 {
+			continue
+		}
+		fromPrev := point.Close/prev.Close - 1
+		toNext := point.Close/next.Close - 1
+		if fromPrev*toNext > 0 && math.Abs(fromPrev) > threshold && math.Abs(toNext) > threshold {
+			result = append(result, i)
+		}
+	}
+	return result
+}
+
+// applyQuality 按 data.quality 检查K线：fail 时返回错误，warn 时记录问题，repair 时返回修复后的K线
+func (dm *DataManager) applyQuality(symbol string, points []DataPoint, end time.Time) ([]DataPoint, error) {
+	cfg := dm.quality
+	if cfg.Policy == "" || cfg.Policy == QualityOff {
+		return points, nil
+	}
+	if cfg.Policy == QualityRepair {
+		repaired, report := RepairBars(symbol, points, end, cfg)
+		if !report.Passed() {
+			log.Printf("已修复 %s 的行情数据: %s; 替换 %d 根, 补齐 %d 根, 删除 %d 根",
+				symbol, report.Summary(), report.Replaced, report.Filled, report.Removed)
+		}
+		return repaired, nil
+	}
+
+	report := CheckQuality(symbol, points, end, cfg)
+	if report.Passed() {
+		return points, nil
+	}
+	first := report.Issues[0]
+	if cfg.Policy == QualityFail {
+		return nil, fmt.Errorf("%s 的行情数据质量检查未通过: %s（%s %s）", symbol, report.Summary(), first.Time.Format("2006-01-02 15:04"), first.Message)
+	}
+	log.Printf("%s 的行情数据质量问题: %s（%s %s）", symbol, report.Summary(), first.Time.Format("2006-01-02 15:04"), first.Message)
+	return points, nil
+}
+
+// CheckSymbolQuality 按标的当前的数据源获取 [startDate, endDate] 的K线并生成质量报告，日期按交易所当地日期解析；
+// 不受 data.quality.policy 影响
+func (dm *DataManager) CheckSymbolQuality(symbol, startDate, endDate string) (QualityReport, error) {
+	session := dm.Session(symbol)
+	start, err := session.ParseDate(startDate)
+	if err != nil {
+		return QualityReport{Symbol: symbol}, fmt.Errorf("解析开始日期失败: %w", err)
+	}
+	end, err := session.ParseDate(endDate)
+	if err != nil {
+		return QualityReport{Symbol: symbol}, fmt.Errorf("解析结束日期失败: %w", err)
+	}
+	end = end.AddDate(0, 0, 1)
+	if now := time.Now(); end.After(now) {
+		end = now
+	}
+	points, err := dm.bars(symbol, start, end)
+	if err != nil {
+		return QualityReport{Symbol: symbol}, err
+	}
+	cfg := dm.quality
+	if cfg.Policy == "" {
+		cfg = config.DataQualityConfig{Policy: QualityWarn}
+	}
+	return CheckQuality(symbol, points, end, cfg), nil
+}
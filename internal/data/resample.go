@@ -0,0 +1,239 @@
+package data
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseInterval 解析K线周期：数字加单位 m（分钟）、h（小时）、d（天）、w（周），如 1m、5m、4h、1d、1w
+func ParseInterval(interval string) (time.Duration, error) {
+	interval = strings.ToLower(strings.TrimSpace(interval))
+	if len(interval) < 2 {
+		return 0, fmt.Errorf("无效的K线周期: '%s'", interval)
+	}
+	count, err := strconv.Atoi(interval[:len(interval)-1])
+	if err != nil || count <= 0 {
+		return 0, fmt.Errorf("无效的K线周期: '%s'", interval)
+	}
+	var unit time.Duration
+	switch interval[len(interval)-1] {
+	case 'm':
+		unit = time.Minute
+	case 'h':
+		unit = time.Hour
+	case 'd':
+		unit = 24 * time.Hour
+	case 'w':
+		unit = 7 * 24 * time.Hour
+	default:
+		return 0, fmt.Errorf("无效的K线周期: '%s'，单位只能是 m、h、d 或 w", interval)
+	}
+	return time.Duration(count) * unit, nil
+}
+
+// AlignTime K线周期的起始时间（不考虑交易时段，见 Session.Align）：日内周期从当天零点起按周期对齐（4h 对齐到 0/4/8/... 点），
+// 日线及以上按天对齐到零点，周线从周一开始；零点和周一按 loc 时区计算，loc 为 nil 时使用UTC
+func AlignTime(t time.Time, interval time.Duration, loc *time.Location) time.Time {
+	if loc == nil {
+		loc = time.UTC
+	}
+	local := t.In(loc)
+	midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+	const day = 24 * time.Hour
+	const week = 7 * day
+	switch {
+	case interval < day:
+		return midnight.Add(local.Sub(midnight) / interval * interval)
+	case interval%week == 0:
+		// 以 1970-01-05（周一）为起点按周数对齐
+		weekday := (int(local.Weekday()) + 6) % 7
+		monday := midnight.AddDate(0, 0, -weekday)
+		weeks := int(interval / week)
+		epoch := time.Date(1970, 1, 5, 0, 0, 0, 0, loc)
+		elapsed := int(monday.Sub(epoch).Hours()+12) / (24 * 7)
+		return monday.AddDate(0, 0, -7*(((elapsed%weeks)+weeks)%weeks))
+	default:
+		days := int(interval / day)
+		epoch := time.Date(1970, 1, 1, 0, 0, 0, 0, loc)
+		elapsed := int(midnight.Sub(epoch).Hours()+12) / 24
+		return midnight.AddDate(0, 0, -(((elapsed % days) + days) % days))
+	}
+}
+
+// sourceInterval K线的原始周期：相邻K线时间间隔的最小值，不足两根时为0
+func sourceInterval(times []time.Time) time.Duration {
+	var interval time.Duration
+	for i := 1; i < len(times); i++ {
+		if gap := times[i].Sub(times[i-1]); gap > 0 && (interval == 0 || gap < interval) {
+			interval = gap
+		}
+	}
+	return interval
+}
+
+// checkResample 目标周期需要不短于原始周期且为其整数倍（日线及以上不要求整数倍，按天对齐）
+func checkResample(source, target time.Duration) error {
+	if source == 0 || target == source {
+		return nil
+	}
+	if target < source {
+		return fmt.Errorf("无法把 %v 的K线重采样为更短的 %v 周期", source, target)
+	}
+	if target < 24*time.Hour && target%source != 0 {
+		return fmt.Errorf("%v 不是原始周期 %v 的整数倍", target, source)
+	}
+	return nil
+}
+
+// Resample 把K线聚合为 interval 周期：开盘价取周期内第一根，最高价和最低价取极值，收盘价取最后一根，成交量求和，
+// 时间为周期的起始时间（按交易所时段 session 对齐，nil 时按UTC零点）。输入需按时间升序；缺失的周期不补齐，最后一个周期可能尚未走完
+func Resample(points []DataPoint, interval time.Duration, session *Session) ([]DataPoint, error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("K线周期必须大于0")
+	}
+	times := make([]time.Time, len(points))
+	for i, point := range points {
+		times[i] = point.Timestamp
+	}
+	if err := checkResample(sourceInterval(times), interval); err != nil {
+		return nil, err
+	}
+
+	var result []DataPoint
+	for _, point := range points {
+		bucket := session.Align(point.Timestamp, interval)
+		if n := len(result); n > 0 && result[n-1].Timestamp.Equal(bucket) {
+			last := &result[n-1]
+			if point.High > last.High {
+				last.High = point.High
+			}
+			if point.Low < last.Low {
+				last.Low = point.Low
+			}
+			last.Close = point.Close
+			last.Volume += point.Volume
+			continue
+		}
+		point.Timestamp = bucket
+		result = append(result, point)
+	}
+	return result, nil
+}
+
+// ResampleFrame 把行情数据聚合为 interval 周期，OHLCV 规则同 Resample；标的、合约等其他列取周期内最后一行的值
+func ResampleFrame(df DataFrame, interval time.Duration, session *Session) (DataFrame, error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("K线周期必须大于0")
+	}
+	timestamps := df["timestamp"]
+	if len(timestamps) == 0 {
+		return df, nil
+	}
+	times := make([]time.Time, len(timestamps))
+	for i, value := range timestamps {
+		t, ok := value.(time.Time)
+		if !ok {
+			return nil, fmt.Errorf("第 %d 行的时间无效: %v", i+1, value)
+		}
+		times[i] = t
+	}
+	if !sort.SliceIsSorted(times, func(i, j int) bool { return times[i].Before(times[j]) }) {
+		return nil, fmt.Errorf("行情数据需按时间升序排列")
+	}
+	if err := checkResample(sourceInterval(times), interval); err != nil {
+		return nil, err
+	}
+
+	// 每个周期的起止行号
+	var starts []int
+	var buckets []time.Time
+	for i, t := range times {
+		bucket := session.Align(t, interval)
+		if n := len(buckets); n == 0 || !buckets[n-1].Equal(bucket) {
+			starts = append(starts, i)
+			buckets = append(buckets, bucket)
+		}
+	}
+	starts = append(starts, len(times))
+
+	result := make(DataFrame, len(df))
+	for column, values := range df {
+		if len(values) != len(times) {
+			return nil, fmt.Errorf("列 '%s' 的数据长度不一致", column)
+		}
+		aggregated := make([]interface{}, len(buckets))
+		for b := range buckets {
+			rows := values[starts[b]:starts[b+1]]
+			switch column {
+			case "timestamp":
+				aggregated[b] = buckets[b]
+			case "open":
+				aggregated[b] = rows[0]
+			case "high":
+				aggregated[b] = extreme(rows, func(a, b float64) bool { return a > b })
+			case "low":
+				aggregated[b] = extreme(rows, func(a, b float64) bool { return a < b })
+			case "volume":
+				aggregated[b] = sumVolume(rows)
+			default:
+				aggregated[b] = rows[len(rows)-1]
+			}
+		}
+		result[column] = aggregated
+	}
+	return result, nil
+}
+
+// extreme 按 better 取数值列中的极值，保留原值的类型
+func extreme(values []interface{}, better func(a, b float64) bool) interface{} {
+	best := values[0]
+	bestValue, _ := toFloat64(best)
+	for _, value := range values[1:] {
+		if v, ok := toFloat64(value); ok && better(v, bestValue) {
+			best, bestValue = value, v
+		}
+	}
+	return best
+}
+
+// sumVolume 成交量求和，整数列保持为 int64
+func sumVolume(values []interface{}) interface{} {
+	var total int64
+	var totalFloat float64
+	integer := true
+	for _, value := range values {
+		switch v := value.(type) {
+		case int64:
+			total += v
+			totalFloat += float64(v)
+		case int:
+			total += int64(v)
+			totalFloat += float64(v)
+		default:
+			integer = false
+			f, _ := toFloat64(value)
+			totalFloat += f
+		}
+	}
+	if integer {
+		return total
+	}
+	return totalFloat
+}
+
+// GetResampledData 获取 [startDate, endDate] 的行情并聚合为 interval 周期（如 5m、1h、1d），
+// 数据源只需提供一种较短周期的K线即可服务其他周期的策略
+func (dm *DataManager) GetResampledData(symbol, interval, startDate, endDate string) (DataFrame, error) {
+	duration, err := ParseInterval(interval)
+	if err != nil {
+		return nil, err
+	}
+	df, err := dm.GetMarketData(symbol, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	return ResampleFrame(df, duration, dm.Session(symbol))
+}
@@ -0,0 +1,160 @@
+package data
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"agent-quant-system/internal/config"
+)
+
+// DateLayout 日期区间参数的格式
+const DateLayout = "2006-01-02"
+
+// Session 交易所的时区和常规交易时段。nil 表示UTC全天交易
+type Session struct {
+	Location     *time.Location
+	Open         time.Duration // 开盘时间距当地零点的时长
+	Close        time.Duration // 收盘时间距当地零点的时长，与 Open 都为0时表示全天交易
+	Weekends     bool          // 周六周日是否交易
+	RegularHours bool          // 只保留常规交易时段内的K线
+}
+
+// NewSession 按配置创建交易所时段
+func NewSession(cfg config.SessionConfig) (*Session, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	location, _ := time.LoadLocation(cfg.Timezone)
+	session := &Session{Location: location, Weekends: cfg.Weekends, RegularHours: cfg.RegularHours}
+	if cfg.Open != "" {
+		open, _ := time.Parse("15:04", cfg.Open)
+		close, _ := time.Parse("15:04", cfg.Close)
+		session.Open = time.Duration(open.Hour())*time.Hour + time.Duration(open.Minute())*time.Minute
+		session.Close = time.Duration(close.Hour())*time.Hour + time.Duration(close.Minute())*time.Minute
+	}
+	return session, nil
+}
+
+// location 交易所时区，nil 时为UTC
+func (s *Session) location() *time.Location {
+	if s == nil || s.Location == nil {
+		return time.UTC
+	}
+	return s.Location
+}
+
+// AllDay 是否全天交易
+func (s *Session) AllDay() bool {
+	return s == nil || s.Open == s.Close
+}
+
+// ParseDate 把 YYYY-MM-DD 解析为交易所当地零点
+func (s *Session) ParseDate(date string) (time.Time, error) {
+	return time.ParseInLocation(DateLayout, date, s.location())
+}
+
+// FormatDate t 在交易所当地的日期
+func (s *Session) FormatDate(t time.Time) string {
+	return t.In(s.location()).Format(DateLayout)
+}
+
+// Contains t 是否在常规交易时段内：交易日（周末不交易时为周一至周五）的 [open, close)
+func (s *Session) Contains(t time.Time) bool {
+	if s == nil {
+		return true
+	}
+	local := t.In(s.location())
+	if !s.Weekends && (local.Weekday() == time.Saturday || local.Weekday() == time.Sunday) {
+		return false
+	}
+	if s.AllDay() {
+		return true
+	}
+	midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, s.location())
+	offset := local.Sub(midnight)
+	return offset >= s.Open && offset < s.Close
+}
+
+// Align K线周期的起始时间：日内周期从开盘时间起按周期对齐（开盘前的K线向前按周期对齐），
+// 日线及以上按交易所当地日期对齐（见 AlignTime）
+func (s *Session) Align(t time.Time, interval time.Duration) time.Time {
+	if interval >= 24*time.Hour || s.AllDay() {
+		return AlignTime(t, interval, s.location())
+	}
+	local := t.In(s.location())
+	open := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, s.location()).Add(s.Open)
+	elapsed := local.Sub(open)
+	periods := elapsed / interval
+	if elapsed < 0 && elapsed%interval != 0 {
+		periods--
+	}
+	return open.Add(periods * interval)
+}
+
+// Filter 只保留常规交易时段内的K线，未启用 RegularHours 时原样返回
+func (s *Session) Filter(points []DataPoint) []DataPoint {
+	if s == nil || !s.RegularHours {
+		return points
+	}
+	filtered := make([]DataPoint, 0, len(points))
+	for _, point := range points {
+		if s.Contains(point.Timestamp) {
+			filtered = append(filtered, point)
+		}
+	}
+	return filtered
+}
+
+// String 时段的简要说明，如 "America/New_York 09:30-16:00"
+func (s *Session) String() string {
+	if s.AllDay() {
+		return s.location().String() + " 全天"
+	}
+	format := func(offset time.Duration) string {
+		return fmt.Sprintf("%02d:%02d", int(offset.Hours()), int(offset.Minutes())%60)
+	}
+	return fmt.Sprintf("%s %s-%s", s.location(), format(s.Open), format(s.Close))
+}
+
+// newSessions 按配置创建各资产类别和标的的交易所时段
+func newSessions(cfg config.DataConfig) (map[AssetClass]*Session, map[string]*Session, error) {
+	classes := make(map[AssetClass]*Session, len(cfg.Sessions))
+	for class, sessionConfig := range cfg.Sessions {
+		session, err := NewSession(sessionConfig)
+		if err != nil {
+			return nil, nil, fmt.Errorf("资产类别 '%s' 的交易时段无效: %w", class, err)
+		}
+		classes[AssetClass(class)] = session
+	}
+	symbols := make(map[string]*Session, len(cfg.SymbolSessions))
+	for symbol, sessionConfig := range cfg.SymbolSessions {
+		session, err := NewSession(sessionConfig)
+		if err != nil {
+			return nil, nil, fmt.Errorf("标的 '%s' 的交易时段无效: %w", symbol, err)
+		}
+		symbols[strings.ToUpper(symbol)] = session
+	}
+	return classes, symbols, nil
+}
+
+// Session 标的所在交易所的时段：优先使用 symbol_sessions，其次为资产类别的时段，都未配置时为 nil（UTC全天交易）
+func (dm *DataManager) Session(symbol string) *Session {
+	if session, ok := dm.symbolSessions[strings.ToUpper(symbol)]; ok {
+		return session
+	}
+	return dm.sessions[dm.ClassifySymbol(symbol)]
+}
+
+// filterContinuous 按常规交易时段过滤连续合约的K线和对应的合约列
+func filterContinuous(session *Session, points []DataPoint, contracts []interface{}) ([]DataPoint, []interface{}) {
+	filteredPoints := make([]DataPoint, 0, len(points))
+	filteredContracts := make([]interface{}, 0, len(contracts))
+	for i, point := range points {
+		if session.Contains(point.Timestamp) {
+			filteredPoints = append(filteredPoints, point)
+			filteredContracts = append(filteredContracts, contracts[i])
+		}
+	}
+	return filteredPoints, filteredContracts
+}
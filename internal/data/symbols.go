@@ -0,0 +1,31 @@
+package data
+
+import (
+	"agent-quant-system/internal/symbols"
+)
+
+// SetSymbols 设置标的代码转换：请求数据源时按数据源名称配置的写法转换标的，行情仍以规范写法返回
+func (dm *DataManager) SetSymbols(mapper *symbols.Mapper) {
+	dm.mutex.Lock()
+	defer dm.mutex.Unlock()
+
+	dm.symbols = mapper
+}
+
+// symbolMapper 当前的标的代码转换，未设置时为nil（不转换）
+func (dm *DataManager) symbolMapper() *symbols.Mapper {
+	dm.mutex.RLock()
+	defer dm.mutex.RUnlock()
+
+	return dm.symbols
+}
+
+// normalizeSymbol 将请求中的标的转换为规范写法
+func (dm *DataManager) normalizeSymbol(symbol string) string {
+	return dm.symbolMapper().Normalize(symbol)
+}
+
+// providerSymbol 标的在数据源中的写法
+func (dm *DataManager) providerSymbol(slot *providerSlot, symbol string) string {
+	return dm.symbolMapper().ToVenue(slot.provider.Name(), symbol)
+}
@@ -0,0 +1,331 @@
+package data
+
+import (
+	"fmt"
+	"hash/fnv"
+	"log"
+	"math"
+	"sort"
+	"time"
+)
+
+// 逐笔成交的主动方向
+const (
+	TickBuy  = "buy"  // 买方主动成交（吃卖盘）
+	TickSell = "sell" // 卖方主动成交（吃买盘）
+)
+
+// Tick 一笔逐笔成交
+type Tick struct {
+	Timestamp time.Time `json:"timestamp"`
+	Price     float64   `json:"price"`
+	Size      float64   `json:"size"`
+	Side      string    `json:"side,omitempty"` // 主动方向 buy / sell，数据源未提供时为空
+}
+
+// BookLevel 订单簿的一档
+type BookLevel struct {
+	Price float64 `json:"price"`
+	Size  float64 `json:"size"`
+}
+
+// BookSnapshot 某一时刻的 L2 订单簿快照，买盘按价格降序、卖盘按价格升序
+type BookSnapshot struct {
+	Timestamp time.Time   `json:"timestamp"`
+	Bids      []BookLevel `json:"bids"`
+	Asks      []BookLevel `json:"asks"`
+}
+
+// BestBid 最优买价，买盘为空时为0
+func (b *BookSnapshot) BestBid() float64 {
+	if len(b.Bids) == 0 {
+		return 0
+	}
+	return b.Bids[0].Price
+}
+
+// BestAsk 最优卖价，卖盘为空时为0
+func (b *BookSnapshot) BestAsk() float64 {
+	if len(b.Asks) == 0 {
+		return 0
+	}
+	return b.Asks[0].Price
+}
+
+// Mid 买卖中间价，一侧为空时为另一侧的最优价
+func (b *BookSnapshot) Mid() float64 {
+	bid, ask := b.BestBid(), b.BestAsk()
+	switch {
+	case bid > 0 && ask > 0:
+		return (bid + ask) / 2
+	case bid > 0:
+		return bid
+	}
+	return ask
+}
+
+// Spread 买卖价差，一侧为空时为0
+func (b *BookSnapshot) Spread() float64 {
+	bid, ask := b.BestBid(), b.BestAsk()
+	if bid <= 0 || ask <= 0 {
+		return 0
+	}
+	return ask - bid
+}
+
+// SizeAt 订单簿一侧在该价格上挂出的数量
+func (b *BookSnapshot) SizeAt(buy bool, price float64) float64 {
+	levels := b.Asks
+	if buy {
+		levels = b.Bids
+	}
+	for _, level := range levels {
+		if level.Price == price {
+			return level.Size
+		}
+	}
+	return 0
+}
+
+// sortBook 买盘按价格降序、卖盘按价格升序排列
+func (b *BookSnapshot) sortBook() {
+	sort.SliceStable(b.Bids, func(i, j int) bool { return b.Bids[i].Price > b.Bids[j].Price })
+	sort.SliceStable(b.Asks, func(i, j int) bool { return b.Asks[i].Price < b.Asks[j].Price })
+}
+
+// TickProvider 提供逐笔成交和订单簿快照的数据源（可选接口）
+type TickProvider interface {
+	// Ticks [start, end) 内的逐笔成交，按时间排序
+	Ticks(symbol string, start, end time.Time) ([]Tick, error)
+
+	// BookSnapshots [start, end) 内的订单簿快照，按时间排序
+	BookSnapshots(symbol string, start, end time.Time) ([]BookSnapshot, error)
+}
+
+// 模拟逐笔数据的参数：成交和快照间隔、订单簿档数、最小价格变动
+const (
+	mockTickInterval = 30 * time.Second
+	mockBookLevels   = 5
+	mockTickSize     = 0.01
+)
+
+// Ticks 模拟逐笔成交：每 30 秒一笔，价格围绕按时间确定的中间价波动，同一时刻的结果与请求区间无关
+func (MockProvider) Ticks(symbol string, start, end time.Time) ([]Tick, error) {
+	var ticks []Tick
+	seed := mockSeed(symbol)
+	for current := start.Truncate(mockTickInterval); current.Before(end); current = current.Add(mockTickInterval) {
+		if current.Before(start) {
+			continue
+		}
+		noise := mockNoise(seed ^ uint64(current.Unix()))
+		mid := mockMid(seed, current)
+		side, price := TickBuy, mid+mockTickSize
+		if noise&1 == 0 {
+			side, price = TickSell, mid-mockTickSize
+		}
+		ticks = append(ticks, Tick{
+			Timestamp: current,
+			Price:     roundTick(price),
+			Size:      float64(1 + (noise>>8)%100),
+			Side:      side,
+		})
+	}
+	return ticks, nil
+}
+
+// BookSnapshots 模拟订单簿快照：与成交错开 15 秒，每侧 5 档，价差 2 个最小价格变动
+func (MockProvider) BookSnapshots(symbol string, start, end time.Time) ([]BookSnapshot, error) {
+	var books []BookSnapshot
+	seed := mockSeed(symbol)
+	offset := mockTickInterval / 2
+	for current := start.Add(-offset).Truncate(mockTickInterval).Add(offset); current.Before(end); current = current.Add(mockTickInterval) {
+		if current.Before(start) {
+			continue
+		}
+		mid := mockMid(seed, current)
+		book := BookSnapshot{Timestamp: current}
+		for level := 0; level < mockBookLevels; level++ {
+			noise := mockNoise(seed ^ uint64(current.Unix()) ^ uint64(level+1)<<32)
+			step := float64(level+1) * mockTickSize
+			book.Bids = append(book.Bids, BookLevel{Price: roundTick(mid - step), Size: float64(50 + noise%200)})
+			book.Asks = append(book.Asks, BookLevel{Price: roundTick(mid + step), Size: float64(50 + (noise>>16)%200)})
+		}
+		books = append(books, book)
+	}
+	return books, nil
+}
+
+// mockMid 模拟的中间价：按小时级正弦波动叠加按时间确定的噪声
+func mockMid(seed uint64, t time.Time) float64 {
+	hours := float64(t.Unix()) / 3600
+	noise := float64(mockNoise(seed^uint64(t.Unix()/60))%100)/100 - 0.5
+	return roundTick(100 + 2*math.Sin(hours/6) + 0.3*noise)
+}
+
+// mockSeed 按标的生成模拟数据的种子，不同标的的走势不同
+func mockSeed(symbol string) uint64 {
+	hash := fnv.New64a()
+	hash.Write([]byte(symbol))
+	return hash.Sum64()
+}
+
+// mockNoise splitmix64 伪随机数
+func mockNoise(x uint64) uint64 {
+	x += 0x9e3779b97f4a7c15
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	return x ^ (x >> 31)
+}
+
+// roundTick 按模拟数据的最小价格变动取整
+func roundTick(price float64) float64 {
+	return math.Round(price/mockTickSize) * mockTickSize
+}
+
+// TickBars 把逐笔成交按周期聚合为K线，K线时间为周期开始时间（按 UTC 对齐），没有成交的周期不生成K线
+func TickBars(ticks []Tick, interval time.Duration) []DataPoint {
+	var bars []DataPoint
+	for _, tick := range ticks {
+		start := tick.Timestamp.Truncate(interval)
+		if n := len(bars); n > 0 && bars[n-1].Timestamp.Equal(start) {
+			bar := &bars[n-1]
+			bar.High = math.Max(bar.High, tick.Price)
+			bar.Low = math.Min(bar.Low, tick.Price)
+			bar.Close = tick.Price
+			bar.Volume += int64(math.Round(tick.Size))
+			continue
+		}
+		bars = append(bars, DataPoint{
+			Timestamp: start,
+			Open:      tick.Price,
+			High:      tick.Price,
+			Low:       tick.Price,
+			Close:     tick.Price,
+			Volume:    int64(math.Round(tick.Size)),
+		})
+	}
+	return bars
+}
+
+// TickFrame 把逐笔成交聚合为K线并转换为策略使用的DataFrame
+func (dm *DataManager) TickFrame(symbol string, ticks []Tick, interval time.Duration) DataFrame {
+	return dm.convertToDataFrame(dm.normalizeSymbol(symbol), TickBars(ticks, interval))
+}
+
+// tickDays 覆盖 [start, end) 的各个 UTC 日期
+func tickDays(start, end time.Time) []time.Time {
+	var days []time.Time
+	for day := start.UTC().Truncate(24 * time.Hour); day.Before(end); day = day.Add(24 * time.Hour) {
+		days = append(days, day)
+	}
+	return days
+}
+
+// GetTicks 获取 [start, end) 内的逐笔成交：配置了 data.ticks.dir 时按日读取本地存储，
+// 缺失的日期向数据源获取，已结束的日期获取后写入存储
+func (dm *DataManager) GetTicks(symbol string, start, end time.Time) ([]Tick, error) {
+	symbol = dm.normalizeSymbol(symbol)
+	slot := dm.acquire(symbol)
+	defer slot.release()
+	provider, _ := slot.provider.(TickProvider)
+	store := dm.tickStore()
+	if provider == nil && store == nil {
+		return nil, fmt.Errorf("数据源 %s 不提供逐笔数据，且未配置 data.ticks.dir", slot.provider.Name())
+	}
+
+	var ticks []Tick
+	for _, day := range tickDays(start, end) {
+		if store != nil {
+			stored, found, err := store.LoadTicks(slot.provider.Name(), symbol, day)
+			if err != nil {
+				return nil, err
+			}
+			if found {
+				ticks = append(ticks, sliceTicks(stored, start, end)...)
+				continue
+			}
+		}
+		if provider == nil {
+			continue
+		}
+
+		dayEnd := day.Add(24 * time.Hour)
+		complete := !dayEnd.After(time.Now())
+		fetched, err := provider.Ticks(dm.providerSymbol(slot, symbol), day, minTime(dayEnd, time.Now()))
+		if err != nil {
+			return nil, fmt.Errorf("数据源 %s 获取逐笔成交失败: %w", slot.provider.Name(), err)
+		}
+		if store != nil && complete {
+			if err := store.SaveTicks(slot.provider.Name(), symbol, day, fetched); err != nil {
+				log.Printf("保存逐笔成交失败: %v", err)
+			}
+		}
+		ticks = append(ticks, sliceTicks(fetched, start, end)...)
+	}
+	if len(ticks) == 0 {
+		return nil, fmt.Errorf("%s 在 %s ~ %s 没有逐笔成交", symbol, start.Format(time.RFC3339), end.Format(time.RFC3339))
+	}
+	log.Printf("成功获取 %d 笔逐笔成交: 标的=%s, 数据源=%s", len(ticks), symbol, slot.provider.Name())
+	return ticks, nil
+}
+
+// GetBookSnapshots 获取 [start, end) 内的订单簿快照，存储方式同 GetTicks；没有快照时返回空列表
+func (dm *DataManager) GetBookSnapshots(symbol string, start, end time.Time) ([]BookSnapshot, error) {
+	symbol = dm.normalizeSymbol(symbol)
+	slot := dm.acquire(symbol)
+	defer slot.release()
+	provider, _ := slot.provider.(TickProvider)
+	store := dm.tickStore()
+
+	var books []BookSnapshot
+	for _, day := range tickDays(start, end) {
+		if store != nil {
+			stored, found, err := store.LoadBooks(slot.provider.Name(), symbol, day)
+			if err != nil {
+				return nil, err
+			}
+			if found {
+				books = append(books, sliceBooks(stored, start, end)...)
+				continue
+			}
+		}
+		if provider == nil {
+			continue
+		}
+
+		dayEnd := day.Add(24 * time.Hour)
+		complete := !dayEnd.After(time.Now())
+		fetched, err := provider.BookSnapshots(dm.providerSymbol(slot, symbol), day, minTime(dayEnd, time.Now()))
+		if err != nil {
+			return nil, fmt.Errorf("数据源 %s 获取订单簿快照失败: %w", slot.provider.Name(), err)
+		}
+		if store != nil && complete {
+			if err := store.SaveBooks(slot.provider.Name(), symbol, day, fetched); err != nil {
+				log.Printf("保存订单簿快照失败: %v", err)
+			}
+		}
+		books = append(books, sliceBooks(fetched, start, end)...)
+	}
+	return books, nil
+}
+
+// tickStore 逐笔数据的本地存储，未配置时为nil
+func (dm *DataManager) tickStore() *TickStore {
+	dm.mutex.RLock()
+	defer dm.mutex.RUnlock()
+	return dm.ticks
+}
+
+// sliceTicks [start, end) 内的逐笔成交
+func sliceTicks(ticks []Tick, start, end time.Time) []Tick {
+	from := sort.Search(len(ticks), func(i int) bool { return !ticks[i].Timestamp.Before(start) })
+	to := sort.Search(len(ticks), func(i int) bool { return !ticks[i].Timestamp.Before(end) })
+	return ticks[from:to]
+}
+
+// sliceBooks [start, end) 内的订单簿快照
+func sliceBooks(books []BookSnapshot, start, end time.Time) []BookSnapshot {
+	from := sort.Search(len(books), func(i int) bool { return !books[i].Timestamp.Before(start) })
+	to := sort.Search(len(books), func(i int) bool { return !books[i].Timestamp.Before(end) })
+	return books[from:to]
+}
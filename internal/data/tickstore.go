@@ -0,0 +1,370 @@
+package data
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 逐笔数据的种类，对应存储目录
+const (
+	tickKindTrades = "trades"
+	tickKindBook   = "book"
+)
+
+// TickStore 逐笔成交和订单簿快照的本地存储：每个数据源、标的、种类、UTC 日期一个 JSON Lines 文件，
+// <dir>/<数据源>/<标的>/<trades|book>/<YYYY-MM-DD>.jsonl；文件存在表示该日的数据已完整获取
+type TickStore struct {
+	dir string
+}
+
+// NewTickStore 创建逐笔数据存储
+func NewTickStore(dir string) *TickStore {
+	return &TickStore{dir: dir}
+}
+
+// path 某一日的文件路径，标的中的路径分隔符替换为下划线
+func (s *TickStore) path(provider, symbol, kind string, day time.Time) string {
+	name := strings.NewReplacer("/", "_", "\\", "_", ":", "_").Replace(strings.ToUpper(symbol))
+	return filepath.Join(s.dir, provider, name, kind, day.UTC().Format("2006-01-02")+".jsonl")
+}
+
+// load 逐行解码某一日的文件，文件不存在时 found 为 false
+func (s *TickStore) load(provider, symbol, kind string, day time.Time, decode func(line []byte) error) (bool, error) {
+	file, err := os.Open(s.path(provider, symbol, kind, day))
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("读取逐笔数据失败: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		if err := decode(scanner.Bytes()); err != nil {
+			return false, fmt.Errorf("解析 %s 第 %d 行失败: %w", file.Name(), line, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return false, fmt.Errorf("读取逐笔数据失败: %w", err)
+	}
+	return true, nil
+}
+
+// save 先写临时文件再重命名，避免中断时留下不完整的文件
+func (s *TickStore) save(provider, symbol, kind string, day time.Time, count int, record func(i int) interface{}) error {
+	path := s.path(provider, symbol, kind, day)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("创建逐笔数据目录失败: %w", err)
+	}
+	tmp := path + ".tmp"
+	file, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("写入逐笔数据失败: %w", err)
+	}
+	writer := bufio.NewWriter(file)
+	encoder := json.NewEncoder(writer)
+	for i := 0; i < count; i++ {
+		if err := encoder.Encode(record(i)); err != nil {
+			file.Close()
+			return fmt.Errorf("序列化逐笔数据失败: %w", err)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		file.Close()
+		return fmt.Errorf("写入逐笔数据失败: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("写入逐笔数据失败: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// LoadTicks 读取某一日的逐笔成交
+func (s *TickStore) LoadTicks(provider, symbol string, day time.Time) ([]Tick, bool, error) {
+	var ticks []Tick
+	found, err := s.load(provider, symbol, tickKindTrades, day, func(line []byte) error {
+		var tick Tick
+		if err := json.Unmarshal(line, &tick); err != nil {
+			return err
+		}
+		ticks = append(ticks, tick)
+		return nil
+	})
+	return ticks, found, err
+}
+
+// SaveTicks 写入某一日的逐笔成交
+func (s *TickStore) SaveTicks(provider, symbol string, day time.Time, ticks []Tick) error {
+	return s.save(provider, symbol, tickKindTrades, day, len(ticks), func(i int) interface{} { return ticks[i] })
+}
+
+// LoadBooks 读取某一日的订单簿快照
+func (s *TickStore) LoadBooks(provider, symbol string, day time.Time) ([]BookSnapshot, bool, error) {
+	var books []BookSnapshot
+	found, err := s.load(provider, symbol, tickKindBook, day, func(line []byte) error {
+		var book BookSnapshot
+		if err := json.Unmarshal(line, &book); err != nil {
+			return err
+		}
+		books = append(books, book)
+		return nil
+	})
+	return books, found, err
+}
+
+// SaveBooks 写入某一日的订单簿快照
+func (s *TickStore) SaveBooks(provider, symbol string, day time.Time, books []BookSnapshot) error {
+	return s.save(provider, symbol, tickKindBook, day, len(books), func(i int) interface{} { return books[i] })
+}
+
+// TickImportResult 逐笔数据的导入结果
+type TickImportResult struct {
+	Symbol  string    `json:"symbol"`
+	Kind    string    `json:"kind"`    // trades / book
+	Records int       `json:"records"` // 本次导入的成交笔数或快照数
+	Days    int       `json:"days"`    // 写入的日期数
+	Start   time.Time `json:"start"`
+	End     time.Time `json:"end"`
+}
+
+// ImportTickFile 导入逐笔数据的CSV文件到导入数据源（imported），替换已导入数据中相同时间区间的记录。
+// 成交文件的列为 timestamp, price, size[, side]；订单簿文件的列为 timestamp, side(bid/ask), price, size，
+// 同一时间的各档合并为一个快照。时间的时区和格式按 data.import 配置解析
+func (dm *DataManager) ImportTickFile(path, symbol string, book bool, opts ImportOptions) (*TickImportResult, error) {
+	store := dm.tickStore()
+	if store == nil {
+		return nil, fmt.Errorf("未配置 data.ticks.dir，无法保存逐笔数据")
+	}
+	if symbol == "" {
+		return nil, fmt.Errorf("需要指定标的")
+	}
+	symbol = dm.normalizeSymbol(strings.ToUpper(symbol))
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("打开文件失败: %w", err)
+	}
+	defer file.Close()
+
+	if book {
+		books, err := readBookCSV(file, opts)
+		if err != nil {
+			return nil, err
+		}
+		return importBooks(store, symbol, books)
+	}
+	ticks, err := readTickCSV(file, opts)
+	if err != nil {
+		return nil, err
+	}
+	return importTicks(store, symbol, ticks)
+}
+
+// importTicks 按日期合并写入逐笔成交
+func importTicks(store *TickStore, symbol string, ticks []Tick) (*TickImportResult, error) {
+	if len(ticks) == 0 {
+		return nil, fmt.Errorf("文件中没有可导入的逐笔成交")
+	}
+	first, last := ticks[0].Timestamp, ticks[len(ticks)-1].Timestamp
+	days := tickDays(first, last.Add(time.Nanosecond))
+	for _, day := range days {
+		existing, _, err := store.LoadTicks(ImportedProviderName, symbol, day)
+		if err != nil {
+			return nil, err
+		}
+		merged := make([]Tick, 0, len(existing))
+		for _, tick := range existing {
+			if tick.Timestamp.Before(first) || tick.Timestamp.After(last) {
+				merged = append(merged, tick)
+			}
+		}
+		merged = append(merged, sliceTicks(ticks, day, day.Add(24*time.Hour))...)
+		sort.SliceStable(merged, func(i, j int) bool { return merged[i].Timestamp.Before(merged[j].Timestamp) })
+		if err := store.SaveTicks(ImportedProviderName, symbol, day, merged); err != nil {
+			return nil, err
+		}
+	}
+	return &TickImportResult{Symbol: symbol, Kind: tickKindTrades, Records: len(ticks), Days: len(days), Start: first, End: last}, nil
+}
+
+// importBooks 按日期合并写入订单簿快照
+func importBooks(store *TickStore, symbol string, books []BookSnapshot) (*TickImportResult, error) {
+	if len(books) == 0 {
+		return nil, fmt.Errorf("文件中没有可导入的订单簿快照")
+	}
+	first, last := books[0].Timestamp, books[len(books)-1].Timestamp
+	days := tickDays(first, last.Add(time.Nanosecond))
+	for _, day := range days {
+		existing, _, err := store.LoadBooks(ImportedProviderName, symbol, day)
+		if err != nil {
+			return nil, err
+		}
+		merged := make([]BookSnapshot, 0, len(existing))
+		for _, book := range existing {
+			if book.Timestamp.Before(first) || book.Timestamp.After(last) {
+				merged = append(merged, book)
+			}
+		}
+		merged = append(merged, sliceBooks(books, day, day.Add(24*time.Hour))...)
+		sort.SliceStable(merged, func(i, j int) bool { return merged[i].Timestamp.Before(merged[j].Timestamp) })
+		if err := store.SaveBooks(ImportedProviderName, symbol, day, merged); err != nil {
+			return nil, err
+		}
+	}
+	return &TickImportResult{Symbol: symbol, Kind: tickKindBook, Records: len(books), Days: len(days), Start: first, End: last}, nil
+}
+
+// tickCSV 按表头读取逐笔CSV，columns 为必需的列，optional 为可选的列；每行按列名取值后交给 row 处理
+func tickCSV(r io.Reader, opts ImportOptions, columns, optional []string, row func(field func(name string) string) error) error {
+	reader := csv.NewReader(r)
+	if opts.Delimiter != 0 {
+		reader.Comma = opts.Delimiter
+	}
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return fmt.Errorf("读取表头失败: %w", err)
+	}
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		index[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	for _, name := range columns {
+		if _, ok := index[name]; !ok {
+			return fmt.Errorf("文件中没有列 %s（需要: %s，可选: %s）", name, strings.Join(columns, ", "), strings.Join(optional, ", "))
+		}
+	}
+
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("第 %d 行解析失败: %w", line, err)
+		}
+		field := func(name string) string {
+			i, ok := index[name]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+		if err := row(field); err != nil {
+			return fmt.Errorf("第 %d 行: %w", line, err)
+		}
+	}
+}
+
+// parseTickNumber 解析价格或数量
+func parseTickNumber(field func(name string) string, name string) (float64, error) {
+	value, err := strconv.ParseFloat(field(name), 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s 无效: %q", name, field(name))
+	}
+	return value, nil
+}
+
+// readTickCSV 读取逐笔成交文件，按时间排序
+func readTickCSV(r io.Reader, opts ImportOptions) ([]Tick, error) {
+	location := opts.Location
+	if location == nil {
+		location = time.UTC
+	}
+	var ticks []Tick
+	err := tickCSV(r, opts, []string{"timestamp", "price", "size"}, []string{"side"}, func(field func(string) string) error {
+		timestamp, err := parseTimestamp(field("timestamp"), opts.TimeFormat, location)
+		if err != nil {
+			return err
+		}
+		price, err := parseTickNumber(field, "price")
+		if err != nil {
+			return err
+		}
+		size, err := parseTickNumber(field, "size")
+		if err != nil {
+			return err
+		}
+		side := strings.ToLower(field("side"))
+		switch side {
+		case "", TickBuy, TickSell:
+		case "b", "bid":
+			side = TickBuy
+		case "s", "a", "ask":
+			side = TickSell
+		default:
+			return fmt.Errorf("side 无效: %q，可选: buy, sell", side)
+		}
+		ticks = append(ticks, Tick{Timestamp: timestamp, Price: price, Size: size, Side: side})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(ticks, func(i, j int) bool { return ticks[i].Timestamp.Before(ticks[j].Timestamp) })
+	return ticks, nil
+}
+
+// readBookCSV 读取订单簿文件，同一时间的各档合并为一个快照，按时间排序
+func readBookCSV(r io.Reader, opts ImportOptions) ([]BookSnapshot, error) {
+	location := opts.Location
+	if location == nil {
+		location = time.UTC
+	}
+	byTime := make(map[time.Time]*BookSnapshot)
+	err := tickCSV(r, opts, []string{"timestamp", "side", "price", "size"}, nil, func(field func(string) string) error {
+		timestamp, err := parseTimestamp(field("timestamp"), opts.TimeFormat, location)
+		if err != nil {
+			return err
+		}
+		price, err := parseTickNumber(field, "price")
+		if err != nil {
+			return err
+		}
+		size, err := parseTickNumber(field, "size")
+		if err != nil {
+			return err
+		}
+		book, ok := byTime[timestamp]
+		if !ok {
+			book = &BookSnapshot{Timestamp: timestamp}
+			byTime[timestamp] = book
+		}
+		switch strings.ToLower(field("side")) {
+		case "bid", "b", "buy":
+			book.Bids = append(book.Bids, BookLevel{Price: price, Size: size})
+		case "ask", "a", "sell":
+			book.Asks = append(book.Asks, BookLevel{Price: price, Size: size})
+		default:
+			return fmt.Errorf("side 无效: %q，可选: bid, ask", field("side"))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	books := make([]BookSnapshot, 0, len(byTime))
+	for _, book := range byTime {
+		book.sortBook()
+		books = append(books, *book)
+	}
+	sort.Slice(books, func(i, j int) bool { return books[i].Timestamp.Before(books[j].Timestamp) })
+	return books, nil
+}
@@ -0,0 +1,255 @@
+package data
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"agent-quant-system/internal/config"
+
+	"github.com/go-resty/resty/v2"
+)
+
+const (
+	// vendorTimeout 行情数据源单次请求的超时
+	vendorTimeout = 15 * time.Second
+	// binanceKlineLimit Binance 单次请求最多返回的K线数
+	binanceKlineLimit = 1000
+)
+
+// VendorProviders 按密钥配置可用的真实行情数据源：binance 使用公开接口，finnhub 需要 api_keys.finnhub_key。
+// 返回的数据源传给 NewDataManagerFromConfig 注册后，可在 data.providers 中使用或运行时切换
+func VendorProviders(keys config.APIKeysConfig) []Provider {
+	providers := []Provider{NewBinanceProvider("")}
+	if keys.FinnhubKey != "" {
+		providers = append(providers, NewFinnhubProvider(keys.FinnhubKey, ""))
+	}
+	return providers
+}
+
+// newVendorClient 创建行情数据源使用的HTTP客户端
+func newVendorClient(baseURL string) *resty.Client {
+	client := resty.New()
+	client.SetTimeout(vendorTimeout)
+	client.SetBaseURL(baseURL)
+	client.SetHeader("User-Agent", "agent-quant-system/1.0")
+	return client
+}
+
+// FinnhubProvider Finnhub 股票行情：小时K线和最新成交价
+type FinnhubProvider struct {
+	httpClient *resty.Client
+	apiKey     string
+}
+
+// NewFinnhubProvider 创建 Finnhub 数据源，baseURL 为空时使用官方地址
+func NewFinnhubProvider(apiKey, baseURL string) *FinnhubProvider {
+	if baseURL == "" {
+		baseURL = "https://finnhub.io/api/v1"
+	}
+	return &FinnhubProvider{httpClient: newVendorClient(baseURL), apiKey: apiKey}
+}
+
+// Name 数据源名称
+func (f *FinnhubProvider) Name() string { return "finnhub" }
+
+// Bars 获取 [start, end) 区间的小时K线
+func (f *FinnhubProvider) Bars(symbol string, start, end time.Time) ([]DataPoint, error) {
+	var result struct {
+		Status string    `json:"s"`
+		Open   []float64 `json:"o"`
+		High   []float64 `json:"h"`
+		Low    []float64 `json:"l"`
+		Close  []float64 `json:"c"`
+		Volume []float64 `json:"v"`
+		Time   []int64   `json:"t"`
+	}
+	resp, err := f.httpClient.R().
+		SetQueryParams(map[string]string{
+			"symbol":     symbol,
+			"resolution": "60",
+			"from":       strconv.FormatInt(start.Unix(), 10),
+			"to":         strconv.FormatInt(end.Unix(), 10),
+			"token":      f.apiKey,
+		}).
+		Get("/stock/candle")
+	if err != nil {
+		return nil, fmt.Errorf("请求Finnhub K线失败: %w", err)
+	}
+	if resp.StatusCode() != 200 {
+		return nil, fmt.Errorf("请求Finnhub K线失败，状态码: %d", resp.StatusCode())
+	}
+	if err := json.Unmarshal(resp.Body(), &result); err != nil {
+		return nil, fmt.Errorf("解析Finnhub K线失败: %w", err)
+	}
+	if result.Status == "no_data" {
+		return nil, nil
+	}
+	if result.Status != "ok" {
+		return nil, fmt.Errorf("Finnhub 返回异常状态: %s", result.Status)
+	}
+
+	count := len(result.Time)
+	if len(result.Open) != count || len(result.High) != count || len(result.Low) != count ||
+		len(result.Close) != count || len(result.Volume) != count {
+		return nil, fmt.Errorf("Finnhub K线字段长度不一致")
+	}
+	bars := make([]DataPoint, 0, count)
+	for i, seconds := range result.Time {
+		timestamp := time.Unix(seconds, 0).UTC()
+		if timestamp.Before(start) || !timestamp.Before(end) {
+			continue
+		}
+		bars = append(bars, DataPoint{
+			Timestamp: timestamp,
+			Open:      result.Open[i],
+			High:      result.High[i],
+			Low:       result.Low[i],
+			Close:     result.Close[i],
+			Volume:    int64(result.Volume[i]),
+		})
+	}
+	return bars, nil
+}
+
+// LatestPrice 最新成交价
+func (f *FinnhubProvider) LatestPrice(symbol string) (float64, error) {
+	var result struct {
+		Current float64 `json:"c"`
+	}
+	resp, err := f.httpClient.R().
+		SetQueryParams(map[string]string{"symbol": symbol, "token": f.apiKey}).
+		Get("/quote")
+	if err != nil {
+		return 0, fmt.Errorf("请求Finnhub报价失败: %w", err)
+	}
+	if resp.StatusCode() != 200 {
+		return 0, fmt.Errorf("请求Finnhub报价失败，状态码: %d", resp.StatusCode())
+	}
+	if err := json.Unmarshal(resp.Body(), &result); err != nil {
+		return 0, fmt.Errorf("解析Finnhub报价失败: %w", err)
+	}
+	if result.Current <= 0 {
+		return 0, fmt.Errorf("Finnhub 没有标的 %s 的报价", symbol)
+	}
+	return result.Current, nil
+}
+
+// BinanceProvider Binance 现货行情（公开接口，不需要密钥）：小时K线和最新成交价。
+// 标的去掉分隔符后请求（BTC-USDT -> BTCUSDT），其他写法通过 [symbols] 中名为 binance 的写法转换
+type BinanceProvider struct {
+	httpClient *resty.Client
+}
+
+// NewBinanceProvider 创建 Binance 数据源，baseURL 为空时使用官方地址
+func NewBinanceProvider(baseURL string) *BinanceProvider {
+	if baseURL == "" {
+		baseURL = "https://api.binance.com"
+	}
+	return &BinanceProvider{httpClient: newVendorClient(baseURL)}
+}
+
+// Name 数据源名称
+func (b *BinanceProvider) Name() string { return "binance" }
+
+// binanceSymbol 标的在 Binance 中的写法
+func binanceSymbol(symbol string) string {
+	return strings.NewReplacer("-", "", "/", "", "_", "").Replace(strings.ToUpper(symbol))
+}
+
+// Bars 获取 [start, end) 区间的小时K线，超过单次上限时分页请求
+func (b *BinanceProvider) Bars(symbol string, start, end time.Time) ([]DataPoint, error) {
+	var bars []DataPoint
+	from := start
+	for from.Before(end) {
+		var rows [][]json.RawMessage
+		resp, err := b.httpClient.R().
+			SetQueryParams(map[string]string{
+				"symbol":    binanceSymbol(symbol),
+				"interval":  "1h",
+				"startTime": strconv.FormatInt(from.UnixMilli(), 10),
+				"endTime":   strconv.FormatInt(end.UnixMilli()-1, 10),
+				"limit":     strconv.Itoa(binanceKlineLimit),
+			}).
+			Get("/api/v3/klines")
+		if err != nil {
+			return nil, fmt.Errorf("请求Binance K线失败: %w", err)
+		}
+		if resp.StatusCode() != 200 {
+			return nil, fmt.Errorf("请求Binance K线失败，状态码: %d, 响应: %s", resp.StatusCode(), resp.String())
+		}
+		if err := json.Unmarshal(resp.Body(), &rows); err != nil {
+			return nil, fmt.Errorf("解析Binance K线失败: %w", err)
+		}
+
+		for _, row := range rows {
+			bar, err := parseBinanceKline(row)
+			if err != nil {
+				return nil, err
+			}
+			bars = append(bars, bar)
+		}
+		if len(rows) < binanceKlineLimit {
+			break
+		}
+		from = bars[len(bars)-1].Timestamp.Add(time.Hour)
+	}
+	return bars, nil
+}
+
+// parseBinanceKline 解析一行K线：[开盘时间(毫秒), 开, 高, 低, 收, 成交量, ...]，价格和成交量为字符串
+func parseBinanceKline(row []json.RawMessage) (DataPoint, error) {
+	if len(row) < 6 {
+		return DataPoint{}, fmt.Errorf("Binance K线字段不足: %d", len(row))
+	}
+	var openTime int64
+	if err := json.Unmarshal(row[0], &openTime); err != nil {
+		return DataPoint{}, fmt.Errorf("解析Binance K线时间失败: %w", err)
+	}
+	values := make([]float64, 5)
+	for i := range values {
+		var text string
+		if err := json.Unmarshal(row[i+1], &text); err != nil {
+			return DataPoint{}, fmt.Errorf("解析Binance K线失败: %w", err)
+		}
+		value, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return DataPoint{}, fmt.Errorf("解析Binance K线数值 %q 失败: %w", text, err)
+		}
+		values[i] = value
+	}
+	return DataPoint{
+		Timestamp: time.UnixMilli(openTime).UTC(),
+		Open:      values[0],
+		High:      values[1],
+		Low:       values[2],
+		Close:     values[3],
+		Volume:    int64(values[4]),
+	}, nil
+}
+
+// LatestPrice 最新成交价
+func (b *BinanceProvider) LatestPrice(symbol string) (float64, error) {
+	var result struct {
+		Price string `json:"price"`
+	}
+	resp, err := b.httpClient.R().
+		SetQueryParam("symbol", binanceSymbol(symbol)).
+		Get("/api/v3/ticker/price")
+	if err != nil {
+		return 0, fmt.Errorf("请求Binance报价失败: %w", err)
+	}
+	if resp.StatusCode() != 200 {
+		return 0, fmt.Errorf("请求Binance报价失败，状态码: %d, 响应: %s", resp.StatusCode(), resp.String())
+	}
+	if err := json.Unmarshal(resp.Body(), &result); err != nil {
+		return 0, fmt.Errorf("解析Binance报价失败: %w", err)
+	}
+	price, err := strconv.ParseFloat(result.Price, 64)
+	if err != nil {
+		return 0, fmt.Errorf("解析Binance报价失败: %w", err)
+	}
+	return price, nil
+}
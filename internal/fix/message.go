@@ -0,0 +1,294 @@
+package fix
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// SOH 字段分隔符
+const SOH = '\x01'
+
+// BeginString FIX 4.4 的协议版本
+const BeginString = "FIX.4.4"
+
+// TimestampLayout UTCTimestamp 字段的格式（毫秒精度）
+const TimestampLayout = "20060102-15:04:05.000"
+
+// 常用标签
+const (
+	TagAccount          = 1
+	TagAvgPx            = 6
+	TagBeginSeqNo       = 7
+	TagBeginString      = 8
+	TagBodyLength       = 9
+	TagCheckSum         = 10
+	TagClOrdID          = 11
+	TagCommission       = 12
+	TagCumQty           = 14
+	TagEndSeqNo         = 16
+	TagExecID           = 17
+	TagLastPx           = 31
+	TagLastQty          = 32
+	TagMsgSeqNum        = 34
+	TagMsgType          = 35
+	TagNewSeqNo         = 36
+	TagOrderID          = 37
+	TagOrderQty         = 38
+	TagOrdStatus        = 39
+	TagOrdType          = 40
+	TagOrigClOrdID      = 41
+	TagPossDupFlag      = 43
+	TagPrice            = 44
+	TagRefSeqNum        = 45
+	TagSenderCompID     = 49
+	TagSendingTime      = 52
+	TagSide             = 54
+	TagSymbol           = 55
+	TagTargetCompID     = 56
+	TagText             = 58
+	TagTimeInForce      = 59
+	TagTransactTime     = 60
+	TagEncryptMethod    = 98
+	TagStopPx           = 99
+	TagHeartBtInt       = 108
+	TagTestReqID        = 112
+	TagGapFillFlag      = 123
+	TagResetSeqNumFlag  = 141
+	TagExecType         = 150
+	TagLeavesQty        = 151
+	TagCxlRejResponseTo = 434
+	TagUsername         = 553
+	TagPassword         = 554
+)
+
+// 消息类型
+const (
+	MsgHeartbeat          = "0"
+	MsgTestRequest        = "1"
+	MsgResendRequest      = "2"
+	MsgReject             = "3"
+	MsgSequenceReset      = "4"
+	MsgLogout             = "5"
+	MsgExecutionReport    = "8"
+	MsgOrderCancelReject  = "9"
+	MsgLogon              = "A"
+	MsgNewOrderSingle     = "D"
+	MsgOrderCancelRequest = "F"
+	MsgBusinessReject     = "j"
+)
+
+// Field 一个 tag=value 字段
+type Field struct {
+	Tag   int
+	Value string
+}
+
+// Message FIX 消息，BeginString、BodyLength 和 CheckSum 在编码时生成，其余字段按添加顺序保存
+type Message struct {
+	Fields []Field
+}
+
+// NewMessage 创建指定类型的消息
+func NewMessage(msgType string) *Message {
+	return &Message{Fields: []Field{{Tag: TagMsgType, Value: msgType}}}
+}
+
+// Set 设置字段，已存在时覆盖第一个同名字段
+func (m *Message) Set(tag int, value string) *Message {
+	for i := range m.Fields {
+		if m.Fields[i].Tag == tag {
+			m.Fields[i].Value = value
+			return m
+		}
+	}
+	m.Fields = append(m.Fields, Field{Tag: tag, Value: value})
+	return m
+}
+
+// SetInt 设置整数字段
+func (m *Message) SetInt(tag, value int) *Message {
+	return m.Set(tag, strconv.Itoa(value))
+}
+
+// SetTime 设置 UTCTimestamp 字段
+func (m *Message) SetTime(tag int, t time.Time) *Message {
+	return m.Set(tag, t.UTC().Format(TimestampLayout))
+}
+
+// Get 获取字段值，不存在时为空字符串
+func (m *Message) Get(tag int) string {
+	for _, field := range m.Fields {
+		if field.Tag == tag {
+			return field.Value
+		}
+	}
+	return ""
+}
+
+// Has 是否包含字段
+func (m *Message) Has(tag int) bool {
+	for _, field := range m.Fields {
+		if field.Tag == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// GetInt 获取整数字段，不存在或无效时为0
+func (m *Message) GetInt(tag int) int {
+	value, _ := strconv.Atoi(m.Get(tag))
+	return value
+}
+
+// MsgType 消息类型
+func (m *Message) MsgType() string {
+	return m.Get(TagMsgType)
+}
+
+// Bytes 编码为带 BeginString、BodyLength 和 CheckSum 的完整消息；MsgType 总是排在正文第一位
+func (m *Message) Bytes() []byte {
+	var body bytes.Buffer
+	writeField(&body, TagMsgType, m.MsgType())
+	for _, field := range m.Fields {
+		switch field.Tag {
+		case TagBeginString, TagBodyLength, TagCheckSum, TagMsgType:
+			continue
+		}
+		writeField(&body, field.Tag, field.Value)
+	}
+
+	var out bytes.Buffer
+	writeField(&out, TagBeginString, BeginString)
+	writeField(&out, TagBodyLength, strconv.Itoa(body.Len()))
+	out.Write(body.Bytes())
+	writeField(&out, TagCheckSum, fmt.Sprintf("%03d", checksum(out.Bytes())))
+	return out.Bytes()
+}
+
+// String 以 | 代替分隔符的可读形式，用于日志
+func (m *Message) String() string {
+	return string(bytes.ReplaceAll(m.Bytes(), []byte{SOH}, []byte{'|'}))
+}
+
+// writeField 写入一个字段
+func writeField(buffer *bytes.Buffer, tag int, value string) {
+	buffer.WriteString(strconv.Itoa(tag))
+	buffer.WriteByte('=')
+	buffer.WriteString(value)
+	buffer.WriteByte(SOH)
+}
+
+// checksum 所有字节之和模256
+func checksum(data []byte) int {
+	sum := 0
+	for _, b := range data {
+		sum += int(b)
+	}
+	return sum % 256
+}
+
+// Parse 解析一条完整的消息并校验 BodyLength 和 CheckSum
+func Parse(data []byte) (*Message, error) {
+	if !bytes.HasPrefix(data, []byte("8=")) {
+		return nil, fmt.Errorf("消息应以 BeginString 开头")
+	}
+	trailer := bytes.LastIndex(data[:len(data)-1], []byte{SOH, '1', '0', '='})
+	if trailer < 0 || data[len(data)-1] != SOH {
+		return nil, fmt.Errorf("消息缺少 CheckSum")
+	}
+	expected, err := strconv.Atoi(string(data[trailer+4 : len(data)-1]))
+	if err != nil {
+		return nil, fmt.Errorf("CheckSum 无效: %w", err)
+	}
+	if actual := checksum(data[:trailer+1]); actual != expected {
+		return nil, fmt.Errorf("CheckSum 不匹配: 期望 %03d, 实际 %03d", expected, actual)
+	}
+
+	message := &Message{}
+	bodyStart, bodyLength := 0, -1
+	offset := 0
+	for offset < trailer+1 {
+		end := bytes.IndexByte(data[offset:], SOH)
+		if end < 0 {
+			return nil, fmt.Errorf("字段缺少分隔符")
+		}
+		raw := data[offset : offset+end]
+		separator := bytes.IndexByte(raw, '=')
+		if separator <= 0 {
+			return nil, fmt.Errorf("字段格式无效: %q", raw)
+		}
+		tag, err := strconv.Atoi(string(raw[:separator]))
+		if err != nil {
+			return nil, fmt.Errorf("标签无效: %q", raw[:separator])
+		}
+		value := string(raw[separator+1:])
+		offset += end + 1
+
+		switch tag {
+		case TagBeginString:
+			if value != BeginString {
+				return nil, fmt.Errorf("不支持的协议版本: %s", value)
+			}
+		case TagBodyLength:
+			if bodyLength, err = strconv.Atoi(value); err != nil {
+				return nil, fmt.Errorf("BodyLength 无效: %w", err)
+			}
+			bodyStart = offset
+		default:
+			message.Fields = append(message.Fields, Field{Tag: tag, Value: value})
+		}
+	}
+	if bodyLength >= 0 && trailer+1-bodyStart != bodyLength {
+		return nil, fmt.Errorf("BodyLength 不匹配: 声明 %d, 实际 %d", bodyLength, trailer+1-bodyStart)
+	}
+	if message.MsgType() == "" {
+		return nil, fmt.Errorf("消息缺少 MsgType")
+	}
+	return message, nil
+}
+
+// Reader 从字节流中逐条读取消息
+type Reader struct {
+	reader *bufio.Reader
+}
+
+// NewReader 创建消息读取器
+func NewReader(r io.Reader) *Reader {
+	return &Reader{reader: bufio.NewReader(r)}
+}
+
+// ReadMessage 读取下一条消息：按 BodyLength 读取正文，再读取 CheckSum 字段
+func (r *Reader) ReadMessage() (*Message, error) {
+	begin, err := r.reader.ReadBytes(SOH)
+	if err != nil {
+		return nil, err
+	}
+	length, err := r.reader.ReadBytes(SOH)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(length, []byte("9=")) {
+		return nil, fmt.Errorf("BeginString 之后应为 BodyLength: %q", length)
+	}
+	bodyLength, err := strconv.Atoi(string(length[2 : len(length)-1]))
+	if err != nil || bodyLength < 0 || bodyLength > 1<<20 {
+		return nil, fmt.Errorf("BodyLength 无效: %q", length)
+	}
+	body := make([]byte, bodyLength)
+	if _, err := io.ReadFull(r.reader, body); err != nil {
+		return nil, err
+	}
+	trailer, err := r.reader.ReadBytes(SOH)
+	if err != nil {
+		return nil, err
+	}
+
+	data := make([]byte, 0, len(begin)+len(length)+len(body)+len(trailer))
+	data = append(append(append(append(data, begin...), length...), body...), trailer...)
+	return Parse(data)
+}
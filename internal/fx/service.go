@@ -0,0 +1,203 @@
+package fx
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"agent-quant-system/internal/config"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// RateSource 汇率数据源接口
+type RateSource interface {
+	// Name 数据源名称
+	Name() string
+
+	// GetRate 获取 1 单位 base 兑换 quote 的汇率
+	GetRate(base, quote string) (float64, error)
+}
+
+// StaticRateSource 静态汇率数据源（来自配置文件）
+type StaticRateSource struct {
+	rates map[string]float64
+}
+
+// NewStaticRateSource 创建静态汇率数据源，键格式为 "USD_CNY"
+func NewStaticRateSource(rates map[string]float64) *StaticRateSource {
+	normalized := make(map[string]float64)
+	for pair, rate := range rates {
+		normalized[strings.ToUpper(pair)] = rate
+	}
+	return &StaticRateSource{rates: normalized}
+}
+
+// Name 数据源名称
+func (s *StaticRateSource) Name() string {
+	return "static"
+}
+
+// GetRate 获取汇率，支持反向报价
+func (s *StaticRateSource) GetRate(base, quote string) (float64, error) {
+	if rate, exists := s.rates[pairKey(base, quote)]; exists && rate > 0 {
+		return rate, nil
+	}
+	if rate, exists := s.rates[pairKey(quote, base)]; exists && rate > 0 {
+		return 1 / rate, nil
+	}
+	return 0, fmt.Errorf("未配置汇率: %s/%s", base, quote)
+}
+
+// HTTPRateSource 通过HTTP接口获取汇率
+// 接口需返回 {"rates": {"CNY": 7.2, ...}} 格式，请求参数为 base 和 symbols
+type HTTPRateSource struct {
+	httpClient *resty.Client
+	url        string
+}
+
+// NewHTTPRateSource 创建HTTP汇率数据源
+func NewHTTPRateSource(url string) *HTTPRateSource {
+	client := resty.New()
+	client.SetTimeout(10 * time.Second)
+	client.SetHeader("Accept", "application/json")
+
+	return &HTTPRateSource{
+		httpClient: client,
+		url:        url,
+	}
+}
+
+// Name 数据源名称
+func (h *HTTPRateSource) Name() string {
+	return "http"
+}
+
+// GetRate 获取汇率
+func (h *HTTPRateSource) GetRate(base, quote string) (float64, error) {
+	var result struct {
+		Rates map[string]float64 `json:"rates"`
+	}
+
+	resp, err := h.httpClient.R().
+		SetQueryParam("base", base).
+		SetQueryParam("symbols", quote).
+		SetResult(&result).
+		Get(h.url)
+	if err != nil {
+		return 0, fmt.Errorf("请求汇率失败: %w", err)
+	}
+	if resp.StatusCode() != 200 {
+		return 0, fmt.Errorf("请求汇率失败，状态码: %d", resp.StatusCode())
+	}
+
+	rate, exists := result.Rates[quote]
+	if !exists || rate <= 0 {
+		return 0, fmt.Errorf("汇率响应中缺少 %s/%s", base, quote)
+	}
+	return rate, nil
+}
+
+// cachedRate 缓存的汇率
+type cachedRate struct {
+	rate      float64
+	fetchedAt time.Time
+}
+
+// Service 汇率服务，带缓存，负责将不同币种金额折算为报告币种
+type Service struct {
+	source            RateSource
+	reportingCurrency string
+	cacheTTL          time.Duration
+	cache             map[string]cachedRate
+	mutex             sync.RWMutex
+}
+
+// NewService 创建汇率服务
+func NewService(source RateSource, reportingCurrency string, cacheTTL time.Duration) *Service {
+	if reportingCurrency == "" {
+		reportingCurrency = "USD"
+	}
+
+	return &Service{
+		source:            source,
+		reportingCurrency: strings.ToUpper(reportingCurrency),
+		cacheTTL:          cacheTTL,
+		cache:             make(map[string]cachedRate),
+	}
+}
+
+// NewServiceFromConfig 根据配置创建汇率服务
+func NewServiceFromConfig(cfg config.FXConfig) *Service {
+	var source RateSource
+	switch cfg.Source {
+	case "http":
+		source = NewHTTPRateSource(cfg.URL)
+	default:
+		source = NewStaticRateSource(cfg.Rates)
+	}
+
+	log.Printf("初始化汇率服务: 数据源=%s, 报告币种=%s", source.Name(), cfg.ReportingCurrency)
+	return NewService(source, cfg.ReportingCurrency, cfg.CacheTTL)
+}
+
+// ReportingCurrency 获取报告币种
+func (s *Service) ReportingCurrency() string {
+	return s.reportingCurrency
+}
+
+// GetRate 获取汇率（优先使用缓存）
+func (s *Service) GetRate(base, quote string) (float64, error) {
+	base = strings.ToUpper(base)
+	quote = strings.ToUpper(quote)
+	if base == quote || base == "" || quote == "" {
+		return 1, nil
+	}
+
+	key := pairKey(base, quote)
+
+	s.mutex.RLock()
+	cached, exists := s.cache[key]
+	s.mutex.RUnlock()
+
+	if exists && (s.cacheTTL <= 0 || time.Since(cached.fetchedAt) < s.cacheTTL) {
+		return cached.rate, nil
+	}
+
+	rate, err := s.source.GetRate(base, quote)
+	if err != nil {
+		if exists {
+			// 数据源不可用时使用过期缓存
+			log.Printf("获取汇率失败，使用缓存值: %s=%.6f, 错误=%v", key, cached.rate, err)
+			return cached.rate, nil
+		}
+		return 0, err
+	}
+
+	s.mutex.Lock()
+	s.cache[key] = cachedRate{rate: rate, fetchedAt: time.Now()}
+	s.mutex.Unlock()
+
+	return rate, nil
+}
+
+// Convert 将金额从 from 币种折算为 to 币种
+func (s *Service) Convert(amount float64, from, to string) (float64, error) {
+	rate, err := s.GetRate(from, to)
+	if err != nil {
+		return 0, err
+	}
+	return amount * rate, nil
+}
+
+// ToReporting 将金额折算为报告币种
+func (s *Service) ToReporting(amount float64, currency string) (float64, error) {
+	return s.Convert(amount, currency, s.reportingCurrency)
+}
+
+// pairKey 货币对键
+func pairKey(base, quote string) string {
+	return strings.ToUpper(base) + "_" + strings.ToUpper(quote)
+}
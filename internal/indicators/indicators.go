@@ -0,0 +1,375 @@
+package indicators
+
+import (
+	"fmt"
+	"math"
+
+	"agent-quant-system/internal/data"
+)
+
+// 本包中的指标函数只返回有效区间的结果：
+// 输入长度为 n、周期为 p 时，结果与输入的末尾对齐，长度通常为 n-p+1。
+
+// Float64Column 从DataFrame中取出数值列并转换为float64切片
+func Float64Column(df data.DataFrame, column string) ([]float64, error) {
+	values, exists := df[column]
+	if !exists {
+		return nil, fmt.Errorf("缺少必需的列: %s", column)
+	}
+
+	result := make([]float64, len(values))
+	for i, value := range values {
+		switch v := value.(type) {
+		case float64:
+			result[i] = v
+		case float32:
+			result[i] = float64(v)
+		case int64:
+			result[i] = float64(v)
+		case int:
+			result[i] = float64(v)
+		default:
+			return nil, fmt.Errorf("列 '%s' 第 %d 行不是数值类型: %T", column, i, value)
+		}
+	}
+
+	return result, nil
+}
+
+// checkLength 检查数据长度是否满足周期要求
+func checkLength(length, period int) error {
+	if period <= 0 {
+		return fmt.Errorf("周期必须大于0")
+	}
+	if length < period {
+		return fmt.Errorf("数据长度不足: 需要 %d, 实际 %d", period, length)
+	}
+	return nil
+}
+
+// SMA 简单移动平均
+func SMA(values []float64, period int) ([]float64, error) {
+	if err := checkLength(len(values), period); err != nil {
+		return nil, err
+	}
+
+	result := make([]float64, 0, len(values)-period+1)
+	sum := 0.0
+	for i, value := range values {
+		sum += value
+		if i >= period {
+			sum -= values[i-period]
+		}
+		if i >= period-1 {
+			result = append(result, sum/float64(period))
+		}
+	}
+
+	return result, nil
+}
+
+// EMA 指数移动平均，以前 period 个数据的简单平均作为初始值
+func EMA(values []float64, period int) ([]float64, error) {
+	if err := checkLength(len(values), period); err != nil {
+		return nil, err
+	}
+
+	alpha := 2.0 / float64(period+1)
+	result := make([]float64, 0, len(values)-period+1)
+
+	seed := 0.0
+	for _, value := range values[:period] {
+		seed += value
+	}
+	ema := seed / float64(period)
+	result = append(result, ema)
+
+	for _, value := range values[period:] {
+		ema = alpha*value + (1-alpha)*ema
+		result = append(result, ema)
+	}
+
+	return result, nil
+}
+
+// RSI 相对强弱指数（周期内涨跌幅的简单平均），结果长度为 n-period
+func RSI(values []float64, period int) ([]float64, error) {
+	if err := checkLength(len(values), period+1); err != nil {
+		return nil, err
+	}
+
+	gains := make([]float64, len(values)-1)
+	losses := make([]float64, len(values)-1)
+	for i := 1; i < len(values); i++ {
+		change := values[i] - values[i-1]
+		if change > 0 {
+			gains[i-1] = change
+		} else {
+			losses[i-1] = -change
+		}
+	}
+
+	avgGains, _ := SMA(gains, period)
+	avgLosses, _ := SMA(losses, period)
+
+	result := make([]float64, len(avgGains))
+	for i := range avgGains {
+		result[i] = rsiValue(avgGains[i], avgLosses[i])
+	}
+
+	return result, nil
+}
+
+// rsiValue 由平均涨幅和平均跌幅计算RSI
+func rsiValue(avgGain, avgLoss float64) float64 {
+	if avgLoss == 0 {
+		return 100
+	}
+	rs := avgGain / avgLoss
+	return 100 - (100 / (1 + rs))
+}
+
+// MACDResult MACD计算结果，三条序列等长并与输入末尾对齐
+type MACDResult struct {
+	MACD      []float64
+	Signal    []float64
+	Histogram []float64
+}
+
+// MACD 指数平滑异同移动平均线
+func MACD(values []float64, fastPeriod, slowPeriod, signalPeriod int) (*MACDResult, error) {
+	if fastPeriod >= slowPeriod {
+		return nil, fmt.Errorf("快线周期 (%d) 必须小于慢线周期 (%d)", fastPeriod, slowPeriod)
+	}
+	if err := checkLength(len(values), slowPeriod+signalPeriod-1); err != nil {
+		return nil, err
+	}
+
+	fast, err := EMA(values, fastPeriod)
+	if err != nil {
+		return nil, err
+	}
+	slow, err := EMA(values, slowPeriod)
+	if err != nil {
+		return nil, err
+	}
+
+	// 对齐快慢线
+	fast = fast[len(fast)-len(slow):]
+	macdLine := make([]float64, len(slow))
+	for i := range slow {
+		macdLine[i] = fast[i] - slow[i]
+	}
+
+	signalLine, err := EMA(macdLine, signalPeriod)
+	if err != nil {
+		return nil, err
+	}
+
+	macdLine = macdLine[len(macdLine)-len(signalLine):]
+	histogram := make([]float64, len(signalLine))
+	for i := range signalLine {
+		histogram[i] = macdLine[i] - signalLine[i]
+	}
+
+	return &MACDResult{MACD: macdLine, Signal: signalLine, Histogram: histogram}, nil
+}
+
+// TrueRange 真实波幅序列，长度为 n-1
+func TrueRange(high, low, close []float64) ([]float64, error) {
+	if len(high) != len(low) || len(low) != len(close) {
+		return nil, fmt.Errorf("最高价、最低价、收盘价长度不一致")
+	}
+	if err := checkLength(len(close), 2); err != nil {
+		return nil, err
+	}
+
+	result := make([]float64, len(close)-1)
+	for i := 1; i < len(close); i++ {
+		result[i-1] = math.Max(high[i]-low[i],
+			math.Max(math.Abs(high[i]-close[i-1]), math.Abs(low[i]-close[i-1])))
+	}
+	return result, nil
+}
+
+// ATR 平均真实波幅（Wilder平滑），结果长度为 n-period
+func ATR(high, low, close []float64, period int) ([]float64, error) {
+	trueRanges, err := TrueRange(high, low, close)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkLength(len(trueRanges), period); err != nil {
+		return nil, err
+	}
+
+	result := make([]float64, 0, len(trueRanges)-period+1)
+	atr := 0.0
+	for _, tr := range trueRanges[:period] {
+		atr += tr
+	}
+	atr /= float64(period)
+	result = append(result, atr)
+
+	for _, tr := range trueRanges[period:] {
+		atr = (atr*float64(period-1) + tr) / float64(period)
+		result = append(result, atr)
+	}
+
+	return result, nil
+}
+
+// BollingerResult 布林带计算结果
+type BollingerResult struct {
+	Middle []float64
+	Upper  []float64
+	Lower  []float64
+}
+
+// Bollinger 布林带，multiplier 为标准差倍数
+func Bollinger(values []float64, period int, multiplier float64) (*BollingerResult, error) {
+	middle, err := SMA(values, period)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &BollingerResult{
+		Middle: middle,
+		Upper:  make([]float64, len(middle)),
+		Lower:  make([]float64, len(middle)),
+	}
+
+	for i, mean := range middle {
+		window := values[i : i+period]
+		variance := 0.0
+		for _, value := range window {
+			variance += (value - mean) * (value - mean)
+		}
+		std := math.Sqrt(variance / float64(period))
+		result.Upper[i] = mean + multiplier*std
+		result.Lower[i] = mean - multiplier*std
+	}
+
+	return result, nil
+}
+
+// DonchianResult 唐奇安通道计算结果
+type DonchianResult struct {
+	Upper  []float64
+	Lower  []float64
+	Middle []float64
+}
+
+// Donchian 唐奇安通道：最近 period 根K线的最高价和最低价，结果长度为 n-period+1
+func Donchian(high, low []float64, period int) (*DonchianResult, error) {
+	if len(high) != len(low) {
+		return nil, fmt.Errorf("最高价、最低价长度不一致")
+	}
+	if err := checkLength(len(high), period); err != nil {
+		return nil, err
+	}
+
+	n := len(high) - period + 1
+	result := &DonchianResult{
+		Upper:  make([]float64, n),
+		Lower:  make([]float64, n),
+		Middle: make([]float64, n),
+	}
+	for i := 0; i < n; i++ {
+		upper, lower := high[i], low[i]
+		for j := i + 1; j < i+period; j++ {
+			upper = math.Max(upper, high[j])
+			lower = math.Min(lower, low[j])
+		}
+		result.Upper[i] = upper
+		result.Lower[i] = lower
+		result.Middle[i] = (upper + lower) / 2
+	}
+	return result, nil
+}
+
+// StdDev 滚动标准差（总体标准差）
+func StdDev(values []float64, period int) ([]float64, error) {
+	bands, err := Bollinger(values, period, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]float64, len(bands.Middle))
+	for i := range bands.Middle {
+		result[i] = bands.Upper[i] - bands.Middle[i]
+	}
+	return result, nil
+}
+
+// VWAP 累计成交量加权平均价，结果与输入等长
+func VWAP(high, low, close, volume []float64) ([]float64, error) {
+	if len(high) != len(low) || len(low) != len(close) || len(close) != len(volume) {
+		return nil, fmt.Errorf("价格与成交量长度不一致")
+	}
+	if len(close) == 0 {
+		return nil, fmt.Errorf("数据为空")
+	}
+
+	result := make([]float64, len(close))
+	cumulativePV := 0.0
+	cumulativeVolume := 0.0
+	for i := range close {
+		typical := (high[i] + low[i] + close[i]) / 3
+		cumulativePV += typical * volume[i]
+		cumulativeVolume += volume[i]
+		if cumulativeVolume > 0 {
+			result[i] = cumulativePV / cumulativeVolume
+		} else {
+			result[i] = typical
+		}
+	}
+
+	return result, nil
+}
+
+// StochasticResult 随机指标计算结果，K 与 D 等长并与输入末尾对齐
+type StochasticResult struct {
+	K []float64
+	D []float64
+}
+
+// Stochastic 随机指标（KD），kPeriod 为观察周期，dPeriod 为 %K 的平滑周期
+func Stochastic(high, low, close []float64, kPeriod, dPeriod int) (*StochasticResult, error) {
+	if len(high) != len(low) || len(low) != len(close) {
+		return nil, fmt.Errorf("最高价、最低价、收盘价长度不一致")
+	}
+	if err := checkLength(len(close), kPeriod+dPeriod-1); err != nil {
+		return nil, err
+	}
+
+	k := make([]float64, 0, len(close)-kPeriod+1)
+	for i := kPeriod - 1; i < len(close); i++ {
+		highest := high[i]
+		lowest := low[i]
+		for j := i - kPeriod + 1; j <= i; j++ {
+			highest = math.Max(highest, high[j])
+			lowest = math.Min(lowest, low[j])
+		}
+
+		if highest == lowest {
+			k = append(k, 50)
+		} else {
+			k = append(k, (close[i]-lowest)/(highest-lowest)*100)
+		}
+	}
+
+	d, err := SMA(k, dPeriod)
+	if err != nil {
+		return nil, err
+	}
+
+	return &StochasticResult{K: k[len(k)-len(d):], D: d}, nil
+}
+
+// Last 获取序列最后一个值
+func Last(values []float64) float64 {
+	if len(values) == 0 {
+		return math.NaN()
+	}
+	return values[len(values)-1]
+}
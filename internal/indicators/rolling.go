@@ -0,0 +1,264 @@
+package indicators
+
+import "math"
+
+// 增量指标：每次 Update 传入一个新数据，O(1) 更新结果，
+// 适用于事件驱动回测和流式行情，避免每根K线重新计算整个窗口。
+// Update 的第二个返回值表示预热是否完成（数据量是否已达到周期）。
+
+// RollingSMA 增量简单移动平均
+type RollingSMA struct {
+	period int
+	window []float64
+	next   int
+	count  int
+	sum    float64
+}
+
+// NewRollingSMA 创建增量简单移动平均
+func NewRollingSMA(period int) *RollingSMA {
+	if period <= 0 {
+		period = 1
+	}
+	return &RollingSMA{
+		period: period,
+		window: make([]float64, period),
+	}
+}
+
+// Update 加入新数据
+func (r *RollingSMA) Update(value float64) (float64, bool) {
+	if r.count == r.period {
+		r.sum -= r.window[r.next]
+	} else {
+		r.count++
+	}
+
+	r.window[r.next] = value
+	r.sum += value
+	r.next = (r.next + 1) % r.period
+
+	return r.Value(), r.Ready()
+}
+
+// Value 当前值
+func (r *RollingSMA) Value() float64 {
+	if r.count == 0 {
+		return math.NaN()
+	}
+	return r.sum / float64(r.count)
+}
+
+// Ready 预热是否完成
+func (r *RollingSMA) Ready() bool {
+	return r.count == r.period
+}
+
+// RollingEMA 增量指数移动平均，预热期内使用简单平均作为初始值
+type RollingEMA struct {
+	period int
+	alpha  float64
+	count  int
+	sum    float64
+	value  float64
+}
+
+// NewRollingEMA 创建增量指数移动平均
+func NewRollingEMA(period int) *RollingEMA {
+	if period <= 0 {
+		period = 1
+	}
+	return &RollingEMA{
+		period: period,
+		alpha:  2.0 / float64(period+1),
+		value:  math.NaN(),
+	}
+}
+
+// Update 加入新数据
+func (r *RollingEMA) Update(value float64) (float64, bool) {
+	if r.count < r.period {
+		r.count++
+		r.sum += value
+		r.value = r.sum / float64(r.count)
+	} else {
+		r.value = r.alpha*value + (1-r.alpha)*r.value
+	}
+	return r.value, r.Ready()
+}
+
+// Value 当前值
+func (r *RollingEMA) Value() float64 {
+	return r.value
+}
+
+// Ready 预热是否完成
+func (r *RollingEMA) Ready() bool {
+	return r.count >= r.period
+}
+
+// RollingRSI 增量相对强弱指数，与 RSI 一致使用周期内涨跌幅的简单平均
+type RollingRSI struct {
+	gains   *RollingSMA
+	losses  *RollingSMA
+	last    float64
+	started bool
+}
+
+// NewRollingRSI 创建增量RSI
+func NewRollingRSI(period int) *RollingRSI {
+	return &RollingRSI{
+		gains:  NewRollingSMA(period),
+		losses: NewRollingSMA(period),
+	}
+}
+
+// Update 加入新数据
+func (r *RollingRSI) Update(value float64) (float64, bool) {
+	if !r.started {
+		r.started = true
+		r.last = value
+		return math.NaN(), false
+	}
+
+	change := value - r.last
+	r.last = value
+
+	gain, loss := 0.0, 0.0
+	if change > 0 {
+		gain = change
+	} else {
+		loss = -change
+	}
+	r.gains.Update(gain)
+	r.losses.Update(loss)
+
+	return r.Value(), r.Ready()
+}
+
+// Value 当前值
+func (r *RollingRSI) Value() float64 {
+	if !r.Ready() {
+		return math.NaN()
+	}
+	return rsiValue(r.gains.Value(), r.losses.Value())
+}
+
+// Ready 预热是否完成
+func (r *RollingRSI) Ready() bool {
+	return r.gains.Ready()
+}
+
+// RollingATR 增量平均真实波幅（Wilder平滑）
+type RollingATR struct {
+	period    int
+	count     int
+	sum       float64
+	value     float64
+	prevClose float64
+	started   bool
+}
+
+// NewRollingATR 创建增量ATR
+func NewRollingATR(period int) *RollingATR {
+	if period <= 0 {
+		period = 1
+	}
+	return &RollingATR{period: period, value: math.NaN()}
+}
+
+// Update 加入新K线
+func (r *RollingATR) Update(high, low, close float64) (float64, bool) {
+	if !r.started {
+		r.started = true
+		r.prevClose = close
+		return math.NaN(), false
+	}
+
+	tr := math.Max(high-low, math.Max(math.Abs(high-r.prevClose), math.Abs(low-r.prevClose)))
+	r.prevClose = close
+
+	if r.count < r.period {
+		r.count++
+		r.sum += tr
+		if r.count == r.period {
+			r.value = r.sum / float64(r.period)
+		}
+	} else {
+		r.value = (r.value*float64(r.period-1) + tr) / float64(r.period)
+	}
+
+	return r.value, r.Ready()
+}
+
+// Value 当前值
+func (r *RollingATR) Value() float64 {
+	return r.value
+}
+
+// Ready 预热是否完成
+func (r *RollingATR) Ready() bool {
+	return r.count >= r.period
+}
+
+// RollingDonchian 增量唐奇安通道：最近 period 根K线的最高价和最低价，用单调队列维护，均摊 O(1)
+type RollingDonchian struct {
+	period int
+	count  int
+	highs  []indexedValue // 下标递增、数值递减
+	lows   []indexedValue // 下标递增、数值递增
+}
+
+// indexedValue 单调队列中的数据及其序号
+type indexedValue struct {
+	index int
+	value float64
+}
+
+// NewRollingDonchian 创建增量唐奇安通道
+func NewRollingDonchian(period int) *RollingDonchian {
+	if period <= 0 {
+		period = 1
+	}
+	return &RollingDonchian{period: period}
+}
+
+// Update 加入新K线的最高价和最低价，返回上轨和下轨
+func (r *RollingDonchian) Update(high, low float64) (float64, float64, bool) {
+	index := r.count
+	r.count++
+
+	for len(r.highs) > 0 && r.highs[len(r.highs)-1].value <= high {
+		r.highs = r.highs[:len(r.highs)-1]
+	}
+	r.highs = append(r.highs, indexedValue{index, high})
+	for len(r.lows) > 0 && r.lows[len(r.lows)-1].value >= low {
+		r.lows = r.lows[:len(r.lows)-1]
+	}
+	r.lows = append(r.lows, indexedValue{index, low})
+
+	// 移出窗口之外的数据
+	oldest := index - r.period + 1
+	for r.highs[0].index < oldest {
+		r.highs = r.highs[1:]
+	}
+	for r.lows[0].index < oldest {
+		r.lows = r.lows[1:]
+	}
+
+	upper, lower := r.Value()
+	return upper, lower, r.Ready()
+}
+
+// Value 当前的上轨和下轨
+func (r *RollingDonchian) Value() (float64, float64) {
+	if r.count == 0 {
+		return math.NaN(), math.NaN()
+	}
+	return r.highs[0].value, r.lows[0].value
+}
+
+// Ready 预热是否完成
+func (r *RollingDonchian) Ready() bool {
+	return r.count >= r.period
+}
@@ -0,0 +1,294 @@
+package instrument
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"agent-quant-system/internal/config"
+	"agent-quant-system/internal/money"
+
+	"github.com/shopspring/decimal"
+)
+
+// ContinuousSuffix 连续合约代码的后缀，如 ES=F
+const ContinuousSuffix = "=F"
+
+// monthCodes 期货月份代码，依次对应 1-12 月
+const monthCodes = "FGHJKMNQUVXZ"
+
+// Type 品种类型
+type Type string
+
+const (
+	Spot   Type = "spot"   // 股票、加密货币等现货，乘数为1
+	Future Type = "future" // 期货合约
+	Option Type = "option" // 期权合约
+)
+
+// Spec 期货品种规格
+type Spec struct {
+	Root       string
+	Multiplier decimal.Decimal
+	TickSize   decimal.Decimal
+	Months     []time.Month // 上市月份，升序
+	ExpiryRule string
+	ExpiryDay  int
+	RollDays   int
+	Adjust     string // 连续合约换月的价格调整: difference / ratio / none
+}
+
+// Contract 期货的一个月份合约
+type Contract struct {
+	Spec   *Spec
+	Year   int
+	Month  time.Month
+	Expiry time.Time // 到期日（UTC 零点）
+}
+
+// Symbol 合约代码：品种 + 月份代码 + 两位年份，如 ESZ26
+func (c Contract) Symbol() string {
+	return fmt.Sprintf("%s%c%02d", c.Spec.Root, monthCodes[c.Month-1], c.Year%100)
+}
+
+// RollDate 移仓日：到期日前 RollDays 个自然日，从该日起下一合约成为主力合约
+func (c Contract) RollDate() time.Time {
+	return c.Expiry.AddDate(0, 0, -c.Spec.RollDays)
+}
+
+// Contract 指定年月的合约，月份不在上市月份中时仍按规则计算到期日
+func (s *Spec) Contract(year int, month time.Month) Contract {
+	return Contract{Spec: s, Year: year, Month: month, Expiry: s.expiry(year, month)}
+}
+
+// expiry 按到期日规则计算合约到期日
+func (s *Spec) expiry(year int, month time.Month) time.Time {
+	first := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	switch s.ExpiryRule {
+	case "last_business_day":
+		return previousWeekday(first.AddDate(0, 1, -1))
+	case "fixed_day":
+		return previousWeekday(time.Date(year, month, s.ExpiryDay, 0, 0, 0, 0, time.UTC))
+	default:
+		// 第三个星期五
+		offset := (int(time.Friday) - int(first.Weekday()) + 7) % 7
+		return first.AddDate(0, 0, offset+14)
+	}
+}
+
+// previousWeekday 遇周末提前到前一个工作日
+func previousWeekday(day time.Time) time.Time {
+	for day.Weekday() == time.Saturday || day.Weekday() == time.Sunday {
+		day = day.AddDate(0, 0, -1)
+	}
+	return day
+}
+
+// Next 下一个上市月份的合约
+func (s *Spec) Next(c Contract) Contract {
+	for _, month := range s.Months {
+		if month > c.Month {
+			return s.Contract(c.Year, month)
+		}
+	}
+	return s.Contract(c.Year+1, s.Months[0])
+}
+
+// Active 时间 t 的主力合约：尚未到达移仓日的最近月份合约
+func (s *Spec) Active(t time.Time) Contract {
+	contract := s.Contract(t.Year()+1, s.Months[0])
+	for _, month := range s.Months {
+		if month >= t.Month() {
+			contract = s.Contract(t.Year(), month)
+			break
+		}
+	}
+	for !t.Before(contract.RollDate()) {
+		contract = s.Next(contract)
+	}
+	return contract
+}
+
+// Contracts [start, end) 区间内依次作为主力合约的合约
+func (s *Spec) Contracts(start, end time.Time) []Contract {
+	contracts := []Contract{s.Active(start)}
+	for {
+		last := contracts[len(contracts)-1]
+		if !last.RollDate().Before(end) {
+			return contracts
+		}
+		contracts = append(contracts, s.Next(last))
+	}
+}
+
+// Continuous 连续合约代码
+func (s *Spec) Continuous() string {
+	return s.Root + ContinuousSuffix
+}
+
+// Instrument 标的的交易规格
+type Instrument struct {
+	Symbol     string
+	Root       string // 期货品种，现货为空
+	Type       Type
+	Multiplier decimal.Decimal
+	TickSize   decimal.Decimal // 0表示不限制
+	Expiry     time.Time       // 期货、期权合约的到期日，连续合约和现货为零值
+	Continuous bool            // 是否为连续合约
+
+	// 期权的标的、行权价和类型，其他品种为零值
+	Underlying string
+	Strike     decimal.Decimal
+	Right      Right
+}
+
+// RoundPrice 将价格取整到最小价格变动的整数倍
+func (i Instrument) RoundPrice(price decimal.Decimal) decimal.Decimal {
+	if !i.TickSize.IsPositive() {
+		return price
+	}
+	return price.Div(i.TickSize).Round(0).Mul(i.TickSize)
+}
+
+// Expired 合约在时间 t 是否已到期（到期日当天仍可交易）
+func (i Instrument) Expired(t time.Time) bool {
+	return !i.Expiry.IsZero() && !t.Before(i.Expiry.AddDate(0, 0, 1))
+}
+
+// SpotInstrument 未配置规格的标的按现货处理：乘数为1，不限制最小价格变动
+func SpotInstrument(symbol string) Instrument {
+	return Instrument{Symbol: symbol, Type: Spot, Multiplier: decimal.NewFromInt(1)}
+}
+
+// Registry 合约规格表，按品种代码查找期货规格，按 OCC 代码识别期权
+type Registry struct {
+	specs map[string]*Spec
+
+	optionMultiplier decimal.Decimal
+	optionTickSize   decimal.Decimal
+}
+
+// NewRegistry 按配置创建合约规格表
+func NewRegistry(cfg config.InstrumentsConfig) (*Registry, error) {
+	registry := &Registry{
+		specs:            make(map[string]*Spec, len(cfg.Futures)),
+		optionMultiplier: money.FromFloat(cfg.Options.Multiplier),
+		optionTickSize:   money.FromFloat(cfg.Options.TickSize),
+	}
+	for root, specConfig := range cfg.Futures {
+		if err := specConfig.Validate(); err != nil {
+			return nil, fmt.Errorf("期货品种 %s 的配置无效: %w", root, err)
+		}
+		spec := &Spec{
+			Root:       strings.ToUpper(root),
+			Multiplier: money.FromFloat(specConfig.Multiplier),
+			TickSize:   money.FromFloat(specConfig.TickSize),
+			ExpiryRule: specConfig.ExpiryRule,
+			ExpiryDay:  specConfig.ExpiryDay,
+			RollDays:   specConfig.RollDays,
+			Adjust:     specConfig.Adjust,
+		}
+		if spec.Adjust == "" {
+			spec.Adjust = "difference"
+		}
+		months := strings.ToUpper(specConfig.Months)
+		if months == "" {
+			months = monthCodes
+		}
+		for _, code := range months {
+			spec.Months = append(spec.Months, time.Month(strings.IndexRune(monthCodes, code)+1))
+		}
+		sort.Slice(spec.Months, func(a, b int) bool { return spec.Months[a] < spec.Months[b] })
+		registry.specs[spec.Root] = spec
+	}
+	return registry, nil
+}
+
+// Spec 品种规格
+func (r *Registry) Spec(root string) (*Spec, bool) {
+	if r == nil {
+		return nil, false
+	}
+	spec, ok := r.specs[strings.ToUpper(root)]
+	return spec, ok
+}
+
+// ContinuousSpec 连续合约代码对应的品种规格
+func (r *Registry) ContinuousSpec(symbol string) (*Spec, bool) {
+	root, ok := strings.CutSuffix(strings.ToUpper(symbol), ContinuousSuffix)
+	if !ok {
+		return nil, false
+	}
+	return r.Spec(root)
+}
+
+// Contract 解析具体合约代码（如 ESZ26），不是已配置品种的合约时返回 false
+func (r *Registry) Contract(symbol string) (Contract, bool) {
+	symbol = strings.ToUpper(symbol)
+	if len(symbol) < 4 {
+		return Contract{}, false
+	}
+	root, code, digits := symbol[:len(symbol)-3], symbol[len(symbol)-3], symbol[len(symbol)-2:]
+	spec, ok := r.Spec(root)
+	if !ok {
+		return Contract{}, false
+	}
+	month := strings.IndexByte(monthCodes, code)
+	year, err := strconv.Atoi(digits)
+	if month < 0 || err != nil {
+		return Contract{}, false
+	}
+	return spec.Contract(2000+year, time.Month(month+1)), true
+}
+
+// Lookup 标的的交易规格，未配置的标的按现货处理
+func (r *Registry) Lookup(symbol string) Instrument {
+	if spec, ok := r.ContinuousSpec(symbol); ok {
+		return Instrument{Symbol: spec.Continuous(), Root: spec.Root, Type: Future,
+			Multiplier: spec.Multiplier, TickSize: spec.TickSize, Continuous: true}
+	}
+	if contract, ok := r.Contract(symbol); ok {
+		return Instrument{Symbol: contract.Symbol(), Root: contract.Spec.Root, Type: Future,
+			Multiplier: contract.Spec.Multiplier, TickSize: contract.Spec.TickSize, Expiry: contract.Expiry}
+	}
+	if option, ok := ParseOption(symbol); ok {
+		return r.optionInstrument(option)
+	}
+	return SpotInstrument(symbol)
+}
+
+// optionInstrument 期权合约的交易规格，未设置规格表时按每张100单位标的
+func (r *Registry) optionInstrument(option OptionContract) Instrument {
+	inst := Instrument{
+		Symbol:     option.Symbol(),
+		Type:       Option,
+		Multiplier: decimal.NewFromInt(100),
+		Expiry:     option.Expiry,
+		Underlying: option.Underlying,
+		Strike:     option.Strike,
+		Right:      option.Right,
+	}
+	if r != nil && r.optionMultiplier.IsPositive() {
+		inst.Multiplier = r.optionMultiplier
+		inst.TickSize = r.optionTickSize
+	}
+	return inst
+}
+
+// Option 期权合约的规格，不是期权代码时返回 false
+func (i Instrument) Option() (OptionContract, bool) {
+	if i.Type != Option {
+		return OptionContract{}, false
+	}
+	return OptionContract{Underlying: i.Underlying, Expiry: i.Expiry, Strike: i.Strike, Right: i.Right}, true
+}
+
+// Resolve 将连续合约代码映射为时间 t 的主力合约代码，其他标的原样返回
+func (r *Registry) Resolve(symbol string, t time.Time) string {
+	if spec, ok := r.ContinuousSpec(symbol); ok {
+		return spec.Active(t).Symbol()
+	}
+	return symbol
+}
@@ -0,0 +1,120 @@
+package instrument
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Right 期权类型
+type Right string
+
+const (
+	Call Right = "C" // 看涨期权
+	Put  Right = "P" // 看跌期权
+)
+
+// optionSymbolPattern OCC 期权代码：标的 + 到期日 YYMMDD + C/P + 行权价*1000（8位）
+var optionSymbolPattern = regexp.MustCompile(`^([A-Z.]{1,6})(\d{6})([CP])(\d{8})$`)
+
+// OptionContract 期权合约
+type OptionContract struct {
+	Underlying string
+	Expiry     time.Time // 到期日（UTC 零点），到期日当天仍可交易
+	Strike     decimal.Decimal
+	Right      Right
+}
+
+// Symbol OCC 期权代码，如 AAPL261218C00150000
+func (c OptionContract) Symbol() string {
+	strike := c.Strike.Mul(decimal.NewFromInt(1000)).Round(0).IntPart()
+	return fmt.Sprintf("%s%s%s%08d", c.Underlying, c.Expiry.Format("060102"), c.Right, strike)
+}
+
+// Intrinsic 标的价格为 underlying 时每单位标的的内在价值
+func (c OptionContract) Intrinsic(underlying decimal.Decimal) decimal.Decimal {
+	value := underlying.Sub(c.Strike)
+	if c.Right == Put {
+		value = value.Neg()
+	}
+	return decimal.Max(value, decimal.Zero)
+}
+
+// ParseOption 解析 OCC 期权代码，不是期权代码时返回 false
+func ParseOption(symbol string) (OptionContract, bool) {
+	match := optionSymbolPattern.FindStringSubmatch(symbol)
+	if match == nil {
+		return OptionContract{}, false
+	}
+	expiry, err := time.Parse("060102", match[2])
+	if err != nil {
+		return OptionContract{}, false
+	}
+	strike, err := strconv.ParseInt(match[4], 10, 64)
+	if err != nil {
+		return OptionContract{}, false
+	}
+	return OptionContract{
+		Underlying: match[1],
+		Expiry:     expiry,
+		Strike:     decimal.New(strike, -3),
+		Right:      Right(match[3]),
+	}, true
+}
+
+// MonthlyExpiries 从 t 起的 count 个月度期权到期日（每月第三个星期五），当月到期日已过时从下月开始
+func MonthlyExpiries(t time.Time, count int) []time.Time {
+	spec := &Spec{}
+	year, month := t.Year(), t.Month()
+	var expiries []time.Time
+	for len(expiries) < count {
+		expiry := spec.expiry(year, month)
+		if !expiry.Before(time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)) {
+			expiries = append(expiries, expiry)
+		}
+		if month == time.December {
+			year, month = year+1, time.January
+		} else {
+			month++
+		}
+	}
+	return expiries
+}
+
+// BlackScholes 按 Black-Scholes 模型计算欧式期权每单位标的的理论价格和 Delta，years 为距到期的年数
+func BlackScholes(right Right, spot, strike, years, rate, volatility float64) (price, delta float64) {
+	if spot <= 0 || strike <= 0 {
+		return 0, 0
+	}
+	if years <= 0 || volatility <= 0 {
+		// 到期或无波动时只有内在价值
+		if right == Put {
+			if strike > spot {
+				return strike - spot, -1
+			}
+			return 0, 0
+		}
+		if spot > strike {
+			return spot - strike, 1
+		}
+		return 0, 0
+	}
+
+	sqrtT := math.Sqrt(years)
+	d1 := (math.Log(spot/strike) + (rate+volatility*volatility/2)*years) / (volatility * sqrtT)
+	d2 := d1 - volatility*sqrtT
+	discount := math.Exp(-rate * years)
+	if right == Put {
+		return strike*discount*normalCDF(-d2) - spot*normalCDF(-d1), normalCDF(d1) - 1
+	}
+	return spot*normalCDF(d1) - strike*discount*normalCDF(d2), normalCDF(d1)
+}
+
+// normalCDF 标准正态分布的累积分布函数
+func normalCDF(x float64) float64 {
+	return 0.5 * math.Erfc(-x/math.Sqrt2)
+}
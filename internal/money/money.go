@@ -0,0 +1,99 @@
+package money
+
+import (
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+func init() {
+	// JSON 中以数字而不是字符串输出，保持与原有接口兼容
+	decimal.MarshalJSONWithoutQuotes = true
+}
+
+// Zero 零值
+var Zero = decimal.Zero
+
+// FromFloat 由浮点数创建（用于策略信号、配置等浮点数据进入账务计算的边界）
+func FromFloat(value float64) decimal.Decimal {
+	return decimal.NewFromFloat(value)
+}
+
+// Float 转换为浮点数（用于统计指标和日志展示）
+func Float(value decimal.Decimal) float64 {
+	return value.InexactFloat64()
+}
+
+// Precision 价格、数量和金额的小数位数
+type Precision struct {
+	Price    int32 `json:"price"`
+	Quantity int32 `json:"quantity"`
+	Amount   int32 `json:"amount"`
+}
+
+// RoundPrice 价格按精度四舍五入
+func (p Precision) RoundPrice(value decimal.Decimal) decimal.Decimal {
+	return value.Round(p.Price)
+}
+
+// RoundQuantity 数量按精度向下截断，避免超出可用资金或持仓
+func (p Precision) RoundQuantity(value decimal.Decimal) decimal.Decimal {
+	return value.Truncate(p.Quantity)
+}
+
+// RoundAmount 金额（余额、手续费、盈亏）按精度四舍五入
+func (p Precision) RoundAmount(value decimal.Decimal) decimal.Decimal {
+	return value.Round(p.Amount)
+}
+
+// 常见经纪商类型的默认精度
+var (
+	StockPrecision  = Precision{Price: 2, Quantity: 0, Amount: 2} // 按整股交易，支持碎股的账户在 precision.quantity 中设置小数位
+	CryptoPrecision = Precision{Price: 8, Quantity: 8, Amount: 8}
+	CNPrecision     = Precision{Price: 2, Quantity: 0, Amount: 2} // A 股和国内期货按整股、整手交易
+)
+
+// DefaultPrecisionFor 获取经纪商类型的默认精度
+func DefaultPrecisionFor(brokerType string) Precision {
+	switch strings.ToLower(brokerType) {
+	case "crypto", "coinbase", "bybit":
+		return CryptoPrecision
+	case "ctp":
+		return CNPrecision
+	}
+	return StockPrecision
+}
+
+// PrecisionTable 账户级默认精度及按标的覆盖
+type PrecisionTable struct {
+	defaults Precision
+	symbols  map[string]Precision
+}
+
+// NewPrecisionTable 创建精度表
+func NewPrecisionTable(defaults Precision, symbols map[string]Precision) *PrecisionTable {
+	normalized := make(map[string]Precision, len(symbols))
+	for symbol, precision := range symbols {
+		normalized[strings.ToUpper(symbol)] = precision
+	}
+	return &PrecisionTable{defaults: defaults, symbols: normalized}
+}
+
+// For 获取标的的精度，未单独配置时使用账户默认精度，没有精度表时按股票精度（整股）
+func (t *PrecisionTable) For(symbol string) Precision {
+	if t == nil {
+		return StockPrecision
+	}
+	if precision, exists := t.symbols[strings.ToUpper(symbol)]; exists {
+		return precision
+	}
+	return t.defaults
+}
+
+// Defaults 账户默认精度
+func (t *PrecisionTable) Defaults() Precision {
+	if t == nil {
+		return StockPrecision
+	}
+	return t.defaults
+}
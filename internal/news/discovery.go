@@ -0,0 +1,118 @@
+package news
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 新闻中常见的标的写法：$TSLA、(NASDAQ: TSLA)、(TSLA)
+var (
+	cashtagPattern   = regexp.MustCompile(`\$([A-Z]{1,5})\b`)
+	exchangePattern  = regexp.MustCompile(`\b(?:NASDAQ|NYSE|AMEX|NYSEARCA|NYSE American|OTC)\s*:\s*([A-Z]{1,5}(?:\.[A-Z])?)\b`)
+	parentheticalTag = regexp.MustCompile(`\(([A-Z]{1,5})\)`)
+)
+
+// tickerStopwords 括号中常见但不是标的代码的缩写
+var tickerStopwords = map[string]bool{
+	"AI": true, "CEO": true, "CFO": true, "COO": true, "CTO": true, "ETF": true, "EU": true,
+	"EPS": true, "FDA": true, "FED": true, "FOMC": true, "GDP": true, "IPO": true, "SEC": true,
+	"UK": true, "US": true, "USA": true, "USD": true, "WHO": true, "YOY": true, "CPI": true,
+	"PMI": true, "ECB": true, "IMF": true, "OPEC": true, "EV": true,
+}
+
+// ExtractTickers 从文本中提取标的代码（去重，保持出现顺序）
+func ExtractTickers(text string) []string {
+	seen := make(map[string]bool)
+	var tickers []string
+
+	add := func(symbol string) {
+		symbol = strings.ToUpper(symbol)
+		if seen[symbol] || tickerStopwords[symbol] {
+			return
+		}
+		seen[symbol] = true
+		tickers = append(tickers, symbol)
+	}
+
+	for _, pattern := range []*regexp.Regexp{cashtagPattern, exchangePattern, parentheticalTag} {
+		for _, match := range pattern.FindAllStringSubmatch(text, -1) {
+			add(match[1])
+		}
+	}
+	return tickers
+}
+
+// Mention 新闻中被提及的标的
+type Mention struct {
+	Symbol    string    `json:"symbol"`
+	Articles  int       `json:"articles"` // 提及该标的的新闻数
+	Score     float64   `json:"score"`    // 标题提及计2分，摘要提及计1分
+	Headlines []string  `json:"headlines,omitempty"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// maxMentionHeadlines 每个标的保留的示例标题数
+const maxMentionHeadlines = 3
+
+// DiscoverSymbols 统计新闻中提及的标的，排除已知标的后返回被至少 minMentions 篇新闻提及的标的（按得分降序）
+func DiscoverSymbols(articles []Article, known []string, minMentions, maxSymbols int) []Mention {
+	exclude := make(map[string]bool, len(known))
+	for _, symbol := range known {
+		exclude[strings.ToUpper(symbol)] = true
+	}
+
+	mentions := make(map[string]*Mention)
+	for _, article := range Deduplicate(articles) {
+		inTitle := make(map[string]bool)
+		for _, symbol := range ExtractTickers(article.Title) {
+			inTitle[symbol] = true
+		}
+		candidates := append(ExtractTickers(article.Title), ExtractTickers(article.Summary)...)
+
+		counted := make(map[string]bool)
+		for _, symbol := range candidates {
+			if exclude[symbol] || counted[symbol] {
+				continue
+			}
+			counted[symbol] = true
+
+			mention, exists := mentions[symbol]
+			if !exists {
+				mention = &Mention{Symbol: symbol}
+				mentions[symbol] = mention
+			}
+			mention.Articles++
+			if inTitle[symbol] {
+				mention.Score += 2
+			} else {
+				mention.Score++
+			}
+			if len(mention.Headlines) < maxMentionHeadlines {
+				mention.Headlines = append(mention.Headlines, article.Title)
+			}
+			if article.PublishedAt.After(mention.LastSeen) {
+				mention.LastSeen = article.PublishedAt
+			}
+		}
+	}
+
+	result := make([]Mention, 0, len(mentions))
+	for _, mention := range mentions {
+		if mention.Articles >= minMentions {
+			result = append(result, *mention)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Score != result[j].Score {
+			return result[i].Score > result[j].Score
+		}
+		return result[i].Symbol < result[j].Symbol
+	})
+
+	if maxSymbols > 0 && len(result) > maxSymbols {
+		result = result[:maxSymbols]
+	}
+	return result
+}
@@ -0,0 +1,240 @@
+package news
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"agent-quant-system/internal/config"
+)
+
+// Article 新闻条目
+type Article struct {
+	Title       string    `json:"title"`
+	Summary     string    `json:"summary"`
+	URL         string    `json:"url"`
+	Source      string    `json:"source"`
+	Symbol      string    `json:"symbol"`
+	PublishedAt time.Time `json:"published_at"`
+}
+
+// Headline 用于发送给Agent的文本
+func (a Article) Headline() string {
+	if a.Summary == "" || strings.Contains(a.Title, a.Summary) {
+		return a.Title
+	}
+	return a.Title + " - " + a.Summary
+}
+
+// Source 新闻数据源接口
+type Source interface {
+	// Name 数据源名称
+	Name() string
+
+	// FetchNews 获取指定标的在 since 之后发布的新闻
+	FetchNews(symbol string, since time.Time) ([]Article, error)
+}
+
+// Fetcher 新闻聚合器，负责从多个数据源拉取、去重和按时间过滤
+type Fetcher struct {
+	sources  []Source
+	maxAge   time.Duration
+	maxItems int
+
+	// 发送给Agent前的相关性过滤，为nil时不过滤
+	relevance *RelevanceFilter
+}
+
+// NewFetcher 创建新闻聚合器
+func NewFetcher(sources []Source, maxAge time.Duration, maxItems int) *Fetcher {
+	if maxAge <= 0 {
+		maxAge = 24 * time.Hour
+	}
+	if maxItems <= 0 {
+		maxItems = 20
+	}
+
+	return &Fetcher{
+		sources:  sources,
+		maxAge:   maxAge,
+		maxItems: maxItems,
+	}
+}
+
+// NewFetcherFromConfig 根据配置创建新闻聚合器，未配置任何数据源时返回nil
+func NewFetcherFromConfig(cfg config.NewsConfig, keys config.APIKeysConfig) *Fetcher {
+	if !cfg.Enabled {
+		return nil
+	}
+
+	var sources []Source
+	for _, feed := range cfg.RSSFeeds {
+		sources = append(sources, NewRSSSource(feed, cfg.RequestTimeout))
+	}
+	if keys.NewsAPIKey != "" {
+		sources = append(sources, NewNewsAPISource(keys.NewsAPIKey, cfg.RequestTimeout))
+	}
+	if keys.FinnhubKey != "" {
+		sources = append(sources, NewFinnhubSource(keys.FinnhubKey, cfg.RequestTimeout))
+	}
+
+	if len(sources) == 0 {
+		log.Printf("未配置任何新闻数据源")
+		return nil
+	}
+
+	names := make([]string, 0, len(sources))
+	for _, source := range sources {
+		names = append(names, source.Name())
+	}
+	log.Printf("初始化新闻聚合器: 数据源=%s", strings.Join(names, ", "))
+
+	fetcher := NewFetcher(sources, cfg.MaxAge, cfg.MaxItems)
+	fetcher.SetRelevanceFilter(NewRelevanceFilter(cfg.Relevance))
+	return fetcher
+}
+
+// SetRelevanceFilter 设置相关性过滤器，为nil时不过滤
+func (f *Fetcher) SetRelevanceFilter(filter *RelevanceFilter) {
+	f.relevance = filter
+}
+
+// Sources 获取数据源列表
+func (f *Fetcher) Sources() []Source {
+	return f.sources
+}
+
+// FetchArticles 并发拉取所有数据源的新闻，去重后按发布时间倒序返回
+func (f *Fetcher) FetchArticles(symbol string) ([]Article, error) {
+	articles, err := f.fetch(symbol)
+	if err != nil {
+		return nil, err
+	}
+	return f.limit(articles), nil
+}
+
+// FetchRelevant 拉取标的新闻并经过相关性过滤，最多返回 max_items 条，用于发送给Agent
+func (f *Fetcher) FetchRelevant(symbol string) ([]Article, error) {
+	articles, err := f.fetch(symbol)
+	if err != nil {
+		return nil, err
+	}
+	return f.limit(f.relevance.Filter(symbol, articles)), nil
+}
+
+// limit 截取最多 max_items 条新闻
+func (f *Fetcher) limit(articles []Article) []Article {
+	if len(articles) > f.maxItems {
+		return articles[:f.maxItems]
+	}
+	return articles
+}
+
+// fetch 并发拉取所有数据源的新闻，去重后按发布时间倒序返回全部结果
+func (f *Fetcher) fetch(symbol string) ([]Article, error) {
+	since := time.Now().Add(-f.maxAge)
+
+	type fetchResult struct {
+		source   string
+		articles []Article
+		err      error
+	}
+
+	results := make([]fetchResult, len(f.sources))
+	var wg sync.WaitGroup
+	for i, source := range f.sources {
+		wg.Add(1)
+		go func(i int, source Source) {
+			defer wg.Done()
+			articles, err := source.FetchNews(symbol, since)
+			results[i] = fetchResult{source: source.Name(), articles: articles, err: err}
+		}(i, source)
+	}
+	wg.Wait()
+
+	var all []Article
+	failed := 0
+	for _, result := range results {
+		if result.err != nil {
+			failed++
+			log.Printf("新闻数据源 %s 拉取失败: %v", result.source, result.err)
+			continue
+		}
+		all = append(all, result.articles...)
+	}
+
+	if failed == len(f.sources) {
+		return nil, fmt.Errorf("所有新闻数据源均拉取失败")
+	}
+
+	articles := Deduplicate(FilterRecent(all, since))
+	sort.Slice(articles, func(i, j int) bool {
+		return articles[i].PublishedAt.After(articles[j].PublishedAt)
+	})
+
+	log.Printf("新闻拉取完成: 标的=%s, 原始=%d, 去重过滤后=%d", symbol, len(all), len(articles))
+	return articles, nil
+}
+
+// FetchHeadlines 获取发送给Agent的新闻文本
+func (f *Fetcher) FetchHeadlines(symbol string) ([]string, error) {
+	articles, err := f.FetchRelevant(symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	headlines := make([]string, 0, len(articles))
+	for _, article := range articles {
+		headlines = append(headlines, article.Headline())
+	}
+	return headlines, nil
+}
+
+// FilterRecent 过滤掉早于 since 的新闻（没有发布时间的新闻保留）
+func FilterRecent(articles []Article, since time.Time) []Article {
+	filtered := make([]Article, 0, len(articles))
+	for _, article := range articles {
+		if !article.PublishedAt.IsZero() && article.PublishedAt.Before(since) {
+			continue
+		}
+		filtered = append(filtered, article)
+	}
+	return filtered
+}
+
+// Deduplicate 按链接和标准化标题去重，保留最早出现的一条
+func Deduplicate(articles []Article) []Article {
+	seenURLs := make(map[string]bool)
+	seenTitles := make(map[string]bool)
+	unique := make([]Article, 0, len(articles))
+
+	for _, article := range articles {
+		title := normalizeTitle(article.Title)
+		if title == "" {
+			continue
+		}
+		if article.URL != "" && seenURLs[article.URL] {
+			continue
+		}
+		if seenTitles[title] {
+			continue
+		}
+
+		if article.URL != "" {
+			seenURLs[article.URL] = true
+		}
+		seenTitles[title] = true
+		unique = append(unique, article)
+	}
+
+	return unique
+}
+
+// normalizeTitle 标准化标题用于去重
+func normalizeTitle(title string) string {
+	title = strings.ToLower(strings.TrimSpace(title))
+	return strings.Join(strings.Fields(title), " ")
+}
@@ -0,0 +1,226 @@
+package news
+
+import (
+	"log"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"agent-quant-system/internal/config"
+)
+
+// quoteSuffixes 加密货币交易对的计价币种后缀，匹配新闻时使用基础币种（BTCUSDT 匹配 BTC）
+var quoteSuffixes = []string{"-USDT", "-USD", "USDT", "USDC", "BUSD", "USD"}
+
+// RelevanceFilter 发送给Agent前的新闻相关性过滤：标的或别名匹配、语言识别和标题相似度去重
+type RelevanceFilter struct {
+	aliases    map[string][]string
+	minScore   float64
+	languages  map[string]bool
+	similarity float64
+}
+
+// NewRelevanceFilter 按配置创建相关性过滤器，未启用时返回nil
+func NewRelevanceFilter(cfg config.NewsRelevanceConfig) *RelevanceFilter {
+	if !cfg.Enabled {
+		return nil
+	}
+
+	filter := &RelevanceFilter{
+		aliases:    make(map[string][]string, len(cfg.Aliases)),
+		minScore:   cfg.MinScore,
+		similarity: cfg.SimilarityThreshold,
+	}
+	// 配置中的键会被转换为小写，统一按大写标的保存
+	for symbol, aliases := range cfg.Aliases {
+		filter.aliases[strings.ToUpper(symbol)] = aliases
+	}
+	if len(cfg.Languages) > 0 {
+		filter.languages = make(map[string]bool, len(cfg.Languages))
+		for _, language := range cfg.Languages {
+			filter.languages[strings.ToLower(language)] = true
+		}
+	}
+	return filter
+}
+
+// Filter 返回与标的相关的新闻，保持原有顺序；相似标题只保留第一条
+func (rf *RelevanceFilter) Filter(symbol string, articles []Article) []Article {
+	if rf == nil {
+		return articles
+	}
+
+	patterns := rf.patterns(symbol)
+	kept := make([]Article, 0, len(articles))
+	var keptTokens []map[string]bool
+	unrelated, language, similar := 0, 0, 0
+
+	for _, article := range articles {
+		if rf.minScore > 0 && relevanceScore(article, patterns) < rf.minScore {
+			unrelated++
+			continue
+		}
+		if rf.languages != nil {
+			if detected := DetectLanguage(article.Title + " " + article.Summary); detected != "" && !rf.languages[detected] {
+				language++
+				continue
+			}
+		}
+		if rf.similarity > 0 {
+			tokens := titleTokens(article.Title)
+			duplicate := false
+			for _, previous := range keptTokens {
+				if jaccard(tokens, previous) >= rf.similarity {
+					duplicate = true
+					break
+				}
+			}
+			if duplicate {
+				similar++
+				continue
+			}
+			keptTokens = append(keptTokens, tokens)
+		}
+		kept = append(kept, article)
+	}
+
+	if dropped := len(articles) - len(kept); dropped > 0 {
+		log.Printf("新闻相关性过滤: 标的=%s, 保留=%d, 不相关=%d, 语言不符=%d, 相似=%d", symbol, len(kept), unrelated, language, similar)
+	}
+	return kept
+}
+
+// patterns 标的代码、基础币种和别名的匹配表达式：代码区分大小写，别名不区分
+func (rf *RelevanceFilter) patterns(symbol string) []*regexp.Regexp {
+	symbol = strings.ToUpper(symbol)
+	codes := []string{symbol}
+	for _, suffix := range quoteSuffixes {
+		if base := strings.TrimSuffix(symbol, suffix); base != symbol && base != "" {
+			codes = append(codes, base)
+			break
+		}
+	}
+
+	var patterns []*regexp.Regexp
+	for _, code := range codes {
+		patterns = append(patterns, regexp.MustCompile(`(?:^|[^A-Za-z0-9])\$?`+regexp.QuoteMeta(code)+`(?:$|[^A-Za-z0-9])`))
+	}
+	for _, alias := range rf.aliases[symbol] {
+		if alias = strings.TrimSpace(alias); alias != "" {
+			patterns = append(patterns, regexp.MustCompile(`(?i)(?:^|[^\pL\pN])`+regexp.QuoteMeta(alias)+`(?:$|[^\pL\pN])`))
+		}
+	}
+	return patterns
+}
+
+// relevanceScore 标题提及计2分，摘要提及计1分
+func relevanceScore(article Article, patterns []*regexp.Regexp) float64 {
+	score := 0.0
+	for _, part := range []struct {
+		text   string
+		weight float64
+	}{{article.Title, 2}, {article.Summary, 1}} {
+		for _, pattern := range patterns {
+			if pattern.MatchString(part.text) {
+				score += part.weight
+				break
+			}
+		}
+	}
+	return score
+}
+
+// languageStopwords 用于区分拉丁字母语言的常见词
+var languageStopwords = map[string][]string{
+	"en": {"the", "and", "of", "to", "in", "is", "for", "on", "with", "as", "at", "by", "its", "from", "after", "shares", "stock"},
+	"de": {"der", "die", "das", "und", "ist", "mit", "den", "von", "für", "auf", "nicht", "sich", "eine", "aktie"},
+	"fr": {"le", "la", "les", "et", "des", "du", "est", "une", "pour", "dans", "sur", "avec", "au", "action"},
+	"es": {"el", "los", "las", "y", "del", "es", "una", "para", "por", "con", "en", "al", "acciones"},
+}
+
+// DetectLanguage 粗略识别文本语言：按文字系统识别中日韩和俄文，拉丁字母按常见词识别 en/de/fr/es；无法识别时返回空字符串
+func DetectLanguage(text string) string {
+	var han, kana, hangul, cyrillic, latin int
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Hiragana, r), unicode.Is(unicode.Katakana, r):
+			kana++
+		case unicode.Is(unicode.Han, r):
+			han++
+		case unicode.Is(unicode.Hangul, r):
+			hangul++
+		case unicode.Is(unicode.Cyrillic, r):
+			cyrillic++
+		case unicode.Is(unicode.Latin, r):
+			latin++
+		}
+	}
+
+	switch {
+	case kana > 0 && kana+han >= latin:
+		return "ja"
+	case han > 0 && han*3 >= latin:
+		return "zh"
+	case hangul > 0 && hangul*2 >= latin:
+		return "ko"
+	case cyrillic > latin:
+		return "ru"
+	case latin == 0:
+		return ""
+	}
+
+	words := make(map[string]int)
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool { return !unicode.IsLetter(r) }) {
+		words[word]++
+	}
+	best, bestCount := "", 0
+	for _, language := range []string{"en", "de", "fr", "es"} {
+		count := 0
+		for _, stopword := range languageStopwords[language] {
+			count += words[stopword]
+		}
+		if count > bestCount {
+			best, bestCount = language, count
+		}
+	}
+	return best
+}
+
+// titleTokens 标题的词集合，用于相似度比较；中日韩文字按单字切分
+func titleTokens(title string) map[string]bool {
+	tokens := make(map[string]bool)
+	var word []rune
+	flush := func() {
+		if len(word) > 0 {
+			tokens[string(word)] = true
+			word = word[:0]
+		}
+	}
+	for _, r := range strings.ToLower(title) {
+		switch {
+		case unicode.Is(unicode.Han, r), unicode.Is(unicode.Hiragana, r), unicode.Is(unicode.Katakana, r), unicode.Is(unicode.Hangul, r):
+			flush()
+			tokens[string(r)] = true
+		case unicode.IsLetter(r), unicode.IsDigit(r):
+			word = append(word, r)
+		default:
+			flush()
+		}
+	}
+	flush()
+	return tokens
+}
+
+// jaccard 两个词集合的 Jaccard 相似度
+func jaccard(a, b map[string]bool) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	shared := 0
+	for token := range a {
+		if b[token] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
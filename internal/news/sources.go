@@ -0,0 +1,274 @@
+package news
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// newHTTPClient 创建新闻数据源使用的HTTP客户端
+func newHTTPClient(timeout time.Duration) *resty.Client {
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+
+	client := resty.New()
+	client.SetTimeout(timeout)
+	client.SetHeader("User-Agent", "agent-quant-system/1.0")
+	return client
+}
+
+// RSSSource RSS/Atom 新闻源，URL 中的 {symbol} 会被替换为标的代码
+type RSSSource struct {
+	httpClient  *resty.Client
+	urlTemplate string
+}
+
+// NewRSSSource 创建RSS新闻源
+func NewRSSSource(urlTemplate string, timeout time.Duration) *RSSSource {
+	return &RSSSource{
+		httpClient:  newHTTPClient(timeout),
+		urlTemplate: urlTemplate,
+	}
+}
+
+// Name 数据源名称
+func (r *RSSSource) Name() string {
+	if parsed, err := url.Parse(r.urlTemplate); err == nil && parsed.Host != "" {
+		return "rss:" + parsed.Host
+	}
+	return "rss"
+}
+
+// rssDocument RSS 2.0 与 Atom 的公共解析结构
+type rssDocument struct {
+	Channel struct {
+		Items []struct {
+			Title       string `xml:"title"`
+			Link        string `xml:"link"`
+			Description string `xml:"description"`
+			PubDate     string `xml:"pubDate"`
+		} `xml:"item"`
+	} `xml:"channel"`
+	Entries []struct {
+		Title   string `xml:"title"`
+		Summary string `xml:"summary"`
+		Updated string `xml:"updated"`
+		Link    struct {
+			Href string `xml:"href,attr"`
+		} `xml:"link"`
+	} `xml:"entry"`
+}
+
+// FetchNews 获取新闻
+func (r *RSSSource) FetchNews(symbol string, since time.Time) ([]Article, error) {
+	feedURL := strings.ReplaceAll(r.urlTemplate, "{symbol}", url.QueryEscape(symbol))
+
+	resp, err := r.httpClient.R().Get(feedURL)
+	if err != nil {
+		return nil, fmt.Errorf("请求RSS失败: %w", err)
+	}
+	if resp.StatusCode() != 200 {
+		return nil, fmt.Errorf("请求RSS失败，状态码: %d", resp.StatusCode())
+	}
+
+	var doc rssDocument
+	if err := xml.Unmarshal(resp.Body(), &doc); err != nil {
+		return nil, fmt.Errorf("解析RSS失败: %w", err)
+	}
+
+	var articles []Article
+	for _, item := range doc.Channel.Items {
+		articles = append(articles, Article{
+			Title:       strings.TrimSpace(item.Title),
+			Summary:     stripTags(item.Description),
+			URL:         item.Link,
+			Source:      r.Name(),
+			Symbol:      symbol,
+			PublishedAt: parseFeedTime(item.PubDate),
+		})
+	}
+	for _, entry := range doc.Entries {
+		articles = append(articles, Article{
+			Title:       strings.TrimSpace(entry.Title),
+			Summary:     stripTags(entry.Summary),
+			URL:         entry.Link.Href,
+			Source:      r.Name(),
+			Symbol:      symbol,
+			PublishedAt: parseFeedTime(entry.Updated),
+		})
+	}
+
+	return articles, nil
+}
+
+// NewsAPISource NewsAPI.org 新闻源
+type NewsAPISource struct {
+	httpClient *resty.Client
+	apiKey     string
+	baseURL    string
+}
+
+// NewNewsAPISource 创建NewsAPI新闻源
+func NewNewsAPISource(apiKey string, timeout time.Duration) *NewsAPISource {
+	return &NewsAPISource{
+		httpClient: newHTTPClient(timeout),
+		apiKey:     apiKey,
+		baseURL:    "https://newsapi.org/v2/everything",
+	}
+}
+
+// Name 数据源名称
+func (n *NewsAPISource) Name() string {
+	return "newsapi"
+}
+
+// FetchNews 获取新闻
+func (n *NewsAPISource) FetchNews(symbol string, since time.Time) ([]Article, error) {
+	var result struct {
+		Status   string `json:"status"`
+		Message  string `json:"message"`
+		Articles []struct {
+			Title       string    `json:"title"`
+			Description string    `json:"description"`
+			URL         string    `json:"url"`
+			PublishedAt time.Time `json:"publishedAt"`
+			Source      struct {
+				Name string `json:"name"`
+			} `json:"source"`
+		} `json:"articles"`
+	}
+
+	resp, err := n.httpClient.R().
+		SetQueryParams(map[string]string{
+			"q":        symbol,
+			"from":     since.UTC().Format(time.RFC3339),
+			"sortBy":   "publishedAt",
+			"pageSize": "50",
+		}).
+		SetHeader("X-Api-Key", n.apiKey).
+		SetResult(&result).
+		Get(n.baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("请求NewsAPI失败: %w", err)
+	}
+	if resp.StatusCode() != 200 {
+		return nil, fmt.Errorf("请求NewsAPI失败，状态码: %d, 响应: %s", resp.StatusCode(), resp.String())
+	}
+
+	articles := make([]Article, 0, len(result.Articles))
+	for _, item := range result.Articles {
+		articles = append(articles, Article{
+			Title:       item.Title,
+			Summary:     item.Description,
+			URL:         item.URL,
+			Source:      n.Name() + ":" + item.Source.Name,
+			Symbol:      symbol,
+			PublishedAt: item.PublishedAt,
+		})
+	}
+
+	return articles, nil
+}
+
+// FinnhubSource Finnhub 公司新闻源
+type FinnhubSource struct {
+	httpClient *resty.Client
+	apiKey     string
+	baseURL    string
+}
+
+// NewFinnhubSource 创建Finnhub新闻源
+func NewFinnhubSource(apiKey string, timeout time.Duration) *FinnhubSource {
+	return &FinnhubSource{
+		httpClient: newHTTPClient(timeout),
+		apiKey:     apiKey,
+		baseURL:    "https://finnhub.io/api/v1/company-news",
+	}
+}
+
+// Name 数据源名称
+func (f *FinnhubSource) Name() string {
+	return "finnhub"
+}
+
+// FetchNews 获取新闻
+func (f *FinnhubSource) FetchNews(symbol string, since time.Time) ([]Article, error) {
+	var result []struct {
+		Headline string `json:"headline"`
+		Summary  string `json:"summary"`
+		URL      string `json:"url"`
+		Source   string `json:"source"`
+		Datetime int64  `json:"datetime"`
+	}
+
+	resp, err := f.httpClient.R().
+		SetQueryParams(map[string]string{
+			"symbol": symbol,
+			"from":   since.Format("2006-01-02"),
+			"to":     time.Now().Format("2006-01-02"),
+		}).
+		SetHeader("X-Finnhub-Token", f.apiKey).
+		SetResult(&result).
+		Get(f.baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("请求Finnhub失败: %w", err)
+	}
+	if resp.StatusCode() != 200 {
+		return nil, fmt.Errorf("请求Finnhub失败，状态码: %d", resp.StatusCode())
+	}
+
+	articles := make([]Article, 0, len(result))
+	for _, item := range result {
+		articles = append(articles, Article{
+			Title:       item.Headline,
+			Summary:     item.Summary,
+			URL:         item.URL,
+			Source:      f.Name() + ":" + item.Source,
+			Symbol:      symbol,
+			PublishedAt: time.Unix(item.Datetime, 0),
+		})
+	}
+
+	return articles, nil
+}
+
+// parseFeedTime 解析RSS/Atom中常见的时间格式
+func parseFeedTime(value string) time.Time {
+	value = strings.TrimSpace(value)
+	layouts := []string{
+		time.RFC1123Z,
+		time.RFC1123,
+		time.RFC3339,
+		"Mon, 2 Jan 2006 15:04:05 -0700",
+		"Mon, 2 Jan 2006 15:04:05 MST",
+		"2006-01-02 15:04:05",
+	}
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// stripTags 去除摘要中的HTML标签
+func stripTags(text string) string {
+	var builder strings.Builder
+	inTag := false
+	for _, r := range text {
+		switch {
+		case r == '<':
+			inTag = true
+		case r == '>':
+			inTag = false
+		case !inTag:
+			builder.WriteRune(r)
+		}
+	}
+	return strings.Join(strings.Fields(builder.String()), " ")
+}
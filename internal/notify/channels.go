@@ -0,0 +1,135 @@
+package notify
+
+import (
+	"fmt"
+	"mime"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"agent-quant-system/internal/config"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// TelegramNotifier 通过Telegram机器人发送通知
+type TelegramNotifier struct {
+	httpClient *resty.Client
+	botToken   string
+	chatID     string
+}
+
+// NewTelegramNotifier 创建Telegram通知渠道
+func NewTelegramNotifier(botToken, chatID string) *TelegramNotifier {
+	client := resty.New()
+	client.SetTimeout(10 * time.Second)
+	client.SetBaseURL("https://api.telegram.org")
+
+	return &TelegramNotifier{
+		httpClient: client,
+		botToken:   botToken,
+		chatID:     chatID,
+	}
+}
+
+// Name 渠道名称
+func (t *TelegramNotifier) Name() string {
+	return "telegram"
+}
+
+// Send 发送通知
+func (t *TelegramNotifier) Send(message Message) error {
+	resp, err := t.httpClient.R().
+		SetBody(map[string]string{
+			"chat_id": t.chatID,
+			"text":    message.Text(),
+		}).
+		Post("/bot" + t.botToken + "/sendMessage")
+	if err != nil {
+		return fmt.Errorf("请求Telegram失败: %w", err)
+	}
+	if resp.StatusCode() != 200 {
+		return fmt.Errorf("请求Telegram失败，状态码: %d", resp.StatusCode())
+	}
+	return nil
+}
+
+// SlackNotifier 通过Slack Incoming Webhook发送通知
+type SlackNotifier struct {
+	httpClient *resty.Client
+	webhookURL string
+}
+
+// NewSlackNotifier 创建Slack通知渠道
+func NewSlackNotifier(webhookURL string) *SlackNotifier {
+	client := resty.New()
+	client.SetTimeout(10 * time.Second)
+
+	return &SlackNotifier{
+		httpClient: client,
+		webhookURL: webhookURL,
+	}
+}
+
+// Name 渠道名称
+func (s *SlackNotifier) Name() string {
+	return "slack"
+}
+
+// Send 发送通知
+func (s *SlackNotifier) Send(message Message) error {
+	text := fmt.Sprintf("*[%s] %s*\n%s", message.Kind, message.Title, message.Body)
+	resp, err := s.httpClient.R().
+		SetBody(map[string]string{"text": text}).
+		Post(s.webhookURL)
+	if err != nil {
+		return fmt.Errorf("请求Slack失败: %w", err)
+	}
+	if resp.StatusCode() != 200 {
+		return fmt.Errorf("请求Slack失败，状态码: %d", resp.StatusCode())
+	}
+	return nil
+}
+
+// EmailNotifier 通过SMTP发送邮件通知
+type EmailNotifier struct {
+	config config.EmailConfig
+}
+
+// NewEmailNotifier 创建邮件通知渠道
+func NewEmailNotifier(cfg config.EmailConfig) *EmailNotifier {
+	return &EmailNotifier{config: cfg}
+}
+
+// Name 渠道名称
+func (e *EmailNotifier) Name() string {
+	return "email"
+}
+
+// Send 发送通知
+func (e *EmailNotifier) Send(message Message) error {
+	addr := fmt.Sprintf("%s:%d", e.config.Host, e.config.Port)
+
+	var auth smtp.Auth
+	if e.config.Username != "" {
+		auth = smtp.PlainAuth("", e.config.Username, e.config.Password, e.config.Host)
+	}
+
+	subject := fmt.Sprintf("[%s] %s", message.Kind, message.Title)
+	content := strings.Join([]string{
+		"From: " + e.config.From,
+		"To: " + strings.Join(e.config.To, ", "),
+		"Subject: " + mime.QEncoding.Encode("UTF-8", subject),
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=UTF-8",
+		"",
+		message.Body,
+		"",
+		message.Time.Format("2006-01-02 15:04:05"),
+	}, "\r\n")
+
+	if err := smtp.SendMail(addr, auth, e.config.From, e.config.To, []byte(content)); err != nil {
+		return fmt.Errorf("发送邮件失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,250 @@
+package notify
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"agent-quant-system/internal/config"
+)
+
+// EventKind 通知事件类型
+type EventKind string
+
+const (
+	EventTrade       EventKind = "trade"        // 订单成交
+	EventRisk        EventKind = "risk"         // 风控拒单或限流
+	EventCycleFailed EventKind = "cycle_failed" // 交易循环失败
+	EventBroker      EventKind = "broker"       // 经纪商断线或不可用
+	EventBacktest    EventKind = "backtest"     // 回测完成
+	EventApproval    EventKind = "approval"     // 订单等待人工确认
+	EventDependency  EventKind = "dependency"   // 数据、Agent、新闻等依赖异常
+	EventPromotion   EventKind = "promotion"    // 策略晋级实盘或回到纸面交易
+	EventReconcile   EventKind = "reconcile"    // 本地账户与经纪商状态不一致
+	EventHalt        EventKind = "halt"         // 交易紧急停止或恢复
+	EventConfig      EventKind = "config"       // 配置热加载成功或失败
+	EventStrategy    EventKind = "strategy"     // 策略因回撤或连续亏损被停用或恢复
+)
+
+// Message 通知内容
+type Message struct {
+	Kind  EventKind `json:"kind"`
+	Title string    `json:"title"`
+	Body  string    `json:"body"`
+	Time  time.Time `json:"time"`
+}
+
+// Text 纯文本格式
+func (m Message) Text() string {
+	return fmt.Sprintf("[%s] %s\n%s\n%s", m.Kind, m.Title, m.Body, m.Time.Format("2006-01-02 15:04:05"))
+}
+
+// Notifier 通知渠道
+type Notifier interface {
+	// Name 渠道名称
+	Name() string
+
+	// Send 发送通知
+	Send(message Message) error
+}
+
+// Dispatcher 通知分发器：按事件类型过滤后异步发送到所有渠道，发送失败只记录日志
+type Dispatcher struct {
+	notifiers []Notifier
+	events    map[EventKind]bool // 为空表示全部事件
+	queue     chan Message
+	closed    bool
+	wg        sync.WaitGroup
+	mutex     sync.RWMutex
+
+	// 进程内订阅者（如控制API的事件流），接收全部事件，不受事件过滤和关闭影响
+	subscribers map[int]chan Message
+	nextID      int
+}
+
+// NewDispatcher 创建通知分发器
+func NewDispatcher(notifiers []Notifier, events []string, queueSize int) *Dispatcher {
+	if queueSize <= 0 {
+		queueSize = 100
+	}
+
+	d := &Dispatcher{
+		notifiers: notifiers,
+		events:    parseEvents(events),
+		queue:     make(chan Message, queueSize),
+	}
+
+	d.wg.Add(1)
+	go d.run()
+	return d
+}
+
+// parseEvents 解析需要通知的事件，为空时返回nil（全部事件）
+func parseEvents(events []string) map[EventKind]bool {
+	if len(events) == 0 {
+		return nil
+	}
+	kinds := make(map[EventKind]bool, len(events))
+	for _, event := range events {
+		kinds[EventKind(strings.ToLower(strings.TrimSpace(event)))] = true
+	}
+	return kinds
+}
+
+// NewDispatcherFromConfig 根据配置创建通知分发器，未启用或没有可用渠道时返回nil
+func NewDispatcherFromConfig(cfg config.NotificationsConfig) *Dispatcher {
+	notifiers := notifiersFromConfig(cfg)
+	if len(notifiers) == 0 {
+		return nil
+	}
+	return NewDispatcher(notifiers, cfg.Events, cfg.QueueSize)
+}
+
+// Reconfigure 按新配置替换通知渠道和事件过滤（热加载配置时使用），队列和订阅者保持不变
+func (d *Dispatcher) Reconfigure(cfg config.NotificationsConfig) {
+	if d == nil {
+		return
+	}
+	notifiers := notifiersFromConfig(cfg)
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.notifiers = notifiers
+	d.events = parseEvents(cfg.Events)
+}
+
+// notifiersFromConfig 按配置创建通知渠道，未启用时返回空
+func notifiersFromConfig(cfg config.NotificationsConfig) []Notifier {
+	if !cfg.Enabled {
+		return nil
+	}
+
+	var notifiers []Notifier
+	if cfg.Telegram.Enabled {
+		notifiers = append(notifiers, NewTelegramNotifier(cfg.Telegram.BotToken, cfg.Telegram.ChatID))
+	}
+	if cfg.Slack.Enabled {
+		notifiers = append(notifiers, NewSlackNotifier(cfg.Slack.WebhookURL))
+	}
+	if cfg.Email.Enabled {
+		notifiers = append(notifiers, NewEmailNotifier(cfg.Email))
+	}
+	if len(notifiers) == 0 {
+		log.Printf("已启用通知但没有配置可用的通知渠道")
+		return nil
+	}
+
+	names := make([]string, 0, len(notifiers))
+	for _, notifier := range notifiers {
+		names = append(names, notifier.Name())
+	}
+	log.Printf("通知渠道: %s", strings.Join(names, ", "))
+	return notifiers
+}
+
+// Subscribe 订阅全部事件，返回事件通道和取消订阅函数；订阅者处理过慢时丢弃事件
+func (d *Dispatcher) Subscribe(bufferSize int) (<-chan Message, func()) {
+	if d == nil {
+		return nil, func() {}
+	}
+	if bufferSize <= 0 {
+		bufferSize = 100
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.subscribers == nil {
+		d.subscribers = make(map[int]chan Message)
+	}
+	id := d.nextID
+	d.nextID++
+	ch := make(chan Message, bufferSize)
+	d.subscribers[id] = ch
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			d.mutex.Lock()
+			delete(d.subscribers, id)
+			d.mutex.Unlock()
+			close(ch)
+		})
+	}
+}
+
+// Notify 发送通知（异步），事件类型未订阅或队列已满时丢弃
+func (d *Dispatcher) Notify(kind EventKind, title, body string) {
+	if d == nil {
+		return
+	}
+
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	message := Message{Kind: kind, Title: title, Body: body, Time: time.Now()}
+	for _, subscriber := range d.subscribers {
+		select {
+		case subscriber <- message:
+		default:
+		}
+	}
+
+	if d.events != nil && !d.events[kind] {
+		return
+	}
+	if d.closed {
+		return
+	}
+
+	select {
+	case d.queue <- message:
+	default:
+		log.Printf("通知队列已满，丢弃通知: %s", title)
+	}
+}
+
+// Notifyf 按格式发送通知
+func (d *Dispatcher) Notifyf(kind EventKind, title, format string, args ...interface{}) {
+	if d == nil {
+		return
+	}
+	d.Notify(kind, title, fmt.Sprintf(format, args...))
+}
+
+// Close 发送完队列中的通知后停止，之后的通知将被丢弃
+func (d *Dispatcher) Close() {
+	if d == nil {
+		return
+	}
+
+	d.mutex.Lock()
+	if d.closed {
+		d.mutex.Unlock()
+		return
+	}
+	d.closed = true
+	close(d.queue)
+	d.mutex.Unlock()
+
+	d.wg.Wait()
+}
+
+// run 发送循环
+func (d *Dispatcher) run() {
+	defer d.wg.Done()
+
+	for message := range d.queue {
+		d.mutex.RLock()
+		notifiers := d.notifiers
+		d.mutex.RUnlock()
+
+		for _, notifier := range notifiers {
+			if err := notifier.Send(message); err != nil {
+				log.Printf("发送通知失败: 渠道=%s, 标题=%s, 错误=%v", notifier.Name(), message.Title, err)
+			}
+		}
+	}
+}
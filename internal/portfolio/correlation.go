@@ -0,0 +1,157 @@
+package portfolio
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"agent-quant-system/internal/data"
+)
+
+// correlationScaleSteps 缩减数量时尝试的比例档数
+const correlationScaleSteps = 20
+
+// CorrelationLimits 相关性过滤的限制，0表示不检查
+type CorrelationLimits struct {
+	MaxPairCorrelation      float64
+	MaxPortfolioCorrelation float64
+	MaxConcentration        float64
+	Downsize                bool    // 超限时缩减数量，否则拒绝
+	MinScale                float64 // 缩减后低于原数量的该比例时拒绝
+}
+
+// CorrelationDecision 相关性过滤对一个买入信号的处理结果
+type CorrelationDecision struct {
+	Symbol               string  `json:"symbol"`
+	Scale                float64 `json:"scale"`                 // 信号数量的保留比例，1为原样通过，0为拒绝
+	PairSymbol           string  `json:"pair_symbol,omitempty"` // 相关系数最高的持仓标的
+	PairCorrelation      float64 `json:"pair_correlation"`      // 与该持仓标的的相关系数
+	PortfolioCorrelation float64 `json:"portfolio_correlation"` // 按保留比例下单后的组合平均相关系数
+	Concentration        float64 `json:"concentration"`         // 按保留比例下单后的集中度
+	Reason               string  `json:"reason,omitempty"`
+}
+
+// PortfolioCorrelation 按持仓市值（空头为负数）加权的两两平均相关系数：
+// Σ w_i w_j ρ_ij / Σ |w_i||w_j|（i≠j），空头与正相关标的相互对冲；不足两个标的时为0
+func PortfolioCorrelation(correlation *data.Matrix, values map[string]float64) float64 {
+	symbols := sortedSymbols(values)
+	numerator, denominator := 0.0, 0.0
+	for i, a := range symbols {
+		for _, b := range symbols[i+1:] {
+			rho, ok := correlation.Get(a, b)
+			if !ok {
+				continue
+			}
+			numerator += values[a] * values[b] * rho
+			denominator += math.Abs(values[a] * values[b])
+		}
+	}
+	if denominator == 0 {
+		return 0
+	}
+	return numerator / denominator
+}
+
+// Concentration 持仓市值的赫芬达尔集中度 Σ w_i² / (Σ |w_i|)²，取值 1/n 到 1，没有持仓时为0
+func Concentration(values map[string]float64) float64 {
+	sum, squares := 0.0, 0.0
+	for _, value := range values {
+		sum += math.Abs(value)
+		squares += value * value
+	}
+	if sum == 0 {
+		return 0
+	}
+	return squares / (sum * sum)
+}
+
+// FilterByCorrelation 检查对 symbol 买入 value 市值后的组合相关性和集中度：
+// 与某个持仓标的的相关系数超过上限时按 (1-|ρ|)/(1-上限) 缩减，组合平均相关系数或集中度超过上限时
+// 取满足限制的最大比例；不允许缩减或缩减后低于 MinScale 时拒绝。holdings 为当前各标的的持仓市值
+func FilterByCorrelation(correlation *data.Matrix, holdings map[string]float64, symbol string, value float64, limits CorrelationLimits) CorrelationDecision {
+	decision := CorrelationDecision{Symbol: symbol, Scale: 1}
+
+	others := 0
+	for _, held := range sortedSymbols(holdings) {
+		if held == symbol || holdings[held] == 0 {
+			continue
+		}
+		others++
+		rho, ok := correlation.Get(symbol, held)
+		if ok && (decision.PairSymbol == "" || math.Abs(rho) > math.Abs(decision.PairCorrelation)) {
+			decision.PairSymbol, decision.PairCorrelation = held, rho
+		}
+	}
+	if others == 0 {
+		// 没有其他持仓，无从比较
+		decision.Concentration = 1
+		return decision
+	}
+
+	var reasons []string
+	if limit := limits.MaxPairCorrelation; limit > 0 && decision.PairSymbol != "" && decision.PairCorrelation > limit {
+		// 同向相关才会加剧集中，负相关的持仓起对冲作用
+		decision.Scale = math.Max(0, (1-decision.PairCorrelation)/(1-limit))
+		reasons = append(reasons, fmt.Sprintf("与 %s 的相关系数 %.2f 超过 %.2f", decision.PairSymbol, decision.PairCorrelation, limit))
+	}
+
+	after := func(scale float64) map[string]float64 {
+		values := make(map[string]float64, len(holdings)+1)
+		for held, heldValue := range holdings {
+			values[held] = heldValue
+		}
+		values[symbol] += value * scale
+		return values
+	}
+	satisfied := func(scale float64) bool {
+		values := after(scale)
+		if limit := limits.MaxPortfolioCorrelation; limit > 0 && PortfolioCorrelation(correlation, values) > limit {
+			return false
+		}
+		if limit := limits.MaxConcentration; limit > 0 && Concentration(values) > limit {
+			return false
+		}
+		return true
+	}
+	if !satisfied(decision.Scale) {
+		// 组合指标不一定随数量单调变化，从大到小逐档尝试
+		scale := 0.0
+		for step := correlationScaleSteps - 1; step > 0; step-- {
+			candidate := decision.Scale * float64(step) / correlationScaleSteps
+			if satisfied(candidate) {
+				scale = candidate
+				break
+			}
+		}
+		values := after(decision.Scale)
+		if limit := limits.MaxPortfolioCorrelation; limit > 0 && PortfolioCorrelation(correlation, values) > limit {
+			reasons = append(reasons, fmt.Sprintf("组合平均相关系数 %.2f 超过 %.2f", PortfolioCorrelation(correlation, values), limit))
+		}
+		if limit := limits.MaxConcentration; limit > 0 && Concentration(values) > limit {
+			reasons = append(reasons, fmt.Sprintf("集中度 %.2f 超过 %.2f", Concentration(values), limit))
+		}
+		decision.Scale = scale
+	}
+
+	if len(reasons) > 0 {
+		decision.Reason = strings.Join(reasons, "; ")
+		if !limits.Downsize || decision.Scale < limits.MinScale {
+			decision.Scale = 0
+		}
+	}
+	values := after(decision.Scale)
+	decision.PortfolioCorrelation = PortfolioCorrelation(correlation, values)
+	decision.Concentration = Concentration(values)
+	return decision
+}
+
+// sortedSymbols 按名称排序的标的，保证计算结果与 map 遍历顺序无关
+func sortedSymbols(values map[string]float64) []string {
+	symbols := make([]string, 0, len(values))
+	for symbol := range values {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+	return symbols
+}
@@ -0,0 +1,247 @@
+package portfolio
+
+import (
+	"fmt"
+	"math"
+
+	"agent-quant-system/internal/data"
+)
+
+// 权重计算方法
+const (
+	MeanVariance = "mean_variance" // 均值-方差：最大化 预期收益 - 风险厌恶系数/2 × 方差
+	RiskParity   = "risk_parity"   // 风险平价：各标的对组合波动的贡献相等
+)
+
+// optimizeIterations 迭代求解的最大次数
+const optimizeIterations = 5000
+
+// Options 权重计算参数
+type Options struct {
+	Method       string
+	RiskAversion float64 // 均值-方差的风险厌恶系数
+	MaxWeight    float64 // 单个标的权重上限，0表示不限制
+	Budget       float64 // 权重合计（投入的权益比例），其余为现金
+}
+
+// Allocation 目标权重及组合的预期收益和波动（按收益率序列的周期计）
+type Allocation struct {
+	Method         string             `json:"method"`
+	Weights        map[string]float64 `json:"weights"`
+	ExpectedReturn float64            `json:"expected_return"`
+	Volatility     float64            `json:"volatility"`
+	Observations   int                `json:"observations"` // 使用的共同时间点数
+}
+
+// Optimize 按历史收益率计算只做多的目标权重，权重非负、不超过上限且合计为 Budget
+func Optimize(returns *data.AlignedReturns, opts Options) (*Allocation, error) {
+	n := len(returns.Symbols)
+	if n == 0 {
+		return nil, fmt.Errorf("没有标的")
+	}
+	if opts.Budget <= 0 {
+		return nil, fmt.Errorf("budget 必须大于0")
+	}
+	if opts.MaxWeight > 0 && opts.MaxWeight*float64(n) < opts.Budget {
+		return nil, fmt.Errorf("%d 个标的按权重上限 %.2f 无法分配 %.2f 的权益", n, opts.MaxWeight, opts.Budget)
+	}
+
+	covariance, err := returns.Covariance(0, len(returns.Times))
+	if err != nil {
+		return nil, err
+	}
+	means := make([]float64, n)
+	for i, series := range returns.Returns {
+		for _, r := range series {
+			means[i] += r
+		}
+		means[i] /= float64(len(series))
+	}
+
+	var weights []float64
+	switch opts.Method {
+	case MeanVariance:
+		weights = meanVariance(means, covariance.Values, opts)
+	case RiskParity:
+		weights, err = riskParity(covariance.Values, opts)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("未知的权重计算方法: %s", opts.Method)
+	}
+
+	allocation := &Allocation{
+		Method:       opts.Method,
+		Weights:      make(map[string]float64, n),
+		Observations: len(returns.Times),
+	}
+	for i, symbol := range returns.Symbols {
+		allocation.Weights[symbol] = weights[i]
+		allocation.ExpectedReturn += weights[i] * means[i]
+	}
+	allocation.Volatility = math.Sqrt(quadratic(weights, covariance.Values))
+	return allocation, nil
+}
+
+// meanVariance 投影梯度法求解带上限的单纯形上的均值-方差问题
+func meanVariance(means []float64, covariance [][]float64, opts Options) []float64 {
+	n := len(means)
+	weights := make([]float64, n)
+	for i := range weights {
+		weights[i] = opts.Budget / float64(n)
+	}
+	weights = project(weights, opts.Budget, opts.MaxWeight)
+
+	// 步长取 1/(λ·tr(Σ))，迹不小于最大特征值，保证收敛
+	trace := 0.0
+	for i := range covariance {
+		trace += covariance[i][i]
+	}
+	if opts.RiskAversion <= 0 || trace <= 0 {
+		// 不考虑风险时只按预期收益分配
+		return project(means, opts.Budget, opts.MaxWeight)
+	}
+	step := 1 / (opts.RiskAversion * trace)
+
+	gradient := make([]float64, n)
+	for iteration := 0; iteration < optimizeIterations; iteration++ {
+		for i := range gradient {
+			gradient[i] = means[i] - opts.RiskAversion*dot(covariance[i], weights)
+		}
+		next := make([]float64, n)
+		for i := range next {
+			next[i] = weights[i] + step*gradient[i]
+		}
+		next = project(next, opts.Budget, opts.MaxWeight)
+
+		change := 0.0
+		for i := range next {
+			change += math.Abs(next[i] - weights[i])
+		}
+		weights = next
+		if change < 1e-10 {
+			break
+		}
+	}
+	return weights
+}
+
+// riskParity 乘法迭代使各标的的风险贡献 w_i·(Σw)_i 相等，再按权重上限投影
+func riskParity(covariance [][]float64, opts Options) ([]float64, error) {
+	n := len(covariance)
+	for i := range covariance {
+		if covariance[i][i] <= 0 {
+			return nil, fmt.Errorf("第 %d 个标的的收益率没有波动，无法计算风险平价", i+1)
+		}
+	}
+
+	weights := make([]float64, n)
+	for i := range weights {
+		weights[i] = 1 / math.Sqrt(covariance[i][i])
+	}
+	normalize(weights, 1)
+
+	for iteration := 0; iteration < optimizeIterations; iteration++ {
+		variance := quadratic(weights, covariance)
+		if variance <= 0 {
+			break
+		}
+		maxDeviation := 0.0
+		for i := range weights {
+			contribution := weights[i] * dot(covariance[i], weights) / variance
+			if contribution <= 0 {
+				continue
+			}
+			maxDeviation = math.Max(maxDeviation, math.Abs(contribution-1/float64(n)))
+			weights[i] *= math.Sqrt(1 / float64(n) / contribution)
+		}
+		normalize(weights, 1)
+		if maxDeviation < 1e-8 {
+			break
+		}
+	}
+
+	for i := range weights {
+		weights[i] *= opts.Budget
+	}
+	return project(weights, opts.Budget, opts.MaxWeight), nil
+}
+
+// project 欧氏投影到 {w : 0 ≤ w_i ≤ cap, Σw = budget}，cap 为0时不限制上限；二分查找平移量
+func project(values []float64, budget, cap float64) []float64 {
+	clip := func(v, shift float64) float64 {
+		v -= shift
+		if v < 0 {
+			return 0
+		}
+		if cap > 0 && v > cap {
+			return cap
+		}
+		return v
+	}
+	sum := func(shift float64) float64 {
+		total := 0.0
+		for _, v := range values {
+			total += clip(v, shift)
+		}
+		return total
+	}
+
+	low, high := math.Inf(1), math.Inf(-1)
+	for _, v := range values {
+		low = math.Min(low, v)
+		high = math.Max(high, v)
+	}
+	upper := budget
+	if cap > 0 {
+		upper = cap
+	}
+	low -= upper
+	for i := 0; i < 200; i++ {
+		mid := (low + high) / 2
+		if sum(mid) > budget {
+			low = mid
+		} else {
+			high = mid
+		}
+	}
+
+	result := make([]float64, len(values))
+	for i, v := range values {
+		result[i] = clip(v, (low+high)/2)
+	}
+	return result
+}
+
+// normalize 按比例缩放使合计为 total
+func normalize(weights []float64, total float64) {
+	sum := 0.0
+	for _, w := range weights {
+		sum += w
+	}
+	if sum <= 0 {
+		return
+	}
+	for i := range weights {
+		weights[i] *= total / sum
+	}
+}
+
+// dot 向量内积
+func dot(a, b []float64) float64 {
+	total := 0.0
+	for i := range a {
+		total += a[i] * b[i]
+	}
+	return total
+}
+
+// quadratic 计算 wᵀΣw
+func quadratic(weights []float64, covariance [][]float64) float64 {
+	total := 0.0
+	for i := range weights {
+		total += weights[i] * dot(covariance[i], weights)
+	}
+	return total
+}
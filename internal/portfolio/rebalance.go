@@ -0,0 +1,96 @@
+package portfolio
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"agent-quant-system/internal/strategy"
+)
+
+// StrategyName 调仓信号使用的策略名称，可在 strategy.allocations 中为其配置账户
+const StrategyName = "portfolio"
+
+// RebalanceOptions 调仓参数
+type RebalanceOptions struct {
+	Threshold     float64 // 目标权重与当前权重相差不超过该值时不调仓
+	MinTradeValue float64 // 调仓金额低于该值时不交易
+}
+
+// Holding 标的的当前持仓和最新价格
+type Holding struct {
+	Quantity float64 `json:"quantity"`
+	Price    float64 `json:"price"`
+}
+
+// Trade 一个标的的调仓计划
+type Trade struct {
+	Symbol        string  `json:"symbol"`
+	CurrentWeight float64 `json:"current_weight"`
+	TargetWeight  float64 `json:"target_weight"`
+	Quantity      float64 `json:"quantity"` // 正数买入，负数卖出
+	Price         float64 `json:"price"`
+	Skipped       string  `json:"skipped,omitempty"` // 不交易的原因
+}
+
+// Rebalance 按目标权重与当前持仓的差额计算调仓，返回每个标的的计划和需要执行的信号（先卖后买）。
+// 不在目标权重中的持仓不调整
+func Rebalance(allocation *Allocation, equity float64, holdings map[string]Holding, opts RebalanceOptions) ([]Trade, []strategy.TradingSignal, error) {
+	if equity <= 0 {
+		return nil, nil, fmt.Errorf("账户权益必须大于0")
+	}
+
+	symbols := make([]string, 0, len(allocation.Weights))
+	for symbol := range allocation.Weights {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+
+	var trades []Trade
+	var sells, buys []strategy.TradingSignal
+	for _, symbol := range symbols {
+		holding := holdings[symbol]
+		trade := Trade{Symbol: symbol, TargetWeight: allocation.Weights[symbol], Price: holding.Price}
+		if holding.Price <= 0 {
+			trade.Skipped = "没有有效价格"
+			trades = append(trades, trade)
+			continue
+		}
+
+		trade.CurrentWeight = holding.Quantity * holding.Price / equity
+		trade.Quantity = (trade.TargetWeight - trade.CurrentWeight) * equity / holding.Price
+		value := math.Abs(trade.Quantity) * holding.Price
+		switch {
+		case math.Abs(trade.TargetWeight-trade.CurrentWeight) <= opts.Threshold:
+			trade.Skipped = "权重偏离未超过阈值"
+		case value < opts.MinTradeValue:
+			trade.Skipped = "调仓金额过小"
+		}
+		trades = append(trades, trade)
+		if trade.Skipped != "" {
+			continue
+		}
+
+		signal := strategy.TradingSignal{
+			Symbol:     symbol,
+			Signal:     strategy.Buy,
+			Price:      holding.Price,
+			Quantity:   math.Abs(trade.Quantity),
+			Confidence: 1,
+			Reason: fmt.Sprintf("组合调仓(%s): 权重 %.1f%% -> %.1f%%", allocation.Method,
+				trade.CurrentWeight*100, trade.TargetWeight*100),
+			Timestamp: time.Now(),
+			Strategy:  StrategyName,
+		}
+		if trade.Quantity < 0 {
+			signal.Signal = strategy.Sell
+			sells = append(sells, signal)
+		} else {
+			buys = append(buys, signal)
+		}
+	}
+
+	// 先卖出释放资金再买入
+	return trades, append(sells, buys...), nil
+}
@@ -0,0 +1,314 @@
+package scanner
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"agent-quant-system/internal/config"
+	"agent-quant-system/internal/data"
+	"agent-quant-system/internal/indicators"
+)
+
+// MarketDataSource 扫描使用的行情数据源
+type MarketDataSource interface {
+	GetMarketData(symbol, startDate, endDate string) (data.DataFrame, error)
+}
+
+// Metrics 标的扫描指标
+type Metrics struct {
+	Symbol     string  `json:"symbol"`
+	AvgVolume  float64 `json:"avg_volume"`
+	Volatility float64 `json:"volatility"` // 收益率标准差
+	Gap        float64 `json:"gap"`        // 最新K线相对前收的跳空幅度
+	LastPrice  float64 `json:"last_price"`
+	Score      float64 `json:"score"`
+}
+
+// Scanner 标的扫描器，用轻量过滤条件从标的池中挑选交易标的
+type Scanner struct {
+	config     config.ScannerConfig
+	dataSource MarketDataSource
+
+	// 临时加入标的池的候选标的（如新闻发现的标的）及其观察期截止时间
+	candidates map[string]time.Time
+	mutex      sync.Mutex
+}
+
+// NewScanner 创建扫描器
+func NewScanner(cfg config.ScannerConfig, dataSource MarketDataSource) *Scanner {
+	return &Scanner{
+		config:     withScannerDefaults(cfg),
+		dataSource: dataSource,
+		candidates: make(map[string]time.Time),
+	}
+}
+
+// UpdateConfig 替换标的池和过滤条件（热加载配置时使用），候选标的保持不变
+func (s *Scanner) UpdateConfig(cfg config.ScannerConfig) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.config = withScannerDefaults(cfg)
+}
+
+// withScannerDefaults 补充未配置的回看天数和加入数量上限
+func withScannerDefaults(cfg config.ScannerConfig) config.ScannerConfig {
+	if cfg.LookbackDays <= 0 {
+		cfg.LookbackDays = 5
+	}
+	if cfg.MaxPromoted <= 0 {
+		cfg.MaxPromoted = 5
+	}
+	return cfg
+}
+
+// AddCandidates 将标的临时加入标的池，观察期内参与扫描，再次加入时延长观察期
+func (s *Scanner) AddCandidates(symbols []string, probation time.Duration) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	until := time.Now().Add(probation)
+	for _, symbol := range normalizeSymbols(symbols) {
+		if _, exists := s.candidates[symbol]; !exists {
+			log.Printf("候选标的加入标的池: %s, 观察期至 %s", symbol, until.Format("2006-01-02 15:04"))
+		}
+		s.candidates[symbol] = until
+	}
+}
+
+// Candidates 获取仍在观察期内的候选标的
+func (s *Scanner) Candidates() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.pruneCandidates(time.Now())
+	symbols := make([]string, 0, len(s.candidates))
+	for symbol := range s.candidates {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+	return symbols
+}
+
+// pruneCandidates 移除观察期已结束的候选标的（调用方需持有锁）
+func (s *Scanner) pruneCandidates(now time.Time) {
+	for symbol, until := range s.candidates {
+		if now.After(until) {
+			delete(s.candidates, symbol)
+			log.Printf("候选标的观察期结束，移出标的池: %s", symbol)
+		}
+	}
+}
+
+// universe 当前扫描的标的池：配置的标的池加观察期内的候选标的
+func (s *Scanner) universe() []string {
+	return normalizeSymbols(append(append([]string(nil), s.config.Universe...), s.Candidates()...))
+}
+
+// Scan 扫描标的池，返回满足条件的标的（按得分降序，最多 MaxPromoted 个）
+func (s *Scanner) Scan() ([]Metrics, error) {
+	universe := s.universe()
+	if len(universe) == 0 {
+		return nil, nil
+	}
+
+	end := time.Now()
+	start := end.AddDate(0, 0, -s.config.LookbackDays)
+
+	var qualified []Metrics
+	failed := 0
+	for _, symbol := range universe {
+		df, err := s.dataSource.GetMarketData(symbol, start.Format("2006-01-02"), end.Format("2006-01-02"))
+		if err != nil {
+			failed++
+			log.Printf("扫描标的 %s 获取数据失败: %v", symbol, err)
+			continue
+		}
+
+		metrics, err := ComputeMetrics(symbol, df)
+		if err != nil {
+			failed++
+			log.Printf("扫描标的 %s 计算指标失败: %v", symbol, err)
+			continue
+		}
+
+		if reason := s.reject(metrics); reason != "" {
+			log.Printf("标的 %s 未通过扫描: %s", symbol, reason)
+			continue
+		}
+		qualified = append(qualified, *metrics)
+	}
+
+	if failed == len(universe) {
+		return nil, fmt.Errorf("标的池全部扫描失败")
+	}
+
+	sort.Slice(qualified, func(i, j int) bool {
+		return qualified[i].Score > qualified[j].Score
+	})
+	if len(qualified) > s.config.MaxPromoted {
+		qualified = qualified[:s.config.MaxPromoted]
+	}
+
+	log.Printf("标的扫描完成: 标的池=%d, 满足条件=%d", len(universe), len(qualified))
+	return qualified, nil
+}
+
+// reject 检查过滤条件，返回不满足的原因（为空表示通过）
+func (s *Scanner) reject(m *Metrics) string {
+	if s.config.MinVolume > 0 && m.AvgVolume < s.config.MinVolume {
+		return "成交量不足"
+	}
+	if s.config.MinVolatility > 0 && m.Volatility < s.config.MinVolatility {
+		return "波动率过低"
+	}
+	if s.config.MaxVolatility > 0 && m.Volatility > s.config.MaxVolatility {
+		return "波动率过高"
+	}
+	if s.config.MinGap > 0 && m.Gap < s.config.MinGap {
+		return "跳空幅度不足"
+	}
+	return ""
+}
+
+// ComputeMetrics 计算单个标的的扫描指标
+func ComputeMetrics(symbol string, df data.DataFrame) (*Metrics, error) {
+	opens, err := indicators.Float64Column(df, "open")
+	if err != nil {
+		return nil, err
+	}
+	closes, err := indicators.Float64Column(df, "close")
+	if err != nil {
+		return nil, err
+	}
+	volumes, err := indicators.Float64Column(df, "volume")
+	if err != nil {
+		return nil, err
+	}
+	if len(closes) < 2 {
+		return nil, fmt.Errorf("数据长度不足")
+	}
+
+	returns := make([]float64, 0, len(closes)-1)
+	for i := 1; i < len(closes); i++ {
+		if closes[i-1] != 0 {
+			returns = append(returns, closes[i]/closes[i-1]-1)
+		}
+	}
+	volatility := 0.0
+	if len(returns) > 0 {
+		std, err := indicators.StdDev(returns, len(returns))
+		if err != nil {
+			return nil, err
+		}
+		volatility = indicators.Last(std)
+	}
+
+	avgVolume := 0.0
+	for _, volume := range volumes {
+		avgVolume += volume
+	}
+	avgVolume /= float64(len(volumes))
+
+	last := len(closes) - 1
+	gap := 0.0
+	if closes[last-1] != 0 {
+		gap = math.Abs(opens[last]-closes[last-1]) / closes[last-1]
+	}
+
+	return &Metrics{
+		Symbol:     symbol,
+		AvgVolume:  avgVolume,
+		Volatility: volatility,
+		Gap:        gap,
+		LastPrice:  closes[last],
+		// 波动和跳空越大越值得关注，成交量取对数避免主导排序
+		Score: (volatility + gap) * math.Log10(1+avgVolume),
+	}, nil
+}
+
+// Watchlist 交易观察列表，由固定标的和扫描加入的标的组成
+type Watchlist struct {
+	static   []string
+	promoted []string
+	mutex    sync.RWMutex
+}
+
+// NewWatchlist 创建观察列表
+func NewWatchlist(static []string) *Watchlist {
+	return &Watchlist{static: normalizeSymbols(static)}
+}
+
+// SetStatic 替换固定标的（热加载配置时使用）
+func (w *Watchlist) SetStatic(symbols []string) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.static = normalizeSymbols(symbols)
+}
+
+// SetPromoted 用最新扫描结果替换扫描加入的标的
+func (w *Watchlist) SetPromoted(symbols []string) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	previous := make(map[string]bool, len(w.promoted))
+	for _, symbol := range w.promoted {
+		previous[symbol] = true
+	}
+
+	w.promoted = normalizeSymbols(symbols)
+	for _, symbol := range w.promoted {
+		if !previous[symbol] && !w.isStatic(symbol) {
+			log.Printf("标的加入观察列表: %s", symbol)
+		}
+		delete(previous, symbol)
+	}
+	for symbol := range previous {
+		log.Printf("标的移出观察列表: %s", symbol)
+	}
+}
+
+// Symbols 获取当前交易标的（固定标的在前，去重）
+func (w *Watchlist) Symbols() []string {
+	w.mutex.RLock()
+	defer w.mutex.RUnlock()
+
+	return normalizeSymbols(append(append([]string(nil), w.static...), w.promoted...))
+}
+
+// Promoted 获取扫描加入的标的
+func (w *Watchlist) Promoted() []string {
+	w.mutex.RLock()
+	defer w.mutex.RUnlock()
+
+	return append([]string(nil), w.promoted...)
+}
+
+// isStatic 是否为固定标的
+func (w *Watchlist) isStatic(symbol string) bool {
+	for _, s := range w.static {
+		if s == symbol {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeSymbols 标的代码转大写并去重，保持原有顺序
+func normalizeSymbols(symbols []string) []string {
+	seen := make(map[string]bool, len(symbols))
+	result := make([]string, 0, len(symbols))
+	for _, symbol := range symbols {
+		symbol = strings.ToUpper(strings.TrimSpace(symbol))
+		if symbol == "" || seen[symbol] {
+			continue
+		}
+		seen[symbol] = true
+		result = append(result, symbol)
+	}
+	return result
+}
@@ -0,0 +1,277 @@
+package schedule
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"agent-quant-system/internal/config"
+)
+
+// weekdayNames 星期的配置写法
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// clockRange 一天内的时间窗口（分钟），end 小于 start 表示跨越午夜
+type clockRange struct {
+	start, end int
+}
+
+// contains 是否包含某一时刻
+func (r clockRange) contains(minute int) bool {
+	if r.start <= r.end {
+		return minute >= r.start && minute < r.end
+	}
+	return minute >= r.start || minute < r.end
+}
+
+// dateRange 日期区间（含首尾）；annual 为 true 时只比较月日，每年重复
+type dateRange struct {
+	start, end time.Time
+	annual     bool
+}
+
+// contains 是否包含某一天
+func (r dateRange) contains(t time.Time) bool {
+	if !r.annual {
+		day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		return !day.Before(r.start) && !day.After(r.end)
+	}
+
+	md := int(t.Month())*100 + t.Day()
+	start := int(r.start.Month())*100 + r.start.Day()
+	end := int(r.end.Month())*100 + r.end.Day()
+	if start <= end {
+		return md >= start && md <= end
+	}
+	// 跨年区间，如 12-15:01-15
+	return md >= start || md <= end
+}
+
+// Schedule 交易时间表：允许的星期、时间窗口、日期区间和禁止交易日期
+type Schedule struct {
+	location *time.Location
+	days     map[time.Weekday]bool
+	windows  []clockRange
+	dates    []dateRange
+	blackout []dateRange
+}
+
+// New 根据配置创建时间表
+func New(cfg config.ScheduleConfig) (*Schedule, error) {
+	s := &Schedule{location: time.Local}
+
+	if cfg.Timezone != "" {
+		location, err := time.LoadLocation(cfg.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("无效的时区 %q: %w", cfg.Timezone, err)
+		}
+		s.location = location
+	}
+
+	if len(cfg.Days) > 0 {
+		s.days = make(map[time.Weekday]bool, len(cfg.Days))
+		for _, name := range cfg.Days {
+			day, ok := parseWeekday(name)
+			if !ok {
+				return nil, fmt.Errorf("无效的星期: %q", name)
+			}
+			s.days[day] = true
+		}
+	}
+
+	for _, window := range cfg.Windows {
+		r, err := parseClockRange(window)
+		if err != nil {
+			return nil, err
+		}
+		s.windows = append(s.windows, r)
+	}
+
+	for _, value := range cfg.Dates {
+		r, err := parseDateRange(value)
+		if err != nil {
+			return nil, err
+		}
+		s.dates = append(s.dates, r)
+	}
+
+	for _, value := range cfg.Blackout {
+		r, err := parseDateRange(value)
+		if err != nil {
+			return nil, err
+		}
+		s.blackout = append(s.blackout, r)
+	}
+
+	return s, nil
+}
+
+// Allows 判断某一时刻是否允许运行，不允许时返回原因
+func (s *Schedule) Allows(t time.Time) (bool, string) {
+	if s == nil {
+		return true, ""
+	}
+
+	local := t.In(s.location)
+
+	for _, r := range s.blackout {
+		if r.contains(local) {
+			return false, fmt.Sprintf("%s 为禁止交易日期", local.Format("2006-01-02"))
+		}
+	}
+
+	if s.days != nil && !s.days[local.Weekday()] {
+		return false, fmt.Sprintf("%s 不在允许的星期内", local.Weekday())
+	}
+
+	if len(s.dates) > 0 {
+		allowed := false
+		for _, r := range s.dates {
+			if r.contains(local) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return false, fmt.Sprintf("%s 不在允许的日期区间内", local.Format("2006-01-02"))
+		}
+	}
+
+	if len(s.windows) > 0 {
+		minute := local.Hour()*60 + local.Minute()
+		allowed := false
+		for _, r := range s.windows {
+			if r.contains(minute) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return false, fmt.Sprintf("%s 不在允许的时间窗口内", local.Format("15:04"))
+		}
+	}
+
+	return true, ""
+}
+
+// parseWeekday 解析星期，支持 "mon"、"Monday" 等写法
+func parseWeekday(name string) (time.Weekday, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if len(name) < 3 {
+		return 0, false
+	}
+	day, ok := weekdayNames[name[:3]]
+	return day, ok
+}
+
+// parseClockRange 解析 "09:30-16:00" 格式的时间窗口
+func parseClockRange(value string) (clockRange, error) {
+	parts := strings.Split(value, "-")
+	if len(parts) != 2 {
+		return clockRange{}, fmt.Errorf("无效的时间窗口 %q，格式应为 HH:MM-HH:MM", value)
+	}
+
+	var minutes [2]int
+	for i, part := range parts {
+		clock, err := time.Parse("15:04", strings.TrimSpace(part))
+		if err != nil {
+			return clockRange{}, fmt.Errorf("无效的时间窗口 %q: %w", value, err)
+		}
+		minutes[i] = clock.Hour()*60 + clock.Minute()
+	}
+	return clockRange{start: minutes[0], end: minutes[1]}, nil
+}
+
+// parseDateRange 解析日期或日期区间：
+// "2025-12-25"、"2025-01-20:2025-02-10" 为具体日期；"12-25"、"01-15:02-15" 为每年重复的月日
+func parseDateRange(value string) (dateRange, error) {
+	parts := strings.Split(value, ":")
+	if len(parts) > 2 {
+		return dateRange{}, fmt.Errorf("无效的日期区间 %q", value)
+	}
+	if len(parts) == 1 {
+		parts = append(parts, parts[0])
+	}
+
+	var r dateRange
+	var dates [2]time.Time
+	for i, part := range parts {
+		part = strings.TrimSpace(part)
+		layout := "2006-01-02"
+		annual := len(part) == len("01-02")
+		if annual {
+			layout = "01-02"
+		}
+		date, err := time.Parse(layout, part)
+		if err != nil {
+			return dateRange{}, fmt.Errorf("无效的日期区间 %q: %w", value, err)
+		}
+		if i > 0 && annual != r.annual {
+			return dateRange{}, fmt.Errorf("无效的日期区间 %q: 首尾格式不一致", value)
+		}
+		r.annual = annual
+		dates[i] = date
+	}
+
+	r.start, r.end = dates[0], dates[1]
+	if !r.annual && r.end.Before(r.start) {
+		return dateRange{}, fmt.Errorf("无效的日期区间 %q: 结束日期早于开始日期", value)
+	}
+	return r, nil
+}
+
+// Scheduler 按策略和标的管理交易时间表
+type Scheduler struct {
+	strategies map[string]*Schedule
+	symbols    map[string]*Schedule
+}
+
+// NewScheduler 根据配置创建调度器
+func NewScheduler(strategies, symbols map[string]config.ScheduleConfig) (*Scheduler, error) {
+	scheduler := &Scheduler{
+		strategies: make(map[string]*Schedule, len(strategies)),
+		symbols:    make(map[string]*Schedule, len(symbols)),
+	}
+
+	for name, cfg := range strategies {
+		s, err := New(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("策略 '%s' 的时间表无效: %w", name, err)
+		}
+		scheduler.strategies[strings.ToLower(name)] = s
+	}
+	for symbol, cfg := range symbols {
+		s, err := New(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("标的 '%s' 的时间表无效: %w", symbol, err)
+		}
+		scheduler.symbols[strings.ToUpper(symbol)] = s
+	}
+
+	return scheduler, nil
+}
+
+// SymbolAllowed 判断标的在某一时刻是否允许交易
+func (s *Scheduler) SymbolAllowed(symbol string, t time.Time) (bool, string) {
+	if s == nil {
+		return true, ""
+	}
+	return s.symbols[strings.ToUpper(symbol)].Allows(t)
+}
+
+// Allowed 判断策略在某一时刻是否允许对标的运行（策略和标的的时间表都需满足）
+func (s *Scheduler) Allowed(strategyName, symbol string, t time.Time) (bool, string) {
+	if s == nil {
+		return true, ""
+	}
+	if ok, reason := s.strategies[strings.ToLower(strategyName)].Allows(t); !ok {
+		return false, "策略时间表: " + reason
+	}
+	if ok, reason := s.SymbolAllowed(symbol, t); !ok {
+		return false, "标的时间表: " + reason
+	}
+	return true, ""
+}
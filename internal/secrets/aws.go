@@ -0,0 +1,172 @@
+package secrets
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"agent-quant-system/internal/config"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// awsService Secrets Manager 的签名服务名
+const awsService = "secretsmanager"
+
+// AWSProvider 从 AWS Secrets Manager 读取密钥，引用格式为 "aws:密钥ID#字段"，
+// 密钥值为 JSON 对象时按字段取值，否则不带字段直接使用密钥字符串。
+// 访问凭证从 AWS_ACCESS_KEY_ID、AWS_SECRET_ACCESS_KEY 和可选的 AWS_SESSION_TOKEN 环境变量读取
+type AWSProvider struct {
+	httpClient *resty.Client
+	region     string
+	endpoint   string
+	now        func() time.Time
+}
+
+// NewAWSProvider 创建 AWS Secrets Manager 密钥源，未配置区域时使用 AWS_REGION 环境变量
+func NewAWSProvider(cfg config.AWSSecretsConfig) *AWSProvider {
+	region := cfg.Region
+	if region == "" {
+		region = os.Getenv("AWS_REGION")
+	}
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+
+	client := resty.New()
+	client.SetTimeout(timeout)
+
+	return &AWSProvider{
+		httpClient: client,
+		region:     region,
+		endpoint:   strings.TrimRight(cfg.Endpoint, "/"),
+		now:        time.Now,
+	}
+}
+
+// Name 密钥源名称
+func (a *AWSProvider) Name() string {
+	return "aws"
+}
+
+// Lookup 调用 GetSecretValue 读取密钥
+func (a *AWSProvider) Lookup(reference string) (string, error) {
+	if a.region == "" {
+		return "", fmt.Errorf("未配置 secrets.aws.region 或 AWS_REGION")
+	}
+	accessKey := os.Getenv("AWS_ACCESS_KEY_ID")
+	secretKey := os.Getenv("AWS_SECRET_ACCESS_KEY")
+	if accessKey == "" || secretKey == "" {
+		return "", fmt.Errorf("AWS_ACCESS_KEY_ID 或 AWS_SECRET_ACCESS_KEY 环境变量未设置")
+	}
+	secretID, field := splitField(reference)
+
+	endpoint := a.endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://%s.%s.amazonaws.com", awsService, a.region)
+	}
+	parsed, err := url.Parse(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("无效的 secrets.aws.endpoint: %w", err)
+	}
+
+	body, err := json.Marshal(map[string]string{"SecretId": secretID})
+	if err != nil {
+		return "", err
+	}
+	headers := map[string]string{
+		"content-type": "application/x-amz-json-1.1",
+		"host":         parsed.Host,
+		"x-amz-date":   a.now().UTC().Format("20060102T150405Z"),
+		"x-amz-target": "secretsmanager.GetSecretValue",
+	}
+	if token := os.Getenv("AWS_SESSION_TOKEN"); token != "" {
+		headers["x-amz-security-token"] = token
+	}
+	headers["authorization"] = signV4(headers, body, a.region, accessKey, secretKey)
+
+	var result struct {
+		SecretString string `json:"SecretString"`
+		Message      string `json:"message"`
+		Type         string `json:"__type"`
+	}
+	request := a.httpClient.R().SetBody(body).SetResult(&result).SetError(&result)
+	for name, value := range headers {
+		if name != "host" {
+			request.SetHeader(name, value)
+		}
+	}
+	// 响应类型为 application/x-amz-json-1.1，需要强制按 JSON 解析
+	request.ForceContentType("application/json")
+	resp, err := request.Post(endpoint + "/")
+	if err != nil {
+		return "", fmt.Errorf("请求 Secrets Manager 失败: %w", err)
+	}
+	if resp.IsError() {
+		return "", fmt.Errorf("Secrets Manager 返回状态码 %d: %s %s", resp.StatusCode(), result.Type, result.Message)
+	}
+
+	if field == "" {
+		return result.SecretString, nil
+	}
+	var values map[string]interface{}
+	if err := json.Unmarshal([]byte(result.SecretString), &values); err != nil {
+		return "", fmt.Errorf("密钥 %s 不是 JSON 对象，不能按字段 %s 取值", secretID, field)
+	}
+	return pickField(values, secretID, field)
+}
+
+// signV4 按 AWS Signature Version 4 计算请求的 Authorization 头，headers 的键需为小写
+func signV4(headers map[string]string, body []byte, region, accessKey, secretKey string) string {
+	amzDate := headers["x-amz-date"]
+	date := amzDate[:8]
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(headers[name]) + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		"POST", "/", "", canonicalHeaders.String(), signedHeaders, hashHex(body),
+	}, "\n")
+	scope := strings.Join([]string{date, region, awsService, "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256", amzDate, scope, hashHex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+secretKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, awsService)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	return fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		accessKey, scope, signedHeaders, signature)
+}
+
+// hashHex 计算 SHA-256 并转为十六进制
+func hashHex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// hmacSHA256 计算 HMAC-SHA256
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,54 @@
+package secrets
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// KeyringProvider 从操作系统钥匙串读取密钥，引用格式为 "keyring:服务/用户"。
+// macOS 使用 security 命令读取登录钥匙串，Linux 使用 secret-tool 读取 Secret Service（GNOME Keyring、KWallet）
+type KeyringProvider struct {
+	goos string
+}
+
+// NewKeyringProvider 创建钥匙串密钥源
+func NewKeyringProvider() *KeyringProvider {
+	return &KeyringProvider{goos: runtime.GOOS}
+}
+
+// Name 密钥源名称
+func (k *KeyringProvider) Name() string {
+	return "keyring"
+}
+
+// Lookup 读取钥匙串中的密钥
+func (k *KeyringProvider) Lookup(reference string) (string, error) {
+	service, user, found := strings.Cut(reference, "/")
+	if !found || service == "" || user == "" {
+		return "", fmt.Errorf("钥匙串引用格式应为 服务/用户: %s", reference)
+	}
+
+	var cmd *exec.Cmd
+	switch k.goos {
+	case "darwin":
+		cmd = exec.Command("security", "find-generic-password", "-s", service, "-a", user, "-w")
+	case "linux", "freebsd", "openbsd":
+		cmd = exec.Command("secret-tool", "lookup", "service", service, "username", user)
+	default:
+		return "", fmt.Errorf("不支持在 %s 上读取钥匙串", k.goos)
+	}
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return "", fmt.Errorf("读取钥匙串 %s 失败: %s", reference, message)
+		}
+		return "", fmt.Errorf("读取钥匙串 %s 失败: %w", reference, err)
+	}
+	return strings.TrimRight(string(output), "\r\n"), nil
+}
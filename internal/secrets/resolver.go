@@ -0,0 +1,135 @@
+package secrets
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"agent-quant-system/internal/config"
+)
+
+// Provider 密钥源接口
+type Provider interface {
+	// Name 密钥源名称
+	Name() string
+
+	// Lookup 按引用（去掉 "scheme:" 前缀后的部分）获取密钥
+	Lookup(reference string) (string, error)
+}
+
+// cachedSecret 缓存的解析结果
+type cachedSecret struct {
+	value     string
+	expiresAt time.Time
+}
+
+// Resolver 按 "scheme:引用" 格式从对应的密钥源解析配置中的密钥；
+// 没有已注册前缀的值视为明文密钥原样返回，以兼容旧配置
+type Resolver struct {
+	providers map[string]Provider
+	ttl       time.Duration
+	cache     map[string]cachedSecret
+	mutex     sync.Mutex
+}
+
+// NewResolver 按配置创建密钥解析器，注册 env、keyring、vault 和 aws 密钥源
+func NewResolver(cfg config.SecretsConfig) *Resolver {
+	resolver := &Resolver{
+		providers: make(map[string]Provider),
+		ttl:       cfg.CacheTTL,
+		cache:     make(map[string]cachedSecret),
+	}
+	resolver.Register("env", EnvProvider{})
+	resolver.Register("keyring", NewKeyringProvider())
+	resolver.Register("vault", NewVaultProvider(cfg.Vault))
+	resolver.Register("aws", NewAWSProvider(cfg.AWS))
+	return resolver
+}
+
+// Register 注册密钥源，已存在时覆盖
+func (r *Resolver) Register(scheme string, provider Provider) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.providers[strings.ToLower(scheme)] = provider
+}
+
+// IsReference 值是否为已注册密钥源的引用
+func (r *Resolver) IsReference(value string) bool {
+	_, _, ok := r.split(value)
+	return ok
+}
+
+// split 拆分引用，返回密钥源和引用部分
+func (r *Resolver) split(value string) (Provider, string, bool) {
+	scheme, reference, found := strings.Cut(value, ":")
+	if !found || reference == "" {
+		return nil, "", false
+	}
+	r.mutex.Lock()
+	provider, exists := r.providers[strings.ToLower(scheme)]
+	r.mutex.Unlock()
+	return provider, reference, exists
+}
+
+// Resolve 解析密钥；引用的解析结果在 cache_ttl 内复用，过期后重新获取，使密钥轮换后无需重启
+func (r *Resolver) Resolve(value string) (Secret, error) {
+	provider, reference, ok := r.split(value)
+	if !ok {
+		return Secret(value), nil
+	}
+
+	now := time.Now()
+	r.mutex.Lock()
+	cached, exists := r.cache[value]
+	r.mutex.Unlock()
+	if exists && now.Before(cached.expiresAt) {
+		return Secret(cached.value), nil
+	}
+
+	secret, err := provider.Lookup(reference)
+	if err != nil {
+		return "", fmt.Errorf("从 %s 获取密钥失败: %w", provider.Name(), err)
+	}
+	if secret == "" {
+		return "", fmt.Errorf("%s 中的密钥为空: %s", provider.Name(), reference)
+	}
+
+	if r.ttl > 0 {
+		r.mutex.Lock()
+		r.cache[value] = cachedSecret{value: secret, expiresAt: now.Add(r.ttl)}
+		r.mutex.Unlock()
+	}
+	return Secret(secret), nil
+}
+
+// Invalidate 清空缓存，下次解析时重新从密钥源获取
+func (r *Resolver) Invalidate() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.cache = make(map[string]cachedSecret)
+}
+
+// EnvProvider 从环境变量读取密钥，引用格式为 "env:变量名"
+type EnvProvider struct{}
+
+// Name 密钥源名称
+func (EnvProvider) Name() string {
+	return "env"
+}
+
+// Lookup 读取环境变量
+func (EnvProvider) Lookup(reference string) (string, error) {
+	value, exists := os.LookupEnv(reference)
+	if !exists {
+		return "", fmt.Errorf("环境变量 %s 未设置", reference)
+	}
+	return value, nil
+}
+
+// splitField 拆分 "路径#字段" 格式的引用，没有字段时返回空字符串
+func splitField(reference string) (string, string) {
+	path, field, _ := strings.Cut(reference, "#")
+	return path, field
+}
@@ -0,0 +1,40 @@
+package secrets
+
+// redacted 输出密钥时使用的占位符
+const redacted = "******"
+
+// Secret 解析后的密钥；格式化输出和序列化时只显示占位符，需要明文时调用 Reveal
+type Secret string
+
+// Reveal 返回密钥明文
+func (s Secret) Reveal() string {
+	return string(s)
+}
+
+// IsEmpty 密钥是否为空
+func (s Secret) IsEmpty() bool {
+	return s == ""
+}
+
+// String 格式化输出时隐藏密钥
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+	return redacted
+}
+
+// GoString 按 %#v 输出时隐藏密钥
+func (s Secret) GoString() string {
+	return `"` + s.String() + `"`
+}
+
+// MarshalJSON 序列化时隐藏密钥
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + s.String() + `"`), nil
+}
+
+// MarshalText 序列化为文本时隐藏密钥
+func (s Secret) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
@@ -0,0 +1,120 @@
+package secrets
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"agent-quant-system/internal/config"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// VaultProvider 从 HashiCorp Vault 的 KV v2 引擎读取密钥，引用格式为 "vault:路径#字段"；
+// 令牌从 VAULT_TOKEN 环境变量读取，不写入配置文件
+type VaultProvider struct {
+	httpClient *resty.Client
+	address    string
+	mount      string
+	namespace  string
+}
+
+// NewVaultProvider 创建 Vault 密钥源，未配置地址时使用 VAULT_ADDR 环境变量
+func NewVaultProvider(cfg config.VaultSecretsConfig) *VaultProvider {
+	address := cfg.Address
+	if address == "" {
+		address = os.Getenv("VAULT_ADDR")
+	}
+	mount := cfg.Mount
+	if mount == "" {
+		mount = "secret"
+	}
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+
+	client := resty.New()
+	client.SetTimeout(timeout)
+	client.SetHeader("Accept", "application/json")
+
+	return &VaultProvider{
+		httpClient: client,
+		address:    strings.TrimRight(address, "/"),
+		mount:      strings.Trim(mount, "/"),
+		namespace:  cfg.Namespace,
+	}
+}
+
+// Name 密钥源名称
+func (v *VaultProvider) Name() string {
+	return "vault"
+}
+
+// Lookup 读取密钥；引用没有字段时，密钥只有一个字段才可以省略
+func (v *VaultProvider) Lookup(reference string) (string, error) {
+	if v.address == "" {
+		return "", fmt.Errorf("未配置 secrets.vault.address 或 VAULT_ADDR")
+	}
+	token := os.Getenv("VAULT_TOKEN")
+	if token == "" {
+		return "", fmt.Errorf("VAULT_TOKEN 环境变量未设置")
+	}
+	path, field := splitField(reference)
+
+	var result struct {
+		Data struct {
+			Data map[string]interface{} `json:"data"`
+		} `json:"data"`
+		Errors []string `json:"errors"`
+	}
+	request := v.httpClient.R().
+		SetHeader("X-Vault-Token", token).
+		SetResult(&result).
+		SetError(&result).
+		ForceContentType("application/json")
+	if v.namespace != "" {
+		request.SetHeader("X-Vault-Namespace", v.namespace)
+	}
+	resp, err := request.Get(fmt.Sprintf("%s/v1/%s/data/%s", v.address, v.mount, strings.Trim(path, "/")))
+	if err != nil {
+		return "", fmt.Errorf("请求 Vault 失败: %w", err)
+	}
+	if resp.IsError() {
+		if len(result.Errors) > 0 {
+			return "", fmt.Errorf("Vault 返回状态码 %d: %s", resp.StatusCode(), strings.Join(result.Errors, "; "))
+		}
+		return "", fmt.Errorf("Vault 返回状态码 %d", resp.StatusCode())
+	}
+
+	return pickField(result.Data.Data, path, field)
+}
+
+// pickField 从键值对形式的密钥中取出字段
+func pickField(values map[string]interface{}, path, field string) (string, error) {
+	if field == "" {
+		if len(values) != 1 {
+			keys := make([]string, 0, len(values))
+			for key := range values {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			return "", fmt.Errorf("%s 包含 %d 个字段 (%s)，需要用 #字段 指定", path, len(values), strings.Join(keys, ", "))
+		}
+		for key := range values {
+			field = key
+		}
+	}
+
+	value, exists := values[field]
+	if !exists {
+		return "", fmt.Errorf("%s 中没有字段 %s", path, field)
+	}
+	text, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("%s 的字段 %s 不是字符串", path, field)
+	}
+	return text, nil
+}
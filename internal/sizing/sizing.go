@@ -0,0 +1,215 @@
+package sizing
+
+import (
+	"fmt"
+	"math"
+
+	"agent-quant-system/internal/config"
+)
+
+// Stats 策略已平仓交易的盈亏统计
+type Stats struct {
+	Trades      int
+	Wins        int
+	GrossProfit float64 // 盈利交易的盈利合计
+	GrossLoss   float64 // 亏损交易的亏损合计（正数）
+}
+
+// Add 记入一笔已平仓交易的盈亏
+func (s *Stats) Add(pnl float64) {
+	s.Trades++
+	if pnl > 0 {
+		s.Wins++
+		s.GrossProfit += pnl
+	} else {
+		s.GrossLoss -= pnl
+	}
+}
+
+// WinRate 胜率
+func (s Stats) WinRate() float64 {
+	if s.Trades == 0 {
+		return 0
+	}
+	return float64(s.Wins) / float64(s.Trades)
+}
+
+// PayoffRatio 盈亏比：平均盈利 / 平均亏损，没有亏损交易时为 +Inf
+func (s Stats) PayoffRatio() float64 {
+	losses := s.Trades - s.Wins
+	if s.Wins == 0 {
+		return 0
+	}
+	if losses == 0 || s.GrossLoss == 0 {
+		return math.Inf(1)
+	}
+	return (s.GrossProfit / float64(s.Wins)) / (s.GrossLoss / float64(losses))
+}
+
+// Request 计算开仓数量所需的信息
+type Request struct {
+	Equity     float64 // 账户权益
+	Price      float64 // 参考价格
+	StopLoss   float64 // 止损价，0表示没有止损
+	Quantity   float64 // 策略给出的数量
+	Multiplier float64 // 合约乘数，0表示1
+	Stats      Stats   // 策略已平仓交易的统计
+}
+
+// unitValue 每单位数量的市值
+func (r Request) unitValue() float64 {
+	if r.Multiplier > 0 {
+		return r.Price * r.Multiplier
+	}
+	return r.Price
+}
+
+// Policy 仓位计算方式，返回开仓数量（未按精度取整），0表示不开仓
+type Policy interface {
+	// Name 方式名称
+	Name() string
+
+	// Quantity 计算开仓数量
+	Quantity(req Request) float64
+}
+
+// FixedFractional 每笔承担权益的 Fraction 比例的风险：有止损时数量 = 权益 * Fraction / 止损距离，
+// 否则买入权益的 Fraction 比例的市值
+type FixedFractional struct {
+	Fraction float64
+}
+
+// Name 方式名称
+func (p FixedFractional) Name() string { return "fixed_fractional" }
+
+// Quantity 按风险金额和止损距离计算数量
+func (p FixedFractional) Quantity(req Request) float64 {
+	if req.Equity <= 0 || req.unitValue() <= 0 {
+		return 0
+	}
+	risk := req.Equity * p.Fraction
+	if req.StopLoss > 0 && req.StopLoss < req.Price {
+		perUnit := req.unitValue() * (req.Price - req.StopLoss) / req.Price
+		// 止损很近时数量可能很大，不超过权益可买入的数量
+		return math.Min(risk/perUnit, req.Equity/req.unitValue())
+	}
+	return risk / req.unitValue()
+}
+
+// FixedNotional 每笔买入固定金额
+type FixedNotional struct {
+	Notional float64
+}
+
+// Name 方式名称
+func (p FixedNotional) Name() string { return "fixed_notional" }
+
+// Quantity 固定金额除以单位市值
+func (p FixedNotional) Quantity(req Request) float64 {
+	if req.unitValue() <= 0 {
+		return 0
+	}
+	return p.Notional / req.unitValue()
+}
+
+// Kelly 按胜率和盈亏比计算 Kelly 比例，乘以 Fraction 后不超过 MaxFraction；
+// 已平仓交易少于 MinTrades 时使用先验胜率和盈亏比
+type Kelly struct {
+	Fraction    float64
+	MaxFraction float64
+	WinRate     float64
+	PayoffRatio float64
+	MinTrades   int
+}
+
+// Name 方式名称
+func (p Kelly) Name() string { return "kelly" }
+
+// Edge 按统计或先验计算的 Kelly 比例 W - (1-W)/R，没有优势时为0或负数
+func (p Kelly) Edge(stats Stats) float64 {
+	winRate, payoff := p.WinRate, p.PayoffRatio
+	if stats.Trades > 0 && stats.Trades >= p.MinTrades {
+		winRate, payoff = stats.WinRate(), stats.PayoffRatio()
+	}
+	if payoff <= 0 {
+		return -1
+	}
+	if math.IsInf(payoff, 1) {
+		return winRate
+	}
+	return winRate - (1-winRate)/payoff
+}
+
+// Quantity 权益乘以（打折并封顶的）Kelly 比例后的市值对应的数量
+func (p Kelly) Quantity(req Request) float64 {
+	if req.Equity <= 0 || req.unitValue() <= 0 {
+		return 0
+	}
+	fraction := p.Edge(req.Stats) * p.Fraction
+	if fraction <= 0 {
+		return 0
+	}
+	if p.MaxFraction > 0 {
+		fraction = math.Min(fraction, p.MaxFraction)
+	}
+	return req.Equity * fraction / req.unitValue()
+}
+
+// New 按配置创建仓位计算方式，未配置方式时返回 nil（使用策略给出的数量）
+func New(cfg config.SizingConfig) (Policy, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	switch cfg.Method {
+	case "":
+		return nil, nil
+	case "fixed_fractional":
+		return FixedFractional{Fraction: cfg.Fraction}, nil
+	case "fixed_notional":
+		return FixedNotional{Notional: cfg.Notional}, nil
+	case "kelly":
+		return Kelly{
+			Fraction:    cfg.KellyFraction,
+			MaxFraction: cfg.MaxFraction,
+			WinRate:     cfg.WinRate,
+			PayoffRatio: cfg.PayoffRatio,
+			MinTrades:   cfg.MinTrades,
+		}, nil
+	default:
+		return nil, fmt.Errorf("未知的仓位计算方式: %s", cfg.Method)
+	}
+}
+
+// Table 各策略的仓位计算方式
+type Table struct {
+	defaults   Policy
+	strategies map[string]Policy
+}
+
+// NewTable 按 risk.sizing 和 strategy.sizing 创建各策略的仓位计算方式
+func NewTable(cfg *config.Config) (*Table, error) {
+	defaults, err := New(cfg.Risk.Sizing)
+	if err != nil {
+		return nil, fmt.Errorf("risk.sizing 配置无效: %w", err)
+	}
+	table := &Table{defaults: defaults, strategies: make(map[string]Policy, len(cfg.Strategy.Sizing))}
+	for name, sizingConfig := range cfg.Strategy.Sizing {
+		policy, err := New(sizingConfig)
+		if err != nil {
+			return nil, fmt.Errorf("strategy.sizing.%s 配置无效: %w", name, err)
+		}
+		table.strategies[name] = policy
+	}
+	return table, nil
+}
+
+// For 策略的仓位计算方式，按策略配置了空的 method 或都未配置时返回 nil
+func (t *Table) For(strategyName string) Policy {
+	if t == nil {
+		return nil
+	}
+	if policy, ok := t.strategies[strategyName]; ok {
+		return policy
+	}
+	return t.defaults
+}
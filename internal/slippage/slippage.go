@@ -0,0 +1,116 @@
+package slippage
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"agent-quant-system/internal/config"
+)
+
+// bps 一个基点
+const bps = 0.0001
+
+// Order 估算滑点所需的订单和行情信息，未知的行情字段为0
+type Order struct {
+	Symbol   string
+	Buy      bool
+	Quantity float64
+	Price    float64 // 参考价格
+	Time     time.Time
+
+	Volume float64 // 参考成交量：回测为当前K线成交量，纸面交易为近期日均成交量
+	High   float64 // 当前K线最高价
+	Low    float64 // 当前K线最低价
+}
+
+// Model 滑点模型，返回相对参考价格的不利滑点率
+type Model interface {
+	// Name 模型名称
+	Name() string
+
+	// Rate 估算订单的滑点率
+	Rate(order Order) float64
+}
+
+// Fixed 固定滑点
+type Fixed struct {
+	BPS float64
+}
+
+// Name 模型名称
+func (m Fixed) Name() string { return "fixed" }
+
+// Rate 固定滑点率
+func (m Fixed) Rate(order Order) float64 {
+	return m.BPS * bps
+}
+
+// Spread 按买卖价差的一半估算滑点：K线高低价已知时价差取振幅的 RangeFraction，否则使用固定价差
+type Spread struct {
+	SpreadBPS     float64
+	RangeFraction float64
+}
+
+// Name 模型名称
+func (m Spread) Name() string { return "spread" }
+
+// Rate 半个价差
+func (m Spread) Rate(order Order) float64 {
+	spread := m.SpreadBPS * bps
+	if m.RangeFraction > 0 && order.Price > 0 && order.High > order.Low {
+		spread = (order.High - order.Low) / order.Price * m.RangeFraction
+	}
+	return spread / 2
+}
+
+// VolumeImpact 按成交量参与率的市场冲击：滑点率 = 基础滑点 + 冲击系数 * (数量/成交量)^指数，成交量未知时只计基础滑点
+type VolumeImpact struct {
+	BaseBPS     float64
+	Coefficient float64
+	Exponent    float64
+}
+
+// Name 模型名称
+func (m VolumeImpact) Name() string { return "volume_impact" }
+
+// Rate 基础滑点加冲击成本
+func (m VolumeImpact) Rate(order Order) float64 {
+	rate := m.BaseBPS * bps
+	if order.Volume > 0 && order.Quantity > 0 {
+		rate += m.Coefficient * math.Pow(order.Quantity/order.Volume, m.Exponent)
+	}
+	return rate
+}
+
+// capped 给模型加上单笔滑点上限
+type capped struct {
+	Model
+	max float64
+}
+
+// Rate 不超过上限的滑点率
+func (m capped) Rate(order Order) float64 {
+	return math.Min(m.Model.Rate(order), m.max)
+}
+
+// New 按配置创建滑点模型，未配置模型时返回 nil
+func New(cfg config.SlippageConfig) (Model, error) {
+	var model Model
+	switch cfg.Model {
+	case "":
+		return nil, nil
+	case "fixed":
+		model = Fixed{BPS: cfg.FixedBPS}
+	case "spread":
+		model = Spread{SpreadBPS: cfg.SpreadBPS, RangeFraction: cfg.RangeFraction}
+	case "volume_impact":
+		model = VolumeImpact{BaseBPS: cfg.BaseBPS, Coefficient: cfg.ImpactCoefficient, Exponent: cfg.ImpactExponent}
+	default:
+		return nil, fmt.Errorf("未知的滑点模型: %s", cfg.Model)
+	}
+	if cfg.MaxBPS > 0 {
+		model = capped{Model: model, max: cfg.MaxBPS * bps}
+	}
+	return model, nil
+}
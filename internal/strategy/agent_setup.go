@@ -0,0 +1,212 @@
+package strategy
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"agent-quant-system/internal/data"
+	"agent-quant-system/internal/indicators"
+)
+
+// TradeSetupConsumer 需要Agent交易方案的策略（可选接口），引擎只在有这类策略运行时才请求交易方案
+type TradeSetupConsumer interface {
+	UsesTradeSetup() bool
+}
+
+// AgentSetupStrategy 把Agent建议的交易方案换算为交易信号：
+// 按目标仓位与当前持仓的差额下单，并校验置信度、仓位上限和止损价格
+type AgentSetupStrategy struct {
+	BaseStrategy
+}
+
+// NewAgentSetupStrategy 创建Agent交易方案策略
+func NewAgentSetupStrategy() *AgentSetupStrategy {
+	return &AgentSetupStrategy{
+		BaseStrategy: BaseStrategy{
+			Name:        "Agent交易方案策略",
+			Description: "按Agent给出的操作、目标仓位和止损价格交易，信号仍经过风控校验",
+			Parameters: StrategyParams{
+				"min_confidence":            0.6,   // 低于该置信度的方案不交易
+				"max_allocation":            0.2,   // 单个标的目标仓位上限（占账户权益的比例）
+				"stop_loss_percent":         5.0,   // 方案未给出止损时使用的止损百分比
+				"max_stop_distance_percent": 15.0,  // 止损价格距当前价格的最大百分比，超出时拒绝方案
+				"min_trade_value":           100.0, // 调仓金额低于该值时不交易
+				"allow_short":               false, // 卖出方案是否可以开空头仓位
+			},
+			Metadata: StrategyMetadata{
+				Author:          "quant_service",
+				Version:         "1.0.0",
+				AssetClasses:    []string{"stock", "crypto"},
+				RequiredColumns: []string{"close"},
+				Tags:            []string{"Agent", "仓位管理"},
+			},
+		},
+	}
+}
+
+// UsesTradeSetup 需要Agent交易方案
+func (as *AgentSetupStrategy) UsesTradeSetup() bool {
+	return true
+}
+
+// ValidateParameters 验证策略参数
+func (as *AgentSetupStrategy) ValidateParameters(params StrategyParams) error {
+	for _, key := range []string{"min_confidence", "max_allocation"} {
+		if value, ok := params[key].(float64); ok && (value < 0 || value > 1) {
+			return fmt.Errorf("%s 必须在 0 到 1 之间", key)
+		}
+	}
+	for _, key := range []string{"stop_loss_percent", "max_stop_distance_percent"} {
+		if value, ok := params[key].(float64); ok && value <= 0 {
+			return fmt.Errorf("%s 必须大于0", key)
+		}
+	}
+	return nil
+}
+
+// Initialize 初始化策略
+func (as *AgentSetupStrategy) Initialize() error {
+	if err := as.ValidateParameters(as.Parameters); err != nil {
+		return fmt.Errorf("策略参数验证失败: %w", err)
+	}
+	as.IsActive = true
+	return nil
+}
+
+// GenerateSignals 把交易方案换算为调仓信号，没有方案或方案未通过校验时不生成信号
+func (as *AgentSetupStrategy) GenerateSignals(df data.DataFrame, guidance *AgentGuidance) ([]TradingSignal, error) {
+	if !as.IsActive {
+		return nil, fmt.Errorf("策略未激活")
+	}
+	if guidance == nil || guidance.Setup == nil {
+		return nil, nil
+	}
+	closes, err := indicators.Float64Column(df, "close")
+	if err != nil {
+		return nil, fmt.Errorf("数据验证失败: %w", err)
+	}
+	if len(closes) == 0 {
+		return nil, fmt.Errorf("数据验证失败: 没有价格数据")
+	}
+
+	signal, err := as.translate(SignalSymbol(df, guidance), closes[len(closes)-1], guidance.Setup)
+	if err != nil {
+		log.Printf("拒绝Agent交易方案: 标的=%s, 原因=%v", guidance.Symbol, err)
+		return nil, nil
+	}
+	if signal == nil {
+		return nil, nil
+	}
+	log.Printf("Agent交易方案生成信号: 标的=%s, %s %.4f @ %.2f, 止损=%.2f",
+		signal.Symbol, signal.Signal.String(), signal.Quantity, signal.Price, signal.StopLoss)
+	return []TradingSignal{*signal}, nil
+}
+
+// translate 按目标仓位计算调仓信号；返回 nil 信号表示无需交易，返回错误表示方案不可接受
+func (as *AgentSetupStrategy) translate(symbol string, price float64, setup *TradeSetup) (*TradingSignal, error) {
+	if setup.Action == Hold {
+		return nil, nil
+	}
+	if price <= 0 {
+		return nil, fmt.Errorf("无效的价格: %.4f", price)
+	}
+	if setup.Equity <= 0 {
+		return nil, fmt.Errorf("账户权益未知，无法换算目标仓位")
+	}
+	if minConfidence := as.GetFloat64Param("min_confidence", 0.6); setup.Confidence < minConfidence {
+		return nil, fmt.Errorf("置信度 %.2f 低于 %.2f", setup.Confidence, minConfidence)
+	}
+
+	// 目标净持仓：买入为多头，卖出为空头（不允许做空时为平仓）
+	allocation := math.Min(setup.TargetAllocation, as.GetFloat64Param("max_allocation", 0.2))
+	target := allocation * setup.Equity / price
+	if setup.Action == Sell {
+		target = -target
+		if !as.GetBoolParam("allow_short", false) {
+			target = 0
+		}
+	}
+
+	delta := target - setup.Position
+	if (setup.Action == Buy && delta <= 0) || (setup.Action == Sell && delta >= 0) {
+		return nil, nil
+	}
+	if value := math.Abs(delta) * price; value < as.GetFloat64Param("min_trade_value", 100) {
+		return nil, nil
+	}
+
+	reason := fmt.Sprintf("Agent交易方案: 目标仓位 %.1f%%", allocation*100)
+	if setup.Rationale != "" {
+		reason += ", " + setup.Rationale
+	}
+	signal := &TradingSignal{
+		Symbol:     symbol,
+		Signal:     setup.Action,
+		Price:      price,
+		Quantity:   math.Abs(delta),
+		Confidence: setup.Confidence,
+		Reason:     reason,
+		Timestamp:  time.Now(),
+	}
+
+	// 只在增加敞口（加多或加空）时设置止损止盈，减仓信号沿用原持仓的止损
+	increasing := (setup.Action == Buy && target > 0) || (setup.Action == Sell && target < 0)
+	if !increasing {
+		return signal, nil
+	}
+
+	stopLoss := setup.StopLoss
+	if stopLoss == 0 {
+		stopLoss = CalculateStopLoss(price, as.GetFloat64Param("stop_loss_percent", 5), setup.Action)
+	}
+	distance := (price - stopLoss) / price * 100
+	if setup.Action == Sell {
+		distance = -distance
+	}
+	if distance <= 0 {
+		return nil, fmt.Errorf("止损价格 %.4f 与操作方向不符（当前价格 %.4f）", stopLoss, price)
+	}
+	if maxDistance := as.GetFloat64Param("max_stop_distance_percent", 15); distance > maxDistance {
+		return nil, fmt.Errorf("止损距离 %.2f%% 超过上限 %.2f%%", distance, maxDistance)
+	}
+	signal.StopLoss = stopLoss
+
+	// 方向不符的止盈价格忽略
+	if setup.TakeProfit > 0 && (setup.TakeProfit-price)*(price-stopLoss) > 0 {
+		signal.TakeProfit = setup.TakeProfit
+	}
+	return signal, nil
+}
+
+// Explain 说明交易方案是否可用及校验结果
+func (as *AgentSetupStrategy) Explain(df data.DataFrame, guidance *AgentGuidance) *Explanation {
+	explanation := &Explanation{Indicators: make(map[string]float64)}
+	if guidance == nil || guidance.Setup == nil {
+		explanation.notef("本轮没有可用的Agent交易方案（没有新闻或Agent不可用）")
+		return explanation
+	}
+	setup := guidance.Setup
+	explanation.Indicators["confidence"] = setup.Confidence
+	explanation.Indicators["target_allocation"] = setup.TargetAllocation
+	explanation.Indicators["position"] = setup.Position
+	explanation.Indicators["equity"] = setup.Equity
+	explanation.notef("Agent方案: %s, 目标仓位 %.1f%%, 置信度 %.2f", setup.Action.String(), setup.TargetAllocation*100, setup.Confidence)
+
+	closes, err := indicators.Float64Column(df, "close")
+	if err != nil || len(closes) == 0 {
+		explanation.notef("没有价格数据")
+		return explanation
+	}
+	signal, err := as.translate(SignalSymbol(df, guidance), closes[len(closes)-1], setup)
+	switch {
+	case err != nil:
+		explanation.notef("方案被拒绝: %v", err)
+	case signal == nil:
+		explanation.notef("当前持仓已符合目标或调仓金额低于 min_trade_value，无需交易")
+	default:
+		explanation.notef("按目标仓位调仓 %.4f", signal.Quantity)
+	}
+	return explanation
+}
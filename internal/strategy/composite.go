@@ -0,0 +1,798 @@
+package strategy
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"agent-quant-system/internal/data"
+	"agent-quant-system/internal/indicators"
+)
+
+// CompositeSpec 组合策略的声明：入场规则给出买卖方向，过滤条件依次检查入场信号，
+// 离场规则设置止损止盈或发出离场信号，仓位计算方式决定下单数量
+type CompositeSpec struct {
+	Description string
+	Filters     []ComponentSpec
+	Entry       ComponentSpec
+	Exit        ComponentSpec // Type 为空时不设止损止盈
+	Sizer       ComponentSpec // Type 为空时使用 fixed
+}
+
+// ComponentSpec 组件的类型和参数，未配置的参数使用组件的默认值
+type ComponentSpec struct {
+	Type   string
+	Params map[string]interface{}
+}
+
+// componentParams 组件的参数（已合并默认值）
+type componentParams StrategyParams
+
+// float 读取数值参数
+func (p componentParams) float(name string) float64 {
+	value, _ := p[name].(float64)
+	return value
+}
+
+// period 读取周期参数
+func (p componentParams) period(name string) int {
+	return int(p.float(name))
+}
+
+// validatePeriods 检查周期参数都是正整数
+func (p componentParams) validatePeriods(names ...string) error {
+	for _, name := range names {
+		if period := p.float(name); period < 1 || period != math.Trunc(period) {
+			return fmt.Errorf("%s 必须是正整数", name)
+		}
+	}
+	return nil
+}
+
+// entryFilter 入场过滤条件：side 方向的入场信号不满足条件时返回 false 和原因
+type entryFilter interface {
+	allow(df data.DataFrame, side Signal) (bool, string, error)
+}
+
+// entryRule 入场规则：返回买卖方向（没有信号时为 Hold）、置信度和原因
+type entryRule interface {
+	entry(df data.DataFrame) (Signal, float64, string, error)
+}
+
+// exitRule 离场规则：levels 为入场信号设置止损止盈价格（0表示不设），exit 在需要卖出离场时返回 true
+type exitRule interface {
+	levels(df data.DataFrame, side Signal, price float64) (float64, float64, error)
+	exit(df data.DataFrame) (bool, string, error)
+}
+
+// filterDef 过滤条件类型的默认参数、需要的行情列、参数验证和构造
+type filterDef struct {
+	defaults StrategyParams
+	columns  []string
+	validate func(componentParams) error
+	build    func(componentParams) entryFilter
+}
+
+// entryDef 入场规则类型的定义
+type entryDef struct {
+	defaults StrategyParams
+	columns  []string
+	validate func(componentParams) error
+	build    func(componentParams) entryRule
+}
+
+// exitDef 离场规则类型的定义
+type exitDef struct {
+	defaults StrategyParams
+	columns  []string
+	validate func(componentParams) error
+	build    func(componentParams) exitRule
+}
+
+// compositeFilters 可用的过滤条件
+var compositeFilters = map[string]filterDef{
+	// trend 收盘价在 period 周期均线之上才买入、之下才卖出
+	"trend": {
+		defaults: StrategyParams{"period": 200.0},
+		columns:  []string{"close"},
+		validate: func(p componentParams) error { return p.validatePeriods("period") },
+		build:    func(p componentParams) entryFilter { return trendFilter{p} },
+	},
+	// volatility ATR 占收盘价的百分比在 [min_pct, max_pct] 内才入场，0表示不限制
+	"volatility": {
+		defaults: StrategyParams{"atr_period": 14.0, "min_pct": 0.0, "max_pct": 0.0},
+		columns:  []string{"high", "low", "close"},
+		validate: func(p componentParams) error {
+			if err := p.validatePeriods("atr_period"); err != nil {
+				return err
+			}
+			if p.float("min_pct") < 0 || p.float("max_pct") < 0 {
+				return fmt.Errorf("min_pct 和 max_pct 不能为负数")
+			}
+			if p.float("max_pct") > 0 && p.float("max_pct") <= p.float("min_pct") {
+				return fmt.Errorf("max_pct 必须大于 min_pct")
+			}
+			return nil
+		},
+		build: func(p componentParams) entryFilter { return volatilityFilter{p} },
+	},
+}
+
+// compositeEntries 可用的入场规则
+var compositeEntries = map[string]entryDef{
+	// ma_cross 快线上穿慢线买入、下穿卖出
+	"ma_cross": {
+		defaults: StrategyParams{"fast_period": 10.0, "slow_period": 30.0},
+		columns:  []string{"close"},
+		validate: func(p componentParams) error {
+			if err := p.validatePeriods("fast_period", "slow_period"); err != nil {
+				return err
+			}
+			if p.float("fast_period") >= p.float("slow_period") {
+				return fmt.Errorf("fast_period 必须小于 slow_period")
+			}
+			return nil
+		},
+		build: func(p componentParams) entryRule { return maCrossEntry{p} },
+	},
+	// rsi RSI 低于 oversold 买入、高于 overbought 卖出
+	"rsi": {
+		defaults: StrategyParams{"period": 14.0, "oversold": 30.0, "overbought": 70.0},
+		columns:  []string{"close"},
+		validate: func(p componentParams) error {
+			if err := p.validatePeriods("period"); err != nil {
+				return err
+			}
+			if p.float("oversold") <= 0 || p.float("overbought") >= 100 || p.float("oversold") >= p.float("overbought") {
+				return fmt.Errorf("需要 0 < oversold < overbought < 100")
+			}
+			return nil
+		},
+		build: func(p componentParams) entryRule { return rsiEntry{p} },
+	},
+	// breakout 收盘价突破之前 period 根K线的最高价买入、跌破最低价卖出
+	"breakout": {
+		defaults: StrategyParams{"period": 20.0},
+		columns:  []string{"high", "low", "close"},
+		validate: func(p componentParams) error { return p.validatePeriods("period") },
+		build:    func(p componentParams) entryRule { return breakoutEntry{p} },
+	},
+}
+
+// compositeExits 可用的离场规则
+var compositeExits = map[string]exitDef{
+	// atr 止损和止盈距离为 ATR 的倍数，take_profit_atr 为0时不设止盈
+	"atr": {
+		defaults: StrategyParams{"atr_period": 14.0, "stop_atr": 2.0, "take_profit_atr": 0.0},
+		columns:  []string{"high", "low", "close"},
+		validate: func(p componentParams) error {
+			if err := p.validatePeriods("atr_period"); err != nil {
+				return err
+			}
+			if p.float("stop_atr") <= 0 || p.float("take_profit_atr") < 0 {
+				return fmt.Errorf("stop_atr 必须大于0，take_profit_atr 不能为负数")
+			}
+			return nil
+		},
+		build: func(p componentParams) exitRule { return atrExit{p} },
+	},
+	// percent 止损和止盈距离为入场价的比例，take_profit_pct 为0时不设止盈
+	"percent": {
+		defaults: StrategyParams{"stop_pct": 0.05, "take_profit_pct": 0.0},
+		columns:  []string{"close"},
+		validate: func(p componentParams) error {
+			if p.float("stop_pct") <= 0 || p.float("stop_pct") >= 1 || p.float("take_profit_pct") < 0 {
+				return fmt.Errorf("stop_pct 必须在 0 到 1 之间，take_profit_pct 不能为负数")
+			}
+			return nil
+		},
+		build: func(p componentParams) exitRule { return percentExit{p} },
+	},
+	// channel 收盘价跌破之前 period 根K线的最低价时卖出离场，不设止损止盈
+	"channel": {
+		defaults: StrategyParams{"period": 10.0},
+		columns:  []string{"high", "low", "close"},
+		validate: func(p componentParams) error { return p.validatePeriods("period") },
+		build:    func(p componentParams) exitRule { return channelExit{p} },
+	},
+}
+
+// CompositeStrategy 组合策略：按配置声明的组件拼装，参数按组件加前缀展开（过滤条件为 filter_<类型>_、
+// 入场规则为 entry_、离场规则为 exit_，仓位参数不加前缀），可在 strategy.parameters 中覆盖
+type CompositeStrategy struct {
+	BaseStrategy
+	spec    CompositeSpec
+	filters []entryFilter
+	entry   entryRule
+	exit    exitRule
+}
+
+// NewCompositeStrategy 按声明创建组合策略，组件类型或参数无效时返回错误
+func NewCompositeStrategy(name string, spec CompositeSpec) (*CompositeStrategy, error) {
+	if name == "" {
+		return nil, fmt.Errorf("策略名称不能为空")
+	}
+	cs := &CompositeStrategy{spec: spec}
+	params, columns, err := cs.defaultParams()
+	if err != nil {
+		return nil, err
+	}
+	description := spec.Description
+	if description == "" {
+		description = cs.summary()
+	}
+	cs.BaseStrategy = BaseStrategy{
+		Name:        name,
+		Description: description,
+		Parameters:  params,
+		Metadata: StrategyMetadata{
+			Author:          "config",
+			Version:         "1.0.0",
+			RequiredColumns: columns,
+			Tags:            []string{"组合策略"},
+		},
+	}
+	if err := cs.bind(params); err != nil {
+		return nil, err
+	}
+	return cs, nil
+}
+
+// RegisterComposite 按声明创建组合策略并注册，名称不能与已注册的策略重复
+func (sm *StrategyManager) RegisterComposite(name string, spec CompositeSpec) error {
+	if _, err := sm.GetStrategy(name); err == nil {
+		return fmt.Errorf("策略名称已存在: %s", name)
+	}
+	composite, err := NewCompositeStrategy(name, spec)
+	if err != nil {
+		return err
+	}
+	return sm.RegisterStrategy(name, composite)
+}
+
+// summary 组件的简要说明，用作未配置描述时的策略描述
+func (cs *CompositeStrategy) summary() string {
+	parts := make([]string, 0, 4)
+	if len(cs.spec.Filters) > 0 {
+		types := make([]string, 0, len(cs.spec.Filters))
+		for _, filter := range cs.spec.Filters {
+			types = append(types, filter.Type)
+		}
+		parts = append(parts, "过滤="+strings.Join(types, "+"))
+	}
+	parts = append(parts, "入场="+cs.spec.Entry.Type)
+	if cs.spec.Exit.Type != "" {
+		parts = append(parts, "离场="+cs.spec.Exit.Type)
+	}
+	parts = append(parts, "仓位="+cs.sizing())
+	return "组合策略: " + strings.Join(parts, ", ")
+}
+
+// sizing 仓位计算方式
+func (cs *CompositeStrategy) sizing() string {
+	if cs.spec.Sizer.Type == "" {
+		return SizingFixed
+	}
+	return cs.spec.Sizer.Type
+}
+
+// filterPrefix 过滤条件参数的前缀
+func filterPrefix(kind string) string {
+	return "filter_" + kind + "_"
+}
+
+// defaultParams 展开各组件的默认参数并合并声明中的参数，同时汇总需要的行情列
+func (cs *CompositeStrategy) defaultParams() (StrategyParams, []string, error) {
+	params := StrategyParams{}
+	needed := map[string]bool{"close": true}
+	add := func(role, prefix string, defaults StrategyParams, columns []string, overrides map[string]interface{}) error {
+		for key, value := range defaults {
+			params[prefix+key] = value
+		}
+		for key, value := range overrides {
+			current, exists := defaults[key]
+			if !exists {
+				return fmt.Errorf("%s 没有参数 %s", role, key)
+			}
+			params[prefix+key] = coerceComponentParam(current, value)
+		}
+		for _, column := range columns {
+			needed[column] = true
+		}
+		return nil
+	}
+
+	seen := make(map[string]bool, len(cs.spec.Filters))
+	for _, filter := range cs.spec.Filters {
+		def, ok := compositeFilters[filter.Type]
+		if !ok {
+			return nil, nil, fmt.Errorf("未知的过滤条件类型: %s（可用: %s）", filter.Type, componentTypes(compositeFilters))
+		}
+		if seen[filter.Type] {
+			return nil, nil, fmt.Errorf("过滤条件 %s 重复", filter.Type)
+		}
+		seen[filter.Type] = true
+		if err := add("过滤条件 "+filter.Type, filterPrefix(filter.Type), def.defaults, def.columns, filter.Params); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	entry, ok := compositeEntries[cs.spec.Entry.Type]
+	if !ok {
+		return nil, nil, fmt.Errorf("未知的入场规则类型: %q（可用: %s）", cs.spec.Entry.Type, componentTypes(compositeEntries))
+	}
+	if err := add("入场规则 "+cs.spec.Entry.Type, "entry_", entry.defaults, entry.columns, cs.spec.Entry.Params); err != nil {
+		return nil, nil, err
+	}
+
+	if cs.spec.Exit.Type != "" {
+		exit, ok := compositeExits[cs.spec.Exit.Type]
+		if !ok {
+			return nil, nil, fmt.Errorf("未知的离场规则类型: %s（可用: %s）", cs.spec.Exit.Type, componentTypes(compositeExits))
+		}
+		if err := add("离场规则 "+cs.spec.Exit.Type, "exit_", exit.defaults, exit.columns, cs.spec.Exit.Params); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	sizing := SizingParameters()
+	delete(sizing, "sizing")
+	if err := add("仓位计算 "+cs.sizing(), "", sizing, nil, cs.spec.Sizer.Params); err != nil {
+		return nil, nil, err
+	}
+	params["sizing"] = cs.sizing()
+	if cs.sizing() == SizingVolTarget && params["volatility_method"] == "atr" {
+		needed["high"], needed["low"] = true, true
+	}
+
+	columns := make([]string, 0, len(needed))
+	for column := range needed {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+	return params, columns, validateSizing(params)
+}
+
+// componentTypes 按名称排序的组件类型列表
+func componentTypes[D any](defs map[string]D) string {
+	types := make([]string, 0, len(defs))
+	for kind := range defs {
+		types = append(types, kind)
+	}
+	sort.Strings(types)
+	return strings.Join(types, ", ")
+}
+
+// coerceComponentParam 将配置中的参数值转换为默认值的类型（TOML 整数解析为 int64，而组件参数为 float64）
+func coerceComponentParam(current, value interface{}) interface{} {
+	if _, ok := current.(float64); ok {
+		switch v := value.(type) {
+		case int:
+			return float64(v)
+		case int64:
+			return float64(v)
+		}
+	}
+	return value
+}
+
+// extract 从展开的参数中取出组件的参数
+func extract(params StrategyParams, prefix string, defaults StrategyParams) componentParams {
+	p := make(componentParams, len(defaults))
+	for key := range defaults {
+		p[key] = params[prefix+key]
+	}
+	return p
+}
+
+// components 按展开的参数验证并构造各组件
+func (cs *CompositeStrategy) components(params StrategyParams) ([]entryFilter, entryRule, exitRule, error) {
+	filters := make([]entryFilter, 0, len(cs.spec.Filters))
+	for _, filter := range cs.spec.Filters {
+		def := compositeFilters[filter.Type]
+		p := extract(params, filterPrefix(filter.Type), def.defaults)
+		if err := def.validate(p); err != nil {
+			return nil, nil, nil, fmt.Errorf("过滤条件 %s: %w", filter.Type, err)
+		}
+		filters = append(filters, def.build(p))
+	}
+
+	entryDef := compositeEntries[cs.spec.Entry.Type]
+	p := extract(params, "entry_", entryDef.defaults)
+	if err := entryDef.validate(p); err != nil {
+		return nil, nil, nil, fmt.Errorf("入场规则 %s: %w", cs.spec.Entry.Type, err)
+	}
+	entry := entryDef.build(p)
+
+	var exit exitRule
+	if cs.spec.Exit.Type != "" {
+		exitDef := compositeExits[cs.spec.Exit.Type]
+		p := extract(params, "exit_", exitDef.defaults)
+		if err := exitDef.validate(p); err != nil {
+			return nil, nil, nil, fmt.Errorf("离场规则 %s: %w", cs.spec.Exit.Type, err)
+		}
+		exit = exitDef.build(p)
+	}
+	return filters, entry, exit, nil
+}
+
+// bind 按参数重新构造组件
+func (cs *CompositeStrategy) bind(params StrategyParams) error {
+	filters, entry, exit, err := cs.components(params)
+	if err != nil {
+		return err
+	}
+	cs.filters, cs.entry, cs.exit = filters, entry, exit
+	return nil
+}
+
+// ValidateParameters 验证各组件的参数和仓位参数
+func (cs *CompositeStrategy) ValidateParameters(params StrategyParams) error {
+	if sizing, ok := params["sizing"].(string); ok && sizing != cs.sizing() {
+		return fmt.Errorf("组合策略的仓位计算方式由 sizer 声明，不能改为 %s", sizing)
+	}
+	if _, _, _, err := cs.components(params); err != nil {
+		return err
+	}
+	return validateSizing(params)
+}
+
+// SetParameters 设置参数并重新构造组件
+func (cs *CompositeStrategy) SetParameters(params StrategyParams) error {
+	if err := cs.bind(params); err != nil {
+		return err
+	}
+	cs.Parameters = params
+	return nil
+}
+
+// Initialize 初始化策略
+func (cs *CompositeStrategy) Initialize() error {
+	if err := cs.ValidateParameters(cs.Parameters); err != nil {
+		return fmt.Errorf("策略参数验证失败: %w", err)
+	}
+	cs.IsActive = true
+	log.Printf("组合策略 %s 已初始化: %s", cs.Name, cs.summary())
+	return nil
+}
+
+// compositeDecision 一轮依次检查各组件的结果
+type compositeDecision struct {
+	side       Signal
+	price      float64
+	confidence float64
+	reason     string
+	exit       bool // 离场规则触发的卖出
+	notes      []string
+}
+
+// evaluate 依次检查离场规则、入场规则和过滤条件：离场规则触发时卖出离场，不再检查入场；
+// 入场信号被任一过滤条件拒绝时不交易
+func (cs *CompositeStrategy) evaluate(df data.DataFrame, guidance *AgentGuidance) (*compositeDecision, error) {
+	closes, err := indicators.Float64Column(df, "close")
+	if err != nil {
+		return nil, err
+	}
+	if len(closes) == 0 {
+		return nil, fmt.Errorf("没有行情数据")
+	}
+	decision := &compositeDecision{side: Hold, price: closes[len(closes)-1]}
+
+	if cs.exit != nil {
+		exit, reason, err := cs.exit.exit(df)
+		if err != nil {
+			return nil, fmt.Errorf("离场规则 %s: %w", cs.spec.Exit.Type, err)
+		}
+		if exit {
+			decision.side, decision.confidence, decision.reason, decision.exit = Sell, 0.6, reason, true
+			decision.notes = append(decision.notes, "离场规则触发: "+reason)
+			return decision, nil
+		}
+	}
+
+	side, confidence, reason, err := cs.entry.entry(df)
+	if err != nil {
+		return nil, fmt.Errorf("入场规则 %s: %w", cs.spec.Entry.Type, err)
+	}
+	if side == Hold {
+		decision.notes = append(decision.notes, "入场规则 "+cs.spec.Entry.Type+" 没有信号")
+		return decision, nil
+	}
+	decision.notes = append(decision.notes, "入场规则: "+reason)
+
+	for i, filter := range cs.filters {
+		allowed, why, err := filter.allow(df, side)
+		if err != nil {
+			return nil, fmt.Errorf("过滤条件 %s: %w", cs.spec.Filters[i].Type, err)
+		}
+		if !allowed {
+			decision.notes = append(decision.notes, fmt.Sprintf("被过滤条件 %s 拒绝: %s", cs.spec.Filters[i].Type, why))
+			return decision, nil
+		}
+		decision.notes = append(decision.notes, fmt.Sprintf("通过过滤条件 %s: %s", cs.spec.Filters[i].Type, why))
+	}
+
+	// Agent情绪同向或反向时调整置信度，反向情绪否决时不交易
+	confidence, reason, allowed := guidance.Adjust(side, confidence, reason)
+	if !allowed {
+		decision.notes = append(decision.notes, "被Agent反向情绪否决")
+		return decision, nil
+	}
+	decision.side, decision.confidence, decision.reason = side, math.Max(0, math.Min(1, confidence)), reason
+	return decision, nil
+}
+
+// GenerateSignals 按组件生成信号
+func (cs *CompositeStrategy) GenerateSignals(df data.DataFrame, guidance *AgentGuidance) ([]TradingSignal, error) {
+	if !cs.IsActive {
+		return nil, fmt.Errorf("策略未激活")
+	}
+
+	decision, err := cs.evaluate(df, guidance)
+	if err != nil {
+		return nil, fmt.Errorf("组合策略 %s 计算失败: %w", cs.Name, err)
+	}
+	if decision.side == Hold {
+		return []TradingSignal{}, nil
+	}
+
+	signal := TradingSignal{
+		Symbol:     SignalSymbol(df, guidance),
+		Signal:     decision.side,
+		Price:      decision.price,
+		Quantity:   cs.PositionSize(df, decision.price, decision.confidence),
+		Confidence: decision.confidence,
+		Reason:     decision.reason,
+		Timestamp:  time.Now(),
+	}
+	if cs.exit != nil && !decision.exit {
+		stop, takeProfit, err := cs.exit.levels(df, decision.side, decision.price)
+		if err != nil {
+			return nil, fmt.Errorf("离场规则 %s: %w", cs.spec.Exit.Type, err)
+		}
+		signal.StopLoss, signal.TakeProfit = stop, takeProfit
+	}
+
+	log.Printf("组合策略 %s 生成信号: %s, 价格=%.2f, 置信度=%.2f, 原因=%s", cs.Name, decision.side, decision.price, decision.confidence, decision.reason)
+	return []TradingSignal{signal}, nil
+}
+
+// Explain 说明各组件依次检查的结果
+func (cs *CompositeStrategy) Explain(df data.DataFrame, guidance *AgentGuidance) *Explanation {
+	explanation := &Explanation{Indicators: make(map[string]float64)}
+	decision, err := cs.evaluate(df, guidance)
+	if err != nil {
+		explanation.notef("数据不满足要求: %v", err)
+		return explanation
+	}
+	explanation.Indicators["price"] = decision.price
+	if decision.side != Hold {
+		explanation.Indicators["confidence"] = decision.confidence
+	}
+	explanation.Notes = append(explanation.Notes, decision.notes...)
+	return explanation
+}
+
+// priorChannel 最新收盘价和之前 period 根K线（不含最新K线）的最高价、最低价
+func priorChannel(df data.DataFrame, period int) (float64, float64, float64, error) {
+	high, err := indicators.Float64Column(df, "high")
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	low, err := indicators.Float64Column(df, "low")
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	closes, err := indicators.Float64Column(df, "close")
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	n := len(closes)
+	if n < period+1 || len(high) != n || len(low) != n {
+		return 0, 0, 0, fmt.Errorf("数据长度不足，需要至少 %d 个数据点", period+1)
+	}
+	channel, err := indicators.Donchian(high[n-1-period:n-1], low[n-1-period:n-1], period)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	return closes[n-1], channel.Upper[0], channel.Lower[0], nil
+}
+
+// latestATR 最新收盘价和最新的ATR
+func latestATR(df data.DataFrame, period int) (float64, float64, error) {
+	high, err := indicators.Float64Column(df, "high")
+	if err != nil {
+		return 0, 0, err
+	}
+	low, err := indicators.Float64Column(df, "low")
+	if err != nil {
+		return 0, 0, err
+	}
+	closes, err := indicators.Float64Column(df, "close")
+	if err != nil {
+		return 0, 0, err
+	}
+	atr, err := indicators.ATR(high, low, closes, period)
+	if err != nil {
+		return 0, 0, fmt.Errorf("计算ATR失败: %w", err)
+	}
+	return indicators.Last(closes), indicators.Last(atr), nil
+}
+
+// trendFilter 均线趋势过滤
+type trendFilter struct{ p componentParams }
+
+func (f trendFilter) allow(df data.DataFrame, side Signal) (bool, string, error) {
+	closes, err := indicators.Float64Column(df, "close")
+	if err != nil {
+		return false, "", err
+	}
+	sma, err := indicators.SMA(closes, f.p.period("period"))
+	if err != nil {
+		return false, "", err
+	}
+	price, average := indicators.Last(closes), indicators.Last(sma)
+	if side == Buy && price <= average || side == Sell && price >= average {
+		return false, fmt.Sprintf("收盘价(%.2f)与%d周期均线(%.2f)的位置和信号方向相反", price, f.p.period("period"), average), nil
+	}
+	return true, fmt.Sprintf("收盘价(%.2f)与%d周期均线(%.2f)的位置和信号方向一致", price, f.p.period("period"), average), nil
+}
+
+// volatilityFilter ATR百分比过滤
+type volatilityFilter struct{ p componentParams }
+
+func (f volatilityFilter) allow(df data.DataFrame, side Signal) (bool, string, error) {
+	price, atr, err := latestATR(df, f.p.period("atr_period"))
+	if err != nil {
+		return false, "", err
+	}
+	if price <= 0 {
+		return false, "", fmt.Errorf("无效的价格: %.4f", price)
+	}
+	pct := atr / price * 100
+	if min := f.p.float("min_pct"); min > 0 && pct < min {
+		return false, fmt.Sprintf("波动率(%.2f%%)低于 %.2f%%", pct, min), nil
+	}
+	if max := f.p.float("max_pct"); max > 0 && pct > max {
+		return false, fmt.Sprintf("波动率(%.2f%%)高于 %.2f%%", pct, max), nil
+	}
+	return true, fmt.Sprintf("波动率(%.2f%%)在允许范围内", pct), nil
+}
+
+// maCrossEntry 均线交叉入场
+type maCrossEntry struct{ p componentParams }
+
+func (e maCrossEntry) entry(df data.DataFrame) (Signal, float64, string, error) {
+	closes, err := indicators.Float64Column(df, "close")
+	if err != nil {
+		return Hold, 0, "", err
+	}
+	fast, err := indicators.SMA(closes, e.p.period("fast_period"))
+	if err != nil {
+		return Hold, 0, "", err
+	}
+	slow, err := indicators.SMA(closes, e.p.period("slow_period"))
+	if err != nil {
+		return Hold, 0, "", err
+	}
+	if len(slow) < 2 {
+		return Hold, 0, "", fmt.Errorf("数据长度不足，需要至少 %d 个数据点", e.p.period("slow_period")+1)
+	}
+	f, fPrev := fast[len(fast)-1], fast[len(fast)-2]
+	s, sPrev := slow[len(slow)-1], slow[len(slow)-2]
+	switch {
+	case fPrev <= sPrev && f > s:
+		return Buy, 0.7, fmt.Sprintf("快线(%.2f)上穿慢线(%.2f)", f, s), nil
+	case fPrev >= sPrev && f < s:
+		return Sell, 0.7, fmt.Sprintf("快线(%.2f)下穿慢线(%.2f)", f, s), nil
+	}
+	return Hold, 0, "", nil
+}
+
+// rsiEntry RSI超买超卖入场
+type rsiEntry struct{ p componentParams }
+
+func (e rsiEntry) entry(df data.DataFrame) (Signal, float64, string, error) {
+	closes, err := indicators.Float64Column(df, "close")
+	if err != nil {
+		return Hold, 0, "", err
+	}
+	values, err := indicators.RSI(closes, e.p.period("period"))
+	if err != nil {
+		return Hold, 0, "", err
+	}
+	rsi := indicators.Last(values)
+	oversold, overbought := e.p.float("oversold"), e.p.float("overbought")
+	switch {
+	case rsi < oversold:
+		return Buy, 0.6 + math.Min((oversold-rsi)/oversold, 1)*0.2, fmt.Sprintf("RSI(%.2f)低于超卖水平(%.0f)", rsi, oversold), nil
+	case rsi > overbought:
+		return Sell, 0.6 + math.Min((rsi-overbought)/(100-overbought), 1)*0.2, fmt.Sprintf("RSI(%.2f)高于超买水平(%.0f)", rsi, overbought), nil
+	}
+	return Hold, 0, "", nil
+}
+
+// breakoutEntry 通道突破入场
+type breakoutEntry struct{ p componentParams }
+
+func (e breakoutEntry) entry(df data.DataFrame) (Signal, float64, string, error) {
+	period := e.p.period("period")
+	price, upper, lower, err := priorChannel(df, period)
+	if err != nil {
+		return Hold, 0, "", err
+	}
+	switch {
+	case price > upper:
+		return Buy, 0.7, fmt.Sprintf("收盘价(%.2f)突破%d周期上轨(%.2f)", price, period, upper), nil
+	case price < lower:
+		return Sell, 0.7, fmt.Sprintf("收盘价(%.2f)跌破%d周期下轨(%.2f)", price, period, lower), nil
+	}
+	return Hold, 0, "", nil
+}
+
+// atrExit ATR倍数止损止盈
+type atrExit struct{ p componentParams }
+
+func (e atrExit) levels(df data.DataFrame, side Signal, price float64) (float64, float64, error) {
+	_, atr, err := latestATR(df, e.p.period("atr_period"))
+	if err != nil {
+		return 0, 0, err
+	}
+	if atr <= 0 {
+		return 0, 0, nil
+	}
+	stop, takeProfit := stopLevels(side, price, e.p.float("stop_atr")*atr, e.p.float("take_profit_atr")*atr)
+	return stop, takeProfit, nil
+}
+
+func (e atrExit) exit(df data.DataFrame) (bool, string, error) {
+	return false, "", nil
+}
+
+// percentExit 入场价比例止损止盈
+type percentExit struct{ p componentParams }
+
+func (e percentExit) levels(df data.DataFrame, side Signal, price float64) (float64, float64, error) {
+	stop, takeProfit := stopLevels(side, price, e.p.float("stop_pct")*price, e.p.float("take_profit_pct")*price)
+	return stop, takeProfit, nil
+}
+
+func (e percentExit) exit(df data.DataFrame) (bool, string, error) {
+	return false, "", nil
+}
+
+// channelExit 通道下轨离场
+type channelExit struct{ p componentParams }
+
+func (e channelExit) levels(df data.DataFrame, side Signal, price float64) (float64, float64, error) {
+	return 0, 0, nil
+}
+
+func (e channelExit) exit(df data.DataFrame) (bool, string, error) {
+	period := e.p.period("period")
+	price, _, lower, err := priorChannel(df, period)
+	if err != nil {
+		return false, "", err
+	}
+	if price < lower {
+		return true, fmt.Sprintf("收盘价(%.2f)跌破%d周期下轨(%.2f)", price, period, lower), nil
+	}
+	return false, "", nil
+}
+
+// stopLevels 按止损和止盈距离计算价格，止盈距离为0时不设止盈
+func stopLevels(side Signal, price, stop, takeProfit float64) (float64, float64) {
+	if side == Sell {
+		if takeProfit > 0 {
+			return price + stop, math.Max(price-takeProfit, 0)
+		}
+		return price + stop, 0
+	}
+	if takeProfit > 0 {
+		return price - stop, price + takeProfit
+	}
+	return price - stop, 0
+}
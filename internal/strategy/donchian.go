@@ -0,0 +1,266 @@
+package strategy
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"agent-quant-system/internal/data"
+	"agent-quant-system/internal/indicators"
+)
+
+// DonchianBreakoutStrategy 唐奇安通道突破策略：收盘价突破之前 entry_period 根K线的最高价时买入，
+// 跌破之前 exit_period 根K线的最低价时卖出；止损和止盈按 ATR 的倍数设置
+type DonchianBreakoutStrategy struct {
+	BaseStrategy
+}
+
+// NewDonchianBreakoutStrategy 创建唐奇安通道突破策略
+func NewDonchianBreakoutStrategy() *DonchianBreakoutStrategy {
+	return &DonchianBreakoutStrategy{
+		BaseStrategy: BaseStrategy{
+			Name:        "唐奇安通道突破策略",
+			Description: "价格突破唐奇安通道上轨时顺势买入、跌破较短周期的下轨时卖出，按ATR设置止损止盈",
+			Parameters: withSizing(StrategyParams{
+				"entry_period":    20.0, // 突破通道的周期
+				"exit_period":     10.0, // 卖出通道的周期，不大于 entry_period
+				"atr_period":      14.0, // ATR周期
+				"stop_atr":        2.0,  // 止损距离（ATR倍数）
+				"take_profit_atr": 4.0,  // 止盈距离（ATR倍数），0表示不设止盈
+			}),
+			Metadata: StrategyMetadata{
+				Author:          "quant_service",
+				Version:         "1.0.0",
+				AssetClasses:    []string{"stock", "crypto"},
+				Timeframes:      []string{"1h", "4h", "1d"},
+				RequiredColumns: []string{"high", "low", "close"},
+				Tags:            []string{"趋势跟踪", "突破", "动量"},
+			},
+		},
+	}
+}
+
+// ValidateParameters 验证策略参数
+func (ds *DonchianBreakoutStrategy) ValidateParameters(params StrategyParams) error {
+	for _, name := range []string{"entry_period", "exit_period", "atr_period"} {
+		if period, ok := params[name].(float64); ok && (period < 1 || period != math.Trunc(period)) {
+			return fmt.Errorf("%s 必须是正整数", name)
+		}
+	}
+	entry, hasEntry := params["entry_period"].(float64)
+	if exit, ok := params["exit_period"].(float64); ok && hasEntry && exit > entry {
+		return fmt.Errorf("exit_period (%v) 不能大于 entry_period (%v)", exit, entry)
+	}
+	if stop, ok := params["stop_atr"].(float64); ok && stop <= 0 {
+		return fmt.Errorf("stop_atr 必须大于0")
+	}
+	if takeProfit, ok := params["take_profit_atr"].(float64); ok && takeProfit < 0 {
+		return fmt.Errorf("take_profit_atr 不能为负数")
+	}
+	return validateSizing(params)
+}
+
+// Initialize 初始化策略
+func (ds *DonchianBreakoutStrategy) Initialize() error {
+	if err := ds.ValidateParameters(ds.Parameters); err != nil {
+		return fmt.Errorf("策略参数验证失败: %w", err)
+	}
+
+	ds.IsActive = true
+	log.Printf("唐奇安通道突破策略已初始化: 突破周期=%.0f, 卖出周期=%.0f, ATR周期=%.0f",
+		ds.GetFloat64Param("entry_period", 20),
+		ds.GetFloat64Param("exit_period", 10),
+		ds.GetFloat64Param("atr_period", 14))
+	return nil
+}
+
+// donchianState 最新K线相对通道的位置
+type donchianState struct {
+	close float64
+	upper float64 // 之前 entry_period 根K线的最高价
+	lower float64 // 之前 exit_period 根K线的最低价
+	atr   float64
+}
+
+// calculate 计算最新K线之前的通道和最新的ATR，通道不包含最新K线
+func (ds *DonchianBreakoutStrategy) calculate(df data.DataFrame) (*donchianState, error) {
+	high, err := indicators.Float64Column(df, "high")
+	if err != nil {
+		return nil, err
+	}
+	low, err := indicators.Float64Column(df, "low")
+	if err != nil {
+		return nil, err
+	}
+	closes, err := indicators.Float64Column(df, "close")
+	if err != nil {
+		return nil, err
+	}
+
+	entry := int(ds.GetFloat64Param("entry_period", 20))
+	exit := int(ds.GetFloat64Param("exit_period", 10))
+	n := len(closes)
+	if n < entry+1 {
+		return nil, fmt.Errorf("数据长度不足，需要至少 %d 个数据点", entry+1)
+	}
+	entryChannel, err := indicators.Donchian(high[n-1-entry:n-1], low[n-1-entry:n-1], entry)
+	if err != nil {
+		return nil, err
+	}
+	exitChannel, err := indicators.Donchian(high[n-1-exit:n-1], low[n-1-exit:n-1], exit)
+	if err != nil {
+		return nil, err
+	}
+	atr, err := indicators.ATR(high, low, closes, int(ds.GetFloat64Param("atr_period", 14)))
+	if err != nil {
+		return nil, fmt.Errorf("计算ATR失败: %w", err)
+	}
+
+	return &donchianState{
+		close: closes[n-1],
+		upper: entryChannel.Upper[0],
+		lower: exitChannel.Lower[0],
+		atr:   indicators.Last(atr),
+	}, nil
+}
+
+// GenerateSignals 生成突破信号
+func (ds *DonchianBreakoutStrategy) GenerateSignals(df data.DataFrame, guidance *AgentGuidance) ([]TradingSignal, error) {
+	log.Printf("开始生成唐奇安通道突破策略信号")
+
+	if !ds.IsActive {
+		return nil, fmt.Errorf("策略未激活")
+	}
+
+	state, err := ds.calculate(df)
+	if err != nil {
+		return nil, fmt.Errorf("计算唐奇安通道失败: %w", err)
+	}
+	return ds.signalFor(state, df, guidance), nil
+}
+
+// signalFor 按最新K线相对通道的位置生成信号，df 用于确定标的和计算仓位
+func (ds *DonchianBreakoutStrategy) signalFor(state *donchianState, df data.DataFrame, guidance *AgentGuidance) []TradingSignal {
+	var side Signal
+	var reason string
+	switch {
+	case state.close > state.upper:
+		side = Buy
+		reason = fmt.Sprintf("向上突破: 收盘价(%.2f)高于%.0f周期上轨(%.2f)", state.close, ds.GetFloat64Param("entry_period", 20), state.upper)
+	case state.close < state.lower:
+		side = Sell
+		reason = fmt.Sprintf("向下跌破: 收盘价(%.2f)低于%.0f周期下轨(%.2f)", state.close, ds.GetFloat64Param("exit_period", 10), state.lower)
+	default:
+		return []TradingSignal{}
+	}
+
+	// 突破幅度（以ATR计）越大置信度越高，Agent情绪同向或反向时调整
+	confidence := 0.6
+	if state.atr > 0 {
+		distance := math.Max(state.close-state.upper, state.lower-state.close)
+		confidence += math.Min(distance/state.atr, 1) * 0.2
+	}
+	confidence, reason, allowed := guidance.Adjust(side, confidence, reason)
+	if !allowed {
+		return []TradingSignal{}
+	}
+
+	signal := TradingSignal{
+		Symbol:     SignalSymbol(df, guidance),
+		Signal:     side,
+		Price:      state.close,
+		Quantity:   ds.PositionSize(df, state.close, confidence),
+		Confidence: confidence,
+		Reason:     reason,
+		Timestamp:  time.Now(),
+	}
+	if state.atr > 0 {
+		stop := ds.GetFloat64Param("stop_atr", 2) * state.atr
+		takeProfit := ds.GetFloat64Param("take_profit_atr", 4) * state.atr
+		if side == Buy {
+			signal.StopLoss = state.close - stop
+			if takeProfit > 0 {
+				signal.TakeProfit = state.close + takeProfit
+			}
+		} else {
+			signal.StopLoss = state.close + stop
+			if takeProfit > 0 {
+				signal.TakeProfit = math.Max(state.close-takeProfit, 0)
+			}
+		}
+	}
+
+	log.Printf("生成唐奇安突破信号: %s, 价格=%.2f, ATR=%.4f, 置信度=%.2f", side, state.close, state.atr, confidence)
+	return []TradingSignal{signal}
+}
+
+// WarmupBars 通道需要之前 entry_period 根K线，ATR 需要 atr_period 个真实波幅
+func (ds *DonchianBreakoutStrategy) WarmupBars() int {
+	return int(math.Max(ds.GetFloat64Param("entry_period", 20), ds.GetFloat64Param("atr_period", 14))) + 1
+}
+
+// NewBarState 创建增量状态
+func (ds *DonchianBreakoutStrategy) NewBarState(symbol string) BarState {
+	return &donchianBarState{
+		strategy: ds,
+		entry:    indicators.NewRollingDonchian(int(ds.GetFloat64Param("entry_period", 20))),
+		exit:     indicators.NewRollingDonchian(int(ds.GetFloat64Param("exit_period", 10))),
+		atr:      indicators.NewRollingATR(int(ds.GetFloat64Param("atr_period", 14))),
+		history:  newBarHistory(symbol, ds.sizingHistorySize()),
+	}
+}
+
+// donchianBarState 唐奇安通道突破策略的增量状态。ATR 从第一根K线起连续平滑，
+// 与按窗口重新计算的结果在预热后的最初一段略有差异
+type donchianBarState struct {
+	strategy    *DonchianBreakoutStrategy
+	entry, exit *indicators.RollingDonchian
+	atr         *indicators.RollingATR
+	history     *barHistory
+}
+
+// OnBar 先用不含新K线的通道判断突破，再把新K线加入通道
+func (s *donchianBarState) OnBar(bar Bar, guidance *AgentGuidance) ([]TradingSignal, error) {
+	if !s.strategy.IsActive {
+		return nil, fmt.Errorf("策略未激活")
+	}
+	upper, _ := s.entry.Value()
+	_, lower := s.exit.Value()
+	ready := s.entry.Ready() && s.exit.Ready()
+	atr, atrReady := s.atr.Update(bar.High, bar.Low, bar.Close)
+	s.entry.Update(bar.High, bar.Low)
+	s.exit.Update(bar.High, bar.Low)
+	s.history.push(bar)
+	if !ready || !atrReady {
+		return nil, nil
+	}
+	return s.strategy.signalFor(&donchianState{close: bar.Close, upper: upper, lower: lower, atr: atr}, s.history.frame(), guidance), nil
+}
+
+// Explain 说明收盘价与通道上下轨的关系
+func (ds *DonchianBreakoutStrategy) Explain(df data.DataFrame, guidance *AgentGuidance) *Explanation {
+	explanation := &Explanation{Indicators: make(map[string]float64)}
+	state, err := ds.calculate(df)
+	if err != nil {
+		explanation.notef("数据不满足要求: %v", err)
+		return explanation
+	}
+
+	explanation.Indicators["close"] = state.close
+	explanation.Indicators["upper"] = state.upper
+	explanation.Indicators["lower"] = state.lower
+	explanation.Indicators["atr"] = state.atr
+	switch {
+	case state.close > state.upper:
+		explanation.notef("收盘价 %.2f 突破上轨 %.2f", state.close, state.upper)
+	case state.close < state.lower:
+		explanation.notef("收盘价 %.2f 跌破下轨 %.2f", state.close, state.lower)
+	default:
+		explanation.notef("收盘价 %.2f 在通道 %.2f~%.2f 内，不生成信号", state.close, state.lower, state.upper)
+	}
+	if guidance != nil && guidance.Sentiment != "" {
+		explanation.notef("Agent情绪 %s 只调整信号置信度，不单独触发信号", guidance.Sentiment)
+	}
+	return explanation
+}
@@ -0,0 +1,218 @@
+package strategy
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// 策略组合的投票方式
+const (
+	EnsembleWeighted  = "weighted"  // 按权重和置信度加权投票，净得分达到阈值时交易
+	EnsembleMajority  = "majority"  // 同向权重超过本轮运行成员总权重的一半时交易
+	EnsembleUnanimous = "unanimous" // 本轮运行的成员全部同向时交易
+)
+
+// Ensemble 策略组合：成员策略对同一标的的买卖信号合并为一个以 Name 为策略名的净信号
+type Ensemble struct {
+	Name      string
+	Method    string
+	Members   []string
+	Weights   map[string]float64 // 未配置的成员权重为1
+	Threshold float64
+	Veto      []string
+}
+
+// EnsembleVote 成员策略的投票
+type EnsembleVote struct {
+	Strategy   string  `json:"strategy"`
+	Signal     string  `json:"signal"` // 没有买卖信号时为持有
+	Confidence float64 `json:"confidence"`
+	Weight     float64 `json:"weight"`
+	Quantity   float64 `json:"quantity,omitempty"`
+	Agreed     bool    `json:"agreed"` // 是否与最终方向一致，用于事后评估各成员的贡献
+}
+
+// EnsembleDecision 一个标的一轮的合并结果
+type EnsembleDecision struct {
+	Symbol string         `json:"symbol"`
+	Method string         `json:"method"`
+	Signal string         `json:"signal"`
+	Score  float64        `json:"score"` // 加权净得分，-1（全部卖出）~ 1（全部买入）
+	Vetoed string         `json:"vetoed,omitempty"`
+	Reason string         `json:"reason"`
+	Votes  []EnsembleVote `json:"votes"`
+}
+
+// weight 成员策略的权重
+func (e *Ensemble) weight(name string) float64 {
+	if weight, ok := e.Weights[name]; ok {
+		return weight
+	}
+	return 1
+}
+
+// isMember 是否为成员策略
+func (e *Ensemble) isMember(name string) bool {
+	for _, member := range e.Members {
+		if member == name {
+			return true
+		}
+	}
+	return false
+}
+
+// direction 买入为1，卖出为-1，其他为0
+func direction(signal Signal) float64 {
+	switch signal {
+	case Buy:
+		return 1
+	case Sell:
+		return -1
+	}
+	return 0
+}
+
+// Aggregate 合并 ran 中成员策略对 symbol 的买卖信号；平仓信号、非成员策略和其他标的的信号原样保留。
+// 本轮没有成员运行时返回 nil 决策；成员运行了但没有买卖信号时按持有计票
+func (e *Ensemble) Aggregate(symbol string, ran []string, signals []TradingSignal) ([]TradingSignal, *EnsembleDecision) {
+	votes := make(map[string]*TradingSignal)
+	var members []string
+	for _, name := range ran {
+		if e.isMember(name) {
+			members = append(members, name)
+		}
+	}
+	if len(members) == 0 {
+		return signals, nil
+	}
+
+	passed := make([]TradingSignal, 0, len(signals))
+	for i := range signals {
+		signal := signals[i]
+		if !e.isMember(signal.Strategy) || signal.Symbol != symbol || signal.ClosePercent > 0 {
+			passed = append(passed, signal)
+			continue
+		}
+		if direction(signal.Signal) == 0 {
+			continue
+		}
+		// 同一成员有多个买卖信号时取置信度最高的
+		if current, ok := votes[signal.Strategy]; !ok || signal.Confidence > current.Confidence {
+			votes[signal.Strategy] = &signals[i]
+		}
+	}
+
+	decision := &EnsembleDecision{Symbol: symbol, Method: e.Method, Signal: Hold.String()}
+	var totalWeight, score, buyWeight, sellWeight float64
+	voted := 0
+	for _, name := range members {
+		weight := e.weight(name)
+		vote := EnsembleVote{Strategy: name, Signal: Hold.String(), Weight: weight}
+		if signal, ok := votes[name]; ok {
+			vote.Signal, vote.Confidence, vote.Quantity = signal.Signal.String(), signal.Confidence, signal.Quantity
+			score += weight * signal.Confidence * direction(signal.Signal)
+			voted++
+			if signal.Signal == Buy {
+				buyWeight += weight
+			} else {
+				sellWeight += weight
+			}
+		}
+		totalWeight += weight
+		decision.Votes = append(decision.Votes, vote)
+	}
+	if totalWeight > 0 {
+		decision.Score = score / totalWeight
+	}
+
+	side := Hold
+	switch e.Method {
+	case EnsembleMajority:
+		if buyWeight > totalWeight/2 {
+			side = Buy
+		} else if sellWeight > totalWeight/2 {
+			side = Sell
+		}
+	case EnsembleUnanimous:
+		if voted == len(members) && buyWeight == totalWeight {
+			side = Buy
+		} else if voted == len(members) && sellWeight == totalWeight {
+			side = Sell
+		}
+	default:
+		if decision.Score != 0 && math.Abs(decision.Score) >= e.Threshold {
+			side = Buy
+			if decision.Score < 0 {
+				side = Sell
+			}
+		}
+	}
+
+	for _, name := range e.Veto {
+		if signal, ok := votes[name]; ok && side != Hold && signal.Signal != side {
+			decision.Vetoed = name
+			side = Hold
+			break
+		}
+	}
+
+	for i := range decision.Votes {
+		decision.Votes[i].Agreed = decision.Votes[i].Signal == side.String()
+	}
+	decision.Signal = side.String()
+	decision.Reason = e.summary(decision)
+	if side == Hold {
+		return passed, decision
+	}
+	return append(passed, e.netSignal(side, decision, votes)), decision
+}
+
+// netSignal 按同向成员的权重与置信度乘积加权平均数量、价格和置信度，
+// 止损止盈和执行提示取乘积最大的同向信号
+func (e *Ensemble) netSignal(side Signal, decision *EnsembleDecision, votes map[string]*TradingSignal) TradingSignal {
+	var lead *TradingSignal
+	var leadScore, total, weights, quantity, price, confidence float64
+	for _, name := range e.Members {
+		signal, ok := votes[name]
+		if !ok || signal.Signal != side {
+			continue
+		}
+		weight := e.weight(name)
+		score := weight * signal.Confidence
+		if lead == nil || score > leadScore {
+			lead, leadScore = signal, score
+		}
+		total += score
+		weights += weight
+		quantity += score * signal.Quantity
+		price += score * signal.Price
+		confidence += weight * signal.Confidence
+	}
+
+	net := *lead
+	net.Strategy = e.Name
+	net.Reason = decision.Reason
+	net.ClientOrderID = ""
+	if total > 0 {
+		net.Quantity = quantity / total
+		net.Price = price / total
+	}
+	if weights > 0 {
+		net.Confidence = confidence / weights
+	}
+	return net
+}
+
+// summary 投票结果的说明
+func (e *Ensemble) summary(decision *EnsembleDecision) string {
+	votes := make([]string, 0, len(decision.Votes))
+	for _, vote := range decision.Votes {
+		votes = append(votes, fmt.Sprintf("%s=%s(%.2f)", vote.Strategy, vote.Signal, vote.Confidence))
+	}
+	reason := fmt.Sprintf("策略组合 %s(%s): %s, 得分=%.2f", e.Name, e.Method, strings.Join(votes, " "), decision.Score)
+	if decision.Vetoed != "" {
+		reason += fmt.Sprintf("，被 %s 否决", decision.Vetoed)
+	}
+	return reason
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"agent-quant-system/internal/data"
+	"agent-quant-system/internal/indicators"
 )
 
 // MovingAverageCrossStrategy 移动平均线交叉策略
@@ -19,13 +20,21 @@ func NewMovingAverageCrossStrategy() *MovingAverageCrossStrategy {
 		BaseStrategy: BaseStrategy{
 			Name:        "移动平均线交叉策略",
 			Description: "基于短期和长期移动平均线交叉的交易策略",
-			Parameters: StrategyParams{
+			Parameters: withSizing(StrategyParams{
 				"short_period":        5.0,       // 短期移动平均线周期
 				"long_period":         20.0,      // 长期移动平均线周期
 				"volume_threshold":    1000000.0, // 成交量阈值
 				"risk_percentage":     2.0,       // 风险百分比
 				"stop_loss_percent":   5.0,       // 止损百分比
 				"take_profit_percent": 10.0,      // 止盈百分比
+			}),
+			Metadata: StrategyMetadata{
+				Author:          "quant_service",
+				Version:         "1.0.0",
+				AssetClasses:    []string{"stock", "crypto"},
+				Timeframes:      []string{"1h", "1d"},
+				RequiredColumns: []string{"close", "volume"},
+				Tags:            []string{"趋势跟踪", "均线"},
 			},
 		},
 	}
@@ -45,7 +54,7 @@ func (ma *MovingAverageCrossStrategy) ValidateParameters(params StrategyParams)
 		return fmt.Errorf("移动平均线周期必须大于0")
 	}
 
-	return nil
+	return validateSizing(params)
 }
 
 // Initialize 初始化策略
@@ -112,22 +121,11 @@ func (ma *MovingAverageCrossStrategy) validateData(df data.DataFrame) error {
 
 // calculateMovingAverage 计算移动平均线
 func (ma *MovingAverageCrossStrategy) calculateMovingAverage(df data.DataFrame, period int) ([]float64, error) {
-	closeData := df["close"]
-	if len(closeData) < period {
-		return nil, fmt.Errorf("数据长度不足")
-	}
-
-	var movingAverages []float64
-
-	for i := period - 1; i < len(closeData); i++ {
-		sum := 0.0
-		for j := i - period + 1; j <= i; j++ {
-			sum += closeData[j].(float64)
-		}
-		movingAverages = append(movingAverages, sum/float64(period))
+	closes, err := indicators.Float64Column(df, "close")
+	if err != nil {
+		return nil, err
 	}
-
-	return movingAverages, nil
+	return indicators.SMA(closes, period)
 }
 
 // generateCrossSignals 生成交叉信号
@@ -164,26 +162,21 @@ func (ma *MovingAverageCrossStrategy) generateCrossSignals(shortMA, longMA []flo
 		confidence := 0.7
 		reason := fmt.Sprintf("金叉信号: 短期MA(%.2f)上穿长期MA(%.2f)", currentShortMA, currentLongMA)
 
-		// 结合Agent指导调整置信度
-		if guidance != nil {
-			if guidance.Sentiment == "Positive" {
-				confidence += 0.1
-				reason += fmt.Sprintf(" + Agent看多(%.2f)", guidance.Confidence)
-			} else if guidance.Sentiment == "Negative" {
-				confidence -= 0.1
-				reason += fmt.Sprintf(" - Agent看空(%.2f)", guidance.Confidence)
-			}
+		// 结合Agent指导调整置信度，金叉和死叉不会同时出现，否决时直接返回
+		confidence, reason, allowed := guidance.Adjust(Buy, confidence, reason)
+		if !allowed {
+			return signals
 		}
 
 		// 计算仓位大小
-		quantity := ma.calculatePositionSize(currentPrice, confidence)
+		quantity := ma.calculatePositionSize(df, currentPrice, confidence)
 
 		// 计算止损止盈
 		stopLoss := CalculateStopLoss(currentPrice, ma.GetFloat64Param("stop_loss_percent", 5), Buy)
 		takeProfit := CalculateTakeProfit(currentPrice, ma.GetFloat64Param("take_profit_percent", 10), Buy)
 
 		signal := TradingSignal{
-			Symbol:     "DEFAULT_SYMBOL", // 实际应用中应该从参数或数据中获取
+			Symbol:     SignalSymbol(df, guidance),
 			Signal:     Buy,
 			Price:      currentPrice,
 			Quantity:   quantity,
@@ -204,25 +197,20 @@ func (ma *MovingAverageCrossStrategy) generateCrossSignals(shortMA, longMA []flo
 		reason := fmt.Sprintf("死叉信号: 短期MA(%.2f)下穿长期MA(%.2f)", currentShortMA, currentLongMA)
 
 		// 结合Agent指导调整置信度
-		if guidance != nil {
-			if guidance.Sentiment == "Negative" {
-				confidence += 0.1
-				reason += fmt.Sprintf(" + Agent看空(%.2f)", guidance.Confidence)
-			} else if guidance.Sentiment == "Positive" {
-				confidence -= 0.1
-				reason += fmt.Sprintf(" - Agent看多(%.2f)", guidance.Confidence)
-			}
+		confidence, reason, allowed := guidance.Adjust(Sell, confidence, reason)
+		if !allowed {
+			return signals
 		}
 
 		// 计算仓位大小
-		quantity := ma.calculatePositionSize(currentPrice, confidence)
+		quantity := ma.calculatePositionSize(df, currentPrice, confidence)
 
 		// 计算止损止盈
 		stopLoss := CalculateStopLoss(currentPrice, ma.GetFloat64Param("stop_loss_percent", 5), Sell)
 		takeProfit := CalculateTakeProfit(currentPrice, ma.GetFloat64Param("take_profit_percent", 10), Sell)
 
 		signal := TradingSignal{
-			Symbol:     "DEFAULT_SYMBOL", // 实际应用中应该从参数或数据中获取
+			Symbol:     SignalSymbol(df, guidance),
 			Signal:     Sell,
 			Price:      currentPrice,
 			Quantity:   quantity,
@@ -240,14 +228,52 @@ func (ma *MovingAverageCrossStrategy) generateCrossSignals(shortMA, longMA []flo
 	return signals
 }
 
-// calculatePositionSize 计算仓位大小
-func (ma *MovingAverageCrossStrategy) calculatePositionSize(price, confidence float64) float64 {
-	// 简化计算，实际应用中应该考虑账户余额和风险管理
-	baseQuantity := 100.0
-	adjustedQuantity := baseQuantity * confidence
+// WarmupBars 判断交叉需要当前和前一根K线的长期均线
+func (ma *MovingAverageCrossStrategy) WarmupBars() int {
+	return int(ma.GetFloat64Param("long_period", 20)) + 1
+}
 
-	// 确保最小仓位
-	if adjustedQuantity < 10.0 {
+// NewBarState 创建增量状态
+func (ma *MovingAverageCrossStrategy) NewBarState(symbol string) BarState {
+	return &maCrossState{
+		strategy: ma,
+		short:    indicators.NewRollingSMA(int(ma.GetFloat64Param("short_period", 5))),
+		long:     indicators.NewRollingSMA(int(ma.GetFloat64Param("long_period", 20))),
+		history:  newBarHistory(symbol, ma.sizingHistorySize()),
+	}
+}
+
+// maCrossState 移动平均线交叉策略的增量状态
+type maCrossState struct {
+	strategy            *MovingAverageCrossStrategy
+	short, long         *indicators.RollingSMA
+	prevShort, prevLong float64
+	hasPrev             bool // 前一根K线的长期均线是否已可用
+	history             *barHistory
+}
+
+// OnBar 更新两条均线，与前一根K线的均线比较判断交叉
+func (s *maCrossState) OnBar(bar Bar, guidance *AgentGuidance) ([]TradingSignal, error) {
+	if !s.strategy.IsActive {
+		return nil, fmt.Errorf("策略未激活")
+	}
+	s.history.push(bar)
+	shortMA, _ := s.short.Update(bar.Close)
+	longMA, ready := s.long.Update(bar.Close)
+	prevShort, prevLong, hasPrev := s.prevShort, s.prevLong, s.hasPrev
+	s.prevShort, s.prevLong, s.hasPrev = shortMA, longMA, ready
+	if !ready || !hasPrev {
+		return nil, nil
+	}
+	return s.strategy.generateCrossSignals([]float64{prevShort, shortMA}, []float64{prevLong, longMA}, s.history.frame(), guidance), nil
+}
+
+// calculatePositionSize 按 sizing 参数计算仓位大小
+func (ma *MovingAverageCrossStrategy) calculatePositionSize(df data.DataFrame, price, confidence float64) float64 {
+	adjustedQuantity := ma.PositionSize(df, price, confidence)
+
+	// 固定数量时确保最小仓位
+	if ma.GetStringParam("sizing", SizingFixed) == SizingFixed && adjustedQuantity < 10.0 {
 		adjustedQuantity = 10.0
 	}
 
@@ -265,11 +291,19 @@ func NewRSIStrategy() *RSIStrategy {
 		BaseStrategy: BaseStrategy{
 			Name:        "RSI策略",
 			Description: "基于相对强弱指数的交易策略",
-			Parameters: StrategyParams{
+			Parameters: withSizing(StrategyParams{
 				"rsi_period":       14.0, // RSI周期
 				"oversold_level":   30.0, // 超卖水平
 				"overbought_level": 70.0, // 超买水平
 				"risk_percentage":  2.0,  // 风险百分比
+			}),
+			Metadata: StrategyMetadata{
+				Author:          "quant_service",
+				Version:         "1.0.0",
+				AssetClasses:    []string{"stock", "crypto"},
+				Timeframes:      []string{"1h", "4h", "1d"},
+				RequiredColumns: []string{"close"},
+				Tags:            []string{"均值回归", "震荡指标"},
 			},
 		},
 	}
@@ -294,14 +328,18 @@ func (rsi *RSIStrategy) GenerateSignals(df data.DataFrame, guidance *AgentGuidan
 		return []TradingSignal{}, nil
 	}
 
-	currentRSI := rsiValues[len(rsiValues)-1]
-	oversoldLevel := rsi.GetFloat64Param("oversold_level", 30)
-	overboughtLevel := rsi.GetFloat64Param("overbought_level", 70)
-
 	// 获取最新价格
 	closeData := df["close"]
 	currentPrice := closeData[len(closeData)-1].(float64)
 
+	return rsi.generateRSISignals(rsiValues[len(rsiValues)-1], currentPrice, df, guidance), nil
+}
+
+// generateRSISignals 按当前RSI生成超卖买入和超买卖出信号，df 用于确定标的和按波动率计算仓位
+func (rsi *RSIStrategy) generateRSISignals(currentRSI, currentPrice float64, df data.DataFrame, guidance *AgentGuidance) []TradingSignal {
+	oversoldLevel := rsi.GetFloat64Param("oversold_level", 30)
+	overboughtLevel := rsi.GetFloat64Param("overbought_level", 70)
+
 	var signals []TradingSignal
 
 	// RSI超卖信号
@@ -309,7 +347,7 @@ func (rsi *RSIStrategy) GenerateSignals(df data.DataFrame, guidance *AgentGuidan
 		confidence := (oversoldLevel - currentRSI) / oversoldLevel
 		reason := fmt.Sprintf("RSI超卖信号: RSI=%.2f < %.2f", currentRSI, oversoldLevel)
 
-		signal := CreateTradingSignal("DEFAULT_SYMBOL", Buy, currentPrice, 100.0, confidence, reason)
+		signal := CreateTradingSignal(SignalSymbol(df, guidance), Buy, currentPrice, rsi.PositionSize(df, currentPrice, 1), confidence, reason)
 		signals = append(signals, signal)
 		log.Printf("生成RSI买入信号: RSI=%.2f", currentRSI)
 	}
@@ -319,63 +357,63 @@ func (rsi *RSIStrategy) GenerateSignals(df data.DataFrame, guidance *AgentGuidan
 		confidence := (currentRSI - overboughtLevel) / (100 - overboughtLevel)
 		reason := fmt.Sprintf("RSI超买信号: RSI=%.2f > %.2f", currentRSI, overboughtLevel)
 
-		signal := CreateTradingSignal("DEFAULT_SYMBOL", Sell, currentPrice, 100.0, confidence, reason)
+		signal := CreateTradingSignal(SignalSymbol(df, guidance), Sell, currentPrice, rsi.PositionSize(df, currentPrice, 1), confidence, reason)
 		signals = append(signals, signal)
 		log.Printf("生成RSI卖出信号: RSI=%.2f", currentRSI)
 	}
 
-	return signals, nil
+	return signals
 }
 
-// calculateRSI 计算RSI指标
-func (rsi *RSIStrategy) calculateRSI(df data.DataFrame, period int) ([]float64, error) {
-	closeData := df["close"]
-	if len(closeData) < period+1 {
-		return nil, fmt.Errorf("数据长度不足")
-	}
-
-	var rsiValues []float64
-	var gains, losses []float64
-
-	// 计算价格变化
-	for i := 1; i < len(closeData); i++ {
-		change := closeData[i].(float64) - closeData[i-1].(float64)
-		if change > 0 {
-			gains = append(gains, change)
-			losses = append(losses, 0)
-		} else {
-			gains = append(gains, 0)
-			losses = append(losses, -change)
-		}
-	}
-
-	// 计算RSI
-	for i := period - 1; i < len(gains); i++ {
-		avgGain := 0.0
-		avgLoss := 0.0
+// WarmupBars RSI需要周期加一根K线的涨跌幅
+func (rsi *RSIStrategy) WarmupBars() int {
+	return int(rsi.GetFloat64Param("rsi_period", 14)) + 1
+}
 
-		for j := i - period + 1; j <= i; j++ {
-			avgGain += gains[j]
-			avgLoss += losses[j]
-		}
+// NewBarState 创建增量状态
+func (rsi *RSIStrategy) NewBarState(symbol string) BarState {
+	return &rsiState{
+		strategy: rsi,
+		rsi:      indicators.NewRollingRSI(int(rsi.GetFloat64Param("rsi_period", 14))),
+		history:  newBarHistory(symbol, rsi.sizingHistorySize()),
+	}
+}
 
-		avgGain /= float64(period)
-		avgLoss /= float64(period)
+// rsiState RSI策略的增量状态
+type rsiState struct {
+	strategy *RSIStrategy
+	rsi      *indicators.RollingRSI
+	history  *barHistory
+}
 
-		if avgLoss == 0 {
-			rsiValues = append(rsiValues, 100)
-		} else {
-			rs := avgGain / avgLoss
-			rsiValue := 100 - (100 / (1 + rs))
-			rsiValues = append(rsiValues, rsiValue)
-		}
+// OnBar 更新RSI，预热完成后按当前值判断超买超卖
+func (s *rsiState) OnBar(bar Bar, guidance *AgentGuidance) ([]TradingSignal, error) {
+	if !s.strategy.IsActive {
+		return nil, fmt.Errorf("策略未激活")
 	}
+	s.history.push(bar)
+	value, ready := s.rsi.Update(bar.Close)
+	if !ready {
+		return nil, nil
+	}
+	return s.strategy.generateRSISignals(value, bar.Close, s.history.frame(), guidance), nil
+}
 
-	return rsiValues, nil
+// calculateRSI 计算RSI指标
+func (rsi *RSIStrategy) calculateRSI(df data.DataFrame, period int) ([]float64, error) {
+	closes, err := indicators.Float64Column(df, "close")
+	if err != nil {
+		return nil, err
+	}
+	return indicators.RSI(closes, period)
 }
 
 // Initialize 初始化RSI策略
 func (rsi *RSIStrategy) Initialize() error {
+	if err := rsi.ValidateParameters(rsi.Parameters); err != nil {
+		return fmt.Errorf("参数验证失败: %w", err)
+	}
+
 	rsi.IsActive = true
 	log.Printf("RSI策略已初始化: 周期=%.0f, 超卖=%.0f, 超买=%.0f",
 		rsi.GetFloat64Param("rsi_period", 14),
@@ -383,3 +421,82 @@ func (rsi *RSIStrategy) Initialize() error {
 		rsi.GetFloat64Param("overbought_level", 70))
 	return nil
 }
+
+// ValidateParameters 验证策略参数
+func (rsi *RSIStrategy) ValidateParameters(params StrategyParams) error {
+	return validateSizing(params)
+}
+
+// Explain 说明均线和成交量条件
+func (ma *MovingAverageCrossStrategy) Explain(df data.DataFrame, guidance *AgentGuidance) *Explanation {
+	explanation := &Explanation{Indicators: make(map[string]float64)}
+	if err := ma.validateData(df); err != nil {
+		explanation.notef("数据不满足要求: %v", err)
+		return explanation
+	}
+	shortMA, err := ma.calculateMovingAverage(df, int(ma.GetFloat64Param("short_period", 5)))
+	if err != nil || len(shortMA) < 2 {
+		explanation.notef("短期均线数据不足")
+		return explanation
+	}
+	longMA, err := ma.calculateMovingAverage(df, int(ma.GetFloat64Param("long_period", 20)))
+	if err != nil || len(longMA) < 2 {
+		explanation.notef("长期均线数据不足")
+		return explanation
+	}
+
+	current, previous := shortMA[len(shortMA)-1], shortMA[len(shortMA)-2]
+	currentLong, previousLong := longMA[len(longMA)-1], longMA[len(longMA)-2]
+	explanation.Indicators["short_ma"] = current
+	explanation.Indicators["long_ma"] = currentLong
+	explanation.Indicators["prev_short_ma"] = previous
+	explanation.Indicators["prev_long_ma"] = previousLong
+
+	volumes := df["volume"]
+	volume, _ := volumes[len(volumes)-1].(int64)
+	threshold := ma.GetFloat64Param("volume_threshold", 1000000)
+	explanation.Indicators["volume"] = float64(volume)
+	if float64(volume) < threshold {
+		explanation.notef("成交量 %d 低于阈值 %.0f，不生成信号", volume, threshold)
+		return explanation
+	}
+
+	switch {
+	case previous <= previousLong && current > currentLong:
+		explanation.notef("短期均线 %.2f 上穿长期均线 %.2f（金叉）", current, currentLong)
+	case previous >= previousLong && current < currentLong:
+		explanation.notef("短期均线 %.2f 下穿长期均线 %.2f（死叉）", current, currentLong)
+	case current > currentLong:
+		explanation.notef("短期均线 %.2f 保持在长期均线 %.2f 上方，未发生交叉", current, currentLong)
+	default:
+		explanation.notef("短期均线 %.2f 保持在长期均线 %.2f 下方，未发生交叉", current, currentLong)
+	}
+	if guidance != nil && guidance.Sentiment != "" {
+		explanation.notef("Agent情绪 %s 只调整信号置信度，不单独触发信号", guidance.Sentiment)
+	}
+	return explanation
+}
+
+// Explain 说明RSI与超买超卖水平的关系
+func (rsi *RSIStrategy) Explain(df data.DataFrame, guidance *AgentGuidance) *Explanation {
+	explanation := &Explanation{Indicators: make(map[string]float64)}
+	values, err := rsi.calculateRSI(df, int(rsi.GetFloat64Param("rsi_period", 14)))
+	if err != nil || len(values) == 0 {
+		explanation.notef("RSI数据不足")
+		return explanation
+	}
+
+	current := values[len(values)-1]
+	oversold := rsi.GetFloat64Param("oversold_level", 30)
+	overbought := rsi.GetFloat64Param("overbought_level", 70)
+	explanation.Indicators["rsi"] = current
+	switch {
+	case current < oversold:
+		explanation.notef("RSI %.2f 低于超卖水平 %.0f", current, oversold)
+	case current > overbought:
+		explanation.notef("RSI %.2f 高于超买水平 %.0f", current, overbought)
+	default:
+		explanation.notef("RSI %.2f 在 %.0f~%.0f 之间，不生成信号", current, oversold, overbought)
+	}
+	return explanation
+}
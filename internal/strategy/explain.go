@@ -0,0 +1,36 @@
+package strategy
+
+import (
+	"fmt"
+
+	"agent-quant-system/internal/data"
+)
+
+// Explanation 策略对本轮决策的说明：用到的指标值和依次检查的条件
+type Explanation struct {
+	Indicators map[string]float64 `json:"indicators,omitempty"`
+	Notes      []string           `json:"notes,omitempty"`
+}
+
+// notef 追加一条条件说明
+func (e *Explanation) notef(format string, args ...interface{}) {
+	e.Notes = append(e.Notes, fmt.Sprintf(format, args...))
+}
+
+// Explainer 能说明决策依据的策略（可选接口），在生成信号后调用，不应有副作用
+type Explainer interface {
+	Explain(df data.DataFrame, guidance *AgentGuidance) *Explanation
+}
+
+// ExplainStrategy 获取策略对本轮决策的说明，策略未实现 Explainer 时返回nil
+func (sm *StrategyManager) ExplainStrategy(name string, df data.DataFrame, guidance *AgentGuidance) *Explanation {
+	strategy, err := sm.GetStrategy(name)
+	if err != nil {
+		return nil
+	}
+	explainer, ok := strategy.(Explainer)
+	if !ok {
+		return nil
+	}
+	return explainer.Explain(df, sm.withPolicy(name, guidance))
+}
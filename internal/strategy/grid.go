@@ -0,0 +1,203 @@
+package strategy
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"agent-quant-system/internal/data"
+	"agent-quant-system/internal/indicators"
+)
+
+// RestingOrder 策略希望在经纪商常驻的限价单
+type RestingOrder struct {
+	Side     Signal  `json:"side"`
+	Level    int     `json:"level"` // 档位序号：网格 0 为最低档，做市的买单为 0、卖单为 1
+	Price    float64 `json:"price"`
+	Quantity float64 `json:"quantity"`
+}
+
+// RestingOrderStrategy 通过常驻限价单交易的策略（可选接口）：交易引擎按返回的挂单撤补经纪商上的订单，并在成交后重新计算
+type RestingOrderStrategy interface {
+	// AppliesTo 是否在该标的上挂单
+	AppliesTo(symbol string) bool
+
+	// RestingOrders 按当前价格、账户净持仓和最近一次成交的档位（没有成交时为-1）返回应挂的限价单，
+	// 返回空列表表示撤销该标的的全部挂单
+	RestingOrders(symbol string, price, position float64, lastFilled int) ([]RestingOrder, error)
+}
+
+// GridStrategy 网格策略：在价格区间内等距设置档位，留空一档，下方的档位挂买单、上方的档位挂卖单。
+// 留空的档位为最近一次成交的档位（尚未成交时为最靠近当前价格的档位），
+// 因此买单成交后上一档挂出卖单，卖单成交后下一档挂出买单
+type GridStrategy struct {
+	BaseStrategy
+}
+
+// NewGridStrategy 创建网格策略
+func NewGridStrategy() *GridStrategy {
+	return &GridStrategy{
+		BaseStrategy: BaseStrategy{
+			Name:        "网格策略",
+			Description: "在上下边界之间按档位常驻限价单，价格来回波动时低买高卖",
+			Parameters: StrategyParams{
+				"lower":      0.0,          // 网格下边界价格
+				"upper":      0.0,          // 网格上边界价格
+				"grid_count": 10.0,         // 区间划分的格数，档位数为格数加1
+				"order_size": 0.0,          // 每档的下单数量
+				"spacing":    "arithmetic", // arithmetic（等差）或 geometric（等比）
+				"symbols":    "",           // 只在这些标的上运行（逗号分隔），为空时对所有标的运行
+			},
+			Metadata: StrategyMetadata{
+				Author:          "quant_service",
+				Version:         "1.0.0",
+				AssetClasses:    []string{"crypto"},
+				RequiredColumns: []string{"close"},
+				Tags:            []string{"网格", "震荡", "限价单"},
+			},
+		},
+	}
+}
+
+// ValidateParameters 验证策略参数，边界和下单数量未设置（为0）时允许注册但不挂单
+func (gs *GridStrategy) ValidateParameters(params StrategyParams) error {
+	lower, _ := params["lower"].(float64)
+	upper, _ := params["upper"].(float64)
+	if lower < 0 || upper < 0 {
+		return fmt.Errorf("lower 和 upper 不能为负数")
+	}
+	if upper > 0 && upper <= lower {
+		return fmt.Errorf("upper 必须大于 lower")
+	}
+	if count, ok := params["grid_count"].(float64); ok && (count < 1 || count != math.Trunc(count)) {
+		return fmt.Errorf("grid_count 必须是正整数")
+	}
+	if size, ok := params["order_size"].(float64); ok && size < 0 {
+		return fmt.Errorf("order_size 不能为负数")
+	}
+	if spacing, ok := params["spacing"].(string); ok && spacing != "arithmetic" && spacing != "geometric" {
+		return fmt.Errorf("spacing 只能是 arithmetic 或 geometric")
+	}
+	if spacing, _ := params["spacing"].(string); spacing == "geometric" && lower <= 0 && upper > 0 {
+		return fmt.Errorf("等比网格的 lower 必须大于0")
+	}
+	return nil
+}
+
+// Initialize 初始化策略
+func (gs *GridStrategy) Initialize() error {
+	if err := gs.ValidateParameters(gs.Parameters); err != nil {
+		return fmt.Errorf("策略参数验证失败: %w", err)
+	}
+	gs.IsActive = true
+	return nil
+}
+
+// GenerateSignals 网格策略不生成市价信号，挂单由交易引擎按 RestingOrders 维护
+func (gs *GridStrategy) GenerateSignals(df data.DataFrame, guidance *AgentGuidance) ([]TradingSignal, error) {
+	if !gs.IsActive {
+		return nil, fmt.Errorf("策略未激活")
+	}
+	return nil, nil
+}
+
+// AppliesTo 策略是否在该标的上运行
+func (gs *GridStrategy) AppliesTo(symbol string) bool {
+	return symbolListContains(gs.GetStringParam("symbols", ""), symbol)
+}
+
+// symbolListContains 逗号分隔的标的列表是否包含 symbol（不区分大小写），列表为空时包含所有标的
+func symbolListContains(list, symbol string) bool {
+	if strings.TrimSpace(list) == "" {
+		return true
+	}
+	for _, item := range strings.Split(list, ",") {
+		if strings.EqualFold(strings.TrimSpace(item), symbol) {
+			return true
+		}
+	}
+	return false
+}
+
+// Levels 网格各档位的价格，从低到高
+func (gs *GridStrategy) Levels() []float64 {
+	lower := gs.GetFloat64Param("lower", 0)
+	upper := gs.GetFloat64Param("upper", 0)
+	count := int(gs.GetFloat64Param("grid_count", 10))
+	if upper <= lower || count < 1 {
+		return nil
+	}
+
+	levels := make([]float64, count+1)
+	geometric := gs.GetStringParam("spacing", "arithmetic") == "geometric" && lower > 0
+	for i := range levels {
+		if geometric {
+			levels[i] = lower * math.Pow(upper/lower, float64(i)/float64(count))
+		} else {
+			levels[i] = lower + (upper-lower)*float64(i)/float64(count)
+		}
+	}
+	return levels
+}
+
+// RestingOrders 计算网格挂单：卖单数量不超过当前持仓可覆盖的档数，买单数量不超过剩余可买的档数（总持仓上限为格数×每档数量）
+func (gs *GridStrategy) RestingOrders(symbol string, price, position float64, lastFilled int) ([]RestingOrder, error) {
+	if !gs.IsActive {
+		return nil, fmt.Errorf("策略未激活")
+	}
+	levels := gs.Levels()
+	size := gs.GetFloat64Param("order_size", 0)
+	if len(levels) == 0 || size <= 0 {
+		return nil, fmt.Errorf("未设置网格边界或每档数量")
+	}
+	if price <= 0 {
+		return nil, fmt.Errorf("无效的价格: %.4f", price)
+	}
+
+	// 留空的档位：最近一次成交的档位，尚未成交（或参数调整后档位不存在）时为最靠近当前价格的档位
+	gap := lastFilled
+	if gap < 0 || gap >= len(levels) {
+		gap = 0
+		for i, level := range levels {
+			if math.Abs(level-price) < math.Abs(levels[gap]-price) {
+				gap = i
+			}
+		}
+	}
+
+	const epsilon = 1e-9
+	held := int(math.Floor(math.Max(position, 0)/size + epsilon))
+	buyable := len(levels) - 1 - held
+
+	var orders []RestingOrder
+	for i := gap - 1; i >= 0 && buyable > 0; i-- {
+		orders = append(orders, RestingOrder{Side: Buy, Level: i, Price: levels[i], Quantity: size})
+		buyable--
+	}
+	for i := gap + 1; i < len(levels) && held > 0; i++ {
+		orders = append(orders, RestingOrder{Side: Sell, Level: i, Price: levels[i], Quantity: size})
+		held--
+	}
+	return orders, nil
+}
+
+// Explain 说明网格区间和当前价格所在的档位
+func (gs *GridStrategy) Explain(df data.DataFrame, guidance *AgentGuidance) *Explanation {
+	explanation := &Explanation{Indicators: make(map[string]float64)}
+	levels := gs.Levels()
+	if len(levels) == 0 || gs.GetFloat64Param("order_size", 0) <= 0 {
+		explanation.notef("未设置网格边界或每档数量，不挂单")
+		return explanation
+	}
+	explanation.Indicators["lower"] = levels[0]
+	explanation.Indicators["upper"] = levels[len(levels)-1]
+	if closes, err := indicators.Float64Column(df, "close"); err == nil && len(closes) > 0 {
+		price := closes[len(closes)-1]
+		explanation.Indicators["price"] = price
+		if price < levels[0] || price > levels[len(levels)-1] {
+			explanation.notef("价格 %.4f 在网格区间 %.4f~%.4f 之外", price, levels[0], levels[len(levels)-1])
+		}
+	}
+	explanation.notef("网格策略不生成市价信号，挂单由交易引擎按档位维护")
+	return explanation
+}
@@ -0,0 +1,96 @@
+package strategy
+
+import (
+	"fmt"
+	"log"
+	"math"
+)
+
+// GuidancePolicy Agent情绪对信号置信度的调整方式，由策略管理器统一提供给所有策略
+type GuidancePolicy struct {
+	Enabled bool    // false 时忽略情绪，信号不做调整
+	Weight  float64 // 情绪与信号同向时置信度增加、反向时减少的幅度
+
+	// Weights 按策略名覆盖 Weight，0 表示该策略不受情绪影响
+	Weights map[string]float64
+
+	ScaleByConfidence bool    // 调整幅度乘以Agent的置信度
+	MinConfidence     float64 // Agent置信度低于该值时忽略情绪
+	VetoConfidence    float64 // 反向情绪的置信度不低于该值时否决信号，0 表示不否决
+}
+
+// DefaultGuidancePolicy 默认调整方式：情绪同向时置信度 +0.1，反向时 -0.1
+func DefaultGuidancePolicy() GuidancePolicy {
+	return GuidancePolicy{Enabled: true, Weight: 0.1}
+}
+
+// forStrategy 按策略名取调整幅度后的调整方式
+func (p GuidancePolicy) forStrategy(name string) *GuidancePolicy {
+	if weight, ok := p.Weights[name]; ok {
+		p.Weight = weight
+	}
+	p.Weights = nil
+	return &p
+}
+
+// SetGuidancePolicy 设置Agent情绪的调整方式，之后执行的策略按新的方式调整
+func (sm *StrategyManager) SetGuidancePolicy(policy GuidancePolicy) {
+	sm.mutex.Lock()
+	defer sm.mutex.Unlock()
+	sm.guidancePolicy = policy
+}
+
+// withPolicy 返回附带策略调整方式的指导副本，guidance 为 nil 时返回 nil
+func (sm *StrategyManager) withPolicy(name string, guidance *AgentGuidance) *AgentGuidance {
+	if guidance == nil {
+		return nil
+	}
+	sm.mutex.RLock()
+	defer sm.mutex.RUnlock()
+	guided := *guidance
+	guided.policy = sm.guidancePolicy.forStrategy(name)
+	return &guided
+}
+
+// Adjust 按调整方式用情绪调整 side 方向信号的置信度（限制在0~1），并在原因后附加说明；
+// 反向情绪的置信度达到否决阈值时返回 false，调用方应丢弃该信号。guidance 为 nil 时不调整，
+// 未经策略管理器传入的指导使用默认调整方式
+func (g *AgentGuidance) Adjust(side Signal, confidence float64, reason string) (float64, string, bool) {
+	if g == nil {
+		return confidence, reason, true
+	}
+	policy := g.policy
+	if policy == nil {
+		defaults := DefaultGuidancePolicy()
+		policy = &defaults
+	}
+	if !policy.Enabled || g.Confidence < policy.MinConfidence {
+		return confidence, reason, true
+	}
+
+	agrees := side == Buy && g.Sentiment == "Positive" || side == Sell && g.Sentiment == "Negative"
+	disagrees := side == Buy && g.Sentiment == "Negative" || side == Sell && g.Sentiment == "Positive"
+	if !agrees && !disagrees {
+		return confidence, reason, true
+	}
+	if disagrees && policy.VetoConfidence > 0 && g.Confidence >= policy.VetoConfidence {
+		log.Printf("Agent情绪 %s (置信度 %.2f) 与%s信号反向，否决信号", g.Sentiment, g.Confidence, side)
+		return confidence, reason + fmt.Sprintf(" x Agent反向(%.2f)否决", g.Confidence), false
+	}
+
+	delta := policy.Weight
+	if policy.ScaleByConfidence {
+		delta *= g.Confidence
+	}
+	if delta == 0 {
+		return confidence, reason, true
+	}
+	if agrees {
+		confidence += delta
+		reason += fmt.Sprintf(" + Agent同向(%.2f)", g.Confidence)
+	} else {
+		confidence -= delta
+		reason += fmt.Sprintf(" - Agent反向(%.2f)", g.Confidence)
+	}
+	return math.Max(0, math.Min(1, confidence)), reason, true
+}
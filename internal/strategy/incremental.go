@@ -0,0 +1,241 @@
+package strategy
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"agent-quant-system/internal/data"
+	"agent-quant-system/internal/indicators"
+)
+
+// Bar 增量策略处理的一根已收盘K线
+type Bar struct {
+	Timestamp time.Time
+	Open      float64
+	High      float64
+	Low       float64
+	Close     float64
+	Volume    float64
+}
+
+// IncrementalStrategy 支持增量模式的策略（可选接口）：指标状态按K线逐根更新，而不是每轮对整个窗口重新计算。
+// 状态由 NewBarState 按标的创建并归调用方所有，实盘循环和回测各自持有，互不影响
+type IncrementalStrategy interface {
+	Strategy
+
+	// WarmupBars 生成信号前需要的K线数，之前的K线只用于更新状态
+	WarmupBars() int
+
+	// NewBarState 为标的创建空的增量状态，周期等参数在创建时确定
+	NewBarState(symbol string) BarState
+}
+
+// BarState 单个标的的增量状态
+type BarState interface {
+	// OnBar 按新K线更新状态，预热完成后返回基于该K线的信号
+	OnBar(bar Bar, guidance *AgentGuidance) ([]TradingSignal, error)
+}
+
+// BarsFromFrame 将DataFrame转换为K线序列，缺少 open 列时使用收盘价，缺少 volume 列时成交量为0
+func BarsFromFrame(df data.DataFrame) ([]Bar, error) {
+	closes, err := indicators.Float64Column(df, "close")
+	if err != nil {
+		return nil, err
+	}
+	column := func(name string, fallback []float64) ([]float64, error) {
+		if _, exists := df[name]; !exists {
+			return fallback, nil
+		}
+		values, err := indicators.Float64Column(df, name)
+		if err == nil && len(values) != len(closes) {
+			err = fmt.Errorf("列 '%s' 长度不一致", name)
+		}
+		return values, err
+	}
+	opens, err := column("open", closes)
+	if err != nil {
+		return nil, err
+	}
+	highs, err := column("high", closes)
+	if err != nil {
+		return nil, err
+	}
+	lows, err := column("low", closes)
+	if err != nil {
+		return nil, err
+	}
+	volumes, err := column("volume", make([]float64, len(closes)))
+	if err != nil {
+		return nil, err
+	}
+
+	timestamps := df["timestamp"]
+	if len(timestamps) != len(closes) {
+		return nil, fmt.Errorf("时间戳列长度不一致")
+	}
+	bars := make([]Bar, len(closes))
+	for i := range closes {
+		timestamp, ok := timestamps[i].(time.Time)
+		if !ok {
+			return nil, fmt.Errorf("第 %d 行时间戳类型错误: %T", i, timestamps[i])
+		}
+		bars[i] = Bar{Timestamp: timestamp, Open: opens[i], High: highs[i], Low: lows[i], Close: closes[i], Volume: volumes[i]}
+	}
+	return bars, nil
+}
+
+// barHistory 增量状态保留的最近若干根K线，转换为DataFrame后用于按波动率计算仓位等需要短窗口的计算
+type barHistory struct {
+	symbol string
+	size   int
+	bars   []Bar
+}
+
+// newBarHistory 创建最多保留 size 根K线的历史
+func newBarHistory(symbol string, size int) *barHistory {
+	if size < 2 {
+		size = 2
+	}
+	return &barHistory{symbol: symbol, size: size, bars: make([]Bar, 0, size)}
+}
+
+// push 加入新K线，超过 size 根时丢弃最早的
+func (h *barHistory) push(bar Bar) {
+	if len(h.bars) == h.size {
+		copy(h.bars, h.bars[1:])
+		h.bars = h.bars[:h.size-1]
+	}
+	h.bars = append(h.bars, bar)
+}
+
+// frame 转换为DataFrame，列与数据管理器返回的行情一致
+func (h *barHistory) frame() data.DataFrame {
+	n := len(h.bars)
+	df := data.DataFrame{
+		"timestamp":       make([]interface{}, n),
+		"open":            make([]interface{}, n),
+		"high":            make([]interface{}, n),
+		"low":             make([]interface{}, n),
+		"close":           make([]interface{}, n),
+		"volume":          make([]interface{}, n),
+		data.SymbolColumn: make([]interface{}, n),
+	}
+	for i, bar := range h.bars {
+		df["timestamp"][i] = bar.Timestamp
+		df["open"][i] = bar.Open
+		df["high"][i] = bar.High
+		df["low"][i] = bar.Low
+		df["close"][i] = bar.Close
+		df["volume"][i] = int64(bar.Volume)
+		df[data.SymbolColumn][i] = h.symbol
+	}
+	return df
+}
+
+// sizingHistorySize 按波动率计算仓位需要保留的K线数
+func (bs *BaseStrategy) sizingHistorySize() int {
+	return int(bs.GetFloat64Param("volatility_lookback", 20)) + 1
+}
+
+// barFeed 实盘循环中一个策略对一个标的的增量状态及已处理到的K线
+type barFeed struct {
+	state BarState
+	last  time.Time
+	bars  int // 已处理的K线数
+}
+
+// SetIncremental 设置以增量模式运行的策略，清除已有的增量状态；策略必须实现 IncrementalStrategy
+func (sm *StrategyManager) SetIncremental(names []string) error {
+	enabled := make(map[string]bool, len(names))
+	for _, name := range names {
+		strategy, err := sm.GetStrategy(name)
+		if err != nil {
+			return err
+		}
+		if _, ok := strategy.(IncrementalStrategy); !ok {
+			return fmt.Errorf("策略 %s 不支持增量模式", name)
+		}
+		enabled[name] = true
+	}
+
+	sm.feedMutex.Lock()
+	defer sm.feedMutex.Unlock()
+	sm.incremental = enabled
+	sm.feeds = make(map[string]*barFeed)
+	return nil
+}
+
+// IsIncremental 策略是否以增量模式运行
+func (sm *StrategyManager) IsIncremental(name string) bool {
+	sm.feedMutex.Lock()
+	defer sm.feedMutex.Unlock()
+	return sm.incremental[name]
+}
+
+// resetFeeds 清除策略在所有标的上的增量状态，下一轮按行情数据重新预热
+func (sm *StrategyManager) resetFeeds(name string) {
+	sm.feedMutex.Lock()
+	defer sm.feedMutex.Unlock()
+	for key := range sm.feeds {
+		if strings.HasPrefix(key, name+"/") {
+			delete(sm.feeds, key)
+		}
+	}
+}
+
+// executeIncremental 把上一轮之后新收盘的K线依次交给增量状态，只返回最新一根K线的信号。
+// 尚未收盘的最后一根K线（开始时间加K线周期晚于当前时间）留到收盘后处理；
+// 行情与上一轮处理到的K线之间有缺口时重建状态并重新预热
+func (sm *StrategyManager) executeIncremental(name string, strategy IncrementalStrategy, df data.DataFrame, guidance *AgentGuidance) ([]TradingSignal, error) {
+	bars, err := BarsFromFrame(df)
+	if err != nil {
+		return nil, fmt.Errorf("解析K线失败: %w", err)
+	}
+	if n := len(bars); n >= 2 {
+		interval := bars[n-1].Timestamp.Sub(bars[n-2].Timestamp)
+		if bars[n-1].Timestamp.Add(interval).After(time.Now()) {
+			bars = bars[:n-1]
+		}
+	}
+	symbol := SignalSymbol(df, guidance)
+
+	sm.feedMutex.Lock()
+	defer sm.feedMutex.Unlock()
+	key := name + "/" + symbol
+	feed, exists := sm.feeds[key]
+	start := 0
+	if exists {
+		start = sort.Search(len(bars), func(i int) bool { return bars[i].Timestamp.After(feed.last) })
+		if start == 0 && len(bars) > 0 {
+			log.Printf("策略 %s 的 %s 行情与上次处理的K线(%s)不连续，重新预热", name, symbol, feed.last.Format("2006-01-02 15:04"))
+			exists = false
+		}
+	}
+	if !exists {
+		feed = &barFeed{state: strategy.NewBarState(symbol)}
+		sm.feeds[key] = feed
+	}
+	if start == len(bars) {
+		log.Printf("策略 %s 的 %s 没有新收盘的K线", name, symbol)
+		return []TradingSignal{}, nil
+	}
+
+	var signals []TradingSignal
+	for i := start; i < len(bars); i++ {
+		signals, err = feed.state.OnBar(bars[i], guidance)
+		if err != nil {
+			// 状态可能已部分更新，下一轮重新预热
+			delete(sm.feeds, key)
+			return nil, err
+		}
+		feed.last = bars[i].Timestamp
+		feed.bars++
+	}
+	if warmup := strategy.WarmupBars(); feed.bars < warmup {
+		log.Printf("策略 %s 的 %s 预热中: %d/%d 根K线", name, symbol, feed.bars, warmup)
+	}
+	return signals, nil
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"sync"
+	"time"
 
 	"agent-quant-system/internal/data"
 )
@@ -12,12 +13,22 @@ import (
 type StrategyManager struct {
 	strategies map[string]Strategy
 	mutex      sync.RWMutex
+
+	// guidancePolicy Agent情绪对各策略信号置信度的调整方式
+	guidancePolicy GuidancePolicy
+
+	// 以增量模式运行的策略及其按标的保存的状态
+	incremental map[string]bool
+	feeds       map[string]*barFeed
+	feedMutex   sync.Mutex
 }
 
 // NewStrategyManager 创建策略管理器
 func NewStrategyManager() *StrategyManager {
 	manager := &StrategyManager{
-		strategies: make(map[string]Strategy),
+		strategies:     make(map[string]Strategy),
+		feeds:          make(map[string]*barFeed),
+		guidancePolicy: DefaultGuidancePolicy(),
 	}
 
 	// 注册默认策略
@@ -45,6 +56,51 @@ func (sm *StrategyManager) registerDefaultStrategies() {
 		sm.strategies["rsi"] = rsiStrategy
 		log.Printf("已注册策略: %s", rsiStrategy.GetName())
 	}
+
+	// 注册唐奇安通道突破策略
+	donchianStrategy := NewDonchianBreakoutStrategy()
+	if err := donchianStrategy.Initialize(); err != nil {
+		log.Printf("唐奇安通道突破策略初始化失败: %v", err)
+	} else {
+		sm.strategies["donchian"] = donchianStrategy
+		log.Printf("已注册策略: %s", donchianStrategy.GetName())
+	}
+
+	// 注册Agent交易方案策略
+	setupStrategy := NewAgentSetupStrategy()
+	if err := setupStrategy.Initialize(); err != nil {
+		log.Printf("Agent交易方案策略初始化失败: %v", err)
+	} else {
+		sm.strategies["agent_setup"] = setupStrategy
+		log.Printf("已注册策略: %s", setupStrategy.GetName())
+	}
+
+	// 注册网格策略
+	gridStrategy := NewGridStrategy()
+	if err := gridStrategy.Initialize(); err != nil {
+		log.Printf("网格策略初始化失败: %v", err)
+	} else {
+		sm.strategies["grid"] = gridStrategy
+		log.Printf("已注册策略: %s", gridStrategy.GetName())
+	}
+
+	// 注册做市策略
+	marketMaking := NewMarketMakingStrategy()
+	if err := marketMaking.Initialize(); err != nil {
+		log.Printf("做市策略初始化失败: %v", err)
+	} else {
+		sm.strategies["market_making"] = marketMaking
+		log.Printf("已注册策略: %s", marketMaking.GetName())
+	}
+
+	// 注册备兑看涨策略
+	coveredCall := NewCoveredCallStrategy()
+	if err := coveredCall.Initialize(); err != nil {
+		log.Printf("备兑看涨策略初始化失败: %v", err)
+	} else {
+		sm.strategies["covered_call"] = coveredCall
+		log.Printf("已注册策略: %s", coveredCall.GetName())
+	}
 }
 
 // RegisterStrategy 注册策略
@@ -91,11 +147,15 @@ func (sm *StrategyManager) GetAvailableStrategies() map[string]StrategyInfo {
 
 	strategies := make(map[string]StrategyInfo)
 	for name, strategy := range sm.strategies {
-		strategies[name] = StrategyInfo{
+		info := StrategyInfo{
 			Name:        strategy.GetName(),
 			Description: strategy.GetDescription(),
 			Parameters:  strategy.GetParameters(),
 		}
+		if provider, ok := strategy.(MetadataProvider); ok {
+			info.Metadata = provider.GetMetadata()
+		}
+		strategies[name] = info
 	}
 
 	return strategies
@@ -106,6 +166,8 @@ type StrategyInfo struct {
 	Name        string         `json:"name"`
 	Description string         `json:"description"`
 	Parameters  StrategyParams `json:"parameters"`
+
+	Metadata StrategyMetadata `json:"metadata"` // 未实现 MetadataProvider 的策略为空
 }
 
 // UnregisterStrategy 注销策略
@@ -129,13 +191,23 @@ func (sm *StrategyManager) UnregisterStrategy(name string) error {
 	return nil
 }
 
-// UpdateStrategyParameters 更新策略参数
+// UpdateStrategyParameters 更新策略参数：params 可以只包含要修改的参数，与当前参数合并后整体验证
 func (sm *StrategyManager) UpdateStrategyParameters(name string, params StrategyParams) error {
 	strategy, err := sm.GetStrategy(name)
 	if err != nil {
 		return err
 	}
 
+	current := strategy.GetParameters()
+	merged := make(StrategyParams, len(current)+len(params))
+	for key, value := range current {
+		merged[key] = value
+	}
+	for key, value := range params {
+		merged[key] = value
+	}
+	params = merged
+
 	// 验证参数
 	if err := strategy.ValidateParameters(params); err != nil {
 		return fmt.Errorf("参数验证失败: %w", err)
@@ -145,6 +217,7 @@ func (sm *StrategyManager) UpdateStrategyParameters(name string, params Strategy
 	if err := strategy.SetParameters(params); err != nil {
 		return fmt.Errorf("设置参数失败: %w", err)
 	}
+	sm.resetFeeds(name)
 
 	log.Printf("成功更新策略 '%s' 的参数", name)
 	return nil
@@ -208,15 +281,61 @@ func (sm *StrategyManager) ExecuteStrategy(name string, data data.DataFrame, gui
 	}
 
 	log.Printf("开始执行策略: %s", name)
-	signals, err := strategy.GenerateSignals(data, guidance)
+	guidance = sm.withPolicy(name, guidance)
+	var signals []TradingSignal
+	if incremental, ok := strategy.(IncrementalStrategy); ok && sm.IsIncremental(name) {
+		signals, err = sm.executeIncremental(name, incremental, data, guidance)
+	} else {
+		signals, err = strategy.GenerateSignals(data, guidance)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("策略执行失败: %w", err)
 	}
 
+	// 标记信号来源策略，未填写标的的信号使用行情数据的标的
+	for i := range signals {
+		if signals[i].Strategy == "" {
+			signals[i].Strategy = name
+		}
+		if signals[i].Symbol == "" {
+			signals[i].Symbol = SignalSymbol(data, guidance)
+		}
+	}
+
 	log.Printf("策略 '%s' 执行完成，生成 %d 个信号", name, len(signals))
 	return signals, nil
 }
 
+// BaselineSignals 以中性情绪的指导运行策略，得到不受Agent情绪调整的信号，用于评估调整是否改善了结果。
+// 增量模式的策略再运行一次会推进增量状态，不计算基线，返回 false
+func (sm *StrategyManager) BaselineSignals(name string, data data.DataFrame, guidance *AgentGuidance) ([]TradingSignal, bool, error) {
+	if sm.IsIncremental(name) {
+		return nil, false, nil
+	}
+	strategy, err := sm.GetStrategy(name)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var neutral *AgentGuidance
+	if guidance != nil {
+		copied := *guidance
+		copied.Sentiment = "Neutral"
+		copied.Confidence = 0
+		neutral = &copied
+	}
+	signals, err := strategy.GenerateSignals(data, neutral)
+	if err != nil {
+		return nil, false, fmt.Errorf("策略执行失败: %w", err)
+	}
+	for i := range signals {
+		if signals[i].Strategy == "" {
+			signals[i].Strategy = name
+		}
+	}
+	return signals, true, nil
+}
+
 // GetStrategyStatus 获取策略状态
 func (sm *StrategyManager) GetStrategyStatus(name string) (*StrategyStatus, error) {
 	strategy, err := sm.GetStrategy(name)
@@ -242,6 +361,30 @@ type StrategyStatus struct {
 	IsActive    bool           `json:"is_active"`
 	Parameters  StrategyParams `json:"parameters"`
 	Description string         `json:"description"`
+
+	// Performance 实盘（含纸面交易）表现，由核心引擎按盈亏账本填充，没有成交的策略为nil
+	Performance *StrategyPerformance `json:"performance,omitempty"`
+
+	// DisabledReason 被策略监控停用的原因，此时 IsActive 为 false
+	DisabledReason string `json:"disabled_reason,omitempty"`
+}
+
+// StrategyPerformance 策略的实盘表现，金额为各账户计价币种的合计，未做汇率折算；
+// 回撤按生成盈亏报告（每轮循环结束和查询状态）时的累计盈亏（含未实现盈亏）计算
+type StrategyPerformance struct {
+	Orders        int       `json:"orders"`   // 成交的订单数
+	Trades        int       `json:"trades"`   // 平仓次数
+	Wins          int       `json:"wins"`     // 盈利的平仓次数
+	HitRate       float64   `json:"hit_rate"` // 胜率，没有平仓时为0
+	RealizedPnL   float64   `json:"realized_pnl"`
+	UnrealizedPnL float64   `json:"unrealized_pnl"`
+	TotalPnL      float64   `json:"total_pnl"`
+	PeakPnL       float64   `json:"peak_pnl"`     // 累计盈亏的最高值（不低于0）
+	Drawdown      float64   `json:"drawdown"`     // 当前累计盈亏距最高值的回撤
+	MaxDrawdown   float64   `json:"max_drawdown"` // 最大回撤
+	UpdatedAt     time.Time `json:"updated_at,omitempty"`
+
+	ConsecutiveLosses int `json:"consecutive_losses"` // 最近连续亏损的平仓次数
 }
 
 // GetAllStrategyStatuses 获取所有策略状态
@@ -0,0 +1,169 @@
+package strategy
+
+import (
+	"fmt"
+	"math"
+
+	"agent-quant-system/internal/data"
+	"agent-quant-system/internal/indicators"
+)
+
+// 做市策略挂单的档位
+const (
+	marketMakingBidLevel = 0
+	marketMakingAskLevel = 1
+)
+
+// MarketMakingStrategy 做市策略：围绕中间价双边挂单赚取买卖价差。持有多头库存时报价中心下移、
+// 持有空头库存时上移，使减仓方向更容易成交；库存达到 max_inventory 时只挂减仓方向的订单
+type MarketMakingStrategy struct {
+	BaseStrategy
+}
+
+// NewMarketMakingStrategy 创建做市策略
+func NewMarketMakingStrategy() *MarketMakingStrategy {
+	return &MarketMakingStrategy{
+		BaseStrategy: BaseStrategy{
+			Name:        "做市策略",
+			Description: "围绕中间价双边常驻限价单，按库存偏移报价并限制库存",
+			Parameters: StrategyParams{
+				"spread_bps":    20.0,  // 买卖报价之间的价差（基点）
+				"order_size":    0.0,   // 每边的下单数量
+				"max_inventory": 0.0,   // 库存上限（净持仓的绝对值），0表示不限制也不偏移报价
+				"skew_bps":      10.0,  // 库存达到上限时报价中心相对中间价的偏移（基点）
+				"requote_bps":   5.0,   // 中间价变动不足该幅度时沿用原报价，避免频繁改单；0表示每次按最新价格报价
+				"allow_short":   false, // 没有持仓时是否挂卖单（做空）
+				"symbols":       "",    // 只在这些标的上运行（逗号分隔），为空时对所有标的运行
+			},
+			Metadata: StrategyMetadata{
+				Author:          "quant_service",
+				Version:         "1.0.0",
+				AssetClasses:    []string{"crypto"},
+				RequiredColumns: []string{"close"},
+				Tags:            []string{"做市", "限价单", "库存"},
+			},
+		},
+	}
+}
+
+// ValidateParameters 验证策略参数，下单数量未设置（为0）时允许注册但不挂单
+func (ms *MarketMakingStrategy) ValidateParameters(params StrategyParams) error {
+	for _, name := range []string{"order_size", "max_inventory", "skew_bps", "requote_bps"} {
+		if value, ok := params[name].(float64); ok && value < 0 {
+			return fmt.Errorf("%s 不能为负数", name)
+		}
+	}
+	if spread, ok := params["spread_bps"].(float64); ok && spread <= 0 {
+		return fmt.Errorf("spread_bps 必须大于0")
+	}
+	if skew, _ := params["skew_bps"].(float64); skew >= 10000 {
+		return fmt.Errorf("skew_bps 必须小于10000")
+	}
+	// 报价中间价按 requote_bps 取整的误差最多为其一半，需小于半个价差，买价才不会高于中间价
+	spread, hasSpread := params["spread_bps"].(float64)
+	if requote, ok := params["requote_bps"].(float64); ok && hasSpread && requote >= spread {
+		return fmt.Errorf("requote_bps 必须小于 spread_bps")
+	}
+	return nil
+}
+
+// Initialize 初始化策略
+func (ms *MarketMakingStrategy) Initialize() error {
+	if err := ms.ValidateParameters(ms.Parameters); err != nil {
+		return fmt.Errorf("策略参数验证失败: %w", err)
+	}
+	ms.IsActive = true
+	return nil
+}
+
+// GenerateSignals 做市策略不生成市价信号，挂单由交易引擎按 RestingOrders 维护
+func (ms *MarketMakingStrategy) GenerateSignals(df data.DataFrame, guidance *AgentGuidance) ([]TradingSignal, error) {
+	if !ms.IsActive {
+		return nil, fmt.Errorf("策略未激活")
+	}
+	return nil, nil
+}
+
+// AppliesTo 策略是否在该标的上运行
+func (ms *MarketMakingStrategy) AppliesTo(symbol string) bool {
+	return symbolListContains(ms.GetStringParam("symbols", ""), symbol)
+}
+
+// RestingOrders 计算买卖报价：报价中心 = 中间价 × (1 - 库存比例 × skew_bps)，库存比例为净持仓/max_inventory（限制在 ±1），
+// 买价和卖价在报价中心两侧各半个 spread_bps；买单数量不超过到库存上限的余量，卖单数量不超过持仓（允许做空时为到空头上限的余量）
+func (ms *MarketMakingStrategy) RestingOrders(symbol string, price, position float64, lastFilled int) ([]RestingOrder, error) {
+	if !ms.IsActive {
+		return nil, fmt.Errorf("策略未激活")
+	}
+	size := ms.GetFloat64Param("order_size", 0)
+	if size <= 0 {
+		return nil, fmt.Errorf("未设置每边的下单数量")
+	}
+	if price <= 0 {
+		return nil, fmt.Errorf("无效的价格: %.4f", price)
+	}
+
+	bid, ask := ms.Quotes(price, position)
+	maxInventory := ms.GetFloat64Param("max_inventory", 0)
+	bidSize, askSize := size, size
+	if maxInventory > 0 {
+		bidSize = math.Min(size, maxInventory-position)
+	}
+	switch {
+	case !ms.GetBoolParam("allow_short", false):
+		askSize = math.Min(size, position)
+	case maxInventory > 0:
+		askSize = math.Min(size, maxInventory+position)
+	}
+
+	const epsilon = 1e-9
+	var orders []RestingOrder
+	if bidSize > epsilon {
+		orders = append(orders, RestingOrder{Side: Buy, Level: marketMakingBidLevel, Price: bid, Quantity: bidSize})
+	}
+	if askSize > epsilon {
+		orders = append(orders, RestingOrder{Side: Sell, Level: marketMakingAskLevel, Price: ask, Quantity: askSize})
+	}
+	return orders, nil
+}
+
+// Quotes 按中间价和净持仓计算买价和卖价
+func (ms *MarketMakingStrategy) Quotes(price, position float64) (float64, float64) {
+	mid := ms.anchor(price)
+	ratio := 0.0
+	if maxInventory := ms.GetFloat64Param("max_inventory", 0); maxInventory > 0 {
+		ratio = math.Max(-1, math.Min(1, position/maxInventory))
+	}
+	center := mid * (1 - ratio*ms.GetFloat64Param("skew_bps", 10)/10000)
+	half := ms.GetFloat64Param("spread_bps", 20) / 10000 / 2
+	return center * (1 - half), center * (1 + half)
+}
+
+// anchor 报价使用的中间价：按 requote_bps 的对数刻度取整，中间价在同一刻度内变动时报价不变
+func (ms *MarketMakingStrategy) anchor(price float64) float64 {
+	step := ms.GetFloat64Param("requote_bps", 5) / 10000
+	if step <= 0 {
+		return price
+	}
+	step = math.Log1p(step)
+	return math.Exp(math.Round(math.Log(price)/step) * step)
+}
+
+// Explain 说明当前价格下没有库存时的报价
+func (ms *MarketMakingStrategy) Explain(df data.DataFrame, guidance *AgentGuidance) *Explanation {
+	explanation := &Explanation{Indicators: make(map[string]float64)}
+	if ms.GetFloat64Param("order_size", 0) <= 0 {
+		explanation.notef("未设置每边的下单数量，不挂单")
+		return explanation
+	}
+	explanation.Indicators["spread_bps"] = ms.GetFloat64Param("spread_bps", 20)
+	if closes, err := indicators.Float64Column(df, "close"); err == nil && len(closes) > 0 {
+		price := closes[len(closes)-1]
+		bid, ask := ms.Quotes(price, 0)
+		explanation.Indicators["price"] = price
+		explanation.Indicators["bid"] = bid
+		explanation.Indicators["ask"] = ask
+	}
+	explanation.notef("做市策略不生成市价信号，报价按库存偏移，由交易引擎维护挂单")
+	return explanation
+}
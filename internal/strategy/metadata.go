@@ -0,0 +1,81 @@
+package strategy
+
+import (
+	"strings"
+)
+
+// StrategyMetadata 策略的展示信息，供前端向非开发人员介绍策略
+type StrategyMetadata struct {
+	DisplayName     string   `json:"display_name,omitempty"`     // 展示名称，为空时使用 GetName
+	Author          string   `json:"author,omitempty"`           // 作者或维护团队
+	Version         string   `json:"version,omitempty"`          // 策略版本
+	AssetClasses    []string `json:"asset_classes,omitempty"`    // 适用的资产类别: stock / crypto
+	Timeframes      []string `json:"timeframes,omitempty"`       // 推荐的K线周期，如 1h、1d
+	RequiredColumns []string `json:"required_columns,omitempty"` // 需要的行情列，如 close、volume
+	Tags            []string `json:"tags,omitempty"`             // 分类标签，如 趋势、均值回归
+}
+
+// MetadataProvider 提供展示信息的策略（可选接口，插件策略可不实现）
+type MetadataProvider interface {
+	GetMetadata() StrategyMetadata
+}
+
+// GetMetadata 获取策略展示信息
+func (bs *BaseStrategy) GetMetadata() StrategyMetadata {
+	metadata := bs.Metadata
+	if metadata.DisplayName == "" {
+		metadata.DisplayName = bs.Name
+	}
+	return metadata
+}
+
+// StrategyFilter 策略发现的筛选条件，零值字段不参与筛选
+type StrategyFilter struct {
+	AssetClass string // 适用于该资产类别
+	Timeframe  string // 推荐该K线周期
+	Tag        string // 带有该标签
+	Query      string // 名称、展示名称或描述中包含该文本（不区分大小写）
+}
+
+// Match 策略是否满足筛选条件；未声明资产类别或周期的策略视为不限
+func (f StrategyFilter) Match(name string, info StrategyInfo) bool {
+	metadata := info.Metadata
+	if f.AssetClass != "" && len(metadata.AssetClasses) > 0 && !containsFold(metadata.AssetClasses, f.AssetClass) {
+		return false
+	}
+	if f.Timeframe != "" && len(metadata.Timeframes) > 0 && !containsFold(metadata.Timeframes, f.Timeframe) {
+		return false
+	}
+	if f.Tag != "" && !containsFold(metadata.Tags, f.Tag) {
+		return false
+	}
+	if f.Query != "" {
+		query := strings.ToLower(f.Query)
+		text := strings.ToLower(name + " " + info.Name + " " + metadata.DisplayName + " " + info.Description)
+		if !strings.Contains(text, query) {
+			return false
+		}
+	}
+	return true
+}
+
+// containsFold values 中是否有与 value 相同的项（不区分大小写）
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// DiscoverStrategies 按条件筛选可用策略
+func (sm *StrategyManager) DiscoverStrategies(filter StrategyFilter) map[string]StrategyInfo {
+	result := make(map[string]StrategyInfo)
+	for name, info := range sm.GetAvailableStrategies() {
+		if filter.Match(name, info) {
+			result[name] = info
+		}
+	}
+	return result
+}
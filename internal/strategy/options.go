@@ -0,0 +1,262 @@
+package strategy
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"agent-quant-system/internal/data"
+	"agent-quant-system/internal/indicators"
+	"agent-quant-system/internal/instrument"
+)
+
+// OptionPosition 账户中标的的一个期权持仓
+type OptionPosition struct {
+	Symbol   string           `json:"symbol"` // OCC 期权代码
+	Strike   float64          `json:"strike"`
+	Expiry   time.Time        `json:"expiry"`
+	Right    instrument.Right `json:"right"`
+	Quantity float64          `json:"quantity"`  // 合约张数，卖出开仓为负
+	AvgPrice float64          `json:"avg_price"` // 开仓时每单位标的的平均权利金
+}
+
+// OptionContext 期权策略每轮运行时的标的行情、持仓和期权链
+type OptionContext struct {
+	Underlying string
+	Price      float64          // 标的最新价格
+	Position   float64          // 标的持仓数量
+	Options    []OptionPosition // 该标的的期权持仓
+	Expiries   []time.Time      // 可交易的期权到期日，升序
+	Multiplier float64          // 每张期权合约对应的标的数量
+	Now        time.Time
+
+	// Chain 获取到期日的期权链
+	Chain func(expiry time.Time) (*data.OptionChain, error)
+}
+
+// OptionStrategy 交易期权的策略（可选接口）：核心引擎每轮为其准备标的的期权持仓和期权链，
+// 按返回的信号下单，信号的 Symbol 为标的或 OCC 期权代码，期权信号的数量为合约张数
+type OptionStrategy interface {
+	// AppliesTo 是否在该标的上运行
+	AppliesTo(symbol string) bool
+
+	// OptionSignals 按当前持仓和期权链生成标的与期权的交易信号
+	OptionSignals(ctx OptionContext) ([]TradingSignal, error)
+}
+
+// CoveredCallStrategy 备兑看涨策略模板：持有标的，每持有一张合约对应的股数就卖出一张虚值看涨期权收取权利金；
+// 期权临近到期时买入平仓，下一轮在新的到期日重新卖出
+type CoveredCallStrategy struct {
+	BaseStrategy
+}
+
+// NewCoveredCallStrategy 创建备兑看涨策略
+func NewCoveredCallStrategy() *CoveredCallStrategy {
+	return &CoveredCallStrategy{
+		BaseStrategy: BaseStrategy{
+			Name:        "备兑看涨策略",
+			Description: "持有标的并按持仓卖出虚值看涨期权收取权利金，临近到期时买回期权滚动到下一到期日",
+			Parameters: StrategyParams{
+				"lots":           0.0,  // 目标备兑的合约张数，为0时只按现有持仓卖出期权，大于0时不足的标的先买入
+				"otm_percent":    0.05, // 行权价高于标的价格的比例
+				"min_days":       20.0, // 卖出期权距到期的最少天数
+				"max_days":       50.0, // 卖出期权距到期的最多天数
+				"close_days":     3.0,  // 距到期不超过该天数时买入平仓
+				"min_premium":    0.0,  // 每单位标的的最低权利金（买价），低于该值不卖出
+				"profit_capture": 0.8,  // 权利金已赚取该比例时提前买入平仓，0表示不提前平仓
+				"symbols":        "",   // 只在这些标的上运行（逗号分隔），为空时对所有标的运行
+			},
+			Metadata: StrategyMetadata{
+				Author:          "quant_service",
+				Version:         "1.0.0",
+				AssetClasses:    []string{"stock"},
+				RequiredColumns: []string{"close"},
+				Tags:            []string{"期权", "备兑", "收益增强"},
+			},
+		},
+	}
+}
+
+// ValidateParameters 验证策略参数
+func (cc *CoveredCallStrategy) ValidateParameters(params StrategyParams) error {
+	for _, name := range []string{"lots", "otm_percent", "min_days", "close_days", "min_premium"} {
+		if value, ok := params[name].(float64); ok && value < 0 {
+			return fmt.Errorf("%s 不能为负数", name)
+		}
+	}
+	if lots, ok := params["lots"].(float64); ok && lots != math.Trunc(lots) {
+		return fmt.Errorf("lots 必须是整数")
+	}
+	minDays, _ := params["min_days"].(float64)
+	if maxDays, ok := params["max_days"].(float64); ok && maxDays < minDays {
+		return fmt.Errorf("max_days 不能小于 min_days")
+	}
+	if capture, ok := params["profit_capture"].(float64); ok && (capture < 0 || capture >= 1) {
+		return fmt.Errorf("profit_capture 必须在 [0, 1) 之间")
+	}
+	return nil
+}
+
+// Initialize 初始化策略
+func (cc *CoveredCallStrategy) Initialize() error {
+	if err := cc.ValidateParameters(cc.Parameters); err != nil {
+		return fmt.Errorf("策略参数验证失败: %w", err)
+	}
+	cc.IsActive = true
+	return nil
+}
+
+// GenerateSignals 备兑看涨策略不按K线生成信号，标的和期权的交易由 OptionSignals 给出
+func (cc *CoveredCallStrategy) GenerateSignals(df data.DataFrame, guidance *AgentGuidance) ([]TradingSignal, error) {
+	if !cc.IsActive {
+		return nil, fmt.Errorf("策略未激活")
+	}
+	return nil, nil
+}
+
+// AppliesTo 策略是否在该标的上运行
+func (cc *CoveredCallStrategy) AppliesTo(symbol string) bool {
+	return symbolListContains(cc.GetStringParam("symbols", ""), symbol)
+}
+
+// OptionSignals 依次处理：买回临近到期或已赚取大部分权利金的看涨期权；标的不足目标张数时买入标的；
+// 未被看涨期权覆盖的整手持仓卖出到期日在 [min_days, max_days] 内、行权价不低于 价格*(1+otm_percent) 的最低行权价看涨期权
+func (cc *CoveredCallStrategy) OptionSignals(ctx OptionContext) ([]TradingSignal, error) {
+	if !cc.IsActive {
+		return nil, fmt.Errorf("策略未激活")
+	}
+	if ctx.Price <= 0 || ctx.Multiplier <= 0 {
+		return nil, fmt.Errorf("无效的标的价格或合约乘数: 价格=%.4f, 乘数=%.0f", ctx.Price, ctx.Multiplier)
+	}
+
+	var signals []TradingSignal
+	chains := make(map[time.Time]*data.OptionChain)
+	chain := func(expiry time.Time) (*data.OptionChain, error) {
+		if cached, ok := chains[expiry]; ok {
+			return cached, nil
+		}
+		loaded, err := ctx.Chain(expiry)
+		if err != nil {
+			return nil, err
+		}
+		chains[expiry] = loaded
+		return loaded, nil
+	}
+
+	// 1. 买回临近到期或已赚取大部分权利金的看涨期权
+	written := 0.0
+	closeDays := cc.GetFloat64Param("close_days", 3)
+	capture := cc.GetFloat64Param("profit_capture", 0.8)
+	for _, option := range ctx.Options {
+		if option.Right != instrument.Call || option.Quantity >= 0 {
+			continue
+		}
+		contracts := -option.Quantity
+		quote, quoted := cc.quote(chain, option)
+		days := option.Expiry.Sub(ctx.Now).Hours() / 24
+		reason := ""
+		switch {
+		case days <= closeDays:
+			reason = fmt.Sprintf("距到期 %.1f 天，买入平仓", days)
+		case quoted && capture > 0 && option.AvgPrice > 0 && quote.Ask > 0 && quote.Ask <= option.AvgPrice*(1-capture):
+			reason = fmt.Sprintf("已赚取 %.0f%% 权利金（卖出 %.2f，买回 %.2f），提前买入平仓",
+				(1-quote.Ask/option.AvgPrice)*100, option.AvgPrice, quote.Ask)
+		}
+		if reason == "" {
+			written += contracts
+			continue
+		}
+		signal := TradingSignal{
+			Symbol: option.Symbol, Signal: Buy, Quantity: contracts, Confidence: 1,
+			Reason: reason, Timestamp: ctx.Now,
+		}
+		if quoted {
+			signal.Price = quote.Ask
+		}
+		signals = append(signals, signal)
+	}
+
+	// 2. 标的不足目标张数时先买入，成交后的下一轮再卖出期权
+	lots := cc.GetFloat64Param("lots", 0)
+	if target := lots * ctx.Multiplier; target > ctx.Position {
+		signals = append(signals, TradingSignal{
+			Symbol: ctx.Underlying, Signal: Buy, Quantity: target - ctx.Position, Price: ctx.Price, Confidence: 1,
+			Reason: fmt.Sprintf("买入标的以备兑 %.0f 张看涨期权", lots), Timestamp: ctx.Now,
+		})
+		return signals, nil
+	}
+
+	// 3. 未被覆盖的整手持仓卖出看涨期权
+	uncovered := math.Floor(ctx.Position/ctx.Multiplier+1e-9) - written
+	if uncovered < 1 {
+		return signals, nil
+	}
+	expiry, ok := cc.expiry(ctx)
+	if !ok {
+		return signals, fmt.Errorf("没有距到期 %.0f~%.0f 天的期权到期日",
+			cc.GetFloat64Param("min_days", 20), cc.GetFloat64Param("max_days", 50))
+	}
+	options, err := chain(expiry)
+	if err != nil {
+		return signals, fmt.Errorf("获取期权链失败: %w", err)
+	}
+	call, ok := cc.selectCall(options, ctx.Price)
+	if !ok {
+		return signals, nil
+	}
+	return append(signals, TradingSignal{
+		Symbol: call.Symbol, Signal: Sell, Quantity: uncovered, Price: call.Bid, Confidence: 1,
+		Reason: fmt.Sprintf("备兑卖出看涨期权: 行权价=%.2f, 到期日=%s, 权利金=%.2f, Delta=%.2f",
+			call.Strike, expiry.Format("2006-01-02"), call.Bid, call.Delta),
+		Timestamp: ctx.Now,
+	}), nil
+}
+
+// quote 期权持仓在期权链中的报价
+func (cc *CoveredCallStrategy) quote(chain func(time.Time) (*data.OptionChain, error), option OptionPosition) (data.OptionQuote, bool) {
+	options, err := chain(option.Expiry)
+	if err != nil {
+		return data.OptionQuote{}, false
+	}
+	return options.Quote(option.Symbol)
+}
+
+// expiry 距到期天数在 [min_days, max_days] 内的最近到期日
+func (cc *CoveredCallStrategy) expiry(ctx OptionContext) (time.Time, bool) {
+	minDays := cc.GetFloat64Param("min_days", 20)
+	maxDays := cc.GetFloat64Param("max_days", 50)
+	for _, expiry := range ctx.Expiries {
+		days := expiry.Sub(ctx.Now).Hours() / 24
+		if days >= minDays && days <= maxDays {
+			return expiry, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// selectCall 行权价不低于 价格*(1+otm_percent) 的最低行权价看涨期权，买价低于最低权利金时不选
+func (cc *CoveredCallStrategy) selectCall(chain *data.OptionChain, price float64) (data.OptionQuote, bool) {
+	floor := price * (1 + cc.GetFloat64Param("otm_percent", 0.05))
+	for _, call := range chain.Calls {
+		if call.Strike < floor {
+			continue
+		}
+		if call.Bid <= 0 || call.Bid < cc.GetFloat64Param("min_premium", 0) {
+			return data.OptionQuote{}, false
+		}
+		return call, true
+	}
+	return data.OptionQuote{}, false
+}
+
+// Explain 说明当前价格下卖出看涨期权的行权价下限
+func (cc *CoveredCallStrategy) Explain(df data.DataFrame, guidance *AgentGuidance) *Explanation {
+	explanation := &Explanation{Indicators: make(map[string]float64)}
+	if closes, err := indicators.Float64Column(df, "close"); err == nil && len(closes) > 0 {
+		price := closes[len(closes)-1]
+		explanation.Indicators["price"] = price
+		explanation.Indicators["min_strike"] = price * (1 + cc.GetFloat64Param("otm_percent", 0.05))
+	}
+	explanation.notef("备兑看涨策略不生成K线信号，按期权链卖出虚值看涨期权")
+	return explanation
+}
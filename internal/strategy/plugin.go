@@ -0,0 +1,92 @@
+package strategy
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"plugin"
+	"sort"
+	"strings"
+)
+
+// 外部策略插件约定：
+//   - 插件实现的是 internal/strategy 中的接口，Go 只允许本模块内的包导入 internal 包，
+//     因此插件源码需放在本模块内（如 plugins/<name>，package main），不能作为单独的模块构建
+//   - 在本模块中使用 go build -buildmode=plugin 构建，Go 版本、go.sum 中的依赖版本和构建参数
+//     （-trimpath、-tags 等）需与主程序一致，否则 plugin.Open 报告包版本不一致；仅支持 Linux 和 macOS，需启用 cgo
+//   - 依赖中 parquet-go 的汇编实现不能动态链接，主程序和插件都需以 -tags purego 构建
+//   - 导出函数 NewStrategy，签名为 func() strategy.Strategy
+//   - 可选导出变量 StrategyName（string），作为注册名称；未导出时使用文件名
+const (
+	pluginFactorySymbol = "NewStrategy"
+	pluginNameSymbol    = "StrategyName"
+)
+
+// LoadPlugins 加载目录下的所有策略插件并注册，返回成功注册的数量
+func (sm *StrategyManager) LoadPlugins(dir string) (int, error) {
+	if _, err := os.Stat(dir); err != nil {
+		return 0, fmt.Errorf("策略插件目录不可用: %w", err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.so"))
+	if err != nil {
+		return 0, fmt.Errorf("扫描策略插件目录失败: %w", err)
+	}
+	sort.Strings(files)
+
+	loaded := 0
+	var failures []string
+	for _, file := range files {
+		name, err := sm.LoadPlugin(file)
+		if err != nil {
+			log.Printf("加载策略插件失败: 文件=%s, 错误=%v", file, err)
+			failures = append(failures, filepath.Base(file))
+			continue
+		}
+		log.Printf("已加载策略插件: %s (%s)", name, file)
+		loaded++
+	}
+
+	if len(failures) > 0 {
+		return loaded, fmt.Errorf("%d 个策略插件加载失败: %s", len(failures), strings.Join(failures, ", "))
+	}
+
+	return loaded, nil
+}
+
+// LoadPlugin 加载单个策略插件并注册，返回注册名称
+func (sm *StrategyManager) LoadPlugin(path string) (string, error) {
+	p, err := plugin.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("打开插件失败（插件需在本模块内用与主程序相同的 Go 版本、依赖和构建参数构建）: %w", err)
+	}
+
+	symbol, err := p.Lookup(pluginFactorySymbol)
+	if err != nil {
+		return "", fmt.Errorf("插件未导出 %s: %w", pluginFactorySymbol, err)
+	}
+
+	factory, ok := symbol.(func() Strategy)
+	if !ok {
+		return "", fmt.Errorf("插件的 %s 类型不正确: %T", pluginFactorySymbol, symbol)
+	}
+
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	if nameSymbol, err := p.Lookup(pluginNameSymbol); err == nil {
+		if pluginName, ok := nameSymbol.(*string); ok && *pluginName != "" {
+			name = *pluginName
+		}
+	}
+
+	if _, err := sm.GetStrategy(name); err == nil {
+		return "", fmt.Errorf("策略名称已存在: %s", name)
+	}
+
+	strategy := factory()
+	if err := sm.RegisterStrategy(name, strategy); err != nil {
+		return "", err
+	}
+
+	return name, nil
+}
@@ -0,0 +1,156 @@
+package strategy
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"agent-quant-system/internal/data"
+	"agent-quant-system/internal/indicators"
+)
+
+// 仓位计算方式
+const (
+	SizingFixed     = "fixed"      // 固定数量：base_quantity * 置信度
+	SizingVolTarget = "vol_target" // 波动率目标：仓位的年化波动率贡献为 sizing_capital * target_volatility
+)
+
+// SizingParameters 仓位计算参数的默认值，合并到策略参数中后可在 strategy.parameters 中按策略覆盖
+func SizingParameters() StrategyParams {
+	return StrategyParams{
+		"sizing":              SizingFixed, // fixed（固定数量）或 vol_target（波动率目标）
+		"base_quantity":       100.0,       // fixed 的基础数量
+		"target_volatility":   0.15,        // vol_target 的目标年化波动率（占 sizing_capital 的比例）
+		"volatility_method":   "stddev",    // stddev（收益率滚动标准差）或 atr（平均真实波幅 / 价格）
+		"volatility_lookback": 20.0,        // 估算波动率的K线数
+		"sizing_capital":      100000.0,    // vol_target 计算仓位使用的资金
+		"max_position_pct":    1.0,         // vol_target 的仓位市值上限（占 sizing_capital 的比例）
+		"bars_per_year":       0.0,         // 年化使用的每年K线数，0表示按K线时间间隔推算
+	}
+}
+
+// withSizing 在策略参数中加入仓位计算参数的默认值
+func withSizing(params StrategyParams) StrategyParams {
+	for key, value := range SizingParameters() {
+		if _, exists := params[key]; !exists {
+			params[key] = value
+		}
+	}
+	return params
+}
+
+// validateSizing 验证仓位计算参数
+func validateSizing(params StrategyParams) error {
+	if sizing, ok := params["sizing"].(string); ok && sizing != SizingFixed && sizing != SizingVolTarget {
+		return fmt.Errorf("sizing 只能是 %s 或 %s", SizingFixed, SizingVolTarget)
+	}
+	if method, ok := params["volatility_method"].(string); ok && method != "stddev" && method != "atr" {
+		return fmt.Errorf("volatility_method 只能是 stddev 或 atr")
+	}
+	for _, name := range []string{"base_quantity", "target_volatility", "sizing_capital", "max_position_pct", "bars_per_year"} {
+		if value, ok := params[name].(float64); ok && value < 0 {
+			return fmt.Errorf("%s 不能为负数", name)
+		}
+	}
+	if lookback, ok := params["volatility_lookback"].(float64); ok && (lookback < 2 || lookback != math.Trunc(lookback)) {
+		return fmt.Errorf("volatility_lookback 必须是不小于2的整数")
+	}
+	return nil
+}
+
+// PositionSize 按策略的 sizing 参数计算下单数量。vol_target 按近期波动率缩放数量，使
+// 数量 * 价格 * 年化波动率 = sizing_capital * target_volatility，再乘以置信度；
+// 数据不足以估算波动率时退回固定数量
+func (bs *BaseStrategy) PositionSize(df data.DataFrame, price, confidence float64) float64 {
+	fixed := bs.GetFloat64Param("base_quantity", 100) * confidence
+	if bs.GetStringParam("sizing", SizingFixed) != SizingVolTarget {
+		return fixed
+	}
+	if price <= 0 {
+		return 0
+	}
+
+	volatility, err := bs.annualizedVolatility(df, price)
+	if err != nil || !(volatility > 0) {
+		log.Printf("无法估算波动率，使用固定数量: %v", err)
+		return fixed
+	}
+
+	capital := bs.GetFloat64Param("sizing_capital", 100000)
+	target := bs.GetFloat64Param("target_volatility", 0.15)
+	quantity := capital * target / (price * volatility) * confidence
+	if maxPct := bs.GetFloat64Param("max_position_pct", 1); maxPct > 0 {
+		quantity = math.Min(quantity, capital*maxPct/price)
+	}
+	log.Printf("波动率目标仓位: 年化波动率=%.2f%%, 目标=%.2f%%, 数量=%.4f", volatility*100, target*100, quantity)
+	return quantity
+}
+
+// annualizedVolatility 按 volatility_method 估算最近 volatility_lookback 根K线的年化波动率
+func (bs *BaseStrategy) annualizedVolatility(df data.DataFrame, price float64) (float64, error) {
+	lookback := int(bs.GetFloat64Param("volatility_lookback", 20))
+	closes, err := indicators.Float64Column(df, "close")
+	if err != nil {
+		return 0, err
+	}
+	if len(closes) < lookback+1 {
+		return 0, fmt.Errorf("数据长度不足: 需要 %d 根K线, 实际 %d", lookback+1, len(closes))
+	}
+
+	var perBar float64
+	switch bs.GetStringParam("volatility_method", "stddev") {
+	case "atr":
+		highs, err := indicators.Float64Column(df, "high")
+		if err != nil {
+			return 0, err
+		}
+		lows, err := indicators.Float64Column(df, "low")
+		if err != nil {
+			return 0, err
+		}
+		atr, err := indicators.ATR(highs, lows, closes, lookback)
+		if err != nil {
+			return 0, err
+		}
+		perBar = indicators.Last(atr) / price
+	default:
+		returns := make([]float64, 0, lookback)
+		for i := len(closes) - lookback; i < len(closes); i++ {
+			if closes[i-1] <= 0 || closes[i] <= 0 {
+				return 0, fmt.Errorf("价格必须为正数才能计算对数收益率")
+			}
+			returns = append(returns, math.Log(closes[i]/closes[i-1]))
+		}
+		stddev, err := indicators.StdDev(returns, lookback)
+		if err != nil {
+			return 0, err
+		}
+		perBar = indicators.Last(stddev)
+	}
+
+	barsPerYear := bs.GetFloat64Param("bars_per_year", 0)
+	if barsPerYear <= 0 {
+		barsPerYear = inferBarsPerYear(df, lookback)
+	}
+	return perBar * math.Sqrt(barsPerYear), nil
+}
+
+// inferBarsPerYear 按最近 lookback 根K线的平均时间间隔推算每年K线数，没有时间列时按日线（252）处理
+func inferBarsPerYear(df data.DataFrame, lookback int) float64 {
+	timestamps := df["timestamp"]
+	if len(timestamps) < lookback+1 {
+		return 252
+	}
+	first, ok1 := timestamps[len(timestamps)-lookback-1].(time.Time)
+	last, ok2 := timestamps[len(timestamps)-1].(time.Time)
+	if !ok1 || !ok2 || !last.After(first) {
+		return 252
+	}
+	interval := last.Sub(first) / time.Duration(lookback)
+	if interval >= 20*time.Hour {
+		// 日线及以上按交易日计
+		return 252 * float64(24*time.Hour) / float64(interval)
+	}
+	return float64(365*24*time.Hour) / float64(interval)
+}
@@ -36,6 +36,41 @@ type AgentGuidance struct {
 	Confidence float64   `json:"confidence"` // 置信度
 	Timestamp  time.Time `json:"timestamp"`  // 时间戳
 	Symbol     string    `json:"symbol"`     // 标的符号
+
+	// Setup Agent建议的交易方案，仅在有策略需要（实现 TradeSetupConsumer）时获取
+	Setup *TradeSetup `json:"setup,omitempty"`
+
+	// policy 策略管理器执行策略时附带的情绪调整方式，见 Adjust
+	policy *GuidancePolicy
+}
+
+// TradeSetup Agent建议的交易方案及换算数量所需的账户信息
+type TradeSetup struct {
+	Action           Signal  `json:"action"`            // 建议操作
+	TargetAllocation float64 `json:"target_allocation"` // 目标仓位占账户权益的比例 [0, 1]
+	StopLoss         float64 `json:"stop_loss"`         // 止损价格，0 表示未给出
+	TakeProfit       float64 `json:"take_profit"`       // 止盈价格，0 表示未给出
+	Confidence       float64 `json:"confidence"`        // 置信度
+	Rationale        string  `json:"rationale"`         // 理由
+	Equity           float64 `json:"equity"`            // 下单账户的权益
+	Position         float64 `json:"position"`          // 下单账户当前的净持仓数量
+}
+
+// Urgency 执行紧迫程度
+type Urgency string
+
+const (
+	UrgencyLow    Urgency = "low"    // 不急于成交：使用限价单，超时不转为市价单
+	UrgencyNormal Urgency = "normal" // 按执行配置下单
+	UrgencyHigh   Urgency = "high"   // 尽快成交：使用市价单
+)
+
+// ExecutionHints 策略对执行层的下单提示，零值字段使用执行配置（trading.execution）
+type ExecutionHints struct {
+	OrderType            string  `json:"order_type,omitempty"`             // 偏好的订单类型: market 或 limit
+	LimitOffset          float64 `json:"limit_offset,omitempty"`           // 限价相对信号价格的偏移比例，买入向下、卖出向上
+	MaxParticipationRate float64 `json:"max_participation_rate,omitempty"` // 订单数量占近期日均成交量的最大比例 (0, 1]
+	Urgency              Urgency `json:"urgency,omitempty"`
 }
 
 // TradingSignal 交易信号
@@ -49,6 +84,18 @@ type TradingSignal struct {
 	Timestamp  time.Time `json:"timestamp"`   // 时间戳
 	StopLoss   float64   `json:"stop_loss"`   // 止损价格
 	TakeProfit float64   `json:"take_profit"` // 止盈价格
+
+	TrailingStopPercent float64 `json:"trailing_stop_percent,omitempty"` // 跟踪止损回撤比例
+	Strategy            string  `json:"strategy,omitempty"`              // 生成信号的策略名称
+
+	// ClosePercent 平仓信号：大于0时按当前净持仓的比例（0~1）平仓，忽略 Signal 和 Quantity
+	ClosePercent float64 `json:"close_percent,omitempty"`
+
+	// Hints 执行提示，为空时完全按执行配置下单
+	Hints *ExecutionHints `json:"hints,omitempty"`
+
+	// ClientOrderID 首次提交结果不确定时由交易引擎返回，重新提交时沿用以避免重复下单
+	ClientOrderID string `json:"client_order_id,omitempty"`
 }
 
 // StrategyParams 策略参数
@@ -87,6 +134,9 @@ type BaseStrategy struct {
 	Description string         `json:"description"`
 	Parameters  StrategyParams `json:"parameters"`
 	IsActive    bool           `json:"is_active"`
+
+	// 作者、版本、适用资产类别等展示信息
+	Metadata StrategyMetadata `json:"metadata"`
 }
 
 // GetName 获取策略名称
@@ -167,6 +217,17 @@ func (bs *BaseStrategy) GetBoolParam(key string, defaultValue bool) bool {
 	return defaultValue
 }
 
+// SignalSymbol 信号的标的：优先使用行情数据的标的列，其次使用Agent指导中的标的
+func SignalSymbol(df data.DataFrame, guidance *AgentGuidance) string {
+	if symbol := df.Symbol(); symbol != "" {
+		return symbol
+	}
+	if guidance != nil {
+		return guidance.Symbol
+	}
+	return ""
+}
+
 // CreateTradingSignal 创建交易信号
 func CreateTradingSignal(symbol string, signal Signal, price, quantity, confidence float64, reason string) TradingSignal {
 	return TradingSignal{
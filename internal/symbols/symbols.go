@@ -0,0 +1,303 @@
+package symbols
+
+import (
+	"strings"
+
+	"agent-quant-system/internal/config"
+)
+
+// DefaultMarket 未配置 default_market 时省略市场代码的股票市场
+const DefaultMarket = "US"
+
+// markets 可识别的股票市场代码，出现在代码前缀（US.AAPL）或后缀（AAPL.US、0700.HK）中
+var markets = map[string]bool{
+	"US": true, "HK": true, "SH": true, "SZ": true, "BJ": true, "SG": true,
+	"JP": true, "UK": true, "L": true, "CA": true, "TO": true, "AU": true, "AX": true, "DE": true,
+}
+
+// stableQuotes 规范写法中直接拼接在币种之后的计价币种（BTCUSDT），其他计价币种用连字符分隔（BTC-USD）
+var stableQuotes = []string{"FDUSD", "USDT", "USDC", "BUSD"}
+
+// quoteCurrencies 带分隔符或按交易所写法拼接时可识别的计价币种
+var quoteCurrencies = []string{"FDUSD", "USDT", "USDC", "BUSD", "USD", "EUR", "GBP", "JPY", "BTC", "ETH", "DAI"}
+
+// Symbol 解析后的标的：股票为代码和市场，加密货币交易对为币种和计价币种
+type Symbol struct {
+	Base   string // 股票代码或币种，如 AAPL、0700、BTC
+	Quote  string // 计价币种，股票为空
+	Market string // 股票市场代码，规范写法中为默认市场时为空
+}
+
+// IsCrypto 是否为加密货币交易对
+func (s Symbol) IsCrypto() bool {
+	return s.Quote != ""
+}
+
+// venue 一个数据源或经纪商的写法
+type venue struct {
+	stockFormat  string
+	cryptoFormat string
+	lowercase    bool
+	quotes       map[string]string // 规范计价币种 -> 该处的计价币种
+	reverse      map[string]string // 该处的计价币种 -> 规范计价币种
+	aliases      map[string]string // 规范写法 -> 该处的写法
+	canonical    map[string]string // 该处的写法 -> 规范写法
+}
+
+// Mapper 标的代码转换服务：把各种写法统一为规范写法，并按数据源、经纪商的写法相互转换；
+// 为 nil 或未配置对应写法时不转换
+type Mapper struct {
+	defaultMarket string
+	venues        map[string]*venue
+}
+
+// NewMapper 按配置创建标的代码转换服务
+func NewMapper(cfg config.SymbolsConfig) *Mapper {
+	m := &Mapper{
+		defaultMarket: strings.ToUpper(cfg.DefaultMarket),
+		venues:        make(map[string]*venue, len(cfg.Venues)),
+	}
+	if m.defaultMarket == "" {
+		m.defaultMarket = DefaultMarket
+	}
+
+	for name, venueConfig := range cfg.Venues {
+		v := &venue{
+			stockFormat:  venueConfig.StockFormat,
+			cryptoFormat: venueConfig.CryptoFormat,
+			lowercase:    venueConfig.Lowercase,
+			quotes:       make(map[string]string, len(venueConfig.Quotes)),
+			reverse:      make(map[string]string, len(venueConfig.Quotes)),
+			aliases:      make(map[string]string, len(venueConfig.Aliases)),
+			canonical:    make(map[string]string, len(venueConfig.Aliases)),
+		}
+		for from, to := range venueConfig.Quotes {
+			from, to = strings.ToUpper(from), strings.ToUpper(to)
+			v.quotes[from] = to
+			v.reverse[to] = from
+		}
+		for symbol, alias := range venueConfig.Aliases {
+			// viper 读取的键为小写，别名的标的按大写处理
+			symbol = m.Normalize(strings.ToUpper(symbol))
+			v.aliases[symbol] = alias
+			v.canonical[strings.ToUpper(alias)] = symbol
+		}
+		// viper 读取的键为小写，名称按不区分大小写匹配
+		m.venues[strings.ToLower(name)] = v
+	}
+	return m
+}
+
+// Parse 解析标的的各种写法：AAPL、AAPL.US、US.AAPL、0700.HK、BTCUSDT、BTC-USD、BTC/USD、BTC_USDT；
+// 无法识别的写法（如期权、期货合约代码）原样作为股票代码
+func (m *Mapper) Parse(symbol string) Symbol {
+	return m.parse(symbol, stableQuotes)
+}
+
+// parse 解析标的，concatQuotes 为不带分隔符时可识别的计价币种
+func (m *Mapper) parse(symbol string, concatQuotes []string) Symbol {
+	symbol = strings.TrimSpace(symbol)
+	upper := strings.ToUpper(symbol)
+
+	for _, separator := range []string{"-", "/", "_"} {
+		if parts := strings.Split(upper, separator); len(parts) == 2 && parts[0] != "" && isQuote(parts[1], quoteCurrencies) {
+			return Symbol{Base: parts[0], Quote: parts[1]}
+		}
+	}
+
+	if parts := strings.Split(symbol, "."); len(parts) == 2 && parts[0] != "" && parts[1] != "" {
+		switch prefix, suffix := strings.ToUpper(parts[0]), strings.ToUpper(parts[1]); {
+		case markets[suffix]:
+			return m.stock(parts[0], suffix)
+		case markets[prefix]:
+			return m.stock(parts[1], prefix)
+		}
+	}
+
+	for _, quote := range concatQuotes {
+		if len(upper) > len(quote) && strings.HasSuffix(upper, quote) && isAlphanumeric(upper[:len(upper)-len(quote)]) {
+			return Symbol{Base: upper[:len(upper)-len(quote)], Quote: quote}
+		}
+	}
+	if isTicker(upper) {
+		return Symbol{Base: upper}
+	}
+	return Symbol{Base: symbol}
+}
+
+// stock 股票代码，默认市场的市场代码省略
+func (m *Mapper) stock(code, market string) Symbol {
+	if market == m.market() {
+		market = ""
+	}
+	return Symbol{Base: strings.ToUpper(code), Market: market}
+}
+
+// market 规范写法中省略的市场代码
+func (m *Mapper) market() string {
+	if m == nil || m.defaultMarket == "" {
+		return DefaultMarket
+	}
+	return m.defaultMarket
+}
+
+// Format 规范写法
+func (m *Mapper) Format(s Symbol) string {
+	if s.IsCrypto() {
+		if isQuote(s.Quote, stableQuotes) {
+			return s.Base + s.Quote
+		}
+		return s.Base + "-" + s.Quote
+	}
+	if s.Market == "" {
+		return s.Base
+	}
+	return s.Base + "." + s.Market
+}
+
+// Normalize 转换为规范写法，如 AAPL.US、US.AAPL -> AAPL，btc/usdt -> BTCUSDT，BTC/USD -> BTC-USD
+func (m *Mapper) Normalize(symbol string) string {
+	if m == nil {
+		return symbol
+	}
+	return m.Format(m.Parse(symbol))
+}
+
+// Venue 第一个配置了写法的名称，如 Venue(账户名称, 经纪商类型)；都未配置时为空
+func (m *Mapper) Venue(names ...string) string {
+	if m == nil {
+		return ""
+	}
+	for _, name := range names {
+		if _, ok := m.venues[strings.ToLower(name)]; ok {
+			return strings.ToLower(name)
+		}
+	}
+	return ""
+}
+
+// ToVenue 转换为数据源或经纪商的写法，未配置该名称时原样返回
+func (m *Mapper) ToVenue(name, symbol string) string {
+	if m == nil {
+		return symbol
+	}
+	v, ok := m.venues[strings.ToLower(name)]
+	if !ok {
+		return symbol
+	}
+
+	canonical := m.Normalize(symbol)
+	if alias, ok := v.aliases[canonical]; ok {
+		return alias
+	}
+
+	s := m.Parse(canonical)
+	var result string
+	if s.IsCrypto() {
+		quote := s.Quote
+		if mapped, ok := v.quotes[quote]; ok {
+			quote = mapped
+		}
+		switch v.cryptoFormat {
+		case "concat":
+			result = s.Base + quote
+		case "dash":
+			result = s.Base + "-" + quote
+		case "slash":
+			result = s.Base + "/" + quote
+		case "underscore":
+			result = s.Base + "_" + quote
+		default:
+			result = m.Format(Symbol{Base: s.Base, Quote: quote})
+		}
+	} else {
+		market := s.Market
+		if market == "" && isTicker(s.Base) {
+			market = m.market()
+		}
+		// 期货、期权等无法识别市场的代码不加市场前后缀
+		switch {
+		case market != "" && v.stockFormat == "suffix":
+			result = s.Base + "." + market
+		case market != "" && v.stockFormat == "prefix":
+			result = market + "." + s.Base
+		default:
+			result = canonical
+		}
+	}
+
+	if v.lowercase {
+		return strings.ToLower(result)
+	}
+	return result
+}
+
+// FromVenue 将数据源或经纪商回报的标的转换为规范写法，未配置该名称时原样返回
+func (m *Mapper) FromVenue(name, symbol string) string {
+	if m == nil {
+		return symbol
+	}
+	v, ok := m.venues[strings.ToLower(name)]
+	if !ok {
+		return symbol
+	}
+	if canonical, ok := v.canonical[strings.ToUpper(strings.TrimSpace(symbol))]; ok {
+		return canonical
+	}
+
+	// 明确配置为拼接写法的交易所，交易对可能使用任意计价币种（如 BTCUSD）；
+	// 未配置时只识别稳定币和映射后的计价币种，以免把股票代码误认为交易对
+	concatQuotes := append(append([]string{}, stableQuotes...), v.venueQuotes()...)
+	if v.cryptoFormat == "concat" {
+		concatQuotes = quoteCurrencies
+	}
+	s := m.parse(symbol, concatQuotes)
+	if s.IsCrypto() {
+		if canonical, ok := v.reverse[s.Quote]; ok {
+			s.Quote = canonical
+		}
+	}
+	return m.Format(s)
+}
+
+// venueQuotes 该处写法中映射后的计价币种
+func (v *venue) venueQuotes() []string {
+	quotes := make([]string, 0, len(v.reverse))
+	for quote := range v.reverse {
+		quotes = append(quotes, quote)
+	}
+	return quotes
+}
+
+// isQuote 是否为列表中的计价币种
+func isQuote(value string, quotes []string) bool {
+	for _, quote := range quotes {
+		if value == quote {
+			return true
+		}
+	}
+	return false
+}
+
+// isTicker 是否为默认市场的股票代码：1 到 5 个字母
+func isTicker(value string) bool {
+	if len(value) == 0 || len(value) > 5 {
+		return false
+	}
+	for _, r := range value {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// isAlphanumeric 是否只包含字母和数字
+func isAlphanumeric(value string) bool {
+	for _, r := range value {
+		if !(r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+			return false
+		}
+	}
+	return value != ""
+}
@@ -0,0 +1,376 @@
+package trading
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"agent-quant-system/internal/config"
+	"agent-quant-system/internal/money"
+
+	"github.com/shopspring/decimal"
+)
+
+// ErrAllocationExceeded 策略已用完分配的资金
+var ErrAllocationExceeded = errors.New("超出策略分配的资金")
+
+// strategyHolding 策略在账户中某个标的上的持仓（按成本计）
+type strategyHolding struct {
+	quantity decimal.Decimal
+	cost     decimal.Decimal
+}
+
+// AllocationReservation 通过检查的买入订单预占的资金，下单失败时归还，挂单期间按未成交数量保留
+type AllocationReservation struct {
+	account    string
+	strategy   string
+	orderID    string // 经纪商受理后的订单ID
+	quantity   decimal.Decimal
+	price      decimal.Decimal
+	multiplier decimal.Decimal // 合约乘数
+}
+
+// notional 预占金额
+func (r *AllocationReservation) notional() decimal.Decimal {
+	return r.quantity.Mul(r.price).Mul(r.multiplier)
+}
+
+// AllocationStatus 策略资金分配状态
+type AllocationStatus struct {
+	Strategy string          `json:"strategy"`
+	Account  string          `json:"account"`
+	Fraction float64         `json:"fraction"`
+	Used     decimal.Decimal `json:"used"`     // 已占用资金（持仓成本）
+	Reserved decimal.Decimal `json:"reserved"` // 下单中和未成交挂单预占的资金
+}
+
+// StrategyAllocator 策略到账户的路由和资金分配：按成交记录各策略在账户中的持仓成本，
+// 开仓时限制策略占用的资金（持仓成本加预占资金）不超过账户权益的分配比例
+type StrategyAllocator struct {
+	allocations    map[string]config.AllocationConfig
+	defaultAccount string
+	holdings       map[string]map[string]map[string]*strategyHolding // 账户 -> 策略 -> 标的 -> 持仓
+	reservations   map[*AllocationReservation]struct{}
+	multipliers    *contractMultipliers
+	mutex          sync.Mutex
+}
+
+// NewStrategyAllocator 创建策略资金分配，未指定默认账户时使用按名称排序的第一个账户
+func NewStrategyAllocator(cfg config.StrategyConfig, accounts []string) *StrategyAllocator {
+	defaultAccount := cfg.DefaultAccount
+	if defaultAccount == "" && len(accounts) > 0 {
+		sorted := append([]string{}, accounts...)
+		sort.Strings(sorted)
+		defaultAccount = sorted[0]
+	}
+
+	allocations := make(map[string]config.AllocationConfig, len(cfg.Allocations))
+	for name, allocation := range cfg.Allocations {
+		if allocation.Account == "" {
+			allocation.Account = defaultAccount
+		}
+		allocations[name] = allocation
+	}
+
+	return &StrategyAllocator{
+		allocations:    allocations,
+		defaultAccount: defaultAccount,
+		holdings:       make(map[string]map[string]map[string]*strategyHolding),
+		reservations:   make(map[*AllocationReservation]struct{}),
+	}
+}
+
+// Route 策略信号应发送到的账户
+func (sa *StrategyAllocator) Route(strategyName string) string {
+	if allocation, exists := sa.allocations[strategyName]; exists {
+		return allocation.Account
+	}
+	return sa.defaultAccount
+}
+
+// CheckAccount 检查策略是否允许在账户中交易：配置了分配的策略只能使用分配的账户
+func (sa *StrategyAllocator) CheckAccount(strategyName, accountName string) error {
+	allocation, exists := sa.allocations[strategyName]
+	if !exists || allocation.Account == accountName {
+		return nil
+	}
+	return fmt.Errorf("策略 '%s' 分配在账户 '%s'，不能在账户 '%s' 交易", strategyName, allocation.Account, accountName)
+}
+
+// Check 检查买入订单是否超出策略的可用资金，超出时按剩余资金缩减数量，没有剩余时拒绝。
+// 检查和预占在同一次加锁中完成，并发的订单不会都按同一份剩余资金通过；未配置分配时返回的预占为 nil
+func (sa *StrategyAllocator) Check(order Order, accountName string, equity decimal.Decimal, precision money.Precision) (Order, *AllocationReservation, error) {
+	allocation, exists := sa.allocations[order.Strategy]
+	if !exists || allocation.Fraction <= 0 || order.Side != BuySide {
+		return order, nil, nil
+	}
+
+	sa.mutex.Lock()
+	defer sa.mutex.Unlock()
+
+	used := sa.used(accountName, order.Strategy).Add(sa.reserved(accountName, order.Strategy))
+	limit := equity.Mul(money.FromFloat(allocation.Fraction))
+	available := limit.Sub(used)
+	price := order.Price
+	if !price.IsPositive() {
+		price = order.referencePrice
+	}
+
+	multiplier := sa.multipliers.multiplier(order.Symbol)
+	if sa.multipliers.orderNotional(order).GreaterThan(available) {
+		if !available.IsPositive() || !price.IsPositive() {
+			return order, nil, fmt.Errorf("%w: 策略 '%s' 已占用 %s, 上限 %s", ErrAllocationExceeded,
+				order.Strategy, used.StringFixed(2), limit.StringFixed(2))
+		}
+
+		quantity := precision.RoundQuantity(available.Div(price.Mul(multiplier)))
+		if !quantity.IsPositive() {
+			return order, nil, fmt.Errorf("%w: 策略 '%s' 剩余资金 %s 不足以买入1个最小单位", ErrAllocationExceeded,
+				order.Strategy, available.StringFixed(2))
+		}
+
+		log.Printf("按策略资金分配缩减订单: 策略=%s, 账户=%s, 数量 %s -> %s, 可用资金=%s",
+			order.Strategy, accountName, order.Quantity, quantity, available.StringFixed(2))
+		order.Quantity = quantity
+	}
+
+	reservation := &AllocationReservation{
+		account:    accountName,
+		strategy:   order.Strategy,
+		quantity:   order.Quantity,
+		price:      price,
+		multiplier: multiplier,
+	}
+	sa.reservations[reservation] = struct{}{}
+	return order, reservation, nil
+}
+
+// Release 归还未提交或已被拒绝的订单预占的资金，reservation 为 nil 时忽略
+func (sa *StrategyAllocator) Release(reservation *AllocationReservation) {
+	if reservation == nil {
+		return
+	}
+	sa.mutex.Lock()
+	defer sa.mutex.Unlock()
+	delete(sa.reservations, reservation)
+}
+
+// Bind 经纪商受理订单后按订单ID保留预占，之后的成交从预占转入持仓，订单撤销或终止时归还剩余部分
+func (sa *StrategyAllocator) Bind(reservation *AllocationReservation, order *Order) {
+	if reservation == nil {
+		return
+	}
+	sa.mutex.Lock()
+	defer sa.mutex.Unlock()
+
+	if _, exists := sa.reservations[reservation]; !exists {
+		return
+	}
+	if order.Status.IsTerminal() {
+		delete(sa.reservations, reservation)
+		return
+	}
+	reservation.orderID = order.ID
+}
+
+// ReleaseOrder 订单撤销或终止后归还按订单ID保留的预占
+func (sa *StrategyAllocator) ReleaseOrder(orderID string) {
+	if orderID == "" {
+		return
+	}
+	sa.mutex.Lock()
+	defer sa.mutex.Unlock()
+
+	for reservation := range sa.reservations {
+		if reservation.orderID == orderID {
+			delete(sa.reservations, reservation)
+		}
+	}
+}
+
+// used 策略在账户中占用的资金，调用方需持有锁
+func (sa *StrategyAllocator) used(accountName, strategyName string) decimal.Decimal {
+	total := decimal.Zero
+	for _, holding := range sa.holdings[accountName][strategyName] {
+		total = total.Add(holding.cost)
+	}
+	return total
+}
+
+// reserved 策略在账户中预占的资金，调用方需持有锁
+func (sa *StrategyAllocator) reserved(accountName, strategyName string) decimal.Decimal {
+	total := decimal.Zero
+	for reservation := range sa.reservations {
+		if reservation.account == accountName && reservation.strategy == strategyName {
+			total = total.Add(reservation.notional())
+		}
+	}
+	return total
+}
+
+// RecordFill 按成交更新策略持仓；卖出数量超过该策略的持仓时（如止损、手动平仓），
+// 剩余部分依次扣减账户中其他策略在该标的上的持仓
+func (sa *StrategyAllocator) RecordFill(filled *Order, strategyName, accountName string) {
+	if !filled.FilledQty.IsPositive() {
+		return
+	}
+
+	sa.mutex.Lock()
+	defer sa.mutex.Unlock()
+	sa.record(filled.Side, filled.Symbol, filled.FilledQty, filled.AvgPrice, strategyName, accountName)
+
+	if filled.Side != BuySide || filled.ID == "" {
+		return
+	}
+	for reservation := range sa.reservations {
+		if reservation.orderID == filled.ID {
+			reservation.quantity = decimal.Max(reservation.quantity.Sub(filled.FilledQty), decimal.Zero)
+		}
+	}
+}
+
+// record 按一笔成交更新持仓，调用方需持有锁
+func (sa *StrategyAllocator) record(side OrderSide, symbol string, quantity, price decimal.Decimal, strategyName, accountName string) {
+	strategies, exists := sa.holdings[accountName]
+	if !exists {
+		strategies = make(map[string]map[string]*strategyHolding)
+		sa.holdings[accountName] = strategies
+	}
+
+	if side == BuySide {
+		symbols, exists := strategies[strategyName]
+		if !exists {
+			symbols = make(map[string]*strategyHolding)
+			strategies[strategyName] = symbols
+		}
+		holding, exists := symbols[symbol]
+		if !exists {
+			holding = &strategyHolding{}
+			symbols[symbol] = holding
+		}
+		holding.quantity = holding.quantity.Add(quantity)
+		holding.cost = holding.cost.Add(sa.multipliers.notional(symbol, quantity, price))
+		return
+	}
+	sa.reduce(strategies, strategyName, symbol, quantity)
+}
+
+// reduce 扣减标的持仓：先扣减 strategyName 的持仓，剩余部分按策略名依次扣减其他策略，调用方需持有锁
+func (sa *StrategyAllocator) reduce(strategies map[string]map[string]*strategyHolding, strategyName, symbol string, quantity decimal.Decimal) {
+	names := make([]string, 0, len(strategies))
+	for name := range strategies {
+		if name != strategyName {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	names = append([]string{strategyName}, names...)
+
+	remaining := quantity
+	for _, name := range names {
+		holding, exists := strategies[name][symbol]
+		if !exists || !remaining.IsPositive() {
+			continue
+		}
+
+		reduced := decimal.Min(remaining, holding.quantity)
+		holding.cost = holding.cost.Sub(holding.cost.Mul(reduced).Div(holding.quantity))
+		holding.quantity = holding.quantity.Sub(reduced)
+		remaining = remaining.Sub(reduced)
+		if !holding.quantity.IsPositive() {
+			delete(strategies[name], symbol)
+		}
+	}
+}
+
+// Rebuild 启动时按成交流水重建各策略的持仓，再按经纪商当前持仓截断（流水之外平掉的部分）。
+// positions 为 账户 -> 标的 -> 持仓数量，未包含的账户（持仓获取失败）不截断
+func (sa *StrategyAllocator) Rebuild(entries []JournalEntry, positions map[string]map[string]decimal.Decimal) {
+	sa.mutex.Lock()
+	defer sa.mutex.Unlock()
+
+	sa.holdings = make(map[string]map[string]map[string]*strategyHolding)
+	for _, entry := range entries {
+		if entry.Kind != JournalTrade || !entry.Quantity.IsPositive() {
+			continue
+		}
+		sa.record(entry.Side, entry.Symbol, entry.Quantity, entry.Price, entry.Strategy, entry.Account)
+	}
+
+	for accountName, strategies := range sa.holdings {
+		held, exists := positions[accountName]
+		if !exists {
+			continue
+		}
+		totals := make(map[string]decimal.Decimal)
+		for _, symbols := range strategies {
+			for symbol, holding := range symbols {
+				totals[symbol] = totals[symbol].Add(holding.quantity)
+			}
+		}
+		for symbol, total := range totals {
+			excess := total.Sub(decimal.Max(held[symbol], decimal.Zero))
+			if excess.IsPositive() {
+				log.Printf("策略持仓超过经纪商持仓，按经纪商持仓截断: 账户=%s, 标的=%s, 流水 %s, 经纪商 %s",
+					accountName, symbol, total, held[symbol])
+				sa.reduce(strategies, "", symbol, excess)
+			}
+		}
+	}
+}
+
+// GetStatus 获取各策略的资金分配状态（按策略名排序）
+func (sa *StrategyAllocator) GetStatus() []AllocationStatus {
+	sa.mutex.Lock()
+	defer sa.mutex.Unlock()
+
+	statuses := make([]AllocationStatus, 0, len(sa.allocations))
+	for name, allocation := range sa.allocations {
+		statuses = append(statuses, AllocationStatus{
+			Strategy: name,
+			Account:  allocation.Account,
+			Fraction: allocation.Fraction,
+			Used:     sa.used(allocation.Account, name),
+			Reserved: sa.reserved(allocation.Account, name),
+		})
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Strategy < statuses[j].Strategy })
+	return statuses
+}
+
+// loadAllocations 启动时按成交流水和经纪商持仓重建策略资金占用，未配置资金分配或成交流水时跳过
+func (te *TradingEngine) loadAllocations() {
+	cfg := te.currentConfig()
+	if len(cfg.Strategy.Allocations) == 0 || cfg.Trading.JournalFile == "" {
+		return
+	}
+	entries, err := ReadJournal(cfg.Trading.JournalFile, time.Time{})
+	if err != nil {
+		log.Printf("读取成交流水失败，策略资金占用从本次启动开始计算: %v", err)
+		return
+	}
+
+	positions := make(map[string]map[string]decimal.Decimal, len(te.brokers))
+	for accountName, broker := range te.brokers {
+		held, err := broker.GetPositions()
+		if err != nil {
+			log.Printf("获取账户 '%s' 持仓失败，策略资金占用只按成交流水重建: %v", accountName, err)
+			continue
+		}
+		quantities := make(map[string]decimal.Decimal, len(held))
+		for symbol, position := range held {
+			quantities[symbol] = position.Quantity
+		}
+		positions[accountName] = quantities
+	}
+
+	te.allocator.Rebuild(entries, positions)
+	for _, status := range te.allocator.GetStatus() {
+		if status.Used.IsPositive() {
+			log.Printf("已重建策略资金占用: 策略=%s, 账户=%s, 占用=%s", status.Strategy, status.Account, status.Used.StringFixed(2))
+		}
+	}
+}
@@ -0,0 +1,443 @@
+package trading
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"agent-quant-system/internal/config"
+	"agent-quant-system/internal/money"
+
+	"github.com/shopspring/decimal"
+)
+
+// ApprovalStatus 确认状态
+type ApprovalStatus string
+
+const (
+	ApprovalPending  ApprovalStatus = "pending"  // 等待确认
+	ApprovalApproved ApprovalStatus = "approved" // 已确认
+	ApprovalRejected ApprovalStatus = "rejected" // 已拒绝
+	ApprovalExpired  ApprovalStatus = "expired"  // 超时未确认
+)
+
+// ErrOrderNotApproved 订单未获确认（被拒绝或超时）
+var ErrOrderNotApproved = errors.New("订单未获确认")
+
+// ApprovalRequest 待确认的订单
+type ApprovalRequest struct {
+	ID          string          `json:"id"`
+	AccountName string          `json:"account_name"`
+	Strategy    string          `json:"strategy"`
+	Symbol      string          `json:"symbol"`
+	Side        OrderSide       `json:"side"`
+	Type        OrderType       `json:"type"`
+	Quantity    decimal.Decimal `json:"quantity"`
+	Price       decimal.Decimal `json:"price"`
+	Notional    decimal.Decimal `json:"notional"`
+	Status      ApprovalStatus  `json:"status"`
+	CreateTime  time.Time       `json:"create_time"`
+	ExpireTime  time.Time       `json:"expire_time"`
+	DecidedBy   string          `json:"decided_by,omitempty"`
+	DecideTime  time.Time       `json:"decide_time,omitempty"`
+	Reason      string          `json:"reason,omitempty"`
+
+	// 订单的客户端订单号，确认后提交的订单沿用
+	ClientOrderID string `json:"client_order_id,omitempty"`
+}
+
+// ApprovalManager 大额订单人工确认：待确认订单保存在文件中（读写时加跨进程的文件锁），
+// 运行中的引擎和命令行等其他进程通过该文件交换确认结果；等待确认的订单暂存在引擎内存中，确认后再提交
+type ApprovalManager struct {
+	config      config.ApprovalConfig
+	notifiers   []func(ApprovalRequest)
+	parked      map[string]*parkedOrder // 按确认请求ID
+	wake        chan struct{}
+	multipliers *contractMultipliers // 名义金额按合约乘数计算
+	mutex       sync.Mutex
+}
+
+// parkedOrder 等待人工确认的订单
+type parkedOrder struct {
+	order       Order
+	accountName string
+	status      ApprovalStatus // 最近一次检查到的确认状态
+	result      *Order         // 确认后提交的订单
+	err         error          // 被拒绝、超时或提交失败
+	done        bool
+	decided     time.Time
+}
+
+// NewApprovalManager 创建人工确认管理器
+func NewApprovalManager(cfg config.ApprovalConfig) *ApprovalManager {
+	return &ApprovalManager{config: cfg, parked: make(map[string]*parkedOrder), wake: make(chan struct{}, 1)}
+}
+
+// OnRequest 注册新确认请求的通知（如推送到消息渠道）
+func (am *ApprovalManager) OnRequest(notifier func(ApprovalRequest)) {
+	am.mutex.Lock()
+	defer am.mutex.Unlock()
+	am.notifiers = append(am.notifiers, notifier)
+}
+
+// Required 订单名义金额是否达到确认阈值
+func (am *ApprovalManager) Required(order Order) bool {
+	if !am.config.Enabled {
+		return false
+	}
+	return am.multipliers.orderNotional(order).GreaterThanOrEqual(money.FromFloat(am.config.MinNotional))
+}
+
+// Park 登记确认请求后立即返回，订单暂存在内存中，确认后由 run 交给引擎提交，不占用下单队列
+func (am *ApprovalManager) Park(order Order, accountName string) (ApprovalRequest, error) {
+	now := time.Now()
+	request := ApprovalRequest{
+		ID:            fmt.Sprintf("APR_%d", now.UnixNano()),
+		AccountName:   accountName,
+		Strategy:      order.Strategy,
+		Symbol:        order.Symbol,
+		Side:          order.Side,
+		Type:          order.Type,
+		Quantity:      order.Quantity,
+		Price:         order.Price,
+		Notional:      am.multipliers.orderNotional(order),
+		ClientOrderID: order.ClientOrderID,
+		Status:        ApprovalPending,
+		CreateTime:    now,
+		ExpireTime:    now.Add(am.config.Timeout),
+	}
+
+	if err := am.update(func(requests []ApprovalRequest) ([]ApprovalRequest, error) {
+		return append(requests, request), nil
+	}); err != nil {
+		return ApprovalRequest{}, fmt.Errorf("登记确认请求失败: %w", err)
+	}
+
+	am.mutex.Lock()
+	am.parked[request.ID] = &parkedOrder{order: order, accountName: accountName, status: ApprovalPending}
+	notifiers := append([]func(ApprovalRequest){}, am.notifiers...)
+	am.mutex.Unlock()
+
+	log.Printf("订单等待人工确认: ID=%s, 账户=%s, 标的=%s, 方向=%s, 数量=%s, 名义金额=%s, 截止=%s",
+		request.ID, accountName, request.Symbol, request.Side, request.Quantity,
+		request.Notional.StringFixed(2), request.ExpireTime.Format("15:04:05"))
+	for _, notify := range notifiers {
+		notify(request)
+	}
+	return request, nil
+}
+
+// Resolve 暂存订单的处理结果：仍在等待确认或提交中时 pending 为 true；
+// 确认后提交的订单通过 result 返回，被拒绝、超时或提交失败时返回错误
+func (am *ApprovalManager) Resolve(id string) (result *Order, pending bool, err error) {
+	am.mutex.Lock()
+	defer am.mutex.Unlock()
+
+	parked, exists := am.parked[id]
+	if !exists {
+		return nil, false, fmt.Errorf("确认请求 %s 不存在", id)
+	}
+	if !parked.done {
+		return nil, true, nil
+	}
+	return parked.result, false, parked.err
+}
+
+// Wake 立即检查确认结果（通过控制API确认或拒绝后调用）
+func (am *ApprovalManager) Wake() {
+	select {
+	case am.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run 定期检查暂存订单的确认结果，直到 stop 关闭：确认的订单交给 handle 提交，被拒绝或超时的订单以错误调用 handle；
+// 停止时仍未确认的请求标记为超时，避免停止后再确认的订单无人提交
+func (am *ApprovalManager) run(stop <-chan struct{}, handle func(order Order, accountName string, err error) (*Order, error)) {
+	ticker := time.NewTicker(am.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			am.abandon()
+			return
+		case <-ticker.C:
+		case <-am.wake:
+		}
+		am.poll(handle)
+	}
+}
+
+// poll 检查所有等待确认的暂存订单
+func (am *ApprovalManager) poll(handle func(order Order, accountName string, err error) (*Order, error)) {
+	am.mutex.Lock()
+	waiting := make([]string, 0, len(am.parked))
+	for id, parked := range am.parked {
+		if parked.status == ApprovalPending {
+			waiting = append(waiting, id)
+		}
+	}
+	am.mutex.Unlock()
+
+	for _, id := range waiting {
+		request, err := am.expire(id)
+		if err != nil {
+			log.Printf("检查确认结果失败: ID=%s, %v", id, err)
+			continue
+		}
+
+		var decision error
+		switch request.Status {
+		case ApprovalPending:
+			continue
+		case ApprovalApproved:
+			log.Printf("订单已确认，提交订单: ID=%s, 确认人=%s", id, request.DecidedBy)
+		case ApprovalRejected:
+			decision = fmt.Errorf("%w: %s 拒绝, 原因=%s", ErrOrderNotApproved, request.DecidedBy, request.Reason)
+		default:
+			decision = fmt.Errorf("%w: 超过 %v 未确认", ErrOrderNotApproved, am.config.Timeout)
+		}
+
+		am.mutex.Lock()
+		parked := am.parked[id]
+		parked.status = request.Status
+		am.mutex.Unlock()
+
+		// 提交经过下单队列，可能等待其他订单，不阻塞对其他确认结果的检查
+		go func(id string, parked *parkedOrder, decision error) {
+			result, err := handle(parked.order, parked.accountName, decision)
+			am.mutex.Lock()
+			parked.result, parked.err, parked.done, parked.decided = result, err, true, time.Now()
+			am.mutex.Unlock()
+		}(id, parked, decision)
+	}
+	am.prune()
+}
+
+// prune 清理一天前处理完的暂存订单
+func (am *ApprovalManager) prune() {
+	cutoff := time.Now().Add(-24 * time.Hour)
+	am.mutex.Lock()
+	defer am.mutex.Unlock()
+	for id, parked := range am.parked {
+		if parked.done && parked.decided.Before(cutoff) {
+			delete(am.parked, id)
+		}
+	}
+}
+
+// abandon 引擎停止时把仍在等待确认的暂存订单标记为超时
+func (am *ApprovalManager) abandon() {
+	am.mutex.Lock()
+	var waiting []string
+	for id, parked := range am.parked {
+		if parked.status == ApprovalPending {
+			waiting = append(waiting, id)
+			parked.status = ApprovalExpired
+			parked.err = fmt.Errorf("%w: 交易引擎已停止", ErrOrderNotApproved)
+			parked.done = true
+			parked.decided = time.Now()
+		}
+	}
+	am.mutex.Unlock()
+	if len(waiting) == 0 {
+		return
+	}
+
+	pending := make(map[string]bool, len(waiting))
+	for _, id := range waiting {
+		pending[id] = true
+	}
+	err := am.update(func(requests []ApprovalRequest) ([]ApprovalRequest, error) {
+		for i := range requests {
+			if pending[requests[i].ID] && requests[i].Status == ApprovalPending {
+				requests[i].Status = ApprovalExpired
+				requests[i].DecideTime = time.Now()
+				requests[i].Reason = "交易引擎已停止"
+			}
+		}
+		return requests, nil
+	})
+	if err != nil {
+		log.Printf("标记未确认订单失败: %v", err)
+		return
+	}
+	log.Printf("交易引擎停止，%d 个未确认的订单已作废", len(waiting))
+}
+
+// expire 读取确认请求，已过截止时间的待确认请求标记为超时；状态未变化时不写回文件
+func (am *ApprovalManager) expire(id string) (ApprovalRequest, error) {
+	var result ApprovalRequest
+	err := am.update(func(requests []ApprovalRequest) ([]ApprovalRequest, error) {
+		for i := range requests {
+			if requests[i].ID != id {
+				continue
+			}
+			if requests[i].Status != ApprovalPending || !time.Now().After(requests[i].ExpireTime) {
+				result = requests[i]
+				return nil, nil
+			}
+			requests[i].Status = ApprovalExpired
+			requests[i].DecideTime = time.Now()
+			result = requests[i]
+			return requests, nil
+		}
+		return nil, fmt.Errorf("确认请求 %s 不存在", id)
+	})
+	return result, err
+}
+
+// Approve 确认订单，返回更新后的确认请求
+func (am *ApprovalManager) Approve(id, by string) (ApprovalRequest, error) {
+	return am.decide(id, by, "", ApprovalApproved)
+}
+
+// Reject 拒绝订单，返回更新后的确认请求
+func (am *ApprovalManager) Reject(id, by, reason string) (ApprovalRequest, error) {
+	return am.decide(id, by, reason, ApprovalRejected)
+}
+
+// decide 记录确认结果，只能处理未超时的待确认请求
+func (am *ApprovalManager) decide(id, by, reason string, status ApprovalStatus) (ApprovalRequest, error) {
+	var result ApprovalRequest
+	err := am.update(func(requests []ApprovalRequest) ([]ApprovalRequest, error) {
+		for i := range requests {
+			if requests[i].ID != id {
+				continue
+			}
+			if requests[i].Status != ApprovalPending {
+				return nil, fmt.Errorf("确认请求 %s 已处理: %s", id, requests[i].Status)
+			}
+			if time.Now().After(requests[i].ExpireTime) {
+				requests[i].Status = ApprovalExpired
+				requests[i].DecideTime = time.Now()
+				return requests, fmt.Errorf("确认请求 %s 已超时", id)
+			}
+			requests[i].Status = status
+			requests[i].DecidedBy = by
+			requests[i].DecideTime = time.Now()
+			requests[i].Reason = reason
+			result = requests[i]
+			return requests, nil
+		}
+		return nil, fmt.Errorf("确认请求 %s 不存在", id)
+	})
+	return result, err
+}
+
+// List 获取确认请求（按创建时间排序），status 为空时返回全部
+func (am *ApprovalManager) List(status ApprovalStatus) ([]ApprovalRequest, error) {
+	am.mutex.Lock()
+	defer am.mutex.Unlock()
+	unlock, err := lockFile(am.config.File)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	requests, err := am.load()
+	if err != nil {
+		return nil, err
+	}
+
+	var result []ApprovalRequest
+	for _, request := range requests {
+		if status == "" || request.Status == status {
+			result = append(result, request)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].CreateTime.Before(result[j].CreateTime) })
+	return result, nil
+}
+
+// update 在文件锁内读取、修改并写回确认请求文件，其他进程的确认结果不会被覆盖；修改函数返回nil列表时不写回
+func (am *ApprovalManager) update(modify func([]ApprovalRequest) ([]ApprovalRequest, error)) error {
+	am.mutex.Lock()
+	defer am.mutex.Unlock()
+	unlock, err := lockFile(am.config.File)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	requests, err := am.load()
+	if err != nil {
+		return err
+	}
+
+	requests, modifyErr := modify(requests)
+	if requests == nil {
+		return modifyErr
+	}
+
+	// 只保留待确认和最近一天内处理过的请求
+	cutoff := time.Now().Add(-24 * time.Hour)
+	kept := requests[:0]
+	for _, request := range requests {
+		if request.Status == ApprovalPending || request.DecideTime.After(cutoff) {
+			kept = append(kept, request)
+		}
+	}
+
+	if err := am.save(kept); err != nil {
+		return err
+	}
+	return modifyErr
+}
+
+// load 读取确认请求文件
+func (am *ApprovalManager) load() ([]ApprovalRequest, error) {
+	content, err := os.ReadFile(am.config.File)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取确认请求文件失败: %w", err)
+	}
+	if len(content) == 0 {
+		return nil, nil
+	}
+
+	var requests []ApprovalRequest
+	if err := json.Unmarshal(content, &requests); err != nil {
+		return nil, fmt.Errorf("解析确认请求文件失败: %w", err)
+	}
+	return requests, nil
+}
+
+// save 写入确认请求文件（先写临时文件再替换，避免其他进程读到不完整的内容）
+func (am *ApprovalManager) save(requests []ApprovalRequest) error {
+	if err := os.MkdirAll(filepath.Dir(am.config.File), 0755); err != nil {
+		return fmt.Errorf("创建确认请求目录失败: %w", err)
+	}
+
+	content, err := json.MarshalIndent(requests, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化确认请求失败: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(am.config.File), filepath.Base(am.config.File)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("写入确认请求文件失败: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return fmt.Errorf("写入确认请求文件失败: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("写入确认请求文件失败: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), am.config.File); err != nil {
+		return fmt.Errorf("写入确认请求文件失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+package trading
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// 国内交易所代码（与 CTP 的 ExchangeID 一致）
+const (
+	ExchangeSSE   = "SSE"   // 上海证券交易所
+	ExchangeSZSE  = "SZSE"  // 深圳证券交易所
+	ExchangeBSE   = "BSE"   // 北京证券交易所
+	ExchangeCFFEX = "CFFEX" // 中国金融期货交易所
+	ExchangeSHFE  = "SHFE"  // 上海期货交易所
+	ExchangeINE   = "INE"   // 上海国际能源交易中心
+	ExchangeDCE   = "DCE"   // 大连商品交易所
+	ExchangeCZCE  = "CZCE"  // 郑州商品交易所
+	ExchangeGFEX  = "GFEX"  // 广州期货交易所
+)
+
+// cnExchangeAliases 标的后缀对应的交易所，如 600519.SH、rb2501.SHFE
+var cnExchangeAliases = map[string]string{
+	"SH": ExchangeSSE, "SSE": ExchangeSSE,
+	"SZ": ExchangeSZSE, "SZSE": ExchangeSZSE,
+	"BJ": ExchangeBSE, "BSE": ExchangeBSE,
+	"CFFEX": ExchangeCFFEX, "SHFE": ExchangeSHFE, "INE": ExchangeINE,
+	"DCE": ExchangeDCE, "CZCE": ExchangeCZCE, "GFEX": ExchangeGFEX,
+}
+
+// AShareBoard A 股板块，决定涨跌停幅度和最小申报数量
+type AShareBoard string
+
+// A 股板块
+const (
+	MainBoard    AShareBoard = "main"    // 沪深主板
+	ChiNextBoard AShareBoard = "chinext" // 创业板
+	STARBoard    AShareBoard = "star"    // 科创板
+	BSEBoard     AShareBoard = "bse"     // 北交所
+)
+
+// ashareLot A 股一手的股数
+var ashareLot = decimal.NewFromInt(100)
+
+// CNInstrument 国内证券或期货合约
+type CNInstrument struct {
+	Code     string // 交易所合约代码，如 600519、rb2501
+	Exchange string
+}
+
+// IsEquity 是否为沪深北交易所的股票（T+1 交收）
+func (c CNInstrument) IsEquity() bool {
+	return c.Exchange == ExchangeSSE || c.Exchange == ExchangeSZSE || c.Exchange == ExchangeBSE
+}
+
+// Board 股票所属板块
+func (c CNInstrument) Board() AShareBoard {
+	switch {
+	case c.Exchange == ExchangeBSE:
+		return BSEBoard
+	case strings.HasPrefix(c.Code, "688") || strings.HasPrefix(c.Code, "689"):
+		return STARBoard
+	case strings.HasPrefix(c.Code, "300") || strings.HasPrefix(c.Code, "301"):
+		return ChiNextBoard
+	default:
+		return MainBoard
+	}
+}
+
+// Symbol 系统内的标的写法：股票为 代码.SH / .SZ / .BJ，期货为 合约.交易所
+func (c CNInstrument) Symbol() string {
+	switch c.Exchange {
+	case ExchangeSSE:
+		return c.Code + ".SH"
+	case ExchangeSZSE:
+		return c.Code + ".SZ"
+	case ExchangeBSE:
+		return c.Code + ".BJ"
+	}
+	return c.Code + "." + c.Exchange
+}
+
+// ParseCNSymbol 解析国内标的：带交易所后缀（600519.SH、000001.SZ、rb2501.SHFE），
+// 或不带后缀的 6 位股票代码（按代码段推断交易所）
+func ParseCNSymbol(symbol string) (CNInstrument, error) {
+	symbol = strings.TrimSpace(symbol)
+	if dot := strings.LastIndex(symbol, "."); dot > 0 {
+		exchange, ok := cnExchangeAliases[strings.ToUpper(symbol[dot+1:])]
+		if !ok {
+			return CNInstrument{}, fmt.Errorf("无法识别标的 %s 的交易所后缀", symbol)
+		}
+		code := symbol[:dot]
+		if exchange == ExchangeSSE || exchange == ExchangeSZSE || exchange == ExchangeBSE {
+			code = strings.ToUpper(code)
+		}
+		return CNInstrument{Code: code, Exchange: exchange}, nil
+	}
+
+	if len(symbol) != 6 || strings.Trim(symbol, "0123456789") != "" {
+		return CNInstrument{}, fmt.Errorf("标的 %s 需要带交易所后缀，如 600519.SH、rb2501.SHFE", symbol)
+	}
+	switch symbol[0] {
+	case '5', '6':
+		return CNInstrument{Code: symbol, Exchange: ExchangeSSE}, nil
+	case '0', '1', '2', '3':
+		return CNInstrument{Code: symbol, Exchange: ExchangeSZSE}, nil
+	case '4', '8', '9':
+		return CNInstrument{Code: symbol, Exchange: ExchangeBSE}, nil
+	}
+	return CNInstrument{}, fmt.Errorf("无法根据代码推断标的 %s 的交易所", symbol)
+}
+
+// PriceLimitRatio 股票的涨跌停幅度：主板 10%（风险警示股 5%），创业板和科创板 20%，北交所 30%
+func PriceLimitRatio(board AShareBoard, st bool) decimal.Decimal {
+	switch board {
+	case ChiNextBoard, STARBoard:
+		return decimal.NewFromFloat(0.2)
+	case BSEBoard:
+		return decimal.NewFromFloat(0.3)
+	}
+	if st {
+		return decimal.NewFromFloat(0.05)
+	}
+	return decimal.NewFromFloat(0.1)
+}
+
+// PriceLimits 按前收盘价计算涨跌停价，四舍五入到分
+func PriceLimits(previousClose, ratio decimal.Decimal) (lower, upper decimal.Decimal) {
+	one := decimal.NewFromInt(1)
+	upper = previousClose.Mul(one.Add(ratio)).Round(2)
+	lower = previousClose.Mul(one.Sub(ratio)).Round(2)
+	return lower, upper
+}
+
+// CheckPriceLimit 检查价格是否在涨跌停价之间，涨跌停价为0时不检查
+func CheckPriceLimit(symbol string, price, lower, upper decimal.Decimal) error {
+	if upper.IsPositive() && price.GreaterThan(upper) {
+		return fmt.Errorf("%s 委托价 %s 高于涨停价 %s", symbol, price, upper)
+	}
+	if lower.IsPositive() && price.LessThan(lower) {
+		return fmt.Errorf("%s 委托价 %s 低于跌停价 %s", symbol, price, lower)
+	}
+	return nil
+}
+
+// RoundAShareQuantity 按申报数量规则取整股票委托数量：
+// 买入主板、创业板按 100 股整数倍向下取整，科创板不少于 200 股、北交所不少于 100 股，超出部分可按 1 股递增；
+// 卖出不足一手的零股须一次性卖出，sellable 为可卖数量
+func RoundAShareQuantity(board AShareBoard, side OrderSide, quantity, sellable decimal.Decimal) (decimal.Decimal, error) {
+	quantity = quantity.Truncate(0)
+
+	if side == SellSide {
+		if quantity.Equal(sellable) {
+			return quantity, nil
+		}
+		if board == STARBoard || board == BSEBoard {
+			if quantity.LessThan(minimumLot(board)) {
+				return decimal.Zero, fmt.Errorf("卖出 %s 股低于最小申报数量 %s 股（零股须一次性卖出，可卖 %s 股）",
+					quantity, minimumLot(board), sellable)
+			}
+			return quantity, nil
+		}
+		rounded := quantity.Div(ashareLot).Floor().Mul(ashareLot)
+		if rounded.IsZero() {
+			return decimal.Zero, fmt.Errorf("卖出 %s 股不足一手（零股须一次性卖出，可卖 %s 股）", quantity, sellable)
+		}
+		return rounded, nil
+	}
+
+	switch board {
+	case STARBoard, BSEBoard:
+		if quantity.LessThan(minimumLot(board)) {
+			return decimal.Zero, fmt.Errorf("买入 %s 股低于最小申报数量 %s 股", quantity, minimumLot(board))
+		}
+		return quantity, nil
+	}
+	rounded := quantity.Div(ashareLot).Floor().Mul(ashareLot)
+	if rounded.IsZero() {
+		return decimal.Zero, fmt.Errorf("买入 %s 股不足一手（100 股）", quantity)
+	}
+	return rounded, nil
+}
+
+// minimumLot 科创板和北交所的最小申报数量
+func minimumLot(board AShareBoard) decimal.Decimal {
+	if board == STARBoard {
+		return decimal.NewFromInt(200)
+	}
+	return ashareLot
+}
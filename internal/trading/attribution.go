@@ -0,0 +1,110 @@
+package trading
+
+import (
+	"time"
+
+	"agent-quant-system/internal/money"
+	"agent-quant-system/internal/strategy"
+
+	"github.com/shopspring/decimal"
+)
+
+// strategyAttribution 策略的成交笔数和累计盈亏曲线的峰值、回撤
+type strategyAttribution struct {
+	orders      int
+	peak        decimal.Decimal // 累计盈亏的最高值，从0开始
+	drawdown    decimal.Decimal
+	maxDrawdown decimal.Decimal
+	lossStreak  int // 最近连续亏损的平仓次数
+	updatedAt   time.Time
+}
+
+// recordClose 记录一次平仓的盈亏（未扣除手续费）
+func (a *strategyAttribution) recordClose(realized decimal.Decimal) {
+	if realized.IsNegative() {
+		a.lossStreak++
+	} else {
+		a.lossStreak = 0
+	}
+}
+
+// attributionFor 获取策略的归因记录，不存在时创建，调用方需持有锁
+func (l *PnLLedger) attributionFor(strategyName string) *strategyAttribution {
+	attribution, exists := l.attribution[strategyName]
+	if !exists {
+		attribution = &strategyAttribution{}
+		l.attribution[strategyName] = attribution
+	}
+	return attribution
+}
+
+// mark 按报告中各策略的累计盈亏更新峰值和回撤；有持仓获取价格失败的策略未实现盈亏不完整，本次不更新
+func (l *PnLLedger) mark(report *PnLReport) {
+	incomplete := incompleteStrategies(report)
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	for name, totals := range report.ByStrategy {
+		if incomplete[name] {
+			continue
+		}
+		attribution := l.attributionFor(name)
+		attribution.peak = decimal.Max(attribution.peak, totals.TotalPnL)
+		attribution.drawdown = attribution.peak.Sub(totals.TotalPnL)
+		attribution.maxDrawdown = decimal.Max(attribution.maxDrawdown, attribution.drawdown)
+		attribution.updatedAt = report.Time
+	}
+}
+
+// incompleteStrategies 报告中有持仓获取价格失败的策略
+func incompleteStrategies(report *PnLReport) map[string]bool {
+	incomplete := make(map[string]bool)
+	for _, position := range report.Positions {
+		if position.PriceError != "" {
+			incomplete[position.Strategy] = true
+		}
+	}
+	return incomplete
+}
+
+// Performance 按盈亏报告和平仓统计汇总各策略的实盘表现
+func (l *PnLLedger) Performance(report *PnLReport) map[string]*strategy.StrategyPerformance {
+	performance := make(map[string]*strategy.StrategyPerformance, len(report.ByStrategy))
+	for name, totals := range report.ByStrategy {
+		stats := l.TradeStats(name)
+		performance[name] = &strategy.StrategyPerformance{
+			Trades:        stats.Trades,
+			Wins:          stats.Wins,
+			HitRate:       stats.WinRate(),
+			RealizedPnL:   money.Float(totals.RealizedPnL),
+			UnrealizedPnL: money.Float(totals.UnrealizedPnL),
+			TotalPnL:      money.Float(totals.TotalPnL),
+		}
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	for name, attribution := range l.attribution {
+		entry, exists := performance[name]
+		if !exists {
+			continue
+		}
+		entry.Orders = attribution.orders
+		entry.PeakPnL = money.Float(attribution.peak)
+		entry.Drawdown = money.Float(attribution.drawdown)
+		entry.MaxDrawdown = money.Float(attribution.maxDrawdown)
+		entry.ConsecutiveLosses = attribution.lossStreak
+		entry.UpdatedAt = attribution.updatedAt
+	}
+	return performance
+}
+
+// StrategyPerformance 按盈亏报告获取各策略的实盘表现，report 为nil时按最新价格生成
+func (te *TradingEngine) StrategyPerformance(report *PnLReport) map[string]*strategy.StrategyPerformance {
+	if report == nil {
+		report = te.GetPnL()
+	}
+	return te.pnl.Performance(report)
+}
@@ -0,0 +1,140 @@
+package trading
+
+import (
+	"log"
+
+	"agent-quant-system/internal/audit"
+	"agent-quant-system/internal/money"
+	"agent-quant-system/internal/strategy"
+
+	"github.com/shopspring/decimal"
+)
+
+// recordAudit 追加一条审计事件，未启用审计日志时跳过，写入失败只打印不影响交易
+func (te *TradingEngine) recordAudit(event audit.Event) {
+	if te.audit == nil {
+		return
+	}
+	if err := te.audit.Record(event); err != nil {
+		log.Printf("记录审计事件失败: %v", err)
+	}
+}
+
+// orderEvent 按订单填充审计事件的通用字段
+func orderEvent(kind audit.Kind, order Order, accountName string) audit.Event {
+	if accountName == "" {
+		accountName = order.AccountName
+	}
+	return audit.Event{
+		Kind:          kind,
+		CorrelationID: order.ClientOrderID,
+		Account:       accountName,
+		Strategy:      order.Strategy,
+		Symbol:        order.Symbol,
+		OrderID:       order.ID,
+		Side:          string(order.Side),
+		Quantity:      order.Quantity,
+		Price:         order.Price,
+		Status:        string(order.Status),
+	}
+}
+
+// auditSignal 记录交易引擎收到的信号
+func (te *TradingEngine) auditSignal(signal strategy.TradingSignal, accountName string) {
+	event := audit.Event{
+		Kind:          audit.KindSignal,
+		CorrelationID: signal.ClientOrderID,
+		Account:       accountName,
+		Strategy:      signal.Strategy,
+		Symbol:        signal.Symbol,
+		Side:          signal.Signal.String(),
+		Quantity:      money.FromFloat(signal.Quantity),
+		Price:         money.FromFloat(signal.Price),
+		Message:       signal.Reason,
+		Details: map[string]interface{}{
+			"confidence":  signal.Confidence,
+			"stop_loss":   signal.StopLoss,
+			"take_profit": signal.TakeProfit,
+		},
+	}
+	if signal.ClosePercent > 0 {
+		event.Details["close_percent"] = signal.ClosePercent
+	}
+	te.recordAudit(event)
+}
+
+// auditCheck 记录一项检查的决定：err 不为空时为拒绝，数量被缩减时为缩减，否则为通过
+func (te *TradingEngine) auditCheck(check string, before, after Order, accountName string, err error) {
+	event := orderEvent(audit.KindRisk, after, accountName)
+	event.Check = check
+	switch {
+	case err != nil:
+		event = orderEvent(audit.KindRisk, before, accountName)
+		event.Check = check
+		event.Decision = audit.DecisionRejected
+		event.Message = err.Error()
+	case !after.Quantity.Equal(before.Quantity):
+		event.Decision = audit.DecisionResized
+		event.Details = map[string]interface{}{"requested_quantity": before.Quantity.String()}
+	default:
+		event.Decision = audit.DecisionApproved
+	}
+	te.recordAudit(event)
+}
+
+// auditBrokerResponse 记录经纪商对下单请求的响应
+func (te *TradingEngine) auditBrokerResponse(order Order, result *Order, accountName string, err error) {
+	event := orderEvent(audit.KindBrokerResponse, order, accountName)
+	if err != nil {
+		event.Status = string(Rejected)
+		event.Message = err.Error()
+	} else if result != nil {
+		event.OrderID = result.ID
+		event.Status = string(result.Status)
+		event.Quantity = result.Quantity
+		event.Price = result.Price
+		if result.FilledQty.IsPositive() {
+			event.Details = map[string]interface{}{
+				"filled_quantity": result.FilledQty.String(),
+				"avg_price":       result.AvgPrice.String(),
+			}
+		}
+	}
+	te.recordAudit(event)
+}
+
+// auditFill 记录一次新增的成交
+func (te *TradingEngine) auditFill(delta *Order, requested Order, accountName string) {
+	event := orderEvent(audit.KindFill, *delta, accountName)
+	if event.CorrelationID == "" {
+		event.CorrelationID = requested.ClientOrderID
+	}
+	if event.Strategy == "" {
+		event.Strategy = requested.Strategy
+	}
+	event.Quantity = delta.FilledQty
+	event.Price = delta.AvgPrice
+	event.Details = map[string]interface{}{"commission": delta.Commission.String()}
+	te.recordAudit(event)
+}
+
+// auditReplace 记录改单，order 为改单前的挂单，err 不为空时为改单失败
+func (te *TradingEngine) auditReplace(order Order, price, quantity decimal.Decimal, accountName, reason string, err error) {
+	event := orderEvent(audit.KindReplace, order, accountName)
+	event.Message = reason
+	if err != nil {
+		event.Message = reason + ": 改单失败: " + err.Error()
+	}
+	event.Details = map[string]interface{}{"new_price": price.String(), "new_quantity": quantity.String()}
+	te.recordAudit(event)
+}
+
+// auditCancel 记录撤单，err 不为空时为撤单失败
+func (te *TradingEngine) auditCancel(order Order, accountName, reason string, err error) {
+	event := orderEvent(audit.KindCancel, order, accountName)
+	event.Message = reason
+	if err != nil {
+		event.Message = reason + ": 撤单失败: " + err.Error()
+	}
+	te.recordAudit(event)
+}
@@ -1,9 +1,17 @@
 package trading
 
 import (
+	"errors"
 	"fmt"
 	"log"
+	"strings"
+	"sync"
 	"time"
+
+	"agent-quant-system/internal/commission"
+	"agent-quant-system/internal/money"
+
+	"github.com/shopspring/decimal"
 )
 
 // OrderType 订单类型
@@ -23,47 +31,85 @@ const (
 	SellSide OrderSide = "sell" // 卖出
 )
 
-// OrderStatus 订单状态
+// TimeInForce 订单有效期，为空时使用经纪商的默认值
+type TimeInForce string
+
+const (
+	GTC TimeInForce = "gtc" // 撤销前有效
+	DAY TimeInForce = "day" // 当日有效，收盘时未成交部分自动撤销
+	IOC TimeInForce = "ioc" // 立即成交，未成交部分撤销
+	FOK TimeInForce = "fok" // 立即全部成交，否则整单撤销
+)
+
+// immediate 是否要求立即成交
+func (tif TimeInForce) immediate() bool {
+	return tif == IOC || tif == FOK
+}
+
+// OrderStatus 订单状态，状态转换见 order_state.go
 type OrderStatus string
 
 const (
-	Pending   OrderStatus = "pending"   // 待处理
-	Submitted OrderStatus = "submitted" // 已提交
-	Filled    OrderStatus = "filled"    // 已成交
-	Cancelled OrderStatus = "cancelled" // 已取消
-	Rejected  OrderStatus = "rejected"  // 已拒绝
+	Pending         OrderStatus = "pending"          // 待处理
+	Submitted       OrderStatus = "submitted"        // 已提交，经纪商已受理但尚未成交
+	PartiallyFilled OrderStatus = "partially_filled" // 部分成交，剩余数量仍在等待成交
+	Filled          OrderStatus = "filled"           // 已成交
+	Cancelled       OrderStatus = "cancelled"        // 已取消
+	Rejected        OrderStatus = "rejected"         // 已拒绝
+	Expired         OrderStatus = "expired"          // 已过期（如 DAY 订单收盘未成交）
 )
 
 // Order 订单结构体
 type Order struct {
-	ID          string      `json:"id"`
-	Symbol      string      `json:"symbol"`
-	Side        OrderSide   `json:"side"`
-	Type        OrderType   `json:"type"`
-	Quantity    float64     `json:"quantity"`
-	Price       float64     `json:"price"`
-	StopPrice   float64     `json:"stop_price,omitempty"`
-	Status      OrderStatus `json:"status"`
-	FilledQty   float64     `json:"filled_quantity"`
-	AvgPrice    float64     `json:"average_price"`
-	Commission  float64     `json:"commission"`
-	CreateTime  time.Time   `json:"create_time"`
-	UpdateTime  time.Time   `json:"update_time"`
-	AccountName string      `json:"account_name"`
-	Strategy    string      `json:"strategy"`
+	ID          string          `json:"id"`
+	Symbol      string          `json:"symbol"`
+	Side        OrderSide       `json:"side"`
+	Type        OrderType       `json:"type"`
+	Quantity    decimal.Decimal `json:"quantity"`
+	Price       decimal.Decimal `json:"price"`
+	StopPrice   decimal.Decimal `json:"stop_price,omitempty"`
+	TimeInForce TimeInForce     `json:"time_in_force,omitempty"`
+	Status      OrderStatus     `json:"status"`
+	FilledQty   decimal.Decimal `json:"filled_quantity"`
+	AvgPrice    decimal.Decimal `json:"average_price"`
+	Commission  decimal.Decimal `json:"commission"`
+	CreateTime  time.Time       `json:"create_time"`
+	UpdateTime  time.Time       `json:"update_time"`
+	AccountName string          `json:"account_name"`
+	Strategy    string          `json:"strategy"`
+	Paper       bool            `json:"paper,omitempty"`       // 未晋级策略在纸面副本中成交的订单
+	DryRun      bool            `json:"dry_run,omitempty"`     // 演练模式下未发送到经纪商的订单
+	ReduceOnly  bool            `json:"reduce_only,omitempty"` // 只减仓：合约经纪商不会因此单开仓或反向开仓，其他经纪商忽略
+
+	// 等待人工确认、尚未发送到经纪商的订单，ID 为确认请求ID；确认后提交的订单通过 ApprovalManager.Resolve 获取
+	AwaitingApproval bool `json:"awaiting_approval,omitempty"`
+
+	// 交易引擎生成的客户端订单号，重新提交时沿用以识别重复订单
+	ClientOrderID string `json:"client_order_id,omitempty"`
+
+	// 限价单超时转为市价单时，市价单记录原限价单的订单ID
+	ParentOrderID string `json:"parent_order_id,omitempty"`
+
+	// 限价单超时转市价单的设置，仅在引擎内部使用
+	fallbackAfter  time.Duration
+	referencePrice decimal.Decimal
+	approved       bool // 已通过人工确认，再次提交时不重复创建确认请求
 }
 
+// ErrBrokerUnavailable 经纪商不可用（未连接、断线等），调用方可据此按降级策略处理
+var ErrBrokerUnavailable = errors.New("经纪商不可用")
+
 // Trade 成交记录
 type Trade struct {
-	ID          string    `json:"id"`
-	OrderID     string    `json:"order_id"`
-	Symbol      string    `json:"symbol"`
-	Side        OrderSide `json:"side"`
-	Quantity    float64   `json:"quantity"`
-	Price       float64   `json:"price"`
-	Commission  float64   `json:"commission"`
-	Timestamp   time.Time `json:"timestamp"`
-	AccountName string    `json:"account_name"`
+	ID          string          `json:"id"`
+	OrderID     string          `json:"order_id"`
+	Symbol      string          `json:"symbol"`
+	Side        OrderSide       `json:"side"`
+	Quantity    decimal.Decimal `json:"quantity"`
+	Price       decimal.Decimal `json:"price"`
+	Commission  decimal.Decimal `json:"commission"`
+	Timestamp   time.Time       `json:"timestamp"`
+	AccountName string          `json:"account_name"`
 }
 
 // BrokerAPI 经纪商API接口
@@ -81,7 +127,7 @@ type BrokerAPI interface {
 	GetOrders(symbol string, status OrderStatus) ([]Order, error)
 
 	// GetBalance 获取余额
-	GetBalance() (float64, error)
+	GetBalance() (decimal.Decimal, error)
 
 	// GetPositions 获取持仓
 	GetPositions() (map[string]Position, error)
@@ -96,40 +142,189 @@ type BrokerAPI interface {
 	Disconnect() error
 }
 
+// FundingBroker 支持资金调整的经纪商（模拟/纸面交易）
+type FundingBroker interface {
+	// Deposit 入金
+	Deposit(amount decimal.Decimal) error
+
+	// Withdraw 出金
+	Withdraw(amount decimal.Decimal) error
+
+	// SetBalance 直接设置余额
+	SetBalance(balance decimal.Decimal) error
+
+	// Reset 重置为初始资金并清空持仓、订单和成交
+	Reset() error
+}
+
 // Position 持仓信息
 type Position struct {
-	Symbol       string    `json:"symbol"`
-	Quantity     float64   `json:"quantity"`
-	AvgPrice     float64   `json:"average_price"`
-	MarketValue  float64   `json:"market_value"`
-	UnrealizedPL float64   `json:"unrealized_pnl"`
-	RealizedPL   float64   `json:"realized_pnl"`
-	UpdateTime   time.Time `json:"update_time"`
+	Symbol       string          `json:"symbol"`
+	Quantity     decimal.Decimal `json:"quantity"`
+	AvgPrice     decimal.Decimal `json:"average_price"`
+	MarketValue  decimal.Decimal `json:"market_value"`
+	UnrealizedPL decimal.Decimal `json:"unrealized_pnl"`
+	RealizedPL   decimal.Decimal `json:"realized_pnl"`
+	UpdateTime   time.Time       `json:"update_time"`
+}
+
+// 模拟经纪商的成交滑点系数
+var (
+	stockSlippage  = decimal.RequireFromString("1.001")
+	cryptoSlippage = decimal.RequireFromString("1.002")
+)
+
+// defaultCommission 未配置佣金模型时模拟和纸面交易经纪商按成交金额的 0.1% 收取佣金
+var defaultCommission commission.Model = commission.Percentage{Rate: decimal.RequireFromString("0.001")}
+
+// CommissionSimulator 按佣金模型计算成交佣金的模拟经纪商（可选接口）
+type CommissionSimulator interface {
+	SetCommissionModel(model commission.Model)
+}
+
+// simulateFill 模拟经纪商和纸面交易共用的撮合：成交剩余数量的 ratio 部分（按数量精度截断），
+// 不足一个数量单位或成交后剩余不足一个单位时成交全部剩余数量；成交已记入订单，
+// 佣金按 model 以合约乘数 multiplier（0表示1）和是否挂单成交 maker 计算
+func simulateFill(order *Order, price, ratio decimal.Decimal, precision money.Precision, model commission.Model, maker bool, multiplier decimal.Decimal) (Trade, error) {
+	remaining := order.Remaining()
+	quantity := precision.RoundQuantity(remaining.Mul(ratio))
+	if !quantity.IsPositive() || !precision.RoundQuantity(remaining.Sub(quantity)).IsPositive() {
+		quantity = remaining
+	}
+
+	now := time.Now()
+	fee := model.Commission(commission.Fill{
+		Symbol: order.Symbol, Buy: order.Side == BuySide, Quantity: quantity, Price: price, Maker: maker,
+		Multiplier: multiplier,
+	})
+	fill := OrderFill{
+		OrderID:    order.ID,
+		Quantity:   quantity,
+		Price:      price,
+		Commission: precision.RoundAmount(fee),
+		Time:       now,
+	}
+	if err := order.ApplyFill(fill, precision); err != nil {
+		return Trade{}, err
+	}
+	return Trade{
+		ID:          fmt.Sprintf("TRADE_%d", now.UnixNano()),
+		OrderID:     order.ID,
+		Symbol:      order.Symbol,
+		Side:        order.Side,
+		Quantity:    fill.Quantity,
+		Price:       fill.Price,
+		Commission:  fill.Commission,
+		Timestamp:   now,
+		AccountName: order.AccountName,
+	}, nil
+}
+
+// QuoteSimulator 按实时报价撮合挂单的模拟经纪商（可选接口）
+type QuoteSimulator interface {
+	SetPriceSource(prices PriceSource)
+}
+
+// pendingSymbols 未完成的限价单和止损单的标的
+func pendingSymbols(orders map[string]Order) map[string]bool {
+	symbols := make(map[string]bool)
+	for _, order := range orders {
+		if order.Type != MarketOrder && order.Status.IsOpen() {
+			symbols[order.Symbol] = true
+		}
+	}
+	return symbols
+}
+
+// fetchQuotes 获取标的的实时报价，未设置价格来源时为空；获取失败的标的不在结果中，挂单留待下次撮合
+func fetchQuotes(prices PriceSource, symbols map[string]bool) map[string]decimal.Decimal {
+	quotes := make(map[string]decimal.Decimal)
+	if prices == nil {
+		return quotes
+	}
+	for symbol, pending := range symbols {
+		if !pending {
+			continue
+		}
+		if price, err := prices.GetLatestPrice(symbol); err == nil && price > 0 {
+			quotes[symbol] = money.FromFloat(price)
+		}
+	}
+	return quotes
 }
 
 // MockStockBroker 模拟股票经纪商
 type MockStockBroker struct {
-	name        string
-	balance     float64
-	positions   map[string]Position
-	orders      map[string]Order
-	trades      []Trade
-	isConnected bool
+	name           string
+	balance        decimal.Decimal
+	initialBalance decimal.Decimal
+	precision      *money.PrecisionTable
+	positions      map[string]Position
+	orders         map[string]Order
+	trades         []Trade
+	fillRatio      decimal.Decimal // 每次撮合成交剩余数量的比例
+	isConnected    bool
+	mutex          sync.Mutex
+
+	commission commission.Model
+	prices     PriceSource // 撮合限价单和止损单的实时报价，未设置时挂单不会成交
 }
 
-// NewMockStockBroker 创建模拟股票经纪商
-func NewMockStockBroker(name string) *MockStockBroker {
+// NewMockStockBroker 创建模拟股票经纪商，成交价格、数量和金额按精度表取整；
+// fillRatio 为每次撮合成交剩余数量的比例，小于1时模拟部分成交，剩余数量在之后查询订单时继续成交
+func NewMockStockBroker(name string, initialBalance decimal.Decimal, precision *money.PrecisionTable, fillRatio decimal.Decimal) *MockStockBroker {
 	return &MockStockBroker{
-		name:      name,
-		balance:   100000.0,
-		positions: make(map[string]Position),
-		orders:    make(map[string]Order),
-		trades:    make([]Trade, 0),
+		name:           name,
+		balance:        initialBalance,
+		initialBalance: initialBalance,
+		precision:      precision,
+		positions:      make(map[string]Position),
+		orders:         make(map[string]Order),
+		trades:         make([]Trade, 0),
+		fillRatio:      fillRatio,
+		commission:     defaultCommission,
 	}
 }
 
+// SetCommissionModel 设置成交佣金模型
+func (b *MockStockBroker) SetCommissionModel(model commission.Model) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.commission = model
+}
+
+// SetPriceSource 设置撮合限价单和止损单使用的实时报价
+func (b *MockStockBroker) SetPriceSource(prices PriceSource) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.prices = prices
+}
+
+// quotes 在锁外获取标的的实时报价，未设置价格来源或获取失败的标的不在结果中
+func (b *MockStockBroker) quotes(symbols map[string]bool) map[string]decimal.Decimal {
+	b.mutex.Lock()
+	prices := b.prices
+	b.mutex.Unlock()
+
+	return fetchQuotes(prices, symbols)
+}
+
+// pendingQuotes 获取全部挂单标的的实时报价
+func (b *MockStockBroker) pendingQuotes() map[string]decimal.Decimal {
+	b.mutex.Lock()
+	symbols := pendingSymbols(b.orders)
+	b.mutex.Unlock()
+
+	return b.quotes(symbols)
+}
+
 // Connect 连接经纪商
 func (b *MockStockBroker) Connect() error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
 	log.Printf("连接到股票经纪商: %s", b.name)
 	b.isConnected = true
 	return nil
@@ -137,6 +332,9 @@ func (b *MockStockBroker) Connect() error {
 
 // Disconnect 断开连接
 func (b *MockStockBroker) Disconnect() error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
 	log.Printf("断开股票经纪商连接: %s", b.name)
 	b.isConnected = false
 	return nil
@@ -144,59 +342,129 @@ func (b *MockStockBroker) Disconnect() error {
 
 // PlaceOrder 下单
 func (b *MockStockBroker) PlaceOrder(order Order) (*Order, error) {
+	quotes := b.quotes(map[string]bool{order.Symbol: order.Type != MarketOrder})
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
 	if !b.isConnected {
-		return nil, fmt.Errorf("经纪商未连接")
+		return nil, fmt.Errorf("经纪商未连接: %w", ErrBrokerUnavailable)
 	}
 
-	log.Printf("股票经纪商 %s 收到订单: %s %s %.2f @ %.2f",
+	log.Printf("股票经纪商 %s 收到订单: %s %s %s @ %s",
 		b.name, order.Side, order.Symbol, order.Quantity, order.Price)
 
+	// 同一客户端订单号只受理一次
+	if existing, exists := findClientOrder(b.orders, order.ClientOrderID); exists {
+		log.Printf("客户端订单号 %s 已存在，返回已有订单: ID=%s", order.ClientOrderID, existing.ID)
+		return existing, nil
+	}
+
 	// 模拟订单处理
 	order.ID = fmt.Sprintf("STOCK_%d", time.Now().UnixNano())
 	order.Status = Submitted
+	order.FilledQty = decimal.Zero
+	order.AvgPrice = decimal.Zero
+	order.Commission = decimal.Zero
 	order.CreateTime = time.Now()
 	order.UpdateTime = time.Now()
+	order.Quantity = b.precision.For(order.Symbol).RoundQuantity(order.Quantity)
 
-	// 模拟订单成交
-	if order.Type == MarketOrder {
-		// 市价单立即成交
-		order.Status = Filled
-		order.FilledQty = order.Quantity
-		order.AvgPrice = order.Price * 1.001 // 模拟滑点
-		order.Commission = order.Quantity * order.AvgPrice * 0.001
-
-		// 更新持仓和余额
-		b.updatePosition(order)
-		b.updateBalance(order)
-
-		// 记录成交
-		trade := Trade{
-			ID:          fmt.Sprintf("TRADE_%d", time.Now().UnixNano()),
-			OrderID:     order.ID,
-			Symbol:      order.Symbol,
-			Side:        order.Side,
-			Quantity:    order.Quantity,
-			Price:       order.AvgPrice,
-			Commission:  order.Commission,
-			Timestamp:   time.Now(),
-			AccountName: order.AccountName,
+	// 模拟订单成交：市价单立即成交，限价单和止损单在实时报价触及时成交
+	price, marketable := b.marketPrice(order), order.Type == MarketOrder
+	if quote, quoted := quotes[order.Symbol]; quoted && !marketable {
+		price, marketable = triggered(order, quote)
+	}
+	if marketable {
+		if order.TimeInForce == FOK && b.fillRatio.LessThan(decimal.NewFromInt(1)) {
+			// 模拟流动性不足，无法一次全部成交的 FOK 订单整单撤销
+			order.Transition(Cancelled)
+			log.Printf("FOK 订单无法全部成交，已撤销: ID=%s", order.ID)
+		} else {
+			b.fill(&order, price)
+			if order.Status == PartiallyFilled && order.TimeInForce == IOC {
+				order.Transition(Cancelled)
+				log.Printf("IOC 订单部分成交，剩余 %s 已撤销: ID=%s", order.Remaining(), order.ID)
+			}
 		}
-		b.trades = append(b.trades, trade)
-
-		log.Printf("订单已成交: ID=%s, 成交价=%.2f", order.ID, order.AvgPrice)
+		b.orders[order.ID] = order
+	} else if order.TimeInForce.immediate() {
+		// 挂单未触及报价，IOC/FOK 订单直接撤销
+		order.Status = Cancelled
+		b.orders[order.ID] = order
+		log.Printf("%s 订单未能立即成交，已撤销: ID=%s", strings.ToUpper(string(order.TimeInForce)), order.ID)
 	} else {
-		// 限价单待成交
+		// 挂单在之后查询订单时按实时报价撮合
 		b.orders[order.ID] = order
-		log.Printf("限价单已提交: ID=%s", order.ID)
+		log.Printf("挂单已提交: ID=%s, 类型=%s", order.ID, order.Type)
 	}
 
 	return &order, nil
 }
 
+// marketPrice 市价单的模拟成交价：委托价格加滑点
+func (b *MockStockBroker) marketPrice(order Order) decimal.Decimal {
+	return b.precision.For(order.Symbol).RoundPrice(order.Price.Mul(stockSlippage)) // 模拟滑点
+}
+
+// fill 按成交比例以 price 撮合一次订单的剩余数量，更新持仓、余额和成交记录
+func (b *MockStockBroker) fill(order *Order, price decimal.Decimal) {
+	precision := b.precision.For(order.Symbol)
+	trade, err := simulateFill(order, precision.RoundPrice(price), b.fillRatio, precision, b.commission, false, decimal.Zero)
+	if err != nil {
+		log.Printf("模拟成交失败: ID=%s, 错误=%v", order.ID, err)
+		return
+	}
+	b.updatePosition(trade)
+	b.updateBalance(trade)
+	b.trades = append(b.trades, trade)
+
+	if order.Status == Filled {
+		log.Printf("订单已成交: ID=%s, 成交价=%s", order.ID, order.AvgPrice)
+	} else {
+		log.Printf("订单部分成交: ID=%s, 本次 %s @ %s, 累计 %s/%s", order.ID, trade.Quantity, trade.Price, order.FilledQty, order.Quantity)
+	}
+}
+
+// matchOrders 继续撮合部分成交的市价单，并按实时报价撮合挂单，在查询订单前调用
+func (b *MockStockBroker) matchOrders(quotes map[string]decimal.Decimal) {
+	for id, order := range b.orders {
+		if !order.Status.IsOpen() {
+			continue
+		}
+		price, marketable := b.marketPrice(order), order.Type == MarketOrder
+		if quote, quoted := quotes[order.Symbol]; quoted && !marketable {
+			price, marketable = triggered(order, quote)
+		}
+		if marketable {
+			b.fill(&order, price)
+			b.orders[id] = order
+		}
+	}
+}
+
+// ExpireOrder 将未成交的订单标记为过期
+func (b *MockStockBroker) ExpireOrder(orderID string) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	order, exists := b.orders[orderID]
+	if !exists {
+		return fmt.Errorf("订单不存在: %s", orderID)
+	}
+	if err := order.Transition(Expired); err != nil {
+		return err
+	}
+	b.orders[orderID] = order
+	return nil
+}
+
 // CancelOrder 撤单
 func (b *MockStockBroker) CancelOrder(orderID string) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
 	if !b.isConnected {
-		return fmt.Errorf("经纪商未连接")
+		return fmt.Errorf("经纪商未连接: %w", ErrBrokerUnavailable)
 	}
 
 	order, exists := b.orders[orderID]
@@ -204,8 +472,9 @@ func (b *MockStockBroker) CancelOrder(orderID string) error {
 		return fmt.Errorf("订单不存在: %s", orderID)
 	}
 
-	order.Status = Cancelled
-	order.UpdateTime = time.Now()
+	if err := order.Transition(Cancelled); err != nil {
+		return err
+	}
 	b.orders[orderID] = order
 
 	log.Printf("订单已取消: ID=%s", orderID)
@@ -214,10 +483,15 @@ func (b *MockStockBroker) CancelOrder(orderID string) error {
 
 // GetOrder 查询订单
 func (b *MockStockBroker) GetOrder(orderID string) (*Order, error) {
+	quotes := b.pendingQuotes()
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
 	if !b.isConnected {
-		return nil, fmt.Errorf("经纪商未连接")
+		return nil, fmt.Errorf("经纪商未连接: %w", ErrBrokerUnavailable)
 	}
 
+	b.matchOrders(quotes)
 	order, exists := b.orders[orderID]
 	if !exists {
 		return nil, fmt.Errorf("订单不存在: %s", orderID)
@@ -226,12 +500,35 @@ func (b *MockStockBroker) GetOrder(orderID string) (*Order, error) {
 	return &order, nil
 }
 
+// GetOrderByClientID 按客户端订单号查询订单
+func (b *MockStockBroker) GetOrderByClientID(clientOrderID string) (*Order, error) {
+	quotes := b.pendingQuotes()
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if !b.isConnected {
+		return nil, fmt.Errorf("经纪商未连接: %w", ErrBrokerUnavailable)
+	}
+
+	b.matchOrders(quotes)
+	order, exists := findClientOrder(b.orders, clientOrderID)
+	if !exists {
+		return nil, fmt.Errorf("客户端订单号 %s: %w", clientOrderID, ErrOrderNotFound)
+	}
+	return order, nil
+}
+
 // GetOrders 查询订单列表
 func (b *MockStockBroker) GetOrders(symbol string, status OrderStatus) ([]Order, error) {
+	quotes := b.pendingQuotes()
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
 	if !b.isConnected {
-		return nil, fmt.Errorf("经纪商未连接")
+		return nil, fmt.Errorf("经纪商未连接: %w", ErrBrokerUnavailable)
 	}
 
+	b.matchOrders(quotes)
 	var orders []Order
 	for _, order := range b.orders {
 		if symbol != "" && order.Symbol != symbol {
@@ -247,9 +544,12 @@ func (b *MockStockBroker) GetOrders(symbol string, status OrderStatus) ([]Order,
 }
 
 // GetBalance 获取余额
-func (b *MockStockBroker) GetBalance() (float64, error) {
+func (b *MockStockBroker) GetBalance() (decimal.Decimal, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
 	if !b.isConnected {
-		return 0, fmt.Errorf("经纪商未连接")
+		return decimal.Zero, fmt.Errorf("经纪商未连接: %w", ErrBrokerUnavailable)
 	}
 
 	return b.balance, nil
@@ -257,8 +557,11 @@ func (b *MockStockBroker) GetBalance() (float64, error) {
 
 // GetPositions 获取持仓
 func (b *MockStockBroker) GetPositions() (map[string]Position, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
 	if !b.isConnected {
-		return nil, fmt.Errorf("经纪商未连接")
+		return nil, fmt.Errorf("经纪商未连接: %w", ErrBrokerUnavailable)
 	}
 
 	positions := make(map[string]Position)
@@ -271,8 +574,11 @@ func (b *MockStockBroker) GetPositions() (map[string]Position, error) {
 
 // GetTrades 获取成交记录
 func (b *MockStockBroker) GetTrades(symbol string, limit int) ([]Trade, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
 	if !b.isConnected {
-		return nil, fmt.Errorf("经纪商未连接")
+		return nil, fmt.Errorf("经纪商未连接: %w", ErrBrokerUnavailable)
 	}
 
 	var trades []Trade
@@ -288,75 +594,122 @@ func (b *MockStockBroker) GetTrades(symbol string, limit int) ([]Trade, error) {
 	return trades, nil
 }
 
-// updatePosition 更新持仓
-func (b *MockStockBroker) updatePosition(order Order) {
-	position, exists := b.positions[order.Symbol]
+// updatePosition 按成交更新持仓
+func (b *MockStockBroker) updatePosition(trade Trade) {
+	position, exists := b.positions[trade.Symbol]
 
 	if !exists {
 		position = Position{
-			Symbol:      order.Symbol,
-			Quantity:    0,
-			AvgPrice:    0,
-			MarketValue: 0,
-			UpdateTime:  time.Now(),
+			Symbol:     trade.Symbol,
+			UpdateTime: time.Now(),
 		}
 	}
 
-	if order.Side == BuySide {
+	if trade.Side == BuySide {
 		// 买入
-		totalCost := position.Quantity*position.AvgPrice + order.Quantity*order.AvgPrice
-		position.Quantity += order.Quantity
-		if position.Quantity > 0 {
-			position.AvgPrice = totalCost / position.Quantity
+		totalCost := position.Quantity.Mul(position.AvgPrice).Add(trade.Quantity.Mul(trade.Price))
+		position.Quantity = position.Quantity.Add(trade.Quantity)
+		if position.Quantity.IsPositive() {
+			position.AvgPrice = b.precision.For(trade.Symbol).RoundPrice(totalCost.Div(position.Quantity))
 		}
 	} else {
 		// 卖出
-		position.Quantity -= order.Quantity
-		if position.Quantity <= 0 {
-			delete(b.positions, order.Symbol)
+		position.Quantity = position.Quantity.Sub(trade.Quantity)
+		if !position.Quantity.IsPositive() {
+			delete(b.positions, trade.Symbol)
 			return
 		}
 	}
 
-	position.MarketValue = position.Quantity * order.AvgPrice
+	position.MarketValue = b.precision.For(trade.Symbol).RoundAmount(position.Quantity.Mul(trade.Price))
 	position.UpdateTime = time.Now()
-	b.positions[order.Symbol] = position
+	b.positions[trade.Symbol] = position
 }
 
-// updateBalance 更新余额
-func (b *MockStockBroker) updateBalance(order Order) {
-	if order.Side == BuySide {
+// updateBalance 按成交更新余额
+func (b *MockStockBroker) updateBalance(trade Trade) {
+	amount := b.precision.For(trade.Symbol).RoundAmount(trade.Quantity.Mul(trade.Price))
+	if trade.Side == BuySide {
 		// 买入减少余额
-		b.balance -= order.Quantity*order.AvgPrice + order.Commission
+		b.balance = b.balance.Sub(amount).Sub(trade.Commission)
 	} else {
 		// 卖出增加余额
-		b.balance += order.Quantity*order.AvgPrice - order.Commission
+		b.balance = b.balance.Add(amount).Sub(trade.Commission)
 	}
 }
 
 // MockCryptoBroker 模拟加密货币交易所
 type MockCryptoBroker struct {
-	name        string
-	balance     float64
-	positions   map[string]Position
-	orders      map[string]Order
-	trades      []Trade
-	isConnected bool
+	name           string
+	balance        decimal.Decimal
+	initialBalance decimal.Decimal
+	precision      *money.PrecisionTable
+	positions      map[string]Position
+	orders         map[string]Order
+	trades         []Trade
+	fillRatio      decimal.Decimal // 每次撮合成交剩余数量的比例
+	isConnected    bool
+	mutex          sync.Mutex
+
+	commission commission.Model
+	prices     PriceSource // 撮合限价单和止损单的实时报价，未设置时挂单不会成交
 }
 
-// NewMockCryptoBroker 创建模拟加密货币交易所
-func NewMockCryptoBroker(name string) *MockCryptoBroker {
+// NewMockCryptoBroker 创建模拟加密货币交易所，成交价格、数量和金额按精度表取整；
+// fillRatio 为每次撮合成交剩余数量的比例，小于1时模拟部分成交，剩余数量在之后查询订单时继续成交
+func NewMockCryptoBroker(name string, initialBalance decimal.Decimal, precision *money.PrecisionTable, fillRatio decimal.Decimal) *MockCryptoBroker {
 	return &MockCryptoBroker{
-		name:      name,
-		balance:   100000.0,
-		positions: make(map[string]Position),
-		orders:    make(map[string]Order),
-		trades:    make([]Trade, 0),
+		name:           name,
+		balance:        initialBalance,
+		initialBalance: initialBalance,
+		precision:      precision,
+		positions:      make(map[string]Position),
+		orders:         make(map[string]Order),
+		trades:         make([]Trade, 0),
+		fillRatio:      fillRatio,
+		commission:     defaultCommission,
 	}
 }
 
+// SetCommissionModel 设置成交佣金模型
+func (b *MockCryptoBroker) SetCommissionModel(model commission.Model) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.commission = model
+}
+
+// SetPriceSource 设置撮合限价单和止损单使用的实时报价
+func (b *MockCryptoBroker) SetPriceSource(prices PriceSource) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.prices = prices
+}
+
+// quotes 在锁外获取标的的实时报价，未设置价格来源或获取失败的标的不在结果中
+func (b *MockCryptoBroker) quotes(symbols map[string]bool) map[string]decimal.Decimal {
+	b.mutex.Lock()
+	prices := b.prices
+	b.mutex.Unlock()
+
+	return fetchQuotes(prices, symbols)
+}
+
+// pendingQuotes 获取全部挂单标的的实时报价
+func (b *MockCryptoBroker) pendingQuotes() map[string]decimal.Decimal {
+	b.mutex.Lock()
+	symbols := pendingSymbols(b.orders)
+	b.mutex.Unlock()
+
+	return b.quotes(symbols)
+}
+
 // Connect 连接交易所
 func (b *MockCryptoBroker) Connect() error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
 	log.Printf("连接到加密货币交易所: %s", b.name)
 	b.isConnected = true
 	return nil
@@ -364,6 +717,9 @@ func (b *MockCryptoBroker) Connect() error {
 
 // Disconnect 断开连接
 func (b *MockCryptoBroker) Disconnect() error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
 	log.Printf("断开加密货币交易所连接: %s", b.name)
 	b.isConnected = false
 	return nil
@@ -371,59 +727,129 @@ func (b *MockCryptoBroker) Disconnect() error {
 
 // PlaceOrder 下单
 func (b *MockCryptoBroker) PlaceOrder(order Order) (*Order, error) {
+	quotes := b.quotes(map[string]bool{order.Symbol: order.Type != MarketOrder})
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
 	if !b.isConnected {
-		return nil, fmt.Errorf("交易所未连接")
+		return nil, fmt.Errorf("交易所未连接: %w", ErrBrokerUnavailable)
 	}
 
-	log.Printf("加密货币交易所 %s 收到订单: %s %s %.2f @ %.2f",
+	log.Printf("加密货币交易所 %s 收到订单: %s %s %s @ %s",
 		b.name, order.Side, order.Symbol, order.Quantity, order.Price)
 
+	// 同一客户端订单号只受理一次
+	if existing, exists := findClientOrder(b.orders, order.ClientOrderID); exists {
+		log.Printf("客户端订单号 %s 已存在，返回已有订单: ID=%s", order.ClientOrderID, existing.ID)
+		return existing, nil
+	}
+
 	// 模拟订单处理
 	order.ID = fmt.Sprintf("CRYPTO_%d", time.Now().UnixNano())
 	order.Status = Submitted
+	order.FilledQty = decimal.Zero
+	order.AvgPrice = decimal.Zero
+	order.Commission = decimal.Zero
 	order.CreateTime = time.Now()
 	order.UpdateTime = time.Now()
+	order.Quantity = b.precision.For(order.Symbol).RoundQuantity(order.Quantity)
 
-	// 模拟订单成交
-	if order.Type == MarketOrder {
-		// 市价单立即成交
-		order.Status = Filled
-		order.FilledQty = order.Quantity
-		order.AvgPrice = order.Price * 1.002 // 模拟更大的滑点
-		order.Commission = order.Quantity * order.AvgPrice * 0.001
-
-		// 更新持仓和余额
-		b.updatePosition(order)
-		b.updateBalance(order)
-
-		// 记录成交
-		trade := Trade{
-			ID:          fmt.Sprintf("TRADE_%d", time.Now().UnixNano()),
-			OrderID:     order.ID,
-			Symbol:      order.Symbol,
-			Side:        order.Side,
-			Quantity:    order.Quantity,
-			Price:       order.AvgPrice,
-			Commission:  order.Commission,
-			Timestamp:   time.Now(),
-			AccountName: order.AccountName,
+	// 模拟订单成交：市价单立即成交，限价单和止损单在实时报价触及时成交
+	price, marketable := b.marketPrice(order), order.Type == MarketOrder
+	if quote, quoted := quotes[order.Symbol]; quoted && !marketable {
+		price, marketable = triggered(order, quote)
+	}
+	if marketable {
+		if order.TimeInForce == FOK && b.fillRatio.LessThan(decimal.NewFromInt(1)) {
+			// 模拟流动性不足，无法一次全部成交的 FOK 订单整单撤销
+			order.Transition(Cancelled)
+			log.Printf("FOK 订单无法全部成交，已撤销: ID=%s", order.ID)
+		} else {
+			b.fill(&order, price)
+			if order.Status == PartiallyFilled && order.TimeInForce == IOC {
+				order.Transition(Cancelled)
+				log.Printf("IOC 订单部分成交，剩余 %s 已撤销: ID=%s", order.Remaining(), order.ID)
+			}
 		}
-		b.trades = append(b.trades, trade)
-
-		log.Printf("订单已成交: ID=%s, 成交价=%.2f", order.ID, order.AvgPrice)
+		b.orders[order.ID] = order
+	} else if order.TimeInForce.immediate() {
+		// 挂单未触及报价，IOC/FOK 订单直接撤销
+		order.Status = Cancelled
+		b.orders[order.ID] = order
+		log.Printf("%s 订单未能立即成交，已撤销: ID=%s", strings.ToUpper(string(order.TimeInForce)), order.ID)
 	} else {
-		// 限价单待成交
+		// 挂单在之后查询订单时按实时报价撮合
 		b.orders[order.ID] = order
-		log.Printf("限价单已提交: ID=%s", order.ID)
+		log.Printf("挂单已提交: ID=%s, 类型=%s", order.ID, order.Type)
 	}
 
 	return &order, nil
 }
 
+// marketPrice 市价单的模拟成交价：委托价格加滑点
+func (b *MockCryptoBroker) marketPrice(order Order) decimal.Decimal {
+	return b.precision.For(order.Symbol).RoundPrice(order.Price.Mul(cryptoSlippage)) // 模拟更大的滑点
+}
+
+// fill 按成交比例以 price 撮合一次订单的剩余数量，更新持仓、余额和成交记录
+func (b *MockCryptoBroker) fill(order *Order, price decimal.Decimal) {
+	precision := b.precision.For(order.Symbol)
+	trade, err := simulateFill(order, precision.RoundPrice(price), b.fillRatio, precision, b.commission, false, decimal.Zero)
+	if err != nil {
+		log.Printf("模拟成交失败: ID=%s, 错误=%v", order.ID, err)
+		return
+	}
+	b.updatePosition(trade)
+	b.updateBalance(trade)
+	b.trades = append(b.trades, trade)
+
+	if order.Status == Filled {
+		log.Printf("订单已成交: ID=%s, 成交价=%s", order.ID, order.AvgPrice)
+	} else {
+		log.Printf("订单部分成交: ID=%s, 本次 %s @ %s, 累计 %s/%s", order.ID, trade.Quantity, trade.Price, order.FilledQty, order.Quantity)
+	}
+}
+
+// matchOrders 继续撮合部分成交的市价单，并按实时报价撮合挂单，在查询订单前调用
+func (b *MockCryptoBroker) matchOrders(quotes map[string]decimal.Decimal) {
+	for id, order := range b.orders {
+		if !order.Status.IsOpen() {
+			continue
+		}
+		price, marketable := b.marketPrice(order), order.Type == MarketOrder
+		if quote, quoted := quotes[order.Symbol]; quoted && !marketable {
+			price, marketable = triggered(order, quote)
+		}
+		if marketable {
+			b.fill(&order, price)
+			b.orders[id] = order
+		}
+	}
+}
+
+// ExpireOrder 将未成交的订单标记为过期
+func (b *MockCryptoBroker) ExpireOrder(orderID string) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	order, exists := b.orders[orderID]
+	if !exists {
+		return fmt.Errorf("订单不存在: %s", orderID)
+	}
+	if err := order.Transition(Expired); err != nil {
+		return err
+	}
+	b.orders[orderID] = order
+	return nil
+}
+
 // CancelOrder 撤单
 func (b *MockCryptoBroker) CancelOrder(orderID string) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
 	if !b.isConnected {
-		return fmt.Errorf("交易所未连接")
+		return fmt.Errorf("交易所未连接: %w", ErrBrokerUnavailable)
 	}
 
 	order, exists := b.orders[orderID]
@@ -431,8 +857,9 @@ func (b *MockCryptoBroker) CancelOrder(orderID string) error {
 		return fmt.Errorf("订单不存在: %s", orderID)
 	}
 
-	order.Status = Cancelled
-	order.UpdateTime = time.Now()
+	if err := order.Transition(Cancelled); err != nil {
+		return err
+	}
 	b.orders[orderID] = order
 
 	log.Printf("订单已取消: ID=%s", orderID)
@@ -441,10 +868,15 @@ func (b *MockCryptoBroker) CancelOrder(orderID string) error {
 
 // GetOrder 查询订单
 func (b *MockCryptoBroker) GetOrder(orderID string) (*Order, error) {
+	quotes := b.pendingQuotes()
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
 	if !b.isConnected {
-		return nil, fmt.Errorf("交易所未连接")
+		return nil, fmt.Errorf("交易所未连接: %w", ErrBrokerUnavailable)
 	}
 
+	b.matchOrders(quotes)
 	order, exists := b.orders[orderID]
 	if !exists {
 		return nil, fmt.Errorf("订单不存在: %s", orderID)
@@ -453,12 +885,35 @@ func (b *MockCryptoBroker) GetOrder(orderID string) (*Order, error) {
 	return &order, nil
 }
 
+// GetOrderByClientID 按客户端订单号查询订单
+func (b *MockCryptoBroker) GetOrderByClientID(clientOrderID string) (*Order, error) {
+	quotes := b.pendingQuotes()
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if !b.isConnected {
+		return nil, fmt.Errorf("交易所未连接: %w", ErrBrokerUnavailable)
+	}
+
+	b.matchOrders(quotes)
+	order, exists := findClientOrder(b.orders, clientOrderID)
+	if !exists {
+		return nil, fmt.Errorf("客户端订单号 %s: %w", clientOrderID, ErrOrderNotFound)
+	}
+	return order, nil
+}
+
 // GetOrders 查询订单列表
 func (b *MockCryptoBroker) GetOrders(symbol string, status OrderStatus) ([]Order, error) {
+	quotes := b.pendingQuotes()
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
 	if !b.isConnected {
-		return nil, fmt.Errorf("交易所未连接")
+		return nil, fmt.Errorf("交易所未连接: %w", ErrBrokerUnavailable)
 	}
 
+	b.matchOrders(quotes)
 	var orders []Order
 	for _, order := range b.orders {
 		if symbol != "" && order.Symbol != symbol {
@@ -474,9 +929,12 @@ func (b *MockCryptoBroker) GetOrders(symbol string, status OrderStatus) ([]Order
 }
 
 // GetBalance 获取余额
-func (b *MockCryptoBroker) GetBalance() (float64, error) {
+func (b *MockCryptoBroker) GetBalance() (decimal.Decimal, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
 	if !b.isConnected {
-		return 0, fmt.Errorf("交易所未连接")
+		return decimal.Zero, fmt.Errorf("交易所未连接: %w", ErrBrokerUnavailable)
 	}
 
 	return b.balance, nil
@@ -484,8 +942,11 @@ func (b *MockCryptoBroker) GetBalance() (float64, error) {
 
 // GetPositions 获取持仓
 func (b *MockCryptoBroker) GetPositions() (map[string]Position, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
 	if !b.isConnected {
-		return nil, fmt.Errorf("交易所未连接")
+		return nil, fmt.Errorf("交易所未连接: %w", ErrBrokerUnavailable)
 	}
 
 	positions := make(map[string]Position)
@@ -498,8 +959,11 @@ func (b *MockCryptoBroker) GetPositions() (map[string]Position, error) {
 
 // GetTrades 获取成交记录
 func (b *MockCryptoBroker) GetTrades(symbol string, limit int) ([]Trade, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
 	if !b.isConnected {
-		return nil, fmt.Errorf("交易所未连接")
+		return nil, fmt.Errorf("交易所未连接: %w", ErrBrokerUnavailable)
 	}
 
 	var trades []Trade
@@ -515,48 +979,46 @@ func (b *MockCryptoBroker) GetTrades(symbol string, limit int) ([]Trade, error)
 	return trades, nil
 }
 
-// updatePosition 更新持仓
-func (b *MockCryptoBroker) updatePosition(order Order) {
-	position, exists := b.positions[order.Symbol]
+// updatePosition 按成交更新持仓
+func (b *MockCryptoBroker) updatePosition(trade Trade) {
+	position, exists := b.positions[trade.Symbol]
 
 	if !exists {
 		position = Position{
-			Symbol:      order.Symbol,
-			Quantity:    0,
-			AvgPrice:    0,
-			MarketValue: 0,
-			UpdateTime:  time.Now(),
+			Symbol:     trade.Symbol,
+			UpdateTime: time.Now(),
 		}
 	}
 
-	if order.Side == BuySide {
+	if trade.Side == BuySide {
 		// 买入
-		totalCost := position.Quantity*position.AvgPrice + order.Quantity*order.AvgPrice
-		position.Quantity += order.Quantity
-		if position.Quantity > 0 {
-			position.AvgPrice = totalCost / position.Quantity
+		totalCost := position.Quantity.Mul(position.AvgPrice).Add(trade.Quantity.Mul(trade.Price))
+		position.Quantity = position.Quantity.Add(trade.Quantity)
+		if position.Quantity.IsPositive() {
+			position.AvgPrice = b.precision.For(trade.Symbol).RoundPrice(totalCost.Div(position.Quantity))
 		}
 	} else {
 		// 卖出
-		position.Quantity -= order.Quantity
-		if position.Quantity <= 0 {
-			delete(b.positions, order.Symbol)
+		position.Quantity = position.Quantity.Sub(trade.Quantity)
+		if !position.Quantity.IsPositive() {
+			delete(b.positions, trade.Symbol)
 			return
 		}
 	}
 
-	position.MarketValue = position.Quantity * order.AvgPrice
+	position.MarketValue = b.precision.For(trade.Symbol).RoundAmount(position.Quantity.Mul(trade.Price))
 	position.UpdateTime = time.Now()
-	b.positions[order.Symbol] = position
+	b.positions[trade.Symbol] = position
 }
 
-// updateBalance 更新余额
-func (b *MockCryptoBroker) updateBalance(order Order) {
-	if order.Side == BuySide {
+// updateBalance 按成交更新余额
+func (b *MockCryptoBroker) updateBalance(trade Trade) {
+	amount := b.precision.For(trade.Symbol).RoundAmount(trade.Quantity.Mul(trade.Price))
+	if trade.Side == BuySide {
 		// 买入减少余额
-		b.balance -= order.Quantity*order.AvgPrice + order.Commission
+		b.balance = b.balance.Sub(amount).Sub(trade.Commission)
 	} else {
 		// 卖出增加余额
-		b.balance += order.Quantity*order.AvgPrice - order.Commission
+		b.balance = b.balance.Add(amount).Sub(trade.Commission)
 	}
 }
@@ -0,0 +1,947 @@
+package trading
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"agent-quant-system/internal/config"
+	"agent-quant-system/internal/money"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/shopspring/decimal"
+)
+
+// Bybit V5 API 的默认设置
+const (
+	defaultBybitURL          = "https://api.bybit.com"
+	defaultBybitRecvWindow   = 5 * time.Second
+	defaultBybitPollInterval = 2 * time.Second
+	defaultBybitTimeout      = 15 * time.Second
+	bybitFundingInterval     = time.Minute // 查询资金费结算记录的间隔
+	bybitPageSize            = 50
+	bybitLeverageUnchanged   = 110043 // 设置的杠杆与当前相同
+)
+
+// FundingPayment 永续合约的一次资金费结算
+type FundingPayment struct {
+	ID       string          `json:"id"`
+	Symbol   string          `json:"symbol"`
+	Amount   decimal.Decimal `json:"amount"`   // 正数为支出，负数为收入
+	Rate     decimal.Decimal `json:"rate"`     // 结算时的资金费率
+	Position decimal.Decimal `json:"position"` // 结算时的持仓数量，空头为负数
+	Time     time.Time       `json:"time"`
+}
+
+// FundingNotifier 推送永续合约资金费结算的经纪商（可选接口）
+type FundingNotifier interface {
+	OnFunding(callback func(FundingPayment))
+}
+
+// LeverageSetter 支持按标的设置杠杆倍数的合约经纪商（可选接口）
+type LeverageSetter interface {
+	SetLeverage(symbol string, leverage float64) error
+}
+
+// FundingRate 永续合约当前的资金费率
+type FundingRate struct {
+	Symbol          string          `json:"symbol"`
+	Rate            decimal.Decimal `json:"rate"` // 正数表示多头向空头支付
+	MarkPrice       decimal.Decimal `json:"mark_price"`
+	NextFundingTime time.Time       `json:"next_funding_time"`
+}
+
+// BybitBroker 通过 V5 API 交易 Bybit 永续合约的经纪商，单向持仓模式：多头数量为正，空头为负
+type BybitBroker struct {
+	name         string
+	category     string
+	settleCoin   string
+	accountType  string
+	config       config.BybitConfig
+	precision    *money.PrecisionTable
+	credentials  CredentialSource
+	httpClient   *resty.Client
+	recvWindow   string
+	pollInterval time.Duration
+
+	leverage         map[string]bool // 已设置过杠杆的标的
+	orders           map[string]Order
+	callbacks        []func(Order)
+	fundingCallbacks []func(FundingPayment)
+	fundingSince     time.Time // 已处理的资金费结算时间
+	fundingSeen      map[string]bool
+	isConnected      bool
+	stopChan         chan struct{}
+	wg               sync.WaitGroup
+	mutex            sync.Mutex
+}
+
+// NewBybitBroker 创建 Bybit 永续合约经纪商
+func NewBybitBroker(name string, cfg config.BybitConfig, precision *money.PrecisionTable, credentials CredentialSource) *BybitBroker {
+	baseURL := strings.TrimRight(cfg.BaseURL, "/")
+	if baseURL == "" {
+		baseURL = defaultBybitURL
+	}
+	category := cfg.Category
+	if category == "" {
+		category = "linear"
+	}
+	settleCoin := strings.ToUpper(cfg.SettleCoin)
+	if settleCoin == "" {
+		settleCoin = "USDT"
+	}
+	accountType := strings.ToUpper(cfg.AccountType)
+	if accountType == "" {
+		accountType = "UNIFIED"
+	}
+	recvWindow := cfg.RecvWindow
+	if recvWindow <= 0 {
+		recvWindow = defaultBybitRecvWindow
+	}
+	pollInterval := cfg.PollInterval
+	if pollInterval <= 0 {
+		pollInterval = defaultBybitPollInterval
+	}
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = defaultBybitTimeout
+	}
+
+	client := resty.New()
+	client.SetBaseURL(baseURL)
+	client.SetTimeout(timeout)
+	client.SetHeader("Content-Type", "application/json")
+	client.SetHeader("Accept", "application/json")
+
+	return &BybitBroker{
+		name:         name,
+		category:     category,
+		settleCoin:   settleCoin,
+		accountType:  accountType,
+		config:       cfg,
+		precision:    precision,
+		credentials:  credentials,
+		httpClient:   client,
+		recvWindow:   strconv.FormatInt(recvWindow.Milliseconds(), 10),
+		pollInterval: pollInterval,
+		leverage:     make(map[string]bool),
+		orders:       make(map[string]Order),
+		fundingSeen:  make(map[string]bool),
+	}
+}
+
+// bybitResponse V5 接口的统一应答
+type bybitResponse struct {
+	RetCode int             `json:"retCode"`
+	RetMsg  string          `json:"retMsg"`
+	Result  json.RawMessage `json:"result"`
+}
+
+// bybitOrder 订单
+type bybitOrder struct {
+	OrderID      string `json:"orderId"`
+	OrderLinkID  string `json:"orderLinkId"`
+	Symbol       string `json:"symbol"`
+	Side         string `json:"side"`
+	OrderType    string `json:"orderType"`
+	Price        string `json:"price"`
+	Qty          string `json:"qty"`
+	TriggerPrice string `json:"triggerPrice"`
+	TimeInForce  string `json:"timeInForce"`
+	OrderStatus  string `json:"orderStatus"`
+	CumExecQty   string `json:"cumExecQty"`
+	AvgPrice     string `json:"avgPrice"`
+	CumExecFee   string `json:"cumExecFee"`
+	ReduceOnly   bool   `json:"reduceOnly"`
+	CreatedTime  string `json:"createdTime"`
+	UpdatedTime  string `json:"updatedTime"`
+}
+
+// bybitPosition 持仓
+type bybitPosition struct {
+	Symbol         string `json:"symbol"`
+	Side           string `json:"side"` // Buy / Sell，无持仓时为空
+	Size           string `json:"size"`
+	AvgPrice       string `json:"avgPrice"`
+	PositionValue  string `json:"positionValue"`
+	MarkPrice      string `json:"markPrice"`
+	Leverage       string `json:"leverage"`
+	UnrealisedPnl  string `json:"unrealisedPnl"`
+	CumRealisedPnl string `json:"cumRealisedPnl"`
+	LiqPrice       string `json:"liqPrice"`
+}
+
+// bybitExecution 成交记录
+type bybitExecution struct {
+	ExecID    string `json:"execId"`
+	OrderID   string `json:"orderId"`
+	Symbol    string `json:"symbol"`
+	Side      string `json:"side"`
+	ExecQty   string `json:"execQty"`
+	ExecPrice string `json:"execPrice"`
+	ExecFee   string `json:"execFee"`
+	ExecType  string `json:"execType"`
+	ExecTime  string `json:"execTime"`
+}
+
+// bybitTransaction 资金流水中的一条记录
+type bybitTransaction struct {
+	ID              string `json:"id"`
+	Symbol          string `json:"symbol"`
+	Side            string `json:"side"`
+	Type            string `json:"type"`
+	Size            string `json:"size"`
+	Change          string `json:"change"`
+	FeeRate         string `json:"feeRate"`
+	TransactionTime string `json:"transactionTime"`
+}
+
+// bybitMillis 解析毫秒时间戳字符串，无效时为零值
+func bybitMillis(value string) time.Time {
+	millis, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || millis <= 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(millis)
+}
+
+// mapBybitOrderStatus 将 Bybit 订单状态映射为系统订单状态
+func mapBybitOrderStatus(status string) OrderStatus {
+	switch status {
+	case "Filled":
+		return Filled
+	case "PartiallyFilled":
+		return PartiallyFilled
+	case "Cancelled", "PartiallyFilledCanceled", "Deactivated":
+		return Cancelled
+	case "Rejected":
+		return Rejected
+	case "New", "Untriggered", "Triggered":
+		return Submitted
+	default:
+		return Pending
+	}
+}
+
+// mapBybitSide 将 Bybit 的买卖方向映射为系统订单方向
+func mapBybitSide(side string) OrderSide {
+	if strings.EqualFold(side, "Sell") {
+		return SellSide
+	}
+	return BuySide
+}
+
+// bybitTIF 转换订单有效期：加密货币全天交易，DAY 按 GTC 处理
+func bybitTIF(tif TimeInForce) string {
+	switch tif {
+	case IOC:
+		return "IOC"
+	case FOK:
+		return "FOK"
+	default:
+		return "GTC"
+	}
+}
+
+// sign 生成 V5 接口的认证头：HMAC-SHA256(时间戳 + API key + recv_window + 查询串或请求体)
+func (b *BybitBroker) sign(payload string) (map[string]string, error) {
+	if b.credentials == nil {
+		return nil, fmt.Errorf("未配置 Bybit API 密钥")
+	}
+	apiKey, apiSecret, err := b.credentials()
+	if err != nil {
+		return nil, fmt.Errorf("获取 Bybit API 密钥失败: %w", err)
+	}
+	if apiKey == "" || apiSecret == "" {
+		return nil, fmt.Errorf("Bybit API 密钥不完整")
+	}
+
+	timestamp := strconv.FormatInt(time.Now().UnixMilli(), 10)
+	mac := hmac.New(sha256.New, []byte(apiSecret))
+	mac.Write([]byte(timestamp + apiKey + b.recvWindow + payload))
+	return map[string]string{
+		"X-BAPI-API-KEY":     apiKey,
+		"X-BAPI-SIGN":        hex.EncodeToString(mac.Sum(nil)),
+		"X-BAPI-SIGN-TYPE":   "2",
+		"X-BAPI-TIMESTAMP":   timestamp,
+		"X-BAPI-RECV-WINDOW": b.recvWindow,
+	}, nil
+}
+
+// do 签名并发送请求，解析 result 字段；连接失败、认证失败和限频视为经纪商不可用，
+// 返回非0的 retCode 作为错误（同时返回 retCode 供调用方识别）
+func (b *BybitBroker) do(method, path string, query url.Values, body, result interface{}) (int, error) {
+	payload := ""
+	request := b.httpClient.R()
+	if method == "GET" {
+		payload = query.Encode()
+		if payload != "" {
+			path += "?" + payload
+		}
+	} else if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return 0, fmt.Errorf("编码请求失败: %w", err)
+		}
+		payload = string(encoded)
+		request.SetBody(encoded)
+	}
+	headers, err := b.sign(payload)
+	if err != nil {
+		return 0, err
+	}
+
+	var response bybitResponse
+	resp, err := request.SetHeaders(headers).SetResult(&response).ForceContentType("application/json").Execute(method, path)
+	if err != nil {
+		return 0, fmt.Errorf("请求 Bybit 失败: %v: %w", err, ErrBrokerUnavailable)
+	}
+	if resp.StatusCode() == 401 || resp.StatusCode() == 403 || resp.StatusCode() == 429 || resp.StatusCode() >= 500 {
+		return 0, fmt.Errorf("Bybit 暂时不可用: %d %s: %w", resp.StatusCode(), strings.TrimSpace(resp.String()), ErrBrokerUnavailable)
+	}
+	if resp.IsError() {
+		return 0, fmt.Errorf("Bybit 返回错误: %d %s", resp.StatusCode(), strings.TrimSpace(resp.String()))
+	}
+	switch response.RetCode {
+	case 0:
+	case 10003, 10004, 10005, 10006, 10018:
+		// 密钥无效、签名错误、权限不足、限频
+		return response.RetCode, fmt.Errorf("Bybit 拒绝请求: %d %s: %w", response.RetCode, response.RetMsg, ErrBrokerUnavailable)
+	default:
+		return response.RetCode, fmt.Errorf("Bybit 返回错误: %d %s", response.RetCode, response.RetMsg)
+	}
+	if result != nil && len(response.Result) > 0 {
+		if err := json.Unmarshal(response.Result, result); err != nil {
+			return 0, fmt.Errorf("解析 Bybit 应答失败: %w", err)
+		}
+	}
+	return 0, nil
+}
+
+// checkConnected 检查是否已连接
+func (b *BybitBroker) checkConnected() error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if !b.isConnected {
+		return fmt.Errorf("经纪商未连接: %w", ErrBrokerUnavailable)
+	}
+	return nil
+}
+
+// Connect 验证API密钥，设置已配置标的的杠杆并启动订单和资金费轮询
+func (b *BybitBroker) Connect() error {
+	log.Printf("连接到 Bybit: %s, 合约类型=%s, 结算币种=%s", b.name, b.category, b.settleCoin)
+
+	if _, err := b.walletBalance(); err != nil {
+		return fmt.Errorf("验证 Bybit API 密钥失败: %w", err)
+	}
+	for symbol, leverage := range b.config.SymbolLeverage {
+		if err := b.SetLeverage(symbol, leverage); err != nil {
+			log.Printf("设置 %s 杠杆失败: %v", symbol, err)
+		}
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.isConnected {
+		return nil
+	}
+	b.isConnected = true
+	if b.fundingSince.IsZero() {
+		b.fundingSince = time.Now()
+	}
+	b.stopChan = make(chan struct{})
+	b.wg.Add(1)
+	go b.poll()
+	return nil
+}
+
+// Disconnect 停止轮询
+func (b *BybitBroker) Disconnect() error {
+	b.mutex.Lock()
+	if !b.isConnected {
+		b.mutex.Unlock()
+		return nil
+	}
+	b.isConnected = false
+	close(b.stopChan)
+	b.mutex.Unlock()
+
+	b.wg.Wait()
+	log.Printf("断开 Bybit 连接: %s", b.name)
+	return nil
+}
+
+// Ping 健康检查：确认接口可达且API密钥仍然有效
+func (b *BybitBroker) Ping() error {
+	if err := b.checkConnected(); err != nil {
+		return err
+	}
+	_, err := b.walletBalance()
+	return err
+}
+
+// OnOrderUpdate 注册订单状态变化回调
+func (b *BybitBroker) OnOrderUpdate(callback func(Order)) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.callbacks = append(b.callbacks, callback)
+}
+
+// OnFunding 注册资金费结算回调，只推送连接之后结算的资金费
+func (b *BybitBroker) OnFunding(callback func(FundingPayment)) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.fundingCallbacks = append(b.fundingCallbacks, callback)
+}
+
+// SetLeverage 设置标的的杠杆倍数（多空相同），与当前设置相同时视为成功
+func (b *BybitBroker) SetLeverage(symbol string, leverage float64) error {
+	if leverage <= 0 {
+		return fmt.Errorf("杠杆倍数必须大于0: %v", leverage)
+	}
+	symbol = strings.ToUpper(symbol)
+	value := strconv.FormatFloat(leverage, 'f', -1, 64)
+	request := map[string]string{
+		"category":     b.category,
+		"symbol":       symbol,
+		"buyLeverage":  value,
+		"sellLeverage": value,
+	}
+	code, err := b.do("POST", "/v5/position/set-leverage", nil, request, nil)
+	if err != nil && code != bybitLeverageUnchanged {
+		return fmt.Errorf("设置杠杆失败: %w", err)
+	}
+
+	b.mutex.Lock()
+	b.leverage[symbol] = true
+	b.mutex.Unlock()
+	log.Printf("Bybit %s 杠杆设置为 %sx", symbol, value)
+	return nil
+}
+
+// ensureLeverage 首次交易标的时按配置设置杠杆
+func (b *BybitBroker) ensureLeverage(symbol string) error {
+	leverage := b.config.LeverageFor(symbol)
+	if leverage <= 0 {
+		return nil
+	}
+	b.mutex.Lock()
+	done := b.leverage[symbol]
+	b.mutex.Unlock()
+	if done {
+		return nil
+	}
+	return b.SetLeverage(symbol, leverage)
+}
+
+// PlaceOrder 下单，ReduceOnly 的订单只减仓；止损单以条件市价单提交
+func (b *BybitBroker) PlaceOrder(order Order) (*Order, error) {
+	if err := b.checkConnected(); err != nil {
+		return nil, err
+	}
+	symbol := strings.ToUpper(order.Symbol)
+	if err := b.ensureLeverage(symbol); err != nil {
+		return nil, err
+	}
+
+	precision := b.precision.For(order.Symbol)
+	if order.ClientOrderID == "" {
+		order.ClientOrderID = newClientOrderID()
+	}
+	request := map[string]interface{}{
+		"category":    b.category,
+		"symbol":      symbol,
+		"side":        "Buy",
+		"orderType":   "Market",
+		"qty":         precision.RoundQuantity(order.Quantity).String(),
+		"orderLinkId": order.ClientOrderID,
+		"reduceOnly":  order.ReduceOnly,
+	}
+	if order.Side == SellSide {
+		request["side"] = "Sell"
+	}
+	switch order.Type {
+	case LimitOrder:
+		request["orderType"] = "Limit"
+		request["price"] = precision.RoundPrice(order.Price).String()
+		request["timeInForce"] = bybitTIF(order.TimeInForce)
+	case StopOrder:
+		if !order.StopPrice.IsPositive() {
+			return nil, fmt.Errorf("止损单必须设置止损价")
+		}
+		// 买入止损在价格上涨到止损价时触发，卖出止损在下跌时触发
+		request["triggerPrice"] = precision.RoundPrice(order.StopPrice).String()
+		request["triggerDirection"] = 2
+		if order.Side == BuySide {
+			request["triggerDirection"] = 1
+		}
+	}
+
+	var result struct {
+		OrderID     string `json:"orderId"`
+		OrderLinkID string `json:"orderLinkId"`
+	}
+	if _, err := b.do("POST", "/v5/order/create", nil, request, &result); err != nil {
+		return nil, fmt.Errorf("提交订单失败: %w", err)
+	}
+
+	order.ID = result.OrderID
+	order.Symbol = symbol
+	order.Status = Submitted
+	order.AccountName = b.name
+	order.UpdateTime = time.Now()
+	if order.CreateTime.IsZero() {
+		order.CreateTime = order.UpdateTime
+	}
+	b.mutex.Lock()
+	b.orders[order.ID] = order
+	b.mutex.Unlock()
+
+	log.Printf("Bybit 订单已提交: 订单ID=%s, 标的=%s, 方向=%s, 数量=%s, 只减仓=%v",
+		order.ID, symbol, order.Side, order.Quantity, order.ReduceOnly)
+	return &order, nil
+}
+
+// CancelOrder 撤单，Bybit 撤单需要标的，只能撤销本地已知的订单
+func (b *BybitBroker) CancelOrder(orderID string) error {
+	if err := b.checkConnected(); err != nil {
+		return err
+	}
+
+	b.mutex.Lock()
+	order, exists := b.orders[orderID]
+	b.mutex.Unlock()
+	if !exists {
+		return fmt.Errorf("订单 %s: %w", orderID, ErrOrderNotFound)
+	}
+
+	request := map[string]string{"category": b.category, "symbol": order.Symbol, "orderId": orderID}
+	if _, err := b.do("POST", "/v5/order/cancel", nil, request, nil); err != nil {
+		return fmt.Errorf("撤单失败: %w", err)
+	}
+	return nil
+}
+
+// toOrder 把接口返回的订单与本地记录合并
+func (b *BybitBroker) toOrder(remote bybitOrder) Order {
+	b.mutex.Lock()
+	order, exists := b.orders[remote.OrderID]
+	b.mutex.Unlock()
+	if !exists {
+		order = Order{
+			ID:            remote.OrderID,
+			Symbol:        remote.Symbol,
+			Side:          mapBybitSide(remote.Side),
+			Type:          MarketOrder,
+			Quantity:      decimalOrZero(remote.Qty),
+			Price:         decimalOrZero(remote.Price),
+			StopPrice:     decimalOrZero(remote.TriggerPrice),
+			ReduceOnly:    remote.ReduceOnly,
+			AccountName:   b.name,
+			ClientOrderID: remote.OrderLinkID,
+			CreateTime:    bybitMillis(remote.CreatedTime),
+		}
+		switch {
+		case order.StopPrice.IsPositive():
+			order.Type = StopOrder
+		case remote.OrderType == "Limit":
+			order.Type = LimitOrder
+		}
+	}
+
+	order.Status = mapBybitOrderStatus(remote.OrderStatus)
+	order.FilledQty = decimalOrZero(remote.CumExecQty)
+	order.AvgPrice = decimalOrZero(remote.AvgPrice)
+	order.Commission = decimalOrZero(remote.CumExecFee)
+	order.UpdateTime = time.Now()
+	if updated := bybitMillis(remote.UpdatedTime); !updated.IsZero() {
+		order.UpdateTime = updated
+	}
+	return order
+}
+
+// queryOrders 查询订单：先查实时订单（未成交和最近完成的），查不到时查历史订单
+func (b *BybitBroker) queryOrders(query url.Values) ([]bybitOrder, error) {
+	query.Set("category", b.category)
+	var result struct {
+		List []bybitOrder `json:"list"`
+	}
+	if _, err := b.do("GET", "/v5/order/realtime", query, nil, &result); err != nil {
+		return nil, fmt.Errorf("获取订单失败: %w", err)
+	}
+	if len(result.List) > 0 || query.Get("orderId") == "" {
+		return result.List, nil
+	}
+	if _, err := b.do("GET", "/v5/order/history", query, nil, &result); err != nil {
+		return nil, fmt.Errorf("获取历史订单失败: %w", err)
+	}
+	return result.List, nil
+}
+
+// GetOrder 查询订单
+func (b *BybitBroker) GetOrder(orderID string) (*Order, error) {
+	if err := b.checkConnected(); err != nil {
+		return nil, err
+	}
+	orders, err := b.queryOrders(url.Values{"orderId": {orderID}})
+	if err != nil {
+		return nil, err
+	}
+	if len(orders) == 0 {
+		return nil, fmt.Errorf("订单 %s: %w", orderID, ErrOrderNotFound)
+	}
+	order := b.toOrder(orders[0])
+	return &order, nil
+}
+
+// GetOrderByClientID 按客户端订单号（orderLinkId）查询订单
+func (b *BybitBroker) GetOrderByClientID(clientOrderID string) (*Order, error) {
+	if err := b.checkConnected(); err != nil {
+		return nil, err
+	}
+	orders, err := b.queryOrders(url.Values{"orderLinkId": {clientOrderID}})
+	if err != nil {
+		return nil, err
+	}
+	if len(orders) == 0 {
+		return nil, fmt.Errorf("客户端订单号 %s: %w", clientOrderID, ErrOrderNotFound)
+	}
+	order := b.toOrder(orders[0])
+	return &order, nil
+}
+
+// GetOrders 查询订单列表：未指定状态或为未完成状态时查询实时订单，其他状态查询历史订单
+func (b *BybitBroker) GetOrders(symbol string, status OrderStatus) ([]Order, error) {
+	if err := b.checkConnected(); err != nil {
+		return nil, err
+	}
+
+	query := url.Values{"category": {b.category}, "limit": {strconv.Itoa(bybitPageSize)}}
+	if symbol != "" {
+		query.Set("symbol", strings.ToUpper(symbol))
+	} else {
+		query.Set("settleCoin", b.settleCoin)
+	}
+	path := "/v5/order/realtime"
+	if status != "" && !status.IsOpen() {
+		path = "/v5/order/history"
+	}
+	var result struct {
+		List []bybitOrder `json:"list"`
+	}
+	if _, err := b.do("GET", path, query, nil, &result); err != nil {
+		return nil, fmt.Errorf("获取订单失败: %w", err)
+	}
+
+	var orders []Order
+	for _, remote := range result.List {
+		order := b.toOrder(remote)
+		if status != "" && order.Status != status {
+			continue
+		}
+		orders = append(orders, order)
+	}
+	return orders, nil
+}
+
+// walletBalance 结算币种的钱包余额（不含未实现盈亏）
+func (b *BybitBroker) walletBalance() (decimal.Decimal, error) {
+	query := url.Values{"accountType": {b.accountType}, "coin": {b.settleCoin}}
+	var result struct {
+		List []struct {
+			Coin []struct {
+				Coin          string `json:"coin"`
+				WalletBalance string `json:"walletBalance"`
+			} `json:"coin"`
+		} `json:"list"`
+	}
+	if _, err := b.do("GET", "/v5/account/wallet-balance", query, nil, &result); err != nil {
+		return decimal.Zero, fmt.Errorf("获取钱包余额失败: %w", err)
+	}
+	for _, account := range result.List {
+		for _, coin := range account.Coin {
+			if strings.EqualFold(coin.Coin, b.settleCoin) {
+				return decimalOrZero(coin.WalletBalance), nil
+			}
+		}
+	}
+	return decimal.Zero, nil
+}
+
+// GetBalance 获取结算币种的钱包余额
+func (b *BybitBroker) GetBalance() (decimal.Decimal, error) {
+	if err := b.checkConnected(); err != nil {
+		return decimal.Zero, err
+	}
+	balance, err := b.walletBalance()
+	if err != nil {
+		return decimal.Zero, err
+	}
+	return b.precision.Defaults().RoundAmount(balance), nil
+}
+
+// GetPositions 获取持仓，多头数量为正、空头为负，市值按标记价格计算
+func (b *BybitBroker) GetPositions() (map[string]Position, error) {
+	if err := b.checkConnected(); err != nil {
+		return nil, err
+	}
+
+	positions := make(map[string]Position)
+	query := url.Values{"category": {b.category}, "settleCoin": {b.settleCoin}, "limit": {"200"}}
+	for {
+		var result struct {
+			List           []bybitPosition `json:"list"`
+			NextPageCursor string          `json:"nextPageCursor"`
+		}
+		if _, err := b.do("GET", "/v5/position/list", query, nil, &result); err != nil {
+			return nil, fmt.Errorf("获取持仓失败: %w", err)
+		}
+
+		for _, item := range result.List {
+			quantity := decimalOrZero(item.Size)
+			if quantity.IsZero() || item.Side == "" {
+				continue
+			}
+			marketValue := decimalOrZero(item.PositionValue)
+			if item.Side == "Sell" {
+				quantity = quantity.Neg()
+				marketValue = marketValue.Neg()
+			}
+			if mark := decimalOrZero(item.MarkPrice); mark.IsPositive() {
+				marketValue = mark.Mul(quantity)
+			}
+			positions[item.Symbol] = Position{
+				Symbol:       item.Symbol,
+				Quantity:     quantity,
+				AvgPrice:     decimalOrZero(item.AvgPrice),
+				MarketValue:  b.precision.For(item.Symbol).RoundAmount(marketValue),
+				UnrealizedPL: decimalOrZero(item.UnrealisedPnl),
+				RealizedPL:   decimalOrZero(item.CumRealisedPnl),
+				UpdateTime:   time.Now(),
+			}
+		}
+
+		if result.NextPageCursor == "" || len(result.List) == 0 {
+			return positions, nil
+		}
+		query.Set("cursor", result.NextPageCursor)
+	}
+}
+
+// GetTrades 获取近期成交记录（不含资金费等非成交执行），手续费为交易所回报的实际手续费
+func (b *BybitBroker) GetTrades(symbol string, limit int) ([]Trade, error) {
+	if err := b.checkConnected(); err != nil {
+		return nil, err
+	}
+
+	if limit <= 0 || limit > 100 {
+		limit = 100
+	}
+	query := url.Values{"category": {b.category}, "execType": {"Trade"}, "limit": {strconv.Itoa(limit)}}
+	if symbol != "" {
+		query.Set("symbol", strings.ToUpper(symbol))
+	}
+	var result struct {
+		List []bybitExecution `json:"list"`
+	}
+	if _, err := b.do("GET", "/v5/execution/list", query, nil, &result); err != nil {
+		return nil, fmt.Errorf("获取成交记录失败: %w", err)
+	}
+
+	// 接口按时间倒序返回，与其他经纪商一致按时间升序返回
+	trades := make([]Trade, 0, len(result.List))
+	for i := len(result.List) - 1; i >= 0; i-- {
+		item := result.List[i]
+		trades = append(trades, Trade{
+			ID:          item.ExecID,
+			OrderID:     item.OrderID,
+			Symbol:      item.Symbol,
+			Side:        mapBybitSide(item.Side),
+			Quantity:    decimalOrZero(item.ExecQty),
+			Price:       decimalOrZero(item.ExecPrice),
+			Commission:  decimalOrZero(item.ExecFee),
+			Timestamp:   bybitMillis(item.ExecTime),
+			AccountName: b.name,
+		})
+	}
+	return trades, nil
+}
+
+// GetFundingRate 查询永续合约当前的资金费率、标记价格和下次结算时间
+func (b *BybitBroker) GetFundingRate(symbol string) (*FundingRate, error) {
+	symbol = strings.ToUpper(symbol)
+	var result struct {
+		List []struct {
+			Symbol          string `json:"symbol"`
+			FundingRate     string `json:"fundingRate"`
+			MarkPrice       string `json:"markPrice"`
+			NextFundingTime string `json:"nextFundingTime"`
+		} `json:"list"`
+	}
+	query := url.Values{"category": {b.category}, "symbol": {symbol}}
+	if _, err := b.do("GET", "/v5/market/tickers", query, nil, &result); err != nil {
+		return nil, fmt.Errorf("获取资金费率失败: %w", err)
+	}
+	if len(result.List) == 0 {
+		return nil, fmt.Errorf("没有 %s 的行情", symbol)
+	}
+	ticker := result.List[0]
+	return &FundingRate{
+		Symbol:          ticker.Symbol,
+		Rate:            decimalOrZero(ticker.FundingRate),
+		MarkPrice:       decimalOrZero(ticker.MarkPrice),
+		NextFundingTime: bybitMillis(ticker.NextFundingTime),
+	}, nil
+}
+
+// fetchFunding 查询 since 之后的资金费结算记录（资金流水中 type=SETTLEMENT），按结算时间升序返回
+func (b *BybitBroker) fetchFunding(since time.Time) ([]FundingPayment, error) {
+	query := url.Values{
+		"accountType": {b.accountType},
+		"category":    {b.category},
+		"currency":    {b.settleCoin},
+		"type":        {"SETTLEMENT"},
+		"startTime":   {strconv.FormatInt(since.UnixMilli(), 10)},
+		"limit":       {strconv.Itoa(bybitPageSize)},
+	}
+	var result struct {
+		List []bybitTransaction `json:"list"`
+	}
+	if _, err := b.do("GET", "/v5/account/transaction-log", query, nil, &result); err != nil {
+		return nil, fmt.Errorf("获取资金费结算记录失败: %w", err)
+	}
+
+	payments := make([]FundingPayment, 0, len(result.List))
+	for i := len(result.List) - 1; i >= 0; i-- {
+		item := result.List[i]
+		position := decimalOrZero(item.Size)
+		if item.Side == "Sell" {
+			position = position.Neg()
+		}
+		// change 为钱包余额的变化，支付资金费时为负数
+		payments = append(payments, FundingPayment{
+			ID:       item.ID,
+			Symbol:   item.Symbol,
+			Amount:   decimalOrZero(item.Change).Neg(),
+			Rate:     decimalOrZero(item.FeeRate),
+			Position: position,
+			Time:     bybitMillis(item.TransactionTime),
+		})
+	}
+	return payments, nil
+}
+
+// poll 定期查询本地未完成的订单，状态、成交数量或手续费变化时触发回调；按 bybitFundingInterval 查询资金费结算
+func (b *BybitBroker) poll() {
+	defer b.wg.Done()
+
+	ticker := time.NewTicker(b.pollInterval)
+	defer ticker.Stop()
+	lastFunding := time.Now()
+
+	for {
+		select {
+		case <-b.stopChan:
+			return
+		case <-ticker.C:
+			b.pollOrders()
+			if time.Since(lastFunding) >= bybitFundingInterval {
+				lastFunding = time.Now()
+				b.pollFunding()
+			}
+		}
+	}
+}
+
+// pollOrders 查询本地未完成订单的最新状态
+func (b *BybitBroker) pollOrders() {
+	b.mutex.Lock()
+	var pending []string
+	for id, order := range b.orders {
+		if !order.Status.IsTerminal() {
+			pending = append(pending, id)
+		}
+	}
+	b.mutex.Unlock()
+
+	var changed []Order
+	for _, id := range pending {
+		orders, err := b.queryOrders(url.Values{"orderId": {id}})
+		if err != nil {
+			log.Printf("Bybit 订单轮询失败: %v", err)
+			continue
+		}
+		if len(orders) == 0 {
+			continue
+		}
+		order := b.toOrder(orders[0])
+		b.mutex.Lock()
+		previous := b.orders[id]
+		if previous.Status != order.Status || !previous.FilledQty.Equal(order.FilledQty) ||
+			!previous.Commission.Equal(order.Commission) {
+			b.orders[id] = order
+			changed = append(changed, order)
+		}
+		b.mutex.Unlock()
+	}
+
+	b.mutex.Lock()
+	callbacks := append([]func(Order){}, b.callbacks...)
+	b.mutex.Unlock()
+	for _, order := range changed {
+		log.Printf("Bybit 订单状态更新: 订单ID=%s, 状态=%s, 已成交=%s, 手续费=%s",
+			order.ID, order.Status, order.FilledQty, order.Commission)
+		for _, callback := range callbacks {
+			callback(order)
+		}
+	}
+}
+
+// pollFunding 推送新的资金费结算记录，同一记录只推送一次
+func (b *BybitBroker) pollFunding() {
+	b.mutex.Lock()
+	since := b.fundingSince
+	callbacks := append([]func(FundingPayment){}, b.fundingCallbacks...)
+	b.mutex.Unlock()
+	if len(callbacks) == 0 {
+		return
+	}
+
+	payments, err := b.fetchFunding(since)
+	if err != nil {
+		log.Printf("Bybit 资金费轮询失败: %v", err)
+		return
+	}
+
+	var fresh []FundingPayment
+	b.mutex.Lock()
+	for _, payment := range payments {
+		if b.fundingSeen[payment.ID] {
+			continue
+		}
+		b.fundingSeen[payment.ID] = true
+		if payment.Time.After(b.fundingSince) {
+			b.fundingSince = payment.Time
+		}
+		fresh = append(fresh, payment)
+	}
+	b.mutex.Unlock()
+
+	for _, payment := range fresh {
+		log.Printf("Bybit 资金费结算: 标的=%s, 持仓=%s, 费率=%s, 金额=%s",
+			payment.Symbol, payment.Position, payment.Rate, payment.Amount)
+		for _, callback := range callbacks {
+			callback(payment)
+		}
+	}
+}
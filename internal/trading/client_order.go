@@ -0,0 +1,197 @@
+package trading
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// clientOrderRetention 客户端订单号的保留时间，超过后同一订单号视为新订单
+const clientOrderRetention = 24 * time.Hour
+
+// ErrOrderNotFound 经纪商确认没有该订单
+var ErrOrderNotFound = errors.New("订单不存在")
+
+// ClientOrderLookup 支持按客户端订单号查询订单的经纪商（可选接口）；
+// 确认没有该订单时返回 ErrOrderNotFound，其他错误表示无法确认
+type ClientOrderLookup interface {
+	GetOrderByClientID(clientOrderID string) (*Order, error)
+}
+
+// OrderSubmitError 下单结果不确定（超时、断线等）时返回的错误，重新提交时应沿用 ClientOrderID，
+// 交易引擎会先按该订单号向经纪商确认，避免首次请求实际已成功时重复下单
+type OrderSubmitError struct {
+	ClientOrderID string
+	Err           error
+}
+
+// Error 错误信息
+func (e *OrderSubmitError) Error() string {
+	return fmt.Sprintf("%v（客户端订单号 %s）", e.Err, e.ClientOrderID)
+}
+
+// Unwrap 返回原始错误
+func (e *OrderSubmitError) Unwrap() error {
+	return e.Err
+}
+
+// ClientOrderIDOf 从下单错误中取出客户端订单号，不是结果不确定的下单错误时返回空字符串
+func ClientOrderIDOf(err error) string {
+	var submitErr *OrderSubmitError
+	if errors.As(err, &submitErr) {
+		return submitErr.ClientOrderID
+	}
+	return ""
+}
+
+// clientOrderSeq 同一纳秒内生成多个订单号时区分先后
+var clientOrderSeq uint64
+
+// newClientOrderID 生成客户端订单号
+func newClientOrderID() string {
+	return fmt.Sprintf("AQS_%d_%d", time.Now().UnixNano(), atomic.AddUint64(&clientOrderSeq, 1))
+}
+
+// clientOrderEntry 客户端订单号的提交结果
+type clientOrderEntry struct {
+	order     *Order // 结果不确定时为 nil
+	updatedAt time.Time
+}
+
+// ClientOrderBook 记录客户端订单号的提交结果，用于识别重复提交
+type ClientOrderBook struct {
+	entries map[string]*clientOrderEntry
+	mutex   sync.Mutex
+}
+
+// NewClientOrderBook 创建客户端订单号记录
+func NewClientOrderBook() *ClientOrderBook {
+	return &ClientOrderBook{entries: make(map[string]*clientOrderEntry)}
+}
+
+// get 查询订单号的提交结果，uncertain 表示之前的提交结果不确定
+func (b *ClientOrderBook) get(clientOrderID string) (order *Order, uncertain, exists bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	entry, exists := b.entries[clientOrderID]
+	if !exists {
+		return nil, false, false
+	}
+	if entry.order == nil {
+		return nil, true, true
+	}
+	copied := *entry.order
+	return &copied, false, true
+}
+
+// record 记录已确认的订单
+func (b *ClientOrderBook) record(clientOrderID string, order *Order) {
+	b.set(clientOrderID, order)
+}
+
+// markUncertain 标记订单号的提交结果不确定
+func (b *ClientOrderBook) markUncertain(clientOrderID string) {
+	b.set(clientOrderID, nil)
+}
+
+// set 更新订单号的记录并清理过期的记录
+func (b *ClientOrderBook) set(clientOrderID string, order *Order) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	now := time.Now()
+	for id, entry := range b.entries {
+		if now.Sub(entry.updatedAt) > clientOrderRetention {
+			delete(b.entries, id)
+		}
+	}
+	entry := &clientOrderEntry{updatedAt: now}
+	if order != nil {
+		copied := *order
+		entry.order = &copied
+	}
+	b.entries[clientOrderID] = entry
+}
+
+// placeOrder 按客户端订单号幂等下单：已确认的订单号直接返回之前的订单；
+// 经纪商不可用导致结果不确定时，先按订单号向经纪商确认没有该订单，再按 trading.order_retry 重试
+func (te *TradingEngine) placeOrder(broker BrokerAPI, order Order) (*Order, error) {
+	clientOrderID := order.ClientOrderID
+	if existing, uncertain, exists := te.clientOrders.get(clientOrderID); exists {
+		if !uncertain {
+			log.Printf("重复提交的订单，返回已有订单: 客户端订单号=%s, 订单ID=%s, 状态=%s", clientOrderID, existing.ID, existing.Status)
+			return existing, nil
+		}
+		found, err := te.lookupClientOrder(broker, clientOrderID)
+		if err != nil {
+			return nil, &OrderSubmitError{ClientOrderID: clientOrderID, Err: err}
+		}
+		if found != nil {
+			return found, nil
+		}
+	}
+
+	retry := te.currentConfig().Trading.OrderRetry
+	for attempt := 1; ; attempt++ {
+		result, err := broker.PlaceOrder(order)
+		if err == nil {
+			te.clientOrders.record(clientOrderID, result)
+			return result, nil
+		}
+		if !errors.Is(err, ErrBrokerUnavailable) {
+			// 经纪商明确拒绝，订单未提交
+			return nil, err
+		}
+
+		te.clientOrders.markUncertain(clientOrderID)
+		if attempt >= retry.Attempts {
+			return nil, &OrderSubmitError{ClientOrderID: clientOrderID, Err: err}
+		}
+		log.Printf("下单结果不确定，%v 后确认并重试（第 %d/%d 次）: 客户端订单号=%s, 错误=%v",
+			retry.Backoff, attempt, retry.Attempts-1, clientOrderID, err)
+		time.Sleep(retry.Backoff)
+
+		found, lookupErr := te.lookupClientOrder(broker, clientOrderID)
+		if lookupErr != nil {
+			return nil, &OrderSubmitError{ClientOrderID: clientOrderID, Err: fmt.Errorf("%v；%w", lookupErr, err)}
+		}
+		if found != nil {
+			return found, nil
+		}
+	}
+}
+
+// lookupClientOrder 按客户端订单号向经纪商确认订单：找到时返回订单，确认不存在时返回 nil；
+// 经纪商不支持查询或查询失败时返回错误，此时不能安全地重新提交
+func (te *TradingEngine) lookupClientOrder(broker BrokerAPI, clientOrderID string) (*Order, error) {
+	if _, ok := baseBroker(broker).(ClientOrderLookup); !ok {
+		return nil, fmt.Errorf("经纪商不支持按客户端订单号查询，无法确认之前的提交是否成功")
+	}
+	found, err := broker.(ClientOrderLookup).GetOrderByClientID(clientOrderID)
+	if errors.Is(err, ErrOrderNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("按客户端订单号查询失败: %w", err)
+	}
+	log.Printf("之前提交的订单已在经纪商处生效，不再重复下单: 客户端订单号=%s, 订单ID=%s, 状态=%s", clientOrderID, found.ID, found.Status)
+	te.clientOrders.record(clientOrderID, found)
+	return found, nil
+}
+
+// findClientOrder 在模拟经纪商的订单中按客户端订单号查找
+func findClientOrder(orders map[string]Order, clientOrderID string) (*Order, bool) {
+	if clientOrderID == "" {
+		return nil, false
+	}
+	for _, order := range orders {
+		if order.ClientOrderID == clientOrderID {
+			return &order, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,145 @@
+package trading
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"agent-quant-system/internal/money"
+
+	"github.com/shopspring/decimal"
+)
+
+// ErrNoPosition 账户没有该标的的持仓
+var ErrNoPosition = errors.New("没有持仓")
+
+// SetPriceSource 设置平仓时使用的实时价格来源，未设置时使用持仓市值折算的价格
+func (te *TradingEngine) SetPriceSource(prices PriceSource) {
+	te.mutex.Lock()
+	defer te.mutex.Unlock()
+	te.prices = prices
+}
+
+// latestPrice 从价格来源获取实时价格，供纸面交易和模拟经纪商撮合使用
+func (te *TradingEngine) latestPrice(symbol string) (float64, error) {
+	te.mutex.RLock()
+	prices := te.prices
+	te.mutex.RUnlock()
+
+	if prices == nil {
+		return 0, fmt.Errorf("未设置实时价格来源")
+	}
+	return prices.GetLatestPrice(symbol)
+}
+
+// averageVolumeDays 估算纸面交易市场冲击使用的日均成交量天数
+const averageVolumeDays = 20
+
+// averageVolume 标的近期日均成交量，价格来源不提供成交量或获取失败时返回0
+func (te *TradingEngine) averageVolume(symbol string) float64 {
+	te.mutex.RLock()
+	prices := te.prices
+	te.mutex.RUnlock()
+
+	source, ok := prices.(interface {
+		GetAverageVolume(symbol string, days int) (float64, error)
+	})
+	if !ok {
+		return 0
+	}
+	volume, err := source.GetAverageVolume(symbol, averageVolumeDays)
+	if err != nil {
+		log.Printf("获取 %s 日均成交量失败，按成交量未知估算滑点: %v", symbol, err)
+		return 0
+	}
+	return volume
+}
+
+// ClosePosition 按比例平掉账户在标的上的净持仓（pct 取 (0, 1]，1 为全部平仓），提交反向市价单
+func (te *TradingEngine) ClosePosition(accountName, symbol string, pct float64) (<-chan OrderResult, error) {
+	return te.closePosition(accountName, symbol, pct, "close_position")
+}
+
+// closePosition 计算并提交平仓订单，strategyName 用于成交流水的策略归属
+func (te *TradingEngine) closePosition(accountName, symbol string, pct float64, strategyName string) (<-chan OrderResult, error) {
+	order, err := te.closeOrder(accountName, symbol, pct, strategyName)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("提交平仓订单: 账户=%s, 标的=%s, 方向=%s, 数量=%s, 比例=%.0f%%, 来源=%s",
+		accountName, symbol, order.Side, order.Quantity, pct*100, strategyName)
+	return te.SubmitOrder(order, accountName)
+}
+
+// closeOrder 根据经纪商当前持仓计算平仓订单：多头卖出、空头买入，部分平仓数量按精度向下取整；订单为只减仓
+func (te *TradingEngine) closeOrder(accountName, symbol string, pct float64, strategyName string) (Order, error) {
+	if pct <= 0 || pct > 1 {
+		return Order{}, fmt.Errorf("平仓比例必须在 (0, 1] 之间: %v", pct)
+	}
+
+	positions, err := te.GetAccountPositions(accountName)
+	if err != nil {
+		return Order{}, fmt.Errorf("获取持仓失败: %w", err)
+	}
+
+	position, exists := positions[symbol]
+	if !exists || position.Quantity.IsZero() {
+		return Order{}, fmt.Errorf("账户 '%s' 的 %s: %w", accountName, symbol, ErrNoPosition)
+	}
+
+	side := SellSide
+	held := position.Quantity
+	if held.IsNegative() {
+		side = BuySide
+		held = held.Neg()
+	}
+
+	quantity := held
+	if pct < 1 {
+		quantity = te.precisionFor(accountName, symbol).RoundQuantity(held.Mul(money.FromFloat(pct)))
+	}
+	if !quantity.IsPositive() {
+		return Order{}, fmt.Errorf("平仓数量按精度取整后为0: 持仓=%s, 比例=%v", held, pct)
+	}
+
+	price := te.closePrice(symbol, position, held)
+	if !price.IsPositive() {
+		return Order{}, fmt.Errorf("无法确定 %s 的平仓价格", symbol)
+	}
+
+	return Order{
+		Symbol:         symbol,
+		Side:           side,
+		Type:           MarketOrder,
+		Quantity:       quantity,
+		Price:          price,
+		Status:         Pending,
+		Strategy:       strategyName,
+		ReduceOnly:     true,
+		CreateTime:     time.Now(),
+		UpdateTime:     time.Now(),
+		referencePrice: price,
+	}, nil
+}
+
+// closePrice 平仓参考价格：优先使用实时价格，其次为持仓市值折算的价格，最后为持仓均价
+func (te *TradingEngine) closePrice(symbol string, position Position, held decimal.Decimal) decimal.Decimal {
+	te.mutex.RLock()
+	prices := te.prices
+	te.mutex.RUnlock()
+
+	if prices != nil {
+		price, err := prices.GetLatestPrice(symbol)
+		if err == nil && price > 0 {
+			return money.FromFloat(price)
+		}
+		log.Printf("获取 %s 实时价格失败，使用持仓价格平仓: %v", symbol, err)
+	}
+
+	if !position.MarketValue.IsZero() && held.IsPositive() {
+		return position.MarketValue.Abs().Div(held)
+	}
+	return position.AvgPrice
+}
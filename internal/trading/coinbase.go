@@ -0,0 +1,1064 @@
+package trading
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"log"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"agent-quant-system/internal/config"
+	"agent-quant-system/internal/money"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/shopspring/decimal"
+)
+
+// Coinbase Advanced Trade 的默认设置
+const (
+	defaultCoinbaseURL          = "https://api.coinbase.com/api/v3/brokerage"
+	defaultCoinbasePollInterval = 2 * time.Second
+	defaultCoinbaseTimeout      = 15 * time.Second
+	coinbasePageSize            = 250 // 账户和订单接口每页条数
+	coinbaseFillsLimit          = 250 // 估算持仓成本时读取的最近成交条数
+	coinbaseJWTLifetime         = 2 * time.Minute
+)
+
+// coinbaseQuotes 不带分隔符的标的（如 BTCUSDT）按这些计价币种拆分，较长的在前
+var coinbaseQuotes = []string{"USDC", "USDT", "USD", "EUR", "GBP", "BTC", "ETH"}
+
+// CredentialSource 每次请求前获取API密钥，密钥轮换后无需重建经纪商
+type CredentialSource func() (apiKey, apiSecret string, err error)
+
+// FeeRates 经纪商回报的手续费等级和费率
+type FeeRates struct {
+	Tier        string          `json:"tier"`
+	MakerRate   decimal.Decimal `json:"maker_rate"`
+	TakerRate   decimal.Decimal `json:"taker_rate"`
+	TotalFees   decimal.Decimal `json:"total_fees"`   // 统计周期内已支付的手续费
+	TotalVolume decimal.Decimal `json:"total_volume"` // 统计周期内的成交金额，决定费率等级
+}
+
+// FeeReporter 能查询手续费费率的经纪商（可选接口）
+type FeeReporter interface {
+	GetFeeRates() (*FeeRates, error)
+}
+
+// CoinbaseBroker 通过 Advanced Trade REST API 接入 Coinbase 的加密货币经纪商
+type CoinbaseBroker struct {
+	name          string
+	portfolioID   string
+	quoteCurrency string
+	precision     *money.PrecisionTable
+	credentials   CredentialSource
+	httpClient    *resty.Client
+	host          string // 签名使用的主机名
+	basePath      string // 签名使用的路径前缀，如 /api/v3/brokerage
+	pollInterval  time.Duration
+
+	symbols     map[string]string // 交易对 -> 下单时使用的标的，持仓按原标的回报
+	orders      map[string]Order  // 本地已知的订单及最近状态
+	callbacks   []func(Order)
+	isConnected bool
+	stopChan    chan struct{}
+	wg          sync.WaitGroup
+	mutex       sync.Mutex
+}
+
+// NewCoinbaseBroker 创建 Coinbase 经纪商，currency 为账户币种，未配置 quote_currency 时作为计价币种
+func NewCoinbaseBroker(name string, cfg config.CoinbaseConfig, currency string, precision *money.PrecisionTable, credentials CredentialSource) *CoinbaseBroker {
+	baseURL := strings.TrimRight(cfg.BaseURL, "/")
+	if baseURL == "" {
+		baseURL = defaultCoinbaseURL
+	}
+	pollInterval := cfg.PollInterval
+	if pollInterval <= 0 {
+		pollInterval = defaultCoinbasePollInterval
+	}
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = defaultCoinbaseTimeout
+	}
+	quote := strings.ToUpper(cfg.QuoteCurrency)
+	if quote == "" {
+		quote = strings.ToUpper(currency)
+	}
+	if quote == "" {
+		quote = "USD"
+	}
+
+	var host, basePath string
+	if parsed, err := url.Parse(baseURL); err == nil {
+		host, basePath = parsed.Host, parsed.Path
+	}
+
+	client := resty.New()
+	client.SetBaseURL(baseURL)
+	client.SetTimeout(timeout)
+	client.SetHeader("Content-Type", "application/json")
+	client.SetHeader("Accept", "application/json")
+
+	return &CoinbaseBroker{
+		name:          name,
+		portfolioID:   cfg.PortfolioID,
+		quoteCurrency: quote,
+		precision:     precision,
+		credentials:   credentials,
+		httpClient:    client,
+		host:          host,
+		basePath:      basePath,
+		pollInterval:  pollInterval,
+		symbols:       make(map[string]string),
+		orders:        make(map[string]Order),
+	}
+}
+
+// coinbaseAmount 带币种的金额
+type coinbaseAmount struct {
+	Value    string `json:"value"`
+	Currency string `json:"currency"`
+}
+
+// coinbaseAccount 单个币种的资金账户
+type coinbaseAccount struct {
+	UUID             string         `json:"uuid"`
+	Currency         string         `json:"currency"`
+	AvailableBalance coinbaseAmount `json:"available_balance"`
+	Hold             coinbaseAmount `json:"hold"`
+}
+
+// coinbaseOrderResponse 下单应答
+type coinbaseOrderResponse struct {
+	Success         bool `json:"success"`
+	SuccessResponse struct {
+		OrderID       string `json:"order_id"`
+		ProductID     string `json:"product_id"`
+		ClientOrderID string `json:"client_order_id"`
+	} `json:"success_response"`
+	ErrorResponse struct {
+		Error                string `json:"error"`
+		Message              string `json:"message"`
+		ErrorDetails         string `json:"error_details"`
+		PreviewFailureReason string `json:"preview_failure_reason"`
+	} `json:"error_response"`
+	FailureReason string `json:"failure_reason"`
+}
+
+// coinbaseOrder 订单查询结果
+type coinbaseOrder struct {
+	OrderID            string    `json:"order_id"`
+	ProductID          string    `json:"product_id"`
+	ClientOrderID      string    `json:"client_order_id"`
+	Side               string    `json:"side"`
+	Status             string    `json:"status"`
+	OrderType          string    `json:"order_type"`
+	TimeInForce        string    `json:"time_in_force"`
+	FilledSize         string    `json:"filled_size"`
+	AverageFilledPrice string    `json:"average_filled_price"`
+	TotalFees          string    `json:"total_fees"`
+	CreatedTime        time.Time `json:"created_time"`
+	LastFillTime       time.Time `json:"last_fill_time"`
+	OrderConfiguration map[string]struct {
+		BaseSize   string `json:"base_size"`
+		LimitPrice string `json:"limit_price"`
+		StopPrice  string `json:"stop_price"`
+	} `json:"order_configuration"`
+}
+
+// coinbaseFill 成交记录
+type coinbaseFill struct {
+	EntryID     string    `json:"entry_id"`
+	TradeID     string    `json:"trade_id"`
+	OrderID     string    `json:"order_id"`
+	TradeTime   time.Time `json:"trade_time"`
+	Price       string    `json:"price"`
+	Size        string    `json:"size"`
+	Commission  string    `json:"commission"`
+	ProductID   string    `json:"product_id"`
+	Side        string    `json:"side"`
+	SizeInQuote bool      `json:"size_in_quote"`
+}
+
+// decimalOrZero 解析接口返回的数字字符串，缺失或无效时为0
+func decimalOrZero(value string) decimal.Decimal {
+	if value == "" {
+		return decimal.Zero
+	}
+	parsed, err := decimal.NewFromString(value)
+	if err != nil {
+		return decimal.Zero
+	}
+	return parsed
+}
+
+// CoinbaseProductID 把系统标的转换为 Coinbase 交易对：BTC-USD、BTC/USD 原样使用，BTCUSDT 按常见计价币种拆分，
+// 无法拆分的（如 BTC）使用 quote 计价
+func CoinbaseProductID(symbol, quote string) string {
+	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+	if strings.Contains(symbol, "-") {
+		return symbol
+	}
+	if strings.Contains(symbol, "/") {
+		return strings.Replace(symbol, "/", "-", 1)
+	}
+	for _, candidate := range coinbaseQuotes {
+		if len(symbol) > len(candidate) && strings.HasSuffix(symbol, candidate) {
+			return symbol[:len(symbol)-len(candidate)] + "-" + candidate
+		}
+	}
+	return symbol + "-" + strings.ToUpper(quote)
+}
+
+// mapCoinbaseOrderStatus 将 Coinbase 订单状态映射为系统订单状态
+func mapCoinbaseOrderStatus(status string, filled decimal.Decimal) OrderStatus {
+	switch strings.ToUpper(status) {
+	case "FILLED":
+		return Filled
+	case "CANCELLED":
+		return Cancelled
+	case "EXPIRED":
+		return Expired
+	case "FAILED":
+		return Rejected
+	case "OPEN", "CANCEL_QUEUED", "EDIT_QUEUED":
+		if filled.IsPositive() {
+			return PartiallyFilled
+		}
+		return Submitted
+	default:
+		// PENDING、QUEUED 等尚未进入订单簿
+		return Pending
+	}
+}
+
+// coinbaseStatusFilter 将系统订单状态转换为订单列表的状态过滤参数
+func coinbaseStatusFilter(status OrderStatus) string {
+	switch status {
+	case Submitted, PartiallyFilled:
+		return "OPEN"
+	case Filled:
+		return "FILLED"
+	case Cancelled:
+		return "CANCELLED"
+	case Expired:
+		return "EXPIRED"
+	case Rejected:
+		return "FAILED"
+	case Pending:
+		return "PENDING"
+	default:
+		return ""
+	}
+}
+
+// mapCoinbaseSide 将 Coinbase 的买卖方向映射为系统订单方向
+func mapCoinbaseSide(side string) OrderSide {
+	if strings.EqualFold(side, "SELL") {
+		return SellSide
+	}
+	return BuySide
+}
+
+// coinbaseOrderConfiguration 按订单类型和有效期生成下单参数。Coinbase 不支持止损市价单，
+// 止损单以止损限价单提交，限价为订单价格，未设置时使用止损价
+func coinbaseOrderConfiguration(order Order, precision money.Precision) (map[string]interface{}, error) {
+	size := precision.RoundQuantity(order.Quantity).String()
+
+	switch order.Type {
+	case MarketOrder, "":
+		return map[string]interface{}{
+			"market_market_ioc": map[string]string{"base_size": size},
+		}, nil
+	case LimitOrder:
+		limit := map[string]interface{}{
+			"base_size":   size,
+			"limit_price": precision.RoundPrice(order.Price).String(),
+		}
+		switch order.TimeInForce {
+		case IOC:
+			return map[string]interface{}{"sor_limit_ioc": limit}, nil
+		case FOK:
+			return map[string]interface{}{"limit_limit_fok": limit}, nil
+		case DAY:
+			limit["end_time"] = endOfDayUTC(time.Now()).Format(time.RFC3339)
+			return map[string]interface{}{"limit_limit_gtd": limit}, nil
+		default:
+			limit["post_only"] = false
+			return map[string]interface{}{"limit_limit_gtc": limit}, nil
+		}
+	case StopOrder:
+		if !order.StopPrice.IsPositive() {
+			return nil, fmt.Errorf("止损单必须设置止损价")
+		}
+		limitPrice := order.Price
+		if !limitPrice.IsPositive() {
+			limitPrice = order.StopPrice
+		}
+		direction := "STOP_DIRECTION_STOP_DOWN"
+		if order.Side == BuySide {
+			direction = "STOP_DIRECTION_STOP_UP"
+		}
+		return map[string]interface{}{
+			"stop_limit_stop_limit_gtc": map[string]string{
+				"base_size":      size,
+				"limit_price":    precision.RoundPrice(limitPrice).String(),
+				"stop_price":     precision.RoundPrice(order.StopPrice).String(),
+				"stop_direction": direction,
+			},
+		}, nil
+	default:
+		return nil, fmt.Errorf("Coinbase 不支持订单类型 %s", order.Type)
+	}
+}
+
+// endOfDayUTC 加密货币全天交易，当日有效的订单在UTC当天结束时过期
+func endOfDayUTC(now time.Time) time.Time {
+	now = now.UTC()
+	return time.Date(now.Year(), now.Month(), now.Day(), 23, 59, 59, 0, time.UTC)
+}
+
+// sign 为请求生成认证头：api_secret 为 EC 私钥时签发 ES256 JWT，否则按旧版密钥做 HMAC-SHA256 签名
+func (b *CoinbaseBroker) sign(method, path string, body []byte) (map[string]string, error) {
+	if b.credentials == nil {
+		return nil, fmt.Errorf("未配置 Coinbase API 密钥")
+	}
+	apiKey, apiSecret, err := b.credentials()
+	if err != nil {
+		return nil, fmt.Errorf("获取 Coinbase API 密钥失败: %w", err)
+	}
+	if apiKey == "" || apiSecret == "" {
+		return nil, fmt.Errorf("Coinbase API 密钥不完整")
+	}
+
+	requestPath := b.basePath + path
+	if index := strings.Index(requestPath, "?"); index >= 0 {
+		requestPath = requestPath[:index]
+	}
+
+	// 配置文件中的私钥常以 \n 转义换行
+	pemSecret := strings.ReplaceAll(apiSecret, `\n`, "\n")
+	if block, _ := pem.Decode([]byte(pemSecret)); block != nil {
+		token, err := coinbaseJWT(apiKey, block, method+" "+b.host+requestPath, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		return map[string]string{"Authorization": "Bearer " + token}, nil
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(apiSecret))
+	mac.Write([]byte(timestamp + method + requestPath + string(body)))
+	return map[string]string{
+		"CB-ACCESS-KEY":       apiKey,
+		"CB-ACCESS-SIGN":      hex.EncodeToString(mac.Sum(nil)),
+		"CB-ACCESS-TIMESTAMP": timestamp,
+	}, nil
+}
+
+// coinbaseJWT 按 CDP API 密钥签发单次请求使用的 ES256 JWT，uri 为 "方法 主机路径"
+func coinbaseJWT(apiKey string, block *pem.Block, uri string, now time.Time) (string, error) {
+	var key *ecdsa.PrivateKey
+	if parsed, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		key = parsed
+	} else if parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		ecKey, ok := parsed.(*ecdsa.PrivateKey)
+		if !ok {
+			return "", fmt.Errorf("Coinbase API 私钥不是 EC 密钥")
+		}
+		key = ecKey
+	} else {
+		return "", fmt.Errorf("解析 Coinbase API 私钥失败: %w", err)
+	}
+
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("生成 JWT nonce 失败: %w", err)
+	}
+	header, _ := json.Marshal(map[string]string{
+		"alg": "ES256", "typ": "JWT", "kid": apiKey, "nonce": hex.EncodeToString(nonce),
+	})
+	claims, _ := json.Marshal(map[string]interface{}{
+		"sub": apiKey,
+		"iss": "cdp",
+		"nbf": now.Unix(),
+		"exp": now.Add(coinbaseJWTLifetime).Unix(),
+		"uri": uri,
+	})
+
+	encoding := base64.RawURLEncoding
+	signingInput := encoding.EncodeToString(header) + "." + encoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(signingInput))
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("签发 JWT 失败: %w", err)
+	}
+	// ES256 签名为定长的 r || s
+	signature := make([]byte, 64)
+	r.FillBytes(signature[:32])
+	s.FillBytes(signature[32:])
+	return signingInput + "." + encoding.EncodeToString(signature), nil
+}
+
+// do 签名并发送请求、解析JSON响应，连接失败和认证失败视为经纪商不可用
+func (b *CoinbaseBroker) do(method, path string, body, result interface{}) error {
+	var payload []byte
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("编码请求失败: %w", err)
+		}
+		payload = encoded
+	}
+	headers, err := b.sign(method, path, payload)
+	if err != nil {
+		return err
+	}
+
+	request := b.httpClient.R().SetHeaders(headers)
+	if payload != nil {
+		request.SetBody(bytes.NewReader(payload))
+	}
+	if result != nil {
+		request.SetResult(result).ForceContentType("application/json")
+	}
+
+	resp, err := request.Execute(method, path)
+	if err != nil {
+		return fmt.Errorf("请求 Coinbase 失败: %v: %w", err, ErrBrokerUnavailable)
+	}
+	if resp.StatusCode() == 401 {
+		return fmt.Errorf("Coinbase 认证失败，请检查 API 密钥和权限: %w", ErrBrokerUnavailable)
+	}
+	if resp.StatusCode() == 429 || resp.StatusCode() >= 500 {
+		return fmt.Errorf("Coinbase 暂时不可用: %d %s: %w", resp.StatusCode(), strings.TrimSpace(resp.String()), ErrBrokerUnavailable)
+	}
+	if resp.IsError() {
+		return fmt.Errorf("Coinbase 返回错误: %d %s", resp.StatusCode(), strings.TrimSpace(resp.String()))
+	}
+	return nil
+}
+
+// withPortfolio 在查询参数中加入配置的投资组合
+func (b *CoinbaseBroker) withPortfolio(query url.Values) url.Values {
+	if b.portfolioID != "" {
+		query.Set("retail_portfolio_id", b.portfolioID)
+	}
+	return query
+}
+
+// checkConnected 检查是否已连接
+func (b *CoinbaseBroker) checkConnected() error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if !b.isConnected {
+		return fmt.Errorf("经纪商未连接: %w", ErrBrokerUnavailable)
+	}
+	return nil
+}
+
+// Connect 验证API密钥并启动订单状态轮询
+func (b *CoinbaseBroker) Connect() error {
+	log.Printf("连接到 Coinbase: %s, 计价币种=%s", b.name, b.quoteCurrency)
+
+	if _, err := b.fetchAccounts(); err != nil {
+		return fmt.Errorf("验证 Coinbase API 密钥失败: %w", err)
+	}
+	if rates, err := b.GetFeeRates(); err != nil {
+		log.Printf("获取 Coinbase 手续费等级失败: %v", err)
+	} else {
+		log.Printf("Coinbase 手续费等级: %s, maker=%s, taker=%s", rates.Tier, rates.MakerRate, rates.TakerRate)
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.isConnected {
+		return nil
+	}
+	b.isConnected = true
+	b.stopChan = make(chan struct{})
+	b.wg.Add(1)
+	go b.pollOrders()
+	return nil
+}
+
+// Disconnect 停止订单轮询
+func (b *CoinbaseBroker) Disconnect() error {
+	b.mutex.Lock()
+	if !b.isConnected {
+		b.mutex.Unlock()
+		return nil
+	}
+	b.isConnected = false
+	close(b.stopChan)
+	b.mutex.Unlock()
+
+	b.wg.Wait()
+	log.Printf("断开 Coinbase 连接: %s", b.name)
+	return nil
+}
+
+// Ping 健康检查：确认接口可达且API密钥仍然有效
+func (b *CoinbaseBroker) Ping() error {
+	if err := b.checkConnected(); err != nil {
+		return err
+	}
+	var accounts struct {
+		Accounts []coinbaseAccount `json:"accounts"`
+	}
+	return b.do("GET", "/accounts?limit=1", nil, &accounts)
+}
+
+// OnOrderUpdate 注册订单状态变化回调
+func (b *CoinbaseBroker) OnOrderUpdate(callback func(Order)) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.callbacks = append(b.callbacks, callback)
+}
+
+// productID 标的对应的交易对，并记住交易对对应的标的
+func (b *CoinbaseBroker) productID(symbol string) string {
+	product := CoinbaseProductID(symbol, b.quoteCurrency)
+	b.mutex.Lock()
+	b.symbols[product] = strings.ToUpper(symbol)
+	b.mutex.Unlock()
+	return product
+}
+
+// symbolFor 交易对对应的系统标的：下过单的按原标的回报，否则为交易对本身
+func (b *CoinbaseBroker) symbolFor(product string) string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if symbol, exists := b.symbols[product]; exists {
+		return symbol
+	}
+	return product
+}
+
+// PlaceOrder 下单，客户端订单号用于识别重复提交
+func (b *CoinbaseBroker) PlaceOrder(order Order) (*Order, error) {
+	if err := b.checkConnected(); err != nil {
+		return nil, err
+	}
+
+	configuration, err := coinbaseOrderConfiguration(order, b.precision.For(order.Symbol))
+	if err != nil {
+		return nil, err
+	}
+	clientOrderID := order.ClientOrderID
+	if clientOrderID == "" {
+		clientOrderID = newClientOrderID()
+		order.ClientOrderID = clientOrderID
+	}
+	request := map[string]interface{}{
+		"client_order_id":     clientOrderID,
+		"product_id":          b.productID(order.Symbol),
+		"side":                strings.ToUpper(string(order.Side)),
+		"order_configuration": configuration,
+	}
+	if b.portfolioID != "" {
+		request["retail_portfolio_id"] = b.portfolioID
+	}
+
+	var response coinbaseOrderResponse
+	if err := b.do("POST", "/orders", request, &response); err != nil {
+		return nil, fmt.Errorf("提交订单失败: %w", err)
+	}
+	if !response.Success {
+		reason := response.ErrorResponse.Message
+		if reason == "" {
+			reason = response.ErrorResponse.Error
+		}
+		if response.ErrorResponse.PreviewFailureReason != "" {
+			reason += " (" + response.ErrorResponse.PreviewFailureReason + ")"
+		}
+		if reason == "" {
+			reason = response.FailureReason
+		}
+		return nil, fmt.Errorf("Coinbase 拒绝订单: %s", reason)
+	}
+
+	order.ID = response.SuccessResponse.OrderID
+	order.Status = Submitted
+	order.UpdateTime = time.Now()
+	if order.CreateTime.IsZero() {
+		order.CreateTime = order.UpdateTime
+	}
+	order.AccountName = b.name
+	b.mutex.Lock()
+	b.orders[order.ID] = order
+	b.mutex.Unlock()
+
+	log.Printf("Coinbase 订单已提交: 订单ID=%s, 交易对=%s, 方向=%s, 数量=%s",
+		order.ID, response.SuccessResponse.ProductID, order.Side, order.Quantity)
+	return &order, nil
+}
+
+// CancelOrder 撤单
+func (b *CoinbaseBroker) CancelOrder(orderID string) error {
+	if err := b.checkConnected(); err != nil {
+		return err
+	}
+
+	var response struct {
+		Results []struct {
+			Success       bool   `json:"success"`
+			FailureReason string `json:"failure_reason"`
+			OrderID       string `json:"order_id"`
+		} `json:"results"`
+	}
+	if err := b.do("POST", "/orders/batch_cancel", map[string][]string{"order_ids": {orderID}}, &response); err != nil {
+		return fmt.Errorf("撤单失败: %w", err)
+	}
+	for _, result := range response.Results {
+		if result.OrderID == orderID && !result.Success {
+			if result.FailureReason == "UNKNOWN_CANCEL_ORDER" {
+				return fmt.Errorf("订单 %s: %w", orderID, ErrOrderNotFound)
+			}
+			return fmt.Errorf("撤单失败: %s", result.FailureReason)
+		}
+	}
+	return nil
+}
+
+// toOrder 把接口返回的订单与本地记录合并
+func (b *CoinbaseBroker) toOrder(remote coinbaseOrder) Order {
+	b.mutex.Lock()
+	order, exists := b.orders[remote.OrderID]
+	b.mutex.Unlock()
+	if !exists {
+		order = Order{
+			ID:            remote.OrderID,
+			Symbol:        b.symbolFor(remote.ProductID),
+			Side:          mapCoinbaseSide(remote.Side),
+			Type:          MarketOrder,
+			AccountName:   b.name,
+			ClientOrderID: remote.ClientOrderID,
+			CreateTime:    remote.CreatedTime,
+		}
+		for kind, configuration := range remote.OrderConfiguration {
+			order.Quantity = decimalOrZero(configuration.BaseSize)
+			order.Price = decimalOrZero(configuration.LimitPrice)
+			order.StopPrice = decimalOrZero(configuration.StopPrice)
+			switch {
+			case strings.HasPrefix(kind, "stop"):
+				order.Type = StopOrder
+			case strings.Contains(kind, "limit"):
+				order.Type = LimitOrder
+			}
+		}
+	}
+
+	order.FilledQty = decimalOrZero(remote.FilledSize)
+	order.Status = mapCoinbaseOrderStatus(remote.Status, order.FilledQty)
+	order.AvgPrice = decimalOrZero(remote.AverageFilledPrice)
+	order.Commission = decimalOrZero(remote.TotalFees)
+	order.UpdateTime = time.Now()
+	if !remote.LastFillTime.IsZero() {
+		order.UpdateTime = remote.LastFillTime
+	}
+	return order
+}
+
+// fetchOrder 查询单个订单
+func (b *CoinbaseBroker) fetchOrder(orderID string) (*Order, error) {
+	var response struct {
+		Order coinbaseOrder `json:"order"`
+	}
+	if err := b.do("GET", "/orders/historical/"+url.PathEscape(orderID), nil, &response); err != nil {
+		return nil, fmt.Errorf("查询订单失败: %w", err)
+	}
+	if response.Order.OrderID == "" {
+		return nil, fmt.Errorf("订单 %s: %w", orderID, ErrOrderNotFound)
+	}
+	order := b.toOrder(response.Order)
+	return &order, nil
+}
+
+// pollOrders 定期查询本地未终止的订单，状态、成交数量或手续费变化时触发回调
+func (b *CoinbaseBroker) pollOrders() {
+	defer b.wg.Done()
+
+	ticker := time.NewTicker(b.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-b.stopChan:
+			return
+		case <-ticker.C:
+			b.mutex.Lock()
+			var pending []string
+			for id, order := range b.orders {
+				if !order.Status.IsTerminal() {
+					pending = append(pending, id)
+				}
+			}
+			b.mutex.Unlock()
+
+			var changed []Order
+			for _, id := range pending {
+				order, err := b.fetchOrder(id)
+				if err != nil {
+					log.Printf("Coinbase 订单轮询失败: %v", err)
+					continue
+				}
+				b.mutex.Lock()
+				previous := b.orders[id]
+				if previous.Status != order.Status || !previous.FilledQty.Equal(order.FilledQty) ||
+					!previous.Commission.Equal(order.Commission) {
+					b.orders[id] = *order
+					changed = append(changed, *order)
+				}
+				b.mutex.Unlock()
+			}
+
+			b.mutex.Lock()
+			callbacks := append([]func(Order){}, b.callbacks...)
+			b.mutex.Unlock()
+			for _, order := range changed {
+				log.Printf("Coinbase 订单状态更新: 订单ID=%s, 状态=%s, 已成交=%s, 手续费=%s",
+					order.ID, order.Status, order.FilledQty, order.Commission)
+				for _, callback := range callbacks {
+					callback(order)
+				}
+			}
+		}
+	}
+}
+
+// GetOrder 查询订单
+func (b *CoinbaseBroker) GetOrder(orderID string) (*Order, error) {
+	if err := b.checkConnected(); err != nil {
+		return nil, err
+	}
+	return b.fetchOrder(orderID)
+}
+
+// GetOrderByClientID 按客户端订单号查询订单
+func (b *CoinbaseBroker) GetOrderByClientID(clientOrderID string) (*Order, error) {
+	b.mutex.Lock()
+	var orderID string
+	for id, order := range b.orders {
+		if order.ClientOrderID == clientOrderID {
+			orderID = id
+			break
+		}
+	}
+	b.mutex.Unlock()
+	if orderID != "" {
+		return b.GetOrder(orderID)
+	}
+
+	orders, err := b.GetOrders("", "")
+	if err != nil {
+		return nil, err
+	}
+	for _, order := range orders {
+		if order.ClientOrderID == clientOrderID {
+			return &order, nil
+		}
+	}
+	return nil, fmt.Errorf("客户端订单号 %s: %w", clientOrderID, ErrOrderNotFound)
+}
+
+// GetOrders 查询最近的订单列表（最多一页）
+func (b *CoinbaseBroker) GetOrders(symbol string, status OrderStatus) ([]Order, error) {
+	if err := b.checkConnected(); err != nil {
+		return nil, err
+	}
+
+	query := b.withPortfolio(url.Values{"limit": {strconv.Itoa(coinbasePageSize)}})
+	if symbol != "" {
+		query.Set("product_ids", b.productID(symbol))
+	}
+	if filter := coinbaseStatusFilter(status); filter != "" {
+		query.Set("order_status", filter)
+	}
+
+	var response struct {
+		Orders []coinbaseOrder `json:"orders"`
+	}
+	if err := b.do("GET", "/orders/historical/batch?"+query.Encode(), nil, &response); err != nil {
+		return nil, fmt.Errorf("获取订单失败: %w", err)
+	}
+
+	var result []Order
+	for _, remote := range response.Orders {
+		order := b.toOrder(remote)
+		if status != "" && order.Status != status {
+			continue
+		}
+		result = append(result, order)
+	}
+	return result, nil
+}
+
+// fetchAccounts 按页读取全部币种的资金账户
+func (b *CoinbaseBroker) fetchAccounts() ([]coinbaseAccount, error) {
+	var accounts []coinbaseAccount
+	cursor := ""
+	for {
+		query := b.withPortfolio(url.Values{"limit": {strconv.Itoa(coinbasePageSize)}})
+		if cursor != "" {
+			query.Set("cursor", cursor)
+		}
+		var response struct {
+			Accounts []coinbaseAccount `json:"accounts"`
+			HasNext  bool              `json:"has_next"`
+			Cursor   string            `json:"cursor"`
+		}
+		if err := b.do("GET", "/accounts?"+query.Encode(), nil, &response); err != nil {
+			return nil, fmt.Errorf("获取资金账户失败: %w", err)
+		}
+		accounts = append(accounts, response.Accounts...)
+		if !response.HasNext || response.Cursor == "" {
+			return accounts, nil
+		}
+		cursor = response.Cursor
+	}
+}
+
+// GetBalance 获取计价币种的余额（含挂单冻结部分）
+func (b *CoinbaseBroker) GetBalance() (decimal.Decimal, error) {
+	if err := b.checkConnected(); err != nil {
+		return decimal.Zero, err
+	}
+
+	accounts, err := b.fetchAccounts()
+	if err != nil {
+		return decimal.Zero, err
+	}
+	for _, account := range accounts {
+		if strings.EqualFold(account.Currency, b.quoteCurrency) {
+			balance := decimalOrZero(account.AvailableBalance.Value).Add(decimalOrZero(account.Hold.Value))
+			return b.precision.Defaults().RoundAmount(balance), nil
+		}
+	}
+	return decimal.Zero, fmt.Errorf("Coinbase 中没有 %s 资金账户", b.quoteCurrency)
+}
+
+// GetPositions 获取持仓：计价币种以外余额不为0的币种按 "币种-计价币种" 交易对回报，
+// 市值按买一卖一中间价计算；Coinbase 不提供持仓成本，平均成本由最近的成交记录估算
+func (b *CoinbaseBroker) GetPositions() (map[string]Position, error) {
+	if err := b.checkConnected(); err != nil {
+		return nil, err
+	}
+
+	accounts, err := b.fetchAccounts()
+	if err != nil {
+		return nil, err
+	}
+
+	quantities := make(map[string]decimal.Decimal)
+	for _, account := range accounts {
+		currency := strings.ToUpper(account.Currency)
+		if currency == b.quoteCurrency {
+			continue
+		}
+		quantity := decimalOrZero(account.AvailableBalance.Value).Add(decimalOrZero(account.Hold.Value))
+		if quantity.IsPositive() {
+			quantities[currency+"-"+b.quoteCurrency] = quantity
+		}
+	}
+	if len(quantities) == 0 {
+		return map[string]Position{}, nil
+	}
+
+	products := make([]string, 0, len(quantities))
+	for product := range quantities {
+		products = append(products, product)
+	}
+	sort.Strings(products)
+	prices, err := b.midPrices(products)
+	if err != nil {
+		log.Printf("获取 Coinbase 行情失败，持仓市值暂不可用: %v", err)
+	}
+
+	positions := make(map[string]Position, len(quantities))
+	for _, product := range products {
+		quantity := quantities[product]
+		symbol := b.symbolFor(product)
+		position := Position{
+			Symbol:     symbol,
+			Quantity:   quantity,
+			UpdateTime: time.Now(),
+		}
+		if cost, err := b.averageCost(product, quantity); err != nil {
+			log.Printf("估算 %s 持仓成本失败: %v", product, err)
+		} else {
+			position.AvgPrice = b.precision.For(symbol).RoundPrice(cost)
+		}
+		if price, ok := prices[product]; ok {
+			position.MarketValue = b.precision.For(symbol).RoundAmount(price.Mul(quantity))
+			if position.AvgPrice.IsPositive() {
+				position.UnrealizedPL = position.MarketValue.Sub(position.AvgPrice.Mul(quantity))
+			}
+		}
+		positions[symbol] = position
+	}
+	return positions, nil
+}
+
+// midPrices 查询交易对的买一卖一中间价
+func (b *CoinbaseBroker) midPrices(products []string) (map[string]decimal.Decimal, error) {
+	query := url.Values{"product_ids": products}
+	var response struct {
+		Pricebooks []struct {
+			ProductID string `json:"product_id"`
+			Bids      []struct {
+				Price string `json:"price"`
+			} `json:"bids"`
+			Asks []struct {
+				Price string `json:"price"`
+			} `json:"asks"`
+		} `json:"pricebooks"`
+	}
+	if err := b.do("GET", "/best_bid_ask?"+query.Encode(), nil, &response); err != nil {
+		return nil, err
+	}
+
+	prices := make(map[string]decimal.Decimal, len(response.Pricebooks))
+	for _, book := range response.Pricebooks {
+		if len(book.Bids) == 0 || len(book.Asks) == 0 {
+			continue
+		}
+		bid, ask := decimalOrZero(book.Bids[0].Price), decimalOrZero(book.Asks[0].Price)
+		if bid.IsPositive() && ask.IsPositive() {
+			prices[book.ProductID] = bid.Add(ask).Div(decimal.NewFromInt(2))
+		}
+	}
+	return prices, nil
+}
+
+// fetchFills 查询最近的成交记录（最新的在前），product 为空时查询全部交易对
+func (b *CoinbaseBroker) fetchFills(product string, limit int) ([]coinbaseFill, error) {
+	if limit <= 0 || limit > coinbaseFillsLimit {
+		limit = coinbaseFillsLimit
+	}
+	query := b.withPortfolio(url.Values{"limit": {strconv.Itoa(limit)}})
+	if product != "" {
+		query.Set("product_ids", product)
+	}
+	var response struct {
+		Fills []coinbaseFill `json:"fills"`
+	}
+	if err := b.do("GET", "/orders/historical/fills?"+query.Encode(), nil, &response); err != nil {
+		return nil, fmt.Errorf("获取成交记录失败: %w", err)
+	}
+	return response.Fills, nil
+}
+
+// averageCost 按时间顺序回放最近的成交估算持仓的平均成本（买入手续费计入成本，清仓后重新计算）；
+// 成交记录覆盖不到全部持仓时，早于记录的部分按已知成本计
+func (b *CoinbaseBroker) averageCost(product string, quantity decimal.Decimal) (decimal.Decimal, error) {
+	fills, err := b.fetchFills(product, coinbaseFillsLimit)
+	if err != nil {
+		return decimal.Zero, err
+	}
+
+	held, cost := decimal.Zero, decimal.Zero
+	for i := len(fills) - 1; i >= 0; i-- {
+		fill := fills[i]
+		size, price := decimalOrZero(fill.Size), decimalOrZero(fill.Price)
+		if fill.SizeInQuote && price.IsPositive() {
+			size = size.Div(price)
+		}
+		if mapCoinbaseSide(fill.Side) == BuySide {
+			held = held.Add(size)
+			cost = cost.Add(size.Mul(price)).Add(decimalOrZero(fill.Commission))
+			continue
+		}
+		if !held.IsPositive() {
+			continue
+		}
+		sold := decimal.Min(size, held)
+		cost = cost.Sub(cost.Mul(sold).Div(held))
+		held = held.Sub(sold)
+	}
+	if !held.IsPositive() {
+		return decimal.Zero, fmt.Errorf("最近 %d 条成交中没有 %s 的买入记录", coinbaseFillsLimit, product)
+	}
+	if held.LessThan(quantity) {
+		log.Printf("%s 最近的成交只覆盖 %s / %s 的持仓，平均成本为估算值", product, held, quantity)
+	}
+	return cost.Div(held), nil
+}
+
+// GetTrades 获取近期成交记录，手续费为 Coinbase 回报的实际手续费
+func (b *CoinbaseBroker) GetTrades(symbol string, limit int) ([]Trade, error) {
+	if err := b.checkConnected(); err != nil {
+		return nil, err
+	}
+
+	product := ""
+	if symbol != "" {
+		product = b.productID(symbol)
+	}
+	fills, err := b.fetchFills(product, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	// 与其他经纪商一致，按时间升序返回
+	trades := make([]Trade, 0, len(fills))
+	for i := len(fills) - 1; i >= 0; i-- {
+		fill := fills[i]
+		quantity, price := decimalOrZero(fill.Size), decimalOrZero(fill.Price)
+		if fill.SizeInQuote && price.IsPositive() {
+			quantity = quantity.Div(price)
+		}
+		id := fill.EntryID
+		if id == "" {
+			id = fill.TradeID
+		}
+		trades = append(trades, Trade{
+			ID:          id,
+			OrderID:     fill.OrderID,
+			Symbol:      b.symbolFor(fill.ProductID),
+			Side:        mapCoinbaseSide(fill.Side),
+			Quantity:    quantity,
+			Price:       price,
+			Commission:  decimalOrZero(fill.Commission),
+			Timestamp:   fill.TradeTime,
+			AccountName: b.name,
+		})
+	}
+	return trades, nil
+}
+
+// GetFeeRates 查询当前的手续费等级、maker / taker 费率和统计周期内的手续费合计
+func (b *CoinbaseBroker) GetFeeRates() (*FeeRates, error) {
+	var response struct {
+		TotalVolume float64 `json:"total_volume"`
+		TotalFees   float64 `json:"total_fees"`
+		FeeTier     struct {
+			PricingTier  string `json:"pricing_tier"`
+			MakerFeeRate string `json:"maker_fee_rate"`
+			TakerFeeRate string `json:"taker_fee_rate"`
+		} `json:"fee_tier"`
+	}
+	if err := b.do("GET", "/transaction_summary", nil, &response); err != nil {
+		return nil, fmt.Errorf("获取手续费等级失败: %w", err)
+	}
+	return &FeeRates{
+		Tier:        response.FeeTier.PricingTier,
+		MakerRate:   decimalOrZero(response.FeeTier.MakerFeeRate),
+		TakerRate:   decimalOrZero(response.FeeTier.TakerFeeRate),
+		TotalFees:   decimal.NewFromFloat(response.TotalFees),
+		TotalVolume: decimal.NewFromFloat(response.TotalVolume),
+	}, nil
+}
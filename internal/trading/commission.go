@@ -0,0 +1,49 @@
+package trading
+
+import (
+	"log"
+
+	"agent-quant-system/internal/commission"
+	"agent-quant-system/internal/config"
+)
+
+// accountCommissions 按配置创建各账户的佣金模型，未配置模型的账户不在结果中
+func accountCommissions(cfg *config.Config) map[string]commission.Model {
+	models := make(map[string]commission.Model)
+	for name := range cfg.Accounts {
+		model, err := commission.New(cfg.CommissionFor(name))
+		if err != nil {
+			log.Printf("账户 %s 的佣金模型无效，使用默认佣金: %v", name, err)
+			continue
+		}
+		if model != nil {
+			models[name] = model
+		}
+	}
+	return models
+}
+
+// estimateCommission 真实经纪商未回报佣金时按账户的佣金模型估算本次成交的佣金，
+// 估算值只记入成交流水、资金分配和盈亏账本，不影响经纪商的余额；限价单按挂单费率估算
+func (te *TradingEngine) estimateCommission(delta *Order, accountName string) {
+	model, exists := te.commissions[accountName]
+	if !exists || !delta.Commission.IsZero() || !delta.FilledQty.IsPositive() {
+		return
+	}
+	if broker, exists := te.brokers[accountName]; exists {
+		if _, simulated := baseBroker(broker).(CommissionSimulator); simulated {
+			// 模拟和纸面交易经纪商已按模型计算佣金
+			return
+		}
+	}
+
+	precision := te.currentConfig().Accounts[accountName].PrecisionTable().For(delta.Symbol)
+	delta.Commission = precision.RoundAmount(model.Commission(commission.Fill{
+		Symbol:   delta.Symbol,
+		Buy:      delta.Side == BuySide,
+		Quantity: delta.FilledQty,
+		Price:    delta.AvgPrice,
+		Maker:    delta.Type == LimitOrder,
+	}))
+	log.Printf("经纪商未回报佣金，按 %s 模型估算: 订单ID=%s, 佣金=%s", model.Name(), delta.ID, delta.Commission)
+}
@@ -0,0 +1,987 @@
+package trading
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"agent-quant-system/internal/config"
+	"agent-quant-system/internal/money"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/shopspring/decimal"
+)
+
+// CTP 网关的默认设置
+const (
+	defaultCTPGatewayURL   = "http://localhost:7001"
+	defaultCTPPollInterval = 2 * time.Second
+	defaultCTPTimeout      = 15 * time.Second
+)
+
+// CTP 枚举值（ThostFtdcUserApiDataType.h）
+const (
+	ctpDirectionBuy  = "0"
+	ctpDirectionSell = "1"
+
+	ctpOffsetOpen           = "0"
+	ctpOffsetClose          = "1"
+	ctpOffsetCloseToday     = "3"
+	ctpOffsetCloseYesterday = "4"
+
+	ctpPriceLimit = "2"
+
+	ctpTimeIOC = "1"
+	ctpTimeGFD = "3"
+
+	ctpVolumeAny      = "1"
+	ctpVolumeComplete = "3"
+
+	ctpPositionShort = "3"
+
+	ctpSubmitInsertRejected = "4"
+)
+
+// CTPBroker 通过 CTP 网关交易 A 股和国内期货。网关（ctp-gateway/gateway.py）是用 openctp 的 CTP 交易接口绑定
+// 连接交易前置，把 ReqOrderInsert、ReqOrderAction 和资金、持仓、报单、成交、行情查询封装为 HTTP JSON 接口的本地进程，
+// 字段沿用 CTP 结构体的字段名：
+//
+//	POST /login          ReqUserLogin（含穿透式认证），返回 TradingDay、FrontID、SessionID、MaxOrderRef
+//	GET  /status         前置连接和登录状态
+//	POST /orders         InputOrder
+//	POST /orders/cancel  InputOrderAction
+//	GET  /orders         当日报单
+//	GET  /trades         当日成交
+//	GET  /account        TradingAccount
+//	GET  /positions      InvestorPosition（附带合约乘数 VolumeMultiple）
+//	GET  /quotes/{id}    DepthMarketData
+//
+// 下单前按国内市场规则检查：股票 T+1（当日买入的股份次日才能卖出）、按一手 100 股取整、委托价不超出涨跌停价；
+// 市价单按涨停价（买）或跌停价（卖）转为即时成交剩余撤销的限价单；期货按持仓自动选择开平，上期所和能源中心区分平今、平昨
+type CTPBroker struct {
+	name         string
+	config       config.CTPConfig
+	precision    *money.PrecisionTable
+	credentials  CredentialSource
+	httpClient   *resty.Client
+	pollInterval time.Duration
+	stSymbols    map[string]bool
+
+	tradingDay  string
+	frontID     int
+	sessionID   int
+	orderRef    int
+	orders      map[string]Order  // 订单ID（FrontID:SessionID:OrderRef）-> 订单
+	clientIDs   map[string]string // 客户端订单号 -> 订单ID
+	callbacks   []func(Order)
+	isConnected bool
+	stopChan    chan struct{}
+	wg          sync.WaitGroup
+	mutex       sync.Mutex
+}
+
+// NewCTPBroker 创建 CTP 经纪商
+func NewCTPBroker(name string, cfg config.CTPConfig, precision *money.PrecisionTable, credentials CredentialSource) *CTPBroker {
+	gatewayURL := strings.TrimRight(cfg.GatewayURL, "/")
+	if gatewayURL == "" {
+		gatewayURL = defaultCTPGatewayURL
+	}
+	pollInterval := cfg.PollInterval
+	if pollInterval <= 0 {
+		pollInterval = defaultCTPPollInterval
+	}
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = defaultCTPTimeout
+	}
+
+	client := resty.New()
+	client.SetBaseURL(gatewayURL)
+	client.SetTimeout(timeout)
+	client.SetHeader("Content-Type", "application/json")
+	client.SetHeader("Accept", "application/json")
+
+	stSymbols := make(map[string]bool, len(cfg.STSymbols))
+	for _, symbol := range cfg.STSymbols {
+		if instrument, err := ParseCNSymbol(symbol); err == nil {
+			stSymbols[instrument.Code] = true
+		}
+	}
+
+	return &CTPBroker{
+		name:         name,
+		config:       cfg,
+		precision:    precision,
+		credentials:  credentials,
+		httpClient:   client,
+		pollInterval: pollInterval,
+		stSymbols:    stSymbols,
+		orders:       make(map[string]Order),
+		clientIDs:    make(map[string]string),
+	}
+}
+
+// ctpResponse 网关应答中的 CTP 错误信息（RspInfo）
+type ctpResponse struct {
+	ErrorID  int    `json:"ErrorID"`
+	ErrorMsg string `json:"ErrorMsg"`
+}
+
+// ctpLogin 登录应答
+type ctpLogin struct {
+	ctpResponse
+	TradingDay  string `json:"TradingDay"`
+	FrontID     int    `json:"FrontID"`
+	SessionID   int    `json:"SessionID"`
+	MaxOrderRef string `json:"MaxOrderRef"`
+}
+
+// ctpStatus 网关状态
+type ctpStatus struct {
+	Connected bool   `json:"Connected"`
+	LoggedIn  bool   `json:"LoggedIn"`
+	Message   string `json:"Message"`
+}
+
+// ctpInputOrder 报单录入请求
+type ctpInputOrder struct {
+	InstrumentID        string  `json:"InstrumentID"`
+	ExchangeID          string  `json:"ExchangeID"`
+	OrderRef            string  `json:"OrderRef"`
+	Direction           string  `json:"Direction"`
+	CombOffsetFlag      string  `json:"CombOffsetFlag"`
+	CombHedgeFlag       string  `json:"CombHedgeFlag"`
+	OrderPriceType      string  `json:"OrderPriceType"`
+	LimitPrice          float64 `json:"LimitPrice"`
+	VolumeTotalOriginal int64   `json:"VolumeTotalOriginal"`
+	TimeCondition       string  `json:"TimeCondition"`
+	VolumeCondition     string  `json:"VolumeCondition"`
+	MinVolume           int64   `json:"MinVolume"`
+	ContingentCondition string  `json:"ContingentCondition"`
+	ForceCloseReason    string  `json:"ForceCloseReason"`
+}
+
+// ctpOrder 报单
+type ctpOrder struct {
+	InstrumentID        string      `json:"InstrumentID"`
+	ExchangeID          string      `json:"ExchangeID"`
+	OrderRef            string      `json:"OrderRef"`
+	FrontID             int         `json:"FrontID"`
+	SessionID           int         `json:"SessionID"`
+	OrderSysID          string      `json:"OrderSysID"`
+	Direction           string      `json:"Direction"`
+	CombOffsetFlag      string      `json:"CombOffsetFlag"`
+	OrderPriceType      string      `json:"OrderPriceType"`
+	LimitPrice          json.Number `json:"LimitPrice"`
+	VolumeTotalOriginal json.Number `json:"VolumeTotalOriginal"`
+	VolumeTraded        json.Number `json:"VolumeTraded"`
+	OrderStatus         string      `json:"OrderStatus"`
+	OrderSubmitStatus   string      `json:"OrderSubmitStatus"`
+	StatusMsg           string      `json:"StatusMsg"`
+	InsertDate          string      `json:"InsertDate"`
+	InsertTime          string      `json:"InsertTime"`
+}
+
+// ctpTrade 成交
+type ctpTrade struct {
+	TradeID      string      `json:"TradeID"`
+	OrderRef     string      `json:"OrderRef"`
+	OrderSysID   string      `json:"OrderSysID"`
+	InstrumentID string      `json:"InstrumentID"`
+	ExchangeID   string      `json:"ExchangeID"`
+	Direction    string      `json:"Direction"`
+	OffsetFlag   string      `json:"OffsetFlag"`
+	Price        json.Number `json:"Price"`
+	Volume       json.Number `json:"Volume"`
+	TradeDate    string      `json:"TradeDate"`
+	TradeTime    string      `json:"TradeTime"`
+}
+
+// ctpAccount 资金账户
+type ctpAccount struct {
+	Balance        json.Number `json:"Balance"`
+	Available      json.Number `json:"Available"`
+	CloseProfit    json.Number `json:"CloseProfit"`
+	PositionProfit json.Number `json:"PositionProfit"`
+}
+
+// ctpPosition 投资者持仓，多空分别一条
+type ctpPosition struct {
+	InstrumentID   string      `json:"InstrumentID"`
+	ExchangeID     string      `json:"ExchangeID"`
+	PosiDirection  string      `json:"PosiDirection"`
+	Position       json.Number `json:"Position"`
+	TodayPosition  json.Number `json:"TodayPosition"`
+	OpenCost       json.Number `json:"OpenCost"`
+	PositionProfit json.Number `json:"PositionProfit"`
+	CloseProfit    json.Number `json:"CloseProfit"`
+	VolumeMultiple json.Number `json:"VolumeMultiple"`
+}
+
+// ctpQuote 行情快照
+type ctpQuote struct {
+	LastPrice       json.Number `json:"LastPrice"`
+	PreClosePrice   json.Number `json:"PreClosePrice"`
+	UpperLimitPrice json.Number `json:"UpperLimitPrice"`
+	LowerLimitPrice json.Number `json:"LowerLimitPrice"`
+}
+
+// ctpHolding 一个合约一个方向的持仓：总量和其中今仓
+type ctpHolding struct {
+	total decimal.Decimal
+	today decimal.Decimal
+}
+
+// ctpNumber 解析网关返回的数字，缺失或无效时为0
+func ctpNumber(n json.Number) decimal.Decimal {
+	return decimalOrZero(n.String())
+}
+
+// mapCTPOrderStatus 将报单状态映射为系统订单状态
+func mapCTPOrderStatus(order ctpOrder) OrderStatus {
+	if order.OrderSubmitStatus == ctpSubmitInsertRejected {
+		return Rejected
+	}
+	switch order.OrderStatus {
+	case "0":
+		return Filled
+	case "1":
+		return PartiallyFilled
+	case "2", "5":
+		// 部分成交不在队列中（即时成交剩余撤销）和已撤单
+		return Cancelled
+	case "3", "b", "c":
+		return Submitted
+	case "4":
+		return Rejected
+	default:
+		return Pending
+	}
+}
+
+// mapCTPSide 将买卖方向映射为系统订单方向
+func mapCTPSide(direction string) OrderSide {
+	if direction == ctpDirectionSell {
+		return SellSide
+	}
+	return BuySide
+}
+
+// ctpTimeCondition 订单有效期对应的有效期类型和成交量类型；国内交易所不支持撤销前有效，按当日有效处理
+func ctpTimeCondition(tif TimeInForce) (string, string) {
+	switch tif {
+	case IOC:
+		return ctpTimeIOC, ctpVolumeAny
+	case FOK:
+		return ctpTimeIOC, ctpVolumeComplete
+	default:
+		return ctpTimeGFD, ctpVolumeAny
+	}
+}
+
+// ctpTime 解析交易日和时间（20240105、09:30:00）
+func ctpTime(date, clock string) time.Time {
+	t, err := time.ParseInLocation("20060102 15:04:05", date+" "+clock, cnLocation)
+	if err != nil {
+		return time.Now()
+	}
+	return t
+}
+
+// cnLocation 国内交易所的时区
+var cnLocation = func() *time.Location {
+	location, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		return time.FixedZone("CST", 8*3600)
+	}
+	return location
+}()
+
+// do 发送请求并解析JSON响应；连接失败和网关未登录视为经纪商不可用，CTP 错误转为错误返回
+func (b *CTPBroker) do(method, path string, body, result interface{}) error {
+	request := b.httpClient.R()
+	if body != nil {
+		request.SetBody(body)
+	}
+	if result != nil {
+		request.SetResult(result).ForceContentType("application/json")
+	}
+
+	resp, err := request.Execute(method, path)
+	if err != nil {
+		return fmt.Errorf("请求 CTP 网关失败: %v: %w", err, ErrBrokerUnavailable)
+	}
+	if resp.StatusCode() == 401 || resp.StatusCode() == 503 {
+		return fmt.Errorf("CTP 网关未登录或前置未连接: %s: %w", strings.TrimSpace(resp.String()), ErrBrokerUnavailable)
+	}
+	if resp.IsError() {
+		var info ctpResponse
+		if json.Unmarshal(resp.Body(), &info) == nil && info.ErrorID != 0 {
+			return fmt.Errorf("CTP 错误 %d: %s", info.ErrorID, info.ErrorMsg)
+		}
+		return fmt.Errorf("CTP 网关返回错误: %d %s", resp.StatusCode(), strings.TrimSpace(resp.String()))
+	}
+	return nil
+}
+
+// checkConnected 检查是否已连接
+func (b *CTPBroker) checkConnected() error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if !b.isConnected {
+		return fmt.Errorf("经纪商未连接: %w", ErrBrokerUnavailable)
+	}
+	return nil
+}
+
+// Connect 登录 CTP（含穿透式认证）并启动订单状态轮询
+func (b *CTPBroker) Connect() error {
+	log.Printf("连接到 CTP 网关: %s, 经纪商代码=%s, 投资者=%s", b.name, b.config.BrokerID, b.config.UserID)
+
+	authCode, password := "", ""
+	if b.credentials != nil {
+		var err error
+		if authCode, password, err = b.credentials(); err != nil {
+			return fmt.Errorf("获取 CTP 登录凭证失败: %w", err)
+		}
+	}
+
+	var login ctpLogin
+	err := b.do("POST", "/login", map[string]string{
+		"BrokerID": b.config.BrokerID,
+		"UserID":   b.config.UserID,
+		"Password": password,
+		"AppID":    b.config.AppID,
+		"AuthCode": authCode,
+	}, &login)
+	if err != nil {
+		return fmt.Errorf("CTP 登录失败: %w", err)
+	}
+	if login.ErrorID != 0 {
+		return fmt.Errorf("CTP 登录失败: 错误 %d: %s", login.ErrorID, login.ErrorMsg)
+	}
+	maxOrderRef, _ := strconv.Atoi(strings.TrimSpace(login.MaxOrderRef))
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.tradingDay = login.TradingDay
+	b.frontID = login.FrontID
+	b.sessionID = login.SessionID
+	if maxOrderRef > b.orderRef {
+		b.orderRef = maxOrderRef
+	}
+	log.Printf("CTP 已登录: %s, 交易日=%s, FrontID=%d, SessionID=%d", b.name, login.TradingDay, login.FrontID, login.SessionID)
+	if b.isConnected {
+		return nil
+	}
+	b.isConnected = true
+	b.stopChan = make(chan struct{})
+	b.wg.Add(1)
+	go b.pollOrders()
+	return nil
+}
+
+// Disconnect 停止订单轮询（不登出网关会话）
+func (b *CTPBroker) Disconnect() error {
+	b.mutex.Lock()
+	if !b.isConnected {
+		b.mutex.Unlock()
+		return nil
+	}
+	b.isConnected = false
+	close(b.stopChan)
+	b.mutex.Unlock()
+
+	b.wg.Wait()
+	log.Printf("断开 CTP 连接: %s", b.name)
+	return nil
+}
+
+// Ping 健康检查：网关已连接交易前置并保持登录
+func (b *CTPBroker) Ping() error {
+	if err := b.checkConnected(); err != nil {
+		return err
+	}
+	var status ctpStatus
+	if err := b.do("GET", "/status", nil, &status); err != nil {
+		return err
+	}
+	if !status.Connected || !status.LoggedIn {
+		return fmt.Errorf("CTP 会话已失效（connected=%v, logged_in=%v）%s: %w",
+			status.Connected, status.LoggedIn, status.Message, ErrBrokerUnavailable)
+	}
+	return nil
+}
+
+// OnOrderUpdate 注册订单状态变化回调
+func (b *CTPBroker) OnOrderUpdate(callback func(Order)) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.callbacks = append(b.callbacks, callback)
+}
+
+// ctpOrderID 由 FrontID、SessionID 和 OrderRef 组成的订单ID，交易日内唯一
+func ctpOrderID(frontID, sessionID int, orderRef string) string {
+	return fmt.Sprintf("%d:%d:%s", frontID, sessionID, strings.TrimSpace(orderRef))
+}
+
+// quote 查询合约的行情快照：最新价、前收盘价和涨跌停价
+func (b *CTPBroker) quote(instrument CNInstrument) (ctpQuote, error) {
+	var quote ctpQuote
+	if err := b.do("GET", "/quotes/"+url.PathEscape(instrument.Code), nil, &quote); err != nil {
+		return quote, fmt.Errorf("查询 %s 行情失败: %w", instrument.Symbol(), err)
+	}
+	return quote, nil
+}
+
+// priceLimits 合约的跌停价和涨停价：优先使用行情中的涨跌停价，股票缺失时按前收盘价和板块幅度计算
+func (b *CTPBroker) priceLimits(instrument CNInstrument, quote ctpQuote) (decimal.Decimal, decimal.Decimal) {
+	lower, upper := ctpNumber(quote.LowerLimitPrice), ctpNumber(quote.UpperLimitPrice)
+	if upper.IsPositive() && lower.IsPositive() {
+		return lower, upper
+	}
+	previousClose := ctpNumber(quote.PreClosePrice)
+	if !instrument.IsEquity() || !previousClose.IsPositive() {
+		return lower, upper
+	}
+	return PriceLimits(previousClose, PriceLimitRatio(instrument.Board(), b.stSymbols[instrument.Code]))
+}
+
+// holdings 合约的多头和空头持仓
+func (b *CTPBroker) holdings(instrument CNInstrument) (long, short ctpHolding, err error) {
+	var items []ctpPosition
+	if err := b.do("GET", "/positions", nil, &items); err != nil {
+		return long, short, fmt.Errorf("获取持仓失败: %w", err)
+	}
+	for _, item := range items {
+		if item.InstrumentID != instrument.Code || (item.ExchangeID != "" && item.ExchangeID != instrument.Exchange) {
+			continue
+		}
+		holding := ctpHolding{total: ctpNumber(item.Position), today: ctpNumber(item.TodayPosition)}
+		if item.PosiDirection == ctpPositionShort {
+			short.total = short.total.Add(holding.total)
+			short.today = short.today.Add(holding.today)
+		} else {
+			long.total = long.total.Add(holding.total)
+			long.today = long.today.Add(holding.today)
+		}
+	}
+	return long, short, nil
+}
+
+// pendingCloses 本地已知的未完成平仓（股票卖出）委托的剩余数量，T+1 可卖数量需扣除
+func (b *CTPBroker) pendingCloses(instrument CNInstrument, side OrderSide) decimal.Decimal {
+	symbol := instrument.Symbol()
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	total := decimal.Zero
+	for _, order := range b.orders {
+		if order.Symbol == symbol && order.Side == side && order.Status.IsOpen() {
+			total = total.Add(order.Quantity.Sub(order.FilledQty))
+		}
+	}
+	return total
+}
+
+// offsetFor 期货订单的开平标志：有反向持仓时平仓（上期所和能源中心今仓用平今），否则开仓
+func (b *CTPBroker) offsetFor(instrument CNInstrument, order Order) (string, error) {
+	long, short, err := b.holdings(instrument)
+	if err != nil {
+		return "", err
+	}
+	opposite := long
+	if order.Side == BuySide {
+		opposite = short
+	}
+	closable := opposite.total.Sub(b.pendingCloses(instrument, order.Side))
+
+	switch {
+	case !opposite.total.IsPositive():
+		if order.ReduceOnly {
+			return "", fmt.Errorf("%s 没有可平的持仓（只减仓订单）", instrument.Symbol())
+		}
+		return ctpOffsetOpen, nil
+	case order.Quantity.GreaterThan(closable):
+		return "", fmt.Errorf("%s 委托数量 %s 超过可平持仓 %s，平仓和反向开仓需分开下单",
+			instrument.Symbol(), order.Quantity, closable)
+	}
+
+	if instrument.Exchange != ExchangeSHFE && instrument.Exchange != ExchangeINE {
+		return ctpOffsetClose, nil
+	}
+	yesterday := opposite.total.Sub(opposite.today)
+	switch {
+	case yesterday.GreaterThanOrEqual(order.Quantity):
+		return ctpOffsetCloseYesterday, nil
+	case yesterday.IsZero():
+		return ctpOffsetCloseToday, nil
+	default:
+		return "", fmt.Errorf("%s 昨仓 %s 不足 %s，上期所需分别平昨和平今", instrument.Symbol(), yesterday, order.Quantity)
+	}
+}
+
+// PlaceOrder 按国内市场规则检查并调整订单后报单
+func (b *CTPBroker) PlaceOrder(order Order) (*Order, error) {
+	if err := b.checkConnected(); err != nil {
+		return nil, err
+	}
+	if order.Type == StopOrder {
+		return nil, fmt.Errorf("CTP 不支持止损单")
+	}
+
+	instrument, err := ParseCNSymbol(order.Symbol)
+	if err != nil {
+		return nil, err
+	}
+	order.Symbol = instrument.Symbol()
+
+	if order.ClientOrderID != "" {
+		b.mutex.Lock()
+		id, exists := b.clientIDs[order.ClientOrderID]
+		existing := b.orders[id]
+		b.mutex.Unlock()
+		if exists {
+			return &existing, nil
+		}
+	}
+
+	precision := b.precision.For(order.Symbol)
+	offset := ctpOffsetOpen
+	if instrument.IsEquity() {
+		sellable := decimal.Zero
+		if order.Side == SellSide {
+			// T+1：当日买入的股份不能卖出，融券卖出不在支持范围内
+			long, _, err := b.holdings(instrument)
+			if err != nil {
+				return nil, err
+			}
+			sellable = long.total.Sub(long.today).Sub(b.pendingCloses(instrument, SellSide))
+			if !sellable.IsPositive() {
+				return nil, fmt.Errorf("%s 没有可卖股份（持仓 %s，其中今日买入 %s）", order.Symbol, long.total, long.today)
+			}
+			if order.Quantity.GreaterThan(sellable) {
+				log.Printf("%s 卖出数量 %s 超过可卖数量 %s（T+1），按可卖数量卖出", order.Symbol, order.Quantity, sellable)
+				order.Quantity = sellable
+			}
+			offset = ctpOffsetClose
+		}
+		quantity, err := RoundAShareQuantity(instrument.Board(), order.Side, order.Quantity, sellable)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", order.Symbol, err)
+		}
+		if !quantity.Equal(order.Quantity) {
+			log.Printf("%s 委托数量 %s 按申报规则调整为 %s", order.Symbol, order.Quantity, quantity)
+		}
+		order.Quantity = quantity
+	} else {
+		order.Quantity = order.Quantity.Truncate(0)
+		if !order.Quantity.IsPositive() {
+			return nil, fmt.Errorf("%s 期货委托数量不足 1 手", order.Symbol)
+		}
+		if offset, err = b.offsetFor(instrument, order); err != nil {
+			return nil, err
+		}
+	}
+
+	quote, err := b.quote(instrument)
+	if err != nil {
+		return nil, err
+	}
+	lower, upper := b.priceLimits(instrument, quote)
+
+	timeCondition, volumeCondition := ctpTimeCondition(order.TimeInForce)
+	price := precision.RoundPrice(order.Price)
+	if order.Type == MarketOrder {
+		// 以涨跌停价作为保护价，未成交部分立即撤销
+		price = upper
+		if order.Side == SellSide {
+			price = lower
+		}
+		if !price.IsPositive() {
+			return nil, fmt.Errorf("%s 缺少涨跌停价，无法转换市价单", order.Symbol)
+		}
+		timeCondition = ctpTimeIOC
+	} else if err := CheckPriceLimit(order.Symbol, price, lower, upper); err != nil {
+		return nil, err
+	}
+
+	b.mutex.Lock()
+	b.orderRef++
+	orderRef := strconv.Itoa(b.orderRef)
+	order.ID = ctpOrderID(b.frontID, b.sessionID, orderRef)
+	b.mutex.Unlock()
+
+	direction := ctpDirectionBuy
+	if order.Side == SellSide {
+		direction = ctpDirectionSell
+	}
+	input := ctpInputOrder{
+		InstrumentID:        instrument.Code,
+		ExchangeID:          instrument.Exchange,
+		OrderRef:            orderRef,
+		Direction:           direction,
+		CombOffsetFlag:      offset,
+		CombHedgeFlag:       "1",
+		OrderPriceType:      ctpPriceLimit,
+		LimitPrice:          money.Float(price),
+		VolumeTotalOriginal: order.Quantity.IntPart(),
+		TimeCondition:       timeCondition,
+		VolumeCondition:     volumeCondition,
+		MinVolume:           1,
+		ContingentCondition: "1",
+		ForceCloseReason:    "0",
+	}
+	if volumeCondition == ctpVolumeComplete {
+		input.MinVolume = input.VolumeTotalOriginal
+	}
+
+	var response ctpResponse
+	if err := b.do("POST", "/orders", input, &response); err != nil {
+		if order.ClientOrderID != "" && errors.Is(err, ErrBrokerUnavailable) {
+			return nil, &OrderSubmitError{ClientOrderID: order.ClientOrderID, Err: err}
+		}
+		return nil, fmt.Errorf("报单失败: %w", err)
+	}
+	if response.ErrorID != 0 {
+		return nil, fmt.Errorf("CTP 拒绝报单: 错误 %d: %s", response.ErrorID, response.ErrorMsg)
+	}
+
+	order.Status = Submitted
+	order.AccountName = b.name
+	order.CreateTime = time.Now()
+	order.UpdateTime = order.CreateTime
+	b.mutex.Lock()
+	b.orders[order.ID] = order
+	if order.ClientOrderID != "" {
+		b.clientIDs[order.ClientOrderID] = order.ID
+	}
+	b.mutex.Unlock()
+
+	log.Printf("CTP 报单已提交: 订单ID=%s, 标的=%s, 方向=%s, 开平=%s, 数量=%s, 价格=%s",
+		order.ID, order.Symbol, order.Side, offset, order.Quantity, price)
+	return &order, nil
+}
+
+// CancelOrder 按 FrontID、SessionID 和 OrderRef 撤单
+func (b *CTPBroker) CancelOrder(orderID string) error {
+	if err := b.checkConnected(); err != nil {
+		return err
+	}
+
+	parts := strings.SplitN(orderID, ":", 3)
+	if len(parts) != 3 {
+		return fmt.Errorf("订单 %s: %w", orderID, ErrOrderNotFound)
+	}
+	frontID, _ := strconv.Atoi(parts[0])
+	sessionID, _ := strconv.Atoi(parts[1])
+
+	b.mutex.Lock()
+	order, exists := b.orders[orderID]
+	b.mutex.Unlock()
+	if !exists {
+		return fmt.Errorf("订单 %s: %w", orderID, ErrOrderNotFound)
+	}
+	instrument, err := ParseCNSymbol(order.Symbol)
+	if err != nil {
+		return err
+	}
+
+	var response ctpResponse
+	err = b.do("POST", "/orders/cancel", map[string]interface{}{
+		"OrderRef":     parts[2],
+		"FrontID":      frontID,
+		"SessionID":    sessionID,
+		"InstrumentID": instrument.Code,
+		"ExchangeID":   instrument.Exchange,
+		"ActionFlag":   "0",
+	}, &response)
+	if err != nil {
+		return fmt.Errorf("撤单失败: %w", err)
+	}
+	if response.ErrorID != 0 {
+		return fmt.Errorf("CTP 拒绝撤单: 错误 %d: %s", response.ErrorID, response.ErrorMsg)
+	}
+	return nil
+}
+
+// fetchTrades 从网关获取当日成交
+func (b *CTPBroker) fetchTrades() ([]ctpTrade, error) {
+	var items []ctpTrade
+	if err := b.do("GET", "/trades", nil, &items); err != nil {
+		return nil, fmt.Errorf("获取成交记录失败: %w", err)
+	}
+	return items, nil
+}
+
+// fetchOrders 从网关获取当日报单并与本地记录合并，成交均价按当日成交计算（CTP 报单不含均价）
+func (b *CTPBroker) fetchOrders() ([]Order, error) {
+	var items []ctpOrder
+	if err := b.do("GET", "/orders", nil, &items); err != nil {
+		return nil, fmt.Errorf("获取报单失败: %w", err)
+	}
+	trades, err := b.fetchTrades()
+	if err != nil {
+		return nil, err
+	}
+	amounts := make(map[string]decimal.Decimal)
+	volumes := make(map[string]decimal.Decimal)
+	for _, trade := range trades {
+		key := trade.ExchangeID + ":" + strings.TrimSpace(trade.OrderSysID)
+		volume := ctpNumber(trade.Volume)
+		amounts[key] = amounts[key].Add(ctpNumber(trade.Price).Mul(volume))
+		volumes[key] = volumes[key].Add(volume)
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	orders := make([]Order, 0, len(items))
+	for _, item := range items {
+		id := ctpOrderID(item.FrontID, item.SessionID, item.OrderRef)
+		order, exists := b.orders[id]
+		if !exists {
+			order = Order{
+				ID:          id,
+				Symbol:      CNInstrument{Code: item.InstrumentID, Exchange: item.ExchangeID}.Symbol(),
+				Side:        mapCTPSide(item.Direction),
+				Type:        LimitOrder,
+				Quantity:    ctpNumber(item.VolumeTotalOriginal),
+				Price:       ctpNumber(item.LimitPrice),
+				AccountName: b.name,
+				CreateTime:  ctpTime(item.InsertDate, item.InsertTime),
+			}
+		}
+		status, filled := mapCTPOrderStatus(item), ctpNumber(item.VolumeTraded)
+		if status != order.Status || !filled.Equal(order.FilledQty) {
+			order.UpdateTime = time.Now()
+		}
+		order.Status = status
+		order.FilledQty = filled
+		key := item.ExchangeID + ":" + strings.TrimSpace(item.OrderSysID)
+		if volume := volumes[key]; volume.IsPositive() {
+			order.AvgPrice = amounts[key].Div(volume)
+		}
+		if order.Status == Rejected && item.StatusMsg != "" && order.Status != b.orders[id].Status {
+			log.Printf("CTP 报单被拒绝: 订单ID=%s, 原因=%s", id, item.StatusMsg)
+		}
+		orders = append(orders, order)
+	}
+	return orders, nil
+}
+
+// pollOrders 定期查询报单状态，状态或成交数量变化时触发回调
+func (b *CTPBroker) pollOrders() {
+	defer b.wg.Done()
+
+	ticker := time.NewTicker(b.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-b.stopChan:
+			return
+		case <-ticker.C:
+			orders, err := b.fetchOrders()
+			if err != nil {
+				log.Printf("CTP 报单轮询失败: %v", err)
+				continue
+			}
+
+			var changed []Order
+			b.mutex.Lock()
+			for _, order := range orders {
+				previous, exists := b.orders[order.ID]
+				if exists && previous.Status == order.Status && previous.FilledQty.Equal(order.FilledQty) {
+					continue
+				}
+				b.orders[order.ID] = order
+				changed = append(changed, order)
+			}
+			callbacks := append([]func(Order){}, b.callbacks...)
+			b.mutex.Unlock()
+
+			for _, order := range changed {
+				log.Printf("CTP 订单状态更新: 订单ID=%s, 状态=%s, 已成交=%s", order.ID, order.Status, order.FilledQty)
+				for _, callback := range callbacks {
+					callback(order)
+				}
+			}
+		}
+	}
+}
+
+// GetOrder 查询订单
+func (b *CTPBroker) GetOrder(orderID string) (*Order, error) {
+	orders, err := b.GetOrders("", "")
+	if err != nil {
+		return nil, err
+	}
+	for _, order := range orders {
+		if order.ID == orderID {
+			return &order, nil
+		}
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if order, exists := b.orders[orderID]; exists {
+		return &order, nil
+	}
+	return nil, fmt.Errorf("订单 %s: %w", orderID, ErrOrderNotFound)
+}
+
+// GetOrderByClientID 按客户端订单号查询本会话提交的订单（CTP 的 OrderRef 为递增编号，不能携带客户端订单号）
+func (b *CTPBroker) GetOrderByClientID(clientOrderID string) (*Order, error) {
+	b.mutex.Lock()
+	id, exists := b.clientIDs[clientOrderID]
+	b.mutex.Unlock()
+	if !exists {
+		return nil, fmt.Errorf("客户端订单号 %s: %w", clientOrderID, ErrOrderNotFound)
+	}
+	return b.GetOrder(id)
+}
+
+// GetOrders 查询订单列表
+func (b *CTPBroker) GetOrders(symbol string, status OrderStatus) ([]Order, error) {
+	if err := b.checkConnected(); err != nil {
+		return nil, err
+	}
+
+	orders, err := b.fetchOrders()
+	if err != nil {
+		return nil, err
+	}
+	if symbol != "" {
+		if instrument, err := ParseCNSymbol(symbol); err == nil {
+			symbol = instrument.Symbol()
+		}
+	}
+
+	var result []Order
+	for _, order := range orders {
+		if symbol != "" && order.Symbol != symbol {
+			continue
+		}
+		if status != "" && order.Status != status {
+			continue
+		}
+		result = append(result, order)
+	}
+	return result, nil
+}
+
+// GetBalance 获取可用资金
+func (b *CTPBroker) GetBalance() (decimal.Decimal, error) {
+	if err := b.checkConnected(); err != nil {
+		return decimal.Zero, err
+	}
+
+	var account ctpAccount
+	if err := b.do("GET", "/account", nil, &account); err != nil {
+		return decimal.Zero, fmt.Errorf("获取资金账户失败: %w", err)
+	}
+	return b.precision.Defaults().RoundAmount(ctpNumber(account.Available)), nil
+}
+
+// GetPositions 获取持仓：同一合约的多空持仓合并为净持仓（空头为负数），成本按开仓成本和合约乘数折算
+func (b *CTPBroker) GetPositions() (map[string]Position, error) {
+	if err := b.checkConnected(); err != nil {
+		return nil, err
+	}
+
+	var items []ctpPosition
+	if err := b.do("GET", "/positions", nil, &items); err != nil {
+		return nil, fmt.Errorf("获取持仓失败: %w", err)
+	}
+
+	costs := make(map[string]decimal.Decimal)
+	positions := make(map[string]Position)
+	for _, item := range items {
+		quantity := ctpNumber(item.Position)
+		if quantity.IsZero() {
+			continue
+		}
+		multiplier := ctpNumber(item.VolumeMultiple)
+		if !multiplier.IsPositive() {
+			multiplier = decimal.NewFromInt(1)
+		}
+		cost := ctpNumber(item.OpenCost).Div(multiplier)
+		if item.PosiDirection == ctpPositionShort {
+			quantity = quantity.Neg()
+			cost = cost.Neg()
+		}
+
+		symbol := CNInstrument{Code: item.InstrumentID, Exchange: item.ExchangeID}.Symbol()
+		position := positions[symbol]
+		position.Symbol = symbol
+		position.Quantity = position.Quantity.Add(quantity)
+		position.UnrealizedPL = position.UnrealizedPL.Add(ctpNumber(item.PositionProfit))
+		position.RealizedPL = position.RealizedPL.Add(ctpNumber(item.CloseProfit))
+		position.UpdateTime = time.Now()
+		costs[symbol] = costs[symbol].Add(cost)
+		positions[symbol] = position
+	}
+
+	for symbol, position := range positions {
+		if position.Quantity.IsZero() {
+			delete(positions, symbol)
+			continue
+		}
+		position.AvgPrice = b.precision.For(symbol).RoundPrice(costs[symbol].Div(position.Quantity))
+		position.MarketValue = costs[symbol].Add(position.UnrealizedPL)
+		positions[symbol] = position
+	}
+	return positions, nil
+}
+
+// GetTrades 获取当日成交记录（CTP 成交回报不含手续费）
+func (b *CTPBroker) GetTrades(symbol string, limit int) ([]Trade, error) {
+	if err := b.checkConnected(); err != nil {
+		return nil, err
+	}
+
+	items, err := b.fetchTrades()
+	if err != nil {
+		return nil, err
+	}
+	if symbol != "" {
+		if instrument, err := ParseCNSymbol(symbol); err == nil {
+			symbol = instrument.Symbol()
+		}
+	}
+
+	b.mutex.Lock()
+	frontID, sessionID := b.frontID, b.sessionID
+	b.mutex.Unlock()
+
+	var trades []Trade
+	for _, item := range items {
+		tradeSymbol := CNInstrument{Code: item.InstrumentID, Exchange: item.ExchangeID}.Symbol()
+		if symbol != "" && tradeSymbol != symbol {
+			continue
+		}
+		trades = append(trades, Trade{
+			ID:          item.ExchangeID + ":" + strings.TrimSpace(item.TradeID),
+			OrderID:     ctpOrderID(frontID, sessionID, item.OrderRef),
+			Symbol:      tradeSymbol,
+			Side:        mapCTPSide(item.Direction),
+			Quantity:    ctpNumber(item.Volume),
+			Price:       ctpNumber(item.Price),
+			Timestamp:   ctpTime(item.TradeDate, item.TradeTime),
+			AccountName: b.name,
+		})
+	}
+
+	if limit > 0 && len(trades) > limit {
+		trades = trades[len(trades)-limit:]
+	}
+	return trades, nil
+}
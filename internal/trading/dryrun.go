@@ -0,0 +1,43 @@
+package trading
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"agent-quant-system/internal/audit"
+)
+
+// DryRun 是否为演练模式：行情、Agent、策略和风控照常运行，订单只记录到日志和审计日志，不发送到经纪商
+func (te *TradingEngine) DryRun() bool {
+	return te.currentConfig().Trading.DryRun
+}
+
+// dryRunOrder 演练模式下记录通过全部检查的订单并返回未发送的订单，不更新账户和持仓
+func (te *TradingEngine) dryRunOrder(order Order, accountName string) *Order {
+	order.ID = fmt.Sprintf("DRYRUN_%d", time.Now().UnixNano())
+	order.AccountName = accountName
+	order.Status = Pending
+	order.DryRun = true
+	order.CreateTime = time.Now()
+	order.UpdateTime = order.CreateTime
+
+	event := orderEvent(audit.KindSubmit, order, accountName)
+	event.Message = "演练模式，订单未发送到经纪商"
+	event.Details = map[string]interface{}{"dry_run": true, "type": string(order.Type), "time_in_force": string(order.TimeInForce)}
+	te.recordAudit(event)
+
+	log.Printf("演练模式，订单未发送到经纪商: 账户=%s, 策略=%s, 标的=%s, 方向=%s, 数量=%s, 价格=%s, 类型=%s, 客户端订单号=%s",
+		accountName, order.Strategy, order.Symbol, order.Side, order.Quantity, order.Price, order.Type, order.ClientOrderID)
+	return &order
+}
+
+// dryRunCancel 演练模式下只记录撤单，不发送到经纪商；非演练模式返回 false
+func (te *TradingEngine) dryRunCancel(order Order, accountName, reason string) bool {
+	if !te.DryRun() {
+		return false
+	}
+	te.auditCancel(order, accountName, reason+"（演练模式，未发送到经纪商）", nil)
+	log.Printf("演练模式，未撤销订单: 账户=%s, 订单ID=%s, 原因=%s", accountName, order.ID, reason)
+	return true
+}
@@ -3,64 +3,366 @@ package trading
 import (
 	"fmt"
 	"log"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"agent-quant-system/internal/account"
+	"agent-quant-system/internal/audit"
+	"agent-quant-system/internal/commission"
 	"agent-quant-system/internal/config"
+	"agent-quant-system/internal/instrument"
+	"agent-quant-system/internal/money"
+	"agent-quant-system/internal/notify"
+	"agent-quant-system/internal/sizing"
+	"agent-quant-system/internal/slippage"
 	"agent-quant-system/internal/strategy"
+	"agent-quant-system/internal/symbols"
+
+	"github.com/shopspring/decimal"
 )
 
 // TradingEngine 交易引擎
 type TradingEngine struct {
-	config         *config.Config
+	config         atomic.Pointer[config.Config] // 当前配置，热加载时整体替换，通过 currentConfig 读取
 	accountManager *account.AccountManager
 	brokers        map[string]BrokerAPI
+	orderQueues    map[string]*OrderQueue
+	riskManager    *RiskManager
+	approvals      *ApprovalManager
+	throttle       *OrderThrottle
+	allocator      *StrategyAllocator
+	exposureBook   *exposureBook // 通过组合敞口检查、尚未成交的订单
+	symbolLists    *SymbolLists
+	killSwitch     *KillSwitch
+	supervisor     *StrategySupervisor
+	promotion      *PromotionManager // 未启用晋级或纸面交易模式时为nil
+	leaderboard    *Leaderboard      // 未启用策略排行榜时为nil
+	notifier       *notify.Dispatcher
+	prices         PriceSource
+	journal        *TradeJournal
+	audit          *audit.Journal // 未配置审计日志时为nil
+	pnl            *PnLLedger
+	taxLots        *TaxLotBook
+	fills          *FillTracker
+	clientOrders   *ClientOrderBook
+	connections    *ConnectionSupervisor
+	grids          *GridManager
+	reconciled     *ReconciliationReport // 最近一次对账结果
+	commissions    map[string]commission.Model
+	instruments    *contractMultipliers         // 期货品种规格和合约乘数，未设置规格时所有标的按现货处理
+	sizing         atomic.Pointer[sizing.Table] // 开仓信号的仓位计算方式，为nil时使用策略给出的数量
+	symbols        *symbols.Mapper              // 标的代码转换，下单前统一为规范写法，发往经纪商时按经纪商写法转换
 	mutex          sync.RWMutex
 	isRunning      bool
+	stopped        bool // 已停止过，再次启动时需重新连接经纪商
+	stopChan       chan struct{}
 }
 
 // NewTradingEngine 创建交易引擎
 func NewTradingEngine(cfg *config.Config, accountManager *account.AccountManager) *TradingEngine {
 	engine := &TradingEngine{
-		config:         cfg,
 		accountManager: accountManager,
 		brokers:        make(map[string]BrokerAPI),
+		orderQueues:    make(map[string]*OrderQueue),
+		pnl:            NewPnLLedger(),
+		taxLots:        NewTaxLotBook(cfg.Trading.TaxLots),
+		fills:          NewFillTracker(),
+		exposureBook:   newExposureBook(),
+		clientOrders:   NewClientOrderBook(),
+		connections:    NewConnectionSupervisor(cfg.Trading.Connection),
+		grids:          NewGridManager(cfg.Trading.Grid.StateFile),
+		commissions:    accountCommissions(cfg),
+		symbols:        symbols.NewMapper(cfg.Symbols),
 		isRunning:      false,
 	}
+	engine.config.Store(cfg)
+	engine.sizing.Store(newSizingTable(cfg))
+
+	// 合约规格在重建盈亏账本之前加载，流水中期货、期权成交的盈亏按合约乘数计算；SetInstruments 可再替换
+	engine.instruments = &contractMultipliers{}
+	if registry, err := instrument.NewRegistry(cfg.Instruments); err != nil {
+		log.Printf("创建合约规格表失败，所有标的按现货处理: %v", err)
+	} else {
+		engine.instruments.registry.Store(registry)
+	}
+	engine.pnl.multipliers = engine.instruments
+	engine.taxLots.multipliers = engine.instruments
+
+	if cfg.Risk.Enabled {
+		engine.riskManager = NewRiskManagerFromConfig(cfg.Risk)
+		engine.applyRiskPresets(cfg.Risk)
+		for name, account := range cfg.Accounts {
+			if account.Margin.Enabled() {
+				engine.riskManager.SetAccountLeverage(name, account.Margin.Leverage)
+			}
+		}
+		engine.riskManager.multipliers = engine.instruments
+	}
+
+	if cfg.Trading.Approval.Enabled {
+		engine.approvals = NewApprovalManager(cfg.Trading.Approval)
+		engine.approvals.multipliers = engine.instruments
+	}
+
+	if cfg.Trading.Throttle.Enabled {
+		engine.throttle = NewOrderThrottle(cfg.Trading.Throttle)
+		engine.throttle.multipliers = engine.instruments
+	}
+
+	accountNames := make([]string, 0, len(cfg.Accounts))
+	for name := range cfg.Accounts {
+		accountNames = append(accountNames, name)
+	}
+	engine.allocator = NewStrategyAllocator(cfg.Strategy, accountNames)
+	engine.allocator.multipliers = engine.instruments
+	engine.symbolLists = NewSymbolLists(cfg.Risk)
+	engine.killSwitch = NewKillSwitch(cfg.Risk.KillSwitch)
+	engine.supervisor = NewStrategySupervisor(cfg.Risk.StrategySupervisor.StateFile)
+
+	if cfg.Trading.Promotion.Enabled && !cfg.Trading.Paper {
+		engine.promotion = NewPromotionManager(cfg, PriceSourceFunc(engine.latestPrice))
+	}
+
+	if cfg.Trading.Leaderboard.Enabled {
+		engine.leaderboard = NewLeaderboard(cfg.Trading.Leaderboard)
+	}
+
+	if cfg.Trading.JournalFile != "" {
+		journal, err := NewTradeJournal(cfg.Trading.JournalFile)
+		if err != nil {
+			log.Printf("打开成交流水失败，将不记录交易成本: %v", err)
+		} else {
+			engine.journal = journal
+		}
+	}
+	engine.loadPnLFromJournal()
+
+	if cfg.Trading.AuditFile != "" {
+		journal, err := audit.Open(cfg.Trading.AuditFile)
+		if err != nil {
+			log.Printf("打开审计日志失败，将不记录审计事件: %v", err)
+		} else {
+			engine.audit = journal
+		}
+	}
 
 	// 初始化经纪商连接
 	engine.initializeBrokers()
+	engine.loadAllocations()
 
 	return engine
 }
 
+// applyRiskPresets 按账户的资产类别设置风控预设的限制，未使用预设时不设置
+func (te *TradingEngine) applyRiskPresets(risk config.RiskConfig) {
+	if risk.Preset == "" {
+		return
+	}
+	for name, account := range te.currentConfig().Accounts {
+		limits := risk.Limits(account.AssetClass())
+		te.riskManager.SetAccountLimits(name, limits)
+		log.Printf("账户 %s 使用风控预设 %s (%s): 单笔仓位 %.0f%%, 总仓位 %.0f%%, 日亏损 %.0f%%, 回撤 %.0f%%, 默认止损 %.0f%%",
+			name, risk.Preset, account.AssetClass(), limits.MaxPositionSize*100, limits.MaxTotalExposure*100,
+			limits.MaxDailyLoss*100, limits.MaxDrawdown*100, limits.StopLossPercent)
+	}
+}
+
+// currentConfig 当前配置。热加载时整体替换为新的配置，返回的配置不会再被修改，
+// 同一次处理中需要多个配置项时应只读取一次
+func (te *TradingEngine) currentConfig() *config.Config {
+	return te.config.Load()
+}
+
+// SetConfig 热加载时替换配置，并按新的 risk.sizing 和 strategy.sizing 重新创建仓位计算方式；
+// cfg 发布后不能再修改
+func (te *TradingEngine) SetConfig(cfg *config.Config) {
+	te.config.Store(cfg)
+	te.ReloadSizing()
+}
+
+// ApplyRiskConfig 热加载风控配置：更新仓位和亏损限制、风控预设和交易名单。
+// 是否启用风控和紧急停止的配置不在此更新，需要重启
+func (te *TradingEngine) ApplyRiskConfig(risk config.RiskConfig) {
+	if te.riskManager != nil {
+		te.riskManager.UpdateLimits(risk)
+		te.applyRiskPresets(risk)
+	}
+	te.symbolLists.Reset(risk)
+}
+
 // initializeBrokers 初始化经纪商连接
 func (te *TradingEngine) initializeBrokers() {
+	cfg := te.currentConfig()
 	log.Printf("初始化经纪商连接")
 
-	for accountName, accountConfig := range te.config.Accounts {
+	if cfg.Trading.Paper {
+		log.Printf("纸面交易模式: 订单按实时报价模拟成交，不会发送到真实经纪商")
+	}
+	if cfg.Trading.DryRun {
+		log.Printf("演练模式: 订单只写入日志和审计日志，不会发送到经纪商，也不会撤销经纪商中的订单")
+	}
+
+	for accountName, accountConfig := range cfg.Accounts {
 		var broker BrokerAPI
 
-		switch accountConfig.BrokerType {
-		case "stock":
-			broker = NewMockStockBroker(accountName)
-		case "crypto":
-			broker = NewMockCryptoBroker(accountName)
+		switch {
+		case cfg.Trading.Paper:
+			paper := NewPaperBroker(accountName, money.FromFloat(accountConfig.StartingBalance()),
+				accountConfig.PrecisionTable(), PriceSourceFunc(te.latestPrice))
+			if model, err := slippage.New(cfg.Backtest.Slippage); err != nil {
+				log.Printf("创建滑点模型失败，纸面交易使用固定滑点: %v", err)
+			} else if model != nil {
+				paper.SetSlippageModel(model, te.averageVolume)
+			}
+			if margin := accountConfig.Margin; margin.Enabled() {
+				paper.SetMargin(margin)
+				log.Printf("纸面账户 %s 使用保证金交易: 杠杆=%.2f, 维持保证金率=%.2f%%, 年化利率=%.2f%%",
+					accountName, margin.Leverage, margin.MaintenanceMargin*100, margin.InterestRate*100)
+			}
+			broker = paper
+		case accountConfig.BrokerType == "stock":
+			broker = NewMockStockBroker(accountName, money.FromFloat(accountConfig.StartingBalance()), accountConfig.PrecisionTable(),
+				money.FromFloat(cfg.Trading.Simulation.FillRatio))
+		case accountConfig.BrokerType == "crypto":
+			broker = NewMockCryptoBroker(accountName, money.FromFloat(accountConfig.StartingBalance()), accountConfig.PrecisionTable(),
+				money.FromFloat(cfg.Trading.Simulation.FillRatio))
+		case accountConfig.BrokerType == "ibkr":
+			broker = NewIBKRBroker(accountName, accountConfig.IBKR, accountConfig.PrecisionTable())
+		case accountConfig.BrokerType == "coinbase":
+			broker = NewCoinbaseBroker(accountName, accountConfig.Coinbase, accountConfig.Currency,
+				accountConfig.PrecisionTable(), te.credentialSource(accountName))
+		case accountConfig.BrokerType == "bybit":
+			broker = NewBybitBroker(accountName, accountConfig.Bybit, accountConfig.PrecisionTable(), te.credentialSource(accountName))
+		case accountConfig.BrokerType == "ctp":
+			broker = NewCTPBroker(accountName, accountConfig.CTP, accountConfig.PrecisionTable(), te.credentialSource(accountName))
+		case accountConfig.BrokerType == "fix":
+			broker = NewFIXBroker(accountName, accountConfig.FIX, money.FromFloat(accountConfig.StartingBalance()),
+				accountConfig.PrecisionTable(), te.credentialSource(accountName))
 		default:
 			log.Printf("未知的经纪商类型: %s", accountConfig.BrokerType)
 			continue
 		}
 
-		// 连接经纪商
-		if err := broker.Connect(); err != nil {
-			log.Printf("连接经纪商 %s 失败: %v", accountName, err)
-			continue
+		// 模拟经纪商按实时报价撮合限价单和止损单
+		if simulator, ok := broker.(QuoteSimulator); ok {
+			simulator.SetPriceSource(PriceSourceFunc(te.latestPrice))
 		}
 
+		if model, exists := te.commissions[accountName]; exists {
+			if simulator, ok := broker.(CommissionSimulator); ok {
+				simulator.SetCommissionModel(model)
+			}
+			log.Printf("账户 %s 使用佣金模型: %s", accountName, model.Name())
+		}
+
+		// 连接失败的经纪商仍然保留，交易引擎运行后由连接监控按退避间隔重连
 		te.brokers[accountName] = broker
-		log.Printf("已连接经纪商: %s (%s)", accountName, accountConfig.BrokerType)
+		if venue := te.symbolVenue(accountName); venue != "" {
+			te.brokers[accountName] = &symbolMappedBroker{BrokerAPI: broker, mapper: te.symbols, venue: venue}
+			log.Printf("经纪商 %s 按 %s 的写法转换标的代码", accountName, venue)
+		}
+		if accountConfig.RateLimit.Enabled() {
+			limiter := NewBrokerRateLimiter(accountName, accountConfig.RateLimit)
+			te.brokers[accountName] = &rateLimitedBroker{BrokerAPI: te.brokers[accountName], limiter: limiter}
+			log.Printf("经纪商 %s 启用接口频率限制: 下单 %.2f 次/秒, 查询 %.0f 次/分钟",
+				accountName, accountConfig.RateLimit.OrdersPerSecond, accountConfig.RateLimit.QueriesPerMinute)
+		}
+		te.orderQueues[accountName] = NewOrderQueue(accountName,
+			cfg.Trading.OrderConcurrency, cfg.Trading.OrderQueueSize, te.ExecuteTrade)
+		connected := true
+		if err := broker.Connect(); err != nil {
+			log.Printf("连接经纪商 %s 失败，将自动重连: %v", accountName, err)
+			te.connections.markDown(accountName, err)
+			connected = false
+		} else {
+			te.connections.markConnected(accountName)
+			log.Printf("已连接经纪商: %s (%s)", accountName, accountConfig.BrokerType)
+		}
+
+		// 永续合约经纪商：资金费结算按持仓分摊记入各策略的盈亏
+		if notifier, ok := broker.(FundingNotifier); ok {
+			name := accountName
+			notifier.OnFunding(func(payment FundingPayment) {
+				symbol := te.canonicalSymbol(name, payment.Symbol)
+				if err := te.RecordFunding(name, "", symbol, payment.Amount); err != nil {
+					log.Printf("记录资金费用失败: %v", err)
+				}
+			})
+		}
+
+		// 支持订单推送的经纪商：成交时同步余额和持仓
+		if notifier, ok := broker.(OrderUpdateNotifier); ok {
+			name := accountName
+			notifier.OnOrderUpdate(func(order Order) {
+				order.Symbol = te.canonicalSymbol(name, order.Symbol)
+				te.applyOrderUpdate(&order, order, name)
+			})
+			if !connected {
+				continue
+			}
+			if err := te.SyncAccount(accountName); err != nil {
+				log.Printf("同步账户 '%s' 失败: %v", accountName, err)
+			}
+		}
+	}
+}
+
+// credentialSource 按账户管理器解析账户的API密钥，每次调用重新解析以支持密钥轮换
+func (te *TradingEngine) credentialSource(accountName string) CredentialSource {
+	return func() (string, string, error) {
+		credentials, err := te.accountManager.GetAccountCredentials(accountName)
+		if err != nil {
+			return "", "", err
+		}
+		return credentials.APIKey.Reveal(), credentials.APISecret.Reveal(), nil
+	}
+}
+
+// SetLeverage 设置合约账户在标的上的杠杆倍数，经纪商需实现 LeverageSetter
+func (te *TradingEngine) SetLeverage(accountName, symbol string, leverage float64) error {
+	broker, err := te.GetBroker(accountName)
+	if err != nil {
+		return err
+	}
+	setter, ok := baseBroker(broker).(LeverageSetter)
+	if !ok {
+		return fmt.Errorf("经纪商 '%s' 不支持设置杠杆", accountName)
+	}
+	return setter.SetLeverage(te.brokerSymbol(accountName, symbol), leverage)
+}
+
+// GetFundingRate 查询永续合约当前的资金费率，经纪商需提供资金费率查询
+func (te *TradingEngine) GetFundingRate(accountName, symbol string) (*FundingRate, error) {
+	broker, err := te.GetBroker(accountName)
+	if err != nil {
+		return nil, err
+	}
+	source, ok := baseBroker(broker).(interface {
+		GetFundingRate(symbol string) (*FundingRate, error)
+	})
+	if !ok {
+		return nil, fmt.Errorf("经纪商 '%s' 不提供资金费率", accountName)
 	}
+	rate, err := source.GetFundingRate(te.brokerSymbol(accountName, symbol))
+	if rate != nil {
+		rate.Symbol = te.canonicalSymbol(accountName, rate.Symbol)
+	}
+	return rate, err
+}
+
+// FeeRates 查询账户在经纪商的手续费等级和费率，经纪商需实现 FeeReporter
+func (te *TradingEngine) FeeRates(accountName string) (*FeeRates, error) {
+	broker, err := te.GetBroker(accountName)
+	if err != nil {
+		return nil, err
+	}
+	reporter, ok := baseBroker(broker).(FeeReporter)
+	if !ok {
+		return nil, fmt.Errorf("经纪商 '%s' 不支持查询手续费费率", accountName)
+	}
+	return reporter.GetFeeRates()
 }
 
 // GetBroker 获取经纪商实例
@@ -70,7 +372,11 @@ func (te *TradingEngine) GetBroker(accountName string) (BrokerAPI, error) {
 
 	broker, exists := te.brokers[accountName]
 	if !exists {
-		return nil, fmt.Errorf("经纪商 '%s' 不存在或未连接", accountName)
+		return nil, fmt.Errorf("经纪商 '%s' 不存在或未连接: %w", accountName, ErrBrokerUnavailable)
+	}
+	if !te.connections.available(accountName) {
+		status := te.connections.Status(accountName)
+		return nil, fmt.Errorf("经纪商 '%s' 连接中断（%s）: %w", accountName, status.LastError, ErrBrokerUnavailable)
 	}
 
 	return broker, nil
@@ -78,9 +384,33 @@ func (te *TradingEngine) GetBroker(accountName string) (BrokerAPI, error) {
 
 // ExecuteTrade 执行交易
 func (te *TradingEngine) ExecuteTrade(order Order, accountName string) (*Order, error) {
-	log.Printf("开始执行交易: 账户=%s, 标的=%s, 方向=%s, 数量=%.2f, 价格=%.2f",
+	log.Printf("开始执行交易: 账户=%s, 标的=%s, 方向=%s, 数量=%s, 价格=%s",
 		accountName, order.Symbol, order.Side, order.Quantity, order.Price)
 
+	// 标的统一为规范写法（AAPL.US -> AAPL，BTC/USDT -> BTCUSDT），发往经纪商时再按经纪商写法转换
+	order.Symbol = te.symbols.Normalize(order.Symbol)
+
+	// 期货连续合约映射为主力合约，拒绝已到期的合约
+	if err := te.resolveContract(&order); err != nil {
+		return nil, err
+	}
+
+	// 客户端订单号，重新提交的订单沿用首次提交时的订单号；同时作为审计日志的关联ID
+	if order.ClientOrderID == "" {
+		order.ClientOrderID = newClientOrderID()
+	}
+
+	// 紧急停止
+	if err := te.checkHalted(order); err != nil {
+		te.auditCheck("kill_switch", order, order, accountName, err)
+		return nil, err
+	}
+
+	// 经纪商连接中断时按 outage_policy 等待恢复或拒绝
+	if err := te.awaitBroker(accountName); err != nil {
+		return nil, err
+	}
+
 	// 获取经纪商
 	broker, err := te.GetBroker(accountName)
 	if err != nil {
@@ -92,38 +422,315 @@ func (te *TradingEngine) ExecuteTrade(order Order, accountName string) (*Order,
 		return nil, fmt.Errorf("账户验证失败: %w", err)
 	}
 
+	// 按账户/标的精度取整价格和数量
+	precision := te.precisionFor(accountName, order.Symbol)
+	order.Price = precision.RoundPrice(order.Price)
+	order.Quantity = precision.RoundQuantity(order.Quantity)
+	if !order.Quantity.IsPositive() {
+		return nil, fmt.Errorf("订单数量按精度取整后为0")
+	}
+
+	// 交易名单，纸面订单同样检查
+	if err := te.checkSymbolLists(broker, order, accountName); err != nil {
+		te.auditCheck("symbol_lists", order, order, accountName, err)
+		te.notifier.Notifyf(notify.EventRisk, "交易名单拒绝订单",
+			"账户=%s, 策略=%s, 标的=%s, 方向=%s\n原因: %v", accountName, order.Strategy, order.Symbol, order.Side, err)
+		return nil, err
+	}
+
+	// 未晋级策略的订单只在纸面副本中成交，按纸面副本的余额和持仓做风险检查
+	if te.promotion != nil && te.promotion.IsPaper(order.Strategy) {
+		paper, err := te.promotion.broker(order.Strategy, accountName)
+		if err != nil {
+			return nil, fmt.Errorf("获取纸面副本失败: %w", err)
+		}
+		if order, err = te.checkOrderRisk(paper, order, accountName); err != nil {
+			return nil, err
+		}
+		return te.executePaperTrade(paper, order, accountName)
+	}
+
+	// 策略资金分配，通过检查的买入订单预占资金，订单最终未提交时归还
+	if err := te.allocator.CheckAccount(order.Strategy, accountName); err != nil {
+		te.auditCheck("allocation", order, order, accountName, err)
+		return nil, err
+	}
+	var reservation *AllocationReservation
+	var exposureReservation *ExposureReservation
+	submitted := false
+	defer func() {
+		if !submitted {
+			te.allocator.Release(reservation)
+			te.exposureBook.Release(exposureReservation)
+		}
+	}()
+	if order.Side == BuySide {
+		equity, err := accountEquity(broker)
+		if err != nil {
+			return nil, fmt.Errorf("获取账户权益失败: %w", err)
+		}
+		requested := order
+		order, reservation, err = te.allocator.Check(order, accountName, equity, precision)
+		if err != nil || !order.Quantity.Equal(requested.Quantity) {
+			te.auditCheck("allocation", requested, order, accountName, err)
+		}
+		if err != nil {
+			te.notifier.Notifyf(notify.EventRisk, "超出策略资金分配",
+				"账户=%s, 策略=%s, 标的=%s\n原因: %v", accountName, order.Strategy, order.Symbol, err)
+			return nil, err
+		}
+	}
+
+	// 风险检查
+	if order, err = te.checkOrderRisk(broker, order, accountName); err != nil {
+		return nil, err
+	}
+
+	// 组合敞口限制，按全部账户合并后的持仓和在途订单检查，通过检查的订单预占敞口，订单最终未提交时归还
+	requested := order
+	order, exposureReservation, err = te.checkExposure(order, accountName)
+	if err != nil || !order.Quantity.Equal(requested.Quantity) {
+		te.auditCheck("exposure", requested, order, accountName, err)
+	}
+	if err != nil {
+		te.notifier.Notifyf(notify.EventRisk, "超出组合敞口限制",
+			"账户=%s, 策略=%s, 标的=%s, 方向=%s, 数量=%s\n原因: %v", accountName, order.Strategy, order.Symbol, order.Side, order.Quantity, err)
+		return nil, fmt.Errorf("组合敞口检查未通过: %w", err)
+	}
+
+	// 下单频率限制，订单最终未提交时归还额度
+	release := func() {}
+	if te.throttle != nil {
+		release, err = te.throttle.Reserve(order, accountName)
+		if err != nil {
+			te.auditCheck("throttle", order, order, accountName, err)
+			log.Printf("订单被限流: 账户=%s, 标的=%s, 策略=%s, 原因=%v", accountName, order.Symbol, order.Strategy, err)
+			te.notifier.Notifyf(notify.EventRisk, "订单被限流",
+				"账户=%s, 策略=%s, 标的=%s\n原因: %v", accountName, order.Strategy, order.Symbol, err)
+			return nil, err
+		}
+	}
+
+	// 演练模式：通过全部检查的订单只记录，不发送到经纪商，大额订单也不创建确认请求
+	if te.DryRun() {
+		return te.dryRunOrder(order, accountName), nil
+	}
+
+	// 大额订单暂存等待人工确认，立即返回不占用下单队列；确认后经下单队列重新提交，届时重新执行上述检查
+	if te.approvals != nil && !order.approved && te.approvals.Required(order) {
+		release()
+		request, err := te.approvals.Park(order, accountName)
+		if err != nil {
+			te.auditCheck("approval", order, order, accountName, err)
+			return nil, err
+		}
+		order.ID = request.ID
+		order.AccountName = accountName
+		order.Status = Pending
+		order.AwaitingApproval = true
+		order.CreateTime = request.CreateTime
+		order.UpdateTime = request.CreateTime
+		return &order, nil
+	}
+
 	// 设置订单信息
 	order.AccountName = accountName
 	order.CreateTime = time.Now()
 	order.UpdateTime = time.Now()
 
 	// 执行订单
-	resultOrder, err := broker.PlaceOrder(order)
+	te.recordAudit(orderEvent(audit.KindSubmit, order, accountName))
+	resultOrder, err := te.placeOrder(broker, order)
+	te.auditBrokerResponse(order, resultOrder, accountName, err)
 	if err != nil {
+		release()
+		if isConnectionError(err) {
+			te.connectionLost(accountName, err)
+		}
 		return nil, fmt.Errorf("下单失败: %w", err)
 	}
-
-	// 更新账户信息
-	if err := te.updateAccountAfterTrade(resultOrder, accountName); err != nil {
-		log.Printf("更新账户信息失败: %v", err)
+	if resultOrder.Status == Rejected {
+		release()
+	}
+	submitted = true
+	te.allocator.Bind(reservation, resultOrder)
+	te.exposureBook.Bind(exposureReservation, resultOrder)
+
+	// 记入已成交部分并更新账户信息
+	te.applyOrderUpdate(resultOrder, order, accountName)
+
+	// 部分成交的市价单继续跟踪剩余数量的成交；限价单超时未成交时转为市价单
+	if resultOrder.Status.IsOpen() && (resultOrder.Type == MarketOrder || order.fallbackAfter > 0) {
+		watched := *resultOrder
+		watched.fallbackAfter = order.fallbackAfter
+		watched.referencePrice = order.referencePrice
+		go te.watchOrder(watched, accountName)
 	}
 
 	log.Printf("交易执行完成: 订单ID=%s, 状态=%s", resultOrder.ID, resultOrder.Status)
 	return resultOrder, nil
 }
 
-// ExecuteSignal 执行交易信号
+// precisionFor 获取账户下标的的价格、数量和金额精度
+func (te *TradingEngine) precisionFor(accountName, symbol string) money.Precision {
+	accountConfig, exists := te.currentConfig().Accounts[accountName]
+	if !exists {
+		return money.StockPrecision
+	}
+	return accountConfig.PrecisionTable().For(symbol)
+}
+
+// accountEquity 账户权益：余额加持仓市值
+func accountEquity(broker BrokerAPI) (decimal.Decimal, error) {
+	equity, err := broker.GetBalance()
+	if err != nil {
+		return decimal.Zero, err
+	}
+	positions, err := broker.GetPositions()
+	if err != nil {
+		return decimal.Zero, err
+	}
+	for _, position := range positions {
+		equity = equity.Add(position.MarketValue)
+	}
+	return equity, nil
+}
+
+// RouteAccount 策略信号应发送到的账户
+func (te *TradingEngine) RouteAccount(strategyName string) string {
+	return te.allocator.Route(strategyName)
+}
+
+// GetAllocations 获取各策略的资金分配状态
+func (te *TradingEngine) GetAllocations() []AllocationStatus {
+	return te.allocator.GetStatus()
+}
+
+// ExecuteSignal 执行交易信号，accountName 为空时按策略路由账户
 func (te *TradingEngine) ExecuteSignal(signal strategy.TradingSignal, accountName string) (*Order, error) {
+	if signal.Symbol == "" {
+		return nil, fmt.Errorf("交易信号缺少标的: 策略=%s", signal.Strategy)
+	}
+	if accountName == "" {
+		accountName = te.RouteAccount(signal.Strategy)
+	}
+	if signal.ClientOrderID == "" {
+		signal.ClientOrderID = newClientOrderID()
+	}
+	te.auditSignal(signal, accountName)
 	log.Printf("开始执行交易信号: 账户=%s, 标的=%s, 信号=%s, 数量=%.2f",
 		accountName, signal.Symbol, signal.Signal.String(), signal.Quantity)
 
+	// 平仓信号按当前持仓计算订单
+	if signal.ClosePercent > 0 {
+		order, err := te.closeOrder(accountName, signal.Symbol, signal.ClosePercent, signal.Strategy)
+		if err != nil {
+			return nil, err
+		}
+		order.ClientOrderID = signal.ClientOrderID
+		return te.ExecuteTrade(order, accountName)
+	}
+
+	// 按仓位计算方式确定开仓数量
+	signal, err := te.sizeSignal(signal, accountName)
+	if err != nil {
+		return nil, err
+	}
+
 	// 转换信号为订单
 	order := te.convertSignalToOrder(signal)
 
+	// 被停用的策略只能减仓或平仓
+	if err := te.checkStrategyEnabled(order, accountName); err != nil {
+		te.auditCheck("strategy_supervisor", order, Order{}, accountName, err)
+		return nil, err
+	}
+
 	// 执行交易
 	return te.ExecuteTrade(order, accountName)
 }
 
+// SubmitOrder 异步提交订单到账户的下单队列
+func (te *TradingEngine) SubmitOrder(order Order, accountName string) (<-chan OrderResult, error) {
+	te.mutex.RLock()
+	queue, exists := te.orderQueues[accountName]
+	te.mutex.RUnlock()
+
+	if !exists {
+		return nil, fmt.Errorf("账户 '%s' 的下单队列不存在", accountName)
+	}
+
+	// 按规范写法和主力合约选择队列，同一标的的不同写法（AAPL.US、aapl）串行执行
+	order.Symbol = te.symbols.Normalize(order.Symbol)
+	if err := te.resolveContract(&order); err != nil {
+		return nil, err
+	}
+	return queue.Submit(order)
+}
+
+// SubmitSignal 异步提交交易信号，accountName 为空时按策略路由账户
+func (te *TradingEngine) SubmitSignal(signal strategy.TradingSignal, accountName string) (<-chan OrderResult, error) {
+	if signal.Symbol == "" {
+		return nil, fmt.Errorf("交易信号缺少标的: 策略=%s", signal.Strategy)
+	}
+	if accountName == "" {
+		accountName = te.RouteAccount(signal.Strategy)
+	}
+	if signal.ClientOrderID == "" {
+		signal.ClientOrderID = newClientOrderID()
+	}
+	te.auditSignal(signal, accountName)
+	if err := te.allocator.CheckAccount(signal.Strategy, accountName); err != nil {
+		te.auditCheck("allocation", te.convertSignalToOrder(signal), Order{}, accountName, err)
+		return nil, err
+	}
+	log.Printf("提交交易信号: 账户=%s, 标的=%s, 信号=%s, 数量=%.2f",
+		accountName, signal.Symbol, signal.Signal.String(), signal.Quantity)
+
+	if signal.ClosePercent > 0 {
+		order, err := te.closeOrder(accountName, signal.Symbol, signal.ClosePercent, signal.Strategy)
+		if err != nil {
+			return nil, err
+		}
+		order.ClientOrderID = signal.ClientOrderID
+		return te.SubmitOrder(order, accountName)
+	}
+	signal, err := te.sizeSignal(signal, accountName)
+	if err != nil {
+		return nil, err
+	}
+	order := te.convertSignalToOrder(signal)
+	if err := te.checkStrategyEnabled(order, accountName); err != nil {
+		te.auditCheck("strategy_supervisor", order, Order{}, accountName, err)
+		return nil, err
+	}
+	return te.SubmitOrder(order, accountName)
+}
+
+// ApplyDefaultStops 按信号路由账户的风控预设为没有止损止盈的开仓信号补充默认值，未配置预设时原样返回
+func (te *TradingEngine) ApplyDefaultStops(signal strategy.TradingSignal) strategy.TradingSignal {
+	cfg := te.currentConfig()
+	if cfg.Risk.Preset == "" || signal.ClosePercent > 0 || signal.Price <= 0 {
+		return signal
+	}
+	if signal.Signal != strategy.Buy && signal.Signal != strategy.Sell {
+		return signal
+	}
+	account, ok := cfg.Accounts[te.RouteAccount(signal.Strategy)]
+	if !ok {
+		return signal
+	}
+
+	limits := cfg.Risk.Limits(account.AssetClass())
+	if signal.StopLoss <= 0 && limits.StopLossPercent > 0 {
+		signal.StopLoss = strategy.CalculateStopLoss(signal.Price, limits.StopLossPercent, signal.Signal)
+	}
+	if signal.TakeProfit <= 0 && limits.TakeProfitPercent > 0 {
+		signal.TakeProfit = strategy.CalculateTakeProfit(signal.Price, limits.TakeProfitPercent, signal.Signal)
+	}
+	return signal
+}
+
 // convertSignalToOrder 将交易信号转换为订单
 func (te *TradingEngine) convertSignalToOrder(signal strategy.TradingSignal) Order {
 	var side OrderSide
@@ -140,21 +747,139 @@ func (te *TradingEngine) convertSignalToOrder(signal strategy.TradingSignal) Ord
 		Symbol:     signal.Symbol,
 		Side:       side,
 		Type:       MarketOrder, // 默认市价单
-		Quantity:   signal.Quantity,
-		Price:      signal.Price,
+		Quantity:   money.FromFloat(signal.Quantity),
+		Price:      money.FromFloat(signal.Price),
 		Status:     Pending,
+		Strategy:   signal.Strategy,
 		CreateTime: time.Now(),
 		UpdateTime: time.Now(),
 	}
+	order.ClientOrderID = signal.ClientOrderID
+
+	// 按配置和策略的执行提示决定市价或限价执行
+	te.applyExecution(&order, signal.Hints)
+	te.capParticipation(&order, signal.Hints)
 
 	// 设置止损和止盈价格
 	if signal.StopLoss > 0 {
-		order.StopPrice = signal.StopLoss
+		order.StopPrice = money.FromFloat(signal.StopLoss)
 	}
 
 	return order
 }
 
+// checkOrderRisk 风险检查并记录审计日志，拒绝时发送风控通知；未启用风控时原样返回
+func (te *TradingEngine) checkOrderRisk(broker BrokerAPI, order Order, accountName string) (Order, error) {
+	if te.riskManager == nil {
+		return order, nil
+	}
+	checkedOrder, err := te.checkRisk(broker, order, accountName)
+	te.auditCheck("risk", order, checkedOrder, accountName, err)
+	if err != nil {
+		te.notifier.Notifyf(notify.EventRisk, "风控拒绝订单",
+			"账户=%s, 策略=%s, 标的=%s, 方向=%s, 数量=%s\n原因: %v", accountName, order.Strategy, order.Symbol, order.Side, order.Quantity, err)
+		return order, fmt.Errorf("风险检查未通过: %w", err)
+	}
+	return checkedOrder, nil
+}
+
+// checkRisk 使用经纪商的最新余额和持仓进行风险检查
+func (te *TradingEngine) checkRisk(broker BrokerAPI, order Order, accountName string) (Order, error) {
+	balance, err := broker.GetBalance()
+	if err != nil {
+		return order, fmt.Errorf("获取余额失败: %w", err)
+	}
+
+	positions, err := broker.GetPositions()
+	if err != nil {
+		return order, fmt.Errorf("获取持仓失败: %w", err)
+	}
+
+	return te.riskManager.CheckOrder(order, accountName, balance, positions)
+}
+
+// GetRiskManager 获取风险管理器（未启用风控时返回nil）
+func (te *TradingEngine) GetRiskManager() *RiskManager {
+	return te.riskManager
+}
+
+// SetNotifier 设置告警通知（成交、风控拒单、限流和待确认订单）
+func (te *TradingEngine) SetNotifier(notifier *notify.Dispatcher) {
+	te.notifier = notifier
+	if te.approvals == nil || notifier == nil {
+		return
+	}
+	te.approvals.OnRequest(func(request ApprovalRequest) {
+		notifier.Notifyf(notify.EventApproval, "订单等待人工确认",
+			"ID=%s\n账户=%s, 策略=%s\n%s %s %s @ %s, 名义金额=%s\n截止 %s 前执行 quant-system approval approve %s 确认",
+			request.ID, request.AccountName, request.Strategy, request.Side, request.Symbol, request.Quantity,
+			request.Price, request.Notional.StringFixed(2), request.ExpireTime.Format("15:04:05"), request.ID)
+	})
+}
+
+// notifyFill 发送成交通知
+func (te *TradingEngine) notifyFill(order *Order, strategyName, accountName string) {
+	if order.Status != Filled {
+		return
+	}
+	te.notifier.Notifyf(notify.EventTrade, fmt.Sprintf("成交 %s %s", order.Side, order.Symbol),
+		"账户=%s, 策略=%s, 订单ID=%s\n数量=%s, 均价=%s, 手续费=%s",
+		accountName, strategyName, order.ID, order.FilledQty, order.AvgPrice, order.Commission)
+}
+
+// handleApproval 处理暂存订单的确认结果：确认的订单经账户的下单队列提交，被拒绝或超时的订单记入审计
+func (te *TradingEngine) handleApproval(order Order, accountName string, decision error) (*Order, error) {
+	if decision != nil {
+		te.auditCheck("approval", order, order, accountName, decision)
+		log.Printf("订单未获确认: 账户=%s, 策略=%s, 标的=%s, %v", accountName, order.Strategy, order.Symbol, decision)
+		return nil, decision
+	}
+
+	order.approved = true
+	results, err := te.SubmitOrder(order, accountName)
+	if err != nil {
+		log.Printf("提交已确认的订单失败: 账户=%s, 标的=%s, %v", accountName, order.Symbol, err)
+		return nil, err
+	}
+	result := <-results
+	if result.Err != nil {
+		log.Printf("提交已确认的订单失败: 账户=%s, 标的=%s, %v", accountName, order.Symbol, result.Err)
+	}
+	return result.Order, result.Err
+}
+
+// resolveApproval 暂存订单的处理结果，见 ApprovalManager.Resolve
+func (te *TradingEngine) resolveApproval(id string) (*Order, bool, error) {
+	if te.approvals == nil {
+		return nil, false, fmt.Errorf("未启用大额订单人工确认")
+	}
+	return te.approvals.Resolve(id)
+}
+
+// DecideApproval 确认或拒绝订单，确认的订单立即提交，返回更新后的确认请求
+func (te *TradingEngine) DecideApproval(id, by, reason string, approve bool) (ApprovalRequest, error) {
+	if te.approvals == nil {
+		return ApprovalRequest{}, fmt.Errorf("未启用大额订单人工确认（trading.approval.enabled）")
+	}
+	var request ApprovalRequest
+	var err error
+	if approve {
+		request, err = te.approvals.Approve(id, by)
+	} else {
+		request, err = te.approvals.Reject(id, by, reason)
+	}
+	if err != nil {
+		return ApprovalRequest{}, err
+	}
+	te.approvals.Wake()
+	return request, nil
+}
+
+// GetApprovalManager 获取人工确认管理器（未启用时返回nil）
+func (te *TradingEngine) GetApprovalManager() *ApprovalManager {
+	return te.approvals
+}
+
 // validateAccount 验证账户
 func (te *TradingEngine) validateAccount(accountName string) error {
 	// 检查账户是否存在
@@ -183,6 +908,11 @@ func (te *TradingEngine) validateAccount(accountName string) error {
 
 // updateAccountAfterTrade 交易后更新账户信息
 func (te *TradingEngine) updateAccountAfterTrade(order *Order, accountName string) error {
+	return te.SyncAccount(accountName)
+}
+
+// SyncAccount 用经纪商的余额和持仓更新账户管理器
+func (te *TradingEngine) SyncAccount(accountName string) error {
 	// 获取经纪商
 	broker, err := te.GetBroker(accountName)
 	if err != nil {
@@ -207,8 +937,8 @@ func (te *TradingEngine) updateAccountAfterTrade(order *Order, accountName strin
 	}
 
 	for symbol, position := range positions {
-		if position.Quantity > 0 {
-			// 更新或添加持仓
+		if !position.Quantity.IsZero() {
+			// 更新或添加持仓（空头持仓数量为负）
 			_, err := te.accountManager.GetPosition(accountName, symbol)
 			if err != nil {
 				// 添加新持仓
@@ -227,19 +957,81 @@ func (te *TradingEngine) updateAccountAfterTrade(order *Order, accountName strin
 		}
 	}
 
+	// 经纪商已不再持有的标的
+	if existing, err := te.accountManager.GetAllPositions(accountName); err == nil {
+		for symbol := range existing {
+			if _, exists := positions[symbol]; !exists {
+				te.accountManager.RemovePosition(accountName, symbol)
+			}
+		}
+	}
+
+	te.syncMargin(accountName, broker)
 	return nil
 }
 
 // GetAccountBalance 获取账户余额
-func (te *TradingEngine) GetAccountBalance(accountName string) (float64, error) {
+func (te *TradingEngine) GetAccountBalance(accountName string) (decimal.Decimal, error) {
 	broker, err := te.GetBroker(accountName)
 	if err != nil {
-		return 0, err
+		return decimal.Zero, err
 	}
 
 	return broker.GetBalance()
 }
 
+// GetAccountEquity 获取账户权益（余额加持仓市值）
+func (te *TradingEngine) GetAccountEquity(accountName string) (decimal.Decimal, error) {
+	broker, err := te.GetBroker(accountName)
+	if err != nil {
+		return decimal.Zero, err
+	}
+
+	return accountEquity(broker)
+}
+
+// BrokerCheck 经纪商只读检查的结果
+type BrokerCheck struct {
+	Account    string          `json:"account"`
+	BrokerType string          `json:"broker_type"`
+	Connected  bool            `json:"connected"`
+	Balance    decimal.Decimal `json:"balance"`
+	Positions  int             `json:"positions"`
+	Error      string          `json:"error,omitempty"`
+}
+
+// VerifyBrokers 用只读请求（余额、持仓）检查每个配置账户的经纪商连接和凭证，不下单
+func (te *TradingEngine) VerifyBrokers() []BrokerCheck {
+	cfg := te.currentConfig()
+	names := make([]string, 0, len(cfg.Accounts))
+	for name := range cfg.Accounts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	checks := make([]BrokerCheck, 0, len(names))
+	for _, name := range names {
+		check := BrokerCheck{Account: name, BrokerType: cfg.Accounts[name].BrokerType}
+		broker, err := te.GetBroker(name)
+		if err != nil {
+			check.Error = err.Error()
+			checks = append(checks, check)
+			continue
+		}
+		check.Connected = true
+
+		if check.Balance, err = broker.GetBalance(); err != nil {
+			check.Error = fmt.Sprintf("获取余额失败: %v", err)
+		} else if positions, err := broker.GetPositions(); err != nil {
+			check.Error = fmt.Sprintf("获取持仓失败: %v", err)
+		} else {
+			check.Positions = len(positions)
+		}
+		checks = append(checks, check)
+	}
+	return checks
+}
+
 // GetAccountPositions 获取账户持仓
 func (te *TradingEngine) GetAccountPositions(accountName string) (map[string]Position, error) {
 	broker, err := te.GetBroker(accountName)
@@ -279,7 +1071,15 @@ func (te *TradingEngine) CancelOrder(accountName, orderID string) error {
 		return err
 	}
 
-	return broker.CancelOrder(orderID)
+	if te.dryRunCancel(Order{ID: orderID}, accountName, "取消订单") {
+		return nil
+	}
+	err = broker.CancelOrder(orderID)
+	te.auditCancel(Order{ID: orderID}, accountName, "取消订单", err)
+	if err == nil {
+		te.releaseReservations(orderID)
+	}
+	return err
 }
 
 // GetTradingStatus 获取交易状态
@@ -289,15 +1089,59 @@ func (te *TradingEngine) GetTradingStatus() *TradingStatus {
 
 	status := &TradingStatus{
 		IsRunning: te.isRunning,
+		Paper:     te.currentConfig().Trading.Paper,
+		DryRun:    te.currentConfig().Trading.DryRun,
 		Brokers:   make(map[string]BrokerStatus),
 	}
 
 	for name := range te.brokers {
-		// 这里可以添加更多状态信息
-		status.Brokers[name] = BrokerStatus{
+		connection := te.connections.Status(name)
+		brokerStatus := BrokerStatus{
 			Name:   name,
-			Status: "connected", // 简化状态
+			Status: string(connection.State),
+		}
+		brokerStatus.Since = connection.Since
+		brokerStatus.LastError = connection.LastError
+		brokerStatus.Reconnects = connection.Reconnects
+		if !connection.NextRetry.IsZero() {
+			brokerStatus.NextRetry = &connection.NextRetry
+		}
+		if queue, exists := te.orderQueues[name]; exists {
+			brokerStatus.PendingOrders = queue.Pending()
+		}
+		status.Brokers[name] = brokerStatus
+
+		if limited, ok := te.brokers[name].(*rateLimitedBroker); ok {
+			if status.RateLimits == nil {
+				status.RateLimits = make(map[string]RateLimitStats)
+			}
+			status.RateLimits[name] = limited.limiter.GetStats()
+		}
+	}
+
+	if te.riskManager != nil {
+		riskStats := te.riskManager.GetStats(riskStatusRecent)
+		status.Risk = &riskStats
+	}
+	status.Costs = te.GetCostSummary()
+
+	status.Allocations = te.allocator.GetStatus()
+	status.SymbolLists = te.symbolLists.GetStatus()
+	status.Promotion = te.GetPromotionStatus()
+	status.Grids = te.GetGrids()
+	status.Halt = te.GetHaltState()
+
+	if te.throttle != nil {
+		throttleStats := te.throttle.GetStats()
+		status.Throttle = &throttleStats
+	}
+
+	if te.approvals != nil {
+		pending, err := te.approvals.List(ApprovalPending)
+		if err != nil {
+			log.Printf("读取待确认订单失败: %v", err)
 		}
+		status.Approvals = pending
 	}
 
 	return status
@@ -305,6 +1149,7 @@ func (te *TradingEngine) GetTradingStatus() *TradingStatus {
 
 // Start 启动交易引擎
 func (te *TradingEngine) Start() error {
+	cfg := te.currentConfig()
 	te.mutex.Lock()
 	defer te.mutex.Unlock()
 
@@ -313,13 +1158,61 @@ func (te *TradingEngine) Start() error {
 	}
 
 	log.Printf("启动交易引擎")
+	if te.stopped {
+		te.reconnectBrokers()
+	}
 	te.isRunning = true
 
+	// 收盘时撤销未成交的 DAY 订单
+	te.stopChan = make(chan struct{})
+	go te.runDayOrderExpiry(te.stopChan)
+	if te.promotion != nil {
+		go te.runPromotionSampling(te.stopChan)
+	}
+	if te.leaderboard != nil {
+		go te.runLeaderboardSampling(te.stopChan)
+	}
+	if cfg.Trading.Reconciliation.Enabled {
+		go te.runReconciliation(te.stopChan)
+	}
+	if cfg.Trading.Snapshot.Enabled {
+		go te.runSnapshotExport(te.stopChan)
+	}
+	if cfg.Trading.Grid.SyncInterval > 0 {
+		go te.runGridSync(te.stopChan)
+	}
+	if te.approvals != nil {
+		go te.approvals.run(te.stopChan, te.handleApproval)
+	}
+	if te.instrumentRegistry() != nil && cfg.Instruments.RollCheckInterval > 0 {
+		go te.runFuturesRoll(te.stopChan)
+	}
+	go te.runConnectionSupervisor(te.stopChan)
+
 	return nil
 }
 
 // Stop 停止交易引擎
 func (te *TradingEngine) Stop() error {
+	if !te.IsRunning() {
+		return fmt.Errorf("交易引擎未运行")
+	}
+
+	// 等待队列中的订单执行完毕（下单过程需要读锁，不能在持有写锁时等待）
+	te.mutex.RLock()
+	queues := make([]*OrderQueue, 0, len(te.orderQueues))
+	for _, queue := range te.orderQueues {
+		queues = append(queues, queue)
+	}
+	te.mutex.RUnlock()
+
+	for _, queue := range queues {
+		queue.Close()
+	}
+	if te.currentConfig().Trading.Grid.CancelOnStop {
+		te.CancelGrids("")
+	}
+
 	te.mutex.Lock()
 	defer te.mutex.Unlock()
 
@@ -329,17 +1222,36 @@ func (te *TradingEngine) Stop() error {
 
 	log.Printf("停止交易引擎")
 	te.isRunning = false
+	te.stopped = true
+	close(te.stopChan)
 
 	// 断开所有经纪商连接
 	for name, broker := range te.brokers {
 		if err := broker.Disconnect(); err != nil {
 			log.Printf("断开经纪商 %s 连接失败: %v", name, err)
 		}
+		te.connections.markStopped(name)
 	}
 
 	return nil
 }
 
+// reconnectBrokers 停止后再次启动时重新连接经纪商并重建下单队列，调用方需持有锁
+func (te *TradingEngine) reconnectBrokers() {
+	cfg := te.currentConfig()
+	for name, broker := range te.brokers {
+		if err := broker.Connect(); err != nil {
+			log.Printf("重新连接经纪商 %s 失败，将自动重连: %v", name, err)
+			te.connections.markDown(name, err)
+		} else {
+			te.connections.markConnected(name)
+		}
+		te.orderQueues[name] = NewOrderQueue(name,
+			cfg.Trading.OrderConcurrency, cfg.Trading.OrderQueueSize, te.ExecuteTrade)
+	}
+	te.stopped = false
+}
+
 // IsRunning 检查是否运行中
 func (te *TradingEngine) IsRunning() bool {
 	te.mutex.RLock()
@@ -350,66 +1262,38 @@ func (te *TradingEngine) IsRunning() bool {
 // TradingStatus 交易状态
 type TradingStatus struct {
 	IsRunning bool                    `json:"is_running"`
+	Paper     bool                    `json:"paper"`   // 是否为纸面交易模式
+	DryRun    bool                    `json:"dry_run"` // 是否为演练模式，订单不发送到经纪商
 	Brokers   map[string]BrokerStatus `json:"brokers"`
-}
+	Risk      *RiskStats              `json:"risk,omitempty"`      // 未启用风控时为nil
+	Costs     *CostSummary            `json:"costs,omitempty"`     // 未启用成交流水时为nil
+	Approvals []ApprovalRequest       `json:"approvals,omitempty"` // 等待人工确认的订单
+	Throttle  *ThrottleStats          `json:"throttle,omitempty"`  // 未启用下单频率限制时为nil
 
-// BrokerStatus 经纪商状态
-type BrokerStatus struct {
-	Name   string `json:"name"`
-	Status string `json:"status"`
-}
-
-// RiskManager 风险管理器
-type RiskManager struct {
-	maxPositionSize float64 // 最大单笔仓位
-	maxDailyLoss    float64 // 最大日亏损
-	maxDrawdown     float64 // 最大回撤
-}
-
-// NewRiskManager 创建风险管理器
-func NewRiskManager(maxPositionSize, maxDailyLoss, maxDrawdown float64) *RiskManager {
-	return &RiskManager{
-		maxPositionSize: maxPositionSize,
-		maxDailyLoss:    maxDailyLoss,
-		maxDrawdown:     maxDrawdown,
-	}
-}
-
-// ValidateTrade 验证交易风险
-func (rm *RiskManager) ValidateTrade(order Order, accountBalance float64, currentPositions map[string]Position) error {
-	// 检查单笔仓位大小
-	positionValue := order.Quantity * order.Price
-	if positionValue > accountBalance*rm.maxPositionSize {
-		return fmt.Errorf("单笔仓位过大: %.2f > %.2f", positionValue, accountBalance*rm.maxPositionSize)
-	}
+	Allocations []AllocationStatus `json:"allocations,omitempty"` // 配置了资金分配的策略
+	SymbolLists SymbolListsStatus  `json:"symbol_lists"`
+	Promotion   []PromotionStatus  `json:"promotion,omitempty"` // 未启用晋级时为空
+	Halt        HaltState          `json:"halt"`
 
-	// 检查总仓位
-	totalPositionValue := positionValue
-	for _, position := range currentPositions {
-		totalPositionValue += position.MarketValue
-	}
-
-	if totalPositionValue > accountBalance {
-		return fmt.Errorf("总仓位超过账户余额")
-	}
+	// 常驻限价单策略维护的网格挂单
+	Grids []GridStatus `json:"grids,omitempty"`
 
-	log.Printf("交易风险验证通过: 单笔仓位=%.2f, 总仓位=%.2f", positionValue, totalPositionValue)
-	return nil
+	// 配置了接口频率限制的经纪商
+	RateLimits map[string]RateLimitStats `json:"rate_limits,omitempty"`
 }
 
-// CalculatePositionSize 计算仓位大小
-func (rm *RiskManager) CalculatePositionSize(accountBalance, riskAmount, stopLossDistance float64) float64 {
-	if stopLossDistance <= 0 {
-		return 0
-	}
-
-	positionSize := riskAmount / stopLossDistance
-
-	// 限制最大仓位
-	maxPosition := accountBalance * rm.maxPositionSize / riskAmount
-	if positionSize > maxPosition {
-		positionSize = maxPosition
-	}
+// riskStatusRecent 状态中展示的最近风控调整条数
+const riskStatusRecent = 10
 
-	return positionSize
+// BrokerStatus 经纪商状态
+type BrokerStatus struct {
+	Name          string `json:"name"`
+	Status        string `json:"status"`
+	PendingOrders int    `json:"pending_orders"`
+
+	// 连接监控记录的连接状态
+	Since      time.Time  `json:"since"`
+	LastError  string     `json:"last_error,omitempty"`
+	Reconnects int        `json:"reconnects"`
+	NextRetry  *time.Time `json:"next_retry,omitempty"` // 连接中断时的下次重连时间
 }
@@ -0,0 +1,207 @@
+package trading
+
+import (
+	"log"
+	"time"
+
+	"agent-quant-system/internal/config"
+	"agent-quant-system/internal/money"
+	"agent-quant-system/internal/strategy"
+
+	"github.com/shopspring/decimal"
+)
+
+// limitOrderPollInterval 限价单成交状态轮询间隔
+var limitOrderPollInterval = time.Second
+
+// marketOrderWatchLimit 部分成交的市价单等待剩余数量成交的最长时间
+const marketOrderWatchLimit = 10 * time.Minute
+
+// participationLookbackDays 计算参与率使用的日均成交量天数
+const participationLookbackDays = 20
+
+// VolumeSource 成交量数据来源，价格来源实现该接口时支持按参与率限制订单数量
+type VolumeSource interface {
+	GetAverageVolume(symbol string, days int) (float64, error)
+}
+
+// executionFor 获取策略对应的下单方式，未单独配置时使用全局默认
+func (te *TradingEngine) executionFor(strategyName string) config.ExecutionConfig {
+	execution := te.currentConfig().Trading.Execution
+	if override, exists := te.currentConfig().Trading.StrategyExecution[strategyName]; exists {
+		if override.OrderType != "" {
+			execution.OrderType = override.OrderType
+		}
+		if override.LimitOffset > 0 {
+			execution.LimitOffset = override.LimitOffset
+		}
+		if override.LimitTimeout > 0 {
+			execution.LimitTimeout = override.LimitTimeout
+		}
+		if override.TimeInForce != "" {
+			execution.TimeInForce = override.TimeInForce
+		}
+	}
+	return execution
+}
+
+// applyHints 用信号的执行提示覆盖下单方式：high 强制市价单，low 使用限价单且超时不转市价单
+func applyHints(execution config.ExecutionConfig, hints *strategy.ExecutionHints) config.ExecutionConfig {
+	if hints == nil {
+		return execution
+	}
+
+	switch hints.OrderType {
+	case string(MarketOrder), string(LimitOrder):
+		execution.OrderType = hints.OrderType
+	case "":
+	default:
+		log.Printf("忽略无效的执行提示: order_type=%s", hints.OrderType)
+	}
+	if hints.LimitOffset > 0 && hints.LimitOffset < 1 {
+		execution.LimitOffset = hints.LimitOffset
+	}
+
+	switch hints.Urgency {
+	case strategy.UrgencyHigh:
+		execution.OrderType = string(MarketOrder)
+	case strategy.UrgencyLow:
+		execution.OrderType = string(LimitOrder)
+		execution.LimitTimeout = 0
+	}
+	return execution
+}
+
+// capParticipation 按执行提示的最大参与率限制订单数量，无法获取成交量时不限制
+func (te *TradingEngine) capParticipation(order *Order, hints *strategy.ExecutionHints) {
+	if hints == nil || hints.MaxParticipationRate <= 0 || hints.MaxParticipationRate > 1 {
+		return
+	}
+
+	te.mutex.RLock()
+	volumes, ok := te.prices.(VolumeSource)
+	te.mutex.RUnlock()
+	if !ok {
+		return
+	}
+
+	averageVolume, err := volumes.GetAverageVolume(order.Symbol, participationLookbackDays)
+	if err != nil {
+		log.Printf("获取 %s 日均成交量失败，不限制参与率: %v", order.Symbol, err)
+		return
+	}
+
+	limit := money.FromFloat(averageVolume * hints.MaxParticipationRate)
+	if order.Quantity.GreaterThan(limit) {
+		log.Printf("按参与率限制订单数量: 标的=%s, 数量 %s -> %s, 参与率=%.2f%%, 日均成交量=%.0f",
+			order.Symbol, order.Quantity, limit, hints.MaxParticipationRate*100, averageVolume)
+		order.Quantity = limit
+	}
+}
+
+// applyExecution 按下单方式（及信号的执行提示）设置订单类型、限价和有效期
+func (te *TradingEngine) applyExecution(order *Order, hints *strategy.ExecutionHints) {
+	execution := applyHints(te.executionFor(order.Strategy), hints)
+	order.TimeInForce = TimeInForce(execution.TimeInForce)
+	if execution.OrderType != string(LimitOrder) || !order.Price.IsPositive() {
+		order.Type = MarketOrder
+		return
+	}
+
+	order.Type = LimitOrder
+	order.referencePrice = order.Price
+	offset := decimal.NewFromFloat(execution.LimitOffset)
+	if order.Side == BuySide {
+		order.Price = order.Price.Mul(decimal.NewFromInt(1).Sub(offset))
+	} else {
+		order.Price = order.Price.Mul(decimal.NewFromInt(1).Add(offset))
+	}
+	order.fallbackAfter = execution.LimitTimeout
+}
+
+// watchOrder 跟踪未完成订单的成交并按新增成交记账：部分成交的市价单等待剩余数量成交，
+// 设置了超时的限价单到期后撤单并以市价单提交剩余数量
+func (te *TradingEngine) watchOrder(order Order, accountName string) {
+	fallback := order.Type == LimitOrder && order.fallbackAfter > 0
+	deadline := time.Now().Add(marketOrderWatchLimit)
+	if fallback {
+		deadline = time.Now().Add(order.fallbackAfter)
+	}
+
+	for time.Now().Before(deadline) {
+		time.Sleep(limitOrderPollInterval)
+
+		current, ok := te.pollOrder(order, accountName)
+		if !ok {
+			continue
+		}
+		order.FilledQty = current.FilledQty
+
+		switch {
+		case current.Status == Filled:
+			log.Printf("订单已全部成交: 订单ID=%s, 均价=%s", order.ID, current.AvgPrice)
+			return
+		case current.Status.IsTerminal():
+			log.Printf("订单已终止: 订单ID=%s, 状态=%s, 已成交=%s", order.ID, current.Status, current.FilledQty)
+			return
+		}
+	}
+
+	if !fallback {
+		log.Printf("订单在 %v 内未全部成交，停止跟踪: 订单ID=%s, 已成交=%s/%s", marketOrderWatchLimit, order.ID, order.FilledQty, order.Quantity)
+		return
+	}
+	if !te.IsRunning() {
+		return
+	}
+
+	log.Printf("限价单超时未成交，撤单并转为市价单: 订单ID=%s, 超时=%v", order.ID, order.fallbackAfter)
+	if err := te.CancelOrder(accountName, order.ID); err != nil {
+		log.Printf("撤销限价单失败，放弃转为市价单: %v", err)
+		return
+	}
+
+	// 撤单前可能又有部分成交
+	if current, ok := te.pollOrder(order, accountName); ok {
+		order.FilledQty = current.FilledQty
+	}
+	remaining := order.Quantity.Sub(order.FilledQty)
+	if !remaining.IsPositive() {
+		return
+	}
+
+	// 市价单是新订单，使用新的客户端订单号，否则会被当作重复提交返回已撤销的限价单
+	marketOrder := order
+	marketOrder.ID = ""
+	marketOrder.ClientOrderID = newClientOrderID()
+	marketOrder.ParentOrderID = order.ID
+	marketOrder.Type = MarketOrder
+	if order.referencePrice.IsPositive() {
+		marketOrder.Price = order.referencePrice
+	}
+	marketOrder.Quantity = remaining
+	marketOrder.FilledQty = decimal.Zero
+	marketOrder.Status = Pending
+	marketOrder.fallbackAfter = 0
+
+	log.Printf("提交市价单: 原订单ID=%s, 客户端订单号=%s, 数量=%s", order.ID, marketOrder.ClientOrderID, remaining)
+	if _, err := te.SubmitOrder(marketOrder, accountName); err != nil {
+		log.Printf("提交市价单失败: %v", err)
+	}
+}
+
+// pollOrder 查询订单的最新状态并记入新增成交
+func (te *TradingEngine) pollOrder(order Order, accountName string) (*Order, bool) {
+	broker, err := te.GetBroker(accountName)
+	if err != nil {
+		log.Printf("查询订单失败: 订单ID=%s, 错误=%v", order.ID, err)
+		return nil, false
+	}
+	current, err := broker.GetOrder(order.ID)
+	if err != nil {
+		log.Printf("查询订单失败: 订单ID=%s, 错误=%v", order.ID, err)
+		return nil, false
+	}
+	te.applyOrderUpdate(current, order, accountName)
+	return current, true
+}
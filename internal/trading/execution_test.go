@@ -0,0 +1,207 @@
+package trading
+
+import (
+	"testing"
+	"time"
+
+	"agent-quant-system/internal/account"
+	"agent-quant-system/internal/config"
+
+	"github.com/shopspring/decimal"
+)
+
+// newTestEngine 创建使用模拟经纪商的交易引擎，账户名为 test
+func newTestEngine(t *testing.T, brokerType string) *TradingEngine {
+	t.Helper()
+
+	cfg := &config.Config{
+		Accounts: map[string]config.AccountConfig{
+			"test": {APIKey: "key", APISecret: "secret", BrokerType: brokerType, InitialBalance: 100000},
+		},
+	}
+	cfg.Trading.Simulation.FillRatio = 1
+	cfg.Trading.OrderConcurrency = 1
+	cfg.Trading.OrderQueueSize = 10
+
+	engine := NewTradingEngine(cfg, account.NewAccountManager(cfg, nil))
+	if err := engine.Start(); err != nil {
+		t.Fatalf("启动交易引擎失败: %v", err)
+	}
+	t.Cleanup(func() { engine.Stop() })
+	return engine
+}
+
+func TestLimitOrderFallbackSubmitsNewMarketOrder(t *testing.T) {
+	interval := limitOrderPollInterval
+	limitOrderPollInterval = 10 * time.Millisecond
+	t.Cleanup(func() { limitOrderPollInterval = interval })
+
+	engine := newTestEngine(t, "stock")
+	order := Order{
+		Symbol:         "AAPL",
+		Side:           BuySide,
+		Type:           LimitOrder,
+		Quantity:       decimal.NewFromInt(10),
+		Price:          decimal.NewFromInt(99),
+		Strategy:       "test",
+		fallbackAfter:  50 * time.Millisecond,
+		referencePrice: decimal.NewFromInt(100),
+	}
+
+	limit, err := engine.ExecuteTrade(order, "test")
+	if err != nil {
+		t.Fatalf("提交限价单失败: %v", err)
+	}
+	if limit.Status != Submitted {
+		t.Fatalf("限价单状态 = %s, 期望 %s", limit.Status, Submitted)
+	}
+
+	broker, err := engine.GetBroker("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var market *Order
+	for deadline := time.Now().Add(3 * time.Second); market == nil && time.Now().Before(deadline); {
+		time.Sleep(20 * time.Millisecond)
+		orders, err := broker.GetOrders("AAPL", Filled)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := range orders {
+			if orders[i].ParentOrderID == limit.ID {
+				market = &orders[i]
+			}
+		}
+	}
+	if market == nil {
+		t.Fatalf("限价单超时后没有提交市价单")
+	}
+
+	if market.ID == limit.ID || market.ClientOrderID == limit.ClientOrderID {
+		t.Errorf("市价单沿用了限价单的订单号: ID=%s, 客户端订单号=%s", market.ID, market.ClientOrderID)
+	}
+	if market.Type != MarketOrder || !market.Quantity.Equal(order.Quantity) {
+		t.Errorf("市价单 类型=%s 数量=%s, 期望 %s %s", market.Type, market.Quantity, MarketOrder, order.Quantity)
+	}
+
+	cancelled, err := broker.GetOrder(limit.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cancelled.Status != Cancelled {
+		t.Errorf("限价单状态 = %s, 期望 %s", cancelled.Status, Cancelled)
+	}
+
+	positions, err := broker.GetPositions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if position := positions["AAPL"]; !position.Quantity.Equal(order.Quantity) {
+		t.Errorf("持仓数量 = %s, 期望 %s", position.Quantity, order.Quantity)
+	}
+}
+
+func TestPaperTradeRunsSymbolListAndRiskChecks(t *testing.T) {
+	cfg := &config.Config{
+		Accounts: map[string]config.AccountConfig{
+			"test": {APIKey: "key", APISecret: "secret", BrokerType: "stock", InitialBalance: 100000},
+		},
+	}
+	cfg.Trading.Simulation.FillRatio = 1
+	cfg.Trading.OrderConcurrency = 1
+	cfg.Trading.OrderQueueSize = 10
+	cfg.Strategy.Active = []string{"trend"}
+	cfg.Trading.Promotion = config.PromotionConfig{Enabled: true, Days: 30, StateFile: t.TempDir() + "/promotion.json"}
+	cfg.Risk = config.RiskConfig{Enabled: true, MaxPositionSize: 0.1, MaxTotalExposure: 1, Blacklist: []string{"TSLA"}}
+
+	engine := NewTradingEngine(cfg, account.NewAccountManager(cfg, nil))
+	if err := engine.Start(); err != nil {
+		t.Fatalf("启动交易引擎失败: %v", err)
+	}
+	t.Cleanup(func() { engine.Stop() })
+	engine.SetPriceSource(PriceSourceFunc(func(string) (float64, error) { return 100, nil }))
+
+	order := func(symbol string, quantity int64) Order {
+		return Order{Symbol: symbol, Side: BuySide, Type: LimitOrder, Quantity: decimal.NewFromInt(quantity),
+			Price: decimal.NewFromInt(100), Strategy: "trend"}
+	}
+	if _, err := engine.ExecuteTrade(order("TSLA", 1), "test"); err == nil {
+		t.Fatalf("黑名单标的的纸面订单应被交易名单拒绝")
+	}
+	// 单笔上限为纸面副本权益 100000 的 10%，200 股名义金额 20000 超限
+	if _, err := engine.ExecuteTrade(order("AAPL", 200), "test"); err == nil {
+		t.Fatalf("超过单笔上限的纸面订单应被风控拒绝")
+	}
+	filled, err := engine.ExecuteTrade(order("AAPL", 50), "test")
+	if err != nil {
+		t.Fatalf("纸面下单失败: %v", err)
+	}
+	if !filled.Paper {
+		t.Fatalf("未晋级策略的订单应在纸面副本中成交")
+	}
+}
+
+func TestOrderQuantityPrecision(t *testing.T) {
+	places := func(n int32) *int32 { return &n }
+	cfg := &config.Config{
+		Accounts: map[string]config.AccountConfig{
+			"stock":      {BrokerType: "stock"},
+			"fractional": {BrokerType: "stock", Precision: config.PrecisionConfig{Quantity: places(4)}},
+			"crypto": {BrokerType: "crypto", SymbolPrecision: map[string]config.PrecisionConfig{
+				"dogeusdt": {Quantity: places(0)},
+				"BTCUSDT":  {Quantity: places(5), Price: places(1)},
+			}},
+			"ctp": {BrokerType: "ctp"},
+		},
+	}
+	engine := NewTradingEngine(cfg, account.NewAccountManager(cfg, nil))
+
+	tests := []struct {
+		name      string
+		account   string
+		symbol    string
+		quantity  string
+		price     string
+		wantQty   string
+		wantPrice string
+	}{
+		{"股票默认整股", "stock", "AAPL", "10.7", "180.456", "10", "180.46"},
+		{"不足一股取整为0", "stock", "AAPL", "0.9", "180", "0", "180"},
+		{"账户开启碎股", "fractional", "AAPL", "10.123456", "180", "10.1234", "180"},
+		{"加密货币默认8位", "crypto", "ETHUSDT", "0.123456789", "2500.123456789", "0.12345678", "2500.12345679"},
+		{"按标的覆盖数量精度（不区分大小写）", "crypto", "DOGEUSDT", "1234.9", "0.123", "1234", "0.123"},
+		{"按标的同时覆盖价格和数量精度", "crypto", "BTCUSDT", "0.1234567", "65000.26", "0.12345", "65000.3"},
+		{"期货按整手", "ctp", "rb2510", "3.5", "3500", "3", "3500"},
+		{"未配置的账户按整股", "missing", "AAPL", "2.5", "10.005", "2", "10.01"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			precision := engine.precisionFor(tt.account, tt.symbol)
+			quantity := precision.RoundQuantity(decimal.RequireFromString(tt.quantity))
+			price := precision.RoundPrice(decimal.RequireFromString(tt.price))
+			if quantity.String() != tt.wantQty || price.String() != tt.wantPrice {
+				t.Fatalf("取整后 = %s @ %s, 期望 %s @ %s", quantity, price, tt.wantQty, tt.wantPrice)
+			}
+		})
+	}
+}
+
+func TestExecuteTradeRoundsToWholeShares(t *testing.T) {
+	engine := newTestEngine(t, "stock")
+	order := Order{Symbol: "AAPL", Side: BuySide, Type: LimitOrder, Quantity: decimal.RequireFromString("10.7"),
+		Price: decimal.NewFromInt(100), Strategy: "test"}
+
+	filled, err := engine.ExecuteTrade(order, "test")
+	if err != nil {
+		t.Fatalf("下单失败: %v", err)
+	}
+	if !filled.Quantity.Equal(decimal.NewFromInt(10)) {
+		t.Fatalf("订单数量 = %s, 期望按整股取整为 10", filled.Quantity)
+	}
+
+	order.Quantity = decimal.RequireFromString("0.5")
+	if _, err := engine.ExecuteTrade(order, "test"); err == nil {
+		t.Fatalf("按整股取整为0的订单应被拒绝")
+	}
+}
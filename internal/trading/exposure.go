@@ -0,0 +1,478 @@
+package trading
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"agent-quant-system/internal/config"
+	"agent-quant-system/internal/instrument"
+	"agent-quant-system/internal/money"
+
+	"github.com/shopspring/decimal"
+)
+
+// ExposureBucket 一个资产类别或行业的敞口
+type ExposureBucket struct {
+	Name       string          `json:"name"`
+	Gross      decimal.Decimal `json:"gross"` // 持仓市值绝对值之和
+	Net        decimal.Decimal `json:"net"`   // 多空轧差后的净市值
+	GrossRatio float64         `json:"gross_ratio"`
+	NetRatio   float64         `json:"net_ratio"`
+	Limit      float64         `json:"limit,omitempty"` // 总敞口上限，0表示不限制
+}
+
+// SymbolExposure 单个标的在全部账户轧差后的敞口
+type SymbolExposure struct {
+	Symbol     string          `json:"symbol"`
+	AssetClass string          `json:"asset_class"`
+	Sector     string          `json:"sector,omitempty"`
+	Value      decimal.Decimal `json:"value"` // 净市值，空头为负数
+	Weight     float64         `json:"weight"`
+}
+
+// ExposureReport 组合敞口报告，金额为各账户计价币种的直接合计
+type ExposureReport struct {
+	Time         time.Time             `json:"time"`
+	Equity       decimal.Decimal       `json:"equity"` // 全部账户的权益合计
+	Gross        decimal.Decimal       `json:"gross"`
+	Net          decimal.Decimal       `json:"net"`
+	GrossRatio   float64               `json:"gross_ratio"`
+	NetRatio     float64               `json:"net_ratio"`
+	AssetClasses []ExposureBucket      `json:"asset_classes"`
+	Sectors      []ExposureBucket      `json:"sectors,omitempty"`
+	Symbols      []SymbolExposure      `json:"symbols"` // 按权重绝对值从大到小
+	Limits       config.ExposureConfig `json:"limits"`
+	Breaches     []string              `json:"breaches,omitempty"` // 当前已超出的限制（如价格变动导致），只阻止继续增加敞口
+	Errors       []string              `json:"errors,omitempty"`   // 获取持仓失败的账户
+}
+
+// portfolioExposure 全部账户合并后的持仓：同一标的的持仓市值轧差合并
+type portfolioExposure struct {
+	equity  decimal.Decimal
+	values  map[string]decimal.Decimal
+	classes map[string]string
+	sectors map[string]string
+}
+
+// exposure 按资产类别或行业汇总的敞口
+type exposure struct {
+	gross decimal.Decimal
+	net   decimal.Decimal
+}
+
+// byClass 各资产类别的敞口
+func (p *portfolioExposure) byClass() map[string]*exposure {
+	return p.group(func(symbol string) string { return p.classes[symbol] })
+}
+
+// bySector 各行业的敞口，未列入行业的标的不计入
+func (p *portfolioExposure) bySector() map[string]*exposure {
+	return p.group(func(symbol string) string { return p.sectors[symbol] })
+}
+
+// group 按 key 汇总各标的的敞口，key 为空的标的不计入
+func (p *portfolioExposure) group(key func(symbol string) string) map[string]*exposure {
+	groups := make(map[string]*exposure)
+	for symbol, value := range p.values {
+		name := key(symbol)
+		if name == "" {
+			continue
+		}
+		group, exists := groups[name]
+		if !exists {
+			group = &exposure{}
+			groups[name] = group
+		}
+		group.gross = group.gross.Add(value.Abs())
+		group.net = group.net.Add(value)
+	}
+	return groups
+}
+
+// gross 各标的持仓市值绝对值之和
+func (p *portfolioExposure) gross() decimal.Decimal {
+	total := decimal.Zero
+	for _, value := range p.values {
+		total = total.Add(value.Abs())
+	}
+	return total
+}
+
+// ratio 金额占权益合计的比例
+func (p *portfolioExposure) ratio(value decimal.Decimal) float64 {
+	if !p.equity.IsPositive() {
+		return 0
+	}
+	return money.Float(value.Div(p.equity))
+}
+
+// ExposureReservation 通过组合敞口检查的订单预占的敞口，下单失败时归还，挂单期间按未成交数量保留
+type ExposureReservation struct {
+	orderID   string // 经纪商受理后的订单ID
+	symbol    string
+	side      OrderSide
+	quantity  decimal.Decimal
+	unitValue decimal.Decimal // 每单位数量的市值（价格乘合约乘数）
+}
+
+// exposureBook 在途订单预占的组合敞口。检查期间持有锁，各账户下单队列并发下单时依次检查，
+// 后检查的订单计入先通过检查、尚未成交的订单，不会都按同一份持仓通过
+type exposureBook struct {
+	reservations map[*ExposureReservation]struct{}
+	mutex        sync.Mutex
+}
+
+// newExposureBook 创建在途敞口记录
+func newExposureBook() *exposureBook {
+	return &exposureBook{reservations: make(map[*ExposureReservation]struct{})}
+}
+
+// pending 与 side 同方向的在途订单按标的合计的市值（卖出为负数），调用方需持有锁。
+// 反方向的在途订单可能不成交，不抵减敞口
+func (b *exposureBook) pending(side OrderSide) map[string]decimal.Decimal {
+	values := make(map[string]decimal.Decimal)
+	for reservation := range b.reservations {
+		if reservation.side != side {
+			continue
+		}
+		value := reservation.quantity.Mul(reservation.unitValue)
+		if side == SellSide {
+			value = value.Neg()
+		}
+		values[reservation.symbol] = values[reservation.symbol].Add(value)
+	}
+	return values
+}
+
+// Release 归还未提交或已被拒绝的订单预占的敞口，reservation 为 nil 时忽略
+func (b *exposureBook) Release(reservation *ExposureReservation) {
+	if reservation == nil {
+		return
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	delete(b.reservations, reservation)
+}
+
+// Bind 经纪商受理订单后按订单ID保留预占，成交部分转入经纪商持仓，订单撤销或终止时归还剩余部分
+func (b *exposureBook) Bind(reservation *ExposureReservation, order *Order) {
+	if reservation == nil {
+		return
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if _, exists := b.reservations[reservation]; !exists {
+		return
+	}
+	if order.Status.IsTerminal() {
+		delete(b.reservations, reservation)
+		return
+	}
+	reservation.orderID = order.ID
+}
+
+// ReleaseOrder 订单撤销或终止后归还按订单ID保留的预占
+func (b *exposureBook) ReleaseOrder(orderID string) {
+	if orderID == "" {
+		return
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	for reservation := range b.reservations {
+		if reservation.orderID == orderID {
+			delete(b.reservations, reservation)
+		}
+	}
+}
+
+// RecordFill 成交部分已计入经纪商持仓，从预占中扣除
+func (b *exposureBook) RecordFill(filled *Order) {
+	if !filled.FilledQty.IsPositive() || filled.ID == "" {
+		return
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	for reservation := range b.reservations {
+		if reservation.orderID == filled.ID {
+			reservation.quantity = decimal.Max(reservation.quantity.Sub(filled.FilledQty), decimal.Zero)
+		}
+	}
+}
+
+// exposureConstraint 一个敞口限制：下单后 |base + 订单市值| 不能超过 limit
+type exposureConstraint struct {
+	name  string
+	base  decimal.Decimal
+	limit decimal.Decimal
+}
+
+// allowed 按方向（买入为1，卖出为-1）可增加的最大订单市值
+func (c exposureConstraint) allowed(sign int64) decimal.Decimal {
+	return c.limit.Sub(c.base.Mul(decimal.NewFromInt(sign)))
+}
+
+// constraints 标的适用的敞口限制
+func (p *portfolioExposure) constraints(cfg config.ExposureConfig, symbol string) []exposureConstraint {
+	value := p.values[symbol]
+	others := p.gross().Sub(value.Abs())
+	limit := func(ratio float64) decimal.Decimal { return p.equity.Mul(money.FromFloat(ratio)) }
+
+	var constraints []exposureConstraint
+	if cfg.MaxGrossExposure > 0 {
+		constraints = append(constraints, exposureConstraint{"组合总敞口超过限制", value, limit(cfg.MaxGrossExposure).Sub(others)})
+	}
+	if cfg.MaxSymbolWeight > 0 {
+		constraints = append(constraints, exposureConstraint{"单个标的权重超过限制", value, limit(cfg.MaxSymbolWeight)})
+	}
+	class := p.classes[symbol]
+	classExposure := p.byClass()[class]
+	if classExposure == nil {
+		classExposure = &exposure{}
+	}
+	if cfg.MaxNetExposure > 0 {
+		constraints = append(constraints, exposureConstraint{fmt.Sprintf("资产类别 %s 的净敞口超过限制", class), classExposure.net, limit(cfg.MaxNetExposure)})
+	}
+	if ratio, ok := cfg.AssetClasses[class]; ok {
+		constraints = append(constraints, exposureConstraint{fmt.Sprintf("资产类别 %s 的总敞口超过限制", class),
+			value, limit(ratio).Sub(classExposure.gross.Sub(value.Abs()))})
+	}
+	if sector := p.sectors[symbol]; sector != "" {
+		sectorExposure := p.bySector()[sector]
+		if sectorExposure == nil {
+			sectorExposure = &exposure{}
+		}
+		constraints = append(constraints, exposureConstraint{fmt.Sprintf("行业 %s 的总敞口超过限制", sector),
+			value, limit(cfg.Sectors[sector].MaxExposure).Sub(sectorExposure.gross.Sub(value.Abs()))})
+	}
+	return constraints
+}
+
+// sectorMembers 标的到行业的映射
+func sectorMembers(cfg config.ExposureConfig) map[string]string {
+	members := make(map[string]string)
+	for sector, sectorConfig := range cfg.Sectors {
+		for _, symbol := range sectorConfig.Symbols {
+			members[strings.ToUpper(symbol)] = sector
+		}
+	}
+	return members
+}
+
+// assetClass 标的的资产类别：期货、期权按合约规格，其他按持有账户的类别
+func (te *TradingEngine) assetClass(symbol, accountName string) string {
+	switch inst := te.instrumentRegistry().Lookup(symbol); inst.Type {
+	case instrument.Future, instrument.Option:
+		return string(inst.Type)
+	}
+	if accountConfig, exists := te.currentConfig().Accounts[accountName]; exists {
+		return accountConfig.AssetClass()
+	}
+	return "stock"
+}
+
+// collectExposure 获取全部账户的权益和持仓并按标的合并，strict 为 true 时任一账户获取失败即返回错误
+func (te *TradingEngine) collectExposure(strict bool) (*portfolioExposure, []string, error) {
+	te.mutex.RLock()
+	names := make([]string, 0, len(te.brokers))
+	for name := range te.brokers {
+		names = append(names, name)
+	}
+	te.mutex.RUnlock()
+	sort.Strings(names)
+
+	p := &portfolioExposure{
+		values:  make(map[string]decimal.Decimal),
+		classes: make(map[string]string),
+		sectors: sectorMembers(te.currentConfig().Risk.Exposure),
+	}
+	var failures []string
+	for _, name := range names {
+		positions, balance, err := te.accountHoldings(name)
+		if err != nil {
+			if strict {
+				return nil, nil, fmt.Errorf("获取账户 %s 的持仓失败，无法检查组合敞口: %w", name, err)
+			}
+			failures = append(failures, fmt.Sprintf("%s: %v", name, err))
+			continue
+		}
+		p.equity = p.equity.Add(balance)
+		for symbol, position := range positions {
+			p.equity = p.equity.Add(position.MarketValue)
+			p.values[symbol] = p.values[symbol].Add(position.MarketValue)
+			if _, exists := p.classes[symbol]; !exists {
+				p.classes[symbol] = te.assetClass(symbol, name)
+			}
+		}
+	}
+	return p, failures, nil
+}
+
+// accountHoldings 账户的持仓和现金余额
+func (te *TradingEngine) accountHoldings(accountName string) (map[string]Position, decimal.Decimal, error) {
+	broker, err := te.GetBroker(accountName)
+	if err != nil {
+		return nil, decimal.Zero, err
+	}
+	balance, err := broker.GetBalance()
+	if err != nil {
+		return nil, decimal.Zero, err
+	}
+	positions, err := broker.GetPositions()
+	if err != nil {
+		return nil, decimal.Zero, err
+	}
+	return positions, balance, nil
+}
+
+// checkExposure 下单前按全部账户合并后的持仓检查组合敞口限制：超限时按 risk.resize_orders 缩减或拒绝，
+// 减少敞口的订单总是允许。与订单同方向的在途订单按全部成交计入；检查和预占在同一次加锁中完成，
+// 通过检查的订单预占敞口，订单最终未提交时由调用方归还。未启用组合敞口限制时返回的预占为 nil
+func (te *TradingEngine) checkExposure(order Order, accountName string) (Order, *ExposureReservation, error) {
+	risk := te.currentConfig().Risk
+	cfg := risk.Exposure
+	if !cfg.Enabled() {
+		return order, nil, nil
+	}
+
+	price := order.Price
+	if !price.IsPositive() {
+		latest, err := te.latestPrice(order.Symbol)
+		if err != nil {
+			return order, nil, fmt.Errorf("获取 %s 价格失败，无法检查组合敞口: %w", order.Symbol, err)
+		}
+		price = money.FromFloat(latest)
+	}
+	unitValue := te.instruments.notional(order.Symbol, decimal.NewFromInt(1), price)
+	if !unitValue.IsPositive() {
+		return order, nil, nil
+	}
+
+	// 获取持仓期间也持有锁：其他订单的成交要么已计入经纪商持仓，要么仍在预占中
+	te.exposureBook.mutex.Lock()
+	defer te.exposureBook.mutex.Unlock()
+
+	p, _, err := te.collectExposure(true)
+	if err != nil {
+		return order, nil, err
+	}
+	for symbol, value := range te.exposureBook.pending(order.Side) {
+		p.values[symbol] = p.values[symbol].Add(value)
+		if _, exists := p.classes[symbol]; !exists {
+			p.classes[symbol] = te.assetClass(symbol, accountName)
+		}
+	}
+	if _, exists := p.classes[order.Symbol]; !exists {
+		p.classes[order.Symbol] = te.assetClass(order.Symbol, accountName)
+	}
+
+	sign := int64(1)
+	if order.Side == SellSide {
+		sign = -1
+	}
+	orderValue := order.Quantity.Mul(unitValue)
+	for _, constraint := range p.constraints(cfg, order.Symbol) {
+		allowed := constraint.allowed(sign)
+		if orderValue.LessThanOrEqual(allowed) {
+			continue
+		}
+		if !risk.ResizeOrders || !allowed.IsPositive() {
+			return order, nil, fmt.Errorf("%s: 订单市值 %s > 可用额度 %s", constraint.name,
+				orderValue.StringFixed(2), decimal.Max(allowed, decimal.Zero).StringFixed(2))
+		}
+		quantity := allowed.Div(unitValue)
+		if order.Quantity.IsInteger() {
+			quantity = quantity.Floor()
+		}
+		if !quantity.IsPositive() {
+			return order, nil, fmt.Errorf("%s: 缩减后数量为0", constraint.name)
+		}
+		log.Printf("%s，订单数量由 %s 缩减为 %s", constraint.name, order.Quantity, quantity)
+		order.Quantity = quantity
+		orderValue = quantity.Mul(unitValue)
+	}
+
+	reservation := &ExposureReservation{
+		symbol:    order.Symbol,
+		side:      order.Side,
+		quantity:  order.Quantity,
+		unitValue: unitValue,
+	}
+	te.exposureBook.reservations[reservation] = struct{}{}
+	return order, reservation, nil
+}
+
+// GetExposure 全部账户合并后的组合敞口和当前超出的限制
+func (te *TradingEngine) GetExposure() *ExposureReport {
+	cfg := te.currentConfig().Risk.Exposure
+	p, failures, _ := te.collectExposure(false)
+
+	report := &ExposureReport{
+		Time:       time.Now(),
+		Equity:     p.equity,
+		Gross:      p.gross(),
+		GrossRatio: p.ratio(p.gross()),
+		Limits:     cfg,
+		Errors:     failures,
+	}
+	for symbol, value := range p.values {
+		report.Net = report.Net.Add(value)
+		report.Symbols = append(report.Symbols, SymbolExposure{
+			Symbol:     symbol,
+			AssetClass: p.classes[symbol],
+			Sector:     p.sectors[symbol],
+			Value:      value,
+			Weight:     p.ratio(value),
+		})
+		if limit := cfg.MaxSymbolWeight; limit > 0 && p.ratio(value.Abs()) > limit {
+			report.Breaches = append(report.Breaches, fmt.Sprintf("标的 %s 权重 %.2f%% > %.2f%%", symbol, p.ratio(value.Abs())*100, limit*100))
+		}
+	}
+	report.NetRatio = p.ratio(report.Net)
+	sort.Slice(report.Symbols, func(i, j int) bool {
+		a, b := report.Symbols[i].Value.Abs(), report.Symbols[j].Value.Abs()
+		if !a.Equal(b) {
+			return a.GreaterThan(b)
+		}
+		return report.Symbols[i].Symbol < report.Symbols[j].Symbol
+	})
+	if limit := cfg.MaxGrossExposure; limit > 0 && report.GrossRatio > limit {
+		report.Breaches = append(report.Breaches, fmt.Sprintf("组合总敞口 %.2f%% > %.2f%%", report.GrossRatio*100, limit*100))
+	}
+
+	for name, group := range p.byClass() {
+		bucket := ExposureBucket{Name: name, Gross: group.gross, Net: group.net,
+			GrossRatio: p.ratio(group.gross), NetRatio: p.ratio(group.net), Limit: cfg.AssetClasses[name]}
+		if bucket.Limit > 0 && bucket.GrossRatio > bucket.Limit {
+			report.Breaches = append(report.Breaches, fmt.Sprintf("资产类别 %s 总敞口 %.2f%% > %.2f%%", name, bucket.GrossRatio*100, bucket.Limit*100))
+		}
+		if limit := cfg.MaxNetExposure; limit > 0 && p.ratio(group.net.Abs()) > limit {
+			report.Breaches = append(report.Breaches, fmt.Sprintf("资产类别 %s 净敞口 %.2f%% > %.2f%%", name, bucket.NetRatio*100, limit*100))
+		}
+		report.AssetClasses = append(report.AssetClasses, bucket)
+	}
+	sort.Slice(report.AssetClasses, func(i, j int) bool { return report.AssetClasses[i].Name < report.AssetClasses[j].Name })
+
+	groups := p.bySector()
+	for name, sectorConfig := range cfg.Sectors {
+		group := groups[name]
+		if group == nil {
+			group = &exposure{}
+		}
+		bucket := ExposureBucket{Name: name, Gross: group.gross, Net: group.net,
+			GrossRatio: p.ratio(group.gross), NetRatio: p.ratio(group.net), Limit: sectorConfig.MaxExposure}
+		if bucket.GrossRatio > bucket.Limit {
+			report.Breaches = append(report.Breaches, fmt.Sprintf("行业 %s 总敞口 %.2f%% > %.2f%%", name, bucket.GrossRatio*100, bucket.Limit*100))
+		}
+		report.Sectors = append(report.Sectors, bucket)
+	}
+	sort.Slice(report.Sectors, func(i, j int) bool { return report.Sectors[i].Name < report.Sectors[j].Name })
+	sort.Strings(report.Breaches)
+	return report
+}
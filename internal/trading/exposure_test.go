@@ -0,0 +1,130 @@
+package trading
+
+import (
+	"sync"
+	"testing"
+
+	"agent-quant-system/internal/account"
+	"agent-quant-system/internal/config"
+
+	"github.com/shopspring/decimal"
+)
+
+// newExposureTestEngine 创建两个各有 100000 初始资金的模拟股票账户，单个标的权重上限为 10%（20000）
+func newExposureTestEngine(t *testing.T, resize bool) *TradingEngine {
+	t.Helper()
+
+	cfg := &config.Config{
+		Accounts: map[string]config.AccountConfig{
+			"a": {APIKey: "key", APISecret: "secret", BrokerType: "stock", InitialBalance: 100000},
+			"b": {APIKey: "key", APISecret: "secret", BrokerType: "stock", InitialBalance: 100000},
+		},
+	}
+	cfg.Trading.OrderConcurrency = 1
+	cfg.Trading.OrderQueueSize = 10
+	cfg.Risk.ResizeOrders = resize
+	cfg.Risk.Exposure.MaxSymbolWeight = 0.1
+
+	engine := NewTradingEngine(cfg, account.NewAccountManager(cfg, nil))
+	if err := engine.Start(); err != nil {
+		t.Fatalf("启动交易引擎失败: %v", err)
+	}
+	t.Cleanup(func() { engine.Stop() })
+	return engine
+}
+
+func TestExposureReservationsUnderConcurrency(t *testing.T) {
+	tests := []struct {
+		name       string
+		resize     bool
+		orders     int   // 两个账户交替并发提交的买入订单数，每笔 quantity 股 @ 100
+		quantity   int64 // 每笔订单的数量
+		wantPassed int
+		wantTotal  int64 // 通过检查的订单数量合计
+	}{
+		{"拒绝超出额度的订单", false, 10, 100, 2, 200},
+		{"额度用完后缩减再拒绝", true, 10, 150, 2, 200},
+		{"额度内全部通过", false, 4, 50, 4, 200},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := newExposureTestEngine(t, tt.resize)
+
+			var wg sync.WaitGroup
+			var mutex sync.Mutex
+			var passed []Order
+			for i := 0; i < tt.orders; i++ {
+				accountName := "a"
+				if i%2 == 1 {
+					accountName = "b"
+				}
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					order := Order{Symbol: "AAPL", Side: BuySide, Type: LimitOrder,
+						Quantity: decimal.NewFromInt(tt.quantity), Price: decimal.NewFromInt(100)}
+					checked, reservation, err := engine.checkExposure(order, accountName)
+					if err != nil {
+						return
+					}
+					if reservation == nil {
+						t.Errorf("通过检查的订单没有预占敞口")
+						return
+					}
+					mutex.Lock()
+					passed = append(passed, checked)
+					mutex.Unlock()
+				}()
+			}
+			wg.Wait()
+
+			total := decimal.Zero
+			for _, order := range passed {
+				total = total.Add(order.Quantity)
+			}
+			if len(passed) != tt.wantPassed || !total.Equal(decimal.NewFromInt(tt.wantTotal)) {
+				t.Fatalf("通过 %d 笔共 %s 股, 期望 %d 笔共 %d 股", len(passed), total, tt.wantPassed, tt.wantTotal)
+			}
+			engine.exposureBook.mutex.Lock()
+			reserved := engine.exposureBook.pending(BuySide)["AAPL"]
+			engine.exposureBook.mutex.Unlock()
+			if !reserved.Equal(decimal.NewFromInt(tt.wantTotal * 100)) {
+				t.Fatalf("在途预占 = %s, 期望 %d", reserved, tt.wantTotal*100)
+			}
+		})
+	}
+}
+
+func TestExposureReservationLifecycle(t *testing.T) {
+	engine := newExposureTestEngine(t, false)
+	order := Order{Symbol: "AAPL", Side: BuySide, Type: LimitOrder, Quantity: decimal.NewFromInt(200), Price: decimal.NewFromInt(100)}
+
+	_, reservation, err := engine.checkExposure(order, "a")
+	if err != nil {
+		t.Fatalf("额度内的订单被拒绝: %v", err)
+	}
+	if _, _, err := engine.checkExposure(order, "b"); err == nil {
+		t.Fatalf("额度已被在途订单占满, 期望拒绝")
+	}
+	// 反方向的在途订单不抵减敞口，卖出仍按当前持仓检查
+	if _, _, err := engine.checkExposure(Order{Symbol: "AAPL", Side: SellSide, Type: LimitOrder,
+		Quantity: decimal.NewFromInt(100), Price: decimal.NewFromInt(100)}, "b"); err != nil {
+		t.Fatalf("卖出订单不应受买入在途订单影响: %v", err)
+	}
+
+	// 受理后部分成交的数量从预占中扣除，撤单后归还剩余部分
+	engine.exposureBook.Bind(reservation, &Order{ID: "order-1", Status: Submitted})
+	engine.exposureBook.RecordFill(&Order{ID: "order-1", FilledQty: decimal.NewFromInt(50)})
+	engine.exposureBook.mutex.Lock()
+	reserved := engine.exposureBook.pending(BuySide)["AAPL"]
+	engine.exposureBook.mutex.Unlock()
+	if !reserved.Equal(decimal.NewFromInt(15000)) {
+		t.Fatalf("部分成交后在途预占 = %s, 期望 15000", reserved)
+	}
+
+	engine.releaseReservations("order-1")
+	if _, reservation, err := engine.checkExposure(order, "b"); err != nil || reservation == nil {
+		t.Fatalf("撤单归还预占后额度内的订单被拒绝: %v", err)
+	}
+}
@@ -0,0 +1,40 @@
+//go:build !unix
+
+package trading
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// lockStaleAfter 锁文件存在超过该时长视为持锁进程已退出
+const lockStaleAfter = 30 * time.Second
+
+// lockFile 以独占创建 <path>.lock 的方式获取跨进程排他锁，返回释放函数
+func lockFile(path string) (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("创建锁文件目录失败: %w", err)
+	}
+	name := path + ".lock"
+	deadline := time.Now().Add(lockStaleAfter)
+	for {
+		lock, err := os.OpenFile(name, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			lock.Close()
+			return func() { os.Remove(name) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("创建锁文件失败: %w", err)
+		}
+		if info, statErr := os.Stat(name); statErr == nil && time.Since(info.ModTime()) > lockStaleAfter {
+			os.Remove(name)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("获取文件锁超时: %s", name)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
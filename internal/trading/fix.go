@@ -0,0 +1,915 @@
+package trading
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"agent-quant-system/internal/config"
+	"agent-quant-system/internal/fix"
+	"agent-quant-system/internal/money"
+
+	"github.com/shopspring/decimal"
+)
+
+// FIX 会话的默认设置
+const (
+	defaultFIXHeartbeat    = 30 * time.Second
+	defaultFIXLogonTimeout = 10 * time.Second
+	defaultFIXAckTimeout   = 5 * time.Second
+	fixLogoutWait          = 2 * time.Second
+	fixMaxTrades           = 1000 // 本地保留的成交记录条数
+)
+
+// fixSession 一次 TCP 连接上的 FIX 会话状态
+type fixSession struct {
+	conn          net.Conn
+	writeMutex    sync.Mutex
+	logon         chan error    // 登录应答或登录失败
+	logout        chan struct{} // 收到对方的 Logout
+	stop          chan struct{}
+	lastSent      time.Time
+	lastReceived  time.Time
+	testRequestAt time.Time // 已发送、尚未收到应答的 TestRequest
+	loggedOn      bool
+	loggingOut    bool // 本方已发送 Logout
+}
+
+// fixPending 等待首个执行报告或撤单拒绝的请求
+type fixPending struct {
+	reply chan *fix.Message
+}
+
+// FIXBroker 通过 FIX 4.4 会话接入机构经纪商：NewOrderSingle 下单、OrderCancelRequest 撤单，
+// 按 ExecutionReport 更新订单状态；余额、持仓和成交按本会话收到的成交回报在本地推算
+type FIXBroker struct {
+	name        string
+	config      config.FIXConfig
+	precision   *money.PrecisionTable
+	credentials CredentialSource
+	heartbeat   time.Duration
+
+	session   *fixSession
+	outSeq    int               // 下一条发出消息的序号
+	inSeq     int               // 期望收到的下一条消息序号
+	orders    map[string]Order  // 客户端订单号（即订单ID）-> 订单
+	brokerIDs map[string]string // 客户端订单号 -> 经纪商订单号（37）
+	cancels   map[string]string // 撤单请求的客户端订单号 -> 原订单
+	pending   map[string]*fixPending
+	rejects   map[string]string // 客户端订单号 -> 拒单原因（58）
+	execSeen  map[string]bool
+	balance   decimal.Decimal
+	positions map[string]Position
+	trades    []Trade
+	callbacks []func(Order)
+	wg        sync.WaitGroup
+	mutex     sync.Mutex
+}
+
+// NewFIXBroker 创建 FIX 经纪商，balance 为本地推算余额的初始值
+func NewFIXBroker(name string, cfg config.FIXConfig, balance decimal.Decimal, precision *money.PrecisionTable, credentials CredentialSource) *FIXBroker {
+	heartbeat := cfg.HeartbeatInterval
+	if heartbeat <= 0 {
+		heartbeat = defaultFIXHeartbeat
+	}
+	if cfg.LogonTimeout <= 0 {
+		cfg.LogonTimeout = defaultFIXLogonTimeout
+	}
+	if cfg.AckTimeout <= 0 {
+		cfg.AckTimeout = defaultFIXAckTimeout
+	}
+	return &FIXBroker{
+		name:        name,
+		config:      cfg,
+		precision:   precision,
+		credentials: credentials,
+		heartbeat:   heartbeat,
+		outSeq:      1,
+		inSeq:       1,
+		orders:      make(map[string]Order),
+		brokerIDs:   make(map[string]string),
+		cancels:     make(map[string]string),
+		pending:     make(map[string]*fixPending),
+		rejects:     make(map[string]string),
+		execSeen:    make(map[string]bool),
+		balance:     balance,
+		positions:   make(map[string]Position),
+	}
+}
+
+// fixSide 系统订单方向对应的 Side（54）
+func fixSide(side OrderSide) string {
+	if side == SellSide {
+		return "2"
+	}
+	return "1"
+}
+
+// mapFIXSide Side（54）对应的系统订单方向，卖空（5、6）视为卖出
+func mapFIXSide(side string) OrderSide {
+	switch side {
+	case "2", "5", "6":
+		return SellSide
+	default:
+		return BuySide
+	}
+}
+
+// fixOrdType 系统订单类型对应的 OrdType（40）
+func fixOrdType(orderType OrderType) string {
+	switch orderType {
+	case LimitOrder:
+		return "2"
+	case StopOrder:
+		return "3"
+	default:
+		return "1"
+	}
+}
+
+// fixTimeInForce 订单有效期对应的 TimeInForce（59），未指定时为当日有效
+func fixTimeInForce(tif TimeInForce) string {
+	switch tif {
+	case GTC:
+		return "1"
+	case IOC:
+		return "3"
+	case FOK:
+		return "4"
+	default:
+		return "0"
+	}
+}
+
+// mapFIXOrdStatus OrdStatus（39）对应的系统订单状态，ok 为 false 表示不改变状态（如待撤单、待改单）
+func mapFIXOrdStatus(status string) (OrderStatus, bool) {
+	switch status {
+	case "0":
+		return Submitted, true
+	case "1":
+		return PartiallyFilled, true
+	case "2":
+		return Filled, true
+	case "4":
+		return Cancelled, true
+	case "8":
+		return Rejected, true
+	case "3", "C":
+		// 当日结束（Done for day）和过期的订单不会再成交
+		return Expired, true
+	case "A":
+		return Pending, true
+	default:
+		return "", false
+	}
+}
+
+// Connect 建立连接并登录，登录成功后启动消息接收和心跳
+func (b *FIXBroker) Connect() error {
+	b.mutex.Lock()
+	if b.session != nil && b.session.loggedOn {
+		b.mutex.Unlock()
+		return nil
+	}
+	b.mutex.Unlock()
+
+	log.Printf("连接到 FIX 会话: %s, 地址=%s, %s -> %s", b.name, b.config.Address, b.config.SenderCompID, b.config.TargetCompID)
+	dialer := &net.Dialer{Timeout: b.config.LogonTimeout}
+	var conn net.Conn
+	var err error
+	if b.config.TLS {
+		conn, err = tls.DialWithDialer(dialer, "tcp", b.config.Address, &tls.Config{InsecureSkipVerify: b.config.InsecureSkipVerify})
+	} else {
+		conn, err = dialer.Dial("tcp", b.config.Address)
+	}
+	if err != nil {
+		return fmt.Errorf("连接 FIX 会话失败: %v: %w", err, ErrBrokerUnavailable)
+	}
+
+	session := &fixSession{
+		conn:         conn,
+		logon:        make(chan error, 1),
+		logout:       make(chan struct{}),
+		stop:         make(chan struct{}),
+		lastReceived: time.Now(),
+	}
+	b.mutex.Lock()
+	b.session = session
+	if !b.config.KeepSequence {
+		b.outSeq, b.inSeq = 1, 1
+	}
+	b.mutex.Unlock()
+
+	b.wg.Add(1)
+	go b.receive(session)
+
+	logon := fix.NewMessage(fix.MsgLogon).
+		SetInt(fix.TagEncryptMethod, 0).
+		SetInt(fix.TagHeartBtInt, int(b.heartbeat/time.Second))
+	if !b.config.KeepSequence {
+		logon.Set(fix.TagResetSeqNumFlag, "Y")
+	}
+	if b.credentials != nil {
+		username, password, err := b.credentials()
+		if err != nil {
+			b.closeSession(session)
+			return fmt.Errorf("获取 FIX 登录凭证失败: %w", err)
+		}
+		if username != "" {
+			logon.Set(fix.TagUsername, username)
+		}
+		if password != "" {
+			logon.Set(fix.TagPassword, password)
+		}
+	}
+	if err := b.send(session, logon); err != nil {
+		b.closeSession(session)
+		return err
+	}
+
+	select {
+	case err := <-session.logon:
+		if err != nil {
+			b.closeSession(session)
+			return err
+		}
+	case <-time.After(b.config.LogonTimeout):
+		b.closeSession(session)
+		return fmt.Errorf("等待 FIX 登录应答超时: %w", ErrBrokerUnavailable)
+	}
+
+	b.wg.Add(1)
+	go b.keepAlive(session)
+	log.Printf("FIX 会话已登录: %s", b.name)
+	return nil
+}
+
+// Disconnect 发送 Logout 并等待对方确认后关闭连接
+func (b *FIXBroker) Disconnect() error {
+	b.mutex.Lock()
+	session := b.session
+	b.mutex.Unlock()
+	if session == nil {
+		return nil
+	}
+
+	b.mutex.Lock()
+	loggedOn := session.loggedOn
+	session.loggingOut = true
+	b.mutex.Unlock()
+	if loggedOn {
+		if err := b.send(session, fix.NewMessage(fix.MsgLogout)); err == nil {
+			select {
+			case <-session.logout:
+			case <-time.After(fixLogoutWait):
+			}
+		}
+	}
+	b.closeSession(session)
+	b.wg.Wait()
+	log.Printf("断开 FIX 会话: %s", b.name)
+	return nil
+}
+
+// closeSession 关闭连接，会话失效后挂起的请求按超时处理
+func (b *FIXBroker) closeSession(session *fixSession) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	select {
+	case <-session.stop:
+		return
+	default:
+	}
+	close(session.stop)
+	session.loggedOn = false
+	session.conn.Close()
+	if b.session == session {
+		b.session = nil
+	}
+}
+
+// Ping 健康检查：会话已登录且在心跳间隔内收到过对方的消息
+func (b *FIXBroker) Ping() error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.session == nil || !b.session.loggedOn {
+		return fmt.Errorf("FIX 会话未登录: %w", ErrBrokerUnavailable)
+	}
+	if silent := time.Since(b.session.lastReceived); silent > 2*b.heartbeat {
+		return fmt.Errorf("FIX 会话已 %v 未收到消息: %w", silent.Round(time.Second), ErrBrokerUnavailable)
+	}
+	return nil
+}
+
+// OnOrderUpdate 注册订单状态变化回调
+func (b *FIXBroker) OnOrderUpdate(callback func(Order)) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.callbacks = append(b.callbacks, callback)
+}
+
+// activeSession 已登录的会话
+func (b *FIXBroker) activeSession() (*fixSession, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.session == nil || !b.session.loggedOn {
+		return nil, fmt.Errorf("FIX 会话未登录: %w", ErrBrokerUnavailable)
+	}
+	return b.session, nil
+}
+
+// send 补齐标准消息头（49、56、34、52）后发送，序号递增
+func (b *FIXBroker) send(session *fixSession, message *fix.Message) error {
+	session.writeMutex.Lock()
+	defer session.writeMutex.Unlock()
+
+	b.mutex.Lock()
+	seq := b.outSeq
+	b.outSeq++
+	b.mutex.Unlock()
+	return b.write(session, message, seq)
+}
+
+// write 以指定序号发送消息，调用方需持有 writeMutex
+func (b *FIXBroker) write(session *fixSession, message *fix.Message, seq int) error {
+	framed := fix.NewMessage(message.MsgType()).
+		Set(fix.TagSenderCompID, b.config.SenderCompID).
+		Set(fix.TagTargetCompID, b.config.TargetCompID).
+		SetInt(fix.TagMsgSeqNum, seq).
+		SetTime(fix.TagSendingTime, time.Now())
+	for _, field := range message.Fields {
+		if field.Tag != fix.TagMsgType {
+			framed.Set(field.Tag, field.Value)
+		}
+	}
+
+	session.conn.SetWriteDeadline(time.Now().Add(b.config.LogonTimeout))
+	if _, err := session.conn.Write(framed.Bytes()); err != nil {
+		b.closeSession(session)
+		return fmt.Errorf("发送 FIX 消息失败: %v: %w", err, ErrBrokerUnavailable)
+	}
+	b.mutex.Lock()
+	session.lastSent = time.Now()
+	b.mutex.Unlock()
+	return nil
+}
+
+// receive 接收并处理消息，连接断开或解析失败时关闭会话
+func (b *FIXBroker) receive(session *fixSession) {
+	defer b.wg.Done()
+
+	reader := fix.NewReader(session.conn)
+	for {
+		message, err := reader.ReadMessage()
+		if err != nil {
+			select {
+			case <-session.stop:
+			default:
+				log.Printf("FIX 会话 %s 连接中断: %v", b.name, err)
+			}
+			select {
+			case session.logon <- fmt.Errorf("FIX 会话在登录前断开: %v: %w", err, ErrBrokerUnavailable):
+			default:
+			}
+			b.closeSession(session)
+			return
+		}
+		b.handle(session, message)
+	}
+}
+
+// handle 处理一条消息：检查序号，处理会话层消息，执行报告和撤单拒绝交给订单处理
+func (b *FIXBroker) handle(session *fixSession, message *fix.Message) {
+	msgType := message.MsgType()
+	seq := message.GetInt(fix.TagMsgSeqNum)
+	possDup := message.Get(fix.TagPossDupFlag) == "Y"
+
+	b.mutex.Lock()
+	session.lastReceived = time.Now()
+	session.testRequestAt = time.Time{}
+	if msgType == fix.MsgLogon && message.Get(fix.TagResetSeqNumFlag) == "Y" {
+		b.inSeq = seq
+	}
+	expected := b.inSeq
+	gap := false
+	switch {
+	case msgType == fix.MsgSequenceReset:
+		// SequenceReset 直接设置下一条消息的序号
+	case seq > expected:
+		gap = true
+		b.inSeq = seq + 1
+	case seq == expected:
+		b.inSeq++
+	case !possDup:
+		b.mutex.Unlock()
+		log.Printf("FIX 消息序号 %d 小于期望的 %d，断开会话", seq, expected)
+		b.send(session, fix.NewMessage(fix.MsgLogout).Set(fix.TagText, fmt.Sprintf("MsgSeqNum too low, expecting %d but received %d", expected, seq)))
+		b.closeSession(session)
+		return
+	}
+	b.mutex.Unlock()
+
+	if gap {
+		// 不缓存乱序消息，照常处理；重发的执行报告按 ExecID 去重
+		log.Printf("FIX 消息序号缺口: 期望 %d, 收到 %d，请求重发", expected, seq)
+		b.send(session, fix.NewMessage(fix.MsgResendRequest).SetInt(fix.TagBeginSeqNo, expected).SetInt(fix.TagEndSeqNo, seq-1))
+	}
+
+	switch msgType {
+	case fix.MsgLogon:
+		b.mutex.Lock()
+		session.loggedOn = true
+		b.mutex.Unlock()
+		select {
+		case session.logon <- nil:
+		default:
+		}
+	case fix.MsgHeartbeat:
+	case fix.MsgTestRequest:
+		b.send(session, fix.NewMessage(fix.MsgHeartbeat).Set(fix.TagTestReqID, message.Get(fix.TagTestReqID)))
+	case fix.MsgResendRequest:
+		b.gapFill(session, message.GetInt(fix.TagBeginSeqNo))
+	case fix.MsgSequenceReset:
+		if next := message.GetInt(fix.TagNewSeqNo); next > 0 {
+			b.mutex.Lock()
+			if next > b.inSeq || message.Get(fix.TagGapFillFlag) != "Y" {
+				b.inSeq = next
+			}
+			b.mutex.Unlock()
+		}
+	case fix.MsgLogout:
+		text := message.Get(fix.TagText)
+		log.Printf("FIX 会话 %s 收到 Logout: %s", b.name, text)
+		select {
+		case session.logon <- fmt.Errorf("FIX 登录被拒绝: %s: %w", text, ErrBrokerUnavailable):
+		default:
+		}
+		b.mutex.Lock()
+		initiated := session.loggedOn && !session.loggingOut
+		session.loggedOn = false
+		b.mutex.Unlock()
+		select {
+		case <-session.logout:
+		default:
+			close(session.logout)
+		}
+		if initiated {
+			// 对方发起的登出需要回复 Logout
+			b.send(session, fix.NewMessage(fix.MsgLogout))
+		}
+		b.closeSession(session)
+	case fix.MsgReject, fix.MsgBusinessReject:
+		log.Printf("FIX 消息被拒绝: 引用序号=%s, 原因=%s", message.Get(fix.TagRefSeqNum), message.Get(fix.TagText))
+	case fix.MsgExecutionReport:
+		b.handleExecutionReport(message)
+	case fix.MsgOrderCancelReject:
+		b.handleCancelReject(message)
+	default:
+		log.Printf("忽略 FIX 消息: 类型=%s", msgType)
+	}
+}
+
+// gapFill 不保存已发送的消息，对方请求重发时以 SequenceReset-GapFill 跳过（订单类消息不重发，避免重复下单）
+func (b *FIXBroker) gapFill(session *fixSession, begin int) {
+	session.writeMutex.Lock()
+	defer session.writeMutex.Unlock()
+
+	b.mutex.Lock()
+	next := b.outSeq
+	b.mutex.Unlock()
+	if begin <= 0 || begin >= next {
+		return
+	}
+	reset := fix.NewMessage(fix.MsgSequenceReset).
+		Set(fix.TagPossDupFlag, "Y").
+		Set(fix.TagGapFillFlag, "Y").
+		SetInt(fix.TagNewSeqNo, next)
+	if err := b.write(session, reset, begin); err != nil {
+		log.Printf("发送 SequenceReset 失败: %v", err)
+	}
+}
+
+// keepAlive 发送心跳；超过心跳间隔未收到消息时发送 TestRequest，再过一个间隔仍无应答则断开会话
+func (b *FIXBroker) keepAlive(session *fixSession) {
+	defer b.wg.Done()
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-session.stop:
+			return
+		case now := <-ticker.C:
+			b.mutex.Lock()
+			idle := now.Sub(session.lastSent)
+			silent := now.Sub(session.lastReceived)
+			testRequestAt := session.testRequestAt
+			b.mutex.Unlock()
+
+			switch {
+			case !testRequestAt.IsZero() && now.Sub(testRequestAt) >= b.heartbeat:
+				log.Printf("FIX 会话 %s 未应答 TestRequest，断开连接", b.name)
+				b.closeSession(session)
+				return
+			case testRequestAt.IsZero() && silent >= b.heartbeat+b.heartbeat/5:
+				b.mutex.Lock()
+				session.testRequestAt = now
+				b.mutex.Unlock()
+				b.send(session, fix.NewMessage(fix.MsgTestRequest).Set(fix.TagTestReqID, strconv.FormatInt(now.UnixNano(), 10)))
+			case idle >= b.heartbeat:
+				b.send(session, fix.NewMessage(fix.MsgHeartbeat))
+			}
+		}
+	}
+}
+
+// await 登记等待 clOrdID 的应答
+func (b *FIXBroker) await(clOrdID string) *fixPending {
+	pending := &fixPending{reply: make(chan *fix.Message, 1)}
+	b.mutex.Lock()
+	b.pending[clOrdID] = pending
+	b.mutex.Unlock()
+	return pending
+}
+
+// resolve 把应答交给等待的请求，调用方需持有锁
+func (b *FIXBroker) resolve(clOrdID string, message *fix.Message) {
+	if pending, exists := b.pending[clOrdID]; exists {
+		delete(b.pending, clOrdID)
+		pending.reply <- message
+	}
+}
+
+// wait 等待应答，超时时取消登记
+func (b *FIXBroker) wait(clOrdID string, pending *fixPending) (*fix.Message, bool) {
+	select {
+	case message := <-pending.reply:
+		return message, true
+	case <-time.After(b.config.AckTimeout):
+		b.mutex.Lock()
+		delete(b.pending, clOrdID)
+		b.mutex.Unlock()
+		return nil, false
+	}
+}
+
+// PlaceOrder 发送 NewOrderSingle，等待首个执行报告确认受理；超时时返回可按客户端订单号确认的 OrderSubmitError
+func (b *FIXBroker) PlaceOrder(order Order) (*Order, error) {
+	session, err := b.activeSession()
+	if err != nil {
+		return nil, err
+	}
+
+	if order.ClientOrderID == "" {
+		order.ClientOrderID = newClientOrderID()
+	}
+	b.mutex.Lock()
+	if existing, exists := b.orders[order.ClientOrderID]; exists {
+		b.mutex.Unlock()
+		return &existing, nil
+	}
+	b.mutex.Unlock()
+
+	precision := b.precision.For(order.Symbol)
+	order.ID = order.ClientOrderID
+	order.Quantity = precision.RoundQuantity(order.Quantity)
+	order.Status = Pending
+	order.AccountName = b.name
+	order.CreateTime = time.Now()
+	order.UpdateTime = order.CreateTime
+
+	message := fix.NewMessage(fix.MsgNewOrderSingle).
+		Set(fix.TagClOrdID, order.ClientOrderID).
+		Set(fix.TagSymbol, order.Symbol).
+		Set(fix.TagSide, fixSide(order.Side)).
+		SetTime(fix.TagTransactTime, order.CreateTime).
+		Set(fix.TagOrderQty, order.Quantity.String()).
+		Set(fix.TagOrdType, fixOrdType(order.Type)).
+		Set(fix.TagTimeInForce, fixTimeInForce(order.TimeInForce))
+	if b.config.Account != "" {
+		message.Set(fix.TagAccount, b.config.Account)
+	}
+	switch order.Type {
+	case LimitOrder:
+		message.Set(fix.TagPrice, precision.RoundPrice(order.Price).String())
+	case StopOrder:
+		message.Set(fix.TagStopPx, precision.RoundPrice(order.StopPrice).String())
+	}
+
+	b.mutex.Lock()
+	b.orders[order.ID] = order
+	b.mutex.Unlock()
+	pending := b.await(order.ClientOrderID)
+	if err := b.send(session, message); err != nil {
+		b.mutex.Lock()
+		delete(b.pending, order.ClientOrderID)
+		b.mutex.Unlock()
+		return nil, &OrderSubmitError{ClientOrderID: order.ClientOrderID, Err: err}
+	}
+
+	if _, ok := b.wait(order.ClientOrderID, pending); !ok {
+		return nil, &OrderSubmitError{
+			ClientOrderID: order.ClientOrderID,
+			Err:           fmt.Errorf("等待 FIX 执行报告超时: %w", ErrBrokerUnavailable),
+		}
+	}
+
+	b.mutex.Lock()
+	accepted := b.orders[order.ID]
+	reason := b.rejects[order.ID]
+	brokerID := b.brokerIDs[order.ID]
+	b.mutex.Unlock()
+	if accepted.Status == Rejected {
+		return nil, fmt.Errorf("经纪商拒绝订单: %s", reason)
+	}
+	log.Printf("FIX 订单已受理: 订单ID=%s, 经纪商订单号=%s, 标的=%s, 方向=%s, 数量=%s, 状态=%s",
+		accepted.ID, brokerID, accepted.Symbol, accepted.Side, accepted.Quantity, accepted.Status)
+	return &accepted, nil
+}
+
+// CancelOrder 发送 OrderCancelRequest，等待撤单确认或拒绝
+func (b *FIXBroker) CancelOrder(orderID string) error {
+	session, err := b.activeSession()
+	if err != nil {
+		return err
+	}
+
+	b.mutex.Lock()
+	order, exists := b.orders[orderID]
+	brokerID := b.brokerIDs[orderID]
+	b.mutex.Unlock()
+	if !exists {
+		return fmt.Errorf("订单 %s: %w", orderID, ErrOrderNotFound)
+	}
+	if order.Status.IsTerminal() {
+		return fmt.Errorf("订单 %s 已是终止状态 %s", orderID, order.Status)
+	}
+
+	cancelID := newClientOrderID()
+	message := fix.NewMessage(fix.MsgOrderCancelRequest).
+		Set(fix.TagOrigClOrdID, orderID).
+		Set(fix.TagClOrdID, cancelID).
+		Set(fix.TagSymbol, order.Symbol).
+		Set(fix.TagSide, fixSide(order.Side)).
+		SetTime(fix.TagTransactTime, time.Now()).
+		Set(fix.TagOrderQty, order.Quantity.String())
+	if brokerID != "" {
+		message.Set(fix.TagOrderID, brokerID)
+	}
+	if b.config.Account != "" {
+		message.Set(fix.TagAccount, b.config.Account)
+	}
+
+	b.mutex.Lock()
+	b.cancels[cancelID] = orderID
+	b.mutex.Unlock()
+	pending := b.await(cancelID)
+	if err := b.send(session, message); err != nil {
+		return fmt.Errorf("撤单失败: %w", err)
+	}
+
+	reply, ok := b.wait(cancelID, pending)
+	b.mutex.Lock()
+	delete(b.cancels, cancelID)
+	b.mutex.Unlock()
+	if !ok {
+		return fmt.Errorf("等待撤单确认超时: %w", ErrBrokerUnavailable)
+	}
+	if reply.MsgType() == fix.MsgOrderCancelReject {
+		return fmt.Errorf("经纪商拒绝撤单: %s", reply.Get(fix.TagText))
+	}
+	return nil
+}
+
+// handleExecutionReport 按执行报告更新订单；成交（ExecType=F）按 ExecID 去重后记入本地成交、持仓和余额
+func (b *FIXBroker) handleExecutionReport(message *fix.Message) {
+	clOrdID := message.Get(fix.TagClOrdID)
+
+	b.mutex.Lock()
+	id := clOrdID
+	if original, exists := b.cancels[clOrdID]; exists {
+		id = original
+	} else if _, exists := b.orders[clOrdID]; !exists {
+		if original := message.Get(fix.TagOrigClOrdID); original != "" {
+			id = original
+		}
+	}
+
+	order, exists := b.orders[id]
+	if !exists {
+		// 其他会话或人工下的订单
+		order = Order{
+			ID:            id,
+			ClientOrderID: id,
+			Symbol:        message.Get(fix.TagSymbol),
+			Side:          mapFIXSide(message.Get(fix.TagSide)),
+			Type:          MarketOrder,
+			Quantity:      decimalOrZero(message.Get(fix.TagOrderQty)),
+			Price:         decimalOrZero(message.Get(fix.TagPrice)),
+			AccountName:   b.name,
+			CreateTime:    time.Now(),
+		}
+		if message.Get(fix.TagOrdType) == "2" {
+			order.Type = LimitOrder
+		}
+	}
+	previous := order
+
+	if brokerID := message.Get(fix.TagOrderID); brokerID != "" && brokerID != "NONE" {
+		b.brokerIDs[id] = brokerID
+	}
+	if status, ok := mapFIXOrdStatus(message.Get(fix.TagOrdStatus)); ok {
+		order.Status = status
+	}
+	if message.Has(fix.TagCumQty) {
+		order.FilledQty = decimalOrZero(message.Get(fix.TagCumQty))
+	}
+	if message.Has(fix.TagAvgPx) {
+		order.AvgPrice = decimalOrZero(message.Get(fix.TagAvgPx))
+	}
+	if order.Status == Rejected {
+		b.rejects[id] = message.Get(fix.TagText)
+	}
+	order.UpdateTime = time.Now()
+
+	execID := message.Get(fix.TagExecID)
+	if message.Get(fix.TagExecType) == "F" && !b.execSeen[execID] {
+		b.execSeen[execID] = true
+		quantity := decimalOrZero(message.Get(fix.TagLastQty))
+		price := decimalOrZero(message.Get(fix.TagLastPx))
+		commission := decimalOrZero(message.Get(fix.TagCommission))
+		order.Commission = order.Commission.Add(commission)
+		if quantity.IsPositive() {
+			b.applyFill(order, execID, quantity, price, commission)
+		}
+	}
+	b.orders[id] = order
+
+	b.resolve(clOrdID, message)
+	if id != clOrdID {
+		b.resolve(id, message)
+	}
+	changed := previous.Status != order.Status || !previous.FilledQty.Equal(order.FilledQty)
+	callbacks := append([]func(Order){}, b.callbacks...)
+	b.mutex.Unlock()
+
+	if changed && exists {
+		log.Printf("FIX 订单状态更新: 订单ID=%s, 状态=%s, 已成交=%s, 均价=%s", order.ID, order.Status, order.FilledQty, order.AvgPrice)
+		for _, callback := range callbacks {
+			callback(order)
+		}
+	}
+}
+
+// applyFill 把一笔成交记入本地成交记录、持仓和余额，调用方需持有锁
+func (b *FIXBroker) applyFill(order Order, execID string, quantity, price, commission decimal.Decimal) {
+	b.trades = append(b.trades, Trade{
+		ID:          execID,
+		OrderID:     order.ID,
+		Symbol:      order.Symbol,
+		Side:        order.Side,
+		Quantity:    quantity,
+		Price:       price,
+		Commission:  commission,
+		Timestamp:   time.Now(),
+		AccountName: b.name,
+	})
+	if len(b.trades) > fixMaxTrades {
+		b.trades = b.trades[len(b.trades)-fixMaxTrades:]
+	}
+
+	signed := quantity
+	amount := quantity.Mul(price)
+	if order.Side == SellSide {
+		signed = quantity.Neg()
+		b.balance = b.balance.Add(amount)
+	} else {
+		b.balance = b.balance.Sub(amount)
+	}
+	b.balance = b.balance.Sub(commission)
+
+	position := b.positions[order.Symbol]
+	held := position.Quantity
+	next := held.Add(signed)
+	switch {
+	case held.IsZero() || held.Sign() == signed.Sign():
+		// 开仓或加仓：按数量加权平均成本
+		cost := position.AvgPrice.Mul(held.Abs()).Add(amount)
+		position.AvgPrice = cost.Div(next.Abs())
+	case next.Sign() == -held.Sign():
+		// 反向开仓：剩余部分按成交价计成本
+		position.AvgPrice = price
+	}
+	position.Symbol = order.Symbol
+	position.Quantity = next
+	position.MarketValue = next.Mul(price)
+	position.UpdateTime = time.Now()
+	if next.IsZero() {
+		delete(b.positions, order.Symbol)
+		return
+	}
+	b.positions[order.Symbol] = position
+}
+
+// handleCancelReject 撤单被拒绝
+func (b *FIXBroker) handleCancelReject(message *fix.Message) {
+	clOrdID := message.Get(fix.TagClOrdID)
+	log.Printf("FIX 撤单被拒绝: 订单ID=%s, 原因=%s", message.Get(fix.TagOrigClOrdID), message.Get(fix.TagText))
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.resolve(clOrdID, message)
+}
+
+// GetOrder 查询订单（本会话已知的订单）
+func (b *FIXBroker) GetOrder(orderID string) (*Order, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if order, exists := b.orders[orderID]; exists {
+		return &order, nil
+	}
+	return nil, fmt.Errorf("订单 %s: %w", orderID, ErrOrderNotFound)
+}
+
+// GetOrderByClientID 按客户端订单号查询订单，FIX 订单以客户端订单号为订单ID
+func (b *FIXBroker) GetOrderByClientID(clientOrderID string) (*Order, error) {
+	order, err := b.GetOrder(clientOrderID)
+	if errors.Is(err, ErrOrderNotFound) {
+		return nil, fmt.Errorf("客户端订单号 %s: %w", clientOrderID, ErrOrderNotFound)
+	}
+	return order, err
+}
+
+// GetOrders 查询订单列表
+func (b *FIXBroker) GetOrders(symbol string, status OrderStatus) ([]Order, error) {
+	if _, err := b.activeSession(); err != nil {
+		return nil, err
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	var orders []Order
+	for _, order := range b.orders {
+		if symbol != "" && !strings.EqualFold(order.Symbol, symbol) {
+			continue
+		}
+		if status != "" && order.Status != status {
+			continue
+		}
+		orders = append(orders, order)
+	}
+	return orders, nil
+}
+
+// GetBalance 按成交回报推算的现金余额
+func (b *FIXBroker) GetBalance() (decimal.Decimal, error) {
+	if _, err := b.activeSession(); err != nil {
+		return decimal.Zero, err
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.precision.Defaults().RoundAmount(b.balance), nil
+}
+
+// GetPositions 按成交回报推算的持仓，市值按最近成交价计算
+func (b *FIXBroker) GetPositions() (map[string]Position, error) {
+	if _, err := b.activeSession(); err != nil {
+		return nil, err
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	positions := make(map[string]Position, len(b.positions))
+	for symbol, position := range b.positions {
+		positions[symbol] = position
+	}
+	return positions, nil
+}
+
+// GetTrades 获取本会话收到的成交记录，手续费为执行报告中的 Commission（12）
+func (b *FIXBroker) GetTrades(symbol string, limit int) ([]Trade, error) {
+	if _, err := b.activeSession(); err != nil {
+		return nil, err
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	var trades []Trade
+	for _, trade := range b.trades {
+		if symbol == "" || strings.EqualFold(trade.Symbol, symbol) {
+			trades = append(trades, trade)
+		}
+	}
+	if limit > 0 && len(trades) > limit {
+		trades = trades[len(trades)-limit:]
+	}
+	return trades, nil
+}
@@ -0,0 +1,217 @@
+package trading
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/shopspring/decimal"
+)
+
+// validateFundingAmount 检查资金调整金额
+func validateFundingAmount(amount decimal.Decimal) error {
+	if !amount.IsPositive() {
+		return fmt.Errorf("金额必须大于0")
+	}
+	return nil
+}
+
+// Deposit 入金
+func (b *MockStockBroker) Deposit(amount decimal.Decimal) error {
+	if err := validateFundingAmount(amount); err != nil {
+		return err
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.balance = b.balance.Add(amount)
+	log.Printf("股票经纪商 %s 入金: %s, 余额=%s", b.name, amount, b.balance)
+	return nil
+}
+
+// Withdraw 出金
+func (b *MockStockBroker) Withdraw(amount decimal.Decimal) error {
+	if err := validateFundingAmount(amount); err != nil {
+		return err
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if amount.GreaterThan(b.balance) {
+		return fmt.Errorf("余额不足: 可用 %s, 申请 %s", b.balance, amount)
+	}
+
+	b.balance = b.balance.Sub(amount)
+	log.Printf("股票经纪商 %s 出金: %s, 余额=%s", b.name, amount, b.balance)
+	return nil
+}
+
+// SetBalance 设置余额
+func (b *MockStockBroker) SetBalance(balance decimal.Decimal) error {
+	if balance.IsNegative() {
+		return fmt.Errorf("余额不能为负数")
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.balance = balance
+	log.Printf("股票经纪商 %s 余额设置为: %s", b.name, balance)
+	return nil
+}
+
+// Reset 重置为初始资金并清空持仓、订单和成交
+func (b *MockStockBroker) Reset() error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.balance = b.initialBalance
+	b.positions = make(map[string]Position)
+	b.orders = make(map[string]Order)
+	b.trades = make([]Trade, 0)
+	log.Printf("股票经纪商 %s 已重置: 余额=%s", b.name, b.balance)
+	return nil
+}
+
+// Deposit 入金
+func (b *MockCryptoBroker) Deposit(amount decimal.Decimal) error {
+	if err := validateFundingAmount(amount); err != nil {
+		return err
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.balance = b.balance.Add(amount)
+	log.Printf("加密货币经纪商 %s 入金: %s, 余额=%s", b.name, amount, b.balance)
+	return nil
+}
+
+// Withdraw 出金
+func (b *MockCryptoBroker) Withdraw(amount decimal.Decimal) error {
+	if err := validateFundingAmount(amount); err != nil {
+		return err
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if amount.GreaterThan(b.balance) {
+		return fmt.Errorf("余额不足: 可用 %s, 申请 %s", b.balance, amount)
+	}
+
+	b.balance = b.balance.Sub(amount)
+	log.Printf("加密货币经纪商 %s 出金: %s, 余额=%s", b.name, amount, b.balance)
+	return nil
+}
+
+// SetBalance 设置余额
+func (b *MockCryptoBroker) SetBalance(balance decimal.Decimal) error {
+	if balance.IsNegative() {
+		return fmt.Errorf("余额不能为负数")
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.balance = balance
+	log.Printf("加密货币经纪商 %s 余额设置为: %s", b.name, balance)
+	return nil
+}
+
+// Reset 重置为初始资金并清空持仓、订单和成交
+func (b *MockCryptoBroker) Reset() error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.balance = b.initialBalance
+	b.positions = make(map[string]Position)
+	b.orders = make(map[string]Order)
+	b.trades = make([]Trade, 0)
+	log.Printf("加密货币经纪商 %s 已重置: 余额=%s", b.name, b.balance)
+	return nil
+}
+
+// getFundingBroker 获取支持资金调整的经纪商
+func (te *TradingEngine) getFundingBroker(accountName string) (FundingBroker, error) {
+	broker, err := te.GetBroker(accountName)
+	if err != nil {
+		return nil, err
+	}
+
+	funding, ok := baseBroker(broker).(FundingBroker)
+	if !ok {
+		return nil, fmt.Errorf("账户 '%s' 的经纪商不支持资金调整", accountName)
+	}
+	return funding, nil
+}
+
+// syncAccountBalance 将经纪商余额同步到账户管理器
+func (te *TradingEngine) syncAccountBalance(accountName string) (decimal.Decimal, error) {
+	balance, err := te.GetAccountBalance(accountName)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	if err := te.accountManager.UpdateAccountBalance(accountName, balance); err != nil {
+		return decimal.Zero, err
+	}
+	return balance, nil
+}
+
+// Deposit 向模拟账户入金，返回调整后余额
+func (te *TradingEngine) Deposit(accountName string, amount decimal.Decimal) (decimal.Decimal, error) {
+	funding, err := te.getFundingBroker(accountName)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	if err := funding.Deposit(amount); err != nil {
+		return decimal.Zero, fmt.Errorf("入金失败: %w", err)
+	}
+	return te.syncAccountBalance(accountName)
+}
+
+// Withdraw 从模拟账户出金，返回调整后余额
+func (te *TradingEngine) Withdraw(accountName string, amount decimal.Decimal) (decimal.Decimal, error) {
+	funding, err := te.getFundingBroker(accountName)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	if err := funding.Withdraw(amount); err != nil {
+		return decimal.Zero, fmt.Errorf("出金失败: %w", err)
+	}
+	return te.syncAccountBalance(accountName)
+}
+
+// SetBalance 设置模拟账户余额
+func (te *TradingEngine) SetBalance(accountName string, balance decimal.Decimal) error {
+	funding, err := te.getFundingBroker(accountName)
+	if err != nil {
+		return err
+	}
+	if err := funding.SetBalance(balance); err != nil {
+		return fmt.Errorf("设置余额失败: %w", err)
+	}
+	_, err = te.syncAccountBalance(accountName)
+	return err
+}
+
+// ResetAccount 将模拟账户重置为初始资金并清空持仓
+func (te *TradingEngine) ResetAccount(accountName string) (decimal.Decimal, error) {
+	funding, err := te.getFundingBroker(accountName)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	if err := funding.Reset(); err != nil {
+		return decimal.Zero, fmt.Errorf("重置账户失败: %w", err)
+	}
+
+	balance, err := te.GetAccountBalance(accountName)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	if err := te.accountManager.ResetAccount(accountName, balance); err != nil {
+		return decimal.Zero, err
+	}
+	return balance, nil
+}
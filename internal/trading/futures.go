@@ -0,0 +1,191 @@
+package trading
+
+import (
+	"fmt"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"agent-quant-system/internal/instrument"
+	"agent-quant-system/internal/money"
+	"agent-quant-system/internal/notify"
+
+	"github.com/shopspring/decimal"
+)
+
+// futuresRollStrategy 自动移仓订单的策略名
+const futuresRollStrategy = "futures_roll"
+
+// contractMultipliers 按合约规格查询合约乘数。交易引擎与盈亏账本、税务批次、风控、大额确认、下单限流和策略资金分配共用一份，
+// 订单和成交的名义金额都经过 notional 计算；为 nil 或未设置合约规格时按现货处理（期权按每张100单位标的）
+type contractMultipliers struct {
+	registry atomic.Pointer[instrument.Registry]
+}
+
+// multiplier 标的的合约乘数，现货为1
+func (m *contractMultipliers) multiplier(symbol string) decimal.Decimal {
+	var registry *instrument.Registry
+	if m != nil {
+		registry = m.registry.Load()
+	}
+	multiplier := registry.Lookup(symbol).Multiplier
+	if !multiplier.IsPositive() {
+		return decimal.NewFromInt(1)
+	}
+	return multiplier
+}
+
+// notional 名义金额：数量 * 价格 * 合约乘数
+func (m *contractMultipliers) notional(symbol string, quantity, price decimal.Decimal) decimal.Decimal {
+	return quantity.Mul(price).Mul(m.multiplier(symbol))
+}
+
+// orderNotional 订单名义金额，市价单没有价格时使用参考价格
+func (m *contractMultipliers) orderNotional(order Order) decimal.Decimal {
+	price := order.Price
+	if !price.IsPositive() {
+		price = order.referencePrice
+	}
+	return m.notional(order.Symbol, order.Quantity, price)
+}
+
+// SetInstruments 设置合约规格：连续合约代码的订单映射为主力合约，期货、期权价格取整到最小价格变动，已到期合约拒绝下单；
+// 盈亏、税务批次、风控和资金分配的金额按合约乘数计算，纸面交易经纪商同时按合约乘数计算金额
+func (te *TradingEngine) SetInstruments(registry *instrument.Registry) {
+	te.mutex.Lock()
+	defer te.mutex.Unlock()
+	te.instruments.registry.Store(registry)
+	for _, broker := range te.brokers {
+		if paper, ok := baseBroker(broker).(*PaperBroker); ok {
+			paper.SetInstruments(registry)
+		}
+	}
+}
+
+// instrumentRegistry 当前的期货品种规格，未设置时为nil
+func (te *TradingEngine) instrumentRegistry() *instrument.Registry {
+	return te.instruments.registry.Load()
+}
+
+// resolveContract 将连续合约代码映射为当前主力合约，检查期货、期权合约是否已到期，并按最小价格变动取整价格
+func (te *TradingEngine) resolveContract(order *Order) error {
+	registry := te.instrumentRegistry()
+	if registry == nil {
+		return nil
+	}
+
+	now := time.Now()
+	if symbol := registry.Resolve(order.Symbol, now); symbol != order.Symbol {
+		log.Printf("连续合约 %s 映射为主力合约 %s", order.Symbol, symbol)
+		order.Symbol = symbol
+	}
+	inst := registry.Lookup(order.Symbol)
+	if inst.Type == instrument.Spot {
+		return nil
+	}
+	if inst.Expired(now) {
+		return fmt.Errorf("合约 %s 已于 %s 到期", inst.Symbol, inst.Expiry.Format("2006-01-02"))
+	}
+	order.Price = inst.RoundPrice(order.Price)
+	order.StopPrice = inst.RoundPrice(order.StopPrice)
+	return nil
+}
+
+// runFuturesRoll 定期检查期货持仓是否到达移仓日，直到 stop 关闭
+func (te *TradingEngine) runFuturesRoll(stop <-chan struct{}) {
+	ticker := time.NewTicker(te.currentConfig().Instruments.RollCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if rolled := te.RollFutures(); rolled > 0 {
+				log.Printf("期货自动移仓完成: %d 个持仓", rolled)
+			}
+		}
+	}
+}
+
+// RollFutures 对所有账户中到达移仓日的期货持仓移仓：市价平掉旧合约，再在当前主力合约开同方向同数量的仓位，返回移仓的持仓数
+func (te *TradingEngine) RollFutures() int {
+	registry := te.instrumentRegistry()
+	if registry == nil {
+		return 0
+	}
+
+	te.mutex.RLock()
+	accounts := make([]string, 0, len(te.brokers))
+	for name := range te.brokers {
+		accounts = append(accounts, name)
+	}
+	te.mutex.RUnlock()
+
+	now := time.Now()
+	rolled := 0
+	for _, accountName := range accounts {
+		positions, err := te.GetAccountPositions(accountName)
+		if err != nil {
+			log.Printf("获取账户 %s 持仓失败，跳过移仓检查: %v", accountName, err)
+			continue
+		}
+		for symbol, position := range positions {
+			contract, ok := registry.Contract(symbol)
+			if !ok || position.Quantity.IsZero() || now.Before(contract.RollDate()) {
+				continue
+			}
+			next := contract.Spec.Active(now)
+			if next.Symbol() == contract.Symbol() {
+				continue
+			}
+			if err := te.rollPosition(accountName, symbol, next.Symbol()); err != nil {
+				log.Printf("期货移仓失败: 账户=%s, %s -> %s, 错误=%v", accountName, symbol, next.Symbol(), err)
+				continue
+			}
+			rolled++
+		}
+	}
+	return rolled
+}
+
+// rollPosition 平掉 symbol 的全部持仓，并在 next 合约开同方向同数量的仓位
+func (te *TradingEngine) rollPosition(accountName, symbol, next string) error {
+	closing, err := te.closeOrder(accountName, symbol, 1, futuresRollStrategy)
+	if err != nil {
+		return err
+	}
+	if _, err := te.ExecuteTrade(closing, accountName); err != nil {
+		return fmt.Errorf("平仓旧合约失败: %w", err)
+	}
+
+	// 平仓方向的反方向即原持仓方向
+	side := BuySide
+	if closing.Side == BuySide {
+		side = SellSide
+	}
+	price := closing.Price
+	if latest, err := te.latestPrice(next); err == nil && latest > 0 {
+		price = money.FromFloat(latest)
+	}
+	opening := Order{
+		Symbol:         next,
+		Side:           side,
+		Type:           MarketOrder,
+		Quantity:       closing.Quantity,
+		Price:          price,
+		Status:         Pending,
+		Strategy:       futuresRollStrategy,
+		CreateTime:     time.Now(),
+		UpdateTime:     time.Now(),
+		referencePrice: price,
+	}
+	if _, err := te.ExecuteTrade(opening, accountName); err != nil {
+		return fmt.Errorf("开仓新合约失败: %w", err)
+	}
+
+	log.Printf("期货移仓: 账户=%s, %s -> %s, 方向=%s, 数量=%s", accountName, symbol, next, side, closing.Quantity)
+	te.notifier.Notifyf(notify.EventTrade, "期货移仓",
+		"账户=%s, %s -> %s, 方向=%s, 数量=%s", accountName, symbol, next, side, closing.Quantity)
+	return nil
+}
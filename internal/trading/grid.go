@@ -0,0 +1,900 @@
+package trading
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"agent-quant-system/internal/money"
+	"agent-quant-system/internal/strategy"
+
+	"github.com/shopspring/decimal"
+)
+
+// gridKey 网格按策略、账户和标的区分
+type gridKey struct {
+	strategy string
+	account  string
+	symbol   string
+}
+
+// gridBook 一个网格在经纪商上的挂单，按方向和限价索引
+type gridBook struct {
+	planner  strategy.RestingOrderStrategy // 从状态文件恢复、尚未由策略重新登记或已停止维护时为nil
+	syncing  sync.Mutex                    // 同一网格的同步串行进行
+	orders   map[string]Order
+	levels   map[string]int // 挂单对应的档位
+	fills    int
+	replaced int             // 累计改单次数
+	held     decimal.Decimal // 网格挂单成交累计的净持仓，不含账户在该标的上的其他持仓
+	limit    float64         // 库存上限，0表示不限制
+	anchor   int             // 最近一次成交的档位，没有成交时为-1
+	lastSync time.Time
+	lastErr  string
+}
+
+// newGridBook 创建空的网格
+func newGridBook() *gridBook {
+	return &gridBook{orders: make(map[string]Order), levels: make(map[string]int), anchor: -1}
+}
+
+// addFilled 记入挂单新增的成交数量，调用方需持有锁
+func (b *gridBook) addFilled(side OrderSide, quantity decimal.Decimal) {
+	if side == SellSide {
+		quantity = quantity.Neg()
+	}
+	b.held = b.held.Add(quantity)
+}
+
+// GridOrderStatus 网格挂单
+type GridOrderStatus struct {
+	OrderID  string          `json:"order_id"`
+	Side     OrderSide       `json:"side"`
+	Level    int             `json:"level"`
+	Price    decimal.Decimal `json:"price"`
+	Quantity decimal.Decimal `json:"quantity"`
+	Filled   decimal.Decimal `json:"filled_quantity"`
+}
+
+// GridStatus 网格状态
+type GridStatus struct {
+	Strategy     string            `json:"strategy"`
+	Account      string            `json:"account"`
+	Symbol       string            `json:"symbol"`
+	Orders       []GridOrderStatus `json:"orders"`
+	Fills        int               `json:"fills"`                   // 累计成交的挂单数
+	Replaced     int               `json:"replaced"`                // 累计改单次数
+	Inventory    float64           `json:"inventory"`               // 网格挂单成交累计的净持仓
+	MaxInventory float64           `json:"max_inventory,omitempty"` // 库存上限，0表示不限制
+	LastSync     time.Time         `json:"last_sync"`
+	Error        string            `json:"error,omitempty"` // 最近一次同步的错误
+}
+
+// OrderReplacer 支持改单的经纪商（可选接口）：修改未成交限价单的价格和数量，返回修改后的订单（订单ID可能变化）；
+// 不支持时常驻挂单调整为撤单后重新下单
+type OrderReplacer interface {
+	ReplaceOrder(orderID string, price, quantity decimal.Decimal) (*Order, error)
+}
+
+// GridManager 由交易引擎维护的网格挂单：定期检查挂单成交、按策略重新计算档位并撤补订单。
+// 挂单、档位和网格持仓写入状态文件，重启后恢复并与经纪商的未成交订单核对
+type GridManager struct {
+	books     map[gridKey]*gridBook
+	stateFile string // 为空时不保存
+	restored  bool   // 从状态文件恢复了网格，尚未与经纪商核对
+	mutex     sync.Mutex
+}
+
+// gridState 状态文件中的一个网格
+type gridState struct {
+	Strategy string           `json:"strategy"`
+	Account  string           `json:"account"`
+	Symbol   string           `json:"symbol"`
+	Orders   map[string]Order `json:"orders"`
+	Levels   map[string]int   `json:"levels"`
+	Fills    int              `json:"fills"`
+	Replaced int              `json:"replaced"`
+	Held     decimal.Decimal  `json:"held"`
+	Anchor   int              `json:"anchor"`
+}
+
+// NewGridManager 创建网格挂单管理器并加载状态文件
+func NewGridManager(stateFile string) *GridManager {
+	gm := &GridManager{books: make(map[gridKey]*gridBook), stateFile: stateFile}
+	if err := gm.load(); err != nil {
+		log.Printf("加载网格状态失败，将重新建立网格: %v", err)
+	}
+	return gm
+}
+
+// load 加载状态文件
+func (gm *GridManager) load() error {
+	if gm.stateFile == "" {
+		return nil
+	}
+	content, err := os.ReadFile(gm.stateFile)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("读取网格状态文件失败: %w", err)
+	}
+	var states []gridState
+	if err := json.Unmarshal(content, &states); err != nil {
+		return fmt.Errorf("解析网格状态文件失败: %w", err)
+	}
+	for _, state := range states {
+		book := newGridBook()
+		for orderKey, order := range state.Orders {
+			book.orders[orderKey] = order
+		}
+		for orderKey, level := range state.Levels {
+			book.levels[orderKey] = level
+		}
+		book.fills, book.replaced, book.held, book.anchor = state.Fills, state.Replaced, state.Held, state.Anchor
+		gm.books[gridKey{strategy: state.Strategy, account: state.Account, symbol: state.Symbol}] = book
+	}
+	gm.restored = len(states) > 0
+	if gm.restored {
+		log.Printf("已从状态文件恢复 %d 个网格", len(states))
+	}
+	return nil
+}
+
+// save 写入状态文件（先写临时文件再重命名），调用方需持有锁
+func (gm *GridManager) save() {
+	if gm.stateFile == "" {
+		return
+	}
+	states := make([]gridState, 0, len(gm.books))
+	for key, book := range gm.books {
+		states = append(states, gridState{
+			Strategy: key.strategy,
+			Account:  key.account,
+			Symbol:   key.symbol,
+			Orders:   book.orders,
+			Levels:   book.levels,
+			Fills:    book.fills,
+			Replaced: book.replaced,
+			Held:     book.held,
+			Anchor:   book.anchor,
+		})
+	}
+	sort.Slice(states, func(i, j int) bool {
+		a, b := states[i], states[j]
+		if a.Strategy != b.Strategy {
+			return a.Strategy < b.Strategy
+		}
+		if a.Account != b.Account {
+			return a.Account < b.Account
+		}
+		return a.Symbol < b.Symbol
+	})
+
+	content, err := json.Marshal(states)
+	if err != nil {
+		log.Printf("序列化网格状态失败: %v", err)
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(gm.stateFile), 0755); err != nil {
+		log.Printf("创建网格状态目录失败: %v", err)
+		return
+	}
+	tmp := gm.stateFile + ".tmp"
+	if err := os.WriteFile(tmp, content, 0644); err != nil {
+		log.Printf("写入网格状态失败: %v", err)
+		return
+	}
+	if err := os.Rename(tmp, gm.stateFile); err != nil {
+		log.Printf("写入网格状态失败: %v", err)
+	}
+}
+
+// gridOrderKey 挂单按方向和限价区分
+func gridOrderKey(side OrderSide, price decimal.Decimal) string {
+	return string(side) + "@" + price.String()
+}
+
+// SyncGrid 登记网格并立即同步一次：记入已成交的挂单，按最新价格和持仓重新计算档位，
+// 撤销多余的挂单并补挂缺少的挂单；经纪商支持改单时，多余的挂单优先改为同方向缺少的挂单
+func (te *TradingEngine) SyncGrid(strategyName, accountName, symbol string, planner strategy.RestingOrderStrategy) (*GridStatus, error) {
+	if accountName == "" {
+		accountName = te.RouteAccount(strategyName)
+	}
+	key := gridKey{strategy: strategyName, account: accountName, symbol: symbol}
+
+	te.grids.mutex.Lock()
+	book, exists := te.grids.books[key]
+	if !exists {
+		book = newGridBook()
+		te.grids.books[key] = book
+	}
+	book.planner = planner
+	te.grids.mutex.Unlock()
+
+	book.syncing.Lock()
+	err := te.syncGrid(key, book)
+	book.syncing.Unlock()
+
+	te.grids.mutex.Lock()
+	book.lastSync = time.Now()
+	book.lastErr = ""
+	if err != nil {
+		book.lastErr = err.Error()
+	}
+	status := book.status(key)
+	te.grids.save()
+	te.grids.mutex.Unlock()
+	return &status, err
+}
+
+// syncGrid 同步一个网格，调用方需持有 book.syncing
+func (te *TradingEngine) syncGrid(key gridKey, book *gridBook) error {
+	broker, err := te.GetBroker(key.account)
+	if err != nil {
+		return err
+	}
+
+	// 1. 检查挂单状态，记入成交
+	te.grids.mutex.Lock()
+	tracked := make(map[string]Order, len(book.orders))
+	for orderKey, order := range book.orders {
+		tracked[orderKey] = order
+	}
+	te.grids.mutex.Unlock()
+
+	var fills []gridFill
+	filled := make(map[OrderSide]decimal.Decimal)
+	for orderKey, order := range tracked {
+		// 演练模式的挂单不在经纪商中，一直视为未成交
+		if order.DryRun {
+			continue
+		}
+		// 等待人工确认的挂单在确认并提交后换成提交的订单，成交已在提交时记账
+		approved := order.AwaitingApproval
+		var current *Order
+		if approved {
+			result, pending, err := te.resolveApproval(order.ID)
+			if pending {
+				continue
+			}
+			if err != nil || result == nil {
+				log.Printf("网格挂单未提交: 确认请求ID=%s, 错误=%v", order.ID, err)
+				delete(tracked, orderKey)
+				continue
+			}
+			current = result
+		} else {
+			queried, err := te.gridOrderBroker(broker, order).GetOrder(order.ID)
+			if err != nil {
+				log.Printf("查询网格挂单失败: 订单ID=%s, 错误=%v", order.ID, err)
+				continue
+			}
+			current = queried
+		}
+		// 部分成交也按新增数量记账，档位在全部成交后才视为成交
+		if !order.Paper && !approved {
+			te.applyOrderUpdate(current, order, key.account)
+		}
+		if delta := current.FilledQty.Sub(order.FilledQty); delta.IsPositive() {
+			filled[order.Side] = filled[order.Side].Add(delta)
+		}
+		switch {
+		case current.Status == Filled:
+			log.Printf("网格挂单已成交: 策略=%s, 标的=%s, %s %s @ %s", key.strategy, key.symbol, current.Side, current.FilledQty, current.AvgPrice)
+			delete(tracked, orderKey)
+			fills = append(fills, gridFill{level: book.levels[orderKey], side: current.Side, at: current.UpdateTime})
+		case current.Status.IsTerminal():
+			log.Printf("网格挂单已终止: 订单ID=%s, 状态=%s, 已成交=%s", order.ID, current.Status, current.FilledQty)
+			delete(tracked, orderKey)
+		case approved:
+			tracked[orderKey] = *current
+		default:
+			order.FilledQty = current.FilledQty
+			tracked[orderKey] = order
+		}
+	}
+
+	te.grids.mutex.Lock()
+	book.recordFills(fills)
+	for side, quantity := range filled {
+		book.addFilled(side, quantity)
+	}
+	te.grids.mutex.Unlock()
+
+	// 紧急停止期间只记入成交，不补挂订单
+	if err := te.checkHalted(Order{Symbol: key.symbol, Strategy: key.strategy}); err != nil {
+		te.storeGridOrders(book, tracked)
+		return err
+	}
+
+	// 2. 按最新价格和网格持仓计算应挂的订单：只计入网格自己成交的数量，账户在该标的上的其他持仓
+	// （其他策略或手动交易）不会被网格卖出，也不占用网格的库存上限
+	price, err := te.latestPrice(key.symbol)
+	if err != nil {
+		te.storeGridOrders(book, tracked)
+		return fmt.Errorf("获取 %s 最新价格失败: %w", key.symbol, err)
+	}
+	te.grids.mutex.Lock()
+	anchor := book.anchor
+	position := book.held.InexactFloat64()
+	book.limit = te.currentConfig().Trading.Grid.InventoryLimit(key.symbol)
+	te.grids.mutex.Unlock()
+	desired, err := book.planner.RestingOrders(key.symbol, price, position, anchor)
+	if err != nil {
+		te.storeGridOrders(book, tracked)
+		return fmt.Errorf("计算网格挂单失败: %w", err)
+	}
+	desired = limitInventory(desired, position, book.limit, key)
+
+	precision := te.precisionFor(key.account, key.symbol)
+	wanted := make(map[string]strategy.RestingOrder, len(desired))
+	missing := make(map[string]strategy.RestingOrder, len(desired))
+	for _, resting := range desired {
+		side := BuySide
+		if resting.Side == strategy.Sell {
+			side = SellSide
+		}
+		orderKey := gridOrderKey(side, precision.RoundPrice(money.FromFloat(resting.Price)))
+		wanted[orderKey] = resting
+		if _, exists := tracked[orderKey]; !exists {
+			missing[orderKey] = resting
+		}
+	}
+
+	// 3. 不再需要的挂单：能改单时改为同方向缺少的挂单，否则撤销
+	replaced := make(map[string]Order)
+	var replacedFills []gridFill
+	for orderKey, order := range tracked {
+		if _, keep := wanted[orderKey]; keep {
+			continue
+		}
+		// 等待人工确认的挂单不再需要时拒绝该确认请求
+		if order.AwaitingApproval {
+			if _, err := te.DecideApproval(order.ID, "grid", "网格挂单调整", false); err != nil {
+				log.Printf("撤销等待确认的网格挂单失败: 确认请求ID=%s, 错误=%v", order.ID, err)
+				continue
+			}
+			delete(tracked, orderKey)
+			continue
+		}
+		if targetKey, ok := replacementFor(order, book.levels[orderKey], missing, precision); ok {
+			resting := missing[targetKey]
+			if current, ok := te.replaceGridOrder(broker, key, order, resting, precision); ok {
+				delete(tracked, orderKey)
+				delete(missing, targetKey)
+				if current.FilledQty.IsPositive() {
+					te.grids.mutex.Lock()
+					book.addFilled(current.Side, current.FilledQty)
+					te.grids.mutex.Unlock()
+				}
+				switch {
+				case current.Status == Filled:
+					replacedFills = append(replacedFills, gridFill{level: resting.Level, side: current.Side, at: time.Now()})
+				case !current.Status.IsTerminal():
+					replaced[targetKey] = *current
+				}
+				continue
+			}
+		}
+		if te.dryRunCancel(order, key.account, "网格挂单调整") {
+			delete(tracked, orderKey)
+			continue
+		}
+		err := te.gridOrderBroker(broker, order).CancelOrder(order.ID)
+		te.auditCancel(order, key.account, "网格挂单调整", err)
+		if err != nil {
+			log.Printf("撤销网格挂单失败: 订单ID=%s, 错误=%v", order.ID, err)
+			continue
+		}
+		te.releaseReservations(order.ID)
+		log.Printf("撤销网格挂单: 策略=%s, 标的=%s, %s @ %s", key.strategy, key.symbol, order.Side, order.Price)
+		delete(tracked, orderKey)
+	}
+	for orderKey, order := range replaced {
+		tracked[orderKey] = order
+	}
+	te.grids.mutex.Lock()
+	book.replaced += len(replaced) + len(replacedFills)
+	book.recordFills(replacedFills)
+	te.grids.mutex.Unlock()
+
+	// 4. 补挂缺少的挂单，经过与其他订单相同的风控、资金分配和限流检查
+	levels := make(map[string]int, len(wanted))
+	var errs []string
+	for orderKey, resting := range wanted {
+		levels[orderKey] = resting.Level
+		if _, exists := missing[orderKey]; !exists {
+			continue
+		}
+		side := BuySide
+		if resting.Side == strategy.Sell {
+			side = SellSide
+		}
+		order := Order{
+			Symbol:      key.symbol,
+			Side:        side,
+			Type:        LimitOrder,
+			Quantity:    money.FromFloat(resting.Quantity),
+			Price:       money.FromFloat(resting.Price),
+			TimeInForce: GTC,
+			Status:      Pending,
+			Strategy:    key.strategy,
+		}
+		placed, err := te.ExecuteTrade(order, key.account)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("档位 %d: %v", resting.Level, err))
+			continue
+		}
+		if placed.FilledQty.IsPositive() {
+			te.grids.mutex.Lock()
+			book.addFilled(side, placed.FilledQty)
+			te.grids.mutex.Unlock()
+		}
+		switch {
+		case placed.Status == Filled:
+			// 挂单价格已被穿越时立即成交，成交已在下单时记入，下次同步会挂出反向订单
+			te.grids.mutex.Lock()
+			book.recordFills([]gridFill{{level: resting.Level, side: side, at: time.Now()}})
+			te.grids.mutex.Unlock()
+		case placed.Status.IsTerminal():
+			errs = append(errs, fmt.Sprintf("档位 %d: 订单状态 %s", resting.Level, placed.Status))
+		default:
+			tracked[orderKey] = *placed
+		}
+	}
+
+	te.grids.mutex.Lock()
+	for orderKey := range tracked {
+		if _, exists := levels[orderKey]; !exists {
+			levels[orderKey] = book.levels[orderKey] // 撤单失败的挂单保留原档位
+		}
+	}
+	book.orders = tracked
+	book.levels = levels
+	te.grids.mutex.Unlock()
+
+	if len(errs) > 0 {
+		return fmt.Errorf("部分网格挂单失败: %v", errs)
+	}
+	return nil
+}
+
+// limitInventory 按库存上限筛选挂单：同方向的挂单按策略返回的顺序累计，全部成交后净持仓的绝对值超出上限的挂单不挂出；
+// limit 为0时不限制
+func limitInventory(desired []strategy.RestingOrder, position, limit float64, key gridKey) []strategy.RestingOrder {
+	if limit <= 0 {
+		return desired
+	}
+	const epsilon = 1e-9
+	filtered := desired[:0:0]
+	bought, sold := 0.0, 0.0
+	for _, resting := range desired {
+		if resting.Side == strategy.Sell {
+			if position-sold-resting.Quantity < -limit-epsilon {
+				log.Printf("挂单超出库存上限，不挂出: 策略=%s, 标的=%s, 卖出 %.8g @ %.8g, 净持仓=%.8g, 上限=%.8g",
+					key.strategy, key.symbol, resting.Quantity, resting.Price, position, limit)
+				continue
+			}
+			sold += resting.Quantity
+		} else {
+			if position+bought+resting.Quantity > limit+epsilon {
+				log.Printf("挂单超出库存上限，不挂出: 策略=%s, 标的=%s, 买入 %.8g @ %.8g, 净持仓=%.8g, 上限=%.8g",
+					key.strategy, key.symbol, resting.Quantity, resting.Price, position, limit)
+				continue
+			}
+			bought += resting.Quantity
+		}
+		filtered = append(filtered, resting)
+	}
+	return filtered
+}
+
+// replacementFor 为不再需要的挂单选择改单目标：同方向、数量不超过原订单（原订单已通过风控检查）的缺少挂单，
+// 优先选择同一档位，其次选择价格最接近的；原订单已部分成交或为演练挂单时不改单
+func replacementFor(order Order, level int, missing map[string]strategy.RestingOrder, precision money.Precision) (string, bool) {
+	if order.DryRun || order.Type != LimitOrder || order.FilledQty.IsPositive() {
+		return "", false
+	}
+	best, bestDistance := "", decimal.Zero
+	for orderKey, resting := range missing {
+		side := BuySide
+		if resting.Side == strategy.Sell {
+			side = SellSide
+		}
+		if side != order.Side || precision.RoundQuantity(money.FromFloat(resting.Quantity)).GreaterThan(order.Quantity) {
+			continue
+		}
+		if resting.Level == level {
+			return orderKey, true
+		}
+		distance := money.FromFloat(resting.Price).Sub(order.Price).Abs()
+		if best == "" || distance.LessThan(bestDistance) || distance.Equal(bestDistance) && orderKey < best {
+			best, bestDistance = orderKey, distance
+		}
+	}
+	return best, best != ""
+}
+
+// replaceGridOrder 把挂单改为新的价格和数量，经纪商不支持改单或改单失败时返回 false 由调用方撤单重挂
+func (te *TradingEngine) replaceGridOrder(broker BrokerAPI, key gridKey, order Order, resting strategy.RestingOrder, precision money.Precision) (*Order, bool) {
+	broker = te.gridOrderBroker(broker, order)
+	if _, ok := baseBroker(broker).(OrderReplacer); !ok {
+		return nil, false
+	}
+	price := precision.RoundPrice(money.FromFloat(resting.Price))
+	quantity := precision.RoundQuantity(money.FromFloat(resting.Quantity))
+	current, err := broker.(OrderReplacer).ReplaceOrder(order.ID, price, quantity)
+	te.auditReplace(order, price, quantity, key.account, "网格挂单调整", err)
+	if err != nil {
+		log.Printf("修改网格挂单失败，改为撤单重挂: 订单ID=%s, 错误=%v", order.ID, err)
+		return nil, false
+	}
+	current.Paper = order.Paper
+	current.Strategy = order.Strategy
+	if !order.Paper {
+		te.applyOrderUpdate(current, order, key.account)
+	}
+	log.Printf("修改网格挂单: 策略=%s, 标的=%s, %s %s @ %s -> %s @ %s", key.strategy, key.symbol,
+		order.Side, order.Quantity, order.Price, current.Quantity, current.Price)
+	return current, true
+}
+
+// gridFill 一次同步中检查到的挂单成交
+type gridFill struct {
+	level int
+	side  OrderSide
+	at    time.Time
+}
+
+// recordFills 记录挂单成交并更新下次计算时留空的档位：同方向的多笔成交取价格最远的档位
+// （同一次撮合中成交时间的先后不可靠），双向都有成交时取成交时间最晚的档位，调用方需持有锁
+func (b *gridBook) recordFills(fills []gridFill) {
+	if len(fills) == 0 {
+		return
+	}
+	b.fills += len(fills)
+
+	last := fills[0]
+	mixed := false
+	for _, fill := range fills[1:] {
+		if fill.side != last.side {
+			mixed = true
+		}
+	}
+	for _, fill := range fills[1:] {
+		switch {
+		case mixed:
+			if fill.at.After(last.at) {
+				last = fill
+			}
+		case fill.side == BuySide && fill.level < last.level, fill.side == SellSide && fill.level > last.level:
+			last = fill
+		}
+	}
+	b.anchor = last.level
+}
+
+// storeGridOrders 保存检查后的挂单
+func (te *TradingEngine) storeGridOrders(book *gridBook, orders map[string]Order) {
+	te.grids.mutex.Lock()
+	book.orders = orders
+	te.grids.mutex.Unlock()
+}
+
+// gridOrderBroker 挂单所在的经纪商：未晋级策略的挂单在其纸面副本中
+func (te *TradingEngine) gridOrderBroker(broker BrokerAPI, order Order) BrokerAPI {
+	if order.Paper && te.promotion != nil {
+		if paper, err := te.promotion.broker(order.Strategy, order.AccountName); err == nil {
+			return paper
+		}
+	}
+	return broker
+}
+
+// reconcileGrids 核对从状态文件恢复的网格：按经纪商当前的未成交订单确认挂单仍然有效，
+// 已不在未成交订单中的挂单逐个查询最终状态并记入成交；经纪商上属于网格策略但未登记的同标的限价单补登记，
+// 避免重启后在同一档位重复挂单
+func (te *TradingEngine) reconcileGrids() {
+	te.grids.mutex.Lock()
+	if !te.grids.restored {
+		te.grids.mutex.Unlock()
+		return
+	}
+	te.grids.restored = false
+	books := make(map[gridKey]*gridBook, len(te.grids.books))
+	for key, book := range te.grids.books {
+		books[key] = book
+	}
+	te.grids.mutex.Unlock()
+
+	for key, book := range books {
+		book.syncing.Lock()
+		err := te.reconcileGrid(key, book)
+		book.syncing.Unlock()
+		if err != nil {
+			log.Printf("核对网格挂单失败: 策略=%s, 账户=%s, 标的=%s, 错误=%v", key.strategy, key.account, key.symbol, err)
+		}
+	}
+
+	te.grids.mutex.Lock()
+	te.grids.save()
+	te.grids.mutex.Unlock()
+}
+
+// reconcileGrid 核对一个网格，调用方需持有 book.syncing
+func (te *TradingEngine) reconcileGrid(key gridKey, book *gridBook) error {
+	live, err := te.GetBroker(key.account)
+	if err != nil {
+		return err
+	}
+	brokers := []BrokerAPI{live}
+	if te.promotion != nil && te.promotion.IsPaper(key.strategy) {
+		if paper, err := te.promotion.broker(key.strategy, key.account); err == nil {
+			brokers = append(brokers, paper)
+		}
+	}
+
+	open := make(map[string]Order)
+	for _, broker := range brokers {
+		for _, status := range OpenOrderStatuses {
+			orders, err := broker.GetOrders(key.symbol, status)
+			if err != nil {
+				return fmt.Errorf("查询未成交订单失败: %w", err)
+			}
+			for _, order := range orders {
+				open[order.ID] = order
+			}
+		}
+	}
+
+	te.grids.mutex.Lock()
+	tracked := make(map[string]Order, len(book.orders))
+	for orderKey, order := range book.orders {
+		tracked[orderKey] = order
+	}
+	te.grids.mutex.Unlock()
+
+	var fills []gridFill
+	filled := make(map[OrderSide]decimal.Decimal)
+	known := make(map[string]bool, len(tracked))
+	for orderKey, order := range tracked {
+		known[order.ID] = true
+		if order.DryRun {
+			continue
+		}
+		current, exists := open[order.ID]
+		if !exists {
+			queried, err := te.gridOrderBroker(live, order).GetOrder(order.ID)
+			if err != nil {
+				log.Printf("网格挂单已不在经纪商中，不再跟踪: 订单ID=%s, 错误=%v", order.ID, err)
+				delete(tracked, orderKey)
+				continue
+			}
+			current = *queried
+		}
+		if !order.Paper {
+			te.applyOrderUpdate(&current, order, key.account)
+		}
+		if delta := current.FilledQty.Sub(order.FilledQty); delta.IsPositive() {
+			filled[order.Side] = filled[order.Side].Add(delta)
+		}
+		switch {
+		case current.Status == Filled:
+			log.Printf("网格挂单在停止期间成交: 策略=%s, 标的=%s, %s %s @ %s", key.strategy, key.symbol, current.Side, current.FilledQty, current.AvgPrice)
+			delete(tracked, orderKey)
+			fills = append(fills, gridFill{level: book.levels[orderKey], side: current.Side, at: current.UpdateTime})
+		case current.Status.IsTerminal():
+			delete(tracked, orderKey)
+		default:
+			order.FilledQty = current.FilledQty
+			tracked[orderKey] = order
+		}
+	}
+
+	adopted := 0
+	for _, order := range open {
+		if known[order.ID] || order.Strategy != key.strategy || order.Type != LimitOrder {
+			continue
+		}
+		orderKey := gridOrderKey(order.Side, order.Price)
+		if _, exists := tracked[orderKey]; exists {
+			continue
+		}
+		tracked[orderKey] = order
+		adopted++
+	}
+
+	te.grids.mutex.Lock()
+	book.recordFills(fills)
+	for side, quantity := range filled {
+		book.addFilled(side, quantity)
+	}
+	for orderKey := range book.levels {
+		if _, exists := tracked[orderKey]; !exists {
+			delete(book.levels, orderKey)
+		}
+	}
+	for orderKey := range tracked {
+		if _, exists := book.levels[orderKey]; !exists {
+			book.levels[orderKey] = -1 // 补登记的挂单档位未知，成交后不作为留空档位
+		}
+	}
+	book.orders = tracked
+	te.grids.mutex.Unlock()
+
+	log.Printf("已核对网格: 策略=%s, 账户=%s, 标的=%s, 挂单 %d 个（补登记 %d 个）, 停止期间成交 %d 个, 网格持仓 %s",
+		key.strategy, key.account, key.symbol, len(tracked), adopted, len(fills), book.held)
+	return nil
+}
+
+// runGridSync 核对恢复的网格后定期同步所有已登记的网格，直到 stop 关闭
+func (te *TradingEngine) runGridSync(stop <-chan struct{}) {
+	te.reconcileGrids()
+
+	ticker := time.NewTicker(te.currentConfig().Trading.Grid.SyncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			te.grids.mutex.Lock()
+			books := make(map[gridKey]strategy.RestingOrderStrategy, len(te.grids.books))
+			for key, book := range te.grids.books {
+				// 恢复后尚未由策略重新登记的网格只保留挂单，等策略运行时再同步
+				if book.planner != nil {
+					books[key] = book.planner
+				}
+			}
+			te.grids.mutex.Unlock()
+
+			for key, planner := range books {
+				if _, err := te.SyncGrid(key.strategy, key.account, key.symbol, planner); err != nil {
+					log.Printf("同步网格失败: 策略=%s, 账户=%s, 标的=%s, 错误=%v", key.strategy, key.account, key.symbol, err)
+				}
+			}
+		}
+	}
+}
+
+// CancelGrids 撤销策略的全部网格挂单并停止维护，strategyName 为空时撤销所有网格，返回撤销的订单数；
+// 网格持仓和成交统计保留，策略重新登记网格时延续，撤单失败的挂单继续跟踪
+func (te *TradingEngine) CancelGrids(strategyName string) int {
+	te.grids.mutex.Lock()
+	stopped := make(map[gridKey]*gridBook)
+	for key, book := range te.grids.books {
+		if strategyName == "" || key.strategy == strategyName {
+			stopped[key] = book
+			book.planner = nil
+		}
+	}
+	te.grids.mutex.Unlock()
+
+	cancelled := 0
+	for key, book := range stopped {
+		book.syncing.Lock()
+		cancelled += te.cancelGridOrders(key, book)
+		book.syncing.Unlock()
+	}
+
+	te.grids.mutex.Lock()
+	te.grids.save()
+	te.grids.mutex.Unlock()
+	if cancelled > 0 {
+		log.Printf("已撤销 %d 个网格挂单", cancelled)
+	}
+	return cancelled
+}
+
+// cancelGridOrders 撤销一个网格的全部挂单，调用方需持有 book.syncing
+func (te *TradingEngine) cancelGridOrders(key gridKey, book *gridBook) int {
+	broker, err := te.GetBroker(key.account)
+	if err != nil {
+		log.Printf("撤销网格挂单失败: %v", err)
+		return 0
+	}
+
+	te.grids.mutex.Lock()
+	remaining := make(map[string]Order, len(book.orders))
+	for orderKey, order := range book.orders {
+		remaining[orderKey] = order
+	}
+	te.grids.mutex.Unlock()
+
+	cancelled := 0
+	for orderKey, order := range remaining {
+		if order.AwaitingApproval {
+			if _, err := te.DecideApproval(order.ID, "grid", "网格停止", false); err != nil {
+				log.Printf("撤销等待确认的网格挂单失败: 确认请求ID=%s, 错误=%v", order.ID, err)
+				continue
+			}
+			delete(remaining, orderKey)
+			cancelled++
+			continue
+		}
+		if te.dryRunCancel(order, key.account, "网格停止") {
+			delete(remaining, orderKey)
+			cancelled++
+			continue
+		}
+		err := te.gridOrderBroker(broker, order).CancelOrder(order.ID)
+		te.auditCancel(order, key.account, "网格停止", err)
+		if err != nil {
+			log.Printf("撤销网格挂单失败: 订单ID=%s, 错误=%v", order.ID, err)
+			continue
+		}
+		te.releaseReservations(order.ID)
+		delete(remaining, orderKey)
+		cancelled++
+	}
+
+	te.grids.mutex.Lock()
+	for orderKey := range book.levels {
+		if _, exists := remaining[orderKey]; !exists {
+			delete(book.levels, orderKey)
+		}
+	}
+	book.orders = remaining
+	te.grids.mutex.Unlock()
+	return cancelled
+}
+
+// GetGrids 获取所有网格的挂单状态
+func (te *TradingEngine) GetGrids() []GridStatus {
+	te.grids.mutex.Lock()
+	defer te.grids.mutex.Unlock()
+
+	statuses := make([]GridStatus, 0, len(te.grids.books))
+	for key, book := range te.grids.books {
+		// 已停止维护且没有挂单的网格只保留网格持仓，不再展示
+		if book.planner == nil && len(book.orders) == 0 {
+			continue
+		}
+		statuses = append(statuses, book.status(key))
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		if statuses[i].Strategy != statuses[j].Strategy {
+			return statuses[i].Strategy < statuses[j].Strategy
+		}
+		if statuses[i].Account != statuses[j].Account {
+			return statuses[i].Account < statuses[j].Account
+		}
+		return statuses[i].Symbol < statuses[j].Symbol
+	})
+	return statuses
+}
+
+// status 网格状态，挂单按限价从高到低排列，调用方需持有锁
+func (b *gridBook) status(key gridKey) GridStatus {
+	status := GridStatus{
+		Strategy:     key.strategy,
+		Account:      key.account,
+		Symbol:       key.symbol,
+		Orders:       make([]GridOrderStatus, 0, len(b.orders)),
+		Fills:        b.fills,
+		Replaced:     b.replaced,
+		Inventory:    b.held.InexactFloat64(),
+		MaxInventory: b.limit,
+		LastSync:     b.lastSync,
+		Error:        b.lastErr,
+	}
+	for orderKey, order := range b.orders {
+		status.Orders = append(status.Orders, GridOrderStatus{
+			OrderID:  order.ID,
+			Side:     order.Side,
+			Level:    b.levels[orderKey],
+			Price:    order.Price,
+			Quantity: order.Quantity,
+			Filled:   order.FilledQty,
+		})
+	}
+	sort.Slice(status.Orders, func(i, j int) bool { return status.Orders[i].Price.GreaterThan(status.Orders[j].Price) })
+	return status
+}
@@ -0,0 +1,714 @@
+package trading
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"agent-quant-system/internal/config"
+	"agent-quant-system/internal/money"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/shopspring/decimal"
+)
+
+// 盈透证券网关的默认设置
+const (
+	defaultIBKRGatewayURL   = "https://localhost:5000/v1/api"
+	defaultIBKRPollInterval = 2 * time.Second
+	ibkrPositionsPageSize   = 100 // 持仓接口每页条数，不足一页说明已取完
+	ibkrMaxOrderReplies     = 5   // 下单时最多自动确认的提示次数
+)
+
+// OrderUpdateNotifier 支持推送订单状态变化的经纪商
+type OrderUpdateNotifier interface {
+	// OnOrderUpdate 注册订单状态变化回调
+	OnOrderUpdate(callback func(Order))
+}
+
+// IBKRBroker 通过 Client Portal Gateway 接入盈透证券的股票经纪商
+type IBKRBroker struct {
+	name         string
+	accountID    string
+	precision    *money.PrecisionTable
+	httpClient   *resty.Client
+	pollInterval time.Duration
+
+	contracts   map[string]int64 // 标的 -> 合约ID
+	orders      map[string]Order // 本地已知的订单及最近状态
+	callbacks   []func(Order)
+	isConnected bool
+	stopChan    chan struct{}
+	wg          sync.WaitGroup
+	mutex       sync.Mutex
+}
+
+// NewIBKRBroker 创建盈透证券经纪商
+func NewIBKRBroker(name string, cfg config.IBKRConfig, precision *money.PrecisionTable) *IBKRBroker {
+	gatewayURL := strings.TrimRight(cfg.GatewayURL, "/")
+	if gatewayURL == "" {
+		gatewayURL = defaultIBKRGatewayURL
+	}
+	pollInterval := cfg.PollInterval
+	if pollInterval <= 0 {
+		pollInterval = defaultIBKRPollInterval
+	}
+
+	client := resty.New()
+	client.SetBaseURL(gatewayURL)
+	client.SetTimeout(15 * time.Second)
+	client.SetHeader("Content-Type", "application/json")
+	client.SetHeader("Accept", "application/json")
+	if cfg.InsecureSkipVerify {
+		client.SetTLSClientConfig(&tls.Config{InsecureSkipVerify: true})
+	}
+
+	return &IBKRBroker{
+		name:         name,
+		accountID:    cfg.AccountID,
+		precision:    precision,
+		httpClient:   client,
+		pollInterval: pollInterval,
+		contracts:    make(map[string]int64),
+		orders:       make(map[string]Order),
+	}
+}
+
+// ibkrAuthStatus 网关会话状态
+type ibkrAuthStatus struct {
+	Authenticated bool   `json:"authenticated"`
+	Connected     bool   `json:"connected"`
+	Competing     bool   `json:"competing"`
+	Message       string `json:"message"`
+}
+
+// ibkrContract 合约搜索结果
+type ibkrContract struct {
+	ConID       json.Number `json:"conid"`
+	Symbol      string      `json:"symbol"`
+	CompanyName string      `json:"companyName"`
+	Description string      `json:"description"`
+}
+
+// ibkrOrderRequest 下单请求中的单个订单
+type ibkrOrderRequest struct {
+	ConID      int64   `json:"conid"`
+	SecType    string  `json:"secType"`
+	COID       string  `json:"cOID,omitempty"`
+	OrderType  string  `json:"orderType"`
+	Side       string  `json:"side"`
+	Quantity   float64 `json:"quantity"`
+	Price      float64 `json:"price,omitempty"`
+	AuxPrice   float64 `json:"auxPrice,omitempty"`
+	TIF        string  `json:"tif"`
+	OutsideRTH bool    `json:"outsideRTH"`
+}
+
+// ibkrOrderReply 下单应答：成功时返回订单ID，需要确认时返回提示ID和内容
+type ibkrOrderReply struct {
+	OrderID     string   `json:"order_id"`
+	OrderStatus string   `json:"order_status"`
+	ReplyID     string   `json:"id"`
+	Message     []string `json:"message"`
+	Error       string   `json:"error"`
+}
+
+// ibkrLiveOrder 订单列表中的订单
+type ibkrLiveOrder struct {
+	OrderID        json.Number `json:"orderId"`
+	ConID          json.Number `json:"conid"`
+	Ticker         string      `json:"ticker"`
+	Side           string      `json:"side"`
+	OrderType      string      `json:"orderType"`
+	Status         string      `json:"status"`
+	TotalSize      json.Number `json:"totalSize"`
+	FilledQuantity json.Number `json:"filledQuantity"`
+	AvgPrice       json.Number `json:"avgPrice"`
+	Price          json.Number `json:"price"`
+	OrderRef       string      `json:"order_ref"`
+	LastExecution  int64       `json:"lastExecutionTime_r"`
+}
+
+// ibkrPosition 持仓
+type ibkrPosition struct {
+	ConID         json.Number `json:"conid"`
+	Ticker        string      `json:"ticker"`
+	ContractDesc  string      `json:"contractDesc"`
+	Position      json.Number `json:"position"`
+	AvgPrice      json.Number `json:"avgPrice"`
+	MktValue      json.Number `json:"mktValue"`
+	UnrealizedPnl json.Number `json:"unrealizedPnl"`
+	RealizedPnl   json.Number `json:"realizedPnl"`
+}
+
+// ibkrTrade 成交记录
+type ibkrTrade struct {
+	ExecutionID string      `json:"execution_id"`
+	OrderID     json.Number `json:"order_id"`
+	OrderRef    string      `json:"order_ref"`
+	Symbol      string      `json:"symbol"`
+	Side        string      `json:"side"`
+	Size        json.Number `json:"size"`
+	Price       json.Number `json:"price"`
+	Commission  json.Number `json:"commission"`
+	TradeTime   int64       `json:"trade_time_r"`
+}
+
+// ibkrSummaryValue 账户概要中的单项
+type ibkrSummaryValue struct {
+	Amount   json.Number `json:"amount"`
+	Currency string      `json:"currency"`
+}
+
+// ibkrDecimal 解析网关返回的数字，缺失或无效时为0
+func ibkrDecimal(n json.Number) decimal.Decimal {
+	if n == "" {
+		return decimal.Zero
+	}
+	value, err := decimal.NewFromString(n.String())
+	if err != nil {
+		return decimal.Zero
+	}
+	return value
+}
+
+// ibkrTIF 转换订单有效期，未指定时为当日有效
+func ibkrTIF(tif TimeInForce) string {
+	if tif == "" {
+		return "DAY"
+	}
+	return strings.ToUpper(string(tif))
+}
+
+// mapIBKROrderStatus 将网关订单状态映射为系统订单状态
+func mapIBKROrderStatus(status string) OrderStatus {
+	switch strings.ToLower(status) {
+	case "filled":
+		return Filled
+	case "cancelled", "apicancelled":
+		return Cancelled
+	case "inactive", "rejected":
+		return Rejected
+	case "pendingsubmit", "presubmitted", "submitted", "pendingcancel":
+		return Submitted
+	default:
+		return Pending
+	}
+}
+
+// mapIBKRSide 将网关的买卖方向映射为系统订单方向
+func mapIBKRSide(side string) OrderSide {
+	switch strings.ToUpper(side) {
+	case "SELL", "S", "SLD":
+		return SellSide
+	default:
+		return BuySide
+	}
+}
+
+// ibkrOrderType 将系统订单类型映射为网关订单类型
+func ibkrOrderType(orderType OrderType) string {
+	switch orderType {
+	case LimitOrder:
+		return "LMT"
+	case StopOrder:
+		return "STP"
+	default:
+		return "MKT"
+	}
+}
+
+// do 发送请求并解析JSON响应，连接失败和未认证视为经纪商不可用
+func (b *IBKRBroker) do(method, path string, body, result interface{}) error {
+	request := b.httpClient.R()
+	if body != nil {
+		request.SetBody(body)
+	}
+	if result != nil {
+		request.SetResult(result).ForceContentType("application/json")
+	}
+
+	resp, err := request.Execute(method, path)
+	if err != nil {
+		return fmt.Errorf("请求盈透网关失败: %v: %w", err, ErrBrokerUnavailable)
+	}
+	if resp.StatusCode() == 401 {
+		return fmt.Errorf("盈透网关会话未认证: %w", ErrBrokerUnavailable)
+	}
+	if resp.IsError() {
+		return fmt.Errorf("盈透网关返回错误: %d %s", resp.StatusCode(), strings.TrimSpace(resp.String()))
+	}
+	return nil
+}
+
+// checkConnected 检查是否已连接
+func (b *IBKRBroker) checkConnected() error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if !b.isConnected {
+		return fmt.Errorf("经纪商未连接: %w", ErrBrokerUnavailable)
+	}
+	return nil
+}
+
+// Connect 检查网关会话并启动订单状态轮询
+func (b *IBKRBroker) Connect() error {
+	log.Printf("连接到盈透证券网关: %s, 账户=%s", b.name, b.accountID)
+
+	var status ibkrAuthStatus
+	if err := b.do("POST", "/iserver/auth/status", nil, &status); err != nil {
+		return err
+	}
+	if !status.Authenticated || !status.Connected {
+		return fmt.Errorf("盈透网关会话未就绪（authenticated=%v, connected=%v）: %w",
+			status.Authenticated, status.Connected, ErrBrokerUnavailable)
+	}
+
+	// 下单前需要先查询一次账户列表
+	var accounts struct {
+		Accounts []string `json:"accounts"`
+	}
+	if err := b.do("GET", "/iserver/accounts", nil, &accounts); err != nil {
+		return fmt.Errorf("获取账户列表失败: %w", err)
+	}
+	found := false
+	for _, id := range accounts.Accounts {
+		if id == b.accountID {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return fmt.Errorf("网关会话中没有账户 %s", b.accountID)
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.isConnected {
+		return nil
+	}
+	b.isConnected = true
+	b.stopChan = make(chan struct{})
+	b.wg.Add(1)
+	go b.pollOrders()
+	return nil
+}
+
+// Disconnect 停止订单轮询（不注销网关会话）
+func (b *IBKRBroker) Disconnect() error {
+	b.mutex.Lock()
+	if !b.isConnected {
+		b.mutex.Unlock()
+		return nil
+	}
+	b.isConnected = false
+	close(b.stopChan)
+	b.mutex.Unlock()
+
+	b.wg.Wait()
+	log.Printf("断开盈透证券连接: %s", b.name)
+	return nil
+}
+
+// Ping 健康检查：确认网关会话仍已认证并连接到盈透服务器
+func (b *IBKRBroker) Ping() error {
+	if err := b.checkConnected(); err != nil {
+		return err
+	}
+	var status ibkrAuthStatus
+	if err := b.do("POST", "/iserver/auth/status", nil, &status); err != nil {
+		return err
+	}
+	if !status.Authenticated || !status.Connected {
+		return fmt.Errorf("盈透网关会话已失效（authenticated=%v, connected=%v）: %w",
+			status.Authenticated, status.Connected, ErrBrokerUnavailable)
+	}
+	return nil
+}
+
+// OnOrderUpdate 注册订单状态变化回调
+func (b *IBKRBroker) OnOrderUpdate(callback func(Order)) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.callbacks = append(b.callbacks, callback)
+}
+
+// LookupContract 按标的查找股票合约ID（结果缓存）
+func (b *IBKRBroker) LookupContract(symbol string) (int64, error) {
+	symbol = strings.ToUpper(symbol)
+
+	b.mutex.Lock()
+	conID, exists := b.contracts[symbol]
+	b.mutex.Unlock()
+	if exists {
+		return conID, nil
+	}
+
+	var contracts []ibkrContract
+	if err := b.do("GET", "/iserver/secdef/search?secType=STK&symbol="+symbol, nil, &contracts); err != nil {
+		return 0, fmt.Errorf("查询合约失败: %w", err)
+	}
+
+	for _, contract := range contracts {
+		if !strings.EqualFold(contract.Symbol, symbol) || contract.ConID == "" {
+			continue
+		}
+		conID, err := contract.ConID.Int64()
+		if err != nil {
+			continue
+		}
+
+		b.mutex.Lock()
+		b.contracts[symbol] = conID
+		b.mutex.Unlock()
+		log.Printf("盈透合约: %s -> %d (%s %s)", symbol, conID, contract.CompanyName, contract.Description)
+		return conID, nil
+	}
+	return 0, fmt.Errorf("未找到标的 %s 的股票合约", symbol)
+}
+
+// PlaceOrder 下单，网关的确认提示自动回复
+func (b *IBKRBroker) PlaceOrder(order Order) (*Order, error) {
+	if err := b.checkConnected(); err != nil {
+		return nil, err
+	}
+
+	conID, err := b.LookupContract(order.Symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	precision := b.precision.For(order.Symbol)
+	request := ibkrOrderRequest{
+		ConID:     conID,
+		SecType:   fmt.Sprintf("%d:STK", conID),
+		COID:      order.ClientOrderID,
+		OrderType: ibkrOrderType(order.Type),
+		Side:      strings.ToUpper(string(order.Side)),
+		Quantity:  money.Float(precision.RoundQuantity(order.Quantity)),
+		TIF:       ibkrTIF(order.TimeInForce),
+	}
+	switch order.Type {
+	case LimitOrder:
+		request.Price = money.Float(precision.RoundPrice(order.Price))
+	case StopOrder:
+		request.Price = money.Float(precision.RoundPrice(order.StopPrice))
+	}
+
+	var replies []ibkrOrderReply
+	path := fmt.Sprintf("/iserver/account/%s/orders", b.accountID)
+	if err := b.do("POST", path, map[string]interface{}{"orders": []ibkrOrderRequest{request}}, &replies); err != nil {
+		return nil, fmt.Errorf("提交订单失败: %w", err)
+	}
+
+	for i := 0; ; i++ {
+		if len(replies) == 0 {
+			return nil, fmt.Errorf("盈透网关未返回下单结果")
+		}
+		reply := replies[0]
+		if reply.Error != "" {
+			return nil, fmt.Errorf("盈透拒绝订单: %s", reply.Error)
+		}
+		if reply.OrderID != "" {
+			order.ID = reply.OrderID
+			order.Status = mapIBKROrderStatus(reply.OrderStatus)
+			break
+		}
+		if reply.ReplyID == "" || i >= ibkrMaxOrderReplies {
+			return nil, fmt.Errorf("盈透订单未确认: %s", strings.Join(reply.Message, "; "))
+		}
+
+		log.Printf("确认盈透下单提示: %s", strings.Join(reply.Message, "; "))
+		replies = nil
+		if err := b.do("POST", "/iserver/reply/"+reply.ReplyID, map[string]bool{"confirmed": true}, &replies); err != nil {
+			return nil, fmt.Errorf("确认订单失败: %w", err)
+		}
+	}
+
+	order.UpdateTime = time.Now()
+	b.mutex.Lock()
+	b.orders[order.ID] = order
+	b.mutex.Unlock()
+
+	log.Printf("盈透订单已提交: 订单ID=%s, 标的=%s, 方向=%s, 数量=%s, 状态=%s",
+		order.ID, order.Symbol, order.Side, order.Quantity, order.Status)
+	return &order, nil
+}
+
+// CancelOrder 撤单
+func (b *IBKRBroker) CancelOrder(orderID string) error {
+	if err := b.checkConnected(); err != nil {
+		return err
+	}
+
+	path := fmt.Sprintf("/iserver/account/%s/order/%s", b.accountID, orderID)
+	if err := b.do("DELETE", path, nil, nil); err != nil {
+		return fmt.Errorf("撤单失败: %w", err)
+	}
+	return nil
+}
+
+// fetchOrders 从网关获取当日订单并与本地记录合并
+func (b *IBKRBroker) fetchOrders() ([]Order, error) {
+	var response struct {
+		Orders []ibkrLiveOrder `json:"orders"`
+	}
+	if err := b.do("GET", "/iserver/account/orders", nil, &response); err != nil {
+		return nil, fmt.Errorf("获取订单失败: %w", err)
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	orders := make([]Order, 0, len(response.Orders))
+	for _, live := range response.Orders {
+		id := live.OrderID.String()
+		order, exists := b.orders[id]
+		if !exists {
+			order = Order{
+				ID:          id,
+				Symbol:      strings.ToUpper(live.Ticker),
+				Side:        mapIBKRSide(live.Side),
+				Type:        MarketOrder,
+				Quantity:    ibkrDecimal(live.TotalSize),
+				Price:       ibkrDecimal(live.Price),
+				AccountName: b.name,
+				CreateTime:  time.Now(),
+			}
+			order.ClientOrderID = live.OrderRef
+			if strings.HasPrefix(strings.ToLower(live.OrderType), "lim") {
+				order.Type = LimitOrder
+			}
+		}
+		order.Status = mapIBKROrderStatus(live.Status)
+		order.FilledQty = ibkrDecimal(live.FilledQuantity)
+		if order.Status == Submitted && order.FilledQty.IsPositive() {
+			// 网关对部分成交的订单仍报告 Submitted
+			order.Status = PartiallyFilled
+		}
+		order.AvgPrice = ibkrDecimal(live.AvgPrice)
+		if live.LastExecution > 0 {
+			order.UpdateTime = time.UnixMilli(live.LastExecution)
+		}
+		orders = append(orders, order)
+	}
+	return orders, nil
+}
+
+// pollOrders 定期查询订单状态，状态或成交数量变化时触发回调，同时保持网关会话
+func (b *IBKRBroker) pollOrders() {
+	defer b.wg.Done()
+
+	ticker := time.NewTicker(b.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-b.stopChan:
+			return
+		case <-ticker.C:
+			if err := b.do("POST", "/tickle", nil, nil); err != nil {
+				log.Printf("盈透网关保活失败: %v", err)
+			}
+
+			orders, err := b.fetchOrders()
+			if err != nil {
+				log.Printf("盈透订单轮询失败: %v", err)
+				continue
+			}
+
+			var changed []Order
+			b.mutex.Lock()
+			for _, order := range orders {
+				previous, exists := b.orders[order.ID]
+				if exists && previous.Status == order.Status && previous.FilledQty.Equal(order.FilledQty) {
+					continue
+				}
+				b.orders[order.ID] = order
+				changed = append(changed, order)
+			}
+			callbacks := append([]func(Order){}, b.callbacks...)
+			b.mutex.Unlock()
+
+			for _, order := range changed {
+				log.Printf("盈透订单状态更新: 订单ID=%s, 状态=%s, 已成交=%s", order.ID, order.Status, order.FilledQty)
+				for _, callback := range callbacks {
+					callback(order)
+				}
+			}
+		}
+	}
+}
+
+// GetOrder 查询订单
+func (b *IBKRBroker) GetOrder(orderID string) (*Order, error) {
+	orders, err := b.GetOrders("", "")
+	if err != nil {
+		return nil, err
+	}
+	for _, order := range orders {
+		if order.ID == orderID {
+			return &order, nil
+		}
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if order, exists := b.orders[orderID]; exists {
+		return &order, nil
+	}
+	return nil, fmt.Errorf("订单 %s 不存在", orderID)
+}
+
+// GetOrderByClientID 按客户端订单号（网关的 order_ref）查询当日订单
+func (b *IBKRBroker) GetOrderByClientID(clientOrderID string) (*Order, error) {
+	orders, err := b.GetOrders("", "")
+	if err != nil {
+		return nil, err
+	}
+	for _, order := range orders {
+		if order.ClientOrderID == clientOrderID {
+			return &order, nil
+		}
+	}
+	return nil, fmt.Errorf("客户端订单号 %s: %w", clientOrderID, ErrOrderNotFound)
+}
+
+// GetOrders 查询订单列表
+func (b *IBKRBroker) GetOrders(symbol string, status OrderStatus) ([]Order, error) {
+	if err := b.checkConnected(); err != nil {
+		return nil, err
+	}
+
+	orders, err := b.fetchOrders()
+	if err != nil {
+		return nil, err
+	}
+
+	var result []Order
+	for _, order := range orders {
+		if symbol != "" && order.Symbol != symbol {
+			continue
+		}
+		if status != "" && order.Status != status {
+			continue
+		}
+		result = append(result, order)
+	}
+	return result, nil
+}
+
+// GetBalance 获取账户现金余额
+func (b *IBKRBroker) GetBalance() (decimal.Decimal, error) {
+	if err := b.checkConnected(); err != nil {
+		return decimal.Zero, err
+	}
+
+	var summary map[string]ibkrSummaryValue
+	if err := b.do("GET", fmt.Sprintf("/portfolio/%s/summary", b.accountID), nil, &summary); err != nil {
+		return decimal.Zero, fmt.Errorf("获取账户概要失败: %w", err)
+	}
+
+	cash, exists := summary["totalcashvalue"]
+	if !exists {
+		return decimal.Zero, fmt.Errorf("账户概要中缺少 totalcashvalue")
+	}
+	return b.precision.Defaults().RoundAmount(ibkrDecimal(cash.Amount)), nil
+}
+
+// GetPositions 获取持仓（按页读取全部持仓）
+func (b *IBKRBroker) GetPositions() (map[string]Position, error) {
+	if err := b.checkConnected(); err != nil {
+		return nil, err
+	}
+
+	positions := make(map[string]Position)
+	for page := 0; ; page++ {
+		var items []ibkrPosition
+		path := fmt.Sprintf("/portfolio/%s/positions/%d", b.accountID, page)
+		if err := b.do("GET", path, nil, &items); err != nil {
+			return nil, fmt.Errorf("获取持仓失败: %w", err)
+		}
+
+		for _, item := range items {
+			symbol := item.Ticker
+			if symbol == "" {
+				symbol = item.ContractDesc
+			}
+			symbol = strings.ToUpper(symbol)
+			positions[symbol] = Position{
+				Symbol:       symbol,
+				Quantity:     ibkrDecimal(item.Position),
+				AvgPrice:     ibkrDecimal(item.AvgPrice),
+				MarketValue:  ibkrDecimal(item.MktValue),
+				UnrealizedPL: ibkrDecimal(item.UnrealizedPnl),
+				RealizedPL:   ibkrDecimal(item.RealizedPnl),
+				UpdateTime:   time.Now(),
+			}
+		}
+
+		if len(items) < ibkrPositionsPageSize {
+			break
+		}
+	}
+	return positions, nil
+}
+
+// tradeOrderID 成交所属订单的订单号，与 PlaceOrder/GetOrders 使用的网关订单号一致，
+// 以便和订单、成交跟踪及交易日志关联；成交记录缺少订单号时按客户端订单号（order_ref）查找
+func (b *IBKRBroker) tradeOrderID(item ibkrTrade) string {
+	if id := item.OrderID.String(); id != "" {
+		return id
+	}
+	if item.OrderRef != "" {
+		for id, order := range b.orders {
+			if order.ClientOrderID == item.OrderRef {
+				return id
+			}
+		}
+	}
+	return item.OrderRef
+}
+
+// GetTrades 获取近期成交记录
+func (b *IBKRBroker) GetTrades(symbol string, limit int) ([]Trade, error) {
+	if err := b.checkConnected(); err != nil {
+		return nil, err
+	}
+
+	var items []ibkrTrade
+	if err := b.do("GET", "/iserver/account/trades", nil, &items); err != nil {
+		return nil, fmt.Errorf("获取成交记录失败: %w", err)
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	var trades []Trade
+	for _, item := range items {
+		if symbol != "" && !strings.EqualFold(item.Symbol, symbol) {
+			continue
+		}
+		trades = append(trades, Trade{
+			ID:          item.ExecutionID,
+			OrderID:     b.tradeOrderID(item),
+			Symbol:      strings.ToUpper(item.Symbol),
+			Side:        mapIBKRSide(item.Side),
+			Quantity:    ibkrDecimal(item.Size),
+			Price:       ibkrDecimal(item.Price),
+			Commission:  ibkrDecimal(item.Commission),
+			Timestamp:   time.UnixMilli(item.TradeTime),
+			AccountName: b.name,
+		})
+	}
+
+	if limit > 0 && len(trades) > limit {
+		trades = trades[len(trades)-limit:]
+	}
+	return trades, nil
+}
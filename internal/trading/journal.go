@@ -0,0 +1,326 @@
+package trading
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// JournalEntryKind 流水类型
+type JournalEntryKind string
+
+const (
+	JournalTrade   JournalEntryKind = "trade"   // 成交
+	JournalFunding JournalEntryKind = "funding" // 资金费用（如永续合约资金费、融资利息）
+)
+
+// JournalEntry 成交流水记录
+type JournalEntry struct {
+	Time           time.Time        `json:"time"`
+	Kind           JournalEntryKind `json:"kind"`
+	Account        string           `json:"account"`
+	Strategy       string           `json:"strategy"`
+	Symbol         string           `json:"symbol"`
+	OrderID        string           `json:"order_id,omitempty"`
+	Side           OrderSide        `json:"side,omitempty"`
+	Quantity       decimal.Decimal  `json:"quantity"`
+	Price          decimal.Decimal  `json:"price"`           // 成交均价
+	ReferencePrice decimal.Decimal  `json:"reference_price"` // 下单时的参考价格
+	Commission     decimal.Decimal  `json:"commission"`
+	Slippage       decimal.Decimal  `json:"slippage"` // 相对参考价格的不利成交成本
+	Funding        decimal.Decimal  `json:"funding"`  // 正数表示支出
+}
+
+// TradeJournal 持久化的成交流水，按行追加JSON，启动时加载当月记录用于成本统计
+type TradeJournal struct {
+	path    string
+	entries []JournalEntry
+	mutex   sync.RWMutex
+}
+
+// NewTradeJournal 打开成交流水文件
+func NewTradeJournal(path string) (*TradeJournal, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("创建流水目录失败: %w", err)
+	}
+
+	journal := &TradeJournal{path: path}
+	if err := journal.load(); err != nil {
+		return nil, err
+	}
+
+	log.Printf("已加载成交流水: 文件=%s, 本月记录=%d", path, len(journal.entries))
+	return journal, nil
+}
+
+// load 加载当月流水记录
+func (j *TradeJournal) load() error {
+	entries, err := ReadJournal(j.path, startOfMonth(time.Now()))
+	if err != nil {
+		return err
+	}
+	j.entries = entries
+	return nil
+}
+
+// ReadJournal 读取流水文件中 since 之后的记录，文件不存在时返回空
+func ReadJournal(path string, since time.Time) ([]JournalEntry, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("打开成交流水失败: %w", err)
+	}
+	defer file.Close()
+
+	var entries []JournalEntry
+	scanner := bufio.NewScanner(file)
+	line := 0
+	for scanner.Scan() {
+		line++
+		var entry JournalEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			log.Printf("跳过无法解析的流水记录: 行=%d, 错误=%v", line, err)
+			continue
+		}
+		if entry.Time.Before(since) {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取成交流水失败: %w", err)
+	}
+	return entries, nil
+}
+
+// Record 追加一条流水记录
+func (j *TradeJournal) Record(entry JournalEntry) error {
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("序列化流水记录失败: %w", err)
+	}
+
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	file, err := os.OpenFile(j.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("打开成交流水失败: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("写入成交流水失败: %w", err)
+	}
+
+	// 内存中只保留当月记录
+	monthStart := startOfMonth(time.Now())
+	if len(j.entries) > 0 && j.entries[0].Time.Before(monthStart) {
+		kept := j.entries[:0]
+		for _, existing := range j.entries {
+			if !existing.Time.Before(monthStart) {
+				kept = append(kept, existing)
+			}
+		}
+		j.entries = kept
+	}
+	j.entries = append(j.entries, entry)
+	return nil
+}
+
+// CostBreakdown 交易成本明细
+type CostBreakdown struct {
+	Trades     int             `json:"trades"`
+	Commission decimal.Decimal `json:"commission"`
+	Slippage   decimal.Decimal `json:"slippage"`
+	Funding    decimal.Decimal `json:"funding"`
+	Total      decimal.Decimal `json:"total"`
+}
+
+// add 累加一条流水
+func (c *CostBreakdown) add(entry JournalEntry) {
+	if entry.Kind == JournalTrade {
+		c.Trades++
+	}
+	c.Commission = c.Commission.Add(entry.Commission)
+	c.Slippage = c.Slippage.Add(entry.Slippage)
+	c.Funding = c.Funding.Add(entry.Funding)
+	c.Total = c.Commission.Add(c.Slippage).Add(c.Funding)
+}
+
+// PeriodCosts 当日和当月的交易成本
+type PeriodCosts struct {
+	Day   CostBreakdown `json:"day"`
+	Month CostBreakdown `json:"month"`
+}
+
+// add 按时间累加到当日/当月
+func (p *PeriodCosts) add(entry JournalEntry, dayStart time.Time) {
+	p.Month.add(entry)
+	if !entry.Time.Before(dayStart) {
+		p.Day.add(entry)
+	}
+}
+
+// CostSummary 交易成本汇总
+type CostSummary struct {
+	Total      PeriodCosts             `json:"total"`
+	ByAccount  map[string]*PeriodCosts `json:"by_account"`
+	ByStrategy map[string]*PeriodCosts `json:"by_strategy"`
+}
+
+// CostSummary 统计当日和当月的成本，按账户和策略分组
+func (j *TradeJournal) CostSummary(now time.Time) *CostSummary {
+	j.mutex.RLock()
+	defer j.mutex.RUnlock()
+
+	summary := &CostSummary{
+		ByAccount:  make(map[string]*PeriodCosts),
+		ByStrategy: make(map[string]*PeriodCosts),
+	}
+
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	monthStart := startOfMonth(now)
+
+	for _, entry := range j.entries {
+		if entry.Time.Before(monthStart) || entry.Time.After(now) {
+			continue
+		}
+
+		summary.Total.add(entry, dayStart)
+
+		accountCosts, exists := summary.ByAccount[entry.Account]
+		if !exists {
+			accountCosts = &PeriodCosts{}
+			summary.ByAccount[entry.Account] = accountCosts
+		}
+		accountCosts.add(entry, dayStart)
+
+		strategyName := entry.Strategy
+		if strategyName == "" {
+			strategyName = "manual"
+		}
+		strategyCosts, exists := summary.ByStrategy[strategyName]
+		if !exists {
+			strategyCosts = &PeriodCosts{}
+			summary.ByStrategy[strategyName] = strategyCosts
+		}
+		strategyCosts.add(entry, dayStart)
+	}
+
+	return summary
+}
+
+// startOfMonth 当月第一天零点
+func startOfMonth(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
+
+// recordFill 将订单成交记入税务批次并写入流水
+func (te *TradingEngine) recordFill(filled *Order, requested Order, accountName string) {
+	if !filled.FilledQty.IsPositive() {
+		return
+	}
+
+	referencePrice := requested.referencePrice
+	if !referencePrice.IsPositive() {
+		referencePrice = requested.Price
+	}
+
+	// 只统计不利方向的价格偏差
+	slippage := decimal.Zero
+	if referencePrice.IsPositive() && filled.AvgPrice.IsPositive() {
+		diff := filled.AvgPrice.Sub(referencePrice)
+		if filled.Side == SellSide {
+			diff = diff.Neg()
+		}
+		slippage = decimal.Max(diff, decimal.Zero).Mul(filled.FilledQty)
+	}
+
+	entry := JournalEntry{
+		Time:           filled.UpdateTime,
+		Kind:           JournalTrade,
+		Account:        accountName,
+		Strategy:       requested.Strategy,
+		Symbol:         filled.Symbol,
+		OrderID:        filled.ID,
+		Side:           filled.Side,
+		Quantity:       filled.FilledQty,
+		Price:          filled.AvgPrice,
+		ReferencePrice: referencePrice,
+		Commission:     filled.Commission,
+		Slippage:       slippage,
+	}
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+	te.taxLots.Apply(entry)
+	if te.journal == nil {
+		return
+	}
+	if err := te.journal.Record(entry); err != nil {
+		log.Printf("记录成交流水失败: %v", err)
+	}
+}
+
+// fundingStrategy 没有策略持仓时资金费用的策略归属
+const fundingStrategy = "funding"
+
+// RecordFunding 记录资金费用（正数为支出），供合约/融资类经纪商使用：记入盈亏账本，启用成交流水时同时写入流水。
+// strategyName 为空时按各策略在该标的上的持仓数量分摊
+func (te *TradingEngine) RecordFunding(accountName, strategyName, symbol string, amount decimal.Decimal) error {
+	if amount.IsZero() {
+		return nil
+	}
+
+	shares := map[string]decimal.Decimal{strategyName: decimal.NewFromInt(1)}
+	if strategyName == "" {
+		if shares = te.pnl.FundingShares(accountName, symbol); len(shares) == 0 {
+			shares = map[string]decimal.Decimal{fundingStrategy: decimal.NewFromInt(1)}
+		}
+	}
+
+	now := time.Now()
+	var failed error
+	for strategy, share := range shares {
+		portion := amount.Mul(share).Round(8)
+		te.pnl.ApplyFunding(accountName, strategy, symbol, portion)
+		if te.journal == nil {
+			continue
+		}
+		err := te.journal.Record(JournalEntry{
+			Time:     now,
+			Kind:     JournalFunding,
+			Account:  accountName,
+			Strategy: strategy,
+			Symbol:   symbol,
+			Funding:  portion,
+		})
+		if err != nil && failed == nil {
+			failed = err
+		}
+	}
+	return failed
+}
+
+// GetCostSummary 获取交易成本汇总（未启用成交流水时返回nil）
+func (te *TradingEngine) GetCostSummary() *CostSummary {
+	if te.journal == nil {
+		return nil
+	}
+	return te.journal.CostSummary(time.Now())
+}
@@ -0,0 +1,325 @@
+package trading
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"agent-quant-system/internal/config"
+	"agent-quant-system/internal/notify"
+
+	"github.com/shopspring/decimal"
+)
+
+// ErrTradingHalted 交易已被紧急停止，需显式恢复后才能下单
+var ErrTradingHalted = errors.New("交易已紧急停止")
+
+// HaltTrigger 紧急停止的触发原因
+type HaltTrigger string
+
+const (
+	HaltManual       HaltTrigger = "manual"        // 命令行或控制API
+	HaltDailyLoss    HaltTrigger = "daily_loss"    // 达到单日亏损上限
+	HaltDrawdown     HaltTrigger = "drawdown"      // 达到最大回撤
+	HaltFailedCycles HaltTrigger = "failed_cycles" // 连续交易循环失败
+)
+
+// haltExemptStrategies 紧急停止期间仍允许执行的订单来源：手动平仓和止损止盈，均只减少持仓
+var haltExemptStrategies = map[string]bool{
+	"close_position":   true,
+	"position_monitor": true,
+}
+
+// HaltState 紧急停止状态
+type HaltState struct {
+	Halted  bool        `json:"halted"`
+	Trigger HaltTrigger `json:"trigger,omitempty"`
+	Reason  string      `json:"reason,omitempty"`
+	Since   time.Time   `json:"since,omitempty"`
+}
+
+// KillSwitch 引擎级紧急停止：手动或在亏损、回撤、连续失败超限时停止交易，
+// 状态写入文件以便其他进程（如命令行）停止或恢复正在运行的引擎，重启后仍保持停止
+type KillSwitch struct {
+	cfg config.KillSwitchConfig
+
+	state        HaltState
+	stateModTime time.Time
+	equityTracks map[string]*equityTrack
+	failedInARow int
+	mutex        sync.Mutex
+}
+
+// NewKillSwitch 创建紧急停止开关并加载已保存的状态
+func NewKillSwitch(cfg config.KillSwitchConfig) *KillSwitch {
+	ks := &KillSwitch{
+		cfg:          cfg,
+		equityTracks: make(map[string]*equityTrack),
+	}
+	ks.refresh()
+	if ks.state.Halted {
+		log.Printf("交易处于紧急停止状态（%s），需恢复后才能下单: %s", ks.state.Since.Format("2006-01-02 15:04:05"), ks.state.Reason)
+	}
+	return ks
+}
+
+// refresh 状态文件被其他进程修改时重新加载，调用方需持有锁或处于初始化阶段
+func (ks *KillSwitch) refresh() {
+	if ks.cfg.StateFile == "" {
+		return
+	}
+	info, err := os.Stat(ks.cfg.StateFile)
+	if err != nil {
+		if os.IsNotExist(err) && !ks.stateModTime.IsZero() {
+			ks.state = HaltState{}
+			ks.stateModTime = time.Time{}
+		}
+		return
+	}
+	if info.ModTime().Equal(ks.stateModTime) {
+		return
+	}
+
+	content, err := os.ReadFile(ks.cfg.StateFile)
+	if err != nil {
+		log.Printf("读取紧急停止状态失败: %v", err)
+		return
+	}
+	var state HaltState
+	if err := json.Unmarshal(content, &state); err != nil {
+		log.Printf("解析紧急停止状态失败: %v", err)
+		return
+	}
+	if ks.state.Halted && !state.Halted {
+		ks.resetTracking()
+	}
+	ks.state = state
+	ks.stateModTime = info.ModTime()
+}
+
+// save 写入状态文件，调用方需持有锁
+func (ks *KillSwitch) save() {
+	if ks.cfg.StateFile == "" {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(ks.cfg.StateFile), 0755); err != nil {
+		log.Printf("创建紧急停止状态目录失败: %v", err)
+		return
+	}
+	content, err := json.MarshalIndent(ks.state, "", "  ")
+	if err != nil {
+		log.Printf("序列化紧急停止状态失败: %v", err)
+		return
+	}
+	if err := os.WriteFile(ks.cfg.StateFile, content, 0644); err != nil {
+		log.Printf("写入紧急停止状态失败: %v", err)
+		return
+	}
+	if info, err := os.Stat(ks.cfg.StateFile); err == nil {
+		ks.stateModTime = info.ModTime()
+	}
+}
+
+// resetTracking 恢复交易时重新开始统计权益和连续失败，调用方需持有锁
+func (ks *KillSwitch) resetTracking() {
+	ks.equityTracks = make(map[string]*equityTrack)
+	ks.failedInARow = 0
+}
+
+// State 获取当前紧急停止状态
+func (ks *KillSwitch) State() HaltState {
+	ks.mutex.Lock()
+	defer ks.mutex.Unlock()
+	ks.refresh()
+	return ks.state
+}
+
+// Halt 停止交易，已停止时返回 false
+func (ks *KillSwitch) Halt(trigger HaltTrigger, reason string) bool {
+	ks.mutex.Lock()
+	defer ks.mutex.Unlock()
+	ks.refresh()
+
+	if ks.state.Halted {
+		return false
+	}
+	ks.state = HaltState{Halted: true, Trigger: trigger, Reason: reason, Since: time.Now()}
+	ks.save()
+	return true
+}
+
+// Resume 恢复交易，未停止时返回 false
+func (ks *KillSwitch) Resume() bool {
+	ks.mutex.Lock()
+	defer ks.mutex.Unlock()
+	ks.refresh()
+
+	if !ks.state.Halted {
+		return false
+	}
+	ks.state = HaltState{}
+	ks.resetTracking()
+	ks.save()
+	return true
+}
+
+// RecordCycle 记录交易循环结果，连续失败达到上限时返回触发原因
+func (ks *KillSwitch) RecordCycle(success bool) (HaltTrigger, string) {
+	ks.mutex.Lock()
+	defer ks.mutex.Unlock()
+
+	if success {
+		ks.failedInARow = 0
+		return "", ""
+	}
+	ks.failedInARow++
+	if ks.cfg.MaxFailedCycles > 0 && ks.failedInARow >= ks.cfg.MaxFailedCycles {
+		return HaltFailedCycles, fmt.Sprintf("连续 %d 个交易循环失败", ks.failedInARow)
+	}
+	return "", ""
+}
+
+// CheckEquity 更新账户权益，达到单日亏损或最大回撤上限时返回触发原因
+func (ks *KillSwitch) CheckEquity(accountName string, equity decimal.Decimal) (HaltTrigger, string) {
+	ks.mutex.Lock()
+	defer ks.mutex.Unlock()
+
+	today := time.Now().Format("2006-01-02")
+	track, exists := ks.equityTracks[accountName]
+	if !exists {
+		track = &equityTrack{day: today, dayStart: equity, peak: equity}
+		ks.equityTracks[accountName] = track
+	}
+	if track.day != today {
+		track.day = today
+		track.dayStart = equity
+	}
+	if equity.GreaterThan(track.peak) {
+		track.peak = equity
+	}
+
+	if ks.cfg.MaxDailyLoss > 0 && track.dayStart.IsPositive() {
+		dailyLoss := track.dayStart.Sub(equity).Div(track.dayStart).InexactFloat64()
+		if dailyLoss >= ks.cfg.MaxDailyLoss {
+			return HaltDailyLoss, fmt.Sprintf("账户 '%s' 单日亏损 %.2f%% >= %.2f%%", accountName, dailyLoss*100, ks.cfg.MaxDailyLoss*100)
+		}
+	}
+	if ks.cfg.MaxDrawdown > 0 && track.peak.IsPositive() {
+		drawdown := track.peak.Sub(equity).Div(track.peak).InexactFloat64()
+		if drawdown >= ks.cfg.MaxDrawdown {
+			return HaltDrawdown, fmt.Sprintf("账户 '%s' 回撤 %.2f%% >= %.2f%%", accountName, drawdown*100, ks.cfg.MaxDrawdown*100)
+		}
+	}
+	return "", ""
+}
+
+// checkHalted 紧急停止期间拒绝除平仓、止损止盈以外的订单
+func (te *TradingEngine) checkHalted(order Order) error {
+	if haltExemptStrategies[order.Strategy] {
+		return nil
+	}
+	if state := te.killSwitch.State(); state.Halted {
+		return fmt.Errorf("%w: %s", ErrTradingHalted, state.Reason)
+	}
+	return nil
+}
+
+// Halt 紧急停止交易：撤销所有账户的未成交订单，之后的信号不再执行，直到调用 Resume
+func (te *TradingEngine) Halt(trigger HaltTrigger, reason string) HaltState {
+	if te.killSwitch.Halt(trigger, reason) {
+		log.Printf("交易紧急停止: 触发=%s, 原因=%s", trigger, reason)
+		cancelled := te.CancelOpenOrders()
+		te.notifier.Notifyf(notify.EventHalt, "交易紧急停止",
+			"触发=%s, 原因=%s\n已撤销 %d 个未成交订单，恢复前不会执行新的信号", trigger, reason, cancelled)
+	}
+	return te.killSwitch.State()
+}
+
+// Resume 恢复紧急停止的交易
+func (te *TradingEngine) Resume() HaltState {
+	if te.killSwitch.Resume() {
+		log.Printf("交易已恢复")
+		te.notifier.Notifyf(notify.EventHalt, "交易已恢复", "紧急停止已解除，信号恢复执行")
+	}
+	return te.killSwitch.State()
+}
+
+// GetHaltState 获取紧急停止状态
+func (te *TradingEngine) GetHaltState() HaltState {
+	return te.killSwitch.State()
+}
+
+// RecordCycle 记录交易循环结果并检查各账户权益，达到自动停止条件时紧急停止交易
+func (te *TradingEngine) RecordCycle(success bool) {
+	if !te.currentConfig().Risk.KillSwitch.Enabled || te.killSwitch.State().Halted {
+		return
+	}
+
+	if trigger, reason := te.killSwitch.RecordCycle(success); trigger != "" {
+		te.Halt(trigger, reason)
+		return
+	}
+
+	te.mutex.RLock()
+	brokers := make(map[string]BrokerAPI, len(te.brokers))
+	for name, broker := range te.brokers {
+		brokers[name] = broker
+	}
+	te.mutex.RUnlock()
+
+	for accountName, broker := range brokers {
+		equity, err := accountEquity(broker)
+		if err != nil {
+			log.Printf("获取账户 '%s' 权益失败，跳过紧急停止检查: %v", accountName, err)
+			continue
+		}
+		if trigger, reason := te.killSwitch.CheckEquity(accountName, equity); trigger != "" {
+			te.Halt(trigger, reason)
+			return
+		}
+	}
+}
+
+// CancelOpenOrders 撤销所有账户的未成交订单，返回撤销数量
+func (te *TradingEngine) CancelOpenOrders() int {
+	te.mutex.RLock()
+	brokers := make(map[string]BrokerAPI, len(te.brokers))
+	for name, broker := range te.brokers {
+		brokers[name] = broker
+	}
+	te.mutex.RUnlock()
+
+	cancelled := 0
+	for accountName, broker := range brokers {
+		for _, status := range append([]OrderStatus{Pending}, OpenOrderStatuses...) {
+			orders, err := broker.GetOrders("", status)
+			if err != nil {
+				log.Printf("查询账户 '%s' 的未成交订单失败: %v", accountName, err)
+				continue
+			}
+			for _, order := range orders {
+				if te.dryRunCancel(order, accountName, "紧急停止撤销未成交订单") {
+					continue
+				}
+				err := broker.CancelOrder(order.ID)
+				te.auditCancel(order, accountName, "紧急停止撤销未成交订单", err)
+				if err != nil {
+					log.Printf("撤销订单失败: 账户=%s, 订单ID=%s, 错误=%v", accountName, order.ID, err)
+					continue
+				}
+				te.releaseReservations(order.ID)
+				cancelled++
+			}
+		}
+	}
+
+	if cancelled > 0 {
+		log.Printf("已撤销 %d 个未成交订单", cancelled)
+	}
+	return cancelled
+}
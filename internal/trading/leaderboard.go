@@ -0,0 +1,285 @@
+package trading
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"agent-quant-system/internal/config"
+	"agent-quant-system/internal/money"
+)
+
+// leaderboardYear 年化夏普比率使用的一年时长（按连续时间计算）
+const leaderboardYear = 365 * 24 * time.Hour
+
+// LeaderboardPoint 策略的一次盈亏采样
+type LeaderboardPoint struct {
+	Time time.Time `json:"time"`
+	PnL  float64   `json:"pnl"` // 累计盈亏（已实现+未实现）
+}
+
+// WindowPerformance 策略在一个滚动窗口内的表现
+type WindowPerformance struct {
+	Window      time.Duration `json:"window"`
+	PnL         float64       `json:"pnl"`          // 窗口内累计盈亏的变化
+	SharpeRatio float64       `json:"sharpe_ratio"` // 按相邻采样的盈亏变化计算的年化夏普比率
+	MaxDrawdown float64       `json:"max_drawdown"` // 窗口内累计盈亏从高点回落的最大金额
+	Samples     int           `json:"samples"`
+	Complete    bool          `json:"complete"` // 采样记录是否覆盖整个窗口
+}
+
+// LeaderboardEntry 排行榜中的一个策略
+type LeaderboardEntry struct {
+	Rank     int                 `json:"rank"`
+	Strategy string              `json:"strategy"`
+	Active   bool                `json:"active"` // 是否在 strategy.active 中
+	TotalPnL float64             `json:"total_pnl"`
+	Windows  []WindowPerformance `json:"windows"`  // 与 windows 配置的顺序相同
+	Decaying bool                `json:"decaying"` // 最短窗口夏普比率为负而最长窗口为正
+}
+
+// LeaderboardReport 策略排行榜，金额为各账户计价币种的合计，未做汇率折算
+type LeaderboardReport struct {
+	Time       time.Time          `json:"time"`
+	RankBy     string             `json:"rank_by"`
+	RankWindow time.Duration      `json:"rank_window"`
+	Entries    []LeaderboardEntry `json:"entries"`
+}
+
+// Leaderboard 策略排行榜：定期采样各策略的累计盈亏，按滚动窗口计算表现并排名
+type Leaderboard struct {
+	cfg    config.LeaderboardConfig
+	points map[string][]LeaderboardPoint
+	mutex  sync.Mutex
+}
+
+// NewLeaderboard 创建策略排行榜并加载盈亏采样
+func NewLeaderboard(cfg config.LeaderboardConfig) *Leaderboard {
+	lb := &Leaderboard{cfg: cfg, points: make(map[string][]LeaderboardPoint)}
+	if err := lb.load(); err != nil {
+		log.Printf("加载策略排行榜采样失败，将重新记录: %v", err)
+	}
+	return lb
+}
+
+// load 加载采样文件
+func (lb *Leaderboard) load() error {
+	content, err := os.ReadFile(lb.cfg.StateFile)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("读取采样文件失败: %w", err)
+	}
+	if err := json.Unmarshal(content, &lb.points); err != nil {
+		return fmt.Errorf("解析采样文件失败: %w", err)
+	}
+	return nil
+}
+
+// save 写入采样文件，调用方需持有锁
+func (lb *Leaderboard) save() {
+	if err := os.MkdirAll(filepath.Dir(lb.cfg.StateFile), 0755); err != nil {
+		log.Printf("创建策略排行榜目录失败: %v", err)
+		return
+	}
+	content, err := json.Marshal(lb.points)
+	if err != nil {
+		log.Printf("序列化策略排行榜采样失败: %v", err)
+		return
+	}
+	if err := os.WriteFile(lb.cfg.StateFile, content, 0644); err != nil {
+		log.Printf("写入策略排行榜采样失败: %v", err)
+	}
+}
+
+// longestWindow 最长的滚动窗口
+func (lb *Leaderboard) longestWindow() time.Duration {
+	longest := time.Duration(0)
+	for _, window := range lb.cfg.Windows {
+		if window > longest {
+			longest = window
+		}
+	}
+	return longest
+}
+
+// Sample 记录各策略当前的累计盈亏，丢弃超出最长窗口的采样（保留窗口起点前的最后一个作为基准）
+func (lb *Leaderboard) Sample(now time.Time, totals map[string]*PnLTotals) {
+	lb.mutex.Lock()
+	defer lb.mutex.Unlock()
+
+	for name, total := range totals {
+		lb.points[name] = append(lb.points[name], LeaderboardPoint{Time: now, PnL: money.Float(total.TotalPnL)})
+	}
+
+	cutoff := now.Add(-lb.longestWindow())
+	for name, points := range lb.points {
+		first := 0
+		for i, point := range points {
+			if point.Time.After(cutoff) {
+				break
+			}
+			first = i
+		}
+		lb.points[name] = points[first:]
+	}
+	lb.save()
+}
+
+// Rank 按采样和当前盈亏计算各策略的窗口表现并排名；rankBy 和 rankWindow 为空时使用配置
+func (lb *Leaderboard) Rank(now time.Time, current map[string]*PnLTotals, active []string, rankBy string, rankWindow time.Duration) (*LeaderboardReport, error) {
+	if rankBy == "" {
+		rankBy = lb.cfg.RankBy
+	}
+	if rankWindow == 0 {
+		rankWindow = lb.cfg.RankWindow
+	}
+	rankIndex := -1
+	for i, window := range lb.cfg.Windows {
+		if window == rankWindow {
+			rankIndex = i
+		}
+	}
+	if rankIndex < 0 {
+		return nil, fmt.Errorf("窗口 %v 不在 windows 配置中", rankWindow)
+	}
+	var less func(a, b WindowPerformance) bool
+	switch rankBy {
+	case "sharpe":
+		less = func(a, b WindowPerformance) bool { return a.SharpeRatio > b.SharpeRatio }
+	case "pnl":
+		less = func(a, b WindowPerformance) bool { return a.PnL > b.PnL }
+	case "drawdown":
+		less = func(a, b WindowPerformance) bool { return a.MaxDrawdown < b.MaxDrawdown }
+	default:
+		return nil, fmt.Errorf("不支持的排名指标: %s", rankBy)
+	}
+
+	activeSet := make(map[string]bool, len(active))
+	for _, name := range active {
+		activeSet[name] = true
+	}
+
+	// 复制采样，计算时不持有锁
+	lb.mutex.Lock()
+	series := make(map[string][]LeaderboardPoint, len(lb.points))
+	for name, points := range lb.points {
+		series[name] = append([]LeaderboardPoint(nil), points...)
+	}
+	lb.mutex.Unlock()
+	for name, total := range current {
+		series[name] = append(series[name], LeaderboardPoint{Time: now, PnL: money.Float(total.TotalPnL)})
+	}
+	for name := range activeSet {
+		if len(series[name]) == 0 {
+			series[name] = []LeaderboardPoint{{Time: now}}
+		}
+	}
+
+	report := &LeaderboardReport{Time: now, RankBy: rankBy, RankWindow: rankWindow}
+	for name, points := range series {
+		entry := LeaderboardEntry{Strategy: name, Active: activeSet[name], TotalPnL: points[len(points)-1].PnL}
+		for _, window := range lb.cfg.Windows {
+			entry.Windows = append(entry.Windows, windowPerformance(points, now, window))
+		}
+		if n := len(entry.Windows); n >= 2 {
+			entry.Decaying = entry.Windows[0].SharpeRatio < 0 && entry.Windows[n-1].SharpeRatio > 0
+		}
+		report.Entries = append(report.Entries, entry)
+	}
+
+	sort.Slice(report.Entries, func(i, j int) bool {
+		a, b := report.Entries[i].Windows[rankIndex], report.Entries[j].Windows[rankIndex]
+		if less(a, b) {
+			return true
+		}
+		if less(b, a) {
+			return false
+		}
+		return report.Entries[i].Strategy < report.Entries[j].Strategy
+	})
+	for i := range report.Entries {
+		report.Entries[i].Rank = i + 1
+	}
+	return report, nil
+}
+
+// windowPerformance 计算 [now-window, now] 内的表现，以窗口起点前的最后一个采样作为基准
+func windowPerformance(series []LeaderboardPoint, now time.Time, window time.Duration) WindowPerformance {
+	performance := WindowPerformance{Window: window}
+	start := now.Add(-window)
+	first := 0
+	for i, point := range series {
+		if point.Time.After(start) {
+			break
+		}
+		first = i
+		performance.Complete = true
+	}
+	points := series[first:]
+	performance.Samples = len(points)
+	if len(points) == 0 {
+		return performance
+	}
+
+	performance.PnL = points[len(points)-1].PnL - points[0].PnL
+	peak := points[0].PnL
+	for _, point := range points {
+		peak = math.Max(peak, point.PnL)
+		performance.MaxDrawdown = math.Max(performance.MaxDrawdown, peak-point.PnL)
+	}
+
+	// 至少需要两个盈亏变化才能估计波动
+	if len(points) < 3 {
+		return performance
+	}
+	changes := make([]float64, len(points)-1)
+	mean := 0.0
+	for i := 1; i < len(points); i++ {
+		changes[i-1] = points[i].PnL - points[i-1].PnL
+		mean += changes[i-1]
+	}
+	mean /= float64(len(changes))
+	variance := 0.0
+	for _, change := range changes {
+		variance += (change - mean) * (change - mean)
+	}
+	variance /= float64(len(changes) - 1)
+
+	spacing := points[len(points)-1].Time.Sub(points[0].Time) / time.Duration(len(changes))
+	if std := math.Sqrt(variance); std > 0 && spacing > 0 {
+		performance.SharpeRatio = mean / std * math.Sqrt(float64(leaderboardYear)/float64(spacing))
+	}
+	return performance
+}
+
+// runLeaderboardSampling 按采样间隔记录各策略的累计盈亏
+func (te *TradingEngine) runLeaderboardSampling(stop <-chan struct{}) {
+	ticker := time.NewTicker(te.currentConfig().Trading.Leaderboard.SampleInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			te.leaderboard.Sample(time.Now(), te.GetPnL().ByStrategy)
+			return
+		case now := <-ticker.C:
+			te.leaderboard.Sample(now, te.GetPnL().ByStrategy)
+		}
+	}
+}
+
+// GetLeaderboard 获取策略排行榜；rankBy 和 rankWindow 为空时使用配置
+func (te *TradingEngine) GetLeaderboard(rankBy string, rankWindow time.Duration) (*LeaderboardReport, error) {
+	if te.leaderboard == nil {
+		return nil, fmt.Errorf("未启用策略排行榜 (trading.leaderboard.enabled)")
+	}
+	return te.leaderboard.Rank(time.Now(), te.GetPnL().ByStrategy, te.currentConfig().Strategy.Active, rankBy, rankWindow)
+}
@@ -0,0 +1,188 @@
+package trading
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"agent-quant-system/internal/account"
+	"agent-quant-system/internal/config"
+	"agent-quant-system/internal/money"
+
+	"github.com/shopspring/decimal"
+)
+
+// MarginStatus 保证金账户状态
+type MarginStatus struct {
+	Leverage          float64         `json:"leverage"`
+	Equity            decimal.Decimal `json:"equity"`
+	PositionValue     decimal.Decimal `json:"position_value"`     // 多空持仓总市值
+	Borrowed          decimal.Decimal `json:"borrowed"`           // 现金为负的部分
+	BuyingPower       decimal.Decimal `json:"buying_power"`       // 权益 * 杠杆 - 持仓市值
+	Utilization       float64         `json:"utilization"`        // 持仓市值 / (权益 * 杠杆)
+	MaintenanceMargin decimal.Decimal `json:"maintenance_margin"` // 维持保证金金额，权益低于该值时强制平仓
+	Interest          decimal.Decimal `json:"interest"`           // 累计融资利息
+	MarginCalls       int             `json:"margin_calls"`       // 强制平仓次数
+}
+
+// MarginReporter 支持保证金交易的经纪商，报告杠杆、借入资金和保证金使用率
+type MarginReporter interface {
+	MarginStatus() (MarginStatus, error)
+}
+
+// SetMargin 设置保证金交易：买入按权益乘以杠杆的购买力检查，现金为负的部分按年化利率计息，
+// 权益低于持仓市值的维持保证金率时按实时报价强制平掉全部持仓
+func (b *PaperBroker) SetMargin(margin config.MarginConfig) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.margin = margin
+	b.interestAt = time.Now()
+}
+
+// positionValue 持仓按实时报价计算的净市值（空仓为负）和总市值（多空绝对值之和），
+// 没有报价的标的使用最近一次成交时的市值
+func (b *PaperBroker) positionValue(quotes map[string]decimal.Decimal) (decimal.Decimal, decimal.Decimal) {
+	net, gross := decimal.Zero, decimal.Zero
+	for symbol, position := range b.positions {
+		value := position.MarketValue
+		if quote, quoted := quotes[symbol]; quoted {
+			value = position.Quantity.Mul(quote).Mul(b.multiplier(symbol))
+		}
+		net = net.Add(value)
+		gross = gross.Add(value.Abs())
+	}
+	return net, gross
+}
+
+// buyingPower 可用于买入或开空的资金：权益 * 杠杆 - 多空持仓总市值，未使用杠杆时为现金余额
+func (b *PaperBroker) buyingPower(quotes map[string]decimal.Decimal) decimal.Decimal {
+	if !b.margin.Enabled() {
+		return b.balance
+	}
+	net, gross := b.positionValue(quotes)
+	power := b.balance.Add(net).Mul(money.FromFloat(b.margin.Leverage)).Sub(gross)
+	return decimal.Max(power, decimal.Zero)
+}
+
+// accrueInterest 对借入资金按上次计息以来的时间计息，利息从现金中扣除
+func (b *PaperBroker) accrueInterest() {
+	now := time.Now()
+	elapsed := now.Sub(b.interestAt)
+	b.interestAt = now
+	if !b.margin.Enabled() || b.margin.InterestRate <= 0 || !b.balance.IsNegative() || elapsed <= 0 {
+		return
+	}
+	// 查询间隔可能只有几秒，利息不按金额精度取整，避免被舍去
+	years := elapsed.Hours() / (24 * 365)
+	interest := b.balance.Neg().Mul(money.FromFloat(b.margin.InterestRate * years)).Round(8)
+	b.balance = b.balance.Sub(interest)
+	b.interest = b.interest.Add(interest)
+}
+
+// checkMarginCall 检查维持保证金（按多空持仓总市值计算），权益不足时按实时报价市价平掉全部持仓
+func (b *PaperBroker) checkMarginCall(quotes map[string]decimal.Decimal) {
+	if !b.margin.Enabled() || b.margin.MaintenanceMargin <= 0 || len(b.positions) == 0 {
+		return
+	}
+	net, gross := b.positionValue(quotes)
+	equity := b.balance.Add(net)
+	required := gross.Mul(money.FromFloat(b.margin.MaintenanceMargin))
+	if equity.GreaterThanOrEqual(required) {
+		return
+	}
+
+	b.marginCalls++
+	log.Printf("纸面交易经纪商 %s 追加保证金: 权益=%s, 维持保证金=%s，强制平仓全部持仓",
+		b.name, equity.StringFixed(2), required.StringFixed(2))
+	for symbol, position := range b.positions {
+		quote, quoted := quotes[symbol]
+		if !quoted {
+			log.Printf("强制平仓 %s 失败: 没有实时报价", symbol)
+			continue
+		}
+		// 空仓和卖出开仓的期权买入平仓
+		side, slip := SellSide, decimal.NewFromInt(1).Neg()
+		if position.Quantity.IsNegative() {
+			side, slip = BuySide, decimal.NewFromInt(1)
+		}
+		order := Order{
+			ID:         fmt.Sprintf("PAPER_LIQ_%d", time.Now().UnixNano()),
+			Symbol:     symbol,
+			Side:       side,
+			Type:       MarketOrder,
+			Quantity:   position.Quantity.Abs(),
+			Status:     Submitted,
+			CreateTime: time.Now(),
+			UpdateTime: time.Now(),
+		}
+		b.fill(&order, quote.Mul(decimal.NewFromInt(1).Add(slip.Mul(b.slippageRate(order, quote)))), false, quotes)
+		b.orders[order.ID] = order
+	}
+}
+
+// MarginStatus 获取保证金账户状态
+func (b *PaperBroker) MarginStatus() (MarginStatus, error) {
+	quotes := b.marketQuotes()
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if !b.isConnected {
+		return MarginStatus{}, fmt.Errorf("经纪商未连接: %w", ErrBrokerUnavailable)
+	}
+
+	b.matchOrders(quotes)
+	net, gross := b.positionValue(quotes)
+	equity := b.balance.Add(net)
+	status := MarginStatus{
+		Leverage:          b.margin.EffectiveLeverage(),
+		Equity:            equity,
+		PositionValue:     gross,
+		BuyingPower:       b.buyingPower(quotes),
+		MaintenanceMargin: gross.Mul(money.FromFloat(b.margin.MaintenanceMargin)),
+		Interest:          b.interest,
+		MarginCalls:       b.marginCalls,
+	}
+	if b.balance.IsNegative() {
+		status.Borrowed = b.balance.Neg()
+	}
+	status.Utilization = marginUtilization(gross, equity, status.Leverage)
+	return status, nil
+}
+
+// marginUtilization 保证金使用率：持仓总市值 / (权益 * 杠杆)，有持仓而权益不为正时为1
+func marginUtilization(positionValue, equity decimal.Decimal, leverage float64) float64 {
+	if !positionValue.IsPositive() {
+		return 0
+	}
+	if !equity.IsPositive() {
+		return 1
+	}
+	return money.Float(positionValue.Div(equity.Mul(money.FromFloat(leverage))))
+}
+
+// syncMargin 用经纪商报告的保证金状态更新账户管理器，不支持保证金或未使用杠杆的账户跳过
+func (te *TradingEngine) syncMargin(accountName string, broker BrokerAPI) {
+	reporter, ok := baseBroker(broker).(MarginReporter)
+	if !ok {
+		return
+	}
+	status, err := reporter.MarginStatus()
+	if err != nil {
+		log.Printf("获取保证金状态失败: %v", err)
+		return
+	}
+	if status.Leverage <= 1 {
+		return
+	}
+	if err := te.accountManager.UpdateAccountMargin(accountName, account.MarginInfo{
+		Leverage:    status.Leverage,
+		Borrowed:    status.Borrowed,
+		BuyingPower: status.BuyingPower,
+		Utilization: status.Utilization,
+		Interest:    status.Interest,
+		MarginCalls: status.MarginCalls,
+	}); err != nil {
+		log.Printf("更新账户保证金状态失败: %v", err)
+	}
+}
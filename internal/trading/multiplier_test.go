@@ -0,0 +1,98 @@
+package trading
+
+import (
+	"testing"
+	"time"
+
+	"agent-quant-system/internal/config"
+	"agent-quant-system/internal/instrument"
+
+	"github.com/shopspring/decimal"
+)
+
+// newTestMultipliers 创建 ES 期货（合约乘数50）的合约乘数查询
+func newTestMultipliers(t *testing.T) *contractMultipliers {
+	t.Helper()
+
+	registry, err := instrument.NewRegistry(config.InstrumentsConfig{
+		Futures: map[string]config.FuturesSpecConfig{"ES": {Multiplier: 50, TickSize: 0.25, Months: "HMUZ"}},
+	})
+	if err != nil {
+		t.Fatalf("创建合约规格表失败: %v", err)
+	}
+	multipliers := &contractMultipliers{}
+	multipliers.registry.Store(registry)
+	return multipliers
+}
+
+func TestFuturesRoundTripUsesMultiplier(t *testing.T) {
+	multipliers := newTestMultipliers(t)
+	opened := time.Date(2026, 3, 2, 15, 0, 0, 0, time.UTC)
+	fills := []JournalEntry{
+		{Time: opened, Kind: JournalTrade, Account: "futures", Strategy: "trend", Symbol: "ESZ26",
+			Side: BuySide, Quantity: decimal.NewFromInt(2), Price: decimal.NewFromInt(5000)},
+		{Time: opened.Add(time.Hour), Kind: JournalTrade, Account: "futures", Strategy: "trend", Symbol: "ESZ26",
+			Side: SellSide, Quantity: decimal.NewFromInt(2), Price: decimal.NewFromInt(5010)},
+		{Time: opened.Add(2 * time.Hour), Kind: JournalTrade, Account: "futures", Strategy: "trend", Symbol: "ESZ26",
+			Side: BuySide, Quantity: decimal.NewFromInt(1), Price: decimal.NewFromInt(5000)},
+	}
+
+	ledger := NewPnLLedger()
+	ledger.multipliers = multipliers
+	lots := NewTaxLotBook(config.TaxLotConfig{})
+	lots.multipliers = multipliers
+	for _, fill := range fills {
+		ledger.Apply(fill.Account, fill.Strategy, fill.Symbol, fill.Side, fill.Quantity, fill.Price, fill.Commission)
+		lots.Apply(fill)
+	}
+
+	// 已实现: (5010 - 5000) * 2 * 50；未实现: (5020 - 5000) * 1 * 50
+	report := ledger.Report(PriceSourceFunc(func(string) (float64, error) { return 5020, nil }))
+	if len(report.Positions) != 1 {
+		t.Fatalf("持仓数 = %d, 期望 1", len(report.Positions))
+	}
+	position := report.Positions[0]
+	if !position.RealizedPnL.Equal(decimal.NewFromInt(1000)) {
+		t.Fatalf("已实现盈亏 = %s, 期望 1000", position.RealizedPnL)
+	}
+	if !position.UnrealizedPnL.Equal(decimal.NewFromInt(1000)) {
+		t.Fatalf("未实现盈亏 = %s, 期望 1000", position.UnrealizedPnL)
+	}
+	if stats := ledger.TradeStats("trend"); stats.Trades != 1 || stats.GrossProfit != 1000 {
+		t.Fatalf("平仓统计 = %+v, 期望 1 笔盈利 1000", stats)
+	}
+
+	gains := lots.Gains(time.Time{}, time.Time{}, "")
+	if len(gains) != 1 {
+		t.Fatalf("已实现损益记录数 = %d, 期望 1", len(gains))
+	}
+	if !gains[0].Proceeds.Equal(decimal.NewFromInt(501000)) || !gains[0].CostBasis.Equal(decimal.NewFromInt(500000)) ||
+		!gains[0].Gain.Equal(decimal.NewFromInt(1000)) {
+		t.Fatalf("税务损益 = 所得 %s, 成本 %s, 收益 %s, 期望 501000 / 500000 / 1000",
+			gains[0].Proceeds, gains[0].CostBasis, gains[0].Gain)
+	}
+	open := lots.OpenLots("futures")
+	if len(open) != 1 || !open[0].CostBasis.Equal(decimal.NewFromInt(250000)) {
+		t.Fatalf("未平仓批次 = %+v, 期望 1 手每手成本 250000", open)
+	}
+}
+
+func TestRiskValueCapUsesMultiplier(t *testing.T) {
+	rm := NewRiskManagerFromConfig(config.RiskConfig{MaxPositionSize: 0.6, MaxTotalExposure: 1, ResizeOrders: true})
+	rm.multipliers = newTestMultipliers(t)
+
+	// 每手名义金额 5000 * 50 = 250000，单笔上限 1000000 * 0.6 = 600000，3 手缩减为 2 手
+	order := Order{Symbol: "ESZ26", Side: BuySide, Type: LimitOrder, Quantity: decimal.NewFromInt(3), Price: decimal.NewFromInt(5000)}
+	checked, err := rm.CheckOrder(order, "futures", decimal.NewFromInt(1000000), nil)
+	if err != nil {
+		t.Fatalf("风险检查失败: %v", err)
+	}
+	if !checked.Quantity.Equal(decimal.NewFromInt(2)) {
+		t.Fatalf("缩减后数量 = %s, 期望 2", checked.Quantity)
+	}
+
+	rm.resizeOrders = false
+	if _, err := rm.CheckOrder(order, "futures", decimal.NewFromInt(1000000), nil); err == nil {
+		t.Fatalf("名义金额 750000 超过单笔上限 600000，期望被拒绝")
+	}
+}
@@ -0,0 +1,75 @@
+package trading
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"agent-quant-system/internal/instrument"
+
+	"github.com/shopspring/decimal"
+)
+
+// SetInstruments 设置合约规格：期货、期权的成交金额、持仓市值和盈亏按合约乘数计算，期权允许卖出开仓
+func (b *PaperBroker) SetInstruments(registry *instrument.Registry) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.instruments = registry
+}
+
+// multiplier 标的的合约乘数，现货为1
+func (b *PaperBroker) multiplier(symbol string) decimal.Decimal {
+	if b.instruments == nil {
+		return decimal.NewFromInt(1)
+	}
+	return b.instruments.Lookup(symbol).Multiplier
+}
+
+// writable 是否允许卖出超过持仓的数量（卖出开仓），只有期权可以
+func (b *PaperBroker) writable(symbol string) bool {
+	return b.instruments != nil && b.instruments.Lookup(symbol).Type == instrument.Option
+}
+
+// settleExpiredOptions 到期的期权按标的实时报价的内在价值现金结算：多头收取、空头支付内在价值，虚值期权作废
+func (b *PaperBroker) settleExpiredOptions(quotes map[string]decimal.Decimal) {
+	if b.instruments == nil {
+		return
+	}
+	now := time.Now()
+	for symbol, position := range b.positions {
+		inst := b.instruments.Lookup(symbol)
+		option, ok := inst.Option()
+		if !ok || !inst.Expired(now) {
+			continue
+		}
+		underlying, quoted := quotes[option.Underlying]
+		if !quoted {
+			log.Printf("期权 %s 到期结算失败: 没有标的 %s 的实时报价", symbol, option.Underlying)
+			continue
+		}
+
+		precision := b.precision.For(symbol)
+		intrinsic := option.Intrinsic(underlying)
+		amount := precision.RoundAmount(position.Quantity.Mul(intrinsic).Mul(inst.Multiplier))
+		realized := precision.RoundAmount(position.Quantity.Mul(intrinsic.Sub(position.AvgPrice)).Mul(inst.Multiplier))
+		b.balance = b.balance.Add(amount)
+		delete(b.positions, symbol)
+
+		side := SellSide
+		if position.Quantity.IsNegative() {
+			side = BuySide
+		}
+		b.trades = append(b.trades, Trade{
+			ID:        fmt.Sprintf("TRADE_%d", time.Now().UnixNano()),
+			OrderID:   fmt.Sprintf("PAPER_EXPIRY_%d", time.Now().UnixNano()),
+			Symbol:    symbol,
+			Side:      side,
+			Quantity:  position.Quantity.Abs(),
+			Price:     intrinsic,
+			Timestamp: now,
+		})
+		log.Printf("纸面交易经纪商 %s 期权到期结算: %s, 数量=%s, 标的价格=%s, 内在价值=%s, 结算金额=%s, 实现盈亏=%s",
+			b.name, symbol, position.Quantity, underlying, intrinsic, amount, realized)
+	}
+}
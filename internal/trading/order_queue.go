@@ -0,0 +1,139 @@
+package trading
+
+import (
+	"fmt"
+	"log"
+	"sync"
+)
+
+// OrderResult 异步下单结果
+type OrderResult struct {
+	Order *Order
+	Err   error
+}
+
+// orderRequest 队列中的下单请求
+type orderRequest struct {
+	order  Order
+	result chan OrderResult
+}
+
+// submitFunc 实际执行下单的函数
+type submitFunc func(order Order, accountName string) (*Order, error)
+
+// OrderQueue 经纪商异步下单队列
+// 同一标的的订单按提交顺序串行执行，不同标的之间并发执行，
+// 总并发数受 concurrency 限制。标的的队列在订单执行完后移除，下次提交时重新创建
+type OrderQueue struct {
+	accountName string
+	submit      submitFunc
+	semaphore   chan struct{}
+	queueSize   int
+	lanes       map[string]chan *orderRequest
+	mutex       sync.Mutex
+	wg          sync.WaitGroup
+	closed      bool
+}
+
+// NewOrderQueue 创建下单队列
+func NewOrderQueue(accountName string, concurrency, queueSize int, submit submitFunc) *OrderQueue {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	if queueSize <= 0 {
+		queueSize = 1
+	}
+
+	return &OrderQueue{
+		accountName: accountName,
+		submit:      submit,
+		semaphore:   make(chan struct{}, concurrency),
+		queueSize:   queueSize,
+		lanes:       make(map[string]chan *orderRequest),
+	}
+}
+
+// Submit 提交订单，返回接收结果的通道；order.Symbol 需已是规范写法，否则同一标的的订单会进入不同队列
+func (q *OrderQueue) Submit(order Order) (<-chan OrderResult, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if q.closed {
+		return nil, fmt.Errorf("账户 '%s' 的下单队列已关闭", q.accountName)
+	}
+
+	lane, exists := q.lanes[order.Symbol]
+	if !exists {
+		lane = make(chan *orderRequest, q.queueSize)
+		q.lanes[order.Symbol] = lane
+		q.wg.Add(1)
+		go q.runLane(order.Symbol, lane)
+	}
+
+	request := &orderRequest{
+		order:  order,
+		result: make(chan OrderResult, 1),
+	}
+
+	select {
+	case lane <- request:
+	default:
+		return nil, fmt.Errorf("标的 %s 的下单队列已满", order.Symbol)
+	}
+
+	return request.result, nil
+}
+
+// runLane 按顺序处理单个标的的订单，队列中没有待执行的订单时移除该标的的队列并退出
+func (q *OrderQueue) runLane(symbol string, lane chan *orderRequest) {
+	defer q.wg.Done()
+
+	for request := range lane {
+		q.semaphore <- struct{}{}
+		order, err := q.submit(request.order, q.accountName)
+		<-q.semaphore
+
+		// 在返回结果前移除，调用方收到结果后再次提交时总会进入新的队列
+		q.mutex.Lock()
+		drained := !q.closed && len(lane) == 0
+		if drained {
+			delete(q.lanes, symbol)
+		}
+		q.mutex.Unlock()
+
+		request.result <- OrderResult{Order: order, Err: err}
+		if drained {
+			return
+		}
+	}
+
+	log.Printf("下单队列已退出: 账户=%s, 标的=%s", q.accountName, symbol)
+}
+
+// Pending 获取队列中等待执行的订单数量
+func (q *OrderQueue) Pending() int {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	pending := 0
+	for _, lane := range q.lanes {
+		pending += len(lane)
+	}
+	return pending
+}
+
+// Close 关闭队列并等待已提交的订单执行完毕
+func (q *OrderQueue) Close() {
+	q.mutex.Lock()
+	if q.closed {
+		q.mutex.Unlock()
+		return
+	}
+	q.closed = true
+	for _, lane := range q.lanes {
+		close(lane)
+	}
+	q.mutex.Unlock()
+
+	q.wg.Wait()
+}
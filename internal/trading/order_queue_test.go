@@ -0,0 +1,73 @@
+package trading
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestSubmitOrderSerializesSymbolSpellings(t *testing.T) {
+	engine := newTestEngine(t, "stock")
+
+	var mutex sync.Mutex
+	active, maxActive := 0, 0
+	var executed []string
+	queue := NewOrderQueue("test", 4, 10, func(order Order, accountName string) (*Order, error) {
+		mutex.Lock()
+		active++
+		if active > maxActive {
+			maxActive = active
+		}
+		executed = append(executed, order.ClientOrderID)
+		mutex.Unlock()
+
+		time.Sleep(20 * time.Millisecond)
+
+		mutex.Lock()
+		active--
+		mutex.Unlock()
+		return &order, nil
+	})
+	t.Cleanup(queue.Close)
+	engine.mutex.Lock()
+	engine.orderQueues["test"] = queue
+	engine.mutex.Unlock()
+
+	var results []<-chan OrderResult
+	for _, spelling := range []string{"AAPL.US", "aapl", "AAPL"} {
+		result, err := engine.SubmitOrder(Order{
+			Symbol:        spelling,
+			Side:          BuySide,
+			Type:          MarketOrder,
+			Quantity:      decimal.NewFromInt(1),
+			ClientOrderID: spelling,
+		}, "test")
+		if err != nil {
+			t.Fatalf("提交订单 %s 失败: %v", spelling, err)
+		}
+		results = append(results, result)
+	}
+	for _, result := range results {
+		if outcome := <-result; outcome.Err != nil || outcome.Order.Symbol != "AAPL" {
+			t.Fatalf("订单结果 = %+v, 期望标的统一为 AAPL", outcome)
+		}
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	if maxActive != 1 {
+		t.Fatalf("同一标的的订单最多同时执行 %d 笔, 期望串行执行", maxActive)
+	}
+	if len(executed) != 3 || executed[0] != "AAPL.US" || executed[1] != "aapl" || executed[2] != "AAPL" {
+		t.Fatalf("执行顺序 = %v, 期望按提交顺序", executed)
+	}
+
+	queue.mutex.Lock()
+	lanes := len(queue.lanes)
+	queue.mutex.Unlock()
+	if lanes != 0 {
+		t.Fatalf("订单执行完后仍有 %d 个标的队列, 期望移除", lanes)
+	}
+}
@@ -0,0 +1,205 @@
+package trading
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"agent-quant-system/internal/money"
+
+	"github.com/shopspring/decimal"
+)
+
+// 订单生命周期：Pending（未提交）→ Submitted（经纪商已受理）→ PartiallyFilled →
+// Filled / Cancelled / Rejected / Expired，后四个为终止状态
+
+// orderTransitions 各状态允许转换到的状态；经纪商可能在受理时直接成交或拒单，因此 Pending 可以转换到任意状态
+var orderTransitions = map[OrderStatus][]OrderStatus{
+	Pending:         {Submitted, PartiallyFilled, Filled, Cancelled, Rejected, Expired},
+	Submitted:       {PartiallyFilled, Filled, Cancelled, Rejected, Expired},
+	PartiallyFilled: {PartiallyFilled, Filled, Cancelled, Expired},
+}
+
+// OpenOrderStatuses 仍可能成交的订单状态
+var OpenOrderStatuses = []OrderStatus{Submitted, PartiallyFilled}
+
+// IsTerminal 是否为终止状态
+func (s OrderStatus) IsTerminal() bool {
+	switch s {
+	case Filled, Cancelled, Rejected, Expired:
+		return true
+	}
+	return false
+}
+
+// IsOpen 经纪商已受理且仍可能成交
+func (s OrderStatus) IsOpen() bool {
+	return s == Submitted || s == PartiallyFilled
+}
+
+// CanTransition 是否允许从当前状态转换到 to
+func (s OrderStatus) CanTransition(to OrderStatus) bool {
+	for _, next := range orderTransitions[s] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Transition 转换订单状态，不允许的转换返回错误且不修改订单
+func (o *Order) Transition(to OrderStatus) error {
+	if !o.Status.CanTransition(to) {
+		return fmt.Errorf("订单 %s 不能从 %s 转换为 %s", o.ID, o.Status, to)
+	}
+	o.Status = to
+	o.UpdateTime = time.Now()
+	return nil
+}
+
+// Remaining 未成交数量
+func (o *Order) Remaining() decimal.Decimal {
+	return decimal.Max(o.Quantity.Sub(o.FilledQty), decimal.Zero)
+}
+
+// OrderFill 一次成交事件
+type OrderFill struct {
+	OrderID    string          `json:"order_id"`
+	Quantity   decimal.Decimal `json:"quantity"`
+	Price      decimal.Decimal `json:"price"`
+	Commission decimal.Decimal `json:"commission"`
+	Time       time.Time       `json:"time"`
+}
+
+// ApplyFill 记入一次成交：累计成交数量和手续费，按成交量加权更新均价，全部成交时转为 Filled，否则为 PartiallyFilled
+func (o *Order) ApplyFill(fill OrderFill, precision money.Precision) error {
+	if !fill.Quantity.IsPositive() {
+		return fmt.Errorf("成交数量必须大于0")
+	}
+	if fill.Quantity.GreaterThan(o.Remaining()) {
+		return fmt.Errorf("成交数量 %s 超过订单 %s 的未成交数量 %s", fill.Quantity, o.ID, o.Remaining())
+	}
+
+	filled := o.FilledQty.Add(fill.Quantity)
+	status := PartiallyFilled
+	if filled.Equal(o.Quantity) {
+		status = Filled
+	}
+	if err := o.Transition(status); err != nil {
+		return err
+	}
+
+	notional := o.AvgPrice.Mul(o.FilledQty).Add(fill.Price.Mul(fill.Quantity))
+	o.FilledQty = filled
+	o.AvgPrice = precision.RoundPrice(notional.Div(filled))
+	o.Commission = o.Commission.Add(fill.Commission)
+	if !fill.Time.IsZero() {
+		o.UpdateTime = fill.Time
+	}
+	return nil
+}
+
+// OrderExpirer 支持将订单标记为过期的经纪商（可选接口），DAY 订单收盘时优先使用，否则撤单
+type OrderExpirer interface {
+	ExpireOrder(orderID string) error
+}
+
+// appliedFill 已记入账本的累计成交
+type appliedFill struct {
+	quantity   decimal.Decimal
+	notional   decimal.Decimal
+	commission decimal.Decimal
+	terminated time.Time // 首次收到终止状态的时间，未终止时为零值
+}
+
+// FillTracker 记录每个订单已记入成交流水、资金分配和盈亏账本的累计成交，
+// 同一订单多次查询到的状态只按新增部分记账。终止的订单保留 clientOrderRetention，
+// 期间重复收到的终止状态（如 ExecuteTrade 记账后经纪商再推送 Filled）不会重复记账
+type FillTracker struct {
+	applied map[string]*appliedFill
+	mutex   sync.Mutex
+}
+
+// NewFillTracker 创建成交跟踪
+func NewFillTracker() *FillTracker {
+	return &FillTracker{applied: make(map[string]*appliedFill)}
+}
+
+// Delta 计算订单相对上次记账新增的成交，返回以新增部分表示的订单（成交数量、均价和手续费为增量）；
+// 没有新增成交时返回 nil
+func (ft *FillTracker) Delta(order *Order) *Order {
+	ft.mutex.Lock()
+	defer ft.mutex.Unlock()
+
+	previous, exists := ft.applied[order.ID]
+	if !exists {
+		previous = &appliedFill{}
+		ft.applied[order.ID] = previous
+	}
+	if order.Status.IsTerminal() && previous.terminated.IsZero() {
+		previous.terminated = time.Now()
+		ft.prune(previous.terminated)
+	}
+
+	quantity := order.FilledQty.Sub(previous.quantity)
+	if !quantity.IsPositive() {
+		return nil
+	}
+	notional := order.FilledQty.Mul(order.AvgPrice)
+	delta := *order
+	delta.FilledQty = quantity
+	delta.AvgPrice = notional.Sub(previous.notional).Div(quantity).Round(8)
+	delta.Commission = order.Commission.Sub(previous.commission)
+
+	previous.quantity = order.FilledQty
+	previous.notional = notional
+	previous.commission = order.Commission
+	return &delta
+}
+
+// prune 清理终止超过保留时间的订单，调用方需持有锁
+func (ft *FillTracker) prune(now time.Time) {
+	for id, applied := range ft.applied {
+		if !applied.terminated.IsZero() && now.Sub(applied.terminated) > clientOrderRetention {
+			delete(ft.applied, id)
+		}
+	}
+}
+
+// releaseReservations 订单撤销或终止后归还其预占的策略资金和组合敞口
+func (te *TradingEngine) releaseReservations(orderID string) {
+	te.allocator.ReleaseOrder(orderID)
+	te.exposureBook.ReleaseOrder(orderID)
+}
+
+// applyOrderUpdate 按订单的最新状态记账：新增成交记入成交流水、策略资金分配和盈亏账本并同步账户，
+// 全部成交时发送通知。ExecuteTrade、挂单监控和经纪商推送都通过这里处理成交，重复的状态不会重复记账
+func (te *TradingEngine) applyOrderUpdate(current *Order, requested Order, accountName string) {
+	strategyName := requested.Strategy
+	if strategyName == "" {
+		strategyName = current.Strategy
+	}
+	if current.Status.IsTerminal() {
+		te.releaseReservations(current.ID)
+	}
+
+	delta := te.fills.Delta(current)
+	if delta == nil {
+		return
+	}
+	if current.Status == PartiallyFilled {
+		log.Printf("订单部分成交: 订单ID=%s, 本次 %s @ %s, 累计 %s/%s",
+			current.ID, delta.FilledQty, delta.AvgPrice, current.FilledQty, current.Quantity)
+	}
+	te.estimateCommission(delta, accountName)
+	te.auditFill(delta, requested, accountName)
+	te.recordFill(delta, requested, accountName)
+	te.allocator.RecordFill(delta, strategyName, accountName)
+	te.exposureBook.RecordFill(delta)
+	te.recordPnL(delta, strategyName, accountName)
+	if err := te.updateAccountAfterTrade(current, accountName); err != nil {
+		log.Printf("更新账户信息失败: %v", err)
+	}
+	te.notifyFill(current, strategyName, accountName)
+}
@@ -0,0 +1,165 @@
+package trading
+
+import (
+	"testing"
+
+	"agent-quant-system/internal/money"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestOrderApplyFill(t *testing.T) {
+	tests := []struct {
+		name       string
+		fills      []OrderFill
+		wantErr    bool // 最后一次成交是否返回错误
+		wantStatus OrderStatus
+		wantFilled string
+		wantAvg    string
+		wantFee    string
+	}{
+		{
+			name:       "一次全部成交",
+			fills:      []OrderFill{{Quantity: decimal.NewFromInt(10), Price: decimal.NewFromInt(100), Commission: decimal.NewFromInt(1)}},
+			wantStatus: Filled, wantFilled: "10", wantAvg: "100", wantFee: "1",
+		},
+		{
+			name: "部分成交按成交量加权均价",
+			fills: []OrderFill{
+				{Quantity: decimal.NewFromInt(4), Price: decimal.NewFromInt(100), Commission: decimal.NewFromInt(1)},
+				{Quantity: decimal.NewFromInt(2), Price: decimal.NewFromInt(103), Commission: decimal.NewFromInt(1)},
+			},
+			wantStatus: PartiallyFilled, wantFilled: "6", wantAvg: "101", wantFee: "2",
+		},
+		{
+			name: "多次成交后全部成交",
+			fills: []OrderFill{
+				{Quantity: decimal.NewFromInt(3), Price: decimal.NewFromInt(100)},
+				{Quantity: decimal.NewFromInt(7), Price: decimal.NewFromInt(110)},
+			},
+			wantStatus: Filled, wantFilled: "10", wantAvg: "107", wantFee: "0",
+		},
+		{
+			name: "超过未成交数量被拒绝且不修改订单",
+			fills: []OrderFill{
+				{Quantity: decimal.NewFromInt(8), Price: decimal.NewFromInt(100)},
+				{Quantity: decimal.NewFromInt(3), Price: decimal.NewFromInt(100)},
+			},
+			wantErr: true, wantStatus: PartiallyFilled, wantFilled: "8", wantAvg: "100", wantFee: "0",
+		},
+		{
+			name: "重复的全部成交被拒绝",
+			fills: []OrderFill{
+				{Quantity: decimal.NewFromInt(10), Price: decimal.NewFromInt(100)},
+				{Quantity: decimal.NewFromInt(10), Price: decimal.NewFromInt(100)},
+			},
+			wantErr: true, wantStatus: Filled, wantFilled: "10", wantAvg: "100", wantFee: "0",
+		},
+		{
+			name:       "成交数量为0被拒绝",
+			fills:      []OrderFill{{Quantity: decimal.Zero, Price: decimal.NewFromInt(100)}},
+			wantErr:    true,
+			wantStatus: Submitted, wantFilled: "0", wantAvg: "0", wantFee: "0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := &Order{ID: "order-1", Quantity: decimal.NewFromInt(10), Status: Submitted}
+			var err error
+			for i, fill := range tt.fills {
+				err = order.ApplyFill(fill, money.StockPrecision)
+				if i < len(tt.fills)-1 && err != nil {
+					t.Fatalf("第 %d 次成交失败: %v", i+1, err)
+				}
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("最后一次成交 err = %v, 期望返回错误 = %v", err, tt.wantErr)
+			}
+			if order.Status != tt.wantStatus || order.FilledQty.String() != tt.wantFilled ||
+				order.AvgPrice.String() != tt.wantAvg || order.Commission.String() != tt.wantFee {
+				t.Fatalf("订单 = 状态 %s, 成交 %s @ %s, 手续费 %s; 期望 %s, %s @ %s, %s",
+					order.Status, order.FilledQty, order.AvgPrice, order.Commission,
+					tt.wantStatus, tt.wantFilled, tt.wantAvg, tt.wantFee)
+			}
+		})
+	}
+}
+
+func TestFillTrackerDelta(t *testing.T) {
+	// update 经纪商推送的订单状态（累计成交），delta 为 nil 表示不应重复记账
+	type update struct {
+		status    OrderStatus
+		filled    int64
+		avgPrice  string
+		fee       string
+		wantDelta bool
+		wantQty   string
+		wantAvg   string
+		wantFee   string
+	}
+	tests := []struct {
+		name    string
+		updates []update
+	}{
+		{
+			name: "部分成交后全部成交只记新增部分",
+			updates: []update{
+				{status: PartiallyFilled, filled: 4, avgPrice: "100", fee: "0.4", wantDelta: true, wantQty: "4", wantAvg: "100", wantFee: "0.4"},
+				{status: Filled, filled: 10, avgPrice: "106", fee: "1", wantDelta: true, wantQty: "6", wantAvg: "110", wantFee: "0.6"},
+			},
+		},
+		{
+			name: "重复的终止状态不重复记账",
+			updates: []update{
+				{status: Filled, filled: 10, avgPrice: "100", fee: "1", wantDelta: true, wantQty: "10", wantAvg: "100", wantFee: "1"},
+				{status: Filled, filled: 10, avgPrice: "100", fee: "1"},
+			},
+		},
+		{
+			name: "部分成交后撤单不再记账",
+			updates: []update{
+				{status: PartiallyFilled, filled: 3, avgPrice: "100", fee: "0", wantDelta: true, wantQty: "3", wantAvg: "100", wantFee: "0"},
+				{status: PartiallyFilled, filled: 3, avgPrice: "100", fee: "0"},
+				{status: Cancelled, filled: 3, avgPrice: "100", fee: "0"},
+			},
+		},
+		{
+			name: "未成交的状态不记账",
+			updates: []update{
+				{status: Submitted, filled: 0, avgPrice: "0", fee: "0"},
+				{status: Rejected, filled: 0, avgPrice: "0", fee: "0"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker := NewFillTracker()
+			for i, u := range tt.updates {
+				order := &Order{
+					ID:         "order-1",
+					Quantity:   decimal.NewFromInt(10),
+					Status:     u.status,
+					FilledQty:  decimal.NewFromInt(u.filled),
+					AvgPrice:   decimal.RequireFromString(u.avgPrice),
+					Commission: decimal.RequireFromString(u.fee),
+				}
+				delta := tracker.Delta(order)
+				if !u.wantDelta {
+					if delta != nil {
+						t.Fatalf("第 %d 次推送 delta = %s @ %s, 期望不记账", i+1, delta.FilledQty, delta.AvgPrice)
+					}
+					continue
+				}
+				if delta == nil {
+					t.Fatalf("第 %d 次推送没有新增成交, 期望 %s @ %s", i+1, u.wantQty, u.wantAvg)
+				}
+				if delta.FilledQty.String() != u.wantQty || delta.AvgPrice.String() != u.wantAvg || delta.Commission.String() != u.wantFee {
+					t.Fatalf("第 %d 次推送 delta = %s @ %s, 手续费 %s; 期望 %s @ %s, %s",
+						i+1, delta.FilledQty, delta.AvgPrice, delta.Commission, u.wantQty, u.wantAvg, u.wantFee)
+				}
+			}
+		})
+	}
+}
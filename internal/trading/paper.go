@@ -0,0 +1,658 @@
+package trading
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"agent-quant-system/internal/commission"
+	"agent-quant-system/internal/config"
+	"agent-quant-system/internal/instrument"
+	"agent-quant-system/internal/money"
+	"agent-quant-system/internal/slippage"
+
+	"github.com/shopspring/decimal"
+)
+
+// paperSlippage 纸面交易市价单相对实时报价的滑点比例
+var paperSlippage = decimal.RequireFromString("0.0005")
+
+// PriceSourceFunc 以函数实现的价格来源
+type PriceSourceFunc func(symbol string) (float64, error)
+
+// GetLatestPrice 获取最新价格
+func (f PriceSourceFunc) GetLatestPrice(symbol string) (float64, error) {
+	return f(symbol)
+}
+
+// PaperBroker 纸面交易经纪商：订单按实时报价撮合，余额和持仓只在内部模拟
+type PaperBroker struct {
+	name           string
+	balance        decimal.Decimal
+	initialBalance decimal.Decimal
+	precision      *money.PrecisionTable
+	prices         PriceSource
+	positions      map[string]Position
+	orders         map[string]Order
+	trades         []Trade
+	isConnected    bool
+	mutex          sync.Mutex
+
+	// 配置的滑点模型，为空时市价单使用固定滑点；volumes 提供估算市场冲击的近期日均成交量
+	slippage slippage.Model
+	volumes  func(symbol string) float64
+
+	commission commission.Model
+
+	// 保证金交易，未设置杠杆时买入不能超过现金余额
+	margin      config.MarginConfig
+	interestAt  time.Time       // 上次计息时间
+	interest    decimal.Decimal // 累计融资利息
+	marginCalls int
+
+	// 合约规格：期货、期权的金额按合约乘数计算，期权可卖出开仓，到期按内在价值结算
+	instruments *instrument.Registry
+}
+
+// NewPaperBroker 创建纸面交易经纪商，prices 提供撮合使用的实时报价
+func NewPaperBroker(name string, initialBalance decimal.Decimal, precision *money.PrecisionTable, prices PriceSource) *PaperBroker {
+	return &PaperBroker{
+		name:           name,
+		balance:        initialBalance,
+		initialBalance: initialBalance,
+		precision:      precision,
+		prices:         prices,
+		positions:      make(map[string]Position),
+		orders:         make(map[string]Order),
+		trades:         make([]Trade, 0),
+		commission:     defaultCommission,
+	}
+}
+
+// SetCommissionModel 设置成交佣金模型
+func (b *PaperBroker) SetCommissionModel(model commission.Model) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.commission = model
+}
+
+// SetSlippageModel 设置市价单的滑点模型，volumes 为空时按成交量未知估算
+func (b *PaperBroker) SetSlippageModel(model slippage.Model, volumes func(symbol string) float64) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.slippage = model
+	b.volumes = volumes
+}
+
+// Connect 连接经纪商
+func (b *PaperBroker) Connect() error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	log.Printf("连接到纸面交易经纪商: %s, 初始资金=%s", b.name, b.balance)
+	b.isConnected = true
+	return nil
+}
+
+// Disconnect 断开连接
+func (b *PaperBroker) Disconnect() error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	log.Printf("断开纸面交易经纪商连接: %s", b.name)
+	b.isConnected = false
+	return nil
+}
+
+// quote 获取标的的实时报价，在锁外调用（价格来源创建后不再修改）
+func (b *PaperBroker) quote(symbol string) (decimal.Decimal, error) {
+	if b.prices == nil {
+		return decimal.Zero, fmt.Errorf("纸面交易未设置实时价格来源")
+	}
+	price, err := b.prices.GetLatestPrice(symbol)
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("获取 %s 实时报价失败: %w", symbol, err)
+	}
+	if price <= 0 {
+		return decimal.Zero, fmt.Errorf("%s 实时报价无效: %v", symbol, price)
+	}
+	return money.FromFloat(price), nil
+}
+
+// marketQuotes 在锁外获取持仓、挂单和持有期权标的的实时报价，撮合、保证金检查和到期结算使用同一份报价；
+// 获取失败的标的不在结果中，相应的撮合和结算留待下次查询
+func (b *PaperBroker) marketQuotes() map[string]decimal.Decimal {
+	b.mutex.Lock()
+	symbols := pendingSymbols(b.orders)
+	for symbol := range b.positions {
+		symbols[symbol] = true
+		if b.instruments != nil {
+			if option, ok := b.instruments.Lookup(symbol).Option(); ok {
+				symbols[option.Underlying] = true
+			}
+		}
+	}
+	b.mutex.Unlock()
+
+	return fetchQuotes(b.prices, symbols)
+}
+
+// PlaceOrder 下单：市价单按实时报价加滑点立即成交，限价单和止损单在报价触及后成交
+func (b *PaperBroker) PlaceOrder(order Order) (*Order, error) {
+	quote, quoteErr := b.quote(order.Symbol)
+	quotes := b.marketQuotes()
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if !b.isConnected {
+		return nil, fmt.Errorf("经纪商未连接: %w", ErrBrokerUnavailable)
+	}
+	if quoteErr != nil {
+		return nil, quoteErr
+	}
+	quotes[order.Symbol] = quote
+
+	log.Printf("纸面交易经纪商 %s 收到订单: %s %s %s @ %s, 实时报价=%s",
+		b.name, order.Side, order.Symbol, order.Quantity, order.Price, quote)
+
+	// 同一客户端订单号只受理一次
+	if existing, exists := findClientOrder(b.orders, order.ClientOrderID); exists {
+		log.Printf("客户端订单号 %s 已存在，返回已有订单: ID=%s", order.ClientOrderID, existing.ID)
+		return existing, nil
+	}
+
+	order.ID = fmt.Sprintf("PAPER_%d", time.Now().UnixNano())
+	order.Status = Submitted
+	order.FilledQty = decimal.Zero
+	order.AvgPrice = decimal.Zero
+	order.Commission = decimal.Zero
+	order.CreateTime = time.Now()
+	order.UpdateTime = time.Now()
+	order.Quantity = b.precision.For(order.Symbol).RoundQuantity(order.Quantity)
+
+	if order.Type == MarketOrder {
+		rate := b.slippageRate(order, quote)
+		multiplier := decimal.NewFromInt(1).Add(rate)
+		if order.Side == SellSide {
+			multiplier = decimal.NewFromInt(1).Sub(rate)
+		}
+		b.fill(&order, quote.Mul(multiplier), false, quotes)
+		b.orders[order.ID] = order
+		return &order, nil
+	}
+
+	if price, ok := triggered(order, quote); ok {
+		b.fill(&order, price, false, quotes)
+		b.orders[order.ID] = order
+		return &order, nil
+	}
+	if order.TimeInForce.immediate() {
+		// 纸面交易按整单撮合，IOC 与 FOK 都在未触及价格时整单撤销
+		order.Status = Cancelled
+		b.orders[order.ID] = order
+		log.Printf("纸面交易 %s 订单未能立即成交，已撤销: ID=%s", strings.ToUpper(string(order.TimeInForce)), order.ID)
+		return &order, nil
+	}
+
+	b.orders[order.ID] = order
+	log.Printf("纸面交易挂单已提交: ID=%s, 类型=%s", order.ID, order.Type)
+	return &order, nil
+}
+
+// slippageRate 市价单相对报价的滑点率，未设置滑点模型时使用固定滑点
+func (b *PaperBroker) slippageRate(order Order, quote decimal.Decimal) decimal.Decimal {
+	if b.slippage == nil {
+		return paperSlippage
+	}
+	estimate := slippage.Order{
+		Symbol:   order.Symbol,
+		Buy:      order.Side == BuySide,
+		Quantity: money.Float(order.Quantity),
+		Price:    money.Float(quote),
+		Time:     time.Now(),
+	}
+	if b.volumes != nil {
+		estimate.Volume = b.volumes(order.Symbol)
+	}
+	return money.FromFloat(b.slippage.Rate(estimate))
+}
+
+// triggered 判断挂单在当前报价下是否成交，返回成交价：
+// 限价单以不差于限价的报价成交，止损单在报价突破止损价后按报价成交；纸面交易和模拟经纪商共用
+func triggered(order Order, quote decimal.Decimal) (decimal.Decimal, bool) {
+	switch order.Type {
+	case LimitOrder:
+		if order.Side == BuySide && quote.LessThanOrEqual(order.Price) {
+			return quote, true
+		}
+		if order.Side == SellSide && quote.GreaterThanOrEqual(order.Price) {
+			return quote, true
+		}
+	case StopOrder:
+		if order.Side == BuySide && quote.GreaterThanOrEqual(order.StopPrice) {
+			return quote, true
+		}
+		if order.Side == SellSide && quote.LessThanOrEqual(order.StopPrice) {
+			return quote, true
+		}
+	}
+	return decimal.Zero, false
+}
+
+// fill 按成交价整单撮合订单，资金或持仓不足时拒单；maker 表示挂单在之后的报价中成交，按挂单费率计佣。
+// 保证金账户可以卖出超过持仓的数量开空仓，开空部分与买入一样占用购买力
+func (b *PaperBroker) fill(order *Order, price decimal.Decimal, maker bool, quotes map[string]decimal.Decimal) {
+	precision := b.precision.For(order.Symbol)
+	avgPrice := precision.RoundPrice(price)
+	multiplier := b.multiplier(order.Symbol)
+	amount := precision.RoundAmount(order.Quantity.Mul(avgPrice).Mul(multiplier))
+	fee := precision.RoundAmount(b.commission.Commission(commission.Fill{
+		Symbol: order.Symbol, Buy: order.Side == BuySide, Quantity: order.Quantity, Price: avgPrice, Maker: maker,
+		Multiplier: multiplier,
+	}))
+
+	position := b.positions[order.Symbol]
+	if order.Side == BuySide {
+		if available := b.buyingPower(quotes); amount.Add(fee).GreaterThan(available) {
+			order.Transition(Rejected)
+			log.Printf("纸面交易拒单: ID=%s, 资金不足: 需要 %s, 可用 %s", order.ID, amount.Add(fee), available)
+			return
+		}
+	}
+	if order.Side == SellSide && order.Quantity.GreaterThan(position.Quantity) && !b.writable(order.Symbol) {
+		if !b.margin.Enabled() {
+			order.Transition(Rejected)
+			log.Printf("纸面交易拒单: ID=%s, 持仓不足: 卖出 %s, 持有 %s", order.ID, order.Quantity, position.Quantity)
+			return
+		}
+		opened := order.Quantity.Sub(decimal.Max(position.Quantity, decimal.Zero))
+		required := precision.RoundAmount(opened.Mul(avgPrice).Mul(multiplier)).Add(fee)
+		if available := b.buyingPower(quotes); required.GreaterThan(available) {
+			order.Transition(Rejected)
+			log.Printf("纸面交易拒单: ID=%s, 保证金不足: 开空 %s 需要 %s, 可用 %s", order.ID, opened, required, available)
+			return
+		}
+	}
+
+	trade, err := simulateFill(order, avgPrice, decimal.NewFromInt(1), precision, b.commission, maker, multiplier)
+	if err != nil {
+		log.Printf("纸面交易成交失败: ID=%s, 错误=%v", order.ID, err)
+		return
+	}
+
+	b.updatePosition(*order)
+	if order.Side == BuySide {
+		b.balance = b.balance.Sub(amount).Sub(trade.Commission)
+	} else {
+		b.balance = b.balance.Add(amount).Sub(trade.Commission)
+	}
+	b.trades = append(b.trades, trade)
+
+	log.Printf("纸面交易订单已成交: ID=%s, 成交价=%s, 余额=%s", order.ID, order.AvgPrice, b.balance)
+}
+
+// updatePosition 更新持仓，减仓时按持仓均价计算已实现盈亏；卖出开仓的期权和保证金空仓数量为负
+func (b *PaperBroker) updatePosition(order Order) {
+	precision := b.precision.For(order.Symbol)
+	multiplier := b.multiplier(order.Symbol)
+	position, exists := b.positions[order.Symbol]
+	if !exists {
+		position = Position{Symbol: order.Symbol}
+	}
+
+	change := order.Quantity
+	if order.Side == SellSide {
+		change = change.Neg()
+	}
+	if position.Quantity.IsZero() || position.Quantity.Sign() == change.Sign() {
+		// 开仓或加仓
+		held := position.Quantity.Abs()
+		totalCost := held.Mul(position.AvgPrice).Add(order.Quantity.Mul(order.AvgPrice))
+		position.Quantity = position.Quantity.Add(change)
+		position.AvgPrice = precision.RoundPrice(totalCost.Div(position.Quantity.Abs()))
+	} else {
+		closed := decimal.Min(order.Quantity, position.Quantity.Abs())
+		realized := order.AvgPrice.Sub(position.AvgPrice).Mul(closed).Mul(multiplier)
+		if position.Quantity.IsNegative() {
+			realized = realized.Neg()
+		}
+		position.RealizedPL = precision.RoundAmount(position.RealizedPL.Add(realized))
+		position.Quantity = position.Quantity.Add(change)
+		if position.Quantity.IsZero() {
+			delete(b.positions, order.Symbol)
+			return
+		}
+		if position.Quantity.Sign() == change.Sign() {
+			// 反向开仓的部分按成交价计成本
+			position.AvgPrice = order.AvgPrice
+		}
+	}
+
+	position.MarketValue = precision.RoundAmount(position.Quantity.Mul(order.AvgPrice).Mul(multiplier))
+	position.UnrealizedPL = precision.RoundAmount(position.MarketValue.Sub(position.Quantity.Mul(position.AvgPrice).Mul(multiplier)))
+	position.UpdateTime = time.Now()
+	b.positions[order.Symbol] = position
+}
+
+// matchOrders 按锁外获取的实时报价撮合挂单，并对借入资金计息、检查维持保证金，在查询订单前调用
+func (b *PaperBroker) matchOrders(quotes map[string]decimal.Decimal) {
+	defer b.checkMarginCall(quotes)
+	b.accrueInterest()
+	b.settleExpiredOptions(quotes)
+
+	for id, order := range b.orders {
+		if !order.Status.IsOpen() {
+			continue
+		}
+		quote, quoted := quotes[order.Symbol]
+		if !quoted {
+			continue
+		}
+		if price, ok := triggered(order, quote); ok {
+			b.fill(&order, price, order.Type == LimitOrder, quotes)
+			b.orders[id] = order
+		}
+	}
+}
+
+// CancelOrder 撤单
+func (b *PaperBroker) CancelOrder(orderID string) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if !b.isConnected {
+		return fmt.Errorf("经纪商未连接: %w", ErrBrokerUnavailable)
+	}
+
+	order, exists := b.orders[orderID]
+	if !exists {
+		return fmt.Errorf("订单不存在: %s", orderID)
+	}
+	if err := order.Transition(Cancelled); err != nil {
+		return err
+	}
+	b.orders[orderID] = order
+
+	log.Printf("纸面交易订单已取消: ID=%s", orderID)
+	return nil
+}
+
+// ReplaceOrder 修改未成交限价单的价格和数量，订单ID不变；新价格已被报价穿越时按报价立即成交
+func (b *PaperBroker) ReplaceOrder(orderID string, price, quantity decimal.Decimal) (*Order, error) {
+	quotes := b.marketQuotes()
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if !b.isConnected {
+		return nil, fmt.Errorf("经纪商未连接: %w", ErrBrokerUnavailable)
+	}
+
+	order, exists := b.orders[orderID]
+	if !exists {
+		return nil, fmt.Errorf("订单不存在: %s", orderID)
+	}
+	if order.Type != LimitOrder || !order.Status.IsOpen() || order.FilledQty.IsPositive() {
+		return nil, fmt.Errorf("只能修改未成交的限价单: ID=%s, 状态=%s", orderID, order.Status)
+	}
+	precision := b.precision.For(order.Symbol)
+	order.Price = precision.RoundPrice(price)
+	order.Quantity = precision.RoundQuantity(quantity)
+	if !order.Quantity.IsPositive() || !order.Price.IsPositive() {
+		return nil, fmt.Errorf("改单的价格和数量必须大于0")
+	}
+	order.UpdateTime = time.Now()
+
+	if quote, quoted := quotes[order.Symbol]; quoted {
+		if fillPrice, ok := triggered(order, quote); ok {
+			b.fill(&order, fillPrice, false, quotes)
+		}
+	}
+	b.orders[orderID] = order
+	log.Printf("纸面交易挂单已修改: ID=%s, %s @ %s", orderID, order.Quantity, order.Price)
+	return &order, nil
+}
+
+// ExpireOrder 将未成交的挂单标记为过期
+func (b *PaperBroker) ExpireOrder(orderID string) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	order, exists := b.orders[orderID]
+	if !exists {
+		return fmt.Errorf("订单不存在: %s", orderID)
+	}
+	if err := order.Transition(Expired); err != nil {
+		return err
+	}
+	b.orders[orderID] = order
+
+	log.Printf("纸面交易订单已过期: ID=%s", orderID)
+	return nil
+}
+
+// GetOrder 查询订单
+func (b *PaperBroker) GetOrder(orderID string) (*Order, error) {
+	quotes := b.marketQuotes()
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if !b.isConnected {
+		return nil, fmt.Errorf("经纪商未连接: %w", ErrBrokerUnavailable)
+	}
+
+	b.matchOrders(quotes)
+	order, exists := b.orders[orderID]
+	if !exists {
+		return nil, fmt.Errorf("订单不存在: %s", orderID)
+	}
+
+	return &order, nil
+}
+
+// GetOrderByClientID 按客户端订单号查询订单
+func (b *PaperBroker) GetOrderByClientID(clientOrderID string) (*Order, error) {
+	quotes := b.marketQuotes()
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if !b.isConnected {
+		return nil, fmt.Errorf("经纪商未连接: %w", ErrBrokerUnavailable)
+	}
+
+	b.matchOrders(quotes)
+	order, exists := findClientOrder(b.orders, clientOrderID)
+	if !exists {
+		return nil, fmt.Errorf("客户端订单号 %s: %w", clientOrderID, ErrOrderNotFound)
+	}
+	return order, nil
+}
+
+// GetOrders 查询订单列表
+func (b *PaperBroker) GetOrders(symbol string, status OrderStatus) ([]Order, error) {
+	quotes := b.marketQuotes()
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if !b.isConnected {
+		return nil, fmt.Errorf("经纪商未连接: %w", ErrBrokerUnavailable)
+	}
+
+	b.matchOrders(quotes)
+	var orders []Order
+	for _, order := range b.orders {
+		if symbol != "" && order.Symbol != symbol {
+			continue
+		}
+		if status != "" && order.Status != status {
+			continue
+		}
+		orders = append(orders, order)
+	}
+
+	return orders, nil
+}
+
+// GetBalance 获取余额
+func (b *PaperBroker) GetBalance() (decimal.Decimal, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if !b.isConnected {
+		return decimal.Zero, fmt.Errorf("经纪商未连接: %w", ErrBrokerUnavailable)
+	}
+
+	b.accrueInterest()
+	return b.balance, nil
+}
+
+// GetPositions 获取持仓，市值和浮动盈亏按实时报价计算
+func (b *PaperBroker) GetPositions() (map[string]Position, error) {
+	quotes := b.marketQuotes()
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if !b.isConnected {
+		return nil, fmt.Errorf("经纪商未连接: %w", ErrBrokerUnavailable)
+	}
+
+	b.matchOrders(quotes)
+	positions := make(map[string]Position, len(b.positions))
+	for symbol, position := range b.positions {
+		if quote, quoted := quotes[symbol]; quoted {
+			precision := b.precision.For(symbol)
+			multiplier := b.multiplier(symbol)
+			position.MarketValue = precision.RoundAmount(position.Quantity.Mul(quote).Mul(multiplier))
+			position.UnrealizedPL = precision.RoundAmount(position.MarketValue.Sub(position.Quantity.Mul(position.AvgPrice).Mul(multiplier)))
+			position.UpdateTime = time.Now()
+		}
+		positions[symbol] = position
+	}
+
+	return positions, nil
+}
+
+// GetTrades 获取成交记录
+func (b *PaperBroker) GetTrades(symbol string, limit int) ([]Trade, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if !b.isConnected {
+		return nil, fmt.Errorf("经纪商未连接: %w", ErrBrokerUnavailable)
+	}
+
+	var trades []Trade
+	count := 0
+	for i := len(b.trades) - 1; i >= 0 && count < limit; i-- {
+		if symbol != "" && b.trades[i].Symbol != symbol {
+			continue
+		}
+		trades = append([]Trade{b.trades[i]}, trades...)
+		count++
+	}
+
+	return trades, nil
+}
+
+// tradesBetween 成交时间在 (from, to] 之间的成交笔数
+func (b *PaperBroker) tradesBetween(from, to time.Time) int {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	count := 0
+	for _, trade := range b.trades {
+		if trade.Timestamp.After(from) && !trade.Timestamp.After(to) {
+			count++
+		}
+	}
+	return count
+}
+
+// snapshot 当前余额和持仓
+func (b *PaperBroker) snapshot() (decimal.Decimal, map[string]Position) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	positions := make(map[string]Position, len(b.positions))
+	for symbol, position := range b.positions {
+		positions[symbol] = position
+	}
+	return b.balance, positions
+}
+
+// restore 按保存的余额和持仓恢复账户，未成交挂单和成交记录不恢复
+func (b *PaperBroker) restore(balance decimal.Decimal, positions map[string]Position) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.balance = balance
+	b.positions = make(map[string]Position, len(positions))
+	for symbol, position := range positions {
+		b.positions[symbol] = position
+	}
+}
+
+// Deposit 入金
+func (b *PaperBroker) Deposit(amount decimal.Decimal) error {
+	if err := validateFundingAmount(amount); err != nil {
+		return err
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.balance = b.balance.Add(amount)
+	log.Printf("纸面交易经纪商 %s 入金: %s, 余额=%s", b.name, amount, b.balance)
+	return nil
+}
+
+// Withdraw 出金
+func (b *PaperBroker) Withdraw(amount decimal.Decimal) error {
+	if err := validateFundingAmount(amount); err != nil {
+		return err
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if amount.GreaterThan(b.balance) {
+		return fmt.Errorf("余额不足: 可用 %s, 申请 %s", b.balance, amount)
+	}
+
+	b.balance = b.balance.Sub(amount)
+	log.Printf("纸面交易经纪商 %s 出金: %s, 余额=%s", b.name, amount, b.balance)
+	return nil
+}
+
+// SetBalance 设置余额
+func (b *PaperBroker) SetBalance(balance decimal.Decimal) error {
+	if balance.IsNegative() {
+		return fmt.Errorf("余额不能为负数")
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.balance = balance
+	log.Printf("纸面交易经纪商 %s 余额设置为: %s", b.name, balance)
+	return nil
+}
+
+// Reset 重置为初始资金并清空持仓、订单和成交
+func (b *PaperBroker) Reset() error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.balance = b.initialBalance
+	b.positions = make(map[string]Position)
+	b.orders = make(map[string]Order)
+	b.trades = make([]Trade, 0)
+	b.interest = decimal.Zero
+	b.interestAt = time.Now()
+	b.marginCalls = 0
+	log.Printf("纸面交易经纪商 %s 已重置: 余额=%s", b.name, b.balance)
+	return nil
+}
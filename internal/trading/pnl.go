@@ -0,0 +1,348 @@
+package trading
+
+import (
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"agent-quant-system/internal/money"
+	"agent-quant-system/internal/sizing"
+
+	"github.com/shopspring/decimal"
+)
+
+// pnlKey 盈亏账本按账户、策略和标的分开记账
+type pnlKey struct {
+	account  string
+	strategy string
+	symbol   string
+}
+
+// pnlLot 未平仓的成交批次，数量为正表示多头，为负表示空头
+type pnlLot struct {
+	quantity decimal.Decimal
+	price    decimal.Decimal
+}
+
+// pnlBook 一个账户、策略、标的组合的批次和已实现盈亏
+type pnlBook struct {
+	lots       []pnlLot
+	realized   decimal.Decimal // 未扣除手续费
+	commission decimal.Decimal
+	funding    decimal.Decimal // 资金费用，正数为支出
+	trades     sizing.Stats    // 每笔平仓成交的盈亏统计（未扣除手续费），供 Kelly 仓位使用
+}
+
+// PositionPnL 单个账户、策略、标的的盈亏
+type PositionPnL struct {
+	Account       string          `json:"account"`
+	Strategy      string          `json:"strategy"`
+	Symbol        string          `json:"symbol"`
+	Quantity      decimal.Decimal `json:"quantity"` // 净持仓，空头为负数
+	AvgCost       decimal.Decimal `json:"avg_cost"` // 未平仓批次的平均成本
+	MarketPrice   decimal.Decimal `json:"market_price"`
+	RealizedPnL   decimal.Decimal `json:"realized_pnl"` // 已扣除手续费和资金费用
+	UnrealizedPnL decimal.Decimal `json:"unrealized_pnl"`
+	Commission    decimal.Decimal `json:"commission"`
+	Funding       decimal.Decimal `json:"funding,omitempty"`     // 资金费用，正数为支出
+	PriceError    string          `json:"price_error,omitempty"` // 获取最新价格失败时，未实现盈亏按0计
+}
+
+// PnLTotals 盈亏合计
+type PnLTotals struct {
+	RealizedPnL   decimal.Decimal `json:"realized_pnl"`
+	UnrealizedPnL decimal.Decimal `json:"unrealized_pnl"`
+	TotalPnL      decimal.Decimal `json:"total_pnl"`
+}
+
+// add 累加一个持仓的盈亏
+func (t *PnLTotals) add(position PositionPnL) {
+	t.RealizedPnL = t.RealizedPnL.Add(position.RealizedPnL)
+	t.UnrealizedPnL = t.UnrealizedPnL.Add(position.UnrealizedPnL)
+	t.TotalPnL = t.RealizedPnL.Add(t.UnrealizedPnL)
+}
+
+// PnLReport 盈亏报告，金额为各账户的计价币种，合计未做汇率折算
+type PnLReport struct {
+	Time       time.Time             `json:"time"`
+	Positions  []PositionPnL         `json:"positions"`
+	ByAccount  map[string]*PnLTotals `json:"by_account"`
+	ByStrategy map[string]*PnLTotals `json:"by_strategy"`
+}
+
+// PnLLedger 按先进先出匹配成交批次计算已实现盈亏，按最新价格计算未平仓批次的未实现盈亏
+type PnLLedger struct {
+	books       map[pnlKey]*pnlBook
+	attribution map[string]*strategyAttribution // 按策略的成交笔数和累计盈亏的回撤
+	multipliers *contractMultipliers            // 盈亏按合约乘数计算
+	mutex       sync.Mutex
+}
+
+// NewPnLLedger 创建盈亏账本
+func NewPnLLedger() *PnLLedger {
+	return &PnLLedger{books: make(map[pnlKey]*pnlBook), attribution: make(map[string]*strategyAttribution)}
+}
+
+// book 获取账本，不存在时创建，调用方需持有锁
+func (l *PnLLedger) book(key pnlKey) *pnlBook {
+	book, exists := l.books[key]
+	if !exists {
+		book = &pnlBook{}
+		l.books[key] = book
+	}
+	return book
+}
+
+// Apply 记入一笔成交：先按先进先出平掉该策略的反向批次，不足时依次平掉账户中其他策略在该标的上的反向批次
+// （如止损、手动平仓），平仓盈亏记在批次所属的策略上；剩余数量作为新批次开仓
+func (l *PnLLedger) Apply(accountName, strategyName, symbol string, side OrderSide, quantity, price, commission decimal.Decimal) {
+	if !quantity.IsPositive() {
+		return
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	key := pnlKey{account: accountName, strategy: strategyName, symbol: symbol}
+	own := l.book(key)
+	own.commission = own.commission.Add(commission)
+	l.attributionFor(strategyName).orders++
+
+	names := []string{strategyName}
+	var others []string
+	for other := range l.books {
+		if other.account == accountName && other.symbol == symbol && other.strategy != strategyName {
+			others = append(others, other.strategy)
+		}
+	}
+	sort.Strings(others)
+	names = append(names, others...)
+
+	signed := quantity
+	if side == SellSide {
+		signed = quantity.Neg()
+	}
+
+	multiplier := l.multipliers.multiplier(symbol)
+	remaining := signed
+	for _, name := range names {
+		book := l.books[pnlKey{account: accountName, strategy: name, symbol: symbol}]
+		closed, realized := false, decimal.Zero
+		for len(book.lots) > 0 && !remaining.IsZero() {
+			lot := &book.lots[0]
+			if lot.quantity.Sign() == remaining.Sign() {
+				break
+			}
+
+			// 本次平仓数量（带方向，与批次方向相同）
+			matched := decimal.Min(lot.quantity.Abs(), remaining.Abs())
+			if lot.quantity.IsNegative() {
+				matched = matched.Neg()
+			}
+			pnl := price.Sub(lot.price).Mul(matched).Mul(multiplier)
+			book.realized = book.realized.Add(pnl)
+			closed, realized = true, realized.Add(pnl)
+			lot.quantity = lot.quantity.Sub(matched)
+			remaining = remaining.Add(matched)
+			if lot.quantity.IsZero() {
+				book.lots = book.lots[1:]
+			}
+		}
+		if closed {
+			book.trades.Add(money.Float(realized))
+			l.attributionFor(name).recordClose(realized)
+		}
+		if remaining.IsZero() {
+			return
+		}
+	}
+
+	own.lots = append(own.lots, pnlLot{quantity: remaining, price: price})
+}
+
+// Position 策略在账户该标的上的未平仓数量，空头为负数
+func (l *PnLLedger) Position(accountName, strategyName, symbol string) decimal.Decimal {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	position := decimal.Zero
+	if book, exists := l.books[pnlKey{account: accountName, strategy: strategyName, symbol: symbol}]; exists {
+		for _, lot := range book.lots {
+			position = position.Add(lot.quantity)
+		}
+	}
+	return position
+}
+
+// FundingShares 资金费用在各策略间的分摊比例：按各策略在账户该标的上未平仓数量的绝对值分摊，
+// 没有策略持仓时返回 nil
+func (l *PnLLedger) FundingShares(accountName, symbol string) map[string]decimal.Decimal {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	held := make(map[string]decimal.Decimal)
+	total := decimal.Zero
+	for key, book := range l.books {
+		if key.account != accountName || key.symbol != symbol {
+			continue
+		}
+		for _, lot := range book.lots {
+			held[key.strategy] = held[key.strategy].Add(lot.quantity.Abs())
+			total = total.Add(lot.quantity.Abs())
+		}
+	}
+	if !total.IsPositive() {
+		return nil
+	}
+	shares := make(map[string]decimal.Decimal, len(held))
+	for strategy, quantity := range held {
+		if quantity.IsPositive() {
+			shares[strategy] = quantity.Div(total)
+		}
+	}
+	return shares
+}
+
+// ApplyFunding 记入一笔资金费用（正数为支出），从策略在该标的上的已实现盈亏中扣除
+func (l *PnLLedger) ApplyFunding(accountName, strategyName, symbol string, amount decimal.Decimal) {
+	if amount.IsZero() {
+		return
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	book := l.book(pnlKey{account: accountName, strategy: strategyName, symbol: symbol})
+	book.funding = book.funding.Add(amount)
+}
+
+// TradeStats 策略在全部账户和标的上的平仓盈亏统计
+func (l *PnLLedger) TradeStats(strategyName string) sizing.Stats {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	var stats sizing.Stats
+	for key, book := range l.books {
+		if key.strategy != strategyName {
+			continue
+		}
+		stats.Trades += book.trades.Trades
+		stats.Wins += book.trades.Wins
+		stats.GrossProfit += book.trades.GrossProfit
+		stats.GrossLoss += book.trades.GrossLoss
+	}
+	return stats
+}
+
+// Report 按最新价格生成盈亏报告；没有持仓且没有已实现盈亏的组合不列出
+func (l *PnLLedger) Report(prices PriceSource) *PnLReport {
+	l.mutex.Lock()
+	positions := make([]PositionPnL, 0, len(l.books))
+	for key, book := range l.books {
+		if len(book.lots) == 0 && book.realized.IsZero() && book.commission.IsZero() && book.funding.IsZero() {
+			continue
+		}
+		position := PositionPnL{
+			Account:     key.account,
+			Strategy:    key.strategy,
+			Symbol:      key.symbol,
+			RealizedPnL: book.realized.Sub(book.commission).Sub(book.funding),
+			Commission:  book.commission,
+			Funding:     book.funding,
+		}
+		cost := decimal.Zero
+		for _, lot := range book.lots {
+			position.Quantity = position.Quantity.Add(lot.quantity)
+			cost = cost.Add(lot.quantity.Mul(lot.price))
+		}
+		if !position.Quantity.IsZero() {
+			position.AvgCost = cost.Div(position.Quantity).Round(8)
+		}
+		positions = append(positions, position)
+	}
+	l.mutex.Unlock()
+
+	// 获取价格可能请求行情，不在锁内进行
+	report := &PnLReport{
+		Time:       time.Now(),
+		ByAccount:  make(map[string]*PnLTotals),
+		ByStrategy: make(map[string]*PnLTotals),
+	}
+	for i := range positions {
+		position := &positions[i]
+		if !position.Quantity.IsZero() && prices != nil {
+			price, err := prices.GetLatestPrice(position.Symbol)
+			if err != nil {
+				position.PriceError = err.Error()
+			} else {
+				position.MarketPrice = money.FromFloat(price)
+				position.UnrealizedPnL = l.multipliers.notional(position.Symbol, position.Quantity, position.MarketPrice.Sub(position.AvgCost)).Round(2)
+			}
+		}
+
+		if report.ByAccount[position.Account] == nil {
+			report.ByAccount[position.Account] = &PnLTotals{}
+		}
+		report.ByAccount[position.Account].add(*position)
+		if report.ByStrategy[position.Strategy] == nil {
+			report.ByStrategy[position.Strategy] = &PnLTotals{}
+		}
+		report.ByStrategy[position.Strategy].add(*position)
+	}
+	l.mark(report)
+
+	sort.Slice(positions, func(i, j int) bool {
+		if positions[i].Account != positions[j].Account {
+			return positions[i].Account < positions[j].Account
+		}
+		if positions[i].Strategy != positions[j].Strategy {
+			return positions[i].Strategy < positions[j].Strategy
+		}
+		return positions[i].Symbol < positions[j].Symbol
+	})
+	report.Positions = positions
+	return report
+}
+
+// loadPnLFromJournal 按成交流水重建盈亏账本和税务批次，使重启后已实现盈亏和持仓成本延续
+func (te *TradingEngine) loadPnLFromJournal() {
+	if te.currentConfig().Trading.JournalFile == "" {
+		return
+	}
+	entries, err := ReadJournal(te.currentConfig().Trading.JournalFile, time.Time{})
+	if err != nil {
+		log.Printf("读取成交流水失败，盈亏从本次启动开始计算: %v", err)
+		return
+	}
+
+	trades := 0
+	for _, entry := range entries {
+		if entry.Kind == JournalFunding {
+			te.pnl.ApplyFunding(entry.Account, entry.Strategy, entry.Symbol, entry.Funding)
+			continue
+		}
+		if entry.Kind != JournalTrade {
+			continue
+		}
+		te.pnl.Apply(entry.Account, entry.Strategy, entry.Symbol, entry.Side, entry.Quantity, entry.Price, entry.Commission)
+		te.taxLots.Apply(entry)
+		trades++
+	}
+	if trades > 0 {
+		log.Printf("已按成交流水重建盈亏账本: 成交 %d 笔", trades)
+	}
+}
+
+// recordPnL 将订单成交记入盈亏账本
+func (te *TradingEngine) recordPnL(filled *Order, strategyName, accountName string) {
+	if !filled.FilledQty.IsPositive() {
+		return
+	}
+	te.pnl.Apply(accountName, strategyName, filled.Symbol, filled.Side, filled.FilledQty, filled.AvgPrice, filled.Commission)
+}
+
+// GetPnL 获取按持仓、账户和策略汇总的盈亏
+func (te *TradingEngine) GetPnL() *PnLReport {
+	return te.pnl.Report(PriceSourceFunc(te.latestPrice))
+}
@@ -0,0 +1,96 @@
+package trading
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestPnLLedgerApplyMatchesFIFO(t *testing.T) {
+	type fill struct {
+		strategy string
+		side     OrderSide
+		quantity int64
+		price    int64
+	}
+	type book struct {
+		realized int64
+		lots     []pnlLot // 按开仓顺序的未平仓批次，空头数量为负数
+	}
+	lot := func(quantity, price int64) pnlLot {
+		return pnlLot{quantity: decimal.NewFromInt(quantity), price: decimal.NewFromInt(price)}
+	}
+
+	tests := []struct {
+		name  string
+		fills []fill
+		want  map[string]book
+	}{
+		{
+			name:  "先平最早的批次",
+			fills: []fill{{"trend", BuySide, 10, 100}, {"trend", BuySide, 10, 110}, {"trend", SellSide, 15, 120}},
+			// (120-100)*10 + (120-110)*5
+			want: map[string]book{"trend": {realized: 250, lots: []pnlLot{lot(5, 110)}}},
+		},
+		{
+			name:  "空头部分平仓",
+			fills: []fill{{"trend", SellSide, 10, 100}, {"trend", BuySide, 4, 90}},
+			want:  map[string]book{"trend": {realized: 40, lots: []pnlLot{lot(-6, 100)}}},
+		},
+		{
+			name:  "反手后剩余数量开新批次",
+			fills: []fill{{"trend", BuySide, 5, 100}, {"trend", SellSide, 8, 110}},
+			want:  map[string]book{"trend": {realized: 50, lots: []pnlLot{lot(-3, 110)}}},
+		},
+		{
+			name:  "同向成交不平仓",
+			fills: []fill{{"trend", BuySide, 5, 100}, {"trend", BuySide, 5, 102}},
+			want:  map[string]book{"trend": {realized: 0, lots: []pnlLot{lot(5, 100), lot(5, 102)}}},
+		},
+		{
+			name:  "自身没有反向批次时平掉其他策略的批次",
+			fills: []fill{{"trend", BuySide, 10, 100}, {"manual", SellSide, 6, 105}},
+			// 平仓盈亏记在批次所属的 trend 上
+			want: map[string]book{
+				"trend":  {realized: 30, lots: []pnlLot{lot(4, 100)}},
+				"manual": {realized: 0},
+			},
+		},
+		{
+			name:  "先平自身批次再平其他策略",
+			fills: []fill{{"grid", BuySide, 5, 90}, {"trend", BuySide, 5, 100}, {"trend", SellSide, 8, 110}},
+			want: map[string]book{
+				"trend": {realized: 50},
+				"grid":  {realized: 60, lots: []pnlLot{lot(2, 90)}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ledger := NewPnLLedger()
+			for _, f := range tt.fills {
+				ledger.Apply("test", f.strategy, "AAPL", f.side, decimal.NewFromInt(f.quantity), decimal.NewFromInt(f.price), decimal.Zero)
+			}
+
+			for strategyName, want := range tt.want {
+				got := ledger.books[pnlKey{account: "test", strategy: strategyName, symbol: "AAPL"}]
+				if got == nil {
+					t.Fatalf("策略 %s 没有账本", strategyName)
+				}
+				if !got.realized.Equal(decimal.NewFromInt(want.realized)) {
+					t.Fatalf("策略 %s 已实现盈亏 = %s, 期望 %d", strategyName, got.realized, want.realized)
+				}
+				if len(got.lots) != len(want.lots) {
+					t.Fatalf("策略 %s 未平仓批次 = %v, 期望 %v", strategyName, got.lots, want.lots)
+				}
+				for i := range want.lots {
+					if !got.lots[i].quantity.Equal(want.lots[i].quantity) || !got.lots[i].price.Equal(want.lots[i].price) {
+						t.Fatalf("策略 %s 第 %d 个批次 = %s @ %s, 期望 %s @ %s", strategyName, i+1,
+							got.lots[i].quantity, got.lots[i].price, want.lots[i].quantity, want.lots[i].price)
+					}
+				}
+			}
+		})
+	}
+}
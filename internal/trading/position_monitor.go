@@ -0,0 +1,360 @@
+package trading
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"agent-quant-system/internal/money"
+	"agent-quant-system/internal/strategy"
+)
+
+// PriceSource 实时价格来源
+type PriceSource interface {
+	GetLatestPrice(symbol string) (float64, error)
+}
+
+// StopRule 持仓的止损止盈规则
+type StopRule struct {
+	AccountName     string    `json:"account_name"`
+	Symbol          string    `json:"symbol"`
+	Side            OrderSide `json:"side"` // 开仓方向，买入为多头
+	EntryPrice      float64   `json:"entry_price"`
+	StopLoss        float64   `json:"stop_loss"`
+	TakeProfit      float64   `json:"take_profit"`
+	TrailingPercent float64   `json:"trailing_percent"` // 跟踪止损回撤比例，0表示不启用
+	HighWater       float64   `json:"high_water"`       // 开仓后的最高价
+	LowWater        float64   `json:"low_water"`        // 开仓后的最低价
+	CreateTime      time.Time `json:"create_time"`
+	Exiting         bool      `json:"exiting,omitempty"` // 已提交平仓订单，等待成交；平仓失败时恢复监控
+
+	exitOrder *Order // 尚未终止的平仓订单
+}
+
+// EffectiveStop 计算当前生效的止损价（固定止损与跟踪止损取更严格者）
+func (r *StopRule) EffectiveStop() float64 {
+	stop := r.StopLoss
+	if r.TrailingPercent <= 0 {
+		return stop
+	}
+
+	if r.Side == BuySide {
+		trailing := r.HighWater * (1 - r.TrailingPercent)
+		if trailing > stop {
+			stop = trailing
+		}
+	} else {
+		trailing := r.LowWater * (1 + r.TrailingPercent)
+		if stop <= 0 || trailing < stop {
+			stop = trailing
+		}
+	}
+	return stop
+}
+
+// PositionMonitor 后台持仓监控，触发止损止盈时自动平仓
+type PositionMonitor struct {
+	engine          *TradingEngine
+	prices          PriceSource
+	interval        time.Duration
+	trailingPercent float64
+
+	rules    map[string]*StopRule
+	mutex    sync.RWMutex
+	stopChan chan struct{}
+	wg       sync.WaitGroup
+	running  bool
+}
+
+// NewPositionMonitor 创建持仓监控
+func NewPositionMonitor(engine *TradingEngine, prices PriceSource, interval time.Duration, trailingPercent float64) *PositionMonitor {
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+
+	return &PositionMonitor{
+		engine:          engine,
+		prices:          prices,
+		interval:        interval,
+		trailingPercent: trailingPercent,
+		rules:           make(map[string]*StopRule),
+	}
+}
+
+// ruleKey 规则索引
+func ruleKey(accountName, symbol string) string {
+	return accountName + "|" + symbol
+}
+
+// TrackSignal 根据成交（含部分成交）订单和原始信号登记或移除止损止盈规则
+func (pm *PositionMonitor) TrackSignal(signal strategy.TradingSignal, order *Order) {
+	if order == nil || !order.FilledQty.IsPositive() {
+		return
+	}
+
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+
+	key := ruleKey(order.AccountName, order.Symbol)
+	existing, exists := pm.rules[key]
+
+	// 反向成交视为平仓
+	if exists && existing.Side != order.Side {
+		delete(pm.rules, key)
+		log.Printf("持仓监控移除规则: 账户=%s, 标的=%s", order.AccountName, order.Symbol)
+		return
+	}
+
+	trailing := signal.TrailingStopPercent
+	if trailing <= 0 {
+		trailing = pm.trailingPercent
+	}
+	if signal.StopLoss <= 0 && signal.TakeProfit <= 0 && trailing <= 0 {
+		return
+	}
+
+	// 止损止盈按浮点价格与行情比较，成交价在此转换
+	price := money.Float(order.AvgPrice)
+	if price <= 0 {
+		price = money.Float(order.Price)
+	}
+
+	if exists {
+		// 加仓时沿用已有的价格极值，仅更新止损止盈水平
+		existing.StopLoss = signal.StopLoss
+		existing.TakeProfit = signal.TakeProfit
+		existing.TrailingPercent = trailing
+		return
+	}
+
+	pm.rules[key] = &StopRule{
+		AccountName:     order.AccountName,
+		Symbol:          order.Symbol,
+		Side:            order.Side,
+		EntryPrice:      price,
+		StopLoss:        signal.StopLoss,
+		TakeProfit:      signal.TakeProfit,
+		TrailingPercent: trailing,
+		HighWater:       price,
+		LowWater:        price,
+		CreateTime:      time.Now(),
+	}
+	log.Printf("持仓监控登记规则: 账户=%s, 标的=%s, 止损=%.2f, 止盈=%.2f, 跟踪=%.2f%%",
+		order.AccountName, order.Symbol, signal.StopLoss, signal.TakeProfit, trailing*100)
+}
+
+// RemoveRule 移除规则
+func (pm *PositionMonitor) RemoveRule(accountName, symbol string) {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+	delete(pm.rules, ruleKey(accountName, symbol))
+}
+
+// GetRules 获取所有规则的副本
+func (pm *PositionMonitor) GetRules() []StopRule {
+	pm.mutex.RLock()
+	defer pm.mutex.RUnlock()
+
+	rules := make([]StopRule, 0, len(pm.rules))
+	for _, rule := range pm.rules {
+		rules = append(rules, *rule)
+	}
+	return rules
+}
+
+// Start 启动后台监控
+func (pm *PositionMonitor) Start() {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+
+	if pm.running {
+		return
+	}
+
+	pm.running = true
+	pm.stopChan = make(chan struct{})
+	pm.wg.Add(1)
+	go pm.run()
+
+	log.Printf("持仓监控已启动，检查间隔: %v", pm.interval)
+}
+
+// Stop 停止后台监控
+func (pm *PositionMonitor) Stop() {
+	pm.mutex.Lock()
+	if !pm.running {
+		pm.mutex.Unlock()
+		return
+	}
+	pm.running = false
+	close(pm.stopChan)
+	pm.mutex.Unlock()
+
+	pm.wg.Wait()
+	log.Printf("持仓监控已停止")
+}
+
+// run 监控循环
+func (pm *PositionMonitor) run() {
+	defer pm.wg.Done()
+
+	ticker := time.NewTicker(pm.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-pm.stopChan:
+			return
+		case <-ticker.C:
+			pm.CheckAll()
+		}
+	}
+}
+
+// CheckAll 检查所有规则，对触发的持仓提交平仓订单，并跟踪尚未终止的平仓订单
+func (pm *PositionMonitor) CheckAll() {
+	for _, rule := range pm.GetRules() {
+		if rule.Exiting {
+			if rule.exitOrder != nil {
+				pm.checkExit(rule)
+			}
+			continue
+		}
+
+		price, err := pm.prices.GetLatestPrice(rule.Symbol)
+		if err != nil {
+			log.Printf("持仓监控获取价格失败: 标的=%s, 错误=%v", rule.Symbol, err)
+			continue
+		}
+
+		reason, triggered := pm.evaluate(rule.AccountName, rule.Symbol, price)
+		if !triggered {
+			continue
+		}
+
+		if err := pm.exitPosition(rule, price, reason); err != nil {
+			log.Printf("持仓监控平仓失败: 账户=%s, 标的=%s, 错误=%v", rule.AccountName, rule.Symbol, err)
+		}
+	}
+}
+
+// evaluate 用最新价格更新极值并判断是否触发
+func (pm *PositionMonitor) evaluate(accountName, symbol string, price float64) (string, bool) {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+
+	rule, exists := pm.rules[ruleKey(accountName, symbol)]
+	if !exists || rule.Exiting {
+		return "", false
+	}
+
+	if price > rule.HighWater {
+		rule.HighWater = price
+	}
+	if price < rule.LowWater {
+		rule.LowWater = price
+	}
+
+	stop := rule.EffectiveStop()
+	if rule.Side == BuySide {
+		if stop > 0 && price <= stop {
+			return fmt.Sprintf("价格 %.2f 触及止损 %.2f", price, stop), true
+		}
+		if rule.TakeProfit > 0 && price >= rule.TakeProfit {
+			return fmt.Sprintf("价格 %.2f 触及止盈 %.2f", price, rule.TakeProfit), true
+		}
+	} else {
+		if stop > 0 && price >= stop {
+			return fmt.Sprintf("价格 %.2f 触及止损 %.2f", price, stop), true
+		}
+		if rule.TakeProfit > 0 && price <= rule.TakeProfit {
+			return fmt.Sprintf("价格 %.2f 触及止盈 %.2f", price, rule.TakeProfit), true
+		}
+	}
+
+	return "", false
+}
+
+// exitPosition 按经纪商当前持仓全部平仓：提交后规则标记为平仓中不再触发，平仓订单全部成交后才移除规则，
+// 提交失败、被拒绝或未全部成交即终止时恢复监控，剩余持仓在下次触发时继续平仓
+func (pm *PositionMonitor) exitPosition(rule StopRule, price float64, reason string) error {
+	log.Printf("持仓监控触发平仓: 账户=%s, 标的=%s, 价格=%.2f, 原因=%s",
+		rule.AccountName, rule.Symbol, price, reason)
+
+	pm.setExiting(rule, true, nil)
+	results, err := pm.engine.closePosition(rule.AccountName, rule.Symbol, 1, "position_monitor")
+	if err != nil {
+		if errors.Is(err, ErrNoPosition) {
+			// 持仓已不存在，规则失效
+			pm.RemoveRule(rule.AccountName, rule.Symbol)
+			return nil
+		}
+		pm.setExiting(rule, false, nil)
+		return fmt.Errorf("提交平仓订单失败: %w", err)
+	}
+
+	// 下单队列中可能有其他订单，等待结果不阻塞其他规则的检查
+	go func() {
+		result := <-results
+		pm.settleExit(rule, result.Order, result.Err)
+	}()
+	return nil
+}
+
+// checkExit 查询尚未终止的平仓订单（或等待人工确认的平仓订单）的最新状态
+func (pm *PositionMonitor) checkExit(rule StopRule) {
+	order := rule.exitOrder
+	if order.AwaitingApproval {
+		result, pending, err := pm.engine.resolveApproval(order.ID)
+		if !pending {
+			pm.settleExit(rule, result, err)
+		}
+		return
+	}
+
+	broker, err := pm.engine.GetBroker(rule.AccountName)
+	if err != nil {
+		log.Printf("持仓监控查询平仓订单失败: 账户=%s, 订单ID=%s, 错误=%v", rule.AccountName, order.ID, err)
+		return
+	}
+	current, err := broker.GetOrder(order.ID)
+	if err != nil {
+		log.Printf("持仓监控查询平仓订单失败: 账户=%s, 订单ID=%s, 错误=%v", rule.AccountName, order.ID, err)
+		return
+	}
+	pm.settleExit(rule, current, nil)
+}
+
+// settleExit 按平仓订单的结果移除规则、继续等待或恢复监控
+func (pm *PositionMonitor) settleExit(rule StopRule, order *Order, err error) {
+	switch {
+	case err != nil || order == nil:
+		log.Printf("持仓监控平仓失败，恢复监控: 账户=%s, 标的=%s, 错误=%v", rule.AccountName, rule.Symbol, err)
+		pm.setExiting(rule, false, nil)
+	case order.Status == Filled:
+		log.Printf("持仓监控平仓完成: 账户=%s, 标的=%s, 订单ID=%s, 数量=%s, 均价=%s",
+			rule.AccountName, rule.Symbol, order.ID, order.FilledQty, order.AvgPrice)
+		pm.RemoveRule(rule.AccountName, rule.Symbol)
+	case order.Status.IsTerminal():
+		log.Printf("持仓监控平仓订单未全部成交，恢复监控: 账户=%s, 标的=%s, 订单ID=%s, 状态=%s, 已成交=%s",
+			rule.AccountName, rule.Symbol, order.ID, order.Status, order.FilledQty)
+		pm.setExiting(rule, false, nil)
+	default:
+		pm.setExiting(rule, true, order)
+	}
+}
+
+// setExiting 设置规则的平仓状态，规则已被移除或替换时忽略
+func (pm *PositionMonitor) setExiting(rule StopRule, exiting bool, order *Order) {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+
+	current, exists := pm.rules[ruleKey(rule.AccountName, rule.Symbol)]
+	if !exists || !current.CreateTime.Equal(rule.CreateTime) {
+		return
+	}
+	current.Exiting = exiting
+	current.exitOrder = order
+}
@@ -0,0 +1,475 @@
+package trading
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"agent-quant-system/internal/commission"
+	"agent-quant-system/internal/config"
+	"agent-quant-system/internal/money"
+	"agent-quant-system/internal/notify"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	// promotionSampleInterval 纸面账户权益采样和晋级检查间隔
+	promotionSampleInterval = 5 * time.Minute
+	// promotionMaxDays 每个策略保留的纸面表现天数
+	promotionMaxDays = 365
+	// promotionDateLayout 纸面表现记录的日期格式
+	promotionDateLayout = "2006-01-02"
+)
+
+// PaperDay 策略一天的纸面表现
+type PaperDay struct {
+	Date   string  `json:"date"`
+	Return float64 `json:"return"` // 当日纸面权益收益率
+	Trades int     `json:"trades"` // 当日成交笔数
+}
+
+// promotionRecord 策略的纸面表现和晋级状态（持久化）
+type promotionRecord struct {
+	StartedAt  time.Time        `json:"started_at"`
+	PromotedAt *time.Time       `json:"promoted_at,omitempty"`
+	Days       []PaperDay       `json:"days"`
+	Track      *paperTrackState `json:"track,omitempty"` // 纸面副本最近一次采样的状态，重启后从这里继续
+}
+
+// paperTrackState 纸面副本的采样状态
+type paperTrackState struct {
+	SampledAt time.Time                    `json:"sampled_at"`
+	Accounts  map[string]paperTrackAccount `json:"accounts"`
+}
+
+// paperTrackAccount 纸面副本在一个账户中的余额、持仓和采样时的权益；未成交挂单不保存
+type paperTrackAccount struct {
+	Balance   decimal.Decimal     `json:"balance"`
+	Positions map[string]Position `json:"positions,omitempty"`
+	Equity    decimal.Decimal     `json:"equity"`
+}
+
+// paperTrack 策略在各账户的纸面副本，每次采样的余额和持仓写入晋级状态
+type paperTrack struct {
+	brokers    map[string]*PaperBroker
+	lastEquity decimal.Decimal
+	sampledAt  time.Time // 上次采样时间，之后的成交计入下一次采样
+}
+
+// PromotionStatus 策略的晋级状态和评估结果
+type PromotionStatus struct {
+	Strategy    string     `json:"strategy"`
+	Live        bool       `json:"live"`      // 信号是否发送到实盘经纪商
+	Requested   bool       `json:"requested"` // 是否列入 live
+	PromotedAt  *time.Time `json:"promoted_at,omitempty"`
+	PaperDays   int        `json:"paper_days"` // 纸面交易天数
+	Trades      int        `json:"trades"`     // 评估期内成交笔数
+	SharpeRatio float64    `json:"sharpe_ratio"`
+	MaxDrawdown float64    `json:"max_drawdown"`
+	Eligible    bool       `json:"eligible"`
+	Reasons     []string   `json:"reasons,omitempty"` // 未满足的条件
+}
+
+// PromotionManager 纸面交易到实盘的晋级：未晋级策略的订单在各账户的纸面副本中按实时报价模拟成交，
+// 定期采样纸面权益；列入 live 的策略在评估期表现满足条件后晋级，之后订单发送到实盘经纪商
+type PromotionManager struct {
+	cfg      config.PromotionConfig
+	accounts map[string]config.AccountConfig
+	prices   PriceSource
+	subject  map[string]bool // 受晋级约束的策略（已启用的策略和申请实盘的策略）
+	live     map[string]bool
+
+	records map[string]*promotionRecord
+	tracks  map[string]*paperTrack
+	mutex   sync.Mutex
+
+	// 按账户配置的佣金模型，纸面副本与账户按同样的佣金成交
+	fees map[string]commission.Model
+}
+
+// NewPromotionManager 创建晋级管理器并加载纸面表现；已晋级但移出 live 的策略回到纸面交易
+func NewPromotionManager(cfg *config.Config, prices PriceSource) *PromotionManager {
+	pm := &PromotionManager{
+		cfg:      cfg.Trading.Promotion,
+		accounts: cfg.Accounts,
+		prices:   prices,
+		subject:  make(map[string]bool),
+		live:     make(map[string]bool),
+		records:  make(map[string]*promotionRecord),
+		tracks:   make(map[string]*paperTrack),
+		fees:     accountCommissions(cfg),
+	}
+	for _, name := range cfg.Strategy.Active {
+		pm.subject[name] = true
+	}
+	for _, name := range pm.cfg.Live {
+		pm.subject[name] = true
+		pm.live[name] = true
+	}
+
+	if err := pm.load(); err != nil {
+		log.Printf("加载晋级状态失败，纸面表现将重新记录: %v", err)
+	}
+	for name, record := range pm.records {
+		if record.PromotedAt != nil && !pm.live[name] {
+			log.Printf("策略 '%s' 已移出 live，回到纸面交易", name)
+			record.PromotedAt = nil
+		}
+		if record.PromotedAt == nil && record.Track != nil {
+			pm.restoreTrack(name, record.Track)
+		}
+	}
+	return pm
+}
+
+// restoreTrack 按上次采样的状态恢复策略的纸面副本，权益变化从上次采样开始计算
+func (pm *PromotionManager) restoreTrack(strategyName string, state *paperTrackState) {
+	track := &paperTrack{brokers: make(map[string]*PaperBroker), sampledAt: state.SampledAt}
+	for accountName, account := range state.Accounts {
+		broker, err := pm.newPaperBroker(strategyName, accountName)
+		if err != nil {
+			log.Printf("恢复策略纸面副本失败: 策略=%s, 账户=%s, 错误=%v", strategyName, accountName, err)
+			continue
+		}
+		broker.restore(account.Balance, account.Positions)
+		track.brokers[accountName] = broker
+		track.lastEquity = track.lastEquity.Add(account.Equity)
+	}
+	if len(track.brokers) > 0 {
+		pm.tracks[strategyName] = track
+		log.Printf("已恢复策略纸面副本: 策略=%s, 账户=%d, 上次采样=%s",
+			strategyName, len(track.brokers), state.SampledAt.Format(time.RFC3339))
+	}
+}
+
+// newPaperBroker 按账户初始资金和佣金模型创建并连接策略的纸面副本
+func (pm *PromotionManager) newPaperBroker(strategyName, accountName string) (*PaperBroker, error) {
+	accountConfig, exists := pm.accounts[accountName]
+	if !exists {
+		return nil, fmt.Errorf("账户 '%s' 不存在", accountName)
+	}
+
+	broker := NewPaperBroker(fmt.Sprintf("%s/%s", accountName, strategyName), money.FromFloat(accountConfig.StartingBalance()),
+		accountConfig.PrecisionTable(), pm.prices)
+	if model, exists := pm.fees[accountName]; exists {
+		broker.SetCommissionModel(model)
+	}
+	if err := broker.Connect(); err != nil {
+		return nil, err
+	}
+	return broker, nil
+}
+
+// load 加载状态文件
+func (pm *PromotionManager) load() error {
+	content, err := os.ReadFile(pm.cfg.StateFile)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("读取晋级状态失败: %w", err)
+	}
+	if err := json.Unmarshal(content, &pm.records); err != nil {
+		return fmt.Errorf("解析晋级状态失败: %w", err)
+	}
+	return nil
+}
+
+// save 写入状态文件，调用方需持有锁
+func (pm *PromotionManager) save() {
+	if err := os.MkdirAll(filepath.Dir(pm.cfg.StateFile), 0755); err != nil {
+		log.Printf("创建晋级状态目录失败: %v", err)
+		return
+	}
+	content, err := json.MarshalIndent(pm.records, "", "  ")
+	if err != nil {
+		log.Printf("序列化晋级状态失败: %v", err)
+		return
+	}
+	if err := os.WriteFile(pm.cfg.StateFile, content, 0644); err != nil {
+		log.Printf("写入晋级状态失败: %v", err)
+	}
+}
+
+// IsPaper 策略的订单是否应在纸面副本中模拟成交
+func (pm *PromotionManager) IsPaper(strategyName string) bool {
+	if !pm.subject[strategyName] {
+		return false
+	}
+
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+	record, exists := pm.records[strategyName]
+	return !exists || record.PromotedAt == nil
+}
+
+// broker 获取策略在账户中的纸面副本，不存在时按账户初始资金创建
+func (pm *PromotionManager) broker(strategyName, accountName string) (*PaperBroker, error) {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+
+	track, exists := pm.tracks[strategyName]
+	if !exists {
+		track = &paperTrack{brokers: make(map[string]*PaperBroker)}
+		pm.tracks[strategyName] = track
+	}
+	if broker, exists := track.brokers[accountName]; exists {
+		return broker, nil
+	}
+
+	broker, err := pm.newPaperBroker(strategyName, accountName)
+	if err != nil {
+		return nil, err
+	}
+
+	// 先结算已有副本的收益，新副本的初始资金不计入收益
+	now := time.Now()
+	if _, exists := pm.records[strategyName]; !exists {
+		pm.records[strategyName] = &promotionRecord{StartedAt: now}
+	}
+	pm.sampleTrack(strategyName, track, now)
+	initialBalance, _ := broker.GetBalance()
+	track.brokers[accountName] = broker
+	track.lastEquity = track.lastEquity.Add(initialBalance)
+	pm.snapshotTrack(strategyName, track, now)
+	pm.save()
+
+	log.Printf("创建策略纸面副本: 策略=%s, 账户=%s, 初始资金=%s", strategyName, accountName, initialBalance)
+	return broker, nil
+}
+
+// sampleTrack 结算策略纸面副本自上次采样以来的收益和成交笔数，调用方需持有锁。
+// 成交笔数按副本的成交记录统计（部分成交的每一笔都计入），并记下各账户的余额和持仓
+func (pm *PromotionManager) sampleTrack(strategyName string, track *paperTrack, now time.Time) {
+	if len(track.brokers) == 0 {
+		return
+	}
+
+	equity := decimal.Zero
+	fills := 0
+	for accountName, broker := range track.brokers {
+		accountEq, err := accountEquity(broker)
+		if err != nil {
+			log.Printf("获取纸面副本权益失败: 策略=%s, 账户=%s, 错误=%v", strategyName, accountName, err)
+			return
+		}
+		equity = equity.Add(accountEq)
+		fills += broker.tradesBetween(track.sampledAt, now)
+	}
+
+	record, exists := pm.records[strategyName]
+	if !exists {
+		record = &promotionRecord{StartedAt: now}
+		pm.records[strategyName] = record
+	}
+
+	date := now.Format(promotionDateLayout)
+	if len(record.Days) == 0 || record.Days[len(record.Days)-1].Date != date {
+		record.Days = append(record.Days, PaperDay{Date: date})
+		if len(record.Days) > promotionMaxDays {
+			record.Days = record.Days[len(record.Days)-promotionMaxDays:]
+		}
+	}
+	today := &record.Days[len(record.Days)-1]
+
+	if track.lastEquity.IsPositive() {
+		periodReturn := money.Float(equity.Div(track.lastEquity)) - 1
+		today.Return = (1+today.Return)*(1+periodReturn) - 1
+	}
+	today.Trades += fills
+	track.lastEquity = equity
+	track.sampledAt = now
+	pm.snapshotTrack(strategyName, track, now)
+}
+
+// snapshotTrack 把纸面副本的余额、持仓和权益记入晋级状态，调用方需持有锁
+func (pm *PromotionManager) snapshotTrack(strategyName string, track *paperTrack, now time.Time) {
+	record, exists := pm.records[strategyName]
+	if !exists {
+		return
+	}
+
+	state := &paperTrackState{SampledAt: now, Accounts: make(map[string]paperTrackAccount, len(track.brokers))}
+	for accountName, broker := range track.brokers {
+		balance, positions := broker.snapshot()
+		equity, err := accountEquity(broker)
+		if err != nil {
+			log.Printf("获取纸面副本权益失败: 策略=%s, 账户=%s, 错误=%v", strategyName, accountName, err)
+			return
+		}
+		state.Accounts[accountName] = paperTrackAccount{Balance: balance, Positions: positions, Equity: equity}
+	}
+	record.Track = state
+}
+
+// Sample 采样全部纸面副本，并将满足条件的申请实盘策略晋级；返回本次晋级的策略
+func (pm *PromotionManager) Sample(now time.Time) []PromotionStatus {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+
+	for name, track := range pm.tracks {
+		pm.sampleTrack(name, track, now)
+	}
+
+	var promoted []PromotionStatus
+	for _, name := range pm.cfg.Live {
+		record, exists := pm.records[name]
+		if !exists || record.PromotedAt != nil {
+			continue
+		}
+		status := pm.evaluate(name, now)
+		if !status.Eligible {
+			continue
+		}
+		promotedAt := now
+		record.PromotedAt = &promotedAt
+		status.Live = true
+		status.PromotedAt = &promotedAt
+		promoted = append(promoted, status)
+		log.Printf("策略晋级实盘: 策略=%s, 成交=%d, 夏普=%.2f, 最大回撤=%.2f%%",
+			name, status.Trades, status.SharpeRatio, status.MaxDrawdown*100)
+	}
+
+	pm.save()
+	return promoted
+}
+
+// evaluate 按评估期内的纸面表现检查晋级条件，调用方需持有锁
+func (pm *PromotionManager) evaluate(strategyName string, now time.Time) PromotionStatus {
+	status := PromotionStatus{Strategy: strategyName, Requested: pm.live[strategyName]}
+	record, exists := pm.records[strategyName]
+	if !exists {
+		status.Live = !pm.subject[strategyName]
+		status.Reasons = []string{"尚无纸面交易记录"}
+		return status
+	}
+	status.Live = record.PromotedAt != nil
+	status.PromotedAt = record.PromotedAt
+	status.PaperDays = int(now.Sub(record.StartedAt).Hours() / 24)
+
+	windowStart := now.AddDate(0, 0, -pm.cfg.Days).Format(promotionDateLayout)
+	var returns []float64
+	for _, day := range record.Days {
+		if day.Date <= windowStart {
+			continue
+		}
+		returns = append(returns, day.Return)
+		status.Trades += day.Trades
+	}
+	status.SharpeRatio, status.MaxDrawdown = paperRiskMetrics(returns)
+
+	if status.PaperDays < pm.cfg.Days {
+		status.Reasons = append(status.Reasons, fmt.Sprintf("纸面交易 %d 天，需要 %d 天", status.PaperDays, pm.cfg.Days))
+	}
+	if status.Trades < pm.cfg.MinTrades {
+		status.Reasons = append(status.Reasons, fmt.Sprintf("成交 %d 笔，需要 %d 笔", status.Trades, pm.cfg.MinTrades))
+	}
+	if status.SharpeRatio < pm.cfg.MinSharpe {
+		status.Reasons = append(status.Reasons, fmt.Sprintf("夏普比率 %.2f，需要 %.2f", status.SharpeRatio, pm.cfg.MinSharpe))
+	}
+	if status.MaxDrawdown > pm.cfg.MaxDrawdown {
+		status.Reasons = append(status.Reasons, fmt.Sprintf("最大回撤 %.2f%%，上限 %.2f%%", status.MaxDrawdown*100, pm.cfg.MaxDrawdown*100))
+	}
+	status.Eligible = len(status.Reasons) == 0
+	return status
+}
+
+// GetStatus 获取受晋级约束的各策略状态（按策略名排序）
+func (pm *PromotionManager) GetStatus() []PromotionStatus {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+
+	now := time.Now()
+	statuses := make([]PromotionStatus, 0, len(pm.subject))
+	for name := range pm.subject {
+		statuses = append(statuses, pm.evaluate(name, now))
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Strategy < statuses[j].Strategy })
+	return statuses
+}
+
+// paperRiskMetrics 日收益率序列的年化夏普比率和最大回撤
+func paperRiskMetrics(returns []float64) (sharpe, maxDrawdown float64) {
+	if len(returns) == 0 {
+		return 0, 0
+	}
+
+	mean := 0.0
+	for _, r := range returns {
+		mean += r
+	}
+	mean /= float64(len(returns))
+
+	variance := 0.0
+	for _, r := range returns {
+		variance += (r - mean) * (r - mean)
+	}
+	if len(returns) > 1 {
+		variance /= float64(len(returns) - 1)
+	}
+	if std := math.Sqrt(variance); std > 0 {
+		sharpe = mean / std * math.Sqrt(252)
+	}
+
+	equity, peak := 1.0, 1.0
+	for _, r := range returns {
+		equity *= 1 + r
+		peak = math.Max(peak, equity)
+		maxDrawdown = math.Max(maxDrawdown, (peak-equity)/peak)
+	}
+	return sharpe, maxDrawdown
+}
+
+// executePaperTrade 在策略的纸面副本中模拟成交已通过交易名单和风险检查的未晋级策略订单，
+// 不占用实盘的资金分配和组合敞口
+func (te *TradingEngine) executePaperTrade(broker *PaperBroker, order Order, accountName string) (*Order, error) {
+	order.AccountName = accountName
+	order.Paper = true
+	order.CreateTime = time.Now()
+	order.UpdateTime = time.Now()
+
+	resultOrder, err := broker.PlaceOrder(order)
+	if err != nil {
+		return nil, fmt.Errorf("纸面下单失败: %w", err)
+	}
+	resultOrder.Paper = true
+
+	log.Printf("未晋级策略的订单已在纸面副本中执行: 策略=%s, 账户=%s, 订单ID=%s, 状态=%s",
+		order.Strategy, accountName, resultOrder.ID, resultOrder.Status)
+	return resultOrder, nil
+}
+
+// runPromotionSampling 定期采样纸面副本并检查晋级条件
+func (te *TradingEngine) runPromotionSampling(stop <-chan struct{}) {
+	ticker := time.NewTicker(promotionSampleInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			te.promotion.Sample(time.Now())
+			return
+		case now := <-ticker.C:
+			for _, status := range te.promotion.Sample(now) {
+				te.notifier.Notifyf(notify.EventPromotion, fmt.Sprintf("策略晋级实盘 %s", status.Strategy),
+					"评估期内成交=%d, 夏普比率=%.2f, 最大回撤=%.2f%%\n之后的信号将发送到实盘经纪商",
+					status.Trades, status.SharpeRatio, status.MaxDrawdown*100)
+			}
+		}
+	}
+}
+
+// GetPromotionStatus 获取策略晋级状态（未启用时返回nil）
+func (te *TradingEngine) GetPromotionStatus() []PromotionStatus {
+	if te.promotion == nil {
+		return nil
+	}
+	return te.promotion.GetStatus()
+}
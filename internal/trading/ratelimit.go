@@ -0,0 +1,283 @@
+package trading
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"agent-quant-system/internal/config"
+
+	"github.com/shopspring/decimal"
+)
+
+// defaultRateLimitWait 未配置 max_wait 时单个请求的最长等待时间
+const defaultRateLimitWait = 10 * time.Second
+
+// ErrRateLimited 请求需要等待的时间超过上限，未发送到经纪商
+var ErrRateLimited = errors.New("超过经纪商接口频率限制")
+
+// RequestKind 经纪商请求类别，按类别分别限制频率
+type RequestKind string
+
+const (
+	RequestOrder RequestKind = "order" // 下单、撤单
+	RequestQuery RequestKind = "query" // 查询余额、持仓、订单和成交
+)
+
+// tokenBucket 令牌桶：按固定速率补充令牌，最多积累 burst 个
+type tokenBucket struct {
+	rate   float64 // 每秒补充的令牌数
+	burst  float64
+	tokens float64 // 可以为负数，表示已被排队的请求预订
+	last   time.Time
+}
+
+// newTokenBucket 创建令牌桶，初始为满
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucket{rate: rate, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// reserve 预订一个令牌，返回需要等待的时间；等待超过 maxWait 时不预订
+func (b *tokenBucket) reserve(now time.Time, maxWait time.Duration) (time.Duration, bool) {
+	b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+
+	var wait time.Duration
+	if b.tokens < 1 {
+		wait = time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+	}
+	if wait > maxWait {
+		return wait, false
+	}
+	b.tokens--
+	return wait, true
+}
+
+// RateLimitStats 单个经纪商的频率限制统计
+type RateLimitStats struct {
+	Requests  map[RequestKind]int `json:"requests"`             // 已放行的请求（含排队后放行的）
+	Delayed   map[RequestKind]int `json:"delayed"`              // 排队等待后放行的请求
+	Throttled map[RequestKind]int `json:"throttled"`            // 等待时间超过上限被拒绝的请求
+	TotalWait time.Duration       `json:"total_wait"`           // 排队等待的累计时间
+	MaxWait   time.Duration       `json:"max_wait"`             // 单个请求的最长等待时间
+	LastDelay time.Time           `json:"last_delay,omitempty"` // 最近一次排队的时间
+}
+
+// BrokerRateLimiter 单个经纪商的接口频率限制
+type BrokerRateLimiter struct {
+	name    string
+	buckets map[RequestKind]*tokenBucket // 未配置限制的类别不在其中
+	maxWait time.Duration
+	stats   RateLimitStats
+	mutex   sync.Mutex
+}
+
+// NewBrokerRateLimiter 按账户配置创建频率限制
+func NewBrokerRateLimiter(name string, cfg config.RateLimitConfig) *BrokerRateLimiter {
+	limiter := &BrokerRateLimiter{
+		name:    name,
+		buckets: make(map[RequestKind]*tokenBucket),
+		maxWait: cfg.MaxWait,
+		stats: RateLimitStats{
+			Requests:  make(map[RequestKind]int),
+			Delayed:   make(map[RequestKind]int),
+			Throttled: make(map[RequestKind]int),
+		},
+	}
+	if limiter.maxWait == 0 {
+		limiter.maxWait = defaultRateLimitWait
+	}
+	if cfg.OrdersPerSecond > 0 {
+		limiter.buckets[RequestOrder] = newTokenBucket(cfg.OrdersPerSecond, cfg.OrderBurst)
+	}
+	if cfg.QueriesPerMinute > 0 {
+		limiter.buckets[RequestQuery] = newTokenBucket(cfg.QueriesPerMinute/60, cfg.QueryBurst)
+	}
+	return limiter
+}
+
+// Wait 等待可以发送一个请求；预计等待超过 max_wait 时返回 ErrRateLimited
+func (l *BrokerRateLimiter) Wait(kind RequestKind) error {
+	l.mutex.Lock()
+	bucket, limited := l.buckets[kind]
+	if !limited {
+		l.stats.Requests[kind]++
+		l.mutex.Unlock()
+		return nil
+	}
+
+	now := time.Now()
+	wait, ok := bucket.reserve(now, l.maxWait)
+	if !ok {
+		l.stats.Throttled[kind]++
+		l.mutex.Unlock()
+		return fmt.Errorf("%w: 经纪商 %s 的 %s 请求需等待 %v，超过上限 %v", ErrRateLimited, l.name, kind, wait.Round(time.Millisecond), l.maxWait)
+	}
+	l.stats.Requests[kind]++
+	if wait > 0 {
+		l.stats.Delayed[kind]++
+		l.stats.TotalWait += wait
+		if wait > l.stats.MaxWait {
+			l.stats.MaxWait = wait
+		}
+		l.stats.LastDelay = now
+	}
+	l.mutex.Unlock()
+
+	if wait > 0 {
+		time.Sleep(wait)
+	}
+	return nil
+}
+
+// GetStats 获取频率限制统计
+func (l *BrokerRateLimiter) GetStats() RateLimitStats {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	stats := l.stats
+	stats.Requests = copyKindCounts(l.stats.Requests)
+	stats.Delayed = copyKindCounts(l.stats.Delayed)
+	stats.Throttled = copyKindCounts(l.stats.Throttled)
+	return stats
+}
+
+// copyKindCounts 复制按请求类别的计数
+func copyKindCounts(counts map[RequestKind]int) map[RequestKind]int {
+	copied := make(map[RequestKind]int, len(counts))
+	for kind, count := range counts {
+		copied[kind] = count
+	}
+	return copied
+}
+
+// rateLimitedBroker 按频率限制转发请求的经纪商。
+// 可选接口（按客户端订单号查询、过期、健康检查）同样经过限制，
+// 调用方需先用 baseBroker 判断原经纪商是否支持
+type rateLimitedBroker struct {
+	BrokerAPI
+	limiter *BrokerRateLimiter
+}
+
+// baseBroker 获取未经频率限制和标的转换包装的经纪商，用于判断支持的可选接口
+func baseBroker(broker BrokerAPI) BrokerAPI {
+	for {
+		switch wrapped := broker.(type) {
+		case *rateLimitedBroker:
+			broker = wrapped.BrokerAPI
+		case *symbolMappedBroker:
+			broker = wrapped.BrokerAPI
+		default:
+			return broker
+		}
+	}
+}
+
+// PlaceOrder 下单
+func (b *rateLimitedBroker) PlaceOrder(order Order) (*Order, error) {
+	if err := b.limiter.Wait(RequestOrder); err != nil {
+		return nil, err
+	}
+	return b.BrokerAPI.PlaceOrder(order)
+}
+
+// CancelOrder 撤单
+func (b *rateLimitedBroker) CancelOrder(orderID string) error {
+	if err := b.limiter.Wait(RequestOrder); err != nil {
+		return err
+	}
+	return b.BrokerAPI.CancelOrder(orderID)
+}
+
+// GetOrder 查询订单
+func (b *rateLimitedBroker) GetOrder(orderID string) (*Order, error) {
+	if err := b.limiter.Wait(RequestQuery); err != nil {
+		return nil, err
+	}
+	return b.BrokerAPI.GetOrder(orderID)
+}
+
+// GetOrders 查询订单列表
+func (b *rateLimitedBroker) GetOrders(symbol string, status OrderStatus) ([]Order, error) {
+	if err := b.limiter.Wait(RequestQuery); err != nil {
+		return nil, err
+	}
+	return b.BrokerAPI.GetOrders(symbol, status)
+}
+
+// GetBalance 获取余额
+func (b *rateLimitedBroker) GetBalance() (decimal.Decimal, error) {
+	if err := b.limiter.Wait(RequestQuery); err != nil {
+		return decimal.Zero, err
+	}
+	return b.BrokerAPI.GetBalance()
+}
+
+// GetPositions 获取持仓
+func (b *rateLimitedBroker) GetPositions() (map[string]Position, error) {
+	if err := b.limiter.Wait(RequestQuery); err != nil {
+		return nil, err
+	}
+	return b.BrokerAPI.GetPositions()
+}
+
+// GetTrades 获取成交记录
+func (b *rateLimitedBroker) GetTrades(symbol string, limit int) ([]Trade, error) {
+	if err := b.limiter.Wait(RequestQuery); err != nil {
+		return nil, err
+	}
+	return b.BrokerAPI.GetTrades(symbol, limit)
+}
+
+// GetOrderByClientID 按客户端订单号查询订单
+func (b *rateLimitedBroker) GetOrderByClientID(clientOrderID string) (*Order, error) {
+	lookup, ok := b.BrokerAPI.(ClientOrderLookup)
+	if !ok {
+		return nil, fmt.Errorf("经纪商不支持按客户端订单号查询")
+	}
+	if err := b.limiter.Wait(RequestQuery); err != nil {
+		return nil, err
+	}
+	return lookup.GetOrderByClientID(clientOrderID)
+}
+
+// ExpireOrder 将订单标记为过期
+func (b *rateLimitedBroker) ExpireOrder(orderID string) error {
+	expirer, ok := b.BrokerAPI.(OrderExpirer)
+	if !ok {
+		return b.CancelOrder(orderID)
+	}
+	if err := b.limiter.Wait(RequestOrder); err != nil {
+		return err
+	}
+	return expirer.ExpireOrder(orderID)
+}
+
+// ReplaceOrder 改单
+func (b *rateLimitedBroker) ReplaceOrder(orderID string, price, quantity decimal.Decimal) (*Order, error) {
+	replacer, ok := b.BrokerAPI.(OrderReplacer)
+	if !ok {
+		return nil, fmt.Errorf("经纪商不支持改单")
+	}
+	if err := b.limiter.Wait(RequestOrder); err != nil {
+		return nil, err
+	}
+	return replacer.ReplaceOrder(orderID, price, quantity)
+}
+
+// Ping 健康检查
+func (b *rateLimitedBroker) Ping() error {
+	checker, ok := b.BrokerAPI.(HealthChecker)
+	if !ok {
+		return fmt.Errorf("经纪商不支持健康检查")
+	}
+	if err := b.limiter.Wait(RequestQuery); err != nil {
+		return err
+	}
+	return checker.Ping()
+}
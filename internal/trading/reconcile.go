@@ -0,0 +1,210 @@
+package trading
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"agent-quant-system/internal/money"
+	"agent-quant-system/internal/notify"
+
+	"github.com/shopspring/decimal"
+)
+
+// DiscrepancyKind 对账差异类型
+type DiscrepancyKind string
+
+const (
+	BalanceDiscrepancy  DiscrepancyKind = "balance"  // 余额不一致
+	PositionDiscrepancy DiscrepancyKind = "position" // 持仓数量不一致（包括只在一方存在的持仓）
+)
+
+// Discrepancy 本地账户状态与经纪商状态的一处差异
+type Discrepancy struct {
+	Account   string          `json:"account"`
+	Kind      DiscrepancyKind `json:"kind"`
+	Symbol    string          `json:"symbol,omitempty"` // 余额差异时为空
+	Local     decimal.Decimal `json:"local"`
+	Broker    decimal.Decimal `json:"broker"`
+	Corrected bool            `json:"corrected"` // 已按经纪商状态修正本地状态
+}
+
+// ReconciliationReport 一次对账的结果
+type ReconciliationReport struct {
+	Time          time.Time         `json:"time"`
+	Accounts      int               `json:"accounts"` // 完成对账的账户数
+	Discrepancies []Discrepancy     `json:"discrepancies,omitempty"`
+	Errors        map[string]string `json:"errors,omitempty"` // 无法对账的账户及原因
+}
+
+// Healthy 所有账户均完成对账且没有未修正的差异
+func (r *ReconciliationReport) Healthy() bool {
+	if len(r.Errors) > 0 {
+		return false
+	}
+	for _, d := range r.Discrepancies {
+		if !d.Corrected {
+			return false
+		}
+	}
+	return true
+}
+
+// Summary 对账结果摘要
+func (r *ReconciliationReport) Summary() string {
+	var lines []string
+	for _, d := range r.Discrepancies {
+		item := fmt.Sprintf("账户=%s, 余额", d.Account)
+		if d.Kind == PositionDiscrepancy {
+			item = fmt.Sprintf("账户=%s, 标的=%s 持仓", d.Account, d.Symbol)
+		}
+		item += fmt.Sprintf(": 本地=%s, 经纪商=%s", d.Local, d.Broker)
+		if d.Corrected {
+			item += " (已修正)"
+		}
+		lines = append(lines, item)
+	}
+	for name, reason := range r.Errors {
+		lines = append(lines, fmt.Sprintf("账户=%s 无法对账: %s", name, reason))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Reconcile 将账户管理器中的余额和持仓与各经纪商对账，autoCorrect 时按经纪商状态修正有差异的账户
+func (te *TradingEngine) Reconcile(autoCorrect bool) *ReconciliationReport {
+	te.mutex.RLock()
+	brokers := make(map[string]BrokerAPI, len(te.brokers))
+	for name, broker := range te.brokers {
+		brokers[name] = broker
+	}
+	te.mutex.RUnlock()
+
+	names := make([]string, 0, len(brokers))
+	for name := range brokers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	report := &ReconciliationReport{Time: time.Now()}
+	for _, name := range names {
+		discrepancies, err := te.reconcileAccount(name, brokers[name])
+		if err != nil {
+			if report.Errors == nil {
+				report.Errors = make(map[string]string)
+			}
+			report.Errors[name] = err.Error()
+			continue
+		}
+		report.Accounts++
+
+		if len(discrepancies) > 0 && autoCorrect {
+			if err := te.SyncAccount(name); err != nil {
+				log.Printf("按经纪商状态修正账户 '%s' 失败: %v", name, err)
+			} else {
+				for i := range discrepancies {
+					discrepancies[i].Corrected = true
+				}
+			}
+		}
+		report.Discrepancies = append(report.Discrepancies, discrepancies...)
+	}
+
+	te.mutex.Lock()
+	te.reconciled = report
+	te.mutex.Unlock()
+
+	if len(report.Discrepancies) > 0 || len(report.Errors) > 0 {
+		log.Printf("对账发现差异:\n%s", report.Summary())
+	}
+	return report
+}
+
+// reconcileAccount 比较单个账户的本地状态与经纪商状态
+func (te *TradingEngine) reconcileAccount(accountName string, broker BrokerAPI) ([]Discrepancy, error) {
+	brokerBalance, err := broker.GetBalance()
+	if err != nil {
+		return nil, fmt.Errorf("获取经纪商余额失败: %w", err)
+	}
+	brokerPositions, err := broker.GetPositions()
+	if err != nil {
+		return nil, fmt.Errorf("获取经纪商持仓失败: %w", err)
+	}
+
+	account, err := te.accountManager.GetAccount(accountName)
+	if err != nil {
+		return nil, err
+	}
+	localPositions, err := te.accountManager.GetAllPositions(accountName)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := te.currentConfig().Trading.Reconciliation
+	var discrepancies []Discrepancy
+
+	balanceTolerance := money.FromFloat(cfg.BalanceTolerance)
+	if account.Balance.Sub(brokerBalance).Abs().GreaterThan(balanceTolerance) {
+		discrepancies = append(discrepancies, Discrepancy{
+			Account: accountName,
+			Kind:    BalanceDiscrepancy,
+			Local:   account.Balance,
+			Broker:  brokerBalance,
+		})
+	}
+
+	symbols := make(map[string]bool, len(localPositions)+len(brokerPositions))
+	for symbol := range localPositions {
+		symbols[symbol] = true
+	}
+	for symbol, position := range brokerPositions {
+		if !position.Quantity.IsZero() {
+			symbols[symbol] = true
+		}
+	}
+
+	quantityTolerance := money.FromFloat(cfg.QuantityTolerance)
+	for _, symbol := range sortedSymbols(symbols) {
+		local := localPositions[symbol].Quantity
+		remote := brokerPositions[symbol].Quantity
+		if local.Sub(remote).Abs().GreaterThan(quantityTolerance) {
+			discrepancies = append(discrepancies, Discrepancy{
+				Account: accountName,
+				Kind:    PositionDiscrepancy,
+				Symbol:  symbol,
+				Local:   local,
+				Broker:  remote,
+			})
+		}
+	}
+	return discrepancies, nil
+}
+
+// runReconciliation 定期对账，发现差异或无法对账时发送通知
+func (te *TradingEngine) runReconciliation(stop <-chan struct{}) {
+	cfg := te.currentConfig().Trading.Reconciliation
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			report := te.Reconcile(cfg.AutoCorrect)
+			if len(report.Discrepancies) == 0 && len(report.Errors) == 0 {
+				continue
+			}
+			te.notifier.Notifyf(notify.EventReconcile, "本地账户与经纪商状态不一致",
+				"差异 %d 处\n%s", len(report.Discrepancies), report.Summary())
+		}
+	}
+}
+
+// GetReconciliation 获取最近一次对账结果（尚未对账时返回nil）
+func (te *TradingEngine) GetReconciliation() *ReconciliationReport {
+	te.mutex.RLock()
+	defer te.mutex.RUnlock()
+	return te.reconciled
+}
@@ -0,0 +1,455 @@
+package trading
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"agent-quant-system/internal/config"
+
+	"github.com/shopspring/decimal"
+)
+
+// RiskManager 风险管理器
+type RiskManager struct {
+	maxPositionSize  float64 // 最大单笔仓位
+	maxTotalExposure float64 // 最大总仓位
+	maxDailyLoss     float64 // 最大日亏损
+	maxDrawdown      float64 // 最大回撤
+	resizeOrders     bool    // 超限时是否缩减订单
+
+	// 按账户资产类别生效的风控预设，未设置的账户使用上面的全局限制
+	accountLimits map[string]config.RiskLimits
+
+	// 保证金账户的杠杆倍数和最大保证金使用率，未设置杠杆的账户不检查保证金使用率
+	leverage             map[string]float64
+	maxMarginUtilization float64
+
+	// 每个账户的权益跟踪，用于日亏损和回撤判断
+	equityTracks map[string]*equityTrack
+	mutex        sync.Mutex
+
+	// 订单价值按合约乘数计算
+	multipliers *contractMultipliers
+
+	// 风控调整记录，用于观察风控约束的触发频率
+	stats       RiskStats
+	adjustments []RiskAdjustment
+	statsMutex  sync.RWMutex
+}
+
+// maxRiskAdjustments 保留的最近风控调整记录数
+const maxRiskAdjustments = 100
+
+// RiskAction 风控对订单的处理结果
+type RiskAction string
+
+const (
+	RiskResized  RiskAction = "resized"  // 数量被缩减
+	RiskRejected RiskAction = "rejected" // 被拒绝
+)
+
+// RiskAdjustment 风控调整记录，保存订单原始意图和调整结果
+type RiskAdjustment struct {
+	Time             time.Time       `json:"time"`
+	Account          string          `json:"account"`
+	Strategy         string          `json:"strategy"`
+	Symbol           string          `json:"symbol"`
+	Side             OrderSide       `json:"side"`
+	Price            decimal.Decimal `json:"price"`
+	OriginalQuantity decimal.Decimal `json:"original_quantity"`
+	AdjustedQuantity decimal.Decimal `json:"adjusted_quantity"` // 被拒绝时为0
+	Action           RiskAction      `json:"action"`
+	Reason           string          `json:"reason"`
+}
+
+// RiskStats 风控统计
+type RiskStats struct {
+	TotalChecks int                           `json:"total_checks"`
+	Passed      int                           `json:"passed"`
+	Resized     int                           `json:"resized"`
+	Rejected    int                           `json:"rejected"`
+	ByStrategy  map[string]*StrategyRiskStats `json:"by_strategy"`
+	Recent      []RiskAdjustment              `json:"recent,omitempty"`
+}
+
+// StrategyRiskStats 单个策略的风控统计
+type StrategyRiskStats struct {
+	Checks   int `json:"checks"`
+	Resized  int `json:"resized"`
+	Rejected int `json:"rejected"`
+}
+
+// BindingRate 风控生效（缩减或拒绝）的订单占比
+func (s *StrategyRiskStats) BindingRate() float64 {
+	if s.Checks == 0 {
+		return 0
+	}
+	return float64(s.Resized+s.Rejected) / float64(s.Checks)
+}
+
+// equityTrack 账户权益跟踪
+type equityTrack struct {
+	day      string
+	dayStart decimal.Decimal
+	peak     decimal.Decimal
+}
+
+// NewRiskManager 创建风险管理器
+func NewRiskManager(maxPositionSize, maxDailyLoss, maxDrawdown float64) *RiskManager {
+	return &RiskManager{
+		maxPositionSize:  maxPositionSize,
+		maxTotalExposure: 1.0,
+		maxDailyLoss:     maxDailyLoss,
+		maxDrawdown:      maxDrawdown,
+		equityTracks:     make(map[string]*equityTrack),
+		stats: RiskStats{
+			ByStrategy: make(map[string]*StrategyRiskStats),
+		},
+	}
+}
+
+// NewRiskManagerFromConfig 根据配置创建风险管理器
+func NewRiskManagerFromConfig(cfg config.RiskConfig) *RiskManager {
+	rm := NewRiskManager(cfg.MaxPositionSize, cfg.MaxDailyLoss, cfg.MaxDrawdown)
+	rm.maxTotalExposure = cfg.MaxTotalExposure
+	rm.resizeOrders = cfg.ResizeOrders
+	rm.maxMarginUtilization = cfg.MaxMarginUtilization
+	return rm
+}
+
+// UpdateLimits 按新的风控配置替换全局限制并清除账户单独的限制（热加载配置时使用），权益跟踪和统计保持不变
+func (rm *RiskManager) UpdateLimits(cfg config.RiskConfig) {
+	rm.mutex.Lock()
+	defer rm.mutex.Unlock()
+
+	rm.maxPositionSize = cfg.MaxPositionSize
+	rm.maxTotalExposure = cfg.MaxTotalExposure
+	rm.maxDailyLoss = cfg.MaxDailyLoss
+	rm.maxDrawdown = cfg.MaxDrawdown
+	rm.resizeOrders = cfg.ResizeOrders
+	rm.maxMarginUtilization = cfg.MaxMarginUtilization
+	rm.accountLimits = nil
+}
+
+// SetAccountLeverage 设置保证金账户的杠杆倍数，买入后的保证金使用率不能超过 max_margin_utilization
+func (rm *RiskManager) SetAccountLeverage(accountName string, leverage float64) {
+	rm.mutex.Lock()
+	defer rm.mutex.Unlock()
+
+	if rm.leverage == nil {
+		rm.leverage = make(map[string]float64)
+	}
+	rm.leverage[accountName] = leverage
+}
+
+// marginLimit 账户的杠杆倍数和最大保证金使用率，未设置杠杆或不限制时 ok 为 false
+func (rm *RiskManager) marginLimit(accountName string) (leverage, maxUtilization float64, ok bool) {
+	rm.mutex.Lock()
+	defer rm.mutex.Unlock()
+
+	leverage = rm.leverage[accountName]
+	return leverage, rm.maxMarginUtilization, leverage > 1 && rm.maxMarginUtilization > 0
+}
+
+// SetAccountLimits 为账户设置单独的仓位和亏损限制
+func (rm *RiskManager) SetAccountLimits(accountName string, limits config.RiskLimits) {
+	rm.mutex.Lock()
+	defer rm.mutex.Unlock()
+
+	if rm.accountLimits == nil {
+		rm.accountLimits = make(map[string]config.RiskLimits)
+	}
+	rm.accountLimits[accountName] = limits
+}
+
+// limitsFor 账户适用的限制
+func (rm *RiskManager) limitsFor(accountName string) config.RiskLimits {
+	rm.mutex.Lock()
+	defer rm.mutex.Unlock()
+
+	if limits, ok := rm.accountLimits[accountName]; ok {
+		return limits
+	}
+	return config.RiskLimits{
+		MaxPositionSize:  rm.maxPositionSize,
+		MaxTotalExposure: rm.maxTotalExposure,
+		MaxDailyLoss:     rm.maxDailyLoss,
+		MaxDrawdown:      rm.maxDrawdown,
+	}
+}
+
+// CheckOrder 下单前风险检查，返回可能被缩减数量后的订单，缩减或拒绝都会被记录
+func (rm *RiskManager) CheckOrder(order Order, accountName string, cashBalance decimal.Decimal, currentPositions map[string]Position) (Order, error) {
+	checked, reason, err := rm.checkOrder(order, accountName, cashBalance, currentPositions)
+
+	switch {
+	case err != nil:
+		rm.recordCheck(order, accountName, decimal.Zero, RiskRejected, err.Error())
+	case !checked.Quantity.Equal(order.Quantity):
+		rm.recordCheck(order, accountName, checked.Quantity, RiskResized, reason)
+	default:
+		rm.recordCheck(order, accountName, checked.Quantity, "", "")
+	}
+
+	return checked, err
+}
+
+// checkOrder 执行风险检查，返回检查后的订单和缩减原因
+func (rm *RiskManager) checkOrder(order Order, accountName string, cashBalance decimal.Decimal, currentPositions map[string]Position) (Order, string, error) {
+	if !order.Quantity.IsPositive() {
+		return order, "", fmt.Errorf("订单数量必须大于0")
+	}
+	if !order.Price.IsPositive() {
+		return order, "", fmt.Errorf("订单价格必须大于0")
+	}
+
+	positionsValue := decimal.Zero
+	for _, position := range currentPositions {
+		positionsValue = positionsValue.Add(position.MarketValue)
+	}
+	equity := cashBalance.Add(positionsValue)
+
+	// 平仓方向的卖单会降低风险敞口，不受仓位限制
+	if order.Side == SellSide {
+		if position, exists := currentPositions[order.Symbol]; exists && position.Quantity.IsPositive() {
+			if order.Quantity.GreaterThan(position.Quantity) {
+				order.Quantity = position.Quantity
+				return order, "卖出数量超过持仓", nil
+			}
+			return order, "", nil
+		}
+	}
+
+	// 检查日亏损和回撤
+	if err := rm.checkLossLimits(accountName, equity); err != nil {
+		return order, "", err
+	}
+
+	limits := rm.limitsFor(accountName)
+	var reasons []string
+
+	// 检查单笔仓位大小
+	maxOrderValue := equity.Mul(decimal.NewFromFloat(limits.MaxPositionSize))
+	resized, err := rm.applyValueCap(&order, maxOrderValue, "单笔仓位过大")
+	if err != nil {
+		return order, "", err
+	}
+	if resized {
+		reasons = append(reasons, "单笔仓位过大")
+	}
+
+	// 检查总仓位
+	remainingExposure := equity.Mul(decimal.NewFromFloat(limits.MaxTotalExposure)).Sub(positionsValue)
+	resized, err = rm.applyValueCap(&order, remainingExposure, "总仓位超过限制")
+	if err != nil {
+		return order, "", err
+	}
+	if resized {
+		reasons = append(reasons, "总仓位超过限制")
+	}
+
+	// 检查保证金使用率：买入后持仓市值不超过 权益 * 杠杆 * 最大使用率
+	if leverage, maxUtilization, ok := rm.marginLimit(accountName); ok {
+		remainingMargin := equity.Mul(decimal.NewFromFloat(leverage * maxUtilization)).Sub(positionsValue)
+		resized, err = rm.applyValueCap(&order, remainingMargin, "保证金使用率超过限制")
+		if err != nil {
+			return order, "", err
+		}
+		if resized {
+			reasons = append(reasons, "保证金使用率超过限制")
+		}
+	}
+
+	orderValue := rm.multipliers.notional(order.Symbol, order.Quantity, order.Price)
+	log.Printf("交易风险验证通过: 账户=%s, 单笔仓位=%s, 总仓位=%s",
+		accountName, orderValue.StringFixed(2), positionsValue.Add(orderValue).StringFixed(2))
+	return order, strings.Join(reasons, "; "), nil
+}
+
+// applyValueCap 将订单价值限制在上限内，根据配置缩减或拒绝，返回是否发生缩减
+func (rm *RiskManager) applyValueCap(order *Order, maxValue decimal.Decimal, reason string) (bool, error) {
+	orderValue := rm.multipliers.notional(order.Symbol, order.Quantity, order.Price)
+	if orderValue.LessThanOrEqual(maxValue) {
+		return false, nil
+	}
+
+	if !rm.resizeOrders || !maxValue.IsPositive() {
+		return false, fmt.Errorf("%s: %s > %s", reason, orderValue.StringFixed(2), decimal.Max(maxValue, decimal.Zero).StringFixed(2))
+	}
+
+	newQuantity := maxValue.Div(rm.multipliers.notional(order.Symbol, decimal.NewFromInt(1), order.Price))
+	if order.Quantity.IsInteger() {
+		// 整数数量的订单（如股票）缩减后仍保持整数
+		newQuantity = newQuantity.Floor()
+	}
+	if !newQuantity.IsPositive() {
+		return false, fmt.Errorf("%s: 缩减后数量为0", reason)
+	}
+
+	log.Printf("%s，订单数量由 %s 缩减为 %s", reason, order.Quantity, newQuantity)
+	order.Quantity = newQuantity
+	return true, nil
+}
+
+// recordCheck 记录一次风险检查结果，action 为空表示原样通过
+func (rm *RiskManager) recordCheck(order Order, accountName string, adjustedQuantity decimal.Decimal, action RiskAction, reason string) {
+	rm.statsMutex.Lock()
+	defer rm.statsMutex.Unlock()
+
+	strategyName := order.Strategy
+	if strategyName == "" {
+		strategyName = "manual"
+	}
+	strategyStats, exists := rm.stats.ByStrategy[strategyName]
+	if !exists {
+		strategyStats = &StrategyRiskStats{}
+		rm.stats.ByStrategy[strategyName] = strategyStats
+	}
+
+	rm.stats.TotalChecks++
+	strategyStats.Checks++
+
+	switch action {
+	case RiskResized:
+		rm.stats.Resized++
+		strategyStats.Resized++
+	case RiskRejected:
+		rm.stats.Rejected++
+		strategyStats.Rejected++
+	default:
+		rm.stats.Passed++
+		return
+	}
+
+	adjustment := RiskAdjustment{
+		Time:             time.Now(),
+		Account:          accountName,
+		Strategy:         strategyName,
+		Symbol:           order.Symbol,
+		Side:             order.Side,
+		Price:            order.Price,
+		OriginalQuantity: order.Quantity,
+		AdjustedQuantity: adjustedQuantity,
+		Action:           action,
+		Reason:           reason,
+	}
+	rm.adjustments = append(rm.adjustments, adjustment)
+	if len(rm.adjustments) > maxRiskAdjustments {
+		rm.adjustments = rm.adjustments[len(rm.adjustments)-maxRiskAdjustments:]
+	}
+
+	log.Printf("风控调整订单: 账户=%s, 策略=%s, 标的=%s, 处理=%s, 数量 %s -> %s, 原因=%s",
+		accountName, strategyName, order.Symbol, action, order.Quantity, adjustedQuantity, reason)
+}
+
+// GetStats 获取风控统计，recent 为返回的最近调整记录条数
+func (rm *RiskManager) GetStats(recent int) RiskStats {
+	rm.statsMutex.RLock()
+	defer rm.statsMutex.RUnlock()
+
+	stats := rm.stats
+	stats.ByStrategy = make(map[string]*StrategyRiskStats, len(rm.stats.ByStrategy))
+	for name, strategyStats := range rm.stats.ByStrategy {
+		copied := *strategyStats
+		stats.ByStrategy[name] = &copied
+	}
+
+	if recent > len(rm.adjustments) {
+		recent = len(rm.adjustments)
+	}
+	if recent > 0 {
+		stats.Recent = append([]RiskAdjustment(nil), rm.adjustments[len(rm.adjustments)-recent:]...)
+	}
+
+	return stats
+}
+
+// GetAdjustments 获取最近的风控调整记录
+func (rm *RiskManager) GetAdjustments() []RiskAdjustment {
+	rm.statsMutex.RLock()
+	defer rm.statsMutex.RUnlock()
+
+	return append([]RiskAdjustment(nil), rm.adjustments...)
+}
+
+// checkLossLimits 检查日亏损和最大回撤
+func (rm *RiskManager) checkLossLimits(accountName string, equity decimal.Decimal) error {
+	limits := rm.limitsFor(accountName)
+
+	rm.mutex.Lock()
+	defer rm.mutex.Unlock()
+
+	today := time.Now().Format("2006-01-02")
+
+	track, exists := rm.equityTracks[accountName]
+	if !exists {
+		track = &equityTrack{day: today, dayStart: equity, peak: equity}
+		rm.equityTracks[accountName] = track
+	}
+	if track.day != today {
+		track.day = today
+		track.dayStart = equity
+	}
+	if equity.GreaterThan(track.peak) {
+		track.peak = equity
+	}
+
+	if limits.MaxDailyLoss > 0 && track.dayStart.IsPositive() {
+		dailyLoss := track.dayStart.Sub(equity).Div(track.dayStart).InexactFloat64()
+		if dailyLoss >= limits.MaxDailyLoss {
+			return fmt.Errorf("已达到单日最大亏损限制: %.2f%% >= %.2f%%", dailyLoss*100, limits.MaxDailyLoss*100)
+		}
+	}
+
+	if limits.MaxDrawdown > 0 && track.peak.IsPositive() {
+		drawdown := track.peak.Sub(equity).Div(track.peak).InexactFloat64()
+		if drawdown >= limits.MaxDrawdown {
+			return fmt.Errorf("已达到最大回撤限制: %.2f%% >= %.2f%%", drawdown*100, limits.MaxDrawdown*100)
+		}
+	}
+
+	return nil
+}
+
+// ValidateTrade 验证交易风险
+func (rm *RiskManager) ValidateTrade(order Order, accountBalance decimal.Decimal, currentPositions map[string]Position) error {
+	// 检查单笔仓位大小
+	positionValue := rm.multipliers.notional(order.Symbol, order.Quantity, order.Price)
+	maxValue := accountBalance.Mul(decimal.NewFromFloat(rm.maxPositionSize))
+	if positionValue.GreaterThan(maxValue) {
+		return fmt.Errorf("单笔仓位过大: %s > %s", positionValue.StringFixed(2), maxValue.StringFixed(2))
+	}
+
+	// 检查总仓位
+	totalPositionValue := positionValue
+	for _, position := range currentPositions {
+		totalPositionValue = totalPositionValue.Add(position.MarketValue)
+	}
+
+	if totalPositionValue.GreaterThan(accountBalance) {
+		return fmt.Errorf("总仓位超过账户余额")
+	}
+
+	log.Printf("交易风险验证通过: 单笔仓位=%s, 总仓位=%s", positionValue.StringFixed(2), totalPositionValue.StringFixed(2))
+	return nil
+}
+
+// CalculatePositionSize 计算仓位大小
+func (rm *RiskManager) CalculatePositionSize(accountBalance, riskAmount, stopLossDistance float64) float64 {
+	if stopLossDistance <= 0 {
+		return 0
+	}
+
+	positionSize := riskAmount / stopLossDistance
+
+	// 限制最大仓位
+	maxPosition := accountBalance * rm.maxPositionSize / riskAmount
+	if positionSize > maxPosition {
+		positionSize = maxPosition
+	}
+
+	return positionSize
+}
@@ -0,0 +1,67 @@
+package trading
+
+import (
+	"fmt"
+	"log"
+
+	"agent-quant-system/internal/config"
+	"agent-quant-system/internal/money"
+	"agent-quant-system/internal/sizing"
+	"agent-quant-system/internal/strategy"
+)
+
+// newSizingTable 按配置创建各策略的仓位计算方式，配置无效时使用策略给出的数量
+func newSizingTable(cfg *config.Config) *sizing.Table {
+	table, err := sizing.NewTable(cfg)
+	if err != nil {
+		log.Printf("仓位计算配置无效，使用策略给出的数量: %v", err)
+		return nil
+	}
+	return table
+}
+
+// ReloadSizing 按当前的 risk.sizing 和 strategy.sizing 重新创建仓位计算方式
+func (te *TradingEngine) ReloadSizing() {
+	te.sizing.Store(newSizingTable(te.currentConfig()))
+}
+
+// sizeSignal 按策略的仓位计算方式（risk.sizing / strategy.sizing）重新计算开仓信号的数量，
+// 使用账户的最新权益和策略在盈亏账本中的平仓统计；平仓信号、卖出信号和未配置方式的策略原样返回
+func (te *TradingEngine) sizeSignal(signal strategy.TradingSignal, accountName string) (strategy.TradingSignal, error) {
+	policy := te.sizing.Load().For(signal.Strategy)
+	if policy == nil || signal.Signal != strategy.Buy || signal.ClosePercent > 0 {
+		return signal, nil
+	}
+
+	broker, err := te.GetBroker(accountName)
+	if err != nil {
+		return signal, fmt.Errorf("获取经纪商失败: %w", err)
+	}
+	equity, err := accountEquity(broker)
+	if err != nil {
+		return signal, fmt.Errorf("获取账户权益失败: %w", err)
+	}
+	price := signal.Price
+	if price <= 0 && te.prices != nil {
+		if price, err = te.prices.GetLatestPrice(signal.Symbol); err != nil {
+			return signal, fmt.Errorf("获取 %s 价格失败: %w", signal.Symbol, err)
+		}
+	}
+
+	request := sizing.Request{
+		Equity:     money.Float(equity),
+		Price:      price,
+		StopLoss:   signal.StopLoss,
+		Quantity:   signal.Quantity,
+		Multiplier: money.Float(te.instrumentRegistry().Lookup(signal.Symbol).Multiplier),
+		Stats:      te.pnl.TradeStats(signal.Strategy),
+	}
+	quantity := policy.Quantity(request)
+	if quantity <= 0 {
+		return signal, fmt.Errorf("仓位计算(%s)结果为0，不开仓: 策略=%s, 标的=%s", policy.Name(), signal.Strategy, signal.Symbol)
+	}
+	log.Printf("仓位计算(%s): 策略=%s, 标的=%s, 权益=%.2f, 信号数量=%.4f, 开仓数量=%.4f",
+		policy.Name(), signal.Strategy, signal.Symbol, request.Equity, signal.Quantity, quantity)
+	signal.Quantity = quantity
+	return signal, nil
+}
@@ -0,0 +1,282 @@
+package trading
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"agent-quant-system/internal/money"
+
+	"github.com/shopspring/decimal"
+)
+
+// SnapshotSchemaVersion 持仓快照格式版本，字段含义变化时递增
+const SnapshotSchemaVersion = 1
+
+// snapshotCSVHeader CSV 快照的列，每个持仓一行，没有持仓的账户输出一行空持仓
+var snapshotCSVHeader = []string{
+	"schema_version", "time", "account", "broker_type", "currency", "balance", "equity",
+	"symbol", "quantity", "average_price", "market_price", "market_value", "unrealized_pnl", "realized_pnl",
+}
+
+// PositionSnapshot 全部账户的持仓和余额快照，供外部风控、合规系统读取。
+//
+// JSON 格式：
+//
+//	{"schema_version": 1, "time": RFC3339, "accounts": [AccountSnapshot...], "errors": {"账户": "原因"}}
+//
+// CSV 格式的列见 snapshotCSVHeader，金额和数量均为十进制字符串，币种为账户计价币种
+type PositionSnapshot struct {
+	SchemaVersion int               `json:"schema_version"`
+	Time          time.Time         `json:"time"`
+	Accounts      []AccountSnapshot `json:"accounts"`
+	Errors        map[string]string `json:"errors,omitempty"` // 无法获取状态的账户及原因
+}
+
+// AccountSnapshot 单个账户的快照，余额和持仓以经纪商返回的为准
+type AccountSnapshot struct {
+	Account    string             `json:"account"`
+	BrokerType string             `json:"broker_type"`
+	Currency   string             `json:"currency"`
+	Balance    decimal.Decimal    `json:"balance"`
+	Equity     decimal.Decimal    `json:"equity"` // 余额加持仓市值
+	Positions  []SnapshotPosition `json:"positions"`
+}
+
+// SnapshotPosition 单个持仓，市场价格取实时价格，取不到时按经纪商的持仓市值推算
+type SnapshotPosition struct {
+	Symbol        string          `json:"symbol"`
+	Quantity      decimal.Decimal `json:"quantity"` // 空头为负数
+	AveragePrice  decimal.Decimal `json:"average_price"`
+	MarketPrice   decimal.Decimal `json:"market_price"`
+	MarketValue   decimal.Decimal `json:"market_value"`
+	UnrealizedPnL decimal.Decimal `json:"unrealized_pnl"`
+	RealizedPnL   decimal.Decimal `json:"realized_pnl"`
+}
+
+// Snapshot 生成全部账户的持仓和余额快照
+func (te *TradingEngine) Snapshot() *PositionSnapshot {
+	te.mutex.RLock()
+	brokers := make(map[string]BrokerAPI, len(te.brokers))
+	for name, broker := range te.brokers {
+		brokers[name] = broker
+	}
+	te.mutex.RUnlock()
+
+	names := make([]string, 0, len(brokers))
+	for name := range brokers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	snapshot := &PositionSnapshot{SchemaVersion: SnapshotSchemaVersion, Time: time.Now()}
+	for _, name := range names {
+		account, err := te.snapshotAccount(name, brokers[name])
+		if err != nil {
+			if snapshot.Errors == nil {
+				snapshot.Errors = make(map[string]string)
+			}
+			snapshot.Errors[name] = err.Error()
+			continue
+		}
+		snapshot.Accounts = append(snapshot.Accounts, *account)
+	}
+	return snapshot
+}
+
+// snapshotAccount 获取单个账户的快照
+func (te *TradingEngine) snapshotAccount(accountName string, broker BrokerAPI) (*AccountSnapshot, error) {
+	balance, err := broker.GetBalance()
+	if err != nil {
+		return nil, fmt.Errorf("获取余额失败: %w", err)
+	}
+	positions, err := broker.GetPositions()
+	if err != nil {
+		return nil, fmt.Errorf("获取持仓失败: %w", err)
+	}
+
+	accountConfig := te.currentConfig().Accounts[accountName]
+	currency := accountConfig.Currency
+	if currency == "" {
+		currency = "USD"
+	}
+	snapshot := &AccountSnapshot{
+		Account:    accountName,
+		BrokerType: accountConfig.BrokerType,
+		Currency:   currency,
+		Balance:    balance,
+		Equity:     balance,
+		Positions:  make([]SnapshotPosition, 0, len(positions)),
+	}
+
+	symbols := make([]string, 0, len(positions))
+	for symbol, position := range positions {
+		if !position.Quantity.IsZero() {
+			symbols = append(symbols, symbol)
+		}
+	}
+	sort.Strings(symbols)
+
+	for _, symbol := range symbols {
+		position := positions[symbol]
+		item := SnapshotPosition{
+			Symbol:        symbol,
+			Quantity:      position.Quantity,
+			AveragePrice:  position.AvgPrice,
+			MarketValue:   position.MarketValue,
+			UnrealizedPnL: position.UnrealizedPL,
+			RealizedPnL:   position.RealizedPL,
+		}
+		if price, err := te.latestPrice(symbol); err == nil && price > 0 {
+			item.MarketPrice = money.FromFloat(price)
+			item.MarketValue = te.instruments.notional(symbol, position.Quantity, item.MarketPrice)
+			item.UnrealizedPnL = te.instruments.notional(symbol, position.Quantity, item.MarketPrice.Sub(position.AvgPrice))
+		} else if !position.Quantity.IsZero() {
+			item.MarketPrice = position.MarketValue.Div(te.instruments.notional(symbol, position.Quantity, decimal.NewFromInt(1))).Abs()
+		}
+		snapshot.Equity = snapshot.Equity.Add(item.MarketValue)
+		snapshot.Positions = append(snapshot.Positions, item)
+	}
+	return snapshot, nil
+}
+
+// WriteJSON 以 JSON 格式输出快照
+func (s *PositionSnapshot) WriteJSON(path string) error {
+	content, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化持仓快照失败: %w", err)
+	}
+	return writeSnapshotFile(path, content)
+}
+
+// WriteCSV 以 CSV 格式输出快照
+func (s *PositionSnapshot) WriteCSV(path string) error {
+	content, err := s.csv()
+	if err != nil {
+		return err
+	}
+	return writeSnapshotFile(path, content)
+}
+
+// csv CSV 格式的快照内容
+func (s *PositionSnapshot) csv() ([]byte, error) {
+	var buffer bytes.Buffer
+	writer := csv.NewWriter(&buffer)
+	if err := writer.Write(snapshotCSVHeader); err != nil {
+		return nil, fmt.Errorf("写入CSV失败: %w", err)
+	}
+
+	timestamp := s.Time.Format(time.RFC3339)
+	for _, account := range s.Accounts {
+		prefix := []string{
+			fmt.Sprint(s.SchemaVersion), timestamp, account.Account, account.BrokerType, account.Currency,
+			account.Balance.String(), account.Equity.String(),
+		}
+		if len(account.Positions) == 0 {
+			if err := writer.Write(append(prefix, "", "", "", "", "", "", "")); err != nil {
+				return nil, fmt.Errorf("写入CSV失败: %w", err)
+			}
+			continue
+		}
+		for _, position := range account.Positions {
+			row := append(append([]string{}, prefix...),
+				position.Symbol, position.Quantity.String(), position.AveragePrice.String(),
+				position.MarketPrice.String(), position.MarketValue.String(),
+				position.UnrealizedPnL.String(), position.RealizedPnL.String())
+			if err := writer.Write(row); err != nil {
+				return nil, fmt.Errorf("写入CSV失败: %w", err)
+			}
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, fmt.Errorf("写入CSV失败: %w", err)
+	}
+	return buffer.Bytes(), nil
+}
+
+// writeSnapshotFile 先写临时文件再重命名，避免外部系统读到写了一半的快照
+func writeSnapshotFile(path string, content []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("创建快照目录失败: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, content, 0644); err != nil {
+		return fmt.Errorf("写入持仓快照失败: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("写入持仓快照失败: %w", err)
+	}
+	return nil
+}
+
+// pushSnapshot 以 JSON 格式 POST 快照到外部系统
+func pushSnapshot(url string, timeout time.Duration, snapshot *PositionSnapshot) error {
+	content, err := json.Marshal(snapshot)
+	if err != nil {
+		return fmt.Errorf("序列化持仓快照失败: %w", err)
+	}
+
+	client := &http.Client{Timeout: timeout}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(content))
+	if err != nil {
+		return fmt.Errorf("推送持仓快照失败: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("推送持仓快照失败，状态码: %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// ExportSnapshot 按配置将快照写入文件（最新快照和按时间命名的历史快照）并推送到外部系统
+func (te *TradingEngine) ExportSnapshot() (*PositionSnapshot, error) {
+	cfg := te.currentConfig().Trading.Snapshot
+	snapshot := te.Snapshot()
+
+	if cfg.Dir != "" {
+		name := "positions_" + snapshot.Time.Format("20060102_150405") + "." + cfg.Format
+		latest := filepath.Join(cfg.Dir, "positions_latest."+cfg.Format)
+		for _, path := range []string{filepath.Join(cfg.Dir, name), latest} {
+			var err error
+			if cfg.Format == "csv" {
+				err = snapshot.WriteCSV(path)
+			} else {
+				err = snapshot.WriteJSON(path)
+			}
+			if err != nil {
+				return snapshot, err
+			}
+		}
+	}
+	if cfg.URL != "" {
+		if err := pushSnapshot(cfg.URL, cfg.Timeout, snapshot); err != nil {
+			return snapshot, err
+		}
+	}
+	return snapshot, nil
+}
+
+// runSnapshotExport 定期导出持仓快照
+func (te *TradingEngine) runSnapshotExport(stop <-chan struct{}) {
+	ticker := time.NewTicker(te.currentConfig().Trading.Snapshot.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if _, err := te.ExportSnapshot(); err != nil {
+				log.Printf("导出持仓快照失败: %v", err)
+			}
+		}
+	}
+}
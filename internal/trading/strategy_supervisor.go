@@ -0,0 +1,283 @@
+package trading
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"agent-quant-system/internal/config"
+	"agent-quant-system/internal/notify"
+	"agent-quant-system/internal/strategy"
+)
+
+// ErrStrategyDisabled 策略已被策略监控停用，需显式恢复后才能开仓
+var ErrStrategyDisabled = errors.New("策略已停用")
+
+// DisableTrigger 策略被停用的原因
+type DisableTrigger string
+
+const (
+	DisableDrawdown          DisableTrigger = "drawdown"           // 回撤达到上限
+	DisableConsecutiveLosses DisableTrigger = "consecutive_losses" // 连续亏损达到上限
+)
+
+// DisabledStrategy 被停用的策略
+type DisabledStrategy struct {
+	Strategy string         `json:"strategy"`
+	Trigger  DisableTrigger `json:"trigger"`
+	Reason   string         `json:"reason"`
+	Since    time.Time      `json:"since"`
+}
+
+// supervisorTrack 策略监控对单个策略的统计基准
+type supervisorTrack struct {
+	Peak     float64 `json:"peak"`      // 开始监控或恢复以来累计盈亏的最高值
+	LossBase int     `json:"loss_base"` // 恢复时已有的连续亏损次数，不计入本次监控
+	Reset    bool    `json:"reset,omitempty"`
+}
+
+// supervisorState 策略监控的状态文件内容
+type supervisorState struct {
+	Disabled map[string]DisabledStrategy `json:"disabled"`
+	Tracks   map[string]*supervisorTrack `json:"tracks"`
+}
+
+// StrategySupervisor 策略监控：按盈亏账本的实盘表现停用回撤或连续亏损超限的策略，
+// 状态写入文件以便其他进程（如命令行）恢复正在运行的引擎中的策略，重启后仍保持停用
+type StrategySupervisor struct {
+	stateFile    string
+	state        supervisorState
+	stateModTime time.Time
+	mutex        sync.Mutex
+}
+
+// NewStrategySupervisor 创建策略监控并加载已保存的状态
+func NewStrategySupervisor(stateFile string) *StrategySupervisor {
+	ss := &StrategySupervisor{stateFile: stateFile}
+	ss.state = supervisorState{Disabled: make(map[string]DisabledStrategy), Tracks: make(map[string]*supervisorTrack)}
+	ss.refresh()
+	for _, disabled := range ss.state.Disabled {
+		log.Printf("策略 '%s' 处于停用状态（%s），需恢复后才能开仓: %s", disabled.Strategy, disabled.Since.Format("2006-01-02 15:04:05"), disabled.Reason)
+	}
+	return ss
+}
+
+// refresh 状态文件被其他进程修改时重新加载，调用方需持有锁或处于初始化阶段
+func (ss *StrategySupervisor) refresh() {
+	if ss.stateFile == "" {
+		return
+	}
+	info, err := os.Stat(ss.stateFile)
+	if err != nil || info.ModTime().Equal(ss.stateModTime) {
+		return
+	}
+
+	content, err := os.ReadFile(ss.stateFile)
+	if err != nil {
+		log.Printf("读取策略监控状态失败: %v", err)
+		return
+	}
+	var state supervisorState
+	if err := json.Unmarshal(content, &state); err != nil {
+		log.Printf("解析策略监控状态失败: %v", err)
+		return
+	}
+	if state.Disabled == nil {
+		state.Disabled = make(map[string]DisabledStrategy)
+	}
+	if state.Tracks == nil {
+		state.Tracks = make(map[string]*supervisorTrack)
+	}
+	ss.state = state
+	ss.stateModTime = info.ModTime()
+}
+
+// save 写入状态文件，调用方需持有锁
+func (ss *StrategySupervisor) save() {
+	if ss.stateFile == "" {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(ss.stateFile), 0755); err != nil {
+		log.Printf("创建策略监控状态目录失败: %v", err)
+		return
+	}
+	content, err := json.MarshalIndent(ss.state, "", "  ")
+	if err != nil {
+		log.Printf("序列化策略监控状态失败: %v", err)
+		return
+	}
+	if err := os.WriteFile(ss.stateFile, content, 0644); err != nil {
+		log.Printf("写入策略监控状态失败: %v", err)
+		return
+	}
+	if info, err := os.Stat(ss.stateFile); err == nil {
+		ss.stateModTime = info.ModTime()
+	}
+}
+
+// Check 按各策略的实盘表现更新统计基准，返回本次新停用的策略
+func (ss *StrategySupervisor) Check(cfg config.StrategySupervisorConfig, performance map[string]*strategy.StrategyPerformance) []DisabledStrategy {
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+	ss.refresh()
+
+	names := make([]string, 0, len(performance))
+	for name := range performance {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var disabled []DisabledStrategy
+	changed := false
+	for _, name := range names {
+		p := performance[name]
+		track, exists := ss.state.Tracks[name]
+		if !exists {
+			track = &supervisorTrack{Peak: p.PeakPnL}
+			ss.state.Tracks[name] = track
+			changed = true
+		}
+		if track.Reset {
+			*track = supervisorTrack{Peak: p.TotalPnL, LossBase: p.ConsecutiveLosses}
+			changed = true
+		}
+		// 恢复后出现过盈利的平仓，连续亏损已重新计数
+		if p.ConsecutiveLosses < track.LossBase {
+			track.LossBase = 0
+			changed = true
+		}
+		if p.TotalPnL > track.Peak {
+			track.Peak = p.TotalPnL
+			changed = true
+		}
+		if _, exists := ss.state.Disabled[name]; exists {
+			continue
+		}
+
+		drawdown := track.Peak - p.TotalPnL
+		losses := p.ConsecutiveLosses - track.LossBase
+		entry := DisabledStrategy{Strategy: name, Since: time.Now()}
+		switch {
+		case cfg.MaxDrawdown > 0 && drawdown >= cfg.MaxDrawdown:
+			entry.Trigger = DisableDrawdown
+			entry.Reason = fmt.Sprintf("回撤 %.2f >= %.2f（最高 %.2f，当前 %.2f）", drawdown, cfg.MaxDrawdown, track.Peak, p.TotalPnL)
+		case cfg.MaxConsecutiveLosses > 0 && losses >= cfg.MaxConsecutiveLosses:
+			entry.Trigger = DisableConsecutiveLosses
+			entry.Reason = fmt.Sprintf("连续亏损 %d 次 >= %d", losses, cfg.MaxConsecutiveLosses)
+		default:
+			continue
+		}
+		ss.state.Disabled[name] = entry
+		disabled = append(disabled, entry)
+		changed = true
+	}
+	if changed {
+		ss.save()
+	}
+	return disabled
+}
+
+// Enable 恢复被停用的策略，回撤和连续亏损从恢复时重新计算；策略未被停用时返回 false
+func (ss *StrategySupervisor) Enable(strategyName string) (DisabledStrategy, bool) {
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+	ss.refresh()
+
+	entry, exists := ss.state.Disabled[strategyName]
+	if !exists {
+		return DisabledStrategy{}, false
+	}
+	delete(ss.state.Disabled, strategyName)
+	ss.state.Tracks[strategyName] = &supervisorTrack{Reset: true}
+	ss.save()
+	return entry, true
+}
+
+// Disabled 策略是否被停用
+func (ss *StrategySupervisor) Disabled(strategyName string) (DisabledStrategy, bool) {
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+	ss.refresh()
+
+	entry, exists := ss.state.Disabled[strategyName]
+	return entry, exists
+}
+
+// List 被停用的策略，按策略名排序
+func (ss *StrategySupervisor) List() []DisabledStrategy {
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+	ss.refresh()
+
+	list := make([]DisabledStrategy, 0, len(ss.state.Disabled))
+	for _, entry := range ss.state.Disabled {
+		list = append(list, entry)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Strategy < list[j].Strategy })
+	return list
+}
+
+// checkStrategyEnabled 被停用的策略不能开仓或加仓（含开空单），按盈亏账本中该策略的持仓
+// 只执行不超过持仓数量的反向订单，即减仓和平仓
+func (te *TradingEngine) checkStrategyEnabled(order Order, accountName string) error {
+	entry, disabled := te.supervisor.Disabled(order.Strategy)
+	if !disabled {
+		return nil
+	}
+
+	// 按账本中的写法查询持仓，已到期合约的订单由 ExecuteTrade 拒绝
+	order.Symbol = te.symbols.Normalize(order.Symbol)
+	_ = te.resolveContract(&order)
+	position := te.pnl.Position(accountName, order.Strategy, order.Symbol)
+	reduces := (order.Side == SellSide && position.IsPositive()) || (order.Side == BuySide && position.IsNegative())
+	if reduces && order.Quantity.LessThanOrEqual(position.Abs()) {
+		return nil
+	}
+	return fmt.Errorf("%w: 策略=%s, 原因=%s, 只能减仓或平仓（持仓 %s，订单 %s %s）",
+		ErrStrategyDisabled, order.Strategy, entry.Reason, position, order.Side, order.Quantity)
+}
+
+// SuperviseStrategies 按盈亏报告检查各策略的回撤和连续亏损，超限时停用策略并发送通知；
+// 有持仓获取价格失败的策略未实现盈亏不完整，本次不检查
+func (te *TradingEngine) SuperviseStrategies(report *PnLReport) {
+	cfg := te.currentConfig().Risk.StrategySupervisor
+	if !cfg.Enabled || report == nil {
+		return
+	}
+	performance := te.pnl.Performance(report)
+	for name := range incompleteStrategies(report) {
+		delete(performance, name)
+	}
+	for _, entry := range te.supervisor.Check(cfg, performance) {
+		log.Printf("策略 '%s' 已停用: %s", entry.Strategy, entry.Reason)
+		te.notifier.Notifyf(notify.EventStrategy, "策略已停用",
+			"策略=%s, 触发=%s, 原因=%s\n恢复前该策略只能减仓或平仓", entry.Strategy, entry.Trigger, entry.Reason)
+	}
+}
+
+// EnableStrategy 恢复被策略监控停用的策略
+func (te *TradingEngine) EnableStrategy(strategyName string) error {
+	entry, ok := te.supervisor.Enable(strategyName)
+	if !ok {
+		return fmt.Errorf("策略 '%s' 未被停用", strategyName)
+	}
+	log.Printf("策略 '%s' 已恢复", strategyName)
+	te.notifier.Notifyf(notify.EventStrategy, "策略已恢复", "策略=%s, 停用原因=%s", strategyName, entry.Reason)
+	return nil
+}
+
+// StrategyDisabled 策略是否被策略监控停用
+func (te *TradingEngine) StrategyDisabled(strategyName string) (DisabledStrategy, bool) {
+	return te.supervisor.Disabled(strategyName)
+}
+
+// DisabledStrategies 被策略监控停用的策略
+func (te *TradingEngine) DisabledStrategies() []DisabledStrategy {
+	return te.supervisor.List()
+}
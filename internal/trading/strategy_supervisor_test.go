@@ -0,0 +1,66 @@
+package trading
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"agent-quant-system/internal/strategy"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestDisabledStrategyOnlyReducesPosition(t *testing.T) {
+	engine := newTestEngine(t, "stock")
+	engine.supervisor.state.Disabled["trend"] = DisabledStrategy{Strategy: "trend", Trigger: DisableDrawdown, Reason: "回撤超限", Since: time.Now()}
+
+	tests := []struct {
+		name     string
+		position int64 // 账本中该策略的持仓，空头为负数
+		side     OrderSide
+		quantity int64
+		allowed  bool
+	}{
+		{"无持仓买入开多", 0, BuySide, 1, false},
+		{"无持仓卖出开空", 0, SellSide, 1, false},
+		{"多头加仓", 10, BuySide, 1, false},
+		{"多头减仓", 10, SellSide, 5, true},
+		{"多头平仓", 10, SellSide, 10, true},
+		{"多头反手开空", 10, SellSide, 15, false},
+		{"空头加仓", -10, SellSide, 1, false},
+		{"空头平仓", -10, BuySide, 10, true},
+		{"空头反手开多", -10, BuySide, 11, false},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			symbol := string(rune('A'+i)) + "XYZ"
+			if tt.position > 0 {
+				engine.pnl.Apply("test", "trend", symbol, BuySide, decimal.NewFromInt(tt.position), decimal.NewFromInt(100), decimal.Zero)
+			} else if tt.position < 0 {
+				engine.pnl.Apply("test", "trend", symbol, SellSide, decimal.NewFromInt(-tt.position), decimal.NewFromInt(100), decimal.Zero)
+			}
+
+			order := Order{Symbol: symbol, Side: tt.side, Type: MarketOrder, Quantity: decimal.NewFromInt(tt.quantity), Strategy: "trend"}
+			err := engine.checkStrategyEnabled(order, "test")
+			if tt.allowed && err != nil {
+				t.Fatalf("持仓 %d 时 %s %d 应允许执行: %v", tt.position, tt.side, tt.quantity, err)
+			}
+			if !tt.allowed && !errors.Is(err, ErrStrategyDisabled) {
+				t.Fatalf("持仓 %d 时 %s %d 应被拒绝, 实际 err = %v", tt.position, tt.side, tt.quantity, err)
+			}
+		})
+	}
+}
+
+func TestDisabledStrategyCannotOpenShort(t *testing.T) {
+	engine := newTestEngine(t, "stock")
+	engine.supervisor.state.Disabled["trend"] = DisabledStrategy{Strategy: "trend", Trigger: DisableDrawdown, Reason: "回撤超限", Since: time.Now()}
+
+	signal := strategy.TradingSignal{Symbol: "aapl", Signal: strategy.Sell, Quantity: 10, Price: 100, Strategy: "trend"}
+	if _, err := engine.ExecuteSignal(signal, "test"); !errors.Is(err, ErrStrategyDisabled) {
+		t.Fatalf("停用策略的开空信号 err = %v, 期望 ErrStrategyDisabled", err)
+	}
+	if _, err := engine.SubmitSignal(signal, "test"); !errors.Is(err, ErrStrategyDisabled) {
+		t.Fatalf("停用策略异步提交的开空信号 err = %v, 期望 ErrStrategyDisabled", err)
+	}
+}
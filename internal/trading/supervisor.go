@@ -0,0 +1,326 @@
+package trading
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"agent-quant-system/internal/config"
+	"agent-quant-system/internal/notify"
+)
+
+// supervisorTick 连接监控的检查粒度，健康检查和重连按各自的时间表执行
+const supervisorTick = time.Second
+
+// ConnectionState 经纪商连接状态
+type ConnectionState string
+
+const (
+	ConnectionConnected    ConnectionState = "connected"
+	ConnectionReconnecting ConnectionState = "reconnecting" // 连接中断，正在按退避间隔重连
+	ConnectionDisconnected ConnectionState = "disconnected" // 交易引擎未运行，不自动重连
+)
+
+// HealthChecker 提供专门健康检查接口的经纪商（可选接口），未实现时以查询余额检查连接
+type HealthChecker interface {
+	Ping() error
+}
+
+// ConnectionStatus 单个经纪商的连接状态
+type ConnectionStatus struct {
+	State      ConnectionState `json:"state"`
+	Since      time.Time       `json:"since"` // 进入当前状态的时间
+	LastCheck  time.Time       `json:"last_check,omitempty"`
+	LastError  string          `json:"last_error,omitempty"`
+	Failures   int             `json:"failures,omitempty"`   // 本次中断以来的重连失败次数
+	NextRetry  time.Time       `json:"next_retry,omitempty"` // 下次重连时间
+	Reconnects int             `json:"reconnects"`           // 启动以来成功重连的次数
+}
+
+// brokerConnection 经纪商连接的监控记录
+type brokerConnection struct {
+	status    ConnectionStatus
+	recovered chan struct{} // 恢复连接时关闭，等待中的订单据此继续
+}
+
+// ConnectionSupervisor 跟踪各经纪商的连接状态：定期健康检查，断线后按指数退避重连
+type ConnectionSupervisor struct {
+	config      config.ConnectionConfig
+	connections map[string]*brokerConnection
+	mutex       sync.Mutex
+}
+
+// NewConnectionSupervisor 创建连接监控
+func NewConnectionSupervisor(cfg config.ConnectionConfig) *ConnectionSupervisor {
+	return &ConnectionSupervisor{config: cfg, connections: make(map[string]*brokerConnection)}
+}
+
+// connection 获取连接记录，不存在时按未连接创建，调用方需持有锁
+func (cs *ConnectionSupervisor) connection(name string) *brokerConnection {
+	conn, exists := cs.connections[name]
+	if !exists {
+		conn = &brokerConnection{
+			status:    ConnectionStatus{State: ConnectionDisconnected, Since: time.Now()},
+			recovered: make(chan struct{}),
+		}
+		cs.connections[name] = conn
+	}
+	return conn
+}
+
+// markConnected 记录连接正常，返回之前是否处于中断状态
+func (cs *ConnectionSupervisor) markConnected(name string) bool {
+	cs.mutex.Lock()
+	defer cs.mutex.Unlock()
+
+	conn := cs.connection(name)
+	conn.status.LastCheck = time.Now()
+	if conn.status.State == ConnectionConnected {
+		return false
+	}
+	wasDown := conn.status.State == ConnectionReconnecting
+	if wasDown {
+		conn.status.Reconnects++
+	}
+	conn.status.State = ConnectionConnected
+	conn.status.Since = time.Now()
+	conn.status.LastError = ""
+	conn.status.Failures = 0
+	conn.status.NextRetry = time.Time{}
+	close(conn.recovered)
+	return wasDown
+}
+
+// markDown 记录连接中断并安排重连，返回之前是否处于连接状态
+func (cs *ConnectionSupervisor) markDown(name string, err error) bool {
+	cs.mutex.Lock()
+	defer cs.mutex.Unlock()
+
+	conn := cs.connection(name)
+	now := time.Now()
+	conn.status.LastCheck = now
+	conn.status.LastError = err.Error()
+	wasUp := conn.status.State == ConnectionConnected
+	if conn.status.State != ConnectionReconnecting {
+		conn.status.State = ConnectionReconnecting
+		conn.status.Since = now
+		conn.status.Failures = 0
+		conn.status.NextRetry = now
+	}
+	if wasUp {
+		conn.recovered = make(chan struct{})
+	}
+	return wasUp
+}
+
+// retryFailed 记录一次重连失败并按指数退避安排下次重连
+func (cs *ConnectionSupervisor) retryFailed(name string, err error) time.Duration {
+	cs.mutex.Lock()
+	defer cs.mutex.Unlock()
+
+	conn := cs.connection(name)
+	conn.status.Failures++
+	conn.status.LastCheck = time.Now()
+	conn.status.LastError = err.Error()
+
+	backoff := cs.config.InitialBackoff
+	for i := 1; i < conn.status.Failures && backoff < cs.config.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > cs.config.MaxBackoff {
+		backoff = cs.config.MaxBackoff
+	}
+	conn.status.NextRetry = time.Now().Add(backoff)
+	return backoff
+}
+
+// markStopped 交易引擎停止后标记为未连接，不再自动重连
+func (cs *ConnectionSupervisor) markStopped(name string) {
+	cs.mutex.Lock()
+	defer cs.mutex.Unlock()
+
+	conn := cs.connection(name)
+	if conn.status.State == ConnectionConnected {
+		conn.recovered = make(chan struct{})
+	}
+	conn.status.State = ConnectionDisconnected
+	conn.status.Since = time.Now()
+	conn.status.NextRetry = time.Time{}
+}
+
+// available 经纪商是否可以接收请求，只有正在重连的经纪商不可用
+func (cs *ConnectionSupervisor) available(name string) bool {
+	cs.mutex.Lock()
+	defer cs.mutex.Unlock()
+	return cs.connection(name).status.State != ConnectionReconnecting
+}
+
+// wait 等待经纪商恢复连接，超时返回 false
+func (cs *ConnectionSupervisor) wait(name string, timeout time.Duration) bool {
+	cs.mutex.Lock()
+	conn := cs.connection(name)
+	if conn.status.State == ConnectionConnected {
+		cs.mutex.Unlock()
+		return true
+	}
+	recovered := conn.recovered
+	cs.mutex.Unlock()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-recovered:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+// due 返回需要健康检查和需要重连的经纪商
+func (cs *ConnectionSupervisor) due(now time.Time) (checks, reconnects []string) {
+	cs.mutex.Lock()
+	defer cs.mutex.Unlock()
+
+	for name, conn := range cs.connections {
+		switch conn.status.State {
+		case ConnectionConnected:
+			if now.Sub(conn.status.LastCheck) >= cs.config.HealthInterval {
+				checks = append(checks, name)
+			}
+		case ConnectionReconnecting:
+			if !now.Before(conn.status.NextRetry) {
+				reconnects = append(reconnects, name)
+			}
+		}
+	}
+	sort.Strings(checks)
+	sort.Strings(reconnects)
+	return checks, reconnects
+}
+
+// Status 获取经纪商的连接状态
+func (cs *ConnectionSupervisor) Status(name string) ConnectionStatus {
+	cs.mutex.Lock()
+	defer cs.mutex.Unlock()
+	return cs.connection(name).status
+}
+
+// pingBroker 检查经纪商连接
+func pingBroker(broker BrokerAPI) error {
+	if _, ok := baseBroker(broker).(HealthChecker); ok {
+		return broker.(HealthChecker).Ping()
+	}
+	_, err := broker.GetBalance()
+	return err
+}
+
+// runConnectionSupervisor 定期检查经纪商连接并重连中断的经纪商，直到 stop 关闭
+func (te *TradingEngine) runConnectionSupervisor(stop <-chan struct{}) {
+	ticker := time.NewTicker(supervisorTick)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			checks, reconnects := te.connections.due(now)
+			for _, name := range checks {
+				te.checkConnection(name)
+			}
+			for _, name := range reconnects {
+				te.reconnectBroker(name)
+			}
+		}
+	}
+}
+
+// rawBroker 获取经纪商实例，不检查连接状态
+func (te *TradingEngine) rawBroker(accountName string) (BrokerAPI, bool) {
+	te.mutex.RLock()
+	defer te.mutex.RUnlock()
+	broker, exists := te.brokers[accountName]
+	return broker, exists
+}
+
+// checkConnection 健康检查，失败时标记连接中断
+func (te *TradingEngine) checkConnection(accountName string) {
+	broker, exists := te.rawBroker(accountName)
+	if !exists {
+		return
+	}
+	if err := pingBroker(broker); err != nil {
+		te.connectionLost(accountName, fmt.Errorf("健康检查失败: %w", err))
+		return
+	}
+	te.connections.markConnected(accountName)
+}
+
+// connectionLost 标记经纪商连接中断，监控会按退避间隔重连
+func (te *TradingEngine) connectionLost(accountName string, err error) {
+	if !te.connections.markDown(accountName, err) {
+		return
+	}
+	log.Printf("经纪商 %s 连接中断，开始自动重连: %v", accountName, err)
+	te.notifier.Notifyf(notify.EventBroker, fmt.Sprintf("经纪商连接中断 %s", accountName),
+		"原因: %v\n断线期间的订单按 trading.connection.outage_policy=%s 处理", err, te.currentConfig().Trading.Connection.OutagePolicy)
+}
+
+// reconnectBroker 断开并重新连接经纪商，连接后经健康检查确认
+func (te *TradingEngine) reconnectBroker(accountName string) {
+	broker, exists := te.rawBroker(accountName)
+	if !exists {
+		return
+	}
+
+	if err := broker.Disconnect(); err != nil {
+		log.Printf("断开经纪商 %s 失败: %v", accountName, err)
+	}
+	err := broker.Connect()
+	if err == nil {
+		err = pingBroker(broker)
+	}
+	if err != nil {
+		backoff := te.connections.retryFailed(accountName, err)
+		status := te.connections.Status(accountName)
+		log.Printf("重连经纪商 %s 失败（第 %d 次），%v 后重试: %v", accountName, status.Failures, backoff, err)
+		return
+	}
+
+	if !te.connections.markConnected(accountName) {
+		return
+	}
+	status := te.connections.Status(accountName)
+	log.Printf("经纪商 %s 已恢复连接（累计重连 %d 次）", accountName, status.Reconnects)
+	te.notifier.Notifyf(notify.EventBroker, fmt.Sprintf("经纪商已恢复连接 %s", accountName),
+		"累计重连 %d 次", status.Reconnects)
+	if err := te.SyncAccount(accountName); err != nil {
+		log.Printf("同步账户 '%s' 失败: %v", accountName, err)
+	}
+}
+
+// awaitBroker 经纪商连接中断时按 outage_policy 处理订单：reject 立即拒绝，
+// queue 等待恢复连接（最长 queue_timeout），交易引擎未运行时不等待
+func (te *TradingEngine) awaitBroker(accountName string) error {
+	if _, exists := te.rawBroker(accountName); !exists || te.connections.available(accountName) {
+		return nil
+	}
+
+	cfg := te.currentConfig().Trading.Connection
+	if cfg.OutagePolicy == "queue" && te.IsRunning() {
+		log.Printf("经纪商 %s 连接中断，订单等待恢复连接（最长 %v）", accountName, cfg.QueueTimeout)
+		if te.connections.wait(accountName, cfg.QueueTimeout) {
+			return nil
+		}
+		return fmt.Errorf("经纪商 '%s' 在 %v 内未恢复连接: %w", accountName, cfg.QueueTimeout, ErrBrokerUnavailable)
+	}
+	return fmt.Errorf("经纪商 '%s' 连接中断: %w", accountName, ErrBrokerUnavailable)
+}
+
+// isConnectionError 是否为连接类错误
+func isConnectionError(err error) bool {
+	return errors.Is(err, ErrBrokerUnavailable)
+}
@@ -0,0 +1,253 @@
+package trading
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+
+	"agent-quant-system/internal/config"
+)
+
+// ErrSymbolRestricted 标的被交易名单禁止
+var ErrSymbolRestricted = errors.New("标的受交易名单限制")
+
+// SymbolListKind 交易名单类型
+type SymbolListKind string
+
+const (
+	Blacklist SymbolListKind = "blacklist" // 禁止交易
+	Whitelist SymbolListKind = "whitelist" // 非空时只允许交易名单内的标的
+)
+
+// SymbolList 一组黑白名单
+type SymbolList struct {
+	Blacklist []string `json:"blacklist"`
+	Whitelist []string `json:"whitelist"`
+}
+
+// SymbolListsStatus 全局和各账户的交易名单
+type SymbolListsStatus struct {
+	Global   SymbolList            `json:"global"`
+	Accounts map[string]SymbolList `json:"accounts,omitempty"`
+}
+
+// symbolSet 单组黑白名单
+type symbolSet struct {
+	blacklist map[string]bool
+	whitelist map[string]bool
+}
+
+// newSymbolSet 按配置创建名单
+func newSymbolSet(blacklist, whitelist []string) *symbolSet {
+	set := &symbolSet{blacklist: make(map[string]bool), whitelist: make(map[string]bool)}
+	for _, symbol := range blacklist {
+		set.blacklist[normalizeSymbol(symbol)] = true
+	}
+	for _, symbol := range whitelist {
+		set.whitelist[normalizeSymbol(symbol)] = true
+	}
+	return set
+}
+
+// list 名单类型对应的集合
+func (s *symbolSet) list(kind SymbolListKind) map[string]bool {
+	if kind == Blacklist {
+		return s.blacklist
+	}
+	return s.whitelist
+}
+
+// check 检查标的是否被这组名单禁止
+func (s *symbolSet) check(symbol string) error {
+	if s.blacklist[symbol] {
+		return fmt.Errorf("%w: %s 在黑名单中", ErrSymbolRestricted, symbol)
+	}
+	if len(s.whitelist) > 0 && !s.whitelist[symbol] {
+		return fmt.Errorf("%w: %s 不在白名单中", ErrSymbolRestricted, symbol)
+	}
+	return nil
+}
+
+// snapshot 按字母排序的名单
+func (s *symbolSet) snapshot() SymbolList {
+	return SymbolList{Blacklist: sortedSymbols(s.blacklist), Whitelist: sortedSymbols(s.whitelist)}
+}
+
+// symbolListEdit 一次运行时的名单修改
+type symbolListEdit struct {
+	kind        SymbolListKind
+	accountName string
+	add         []string
+	remove      []string
+}
+
+// SymbolLists 交易名单：全局名单对所有账户生效，账户名单在其基础上额外限制
+// （任一黑名单包含即禁止，任一非空白名单不包含即禁止）
+type SymbolLists struct {
+	global   *symbolSet
+	accounts map[string]*symbolSet
+	edits    []symbolListEdit // 运行时通过 Update 做的修改，热加载配置后按顺序重新应用
+	mutex    sync.RWMutex
+}
+
+// NewSymbolLists 按风控配置创建交易名单
+func NewSymbolLists(cfg config.RiskConfig) *SymbolLists {
+	lists := &SymbolLists{
+		global:   newSymbolSet(cfg.Blacklist, cfg.Whitelist),
+		accounts: make(map[string]*symbolSet, len(cfg.AccountSymbols)),
+	}
+	for name, accountLists := range cfg.AccountSymbols {
+		lists.accounts[name] = newSymbolSet(accountLists.Blacklist, accountLists.Whitelist)
+	}
+	return lists
+}
+
+// Reset 按风控配置重建全部名单（热加载配置时使用），再按顺序重新应用运行时通过 Update 做的修改
+func (sl *SymbolLists) Reset(cfg config.RiskConfig) {
+	next := NewSymbolLists(cfg)
+
+	sl.mutex.Lock()
+	defer sl.mutex.Unlock()
+	for _, edit := range sl.edits {
+		next.apply(edit)
+	}
+	sl.global = next.global
+	sl.accounts = next.accounts
+	if len(sl.edits) > 0 {
+		log.Printf("交易名单已按配置重建，并重新应用 %d 次运行时修改", len(sl.edits))
+	}
+}
+
+// Check 检查账户是否允许交易标的
+func (sl *SymbolLists) Check(accountName, symbol string) error {
+	symbol = normalizeSymbol(symbol)
+
+	sl.mutex.RLock()
+	defer sl.mutex.RUnlock()
+
+	if err := sl.global.check(symbol); err != nil {
+		return err
+	}
+	if set, exists := sl.accounts[accountName]; exists {
+		if err := set.check(symbol); err != nil {
+			return fmt.Errorf("账户 '%s': %w", accountName, err)
+		}
+	}
+	return nil
+}
+
+// Update 运行时修改名单，accountName 为空时修改全局名单
+func (sl *SymbolLists) Update(kind SymbolListKind, accountName string, add, remove []string) error {
+	if kind != Blacklist && kind != Whitelist {
+		return fmt.Errorf("未知的名单类型: %s", kind)
+	}
+
+	sl.mutex.Lock()
+	defer sl.mutex.Unlock()
+
+	edit := symbolListEdit{kind: kind, accountName: accountName, add: add, remove: remove}
+	sl.apply(edit)
+	sl.edits = append(sl.edits, edit)
+
+	scope := "全局"
+	if accountName != "" {
+		scope = "账户 " + accountName
+	}
+	log.Printf("已更新交易名单: 范围=%s, 类型=%s, 添加=%v, 移除=%v", scope, kind, add, remove)
+	return nil
+}
+
+// apply 应用一次名单修改，调用方需持有写锁
+func (sl *SymbolLists) apply(edit symbolListEdit) {
+	set := sl.global
+	if edit.accountName != "" {
+		var exists bool
+		if set, exists = sl.accounts[edit.accountName]; !exists {
+			set = newSymbolSet(nil, nil)
+			sl.accounts[edit.accountName] = set
+		}
+	}
+
+	list := set.list(edit.kind)
+	for _, symbol := range edit.add {
+		list[normalizeSymbol(symbol)] = true
+	}
+	for _, symbol := range edit.remove {
+		delete(list, normalizeSymbol(symbol))
+	}
+}
+
+// GetStatus 获取当前交易名单
+func (sl *SymbolLists) GetStatus() SymbolListsStatus {
+	sl.mutex.RLock()
+	defer sl.mutex.RUnlock()
+
+	status := SymbolListsStatus{Global: sl.global.snapshot()}
+	for name, set := range sl.accounts {
+		if len(set.blacklist) == 0 && len(set.whitelist) == 0 {
+			continue
+		}
+		if status.Accounts == nil {
+			status.Accounts = make(map[string]SymbolList)
+		}
+		status.Accounts[name] = set.snapshot()
+	}
+	return status
+}
+
+// checkSymbolLists 检查订单是否违反交易名单；受限标的仍允许减少现有持仓的订单，以便退出
+func (te *TradingEngine) checkSymbolLists(broker BrokerAPI, order Order, accountName string) error {
+	restriction := te.symbolLists.Check(accountName, order.Symbol)
+	if restriction == nil {
+		return nil
+	}
+
+	positions, err := broker.GetPositions()
+	if err != nil {
+		return fmt.Errorf("获取持仓失败: %w", err)
+	}
+
+	held := positions[order.Symbol].Quantity
+	if order.Side == BuySide {
+		held = held.Neg()
+	}
+	if held.GreaterThanOrEqual(order.Quantity) {
+		log.Printf("标的受交易名单限制，允许减仓订单: 账户=%s, 标的=%s, 方向=%s, 数量=%s",
+			accountName, order.Symbol, order.Side, order.Quantity)
+		return nil
+	}
+	return restriction
+}
+
+// UpdateSymbolList 运行时修改交易名单，accountName 为空时修改全局名单
+func (te *TradingEngine) UpdateSymbolList(kind SymbolListKind, accountName string, add, remove []string) error {
+	if accountName != "" {
+		if _, exists := te.currentConfig().Accounts[accountName]; !exists {
+			return fmt.Errorf("账户 '%s' 不存在", accountName)
+		}
+	}
+	return te.symbolLists.Update(kind, accountName, add, remove)
+}
+
+// GetSymbolLists 获取当前交易名单
+func (te *TradingEngine) GetSymbolLists() SymbolListsStatus {
+	return te.symbolLists.GetStatus()
+}
+
+// normalizeSymbol 标的名称统一为大写
+func normalizeSymbol(symbol string) string {
+	return strings.ToUpper(strings.TrimSpace(symbol))
+}
+
+// sortedSymbols 排序后的标的列表
+func sortedSymbols(set map[string]bool) []string {
+	symbols := make([]string, 0, len(set))
+	for symbol := range set {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+	return symbols
+}
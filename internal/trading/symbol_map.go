@@ -0,0 +1,165 @@
+package trading
+
+import (
+	"fmt"
+
+	"agent-quant-system/internal/symbols"
+
+	"github.com/shopspring/decimal"
+)
+
+// symbolMappedBroker 按经纪商写法转换标的的经纪商：下单和查询时把规范写法转换为经纪商的写法，
+// 回报的订单、持仓和成交转换回规范写法。与频率限制相同，调用方需先用 baseBroker 判断原经纪商支持的可选接口
+type symbolMappedBroker struct {
+	BrokerAPI
+	mapper *symbols.Mapper
+	venue  string
+}
+
+// Symbols 标的代码转换服务，数据管理器使用同一份配置转换数据源请求
+func (te *TradingEngine) Symbols() *symbols.Mapper {
+	return te.symbols
+}
+
+// brokerSymbol 标的在账户经纪商中的写法，未配置转换时原样返回
+func (te *TradingEngine) brokerSymbol(accountName, symbol string) string {
+	return te.symbols.ToVenue(te.symbolVenue(accountName), symbol)
+}
+
+// canonicalSymbol 经纪商回报的标的对应的规范写法
+func (te *TradingEngine) canonicalSymbol(accountName, symbol string) string {
+	return te.symbols.FromVenue(te.symbolVenue(accountName), symbol)
+}
+
+// symbolVenue 账户使用的标的写法名称：账户名称优先于经纪商类型，纸面交易不转换
+func (te *TradingEngine) symbolVenue(accountName string) string {
+	if te.currentConfig().Trading.Paper {
+		return ""
+	}
+	return te.symbols.Venue(accountName, te.currentConfig().Accounts[accountName].BrokerType)
+}
+
+// order 转换订单的标的
+func (b *symbolMappedBroker) order(order *Order, symbol string) *Order {
+	if order == nil {
+		return nil
+	}
+	mapped := *order
+	if symbol == "" {
+		symbol = b.mapper.FromVenue(b.venue, order.Symbol)
+	}
+	mapped.Symbol = symbol
+	return &mapped
+}
+
+// PlaceOrder 下单，返回的订单沿用调用方的标的写法
+func (b *symbolMappedBroker) PlaceOrder(order Order) (*Order, error) {
+	symbol := order.Symbol
+	order.Symbol = b.mapper.ToVenue(b.venue, symbol)
+	placed, err := b.BrokerAPI.PlaceOrder(order)
+	return b.order(placed, symbol), err
+}
+
+// GetOrder 查询订单
+func (b *symbolMappedBroker) GetOrder(orderID string) (*Order, error) {
+	order, err := b.BrokerAPI.GetOrder(orderID)
+	return b.order(order, ""), err
+}
+
+// GetOrders 查询订单列表
+func (b *symbolMappedBroker) GetOrders(symbol string, status OrderStatus) ([]Order, error) {
+	if symbol != "" {
+		symbol = b.mapper.ToVenue(b.venue, symbol)
+	}
+	orders, err := b.BrokerAPI.GetOrders(symbol, status)
+	for i := range orders {
+		orders[i].Symbol = b.mapper.FromVenue(b.venue, orders[i].Symbol)
+	}
+	return orders, err
+}
+
+// GetPositions 获取持仓，按规范写法汇总
+func (b *symbolMappedBroker) GetPositions() (map[string]Position, error) {
+	positions, err := b.BrokerAPI.GetPositions()
+	if err != nil {
+		return nil, err
+	}
+	mapped := make(map[string]Position, len(positions))
+	for _, position := range positions {
+		position.Symbol = b.mapper.FromVenue(b.venue, position.Symbol)
+		if existing, exists := mapped[position.Symbol]; exists {
+			// 经纪商的两种写法映射到同一标的时合并数量和市值
+			position = mergePositions(existing, position)
+		}
+		mapped[position.Symbol] = position
+	}
+	return mapped, nil
+}
+
+// mergePositions 合并同一标的的两条持仓，均价按数量加权
+func mergePositions(a, b Position) Position {
+	quantity := a.Quantity.Add(b.Quantity)
+	merged := a
+	merged.Quantity = quantity
+	merged.MarketValue = a.MarketValue.Add(b.MarketValue)
+	merged.UnrealizedPL = a.UnrealizedPL.Add(b.UnrealizedPL)
+	merged.RealizedPL = a.RealizedPL.Add(b.RealizedPL)
+	merged.AvgPrice = decimal.Zero
+	if !quantity.IsZero() {
+		merged.AvgPrice = a.AvgPrice.Mul(a.Quantity).Add(b.AvgPrice.Mul(b.Quantity)).Div(quantity)
+	}
+	if b.UpdateTime.After(a.UpdateTime) {
+		merged.UpdateTime = b.UpdateTime
+	}
+	return merged
+}
+
+// GetTrades 获取成交记录
+func (b *symbolMappedBroker) GetTrades(symbol string, limit int) ([]Trade, error) {
+	if symbol != "" {
+		symbol = b.mapper.ToVenue(b.venue, symbol)
+	}
+	trades, err := b.BrokerAPI.GetTrades(symbol, limit)
+	for i := range trades {
+		trades[i].Symbol = b.mapper.FromVenue(b.venue, trades[i].Symbol)
+	}
+	return trades, err
+}
+
+// GetOrderByClientID 按客户端订单号查询订单
+func (b *symbolMappedBroker) GetOrderByClientID(clientOrderID string) (*Order, error) {
+	lookup, ok := b.BrokerAPI.(ClientOrderLookup)
+	if !ok {
+		return nil, fmt.Errorf("经纪商不支持按客户端订单号查询")
+	}
+	order, err := lookup.GetOrderByClientID(clientOrderID)
+	return b.order(order, ""), err
+}
+
+// ExpireOrder 将订单标记为过期
+func (b *symbolMappedBroker) ExpireOrder(orderID string) error {
+	expirer, ok := b.BrokerAPI.(OrderExpirer)
+	if !ok {
+		return b.CancelOrder(orderID)
+	}
+	return expirer.ExpireOrder(orderID)
+}
+
+// ReplaceOrder 改单
+func (b *symbolMappedBroker) ReplaceOrder(orderID string, price, quantity decimal.Decimal) (*Order, error) {
+	replacer, ok := b.BrokerAPI.(OrderReplacer)
+	if !ok {
+		return nil, fmt.Errorf("经纪商不支持改单")
+	}
+	order, err := replacer.ReplaceOrder(orderID, price, quantity)
+	return b.order(order, ""), err
+}
+
+// Ping 健康检查
+func (b *symbolMappedBroker) Ping() error {
+	checker, ok := b.BrokerAPI.(HealthChecker)
+	if !ok {
+		return fmt.Errorf("经纪商不支持健康检查")
+	}
+	return checker.Ping()
+}
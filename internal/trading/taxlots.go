@@ -0,0 +1,322 @@
+package trading
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"agent-quant-system/internal/config"
+	"agent-quant-system/internal/instrument"
+
+	"github.com/shopspring/decimal"
+)
+
+// 平仓时选择税务批次的方法
+const (
+	LotFIFO        = "fifo"         // 先进先出
+	LotLIFO        = "lifo"         // 后进先出
+	LotHighestCost = "highest_cost" // 多头先平成本最高的批次，空头先平卖出价最低的批次，使已实现收益最小
+)
+
+// 损益期限
+const (
+	TermShort = "short"
+	TermLong  = "long"
+)
+
+// TaxLot 一笔开仓成交形成的税务批次，数量为正表示多头，为负表示空头（卖空）
+type TaxLot struct {
+	ID        string          `json:"id"`
+	Account   string          `json:"account"`
+	Symbol    string          `json:"symbol"`
+	OrderID   string          `json:"order_id,omitempty"` // 开仓订单ID
+	Opened    time.Time       `json:"opened"`
+	Quantity  decimal.Decimal `json:"quantity"`   // 剩余数量
+	CostBasis decimal.Decimal `json:"cost_basis"` // 每单位成本（多头含买入手续费）或卖出所得（空头扣除卖出手续费）
+}
+
+// RealizedGain 平掉（部分）批次的已实现损益
+type RealizedGain struct {
+	Account   string          `json:"account"`
+	Symbol    string          `json:"symbol"`
+	LotID     string          `json:"lot_id"`
+	OrderID   string          `json:"order_id,omitempty"` // 平仓订单ID
+	Quantity  decimal.Decimal `json:"quantity"`           // 平仓数量，平空头批次为负数
+	Opened    time.Time       `json:"opened"`
+	Closed    time.Time       `json:"closed"`
+	Proceeds  decimal.Decimal `json:"proceeds"`   // 卖出所得，已扣除手续费
+	CostBasis decimal.Decimal `json:"cost_basis"` // 买入成本，含手续费
+	Gain      decimal.Decimal `json:"gain"`
+	Term      string          `json:"term"` // short / long
+}
+
+// GainsSummary 已实现损益合计
+type GainsSummary struct {
+	Proceeds  decimal.Decimal `json:"proceeds"`
+	CostBasis decimal.Decimal `json:"cost_basis"`
+	ShortTerm decimal.Decimal `json:"short_term"`
+	LongTerm  decimal.Decimal `json:"long_term"`
+	Total     decimal.Decimal `json:"total"`
+}
+
+// taxLotKey 税务批次按账户和标的分开
+type taxLotKey struct {
+	account string
+	symbol  string
+}
+
+// TaxLotBook 税务批次账本：按成交逐笔建立批次，平仓时按账户的方法选择批次并记录已实现损益
+type TaxLotBook struct {
+	config   config.TaxLotConfig
+	lots     map[taxLotKey][]*TaxLot // 按开仓先后排列
+	gains    []RealizedGain
+	sequence int
+
+	// 成本和所得按合约乘数计算
+	multipliers *contractMultipliers
+	mutex       sync.Mutex
+}
+
+// NewTaxLotBook 创建税务批次账本
+func NewTaxLotBook(cfg config.TaxLotConfig) *TaxLotBook {
+	if cfg.Method == "" {
+		cfg.Method = LotFIFO
+	}
+	if cfg.LongTermDays <= 0 {
+		cfg.LongTermDays = 365
+	}
+	return &TaxLotBook{config: cfg, lots: make(map[taxLotKey][]*TaxLot)}
+}
+
+// BuildTaxLots 按成交流水文件重建税务批次账本，期货、期权的成本和所得按 instruments 中的合约乘数计算
+func BuildTaxLots(journalFile string, cfg config.TaxLotConfig, instruments *instrument.Registry) (*TaxLotBook, error) {
+	entries, err := ReadJournal(journalFile, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	book := NewTaxLotBook(cfg)
+	book.multipliers = &contractMultipliers{}
+	book.multipliers.registry.Store(instruments)
+	for _, entry := range entries {
+		book.Apply(entry)
+	}
+	return book, nil
+}
+
+// Method 账户使用的批次选择方法
+func (b *TaxLotBook) Method(accountName string) string {
+	if method, ok := b.config.AccountMethods[accountName]; ok {
+		return method
+	}
+	return b.config.Method
+}
+
+// Apply 记入一笔成交流水：先按方法平掉反向批次，剩余数量作为新批次开仓；手续费按数量分摊到平仓和开仓部分。
+// 资金费用等非成交流水不影响批次
+func (b *TaxLotBook) Apply(entry JournalEntry) {
+	if entry.Kind != JournalTrade || !entry.Quantity.IsPositive() {
+		return
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	key := taxLotKey{account: entry.Account, symbol: entry.Symbol}
+	unitValue := b.multipliers.notional(entry.Symbol, decimal.NewFromInt(1), entry.Price) // 每单位数量的金额
+	commissionPerUnit := entry.Commission.Div(entry.Quantity)
+	remaining := entry.Quantity
+	buying := entry.Side == BuySide
+	method := b.Method(entry.Account)
+
+	for remaining.IsPositive() {
+		index := b.selectLot(b.lots[key], buying, method)
+		if index < 0 {
+			break
+		}
+		lot := b.lots[key][index]
+		matched := decimal.Min(lot.Quantity.Abs(), remaining)
+		commission := commissionPerUnit.Mul(matched)
+
+		gain := RealizedGain{
+			Account: entry.Account,
+			Symbol:  entry.Symbol,
+			LotID:   lot.ID,
+			OrderID: entry.OrderID,
+			Opened:  lot.Opened,
+			Closed:  entry.Time,
+			Term:    TermShort,
+		}
+		if buying {
+			// 买入平空：批次记录的是卖出所得
+			gain.Quantity = matched.Neg()
+			gain.Proceeds = lot.CostBasis.Mul(matched)
+			gain.CostBasis = unitValue.Mul(matched).Add(commission)
+			lot.Quantity = lot.Quantity.Add(matched)
+		} else {
+			gain.Quantity = matched
+			gain.Proceeds = unitValue.Mul(matched).Sub(commission)
+			gain.CostBasis = lot.CostBasis.Mul(matched)
+			lot.Quantity = lot.Quantity.Sub(matched)
+			if entry.Time.After(lot.Opened.AddDate(0, 0, b.config.LongTermDays)) {
+				gain.Term = TermLong
+			}
+		}
+		gain.Proceeds = gain.Proceeds.Round(8)
+		gain.CostBasis = gain.CostBasis.Round(8)
+		gain.Gain = gain.Proceeds.Sub(gain.CostBasis)
+		b.gains = append(b.gains, gain)
+
+		remaining = remaining.Sub(matched)
+		if lot.Quantity.IsZero() {
+			b.lots[key] = append(b.lots[key][:index], b.lots[key][index+1:]...)
+		}
+	}
+	if !remaining.IsPositive() {
+		return
+	}
+
+	b.sequence++
+	lot := &TaxLot{
+		ID:       fmt.Sprintf("LOT-%06d", b.sequence),
+		Account:  entry.Account,
+		Symbol:   entry.Symbol,
+		OrderID:  entry.OrderID,
+		Opened:   entry.Time,
+		Quantity: remaining,
+	}
+	if buying {
+		lot.CostBasis = unitValue.Add(commissionPerUnit).Round(8)
+	} else {
+		lot.Quantity = remaining.Neg()
+		lot.CostBasis = unitValue.Sub(commissionPerUnit).Round(8)
+	}
+	b.lots[key] = append(b.lots[key], lot)
+}
+
+// selectLot 按方法选择被平掉的反向批次：买入平空头批次，卖出平多头批次，没有反向批次时返回 -1
+func (b *TaxLotBook) selectLot(lots []*TaxLot, buying bool, method string) int {
+	selected := -1
+	for i, lot := range lots {
+		if buying != lot.Quantity.IsNegative() {
+			continue
+		}
+		switch {
+		case selected < 0:
+			selected = i
+		case method == LotLIFO:
+			selected = i
+		case method == LotHighestCost:
+			// 多头成本越高、空头卖出所得越低，平仓的已实现收益越小
+			best := lots[selected].CostBasis
+			if (!buying && lot.CostBasis.GreaterThan(best)) || (buying && lot.CostBasis.LessThan(best)) {
+				selected = i
+			}
+		}
+		if selected >= 0 && method == LotFIFO {
+			break
+		}
+	}
+	return selected
+}
+
+// OpenLots 未平仓的批次，accountName 为空时返回全部账户；按账户、标的和开仓时间排序
+func (b *TaxLotBook) OpenLots(accountName string) []TaxLot {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	var lots []TaxLot
+	for key, entries := range b.lots {
+		if accountName != "" && key.account != accountName {
+			continue
+		}
+		for _, lot := range entries {
+			lots = append(lots, *lot)
+		}
+	}
+	sort.SliceStable(lots, func(i, j int) bool {
+		if lots[i].Account != lots[j].Account {
+			return lots[i].Account < lots[j].Account
+		}
+		if lots[i].Symbol != lots[j].Symbol {
+			return lots[i].Symbol < lots[j].Symbol
+		}
+		return lots[i].Opened.Before(lots[j].Opened)
+	})
+	return lots
+}
+
+// Gains 平仓时间在 [from, to) 内的已实现损益（零值表示不限），accountName 为空时返回全部账户；按平仓时间排序
+func (b *TaxLotBook) Gains(from, to time.Time, accountName string) []RealizedGain {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	var gains []RealizedGain
+	for _, gain := range b.gains {
+		if accountName != "" && gain.Account != accountName {
+			continue
+		}
+		if (!from.IsZero() && gain.Closed.Before(from)) || (!to.IsZero() && !gain.Closed.Before(to)) {
+			continue
+		}
+		gains = append(gains, gain)
+	}
+	return gains
+}
+
+// SummarizeGains 按短期和长期汇总已实现损益
+func SummarizeGains(gains []RealizedGain) GainsSummary {
+	var summary GainsSummary
+	for _, gain := range gains {
+		summary.Proceeds = summary.Proceeds.Add(gain.Proceeds)
+		summary.CostBasis = summary.CostBasis.Add(gain.CostBasis)
+		if gain.Term == TermLong {
+			summary.LongTerm = summary.LongTerm.Add(gain.Gain)
+		} else {
+			summary.ShortTerm = summary.ShortTerm.Add(gain.Gain)
+		}
+	}
+	summary.Total = summary.ShortTerm.Add(summary.LongTerm)
+	return summary
+}
+
+// gainsCSVHeader 已实现损益CSV的列
+var gainsCSVHeader = []string{
+	"account", "symbol", "quantity", "date_acquired", "date_sold", "proceeds", "cost_basis", "gain", "term", "lot_id", "order_id",
+}
+
+// WriteGainsCSV 以 CSV 格式输出已实现损益，每行为一次平仓的一个批次，时间为 RFC3339
+func WriteGainsCSV(w io.Writer, gains []RealizedGain) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(gainsCSVHeader); err != nil {
+		return fmt.Errorf("写入CSV失败: %w", err)
+	}
+	for _, gain := range gains {
+		row := []string{
+			gain.Account, gain.Symbol, gain.Quantity.String(),
+			gain.Opened.Format(time.RFC3339), gain.Closed.Format(time.RFC3339),
+			gain.Proceeds.StringFixed(2), gain.CostBasis.StringFixed(2), gain.Gain.StringFixed(2),
+			gain.Term, gain.LotID, gain.OrderID,
+		}
+		if err := writer.Write(row); err != nil {
+			return fmt.Errorf("写入CSV失败: %w", err)
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("写入CSV失败: %w", err)
+	}
+	return nil
+}
+
+// TaxLots 获取未平仓的税务批次，accountName 为空时返回全部账户
+func (te *TradingEngine) TaxLots(accountName string) []TaxLot {
+	return te.taxLots.OpenLots(accountName)
+}
+
+// RealizedGains 获取 [from, to) 内的已实现损益
+func (te *TradingEngine) RealizedGains(from, to time.Time, accountName string) []RealizedGain {
+	return te.taxLots.Gains(from, to, accountName)
+}
@@ -0,0 +1,145 @@
+package trading
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"agent-quant-system/internal/config"
+	"agent-quant-system/internal/money"
+
+	"github.com/shopspring/decimal"
+)
+
+// ErrOrderThrottled 订单超过下单频率限制
+var ErrOrderThrottled = errors.New("超过下单频率限制")
+
+// throttleEvent 时间窗口内的一次下单
+type throttleEvent struct {
+	id       uint64
+	time     time.Time
+	symbol   string
+	notional decimal.Decimal
+}
+
+// ThrottleStats 频率限制统计
+type ThrottleStats struct {
+	Allowed   int `json:"allowed"`
+	Throttled int `json:"throttled"`
+}
+
+// OrderThrottle 按账户和标的限制下单频率及名义金额（滑动时间窗口）
+type OrderThrottle struct {
+	config      config.ThrottleConfig
+	events      map[string][]throttleEvent // 账户 -> 窗口内的下单记录
+	nextID      uint64
+	stats       ThrottleStats
+	multipliers *contractMultipliers // 名义金额按合约乘数计算
+	mutex       sync.Mutex
+}
+
+// NewOrderThrottle 创建下单频率限制
+func NewOrderThrottle(cfg config.ThrottleConfig) *OrderThrottle {
+	return &OrderThrottle{
+		config: cfg,
+		events: make(map[string][]throttleEvent),
+	}
+}
+
+// Reserve 检查订单是否超限，未超限时占用额度；下单失败时调用返回的 release 归还额度
+func (t *OrderThrottle) Reserve(order Order, accountName string) (release func(), err error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	now := time.Now()
+	events := t.prune(accountName, now)
+	notional := t.multipliers.orderNotional(order)
+
+	if err := t.check(events, order.Symbol, notional, now); err != nil {
+		t.stats.Throttled++
+		return nil, fmt.Errorf("%w: %v", ErrOrderThrottled, err)
+	}
+
+	t.nextID++
+	id := t.nextID
+	t.events[accountName] = append(events, throttleEvent{id: id, time: now, symbol: order.Symbol, notional: notional})
+	t.stats.Allowed++
+
+	return func() { t.release(accountName, id) }, nil
+}
+
+// check 判断新订单是否超过任一限制
+func (t *OrderThrottle) check(events []throttleEvent, symbol string, notional decimal.Decimal, now time.Time) error {
+	var symbolOrders, accountOrders int
+	windowNotional := notional
+	for _, event := range events {
+		if event.symbol == symbol && now.Sub(event.time) < t.config.SymbolWindow {
+			symbolOrders++
+		}
+		if now.Sub(event.time) < t.config.AccountWindow {
+			accountOrders++
+		}
+		if now.Sub(event.time) < t.config.NotionalWindow {
+			windowNotional = windowNotional.Add(event.notional)
+		}
+	}
+
+	if t.config.MaxOrdersPerSymbol > 0 && symbolOrders >= t.config.MaxOrdersPerSymbol {
+		return fmt.Errorf("标的 %s 在 %v 内已下单 %d 次", symbol, t.config.SymbolWindow, symbolOrders)
+	}
+	if t.config.MaxOrdersPerAccount > 0 && accountOrders >= t.config.MaxOrdersPerAccount {
+		return fmt.Errorf("账户在 %v 内已下单 %d 次", t.config.AccountWindow, accountOrders)
+	}
+	if t.config.MaxNotionalPerWindow > 0 {
+		limit := money.FromFloat(t.config.MaxNotionalPerWindow)
+		if windowNotional.GreaterThan(limit) {
+			return fmt.Errorf("账户在 %v 内的名义金额 %s 将超过上限 %s",
+				t.config.NotionalWindow, windowNotional.StringFixed(2), limit.StringFixed(2))
+		}
+	}
+	return nil
+}
+
+// prune 移除所有窗口之外的记录
+func (t *OrderThrottle) prune(accountName string, now time.Time) []throttleEvent {
+	longest := t.config.SymbolWindow
+	if t.config.AccountWindow > longest {
+		longest = t.config.AccountWindow
+	}
+	if t.config.NotionalWindow > longest {
+		longest = t.config.NotionalWindow
+	}
+
+	events := t.events[accountName]
+	kept := events[:0]
+	for _, event := range events {
+		if now.Sub(event.time) < longest {
+			kept = append(kept, event)
+		}
+	}
+	t.events[accountName] = kept
+	return kept
+}
+
+// release 归还未成功下单的额度
+func (t *OrderThrottle) release(accountName string, id uint64) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	events := t.events[accountName]
+	for i, event := range events {
+		if event.id == id {
+			t.events[accountName] = append(events[:i], events[i+1:]...)
+			t.stats.Allowed--
+			return
+		}
+	}
+}
+
+// GetStats 获取频率限制统计
+func (t *OrderThrottle) GetStats() ThrottleStats {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.stats
+}
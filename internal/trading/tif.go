@@ -0,0 +1,100 @@
+package trading
+
+import (
+	"log"
+	"time"
+)
+
+// nextMarketClose 计算 now 之后的下一个收盘时间
+func nextMarketClose(now time.Time, marketClose string, location *time.Location) (time.Time, error) {
+	clock, err := time.Parse("15:04", marketClose)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	local := now.In(location)
+	next := time.Date(local.Year(), local.Month(), local.Day(), clock.Hour(), clock.Minute(), 0, 0, location)
+	if !next.After(local) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next, nil
+}
+
+// runDayOrderExpiry 每天收盘时撤销未成交的 DAY 订单，直到 stop 关闭
+func (te *TradingEngine) runDayOrderExpiry(stop <-chan struct{}) {
+	location, err := time.LoadLocation(te.currentConfig().Trading.MarketTimezone)
+	if err != nil {
+		log.Printf("加载收盘时区失败，DAY 订单不会自动撤销: %v", err)
+		return
+	}
+
+	for {
+		next, err := nextMarketClose(time.Now(), te.currentConfig().Trading.MarketClose, location)
+		if err != nil {
+			log.Printf("解析收盘时间失败，DAY 订单不会自动撤销: %v", err)
+			return
+		}
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+			te.ExpireDayOrders(next)
+		}
+	}
+}
+
+// ExpireDayOrders 撤销所有在收盘时间之前提交、仍未成交的 DAY 订单，返回撤销数量
+func (te *TradingEngine) ExpireDayOrders(marketClose time.Time) int {
+	te.mutex.RLock()
+	brokers := make(map[string]BrokerAPI, len(te.brokers))
+	for name, broker := range te.brokers {
+		brokers[name] = broker
+	}
+	te.mutex.RUnlock()
+
+	expired := 0
+	for accountName, broker := range brokers {
+		var orders []Order
+		for _, status := range OpenOrderStatuses {
+			open, err := broker.GetOrders("", status)
+			if err != nil {
+				log.Printf("查询账户 '%s' 的挂单失败，跳过 DAY 订单撤销: %v", accountName, err)
+				orders = nil
+				break
+			}
+			orders = append(orders, open...)
+		}
+
+		for _, order := range orders {
+			if order.TimeInForce != DAY || order.CreateTime.After(marketClose) {
+				continue
+			}
+			if te.dryRunCancel(order, accountName, "DAY 订单收盘未成交") {
+				continue
+			}
+			// 支持过期状态的经纪商标记为 Expired，否则撤单
+			var err error
+			if _, ok := baseBroker(broker).(OrderExpirer); ok {
+				err = broker.(OrderExpirer).ExpireOrder(order.ID)
+			} else {
+				err = broker.CancelOrder(order.ID)
+			}
+			te.auditCancel(order, accountName, "DAY 订单收盘未成交", err)
+			if err != nil {
+				log.Printf("撤销过期 DAY 订单失败: 账户=%s, 订单ID=%s, 错误=%v", accountName, order.ID, err)
+				continue
+			}
+			te.releaseReservations(order.ID)
+			log.Printf("DAY 订单收盘未成交，已撤销: 账户=%s, 订单ID=%s, 标的=%s, 已成交=%s", accountName, order.ID, order.Symbol, order.FilledQty)
+			expired++
+		}
+	}
+
+	if expired > 0 {
+		log.Printf("收盘撤销 %d 个 DAY 订单", expired)
+	}
+	return expired
+}
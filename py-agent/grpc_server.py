@@ -0,0 +1,157 @@
+"""
+Agent Quant System - gRPC 服务
+与 REST 接口提供相同的分析能力，协议定义见 ../proto/agent.proto（运行时加载，无需生成代码）
+"""
+
+import asyncio
+import logging
+import os
+import sys
+import time
+from typing import Awaitable, Callable, List
+
+from google.protobuf.message import DecodeError
+
+import grpc
+
+import security
+
+logger = logging.getLogger(__name__)
+
+# 加载协议定义
+PROTO_DIR = os.getenv("PROTO_DIR", os.path.join(os.path.dirname(os.path.abspath(__file__)), "..", "proto"))
+sys.path.append(PROTO_DIR)
+agent_pb2, agent_pb2_grpc = grpc.protos_and_services("agent.proto")
+SERVICE_NAME = "agent.v1.AgentService"
+
+SENTIMENTS = {
+    "positive": agent_pb2.SENTIMENT_POSITIVE,
+    "negative": agent_pb2.SENTIMENT_NEGATIVE,
+    "neutral": agent_pb2.SENTIMENT_NEUTRAL,
+}
+
+# 流式分析时推送进度的间隔（秒），需小于客户端的 agent_service.timeout
+PROGRESS_INTERVAL = float(os.getenv("GRPC_PROGRESS_INTERVAL", 2))
+
+
+def to_proto(result) -> "agent_pb2.NewsAnalysisResponse":
+    """转换分析结果，无法识别的情绪按中性处理"""
+    response = agent_pb2.NewsAnalysisResponse(
+        symbol=result.symbol,
+        sentiment=SENTIMENTS.get(result.sentiment.strip().lower(), agent_pb2.SENTIMENT_NEUTRAL),
+        reason=result.reason,
+        confidence_score=min(1.0, max(0.0, result.confidence_score)),
+    )
+    response.timestamp.GetCurrentTime()
+    return response
+
+
+class AgentService(agent_pb2_grpc.AgentServiceServicer):
+    """新闻情绪分析服务"""
+
+    def __init__(self, analyze: Callable[[str, List[str]], Awaitable], version: str, start_time: float):
+        self.analyze = analyze
+        self.version = version
+        self.start_time = start_time
+
+    async def authorize(self, method: str, raw: bytes, request_type, context):
+        """按收到的原始请求字节校验签名后再解析请求消息，不依赖重新序列化的结果与 Go 客户端一致"""
+        metadata = dict(context.invocation_metadata())
+        try:
+            security.verify(metadata.get, "POST", f"/{SERVICE_NAME}/{method}", raw)
+        except security.AuthError as e:
+            logger.warning(f"拒绝未通过认证的gRPC请求: {method}, {e}")
+            await context.abort(grpc.StatusCode.UNAUTHENTICATED, str(e))
+        try:
+            return request_type.FromString(raw)
+        except DecodeError as e:
+            await context.abort(grpc.StatusCode.INVALID_ARGUMENT, f"无法解析请求消息: {e}")
+
+    async def _validate(self, request, context):
+        if not request.symbol:
+            await context.abort(grpc.StatusCode.INVALID_ARGUMENT, "股票代码不能为空")
+        if not request.news_items:
+            await context.abort(grpc.StatusCode.INVALID_ARGUMENT, "新闻列表不能为空")
+
+    def _run(self, request) -> asyncio.Future:
+        # real_analyze_news 内部同步调用 OpenAI，放到线程中执行，分析期间事件循环仍可推送进度
+        loop = asyncio.get_running_loop()
+        return loop.run_in_executor(None, lambda: asyncio.run(self.analyze(request.symbol, list(request.news_items))))
+
+    async def AnalyzeNews(self, request, context):
+        logger.info(f"收到gRPC分析请求: 标的={request.symbol}, 新闻数量={len(request.news_items)}")
+        await self._validate(request, context)
+        try:
+            result = await self._run(request)
+        except Exception as e:
+            logger.error(f"gRPC分析请求处理失败: {e}")
+            await context.abort(grpc.StatusCode.INTERNAL, f"内部服务器错误: {e}")
+        logger.info(f"分析完成: 标的={result.symbol}, 情绪={result.sentiment}, 置信度={result.confidence_score}")
+        return to_proto(result)
+
+    async def AnalyzeNewsStream(self, request, context):
+        logger.info(f"收到gRPC流式分析请求: 标的={request.symbol}, 新闻数量={len(request.news_items)}")
+        await self._validate(request, context)
+
+        yield agent_pb2.AnalysisEvent(progress=agent_pb2.AnalysisProgress(
+            stage="received", message=f"{len(request.news_items)} 条新闻"))
+        task = self._run(request)
+        started = time.time()
+        while True:
+            done, _ = await asyncio.wait([task], timeout=PROGRESS_INTERVAL)
+            if done:
+                break
+            yield agent_pb2.AnalysisEvent(progress=agent_pb2.AnalysisProgress(
+                stage="llm", message=f"已分析 {time.time() - started:.0f} 秒"))
+
+        try:
+            result = task.result()
+        except Exception as e:
+            logger.error(f"gRPC流式分析处理失败: {e}")
+            await context.abort(grpc.StatusCode.INTERNAL, f"内部服务器错误: {e}")
+        logger.info(f"分析完成: 标的={result.symbol}, 情绪={result.sentiment}, 置信度={result.confidence_score}")
+        yield agent_pb2.AnalysisEvent(result=to_proto(result))
+
+    async def Health(self, request, context):
+        return agent_pb2.HealthResponse(
+            status="healthy",
+            version=self.version,
+            uptime_seconds=time.time() - self.start_time,
+        )
+
+
+def service_handler(service: AgentService) -> grpc.GenericRpcHandler:
+    """注册服务的各个方法：请求不经反序列化直接以原始字节交给处理函数，认证通过后再解析"""
+
+    def unary(method: str, request_type, response_type):
+        async def handle(raw: bytes, context):
+            request = await service.authorize(method, raw, request_type, context)
+            return await getattr(service, method)(request, context)
+        return grpc.unary_unary_rpc_method_handler(handle, response_serializer=response_type.SerializeToString)
+
+    def stream(method: str, request_type, response_type):
+        async def handle(raw: bytes, context):
+            request = await service.authorize(method, raw, request_type, context)
+            async for event in getattr(service, method)(request, context):
+                yield event
+        return grpc.unary_stream_rpc_method_handler(handle, response_serializer=response_type.SerializeToString)
+
+    return grpc.method_handlers_generic_handler(SERVICE_NAME, {
+        "AnalyzeNews": unary("AnalyzeNews", agent_pb2.NewsAnalysisRequest, agent_pb2.NewsAnalysisResponse),
+        "AnalyzeNewsStream": stream("AnalyzeNewsStream", agent_pb2.NewsAnalysisRequest, agent_pb2.AnalysisEvent),
+        "Health": unary("Health", agent_pb2.HealthRequest, agent_pb2.HealthResponse),
+    })
+
+
+async def start_grpc_server(host: str, port: int, service: AgentService) -> grpc.aio.Server:
+    """在当前事件循环中启动 gRPC 服务，配置证书时使用TLS（见 security.py）"""
+    server = grpc.aio.server()
+    server.add_generic_rpc_handlers((service_handler(service),))
+    credentials = security.grpc_server_credentials()
+    if credentials:
+        server.add_secure_port(f"{host}:{port}", credentials)
+    else:
+        server.add_insecure_port(f"{host}:{port}")
+    await server.start()
+    logger.info(f"gRPC服务已启动: {host}:{port}, TLS: {credentials is not None}")
+    return server
@@ -10,7 +10,7 @@ from typing import List, Optional
 from datetime import datetime
 
 import uvicorn
-from fastapi import FastAPI, HTTPException, Depends, status
+from fastapi import FastAPI, HTTPException, Depends, Request, status
 from fastapi.middleware.cors import CORSMiddleware
 from fastapi.responses import JSONResponse
 from pydantic import BaseModel, Field
@@ -19,6 +19,8 @@ from dotenv import load_dotenv
 # 加载环境变量
 load_dotenv()
 
+import security
+
 # 配置日志
 logging.basicConfig(
     level=logging.INFO,
@@ -30,13 +32,22 @@ logging.basicConfig(
 )
 logger = logging.getLogger(__name__)
 
+async def verify_request(request: Request):
+    """校验请求的API密钥、Bearer令牌和签名（见 security.py），未配置时不校验"""
+    try:
+        security.verify(request.headers.get, request.method, request.url.path, await request.body())
+    except security.AuthError as e:
+        logger.warning(f"拒绝未通过认证的请求: {request.url.path}, {e}")
+        raise HTTPException(status_code=401, detail=str(e))
+
 # 创建FastAPI应用
 app = FastAPI(
     title="Agent Quant System",
     description="混合架构量化交易系统的Python Agent服务",
     version="1.0.0",
     docs_url="/docs",
-    redoc_url="/redoc"
+    redoc_url="/redoc",
+    dependencies=[Depends(verify_request)]
 )
 
 # 添加CORS中间件
@@ -71,6 +82,7 @@ class HealthResponse(BaseModel):
 # 全局变量
 start_time = time.time()
 openai_client = None
+grpc_server = None
 
 # 初始化OpenAI客户端
 def init_openai_client():
@@ -309,6 +321,15 @@ async def startup_event():
     # 初始化OpenAI客户端
     init_openai_client()
     
+    # 配置 GRPC_PORT 时同时提供 gRPC 接口（agent_service.mode = "grpc"）
+    grpc_port = os.getenv("GRPC_PORT")
+    if grpc_port:
+        global grpc_server
+        from grpc_server import AgentService, start_grpc_server
+        grpc_server = await start_grpc_server(
+            os.getenv("HOST", "0.0.0.0"), int(grpc_port),
+            AgentService(real_analyze_news, "1.0.0", start_time))
+    
     logger.info("Agent Quant System Python服务启动完成")
 
 # 关闭事件
@@ -316,6 +337,8 @@ async def startup_event():
 async def shutdown_event():
     """应用关闭事件"""
     logger.info("Agent Quant System Python服务正在关闭...")
+    if grpc_server:
+        await grpc_server.stop(grace=5)
 
 # 异常处理器
 @app.exception_handler(Exception)
@@ -338,14 +361,15 @@ def main():
     port = int(os.getenv("PORT", 8000))
     debug = os.getenv("DEBUG", "false").lower() == "true"
     
-    logger.info(f"启动服务: {host}:{port}, 调试模式: {debug}")
+    logger.info(f"启动服务: {host}:{port}, 调试模式: {debug}, TLS: {security.tls_enabled()}")
     
     uvicorn.run(
         "main:app",
         host=host,
         port=port,
         reload=debug,
-        log_level="info"
+        log_level="info",
+        **security.uvicorn_ssl_options()
     )
 
 if __name__ == "__main__":
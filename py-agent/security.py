@@ -0,0 +1,114 @@
+"""
+Agent Quant System - 请求认证
+校验 Go 客户端发送的 API 密钥、Bearer 令牌和请求签名（与 internal/agent/security.go 一致），
+REST 和 gRPC 接口共用。各项均通过环境变量配置，未配置的项不校验
+"""
+
+import hashlib
+import hmac
+import os
+import ssl
+import threading
+import time
+from typing import Callable, Dict, Optional
+
+API_KEY = os.getenv("AGENT_API_KEY", "")
+BEARER_TOKEN = os.getenv("AGENT_BEARER_TOKEN", "")
+SIGNING_SECRET = os.getenv("AGENT_SIGNING_SECRET", "")
+# 签名时间与服务端时间允许的最大偏差（秒），超出时按重放请求拒绝；期间收到过的 nonce 再次出现时同样拒绝
+SIGNATURE_TOLERANCE = int(os.getenv("AGENT_SIGNATURE_TOLERANCE", 300))
+
+# TLS：配置 TLS_CLIENT_CA_FILE 时要求客户端提供该CA签发的证书（双向TLS）
+TLS_CERT_FILE = os.getenv("TLS_CERT_FILE", "")
+TLS_KEY_FILE = os.getenv("TLS_KEY_FILE", "")
+TLS_CLIENT_CA_FILE = os.getenv("TLS_CLIENT_CA_FILE", "")
+
+
+class AuthError(Exception):
+    """认证失败"""
+
+
+class NonceCache:
+    """签名仍在有效期内的请求的 nonce，过期的记录在写入时清理"""
+
+    def __init__(self):
+        self.seen: Dict[str, float] = {}
+        self.lock = threading.Lock()
+
+    def add(self, nonce: str, expiry: float, now: float) -> bool:
+        """记录 nonce 直到 expiry（签名过期的时间），仍在记录中时返回 False"""
+        with self.lock:
+            for key in [key for key, until in self.seen.items() if until < now]:
+                del self.seen[key]
+            if nonce in self.seen:
+                return False
+            self.seen[nonce] = expiry
+            return True
+
+
+_nonces = NonceCache()
+
+
+def sign(secret: str, timestamp: str, nonce: str, method: str, path: str, body: bytes) -> str:
+    """HMAC-SHA256(secret, timestamp + "\\n" + nonce + "\\n" + method + "\\n" + path + "\\n" + hex(sha256(body)))；
+    body 为收到的原始请求体或 gRPC 请求消息字节"""
+    message = "\n".join([timestamp, nonce, method, path, hashlib.sha256(body).hexdigest()])
+    return hmac.new(secret.encode(), message.encode(), hashlib.sha256).hexdigest()
+
+
+def verify(header: Callable[[str], Optional[str]], method: str, path: str, body: bytes) -> None:
+    """按配置校验请求，header 按小写名称返回请求头；失败时抛出 AuthError"""
+    if API_KEY and not hmac.compare_digest(header("x-api-key") or "", API_KEY):
+        raise AuthError("API密钥无效")
+    if BEARER_TOKEN and not hmac.compare_digest(header("authorization") or "", f"Bearer {BEARER_TOKEN}"):
+        raise AuthError("Bearer令牌无效")
+    if SIGNING_SECRET:
+        timestamp = header("x-agent-timestamp") or ""
+        nonce = header("x-agent-nonce") or ""
+        signature = header("x-agent-signature") or ""
+        now = time.time()
+        try:
+            skew = abs(now - int(timestamp))
+        except ValueError:
+            raise AuthError("缺少签名时间")
+        if skew > SIGNATURE_TOLERANCE:
+            raise AuthError(f"签名时间偏差 {skew:.0f} 秒，超过 {SIGNATURE_TOLERANCE} 秒")
+        if not nonce:
+            raise AuthError("缺少请求 nonce")
+        if not hmac.compare_digest(signature, sign(SIGNING_SECRET, timestamp, nonce, method, path, body)):
+            raise AuthError("请求签名无效")
+        # 签名通过后才记录，伪造的请求不能占用 nonce
+        if not _nonces.add(nonce, int(timestamp) + SIGNATURE_TOLERANCE, now):
+            raise AuthError("重复的请求 nonce，按重放请求拒绝")
+
+
+def tls_enabled() -> bool:
+    return bool(TLS_CERT_FILE and TLS_KEY_FILE)
+
+
+def uvicorn_ssl_options() -> dict:
+    """uvicorn 的 TLS 参数，未配置证书时为空"""
+    if not tls_enabled():
+        return {}
+    options = {"ssl_certfile": TLS_CERT_FILE, "ssl_keyfile": TLS_KEY_FILE}
+    if TLS_CLIENT_CA_FILE:
+        options.update(ssl_ca_certs=TLS_CLIENT_CA_FILE, ssl_cert_reqs=ssl.CERT_REQUIRED)
+    return options
+
+
+def grpc_server_credentials():
+    """gRPC 服务的TLS凭证，未配置证书时为 None"""
+    if not tls_enabled():
+        return None
+    import grpc
+
+    def read(path: str) -> bytes:
+        with open(path, "rb") as f:
+            return f.read()
+
+    root = read(TLS_CLIENT_CA_FILE) if TLS_CLIENT_CA_FILE else None
+    return grpc.ssl_server_credentials(
+        [(read(TLS_KEY_FILE), read(TLS_CERT_FILE))],
+        root_certificates=root,
+        require_client_auth=root is not None,
+    )